@@ -43,7 +43,7 @@ func run(args []string, out io.Writer, repo ports.DNSRepository) error {
 	createCmd := flag.NewFlagSet("create", flag.ContinueOnError)
 	createCmd.SetOutput(io.Discard)
 	tenantID := createCmd.String("tenant", "default-tenant", "Tenant ID")
-	role := createCmd.String("role", "admin", "Role (admin or reader)")
+	role := createCmd.String("role", "admin", "Role (admin, reader, break_glass or approver)")
 	name := createCmd.String("name", "generic-key", "Description of the key")
 	days := createCmd.Int("days", 365, "Validity in days")
 
@@ -65,8 +65,8 @@ func run(args []string, out io.Writer, repo ports.DNSRepository) error {
 		if err := createCmd.Parse(args[2:]); err != nil {
 			return err
 		}
-		if *role != "admin" && *role != "reader" && *role != "writer" {
-			return fmt.Errorf("invalid role %q: must be 'admin', 'writer', or 'reader'", *role)
+		if *role != "admin" && *role != "reader" && *role != "writer" && *role != "break_glass" && *role != "approver" {
+			return fmt.Errorf("invalid role %q: must be 'admin', 'writer', 'reader', 'break_glass' or 'approver'", *role)
 		}
 		if *days <= 0 {
 			return fmt.Errorf("invalid days %d: must be > 0", *days)
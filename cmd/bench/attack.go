@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/adapters/memory"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+	"github.com/poyrazK/cloudDNS/internal/dns/server"
+)
+
+// Attack scenarios, each mixed with the same legitimate traffic.
+const (
+	// scenarioBaseline sends legitimate traffic only.
+	scenarioBaseline = "baseline"
+	// scenarioSpoofedFlood asks for existing names from random spoofed sources,
+	// which per-client limits never see twice.
+	scenarioSpoofedFlood = "spoofed-flood"
+	// scenarioVictimFlood spoofs the addresses of the legitimate resolvers, as a
+	// reflection attack aimed at them does.
+	scenarioVictimFlood = "victim-flood"
+	// scenarioRandomSubdomain asks for random names under the zone from random
+	// sources, so every query misses the caches.
+	scenarioRandomSubdomain = "random-subdomain"
+)
+
+var attackScenarios = []string{scenarioBaseline, scenarioSpoofedFlood, scenarioVictimFlood, scenarioRandomSubdomain}
+
+const attackZone = "bench.test."
+
+// rateLimit is a per-client token bucket setting under test.
+type rateLimit struct {
+	QPS   float64
+	Burst int
+}
+
+func (l rateLimit) String() string {
+	return fmt.Sprintf("%g/%d", l.QPS, l.Burst)
+}
+
+// attackConfig configures simulated attacks on an in-process server with an
+// in-memory zone. Queries are handed to the server with the source address
+// they pretend to come from, which a load generator on the network could not
+// do without raw sockets.
+type attackConfig struct {
+	Duration    time.Duration // per scenario and limit
+	Concurrency int
+	Records     int // names in the zone
+	Resolvers   int // addresses legitimate queries come from
+	AttackRatio int // attack queries per legitimate query
+	Limits      []rateLimit
+	Scenarios   []string
+}
+
+// attackResult is the outcome of one scenario under one rate limit.
+type attackResult struct {
+	Scenario       string
+	Limit          rateLimit
+	LegitSent      uint64
+	LegitAnswered  uint64 // answered NOERROR with at least one record
+	LegitP99       time.Duration
+	AttackSent     uint64
+	AttackAnswered uint64 // answered at all, rather than dropped
+	Lookups        uint64 // record lookups that reached the repository
+}
+
+// LegitSuccess is the share of legitimate queries answered, in percent.
+func (r attackResult) LegitSuccess() float64 {
+	if r.LegitSent == 0 {
+		return 0
+	}
+	return float64(r.LegitAnswered) / float64(r.LegitSent) * 100
+}
+
+// parseRateLimits reads a comma-separated list of qps/burst pairs.
+func parseRateLimits(spec string) ([]rateLimit, error) {
+	var limits []rateLimit
+	for _, item := range splitNonEmpty(spec) {
+		qps, burst, ok := strings.Cut(item, "/")
+		if !ok {
+			return nil, fmt.Errorf("rate limit %q: want qps/burst", item)
+		}
+		l := rateLimit{}
+		var err error
+		if l.QPS, err = strconv.ParseFloat(qps, 64); err != nil || l.QPS <= 0 {
+			return nil, fmt.Errorf("rate limit %q: bad qps", item)
+		}
+		if l.Burst, err = strconv.Atoi(burst); err != nil || l.Burst <= 0 {
+			return nil, fmt.Errorf("rate limit %q: bad burst", item)
+		}
+		limits = append(limits, l)
+	}
+	if len(limits) == 0 {
+		return nil, errors.New("no rate limits")
+	}
+	return limits, nil
+}
+
+func splitNonEmpty(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// countingRepo counts the record lookups the server's caches let through.
+type countingRepo struct {
+	*memory.Repository
+	lookups atomic.Uint64
+}
+
+func (r *countingRepo) GetRecords(ctx context.Context, name string, qType domain.RecordType, clientIP string) ([]domain.Record, error) {
+	r.lookups.Add(1)
+	return r.Repository.GetRecords(ctx, name, qType, clientIP)
+}
+
+// runAttack runs every scenario under every rate limit, each against a fresh
+// server so caches and buckets start empty, and writes a table of how much
+// legitimate traffic got through.
+func runAttack(w io.Writer, cfg attackConfig) ([]attackResult, error) {
+	if cfg.Concurrency < 1 || cfg.Records < 1 || cfg.Resolvers < 1 || cfg.AttackRatio < 1 {
+		return nil, errors.New("concurrency, records, resolvers and attack ratio must be positive")
+	}
+	for _, scenario := range cfg.Scenarios {
+		if !containsString(attackScenarios, scenario) {
+			return nil, fmt.Errorf("unknown scenario %q (want %s)", scenario, strings.Join(attackScenarios, ", "))
+		}
+	}
+	_, _ = fmt.Fprintf(w, "Simulating attacks: %v per run | %d workers | %d names | %d resolvers | %d attack queries per legitimate one\n",
+		cfg.Duration, cfg.Concurrency, cfg.Records, cfg.Resolvers, cfg.AttackRatio)
+
+	var results []attackResult
+	for _, scenario := range cfg.Scenarios {
+		for _, limit := range cfg.Limits {
+			res, err := attackRun(cfg, scenario, limit)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, res)
+		}
+	}
+	printAttackReport(w, results)
+	return results, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// attackRun runs one scenario under one rate limit.
+func attackRun(cfg attackConfig, scenario string, limit rateLimit) (attackResult, error) {
+	repo := &countingRepo{Repository: memory.NewRepository()}
+	zone := domain.Zone{ID: "bench", TenantID: "bench", Name: attackZone}
+	records := []domain.Record{
+		{Name: attackZone, Type: domain.TypeSOA, TTL: 3600, Content: "ns1.bench.test. hostmaster.bench.test. 1 3600 600 86400 300"},
+		{Name: attackZone, Type: domain.TypeNS, TTL: 3600, Content: "ns1.bench.test."},
+	}
+	for i := 0; i < cfg.Records; i++ {
+		records = append(records, domain.Record{Name: fmt.Sprintf("host-%d.%s", i, attackZone), Type: domain.TypeA, TTL: 300, Content: "192.0.2.1"})
+	}
+	if err := repo.CreateZoneWithRecords(context.Background(), &zone, records); err != nil {
+		return attackResult{}, err
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	srv := server.NewServer("127.0.0.1:0", repo, logger)
+	srv.SetRateLimit(limit.QPS, limit.Burst)
+
+	res := attackResult{Scenario: scenario, Limit: limit}
+	var legitSent, legitAnswered, attackSent, attackAnswered atomic.Uint64
+	var mu sync.Mutex
+	var latencies []time.Duration
+
+	deadline := time.Now().Add(cfg.Duration)
+	var wg sync.WaitGroup
+	for worker := 0; worker < cfg.Concurrency; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(int64(worker) + 1))         // #nosec G404
+			zipf := rand.NewZipf(r, 1.1, 100, uint64(cfg.Records-1)) // #nosec G115
+			var own []time.Duration
+			for i := 0; time.Now().Before(deadline); i++ {
+				if scenario == scenarioBaseline || i%(cfg.AttackRatio+1) == 0 {
+					name := fmt.Sprintf("host-%d.%s", zipf.Uint64(), attackZone)
+					start := time.Now()
+					resp, _ := srv.ServeQuery(attackQuery(r, name), resolverAddr(r.Intn(cfg.Resolvers)))
+					own = append(own, time.Since(start))
+					legitSent.Add(1)
+					if answered(resp) {
+						legitAnswered.Add(1)
+					}
+					continue
+				}
+
+				name, source := attackTarget(r, scenario, cfg, zipf)
+				if resp, _ := srv.ServeQuery(attackQuery(r, name), source); resp != nil {
+					attackAnswered.Add(1)
+				}
+				attackSent.Add(1)
+			}
+			mu.Lock()
+			latencies = append(latencies, own...)
+			mu.Unlock()
+		}(worker)
+	}
+	wg.Wait()
+
+	res.LegitSent, res.LegitAnswered = legitSent.Load(), legitAnswered.Load()
+	res.AttackSent, res.AttackAnswered = attackSent.Load(), attackAnswered.Load()
+	res.Lookups = repo.lookups.Load()
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		res.LegitP99 = latencies[int(float64(len(latencies)-1)*0.99)]
+	}
+	return res, nil
+}
+
+// attackTarget picks the name and spoofed source of one attack query.
+func attackTarget(r *rand.Rand, scenario string, cfg attackConfig, zipf *rand.Zipf) (string, string) {
+	switch scenario {
+	case scenarioVictimFlood:
+		return fmt.Sprintf("host-%d.%s", zipf.Uint64(), attackZone), resolverAddr(r.Intn(cfg.Resolvers))
+	case scenarioRandomSubdomain:
+		return fmt.Sprintf("%x.%s", r.Uint64(), attackZone), randomAddr(r)
+	default:
+		return fmt.Sprintf("host-%d.%s", zipf.Uint64(), attackZone), randomAddr(r)
+	}
+}
+
+// resolverAddr is the address of the i-th legitimate resolver.
+func resolverAddr(i int) string {
+	return fmt.Sprintf("100.64.%d.%d:53", i/256, i%256)
+}
+
+// randomAddr is a random spoofed source address.
+func randomAddr(r *rand.Rand) string {
+	ip := r.Uint32()
+	return fmt.Sprintf("%d.%d.%d.%d:%d", byte(ip>>24), byte(ip>>16), byte(ip>>8), byte(ip), 1024+r.Intn(60000)) // #nosec G115
+}
+
+func attackQuery(r *rand.Rand, name string) []byte {
+	p := packet.NewDNSPacket()
+	p.Header.ID = uint16(r.Uint32()) // #nosec G115
+	p.Questions = append(p.Questions, packet.DNSQuestion{Name: name, QType: packet.A})
+	buf := packet.NewBytePacketBuffer()
+	_ = p.Write(buf)
+	return buf.Buf[:buf.Position()]
+}
+
+// answered reports whether a response is NOERROR with at least one answer.
+func answered(resp []byte) bool {
+	return len(resp) >= 12 && resp[3]&0x0F == 0 && binary.BigEndian.Uint16(resp[6:8]) > 0
+}
+
+func printAttackReport(w io.Writer, results []attackResult) {
+	_, _ = fmt.Fprintln(w, "\n==========================================================================================")
+	_, _ = fmt.Fprintln(w, "                         LEGITIMATE TRAFFIC UNDER ATTACK")
+	_, _ = fmt.Fprintln(w, "==========================================================================================")
+	_, _ = fmt.Fprintf(w, "%-17s | %-15s | %-10s | %-10s | %-12s | %-12s\n", "Scenario", "Limit qps/burst", "Legit OK", "Legit P99", "Attack ans.", "Lookups/1k q")
+	_, _ = fmt.Fprintln(w, "------------------------------------------------------------------------------------------")
+	for _, res := range results {
+		attack := "-"
+		if res.AttackSent > 0 {
+			attack = fmt.Sprintf("%.1f%%", float64(res.AttackAnswered)/float64(res.AttackSent)*100)
+		}
+		lookups := "-"
+		if total := res.LegitSent + res.AttackSent; total > 0 {
+			lookups = fmt.Sprintf("%.0f", float64(res.Lookups)/float64(total)*1000)
+		}
+		_, _ = fmt.Fprintf(w, "%-17s | %-15s | %-10s | %-10v | %-12s | %-12s\n",
+			res.Scenario, res.Limit, fmt.Sprintf("%.2f%%", res.LegitSuccess()), res.LegitP99.Round(time.Microsecond), attack, lookups)
+	}
+	_, _ = fmt.Fprintln(w, "==========================================================================================")
+	_, _ = fmt.Fprintln(w, "The per-client limiter is the only shedding stage: there is no response rate limiting (RRL),")
+	_, _ = fmt.Fprintln(w, "so spoofed sources each get a fresh bucket. Lookups/1k q counts queries the caches let through.")
+}
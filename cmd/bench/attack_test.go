@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimits(t *testing.T) {
+	limits, err := parseRateLimits("500000/200000, 50/100")
+	if err != nil || len(limits) != 2 || limits[1] != (rateLimit{QPS: 50, Burst: 100}) {
+		t.Fatalf("Unexpected limits %v: %v", limits, err)
+	}
+	for _, bad := range []string{"", "100", "x/10", "100/0", "-1/10"} {
+		if _, err := parseRateLimits(bad); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestRunAttack(t *testing.T) {
+	cfg := attackConfig{
+		Duration: 200 * time.Millisecond, Concurrency: 2, Records: 50, Resolvers: 2, AttackRatio: 5,
+		Limits:    []rateLimit{{QPS: 1000000, Burst: 1000000}, {QPS: 1, Burst: 10}},
+		Scenarios: []string{scenarioBaseline, scenarioVictimFlood, scenarioRandomSubdomain},
+	}
+	var out bytes.Buffer
+	results, err := runAttack(&out, cfg)
+	if err != nil {
+		t.Fatalf("runAttack failed: %v", err)
+	}
+	if len(results) != 6 {
+		t.Fatalf("Expected a result per scenario and limit, got %d", len(results))
+	}
+
+	byRun := map[string]attackResult{}
+	for _, res := range results {
+		byRun[res.Scenario+" "+res.Limit.String()] = res
+	}
+	if res := byRun["baseline 1e+06/1000000"]; res.LegitSent == 0 || res.LegitSuccess() != 100 || res.AttackSent != 0 {
+		t.Errorf("Expected all legitimate queries answered without an attack, got %+v", res)
+	}
+	if res := byRun["victim-flood 1/10"]; res.LegitSuccess() >= 50 {
+		t.Errorf("Expected a flood spoofing the resolvers to exhaust their buckets, got %+v", res)
+	}
+	if res := byRun["random-subdomain 1e+06/1000000"]; res.AttackSent == 0 || res.Lookups < res.AttackSent {
+		t.Errorf("Expected random names to miss the caches, got %+v", res)
+	}
+	if !strings.Contains(out.String(), "victim-flood") {
+		t.Errorf("Expected the report to list every run:\n%s", out.String())
+	}
+
+	cfg.Scenarios = []string{"slowloris"}
+	if _, err := runAttack(&out, cfg); err == nil {
+		t.Error("Expected an unknown scenario to be rejected")
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+// compareConfig configures a benchmark comparison of two servers, typically
+// one running the base branch and one running a pull request's build.
+type compareConfig struct {
+	Baseline    string
+	Candidate   string
+	Rounds      int
+	Alpha       float64
+	Count       int
+	Concurrency int
+	RangeLimit  uint64
+	ZipfS       float64
+	ZipfV       float64
+}
+
+// benchSample is the outcome of one benchmark round against one server.
+type benchSample struct {
+	Throughput float64 // answered queries per second
+	P50        float64 // milliseconds
+	P99        float64 // milliseconds
+}
+
+// comparedMetric is one metric of a comparison with its Welch t-test.
+type comparedMetric struct {
+	Name           string
+	Unit           string
+	HigherIsBetter bool
+	Baseline       []float64
+	Candidate      []float64
+	PValue         float64
+}
+
+// runCompare benchmarks both servers in alternating rounds, so drift on the host
+// affects them alike, and writes a table of throughput and latency deltas with
+// the significance of each.
+func runCompare(w io.Writer, cfg compareConfig) error {
+	if cfg.Baseline == "" || cfg.Candidate == "" {
+		return errors.New("both -baseline and -candidate (or BENCH_BASELINE and BENCH_CANDIDATE) are required")
+	}
+	if cfg.Baseline == cfg.Candidate {
+		return errors.New("baseline and candidate must be different servers")
+	}
+	if cfg.Rounds < 2 {
+		return errors.New("at least 2 rounds are needed to test significance")
+	}
+	_, _ = fmt.Fprintf(w, "Comparing %s (baseline) with %s (candidate): %d rounds of %d queries | %d concurrency\n",
+		cfg.Baseline, cfg.Candidate, cfg.Rounds, cfg.Count, cfg.Concurrency)
+
+	// One discarded round each warms caches and connection tracking
+	for _, target := range []string{cfg.Baseline, cfg.Candidate} {
+		if _, err := measureRound(cfg, target); err != nil {
+			return err
+		}
+	}
+
+	var baseline, candidate []benchSample
+	for i := 0; i < cfg.Rounds; i++ {
+		order := []string{cfg.Baseline, cfg.Candidate}
+		if i%2 == 1 {
+			order[0], order[1] = order[1], order[0]
+		}
+		for _, target := range order {
+			s, err := measureRound(cfg, target)
+			if err != nil {
+				return err
+			}
+			if target == cfg.Baseline {
+				baseline = append(baseline, s)
+			} else {
+				candidate = append(candidate, s)
+			}
+		}
+		_, _ = fmt.Fprintf(w, "Round %d/%d done\n", i+1, cfg.Rounds)
+	}
+
+	printComparison(w, compareSamples(baseline, candidate), cfg.Alpha)
+	return nil
+}
+
+// measureRound runs one benchmark round against target.
+func measureRound(cfg compareConfig, target string) (benchSample, error) {
+	duration, stats := runLoad(target, cfg.Count, cfg.Concurrency, cfg.RangeLimit, cfg.ZipfS, cfg.ZipfV)
+	latencies := make([]time.Duration, 0, len(stats.Latencies))
+	for l := range stats.Latencies {
+		latencies = append(latencies, l)
+	}
+	if len(latencies) == 0 {
+		return benchSample{}, fmt.Errorf("no answers from %s", target)
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	return benchSample{
+		Throughput: float64(stats.Success) / duration.Seconds(),
+		P50:        ms(latencies[len(latencies)/2]),
+		P99:        ms(latencies[int(float64(len(latencies))*0.99)]),
+	}, nil
+}
+
+func compareSamples(baseline, candidate []benchSample) []comparedMetric {
+	pick := func(samples []benchSample, f func(benchSample) float64) []float64 {
+		values := make([]float64, len(samples))
+		for i, s := range samples {
+			values[i] = f(s)
+		}
+		return values
+	}
+	metrics := []comparedMetric{
+		{Name: "Throughput", Unit: "q/s", HigherIsBetter: true,
+			Baseline:  pick(baseline, func(s benchSample) float64 { return s.Throughput }),
+			Candidate: pick(candidate, func(s benchSample) float64 { return s.Throughput })},
+		{Name: "P50 Latency", Unit: "ms",
+			Baseline:  pick(baseline, func(s benchSample) float64 { return s.P50 }),
+			Candidate: pick(candidate, func(s benchSample) float64 { return s.P50 })},
+		{Name: "P99 Latency", Unit: "ms",
+			Baseline:  pick(baseline, func(s benchSample) float64 { return s.P99 }),
+			Candidate: pick(candidate, func(s benchSample) float64 { return s.P99 })},
+	}
+	for i := range metrics {
+		metrics[i].PValue = welchTTest(metrics[i].Baseline, metrics[i].Candidate)
+	}
+	return metrics
+}
+
+func printComparison(w io.Writer, metrics []comparedMetric, alpha float64) {
+	_, _ = fmt.Fprintln(w, "\n==========================================================================================")
+	_, _ = fmt.Fprintln(w, "                              BENCHMARK COMPARISON REPORT                                 ")
+	_, _ = fmt.Fprintln(w, "==========================================================================================")
+	_, _ = fmt.Fprintf(w, "%-12s | %-22s | %-22s | %-8s | %-7s | %s\n", "Metric", "Baseline", "Candidate", "Delta", "p-value", "Result")
+	_, _ = fmt.Fprintln(w, "------------------------------------------------------------------------------------------")
+	for _, m := range metrics {
+		baseMean, baseSD := meanStdDev(m.Baseline)
+		candMean, candSD := meanStdDev(m.Candidate)
+		delta := 0.0
+		if baseMean != 0 {
+			delta = (candMean - baseMean) / baseMean * 100
+		}
+		result := "no significant change"
+		if m.PValue < alpha {
+			result = "worse"
+			if (candMean > baseMean) == m.HigherIsBetter {
+				result = "better"
+			}
+		}
+		_, _ = fmt.Fprintf(w, "%-12s | %-22s | %-22s | %+7.2f%% | %-7.3f | %s\n", m.Name,
+			fmt.Sprintf("%.3f ± %.3f %s", baseMean, baseSD, m.Unit),
+			fmt.Sprintf("%.3f ± %.3f %s", candMean, candSD, m.Unit),
+			delta, m.PValue, result)
+	}
+	_, _ = fmt.Fprintf(w, "==========================================================================================\n")
+	_, _ = fmt.Fprintf(w, "Welch's t-test per metric; changes with p < %.3f are reported as significant.\n", alpha)
+}
+
+// meanStdDev returns the mean and sample standard deviation of values.
+func meanStdDev(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	if len(values) < 2 {
+		return mean, 0
+	}
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sq / float64(len(values)-1))
+}
+
+// welchTTest returns the two-sided p-value of Welch's t-test for a difference
+// between the means of a and b, which need not share a variance.
+func welchTTest(a, b []float64) float64 {
+	if len(a) < 2 || len(b) < 2 {
+		return 1
+	}
+	meanA, sdA := meanStdDev(a)
+	meanB, sdB := meanStdDev(b)
+	va, vb := sdA*sdA/float64(len(a)), sdB*sdB/float64(len(b))
+	if va+vb == 0 {
+		if meanA == meanB {
+			return 1
+		}
+		return 0
+	}
+	t := (meanA - meanB) / math.Sqrt(va+vb)
+	df := (va + vb) * (va + vb) / (va*va/float64(len(a)-1) + vb*vb/float64(len(b)-1))
+	return regIncBeta(df/2, 0.5, df/(df+t*t))
+}
+
+// regIncBeta is the regularized incomplete beta function I_x(a, b), evaluated
+// with Lentz's continued fraction.
+func regIncBeta(a, b, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	lgAB, _ := math.Lgamma(a + b)
+	lgA, _ := math.Lgamma(a)
+	lgB, _ := math.Lgamma(b)
+	front := math.Exp(lgAB - lgA - lgB + a*math.Log(x) + b*math.Log(1-x))
+	// The continued fraction converges quickly only below the mean
+	if x > (a+1)/(a+b+2) {
+		return 1 - front*betaContinuedFraction(b, a, 1-x)/b
+	}
+	return front * betaContinuedFraction(a, b, x) / a
+}
+
+func betaContinuedFraction(a, b, x float64) float64 {
+	const (
+		maxIterations = 200
+		epsilon       = 1e-14
+		tiny          = 1e-300
+	)
+	c, d := 1.0, 1-(a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	f := d
+	for m := 1; m <= maxIterations; m++ {
+		fm := float64(m)
+		for _, num := range []float64{
+			fm * (b - fm) * x / ((a + 2*fm - 1) * (a + 2*fm)),
+			-(a + fm) * (a + b + fm) * x / ((a + 2*fm) * (a + 2*fm + 1)),
+		} {
+			d = 1 + num*d
+			if math.Abs(d) < tiny {
+				d = tiny
+			}
+			c = 1 + num/c
+			if math.Abs(c) < tiny {
+				c = tiny
+			}
+			d = 1 / d
+			f *= d * c
+		}
+		if math.Abs(d*c-1) < epsilon {
+			break
+		}
+	}
+	return f
+}
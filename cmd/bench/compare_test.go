@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+func TestWelchTTest(t *testing.T) {
+	// t = -1 with 8 degrees of freedom
+	if p := welchTTest([]float64{1, 2, 3, 4, 5}, []float64{2, 3, 4, 5, 6}); math.Abs(p-0.3466) > 1e-3 {
+		t.Errorf("Expected p = 0.3466, got %v", p)
+	}
+	if p := welchTTest([]float64{100, 101, 99, 100}, []float64{150, 149, 151, 150}); p > 1e-4 {
+		t.Errorf("Expected a clear difference to be significant, got p = %v", p)
+	}
+	if p := welchTTest([]float64{5, 5}, []float64{5, 5}); p != 1 {
+		t.Errorf("Expected identical constant samples to give p = 1, got %v", p)
+	}
+	if got := regIncBeta(2, 3, 0.4); math.Abs(got-0.5248) > 1e-4 {
+		t.Errorf("Expected I_0.4(2, 3) = 0.5248, got %v", got)
+	}
+}
+
+func TestPrintComparison(t *testing.T) {
+	baseline := []benchSample{{Throughput: 1000, P50: 1.0, P99: 5}, {Throughput: 1010, P50: 1.1, P99: 5.2}, {Throughput: 990, P50: 0.9, P99: 4.8}}
+	candidate := []benchSample{{Throughput: 1500, P50: 1.0, P99: 9}, {Throughput: 1490, P50: 1.1, P99: 9.2}, {Throughput: 1510, P50: 0.9, P99: 8.8}}
+	var out bytes.Buffer
+	printComparison(&out, compareSamples(baseline, candidate), 0.05)
+
+	lines := map[string]string{}
+	for _, l := range strings.Split(out.String(), "\n") {
+		if name, _, ok := strings.Cut(l, " |"); ok {
+			lines[strings.TrimSpace(name)] = l
+		}
+	}
+	if !strings.Contains(lines["Throughput"], "+50.00%") || !strings.HasSuffix(lines["Throughput"], "better") {
+		t.Errorf("Expected throughput to be significantly better: %q", lines["Throughput"])
+	}
+	if !strings.HasSuffix(lines["P50 Latency"], "no significant change") {
+		t.Errorf("Expected an unchanged median: %q", lines["P50 Latency"])
+	}
+	if !strings.HasSuffix(lines["P99 Latency"], "worse") {
+		t.Errorf("Expected the tail latency to be significantly worse: %q", lines["P99 Latency"])
+	}
+}
+
+func startEchoDNS(t *testing.T) string {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, remote, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			req := packet.NewDNSPacket()
+			pb := packet.NewBytePacketBuffer()
+			pb.Load(buf[:n])
+			_ = req.FromBuffer(pb)
+			resp := packet.NewDNSPacket()
+			resp.Header.ID = req.Header.ID
+			resp.Header.Response = true
+			resBuf := packet.NewBytePacketBuffer()
+			_ = resp.Write(resBuf)
+			_, _ = conn.WriteToUDP(resBuf.Buf[:resBuf.Position()], remote)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestRunCompare(t *testing.T) {
+	cfg := compareConfig{Rounds: 2, Alpha: 0.05, Count: 20, Concurrency: 2, RangeLimit: 100, ZipfS: 1.1, ZipfV: 100}
+	var out bytes.Buffer
+	if err := runCompare(&out, cfg); err == nil {
+		t.Error("Expected an error without servers")
+	}
+
+	cfg.Baseline, cfg.Candidate = startEchoDNS(t), startEchoDNS(t)
+	if err := runCompare(&out, cfg); err != nil {
+		t.Fatalf("runCompare failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "BENCHMARK COMPARISON REPORT") || !strings.Contains(out.String(), "P99 Latency") {
+		t.Errorf("Unexpected report:\n%s", out.String())
+	}
+}
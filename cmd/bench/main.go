@@ -48,20 +48,54 @@ type Result struct {
 var tlds = []string{"com", "net", "org", "io", "dev", "ai", "cloud", "gov", "edu", "tr", "com.tr", "me", "info"}
 
 func main() {
-	mode := flag.String("mode", "bench", "Mode: bench, scale-test, or seed")
+	mode := flag.String("mode", "bench", "Mode: bench, compare, attack, scale-test, or seed")
 	target := flag.String("server", "127.0.0.1:10053", "DNS server to test")
 	concurrency := flag.Int("c", 10, "Number of concurrent workers")
 	count := flag.Int("n", 1000, "Total number of queries to send")
 	rangeLimit := flag.Int("range", 10000000, "Number of records in the database (default 10M)")
 	zipfS := flag.Float64("zipf-s", 1.1, "Zipf distribution constant (s > 1). Higher means more 'Hot' domains.")
 	zipfV := flag.Float64("zipf-v", 100, "Zipf distribution constant (v >= 1).")
+	baseline := flag.String("baseline", os.Getenv("BENCH_BASELINE"), "compare: server running the baseline build (or BENCH_BASELINE)")
+	candidate := flag.String("candidate", os.Getenv("BENCH_CANDIDATE"), "compare: server running the candidate build (or BENCH_CANDIDATE)")
+	rounds := flag.Int("rounds", 5, "compare: number of alternating rounds run against each server")
+	alpha := flag.Float64("alpha", 0.05, "compare: significance level of the Welch t-tests")
+	affinity := flag.Bool("affinity", false, "scale-test: pin UDP listeners and workers to NUMA nodes")
+	attackDuration := flag.Duration("attack-duration", 5*time.Second, "attack: how long each scenario runs under each rate limit")
+	attackRecords := flag.Int("attack-records", 1000, "attack: names in the in-memory zone")
+	resolvers := flag.Int("resolvers", 50, "attack: addresses legitimate queries come from")
+	attackRatio := flag.Int("attack-ratio", 10, "attack: attack queries per legitimate query")
+	limits := flag.String("limits", fmt.Sprintf("%d/%d,5000/10000,500/1000,50/100", server.DefaultRateLimitQPS, server.DefaultRateLimitBurst), "attack: comma-separated per-client rate limits to compare, as qps/burst")
+	scenarios := flag.String("scenarios", strings.Join(attackScenarios, ","), "attack: comma-separated scenarios to run")
 	flag.Parse()
 
 	switch *mode {
+	case "compare":
+		cfg := compareConfig{
+			Baseline: *baseline, Candidate: *candidate, Rounds: *rounds, Alpha: *alpha,
+			Count: *count, Concurrency: *concurrency, RangeLimit: uint64(*rangeLimit), ZipfS: *zipfS, ZipfV: *zipfV, // #nosec G115
+		}
+		if err := runCompare(os.Stdout, cfg); err != nil {
+			fmt.Printf("Comparison failed: %v\n", err)
+			os.Exit(1)
+		}
+	case "attack":
+		rateLimits, err := parseRateLimits(*limits)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		cfg := attackConfig{
+			Duration: *attackDuration, Concurrency: *concurrency, Records: *attackRecords, Resolvers: *resolvers,
+			AttackRatio: *attackRatio, Limits: rateLimits, Scenarios: splitNonEmpty(*scenarios),
+		}
+		if _, err := runAttack(os.Stdout, cfg); err != nil {
+			fmt.Printf("Attack simulation failed: %v\n", err)
+			os.Exit(1)
+		}
 	case "seed":
 		runSeed(*rangeLimit)
 	case "scale-test":
-		runScaleTest(*count, *concurrency)
+		runScaleTest(*count, *concurrency, *affinity)
 	default:
 		runBenchmark(*target, *count, *concurrency, uint64(*rangeLimit), *zipfS, *zipfV) // #nosec G115
 	}
@@ -71,7 +105,14 @@ func runBenchmark(target string, count int, concurrency int, rangeLimit uint64,
 	fmt.Printf("Starting Realistic Benchmark\n")
 	fmt.Printf("Configuration: %d queries | %d concurrency | Pool Size: %d | Zipf(s=%.1f, v=%.1f)\n", count, concurrency, rangeLimit, s, v)
 
-	stats := Stats{
+	duration, stats := runLoad(target, count, concurrency, rangeLimit, s, v)
+	printEnhancedReport(duration, stats, concurrency, count)
+}
+
+// runLoad sends count queries to target from concurrency workers and returns how
+// long they took, with the latencies collected in the closed stats channel.
+func runLoad(target string, count int, concurrency int, rangeLimit uint64, s float64, v float64) (time.Duration, *Stats) {
+	stats := &Stats{
 		Latencies: make(chan time.Duration, count),
 	}
 
@@ -84,15 +125,14 @@ func runBenchmark(target string, count int, concurrency int, rangeLimit uint64,
 	for i := 0; i < concurrency; i++ {
 		go func(workerID int) {
 			defer wg.Done()
-			runRealisticWorker(target, queriesPerWorker, workerID, rangeLimit, s, v, &stats)
+			runRealisticWorker(target, queriesPerWorker, workerID, rangeLimit, s, v, stats)
 		}(i)
 	}
 
 	wg.Wait()
 	duration := time.Since(start)
 	close(stats.Latencies)
-
-	printEnhancedReport(duration, &stats, concurrency, count)
+	return duration, stats
 }
 
 func runRealisticWorker(target string, count int, workerID int, rangeLimit uint64, s float64, v float64, stats *Stats) {
@@ -249,7 +289,7 @@ func seedDatabase(ctx context.Context, db *sql.DB, total int) error {
 	return nil
 }
 
-func runScaleTest(count int, concurrency int) {
+func runScaleTest(count int, concurrency int, affinity bool) {
 	ctx := context.Background()
 
 	// 1. Infrastructure
@@ -313,7 +353,8 @@ func runScaleTest(count int, concurrency int) {
 	repo := repository.NewPostgresRepository(db)
 	srv := server.NewServer(addr, repo, logger)
 	srv.Redis = server.NewRedisCache(fmt.Sprintf("%s:%s", redisHost, redisPort.Port()), "", 0)
-	_ = srv.Run()
+	srv.CPUAffinity = affinity
+	go func() { _ = srv.Run(ctx) }()
 
 	time.Sleep(1 * time.Second)
 
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// adminListener opens the listener of the admin API. An addr of "unix:/path"
+// binds a unix socket only the service user can connect to; any other addr is a
+// TCP port that only accepts clients presenting a certificate signed by the CA
+// in caFile.
+func adminListener(addr, certFile, keyFile, caFile string) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		if path == "" {
+			return nil, errors.New("ADMIN_ADDR: missing unix socket path")
+		}
+		// Replace the socket left behind by an unclean exit, but nothing else
+		if fi, err := os.Lstat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			_ = os.Remove(path)
+		}
+		l, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.Chmod(path, 0o600); err != nil {
+			_ = l.Close()
+			return nil, err
+		}
+		return l, nil
+	}
+
+	tlsConfig, err := adminTLSConfig(certFile, keyFile, caFile)
+	if err != nil {
+		return nil, err
+	}
+	return tls.Listen("tcp", addr, tlsConfig)
+}
+
+// adminTLSConfig returns the mutual TLS config of a TCP admin listener.
+func adminTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	if certFile == "" || keyFile == "" || caFile == "" {
+		return nil, errors.New("ADMIN_ADDR: a TCP admin listener requires ADMIN_TLS_CERT, ADMIN_TLS_KEY and ADMIN_CLIENT_CA")
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("ADMIN_TLS_CERT/ADMIN_TLS_KEY: %w", err)
+	}
+	caPEM, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("ADMIN_CLIENT_CA: %w", err)
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("ADMIN_CLIENT_CA: no certificates found in %s", caFile)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
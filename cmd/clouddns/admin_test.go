@@ -0,0 +1,88 @@
+package main
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAdminListenerUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "admin.sock")
+	for i := 0; i < 2; i++ { // The second bind replaces the stale socket
+		l, err := adminListener("unix:"+path, "", "", "")
+		if err != nil {
+			t.Fatalf("adminListener: %v", err)
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := fi.Mode().Perm(); perm != 0o600 {
+			t.Errorf("Expected socket mode 0600, got %o", perm)
+		}
+		if i == 0 {
+			// Leave the socket file behind as after a crash
+			l.(*net.UnixListener).SetUnlinkOnClose(false)
+		}
+		_ = l.Close()
+	}
+
+	if _, err := adminListener("unix:", "", "", ""); err == nil {
+		t.Error("Expected an error for an empty socket path")
+	}
+	file := filepath.Join(t.TempDir(), "not-a-socket")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := adminListener("unix:"+file, "", "", ""); err == nil {
+		t.Error("Expected a regular file not to be replaced")
+	}
+}
+
+func TestAdminListenerMutualTLS(t *testing.T) {
+	certFile, keyFile := writeCertificate(t, t.TempDir(), time.Now().Add(24*time.Hour))
+	if _, err := adminListener("127.0.0.1:0", certFile, keyFile, ""); err == nil || !strings.Contains(err.Error(), "ADMIN_CLIENT_CA") {
+		t.Fatalf("Expected a TCP listener without a client CA to be refused, got %v", err)
+	}
+
+	// The self-signed certificate doubles as the client CA and client certificate
+	l, err := adminListener("127.0.0.1:0", certFile, keyFile, certFile)
+	if err != nil {
+		t.Fatalf("adminListener: %v", err)
+	}
+	srv := &http.Server{
+		Handler:           http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) }),
+		ReadHeaderTimeout: time.Second,
+	}
+	go func() { _ = srv.Serve(l) }()
+	defer func() { _ = srv.Close() }()
+
+	get := func(certs []tls.Certificate) (*http.Response, error) {
+		client := &http.Client{Timeout: 5 * time.Second, Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true, Certificates: certs},
+		}}
+		return client.Get("https://" + l.Addr().String() + "/")
+	}
+
+	if resp, err := get(nil); err == nil {
+		_ = resp.Body.Close()
+		t.Error("Expected a client without a certificate to be rejected")
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := get([]tls.Certificate{cert})
+	if err != nil {
+		t.Fatalf("Expected a client certificate to be accepted: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("Expected 204, got %d", resp.StatusCode)
+	}
+}
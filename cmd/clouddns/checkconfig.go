@@ -0,0 +1,326 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/poyrazK/cloudDNS/internal/adapters/repository"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+	"github.com/poyrazK/cloudDNS/internal/dns/server"
+)
+
+// Settings run falls back to defaults for when they do not parse. --check-config
+// reports them instead, so a typo does not silently change behaviour.
+var (
+	uint32Settings = []string{
+		"ANYCAST_LOCAL_ASN", "API_AUTH_FAILURE_THRESHOLD", "API_AUTH_MAX_FAILURES", "API_SPIKE_FACTOR", "BGP_PEER_ASN", "CACHE_MAX_RESPONSE_BYTES", "CACHE_MAX_RESPONSE_RECORDS",
+		"DB_BREAKER_THRESHOLD", "DOH_PADDING_BLOCK_SIZE", "DOT_PADDING_BLOCK_SIZE", "METRICS_TOP_ZONES",
+		"QUERY_ANALYTICS_SAMPLE_RATE", "QUERY_EVENT_SAMPLE_RATE", "RATE_LIMIT_LEASE", "REDIS_WRITE_QUEUE",
+	}
+	durationSettings = []string{
+		"ANYCAST_WITHDRAW_GRACE", "API_AUTH_BAN", "API_AUTH_MAX_BAN", "AUTHZ_TIMEOUT", "CONFIG_AUDIT_INTERVAL", "CONSISTENCY_CHECK_INTERVAL", "DB_APEX_CACHE_REVALIDATE",
+		"DB_BREAKER_COOLDOWN", "DB_CALL_TIMEOUT", "DNS_COOKIE_ROTATION", "DNS_DRAIN_TIMEOUT", "DR_EXPORT_INTERVAL", "DR_SNAPSHOT_INTERVAL", "EDGE_FULL_SYNC_INTERVAL", "NODE_HEARTBEAT_INTERVAL", "PROBE_INTERVAL",
+		"PROBE_SLOW_THRESHOLD", "QUERY_ANALYTICS_WINDOW", "REDIS_HEALTH_INTERVAL", "REDIS_TIMEOUT",
+		"SCHEDULED_CHANGE_INTERVAL", "SECONDARY_CHECK_INTERVAL", "UPDATE_NOTIFY_DEBOUNCE", "USAGE_FLUSH_INTERVAL",
+	}
+	choiceSettings = map[string][]string{
+		"ANYCAST_ENABLED":        {"true", "false"},
+		"AUTHZ_MODE":             {"webhook", "opa"},
+		"AUTHZ_READS":            {"true", "false"},
+		"DB_CHANGE_LISTEN":       {"true", "false"},
+		"DB_CHANGE_NOTIFY":       {"true", "false"},
+		"RATE_LIMIT_SHARED":      {"true", "false"},
+		"DNS_COOKIES":            {"true", "false"},
+		"DNS_CLIENT_SUBNET":      {"true", "false"},
+		"DNS_EARLY_DATA_UPDATES": {"allow", "refuse"},
+		"DNSSEC_KEY_EXPORT":      {"allow", "deny"},
+		"DNS_CPU_AFFINITY":       {"numa", "off"},
+		"OUT_OF_ZONE_RESPONSE":   {server.OutOfZoneRefused, server.OutOfZoneNXDomain},
+	}
+)
+
+// checkConfig validates the configuration run would start with and returns
+// every problem found, each naming the setting at fault. With connect set it
+// also connects to the databases and Redis shards and checks the TSIG settings
+// of the external secondaries stored in the database.
+func checkConfig(ctx context.Context, connect bool) []error {
+	var problems []error
+	report := func(format string, args ...any) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+
+	for _, key := range uint32Settings {
+		if val := os.Getenv(key); val != "" {
+			if _, err := strconv.ParseUint(val, 10, 32); err != nil {
+				report("%s: %q is not an unsigned 32-bit integer", key, val)
+			}
+		}
+	}
+	for _, key := range durationSettings {
+		if val := os.Getenv(key); val != "" {
+			if _, err := time.ParseDuration(val); err != nil {
+				report("%s: %q is not a duration such as 30s or 5m", key, val)
+			}
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(choiceSettings)) {
+		choices := choiceSettings[key]
+		val := os.Getenv(key)
+		if key == "OUT_OF_ZONE_RESPONSE" {
+			val = strings.ToLower(val)
+		}
+		if val != "" && !slices.Contains(choices, val) {
+			report("%s: %q is not one of %s", key, os.Getenv(key), strings.Join(choices, ", "))
+		}
+	}
+
+	for _, key := range []string{"DNS_ADDR", "API_ADDR", "NODE_ADDRESS"} {
+		if val := os.Getenv(key); val != "" {
+			if _, _, err := net.SplitHostPort(val); err != nil {
+				report("%s: %q is not a host:port address: %v", key, val, err)
+			}
+		}
+	}
+	if addr := os.Getenv("ADMIN_ADDR"); addr != "" {
+		if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+			if path == "" {
+				report("ADMIN_ADDR: missing unix socket path")
+			}
+		} else if _, _, err := net.SplitHostPort(addr); err != nil {
+			report("ADMIN_ADDR: %q is neither unix:/path nor a host:port address: %v", addr, err)
+		} else if _, err := adminTLSConfig(os.Getenv("ADMIN_TLS_CERT"), os.Getenv("ADMIN_TLS_KEY"), os.Getenv("ADMIN_CLIENT_CA")); err != nil {
+			problems = append(problems, err)
+		}
+	}
+	if addr := os.Getenv("DOH_CLEARTEXT_ADDR"); addr != "" {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			report("DOH_CLEARTEXT_ADDR: %q is not a host:port address: %v", addr, err)
+		}
+	}
+	for _, key := range []string{"PROBE_TARGETS", "PROPAGATION_RESOLVERS"} {
+		for _, addr := range resolverAddrs(os.Getenv(key)) {
+			if _, _, err := net.SplitHostPort(addr); err != nil {
+				report("%s: %q is not a host:port address: %v", key, addr, err)
+			}
+		}
+	}
+	for _, key := range []string{"API_ANOMALY_WEBHOOK_URL", "AUTHZ_URL", "PROBE_WEBHOOK_URL"} {
+		if hook := os.Getenv(key); hook != "" {
+			if u, err := url.Parse(hook); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				report("%s: %q is not an http or https URL", key, hook)
+			}
+		}
+	}
+
+	if os.Getenv("ANYCAST_ENABLED") == "true" {
+		for _, key := range []string{"ANYCAST_VIP", "BGP_PEER_IP"} {
+			val := os.Getenv(key)
+			if val == "" {
+				report("%s: must be set when ANYCAST_ENABLED=true", key)
+			} else if net.ParseIP(val) == nil {
+				report("%s: %q is not an IP address", key, val)
+			}
+		}
+	}
+
+	for _, pair := range [][2]string{{"DNS_TLS_CERT", "DNS_TLS_KEY"}, {"API_TLS_CERT", "API_TLS_KEY"}, {"ADMIN_TLS_CERT", "ADMIN_TLS_KEY"}} {
+		if err := checkCertificate(pair[0], pair[1], time.Now()); err != nil {
+			problems = append(problems, err)
+		}
+	}
+
+	if _, err := tsigKeys(os.Getenv("TSIG_KEYS")); err != nil {
+		problems = append(problems, err)
+	}
+	if _, err := encryptionKeyring(); err != nil {
+		problems = append(problems, err)
+	}
+	if os.Getenv("DR_S3_BUCKET") != "" {
+		if _, err := recoveryObjectStore(); err != nil {
+			problems = append(problems, err)
+		}
+		if _, err := backupKeyring(); err != nil {
+			problems = append(problems, err)
+		}
+	}
+	if _, err := server.ParseDNS64Views(os.Getenv("DNS64")); err != nil {
+		report("DNS64: %v", err)
+	}
+	if _, err := server.ParseSearchViews(os.Getenv("DNS_SEARCH_SUFFIXES")); err != nil {
+		report("DNS_SEARCH_SUFFIXES: %v", err)
+	}
+
+	if edgeMode() {
+		problems = append(problems, checkEdge()...)
+	}
+
+	dsns := databaseURLs()
+	for i, dsn := range dsns {
+		key := "DATABASE_URL"
+		if i > 0 {
+			key = "DATABASE_STANDBY_URLS"
+		}
+		if _, err := pgx.ParseConfig(dsn); err != nil {
+			report("%s: %s does not parse: %v", key, repository.RedactDSN(dsn), err)
+		}
+	}
+	shards := splitList(os.Getenv("REDIS_URL"))
+	if os.Getenv("REDIS_URL") != "" && len(shards) == 0 {
+		report("REDIS_URL: lists no address")
+	}
+	for _, addr := range shards {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			report("REDIS_URL: %q is not a host:port address: %v", addr, err)
+		}
+	}
+	if os.Getenv("RATE_LIMIT_SHARED") == "true" && len(shards) == 0 {
+		report("RATE_LIMIT_SHARED: requires REDIS_URL")
+	}
+
+	if !connect || len(problems) > 0 {
+		return problems
+	}
+	return append(problems, checkConnectivity(ctx, dsns, shards)...)
+}
+
+// checkConnectivity pings every database and Redis shard and checks the TSIG
+// secrets of the external secondaries in the primary database.
+func checkConnectivity(ctx context.Context, dsns, shards []string) []error {
+	var problems []error
+	for i, dsn := range dsns {
+		db, err := openDB(dsn)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("database %s: %w", repository.RedactDSN(dsn), err))
+			continue
+		}
+		pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		err = db.PingContext(pingCtx)
+		cancel()
+		if err != nil {
+			problems = append(problems, fmt.Errorf("database %s: %w", repository.RedactDSN(dsn), err))
+		} else if i == 0 {
+			repo := repository.NewPostgresRepository(db)
+			if keys, _ := encryptionKeyring(); keys != nil {
+				repo.SetEncryption(keys)
+			}
+			problems = append(problems, checkSecondaries(ctx, repo)...)
+		}
+		_ = db.Close()
+	}
+
+	for _, addr := range shards {
+		cache := server.NewRedisCache(addr, "", 0)
+		pingCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		if err := cache.Ping(pingCtx); err != nil {
+			problems = append(problems, fmt.Errorf("redis %s: %w", addr, err))
+		}
+		cancel()
+		_ = cache.Close(context.Background())
+	}
+	return problems
+}
+
+// checkSecondaries reports external secondaries whose TSIG key name and secret
+// are not both set or whose secret is not valid base64.
+func checkSecondaries(ctx context.Context, repo *repository.PostgresRepository) []error {
+	zones, err := repo.ListZones(ctx, "")
+	if err != nil {
+		return []error{fmt.Errorf("database: failed to list zones: %w", err)}
+	}
+	var problems []error
+	for _, z := range zones {
+		providers, err := repo.ListSecondaryProviders(ctx, z.ID)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("zone %s: failed to list secondaries: %w", z.Name, err))
+			continue
+		}
+		for _, p := range providers {
+			if (p.TSIGKeyName == "") != (p.TSIGSecret == "") {
+				problems = append(problems, fmt.Errorf("zone %s: secondary %s has a TSIG key name or secret but not both", z.Name, p.Name))
+			} else if _, err := base64.StdEncoding.DecodeString(p.TSIGSecret); err != nil {
+				problems = append(problems, fmt.Errorf("zone %s: secondary %s: TSIG secret of key %s is not valid base64", z.Name, p.Name, p.TSIGKeyName))
+			}
+		}
+	}
+	return problems
+}
+
+// checkCertificate loads the certificate and key named by the certKey and
+// keyKey settings, which must be set together, and rejects a leaf certificate
+// that is expired or not yet valid at now.
+func checkCertificate(certKey, keyKey string, now time.Time) error {
+	certFile, keyFile := os.Getenv(certKey), os.Getenv(keyKey)
+	if certFile == "" && keyFile == "" {
+		return nil
+	}
+	if certFile == "" || keyFile == "" {
+		return fmt.Errorf("%s and %s must be set together", certKey, keyKey)
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return fmt.Errorf("%s/%s: %w", certKey, keyKey, err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("%s: %w", certKey, err)
+	}
+	if now.After(leaf.NotAfter) {
+		return fmt.Errorf("%s: certificate expired at %s", certKey, leaf.NotAfter.Format(time.RFC3339))
+	}
+	if now.Before(leaf.NotBefore) {
+		return fmt.Errorf("%s: certificate is not valid before %s", certKey, leaf.NotBefore.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// tsigKeys parses a comma-separated list of name:secret TSIG keys, where the
+// secret is base64 as generated by tsig-keygen.
+func tsigKeys(list string) (map[string][]byte, error) {
+	keys := map[string][]byte{}
+	for _, entry := range splitList(list) {
+		name, secret, ok := strings.Cut(entry, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("TSIG_KEYS: entry %q is not name:secret", entry)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(secret))
+		if err != nil || len(decoded) == 0 {
+			return nil, fmt.Errorf("TSIG_KEYS: secret of key %s is not valid base64", name)
+		}
+		name = dnsname.Fqdn(name)
+		if _, dup := keys[name]; dup {
+			return nil, fmt.Errorf("TSIG_KEYS: key %s is listed twice", name)
+		}
+		keys[name] = decoded
+	}
+	return keys, nil
+}
+
+// databaseURLs returns DATABASE_URL, or its default, followed by the standby
+// URLs. It is empty when the database is disabled with DATABASE_URL=none and on
+// edge nodes, which do without one.
+func databaseURLs() []string {
+	if edgeMode() {
+		return nil
+	}
+	dbURL := os.Getenv("DATABASE_URL")
+	if dbURL == "" {
+		dbURL = defaultDatabaseURL
+	}
+	if dbURL == "none" {
+		return nil
+	}
+	return append([]string{dbURL}, splitList(os.Getenv("DATABASE_STANDBY_URLS"))...)
+}
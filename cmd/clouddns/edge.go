@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/adapters/edgestore"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+)
+
+// Defaults of an edge node.
+const (
+	defaultEdgeStore    = "clouddns-edge.json"
+	defaultEdgeFullSync = 6 * time.Hour
+)
+
+// edgeMode reports whether the node runs as an edge node: without PostgreSQL,
+// serving the zones it transfers from the EDGE_HUB servers.
+func edgeMode() bool {
+	return os.Getenv("EDGE_HUB") != ""
+}
+
+// checkEdge validates the EDGE_* settings of an edge node.
+func checkEdge() []error {
+	var problems []error
+	if _, err := domain.ParseMasters(os.Getenv("EDGE_HUB")); err != nil {
+		problems = append(problems, fmt.Errorf("EDGE_HUB: %v", err))
+	}
+	zones := splitList(os.Getenv("EDGE_ZONES"))
+	if len(zones) == 0 {
+		problems = append(problems, errors.New("EDGE_ZONES: must list the zones to serve when EDGE_HUB is set"))
+	}
+	for _, zone := range zones {
+		if err := domain.ValidateZoneName(dnsname.Fqdn(zone)); err != nil {
+			problems = append(problems, fmt.Errorf("EDGE_ZONES: %q: %v", zone, err))
+		}
+	}
+	return problems
+}
+
+// openEdgeStore opens the local store of an edge node at EDGE_STORE and
+// configures it with the EDGE_ZONES secondary zones of the EDGE_HUB servers.
+func openEdgeStore(ctx context.Context, logger *slog.Logger) (*edgestore.Store, error) {
+	if problems := checkEdge(); len(problems) > 0 {
+		return nil, errors.Join(problems...)
+	}
+	path := os.Getenv("EDGE_STORE")
+	if path == "" {
+		path = defaultEdgeStore
+	}
+	store, err := edgestore.Open(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	zones := splitList(os.Getenv("EDGE_ZONES"))
+	if err := store.Configure(ctx, zones, os.Getenv("EDGE_HUB")); err != nil {
+		return nil, err
+	}
+	logger.Info("edge mode enabled", "hub", os.Getenv("EDGE_HUB"), "zones", len(zones), "store", path)
+	return store, nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/poyrazK/cloudDNS/internal/adapters/repository"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/envelope"
+)
+
+// encryptionKeyring returns the keyring sealing stored secrets, configured by
+// ENCRYPTION_KEYS as "id:base64key" pairs and ENCRYPTION_KEY_ID naming the
+// current key. It returns nil when encryption is disabled.
+func encryptionKeyring() (*envelope.Keyring, error) {
+	spec := os.Getenv("ENCRYPTION_KEYS")
+	if spec == "" {
+		if os.Getenv("ENCRYPTION_KEY_ID") != "" {
+			return nil, errors.New("ENCRYPTION_KEY_ID: requires ENCRYPTION_KEYS")
+		}
+		return nil, nil
+	}
+	keys, err := envelope.ParseKeyring(spec, os.Getenv("ENCRYPTION_KEY_ID"))
+	if err != nil {
+		return nil, fmt.Errorf("ENCRYPTION_KEYS: %w", err)
+	}
+	return keys, nil
+}
+
+// reencryptSecrets seals the secrets in the primary database that are still in
+// plaintext or sealed with a retired key, for --reencrypt-secrets.
+func reencryptSecrets(ctx context.Context) error {
+	keys, err := encryptionKeyring()
+	if err != nil {
+		return err
+	}
+	if keys == nil {
+		return errors.New("ENCRYPTION_KEYS must be set to re-encrypt secrets")
+	}
+	dsns := databaseURLs()
+	if len(dsns) == 0 {
+		return errors.New("DATABASE_URL must be set to re-encrypt secrets")
+	}
+	db, err := openDB(dsns[0])
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+
+	repo := repository.NewPostgresRepository(db)
+	repo.SetEncryption(keys)
+	n, err := repo.ReencryptSecrets(ctx)
+	if err != nil {
+		return fmt.Errorf("re-encrypted %d secrets before failing: %w", n, err)
+	}
+	fmt.Printf("re-encrypted %d secrets with key %s\n", n, keys.CurrentID())
+	return nil
+}
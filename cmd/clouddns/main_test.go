@@ -2,7 +2,11 @@ package main
 
 import (
 	"context"
+	"log/slog"
 	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/dns/server"
 )
 
 func TestGetEnvUint32(t *testing.T) {
@@ -110,3 +114,91 @@ func TestRunFullLifecycle(t *testing.T) {
 		t.Errorf("Application failed during full lifecycle run: %v", err)
 	}
 }
+
+func TestGetEnvDuration(t *testing.T) {
+	t.Setenv("TEST_DURATION", "250ms")
+
+	if val := getEnvDuration("TEST_DURATION", 0); val != 250*time.Millisecond {
+		t.Errorf("Expected 250ms, got %v", val)
+	}
+
+	if val := getEnvDuration("NON_EXISTENT", time.Second); val != time.Second {
+		t.Errorf("Expected default 1s, got %v", val)
+	}
+
+	t.Setenv("INVALID_DURATION", "soon")
+	if val := getEnvDuration("INVALID_DURATION", 2*time.Second); val != 2*time.Second {
+		t.Errorf("Expected default 2s for invalid input, got %v", val)
+	}
+}
+
+func TestNodeConfigApplier(t *testing.T) {
+	level := new(slog.LevelVar)
+	apply := nodeConfigApplier(level, server.NewServer("127.0.0.1:0", nil, slog.Default()), 100)
+
+	if err := apply(map[string]string{"log_level": "debug", "query_event_sample_rate": "10", "shadow_serve_zones": "z1,z2"}); err != nil {
+		t.Fatalf("Expected valid config to apply, got %v", err)
+	}
+	if level.Level() != slog.LevelDebug {
+		t.Errorf("Expected debug level, got %v", level.Level())
+	}
+
+	for _, cfg := range []map[string]string{
+		{"log_level": "loud"},
+		{"query_event_sample_rate": "-1"},
+		{"cache_size": "10"},
+	} {
+		if err := apply(cfg); err == nil {
+			t.Errorf("Expected error for %v", cfg)
+		}
+	}
+	if level.Level() != slog.LevelDebug {
+		t.Errorf("Rejected config must not change the level, got %v", level.Level())
+	}
+
+	if err := apply(map[string]string{}); err != nil || level.Level() != slog.LevelInfo {
+		t.Errorf("Expected empty config to restore defaults, got %v, %v", err, level.Level())
+	}
+}
+
+func TestProbeTargets(t *testing.T) {
+	tests := []struct {
+		list, dnsAddr string
+		want          []string
+	}{
+		{"", "127.0.0.1:10053", []string{"127.0.0.1:10053"}},
+		{"", ":53", []string{"127.0.0.1:53"}},
+		{"", "0.0.0.0:53", []string{"127.0.0.1:53"}},
+		{"", "[::]:53", []string{"[::1]:53"}},
+		{"", "10.0.0.5:53", []string{"10.0.0.5:53"}},
+		{" 192.0.2.1:53, ,198.51.100.1:53", ":53", []string{"192.0.2.1:53", "198.51.100.1:53"}},
+	}
+	for _, tt := range tests {
+		got := probeTargets(tt.list, tt.dnsAddr)
+		if len(got) != len(tt.want) {
+			t.Errorf("probeTargets(%q, %q) = %v, want %v", tt.list, tt.dnsAddr, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("probeTargets(%q, %q) = %v, want %v", tt.list, tt.dnsAddr, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestResolverAddrs(t *testing.T) {
+	if got := resolverAddrs(""); got != nil {
+		t.Errorf("Expected no resolvers for an empty list, got %v", got)
+	}
+	got := resolverAddrs(" 8.8.8.8, 1.1.1.1:5353 ,,2606:4700:4700::1111")
+	want := []string{"8.8.8.8:53", "1.1.1.1:5353", "[2606:4700:4700::1111]:53"}
+	if len(got) != len(want) {
+		t.Fatalf("resolverAddrs = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("resolverAddrs = %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/adapters/objectstore"
+	"github.com/poyrazK/cloudDNS/internal/adapters/repository"
+	"github.com/poyrazK/cloudDNS/internal/core/services"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/envelope"
+)
+
+// recoveryObjectStore returns the bucket disaster-recovery replicas are kept in,
+// configured by the DR_S3_* variables. It returns nil when DR_S3_BUCKET is unset.
+func recoveryObjectStore() (*objectstore.S3, error) {
+	bucket := os.Getenv("DR_S3_BUCKET")
+	if bucket == "" {
+		return nil, nil
+	}
+	endpoint := os.Getenv("DR_S3_ENDPOINT")
+	if endpoint == "" {
+		endpoint = "https://s3.amazonaws.com"
+	}
+	store, err := objectstore.NewS3(objectstore.Config{
+		Endpoint:  endpoint,
+		Region:    os.Getenv("DR_S3_REGION"),
+		Bucket:    bucket,
+		Prefix:    os.Getenv("DR_S3_PREFIX"),
+		AccessKey: os.Getenv("DR_S3_ACCESS_KEY"),
+		SecretKey: os.Getenv("DR_S3_SECRET_KEY"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("DR_S3_BUCKET: %w", err)
+	}
+	return store, nil
+}
+
+// backupKeyring returns the keyring sealing secrets in snapshots, configured by
+// BACKUP_KEYS and BACKUP_KEY_ID as for clouddnsctl backup.
+func backupKeyring() (*envelope.Keyring, error) {
+	spec := os.Getenv("BACKUP_KEYS")
+	if spec == "" {
+		return nil, errors.New("BACKUP_KEYS must be set: secrets in snapshots are always encrypted")
+	}
+	keys, err := envelope.ParseKeyring(spec, os.Getenv("BACKUP_KEY_ID"))
+	if err != nil {
+		return nil, fmt.Errorf("BACKUP_KEYS: %w", err)
+	}
+	return keys, nil
+}
+
+// disasterRecovery returns the replicator of repo to the DR_S3_BUCKET bucket,
+// or nil when replication is not configured.
+func disasterRecovery(repo *repository.PostgresRepository, logger *slog.Logger) (*services.DisasterRecovery, error) {
+	objects, err := recoveryObjectStore()
+	if err != nil || objects == nil {
+		return nil, err
+	}
+	keys, err := backupKeyring()
+	if err != nil {
+		return nil, err
+	}
+	return services.NewDisasterRecovery(repository.NewRecoveryStore(repo, keys), objects, logger), nil
+}
+
+// bootstrapFromReplica rebuilds the empty primary database from the latest
+// snapshot and change log in DR_S3_BUCKET, for --dr-bootstrap.
+func bootstrapFromReplica(ctx context.Context) error {
+	dsns := databaseURLs()
+	if len(dsns) == 0 {
+		return errors.New("DATABASE_URL must be set to bootstrap from a replica")
+	}
+	db, err := openDB(dsns[0])
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+
+	repo := repository.NewPostgresRepository(db)
+	keys, err := encryptionKeyring()
+	if err != nil {
+		return err
+	}
+	if keys != nil {
+		repo.SetEncryption(keys)
+	}
+	recovery, err := disasterRecovery(repo, nil)
+	if err != nil {
+		return err
+	}
+	if recovery == nil {
+		return errors.New("DR_S3_BUCKET must be set to bootstrap from a replica")
+	}
+	report, err := recovery.Bootstrap(ctx)
+	if err != nil {
+		if report != nil {
+			return fmt.Errorf("restored %s but failed to replay its change log after %d entries: %w", report.Snapshot, report.Entries, err)
+		}
+		return err
+	}
+	fmt.Printf("restored %s and replayed %d change log entries (%d zones), recovered to %s\n",
+		report.Snapshot, report.Entries, report.ZonesApplied, report.RecoveredTo.Format(time.RFC3339))
+	return nil
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/adapters/repository"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/envelope"
+)
+
+type fakeStore struct {
+	snap     *repository.Snapshot
+	restored *repository.Snapshot
+	err      error
+}
+
+func (f *fakeStore) Backup(_ context.Context, _ *envelope.Keyring) (*repository.Snapshot, error) {
+	return f.snap, f.err
+}
+
+func (f *fakeStore) Restore(_ context.Context, snap *repository.Snapshot, _ *envelope.Keyring) error {
+	f.restored = snap
+	return f.err
+}
+
+func testKeys(t *testing.T) *envelope.Keyring {
+	t.Helper()
+	keys, err := envelope.ParseKeyring("backup:"+base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32)), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	ctx := context.Background()
+	keys := testKeys(t)
+	path := filepath.Join(t.TempDir(), "backup.json.gz")
+	store := &fakeStore{snap: &repository.Snapshot{
+		Version:   repository.SnapshotVersion,
+		CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		SecretKey: "backup",
+		Tables: map[string]json.RawMessage{
+			"dns_zones":   json.RawMessage(`[{"id":"z1"}]`),
+			"dns_records": json.RawMessage(`[{"id":"r1"},{"id":"r2"}]`),
+		},
+	}}
+
+	out := &bytes.Buffer{}
+	if err := run(ctx, []string{"clouddnsctl", "backup", "-o", path}, out, store, nil, nil, keys); err != nil {
+		t.Fatalf("backup failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "dns_records        2") {
+		t.Errorf("Expected row counts in the output, got %q", out.String())
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected a private archive, got %v, %v", info, err)
+	}
+	if err := run(ctx, []string{"clouddnsctl", "backup", "-o", path}, out, store, nil, nil, keys); err == nil {
+		t.Error("Expected an existing archive not to be overwritten")
+	}
+
+	out.Reset()
+	if err := run(ctx, []string{"clouddnsctl", "restore", "-i", path}, out, store, nil, nil, keys); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	if store.restored == nil || string(store.restored.Tables["dns_zones"]) != `[{"id":"z1"}]` || !store.restored.CreatedAt.Equal(store.snap.CreatedAt) {
+		t.Errorf("Unexpected restored snapshot %+v", store.restored)
+	}
+	if !strings.Contains(out.String(), "2026-01-02T03:04:05Z") {
+		t.Errorf("Expected the backup time in the output, got %q", out.String())
+	}
+
+	store.err = repository.ErrRestoreNotEmpty
+	if err := run(ctx, []string{"clouddnsctl", "restore", "-i", path}, out, store, nil, nil, keys); !errors.Is(err, repository.ErrRestoreNotEmpty) {
+		t.Errorf("Expected ErrRestoreNotEmpty, got %v", err)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	ctx := context.Background()
+	keys := testKeys(t)
+	store := &fakeStore{}
+	for _, tc := range []struct {
+		args []string
+		keys *envelope.Keyring
+	}{
+		{[]string{"clouddnsctl"}, keys},
+		{[]string{"clouddnsctl", "backup", "-o", "x"}, nil},
+		{[]string{"clouddnsctl", "backup"}, keys},
+		{[]string{"clouddnsctl", "restore"}, keys},
+		{[]string{"clouddnsctl", "restore", "-i", filepath.Join(t.TempDir(), "missing")}, keys},
+		{[]string{"clouddnsctl", "export"}, keys},
+		{[]string{"clouddnsctl", "import", "-zone", "z1"}, nil},
+	} {
+		if err := run(ctx, tc.args, &bytes.Buffer{}, store, nil, nil, tc.keys); err == nil {
+			t.Errorf("Expected %v to fail", tc.args)
+		}
+	}
+}
+
+type fakeImporter struct {
+	format string
+	body   string
+	dryRun bool
+}
+
+func (f *fakeImporter) ImportRecords(_ context.Context, zoneID, _, format string, r io.Reader, dryRun bool) (*domain.RecordImportResult, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	f.format, f.body, f.dryRun = format, string(data), dryRun
+	if strings.Contains(f.body, "bad") {
+		return &domain.RecordImportResult{ZoneID: zoneID, Invalid: 1, Rows: []domain.RecordImportRow{{Row: 1, Name: "bad.example.com.", Type: domain.TypeA, Status: "invalid", Error: "invalid IPv4 address"}}},
+			fmt.Errorf("%w: 1 of 1 rows are invalid", domain.ErrInvalidRecordImport)
+	}
+	return &domain.RecordImportResult{ZoneID: zoneID, DryRun: dryRun, Created: 1, Rows: []domain.RecordImportRow{{Row: 1, Status: "created"}}}, nil
+}
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "records.CSV")
+	if err := os.WriteFile(path, []byte("type,content\nA,192.0.2.1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Importing needs no backup keys
+	importer := &fakeImporter{}
+	out := &bytes.Buffer{}
+	if err := run(ctx, []string{"clouddnsctl", "import", "-tenant", "t1", "-zone", "z1", "-f", path}, out, nil, importer, nil, nil); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if importer.format != "csv" || !strings.Contains(importer.body, "192.0.2.1") || importer.dryRun {
+		t.Errorf("Unexpected import: %+v", importer)
+	}
+	if !strings.Contains(out.String(), "Imported 1 records into zone z1") {
+		t.Errorf("Unexpected output: %s", out.String())
+	}
+
+	bad := filepath.Join(dir, "bad.txt")
+	if err := os.WriteFile(bad, []byte(`[{"name": "bad"}]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	err := run(ctx, []string{"clouddnsctl", "import", "-tenant", "t1", "-zone", "z1", "-f", bad, "-format", "json", "-dry-run"}, out, nil, importer, nil, nil)
+	if !errors.Is(err, domain.ErrInvalidRecordImport) {
+		t.Fatalf("Expected ErrInvalidRecordImport, got %v", err)
+	}
+	if importer.format != "json" || !importer.dryRun || !strings.Contains(out.String(), "row 1: bad.example.com. A: invalid IPv4 address") {
+		t.Errorf("Expected the invalid row reported, got %q", out.String())
+	}
+}
+
+type fakeLimiter struct {
+	limits []domain.QueryLimit
+}
+
+func (f *fakeLimiter) SetQueryLimit(_ context.Context, limit *domain.QueryLimit) error {
+	if limit.Burst == 0 {
+		limit.Burst = 7
+	}
+	f.limits = append(f.limits, *limit)
+	return nil
+}
+
+func (f *fakeLimiter) ListQueryLimits(_ context.Context, _ string) ([]domain.QueryLimit, error) {
+	return f.limits, nil
+}
+
+func (f *fakeLimiter) DeleteQueryLimit(_ context.Context, tenantID string, zoneID string) error {
+	for i, l := range f.limits {
+		if l.TenantID == tenantID && l.ZoneID == zoneID {
+			f.limits = append(f.limits[:i], f.limits[i+1:]...)
+			return nil
+		}
+	}
+	return domain.ErrNotFound
+}
+
+func TestLimits(t *testing.T) {
+	ctx := context.Background()
+	limiter := &fakeLimiter{}
+	out := &bytes.Buffer{}
+	if err := run(ctx, []string{"clouddnsctl", "limits", "set", "-tenant", "t1", "-qps", "6.5"}, out, nil, nil, limiter, nil); err != nil {
+		t.Fatalf("limits set failed: %v", err)
+	}
+	if err := run(ctx, []string{"clouddnsctl", "limits", "set", "-tenant", "t1", "-zone", "z1", "-qps", "2", "-burst", "10"}, out, nil, nil, limiter, nil); err != nil {
+		t.Fatalf("limits set failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "Limited tenant t1 to 6.5 qps (burst 7)") || !strings.Contains(out.String(), "tenant t1 zone z1 to 2 qps (burst 10)") {
+		t.Errorf("Unexpected output: %q", out.String())
+	}
+
+	out.Reset()
+	if err := run(ctx, []string{"clouddnsctl", "limits", "list"}, out, nil, nil, limiter, nil); err != nil {
+		t.Fatalf("limits list failed: %v", err)
+	}
+	if strings.Count(out.String(), "qps") != 2 {
+		t.Errorf("Expected both limits listed, got %q", out.String())
+	}
+
+	if err := run(ctx, []string{"clouddnsctl", "limits", "delete", "-tenant", "t1", "-zone", "z1"}, out, nil, nil, limiter, nil); err != nil || len(limiter.limits) != 1 {
+		t.Fatalf("limits delete failed: %v, %+v", err, limiter.limits)
+	}
+	if err := run(ctx, []string{"clouddnsctl", "limits", "delete", "-tenant", "t1", "-zone", "z1"}, out, nil, nil, limiter, nil); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	for _, args := range [][]string{
+		{"clouddnsctl", "limits"},
+		{"clouddnsctl", "limits", "set", "-qps", "5"},
+		{"clouddnsctl", "limits", "set", "-tenant", "t1"},
+		{"clouddnsctl", "limits", "delete"},
+		{"clouddnsctl", "limits", "raise"},
+	} {
+		if err := run(ctx, args, out, nil, nil, limiter, nil); err == nil {
+			t.Errorf("Expected %v to fail", args)
+		}
+	}
+}
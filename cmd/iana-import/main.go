@@ -7,7 +7,6 @@ import (
 	"log"
 	"net/http"
 	"os"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,6 +14,7 @@ import (
 	"github.com/poyrazK/cloudDNS/internal/adapters/repository"
 	"github.com/poyrazK/cloudDNS/internal/core/domain"
 	"github.com/poyrazK/cloudDNS/internal/core/ports"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
 	"github.com/poyrazK/cloudDNS/internal/dns/master"
 )
 
@@ -114,9 +114,7 @@ func RunImport(ctx context.Context, repo ports.DNSRepository, url string) error
 			batch[j].UpdatedAt = time.Now()
 
 			// Standardize name
-			if !strings.HasSuffix(batch[j].Name, ".") {
-				batch[j].Name += "."
-			}
+			batch[j].Name = dnsname.Fqdn(batch[j].Name)
 		}
 
 		if err := repo.BatchCreateRecords(ctx, batch); err != nil {
@@ -11,13 +11,13 @@ import (
 	"log"
 	"net/http"
 	"os"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/poyrazK/cloudDNS/internal/adapters/repository"
 	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
 )
 
 const top1mURL = "http://s3-us-west-1.amazonaws.com/umbrella-static/top-1m.csv.zip"
@@ -121,9 +121,7 @@ func main() {
 		}
 
 		domainName := line[1]
-		if !strings.HasSuffix(domainName, ".") {
-			domainName += "."
-		}
+		domainName = dnsname.Fqdn(domainName)
 
 		records = append(records, domain.Record{
 			ID:        uuid.New().String(),
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// apiKeyUsageResponse is the body of GET /api-keys/usage.
+type apiKeyUsageResponse struct {
+	Keys      []domain.APIKeyUsage `json:"keys"`
+	Anomalies []domain.APIAnomaly  `json:"anomalies"`
+}
+
+// GetAPIKeyUsage returns the request and error rates of the tenant's API keys
+// over the last hour on this node, with the anomalies detected for them.
+func (h *APIHandler) GetAPIKeyUsage(w http.ResponseWriter, r *http.Request) {
+	if h.apiUsage == nil {
+		http.Error(w, "API usage tracking is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("GetAPIKeyUsage: missing or invalid tenant ID in context")
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return
+	}
+
+	resp := apiKeyUsageResponse{
+		Keys:      h.apiUsage.APIKeyUsage(tenantID),
+		Anomalies: h.apiUsage.APIAnomalies(tenantID),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("failed to encode API key usage response: %v", err)
+	}
+}
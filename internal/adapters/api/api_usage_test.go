@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/testutil"
+)
+
+type stubUsageTracker struct {
+	requests []int
+	keyIDs   []string
+	failures []string
+}
+
+func (s *stubUsageTracker) RecordRequest(key domain.APIKey, status int) {
+	s.keyIDs = append(s.keyIDs, key.ID)
+	s.requests = append(s.requests, status)
+}
+
+func (s *stubUsageTracker) RecordAuthFailure(clientIP string, keyPrefix string) {
+	s.failures = append(s.failures, clientIP+" "+keyPrefix)
+}
+
+func (s *stubUsageTracker) APIKeyUsage(tenantID string) []domain.APIKeyUsage {
+	return []domain.APIKeyUsage{{KeyID: "k1", Requests: 12, Errors: 3, ErrorRate: 0.25}}
+}
+
+func (s *stubUsageTracker) APIAnomalies(tenantID string) []domain.APIAnomaly {
+	return []domain.APIAnomaly{{Kind: domain.APIAnomalyRequestSpike, TenantID: tenantID, KeyID: "k1", Count: 300}}
+}
+
+func TestUsageMiddleware(t *testing.T) {
+	repo := &testutil.MockRepo{}
+	repo.On("GetAPIKeyByHash", hashAPIKey("cdns_goodkey")).Return(&domain.APIKey{ID: "k1", TenantID: "t1", Role: domain.RoleAdmin, Active: true}, nil)
+	repo.On("GetAPIKeyByHash", hashAPIKey("cdns_leakedkey")).Return(nil, nil)
+	usage := &stubUsageTracker{}
+	handler := UsageMiddleware(AuthMiddleware(repo), usage)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	serve := func(path, key string) int {
+		req := httptest.NewRequest("GET", path, nil)
+		req.RemoteAddr = "192.0.2.7:40000"
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := serve("/zones", "cdns_goodkey"); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if code := serve("/missing", "cdns_goodkey"); code != http.StatusNotFound {
+		t.Fatalf("Expected 404, got %d", code)
+	}
+	if len(usage.requests) != 2 || usage.requests[0] != http.StatusOK || usage.requests[1] != http.StatusNotFound || usage.keyIDs[0] != "k1" {
+		t.Errorf("Unexpected recorded requests %v for keys %v", usage.requests, usage.keyIDs)
+	}
+
+	serve("/zones", "cdns_leakedkey")
+	serve("/zones", "")
+	if len(usage.failures) != 2 || usage.failures[0] != "192.0.2.7 cdns_lea" || usage.failures[1] != "192.0.2.7 " {
+		t.Errorf("Unexpected recorded auth failures %q", usage.failures)
+	}
+}
+
+func TestGetAPIKeyUsage(t *testing.T) {
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+	req := withTenant(httptest.NewRequest("GET", "/api-keys/usage", nil), testTenantID)
+
+	w := httptest.NewRecorder()
+	handler.GetAPIKeyUsage(w, req)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a tracker, got %d", w.Code)
+	}
+
+	handler.SetAPIUsage(&stubUsageTracker{})
+	w = httptest.NewRecorder()
+	handler.GetAPIKeyUsage(w, req)
+	var resp apiKeyUsageResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("Unexpected response %d: %v", w.Code, err)
+	}
+	if len(resp.Keys) != 1 || resp.Keys[0].ErrorRate != 0.25 || len(resp.Anomalies) != 1 || resp.Anomalies[0].TenantID != testTenantID {
+		t.Errorf("Unexpected usage %+v", resp)
+	}
+}
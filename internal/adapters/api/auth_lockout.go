@@ -0,0 +1,39 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+)
+
+// ListAuthBans returns the clients this node has banned for presenting too many
+// invalid API keys.
+func (h *APIHandler) ListAuthBans(w http.ResponseWriter, r *http.Request) {
+	if h.authLockout == nil {
+		http.Error(w, "Authentication lockout is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.authLockout.ListBans()); err != nil {
+		log.Printf("failed to encode auth bans response: %v", err)
+	}
+}
+
+// ClearAuthBan lifts the ban of a client on this node.
+func (h *APIHandler) ClearAuthBan(w http.ResponseWriter, r *http.Request) {
+	if h.authLockout == nil {
+		http.Error(w, "Authentication lockout is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	ip := r.PathValue("ip")
+	if net.ParseIP(ip) == nil {
+		http.Error(w, "Invalid IP address", http.StatusBadRequest)
+		return
+	}
+	if !h.authLockout.ClearBan(ip) {
+		http.Error(w, "Client is not banned", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
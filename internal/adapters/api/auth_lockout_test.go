@@ -0,0 +1,101 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/core/services"
+	"github.com/poyrazK/cloudDNS/internal/testutil"
+)
+
+func TestLockoutMiddleware(t *testing.T) {
+	repo := &testutil.MockRepo{}
+	repo.On("GetAPIKeyByHash", hashAPIKey("cdns_goodkey")).Return(&domain.APIKey{ID: "k1", TenantID: "t1", Role: domain.RoleAdmin, Active: true}, nil)
+	repo.On("GetAPIKeyByHash", hashAPIKey("cdns_guess")).Return(nil, nil)
+	lockout := services.NewAuthLockout(nil)
+	lockout.SetThresholds(3, time.Minute, time.Hour)
+	usage := &stubUsageTracker{}
+	handler := LockoutMiddleware(UsageMiddleware(AuthMiddleware(repo), usage), lockout)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	serve := func(ip, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/zones", nil)
+		req.RemoteAddr = ip + ":40000"
+		req.Header.Set("Authorization", "Bearer "+key)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		serve("192.0.2.7", "cdns_guess")
+	}
+	// A valid key resets the count
+	if w := serve("192.0.2.7", "cdns_goodkey"); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+	for i := 0; i < 2; i++ {
+		serve("192.0.2.7", "cdns_guess")
+	}
+	if w := serve("192.0.2.7", "cdns_goodkey"); w.Code != http.StatusOK {
+		t.Fatalf("Expected no ban after a reset, got %d", w.Code)
+	}
+
+	for i := 0; i < 3; i++ {
+		serve("192.0.2.7", "cdns_guess")
+	}
+	w := serve("192.0.2.7", "cdns_goodkey")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "60" {
+		t.Fatalf("Expected 429 with Retry-After 60, got %d %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := serve("192.0.2.8", "cdns_goodkey"); w.Code != http.StatusOK {
+		t.Errorf("Expected other clients to be served, got %d", w.Code)
+	}
+	// Banned requests never reach authentication
+	if len(usage.requests) != 3 || len(usage.failures) != 7 {
+		t.Errorf("Expected 3 requests and 7 failures to be tracked, got %v and %q", usage.requests, usage.failures)
+	}
+}
+
+func TestAuthBanEndpoints(t *testing.T) {
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+	call := func(fn http.HandlerFunc, method, target, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		if ip != "" {
+			req.SetPathValue("ip", ip)
+		}
+		w := httptest.NewRecorder()
+		fn(w, req)
+		return w
+	}
+
+	if w := call(handler.ListAuthBans, "GET", "/auth/bans", ""); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a lockout, got %d", w.Code)
+	}
+
+	lockout := services.NewAuthLockout(nil)
+	lockout.SetThresholds(1, time.Minute, time.Hour)
+	lockout.RecordFailure("2001:db8::1")
+	handler.SetAuthLockout(lockout)
+
+	w := call(handler.ListAuthBans, "GET", "/auth/bans", "")
+	var bans []domain.AuthBan
+	if err := json.NewDecoder(w.Body).Decode(&bans); err != nil || len(bans) != 1 || bans[0].ClientIP != "2001:db8::1" {
+		t.Fatalf("Unexpected bans %+v (%v)", bans, err)
+	}
+
+	if w := call(handler.ClearAuthBan, "DELETE", "/auth/bans/x", "not-an-ip"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid IP, got %d", w.Code)
+	}
+	if w := call(handler.ClearAuthBan, "DELETE", "/auth/bans/x", "2001:db8::1"); w.Code != http.StatusNoContent {
+		t.Errorf("Expected 204, got %d", w.Code)
+	}
+	if w := call(handler.ClearAuthBan, "DELETE", "/auth/bans/x", "2001:db8::1"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 once cleared, got %d", w.Code)
+	}
+}
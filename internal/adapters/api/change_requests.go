@@ -0,0 +1,168 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/core/ports"
+)
+
+// writeReviewError maps change review errors to HTTP statuses.
+func writeReviewError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrSelfReview):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, domain.ErrInvalidChangeRequest):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// reviewTenant checks that change review is enabled and returns the caller's
+// tenant, writing the error response if either is missing.
+func (h *APIHandler) reviewTenant(w http.ResponseWriter, r *http.Request, op string) (string, bool) {
+	if h.reviews == nil {
+		http.Error(w, "Change review is not enabled", http.StatusServiceUnavailable)
+		return "", false
+	}
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("%s: missing or invalid tenant ID in context", op)
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return "", false
+	}
+	return tenantID, true
+}
+
+// callerKeyID returns the ID of the API key making the request.
+func callerKeyID(r *http.Request) string {
+	if key, ok := r.Context().Value(CtxAPIKey).(*domain.APIKey); ok && key != nil {
+		return key.ID
+	}
+	return ""
+}
+
+// submitForReview turns a change refused because its zone is under review into
+// a change request, answering 202 Accepted with the request. It reports whether
+// it wrote the response; when it did not, err is not a review refusal.
+func (h *APIHandler) submitForReview(w http.ResponseWriter, r *http.Request, zoneID string, tenantID string, err error, change *domain.ChangeRequest) bool {
+	if h.reviews == nil || !errors.Is(err, domain.ErrReviewRequired) {
+		return false
+	}
+	change.RequestedBy = callerKeyID(r)
+	if err := h.reviews.SubmitChange(r.Context(), zoneID, tenantID, change); err != nil {
+		writeReviewError(w, err)
+		return true
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(change); err != nil {
+		log.Printf("failed to encode change request response: %v", err)
+	}
+	return true
+}
+
+// GetZoneReview reports whether a zone is under review.
+func (h *APIHandler) GetZoneReview(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.reviewTenant(w, r, "GetZoneReview")
+	if !ok {
+		return
+	}
+	required, err := h.reviews.GetZoneReview(r.Context(), r.PathValue("id"), tenantID)
+	if err != nil {
+		writeReviewError(w, err)
+		return
+	}
+	writeZoneReview(w, r.PathValue("id"), required)
+}
+
+// EnableZoneReview puts a zone under review: from then on its record changes
+// become change requests that an approver must approve.
+func (h *APIHandler) EnableZoneReview(w http.ResponseWriter, r *http.Request) {
+	h.setZoneReview(w, r, "EnableZoneReview", true)
+}
+
+// DisableZoneReview takes a zone out of review. Only approvers may do so, so
+// that the key whose changes are reviewed cannot lift the review itself.
+func (h *APIHandler) DisableZoneReview(w http.ResponseWriter, r *http.Request) {
+	h.setZoneReview(w, r, "DisableZoneReview", false)
+}
+
+func (h *APIHandler) setZoneReview(w http.ResponseWriter, r *http.Request, op string, required bool) {
+	tenantID, ok := h.reviewTenant(w, r, op)
+	if !ok {
+		return
+	}
+	if err := h.reviews.SetZoneReview(r.Context(), r.PathValue("id"), tenantID, required); err != nil {
+		writeReviewError(w, err)
+		return
+	}
+	writeZoneReview(w, r.PathValue("id"), required)
+}
+
+func writeZoneReview(w http.ResponseWriter, zoneID string, required bool) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"zone_id": zoneID, "review_required": required}); err != nil {
+		log.Printf("failed to encode zone review response: %v", err)
+	}
+}
+
+// ListChangeRequests returns a zone's change requests and their outcome.
+func (h *APIHandler) ListChangeRequests(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.reviewTenant(w, r, "ListChangeRequests")
+	if !ok {
+		return
+	}
+	changes, err := h.reviews.ListChangeRequests(r.Context(), r.PathValue("id"), tenantID)
+	if err != nil {
+		writeReviewError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(changes); err != nil {
+		log.Printf("failed to encode change requests response: %v", err)
+	}
+}
+
+// ApproveChangeRequest approves a pending change request and applies it; the
+// optional body is {"comment": "..."}.
+func (h *APIHandler) ApproveChangeRequest(w http.ResponseWriter, r *http.Request) {
+	h.reviewChangeRequest(w, r, "ApproveChangeRequest", ports.ChangeReviewManager.ApproveChange)
+}
+
+// RejectChangeRequest rejects a pending change request; the optional body is
+// {"comment": "..."}.
+func (h *APIHandler) RejectChangeRequest(w http.ResponseWriter, r *http.Request) {
+	h.reviewChangeRequest(w, r, "RejectChangeRequest", ports.ChangeReviewManager.RejectChange)
+}
+
+func (h *APIHandler) reviewChangeRequest(w http.ResponseWriter, r *http.Request, op string, decide func(ports.ChangeReviewManager, context.Context, string, string, string, string, string) (*domain.ChangeRequest, error)) {
+	tenantID, ok := h.reviewTenant(w, r, op)
+	if !ok {
+		return
+	}
+	var req struct {
+		Comment string `json:"comment"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	change, err := decide(h.reviews, r.Context(), r.PathValue("id"), tenantID, r.PathValue("request_id"), callerKeyID(r), req.Comment)
+	if err != nil {
+		writeReviewError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(change); err != nil {
+		log.Printf("failed to encode change request response: %v", err)
+	}
+}
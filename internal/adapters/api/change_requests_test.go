@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/testutil"
+)
+
+type stubReviews struct {
+	submitted *domain.ChangeRequest
+	required  bool
+}
+
+func (s *stubReviews) SetZoneReview(_ context.Context, zoneID, _ string, required bool) error {
+	if zoneID != "z1" {
+		return domain.ErrNotFound
+	}
+	s.required = required
+	return nil
+}
+
+func (s *stubReviews) GetZoneReview(_ context.Context, _, _ string) (bool, error) {
+	return s.required, nil
+}
+
+func (s *stubReviews) SubmitChange(_ context.Context, _, _ string, c *domain.ChangeRequest) error {
+	c.ID = "cr1"
+	c.Status = domain.ChangePending
+	s.submitted = c
+	return nil
+}
+
+func (s *stubReviews) ListChangeRequests(_ context.Context, _, _ string) ([]domain.ChangeRequest, error) {
+	return []domain.ChangeRequest{{ID: "cr1", ZoneID: "z1", Action: domain.ChangeDeleteRecord, RecordID: "r1", Status: domain.ChangePending, RequestedBy: "k1"}}, nil
+}
+
+func (s *stubReviews) ApproveChange(_ context.Context, _, _ string, id, reviewer, comment string) (*domain.ChangeRequest, error) {
+	switch {
+	case id != "cr1":
+		return nil, fmt.Errorf("change request %s: %w", id, domain.ErrNotFound)
+	case reviewer == "k1":
+		return nil, domain.ErrSelfReview
+	}
+	return &domain.ChangeRequest{ID: id, Status: domain.ChangeApplied, ReviewedBy: reviewer, Comment: comment}, nil
+}
+
+func (s *stubReviews) RejectChange(_ context.Context, _, _ string, id, _, _ string) (*domain.ChangeRequest, error) {
+	return nil, fmt.Errorf("change request %s is rejected: %w", id, domain.ErrInvalidChangeRequest)
+}
+
+func withKey(req *http.Request, keyID string) *http.Request {
+	req = withTenant(req, testTenantID)
+	return req.WithContext(context.WithValue(req.Context(), CtxAPIKey, &domain.APIKey{ID: keyID}))
+}
+
+func TestChangeRequestEndpoints(t *testing.T) {
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+	call := func(fn http.HandlerFunc, method, body, keyID string, path map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/zones/z1/change-requests", strings.NewReader(body))
+		for k, v := range path {
+			req.SetPathValue(k, v)
+		}
+		w := httptest.NewRecorder()
+		fn(w, withKey(req, keyID))
+		return w
+	}
+	z1 := map[string]string{"id": "z1"}
+	cr1 := map[string]string{"id": "z1", "request_id": "cr1"}
+
+	if w := call(handler.ListChangeRequests, "GET", "", "k1", z1); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without change review, got %d", w.Code)
+	}
+	stub := &stubReviews{}
+	handler.SetReviews(stub)
+
+	if w := call(handler.EnableZoneReview, "PUT", "", "k1", z1); w.Code != http.StatusOK || !stub.required || !strings.Contains(w.Body.String(), `"review_required":true`) {
+		t.Errorf("Unexpected enable %d: %s", w.Code, w.Body.String())
+	}
+	if w := call(handler.EnableZoneReview, "PUT", "", "k1", map[string]string{"id": "z9"}); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown zone, got %d", w.Code)
+	}
+	if w := call(handler.GetZoneReview, "GET", "", "k1", z1); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"review_required":true`) {
+		t.Errorf("Unexpected review status %d: %s", w.Code, w.Body.String())
+	}
+
+	w := call(handler.ListChangeRequests, "GET", "", "k1", z1)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"requested_by":"k1"`) {
+		t.Errorf("Unexpected listing %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := call(handler.ApproveChangeRequest, "POST", `{"comment":`, "k2", cr1); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for malformed JSON, got %d", w.Code)
+	}
+	if w := call(handler.ApproveChangeRequest, "POST", "", "k1", cr1); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for approving one's own change, got %d", w.Code)
+	}
+	w = call(handler.ApproveChangeRequest, "POST", `{"comment":"ok"}`, "k2", cr1)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"status":"applied"`) || !strings.Contains(w.Body.String(), `"reviewed_by":"k2"`) {
+		t.Errorf("Unexpected approval %d: %s", w.Code, w.Body.String())
+	}
+	if w := call(handler.ApproveChangeRequest, "POST", "", "k2", map[string]string{"id": "z1", "request_id": "cr9"}); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown request, got %d", w.Code)
+	}
+	if w := call(handler.RejectChangeRequest, "POST", "", "k2", cr1); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for a decided request, got %d", w.Code)
+	}
+
+	if w := call(handler.DisableZoneReview, "DELETE", "", "k2", z1); w.Code != http.StatusOK || stub.required {
+		t.Errorf("Unexpected disable %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestMutationSubmittedForReview(t *testing.T) {
+	svc := &mockDNSService{err: fmt.Errorf("%w: CREATE_RECORD www", domain.ErrReviewRequired)}
+	handler := NewAPIHandler(svc, &testutil.MockRepo{})
+
+	body := `{"name":"www.example.com.","type":"A","content":"192.0.2.1","ttl":300}`
+	req := httptest.NewRequest("POST", "/zones/z1/records", strings.NewReader(body))
+	req.SetPathValue("id", "z1")
+	w := httptest.NewRecorder()
+	handler.CreateRecord(w, withKey(req, "k1"))
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 without change review, got %d", w.Code)
+	}
+
+	stub := &stubReviews{}
+	handler.SetReviews(stub)
+	req = httptest.NewRequest("POST", "/zones/z1/records", strings.NewReader(body))
+	req.SetPathValue("id", "z1")
+	w = httptest.NewRecorder()
+	handler.CreateRecord(w, withKey(req, "k1"))
+	if w.Code != http.StatusAccepted || stub.submitted == nil || stub.submitted.Action != domain.ChangeCreateRecord || stub.submitted.RequestedBy != "k1" {
+		t.Fatalf("Expected the record to be submitted for review, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest("DELETE", "/zones/z1/records/r1?force=true", nil)
+	req.SetPathValue("zone_id", "z1")
+	req.SetPathValue("id", "r1")
+	w = httptest.NewRecorder()
+	handler.DeleteRecord(w, withKey(req, "k1"))
+	if w.Code != http.StatusAccepted || stub.submitted.Action != domain.ChangeDeleteRecord || stub.submitted.RecordID != "r1" || !stub.submitted.Force {
+		t.Errorf("Expected a forced delete to be submitted for review, got %d: %+v", w.Code, stub.submitted)
+	}
+}
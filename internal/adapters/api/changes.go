@@ -0,0 +1,186 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/serial"
+)
+
+// changeFeedPollInterval is how often a waiting change feed checks the journal.
+var changeFeedPollInterval = time.Second
+
+// maxChangeFeedWait caps how long a long-poll request is held open.
+const maxChangeFeedWait = time.Minute
+
+// ListZoneChanges returns the zone's change journal after since_serial, the data
+// IXFR serves, as JSON pages. Query parameters: since_serial (required), limit,
+// and wait, a duration of up to a minute to hold the request open until a change
+// arrives. Clients that accept text/event-stream instead receive every page as a
+// Server-Sent Event whose ID is its next_serial, so a reconnect with
+// Last-Event-ID resumes where the stream left off.
+func (h *APIHandler) ListZoneChanges(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("ListZoneChanges: missing or invalid tenant ID in context")
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return
+	}
+
+	q := r.URL.Query()
+	stream := strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+	rawSince := q.Get("since_serial")
+	if id := r.Header.Get("Last-Event-ID"); stream && id != "" {
+		rawSince = id
+	}
+	if rawSince == "" {
+		http.Error(w, "since_serial is required", http.StatusBadRequest)
+		return
+	}
+	since, err := serial.Parse(rawSince)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit := 0
+	if v := q.Get("limit"); v != "" {
+		n, errAtoi := strconv.Atoi(v)
+		if errAtoi != nil || n < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	var wait time.Duration
+	if v := q.Get("wait"); v != "" {
+		d, errParse := time.ParseDuration(v)
+		if errParse != nil || d < 0 {
+			http.Error(w, "Invalid wait", http.StatusBadRequest)
+			return
+		}
+		wait = min(d, maxChangeFeedWait)
+	}
+
+	zoneID := r.PathValue("id")
+	if stream {
+		h.streamZoneChanges(w, r, zoneID, tenantID, since, limit)
+		return
+	}
+
+	if wait > 0 {
+		// Long polls outlive the server-wide write timeout
+		_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(wait + 10*time.Second))
+	}
+	feed, err := h.waitZoneChanges(r.Context(), zoneID, tenantID, since, limit, wait)
+	if err != nil {
+		writeRRsetError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(feed); err != nil {
+		log.Printf("failed to encode zone changes response: %v", err)
+	}
+}
+
+// waitZoneChanges returns the first page of changes after since, polling the
+// journal for up to wait while it is empty.
+func (h *APIHandler) waitZoneChanges(ctx context.Context, zoneID, tenantID string, since uint32, limit int, wait time.Duration) (*domain.ZoneChangeFeed, error) {
+	feed, err := h.svc.ListZoneChangeFeed(ctx, zoneID, tenantID, since, limit)
+	if err != nil || len(feed.Changes) > 0 || wait <= 0 {
+		return feed, err
+	}
+
+	deadline := time.NewTimer(wait)
+	defer deadline.Stop()
+	poll := time.NewTicker(changeFeedPollInterval)
+	defer poll.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return feed, nil
+		case <-deadline.C:
+			return feed, nil
+		case <-poll.C:
+			feed, err = h.svc.ListZoneChangeFeed(ctx, zoneID, tenantID, since, limit)
+			if err != nil || len(feed.Changes) > 0 {
+				return feed, err
+			}
+		}
+	}
+}
+
+// streamZoneChanges sends every page of changes after since as a Server-Sent
+// Event, then keeps polling the journal for new ones until the client leaves.
+func (h *APIHandler) streamZoneChanges(w http.ResponseWriter, r *http.Request, zoneID, tenantID string, since uint32, limit int) {
+	ctx := r.Context()
+	feed, err := h.svc.ListZoneChangeFeed(ctx, zoneID, tenantID, since, limit)
+	if err != nil {
+		writeRRsetError(w, err)
+		return
+	}
+
+	// Streams outlive the server-wide write timeout
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		log.Printf("ListZoneChanges: streaming unsupported: %v", err)
+		return
+	}
+
+	heartbeat := time.NewTicker(eventHeartbeat)
+	defer heartbeat.Stop()
+	poll := time.NewTicker(changeFeedPollInterval)
+	defer poll.Stop()
+
+	for {
+		if len(feed.Changes) > 0 {
+			data, err := json.Marshal(feed)
+			if err != nil {
+				log.Printf("failed to encode zone changes: %v", err)
+				return
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: changes\ndata: %s\n\n", feed.NextSerial, data); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+
+	wait:
+		for !feed.HasMore {
+			select {
+			case <-ctx.Done():
+				return
+			case <-heartbeat.C:
+				if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+					return
+				}
+				if err := rc.Flush(); err != nil {
+					return
+				}
+			case <-poll.C:
+				break wait
+			}
+		}
+
+		feed, err = h.svc.ListZoneChangeFeed(ctx, zoneID, tenantID, feed.NextSerial, limit)
+		if err != nil {
+			log.Printf("ListZoneChanges: failed to poll zone %s: %v", zoneID, err)
+			return
+		}
+	}
+}
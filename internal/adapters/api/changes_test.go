@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/testutil"
+)
+
+func TestListZoneChanges(t *testing.T) {
+	changeFeedPollInterval = 10 * time.Millisecond
+	defer func() { changeFeedPollInterval = time.Second }()
+
+	empty := &domain.ZoneChangeFeed{ZoneID: "z1", SinceSerial: 5, NextSerial: 5, Changes: []domain.ZoneChange{}}
+	page := &domain.ZoneChangeFeed{ZoneID: "z1", SinceSerial: 5, NextSerial: 6, Changes: []domain.ZoneChange{
+		{ZoneID: "z1", Serial: 6, Action: "ADD", Name: "www.example.com.", Type: domain.TypeA, Content: "192.0.2.1"},
+	}}
+
+	get := func(svc *testutil.MockDNSService, query string, header http.Header) *httptest.ResponseRecorder {
+		handler := NewAPIHandler(svc, &testutil.MockRepo{})
+		req := httptest.NewRequest("GET", "/zones/z1/changes"+query, nil)
+		req.SetPathValue("id", "z1")
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		w := httptest.NewRecorder()
+		handler.ListZoneChanges(w, withTenant(req, testTenantID))
+		return w
+	}
+
+	for _, query := range []string{"", "?since_serial=abc", "?since_serial=1&limit=0", "?since_serial=1&wait=soon"} {
+		if w := get(&testutil.MockDNSService{}, query, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %q, got %d", query, w.Code)
+		}
+	}
+
+	missing := &testutil.MockDNSService{}
+	missing.On("ListZoneChangeFeed", "z1", testTenantID, uint32(1), 0).Return(nil, domain.ErrNotFound)
+	if w := get(missing, "?since_serial=1", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown zone, got %d", w.Code)
+	}
+
+	// A long poll waits for the first change
+	svc := &testutil.MockDNSService{}
+	svc.On("ListZoneChangeFeed", "z1", testTenantID, uint32(5), 10).Return(empty, nil).Twice()
+	svc.On("ListZoneChangeFeed", "z1", testTenantID, uint32(5), 10).Return(page, nil)
+	w := get(svc, "?since_serial=5&limit=10&wait=5s", nil)
+	var got domain.ZoneChangeFeed
+	if w.Code != http.StatusOK || json.NewDecoder(w.Body).Decode(&got) != nil || got.NextSerial != 6 || len(got.Changes) != 1 {
+		t.Fatalf("Expected the change after waiting, got %d: %+v", w.Code, got)
+	}
+
+	// The stream resumes from Last-Event-ID and labels events with their serial
+	stream := &testutil.MockDNSService{}
+	stream.On("ListZoneChangeFeed", "z1", testTenantID, uint32(5), 0).Return(page, nil).Once()
+	stream.On("ListZoneChangeFeed", "z1", testTenantID, uint32(6), 0).Return(&domain.ZoneChangeFeed{ZoneID: "z1", SinceSerial: 6, NextSerial: 6}, nil)
+	handler := NewAPIHandler(stream, &testutil.MockRepo{})
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "/zones/z1/changes?since_serial=1", nil).WithContext(ctx)
+	req.SetPathValue("id", "z1")
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Last-Event-ID", "5")
+	w = httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		handler.ListZoneChanges(w, withTenant(req, testTenantID))
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	<-done
+
+	body := w.Body.String()
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Unexpected content type %q", ct)
+	}
+	if !strings.Contains(body, "id: 6\nevent: changes\n") || !strings.Contains(body, "www.example.com.") {
+		t.Errorf("Expected a changes event for serial 6, got %q", body)
+	}
+	if strings.Count(body, "event: changes") != 1 {
+		t.Errorf("Expected no events once caught up, got %q", body)
+	}
+}
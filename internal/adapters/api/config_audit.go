@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// GetConfigAudit returns the caller's findings from the latest scheduled
+// configuration audit, auditing the tenant's zones when none is available yet.
+func (h *APIHandler) GetConfigAudit(w http.ResponseWriter, r *http.Request) {
+	if h.configAudit == nil {
+		http.Error(w, "Configuration audits are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("GetConfigAudit: missing or invalid tenant ID in context")
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return
+	}
+
+	report := h.configAudit.LatestReport(tenantID)
+	if report == nil {
+		var err error
+		if report, err = h.configAudit.Audit(r.Context(), tenantID); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	writeConfigAudit(w, report)
+}
+
+// RunConfigAudit audits the caller's zones now, for example after fixing the
+// problems reported by the last scheduled audit.
+func (h *APIHandler) RunConfigAudit(w http.ResponseWriter, r *http.Request) {
+	if h.configAudit == nil {
+		http.Error(w, "Configuration audits are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("RunConfigAudit: missing or invalid tenant ID in context")
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return
+	}
+
+	report, err := h.configAudit.Audit(r.Context(), tenantID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeConfigAudit(w, report)
+}
+
+func writeConfigAudit(w http.ResponseWriter, report *domain.ConfigAuditReport) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("failed to encode configuration audit response: %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/testutil"
+)
+
+type stubConfigAudit struct {
+	latest  *domain.ConfigAuditReport
+	audited string
+}
+
+func (s *stubConfigAudit) Audit(_ context.Context, tenantID string) (*domain.ConfigAuditReport, error) {
+	s.audited = tenantID
+	return &domain.ConfigAuditReport{Zones: 1, Findings: []domain.ConfigFinding{{Check: domain.AuditCNAMEAtApex, Name: "fresh.test."}}}, nil
+}
+
+func (s *stubConfigAudit) LatestReport(_ string) *domain.ConfigAuditReport {
+	return s.latest
+}
+
+func TestConfigAuditEndpoints(t *testing.T) {
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+	call := func(fn http.HandlerFunc, method string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/config-audit", nil)
+		w := httptest.NewRecorder()
+		fn(w, withTenant(req, testTenantID))
+		return w
+	}
+
+	if w := call(handler.GetConfigAudit, "GET"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without an auditor, got %d", w.Code)
+	}
+	stub := &stubConfigAudit{}
+	handler.SetConfigAudit(stub)
+
+	if w := call(handler.GetConfigAudit, "GET"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "fresh.test.") || stub.audited != testTenantID {
+		t.Errorf("Expected an on-demand audit before the first scheduled run, got %d: %s", w.Code, w.Body.String())
+	}
+	stub.audited = ""
+	stub.latest = &domain.ConfigAuditReport{Findings: []domain.ConfigFinding{{Check: domain.AuditTTLMismatch, Name: "cached.test."}}}
+	if w := call(handler.GetConfigAudit, "GET"); !strings.Contains(w.Body.String(), "cached.test.") || stub.audited != "" {
+		t.Errorf("Expected the scheduled report, got %s", w.Body.String())
+	}
+	if w := call(handler.RunConfigAudit, "POST"); w.Code != http.StatusOK || stub.audited != testTenantID {
+		t.Errorf("Expected POST to audit the tenant now, got %d", w.Code)
+	}
+}
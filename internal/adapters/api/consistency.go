@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// GetConsistencyReport returns the latest scheduled fleet consistency report,
+// running a check when none is available yet.
+func (h *APIHandler) GetConsistencyReport(w http.ResponseWriter, r *http.Request) {
+	if h.consistency == nil {
+		http.Error(w, "Consistency checks are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	report := h.consistency.LatestReport()
+	if report == nil {
+		var err error
+		if report, err = h.consistency.Check(r.Context(), nil); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("failed to encode consistency response: %v", err)
+	}
+}
+
+// RunConsistencyCheck asks every node the queries in the request body, or every
+// zone apex when the body is empty, and returns the comparison.
+func (h *APIHandler) RunConsistencyCheck(w http.ResponseWriter, r *http.Request) {
+	if h.consistency == nil {
+		http.Error(w, "Consistency checks are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req struct {
+		Queries []domain.ConsistencyQuery `json:"queries"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, q := range req.Queries {
+		if q.Name == "" || q.Type == "" {
+			http.Error(w, "Each query needs a name and type", http.StatusBadRequest)
+			return
+		}
+	}
+
+	report, err := h.consistency.Check(r.Context(), req.Queries)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("failed to encode consistency response: %v", err)
+	}
+}
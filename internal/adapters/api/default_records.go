@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// writeDefaultRecordError maps default record errors to HTTP statuses.
+func writeDefaultRecordError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidDefaultRecord):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		writeMutationError(w, err)
+	}
+}
+
+// defaultRecordTenant checks that default records are enabled and returns the
+// caller's tenant, writing the error response if either is missing.
+func (h *APIHandler) defaultRecordTenant(w http.ResponseWriter, r *http.Request, op string) (string, bool) {
+	if h.defaults == nil {
+		http.Error(w, "Default records are not enabled", http.StatusServiceUnavailable)
+		return "", false
+	}
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("%s: missing or invalid tenant ID in context", op)
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return "", false
+	}
+	return tenantID, true
+}
+
+// ListDefaultRecords returns a zone's default records.
+func (h *APIHandler) ListDefaultRecords(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.defaultRecordTenant(w, r, "ListDefaultRecords")
+	if !ok {
+		return
+	}
+	records, err := h.defaults.ListDefaultRecords(r.Context(), r.PathValue("id"), tenantID)
+	if err != nil {
+		writeDefaultRecordError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(records); err != nil {
+		log.Printf("failed to encode default records response: %v", err)
+	}
+}
+
+// CreateDefaultRecord adds a default record to a zone; the body is {"type",
+// "content", "ttl", "priority", "min_depth", "max_depth"}.
+func (h *APIHandler) CreateDefaultRecord(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.defaultRecordTenant(w, r, "CreateDefaultRecord")
+	if !ok {
+		return
+	}
+	var rec domain.DefaultRecord
+	if err := json.NewDecoder(r.Body).Decode(&rec); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.defaults.CreateDefaultRecord(r.Context(), r.PathValue("id"), tenantID, &rec); err != nil {
+		writeDefaultRecordError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(rec); err != nil {
+		log.Printf("failed to encode default record response: %v", err)
+	}
+}
+
+// DeleteDefaultRecord removes a default record of a zone.
+func (h *APIHandler) DeleteDefaultRecord(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.defaultRecordTenant(w, r, "DeleteDefaultRecord")
+	if !ok {
+		return
+	}
+	if err := h.defaults.DeleteDefaultRecord(r.Context(), r.PathValue("id"), tenantID, r.PathValue("default_id")); err != nil {
+		writeDefaultRecordError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/testutil"
+)
+
+type stubDefaultRecords struct {
+	saved   *domain.DefaultRecord
+	deleted string
+}
+
+func (s *stubDefaultRecords) CreateDefaultRecord(_ context.Context, zoneID string, _ string, rec *domain.DefaultRecord) error {
+	if zoneID != "z1" {
+		return domain.ErrNotFound
+	}
+	if rec.Content == "" {
+		return fmt.Errorf("no content: %w", domain.ErrInvalidDefaultRecord)
+	}
+	rec.ID = "d1"
+	s.saved = rec
+	return nil
+}
+
+func (s *stubDefaultRecords) ListDefaultRecords(_ context.Context, _ string, _ string) ([]domain.DefaultRecord, error) {
+	return []domain.DefaultRecord{{ID: "d1", ZoneID: "z1", Type: domain.TypeA, Content: "192.0.2.1", MinDepth: 1}}, nil
+}
+
+func (s *stubDefaultRecords) DeleteDefaultRecord(_ context.Context, _ string, _ string, id string) error {
+	if id != "d1" {
+		return domain.ErrNotFound
+	}
+	s.deleted = id
+	return nil
+}
+
+func TestDefaultRecordEndpoints(t *testing.T) {
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+	call := func(fn http.HandlerFunc, method, body, zoneID, id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/zones/"+zoneID+"/default-records", strings.NewReader(body))
+		req.SetPathValue("id", zoneID)
+		req.SetPathValue("default_id", id)
+		w := httptest.NewRecorder()
+		fn(w, withTenant(req, testTenantID))
+		return w
+	}
+
+	if w := call(handler.ListDefaultRecords, "GET", "", "z1", ""); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a default record manager, got %d", w.Code)
+	}
+	stub := &stubDefaultRecords{}
+	handler.SetDefaultRecords(stub)
+
+	if w := call(handler.ListDefaultRecords, "GET", "", "z1", ""); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"min_depth":1`) {
+		t.Errorf("Unexpected listing %d: %s", w.Code, w.Body.String())
+	}
+	if w := call(handler.CreateDefaultRecord, "POST", `{"type":`, "z1", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for malformed JSON, got %d", w.Code)
+	}
+	if w := call(handler.CreateDefaultRecord, "POST", `{"type":"A"}`, "z1", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a default record without content, got %d", w.Code)
+	}
+	if w := call(handler.CreateDefaultRecord, "POST", `{"type":"A","content":"192.0.2.1"}`, "z2", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown zone, got %d", w.Code)
+	}
+	w := call(handler.CreateDefaultRecord, "POST", `{"type":"A","content":"192.0.2.1","min_depth":2,"max_depth":3}`, "z1", "")
+	if w.Code != http.StatusCreated || stub.saved == nil || stub.saved.MaxDepth != 3 || !strings.Contains(w.Body.String(), `"id":"d1"`) {
+		t.Errorf("Unexpected default record %d: %s", w.Code, w.Body.String())
+	}
+	if w := call(handler.DeleteDefaultRecord, "DELETE", "", "z1", "d2"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown default record, got %d", w.Code)
+	}
+	if w := call(handler.DeleteDefaultRecord, "DELETE", "", "z1", "d1"); w.Code != http.StatusNoContent || stub.deleted != "d1" {
+		t.Errorf("Expected 204 deleting d1, got %d", w.Code)
+	}
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/core/ports"
+)
+
+// writeDelegationError maps delegation errors to HTTP statuses.
+func writeDelegationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound), errors.Is(err, domain.ErrNoHostedParent):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrChangeFrozen):
+		http.Error(w, err.Error(), http.StatusLocked)
+	case errors.Is(err, domain.ErrReviewRequired):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, domain.ErrInvalidRRset):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// GetDelegation compares a zone's NS and DS records in its parent zone with the
+// zone itself, when the tenant hosts both.
+func (h *APIHandler) GetDelegation(w http.ResponseWriter, r *http.Request) {
+	h.serveDelegation(w, r, "GetDelegation", ports.DelegationSyncer.CheckDelegation)
+}
+
+// SyncDelegation rewrites the zone's NS and DS records in its parent zone to
+// match the zone.
+func (h *APIHandler) SyncDelegation(w http.ResponseWriter, r *http.Request) {
+	h.serveDelegation(w, r, "SyncDelegation", ports.DelegationSyncer.SyncDelegation)
+}
+
+func (h *APIHandler) serveDelegation(w http.ResponseWriter, r *http.Request, op string, call func(ports.DelegationSyncer, context.Context, string, string) (*domain.DelegationStatus, error)) {
+	if h.delegations == nil {
+		http.Error(w, "Delegation sync is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("%s: missing or invalid tenant ID in context", op)
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return
+	}
+
+	status, err := call(h.delegations, r.Context(), r.PathValue("id"), tenantID)
+	if err != nil {
+		writeDelegationError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		log.Printf("failed to encode delegation response: %v", err)
+	}
+}
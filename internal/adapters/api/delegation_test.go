@@ -0,0 +1,55 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/testutil"
+)
+
+type stubDelegations struct {
+	synced bool
+}
+
+func (s *stubDelegations) CheckDelegation(_ context.Context, zoneID string, _ string) (*domain.DelegationStatus, error) {
+	if zoneID != "z1" {
+		return nil, fmt.Errorf("zone %s: %w", zoneID, domain.ErrNoHostedParent)
+	}
+	return &domain.DelegationStatus{ZoneID: zoneID, ParentZone: "example.test.", InSync: s.synced}, nil
+}
+
+func (s *stubDelegations) SyncDelegation(ctx context.Context, zoneID string, tenantID string) (*domain.DelegationStatus, error) {
+	s.synced = true
+	return s.CheckDelegation(ctx, zoneID, tenantID)
+}
+
+func TestDelegationEndpoints(t *testing.T) {
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+	call := func(fn http.HandlerFunc, method, id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/zones/"+id+"/delegation", nil)
+		req.SetPathValue("id", id)
+		w := httptest.NewRecorder()
+		fn(w, withTenant(req, testTenantID))
+		return w
+	}
+
+	if w := call(handler.GetDelegation, "GET", "z1"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a syncer, got %d", w.Code)
+	}
+	handler.SetDelegations(&stubDelegations{})
+
+	if w := call(handler.GetDelegation, "GET", "z2"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 without a hosted parent, got %d", w.Code)
+	}
+	if w := call(handler.GetDelegation, "GET", "z1"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"in_sync":false`) {
+		t.Errorf("Unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	if w := call(handler.SyncDelegation, "POST", "z1"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"in_sync":true`) {
+		t.Errorf("Unexpected sync %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,253 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// writeKeyError maps DNSSEC key management errors to HTTP statuses.
+func writeKeyError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidKeyOperation):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrKeyInUse):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, domain.ErrKeyExportDisabled):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// dnssecTenant checks that key management is enabled and returns the caller's
+// tenant, writing the error response if either is missing.
+func (h *APIHandler) dnssecTenant(w http.ResponseWriter, r *http.Request, op string) (string, bool) {
+	if h.dnssecKeys == nil {
+		http.Error(w, "DNSSEC key management is not enabled", http.StatusServiceUnavailable)
+		return "", false
+	}
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("%s: missing or invalid tenant ID in context", op)
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return "", false
+	}
+	return tenantID, true
+}
+
+func writeKeyJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("failed to encode DNSSEC key response: %v", err)
+	}
+}
+
+// ListZoneKeys returns the public data of a zone's DNSSEC keys.
+func (h *APIHandler) ListZoneKeys(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.dnssecTenant(w, r, "ListZoneKeys")
+	if !ok {
+		return
+	}
+	keys, err := h.dnssecKeys.ListZoneKeys(r.Context(), r.PathValue("id"), tenantID)
+	if err != nil {
+		writeKeyError(w, err)
+		return
+	}
+	writeKeyJSON(w, http.StatusOK, keys)
+}
+
+// RolloverKey generates a new KSK or ZSK for a zone; the body is {"key_type": "ZSK"}.
+func (h *APIHandler) RolloverKey(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.dnssecTenant(w, r, "RolloverKey")
+	if !ok {
+		return
+	}
+	var req struct {
+		KeyType string `json:"key_type"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	key, err := h.dnssecKeys.RolloverKey(r.Context(), r.PathValue("id"), tenantID, strings.ToUpper(req.KeyType))
+	if err != nil {
+		writeKeyError(w, err)
+		return
+	}
+	writeKeyJSON(w, http.StatusCreated, key)
+}
+
+// SetKeyActive activates or deactivates a zone key; the body is {"active": false}.
+func (h *APIHandler) SetKeyActive(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.dnssecTenant(w, r, "SetKeyActive")
+	if !ok {
+		return
+	}
+	var req struct {
+		Active *bool `json:"active"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Active == nil {
+		http.Error(w, "Invalid request: active is required", http.StatusBadRequest)
+		return
+	}
+
+	key, err := h.dnssecKeys.SetKeyActive(r.Context(), r.PathValue("id"), tenantID, r.PathValue("key_id"), *req.Active)
+	if err != nil {
+		writeKeyError(w, err)
+		return
+	}
+	writeKeyJSON(w, http.StatusOK, key)
+}
+
+// GetZoneDS returns DS records for a zone's active KSKs. The digest query parameter
+// lists the digest types as numbers or names (sha1, sha256, sha384) and defaults
+// to SHA-256. With format=text the records are returned in zone file format.
+func (h *APIHandler) GetZoneDS(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.dnssecTenant(w, r, "GetZoneDS")
+	if !ok {
+		return
+	}
+	digests := []uint8{2}
+	if v := r.URL.Query().Get("digest"); v != "" {
+		digests = digests[:0]
+		for _, d := range strings.Split(v, ",") {
+			dt, err := parseDigestType(d)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			digests = append(digests, dt)
+		}
+	}
+
+	records, err := h.dnssecKeys.ZoneDS(r.Context(), r.PathValue("id"), tenantID, digests)
+	if err != nil {
+		writeKeyError(w, err)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		for _, ds := range records {
+			if _, err := w.Write([]byte(ds.Record + "\n")); err != nil {
+				log.Printf("failed to write DS response: %v", err)
+				return
+			}
+		}
+		return
+	}
+	writeKeyJSON(w, http.StatusOK, records)
+}
+
+// ImportKey stores a key migrated from BIND. The body carries the contents of the
+// .key and .private files as {"public_key": "...", "private_key": "..."}.
+func (h *APIHandler) ImportKey(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.dnssecTenant(w, r, "ImportKey")
+	if !ok {
+		return
+	}
+	var files domain.DNSSECKeyFiles
+	if err := json.NewDecoder(r.Body).Decode(&files); err != nil || files.PublicKey == "" || files.PrivateKey == "" {
+		http.Error(w, "Invalid request: public_key and private_key are required", http.StatusBadRequest)
+		return
+	}
+
+	key, err := h.dnssecKeys.ImportKey(r.Context(), r.PathValue("id"), tenantID, files)
+	if err != nil {
+		writeKeyError(w, err)
+		return
+	}
+	writeKeyJSON(w, http.StatusCreated, key)
+}
+
+// ExportKey returns a zone key, including its private half, as BIND key files.
+func (h *APIHandler) ExportKey(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.dnssecTenant(w, r, "ExportKey")
+	if !ok {
+		return
+	}
+	files, err := h.dnssecKeys.ExportKey(r.Context(), r.PathValue("id"), tenantID, r.PathValue("key_id"))
+	if err != nil {
+		writeKeyError(w, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeKeyJSON(w, http.StatusOK, files)
+}
+
+// ListSignerKeys returns the DNSKEY records of a zone's own ZSKs for the other
+// providers of a multi-signer zone, as a JSON array or, with format=text, in zone
+// file format.
+func (h *APIHandler) ListSignerKeys(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.dnssecTenant(w, r, "ListSignerKeys")
+	if !ok {
+		return
+	}
+	records, err := h.dnssecKeys.SignerKeys(r.Context(), r.PathValue("id"), tenantID)
+	if err != nil {
+		writeKeyError(w, err)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		for _, rec := range records {
+			if _, err := w.Write([]byte(rec + "\n")); err != nil {
+				log.Printf("failed to write signer key response: %v", err)
+				return
+			}
+		}
+		return
+	}
+	writeKeyJSON(w, http.StatusOK, records)
+}
+
+// ImportSignerKey publishes another provider's ZSK in a zone's DNSKEY RRset; the
+// body is {"dnskey": "example.com. 3600 IN DNSKEY 256 3 13 ..."}.
+func (h *APIHandler) ImportSignerKey(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.dnssecTenant(w, r, "ImportSignerKey")
+	if !ok {
+		return
+	}
+	var req struct {
+		DNSKEY string `json:"dnskey"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.DNSKEY == "" {
+		http.Error(w, "Invalid request: dnskey is required", http.StatusBadRequest)
+		return
+	}
+
+	key, err := h.dnssecKeys.ImportSignerKey(r.Context(), r.PathValue("id"), tenantID, req.DNSKEY)
+	if err != nil {
+		writeKeyError(w, err)
+		return
+	}
+	writeKeyJSON(w, http.StatusCreated, key)
+}
+
+// parseDigestType accepts a DS digest type by number or by name.
+func parseDigestType(s string) (uint8, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "sha1", "sha-1":
+		return 1, nil
+	case "sha256", "sha-256":
+		return 2, nil
+	case "sha384", "sha-384":
+		return 4, nil
+	}
+	n, err := strconv.ParseUint(strings.TrimSpace(s), 10, 8)
+	if err != nil {
+		return 0, errors.New("invalid digest type: " + s)
+	}
+	return uint8(n), nil
+}
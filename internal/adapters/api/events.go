@@ -0,0 +1,89 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// eventHeartbeat is how often an idle stream sends a comment line so proxies and
+// clients can tell a quiet stream from a dead one.
+var eventHeartbeat = 15 * time.Second
+
+// StreamEvents streams the tenant's live zone, record, sampled query and SLO
+// events as Server-Sent Events. Optional query parameters: zone_id, and types as
+// a comma-separated list of "zone", "record", "query" and "slo".
+func (h *APIHandler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("StreamEvents: missing or invalid tenant ID in context")
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return
+	}
+	if h.events == nil {
+		http.Error(w, "Event streaming is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	filter := domain.EventFilter{TenantID: tenantID, ZoneID: r.URL.Query().Get("zone_id")}
+	if raw := r.URL.Query().Get("types"); raw != "" {
+		for _, t := range strings.Split(raw, ",") {
+			switch et := domain.EventType(strings.TrimSpace(t)); et {
+			case domain.EventZoneChange, domain.EventRecordChange, domain.EventQuery, domain.EventSLO:
+				filter.Types = append(filter.Types, et)
+			default:
+				http.Error(w, fmt.Sprintf("unknown event type %q", t), http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	// Streams outlive the server-wide write timeout
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		log.Printf("StreamEvents: streaming unsupported: %v", err)
+		return
+	}
+
+	ctx := r.Context()
+	events := h.events.Subscribe(ctx, filter)
+	heartbeat := time.NewTicker(eventHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case evt, open := <-events:
+			if !open {
+				return
+			}
+			data, err := json.Marshal(evt)
+			if err != nil {
+				log.Printf("failed to encode event: %v", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", evt.Type, data); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/core/services"
+	"github.com/poyrazK/cloudDNS/internal/testutil"
+)
+
+func TestStreamEvents(t *testing.T) {
+	bus := services.NewEventBus(0)
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+	handler.SetEvents(bus)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "/events?types=record", nil).WithContext(ctx)
+	req = withTenant(req, testTenantID)
+	w := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		handler.StreamEvents(w, req)
+		close(done)
+	}()
+
+	// Wait for the handler to subscribe before publishing
+	deadline := time.Now().Add(time.Second)
+	for bus.Subscribers() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	bus.Publish(domain.Event{Type: domain.EventQuery, TenantID: testTenantID})
+	bus.Publish(domain.Event{Type: domain.EventRecordChange, TenantID: "other", Name: "foreign.example."})
+	bus.Publish(domain.Event{Type: domain.EventRecordChange, TenantID: testTenantID, Name: "www.example."})
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	<-done
+
+	body := w.Body.String()
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Unexpected content type %q", ct)
+	}
+	if !strings.Contains(body, "event: record\n") || !strings.Contains(body, "www.example.") {
+		t.Errorf("Expected record event in stream, got %q", body)
+	}
+	if strings.Contains(body, "foreign.example.") || strings.Contains(body, "event: query") {
+		t.Errorf("Stream leaked filtered events: %q", body)
+	}
+}
+
+func TestStreamEvents_Errors(t *testing.T) {
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+
+	w := httptest.NewRecorder()
+	handler.StreamEvents(w, withTenant(httptest.NewRequest("GET", "/events", nil), testTenantID))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without an event stream, got %d", w.Code)
+	}
+
+	handler.SetEvents(services.NewEventBus(0))
+	w = httptest.NewRecorder()
+	handler.StreamEvents(w, withTenant(httptest.NewRequest("GET", "/events?types=bogus", nil), testTenantID))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for unknown type, got %d", w.Code)
+	}
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// writeMutationError reports a failed zone or record change, answering 423 Locked
+// when a change freeze rejected it, 409 Conflict when the zone is under review,
+// 403 Forbidden for a protected record and 400 Bad Request for a record scoped
+// to an unknown view, with a malformed geo policy or referencing an unusable
+// target or health check.
+func writeMutationError(w http.ResponseWriter, err error) {
+	if errors.Is(err, domain.ErrInvalidView) || errors.Is(err, domain.ErrInvalidGeoPolicy) || errors.Is(err, domain.ErrInvalidTarget) || errors.Is(err, domain.ErrInvalidHealthCheck) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, domain.ErrChangeFrozen) {
+		http.Error(w, err.Error(), http.StatusLocked)
+		return
+	}
+	if errors.Is(err, domain.ErrReviewRequired) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, domain.ErrRecordProtected) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+// writeFreezeError maps change freeze errors to HTTP statuses.
+func writeFreezeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidChangeFreeze):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// freezeTenant checks that change freezes are enabled and returns the caller's
+// tenant, writing the error response if either is missing.
+func (h *APIHandler) freezeTenant(w http.ResponseWriter, r *http.Request, op string) (string, bool) {
+	if h.freezes == nil {
+		http.Error(w, "Change freezes are not enabled", http.StatusServiceUnavailable)
+		return "", false
+	}
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("%s: missing or invalid tenant ID in context", op)
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return "", false
+	}
+	return tenantID, true
+}
+
+// ListChangeFreezes returns the tenant's current and upcoming change freezes.
+func (h *APIHandler) ListChangeFreezes(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.freezeTenant(w, r, "ListChangeFreezes")
+	if !ok {
+		return
+	}
+	freezes, err := h.freezes.ListChangeFreezes(r.Context(), tenantID)
+	if err != nil {
+		writeFreezeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(freezes); err != nil {
+		log.Printf("failed to encode change freezes response: %v", err)
+	}
+}
+
+// CreateChangeFreeze freezes the tenant's zones, or one zone, for a window; the
+// body is {"zone_id", "reason", "starts_at", "ends_at"}.
+func (h *APIHandler) CreateChangeFreeze(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.freezeTenant(w, r, "CreateChangeFreeze")
+	if !ok {
+		return
+	}
+	var freeze domain.ChangeFreeze
+	if err := json.NewDecoder(r.Body).Decode(&freeze); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.freezes.CreateChangeFreeze(r.Context(), tenantID, &freeze); err != nil {
+		writeFreezeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(freeze); err != nil {
+		log.Printf("failed to encode change freeze response: %v", err)
+	}
+}
+
+// DeleteChangeFreeze lifts a change freeze.
+func (h *APIHandler) DeleteChangeFreeze(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.freezeTenant(w, r, "DeleteChangeFreeze")
+	if !ok {
+		return
+	}
+	if err := h.freezes.DeleteChangeFreeze(r.Context(), tenantID, r.PathValue("id")); err != nil {
+		writeFreezeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/testutil"
+)
+
+type stubFreezes struct {
+	created *domain.ChangeFreeze
+	deleted string
+}
+
+func (s *stubFreezes) CreateChangeFreeze(_ context.Context, _ string, f *domain.ChangeFreeze) error {
+	if !f.EndsAt.After(f.StartsAt) {
+		return fmt.Errorf("bad window: %w", domain.ErrInvalidChangeFreeze)
+	}
+	f.ID = "f1"
+	s.created = f
+	return nil
+}
+
+func (s *stubFreezes) ListChangeFreezes(_ context.Context, _ string) ([]domain.ChangeFreeze, error) {
+	return []domain.ChangeFreeze{{ID: "f1", Reason: "holidays"}}, nil
+}
+
+func (s *stubFreezes) DeleteChangeFreeze(_ context.Context, _ string, id string) error {
+	if id != "f1" {
+		return domain.ErrNotFound
+	}
+	s.deleted = id
+	return nil
+}
+
+func TestChangeFreezeEndpoints(t *testing.T) {
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+	call := func(fn http.HandlerFunc, method, body, id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/freezes", strings.NewReader(body))
+		req.SetPathValue("id", id)
+		w := httptest.NewRecorder()
+		fn(w, withTenant(req, testTenantID))
+		return w
+	}
+
+	if w := call(handler.ListChangeFreezes, "GET", "", ""); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a freeze manager, got %d", w.Code)
+	}
+	stub := &stubFreezes{}
+	handler.SetFreezes(stub)
+
+	if w := call(handler.ListChangeFreezes, "GET", "", ""); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "holidays") {
+		t.Errorf("Unexpected listing %d: %s", w.Code, w.Body.String())
+	}
+	if w := call(handler.CreateChangeFreeze, "POST", `{"ends_at":`, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for malformed JSON, got %d", w.Code)
+	}
+	if w := call(handler.CreateChangeFreeze, "POST", `{"starts_at":"2030-01-02T00:00:00Z","ends_at":"2030-01-01T00:00:00Z"}`, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an inverted window, got %d", w.Code)
+	}
+	w := call(handler.CreateChangeFreeze, "POST", `{"zone_id":"z1","reason":"launch","starts_at":"2030-01-01T00:00:00Z","ends_at":"2030-01-02T00:00:00Z"}`, "")
+	if w.Code != http.StatusCreated || stub.created == nil || stub.created.ZoneID != "z1" || stub.created.Reason != "launch" {
+		t.Errorf("Unexpected freeze %d: %s", w.Code, w.Body.String())
+	}
+	if w := call(handler.DeleteChangeFreeze, "DELETE", "", "f2"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown freeze, got %d", w.Code)
+	}
+	if w := call(handler.DeleteChangeFreeze, "DELETE", "", "f1"); w.Code != http.StatusNoContent || stub.deleted != "f1" {
+		t.Errorf("Expected 204 lifting f1, got %d", w.Code)
+	}
+}
+
+func TestFrozenChangeIsLocked(t *testing.T) {
+	svc := &mockDNSService{err: fmt.Errorf("%w until tomorrow", domain.ErrChangeFrozen)}
+	handler := NewAPIHandler(svc, &testutil.MockRepo{})
+
+	req := httptest.NewRequest("POST", recordsPath, strings.NewReader(`{"name":"www","type":"A","content":"192.0.2.1"}`))
+	w := httptest.NewRecorder()
+	handler.CreateRecord(w, withTenant(req, testTenantID))
+	if w.Code != http.StatusLocked {
+		t.Errorf("Expected 423 for a frozen zone, got %d", w.Code)
+	}
+}
+
+func TestBreakGlassKey(t *testing.T) {
+	mockRepo := &testutil.MockRepo{}
+	rawKey := "cdns_breakglass"
+	hash := sha256.Sum256([]byte(rawKey))
+	expires := time.Now().Add(time.Hour)
+	mockRepo.On("GetAPIKeyByHash", hex.EncodeToString(hash[:])).Return(&domain.APIKey{
+		TenantID: "t1", Role: domain.RoleBreakGlass, Active: true, ExpiresAt: &expires,
+	}, nil)
+
+	var breakGlass bool
+	handler := AuthMiddleware(mockRepo)(RequireRole(domain.RoleAdmin, domain.RoleBreakGlass)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		breakGlass = domain.IsBreakGlass(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	req := httptest.NewRequest("POST", "/zones", nil)
+	req.Header.Set("Authorization", "Bearer "+rawKey)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !breakGlass {
+		t.Errorf("Expected a break-glass key to pass admin checks and bypass freezes, got %d (break-glass %v)", rr.Code, breakGlass)
+	}
+}
@@ -2,8 +2,12 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/poyrazK/cloudDNS/internal/core/domain"
 	"github.com/poyrazK/cloudDNS/internal/core/ports"
@@ -12,8 +16,47 @@ import (
 
 // APIHandler handles HTTP requests for zone and record management.
 type APIHandler struct {
-	svc  ports.DNSService
-	repo ports.DNSRepository
+	svc       ports.DNSService
+	repo      ports.DNSRepository
+	readiness ports.ReadinessChecker
+	events    ports.EventStream
+	nodes     ports.NodeLister
+	rollouts  ports.RolloutOrchestrator
+	probes    ports.ProbeReporter
+
+	consistency ports.ConsistencyChecker
+	usage       ports.UsageReporter
+	queryStats  ports.QueryStatsReporter
+	clientStats ports.ClientStatsReporter
+	transports  ports.TransportPostureReporter
+	tracer      ports.QueryTracer
+	dnssecKeys  ports.DNSSECKeyManager
+	transfers   ports.ZoneTransferTrigger
+	secondaries ports.SecondaryManager
+	schedules   ports.ScheduledChangeManager
+	freezes     ports.ChangeFreezeManager
+	reviews     ports.ChangeReviewManager
+	shadows     ports.ShadowChangeManager
+	slos        ports.SLOManager
+	limits      ports.QueryLimitManager
+	views       ports.ViewManager
+	defaults    ports.DefaultRecordManager
+	targets     ports.TargetManager
+	checks      ports.HealthCheckManager
+	ipam        ports.IPAMManager
+	propagation ports.PropagationChecker
+	configAudit ports.ConfigAuditor
+	delegations ports.DelegationSyncer
+	maintenance ports.NodeMaintainer
+	apiUsage    ports.APIUsageTracker
+	authLockout ports.AuthLockout
+	authorizer  ports.Authorizer
+
+	// authorizeReads puts read requests to the authorizer too.
+	authorizeReads bool
+
+	// separateAdmin keeps the routes of RegisterAdminRoutes off the main mux.
+	separateAdmin bool
 }
 
 // NewAPIHandler creates and returns a new APIHandler instance.
@@ -21,24 +64,326 @@ func NewAPIHandler(svc ports.DNSService, repo ports.DNSRepository) *APIHandler {
 	return &APIHandler{svc: svc, repo: repo}
 }
 
+// SetReadiness configures the checker backing the /readyz endpoint.
+func (h *APIHandler) SetReadiness(r ports.ReadinessChecker) {
+	h.readiness = r
+}
+
+// SetEvents configures the stream backing the /events endpoint.
+func (h *APIHandler) SetEvents(e ports.EventStream) {
+	h.events = e
+}
+
+// SetNodes configures the registry backing the /nodes endpoint.
+func (h *APIHandler) SetNodes(n ports.NodeLister) {
+	h.nodes = n
+}
+
+// SetRollouts configures the orchestrator backing the /rollouts endpoints.
+func (h *APIHandler) SetRollouts(o ports.RolloutOrchestrator) {
+	h.rollouts = o
+}
+
+// SetProbes configures the synthetic prober backing the /probes endpoint.
+func (h *APIHandler) SetProbes(p ports.ProbeReporter) {
+	h.probes = p
+}
+
+// SetConsistency configures the checker backing the /consistency endpoints.
+func (h *APIHandler) SetConsistency(c ports.ConsistencyChecker) {
+	h.consistency = c
+}
+
+// SetUsage configures the aggregator backing the usage endpoints.
+func (h *APIHandler) SetUsage(u ports.UsageReporter) {
+	h.usage = u
+}
+
+// SetQueryStats configures the analytics backing the /stats/queries endpoint.
+func (h *APIHandler) SetQueryStats(q ports.QueryStatsReporter) {
+	h.queryStats = q
+}
+
+// SetClientStats configures the statistics backing the /stats/clients endpoint.
+func (h *APIHandler) SetClientStats(c ports.ClientStatsReporter) {
+	h.clientStats = c
+}
+
+// SetTransports configures the reporter backing the /transports endpoint.
+func (h *APIHandler) SetTransports(t ports.TransportPostureReporter) {
+	h.transports = t
+}
+
+// SetQueryTracer configures the tracer backing the /resolve endpoint.
+func (h *APIHandler) SetQueryTracer(t ports.QueryTracer) {
+	h.tracer = t
+}
+
+// SetDNSSECKeys configures the key manager backing the /zones/{id}/dnssec endpoints.
+func (h *APIHandler) SetDNSSECKeys(k ports.DNSSECKeyManager) {
+	h.dnssecKeys = k
+}
+
+// SetTransfers configures the trigger backing the /zones/{id}/transfer and
+// /zones/{id}/notify endpoints.
+func (h *APIHandler) SetTransfers(t ports.ZoneTransferTrigger) {
+	h.transfers = t
+}
+
+// SetSecondaries configures the manager backing the /zones/{id}/secondaries endpoints.
+func (h *APIHandler) SetSecondaries(s ports.SecondaryManager) {
+	h.secondaries = s
+}
+
+// SetSchedules configures the manager backing the /zones/{id}/scheduled-changes endpoints.
+func (h *APIHandler) SetSchedules(s ports.ScheduledChangeManager) {
+	h.schedules = s
+}
+
+// SetShadows configures the manager backing the /zones/{id}/shadow endpoints.
+func (h *APIHandler) SetShadows(s ports.ShadowChangeManager) {
+	h.shadows = s
+}
+
+// SetSLOs configures the manager backing the /zones/{id}/slo endpoints.
+func (h *APIHandler) SetSLOs(s ports.SLOManager) {
+	h.slos = s
+}
+
+// SetQueryLimits configures the manager backing the /query-limits endpoint.
+func (h *APIHandler) SetQueryLimits(l ports.QueryLimitManager) {
+	h.limits = l
+}
+
+// SetViews configures the manager backing the /views endpoints.
+func (h *APIHandler) SetViews(v ports.ViewManager) {
+	h.views = v
+}
+
+// SetDefaultRecords configures the manager backing the /zones/{id}/default-records endpoints.
+func (h *APIHandler) SetDefaultRecords(d ports.DefaultRecordManager) {
+	h.defaults = d
+}
+
+// SetTargets configures the manager backing the /targets endpoints.
+func (h *APIHandler) SetTargets(t ports.TargetManager) {
+	h.targets = t
+}
+
+// SetHealthChecks configures the manager backing the /healthchecks endpoints.
+func (h *APIHandler) SetHealthChecks(c ports.HealthCheckManager) {
+	h.checks = c
+}
+
+// SetIPAM configures the manager backing the /subnets endpoints.
+func (h *APIHandler) SetIPAM(m ports.IPAMManager) {
+	h.ipam = m
+}
+
+// SetFreezes configures the manager backing the /freezes endpoints.
+func (h *APIHandler) SetFreezes(f ports.ChangeFreezeManager) {
+	h.freezes = f
+}
+
+// SetReviews configures the manager backing the /zones/{id}/review and
+// /zones/{id}/change-requests endpoints. Changes to zones under review are
+// submitted to it as change requests.
+func (h *APIHandler) SetReviews(m ports.ChangeReviewManager) {
+	h.reviews = m
+}
+
+// SetPropagation configures the checker backing the record propagation endpoint.
+func (h *APIHandler) SetPropagation(p ports.PropagationChecker) {
+	h.propagation = p
+}
+
+// SetConfigAudit configures the auditor backing the /config-audit endpoints.
+func (h *APIHandler) SetConfigAudit(a ports.ConfigAuditor) {
+	h.configAudit = a
+}
+
+// SetDelegations configures the syncer backing the /zones/{id}/delegation endpoints.
+func (h *APIHandler) SetDelegations(d ports.DelegationSyncer) {
+	h.delegations = d
+}
+
+// SetMaintenance configures the node backing the /node/cache/flush and
+// /node/drain endpoints.
+func (h *APIHandler) SetMaintenance(m ports.NodeMaintainer) {
+	h.maintenance = m
+}
+
+// SetAPIUsage configures the tracker fed by authenticated requests and backing
+// the /api-keys/usage endpoint.
+func (h *APIHandler) SetAPIUsage(u ports.APIUsageTracker) {
+	h.apiUsage = u
+}
+
+// SetAuthLockout configures the bans of clients presenting invalid API keys and
+// the /auth/bans endpoints.
+func (h *APIHandler) SetAuthLockout(l ports.AuthLockout) {
+	h.authLockout = l
+}
+
+// SetAuthorizer puts authenticated requests that change something, and reads
+// as well when reads is set, to an external authorizer after the API key is
+// checked.
+func (h *APIHandler) SetAuthorizer(a ports.Authorizer, reads bool) {
+	h.authorizer = a
+	h.authorizeReads = reads
+}
+
+// SetSeparateAdmin moves the destructive operations of RegisterAdminRoutes off
+// the main API, so they are only reachable through the admin listener.
+func (h *APIHandler) SetSeparateAdmin(separate bool) {
+	h.separateAdmin = separate
+}
+
 // RegisterRoutes registers the API routes with the provided ServeMux.
 func (h *APIHandler) RegisterRoutes(mux *http.ServeMux) {
 	// Public Routes
 	mux.HandleFunc("GET /health", h.HealthCheck)
+	mux.HandleFunc("GET /livez", h.Livez)
+	mux.HandleFunc("GET /readyz", h.Readyz)
 	mux.HandleFunc("GET /metrics", h.Metrics)
+	mux.Handle("GET /ui/", UIHandler())
+	mux.Handle("GET /ui", http.RedirectHandler("/ui/", http.StatusMovedPermanently))
 
 	// Middleware
-	auth := AuthMiddleware(h.repo)
-	admin := RequireRole(domain.RoleAdmin)
+	auth := h.authMiddleware()
+	admin := RequireRole(domain.RoleAdmin, domain.RoleBreakGlass)
+	approver := RequireRole(domain.RoleApprover)
 
 	// Protected Routes (scoped by tenant_id from auth key)
 	mux.Handle("POST /zones", auth(admin(http.HandlerFunc(h.CreateZone))))
 	mux.Handle("GET /zones", auth(http.HandlerFunc(h.ListZones)))
+	mux.Handle("POST /zones/import", auth(admin(http.HandlerFunc(h.ImportZone))))
 	mux.Handle("GET /zones/{id}/records", auth(http.HandlerFunc(h.ListRecordsForZone)))
+	mux.Handle("GET /zones/{id}/summary", auth(http.HandlerFunc(h.GetZoneSummary)))
+	mux.Handle("GET /zones/{id}/diff", auth(http.HandlerFunc(h.DiffZone)))
+	mux.Handle("GET /zones/{id}/changes", auth(http.HandlerFunc(h.ListZoneChanges)))
+	mux.Handle("PUT /zones/{id}/dnssec", auth(admin(http.HandlerFunc(h.SetZoneDNSSEC))))
+	mux.Handle("PUT /zones/{id}/role", auth(admin(http.HandlerFunc(h.SetZoneRole))))
+	mux.Handle("POST /zones/{id}/clone", auth(admin(http.HandlerFunc(h.CloneZone))))
+	mux.Handle("GET /zones/{id}/dnssec/keys", auth(http.HandlerFunc(h.ListZoneKeys)))
+	mux.Handle("GET /zones/{id}/dnssec/ds", auth(http.HandlerFunc(h.GetZoneDS)))
+	mux.Handle("GET /zones/{id}/dnssec/signer-keys", auth(http.HandlerFunc(h.ListSignerKeys)))
+	mux.Handle("POST /zones/{id}/transfer", auth(admin(http.HandlerFunc(h.TransferZone))))
+	mux.Handle("POST /zones/{id}/notify", auth(admin(http.HandlerFunc(h.NotifyZone))))
+	mux.Handle("GET /zones/{id}/secondaries", auth(http.HandlerFunc(h.ListSecondaries)))
+	mux.Handle("POST /zones/{id}/secondaries", auth(admin(http.HandlerFunc(h.AddSecondary))))
+	mux.Handle("DELETE /zones/{id}/secondaries/{secondary_id}", auth(admin(http.HandlerFunc(h.DeleteSecondary))))
+	mux.Handle("GET /zones/{id}/scheduled-changes", auth(http.HandlerFunc(h.ListScheduledChanges)))
+	mux.Handle("POST /zones/{id}/scheduled-changes", auth(admin(http.HandlerFunc(h.ScheduleChange))))
+	mux.Handle("DELETE /zones/{id}/scheduled-changes/{change_id}", auth(admin(http.HandlerFunc(h.CancelScheduledChange))))
+	mux.Handle("GET /zones/{id}/review", auth(http.HandlerFunc(h.GetZoneReview)))
+	mux.Handle("PUT /zones/{id}/review", auth(RequireRole(domain.RoleAdmin, domain.RoleBreakGlass, domain.RoleApprover)(http.HandlerFunc(h.EnableZoneReview))))
+	mux.Handle("DELETE /zones/{id}/review", auth(approver(http.HandlerFunc(h.DisableZoneReview))))
+	mux.Handle("GET /zones/{id}/change-requests", auth(http.HandlerFunc(h.ListChangeRequests)))
+	mux.Handle("POST /zones/{id}/change-requests/{request_id}/approve", auth(approver(http.HandlerFunc(h.ApproveChangeRequest))))
+	mux.Handle("POST /zones/{id}/change-requests/{request_id}/reject", auth(approver(http.HandlerFunc(h.RejectChangeRequest))))
+	mux.Handle("GET /zones/{id}/shadow", auth(http.HandlerFunc(h.GetShadowChange)))
+	mux.Handle("PUT /zones/{id}/shadow", auth(admin(http.HandlerFunc(h.StageShadowChange))))
+	mux.Handle("DELETE /zones/{id}/shadow", auth(admin(http.HandlerFunc(h.DiscardShadowChange))))
+	mux.Handle("POST /zones/{id}/shadow/commit", auth(admin(http.HandlerFunc(h.CommitShadowChange))))
+	mux.Handle("GET /zones/{id}/slo", auth(http.HandlerFunc(h.GetZoneSLO)))
+	mux.Handle("PUT /zones/{id}/slo", auth(admin(http.HandlerFunc(h.SetZoneSLO))))
+	mux.Handle("DELETE /zones/{id}/slo", auth(admin(http.HandlerFunc(h.DeleteZoneSLO))))
+	mux.Handle("GET /targets", auth(http.HandlerFunc(h.ListTargets)))
+	mux.Handle("POST /targets", auth(admin(http.HandlerFunc(h.CreateTarget))))
+	mux.Handle("PUT /targets/{id}", auth(admin(http.HandlerFunc(h.UpdateTarget))))
+	mux.Handle("DELETE /targets/{id}", auth(admin(http.HandlerFunc(h.DeleteTarget))))
+	mux.Handle("GET /healthchecks", auth(http.HandlerFunc(h.ListHealthChecks)))
+	mux.Handle("POST /healthchecks", auth(admin(http.HandlerFunc(h.CreateHealthCheck))))
+	mux.Handle("PUT /healthchecks/{id}", auth(admin(http.HandlerFunc(h.UpdateHealthCheck))))
+	mux.Handle("DELETE /healthchecks/{id}", auth(admin(http.HandlerFunc(h.DeleteHealthCheck))))
+	mux.Handle("GET /subnets", auth(http.HandlerFunc(h.ListSubnets)))
+	mux.Handle("POST /subnets", auth(admin(http.HandlerFunc(h.CreateSubnet))))
+	mux.Handle("DELETE /subnets/{id}", auth(admin(http.HandlerFunc(h.DeleteSubnet))))
+	mux.Handle("GET /subnets/{id}/allocations", auth(http.HandlerFunc(h.ListAllocations)))
+	mux.Handle("POST /subnets/{id}/allocations", auth(admin(http.HandlerFunc(h.AllocateAddress))))
+	mux.Handle("DELETE /subnets/{id}/allocations/{allocation_id}", auth(admin(http.HandlerFunc(h.ReleaseAddress))))
+	mux.Handle("GET /zones/{id}/default-records", auth(http.HandlerFunc(h.ListDefaultRecords)))
+	mux.Handle("POST /zones/{id}/default-records", auth(admin(http.HandlerFunc(h.CreateDefaultRecord))))
+	mux.Handle("DELETE /zones/{id}/default-records/{default_id}", auth(admin(http.HandlerFunc(h.DeleteDefaultRecord))))
+	mux.Handle("GET /query-limits", auth(http.HandlerFunc(h.ListQueryLimits)))
+	mux.Handle("GET /views", auth(http.HandlerFunc(h.ListViews)))
+	mux.Handle("POST /views", auth(admin(http.HandlerFunc(h.CreateView))))
+	mux.Handle("PUT /views/{id}", auth(admin(http.HandlerFunc(h.UpdateView))))
+	mux.Handle("DELETE /views/{id}", auth(admin(http.HandlerFunc(h.DeleteView))))
+	mux.Handle("GET /zones/{id}/usage", auth(http.HandlerFunc(h.GetZoneUsage)))
+	mux.Handle("GET /usage/export", auth(http.HandlerFunc(h.ExportUsage)))
+	mux.Handle("GET /usage/summary", auth(http.HandlerFunc(h.GetUsageSummary)))
 	mux.Handle("DELETE /zones/{id}", auth(admin(http.HandlerFunc(h.DeleteZone))))
 	mux.Handle("POST /zones/{id}/records", auth(admin(http.HandlerFunc(h.CreateRecord))))
+	mux.Handle("POST /zones/{id}/records/import", auth(admin(http.HandlerFunc(h.ImportRecords))))
+	mux.Handle("GET /zones/{id}/rrsets/{name}/{type}", auth(http.HandlerFunc(h.GetRRset)))
+	mux.Handle("PUT /zones/{id}/rrsets/{name}/{type}", auth(admin(http.HandlerFunc(h.ReplaceRRset))))
 	mux.Handle("DELETE /zones/{zone_id}/records/{id}", auth(admin(http.HandlerFunc(h.DeleteRecord))))
+	mux.Handle("POST /zones/{id}/records/{rid}/check", auth(http.HandlerFunc(h.CheckRecordPropagation)))
+	mux.Handle("PUT /zones/{id}/records/{rid}/protection", auth(admin(http.HandlerFunc(h.SetRecordProtection))))
+	mux.Handle("GET /zones/{id}/delegation", auth(http.HandlerFunc(h.GetDelegation)))
+	mux.Handle("POST /zones/{id}/delegation/sync", auth(admin(http.HandlerFunc(h.SyncDelegation))))
 	mux.Handle("GET /audit-logs", auth(http.HandlerFunc(h.ListAuditLogs)))
+	mux.Handle("GET /api-keys/usage", auth(admin(http.HandlerFunc(h.GetAPIKeyUsage))))
+	mux.Handle("GET /freezes", auth(http.HandlerFunc(h.ListChangeFreezes)))
+	mux.Handle("POST /freezes", auth(admin(http.HandlerFunc(h.CreateChangeFreeze))))
+	mux.Handle("DELETE /freezes/{id}", auth(admin(http.HandlerFunc(h.DeleteChangeFreeze))))
+	mux.Handle("GET /config-audit", auth(http.HandlerFunc(h.GetConfigAudit)))
+	mux.Handle("POST /config-audit", auth(admin(http.HandlerFunc(h.RunConfigAudit))))
+	mux.Handle("GET /events", auth(http.HandlerFunc(h.StreamEvents)))
+	mux.Handle("GET /nodes", auth(admin(http.HandlerFunc(h.ListNodes))))
+	mux.Handle("GET /probes", auth(admin(http.HandlerFunc(h.ListProbes))))
+	mux.Handle("GET /transports", auth(admin(http.HandlerFunc(h.ListTransports))))
+	mux.Handle("GET /stats/queries", auth(admin(http.HandlerFunc(h.GetQueryStats))))
+	mux.Handle("GET /resolve", auth(admin(http.HandlerFunc(h.TraceQuery))))
+	mux.Handle("GET /stats/clients", auth(admin(http.HandlerFunc(h.GetClientStats))))
+	mux.Handle("GET /consistency", auth(admin(http.HandlerFunc(h.GetConsistencyReport))))
+	mux.Handle("POST /consistency", auth(admin(http.HandlerFunc(h.RunConsistencyCheck))))
+	mux.Handle("POST /rollouts", auth(admin(http.HandlerFunc(h.StartRollout))))
+	mux.Handle("GET /rollouts", auth(admin(http.HandlerFunc(h.ListRollouts))))
+	mux.Handle("GET /rollouts/{id}", auth(admin(http.HandlerFunc(h.GetRollout))))
+	mux.Handle("POST /rollouts/{id}/abort", auth(admin(http.HandlerFunc(h.AbortRollout))))
+
+	if !h.separateAdmin {
+		h.RegisterAdminRoutes(mux)
+	}
+}
+
+// RegisterAdminRoutes registers the destructive operations: DNSSEC key
+// management, cache flushes, node drains and authentication bans. They still require an admin API key
+// wherever they are served.
+func (h *APIHandler) RegisterAdminRoutes(mux *http.ServeMux) {
+	auth := h.authMiddleware()
+	admin := RequireRole(domain.RoleAdmin, domain.RoleBreakGlass)
+
+	mux.Handle("POST /zones/{id}/dnssec/rollover", auth(admin(http.HandlerFunc(h.RolloverKey))))
+	mux.Handle("PUT /zones/{id}/dnssec/keys/{key_id}", auth(admin(http.HandlerFunc(h.SetKeyActive))))
+	mux.Handle("POST /zones/{id}/dnssec/keys/import", auth(admin(http.HandlerFunc(h.ImportKey))))
+	mux.Handle("GET /zones/{id}/dnssec/keys/{key_id}/export", auth(admin(http.HandlerFunc(h.ExportKey))))
+	mux.Handle("POST /zones/{id}/dnssec/signer-keys", auth(admin(http.HandlerFunc(h.ImportSignerKey))))
+	mux.Handle("POST /node/cache/flush", auth(admin(http.HandlerFunc(h.FlushCache))))
+	mux.Handle("POST /node/drain", auth(admin(http.HandlerFunc(h.DrainNode))))
+	mux.Handle("GET /auth/bans", auth(admin(http.HandlerFunc(h.ListAuthBans))))
+	mux.Handle("DELETE /auth/bans/{ip}", auth(admin(http.HandlerFunc(h.ClearAuthBan))))
+}
+
+// authMiddleware returns the API key authentication, followed by the external
+// authorizer, reporting to the usage tracker and turning away banned clients
+// when those are configured.
+func (h *APIHandler) authMiddleware() func(http.Handler) http.Handler {
+	auth := AuthMiddleware(h.repo)
+	if h.authorizer != nil {
+		authenticate, authorize := auth, AuthorizeMiddleware(h.authorizer, h.authorizeReads)
+		auth = func(next http.Handler) http.Handler { return authenticate(authorize(next)) }
+	}
+	if h.apiUsage != nil {
+		auth = UsageMiddleware(auth, h.apiUsage)
+	}
+	if h.authLockout != nil {
+		auth = LockoutMiddleware(auth, h.authLockout)
+	}
+	return auth
 }
 
 // Metrics handles Prometheus metrics scraping requests.
@@ -79,6 +424,45 @@ func (h *APIHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// Livez reports that the process is alive. It never touches dependencies so that a
+// database outage does not get the process restarted.
+func (h *APIHandler) Livez(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "UP"}); err != nil {
+		log.Printf("failed to encode liveness response: %v", err)
+	}
+}
+
+// Readyz reports whether the node is ready to receive traffic, with per-dependency
+// status and latency.
+func (h *APIHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	var report *domain.ReadinessReport
+	if h.readiness != nil {
+		report = h.readiness.Readiness(r.Context())
+	} else {
+		report = &domain.ReadinessReport{Ready: true}
+		for name, checkErr := range h.svc.HealthCheck(r.Context()) {
+			status := domain.DependencyStatus{Name: name, Healthy: checkErr == nil}
+			if checkErr != nil {
+				status.Error = checkErr.Error()
+				report.Ready = false
+			}
+			report.Checks = append(report.Checks, status)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if report.Ready {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("failed to encode readiness response: %v", err)
+	}
+}
+
 // ListAuditLogs retrieves audit entries for a specific tenant via the management API.
 func (h *APIHandler) ListAuditLogs(w http.ResponseWriter, r *http.Request) {
 	tenantID, ok := r.Context().Value(CtxTenantID).(string)
@@ -130,7 +514,7 @@ func (h *APIHandler) CreateZone(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.svc.CreateZone(r.Context(), &zone); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeMutationError(w, err)
 		return
 	}
 
@@ -183,6 +567,271 @@ func (h *APIHandler) ListRecordsForZone(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// ListNodes returns the fleet status reported by every node's heartbeat.
+func (h *APIHandler) ListNodes(w http.ResponseWriter, r *http.Request) {
+	if h.nodes == nil {
+		http.Error(w, "Node registry is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	nodes, err := h.nodes.ListNodes(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if nodes == nil {
+		nodes = []domain.Node{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(nodes); err != nil {
+		log.Printf("failed to encode nodes response: %v", err)
+	}
+}
+
+// ListProbes returns the latest synthetic monitoring result for every zone apex.
+func (h *APIHandler) ListProbes(w http.ResponseWriter, r *http.Request) {
+	if h.probes == nil {
+		http.Error(w, "Synthetic probes are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.probes.ProbeResults()); err != nil {
+		log.Printf("failed to encode probes response: %v", err)
+	}
+}
+
+// ListTransports returns the padding, TLS and early data posture of each encrypted
+// DNS transport.
+func (h *APIHandler) ListTransports(w http.ResponseWriter, r *http.Request) {
+	if h.transports == nil {
+		http.Error(w, "Transport posture is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.transports.TransportPosture()); err != nil {
+		log.Printf("failed to encode transports response: %v", err)
+	}
+}
+
+// GetQueryStats returns estimated unique clients, unique names and per-zone hot
+// names for the current analytics window. The optional zone parameter selects one
+// zone and limit caps the number of zones returned.
+func (h *APIHandler) GetQueryStats(w http.ResponseWriter, r *http.Request) {
+	if h.queryStats == nil {
+		http.Error(w, "Query analytics are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.queryStats.QueryStats(r.URL.Query().Get("zone"), limit)); err != nil {
+		log.Printf("failed to encode query stats response: %v", err)
+	}
+}
+
+// GetClientStats returns the EDNS, buffer size, DNSSEC, cookie and transport
+// capabilities of queries in the current window, in total and per source
+// network. The optional limit caps the number of networks returned.
+func (h *APIHandler) GetClientStats(w http.ResponseWriter, r *http.Request) {
+	if h.clientStats == nil {
+		http.Error(w, "Client statistics are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.clientStats.ClientStats(limit)); err != nil {
+		log.Printf("failed to encode client stats response: %v", err)
+	}
+}
+
+// GetZoneSummary returns record counts, serial, DNSSEC and transfer status and size
+// estimates for a zone in a single response. Transfer status covers the last
+// refresh of a secondary zone and the serials of its external secondaries.
+func (h *APIHandler) GetZoneSummary(w http.ResponseWriter, r *http.Request) {
+	zoneID := r.PathValue("id")
+
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("GetZoneSummary: missing or invalid tenant ID in context")
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return
+	}
+
+	summary, err := h.svc.GetZoneSummary(r.Context(), zoneID, tenantID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if summary == nil {
+		http.Error(w, "Zone not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(summary); err != nil {
+		log.Printf("failed to encode zone summary response: %v", err)
+	}
+}
+
+// SetZoneDNSSEC opts a zone in or out of DNSSEC signing. Disabling takes the zone
+// insecure and signals the parent to remove its DS records.
+func (h *APIHandler) SetZoneDNSSEC(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Enabled == nil {
+		http.Error(w, "Invalid request: enabled is required", http.StatusBadRequest)
+		return
+	}
+
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("SetZoneDNSSEC: missing or invalid tenant ID in context")
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return
+	}
+
+	zone, err := h.svc.SetZoneDNSSEC(r.Context(), r.PathValue("id"), tenantID, *req.Enabled)
+	if err != nil {
+		writeMutationError(w, err)
+		return
+	}
+	if zone == nil {
+		http.Error(w, "Zone not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(zone); err != nil {
+		log.Printf("failed to encode zone response: %v", err)
+	}
+}
+
+// SetZoneRole makes a zone a primary or a secondary; the body is {"role",
+// "master_server"} with role "master" or "slave". master_server, required for a
+// secondary, lists the masters to transfer from, separated by commas.
+func (h *APIHandler) SetZoneRole(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Role         string `json:"role"`
+		MasterServer string `json:"master_server"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Role == "" {
+		http.Error(w, "Invalid request: role is required", http.StatusBadRequest)
+		return
+	}
+	if err := domain.ValidateZoneRole(req.Role, req.MasterServer); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("SetZoneRole: missing or invalid tenant ID in context")
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return
+	}
+
+	zone, err := h.svc.SetZoneRole(r.Context(), r.PathValue("id"), tenantID, req.Role, req.MasterServer)
+	if err != nil {
+		writeMutationError(w, err)
+		return
+	}
+	if zone == nil {
+		http.Error(w, "Zone not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(zone); err != nil {
+		log.Printf("failed to encode zone response: %v", err)
+	}
+}
+
+// CloneZone copies a zone and its records into a new zone; the body is
+// {"name", "copy_dnssec", "target_api_key"}. name renames the origin of the copy.
+// target_api_key, an admin key of another tenant, hands the copy to that tenant.
+func (h *APIHandler) CloneZone(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name         string `json:"name"`
+		CopyDNSSEC   bool   `json:"copy_dnssec"`
+		TargetAPIKey string `json:"target_api_key"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Name != "" {
+		if err := domain.ValidateZoneName(req.Name); err != nil {
+			http.Error(w, "Invalid zone name: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("CloneZone: missing or invalid tenant ID in context")
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return
+	}
+
+	opts := domain.ZoneCloneOptions{Name: req.Name, CopyDNSSEC: req.CopyDNSSEC}
+	if req.TargetAPIKey != "" {
+		target, err := h.repo.GetAPIKeyByHash(r.Context(), hashAPIKey(strings.TrimSpace(req.TargetAPIKey)))
+		if err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if target == nil || !target.Active || target.Role != domain.RoleAdmin ||
+			(target.ExpiresAt != nil && target.ExpiresAt.Before(time.Now())) {
+			http.Error(w, "Forbidden: target_api_key must be an active admin key", http.StatusForbidden)
+			return
+		}
+		opts.TargetTenantID = target.TenantID
+	}
+
+	zone, err := h.svc.CloneZone(r.Context(), r.PathValue("id"), tenantID, opts)
+	if err != nil {
+		writeMutationError(w, err)
+		return
+	}
+	if zone == nil {
+		http.Error(w, "Zone not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(zone); err != nil {
+		log.Printf("failed to encode zone response: %v", err)
+	}
+}
+
 func (h *APIHandler) CreateRecord(w http.ResponseWriter, r *http.Request) {
 	zoneID := r.PathValue("id")
 	var record domain.Record
@@ -197,6 +846,28 @@ func (h *APIHandler) CreateRecord(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
+	if record.Type == domain.TypeSVCB || record.Type == domain.TypeHTTPS {
+		if err := domain.ValidateSVCBFields(record.Priority, record.Content); err != nil {
+			http.Error(w, "Invalid "+string(record.Type)+" record: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if record.Type == domain.TypeCAA {
+		if err := domain.ValidateCAAContent(record.Content); err != nil {
+			http.Error(w, "Invalid CAA record: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if record.Type == domain.TypeALIAS {
+		if err := domain.ValidateALIASContent(record.Content); err != nil {
+			http.Error(w, "Invalid ALIAS record: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if err := domain.ValidateRDATAContent(record.Type, record.Content); err != nil {
+		http.Error(w, "Invalid "+string(record.Type)+" record: "+err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	record.ZoneID = zoneID
 
@@ -209,7 +880,9 @@ func (h *APIHandler) CreateRecord(w http.ResponseWriter, r *http.Request) {
 	record.TenantID = tenantID
 
 	if err := h.svc.CreateRecord(r.Context(), &record); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		if !h.submitForReview(w, r, zoneID, tenantID, err, &domain.ChangeRequest{Action: domain.ChangeCreateRecord, Record: &record}) {
+			writeMutationError(w, err)
+		}
 		return
 	}
 
@@ -230,7 +903,7 @@ func (h *APIHandler) DeleteZone(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.svc.DeleteZone(r.Context(), id, tenantID); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeMutationError(w, err)
 		return
 	}
 
@@ -248,10 +921,55 @@ func (h *APIHandler) DeleteRecord(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.svc.DeleteRecord(r.Context(), id, zoneID, tenantID); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	ctx := r.Context()
+	if r.URL.Query().Get("force") == "true" {
+		ctx = domain.WithForce(ctx)
+	}
+	if err := h.svc.DeleteRecord(ctx, id, zoneID, tenantID); err != nil {
+		change := &domain.ChangeRequest{Action: domain.ChangeDeleteRecord, RecordID: id, Force: domain.IsForced(ctx)}
+		if !h.submitForReview(w, r, zoneID, tenantID, err, change) {
+			writeMutationError(w, err)
+		}
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// SetRecordProtection protects a record from deletion, or lifts the protection
+// with a break-glass key.
+func (h *APIHandler) SetRecordProtection(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Protected *bool `json:"protected"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Protected == nil {
+		http.Error(w, "protected is required", http.StatusBadRequest)
+		return
+	}
+
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("SetRecordProtection: missing or invalid tenant ID in context")
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return
+	}
+
+	record, err := h.svc.SetRecordProtection(r.Context(), r.PathValue("rid"), r.PathValue("id"), tenantID, *req.Protected)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		writeMutationError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(record); err != nil {
+		log.Printf("failed to encode record response: %v", err)
+	}
+}
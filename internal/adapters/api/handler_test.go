@@ -5,10 +5,13 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/poyrazK/cloudDNS/internal/core/domain"
 	"github.com/poyrazK/cloudDNS/internal/testutil"
@@ -64,6 +67,130 @@ func (m *mockDNSService) ListRecordsForZone(_ context.Context, _, _ string) ([]d
 	return m.records, nil
 }
 
+func (m *mockDNSService) GetZoneSummary(_ context.Context, zoneID, _ string) (*domain.ZoneSummary, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	for _, z := range m.zones {
+		if z.ID == zoneID {
+			return &domain.ZoneSummary{ZoneID: z.ID, ZoneName: z.Name, TotalRecords: len(m.records)}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockDNSService) SetZoneDNSSEC(_ context.Context, zoneID, _ string, enabled bool) (*domain.Zone, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	for i := range m.zones {
+		if m.zones[i].ID == zoneID {
+			m.zones[i].DNSSECEnabled = &enabled
+			return &m.zones[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockDNSService) SetZoneRole(_ context.Context, zoneID, _ string, role, masterServer string) (*domain.Zone, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	for i := range m.zones {
+		if m.zones[i].ID == zoneID {
+			m.zones[i].Role = role
+			m.zones[i].MasterServer = masterServer
+			return &m.zones[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockDNSService) CloneZone(_ context.Context, zoneID, tenantID string, opts domain.ZoneCloneOptions) (*domain.Zone, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	for _, z := range m.zones {
+		if z.ID == zoneID {
+			clone := domain.Zone{ID: "zone-clone", TenantID: tenantID, Name: z.Name}
+			if opts.Name != "" {
+				clone.Name = opts.Name
+			}
+			if opts.TargetTenantID != "" {
+				clone.TenantID = opts.TargetTenantID
+			}
+			return &clone, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockDNSService) GetRRset(_ context.Context, zoneID, _ string, name string, qType domain.RecordType) (*domain.RRset, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	rrset := &domain.RRset{ZoneID: zoneID, Name: name, Type: qType}
+	for _, r := range m.records {
+		if r.ZoneID == zoneID && r.Name == name && r.Type == qType {
+			rrset.TTL = r.TTL
+			rrset.Records = append(rrset.Records, domain.RRsetRecord{Content: r.Content})
+		}
+	}
+	if len(rrset.Records) == 0 {
+		return nil, domain.ErrNotFound
+	}
+	return rrset, nil
+}
+
+func (m *mockDNSService) ReplaceRRset(_ context.Context, zoneID, _ string, rrset *domain.RRset) (*domain.RRset, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	if rrset.Type == domain.TypeSOA {
+		return nil, domain.ErrInvalidRRset
+	}
+	var next []domain.Record
+	for _, r := range m.records {
+		if r.ZoneID != zoneID || r.Name != rrset.Name || r.Type != rrset.Type {
+			next = append(next, r)
+		}
+	}
+	for _, rr := range rrset.Records {
+		next = append(next, domain.Record{ZoneID: zoneID, Name: rrset.Name, Type: rrset.Type, TTL: rrset.TTL, Content: rr.Content})
+	}
+	m.records = next
+	rrset.ZoneID = zoneID
+	return rrset, nil
+}
+
+func (m *mockDNSService) DiffZone(_ context.Context, zoneID, _ string, against string) (*domain.ZoneDiff, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	for _, z := range m.zones {
+		if z.ID == zoneID {
+			diff := &domain.ZoneDiff{ZoneID: z.ID, ZoneName: z.Name, AgainstZoneID: against}
+			for _, r := range m.records {
+				diff.Added = append(diff.Added, domain.RRset{Name: r.Name, Type: r.Type, TTL: r.TTL, Records: []domain.RRsetRecord{{Content: r.Content}}})
+			}
+			return diff, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *mockDNSService) ListZoneChangeFeed(_ context.Context, zoneID, _ string, sinceSerial uint32, _ int) (*domain.ZoneChangeFeed, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	for _, z := range m.zones {
+		if z.ID == zoneID {
+			return &domain.ZoneChangeFeed{ZoneID: z.ID, ZoneName: z.Name, SinceSerial: sinceSerial, NextSerial: sinceSerial}, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
 func (m *mockDNSService) DeleteZone(_ context.Context, _, _ string) error {
 	return m.err
 }
@@ -72,13 +199,33 @@ func (m *mockDNSService) DeleteRecord(_ context.Context, _, _, _ string) error {
 	return m.err
 }
 
-func (m *mockDNSService) ImportZone(_ context.Context, tenantID string, _ io.Reader) (*domain.Zone, error) {
+func (m *mockDNSService) SetRecordProtection(_ context.Context, recordID, zoneID, _ string, protected bool) (*domain.Record, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	for i := range m.records {
+		if m.records[i].ID == recordID && m.records[i].ZoneID == zoneID {
+			m.records[i].Protected = protected
+			return &m.records[i], nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *mockDNSService) ImportZone(_ context.Context, tenantID string, _ io.Reader, _ map[string]string) (*domain.Zone, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
 	return &domain.Zone{ID: "zone-imported", TenantID: tenantID}, nil
 }
 
+func (m *mockDNSService) ImportRecords(_ context.Context, zoneID, _, _ string, _ io.Reader, dryRun bool) (*domain.RecordImportResult, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &domain.RecordImportResult{ZoneID: zoneID, DryRun: dryRun, Created: 1, Rows: []domain.RecordImportRow{{Row: 1, Name: "www.example.com.", Type: domain.TypeA, Status: "created"}}}, nil
+}
+
 func (m *mockDNSService) ListAuditLogs(_ context.Context, tenantID string) ([]domain.AuditLog, error) {
 	if m.err != nil {
 		return nil, m.err
@@ -155,6 +302,448 @@ func TestHealthCheckDegraded(t *testing.T) {
 	}
 }
 
+type stubReadiness struct {
+	report *domain.ReadinessReport
+}
+
+func (s *stubReadiness) Readiness(_ context.Context) *domain.ReadinessReport {
+	return s.report
+}
+
+type stubNodes struct {
+	nodes []domain.Node
+	err   error
+}
+
+func (s *stubNodes) ListNodes(_ context.Context) ([]domain.Node, error) {
+	return s.nodes, s.err
+}
+
+func TestListNodes(t *testing.T) {
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+
+	w := httptest.NewRecorder()
+	handler.ListNodes(w, httptest.NewRequest("GET", "/nodes", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a registry, got %d", w.Code)
+	}
+
+	handler.SetNodes(&stubNodes{nodes: []domain.Node{{ID: "node-a", Status: domain.NodeStatusUp, QPS: 3}}})
+	w = httptest.NewRecorder()
+	handler.ListNodes(w, httptest.NewRequest("GET", "/nodes", nil))
+	var nodes []domain.Node
+	if w.Code != http.StatusOK || json.NewDecoder(w.Body).Decode(&nodes) != nil || len(nodes) != 1 || nodes[0].ID != "node-a" {
+		t.Errorf("Unexpected response %d: %+v", w.Code, nodes)
+	}
+
+	handler.SetNodes(&stubNodes{err: errors.New("db down")})
+	w = httptest.NewRecorder()
+	handler.ListNodes(w, httptest.NewRequest("GET", "/nodes", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf(status500Err, w.Code)
+	}
+}
+
+type stubProbes []domain.ProbeResult
+
+func (s stubProbes) ProbeResults() []domain.ProbeResult { return s }
+
+func TestListProbes(t *testing.T) {
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+
+	w := httptest.NewRecorder()
+	handler.ListProbes(w, httptest.NewRequest("GET", "/probes", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a prober, got %d", w.Code)
+	}
+
+	handler.SetProbes(stubProbes{{ZoneName: "example.com.", Type: domain.TypeSOA, Status: domain.ProbeWrong, Detail: "serial 1, expected 2"}})
+	w = httptest.NewRecorder()
+	handler.ListProbes(w, httptest.NewRequest("GET", "/probes", nil))
+	var results []domain.ProbeResult
+	if w.Code != http.StatusOK || json.NewDecoder(w.Body).Decode(&results) != nil || len(results) != 1 || results[0].Status != domain.ProbeWrong {
+		t.Errorf("Unexpected response %d: %+v", w.Code, results)
+	}
+}
+
+type stubQueryStats struct {
+	zone  string
+	limit int
+}
+
+func (s *stubQueryStats) QueryStats(zone string, limit int) *domain.QueryStats {
+	s.zone, s.limit = zone, limit
+	return &domain.QueryStats{Queries: 42, Zones: []domain.ZoneQueryStats{{Zone: "example.com.", HotNames: []domain.HotName{{Name: "www.example.com.", Count: 40}}}}}
+}
+
+func TestGetQueryStats(t *testing.T) {
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+
+	w := httptest.NewRecorder()
+	handler.GetQueryStats(w, httptest.NewRequest("GET", "/stats/queries", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without analytics, got %d", w.Code)
+	}
+
+	stub := &stubQueryStats{}
+	handler.SetQueryStats(stub)
+	w = httptest.NewRecorder()
+	handler.GetQueryStats(w, httptest.NewRequest("GET", "/stats/queries?zone=example.com.&limit=5", nil))
+	var stats domain.QueryStats
+	if w.Code != http.StatusOK || json.NewDecoder(w.Body).Decode(&stats) != nil || stats.Queries != 42 || stats.Zones[0].HotNames[0].Count != 40 {
+		t.Errorf("Unexpected response %d: %+v", w.Code, stats)
+	}
+	if stub.zone != "example.com." || stub.limit != 5 {
+		t.Errorf("Expected zone and limit passed through, got %q, %d", stub.zone, stub.limit)
+	}
+
+	w = httptest.NewRecorder()
+	handler.GetQueryStats(w, httptest.NewRequest("GET", "/stats/queries?limit=-1", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid limit, got %d", w.Code)
+	}
+}
+
+type stubClientStats struct {
+	limit int
+}
+
+func (s *stubClientStats) ClientStats(limit int) *domain.ClientStats {
+	s.limit = limit
+	return &domain.ClientStats{
+		Total:   domain.ClientCapabilities{Queries: 10, EDNS: 8, UDPSizes: map[string]uint64{"513-1232": 8}},
+		Sources: []domain.ClientSourceStats{{Source: "192.0.2.0/24", ClientCapabilities: domain.ClientCapabilities{Queries: 10}}},
+	}
+}
+
+func TestGetClientStats(t *testing.T) {
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+
+	w := httptest.NewRecorder()
+	handler.GetClientStats(w, httptest.NewRequest("GET", "/stats/clients", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without client statistics, got %d", w.Code)
+	}
+
+	stub := &stubClientStats{}
+	handler.SetClientStats(stub)
+	w = httptest.NewRecorder()
+	handler.GetClientStats(w, httptest.NewRequest("GET", "/stats/clients?limit=5", nil))
+	var stats domain.ClientStats
+	if w.Code != http.StatusOK || json.NewDecoder(w.Body).Decode(&stats) != nil || stats.Total.UDPSizes["513-1232"] != 8 || stats.Sources[0].Queries != 10 {
+		t.Errorf("Unexpected response %d: %+v", w.Code, stats)
+	}
+	if stub.limit != 5 {
+		t.Errorf("Expected limit passed through, got %d", stub.limit)
+	}
+
+	w = httptest.NewRecorder()
+	handler.GetClientStats(w, httptest.NewRequest("GET", "/stats/clients?limit=x", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid limit, got %d", w.Code)
+	}
+}
+
+type stubConsistency struct {
+	latest  *domain.ConsistencyReport
+	queries []domain.ConsistencyQuery
+}
+
+func (s *stubConsistency) Check(_ context.Context, queries []domain.ConsistencyQuery) (*domain.ConsistencyReport, error) {
+	s.queries = queries
+	return &domain.ConsistencyReport{Nodes: 2, Checks: []domain.ConsistencyCheck{{ConsistencyQuery: domain.ConsistencyQuery{Name: "live."}}}}, nil
+}
+
+func (s *stubConsistency) LatestReport() *domain.ConsistencyReport { return s.latest }
+
+func TestConsistency(t *testing.T) {
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+
+	w := httptest.NewRecorder()
+	handler.GetConsistencyReport(w, httptest.NewRequest("GET", "/consistency", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a checker, got %d", w.Code)
+	}
+
+	stub := &stubConsistency{}
+	handler.SetConsistency(stub)
+	w = httptest.NewRecorder()
+	handler.GetConsistencyReport(w, httptest.NewRequest("GET", "/consistency", nil))
+	var report domain.ConsistencyReport
+	if w.Code != http.StatusOK || json.NewDecoder(w.Body).Decode(&report) != nil || report.Nodes != 2 {
+		t.Errorf("Expected a live check before the first scheduled run, got %d %+v", w.Code, report)
+	}
+
+	stub.latest = &domain.ConsistencyReport{Nodes: 5, Divergent: 1}
+	w = httptest.NewRecorder()
+	handler.GetConsistencyReport(w, httptest.NewRequest("GET", "/consistency", nil))
+	if json.NewDecoder(w.Body).Decode(&report) != nil || report.Nodes != 5 {
+		t.Errorf("Expected the scheduled report, got %+v", report)
+	}
+
+	w = httptest.NewRecorder()
+	handler.RunConsistencyCheck(w, httptest.NewRequest("POST", "/consistency", bytes.NewBufferString(`{"queries":[{"name":"www.example.com.","type":"A"}]}`)))
+	if w.Code != http.StatusOK || len(stub.queries) != 1 || stub.queries[0].Type != domain.TypeA {
+		t.Errorf("Expected ad-hoc check with the requested query, got %d %+v", w.Code, stub.queries)
+	}
+
+	w = httptest.NewRecorder()
+	handler.RunConsistencyCheck(w, httptest.NewRequest("POST", "/consistency", nil))
+	if w.Code != http.StatusOK || stub.queries != nil {
+		t.Errorf("Expected empty body to check every zone apex, got %d %+v", w.Code, stub.queries)
+	}
+
+	w = httptest.NewRecorder()
+	handler.RunConsistencyCheck(w, httptest.NewRequest("POST", "/consistency", bytes.NewBufferString(`{"queries":[{"name":"x."}]}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a query without a type, got %d", w.Code)
+	}
+}
+
+type stubUsage struct {
+	from, to time.Time
+	rows     []domain.ZoneUsage
+}
+
+func (s *stubUsage) ZoneUsage(_ context.Context, zoneID string, _ string, from, to time.Time) (*domain.ZoneUsageReport, error) {
+	s.from, s.to = from, to
+	if zoneID != "z1" {
+		return nil, nil
+	}
+	return &domain.ZoneUsageReport{ZoneID: "z1", Queries: 42, Hourly: s.rows}, nil
+}
+
+func (s *stubUsage) ExportUsage(_ context.Context, _ string, from, to time.Time) ([]domain.ZoneUsage, error) {
+	s.from, s.to = from, to
+	return s.rows, nil
+}
+
+func (s *stubUsage) TenantUsageSummary(_ context.Context, _ string, from, to time.Time) (*domain.TenantUsageSummary, error) {
+	s.from, s.to = from, to
+	summary := &domain.TenantUsageSummary{From: from, To: to}
+	for _, u := range s.rows {
+		summary.Queries += u.Queries
+		summary.Zones = append(summary.Zones, domain.ZoneUsageTotal{ZoneID: u.ZoneID, ZoneName: u.ZoneName, Queries: u.Queries})
+	}
+	return summary, nil
+}
+
+func TestZoneUsage(t *testing.T) {
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+	usageReq := func(target, id string) *http.Request {
+		req := withTenant(httptest.NewRequest("GET", target, nil), "t1")
+		req.SetPathValue("id", id)
+		return req
+	}
+
+	w := httptest.NewRecorder()
+	handler.GetZoneUsage(w, usageReq("/zones/z1/usage", "z1"))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without usage stats, got %d", w.Code)
+	}
+
+	hour := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	stub := &stubUsage{rows: []domain.ZoneUsage{{ZoneID: "z1", ZoneName: "example.com.", Hour: hour, Queries: 42, NXDomain: 3, TransferBytes: 7}}}
+	handler.SetUsage(stub)
+
+	w = httptest.NewRecorder()
+	handler.GetZoneUsage(w, usageReq("/zones/z1/usage?from=2026-05-01T00:00:00Z&to=2026-05-02T00:00:00Z", "z1"))
+	var report domain.ZoneUsageReport
+	if w.Code != http.StatusOK || json.NewDecoder(w.Body).Decode(&report) != nil || report.Queries != 42 {
+		t.Errorf("Unexpected usage response %d: %+v", w.Code, report)
+	}
+	if !stub.from.Equal(hour.Add(-10*time.Hour)) || stub.to.Sub(stub.from) != 24*time.Hour {
+		t.Errorf("Expected requested range, got %v - %v", stub.from, stub.to)
+	}
+
+	w = httptest.NewRecorder()
+	handler.GetZoneUsage(w, usageReq("/zones/z1/usage", "z1"))
+	if w.Code != http.StatusOK || stub.to.Sub(stub.from) != 24*time.Hour {
+		t.Errorf("Expected default 24h range, got %d %v - %v", w.Code, stub.from, stub.to)
+	}
+
+	w = httptest.NewRecorder()
+	handler.GetZoneUsage(w, usageReq("/zones/z9/usage", "z9"))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown zone, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.GetZoneUsage(w, usageReq("/zones/z1/usage?from=yesterday", "z1"))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid range, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ExportUsage(w, httptest.NewRequest("GET", "/usage/export", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without tenant, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ExportUsage(w, usageReq("/usage/export?from=2026-05-01T00:00:00Z&to=2026-05-01T00:00:00Z", ""))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an empty range, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ExportUsage(w, usageReq("/usage/export", ""))
+	want := "hour,zone_id,zone_name,queries,nxdomain,transfer_bytes\n2026-05-01T10:00:00Z,z1,example.com.,42,3,7\n"
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/csv" || w.Body.String() != want {
+		t.Errorf("Unexpected export %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	handler.GetUsageSummary(w, usageReq("/usage/summary", ""))
+	var summary domain.TenantUsageSummary
+	if w.Code != http.StatusOK || json.NewDecoder(w.Body).Decode(&summary) != nil || summary.Queries != 42 || len(summary.Zones) != 1 {
+		t.Errorf("Unexpected usage summary %d: %+v", w.Code, summary)
+	}
+}
+
+type stubRollouts struct {
+	rollout *domain.Rollout
+	err     error
+}
+
+func (s *stubRollouts) StartRollout(_ context.Context, r *domain.Rollout) (*domain.Rollout, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	r.ID = "r1"
+	return r, nil
+}
+
+func (s *stubRollouts) GetRollout(_ context.Context, _ string) (*domain.Rollout, error) {
+	return s.rollout, s.err
+}
+
+func (s *stubRollouts) ListRollouts(_ context.Context) ([]domain.Rollout, error) {
+	return nil, s.err
+}
+
+func (s *stubRollouts) AbortRollout(_ context.Context, _ string) error {
+	return s.err
+}
+
+func TestRollouts(t *testing.T) {
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+	body := `{"config":{"log_level":"debug"},"batch_size":2}`
+
+	w := httptest.NewRecorder()
+	handler.StartRollout(w, httptest.NewRequest("POST", "/rollouts", bytes.NewBufferString(body)))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without an orchestrator, got %d", w.Code)
+	}
+
+	handler.SetRollouts(&stubRollouts{})
+	w = httptest.NewRecorder()
+	handler.StartRollout(w, httptest.NewRequest("POST", "/rollouts", bytes.NewBufferString(body)))
+	var started domain.Rollout
+	if w.Code != http.StatusAccepted || json.NewDecoder(w.Body).Decode(&started) != nil || started.ID != "r1" || started.BatchSize != 2 {
+		t.Errorf("Unexpected start response %d: %+v", w.Code, started)
+	}
+
+	w = httptest.NewRecorder()
+	handler.StartRollout(w, httptest.NewRequest("POST", "/rollouts", bytes.NewBufferString("{bad")))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for malformed body, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ListRollouts(w, httptest.NewRequest("GET", "/rollouts", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "[]\n" {
+		t.Errorf("Expected empty list, got %d %q", w.Code, w.Body.String())
+	}
+
+	req := httptest.NewRequest("GET", "/rollouts/r1", nil)
+	req.SetPathValue("id", "r1")
+	w = httptest.NewRecorder()
+	handler.GetRollout(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown rollout, got %d", w.Code)
+	}
+
+	handler.SetRollouts(&stubRollouts{rollout: &domain.Rollout{ID: "r1", State: domain.RolloutRunning}})
+	w = httptest.NewRecorder()
+	handler.GetRollout(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	handler.AbortRollout(w, httptest.NewRequest("POST", "/rollouts/r1/abort", nil))
+	if w.Code != http.StatusAccepted {
+		t.Errorf("Expected 202 on abort, got %d", w.Code)
+	}
+
+	handler.SetRollouts(&stubRollouts{err: domain.ErrInvalidRollout})
+	w = httptest.NewRecorder()
+	handler.StartRollout(w, httptest.NewRequest("POST", "/rollouts", bytes.NewBufferString(body)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid rollout, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	handler.AbortRollout(w, httptest.NewRequest("POST", "/rollouts/r1/abort", nil))
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 when the rollout is not running, got %d", w.Code)
+	}
+}
+
+func TestLivez(t *testing.T) {
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+
+	w := httptest.NewRecorder()
+	handler.Livez(w, httptest.NewRequest("GET", "/livez", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+	readiness := &stubReadiness{report: &domain.ReadinessReport{
+		Ready: false,
+		Checks: []domain.DependencyStatus{
+			{Name: "postgres", Healthy: true, LatencyMs: 1.5},
+			{Name: "zones", Healthy: false, Error: "no zones loaded"},
+		},
+	}}
+	handler.SetReadiness(readiness)
+
+	w := httptest.NewRecorder()
+	handler.Readyz(w, httptest.NewRequest("GET", "/readyz", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", w.Code)
+	}
+	var report domain.ReadinessReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(report.Checks) != 2 || report.Checks[0].LatencyMs != 1.5 {
+		t.Errorf("Unexpected readiness report: %+v", report)
+	}
+
+	readiness.report = &domain.ReadinessReport{Ready: true}
+	w = httptest.NewRecorder()
+	handler.Readyz(w, httptest.NewRequest("GET", "/readyz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+}
+
+func TestReadyzFallsBackToHealthCheck(t *testing.T) {
+	svc := &testutil.MockDNSService{}
+	handler := NewAPIHandler(svc, &testutil.MockRepo{})
+	svc.On("HealthCheck").Return(map[string]error{"postgres": errors.New("db down")}).Once()
+
+	w := httptest.NewRecorder()
+	handler.Readyz(w, httptest.NewRequest("GET", "/readyz", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", w.Code)
+	}
+}
+
 func TestCreateZoneBadRequest(t *testing.T) {
 	svc := &mockDNSService{}
 	repo := &testutil.MockRepo{}
@@ -292,6 +881,22 @@ func TestCreateRecordBadRequest(t *testing.T) {
 	}
 }
 
+func TestCreateRecordInvalidRDATA(t *testing.T) {
+	handler := NewAPIHandler(&mockDNSService{}, &testutil.MockRepo{})
+
+	rec := domain.Record{Name: "_443._tcp.www.example.com.", Type: domain.TypeTLSA, Content: "3 1 1 0c72", TTL: 300}
+	body, _ := json.Marshal(rec)
+	req := httptest.NewRequest("POST", recordsPath, bytes.NewBuffer(body))
+	req = withTenant(req, testTenantID)
+	w := httptest.NewRecorder()
+
+	handler.CreateRecord(w, req)
+
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "Invalid TLSA record") {
+		t.Errorf("Expected a short SHA-256 digest to be rejected, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
 func TestCreateRecordInternalError(t *testing.T) {
 	svc := &mockDNSService{err: errors.New("fail")}
 	repo := &testutil.MockRepo{}
@@ -328,6 +933,226 @@ func TestListRecordsForZoneSuccess(t *testing.T) {
 	}
 }
 
+func TestGetZoneSummary(t *testing.T) {
+	svc := &mockDNSService{
+		zones:   []domain.Zone{{ID: "z1", Name: "example.com."}},
+		records: []domain.Record{{ID: "r1", Name: "www"}},
+	}
+	handler := NewAPIHandler(svc, &testutil.MockRepo{})
+
+	req := httptest.NewRequest("GET", "/zones/z1/summary", nil)
+	req.SetPathValue("id", "z1")
+	req = withTenant(req, testTenantID)
+	w := httptest.NewRecorder()
+	handler.GetZoneSummary(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf(status200Err, w.Code)
+	}
+	var summary domain.ZoneSummary
+	if err := json.NewDecoder(w.Body).Decode(&summary); err != nil || summary.ZoneName != "example.com." || summary.TotalRecords != 1 {
+		t.Errorf("Unexpected summary %+v (err %v)", summary, err)
+	}
+
+	req = httptest.NewRequest("GET", "/zones/missing/summary", nil)
+	req.SetPathValue("id", "missing")
+	req = withTenant(req, testTenantID)
+	w = httptest.NewRecorder()
+	handler.GetZoneSummary(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown zone, got %d", w.Code)
+	}
+
+	svc.err = errors.New("fail")
+	w = httptest.NewRecorder()
+	handler.GetZoneSummary(w, withTenant(httptest.NewRequest("GET", "/zones/z1/summary", nil), testTenantID))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf(status500Err, w.Code)
+	}
+}
+
+func TestSetZoneDNSSEC(t *testing.T) {
+	svc := &mockDNSService{zones: []domain.Zone{{ID: "z1", Name: "example.com."}}}
+	handler := NewAPIHandler(svc, &testutil.MockRepo{})
+
+	put := func(id, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", "/zones/"+id+"/dnssec", bytes.NewBufferString(body))
+		req.SetPathValue("id", id)
+		w := httptest.NewRecorder()
+		handler.SetZoneDNSSEC(w, withTenant(req, testTenantID))
+		return w
+	}
+
+	if w := put("z1", `{}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without enabled, got %d", w.Code)
+	}
+	if w := put("missing", `{"enabled":false}`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown zone, got %d", w.Code)
+	}
+
+	w := put("z1", `{"enabled":false}`)
+	var zone domain.Zone
+	if w.Code != http.StatusOK || json.NewDecoder(w.Body).Decode(&zone) != nil || zone.SignsDNSSEC() {
+		t.Errorf("Expected zone opted out, got %d: %+v", w.Code, zone)
+	}
+
+	svc.err = errors.New("fail")
+	if w := put("z1", `{"enabled":true}`); w.Code != http.StatusInternalServerError {
+		t.Errorf(status500Err, w.Code)
+	}
+}
+
+func TestSetZoneRole(t *testing.T) {
+	svc := &mockDNSService{zones: []domain.Zone{{ID: "z1", Name: "example.com.", Role: "master"}}}
+	handler := NewAPIHandler(svc, &testutil.MockRepo{})
+
+	put := func(id, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", "/zones/"+id+"/role", bytes.NewBufferString(body))
+		req.SetPathValue("id", id)
+		w := httptest.NewRecorder()
+		handler.SetZoneRole(w, withTenant(req, testTenantID))
+		return w
+	}
+
+	for _, body := range []string{`{}`, `{"role":"primary"}`, `{"role":"slave"}`, `{"role":"slave","master_server":"192.0.2.1:99999"}`} {
+		if w := put("z1", body); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", body, w.Code)
+		}
+	}
+	if w := put("missing", `{"role":"master"}`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown zone, got %d", w.Code)
+	}
+
+	w := put("z1", `{"role":"slave","master_server":"192.0.2.1, 192.0.2.2:5353"}`)
+	var zone domain.Zone
+	if w.Code != http.StatusOK || json.NewDecoder(w.Body).Decode(&zone) != nil || zone.Role != "slave" || zone.MasterServer != "192.0.2.1, 192.0.2.2:5353" {
+		t.Errorf("Expected a secondary zone, got %d: %+v", w.Code, zone)
+	}
+
+	svc.err = domain.ErrChangeFrozen
+	if w := put("z1", `{"role":"master"}`); w.Code != http.StatusLocked {
+		t.Errorf("Expected 423 during a change freeze, got %d", w.Code)
+	}
+}
+
+func TestCloneZone(t *testing.T) {
+	svc := &mockDNSService{zones: []domain.Zone{{ID: "z1", Name: "example.com."}}}
+	repo := &testutil.MockRepo{}
+	handler := NewAPIHandler(svc, repo)
+
+	clone := func(id, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/zones/"+id+"/clone", bytes.NewBufferString(body))
+		req.SetPathValue("id", id)
+		w := httptest.NewRecorder()
+		handler.CloneZone(w, withTenant(req, testTenantID))
+		return w
+	}
+
+	if w := clone("z1", `{"name":"bad..name"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid name, got %d", w.Code)
+	}
+	if w := clone("missing", `{}`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown zone, got %d", w.Code)
+	}
+
+	w := clone("z1", `{"name":"staging.example.com."}`)
+	var zone domain.Zone
+	if w.Code != http.StatusCreated || json.NewDecoder(w.Body).Decode(&zone) != nil || zone.Name != "staging.example.com." || zone.TenantID != testTenantID {
+		t.Errorf("Expected renamed copy, got %d: %+v", w.Code, zone)
+	}
+
+	// A copy for another tenant needs an active admin key of that tenant
+	repo.On("GetAPIKeyByHash", hashAPIKey("reader-key")).Return(&domain.APIKey{TenantID: "t2", Role: domain.RoleReader, Active: true}, nil)
+	repo.On("GetAPIKeyByHash", hashAPIKey("admin-key")).Return(&domain.APIKey{TenantID: "t2", Role: domain.RoleAdmin, Active: true}, nil)
+	if w := clone("z1", `{"target_api_key":"reader-key"}`); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a reader target key, got %d", w.Code)
+	}
+	w = clone("z1", `{"target_api_key":"admin-key"}`)
+	if w.Code != http.StatusCreated || json.NewDecoder(w.Body).Decode(&zone) != nil || zone.TenantID != "t2" {
+		t.Errorf("Expected copy owned by t2, got %d: %+v", w.Code, zone)
+	}
+
+	svc.err = errors.New("fail")
+	if w := clone("z1", `{}`); w.Code != http.StatusInternalServerError {
+		t.Errorf(status500Err, w.Code)
+	}
+}
+
+func TestRRsetEndpoints(t *testing.T) {
+	svc := &mockDNSService{records: []domain.Record{{ZoneID: "z1", Name: "www", Type: domain.TypeA, TTL: 300, Content: "192.0.2.1"}}}
+	handler := NewAPIHandler(svc, &testutil.MockRepo{})
+
+	do := func(method, name, qType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/zones/z1/rrsets/"+name+"/"+qType, bytes.NewBufferString(body))
+		req.SetPathValue("id", "z1")
+		req.SetPathValue("name", name)
+		req.SetPathValue("type", qType)
+		w := httptest.NewRecorder()
+		if method == "GET" {
+			handler.GetRRset(w, withTenant(req, testTenantID))
+		} else {
+			handler.ReplaceRRset(w, withTenant(req, testTenantID))
+		}
+		return w
+	}
+
+	var rrset domain.RRset
+	w := do("GET", "www", "A", "")
+	if w.Code != http.StatusOK || json.NewDecoder(w.Body).Decode(&rrset) != nil || len(rrset.Records) != 1 {
+		t.Errorf("Expected the A RRset, got %d: %+v", w.Code, rrset)
+	}
+	if w := do("GET", "www", "AAAA", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing RRset, got %d", w.Code)
+	}
+
+	w = do("PUT", "www", "A", `{"ttl":600,"records":[{"content":"192.0.2.7"},{"content":"192.0.2.8"}]}`)
+	if w.Code != http.StatusOK || json.NewDecoder(w.Body).Decode(&rrset) != nil || rrset.TTL != 600 || len(rrset.Records) != 2 {
+		t.Errorf("Expected the RRset replaced, got %d: %+v", w.Code, rrset)
+	}
+	if len(svc.records) != 2 {
+		t.Errorf("Expected the old record replaced, got %+v", svc.records)
+	}
+	if w := do("PUT", "www", "A", `{"records":`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a malformed body, got %d", w.Code)
+	}
+	if w := do("PUT", "@", "SOA", `{"records":[]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid RRset, got %d", w.Code)
+	}
+
+	svc.err = errors.New("fail")
+	if w := do("GET", "www", "A", ""); w.Code != http.StatusInternalServerError {
+		t.Errorf(status500Err, w.Code)
+	}
+}
+
+func TestDiffZone(t *testing.T) {
+	svc := &mockDNSService{
+		zones:   []domain.Zone{{ID: "z1", Name: "example.com."}},
+		records: []domain.Record{{Name: "www.example.com.", Type: domain.TypeA, TTL: 300, Content: "192.0.2.1"}},
+	}
+	handler := NewAPIHandler(svc, &testutil.MockRepo{})
+
+	diff := func(id, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/zones/"+id+"/diff"+query, nil)
+		req.SetPathValue("id", id)
+		w := httptest.NewRecorder()
+		handler.DiffZone(w, withTenant(req, testTenantID))
+		return w
+	}
+
+	if w := diff("z1", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without against, got %d", w.Code)
+	}
+	if w := diff("missing", "?against=z2"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown zone, got %d", w.Code)
+	}
+	w := diff("z1", "?against=z2")
+	var got domain.ZoneDiff
+	if w.Code != http.StatusOK || json.NewDecoder(w.Body).Decode(&got) != nil || got.AgainstZoneID != "z2" || len(got.Added) != 1 {
+		t.Errorf("Expected diff against z2, got %d: %+v", w.Code, got)
+	}
+}
+
 func TestListRecordsForZoneInternalError(t *testing.T) {
 	svc := &mockDNSService{err: errors.New("fail")}
 	repo := &testutil.MockRepo{}
@@ -376,6 +1201,69 @@ func TestDeleteRecordInternalError(t *testing.T) {
 	}
 }
 
+// forceRecordingService records whether deletions asked to force.
+type forceRecordingService struct {
+	*mockDNSService
+	forced bool
+}
+
+func (m *forceRecordingService) DeleteRecord(ctx context.Context, _, _, _ string) error {
+	m.forced = domain.IsForced(ctx)
+	return m.err
+}
+
+func TestDeleteRecordProtected(t *testing.T) {
+	svc := &forceRecordingService{mockDNSService: &mockDNSService{err: fmt.Errorf("%w: example.com. NS", domain.ErrRecordProtected)}}
+	handler := NewAPIHandler(svc, &testutil.MockRepo{})
+
+	req := httptest.NewRequest("DELETE", "/zones/z1/records/r1", nil)
+	req = withTenant(req, testTenantID)
+	w := httptest.NewRecorder()
+	handler.DeleteRecord(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a protected record, got %d", w.Code)
+	}
+	if svc.forced {
+		t.Error("Expected no force without the flag")
+	}
+
+	svc.err = nil
+	req = httptest.NewRequest("DELETE", "/zones/z1/records/r1?force=true", nil)
+	req = withTenant(req, testTenantID)
+	w = httptest.NewRecorder()
+	handler.DeleteRecord(w, req)
+	if w.Code != http.StatusNoContent || !svc.forced {
+		t.Errorf("Expected a forced deletion, got %d (forced %v)", w.Code, svc.forced)
+	}
+}
+
+func TestSetRecordProtectionHandler(t *testing.T) {
+	svc := &mockDNSService{records: []domain.Record{{ID: "r1", ZoneID: "z1", Name: "example.com.", Type: domain.TypeNS}}}
+	handler := NewAPIHandler(svc, &testutil.MockRepo{})
+
+	send := func(body, recordID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", "/zones/z1/records/"+recordID+"/protection", strings.NewReader(body))
+		req.SetPathValue("id", "z1")
+		req.SetPathValue("rid", recordID)
+		req = withTenant(req, testTenantID)
+		w := httptest.NewRecorder()
+		handler.SetRecordProtection(w, req)
+		return w
+	}
+
+	w := send(`{"protected": true}`, "r1")
+	var rec domain.Record
+	if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &rec) != nil || !rec.Protected {
+		t.Errorf("Expected the record protected, got %d %s", w.Code, w.Body.String())
+	}
+	if w := send(`{}`, "r1"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without protected, got %d", w.Code)
+	}
+	if w := send(`{"protected": true}`, "missing"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown record, got %d", w.Code)
+	}
+}
+
 func TestMetrics(t *testing.T) {
 	svc := &mockDNSService{}
 	repo := &testutil.MockRepo{}
@@ -390,3 +1278,288 @@ func TestMetrics(t *testing.T) {
 		t.Errorf(status200Err, w.Code)
 	}
 }
+
+type stubTransports []domain.TransportPosture
+
+func (s stubTransports) TransportPosture() []domain.TransportPosture { return s }
+
+func TestListTransports(t *testing.T) {
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+
+	w := httptest.NewRecorder()
+	handler.ListTransports(w, httptest.NewRequest("GET", "/transports", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a reporter, got %d", w.Code)
+	}
+
+	handler.SetTransports(stubTransports{{Transport: "doh", Enabled: true, PaddingBlockSize: 468, EarlyData: true}})
+	w = httptest.NewRecorder()
+	handler.ListTransports(w, httptest.NewRequest("GET", "/transports", nil))
+	var posture []domain.TransportPosture
+	if w.Code != http.StatusOK || json.NewDecoder(w.Body).Decode(&posture) != nil || len(posture) != 1 || posture[0].PaddingBlockSize != 468 || posture[0].EarlyDataUpdates {
+		t.Errorf("Unexpected response %d: %+v", w.Code, posture)
+	}
+}
+
+type stubKeyManager struct {
+	digests []uint8
+}
+
+func (s *stubKeyManager) ListZoneKeys(_ context.Context, zoneID, _ string) ([]domain.DNSSECKeyInfo, error) {
+	if zoneID != "z1" {
+		return nil, domain.ErrNotFound
+	}
+	return []domain.DNSSECKeyInfo{{ID: "k1", KeyType: "KSK", Flags: 257, KeyTag: 12345, Active: true}}, nil
+}
+
+func (s *stubKeyManager) RolloverKey(_ context.Context, _, _ string, keyType string) (*domain.DNSSECKeyInfo, error) {
+	if keyType != "KSK" && keyType != "ZSK" {
+		return nil, domain.ErrInvalidKeyOperation
+	}
+	return &domain.DNSSECKeyInfo{ID: "k2", KeyType: keyType, Active: true}, nil
+}
+
+func (s *stubKeyManager) SetKeyActive(_ context.Context, _, _ string, keyID string, active bool) (*domain.DNSSECKeyInfo, error) {
+	if keyID == "k1" && !active {
+		return nil, domain.ErrKeyInUse
+	}
+	return &domain.DNSSECKeyInfo{ID: keyID, Active: active}, nil
+}
+
+func (s *stubKeyManager) ZoneDS(_ context.Context, _, _ string, digestTypes []uint8) ([]domain.DSRecord, error) {
+	s.digests = digestTypes
+	return []domain.DSRecord{{KeyTag: 12345, Record: "example.com. 3600 IN DS 12345 13 2 AB"}}, nil
+}
+
+func (s *stubKeyManager) ImportKey(_ context.Context, _, _ string, files domain.DNSSECKeyFiles) (*domain.DNSSECKeyInfo, error) {
+	if !strings.Contains(files.PublicKey, "DNSKEY") {
+		return nil, domain.ErrInvalidKeyOperation
+	}
+	return &domain.DNSSECKeyInfo{ID: "k3", KeyType: "KSK", Active: true}, nil
+}
+
+func (s *stubKeyManager) ExportKey(_ context.Context, _, _ string, keyID string) (*domain.DNSSECKeyFiles, error) {
+	if keyID != "k1" {
+		return nil, domain.ErrKeyExportDisabled
+	}
+	return &domain.DNSSECKeyFiles{Name: "Kexample.com.+013+12345", PublicKey: "example.com. IN DNSKEY 257 3 13 AA==", PrivateKey: "Private-key-format: v1.3"}, nil
+}
+
+func (s *stubKeyManager) ImportSignerKey(_ context.Context, _, _ string, dnskey string) (*domain.DNSSECKeyInfo, error) {
+	if !strings.Contains(dnskey, "DNSKEY 256") {
+		return nil, domain.ErrInvalidKeyOperation
+	}
+	return &domain.DNSSECKeyInfo{ID: "k4", KeyType: "ZSK", Active: true, External: true}, nil
+}
+
+func (s *stubKeyManager) SignerKeys(_ context.Context, _, _ string) ([]string, error) {
+	return []string{"example.com. 3600 IN DNSKEY 256 3 13 AA=="}, nil
+}
+
+func TestDNSSECKeyEndpoints(t *testing.T) {
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+	call := func(fn http.HandlerFunc, method, target, body string, path map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		for k, v := range path {
+			req.SetPathValue(k, v)
+		}
+		w := httptest.NewRecorder()
+		fn(w, withTenant(req, testTenantID))
+		return w
+	}
+	z1 := map[string]string{"id": "z1"}
+
+	if w := call(handler.ListZoneKeys, "GET", "/zones/z1/dnssec/keys", "", z1); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a key manager, got %d", w.Code)
+	}
+
+	keys := &stubKeyManager{}
+	handler.SetDNSSECKeys(keys)
+
+	w := call(handler.ListZoneKeys, "GET", "/zones/z1/dnssec/keys", "", z1)
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "private") || !strings.Contains(w.Body.String(), `"key_tag":12345`) {
+		t.Errorf("Unexpected key listing %d: %s", w.Code, w.Body.String())
+	}
+	if w := call(handler.ListZoneKeys, "GET", "/zones/z9/dnssec/keys", "", map[string]string{"id": "z9"}); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown zone, got %d", w.Code)
+	}
+
+	if w := call(handler.RolloverKey, "POST", "/zones/z1/dnssec/rollover", `{"key_type":"zsk"}`, z1); w.Code != http.StatusCreated {
+		t.Errorf("Expected 201 for rollover, got %d", w.Code)
+	}
+	if w := call(handler.RolloverKey, "POST", "/zones/z1/dnssec/rollover", `{"key_type":"other"}`, z1); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for bad key type, got %d", w.Code)
+	}
+
+	k1 := map[string]string{"id": "z1", "key_id": "k1"}
+	if w := call(handler.SetKeyActive, "PUT", "/zones/z1/dnssec/keys/k1", `{}`, k1); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without active, got %d", w.Code)
+	}
+	if w := call(handler.SetKeyActive, "PUT", "/zones/z1/dnssec/keys/k1", `{"active":false}`, k1); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 deactivating the last key, got %d", w.Code)
+	}
+
+	w = call(handler.GetZoneDS, "GET", "/zones/z1/dnssec/ds?digest=sha1,2,sha384&format=text", "", z1)
+	if w.Code != http.StatusOK || w.Body.String() != "example.com. 3600 IN DS 12345 13 2 AB\n" || len(keys.digests) != 3 || keys.digests[2] != 4 {
+		t.Errorf("Unexpected DS response %d %q (digests %v)", w.Code, w.Body.String(), keys.digests)
+	}
+	if w := call(handler.GetZoneDS, "GET", "/zones/z1/dnssec/ds?digest=md5", "", z1); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for unknown digest, got %d", w.Code)
+	}
+
+	if w := call(handler.ImportKey, "POST", "/zones/z1/dnssec/keys/import", `{"public_key":"x"}`, z1); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without a private key, got %d", w.Code)
+	}
+	if w := call(handler.ImportKey, "POST", "/zones/z1/dnssec/keys/import", `{"public_key":"x","private_key":"y"}`, z1); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a malformed key, got %d", w.Code)
+	}
+	if w := call(handler.ImportKey, "POST", "/zones/z1/dnssec/keys/import", `{"public_key":"example.com. IN DNSKEY 257 3 13 AA==","private_key":"y"}`, z1); w.Code != http.StatusCreated {
+		t.Errorf("Expected 201 for an import, got %d", w.Code)
+	}
+
+	w = call(handler.ExportKey, "GET", "/zones/z1/dnssec/keys/k1/export", "", k1)
+	if w.Code != http.StatusOK || w.Header().Get("Cache-Control") != "no-store" || !strings.Contains(w.Body.String(), "Kexample.com.+013+12345") {
+		t.Errorf("Unexpected export %d: %s", w.Code, w.Body.String())
+	}
+	if w := call(handler.ExportKey, "GET", "/zones/z1/dnssec/keys/k2/export", "", map[string]string{"id": "z1", "key_id": "k2"}); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 when export is disabled, got %d", w.Code)
+	}
+
+	w = call(handler.ListSignerKeys, "GET", "/zones/z1/dnssec/signer-keys?format=text", "", z1)
+	if w.Code != http.StatusOK || w.Body.String() != "example.com. 3600 IN DNSKEY 256 3 13 AA==\n" {
+		t.Errorf("Unexpected signer keys %d %q", w.Code, w.Body.String())
+	}
+	if w := call(handler.ImportSignerKey, "POST", "/zones/z1/dnssec/signer-keys", `{}`, z1); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without a dnskey, got %d", w.Code)
+	}
+	if w := call(handler.ImportSignerKey, "POST", "/zones/z1/dnssec/signer-keys", `{"dnskey":"example.com. IN DNSKEY 257 3 13 AA=="}`, z1); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a KSK, got %d", w.Code)
+	}
+	w = call(handler.ImportSignerKey, "POST", "/zones/z1/dnssec/signer-keys", `{"dnskey":"example.com. IN DNSKEY 256 3 13 AA=="}`, z1)
+	if w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), `"external":true`) {
+		t.Errorf("Unexpected signer key import %d: %s", w.Code, w.Body.String())
+	}
+}
+
+type stubTransfers struct{}
+
+func (stubTransfers) TransferZone(_ context.Context, zoneID, _ string) (*domain.ZoneTransferResult, error) {
+	switch zoneID {
+	case "primary":
+		return nil, domain.ErrInvalidZoneRole
+	case "unreachable":
+		return nil, domain.ErrTransferFailed
+	}
+	return &domain.ZoneTransferResult{ZoneID: zoneID, Method: "IXFR", LocalSerial: 1, MasterSerial: 2}, nil
+}
+
+func (stubTransfers) NotifyZone(_ context.Context, zoneID, _ string) (*domain.NotifyResult, error) {
+	if zoneID != "primary" {
+		return nil, domain.ErrNotFound
+	}
+	return &domain.NotifyResult{ZoneID: zoneID, Targets: []domain.NotifyTarget{{Address: "192.0.2.1:53", Status: "acknowledged"}}}, nil
+}
+
+func TestZoneSyncEndpoints(t *testing.T) {
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+	call := func(fn http.HandlerFunc, zoneID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/zones/"+zoneID+"/transfer", nil)
+		req.SetPathValue("id", zoneID)
+		w := httptest.NewRecorder()
+		fn(w, withTenant(req, testTenantID))
+		return w
+	}
+
+	if w := call(handler.TransferZone, "secondary"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a transfer trigger, got %d", w.Code)
+	}
+	handler.SetTransfers(stubTransfers{})
+
+	w := call(handler.TransferZone, "secondary")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"method":"IXFR"`) {
+		t.Errorf("Unexpected transfer response %d: %s", w.Code, w.Body.String())
+	}
+	if w := call(handler.TransferZone, "primary"); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 pulling a primary zone, got %d", w.Code)
+	}
+	if w := call(handler.TransferZone, "unreachable"); w.Code != http.StatusBadGateway {
+		t.Errorf("Expected 502 when the master fails, got %d", w.Code)
+	}
+
+	w = call(handler.NotifyZone, "primary")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"status":"acknowledged"`) {
+		t.Errorf("Unexpected notify response %d: %s", w.Code, w.Body.String())
+	}
+	if w := call(handler.NotifyZone, "missing"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown zone, got %d", w.Code)
+	}
+}
+
+type stubSecondaries struct {
+	added   *domain.SecondaryProvider
+	deleted string
+}
+
+func (s *stubSecondaries) AddSecondary(_ context.Context, _, _ string, p *domain.SecondaryProvider) error {
+	if p.TSIGKeyName != "" && p.TSIGSecret == "" {
+		return domain.ErrInvalidSecondary
+	}
+	p.ID = "s1"
+	s.added = p
+	return nil
+}
+
+func (s *stubSecondaries) ListSecondaries(_ context.Context, zoneID, _ string) ([]domain.SecondaryProvider, error) {
+	if zoneID != "z1" {
+		return nil, domain.ErrNotFound
+	}
+	return []domain.SecondaryProvider{{ID: "s1", Address: "192.0.2.1:53", TSIGKeyName: "k.", TSIGSecret: "c2VjcmV0", ObservedSerial: 7, InSync: true}}, nil
+}
+
+func (s *stubSecondaries) DeleteSecondary(_ context.Context, _, _ string, id string) error {
+	s.deleted = id
+	return nil
+}
+
+func TestSecondaryEndpoints(t *testing.T) {
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+	call := func(fn http.HandlerFunc, method, body string, path map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/zones/z1/secondaries", strings.NewReader(body))
+		for k, v := range path {
+			req.SetPathValue(k, v)
+		}
+		w := httptest.NewRecorder()
+		fn(w, withTenant(req, testTenantID))
+		return w
+	}
+	z1 := map[string]string{"id": "z1"}
+
+	if w := call(handler.ListSecondaries, "GET", "", z1); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a secondary manager, got %d", w.Code)
+	}
+	stub := &stubSecondaries{}
+	handler.SetSecondaries(stub)
+
+	w := call(handler.ListSecondaries, "GET", "", z1)
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "c2VjcmV0") || !strings.Contains(w.Body.String(), `"in_sync":true`) {
+		t.Errorf("Unexpected listing %d: %s", w.Code, w.Body.String())
+	}
+	if w := call(handler.ListSecondaries, "GET", "", map[string]string{"id": "z9"}); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown zone, got %d", w.Code)
+	}
+
+	if w := call(handler.AddSecondary, "POST", `{"name":"bind"}`, z1); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without an address, got %d", w.Code)
+	}
+	if w := call(handler.AddSecondary, "POST", `{"address":"192.0.2.1","tsig_key_name":"k"}`, z1); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an incomplete TSIG key, got %d", w.Code)
+	}
+	w = call(handler.AddSecondary, "POST", `{"name":"bind","address":"192.0.2.1","tsig_key_name":"k","tsig_secret":"c2VjcmV0"}`, z1)
+	if w.Code != http.StatusCreated || stub.added == nil || stub.added.TSIGSecret != "c2VjcmV0" || strings.Contains(w.Body.String(), "c2VjcmV0") {
+		t.Errorf("Unexpected create %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := call(handler.DeleteSecondary, "DELETE", "", map[string]string{"id": "z1", "secondary_id": "s1"}); w.Code != http.StatusNoContent || stub.deleted != "s1" {
+		t.Errorf("Expected 204 deleting s1, got %d (%q)", w.Code, stub.deleted)
+	}
+}
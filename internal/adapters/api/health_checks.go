@@ -0,0 +1,114 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// writeHealthCheckError maps health check errors to HTTP statuses.
+func writeHealthCheckError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidHealthCheck):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// healthCheckTenant checks that health checks are enabled and returns the
+// caller's tenant, writing the error response if either is missing.
+func (h *APIHandler) healthCheckTenant(w http.ResponseWriter, r *http.Request, op string) (string, bool) {
+	if h.checks == nil {
+		http.Error(w, "Health checks are not enabled", http.StatusServiceUnavailable)
+		return "", false
+	}
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("%s: missing or invalid tenant ID in context", op)
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return "", false
+	}
+	return tenantID, true
+}
+
+// ListHealthChecks returns the tenant's health checks with their status.
+func (h *APIHandler) ListHealthChecks(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.healthCheckTenant(w, r, "ListHealthChecks")
+	if !ok {
+		return
+	}
+	checks, err := h.checks.ListHealthChecks(r.Context(), tenantID)
+	if err != nil {
+		writeHealthCheckError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(checks); err != nil {
+		log.Printf("failed to encode health checks response: %v", err)
+	}
+}
+
+// CreateHealthCheck adds a health check; the body is {"name", "protocol",
+// "target", "path", "expected_status", "expected_body", "interval", "timeout",
+// "failure_threshold", "success_threshold"}.
+func (h *APIHandler) CreateHealthCheck(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.healthCheckTenant(w, r, "CreateHealthCheck")
+	if !ok {
+		return
+	}
+	var check domain.HealthCheck
+	if err := json.NewDecoder(r.Body).Decode(&check); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.checks.CreateHealthCheck(r.Context(), tenantID, &check); err != nil {
+		writeHealthCheckError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(check); err != nil {
+		log.Printf("failed to encode health check response: %v", err)
+	}
+}
+
+// UpdateHealthCheck replaces the settings of a health check.
+func (h *APIHandler) UpdateHealthCheck(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.healthCheckTenant(w, r, "UpdateHealthCheck")
+	if !ok {
+		return
+	}
+	var check domain.HealthCheck
+	if err := json.NewDecoder(r.Body).Decode(&check); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	check.ID = r.PathValue("id")
+	if err := h.checks.UpdateHealthCheck(r.Context(), tenantID, &check); err != nil {
+		writeHealthCheckError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(check); err != nil {
+		log.Printf("failed to encode health check response: %v", err)
+	}
+}
+
+// DeleteHealthCheck removes a health check no record references.
+func (h *APIHandler) DeleteHealthCheck(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.healthCheckTenant(w, r, "DeleteHealthCheck")
+	if !ok {
+		return
+	}
+	if err := h.checks.DeleteHealthCheck(r.Context(), tenantID, r.PathValue("id")); err != nil {
+		writeHealthCheckError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
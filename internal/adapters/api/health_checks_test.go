@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/testutil"
+)
+
+type stubHealthChecks struct {
+	saved   *domain.HealthCheck
+	deleted string
+}
+
+func (s *stubHealthChecks) CreateHealthCheck(_ context.Context, _ string, c *domain.HealthCheck) error {
+	if c.Target == "" {
+		return fmt.Errorf("no target: %w", domain.ErrInvalidHealthCheck)
+	}
+	c.ID = "c1"
+	s.saved = c
+	return nil
+}
+
+func (s *stubHealthChecks) UpdateHealthCheck(_ context.Context, _ string, c *domain.HealthCheck) error {
+	if c.ID != "c1" {
+		return domain.ErrNotFound
+	}
+	s.saved = c
+	return nil
+}
+
+func (s *stubHealthChecks) ListHealthChecks(_ context.Context, _ string) ([]domain.HealthCheck, error) {
+	return []domain.HealthCheck{{ID: "c1", Name: "app", Protocol: domain.HealthCheckProtocolGRPC, Target: "10.0.0.1:50051", Status: domain.HealthStatusUnhealthy}}, nil
+}
+
+func (s *stubHealthChecks) DeleteHealthCheck(_ context.Context, _ string, id string) error {
+	if id != "c1" {
+		return domain.ErrNotFound
+	}
+	s.deleted = id
+	return nil
+}
+
+func TestHealthCheckEndpoints(t *testing.T) {
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+	call := func(fn http.HandlerFunc, method, body, id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/healthchecks", strings.NewReader(body))
+		req.SetPathValue("id", id)
+		w := httptest.NewRecorder()
+		fn(w, withTenant(req, testTenantID))
+		return w
+	}
+
+	if w := call(handler.ListHealthChecks, "GET", "", ""); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a health check manager, got %d", w.Code)
+	}
+	stub := &stubHealthChecks{}
+	handler.SetHealthChecks(stub)
+
+	if w := call(handler.ListHealthChecks, "GET", "", ""); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"status":"UNHEALTHY"`) {
+		t.Errorf("Unexpected listing %d: %s", w.Code, w.Body.String())
+	}
+	if w := call(handler.CreateHealthCheck, "POST", `{"name":`, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for malformed JSON, got %d", w.Code)
+	}
+	if w := call(handler.CreateHealthCheck, "POST", `{"name":"app","protocol":"HTTPS"}`, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a health check without a target, got %d", w.Code)
+	}
+	w := call(handler.CreateHealthCheck, "POST", `{"name":"app","protocol":"HTTPS","target":"app.example","path":"/healthz","expected_status":200,"expected_body":"ok","interval":15,"timeout":3,"failure_threshold":2,"success_threshold":1}`, "")
+	if w.Code != http.StatusCreated || stub.saved == nil || stub.saved.ExpectedStatus != 200 || stub.saved.FailureThreshold != 2 || !strings.Contains(w.Body.String(), `"id":"c1"`) {
+		t.Errorf("Unexpected health check %d: %s", w.Code, w.Body.String())
+	}
+	if w := call(handler.UpdateHealthCheck, "PUT", `{"name":"app","protocol":"TCP","target":"app.example:443"}`, "c2"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown health check, got %d", w.Code)
+	}
+	if w := call(handler.UpdateHealthCheck, "PUT", `{"name":"app","protocol":"TCP","target":"app.example:443"}`, "c1"); w.Code != http.StatusOK || stub.saved.Protocol != domain.HealthCheckProtocolTCP {
+		t.Errorf("Unexpected update %d: %s", w.Code, w.Body.String())
+	}
+	if w := call(handler.DeleteHealthCheck, "DELETE", "", "c1"); w.Code != http.StatusNoContent || stub.deleted != "c1" {
+		t.Errorf("Expected 204 deleting c1, got %d", w.Code)
+	}
+}
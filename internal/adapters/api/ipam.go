@@ -0,0 +1,148 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// writeIPAMError maps subnet and allocation errors to HTTP statuses, and
+// failed changes of an allocation's records as writeMutationError does.
+func writeIPAMError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidSubnet):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		writeMutationError(w, err)
+	}
+}
+
+// ipamTenant checks that IPAM is enabled and returns the caller's tenant,
+// writing the error response if either is missing.
+func (h *APIHandler) ipamTenant(w http.ResponseWriter, r *http.Request, op string) (string, bool) {
+	if h.ipam == nil {
+		http.Error(w, "IPAM is not enabled", http.StatusServiceUnavailable)
+		return "", false
+	}
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("%s: missing or invalid tenant ID in context", op)
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return "", false
+	}
+	return tenantID, true
+}
+
+// ListSubnets returns the tenant's subnets.
+func (h *APIHandler) ListSubnets(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.ipamTenant(w, r, "ListSubnets")
+	if !ok {
+		return
+	}
+	subnets, err := h.ipam.ListSubnets(r.Context(), tenantID)
+	if err != nil {
+		writeIPAMError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(subnets); err != nil {
+		log.Printf("failed to encode subnets response: %v", err)
+	}
+}
+
+// CreateSubnet adds a subnet; the body is {"name", "cidr", "zone_id",
+// "name_template", "ttl"}.
+func (h *APIHandler) CreateSubnet(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.ipamTenant(w, r, "CreateSubnet")
+	if !ok {
+		return
+	}
+	var subnet domain.Subnet
+	if err := json.NewDecoder(r.Body).Decode(&subnet); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.ipam.CreateSubnet(r.Context(), tenantID, &subnet); err != nil {
+		writeIPAMError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(subnet); err != nil {
+		log.Printf("failed to encode subnet response: %v", err)
+	}
+}
+
+// DeleteSubnet removes a subnet without allocated addresses.
+func (h *APIHandler) DeleteSubnet(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.ipamTenant(w, r, "DeleteSubnet")
+	if !ok {
+		return
+	}
+	if err := h.ipam.DeleteSubnet(r.Context(), tenantID, r.PathValue("id")); err != nil {
+		writeIPAMError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListAllocations returns the addresses allocated from a subnet.
+func (h *APIHandler) ListAllocations(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.ipamTenant(w, r, "ListAllocations")
+	if !ok {
+		return
+	}
+	allocs, err := h.ipam.ListAllocations(r.Context(), tenantID, r.PathValue("id"))
+	if err != nil {
+		writeIPAMError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(allocs); err != nil {
+		log.Printf("failed to encode allocations response: %v", err)
+	}
+}
+
+// AllocateAddress allocates an address from a subnet and creates its records;
+// the body is {"address", "hostname"}, both optional, and an empty address
+// takes the lowest free one.
+func (h *APIHandler) AllocateAddress(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.ipamTenant(w, r, "AllocateAddress")
+	if !ok {
+		return
+	}
+	var alloc domain.IPAllocation
+	if err := json.NewDecoder(r.Body).Decode(&alloc); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	alloc.SubnetID = r.PathValue("id")
+	if err := h.ipam.AllocateAddress(r.Context(), tenantID, &alloc); err != nil {
+		writeIPAMError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(alloc); err != nil {
+		log.Printf("failed to encode allocation response: %v", err)
+	}
+}
+
+// ReleaseAddress releases an allocated address and removes its records.
+func (h *APIHandler) ReleaseAddress(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.ipamTenant(w, r, "ReleaseAddress")
+	if !ok {
+		return
+	}
+	if err := h.ipam.ReleaseAddress(r.Context(), tenantID, r.PathValue("id"), r.PathValue("allocation_id")); err != nil {
+		writeIPAMError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
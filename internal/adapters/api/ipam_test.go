@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/testutil"
+)
+
+type stubIPAM struct {
+	allocated *domain.IPAllocation
+	released  string
+}
+
+func (s *stubIPAM) CreateSubnet(_ context.Context, _ string, subnet *domain.Subnet) error {
+	if subnet.CIDR == "" {
+		return fmt.Errorf("no CIDR: %w", domain.ErrInvalidSubnet)
+	}
+	subnet.ID = "s1"
+	return nil
+}
+
+func (s *stubIPAM) ListSubnets(_ context.Context, _ string) ([]domain.Subnet, error) {
+	return []domain.Subnet{{ID: "s1", Name: "lan", CIDR: "192.0.2.0/24"}}, nil
+}
+
+func (s *stubIPAM) DeleteSubnet(_ context.Context, _ string, id string) error {
+	if id != "s1" {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (s *stubIPAM) AllocateAddress(_ context.Context, _ string, alloc *domain.IPAllocation) error {
+	if alloc.SubnetID != "s1" {
+		return domain.ErrNotFound
+	}
+	if alloc.Hostname == "frozen" {
+		return fmt.Errorf("failed to create the forward record: %w", domain.ErrChangeFrozen)
+	}
+	alloc.ID = "a1"
+	if alloc.Address == "" {
+		alloc.Address = "192.0.2.1"
+	}
+	s.allocated = alloc
+	return nil
+}
+
+func (s *stubIPAM) ListAllocations(_ context.Context, _ string, subnetID string) ([]domain.IPAllocation, error) {
+	if subnetID != "s1" {
+		return nil, domain.ErrNotFound
+	}
+	return []domain.IPAllocation{{ID: "a1", SubnetID: "s1", Address: "192.0.2.1"}}, nil
+}
+
+func (s *stubIPAM) ReleaseAddress(_ context.Context, _ string, subnetID string, id string) error {
+	if subnetID != "s1" || id != "a1" {
+		return domain.ErrNotFound
+	}
+	s.released = id
+	return nil
+}
+
+func TestIPAMEndpoints(t *testing.T) {
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+	call := func(fn http.HandlerFunc, method, body, id, allocID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/subnets", strings.NewReader(body))
+		req.SetPathValue("id", id)
+		req.SetPathValue("allocation_id", allocID)
+		w := httptest.NewRecorder()
+		fn(w, withTenant(req, testTenantID))
+		return w
+	}
+
+	if w := call(handler.ListSubnets, "GET", "", "", ""); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without an IPAM manager, got %d", w.Code)
+	}
+	stub := &stubIPAM{}
+	handler.SetIPAM(stub)
+
+	if w := call(handler.ListSubnets, "GET", "", "", ""); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"cidr":"192.0.2.0/24"`) {
+		t.Errorf("Unexpected listing %d: %s", w.Code, w.Body.String())
+	}
+	if w := call(handler.CreateSubnet, "POST", `{"name":"lan"}`, "", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a subnet without a CIDR, got %d", w.Code)
+	}
+	if w := call(handler.CreateSubnet, "POST", `{"name":"lan","cidr":"192.0.2.0/24","zone_id":"z1"}`, "", ""); w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), `"id":"s1"`) {
+		t.Errorf("Unexpected subnet %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := call(handler.AllocateAddress, "POST", "", "s2", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown subnet, got %d", w.Code)
+	}
+	if w := call(handler.AllocateAddress, "POST", `{"hostname":"frozen"}`, "s1", ""); w.Code != http.StatusLocked {
+		t.Errorf("Expected 423 when a freeze blocks the records, got %d", w.Code)
+	}
+	if w := call(handler.AllocateAddress, "POST", "", "s1", ""); w.Code != http.StatusCreated || stub.allocated.Address != "192.0.2.1" {
+		t.Errorf("Expected the next free address without a body, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := call(handler.AllocateAddress, "POST", `{"address":"192.0.2.9","hostname":"db"}`, "s1", ""); w.Code != http.StatusCreated || stub.allocated.Hostname != "db" {
+		t.Errorf("Unexpected allocation %d: %s", w.Code, w.Body.String())
+	}
+	if w := call(handler.ListAllocations, "GET", "", "s1", ""); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"address":"192.0.2.1"`) {
+		t.Errorf("Unexpected allocations %d: %s", w.Code, w.Body.String())
+	}
+	if w := call(handler.ReleaseAddress, "DELETE", "", "s1", "a1"); w.Code != http.StatusNoContent || stub.released != "a1" {
+		t.Errorf("Expected 204 releasing a1, got %d", w.Code)
+	}
+	if w := call(handler.DeleteSubnet, "DELETE", "", "s1", ""); w.Code != http.StatusNoContent {
+		t.Errorf("Expected 204 deleting s1, got %d", w.Code)
+	}
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// FlushCache empties the response caches of this node.
+func (h *APIHandler) FlushCache(w http.ResponseWriter, r *http.Request) {
+	if h.maintenance == nil {
+		http.Error(w, "Node maintenance is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	if err := h.maintenance.FlushCache(r.Context()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "flushed"}); err != nil {
+		log.Printf("failed to encode cache flush response: %v", err)
+	}
+}
+
+// DrainNode takes this node's DNS server out of service. It returns once the
+// in-flight queries have been answered.
+func (h *APIHandler) DrainNode(w http.ResponseWriter, r *http.Request) {
+	if h.maintenance == nil {
+		http.Error(w, "Node maintenance is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	if err := h.maintenance.Drain(r.Context()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "drained"}); err != nil {
+		log.Printf("failed to encode drain response: %v", err)
+	}
+}
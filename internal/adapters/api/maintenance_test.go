@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/testutil"
+)
+
+type stubMaintainer struct {
+	flushed, drained bool
+	err              error
+}
+
+func (s *stubMaintainer) FlushCache(_ context.Context) error {
+	s.flushed = true
+	return s.err
+}
+
+func (s *stubMaintainer) Drain(_ context.Context) error {
+	s.drained = true
+	return s.err
+}
+
+func TestMaintenanceEndpoints(t *testing.T) {
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+	call := func(fn http.HandlerFunc, target string) int {
+		w := httptest.NewRecorder()
+		fn(w, httptest.NewRequest("POST", target, nil))
+		return w.Code
+	}
+
+	if code := call(handler.FlushCache, "/node/cache/flush"); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a maintainer, got %d", code)
+	}
+
+	node := &stubMaintainer{}
+	handler.SetMaintenance(node)
+	if code := call(handler.FlushCache, "/node/cache/flush"); code != http.StatusOK || !node.flushed {
+		t.Errorf("Expected the cache to be flushed, got %d", code)
+	}
+	if code := call(handler.DrainNode, "/node/drain"); code != http.StatusOK || !node.drained {
+		t.Errorf("Expected the node to be drained, got %d", code)
+	}
+
+	node.err = errors.New("drain timed out")
+	if code := call(handler.DrainNode, "/node/drain"); code != http.StatusInternalServerError {
+		t.Errorf("Expected 500 for a failed drain, got %d", code)
+	}
+}
+
+func TestSeparateAdminRoutes(t *testing.T) {
+	routed := func(mux *http.ServeMux, method, target string) bool {
+		_, pattern := mux.Handler(httptest.NewRequest(method, target, nil))
+		return pattern != ""
+	}
+
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	if !routed(mux, "POST", "/node/drain") || !routed(mux, "GET", "/zones/z1/dnssec/keys/k1/export") {
+		t.Error("Expected admin routes on the main API by default")
+	}
+
+	handler.SetSeparateAdmin(true)
+	mux, adminMux := http.NewServeMux(), http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	handler.RegisterAdminRoutes(adminMux)
+	for _, route := range [][2]string{
+		{"POST", "/node/drain"},
+		{"POST", "/node/cache/flush"},
+		{"POST", "/zones/z1/dnssec/rollover"},
+		{"GET", "/zones/z1/dnssec/keys/k1/export"},
+	} {
+		if routed(mux, route[0], route[1]) {
+			t.Errorf("%s %s is still served by the main API", route[0], route[1])
+		}
+		if !routed(adminMux, route[0], route[1]) {
+			t.Errorf("%s %s is not served by the admin listener", route[0], route[1])
+		}
+	}
+	if !routed(mux, "GET", "/zones/z1/dnssec/keys") {
+		t.Error("Expected read-only key listing to stay on the main API")
+	}
+}
@@ -1,15 +1,23 @@
 package api
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/poyrazK/cloudDNS/internal/core/domain"
 	"github.com/poyrazK/cloudDNS/internal/core/ports"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
 )
 
 type contextKey string
@@ -17,8 +25,15 @@ type contextKey string
 const (
 	CtxTenantID contextKey = "tenant_id"
 	CtxRole     contextKey = "role"
+	CtxAPIKey   contextKey = "api_key"
 )
 
+// hashAPIKey returns the stored form of a raw API key.
+func hashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
 func AuthMiddleware(repo ports.DNSRepository) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -33,10 +48,7 @@ func AuthMiddleware(repo ports.DNSRepository) func(http.Handler) http.Handler {
 				http.Error(w, "Unauthorized: missing or invalid authorization header", http.StatusUnauthorized)
 				return
 			}
-			hash := sha256.Sum256([]byte(key))
-			keyHash := hex.EncodeToString(hash[:])
-
-			apiKey, err := repo.GetAPIKeyByHash(r.Context(), keyHash)
+			apiKey, err := repo.GetAPIKeyByHash(r.Context(), hashAPIKey(key))
 			if err != nil {
 				http.Error(w, "Internal server error", http.StatusInternalServerError)
 				return
@@ -54,6 +66,10 @@ func AuthMiddleware(repo ports.DNSRepository) func(http.Handler) http.Handler {
 
 			ctx := context.WithValue(r.Context(), CtxTenantID, apiKey.TenantID)
 			ctx = context.WithValue(ctx, CtxRole, apiKey.Role)
+			ctx = context.WithValue(ctx, CtxAPIKey, apiKey)
+			if apiKey.Role == domain.RoleBreakGlass {
+				ctx = domain.WithBreakGlass(ctx)
+			}
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
@@ -86,3 +102,207 @@ func RequireRole(roles ...domain.Role) func(http.Handler) http.Handler {
 		})
 	}
 }
+
+// UsageMiddleware wraps auth so that usage sees the status of every request made
+// with an API key, and every request auth rejects as unauthorized.
+func UsageMiddleware(auth func(http.Handler) http.Handler, usage ports.APIUsageTracker) func(http.Handler) http.Handler {
+	return observeAuth(auth, func(r *http.Request, key *domain.APIKey, status int) {
+		switch {
+		case key != nil:
+			usage.RecordRequest(*key, status)
+		case status == http.StatusUnauthorized:
+			usage.RecordAuthFailure(requestClientIP(r), presentedKeyPrefix(r))
+		}
+	})
+}
+
+// LockoutMiddleware wraps auth so that clients banned by lockout for presenting
+// too many invalid API keys are turned away before their key is checked.
+func LockoutMiddleware(auth func(http.Handler) http.Handler, lockout ports.AuthLockout) func(http.Handler) http.Handler {
+	observed := observeAuth(auth, func(r *http.Request, key *domain.APIKey, status int) {
+		switch {
+		case key != nil:
+			lockout.RecordSuccess(requestClientIP(r))
+		case status == http.StatusUnauthorized:
+			lockout.RecordFailure(requestClientIP(r))
+		}
+	})
+	return func(next http.Handler) http.Handler {
+		checked := observed(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if wait, banned := lockout.Banned(requestClientIP(r)); banned {
+				metrics.APIAuthBannedRequests.Inc()
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				http.Error(w, "Too many failed authentication attempts", http.StatusTooManyRequests)
+				return
+			}
+			checked.ServeHTTP(w, r)
+		})
+	}
+}
+
+// maxAuthorizationBody is the largest request body passed to the authorizer.
+const maxAuthorizationBody = 1 << 20
+
+// AuthorizeMiddleware puts authenticated requests to authorizer and refuses
+// those it denies, and all of them while it cannot decide. Only requests that
+// change something are put to it unless reads is set.
+func AuthorizeMiddleware(authorizer ports.Authorizer, reads bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !reads && (r.Method == http.MethodGet || r.Method == http.MethodHead) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			key, ok := r.Context().Value(CtxAPIKey).(*domain.APIKey)
+			if !ok {
+				http.Error(w, "Forbidden: API key not found in context", http.StatusForbidden)
+				return
+			}
+			body, omitted, err := authorizationBody(r)
+			if err != nil {
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+			decision, err := authorizer.Authorize(r.Context(), domain.AuthorizationRequest{
+				TenantID:    key.TenantID,
+				KeyID:       key.ID,
+				KeyName:     key.Name,
+				Role:        key.Role,
+				Action:      r.Pattern,
+				Resource:    r.URL.Path,
+				Params:      routeParams(r),
+				ClientIP:    requestClientIP(r),
+				ContentType: r.Header.Get("Content-Type"),
+				Body:        body,
+				BodyOmitted: omitted,
+			})
+			if err != nil {
+				metrics.APIAuthorizationDecisions.WithLabelValues("error").Inc()
+				log.Printf("External authorization failed for %s: %v", r.Pattern, err)
+				http.Error(w, "Authorization service unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			if !decision.Allowed {
+				metrics.APIAuthorizationDecisions.WithLabelValues("denied").Inc()
+				msg := "Forbidden: denied by policy"
+				if decision.Reason != "" {
+					msg += ": " + decision.Reason
+				}
+				http.Error(w, msg, http.StatusForbidden)
+				return
+			}
+			metrics.APIAuthorizationDecisions.WithLabelValues("allowed").Inc()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// authorizationBody returns the body of r for the authorizer if it is a JSON
+// document of at most maxAuthorizationBody bytes, leaving it to be read again
+// by the handler. It reports whether r has a body that is left out instead.
+func authorizationBody(r *http.Request) (json.RawMessage, bool, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, false, nil
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxAuthorizationBody+1))
+	if err != nil {
+		return nil, false, err
+	}
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), r.Body), r.Body}
+	if len(data) == 0 {
+		return nil, false, nil
+	}
+	if len(data) > maxAuthorizationBody || !json.Valid(data) {
+		return nil, true, nil
+	}
+	return data, false, nil
+}
+
+// routeParams returns the values of the wildcards of the route r matched.
+func routeParams(r *http.Request) map[string]string {
+	params := make(map[string]string)
+	for rest := r.Pattern; ; {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			break
+		}
+		if name := strings.TrimSuffix(rest[start+1:start+end], "..."); name != "$" {
+			params[name] = r.PathValue(name)
+		}
+		rest = rest[start+end+1:]
+	}
+	return params
+}
+
+// observeAuth wraps auth so that observe sees the outcome of every request: the
+// API key it was authenticated with, nil if it was not, and the response status.
+func observeAuth(auth func(http.Handler) http.Handler, observe func(r *http.Request, key *domain.APIKey, status int)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		inner := auth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, _ := r.Context().Value(CtxAPIKey).(*domain.APIKey)
+			// Observers may be nested, each with its own writer
+			for sw, ok := w.(*statusWriter); ok; sw, ok = sw.ResponseWriter.(*statusWriter) {
+				sw.key = key
+			}
+			next.ServeHTTP(w, r)
+		}))
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			inner.ServeHTTP(sw, r)
+			observe(r, sw.key, sw.status)
+		})
+	}
+}
+
+// requestClientIP returns the address of the client that sent r.
+func requestClientIP(r *http.Request) string {
+	clientIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return clientIP
+}
+
+// statusWriter records the status of a response and the API key it was made with.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	key         *domain.APIKey
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status, w.wroteHeader = status, true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap gives http.ResponseController access to the underlying writer, which
+// the streaming endpoints flush through.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// presentedKeyPrefix returns the identifying prefix of the bearer key of r, as
+// stored in APIKey.KeyPrefix, without revealing the rest of it.
+func presentedKeyPrefix(r *http.Request) string {
+	key := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if len(key) < 8 {
+		return ""
+	}
+	return key[:8]
+}
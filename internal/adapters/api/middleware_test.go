@@ -5,8 +5,10 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -163,3 +165,64 @@ func TestRequireRole(t *testing.T) {
 		}
 	})
 }
+
+type stubAuthorizer struct {
+	got      domain.AuthorizationRequest
+	decision domain.AuthorizationDecision
+	err      error
+}
+
+func (a *stubAuthorizer) Authorize(_ context.Context, req domain.AuthorizationRequest) (domain.AuthorizationDecision, error) {
+	a.got = req
+	return a.decision, a.err
+}
+
+func TestAuthorizeMiddleware(t *testing.T) {
+	authz := &stubAuthorizer{}
+	var handled string
+	mux := http.NewServeMux()
+	mux.Handle("/zones/{id}/records", AuthorizeMiddleware(authz, false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		handled = string(body)
+	})))
+	call := func(method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/zones/z1/records", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req = req.WithContext(context.WithValue(req.Context(), CtxAPIKey, &domain.APIKey{ID: "k1", TenantID: "t1", Role: domain.RoleAdmin}))
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		return rr
+	}
+
+	authz.decision = domain.AuthorizationDecision{Allowed: false, Reason: "change ticket required"}
+	if rr := call("GET", ""); rr.Code != http.StatusOK {
+		t.Errorf("Expected reads to skip the authorizer, got %d", rr.Code)
+	}
+	rr := call("POST", `{"name":"www","type":"A"}`)
+	if rr.Code != http.StatusForbidden || !strings.Contains(rr.Body.String(), "change ticket required") {
+		t.Errorf("Expected a denial with its reason, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if authz.got.Action != "/zones/{id}/records" || authz.got.Params["id"] != "z1" || authz.got.TenantID != "t1" || string(authz.got.Body) != `{"name":"www","type":"A"}` || authz.got.ContentType != "application/json" {
+		t.Errorf("Unexpected authorization request %+v", authz.got)
+	}
+
+	authz.decision = domain.AuthorizationDecision{Allowed: true}
+	if rr := call("POST", `{"name":"www"}`); rr.Code != http.StatusOK || handled != `{"name":"www"}` {
+		t.Errorf("Expected the handler to read the body after approval, got %d with %q", rr.Code, handled)
+	}
+	if rr := call("POST", "not json"); rr.Code != http.StatusOK || authz.got.Body != nil || !authz.got.BodyOmitted || handled != "not json" {
+		t.Errorf("Expected a non-JSON body to be left out and flagged, got %d with %+v", rr.Code, authz.got)
+	}
+	large := `{"pad":"` + strings.Repeat("x", maxAuthorizationBody) + `"}`
+	if rr := call("POST", large); rr.Code != http.StatusOK || authz.got.Body != nil || !authz.got.BodyOmitted || handled != large {
+		t.Errorf("Expected a body over the limit to be left out and flagged, got %d with omitted %v", rr.Code, authz.got.BodyOmitted)
+	}
+	if rr := call("DELETE", ""); rr.Code != http.StatusOK || authz.got.BodyOmitted {
+		t.Errorf("Expected a request without a body not to be flagged, got %d with %+v", rr.Code, authz.got)
+	}
+
+	authz.err = errors.New("connection refused")
+	if rr := call("DELETE", ""); rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 while the authorizer cannot decide, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// CheckRecordPropagation asks external resolvers and the fleet's nodes for a
+// record and reports where it is visible and with what remaining TTL.
+func (h *APIHandler) CheckRecordPropagation(w http.ResponseWriter, r *http.Request) {
+	if h.propagation == nil {
+		http.Error(w, "Propagation checks are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("CheckRecordPropagation: missing or invalid tenant ID in context")
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return
+	}
+
+	report, err := h.propagation.CheckRecordPropagation(r.Context(), r.PathValue("id"), tenantID, r.PathValue("rid"))
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("failed to encode propagation response: %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/testutil"
+)
+
+type stubPropagation struct{}
+
+func (stubPropagation) CheckRecordPropagation(_ context.Context, zoneID, _, recordID string) (*domain.PropagationReport, error) {
+	if zoneID != "z1" || recordID != "r1" {
+		return nil, domain.ErrNotFound
+	}
+	return &domain.PropagationReport{RecordID: "r1", Propagated: 1, Total: 2, Results: []domain.PropagationResult{
+		{Resolver: "8.8.8.8:53", Kind: "resolver", Status: domain.PropagationVisible, TTL: 120},
+		{Resolver: "1.1.1.1:53", Kind: "resolver", Status: domain.PropagationDifferent},
+	}}, nil
+}
+
+func TestCheckRecordPropagation(t *testing.T) {
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+	call := func(rid string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/zones/z1/records/"+rid+"/check", nil)
+		req.SetPathValue("id", "z1")
+		req.SetPathValue("rid", rid)
+		w := httptest.NewRecorder()
+		handler.CheckRecordPropagation(w, withTenant(req, testTenantID))
+		return w
+	}
+
+	if w := call("r1"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a checker, got %d", w.Code)
+	}
+	handler.SetPropagation(stubPropagation{})
+	if w := call("r9"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown record, got %d", w.Code)
+	}
+	w := call("r1")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"propagated":1`) || !strings.Contains(w.Body.String(), `"status":"different"`) {
+		t.Errorf("Unexpected report %d: %s", w.Code, w.Body.String())
+	}
+}
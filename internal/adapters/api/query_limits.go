@@ -0,0 +1,31 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// ListQueryLimits returns the query rate limits set on the caller's tenant and
+// its zones. Operators set them with clouddnsctl, so tenants can only read them.
+func (h *APIHandler) ListQueryLimits(w http.ResponseWriter, r *http.Request) {
+	if h.limits == nil {
+		http.Error(w, "Query limits are not available", http.StatusServiceUnavailable)
+		return
+	}
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("ListQueryLimits: missing or invalid tenant ID in context")
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return
+	}
+	limits, err := h.limits.ListQueryLimits(r.Context(), tenantID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(limits); err != nil {
+		log.Printf("failed to encode query limits response: %v", err)
+	}
+}
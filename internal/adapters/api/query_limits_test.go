@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/testutil"
+)
+
+type stubQueryLimits struct {
+	tenantID string
+}
+
+func (s *stubQueryLimits) SetQueryLimit(_ context.Context, _ *domain.QueryLimit) error {
+	return nil
+}
+
+func (s *stubQueryLimits) ListQueryLimits(_ context.Context, tenantID string) ([]domain.QueryLimit, error) {
+	s.tenantID = tenantID
+	return []domain.QueryLimit{{TenantID: tenantID, QPS: 100, Burst: 200}}, nil
+}
+
+func (s *stubQueryLimits) DeleteQueryLimit(_ context.Context, _, _ string) error {
+	return nil
+}
+
+func TestListQueryLimits(t *testing.T) {
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+	call := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ListQueryLimits(w, withTenant(httptest.NewRequest("GET", "/query-limits", nil), testTenantID))
+		return w
+	}
+	if w := call(); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without query limits, got %d", w.Code)
+	}
+
+	stub := &stubQueryLimits{}
+	handler.SetQueryLimits(stub)
+	w := call()
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var limits []domain.QueryLimit
+	if err := json.NewDecoder(w.Body).Decode(&limits); err != nil || len(limits) != 1 || limits[0].QPS != 100 {
+		t.Errorf("Unexpected limits %+v, %v", limits, err)
+	}
+	if stub.tenantID != testTenantID {
+		t.Errorf("Expected the caller's tenant to be listed, got %q", stub.tenantID)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ListQueryLimits(w, httptest.NewRequest("GET", "/query-limits", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a tenant, got %d", w.Code)
+	}
+}
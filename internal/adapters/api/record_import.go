@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// ImportRecords adds records to a zone from a CSV or JSON upload. The format
+// comes from ?format=csv|json or else the request's Content-Type, and
+// ?dry_run=true only validates. An import with invalid rows writes nothing and
+// answers 422 with the result of every row.
+func (h *APIHandler) ImportRecords(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("ImportRecords: missing or invalid tenant ID in context")
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = importFormat(r.Header.Get("Content-Type"))
+	}
+	dryRun := false
+	if v := r.URL.Query().Get("dry_run"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, "dry_run must be a boolean", http.StatusBadRequest)
+			return
+		}
+		dryRun = parsed
+	}
+
+	result, err := h.svc.ImportRecords(r.Context(), r.PathValue("id"), tenantID, format, r.Body, dryRun)
+	switch {
+	case err == nil:
+	case errors.Is(err, domain.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, domain.ErrInvalidRecordImport) && result != nil:
+	case errors.Is(err, domain.ErrInvalidRecordImport):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	default:
+		writeMutationError(w, err)
+		return
+	}
+
+	status := http.StatusCreated
+	if err != nil {
+		status = http.StatusUnprocessableEntity
+	} else if dryRun {
+		status = http.StatusOK
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		log.Printf("failed to encode import result: %v", err)
+	}
+}
+
+// importFormat maps an upload's Content-Type to an import format.
+func importFormat(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	switch mediaType {
+	case "text/csv", "application/csv":
+		return "csv"
+	case "application/json":
+		return "json"
+	}
+	return ""
+}
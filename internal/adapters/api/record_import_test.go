@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/testutil"
+)
+
+// importRecordingService records the format an import was called with and can
+// report invalid rows.
+type importRecordingService struct {
+	*mockDNSService
+	format  string
+	invalid bool
+}
+
+func (m *importRecordingService) ImportRecords(ctx context.Context, zoneID, tenantID, format string, r io.Reader, dryRun bool) (*domain.RecordImportResult, error) {
+	m.format = format
+	if m.invalid {
+		return &domain.RecordImportResult{ZoneID: zoneID, Invalid: 1, Rows: []domain.RecordImportRow{{Row: 1, Status: "invalid", Error: "bad"}}},
+			fmt.Errorf("%w: 1 of 1 rows are invalid", domain.ErrInvalidRecordImport)
+	}
+	return m.mockDNSService.ImportRecords(ctx, zoneID, tenantID, format, r, dryRun)
+}
+
+func TestImportRecordsHandler(t *testing.T) {
+	svc := &importRecordingService{mockDNSService: &mockDNSService{}}
+	handler := NewAPIHandler(svc, &testutil.MockRepo{})
+
+	send := func(query, contentType string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/zones/z1/records/import"+query, strings.NewReader("type,content\nA,192.0.2.1\n"))
+		req.Header.Set("Content-Type", contentType)
+		req.SetPathValue("id", "z1")
+		req = withTenant(req, testTenantID)
+		w := httptest.NewRecorder()
+		handler.ImportRecords(w, req)
+		return w
+	}
+
+	w := send("", "text/csv; charset=utf-8")
+	var res domain.RecordImportResult
+	if w.Code != http.StatusCreated || json.Unmarshal(w.Body.Bytes(), &res) != nil || res.Created != 1 {
+		t.Fatalf("Expected 201 with the result, got %d %s", w.Code, w.Body.String())
+	}
+	if svc.format != "csv" {
+		t.Errorf("Expected the format from the Content-Type, got %q", svc.format)
+	}
+	if w := send("?format=json&dry_run=true", "text/plain"); w.Code != http.StatusOK || svc.format != "json" {
+		t.Errorf("Expected 200 for a dry run in the requested format, got %d (%q)", w.Code, svc.format)
+	}
+	if w := send("?dry_run=maybe", "text/csv"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a bad dry_run, got %d", w.Code)
+	}
+
+	svc.invalid = true
+	w = send("", "text/csv")
+	if w.Code != http.StatusUnprocessableEntity || json.Unmarshal(w.Body.Bytes(), &res) != nil || res.Rows[0].Error != "bad" {
+		t.Errorf("Expected 422 with the row errors, got %d %s", w.Code, w.Body.String())
+	}
+
+	svc.invalid = false
+	svc.err = fmt.Errorf("%w: unsupported format", domain.ErrInvalidRecordImport)
+	if w := send("", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unreadable upload, got %d", w.Code)
+	}
+	svc.err = fmt.Errorf("zone z1: %w", domain.ErrNotFound)
+	if w := send("", "text/csv"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown zone, got %d", w.Code)
+	}
+}
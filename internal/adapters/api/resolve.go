@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// TraceQuery answers GET /resolve?name=&type=&client_ip= by running the query
+// through this node's resolution pipeline as if it came from client_ip, and
+// returns every step along with the final answer. type defaults to A and
+// dnssec=true sets the DO bit. format=text renders the trace as plain text,
+// one line per step and record.
+func (h *APIHandler) TraceQuery(w http.ResponseWriter, r *http.Request) {
+	if h.tracer == nil {
+		http.Error(w, "Query tracing is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	q := r.URL.Query()
+	qType := domain.RecordType(strings.ToUpper(q.Get("type")))
+	if qType == "" {
+		qType = domain.TypeA
+	}
+	dnssec := false
+	if v := q.Get("dnssec"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, "dnssec must be a boolean", http.StatusBadRequest)
+			return
+		}
+		dnssec = parsed
+	}
+	format := q.Get("format")
+	if format != "" && format != "json" && format != "text" {
+		http.Error(w, "format must be json or text", http.StatusBadRequest)
+		return
+	}
+
+	trace, err := h.tracer.TraceQuery(r.Context(), q.Get("name"), qType, q.Get("client_ip"), dnssec)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidQueryTrace) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if format == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if _, err := w.Write([]byte(formatQueryTrace(trace))); err != nil {
+			log.Printf("failed to write query trace: %v", err)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(trace); err != nil {
+		log.Printf("failed to encode query trace: %v", err)
+	}
+}
+
+// formatQueryTrace renders a trace in the style of dig: the steps as comments,
+// then the records of each section.
+func formatQueryTrace(t *domain.QueryTrace) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, ";; %s %s from %s (dnssec %t): %s in %.3f ms\n", t.Name, t.Type, t.ClientIP, t.DNSSEC, t.Rcode, t.DurationMS)
+	for i, step := range t.Steps {
+		fmt.Fprintf(&b, ";; %2d. %-10s %s\n", i+1, step.Stage, step.Detail)
+	}
+	for _, e := range t.Errors {
+		fmt.Fprintf(&b, ";; %s\n", e)
+	}
+	for _, section := range []struct {
+		name    string
+		records []string
+	}{{"ANSWER", t.Answers}, {"AUTHORITY", t.Authorities}, {"ADDITIONAL", t.Additionals}} {
+		if len(section.records) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n;; %s SECTION:\n", section.name)
+		for _, rec := range section.records {
+			b.WriteString(rec)
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/testutil"
+)
+
+// stubTracer records the query it traces and answers with a fixed trace.
+type stubTracer struct {
+	name, clientIP string
+	qType          domain.RecordType
+	dnssec         bool
+}
+
+func (s *stubTracer) TraceQuery(_ context.Context, name string, qType domain.RecordType, clientIP string, dnssec bool) (*domain.QueryTrace, error) {
+	if name == "bad." {
+		return nil, fmt.Errorf("%w: invalid client IP", domain.ErrInvalidQueryTrace)
+	}
+	s.name, s.qType, s.clientIP, s.dnssec = name, qType, clientIP, dnssec
+	return &domain.QueryTrace{
+		Name: name, Type: qType, ClientIP: clientIP, Rcode: "NOERROR",
+		Steps:   []domain.QueryTraceStep{{Stage: "view", Detail: "client sees the split-horizon records of 10.0.0.0/8"}},
+		Answers: []string{"www.example.com. 300 IN A 10.1.1.1"},
+	}, nil
+}
+
+func TestTraceQueryHandler(t *testing.T) {
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+
+	w := httptest.NewRecorder()
+	handler.TraceQuery(w, httptest.NewRequest("GET", "/resolve?name=www.example.com.", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a tracer, got %d", w.Code)
+	}
+
+	stub := &stubTracer{}
+	handler.SetQueryTracer(stub)
+	w = httptest.NewRecorder()
+	handler.TraceQuery(w, httptest.NewRequest("GET", "/resolve?name=www.example.com.&client_ip=10.2.3.4&dnssec=true", nil))
+	var trace domain.QueryTrace
+	if w.Code != http.StatusOK || json.NewDecoder(w.Body).Decode(&trace) != nil || trace.Answers[0] != "www.example.com. 300 IN A 10.1.1.1" {
+		t.Fatalf("Unexpected response %d: %+v", w.Code, trace)
+	}
+	if stub.name != "www.example.com." || stub.qType != domain.TypeA || stub.clientIP != "10.2.3.4" || !stub.dnssec {
+		t.Errorf("Expected the query passed through with type A by default, got %+v", stub)
+	}
+
+	w = httptest.NewRecorder()
+	handler.TraceQuery(w, httptest.NewRequest("GET", "/resolve?name=www.example.com.&type=aaaa&client_ip=10.2.3.4&format=text", nil))
+	body := w.Body.String()
+	if w.Code != http.StatusOK || stub.qType != domain.TypeAAAA || !strings.Contains(body, ";;  1. view") || !strings.Contains(body, "ANSWER SECTION:\nwww.example.com. 300 IN A 10.1.1.1\n") {
+		t.Errorf("Unexpected text trace %d:\n%s", w.Code, body)
+	}
+
+	for _, query := range []string{"name=bad.&client_ip=x", "name=a.&dnssec=maybe", "name=a.&format=xml"} {
+		w = httptest.NewRecorder()
+		handler.TraceQuery(w, httptest.NewRequest("GET", "/resolve?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, w.Code)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// StartRollout begins a staged node configuration rollout across the fleet, or
+// with zone_id set, a staged rollout of the zone's shadow change.
+func (h *APIHandler) StartRollout(w http.ResponseWriter, r *http.Request) {
+	if h.rollouts == nil {
+		http.Error(w, "Rollouts are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	var spec domain.Rollout
+	if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rollout, err := h.rollouts.StartRollout(r.Context(), &spec)
+	if errors.Is(err, domain.ErrInvalidRollout) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(rollout); err != nil {
+		log.Printf("failed to encode rollout response: %v", err)
+	}
+}
+
+// ListRollouts returns all rollouts with their progress.
+func (h *APIHandler) ListRollouts(w http.ResponseWriter, r *http.Request) {
+	if h.rollouts == nil {
+		http.Error(w, "Rollouts are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	rollouts, err := h.rollouts.ListRollouts(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if rollouts == nil {
+		rollouts = []domain.Rollout{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(rollouts); err != nil {
+		log.Printf("failed to encode rollouts response: %v", err)
+	}
+}
+
+// GetRollout returns a single rollout.
+func (h *APIHandler) GetRollout(w http.ResponseWriter, r *http.Request) {
+	if h.rollouts == nil {
+		http.Error(w, "Rollouts are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	rollout, err := h.rollouts.GetRollout(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if rollout == nil {
+		http.Error(w, "Rollout not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(rollout); err != nil {
+		log.Printf("failed to encode rollout response: %v", err)
+	}
+}
+
+// AbortRollout halts a running rollout and rolls back the nodes it updated.
+func (h *APIHandler) AbortRollout(w http.ResponseWriter, r *http.Request) {
+	if h.rollouts == nil {
+		http.Error(w, "Rollouts are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	err := h.rollouts.AbortRollout(r.Context(), r.PathValue("id"))
+	if errors.Is(err, domain.ErrInvalidRollout) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
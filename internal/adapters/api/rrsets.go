@@ -0,0 +1,123 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// writeRRsetError maps RRset errors to HTTP statuses.
+func writeRRsetError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidRRset):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrChangeFrozen):
+		http.Error(w, err.Error(), http.StatusLocked)
+	case errors.Is(err, domain.ErrReviewRequired):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, domain.ErrRecordProtected):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// GetRRset returns every record of one name and type in a zone. The name may be
+// absolute, relative to the zone or "@" for the apex.
+func (h *APIHandler) GetRRset(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("GetRRset: missing or invalid tenant ID in context")
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return
+	}
+
+	rrset, err := h.svc.GetRRset(r.Context(), r.PathValue("id"), tenantID, r.PathValue("name"), domain.RecordType(r.PathValue("type")))
+	if err != nil {
+		writeRRsetError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(rrset); err != nil {
+		log.Printf("failed to encode rrset response: %v", err)
+	}
+}
+
+// ReplaceRRset atomically replaces one name and type in a zone with the records
+// in the body, {"ttl", "records": [{"content", ...}]}. An empty records list
+// deletes the RRset.
+func (h *APIHandler) ReplaceRRset(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TTL     int                  `json:"ttl"`
+		Records []domain.RRsetRecord `json:"records"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("ReplaceRRset: missing or invalid tenant ID in context")
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return
+	}
+
+	rrset := &domain.RRset{
+		Name:    r.PathValue("name"),
+		Type:    domain.RecordType(r.PathValue("type")),
+		TTL:     req.TTL,
+		Records: req.Records,
+	}
+	ctx := r.Context()
+	if r.URL.Query().Get("force") == "true" {
+		ctx = domain.WithForce(ctx)
+	}
+	updated, err := h.svc.ReplaceRRset(ctx, r.PathValue("id"), tenantID, rrset)
+	if err != nil {
+		change := &domain.ChangeRequest{Action: domain.ChangeReplaceRRset, RRset: rrset, Force: domain.IsForced(ctx)}
+		if !h.submitForReview(w, r, r.PathValue("id"), tenantID, err, change) {
+			writeRRsetError(w, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(updated); err != nil {
+		log.Printf("failed to encode rrset response: %v", err)
+	}
+}
+
+// DiffZone lists the RRsets added, removed or changed relative to the zone or
+// SOA serial given by the against parameter.
+func (h *APIHandler) DiffZone(w http.ResponseWriter, r *http.Request) {
+	against := r.URL.Query().Get("against")
+	if against == "" {
+		http.Error(w, "against is required: a zone ID or an SOA serial", http.StatusBadRequest)
+		return
+	}
+
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("DiffZone: missing or invalid tenant ID in context")
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return
+	}
+
+	diff, err := h.svc.DiffZone(r.Context(), r.PathValue("id"), tenantID, against)
+	if err != nil {
+		writeRRsetError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(diff); err != nil {
+		log.Printf("failed to encode zone diff response: %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// writeScheduleError maps scheduled change errors to HTTP statuses.
+func writeScheduleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidScheduledChange):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// scheduleTenant checks that scheduled changes are enabled and returns the
+// caller's tenant, writing the error response if either is missing.
+func (h *APIHandler) scheduleTenant(w http.ResponseWriter, r *http.Request, op string) (string, bool) {
+	if h.schedules == nil {
+		http.Error(w, "Scheduled changes are not enabled", http.StatusServiceUnavailable)
+		return "", false
+	}
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("%s: missing or invalid tenant ID in context", op)
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return "", false
+	}
+	return tenantID, true
+}
+
+// ListScheduledChanges returns a zone's scheduled record changes and their outcome.
+func (h *APIHandler) ListScheduledChanges(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.scheduleTenant(w, r, "ListScheduledChanges")
+	if !ok {
+		return
+	}
+	changes, err := h.schedules.ListScheduledChanges(r.Context(), r.PathValue("id"), tenantID)
+	if err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(changes); err != nil {
+		log.Printf("failed to encode scheduled changes response: %v", err)
+	}
+}
+
+// ScheduleChange schedules a record change for later; the body is
+// {"action": "CREATE"|"UPDATE"|"DELETE", "run_at", "record_id", "record"}.
+func (h *APIHandler) ScheduleChange(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.scheduleTenant(w, r, "ScheduleChange")
+	if !ok {
+		return
+	}
+	var change domain.ScheduledChange
+	if err := json.NewDecoder(r.Body).Decode(&change); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.schedules.ScheduleChange(r.Context(), r.PathValue("id"), tenantID, &change); err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(change); err != nil {
+		log.Printf("failed to encode scheduled change response: %v", err)
+	}
+}
+
+// CancelScheduledChange cancels a pending scheduled change.
+func (h *APIHandler) CancelScheduledChange(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.scheduleTenant(w, r, "CancelScheduledChange")
+	if !ok {
+		return
+	}
+	if err := h.schedules.CancelScheduledChange(r.Context(), r.PathValue("id"), tenantID, r.PathValue("change_id")); err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
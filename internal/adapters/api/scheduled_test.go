@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/testutil"
+)
+
+type stubSchedules struct {
+	scheduled *domain.ScheduledChange
+	cancelled string
+}
+
+func (s *stubSchedules) ScheduleChange(_ context.Context, zoneID, _ string, c *domain.ScheduledChange) error {
+	if zoneID != "z1" {
+		return domain.ErrNotFound
+	}
+	if c.Action != domain.ScheduledCreate {
+		return fmt.Errorf("action %q: %w", c.Action, domain.ErrInvalidScheduledChange)
+	}
+	c.ID = "sc1"
+	c.Status = domain.ScheduledPending
+	s.scheduled = c
+	return nil
+}
+
+func (s *stubSchedules) ListScheduledChanges(_ context.Context, zoneID, _ string) ([]domain.ScheduledChange, error) {
+	if zoneID != "z1" {
+		return nil, domain.ErrNotFound
+	}
+	return []domain.ScheduledChange{{ID: "sc1", ZoneID: "z1", Action: domain.ScheduledDelete, RecordID: "r1", Status: domain.ScheduledPending}}, nil
+}
+
+func (s *stubSchedules) CancelScheduledChange(_ context.Context, _, _ string, id string) error {
+	if id != "sc1" {
+		return domain.ErrInvalidScheduledChange
+	}
+	s.cancelled = id
+	return nil
+}
+
+func TestScheduledChangeEndpoints(t *testing.T) {
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+	call := func(fn http.HandlerFunc, method, body string, path map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/zones/z1/scheduled-changes", strings.NewReader(body))
+		for k, v := range path {
+			req.SetPathValue(k, v)
+		}
+		w := httptest.NewRecorder()
+		fn(w, withTenant(req, testTenantID))
+		return w
+	}
+	z1 := map[string]string{"id": "z1"}
+
+	if w := call(handler.ListScheduledChanges, "GET", "", z1); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a scheduler, got %d", w.Code)
+	}
+	stub := &stubSchedules{}
+	handler.SetSchedules(stub)
+
+	w := call(handler.ListScheduledChanges, "GET", "", z1)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"status":"pending"`) {
+		t.Errorf("Unexpected listing %d: %s", w.Code, w.Body.String())
+	}
+	if w := call(handler.ListScheduledChanges, "GET", "", map[string]string{"id": "z9"}); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown zone, got %d", w.Code)
+	}
+
+	if w := call(handler.ScheduleChange, "POST", `{"action":`, z1); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for malformed JSON, got %d", w.Code)
+	}
+	if w := call(handler.ScheduleChange, "POST", `{"action":"RENAME"}`, z1); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown action, got %d", w.Code)
+	}
+	body := `{"action":"CREATE","run_at":"2030-01-01T03:00:00Z","record":{"name":"www","type":"A","content":"192.0.2.1","ttl":300}}`
+	w = call(handler.ScheduleChange, "POST", body, z1)
+	if w.Code != http.StatusCreated || stub.scheduled == nil || stub.scheduled.Record.Content != "192.0.2.1" || stub.scheduled.RunAt.Year() != 2030 {
+		t.Errorf("Unexpected schedule %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := call(handler.CancelScheduledChange, "DELETE", "", map[string]string{"id": "z1", "change_id": "sc2"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 cancelling a change that is not pending, got %d", w.Code)
+	}
+	if w := call(handler.CancelScheduledChange, "DELETE", "", map[string]string{"id": "z1", "change_id": "sc1"}); w.Code != http.StatusNoContent || stub.cancelled != "sc1" {
+		t.Errorf("Expected 204 cancelling sc1, got %d", w.Code)
+	}
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// writeSecondaryError maps external secondary errors to HTTP statuses.
+func writeSecondaryError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidSecondary):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// secondaryTenant checks that external secondaries are enabled and returns the
+// caller's tenant, writing the error response if either is missing.
+func (h *APIHandler) secondaryTenant(w http.ResponseWriter, r *http.Request, op string) (string, bool) {
+	if h.secondaries == nil {
+		http.Error(w, "External secondaries are not enabled", http.StatusServiceUnavailable)
+		return "", false
+	}
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("%s: missing or invalid tenant ID in context", op)
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return "", false
+	}
+	return tenantID, true
+}
+
+// ListSecondaries returns a zone's external secondaries and their observed serials.
+func (h *APIHandler) ListSecondaries(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.secondaryTenant(w, r, "ListSecondaries")
+	if !ok {
+		return
+	}
+	providers, err := h.secondaries.ListSecondaries(r.Context(), r.PathValue("id"), tenantID)
+	if err != nil {
+		writeSecondaryError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(providers); err != nil {
+		log.Printf("failed to encode secondaries response: %v", err)
+	}
+}
+
+// AddSecondary registers an external secondary for a zone; the body is
+// {"name", "address", "tsig_key_name", "tsig_secret"}.
+func (h *APIHandler) AddSecondary(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.secondaryTenant(w, r, "AddSecondary")
+	if !ok {
+		return
+	}
+	var req struct {
+		Name        string `json:"name"`
+		Address     string `json:"address"`
+		TSIGKeyName string `json:"tsig_key_name"`
+		TSIGSecret  string `json:"tsig_secret"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Address == "" {
+		http.Error(w, "address is required", http.StatusBadRequest)
+		return
+	}
+	provider := &domain.SecondaryProvider{Name: req.Name, Address: req.Address, TSIGKeyName: req.TSIGKeyName, TSIGSecret: req.TSIGSecret}
+	if err := h.secondaries.AddSecondary(r.Context(), r.PathValue("id"), tenantID, provider); err != nil {
+		writeSecondaryError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(provider); err != nil {
+		log.Printf("failed to encode secondary response: %v", err)
+	}
+}
+
+// DeleteSecondary removes an external secondary from a zone.
+func (h *APIHandler) DeleteSecondary(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.secondaryTenant(w, r, "DeleteSecondary")
+	if !ok {
+		return
+	}
+	if err := h.secondaries.DeleteSecondary(r.Context(), r.PathValue("id"), tenantID, r.PathValue("secondary_id")); err != nil {
+		writeSecondaryError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
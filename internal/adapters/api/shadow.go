@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// writeShadowError maps shadow change errors to HTTP statuses.
+func writeShadowError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidShadowChange), errors.Is(err, domain.ErrInvalidRRset):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		writeMutationError(w, err)
+	}
+}
+
+// shadowTenant checks that shadow mode is enabled and returns the caller's
+// tenant, writing the error response if either is missing.
+func (h *APIHandler) shadowTenant(w http.ResponseWriter, r *http.Request, op string) (string, bool) {
+	if h.shadows == nil {
+		http.Error(w, "Shadow mode is not enabled", http.StatusServiceUnavailable)
+		return "", false
+	}
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("%s: missing or invalid tenant ID in context", op)
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return "", false
+	}
+	return tenantID, true
+}
+
+// GetShadowChange returns the RRset changes staged in shadow mode for a zone.
+func (h *APIHandler) GetShadowChange(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.shadowTenant(w, r, "GetShadowChange")
+	if !ok {
+		return
+	}
+	change, err := h.shadows.GetShadowChange(r.Context(), r.PathValue("id"), tenantID)
+	if err != nil {
+		writeShadowError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(change); err != nil {
+		log.Printf("failed to encode shadow change response: %v", err)
+	}
+}
+
+// StageShadowChange stages RRset replacements in shadow mode; the body is
+// {"description", "rrsets": [{"name", "type", "ttl", "records"}], "canary_networks"}.
+// Live answers are compared with the staged ones but only canary clients are
+// served them.
+func (h *APIHandler) StageShadowChange(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.shadowTenant(w, r, "StageShadowChange")
+	if !ok {
+		return
+	}
+	var change domain.ShadowChange
+	if err := json.NewDecoder(r.Body).Decode(&change); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.shadows.StageShadowChange(r.Context(), r.PathValue("id"), tenantID, &change); err != nil {
+		writeShadowError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(change); err != nil {
+		log.Printf("failed to encode shadow change response: %v", err)
+	}
+}
+
+// DiscardShadowChange drops a zone's shadow change without applying it.
+func (h *APIHandler) DiscardShadowChange(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.shadowTenant(w, r, "DiscardShadowChange")
+	if !ok {
+		return
+	}
+	if err := h.shadows.DiscardShadowChange(r.Context(), r.PathValue("id"), tenantID); err != nil {
+		writeShadowError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CommitShadowChange applies a zone's shadow change and returns what was applied.
+func (h *APIHandler) CommitShadowChange(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.shadowTenant(w, r, "CommitShadowChange")
+	if !ok {
+		return
+	}
+	change, err := h.shadows.CommitShadowChange(r.Context(), r.PathValue("id"), tenantID)
+	if err != nil {
+		writeShadowError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(change); err != nil {
+		log.Printf("failed to encode shadow change response: %v", err)
+	}
+}
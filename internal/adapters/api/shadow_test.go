@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/testutil"
+)
+
+type stubShadows struct {
+	staged    *domain.ShadowChange
+	committed bool
+}
+
+func (s *stubShadows) StageShadowChange(_ context.Context, zoneID, _ string, c *domain.ShadowChange) error {
+	if zoneID != "z1" {
+		return domain.ErrNotFound
+	}
+	if len(c.RRsets) == 0 {
+		return fmt.Errorf("no rrsets: %w", domain.ErrInvalidShadowChange)
+	}
+	c.ZoneID = zoneID
+	s.staged = c
+	return nil
+}
+
+func (s *stubShadows) GetShadowChange(_ context.Context, zoneID, _ string) (*domain.ShadowChange, error) {
+	if zoneID != "z1" || s.staged == nil {
+		return nil, domain.ErrNotFound
+	}
+	return s.staged, nil
+}
+
+func (s *stubShadows) DiscardShadowChange(ctx context.Context, zoneID, tenantID string) error {
+	if _, err := s.GetShadowChange(ctx, zoneID, tenantID); err != nil {
+		return err
+	}
+	s.staged = nil
+	return nil
+}
+
+func (s *stubShadows) CommitShadowChange(ctx context.Context, zoneID, tenantID string) (*domain.ShadowChange, error) {
+	change, err := s.GetShadowChange(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if change.Description == "frozen" {
+		return nil, domain.ErrChangeFrozen
+	}
+	s.staged = nil
+	s.committed = true
+	return change, nil
+}
+
+func TestShadowChangeEndpoints(t *testing.T) {
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+	call := func(fn http.HandlerFunc, method, body string, zoneID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/zones/"+zoneID+"/shadow", strings.NewReader(body))
+		req.SetPathValue("id", zoneID)
+		w := httptest.NewRecorder()
+		fn(w, withTenant(req, testTenantID))
+		return w
+	}
+
+	if w := call(handler.GetShadowChange, "GET", "", "z1"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without shadow mode, got %d", w.Code)
+	}
+	stub := &stubShadows{}
+	handler.SetShadows(stub)
+
+	if w := call(handler.GetShadowChange, "GET", "", "z1"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 with nothing staged, got %d", w.Code)
+	}
+	if w := call(handler.StageShadowChange, "PUT", `{"rrsets":`, "z1"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for malformed JSON, got %d", w.Code)
+	}
+	if w := call(handler.StageShadowChange, "PUT", `{"rrsets":[]}`, "z1"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an empty change, got %d", w.Code)
+	}
+	body := `{"description":"frozen","rrsets":[{"name":"www","type":"A","records":[{"content":"192.0.2.2"}]}],"canary_networks":["10.0.0.0/8"]}`
+	w := call(handler.StageShadowChange, "PUT", body, "z1")
+	if w.Code != http.StatusOK || stub.staged == nil || stub.staged.RRsets[0].Records[0].Content != "192.0.2.2" || stub.staged.CanaryNetworks[0] != "10.0.0.0/8" {
+		t.Fatalf("Unexpected stage %d: %s", w.Code, w.Body.String())
+	}
+	if w := call(handler.GetShadowChange, "GET", "", "z1"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"rrsets"`) {
+		t.Errorf("Unexpected shadow change %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := call(handler.CommitShadowChange, "POST", "", "z1"); w.Code != http.StatusLocked {
+		t.Errorf("Expected 423 committing during a freeze, got %d", w.Code)
+	}
+	stub.staged.Description = ""
+	if w := call(handler.CommitShadowChange, "POST", "", "z1"); w.Code != http.StatusOK || !stub.committed {
+		t.Errorf("Expected the commit to succeed, got %d", w.Code)
+	}
+	if w := call(handler.DiscardShadowChange, "DELETE", "", "z1"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 discarding nothing, got %d", w.Code)
+	}
+	stub.staged = &domain.ShadowChange{ZoneID: "z1"}
+	if w := call(handler.DiscardShadowChange, "DELETE", "", "z1"); w.Code != http.StatusNoContent || stub.staged != nil {
+		t.Errorf("Expected 204 discarding the change, got %d", w.Code)
+	}
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// writeSLOError maps zone SLO errors to HTTP statuses.
+func writeSLOError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidSLO):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// sloTenant checks that SLO tracking is enabled and returns the caller's
+// tenant, writing the error response if either is missing.
+func (h *APIHandler) sloTenant(w http.ResponseWriter, r *http.Request, op string) (string, bool) {
+	if h.slos == nil {
+		http.Error(w, "SLO tracking is not enabled", http.StatusServiceUnavailable)
+		return "", false
+	}
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("%s: missing or invalid tenant ID in context", op)
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return "", false
+	}
+	return tenantID, true
+}
+
+// GetZoneSLO returns a zone's latency percentiles on the answering node and,
+// if the zone has an SLO, whether it is being breached.
+func (h *APIHandler) GetZoneSLO(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.sloTenant(w, r, "GetZoneSLO")
+	if !ok {
+		return
+	}
+	status, err := h.slos.GetZoneSLOStatus(r.Context(), r.PathValue("id"), tenantID)
+	if err != nil {
+		writeSLOError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		log.Printf("failed to encode SLO status response: %v", err)
+	}
+}
+
+// SetZoneSLO sets a zone's latency objective; the body is
+// {"percentile", "threshold_ms", "window_minutes"}.
+func (h *APIHandler) SetZoneSLO(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.sloTenant(w, r, "SetZoneSLO")
+	if !ok {
+		return
+	}
+	var slo domain.ZoneSLO
+	if err := json.NewDecoder(r.Body).Decode(&slo); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.slos.SetZoneSLO(r.Context(), r.PathValue("id"), tenantID, &slo); err != nil {
+		writeSLOError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(slo); err != nil {
+		log.Printf("failed to encode SLO response: %v", err)
+	}
+}
+
+// DeleteZoneSLO removes a zone's latency objective.
+func (h *APIHandler) DeleteZoneSLO(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.sloTenant(w, r, "DeleteZoneSLO")
+	if !ok {
+		return
+	}
+	if err := h.slos.DeleteZoneSLO(r.Context(), r.PathValue("id"), tenantID); err != nil {
+		writeSLOError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
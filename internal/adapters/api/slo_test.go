@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/testutil"
+)
+
+type stubSLOs struct {
+	slo *domain.ZoneSLO
+}
+
+func (s *stubSLOs) SetZoneSLO(_ context.Context, zoneID, _ string, slo *domain.ZoneSLO) error {
+	if zoneID != "z1" {
+		return domain.ErrNotFound
+	}
+	if slo.ThresholdMs <= 0 {
+		return fmt.Errorf("threshold_ms must be positive: %w", domain.ErrInvalidSLO)
+	}
+	slo.ZoneID = zoneID
+	s.slo = slo
+	return nil
+}
+
+func (s *stubSLOs) GetZoneSLOStatus(_ context.Context, zoneID, _ string) (*domain.ZoneSLOStatus, error) {
+	if zoneID != "z1" {
+		return nil, domain.ErrNotFound
+	}
+	return &domain.ZoneSLOStatus{SLO: s.slo, Latency: domain.ZoneLatency{WindowMinutes: 5, Queries: 40, P99Ms: 20}, ObservedMs: 20, Breaching: s.slo != nil}, nil
+}
+
+func (s *stubSLOs) DeleteZoneSLO(_ context.Context, zoneID, _ string) error {
+	if zoneID != "z1" || s.slo == nil {
+		return domain.ErrNotFound
+	}
+	s.slo = nil
+	return nil
+}
+
+func TestZoneSLOEndpoints(t *testing.T) {
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+	call := func(fn http.HandlerFunc, method, body string, zoneID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/zones/"+zoneID+"/slo", strings.NewReader(body))
+		req.SetPathValue("id", zoneID)
+		w := httptest.NewRecorder()
+		fn(w, withTenant(req, testTenantID))
+		return w
+	}
+
+	if w := call(handler.GetZoneSLO, "GET", "", "z1"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without SLO tracking, got %d", w.Code)
+	}
+	stub := &stubSLOs{}
+	handler.SetSLOs(stub)
+
+	if w := call(handler.SetZoneSLO, "PUT", `{"percentile":`, "z1"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for malformed JSON, got %d", w.Code)
+	}
+	if w := call(handler.SetZoneSLO, "PUT", `{"percentile": 99}`, "z1"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid SLO, got %d", w.Code)
+	}
+	if w := call(handler.SetZoneSLO, "PUT", `{"percentile": 99, "threshold_ms": 5}`, "missing"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown zone, got %d", w.Code)
+	}
+	if w := call(handler.SetZoneSLO, "PUT", `{"percentile": 99, "threshold_ms": 5, "window_minutes": 10}`, "z1"); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if stub.slo == nil || stub.slo.Percentile != 99 || stub.slo.WindowMinutes != 10 {
+		t.Fatalf("Expected the SLO to be passed through, got %+v", stub.slo)
+	}
+
+	w := call(handler.GetZoneSLO, "GET", "", "z1")
+	var status domain.ZoneSLOStatus
+	if err := json.NewDecoder(w.Body).Decode(&status); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("Expected a status, got %d: %v", w.Code, err)
+	}
+	if !status.Breaching || status.SLO == nil || status.Latency.P99Ms != 20 {
+		t.Errorf("Unexpected status %+v", status)
+	}
+
+	if w := call(handler.DeleteZoneSLO, "DELETE", "", "z1"); w.Code != http.StatusNoContent {
+		t.Errorf("Expected 204, got %d", w.Code)
+	}
+	if w := call(handler.DeleteZoneSLO, "DELETE", "", "z1"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 once deleted, got %d", w.Code)
+	}
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// writeTargetError maps target errors to HTTP statuses.
+func writeTargetError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidTarget):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		writeMutationError(w, err)
+	}
+}
+
+// targetTenant checks that targets are enabled and returns the caller's tenant,
+// writing the error response if either is missing.
+func (h *APIHandler) targetTenant(w http.ResponseWriter, r *http.Request, op string) (string, bool) {
+	if h.targets == nil {
+		http.Error(w, "Targets are not enabled", http.StatusServiceUnavailable)
+		return "", false
+	}
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("%s: missing or invalid tenant ID in context", op)
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return "", false
+	}
+	return tenantID, true
+}
+
+// ListTargets returns the tenant's targets.
+func (h *APIHandler) ListTargets(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.targetTenant(w, r, "ListTargets")
+	if !ok {
+		return
+	}
+	targets, err := h.targets.ListTargets(r.Context(), tenantID)
+	if err != nil {
+		writeTargetError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(targets); err != nil {
+		log.Printf("failed to encode targets response: %v", err)
+	}
+}
+
+// CreateTarget adds a target; the body is {"name", "addresses"}.
+func (h *APIHandler) CreateTarget(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.targetTenant(w, r, "CreateTarget")
+	if !ok {
+		return
+	}
+	var target domain.Target
+	if err := json.NewDecoder(r.Body).Decode(&target); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.targets.CreateTarget(r.Context(), tenantID, &target); err != nil {
+		writeTargetError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(target); err != nil {
+		log.Printf("failed to encode target response: %v", err)
+	}
+}
+
+// UpdateTarget replaces the name and addresses of a target, and with them the
+// answers of every record referencing it.
+func (h *APIHandler) UpdateTarget(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.targetTenant(w, r, "UpdateTarget")
+	if !ok {
+		return
+	}
+	var target domain.Target
+	if err := json.NewDecoder(r.Body).Decode(&target); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	target.ID = r.PathValue("id")
+	if err := h.targets.UpdateTarget(r.Context(), tenantID, &target); err != nil {
+		writeTargetError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(target); err != nil {
+		log.Printf("failed to encode target response: %v", err)
+	}
+}
+
+// DeleteTarget removes a target no record references.
+func (h *APIHandler) DeleteTarget(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.targetTenant(w, r, "DeleteTarget")
+	if !ok {
+		return
+	}
+	if err := h.targets.DeleteTarget(r.Context(), tenantID, r.PathValue("id")); err != nil {
+		writeTargetError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/testutil"
+)
+
+type stubTargets struct {
+	saved   *domain.Target
+	deleted string
+}
+
+func (s *stubTargets) CreateTarget(_ context.Context, _ string, t *domain.Target) error {
+	if len(t.Addresses) == 0 {
+		return fmt.Errorf("no addresses: %w", domain.ErrInvalidTarget)
+	}
+	t.ID = "t1"
+	s.saved = t
+	return nil
+}
+
+func (s *stubTargets) UpdateTarget(_ context.Context, _ string, t *domain.Target) error {
+	if t.ID != "t1" {
+		return domain.ErrNotFound
+	}
+	s.saved = t
+	return nil
+}
+
+func (s *stubTargets) ListTargets(_ context.Context, _ string) ([]domain.Target, error) {
+	return []domain.Target{{ID: "t1", Name: "ingress", Addresses: []string{"192.0.2.1"}}}, nil
+}
+
+func (s *stubTargets) DeleteTarget(_ context.Context, _ string, id string) error {
+	if id != "t1" {
+		return domain.ErrNotFound
+	}
+	s.deleted = id
+	return nil
+}
+
+func TestTargetEndpoints(t *testing.T) {
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+	call := func(fn http.HandlerFunc, method, body, id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/targets", strings.NewReader(body))
+		req.SetPathValue("id", id)
+		w := httptest.NewRecorder()
+		fn(w, withTenant(req, testTenantID))
+		return w
+	}
+
+	if w := call(handler.ListTargets, "GET", "", ""); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a target manager, got %d", w.Code)
+	}
+	stub := &stubTargets{}
+	handler.SetTargets(stub)
+
+	if w := call(handler.ListTargets, "GET", "", ""); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"addresses":["192.0.2.1"]`) {
+		t.Errorf("Unexpected listing %d: %s", w.Code, w.Body.String())
+	}
+	if w := call(handler.CreateTarget, "POST", `{"name":`, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for malformed JSON, got %d", w.Code)
+	}
+	if w := call(handler.CreateTarget, "POST", `{"name":"ingress"}`, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a target without addresses, got %d", w.Code)
+	}
+	w := call(handler.CreateTarget, "POST", `{"name":"ingress","addresses":["192.0.2.1","2001:db8::1"]}`, "")
+	if w.Code != http.StatusCreated || stub.saved == nil || len(stub.saved.Addresses) != 2 || !strings.Contains(w.Body.String(), `"id":"t1"`) {
+		t.Errorf("Unexpected target %d: %s", w.Code, w.Body.String())
+	}
+	if w := call(handler.UpdateTarget, "PUT", `{"name":"ingress","addresses":["192.0.2.2"]}`, "t2"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown target, got %d", w.Code)
+	}
+	if w := call(handler.UpdateTarget, "PUT", `{"name":"ingress","addresses":["192.0.2.2"]}`, "t1"); w.Code != http.StatusOK || stub.saved.Addresses[0] != "192.0.2.2" {
+		t.Errorf("Unexpected update %d: %s", w.Code, w.Body.String())
+	}
+	if w := call(handler.DeleteTarget, "DELETE", "", "t1"); w.Code != http.StatusNoContent || stub.deleted != "t1" {
+		t.Errorf("Expected 204 deleting t1, got %d", w.Code)
+	}
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// writeTransferError maps zone synchronization errors to HTTP statuses.
+func writeTransferError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidZoneRole):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, domain.ErrTransferFailed):
+		http.Error(w, err.Error(), http.StatusBadGateway)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// transferTenant checks that on-demand transfers are enabled and returns the
+// caller's tenant, writing the error response if either is missing.
+func (h *APIHandler) transferTenant(w http.ResponseWriter, r *http.Request, op string) (string, bool) {
+	if h.transfers == nil {
+		http.Error(w, "Zone transfers are not enabled", http.StatusServiceUnavailable)
+		return "", false
+	}
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("%s: missing or invalid tenant ID in context", op)
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return "", false
+	}
+	return tenantID, true
+}
+
+// TransferZone pulls a secondary zone from its configured master now.
+func (h *APIHandler) TransferZone(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.transferTenant(w, r, "TransferZone")
+	if !ok {
+		return
+	}
+	res, err := h.transfers.TransferZone(r.Context(), r.PathValue("id"), tenantID)
+	if err != nil {
+		writeTransferError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		log.Printf("failed to encode transfer response: %v", err)
+	}
+}
+
+// NotifyZone sends NOTIFY for a primary zone to its secondaries now.
+func (h *APIHandler) NotifyZone(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.transferTenant(w, r, "NotifyZone")
+	if !ok {
+		return
+	}
+	res, err := h.transfers.NotifyZone(r.Context(), r.PathValue("id"), tenantID)
+	if err != nil {
+		writeTransferError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		log.Printf("failed to encode notify response: %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// uiAssets holds the single-page admin UI. The assets themselves are public; every
+// API call the UI makes carries the operator's API key and goes through AuthMiddleware.
+//
+//go:embed ui
+var uiAssets embed.FS
+
+// UIHandler serves the embedded admin UI under /ui/.
+func UIHandler() http.Handler {
+	sub, err := fs.Sub(uiAssets, "ui")
+	if err != nil {
+		// The embed directive guarantees the directory exists
+		panic(err)
+	}
+	files := http.StripPrefix("/ui/", http.FileServerFS(sub))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", "default-src 'self'; connect-src 'self'; frame-ancestors 'none'")
+		w.Header().Set("X-Frame-Options", "DENY")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		files.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,235 @@
+// cloudDNS admin UI. Talks to the management API with the API key entered at sign-in;
+// authorization is enforced server-side by the same middleware as any other client.
+(function () {
+  "use strict";
+
+  const KEY_STORAGE = "clouddns.apiKey";
+  let currentZone = null;
+
+  const $ = (id) => document.getElementById(id);
+
+  function apiKey() {
+    return sessionStorage.getItem(KEY_STORAGE);
+  }
+
+  async function api(method, path, body) {
+    const opts = { method, headers: { Authorization: "Bearer " + apiKey() } };
+    if (body !== undefined) {
+      opts.headers["Content-Type"] = "application/json";
+      opts.body = JSON.stringify(body);
+    }
+    const res = await fetch(path, opts);
+    if (res.status === 401) {
+      signOut();
+      throw new Error("Session expired or API key rejected");
+    }
+    if (!res.ok) {
+      throw new Error((await res.text()).trim() || res.statusText);
+    }
+    const type = res.headers.get("Content-Type") || "";
+    return type.includes("application/json") ? res.json() : null;
+  }
+
+  function showError(err) {
+    const box = $("error");
+    box.textContent = err.message || String(err);
+    box.hidden = false;
+    setTimeout(() => { box.hidden = true; }, 5000);
+  }
+
+  function el(tag, text, attrs) {
+    const node = document.createElement(tag);
+    if (text !== undefined && text !== null) node.textContent = String(text);
+    Object.entries(attrs || {}).forEach(([k, v]) => node.setAttribute(k, v));
+    return node;
+  }
+
+  function row(cells) {
+    const tr = el("tr");
+    cells.forEach((c) => {
+      const td = el("td");
+      if (c instanceof Node) td.appendChild(c); else td.textContent = c === undefined ? "" : String(c);
+      tr.appendChild(td);
+    });
+    return tr;
+  }
+
+  // --- Session ---
+
+  function signIn(key) {
+    sessionStorage.setItem(KEY_STORAGE, key);
+    $("login").hidden = true;
+    $("logout").hidden = false;
+    showView("zones");
+  }
+
+  function signOut() {
+    sessionStorage.removeItem(KEY_STORAGE);
+    document.querySelectorAll(".view").forEach((v) => { v.hidden = true; });
+    $("login").hidden = false;
+    $("logout").hidden = true;
+  }
+
+  function showView(name) {
+    if (!apiKey()) return;
+    document.querySelectorAll("header nav button").forEach((b) => {
+      b.classList.toggle("active", b.dataset.view === name);
+    });
+    document.querySelectorAll(".view").forEach((v) => { v.hidden = v.id !== "view-" + name; });
+    const loaders = { zones: loadZones, stats: loadStats, audit: loadAudit };
+    loaders[name]().catch(showError);
+  }
+
+  // --- Zones and records ---
+
+  async function loadZones() {
+    const zones = (await api("GET", "/zones")) || [];
+    const list = $("zone-list");
+    list.replaceChildren();
+    zones.sort((a, b) => a.name.localeCompare(b.name)).forEach((z) => {
+      const li = el("li", z.name);
+      if (currentZone && currentZone.id === z.id) li.classList.add("selected");
+      li.addEventListener("click", () => selectZone(z).catch(showError));
+      list.appendChild(li);
+    });
+    if (currentZone && !zones.some((z) => z.id === currentZone.id)) {
+      currentZone = null;
+      $("zone-detail").hidden = true;
+    }
+  }
+
+  async function selectZone(zone) {
+    currentZone = zone;
+    document.querySelectorAll("#zone-list li").forEach((li) => {
+      li.classList.toggle("selected", li.textContent === zone.name);
+    });
+    $("zone-title").textContent = zone.name;
+    $("zone-detail").hidden = false;
+    await Promise.all([loadSummary(zone.id), loadRecords(zone.id)]);
+  }
+
+  async function loadSummary(zoneID) {
+    const s = await api("GET", "/zones/" + encodeURIComponent(zoneID) + "/summary");
+    const counts = Object.entries(s.record_counts || {}).map(([t, n]) => t + ": " + n).join(", ");
+    const fields = [
+      ["Serial", s.serial],
+      ["Records", s.total_records + (counts ? " (" + counts + ")" : "")],
+      ["DNSSEC", s.dnssec_enabled ? "Signed (" + s.active_keys + " active keys)" : "Unsigned"],
+      ["Role", s.role + (s.master_server ? " of " + s.master_server : "")],
+      ["Last change", s.last_change_at ? new Date(s.last_change_at).toLocaleString() : "never"],
+      ["Estimated size", s.estimated_wire_bytes + " bytes"],
+    ];
+    const dl = $("zone-summary");
+    dl.replaceChildren();
+    fields.forEach(([k, v]) => { dl.appendChild(el("dt", k)); dl.appendChild(el("dd", v)); });
+  }
+
+  async function loadRecords(zoneID) {
+    const records = (await api("GET", "/zones/" + encodeURIComponent(zoneID) + "/records")) || [];
+    const body = $("record-rows");
+    body.replaceChildren();
+    records.forEach((r) => {
+      const del = el("button", "Delete", { class: "danger" });
+      del.addEventListener("click", () => deleteRecord(r).catch(showError));
+      const content = (r.priority !== undefined ? r.priority + " " : "") + r.content;
+      body.appendChild(row([r.name, r.type, content, r.ttl, r.health_status || "", del]));
+    });
+  }
+
+  async function deleteRecord(rec) {
+    if (!confirm("Delete " + rec.type + " record " + rec.name + "?")) return;
+    await api("DELETE", "/zones/" + encodeURIComponent(currentZone.id) + "/records/" + encodeURIComponent(rec.id));
+    await selectZone(currentZone);
+  }
+
+  async function createZone(form) {
+    const data = new FormData(form);
+    await api("POST", "/zones", { name: data.get("name"), description: data.get("description") });
+    form.reset();
+    await loadZones();
+  }
+
+  async function deleteZone() {
+    if (!currentZone || !confirm("Delete zone " + currentZone.name + " and all its records?")) return;
+    await api("DELETE", "/zones/" + encodeURIComponent(currentZone.id));
+    currentZone = null;
+    $("zone-detail").hidden = true;
+    await loadZones();
+  }
+
+  async function createRecord(form) {
+    const data = new FormData(form);
+    const rec = {
+      name: data.get("name"),
+      type: data.get("type"),
+      content: data.get("content"),
+      ttl: parseInt(data.get("ttl"), 10) || 300,
+    };
+    if (data.get("priority") !== "") rec.priority = parseInt(data.get("priority"), 10);
+    await api("POST", "/zones/" + encodeURIComponent(currentZone.id) + "/records", rec);
+    form.reset();
+    await selectZone(currentZone);
+  }
+
+  // --- Query stats (parsed from the Prometheus exposition) ---
+
+  async function loadStats() {
+    const res = await fetch("/metrics");
+    if (!res.ok) throw new Error("metrics unavailable: " + res.statusText);
+    const totals = { rcode: {}, qtype: {}, protocol: {} };
+    const re = /^clouddns_queries_total\{([^}]*)\}\s+([0-9.eE+]+)/;
+    (await res.text()).split("\n").forEach((line) => {
+      const m = re.exec(line);
+      if (!m) return;
+      const value = parseFloat(m[2]);
+      m[1].split(",").forEach((pair) => {
+        const [k, v] = pair.split("=");
+        if (totals[k]) {
+          const label = v.replace(/"/g, "");
+          totals[k][label] = (totals[k][label] || 0) + value;
+        }
+      });
+    });
+    Object.keys(totals).forEach((k) => {
+      const body = $("stats-" + k);
+      body.replaceChildren();
+      Object.entries(totals[k]).sort((a, b) => b[1] - a[1]).forEach(([label, n]) => {
+        body.appendChild(row([label, n]));
+      });
+    });
+  }
+
+  // --- Audit log ---
+
+  async function loadAudit() {
+    const logs = (await api("GET", "/audit-logs")) || [];
+    const body = $("audit-rows");
+    body.replaceChildren();
+    logs.forEach((l) => {
+      body.appendChild(row([
+        new Date(l.created_at).toLocaleString(),
+        l.action,
+        l.resource_type + " " + l.resource_id,
+        l.details,
+      ]));
+    });
+  }
+
+  // --- Wiring ---
+
+  document.querySelectorAll("header nav button").forEach((b) => {
+    b.addEventListener("click", () => showView(b.dataset.view));
+  });
+  $("logout").addEventListener("click", signOut);
+  $("login-form").addEventListener("submit", (e) => {
+    e.preventDefault();
+    signIn($("api-key").value.trim());
+    $("api-key").value = "";
+  });
+  $("zone-form").addEventListener("submit", (e) => { e.preventDefault(); createZone(e.target).catch(showError); });
+  $("record-form").addEventListener("submit", (e) => { e.preventDefault(); createRecord(e.target).catch(showError); });
+  $("zone-delete").addEventListener("click", () => deleteZone().catch(showError));
+  $("stats-refresh").addEventListener("click", () => loadStats().catch(showError));
+
+  if (apiKey()) signIn(apiKey()); else signOut();
+})();
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/testutil"
+)
+
+func TestUIServesEmbeddedAssets(t *testing.T) {
+	mux := http.NewServeMux()
+	NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{}).RegisterRoutes(mux)
+
+	cases := []struct {
+		path     string
+		contains string
+	}{
+		{"/ui/", "<title>cloudDNS Admin</title>"},
+		{"/ui/app.js", "Authorization"},
+		{"/ui/app.css", "body"},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", tc.path, nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d", tc.path, w.Code)
+			continue
+		}
+		if !strings.Contains(w.Body.String(), tc.contains) {
+			t.Errorf("%s: body missing %q", tc.path, tc.contains)
+		}
+		if w.Header().Get("Content-Security-Policy") == "" {
+			t.Errorf("%s: missing Content-Security-Policy header", tc.path)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/ui", nil))
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/ui/" {
+		t.Errorf("Expected redirect to /ui/, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/ui/missing.js", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown asset, got %d", w.Code)
+	}
+}
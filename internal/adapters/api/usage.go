@@ -0,0 +1,150 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// defaultUsageRange is the period reported when no range is requested.
+const defaultUsageRange = 24 * time.Hour
+
+// usageRange parses the RFC 3339 from and to query parameters, defaulting to the
+// last 24 hours.
+func usageRange(r *http.Request) (time.Time, time.Time, error) {
+	to := time.Now().UTC()
+	if v := r.URL.Query().Get("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to: %w", err)
+		}
+		to = t
+	}
+	from := to.Add(-defaultUsageRange)
+	if v := r.URL.Query().Get("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from: %w", err)
+		}
+		from = t
+	}
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, errors.New("from must be before to")
+	}
+	return from, to, nil
+}
+
+// GetZoneUsage returns a zone's hourly query, NXDOMAIN and transfer counts.
+func (h *APIHandler) GetZoneUsage(w http.ResponseWriter, r *http.Request) {
+	if h.usage == nil {
+		http.Error(w, "Usage statistics are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("GetZoneUsage: missing or invalid tenant ID in context")
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return
+	}
+	from, to, err := usageRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.usage.ZoneUsage(r.Context(), r.PathValue("id"), tenantID, from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if report == nil {
+		http.Error(w, "Zone not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("failed to encode zone usage response: %v", err)
+	}
+}
+
+// GetUsageSummary returns the tenant's usage summed across the fleet, per zone and
+// per hour, for customer-facing dashboards.
+func (h *APIHandler) GetUsageSummary(w http.ResponseWriter, r *http.Request) {
+	if h.usage == nil {
+		http.Error(w, "Usage statistics are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("GetUsageSummary: missing or invalid tenant ID in context")
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return
+	}
+	from, to, err := usageRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	summary, err := h.usage.TenantUsageSummary(r.Context(), tenantID, from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(summary); err != nil {
+		log.Printf("failed to encode usage summary response: %v", err)
+	}
+}
+
+// ExportUsage streams the tenant's hourly usage for every zone as CSV for billing.
+func (h *APIHandler) ExportUsage(w http.ResponseWriter, r *http.Request) {
+	if h.usage == nil {
+		http.Error(w, "Usage statistics are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("ExportUsage: missing or invalid tenant ID in context")
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return
+	}
+	from, to, err := usageRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	usage, err := h.usage.ExportUsage(r.Context(), tenantID, from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="usage-%s-%s.csv"`,
+		from.UTC().Format("20060102T15"), to.UTC().Format("20060102T15")))
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"hour", "zone_id", "zone_name", "queries", "nxdomain", "transfer_bytes"})
+	for _, u := range usage {
+		_ = cw.Write([]string{
+			u.Hour.UTC().Format(time.RFC3339),
+			u.ZoneID,
+			u.ZoneName,
+			strconv.FormatInt(u.Queries, 10),
+			strconv.FormatInt(u.NXDomain, 10),
+			strconv.FormatInt(u.TransferBytes, 10),
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		log.Printf("failed to write usage export: %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// writeViewError maps view errors to HTTP statuses.
+func writeViewError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidView):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// viewTenant checks that views are enabled and returns the caller's tenant,
+// writing the error response if either is missing.
+func (h *APIHandler) viewTenant(w http.ResponseWriter, r *http.Request, op string) (string, bool) {
+	if h.views == nil {
+		http.Error(w, "Views are not enabled", http.StatusServiceUnavailable)
+		return "", false
+	}
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("%s: missing or invalid tenant ID in context", op)
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return "", false
+	}
+	return tenantID, true
+}
+
+// ListViews returns the tenant's views in the order clients are matched
+// against them.
+func (h *APIHandler) ListViews(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.viewTenant(w, r, "ListViews")
+	if !ok {
+		return
+	}
+	views, err := h.views.ListViews(r.Context(), tenantID)
+	if err != nil {
+		writeViewError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(views); err != nil {
+		log.Printf("failed to encode views response: %v", err)
+	}
+}
+
+// CreateView adds a view; the body is {"name", "networks", "priority"}.
+func (h *APIHandler) CreateView(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.viewTenant(w, r, "CreateView")
+	if !ok {
+		return
+	}
+	var view domain.View
+	if err := json.NewDecoder(r.Body).Decode(&view); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.views.CreateView(r.Context(), tenantID, &view); err != nil {
+		writeViewError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(view); err != nil {
+		log.Printf("failed to encode view response: %v", err)
+	}
+}
+
+// UpdateView replaces the name, networks and priority of a view.
+func (h *APIHandler) UpdateView(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.viewTenant(w, r, "UpdateView")
+	if !ok {
+		return
+	}
+	var view domain.View
+	if err := json.NewDecoder(r.Body).Decode(&view); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	view.ID = r.PathValue("id")
+	if err := h.views.UpdateView(r.Context(), tenantID, &view); err != nil {
+		writeViewError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(view); err != nil {
+		log.Printf("failed to encode view response: %v", err)
+	}
+}
+
+// DeleteView removes a view and the records scoped to it.
+func (h *APIHandler) DeleteView(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.viewTenant(w, r, "DeleteView")
+	if !ok {
+		return
+	}
+	if err := h.views.DeleteView(r.Context(), tenantID, r.PathValue("id")); err != nil {
+		writeViewError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
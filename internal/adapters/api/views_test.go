@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/testutil"
+)
+
+type stubViews struct {
+	saved   *domain.View
+	deleted string
+}
+
+func (s *stubViews) CreateView(_ context.Context, _ string, v *domain.View) error {
+	if len(v.Networks) == 0 {
+		return fmt.Errorf("no networks: %w", domain.ErrInvalidView)
+	}
+	v.ID = "v1"
+	s.saved = v
+	return nil
+}
+
+func (s *stubViews) UpdateView(_ context.Context, _ string, v *domain.View) error {
+	if v.ID != "v1" {
+		return domain.ErrNotFound
+	}
+	s.saved = v
+	return nil
+}
+
+func (s *stubViews) ListViews(_ context.Context, _ string) ([]domain.View, error) {
+	return []domain.View{{ID: "v1", Name: "internal", Networks: []string{"10.0.0.0/8"}}}, nil
+}
+
+func (s *stubViews) DeleteView(_ context.Context, _ string, id string) error {
+	if id != "v1" {
+		return domain.ErrNotFound
+	}
+	s.deleted = id
+	return nil
+}
+
+func TestViewEndpoints(t *testing.T) {
+	handler := NewAPIHandler(&testutil.MockDNSService{}, &testutil.MockRepo{})
+	call := func(fn http.HandlerFunc, method, body, id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/views", strings.NewReader(body))
+		req.SetPathValue("id", id)
+		w := httptest.NewRecorder()
+		fn(w, withTenant(req, testTenantID))
+		return w
+	}
+
+	if w := call(handler.ListViews, "GET", "", ""); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a view manager, got %d", w.Code)
+	}
+	stub := &stubViews{}
+	handler.SetViews(stub)
+
+	if w := call(handler.ListViews, "GET", "", ""); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"networks":["10.0.0.0/8"]`) {
+		t.Errorf("Unexpected listing %d: %s", w.Code, w.Body.String())
+	}
+	if w := call(handler.CreateView, "POST", `{"name":`, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for malformed JSON, got %d", w.Code)
+	}
+	if w := call(handler.CreateView, "POST", `{"name":"internal"}`, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a view without networks, got %d", w.Code)
+	}
+	w := call(handler.CreateView, "POST", `{"name":"internal","networks":["10.0.0.0/8"],"priority":10}`, "")
+	if w.Code != http.StatusCreated || stub.saved == nil || stub.saved.Priority != 10 || !strings.Contains(w.Body.String(), `"id":"v1"`) {
+		t.Errorf("Unexpected view %d: %s", w.Code, w.Body.String())
+	}
+	if w := call(handler.UpdateView, "PUT", `{"name":"internal","networks":["10.0.0.0/16"]}`, "v2"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown view, got %d", w.Code)
+	}
+	if w := call(handler.UpdateView, "PUT", `{"name":"internal","networks":["10.0.0.0/16"]}`, "v1"); w.Code != http.StatusOK || stub.saved.Networks[0] != "10.0.0.0/16" {
+		t.Errorf("Unexpected update %d: %s", w.Code, w.Body.String())
+	}
+	if w := call(handler.DeleteView, "DELETE", "", "v1"); w.Code != http.StatusNoContent || stub.deleted != "v1" {
+		t.Errorf("Expected 204 deleting v1, got %d", w.Code)
+	}
+}
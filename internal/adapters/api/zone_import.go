@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// zoneImportRequest is the JSON form of a zone import: the master file and the
+// values of its template variables.
+type zoneImportRequest struct {
+	ZoneFile  string            `json:"zone_file"`
+	Variables map[string]string `json:"variables"`
+}
+
+// ImportZone creates a zone from a master file. The file is the request body,
+// with template variables given as ?var.<name>=<value> parameters, or a JSON
+// zoneImportRequest when the Content-Type is application/json. Every {{name}}
+// placeholder in the file is replaced with its value before it is parsed.
+func (h *APIHandler) ImportZone(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(CtxTenantID).(string)
+	if !ok || tenantID == "" {
+		log.Printf("ImportZone: missing or invalid tenant ID in context")
+		http.Error(w, "Unauthorized: missing tenant context", http.StatusUnauthorized)
+		return
+	}
+
+	var body io.Reader = r.Body
+	vars := make(map[string]string)
+	for key, values := range r.URL.Query() {
+		if name, ok := strings.CutPrefix(key, "var."); ok && len(values) > 0 {
+			vars[name] = values[len(values)-1]
+		}
+	}
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err == nil && mediaType == "application/json" {
+		var req zoneImportRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if strings.TrimSpace(req.ZoneFile) == "" {
+			http.Error(w, "zone_file is required", http.StatusBadRequest)
+			return
+		}
+		body = strings.NewReader(req.ZoneFile)
+		for name, value := range req.Variables {
+			vars[name] = value
+		}
+	}
+
+	zone, err := h.svc.ImportZone(r.Context(), tenantID, body, vars)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidZoneImport) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeMutationError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(zone); err != nil {
+		log.Printf("failed to encode zone response: %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/testutil"
+)
+
+// zoneImportRecordingService records the zone file and variables an import was
+// called with.
+type zoneImportRecordingService struct {
+	*mockDNSService
+	file string
+	vars map[string]string
+}
+
+func (m *zoneImportRecordingService) ImportZone(ctx context.Context, tenantID string, r io.Reader, vars map[string]string) (*domain.Zone, error) {
+	data, _ := io.ReadAll(r)
+	m.file, m.vars = string(data), vars
+	return m.mockDNSService.ImportZone(ctx, tenantID, r, vars)
+}
+
+func TestImportZoneHandler(t *testing.T) {
+	svc := &zoneImportRecordingService{mockDNSService: &mockDNSService{}}
+	handler := NewAPIHandler(svc, &testutil.MockRepo{})
+
+	send := func(query, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/zones/import"+query, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		req = withTenant(req, testTenantID)
+		w := httptest.NewRecorder()
+		handler.ImportZone(w, req)
+		return w
+	}
+
+	w := send("?var.lb_ip=10.0.0.1&var.env=prod", "text/dns", "www A {{lb_ip}}\n")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d %s", w.Code, w.Body.String())
+	}
+	if svc.file != "www A {{lb_ip}}\n" || svc.vars["lb_ip"] != "10.0.0.1" || svc.vars["env"] != "prod" {
+		t.Errorf("Expected the body and query variables passed on, got %q %v", svc.file, svc.vars)
+	}
+
+	w = send("?var.env=dev", "application/json", `{"zone_file": "www A {{lb_ip}}\n", "variables": {"lb_ip": "10.0.0.2"}}`)
+	if w.Code != http.StatusCreated || svc.file != "www A {{lb_ip}}\n" || svc.vars["lb_ip"] != "10.0.0.2" || svc.vars["env"] != "dev" {
+		t.Errorf("Expected the JSON zone file and variables passed on, got %d %q %v", w.Code, svc.file, svc.vars)
+	}
+	if w := send("", "application/json", `{"variables": {}}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without a zone file, got %d", w.Code)
+	}
+
+	svc.err = fmt.Errorf("%w: no value for template variables lb_ip", domain.ErrInvalidZoneImport)
+	if w := send("", "text/dns", "www A {{lb_ip}}\n"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a missing variable, got %d", w.Code)
+	}
+	svc.err = domain.ErrChangeFrozen
+	if w := send("", "text/dns", "www A 10.0.0.1\n"); w.Code != http.StatusLocked {
+		t.Errorf("Expected 423 during a change freeze, got %d", w.Code)
+	}
+}
@@ -0,0 +1,176 @@
+// Package edgestore keeps the zones of an edge node, a server that runs
+// without PostgreSQL and serves copies of zones transferred from a hub. The
+// copies are held in memory and written to a local file after every transfer,
+// so a restarted node answers from its last copy while it catches up.
+package edgestore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/poyrazK/cloudDNS/internal/adapters/memory"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+)
+
+// TenantID owns every zone of an edge node.
+const TenantID = "edge"
+
+// Store is a ports.DNSRepository for edge nodes: an in-memory repository whose
+// zones are saved to a file whenever a transfer changes them.
+type Store struct {
+	*memory.Repository
+	path string
+	// mu serialises writes of the file
+	mu sync.Mutex
+}
+
+// snapshot is the file format: every zone with its records.
+type snapshot struct {
+	Zones []zoneSnapshot `json:"zones"`
+}
+
+type zoneSnapshot struct {
+	Zone    domain.Zone     `json:"zone"`
+	Records []domain.Record `json:"records"`
+}
+
+// Open returns a store saved to path, loaded with the zones saved there by a
+// previous run. A missing file is an empty store.
+func Open(ctx context.Context, path string) (*Store, error) {
+	s := &Store{Repository: memory.NewRepository(), path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read edge store: %w", err)
+	}
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("failed to parse edge store %s: %w", path, err)
+	}
+	for _, z := range snap.Zones {
+		zone := z.Zone
+		if err := s.Repository.CreateZone(ctx, &zone); err != nil {
+			return nil, fmt.Errorf("failed to load zone %s: %w", zone.Name, err)
+		}
+		if err := s.Repository.ReplaceZoneRecords(ctx, zone.ID, z.Records); err != nil {
+			return nil, fmt.Errorf("failed to load zone %s: %w", zone.Name, err)
+		}
+	}
+	return s, nil
+}
+
+// Configure makes the store hold exactly the secondary zones named, pulled
+// from masters (as in a zone's master_server). Zones already held keep their
+// records and are pointed at masters; zones no longer named are removed.
+// DNSSEC is off for every zone: the hub does not transfer its keys, so an edge
+// node serves its copies unsigned rather than signing them with keys of its own.
+func (s *Store) Configure(ctx context.Context, names []string, masters string) error {
+	if _, err := domain.ParseMasters(masters); err != nil {
+		return fmt.Errorf("invalid hub: %w", err)
+	}
+	wanted := make([]string, 0, len(names))
+	for _, name := range names {
+		name = dnsname.Fqdn(name)
+		if err := domain.ValidateZoneName(name); err != nil {
+			return fmt.Errorf("invalid edge zone %q: %w", name, err)
+		}
+		wanted = append(wanted, dnsname.Normalize(name))
+	}
+
+	zones, err := s.ListZones(ctx, TenantID)
+	if err != nil {
+		return err
+	}
+	for _, zone := range zones {
+		if !slices.Contains(wanted, dnsname.Normalize(zone.Name)) {
+			if err := s.DeleteZone(ctx, zone.ID, TenantID); err != nil {
+				return fmt.Errorf("failed to remove zone %s: %w", zone.Name, err)
+			}
+		}
+	}
+	for _, name := range wanted {
+		zone, err := s.GetZone(ctx, name)
+		if err != nil {
+			return err
+		}
+		if zone != nil {
+			err = s.SetZoneRole(ctx, zone.ID, TenantID, "slave", masters)
+			if err == nil {
+				err = s.SetZoneDNSSEC(ctx, zone.ID, TenantID, false)
+			}
+		} else {
+			unsigned := false
+			err = s.CreateZone(ctx, &domain.Zone{Name: name, TenantID: TenantID, Role: "slave", MasterServer: masters, DNSSECEnabled: &unsigned})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to configure zone %s: %w", name, err)
+		}
+	}
+	return s.save(ctx)
+}
+
+// ReplaceZoneRecords swaps the records of a zone after a full transfer and
+// saves the store.
+func (s *Store) ReplaceZoneRecords(ctx context.Context, zoneID string, records []domain.Record) error {
+	if err := s.Repository.ReplaceZoneRecords(ctx, zoneID, records); err != nil {
+		return err
+	}
+	return s.save(ctx)
+}
+
+// ApplyZoneDiff applies an incremental transfer and saves the store.
+func (s *Store) ApplyZoneDiff(ctx context.Context, zoneID string, diffs []domain.IXFRChunk) error {
+	if err := s.Repository.ApplyZoneDiff(ctx, zoneID, diffs); err != nil {
+		return err
+	}
+	return s.save(ctx)
+}
+
+// save writes every zone to the file, replacing it atomically so a crash
+// leaves the previous copy.
+func (s *Store) save(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	zones, err := s.ListZones(ctx, TenantID)
+	if err != nil {
+		return err
+	}
+	snap := snapshot{Zones: make([]zoneSnapshot, 0, len(zones))}
+	for _, zone := range zones {
+		records, err := s.ListRecordsForZone(ctx, zone.ID, TenantID)
+		if err != nil {
+			return err
+		}
+		snap.Zones = append(snap.Zones, zoneSnapshot{Zone: zone, Records: records})
+	}
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to save edge store: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to save edge store: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save edge store: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to save edge store: %w", err)
+	}
+	return nil
+}
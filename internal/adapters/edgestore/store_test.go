@@ -0,0 +1,89 @@
+package edgestore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+func TestStorePersistsTransfers(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "edge.json")
+	s, err := Open(ctx, path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if err := s.Configure(ctx, []string{"example.test", "other.test."}, "192.0.2.1"); err != nil {
+		t.Fatalf("Configure failed: %v", err)
+	}
+	zone, _ := s.GetZone(ctx, "example.test.")
+	if zone == nil || zone.Role != "slave" || zone.MasterServer != "192.0.2.1" || zone.TenantID != TenantID {
+		t.Fatalf("Expected a secondary zone of the hub, got %+v", zone)
+	}
+	records := []domain.Record{
+		{Name: "example.test.", Type: domain.TypeSOA, TTL: 3600, Content: "ns.example.test. admin.example.test. 7 3600 600 86400 300"},
+		{Name: "www.example.test.", Type: domain.TypeA, TTL: 300, Content: "192.0.2.10"},
+	}
+	if err := s.ReplaceZoneRecords(ctx, zone.ID, records); err != nil {
+		t.Fatalf("ReplaceZoneRecords failed: %v", err)
+	}
+
+	// A restart serves the last copy, pointed at the current hub
+	s, err = Open(ctx, path)
+	if err != nil {
+		t.Fatalf("reopening failed: %v", err)
+	}
+	if err := s.Configure(ctx, []string{"example.test."}, "192.0.2.2:5353"); err != nil {
+		t.Fatalf("Configure failed: %v", err)
+	}
+	got, err := s.GetRecords(ctx, "www.example.test.", domain.TypeA, "")
+	if err != nil || len(got) != 1 || got[0].Content != "192.0.2.10" {
+		t.Fatalf("Expected the saved record, got %+v, %v", got, err)
+	}
+	if zone, _ := s.GetZone(ctx, "example.test."); zone == nil || zone.MasterServer != "192.0.2.2:5353" {
+		t.Errorf("Expected the zone to follow the new hub, got %+v", zone)
+	}
+	if zone, _ := s.GetZone(ctx, "other.test."); zone != nil {
+		t.Errorf("Expected a zone no longer configured to be removed, got %+v", zone)
+	}
+}
+
+func TestStoreConfigureRejectsBadSettings(t *testing.T) {
+	ctx := context.Background()
+	s, err := Open(ctx, filepath.Join(t.TempDir(), "edge.json"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if err := s.Configure(ctx, []string{"example.test."}, ""); err == nil {
+		t.Error("Expected a missing hub to be rejected")
+	}
+	if err := s.Configure(ctx, []string{"bad..name"}, "192.0.2.1"); err == nil {
+		t.Error("Expected an invalid zone name to be rejected")
+	}
+}
+
+func TestStoreZonesAreUnsigned(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "edge.json")
+	// A file saved before edge zones turned DNSSEC off leaves it unset
+	legacy := `{"zones":[{"zone":{"id":"z1","tenant_id":"edge","name":"old.test.","role":"slave","master_server":"192.0.2.1"},"records":[]}]}`
+	if err := os.WriteFile(path, []byte(legacy), 0o600); err != nil {
+		t.Fatalf("failed to write store: %v", err)
+	}
+	s, err := Open(ctx, path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if err := s.Configure(ctx, []string{"old.test.", "new.test."}, "192.0.2.1"); err != nil {
+		t.Fatalf("Configure failed: %v", err)
+	}
+	for _, name := range []string{"old.test.", "new.test."} {
+		zone, _ := s.GetZone(ctx, name)
+		if zone == nil || zone.SignsDNSSEC() {
+			t.Errorf("Expected %s to be served unsigned, got %+v", name, zone)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Package geoip locates client addresses with MaxMind databases (.mmdb), such
+// as GeoLite2-Country, GeoLite2-City and GeoLite2-ASN, or compatible ones.
+package geoip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+
+	"github.com/oschwald/maxminddb-golang/v2"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// Database answers locations from one or more MaxMind databases. Country and
+// ASN data come in separate databases, so each lookup merges what all of them
+// know about an address, the first database to know a field winning.
+type Database struct {
+	readers []*maxminddb.Reader
+}
+
+// location is the part of a MaxMind record a client is located by.
+type location struct {
+	Continent struct {
+		Code string `maxminddb:"code"`
+	} `maxminddb:"continent"`
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	// RegisteredCountry stands in for Country, which is missing for
+	// addresses such as anycast ones
+	RegisteredCountry struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"registered_country"`
+	ASN uint32 `maxminddb:"autonomous_system_number"`
+}
+
+// Open opens the databases at paths.
+func Open(paths ...string) (*Database, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("geoip: no database")
+	}
+	db := &Database{}
+	for _, path := range paths {
+		reader, err := maxminddb.Open(path)
+		if err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("geoip: %s: %w", path, err)
+		}
+		db.readers = append(db.readers, reader)
+	}
+	return db, nil
+}
+
+// Locate returns what the databases know about ip; false if nothing.
+func (d *Database) Locate(ip net.IP) (domain.GeoLocation, bool) {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return domain.GeoLocation{}, false
+	}
+	addr = addr.Unmap()
+
+	var loc domain.GeoLocation
+	for _, reader := range d.readers {
+		var rec location
+		if err := reader.Lookup(addr).Decode(&rec); err != nil {
+			continue
+		}
+		if loc.Continent == "" {
+			loc.Continent = rec.Continent.Code
+		}
+		if loc.Country == "" {
+			loc.Country = rec.Country.ISOCode
+		}
+		if loc.Country == "" {
+			loc.Country = rec.RegisteredCountry.ISOCode
+		}
+		if loc.ASN == 0 {
+			loc.ASN = rec.ASN
+		}
+	}
+	return loc, loc != domain.GeoLocation{}
+}
+
+// Close closes the databases.
+func (d *Database) Close() error {
+	var errs []error
+	for _, reader := range d.readers {
+		errs = append(errs, reader.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,95 @@
+package geoip
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/maxmind/mmdbwriter"
+	"github.com/maxmind/mmdbwriter/mmdbtype"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// writeDatabase writes a database mapping each network to its record.
+func writeDatabase(t *testing.T, dbType string, records map[string]mmdbtype.Map) string {
+	t.Helper()
+	tree, err := mmdbwriter.New(mmdbwriter.Options{DatabaseType: dbType, RecordSize: 24, IncludeReservedNetworks: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for cidr, rec := range records {
+		_, network, errParse := net.ParseCIDR(cidr)
+		if errParse != nil {
+			t.Fatal(errParse)
+		}
+		if errInsert := tree.Insert(network, rec); errInsert != nil {
+			t.Fatal(errInsert)
+		}
+	}
+	path := filepath.Join(t.TempDir(), dbType+".mmdb")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := tree.WriteTo(f); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func country(continent, iso string) mmdbtype.Map {
+	return mmdbtype.Map{
+		"continent": mmdbtype.Map{"code": mmdbtype.String(continent)},
+		"country":   mmdbtype.Map{"iso_code": mmdbtype.String(iso)},
+	}
+}
+
+func TestDatabaseLocate(t *testing.T) {
+	countries := writeDatabase(t, "GeoLite2-Country", map[string]mmdbtype.Map{
+		"81.0.0.0/8":    country("EU", "DE"),
+		"2001:db8::/32": country("NA", "US"),
+		"198.51.100.0/24": {
+			"continent":          mmdbtype.Map{"code": mmdbtype.String("EU")},
+			"registered_country": mmdbtype.Map{"iso_code": mmdbtype.String("NL")},
+		},
+	})
+	asns := writeDatabase(t, "GeoLite2-ASN", map[string]mmdbtype.Map{
+		"81.2.0.0/16": {"autonomous_system_number": mmdbtype.Uint32(64500)},
+	})
+
+	db, err := Open(countries, asns)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	tests := []struct {
+		ip    string
+		want  domain.GeoLocation
+		found bool
+	}{
+		{"81.2.3.4", domain.GeoLocation{Continent: "EU", Country: "DE", ASN: 64500}, true},
+		{"81.9.3.4", domain.GeoLocation{Continent: "EU", Country: "DE"}, true},
+		{"::ffff:81.2.3.4", domain.GeoLocation{Continent: "EU", Country: "DE", ASN: 64500}, true},
+		{"2001:db8::1", domain.GeoLocation{Continent: "NA", Country: "US"}, true},
+		{"198.51.100.7", domain.GeoLocation{Continent: "EU", Country: "NL"}, true},
+		{"192.0.2.1", domain.GeoLocation{}, false},
+	}
+	for _, tt := range tests {
+		got, found := db.Locate(net.ParseIP(tt.ip))
+		if got != tt.want || found != tt.found {
+			t.Errorf("Locate(%s) = %+v, %v, want %+v, %v", tt.ip, got, found, tt.want, tt.found)
+		}
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	if _, err := Open(); err == nil {
+		t.Error("Expected an error without databases")
+	}
+	if _, err := Open(filepath.Join(t.TempDir(), "missing.mmdb")); err == nil {
+		t.Error("Expected an error for a missing database")
+	}
+}
@@ -0,0 +1,1427 @@
+// Package memory provides a repository that holds everything in memory, for
+// edge nodes that run without PostgreSQL and for in-process test topologies.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/core/ports"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+	"github.com/poyrazK/cloudDNS/internal/dns/serial"
+)
+
+var _ ports.DNSRepository = (*Repository)(nil)
+
+// Repository is a ports.DNSRepository holding everything in memory. It
+// follows the PostgreSQL repository's semantics for zones, records, zone
+// changes and transfers; the other stores keep what they are given.
+type Repository struct {
+	mu          sync.RWMutex
+	zones       []domain.Zone
+	records     []domain.Record
+	changes     []domain.ZoneChange
+	keys        []domain.DNSSECKey
+	apiKeys     []domain.APIKey
+	audits      []domain.AuditLog
+	health      map[string]domain.HealthStatus
+	nodes       map[string]domain.Node
+	nodeConfig  map[string]map[string]string
+	rollouts    map[string]domain.Rollout
+	usage       []domain.ZoneUsage
+	secondaries []domain.SecondaryProvider
+	refreshes   map[string]domain.ZoneRefresh
+	scheduled   []domain.ScheduledChange
+	freezes     []domain.ChangeFreeze
+	shadows     map[string]domain.ShadowChange
+	slos        map[string]domain.ZoneSLO
+	limits      []domain.QueryLimit
+	views       []domain.View
+	defaults    []domain.DefaultRecord
+	targets     []domain.Target
+	checks      []domain.HealthCheck
+	subnets     []domain.Subnet
+	allocs      []domain.IPAllocation
+	reviewed    map[string]string
+	requests    []domain.ChangeRequest
+}
+
+// NewRepository creates an empty repository.
+func NewRepository() *Repository {
+	return &Repository{
+		health:     make(map[string]domain.HealthStatus),
+		nodes:      make(map[string]domain.Node),
+		nodeConfig: make(map[string]map[string]string),
+		rollouts:   make(map[string]domain.Rollout),
+		shadows:    make(map[string]domain.ShadowChange),
+		slos:       make(map[string]domain.ZoneSLO),
+		reviewed:   make(map[string]string),
+		refreshes:  make(map[string]domain.ZoneRefresh),
+	}
+}
+
+// visibleTo reports whether a record scoped to network is visible to clientIP,
+// an address or a client subnet.
+func visibleTo(network *string, clientIP string) bool {
+	if network == nil {
+		return true
+	}
+	_, scope, err := net.ParseCIDR(*network)
+	if err != nil {
+		return false
+	}
+	if ip, subnet, err := net.ParseCIDR(clientIP); err == nil {
+		ones, _ := subnet.Mask.Size()
+		scopeOnes, _ := scope.Mask.Size()
+		return scope.Contains(ip) && ones >= scopeOnes
+	}
+	ip := net.ParseIP(clientIP)
+	return ip != nil && scope.Contains(ip)
+}
+
+// withHealth returns rec with its probed health status, that of its health
+// check if it references one.
+func (m *Repository) withHealth(rec domain.Record) domain.Record {
+	if rec.HealthCheckID != nil {
+		rec.HealthStatus = domain.HealthStatusUnknown
+		for _, c := range m.checks {
+			if c.ID == *rec.HealthCheckID && c.Status != "" {
+				rec.HealthStatus = c.Status
+			}
+		}
+		return rec
+	}
+	if status, ok := m.health[rec.ID]; ok {
+		rec.HealthStatus = status
+	} else {
+		rec.HealthStatus = domain.HealthStatusUnknown
+	}
+	return rec
+}
+
+func (m *Repository) GetRecords(_ context.Context, name string, qType domain.RecordType, clientIP string) ([]domain.Record, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var res []domain.Record
+	for _, r := range m.records {
+		if dnsname.Equal(r.Name, name) && (qType == "" || r.Type == qType) && visibleTo(r.Network, clientIP) {
+			res = append(res, m.withHealth(r))
+		}
+	}
+	return res, nil
+}
+
+func (m *Repository) GetIPsForName(_ context.Context, name string, clientIP string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var ips []string
+	for _, r := range m.records {
+		if dnsname.Equal(r.Name, name) && (r.Type == domain.TypeA || r.Type == domain.TypeAAAA) && visibleTo(r.Network, clientIP) {
+			ips = append(ips, r.Content)
+		}
+	}
+	return ips, nil
+}
+
+func (m *Repository) ListRecordNetworks(_ context.Context) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	seen := make(map[string]bool)
+	var networks []string
+	for _, r := range m.records {
+		if r.Network != nil && !seen[*r.Network] {
+			seen[*r.Network] = true
+			networks = append(networks, *r.Network)
+		}
+	}
+	sort.Strings(networks)
+	return networks, nil
+}
+
+func (m *Repository) ListRecordGeoScopes(_ context.Context) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	seen := make(map[string]bool)
+	var scopes []string
+	for _, r := range m.records {
+		if r.Geo == nil {
+			continue
+		}
+		for _, scope := range r.Geo.Scopes() {
+			if !seen[scope] {
+				seen[scope] = true
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	sort.Strings(scopes)
+	return scopes, nil
+}
+
+func (m *Repository) GetZone(_ context.Context, name string) (*domain.Zone, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, z := range m.zones {
+		if dnsname.Equal(z.Name, name) {
+			return &z, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *Repository) GetZoneByID(_ context.Context, zoneID string, tenantID string) (*domain.Zone, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, z := range m.zones {
+		if z.ID == zoneID && (tenantID == "" || z.TenantID == tenantID) {
+			return &z, nil
+		}
+	}
+	return nil, nil
+}
+
+// ownedBy reports whether zoneID belongs to tenantID. Records are scoped by
+// their zone's tenant, as in Postgres, since dynamic updates leave theirs empty.
+func (m *Repository) ownedBy(zoneID, tenantID string) bool {
+	for _, z := range m.zones {
+		if z.ID == zoneID {
+			return tenantID == "" || z.TenantID == tenantID
+		}
+	}
+	return false
+}
+
+func (m *Repository) GetRecord(_ context.Context, id string, zoneID string, tenantID string) (*domain.Record, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	owned := m.ownedBy(zoneID, tenantID)
+	for _, r := range m.records {
+		if r.ID == id && r.ZoneID == zoneID && owned {
+			rec := m.withHealth(r)
+			return &rec, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *Repository) ListRecordsForZone(_ context.Context, zoneID string, tenantID string) ([]domain.Record, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	owned := m.ownedBy(zoneID, tenantID)
+	var res []domain.Record
+	for _, r := range m.records {
+		if r.ZoneID == zoneID && owned {
+			res = append(res, m.withHealth(r))
+		}
+	}
+	return res, nil
+}
+
+func (m *Repository) NameExists(_ context.Context, zoneID string, name string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, r := range m.records {
+		if r.ZoneID == zoneID && dnsname.IsSubdomain(r.Name, name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *Repository) CreateZone(_ context.Context, zone *domain.Zone) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, z := range m.zones {
+		if dnsname.Equal(z.Name, zone.Name) {
+			return fmt.Errorf("zone %s already exists", zone.Name)
+		}
+	}
+	if zone.ID == "" {
+		zone.ID = uuid.New().String()
+	}
+	m.zones = append(m.zones, *zone)
+	return nil
+}
+
+func (m *Repository) CreateZoneWithRecords(ctx context.Context, zone *domain.Zone, records []domain.Record) error {
+	if err := m.CreateZone(ctx, zone); err != nil {
+		return err
+	}
+	for i := range records {
+		records[i].ZoneID = zone.ID
+	}
+	return m.BatchCreateRecords(ctx, records)
+}
+
+// prepare fills in the ID and timestamps of a new record.
+func prepare(rec *domain.Record) {
+	if rec.ID == "" {
+		rec.ID = uuid.New().String()
+	}
+	if rec.CreatedAt.IsZero() {
+		now := time.Now()
+		rec.CreatedAt, rec.UpdatedAt = now, now
+	}
+	if rec.HealthCheckType == "" {
+		rec.HealthCheckType = domain.HealthCheckNone
+	}
+}
+
+func (m *Repository) CreateRecord(_ context.Context, record *domain.Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	prepare(record)
+	m.records = append(m.records, *record)
+	return nil
+}
+
+func (m *Repository) BatchCreateRecords(_ context.Context, records []domain.Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range records {
+		prepare(&records[i])
+		m.records = append(m.records, records[i])
+	}
+	return nil
+}
+
+func (m *Repository) HasZones(_ context.Context) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.zones) > 0, nil
+}
+
+func (m *Repository) ListZones(_ context.Context, tenantID string) ([]domain.Zone, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var res []domain.Zone
+	for _, z := range m.zones {
+		if tenantID == "" || z.TenantID == tenantID {
+			res = append(res, z)
+		}
+	}
+	return res, nil
+}
+
+// removeRecords deletes the records matching drop and reports how many there
+// were. The caller holds m.mu.
+func (m *Repository) removeRecords(drop func(domain.Record) bool) int {
+	next := m.records[:0]
+	removed := 0
+	for _, r := range m.records {
+		if drop(r) {
+			removed++
+			continue
+		}
+		next = append(next, r)
+	}
+	m.records = next
+	return removed
+}
+
+func (m *Repository) DeleteZone(_ context.Context, zoneID string, tenantID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, z := range m.zones {
+		if z.ID == zoneID && z.TenantID == tenantID {
+			m.zones = append(m.zones[:i], m.zones[i+1:]...)
+			m.removeRecords(func(r domain.Record) bool { return r.ZoneID == zoneID })
+			return nil
+		}
+	}
+	return domain.ErrNotFound
+}
+
+// updateZone applies fn to a tenant's zone. The caller must not hold m.mu.
+func (m *Repository) updateZone(zoneID, tenantID string, fn func(*domain.Zone)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.zones {
+		if m.zones[i].ID == zoneID && m.zones[i].TenantID == tenantID {
+			fn(&m.zones[i])
+			m.zones[i].UpdatedAt = time.Now()
+			return nil
+		}
+	}
+	return domain.ErrNotFound
+}
+
+func (m *Repository) SetZoneDNSSEC(_ context.Context, zoneID string, tenantID string, enabled bool) error {
+	return m.updateZone(zoneID, tenantID, func(z *domain.Zone) { z.DNSSECEnabled = &enabled })
+}
+
+func (m *Repository) SetZoneRole(_ context.Context, zoneID string, tenantID string, role string, masterServer string) error {
+	return m.updateZone(zoneID, tenantID, func(z *domain.Zone) {
+		z.Role = role
+		z.MasterServer = masterServer
+	})
+}
+
+func (m *Repository) DeleteRecord(_ context.Context, recordID string, zoneID string, tenantID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	owned := m.ownedBy(zoneID, tenantID)
+	m.removeRecords(func(r domain.Record) bool {
+		return r.ID == recordID && r.ZoneID == zoneID && owned
+	})
+	return nil
+}
+
+func (m *Repository) SetRecordProtected(_ context.Context, recordID string, zoneID string, tenantID string, protected bool) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	owned := m.ownedBy(zoneID, tenantID)
+	for i, r := range m.records {
+		if r.ID == recordID && r.ZoneID == zoneID && owned {
+			m.records[i].Protected = protected
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *Repository) DeleteRecordsByNameAndType(_ context.Context, zoneID string, name string, qType domain.RecordType) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removeRecords(func(r domain.Record) bool {
+		return r.ZoneID == zoneID && dnsname.Equal(r.Name, name) && r.Type == qType
+	})
+	return nil
+}
+
+func (m *Repository) DeleteRecordsByName(_ context.Context, zoneID string, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removeRecords(func(r domain.Record) bool { return r.ZoneID == zoneID && dnsname.Equal(r.Name, name) })
+	return nil
+}
+
+func (m *Repository) DeleteRecordsForZone(_ context.Context, zoneID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removeRecords(func(r domain.Record) bool { return r.ZoneID == zoneID })
+	return nil
+}
+
+func (m *Repository) ReplaceZoneRecords(_ context.Context, zoneID string, records []domain.Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removeRecords(func(r domain.Record) bool { return r.ZoneID == zoneID })
+	for _, rec := range records {
+		rec.ZoneID = zoneID
+		prepare(&rec)
+		m.records = append(m.records, rec)
+	}
+	return nil
+}
+
+// ApplyZoneDiff applies the difference sequences of an IXFR all or nothing. A
+// deleted record the zone does not hold fails the whole diff with ErrNotFound.
+func (m *Repository) ApplyZoneDiff(_ context.Context, zoneID string, diffs []domain.IXFRChunk) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	records := append([]domain.Record(nil), m.records...)
+	for _, diff := range diffs {
+		for _, del := range diff.Deleted {
+			found := false
+			for i := 0; i < len(records); i++ {
+				r := records[i]
+				if r.ZoneID != zoneID || r.Type != del.Type {
+					continue
+				}
+				if del.Type == domain.TypeSOA || dnsname.Equal(r.Name, del.Name) && r.Content == del.Content {
+					records = append(records[:i], records[i+1:]...)
+					found = true
+					if del.Type != domain.TypeSOA {
+						break
+					}
+					i--
+				}
+			}
+			if !found && del.Type != domain.TypeSOA {
+				return fmt.Errorf("serial %d deletes %s %s %s: %w", diff.Serial, del.Name, del.Type, del.Content, domain.ErrNotFound)
+			}
+		}
+		for _, add := range diff.Added {
+			add.ZoneID = zoneID
+			prepare(&add)
+			records = append(records, add)
+		}
+	}
+	m.records = records
+	return nil
+}
+
+func (m *Repository) DeleteRecordSpecific(_ context.Context, zoneID string, name string, qType domain.RecordType, content string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removeRecords(func(r domain.Record) bool {
+		return r.ZoneID == zoneID && dnsname.Equal(r.Name, name) && r.Type == qType && r.Content == content
+	})
+	return nil
+}
+
+func (m *Repository) ReplaceRRset(_ context.Context, zoneID string, name string, qType domain.RecordType, records []domain.Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removeRecords(func(r domain.Record) bool {
+		return r.ZoneID == zoneID && dnsname.Equal(r.Name, name) && r.Type == qType
+	})
+	for _, rec := range records {
+		prepare(&rec)
+		m.records = append(m.records, rec)
+	}
+	return nil
+}
+
+func (m *Repository) RecordZoneChange(_ context.Context, change *domain.ZoneChange) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.changes = append(m.changes, *change)
+	return nil
+}
+
+func (m *Repository) ListZoneChanges(_ context.Context, zoneID string, fromSerial uint32) ([]domain.ZoneChange, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var res []domain.ZoneChange
+	for _, c := range m.changes {
+		if c.ZoneID == zoneID && serial.Greater(c.Serial, fromSerial) {
+			res = append(res, c)
+		}
+	}
+	return res, nil
+}
+
+func (m *Repository) LastZoneChangeAt(_ context.Context, zoneID string) (*time.Time, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var last *time.Time
+	for i := range m.changes {
+		if c := &m.changes[i]; c.ZoneID == zoneID && (last == nil || c.CreatedAt.After(*last)) {
+			t := c.CreatedAt
+			last = &t
+		}
+	}
+	return last, nil
+}
+
+// GetIXFRChain groups the changes after fromSerial up to toSerial into one
+// difference sequence per serial, oldest first.
+func (m *Repository) GetIXFRChain(ctx context.Context, zoneID string, fromSerial uint32, toSerial uint32) ([]domain.IXFRChunk, error) {
+	changes, err := m.ListZoneChanges(ctx, zoneID, fromSerial)
+	if err != nil {
+		return nil, err
+	}
+	chunks := make(map[uint32]*domain.IXFRChunk)
+	var serials []uint32
+	for _, c := range changes {
+		if serial.Greater(c.Serial, toSerial) {
+			continue
+		}
+		chunk, ok := chunks[c.Serial]
+		if !ok {
+			chunk = &domain.IXFRChunk{Serial: c.Serial}
+			chunks[c.Serial] = chunk
+			serials = append(serials, c.Serial)
+		}
+		rec := domain.Record{Name: c.Name, Type: c.Type, Content: c.Content, TTL: c.TTL, Priority: c.Priority, Weight: c.Weight, Port: c.Port}
+		if c.Action == "DELETE" {
+			chunk.Deleted = append(chunk.Deleted, rec)
+		} else {
+			chunk.Added = append(chunk.Added, rec)
+		}
+	}
+	sort.Slice(serials, func(i, j int) bool { return serial.Less(serials[i], serials[j]) })
+	result := make([]domain.IXFRChunk, 0, len(serials))
+	for _, s := range serials {
+		result = append(result, *chunks[s])
+	}
+	return result, nil
+}
+
+func (m *Repository) SaveAuditLog(_ context.Context, log *domain.AuditLog) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.audits = append(m.audits, *log)
+	return nil
+}
+
+func (m *Repository) GetAuditLogs(_ context.Context, tenantID string) ([]domain.AuditLog, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var res []domain.AuditLog
+	for _, l := range m.audits {
+		if l.TenantID == tenantID {
+			res = append(res, l)
+		}
+	}
+	return res, nil
+}
+
+func (m *Repository) Ping(_ context.Context) error { return nil }
+
+func (m *Repository) CreateKey(_ context.Context, key *domain.DNSSECKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keys = append(m.keys, *key)
+	return nil
+}
+
+func (m *Repository) ListKeysForZone(_ context.Context, zoneID string) ([]domain.DNSSECKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var res []domain.DNSSECKey
+	for _, k := range m.keys {
+		if k.ZoneID == zoneID {
+			res = append(res, k)
+		}
+	}
+	return res, nil
+}
+
+func (m *Repository) UpdateKey(_ context.Context, key *domain.DNSSECKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.keys {
+		if m.keys[i].ID == key.ID {
+			m.keys[i] = *key
+			return nil
+		}
+	}
+	return domain.ErrNotFound
+}
+
+func (m *Repository) GetAPIKeyByHash(_ context.Context, keyHash string) (*domain.APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, k := range m.apiKeys {
+		if k.KeyHash == keyHash {
+			return &k, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *Repository) CreateAPIKey(_ context.Context, key *domain.APIKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.apiKeys = append(m.apiKeys, *key)
+	return nil
+}
+
+func (m *Repository) ListAPIKeys(_ context.Context, tenantID string) ([]domain.APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var res []domain.APIKey
+	for _, k := range m.apiKeys {
+		if k.TenantID == tenantID {
+			res = append(res, k)
+		}
+	}
+	return res, nil
+}
+
+func (m *Repository) DeleteAPIKey(_ context.Context, tenantID string, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, k := range m.apiKeys {
+		if k.ID == id && k.TenantID == tenantID {
+			m.apiKeys = append(m.apiKeys[:i], m.apiKeys[i+1:]...)
+			return nil
+		}
+	}
+	return domain.ErrNotFound
+}
+
+func (m *Repository) UpdateRecordHealth(_ context.Context, recordID string, status domain.HealthStatus, _ string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.health[recordID] = status
+	return nil
+}
+
+func (m *Repository) GetRecordsToProbe(_ context.Context) ([]domain.Record, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var res []domain.Record
+	for _, r := range m.records {
+		if r.HealthCheckType != "" && r.HealthCheckType != domain.HealthCheckNone {
+			res = append(res, m.withHealth(r))
+		}
+	}
+	return res, nil
+}
+
+func (m *Repository) UpsertNode(_ context.Context, node *domain.Node) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nodes[node.ID] = *node
+	return nil
+}
+
+func (m *Repository) ListNodes(_ context.Context) ([]domain.Node, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	nodes := make([]domain.Node, 0, len(m.nodes))
+	for _, n := range m.nodes {
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return nodes, nil
+}
+
+func (m *Repository) GetNodeConfig(_ context.Context, nodeID string) (map[string]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	cfg := make(map[string]string, len(m.nodeConfig[nodeID]))
+	for k, v := range m.nodeConfig[nodeID] {
+		cfg[k] = v
+	}
+	return cfg, nil
+}
+
+func (m *Repository) SetNodeConfig(_ context.Context, nodeID string, cfg map[string]string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored := make(map[string]string, len(cfg))
+	for k, v := range cfg {
+		stored[k] = v
+	}
+	m.nodeConfig[nodeID] = stored
+	return nil
+}
+
+func (m *Repository) SaveRollout(_ context.Context, rollout *domain.Rollout) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rollouts[rollout.ID] = *rollout
+	return nil
+}
+
+func (m *Repository) GetRollout(_ context.Context, id string) (*domain.Rollout, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if r, ok := m.rollouts[id]; ok {
+		return &r, nil
+	}
+	return nil, nil
+}
+
+func (m *Repository) ListRollouts(_ context.Context) ([]domain.Rollout, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	rollouts := make([]domain.Rollout, 0, len(m.rollouts))
+	for _, r := range m.rollouts {
+		rollouts = append(rollouts, r)
+	}
+	sort.Slice(rollouts, func(i, j int) bool { return rollouts[i].ID < rollouts[j].ID })
+	return rollouts, nil
+}
+
+func (m *Repository) AddZoneUsage(_ context.Context, usage []domain.ZoneUsage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.usage = append(m.usage, usage...)
+	return nil
+}
+
+func (m *Repository) ListZoneUsage(_ context.Context, tenantID string, zoneID string, from, to time.Time) ([]domain.ZoneUsage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	owned := make(map[string]bool)
+	for _, z := range m.zones {
+		owned[z.ID] = z.TenantID == tenantID
+	}
+	var res []domain.ZoneUsage
+	for _, u := range m.usage {
+		if owned[u.ZoneID] && (zoneID == "" || u.ZoneID == zoneID) && !u.Hour.Before(from) && u.Hour.Before(to) {
+			res = append(res, u)
+		}
+	}
+	return res, nil
+}
+
+func (m *Repository) CreateSecondaryProvider(_ context.Context, provider *domain.SecondaryProvider) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.secondaries = append(m.secondaries, *provider)
+	return nil
+}
+
+func (m *Repository) ListSecondaryProviders(_ context.Context, zoneID string) ([]domain.SecondaryProvider, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var res []domain.SecondaryProvider
+	for _, p := range m.secondaries {
+		if p.ZoneID == zoneID {
+			res = append(res, p)
+		}
+	}
+	return res, nil
+}
+
+func (m *Repository) DeleteSecondaryProvider(_ context.Context, id string, zoneID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, p := range m.secondaries {
+		if p.ID == id && p.ZoneID == zoneID {
+			m.secondaries = append(m.secondaries[:i], m.secondaries[i+1:]...)
+			return nil
+		}
+	}
+	return domain.ErrNotFound
+}
+
+func (m *Repository) UpdateSecondaryProviderStatus(_ context.Context, provider *domain.SecondaryProvider) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.secondaries {
+		if m.secondaries[i].ID == provider.ID {
+			m.secondaries[i] = *provider
+			return nil
+		}
+	}
+	return domain.ErrNotFound
+}
+
+func (m *Repository) SaveZoneRefresh(_ context.Context, refresh *domain.ZoneRefresh) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	saved := *refresh
+	if saved.SucceededAt == nil {
+		saved.SucceededAt = m.refreshes[refresh.ZoneID].SucceededAt
+	}
+	m.refreshes[refresh.ZoneID] = saved
+	return nil
+}
+
+func (m *Repository) GetZoneRefresh(_ context.Context, zoneID string) (*domain.ZoneRefresh, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	refresh, ok := m.refreshes[zoneID]
+	if !ok {
+		return nil, nil
+	}
+	return &refresh, nil
+}
+
+func (m *Repository) CreateScheduledChange(_ context.Context, change *domain.ScheduledChange) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.scheduled = append(m.scheduled, *change)
+	return nil
+}
+
+func (m *Repository) ListScheduledChanges(_ context.Context, zoneID string) ([]domain.ScheduledChange, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var res []domain.ScheduledChange
+	for _, c := range m.scheduled {
+		if c.ZoneID == zoneID {
+			res = append(res, c)
+		}
+	}
+	return res, nil
+}
+
+func (m *Repository) CancelScheduledChange(_ context.Context, id string, zoneID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, c := range m.scheduled {
+		if c.ID == id && c.ZoneID == zoneID && c.Status == domain.ScheduledPending {
+			m.scheduled = append(m.scheduled[:i], m.scheduled[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *Repository) ClaimDueScheduledChanges(_ context.Context, now time.Time, limit int) ([]domain.ScheduledChange, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var claimed []domain.ScheduledChange
+	for i := range m.scheduled {
+		if len(claimed) >= limit {
+			break
+		}
+		if c := &m.scheduled[i]; c.Status == domain.ScheduledPending && !c.RunAt.After(now) {
+			c.Status = domain.ScheduledRunning
+			claimed = append(claimed, *c)
+		}
+	}
+	return claimed, nil
+}
+
+func (m *Repository) FinishScheduledChange(_ context.Context, change *domain.ScheduledChange) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.scheduled {
+		if m.scheduled[i].ID == change.ID {
+			m.scheduled[i] = *change
+			return nil
+		}
+	}
+	return domain.ErrNotFound
+}
+
+func (m *Repository) SetZoneReview(_ context.Context, zoneID string, tenantID string, required bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if required {
+		m.reviewed[zoneID] = tenantID
+	} else {
+		delete(m.reviewed, zoneID)
+	}
+	return nil
+}
+
+func (m *Repository) IsZoneReviewed(_ context.Context, zoneID string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, ok := m.reviewed[zoneID]
+	return ok, nil
+}
+
+func (m *Repository) CreateChangeRequest(_ context.Context, change *domain.ChangeRequest) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests = append(m.requests, *change)
+	return nil
+}
+
+func (m *Repository) ListChangeRequests(_ context.Context, zoneID string) ([]domain.ChangeRequest, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var res []domain.ChangeRequest
+	for i := len(m.requests) - 1; i >= 0; i-- {
+		if m.requests[i].ZoneID == zoneID {
+			res = append(res, m.requests[i])
+		}
+	}
+	return res, nil
+}
+
+func (m *Repository) GetChangeRequest(_ context.Context, id string, zoneID string) (*domain.ChangeRequest, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, c := range m.requests {
+		if c.ID == id && c.ZoneID == zoneID {
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *Repository) ReviewChangeRequest(_ context.Context, change *domain.ChangeRequest) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.requests {
+		if c := &m.requests[i]; c.ID == change.ID && c.ZoneID == change.ZoneID && c.Status == domain.ChangePending {
+			*c = *change
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *Repository) FinishChangeRequest(_ context.Context, change *domain.ChangeRequest) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.requests {
+		if m.requests[i].ID == change.ID {
+			m.requests[i] = *change
+			return nil
+		}
+	}
+	return domain.ErrNotFound
+}
+
+func (m *Repository) CreateChangeFreeze(_ context.Context, freeze *domain.ChangeFreeze) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.freezes = append(m.freezes, *freeze)
+	return nil
+}
+
+func (m *Repository) ListChangeFreezes(_ context.Context, tenantID string) ([]domain.ChangeFreeze, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var res []domain.ChangeFreeze
+	for _, f := range m.freezes {
+		if f.TenantID == tenantID {
+			res = append(res, f)
+		}
+	}
+	return res, nil
+}
+
+func (m *Repository) DeleteChangeFreeze(_ context.Context, id string, tenantID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, f := range m.freezes {
+		if f.ID == id && f.TenantID == tenantID {
+			m.freezes = append(m.freezes[:i], m.freezes[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *Repository) GetActiveChangeFreeze(_ context.Context, tenantID string, zoneID string, at time.Time) (*domain.ChangeFreeze, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, f := range m.freezes {
+		if f.TenantID == tenantID && (f.ZoneID == "" || f.ZoneID == zoneID) && f.Active(at) {
+			return &f, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *Repository) SaveShadowChange(_ context.Context, change *domain.ShadowChange) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.shadows[change.ZoneID] = *change
+	return nil
+}
+
+func (m *Repository) GetShadowChange(_ context.Context, zoneID string) (*domain.ShadowChange, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if c, ok := m.shadows[zoneID]; ok {
+		return &c, nil
+	}
+	return nil, nil
+}
+
+func (m *Repository) ListShadowChanges(_ context.Context) ([]domain.ShadowChange, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	changes := make([]domain.ShadowChange, 0, len(m.shadows))
+	for _, c := range m.shadows {
+		changes = append(changes, c)
+	}
+	return changes, nil
+}
+
+func (m *Repository) DeleteShadowChange(_ context.Context, zoneID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.shadows[zoneID]
+	delete(m.shadows, zoneID)
+	return ok, nil
+}
+
+func (m *Repository) SaveZoneSLO(_ context.Context, slo *domain.ZoneSLO) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.slos[slo.ZoneID] = *slo
+	return nil
+}
+
+func (m *Repository) GetZoneSLO(_ context.Context, zoneID string) (*domain.ZoneSLO, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if slo, ok := m.slos[zoneID]; ok {
+		return &slo, nil
+	}
+	return nil, nil
+}
+
+func (m *Repository) ListZoneSLOs(_ context.Context) ([]domain.ZoneSLO, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	slos := make([]domain.ZoneSLO, 0, len(m.slos))
+	for _, slo := range m.slos {
+		slos = append(slos, slo)
+	}
+	return slos, nil
+}
+
+func (m *Repository) DeleteZoneSLO(_ context.Context, zoneID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.slos[zoneID]
+	delete(m.slos, zoneID)
+	return ok, nil
+}
+
+func (m *Repository) SaveQueryLimit(_ context.Context, limit *domain.QueryLimit) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.limits {
+		if m.limits[i].TenantID == limit.TenantID && m.limits[i].ZoneID == limit.ZoneID {
+			m.limits[i] = *limit
+			return nil
+		}
+	}
+	m.limits = append(m.limits, *limit)
+	return nil
+}
+
+func (m *Repository) ListQueryLimits(_ context.Context, tenantID string) ([]domain.QueryLimit, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var res []domain.QueryLimit
+	for _, l := range m.limits {
+		if tenantID == "" || l.TenantID == tenantID {
+			res = append(res, l)
+		}
+	}
+	return res, nil
+}
+
+func (m *Repository) DeleteQueryLimit(_ context.Context, tenantID string, zoneID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, l := range m.limits {
+		if l.TenantID == tenantID && l.ZoneID == zoneID {
+			m.limits = append(m.limits[:i], m.limits[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *Repository) SaveView(_ context.Context, view *domain.View) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.views {
+		if m.views[i].ID == view.ID {
+			if m.views[i].TenantID == view.TenantID {
+				m.views[i] = *view
+			}
+			return nil
+		}
+	}
+	m.views = append(m.views, *view)
+	return nil
+}
+
+// ListViews returns views in the order clients are matched against them.
+func (m *Repository) ListViews(_ context.Context, tenantID string) ([]domain.View, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var res []domain.View
+	for _, v := range m.views {
+		if tenantID == "" || v.TenantID == tenantID {
+			res = append(res, v)
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].TenantID != res[j].TenantID {
+			return res[i].TenantID < res[j].TenantID
+		}
+		if res[i].Priority != res[j].Priority {
+			return res[i].Priority < res[j].Priority
+		}
+		return res[i].Name < res[j].Name
+	})
+	return res, nil
+}
+
+// DeleteView removes a view with the records scoped to it.
+func (m *Repository) DeleteView(_ context.Context, id string, tenantID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, v := range m.views {
+		if v.ID == id && v.TenantID == tenantID {
+			m.views = append(m.views[:i], m.views[i+1:]...)
+			m.removeRecords(func(r domain.Record) bool { return r.ViewID != nil && *r.ViewID == id })
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *Repository) SaveDefaultRecord(_ context.Context, rec *domain.DefaultRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.defaults = append(m.defaults, *rec)
+	return nil
+}
+
+func (m *Repository) ListDefaultRecords(_ context.Context, zoneID string) ([]domain.DefaultRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var res []domain.DefaultRecord
+	for _, d := range m.defaults {
+		if zoneID == "" || d.ZoneID == zoneID {
+			res = append(res, d)
+		}
+	}
+	return res, nil
+}
+
+func (m *Repository) DeleteDefaultRecord(_ context.Context, id string, zoneID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, d := range m.defaults {
+		if d.ID == id && d.ZoneID == zoneID {
+			m.defaults = append(m.defaults[:i], m.defaults[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *Repository) SaveTarget(_ context.Context, target *domain.Target) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.targets {
+		if m.targets[i].ID == target.ID {
+			if m.targets[i].TenantID == target.TenantID {
+				m.targets[i] = *target
+			}
+			return nil
+		}
+	}
+	m.targets = append(m.targets, *target)
+	return nil
+}
+
+func (m *Repository) ListTargets(_ context.Context, tenantID string) ([]domain.Target, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var res []domain.Target
+	for _, t := range m.targets {
+		if tenantID == "" || t.TenantID == tenantID {
+			res = append(res, t)
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].TenantID != res[j].TenantID {
+			return res[i].TenantID < res[j].TenantID
+		}
+		return res[i].Name < res[j].Name
+	})
+	return res, nil
+}
+
+// DeleteTarget removes a target no record references.
+func (m *Repository) DeleteTarget(_ context.Context, id string, tenantID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range m.records {
+		if r.TargetID != nil && *r.TargetID == id {
+			return false, fmt.Errorf("target %s is referenced by record %s", id, r.ID)
+		}
+	}
+	for i, t := range m.targets {
+		if t.ID == id && t.TenantID == tenantID {
+			m.targets = append(m.targets[:i], m.targets[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *Repository) ListTargetRecords(_ context.Context, targetID string) ([]domain.Record, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var res []domain.Record
+	for _, r := range m.records {
+		if r.TargetID != nil && *r.TargetID == targetID {
+			res = append(res, r)
+		}
+	}
+	return res, nil
+}
+
+func (m *Repository) SaveHealthCheck(_ context.Context, check *domain.HealthCheck) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.checks {
+		if m.checks[i].ID == check.ID {
+			if m.checks[i].TenantID == check.TenantID {
+				status, lastError, changedAt := m.checks[i].Status, m.checks[i].LastError, m.checks[i].StatusChangedAt
+				m.checks[i] = *check
+				m.checks[i].Status, m.checks[i].LastError, m.checks[i].StatusChangedAt = status, lastError, changedAt
+			}
+			return nil
+		}
+	}
+	m.checks = append(m.checks, *check)
+	return nil
+}
+
+func (m *Repository) ListHealthChecks(_ context.Context, tenantID string) ([]domain.HealthCheck, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var res []domain.HealthCheck
+	for _, c := range m.checks {
+		if tenantID == "" || c.TenantID == tenantID {
+			res = append(res, c)
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].TenantID != res[j].TenantID {
+			return res[i].TenantID < res[j].TenantID
+		}
+		return res[i].Name < res[j].Name
+	})
+	return res, nil
+}
+
+// DeleteHealthCheck removes a health check no record references.
+func (m *Repository) DeleteHealthCheck(_ context.Context, id string, tenantID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range m.records {
+		if r.HealthCheckID != nil && *r.HealthCheckID == id {
+			return false, fmt.Errorf("health check %s is referenced by record %s", id, r.ID)
+		}
+	}
+	for i, c := range m.checks {
+		if c.ID == id && c.TenantID == tenantID {
+			m.checks = append(m.checks[:i], m.checks[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *Repository) UpdateHealthCheckStatus(_ context.Context, id string, status domain.HealthStatus, errMsg string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for i := range m.checks {
+		if m.checks[i].ID == id {
+			m.checks[i].Status = status
+			m.checks[i].LastError = errMsg
+			m.checks[i].StatusChangedAt = &now
+		}
+	}
+	return nil
+}
+
+func (m *Repository) ListHealthCheckRecords(_ context.Context, checkID string) ([]domain.Record, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var res []domain.Record
+	for _, r := range m.records {
+		if r.HealthCheckID != nil && *r.HealthCheckID == checkID {
+			res = append(res, m.withHealth(r))
+		}
+	}
+	return res, nil
+}
+
+func (m *Repository) SaveSubnet(_ context.Context, subnet *domain.Subnet) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subnets = append(m.subnets, *subnet)
+	return nil
+}
+
+func (m *Repository) ListSubnets(_ context.Context, tenantID string) ([]domain.Subnet, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var res []domain.Subnet
+	for _, s := range m.subnets {
+		if tenantID == "" || s.TenantID == tenantID {
+			res = append(res, s)
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].TenantID != res[j].TenantID {
+			return res[i].TenantID < res[j].TenantID
+		}
+		return res[i].Name < res[j].Name
+	})
+	return res, nil
+}
+
+// DeleteSubnet removes a subnet with its allocations.
+func (m *Repository) DeleteSubnet(_ context.Context, id string, tenantID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, s := range m.subnets {
+		if s.ID == id && s.TenantID == tenantID {
+			m.subnets = append(m.subnets[:i], m.subnets[i+1:]...)
+			m.allocs = slices.DeleteFunc(m.allocs, func(a domain.IPAllocation) bool { return a.SubnetID == id })
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// SaveIPAllocation stores an allocation, failing if its address is taken.
+func (m *Repository) SaveIPAllocation(_ context.Context, alloc *domain.IPAllocation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, a := range m.allocs {
+		if a.SubnetID == alloc.SubnetID && a.Address == alloc.Address {
+			return fmt.Errorf("address %s is already allocated", alloc.Address)
+		}
+	}
+	m.allocs = append(m.allocs, *alloc)
+	return nil
+}
+
+func (m *Repository) ListIPAllocations(_ context.Context, subnetID string) ([]domain.IPAllocation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var res []domain.IPAllocation
+	for _, a := range m.allocs {
+		if a.SubnetID == subnetID {
+			res = append(res, a)
+		}
+	}
+	return res, nil
+}
+
+func (m *Repository) DeleteIPAllocation(_ context.Context, id string, subnetID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, a := range m.allocs {
+		if a.ID == id && a.SubnetID == subnetID {
+			m.allocs = append(m.allocs[:i], m.allocs[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ZoneSnapshot returns the records of the zone named zoneName in a canonical
+// order, as "name TTL type content" lines, and the zone's SOA serial. It
+// reports false if there is no such zone.
+func (m *Repository) ZoneSnapshot(zoneName string) ([]string, uint32, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var zoneID string
+	for _, z := range m.zones {
+		if dnsname.Equal(z.Name, zoneName) {
+			zoneID = z.ID
+		}
+	}
+	if zoneID == "" {
+		return nil, 0, false
+	}
+	var lines []string
+	var soaSerial uint32
+	for _, r := range m.records {
+		if r.ZoneID != zoneID {
+			continue
+		}
+		content := r.Content
+		if r.Type == domain.TypeSOA {
+			soaSerial, _ = serial.FromSOA(r.Content)
+		}
+		if r.Priority != nil {
+			content = fmt.Sprintf("%d %s", *r.Priority, content)
+		}
+		lines = append(lines, fmt.Sprintf("%s %d %s %s", dnsname.Normalize(r.Name), r.TTL, r.Type, strings.ToLower(content)))
+	}
+	sort.Strings(lines)
+	return lines, soaSerial, true
+}
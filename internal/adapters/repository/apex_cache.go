@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+	"github.com/poyrazK/cloudDNS/internal/dns/serial"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+)
+
+// apexCache keeps the SOA and NS RRsets of zone apexes in memory, so filling the
+// authority section of a response does not cost a database round trip. Entries
+// hold the records of every network and are filtered per client on the way out.
+//
+// Writes made through the repository drop the affected zone right away. Changes
+// made by other nodes are picked up by comparing the zone's SOA serial once an
+// entry is older than the revalidation interval.
+type apexCache struct {
+	mu         sync.RWMutex
+	entries    map[string]*apexEntry // by normalized owner name
+	revalidate time.Duration
+	now        func() time.Time
+}
+
+type apexEntry struct {
+	apex    bool // false caches that the name is not a zone apex
+	zoneID  string
+	serial  uint32
+	records []domain.Record
+	checked time.Time
+}
+
+// EnableApexCache turns on in-memory caching of zone apex SOA and NS records.
+// Cached zones are checked for a new SOA serial at most once per revalidate.
+func (r *PostgresRepository) EnableApexCache(revalidate time.Duration) {
+	r.apex = &apexCache{
+		entries:    make(map[string]*apexEntry),
+		revalidate: revalidate,
+		now:        time.Now,
+	}
+}
+
+// apexRecords answers a SOA or NS lookup from the apex cache. ok is false if name
+// is not a zone apex, in which case the caller queries the database as usual.
+func (r *PostgresRepository) apexRecords(ctx context.Context, name string, qType domain.RecordType, clientIP string) ([]domain.Record, bool, error) {
+	name = dnsname.Normalize(name)
+	c := r.apex
+
+	c.mu.RLock()
+	entry := c.entries[name]
+	stale := entry != nil && c.now().Sub(entry.checked) >= c.revalidate
+	c.mu.RUnlock()
+
+	if stale {
+		fresh, err := r.revalidateApex(ctx, entry)
+		if err != nil {
+			return nil, false, err
+		}
+		if !fresh {
+			if entry.apex {
+				c.invalidateZone(entry.zoneID)
+			} else {
+				c.mu.Lock()
+				delete(c.entries, name)
+				c.mu.Unlock()
+			}
+			entry = nil
+		}
+	}
+
+	if entry != nil {
+		metrics.CacheOperations.WithLabelValues("apex", "hit").Inc()
+	} else {
+		metrics.CacheOperations.WithLabelValues("apex", "miss").Inc()
+		var err error
+		if entry, err = r.loadApex(ctx, name); err != nil {
+			return nil, false, err
+		}
+	}
+	if !entry.apex {
+		return nil, false, nil
+	}
+	return filterApex(entry.records, qType, clientIP), true, nil
+}
+
+// loadApex reads the SOA and NS records of name for all networks and caches them.
+func (r *PostgresRepository) loadApex(ctx context.Context, name string) (*apexEntry, error) {
+	query := `SELECT r.id, r.zone_id, r.name, r.type, r.content, r.ttl, r.priority, r.weight, r.port, r.network, r.view_id, r.geo,
+	                 r.health_check_type, r.health_check_target, COALESCE(h.status, 'UNKNOWN')
+	          FROM dns_records r
+	          LEFT JOIN record_health h ON r.id = h.record_id
+	          WHERE LOWER(r.name) = LOWER($1) AND r.type IN ('SOA', 'NS')`
+	rows, err := r.queryContext(ctx, query, name)
+	if err != nil {
+		return nil, err
+	}
+	records, err := scanRecordRows(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &apexEntry{records: records, checked: r.apex.now()}
+	for _, rec := range records {
+		if rec.Type == domain.TypeSOA {
+			if current, ok := serial.FromSOA(rec.Content); ok {
+				entry.apex = true
+				entry.zoneID = rec.ZoneID
+				entry.serial = current
+				break
+			}
+		}
+	}
+
+	r.apex.mu.Lock()
+	r.apex.entries[name] = entry
+	r.apex.mu.Unlock()
+	return entry, nil
+}
+
+// revalidateApex reports whether a cached entry is still current: for an apex the
+// zone's SOA serial must be unchanged, other names are simply looked up again.
+func (r *PostgresRepository) revalidateApex(ctx context.Context, entry *apexEntry) (bool, error) {
+	if !entry.apex {
+		return false, nil
+	}
+	rows, err := r.queryContext(ctx, `SELECT content FROM dns_records WHERE zone_id = $1 AND type = 'SOA'`, entry.zoneID)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	fresh := false
+	for rows.Next() {
+		var content string
+		if err := rows.Scan(&content); err != nil {
+			return false, err
+		}
+		if current, ok := serial.FromSOA(content); ok && current == entry.serial {
+			fresh = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+	if fresh {
+		r.apex.mu.Lock()
+		entry.checked = r.apex.now()
+		r.apex.mu.Unlock()
+	}
+	return fresh, nil
+}
+
+// invalidateZone drops the apex entries of the given zones along with every cached
+// non-apex name, one of which may just have become an apex.
+func (c *apexCache) invalidateZone(zoneIDs ...string) {
+	zones := make(map[string]bool, len(zoneIDs))
+	for _, id := range zoneIDs {
+		zones[id] = true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for name, e := range c.entries {
+		if !e.apex || zones[e.zoneID] {
+			delete(c.entries, name)
+		}
+	}
+}
+
+// invalidateApex drops the cached zones touched by a write, if caching is enabled.
+func (r *PostgresRepository) invalidateApex(zoneIDs ...string) {
+	if r.apex != nil {
+		r.apex.invalidateZone(zoneIDs...)
+	}
+}
+
+// filterApex returns the cached records of qType visible to clientIP, mirroring the
+// split-horizon rule of GetRecords: global records plus those whose network
+// contains the client address or subnet.
+func filterApex(records []domain.Record, qType domain.RecordType, clientIP string) []domain.Record {
+	ip, ones := net.ParseIP(clientIP), -1
+	if ip == nil {
+		if subnetIP, subnet, err := net.ParseCIDR(clientIP); err == nil {
+			ip = subnetIP
+			ones, _ = subnet.Mask.Size()
+		}
+	}
+	var res []domain.Record
+	for _, rec := range records {
+		if rec.Type != qType {
+			continue
+		}
+		if rec.Network != nil && *rec.Network != "" {
+			_, cidr, err := net.ParseCIDR(*rec.Network)
+			if err != nil || ip == nil || !cidr.Contains(ip) {
+				continue
+			}
+			if size, _ := cidr.Mask.Size(); ones >= 0 && size > ones {
+				continue // The network holds only part of the client subnet
+			}
+		}
+		res = append(res, rec)
+	}
+	return res
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+var apexColumns = []string{"id", "zone_id", "name", "type", "content", "ttl", "priority", "weight", "port", "network", "view_id", "geo", "target_id", "health_check_type", "health_check_target", "health_check_id", "status"}
+
+func apexRows() *sqlmock.Rows {
+	return sqlmock.NewRows(apexColumns).
+		AddRow("s1", "z1", "example.com.", "SOA", "ns1.example.com. admin.example.com. 7 3600 600 86400 300", 300, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, "UNKNOWN").
+		AddRow("n1", "z1", "example.com.", "NS", "ns1.example.com.", 300, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, "UNKNOWN").
+		AddRow("n2", "z1", "example.com.", "NS", "ns-internal.example.com.", 300, nil, nil, nil, "10.0.0.0/8", nil, nil, nil, nil, nil, nil, "UNKNOWN")
+}
+
+func TestApexCache(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	repo := NewPostgresRepository(db)
+	repo.EnableApexCache(time.Minute)
+	now := time.Now()
+	repo.apex.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// The first lookup loads SOA and NS for every network in one query
+	mock.ExpectQuery(`SELECT .* FROM dns_records r .* WHERE LOWER\(r\.name\) = LOWER\(\$1\) AND r\.type IN \('SOA', 'NS'\)`).
+		WithArgs("example.com.").
+		WillReturnRows(apexRows())
+
+	soa, err := repo.GetRecords(ctx, "Example.com.", domain.TypeSOA, "192.0.2.1")
+	if err != nil || len(soa) != 1 || soa[0].ID != "s1" {
+		t.Fatalf("Expected cached SOA, got %+v (%v)", soa, err)
+	}
+
+	// Later lookups are served from memory, split-horizon included
+	if ns, _ := repo.GetRecords(ctx, "example.com.", domain.TypeNS, "192.0.2.1"); len(ns) != 1 {
+		t.Errorf("Expected only the global NS for an outside client, got %+v", ns)
+	}
+	if ns, _ := repo.GetRecords(ctx, "example.com.", domain.TypeNS, "10.1.2.3"); len(ns) != 2 {
+		t.Errorf("Expected the internal NS as well, got %+v", ns)
+	}
+
+	// Past the revalidation interval an unchanged serial keeps the entry
+	now = now.Add(2 * time.Minute)
+	mock.ExpectQuery(`SELECT content FROM dns_records WHERE zone_id = \$1 AND type = 'SOA'`).
+		WithArgs("z1").
+		WillReturnRows(sqlmock.NewRows([]string{"content"}).AddRow("ns1.example.com. admin.example.com. 7 3600 600 86400 300"))
+	if ns, err := repo.GetRecords(ctx, "example.com.", domain.TypeNS, "192.0.2.1"); err != nil || len(ns) != 1 {
+		t.Errorf("Expected entry kept for an unchanged serial, got %+v (%v)", ns, err)
+	}
+
+	// A new serial, e.g. from an update on another node, reloads the zone
+	now = now.Add(2 * time.Minute)
+	mock.ExpectQuery(`SELECT content FROM dns_records WHERE zone_id = \$1 AND type = 'SOA'`).
+		WithArgs("z1").
+		WillReturnRows(sqlmock.NewRows([]string{"content"}).AddRow("ns1.example.com. admin.example.com. 8 3600 600 86400 300"))
+	mock.ExpectQuery(`SELECT .* FROM dns_records r .* r\.type IN \('SOA', 'NS'\)`).
+		WithArgs("example.com.").
+		WillReturnRows(sqlmock.NewRows(apexColumns).
+			AddRow("s2", "z1", "example.com.", "SOA", "ns1.example.com. admin.example.com. 8 3600 600 86400 300", 300, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, "UNKNOWN"))
+	if soa, err := repo.GetRecords(ctx, "example.com.", domain.TypeSOA, "192.0.2.1"); err != nil || len(soa) != 1 || soa[0].ID != "s2" {
+		t.Errorf("Expected reloaded SOA, got %+v (%v)", soa, err)
+	}
+
+	// Local writes drop the zone immediately
+	mock.ExpectExec(`DELETE FROM dns_records WHERE zone_id = \$1 AND LOWER\(name\) = LOWER\(\$2\) AND type = \$3`).
+		WithArgs("z1", "example.com.", "NS").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	if err := repo.DeleteRecordsByNameAndType(ctx, "z1", "example.com.", domain.TypeNS); err != nil {
+		t.Fatalf("DeleteRecordsByNameAndType failed: %v", err)
+	}
+	mock.ExpectQuery(`SELECT .* FROM dns_records r .* r\.type IN \('SOA', 'NS'\)`).
+		WithArgs("example.com.").
+		WillReturnRows(apexRows())
+	if _, err := repo.GetRecords(ctx, "example.com.", domain.TypeSOA, "192.0.2.1"); err != nil {
+		t.Errorf("Expected reload after a local write, got %v", err)
+	}
+
+	// Names without a SOA are not an apex and use the regular query
+	mock.ExpectQuery(`SELECT .* FROM dns_records r .* r\.type IN \('SOA', 'NS'\)`).
+		WithArgs("sub.example.com.").
+		WillReturnRows(sqlmock.NewRows(apexColumns).
+			AddRow("d1", "z1", "sub.example.com.", "NS", "ns.sub.example.com.", 300, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, "UNKNOWN"))
+	mock.ExpectQuery(`SELECT .* FROM dns_records r .* \$2::inet <<= r\.network\) AND r\.type = \$3`).
+		WithArgs("sub.example.com.", "192.0.2.1", "NS").
+		WillReturnRows(sqlmock.NewRows(apexColumns).
+			AddRow("d1", "z1", "sub.example.com.", "NS", "ns.sub.example.com.", 300, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, "UNKNOWN"))
+	if ns, err := repo.GetRecords(ctx, "sub.example.com.", domain.TypeNS, "192.0.2.1"); err != nil || len(ns) != 1 {
+		t.Errorf("Expected delegation NS from the database, got %+v (%v)", ns, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestFilterApexClientSubnet(t *testing.T) {
+	wide, narrow := "10.0.0.0/8", "10.1.2.0/28"
+	records := []domain.Record{
+		{ID: "global", Type: domain.TypeNS},
+		{ID: "wide", Type: domain.TypeNS, Network: &wide},
+		{ID: "narrow", Type: domain.TypeNS, Network: &narrow},
+	}
+	ids := func(recs []domain.Record) []string {
+		var out []string
+		for _, r := range recs {
+			out = append(out, r.ID)
+		}
+		return out
+	}
+
+	for _, tc := range []struct {
+		client string
+		want   []string
+	}{
+		{"10.1.2.3", []string{"global", "wide", "narrow"}},
+		{"10.1.2.0/24", []string{"global", "wide"}},
+		{"10.1.2.0/28", []string{"global", "wide", "narrow"}},
+		{"192.0.2.0/24", []string{"global"}},
+	} {
+		if got := ids(filterApex(records, domain.TypeNS, tc.client)); !slices.Equal(got, tc.want) {
+			t.Errorf("filterApex(%s) = %v, want %v", tc.client, got, tc.want)
+		}
+	}
+}
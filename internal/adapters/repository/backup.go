@@ -0,0 +1,246 @@
+package repository
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/envelope"
+)
+
+// SnapshotVersion is the format of snapshots written by Backup.
+const SnapshotVersion = 1
+
+// backupTables are the tables a snapshot holds, parents before children so they
+// can be restored in order. Operational state (nodes, rollouts, usage, health,
+// audit logs and IXFR history) is left out and rebuilt by the new deployment.
+var backupTables = []string{
+	"dns_zones", "dns_views", "dns_targets", "health_checks", "dns_records", "dnssec_keys", "zone_secondaries", "api_keys", "scheduled_changes", "change_freezes",
+	"zone_review_policies", "change_requests", "query_limits", "dns_default_records", "ipam_subnets", "ipam_allocations",
+	"dns_zone_slos", "dns_shadow_changes",
+}
+
+// backupOrder sorts the rows of the tables that have no id column, so that
+// snapshots of the same data are identical.
+var backupOrder = map[string]string{
+	"zone_review_policies": "t.zone_id",
+	"query_limits":         "t.tenant_id, t.zone_id",
+	"dns_zone_slos":        "t.zone_id",
+	"dns_shadow_changes":   "t.zone_id",
+}
+
+// backupSecrets are the secret columns of backupTables, sealed with the backup key
+// in snapshots whether or not they are encrypted at rest.
+var backupSecrets = map[string]string{
+	"dnssec_keys":      "private_key",
+	"zone_secondaries": "tsig_secret",
+}
+
+// ErrRestoreNotEmpty is returned when restoring into a database that already
+// holds zones or API keys.
+var ErrRestoreNotEmpty = errors.New("restore target is not empty")
+
+// Snapshot is a portable copy of a deployment's configuration: each table as a
+// JSON array of rows as Postgres renders them, with secrets sealed by the backup
+// key named SecretKey.
+type Snapshot struct {
+	Version   int                        `json:"version"`
+	CreatedAt time.Time                  `json:"created_at"`
+	SecretKey string                     `json:"secret_key"`
+	Tables    map[string]json.RawMessage `json:"tables"`
+}
+
+// Rows returns the number of rows of each table in the snapshot.
+func (s *Snapshot) Rows() (map[string]int, error) {
+	counts := make(map[string]int, len(s.Tables))
+	for table, raw := range s.Tables {
+		var rows []json.RawMessage
+		if err := json.Unmarshal(raw, &rows); err != nil {
+			return nil, fmt.Errorf("table %s: %w", table, err)
+		}
+		counts[table] = len(rows)
+	}
+	return counts, nil
+}
+
+// Backup copies every table of the snapshot in one read-only repeatable-read
+// transaction, so the copy is consistent even while the API and dynamic updates
+// keep writing. Secrets are decrypted with the at-rest keyring, if any, and
+// sealed with keys, which is required so that snapshots never hold plaintext.
+func (r *PostgresRepository) Backup(ctx context.Context, keys *envelope.Keyring) (*Snapshot, error) {
+	if keys == nil {
+		return nil, errors.New("a backup key is required to seal secrets")
+	}
+	tx, err := r.conn().BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	r.observe(err)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if errRollback := tx.Rollback(); errRollback != nil && !errors.Is(errRollback, sql.ErrTxDone) {
+			log.Printf("failed to end backup transaction: %v", errRollback)
+		}
+	}()
+
+	snap := &Snapshot{
+		Version:   SnapshotVersion,
+		CreatedAt: time.Now().UTC(),
+		SecretKey: keys.CurrentID(),
+		Tables:    make(map[string]json.RawMessage, len(backupTables)),
+	}
+	for _, table := range backupTables {
+		var raw []byte
+		order, ok := backupOrder[table]
+		if !ok {
+			order = "t.id"
+		}
+		// table is one of backupTables and order one of backupOrder
+		// #nosec G201
+		query := fmt.Sprintf("SELECT COALESCE(json_agg(t ORDER BY %s), '[]') FROM %s t", order, table)
+		if err := tx.QueryRowContext(ctx, query).Scan(&raw); err != nil {
+			return nil, fmt.Errorf("failed to back up %s: %w", table, err)
+		}
+		if col, ok := backupSecrets[table]; ok {
+			if raw, err = transformSecrets(raw, table, col, func(id string, value []byte) ([]byte, error) {
+				plain, err := r.openSecret(table+"."+col, id, value)
+				if err != nil || len(plain) == 0 {
+					return plain, err
+				}
+				return keys.Seal(plain, backupAAD(table, col, id))
+			}); err != nil {
+				return nil, err
+			}
+		}
+		snap.Tables[table] = raw
+	}
+	return snap, tx.Commit()
+}
+
+// Restore loads a snapshot into an empty database in one transaction, so a
+// failed restore leaves nothing behind. Secrets are opened with keys, the backup
+// key the snapshot was sealed with, and sealed again with the at-rest keyring.
+func (r *PostgresRepository) Restore(ctx context.Context, snap *Snapshot, keys *envelope.Keyring) error {
+	if snap.Version != SnapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", snap.Version)
+	}
+	if keys == nil {
+		return errors.New("the backup key is required to open secrets")
+	}
+	tx, err := r.conn().BeginTx(ctx, nil)
+	r.observe(err)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if errRollback := tx.Rollback(); errRollback != nil && !errors.Is(errRollback, sql.ErrTxDone) {
+			log.Printf("failed to roll back restore: %v", errRollback)
+		}
+	}()
+
+	var populated bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM dns_zones) OR EXISTS (SELECT 1 FROM api_keys)`).Scan(&populated); err != nil {
+		return err
+	}
+	if populated {
+		return ErrRestoreNotEmpty
+	}
+
+	for _, table := range backupTables {
+		raw, ok := snap.Tables[table]
+		if !ok {
+			continue
+		}
+		if col, ok := backupSecrets[table]; ok {
+			if raw, err = transformSecrets(raw, table, col, func(id string, value []byte) ([]byte, error) {
+				if len(value) == 0 {
+					return value, nil
+				}
+				plain, err := keys.Open(value, backupAAD(table, col, id))
+				if err != nil {
+					return nil, err
+				}
+				return r.sealSecret(table+"."+col, id, plain)
+			}); err != nil {
+				return err
+			}
+		}
+		// table is one of backupTables
+		// #nosec G201
+		query := fmt.Sprintf("INSERT INTO %[1]s SELECT * FROM json_populate_recordset(NULL::%[1]s, $1::json)", table)
+		if _, err := tx.ExecContext(ctx, query, string(raw)); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", table, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// transformSecrets rewrites column col of every row of a table rendered by
+// json_agg with fn, which is given the row ID and the column value.
+func transformSecrets(raw []byte, table, col string, fn func(id string, value []byte) ([]byte, error)) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var rows []map[string]any
+	if err := dec.Decode(&rows); err != nil {
+		return nil, fmt.Errorf("table %s: %w", table, err)
+	}
+	binary := col == "private_key"
+	for _, row := range rows {
+		id, _ := row["id"].(string)
+		text, _ := row[col].(string)
+		value := []byte(text)
+		if binary {
+			// bytea is rendered in hex format, \x0a0b...
+			decoded, err := hex.DecodeString(strings.TrimPrefix(text, `\x`))
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s of %s: invalid bytea: %w", table, col, id, err)
+			}
+			value = decoded
+		}
+		out, err := fn(id, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s of %s: %w", table, col, id, err)
+		}
+		if binary {
+			row[col] = `\x` + hex.EncodeToString(out)
+		} else {
+			row[col] = string(out)
+		}
+	}
+	return json.Marshal(rows)
+}
+
+func backupAAD(table, col, id string) []byte {
+	return []byte("backup:" + table + "." + col + ":" + id)
+}
+
+// WriteSnapshot writes snap to w as gzip-compressed JSON.
+func WriteSnapshot(w io.Writer, snap *Snapshot) error {
+	zw := gzip.NewWriter(w)
+	if err := json.NewEncoder(zw).Encode(snap); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// ReadSnapshot reads a snapshot written by WriteSnapshot.
+func ReadSnapshot(rd io.Reader) (*Snapshot, error) {
+	zr, err := gzip.NewReader(rd)
+	if err != nil {
+		return nil, fmt.Errorf("not a snapshot archive: %w", err)
+	}
+	defer func() { _ = zr.Close() }()
+	var snap Snapshot
+	if err := json.NewDecoder(zr).Decode(&snap); err != nil {
+		return nil, fmt.Errorf("malformed snapshot: %w", err)
+	}
+	return &snap, nil
+}
@@ -0,0 +1,281 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func expectBackupQueries(mock sqlmock.Sqlmock, tables map[string]string) {
+	mock.ExpectBegin()
+	for _, table := range backupTables {
+		rows, ok := tables[table]
+		if !ok {
+			rows = `[]`
+		}
+		mock.ExpectQuery(regexp.QuoteMeta(fmt.Sprintf("FROM %s t", table))).
+			WillReturnRows(sqlmock.NewRows([]string{"json_agg"}).AddRow([]byte(rows)))
+	}
+	mock.ExpectCommit()
+}
+
+func TestBackupRestore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	ctx := context.Background()
+
+	// The source seals its secrets at rest with k1
+	source := NewPostgresRepository(db)
+	source.SetEncryption(testKeyring(t, kekSpec("k1", 1), ""))
+	sealedKey, _ := source.sealSecret(columnPrivateKey, "key1", []byte("private"))
+	backupKeys := testKeyring(t, kekSpec("backup", 9), "")
+
+	expectBackupQueries(mock, map[string]string{
+		"dns_zones":   `[{"id":"z1","tenant_id":"t1","name":"example.com."}]`,
+		"dns_records": `[{"id":"r1","zone_id":"z1","name":"www.example.com.","type":"A","content":"192.0.2.1","ttl":300}]`,
+		"dnssec_keys": `[{"id":"key1","zone_id":"z1","private_key":"\\x` + hex.EncodeToString(sealedKey) + `"},` +
+			`{"id":"key2","zone_id":"z1","private_key":"\\x"}]`,
+		"zone_secondaries":  `[{"id":"s1","zone_id":"z1","tsig_secret":"c2VjcmV0","observed_serial":4294967295}]`,
+		"api_keys":          `[]`,
+		"scheduled_changes": `[]`,
+		"change_freezes":    `[]`,
+	})
+	snap, err := source.Backup(ctx, backupKeys)
+	if err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unfulfilled expectations: %v", err)
+	}
+	for _, secret := range []string{"c2VjcmV0", hex.EncodeToString([]byte("private")), hex.EncodeToString(sealedKey)} {
+		if bytes.Contains(snap.Tables["dnssec_keys"], []byte(secret)) || bytes.Contains(snap.Tables["zone_secondaries"], []byte(secret)) {
+			t.Errorf("Expected the snapshot to hold secrets sealed with the backup key only, found %s", secret)
+		}
+	}
+	if !bytes.Contains(snap.Tables["zone_secondaries"], []byte("4294967295")) {
+		t.Error("Expected large numbers to survive unchanged")
+	}
+
+	// The archive round-trips
+	var archive bytes.Buffer
+	if err := WriteSnapshot(&archive, snap); err != nil {
+		t.Fatalf("WriteSnapshot failed: %v", err)
+	}
+	snap, err = ReadSnapshot(&archive)
+	if err != nil {
+		t.Fatalf("ReadSnapshot failed: %v", err)
+	}
+	if counts, _ := snap.Rows(); counts["dnssec_keys"] != 2 || counts["api_keys"] != 0 || snap.SecretKey != "backup" {
+		t.Errorf("Unexpected snapshot %v, key %s", counts, snap.SecretKey)
+	}
+
+	// The target seals its secrets at rest with its own key
+	target := NewPostgresRepository(db)
+	targetKeys := testKeyring(t, kekSpec("k7", 7), "")
+	target.SetEncryption(targetKeys)
+	inserted := map[string]*capture{}
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT EXISTS`).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	for _, table := range backupTables {
+		inserted[table] = &capture{}
+		mock.ExpectExec(regexp.QuoteMeta(fmt.Sprintf("INSERT INTO %[1]s SELECT * FROM json_populate_recordset(NULL::%[1]s", table))).
+			WithArgs(inserted[table]).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+	if err := target.Restore(ctx, snap, backupKeys); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unfulfilled expectations: %v", err)
+	}
+
+	var keys []struct {
+		ID         string `json:"id"`
+		PrivateKey string `json:"private_key"`
+	}
+	if err := json.Unmarshal([]byte(inserted["dnssec_keys"].value.(string)), &keys); err != nil || len(keys) != 2 {
+		t.Fatalf("Unexpected restored keys %v: %v", inserted["dnssec_keys"].value, err)
+	}
+	stored, _ := hex.DecodeString(strings.TrimPrefix(keys[0].PrivateKey, `\x`))
+	if plain, err := targetKeys.Open(stored, []byte(columnPrivateKey+":key1")); err != nil || string(plain) != "private" {
+		t.Errorf("Expected the private key sealed with the target key, got %q, %v", plain, err)
+	}
+	if keys[1].PrivateKey != `\x` {
+		t.Errorf("Expected an empty private key to stay empty, got %q", keys[1].PrivateKey)
+	}
+	var secondaries []struct {
+		TSIGSecret string `json:"tsig_secret"`
+	}
+	_ = json.Unmarshal([]byte(inserted["zone_secondaries"].value.(string)), &secondaries)
+	if len(secondaries) != 1 {
+		t.Fatalf("Unexpected restored secondaries %v", inserted["zone_secondaries"].value)
+	}
+	if plain, err := targetKeys.Open([]byte(secondaries[0].TSIGSecret), []byte(columnTSIGSecret+":s1")); err != nil || string(plain) != "c2VjcmV0" {
+		t.Errorf("Expected the TSIG secret sealed with the target key, got %q, %v", plain, err)
+	}
+
+	// The snapshot cannot be opened without the backup key
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT EXISTS`).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	for _, table := range backupTables {
+		if table == "dnssec_keys" {
+			break
+		}
+		mock.ExpectExec(`INSERT INTO ` + table + ` `).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectRollback()
+	if err := target.Restore(ctx, snap, testKeyring(t, kekSpec("other", 3), "")); err == nil {
+		t.Error("Expected a restore with the wrong backup key to fail")
+	}
+}
+
+func TestBackupRestoreRecordInView(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	ctx := context.Background()
+	repo := NewPostgresRepository(db)
+	keys := testKeyring(t, kekSpec("backup", 9), "")
+
+	views := `[{"id":"v1","tenant_id":"t1","name":"internal","networks":["10.0.0.0/8"],"priority":0}]`
+	records := `[{"id":"r1","zone_id":"z1","name":"www.example.com.","type":"A","content":"10.0.0.1","ttl":300,"view_id":"v1"}]`
+	expectBackupQueries(mock, map[string]string{
+		"dns_zones":   `[{"id":"z1","tenant_id":"t1","name":"example.com."}]`,
+		"dns_views":   views,
+		"dns_records": records,
+	})
+	snap, err := repo.Backup(ctx, keys)
+	if err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+
+	// The view is restored before the record that references it
+	inserted := map[string]*capture{}
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT EXISTS`).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	for _, table := range backupTables {
+		inserted[table] = &capture{}
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO " + table + " ")).WithArgs(inserted[table]).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+	if err := repo.Restore(ctx, snap, keys); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unfulfilled expectations: %v", err)
+	}
+	if inserted["dns_views"].value != views || inserted["dns_records"].value != records {
+		t.Errorf("Expected the view and its record to round-trip, got %v and %v", inserted["dns_views"].value, inserted["dns_records"].value)
+	}
+	// Every table dns_records references is restored first
+	for _, parent := range []string{"dns_zones", "dns_views", "dns_targets", "health_checks"} {
+		if indexOf(backupTables, parent) > indexOf(backupTables, "dns_records") {
+			t.Errorf("Expected %s to be restored before dns_records", parent)
+		}
+	}
+}
+
+func TestBackupReviewedZone(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	repo := NewPostgresRepository(db)
+
+	// Tables without an id column are ordered by their key
+	policies := `[{"zone_id":"z1","tenant_id":"t1"}]`
+	mock.ExpectBegin()
+	for _, table := range backupTables {
+		rows := `[]`
+		if table == "zone_review_policies" {
+			rows = policies
+		}
+		order := "t.id"
+		if o, ok := backupOrder[table]; ok {
+			order = o
+		}
+		mock.ExpectQuery(regexp.QuoteMeta(fmt.Sprintf("ORDER BY %s), '[]') FROM %s t", order, table))).
+			WillReturnRows(sqlmock.NewRows([]string{"json_agg"}).AddRow([]byte(rows)))
+	}
+	mock.ExpectCommit()
+	snap, err := repo.Backup(context.Background(), testKeyring(t, kekSpec("backup", 9), ""))
+	if err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	if string(snap.Tables["zone_review_policies"]) != policies {
+		t.Errorf("Expected the review policy in the snapshot, got %s", snap.Tables["zone_review_policies"])
+	}
+
+	// Each table is restored after the tables it references
+	parents := map[string][]string{
+		"zone_review_policies": {"dns_zones"},
+		"change_requests":      {"dns_zones"},
+		"query_limits":         {"dns_zones"},
+		"dns_default_records":  {"dns_zones"},
+		"ipam_subnets":         {"dns_zones"},
+		"ipam_allocations":     {"ipam_subnets"},
+		"dns_zone_slos":        {"dns_zones"},
+		"dns_shadow_changes":   {"dns_zones"},
+	}
+	for child, refs := range parents {
+		if indexOf(backupTables, child) == len(backupTables) {
+			t.Errorf("Expected %s to be backed up", child)
+		}
+		for _, parent := range refs {
+			if indexOf(backupTables, parent) > indexOf(backupTables, child) {
+				t.Errorf("Expected %s to be restored before %s", parent, child)
+			}
+		}
+	}
+}
+
+func indexOf(tables []string, table string) int {
+	for i, t := range tables {
+		if t == table {
+			return i
+		}
+	}
+	return len(tables)
+}
+
+func TestRestoreRefusesPopulatedDatabase(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	repo := NewPostgresRepository(db)
+	keys := testKeyring(t, kekSpec("backup", 9), "")
+
+	if err := repo.Restore(context.Background(), &Snapshot{Version: 99}, keys); err == nil || !strings.Contains(err.Error(), "version") {
+		t.Errorf("Expected an unsupported version to be rejected, got %v", err)
+	}
+	if _, err := repo.Backup(context.Background(), nil); err == nil {
+		t.Error("Expected Backup to require a backup key")
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT EXISTS`).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+	err = repo.Restore(context.Background(), &Snapshot{Version: SnapshotVersion, Tables: map[string]json.RawMessage{}}, keys)
+	if !errors.Is(err, ErrRestoreNotEmpty) {
+		t.Errorf("Expected ErrRestoreNotEmpty, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,756 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/core/ports"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+)
+
+// ErrCircuitOpen is returned instead of calling the database while the circuit
+// breaker is open.
+var ErrCircuitOpen = errors.New("database circuit breaker is open")
+
+// CircuitState is the state of a CircuitBreaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets every call through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects every call until the cooldown elapses.
+	CircuitOpen
+	// CircuitHalfOpen lets a single trial call through to probe recovery.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerConfig tunes when the breaker trips and how long it stays open.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the circuit.
+	FailureThreshold int
+	// Cooldown is how long the circuit stays open before a trial call is allowed.
+	Cooldown time.Duration
+	// CallTimeout bounds each call so a hung database counts as a failure. Zero disables it.
+	CallTimeout time.Duration
+	// OnStateChange, if set, is invoked after every state transition. It runs with the
+	// breaker locked, so it must not block or call back into the breaker.
+	OnStateChange func(from, to CircuitState)
+}
+
+// CircuitBreaker counts consecutive failures and short-circuits calls once the
+// configured threshold is reached.
+type CircuitBreaker struct {
+	mu       sync.Mutex
+	cfg      CircuitBreakerConfig
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	trial    bool
+	logger   *slog.Logger
+	now      func() time.Time
+}
+
+// NewCircuitBreaker creates a closed breaker, filling in defaults for unset fields.
+func NewCircuitBreaker(cfg CircuitBreakerConfig, logger *slog.Logger) *CircuitBreaker {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 5
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = 30 * time.Second
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	metrics.DBCircuitState.Set(float64(CircuitClosed))
+	return &CircuitBreaker{cfg: cfg, logger: logger, now: time.Now}
+}
+
+// State returns the current breaker state.
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Allow reports whether a call may proceed. It returns ErrCircuitOpen while the
+// circuit is open, or while a half-open trial call is already in flight.
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if b.now().Sub(b.openedAt) < b.cfg.Cooldown {
+			return ErrCircuitOpen
+		}
+		b.transition(CircuitHalfOpen)
+		b.trial = true
+		return nil
+	case CircuitHalfOpen:
+		if b.trial {
+			return ErrCircuitOpen
+		}
+		b.trial = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Record feeds the outcome of an allowed call back into the breaker.
+func (b *CircuitBreaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !isBreakerFailure(err) {
+		b.failures = 0
+		b.trial = false
+		if b.state != CircuitClosed {
+			b.transition(CircuitClosed)
+		}
+		return
+	}
+
+	b.failures++
+	b.trial = false
+	if b.state == CircuitHalfOpen || (b.state == CircuitClosed && b.failures >= b.cfg.FailureThreshold) {
+		b.openedAt = b.now()
+		b.transition(CircuitOpen)
+	}
+}
+
+// transition must be called with b.mu held.
+func (b *CircuitBreaker) transition(to CircuitState) {
+	from := b.state
+	b.state = to
+	metrics.DBCircuitState.Set(float64(to))
+	metrics.DBCircuitTransitions.WithLabelValues(to.String()).Inc()
+	b.logger.Warn("database circuit breaker state changed", "from", from.String(), "to", to.String(), "failures", b.failures)
+	if b.cfg.OnStateChange != nil {
+		b.cfg.OnStateChange(from, to)
+	}
+}
+
+// isBreakerFailure reports whether err should count towards tripping the breaker.
+// Missing rows and caller cancellations say nothing about database health.
+func isBreakerFailure(err error) bool {
+	return err != nil && !errors.Is(err, sql.ErrNoRows) && !errors.Is(err, context.Canceled)
+}
+
+// CircuitBreakerRepository decorates a DNSRepository with a CircuitBreaker so that a
+// database outage fails fast instead of piling up blocked workers.
+type CircuitBreakerRepository struct {
+	ports.DNSRepository
+	breaker *CircuitBreaker
+}
+
+// NewCircuitBreakerRepository wraps repo with a circuit breaker built from cfg.
+func NewCircuitBreakerRepository(repo ports.DNSRepository, cfg CircuitBreakerConfig, logger *slog.Logger) *CircuitBreakerRepository {
+	return &CircuitBreakerRepository{
+		DNSRepository: repo,
+		breaker:       NewCircuitBreaker(cfg, logger),
+	}
+}
+
+// Breaker exposes the underlying breaker, e.g. for health reporting.
+func (r *CircuitBreakerRepository) Breaker() *CircuitBreaker {
+	return r.breaker
+}
+
+func (r *CircuitBreakerRepository) do(ctx context.Context, fn func(context.Context) error) error {
+	_, err := guard(r, ctx, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	})
+	return err
+}
+
+func guard[T any](r *CircuitBreakerRepository, ctx context.Context, fn func(context.Context) (T, error)) (T, error) {
+	var zero T
+	if err := r.breaker.Allow(); err != nil {
+		return zero, err
+	}
+	if r.breaker.cfg.CallTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.breaker.cfg.CallTimeout)
+		defer cancel()
+	}
+	res, err := fn(ctx)
+	r.breaker.Record(err)
+	return res, err
+}
+
+func (r *CircuitBreakerRepository) GetRecords(ctx context.Context, name string, qType domain.RecordType, clientIP string) ([]domain.Record, error) {
+	return guard(r, ctx, func(ctx context.Context) ([]domain.Record, error) {
+		return r.DNSRepository.GetRecords(ctx, name, qType, clientIP)
+	})
+}
+
+func (r *CircuitBreakerRepository) GetIPsForName(ctx context.Context, name string, clientIP string) ([]string, error) {
+	return guard(r, ctx, func(ctx context.Context) ([]string, error) {
+		return r.DNSRepository.GetIPsForName(ctx, name, clientIP)
+	})
+}
+
+func (r *CircuitBreakerRepository) GetZone(ctx context.Context, name string) (*domain.Zone, error) {
+	return guard(r, ctx, func(ctx context.Context) (*domain.Zone, error) {
+		return r.DNSRepository.GetZone(ctx, name)
+	})
+}
+
+func (r *CircuitBreakerRepository) GetZoneByID(ctx context.Context, zoneID string, tenantID string) (*domain.Zone, error) {
+	return guard(r, ctx, func(ctx context.Context) (*domain.Zone, error) {
+		return r.DNSRepository.GetZoneByID(ctx, zoneID, tenantID)
+	})
+}
+
+func (r *CircuitBreakerRepository) GetRecord(ctx context.Context, id string, zoneID string, tenantID string) (*domain.Record, error) {
+	return guard(r, ctx, func(ctx context.Context) (*domain.Record, error) {
+		return r.DNSRepository.GetRecord(ctx, id, zoneID, tenantID)
+	})
+}
+
+func (r *CircuitBreakerRepository) ListRecordsForZone(ctx context.Context, zoneID string, tenantID string) ([]domain.Record, error) {
+	return guard(r, ctx, func(ctx context.Context) ([]domain.Record, error) {
+		return r.DNSRepository.ListRecordsForZone(ctx, zoneID, tenantID)
+	})
+}
+
+func (r *CircuitBreakerRepository) NameExists(ctx context.Context, zoneID string, name string) (bool, error) {
+	return guard(r, ctx, func(ctx context.Context) (bool, error) {
+		return r.DNSRepository.NameExists(ctx, zoneID, name)
+	})
+}
+
+func (r *CircuitBreakerRepository) CreateZone(ctx context.Context, zone *domain.Zone) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.CreateZone(ctx, zone) })
+}
+
+func (r *CircuitBreakerRepository) CreateZoneWithRecords(ctx context.Context, zone *domain.Zone, records []domain.Record) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.CreateZoneWithRecords(ctx, zone, records) })
+}
+
+func (r *CircuitBreakerRepository) CreateRecord(ctx context.Context, record *domain.Record) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.CreateRecord(ctx, record) })
+}
+
+func (r *CircuitBreakerRepository) BatchCreateRecords(ctx context.Context, records []domain.Record) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.BatchCreateRecords(ctx, records) })
+}
+
+func (r *CircuitBreakerRepository) ListZones(ctx context.Context, tenantID string) ([]domain.Zone, error) {
+	return guard(r, ctx, func(ctx context.Context) ([]domain.Zone, error) {
+		return r.DNSRepository.ListZones(ctx, tenantID)
+	})
+}
+
+func (r *CircuitBreakerRepository) HasZones(ctx context.Context) (bool, error) {
+	return guard(r, ctx, r.DNSRepository.HasZones)
+}
+
+func (r *CircuitBreakerRepository) DeleteZone(ctx context.Context, zoneID string, tenantID string) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.DeleteZone(ctx, zoneID, tenantID) })
+}
+
+func (r *CircuitBreakerRepository) SetZoneRole(ctx context.Context, zoneID string, tenantID string, role string, masterServer string) error {
+	return r.do(ctx, func(ctx context.Context) error {
+		return r.DNSRepository.SetZoneRole(ctx, zoneID, tenantID, role, masterServer)
+	})
+}
+
+func (r *CircuitBreakerRepository) SetZoneDNSSEC(ctx context.Context, zoneID string, tenantID string, enabled bool) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.SetZoneDNSSEC(ctx, zoneID, tenantID, enabled) })
+}
+
+func (r *CircuitBreakerRepository) DeleteRecord(ctx context.Context, recordID string, zoneID string, tenantID string) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.DeleteRecord(ctx, recordID, zoneID, tenantID) })
+}
+
+func (r *CircuitBreakerRepository) SetRecordProtected(ctx context.Context, recordID string, zoneID string, tenantID string, protected bool) (bool, error) {
+	return guard(r, ctx, func(ctx context.Context) (bool, error) {
+		return r.DNSRepository.SetRecordProtected(ctx, recordID, zoneID, tenantID, protected)
+	})
+}
+
+func (r *CircuitBreakerRepository) DeleteRecordsByNameAndType(ctx context.Context, zoneID string, name string, qType domain.RecordType) error {
+	return r.do(ctx, func(ctx context.Context) error {
+		return r.DNSRepository.DeleteRecordsByNameAndType(ctx, zoneID, name, qType)
+	})
+}
+
+func (r *CircuitBreakerRepository) DeleteRecordsByName(ctx context.Context, zoneID string, name string) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.DeleteRecordsByName(ctx, zoneID, name) })
+}
+
+func (r *CircuitBreakerRepository) DeleteRecordsForZone(ctx context.Context, zoneID string) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.DeleteRecordsForZone(ctx, zoneID) })
+}
+
+func (r *CircuitBreakerRepository) ReplaceZoneRecords(ctx context.Context, zoneID string, records []domain.Record) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.ReplaceZoneRecords(ctx, zoneID, records) })
+}
+
+func (r *CircuitBreakerRepository) ApplyZoneDiff(ctx context.Context, zoneID string, diffs []domain.IXFRChunk) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.ApplyZoneDiff(ctx, zoneID, diffs) })
+}
+
+func (r *CircuitBreakerRepository) DeleteRecordSpecific(ctx context.Context, zoneID string, name string, qType domain.RecordType, content string) error {
+	return r.do(ctx, func(ctx context.Context) error {
+		return r.DNSRepository.DeleteRecordSpecific(ctx, zoneID, name, qType, content)
+	})
+}
+
+func (r *CircuitBreakerRepository) ReplaceRRset(ctx context.Context, zoneID string, name string, qType domain.RecordType, records []domain.Record) error {
+	return r.do(ctx, func(ctx context.Context) error {
+		return r.DNSRepository.ReplaceRRset(ctx, zoneID, name, qType, records)
+	})
+}
+
+func (r *CircuitBreakerRepository) RecordZoneChange(ctx context.Context, change *domain.ZoneChange) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.RecordZoneChange(ctx, change) })
+}
+
+func (r *CircuitBreakerRepository) ListZoneChanges(ctx context.Context, zoneID string, fromSerial uint32) ([]domain.ZoneChange, error) {
+	return guard(r, ctx, func(ctx context.Context) ([]domain.ZoneChange, error) {
+		return r.DNSRepository.ListZoneChanges(ctx, zoneID, fromSerial)
+	})
+}
+
+func (r *CircuitBreakerRepository) LastZoneChangeAt(ctx context.Context, zoneID string) (*time.Time, error) {
+	return guard(r, ctx, func(ctx context.Context) (*time.Time, error) {
+		return r.DNSRepository.LastZoneChangeAt(ctx, zoneID)
+	})
+}
+
+func (r *CircuitBreakerRepository) GetIXFRChain(ctx context.Context, zoneID string, fromSerial uint32, toSerial uint32) ([]domain.IXFRChunk, error) {
+	return guard(r, ctx, func(ctx context.Context) ([]domain.IXFRChunk, error) {
+		return r.DNSRepository.GetIXFRChain(ctx, zoneID, fromSerial, toSerial)
+	})
+}
+
+func (r *CircuitBreakerRepository) SaveAuditLog(ctx context.Context, log *domain.AuditLog) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.SaveAuditLog(ctx, log) })
+}
+
+func (r *CircuitBreakerRepository) GetAuditLogs(ctx context.Context, tenantID string) ([]domain.AuditLog, error) {
+	return guard(r, ctx, func(ctx context.Context) ([]domain.AuditLog, error) {
+		return r.DNSRepository.GetAuditLogs(ctx, tenantID)
+	})
+}
+
+// Ping bypasses the open circuit so health checks keep reporting the real
+// database status, but still feeds its result into the breaker.
+func (r *CircuitBreakerRepository) Ping(ctx context.Context) error {
+	err := r.DNSRepository.Ping(ctx)
+	if r.breaker.State() == CircuitClosed || err == nil {
+		r.breaker.Record(err)
+	}
+	return err
+}
+
+func (r *CircuitBreakerRepository) CreateKey(ctx context.Context, key *domain.DNSSECKey) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.CreateKey(ctx, key) })
+}
+
+func (r *CircuitBreakerRepository) ListKeysForZone(ctx context.Context, zoneID string) ([]domain.DNSSECKey, error) {
+	return guard(r, ctx, func(ctx context.Context) ([]domain.DNSSECKey, error) {
+		return r.DNSRepository.ListKeysForZone(ctx, zoneID)
+	})
+}
+
+func (r *CircuitBreakerRepository) UpdateKey(ctx context.Context, key *domain.DNSSECKey) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.UpdateKey(ctx, key) })
+}
+
+func (r *CircuitBreakerRepository) GetAPIKeyByHash(ctx context.Context, keyHash string) (*domain.APIKey, error) {
+	return guard(r, ctx, func(ctx context.Context) (*domain.APIKey, error) {
+		return r.DNSRepository.GetAPIKeyByHash(ctx, keyHash)
+	})
+}
+
+func (r *CircuitBreakerRepository) CreateAPIKey(ctx context.Context, key *domain.APIKey) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.CreateAPIKey(ctx, key) })
+}
+
+func (r *CircuitBreakerRepository) ListAPIKeys(ctx context.Context, tenantID string) ([]domain.APIKey, error) {
+	return guard(r, ctx, func(ctx context.Context) ([]domain.APIKey, error) {
+		return r.DNSRepository.ListAPIKeys(ctx, tenantID)
+	})
+}
+
+func (r *CircuitBreakerRepository) DeleteAPIKey(ctx context.Context, tenantID string, id string) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.DeleteAPIKey(ctx, tenantID, id) })
+}
+
+func (r *CircuitBreakerRepository) UpdateRecordHealth(ctx context.Context, recordID string, status domain.HealthStatus, errMsg string) error {
+	return r.do(ctx, func(ctx context.Context) error {
+		return r.DNSRepository.UpdateRecordHealth(ctx, recordID, status, errMsg)
+	})
+}
+
+func (r *CircuitBreakerRepository) GetRecordsToProbe(ctx context.Context) ([]domain.Record, error) {
+	return guard(r, ctx, func(ctx context.Context) ([]domain.Record, error) {
+		return r.DNSRepository.GetRecordsToProbe(ctx)
+	})
+}
+
+func (r *CircuitBreakerRepository) UpsertNode(ctx context.Context, node *domain.Node) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.UpsertNode(ctx, node) })
+}
+
+func (r *CircuitBreakerRepository) ListNodes(ctx context.Context) ([]domain.Node, error) {
+	return guard(r, ctx, func(ctx context.Context) ([]domain.Node, error) {
+		return r.DNSRepository.ListNodes(ctx)
+	})
+}
+
+func (r *CircuitBreakerRepository) GetNodeConfig(ctx context.Context, nodeID string) (map[string]string, error) {
+	return guard(r, ctx, func(ctx context.Context) (map[string]string, error) {
+		return r.DNSRepository.GetNodeConfig(ctx, nodeID)
+	})
+}
+
+func (r *CircuitBreakerRepository) SetNodeConfig(ctx context.Context, nodeID string, cfg map[string]string) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.SetNodeConfig(ctx, nodeID, cfg) })
+}
+
+func (r *CircuitBreakerRepository) SaveRollout(ctx context.Context, rollout *domain.Rollout) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.SaveRollout(ctx, rollout) })
+}
+
+func (r *CircuitBreakerRepository) GetRollout(ctx context.Context, id string) (*domain.Rollout, error) {
+	return guard(r, ctx, func(ctx context.Context) (*domain.Rollout, error) {
+		return r.DNSRepository.GetRollout(ctx, id)
+	})
+}
+
+func (r *CircuitBreakerRepository) ListRollouts(ctx context.Context) ([]domain.Rollout, error) {
+	return guard(r, ctx, func(ctx context.Context) ([]domain.Rollout, error) {
+		return r.DNSRepository.ListRollouts(ctx)
+	})
+}
+
+func (r *CircuitBreakerRepository) AddZoneUsage(ctx context.Context, usage []domain.ZoneUsage) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.AddZoneUsage(ctx, usage) })
+}
+
+func (r *CircuitBreakerRepository) ListZoneUsage(ctx context.Context, tenantID string, zoneID string, from, to time.Time) ([]domain.ZoneUsage, error) {
+	return guard(r, ctx, func(ctx context.Context) ([]domain.ZoneUsage, error) {
+		return r.DNSRepository.ListZoneUsage(ctx, tenantID, zoneID, from, to)
+	})
+}
+
+func (r *CircuitBreakerRepository) CreateSecondaryProvider(ctx context.Context, p *domain.SecondaryProvider) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.CreateSecondaryProvider(ctx, p) })
+}
+
+func (r *CircuitBreakerRepository) ListSecondaryProviders(ctx context.Context, zoneID string) ([]domain.SecondaryProvider, error) {
+	return guard(r, ctx, func(ctx context.Context) ([]domain.SecondaryProvider, error) {
+		return r.DNSRepository.ListSecondaryProviders(ctx, zoneID)
+	})
+}
+
+func (r *CircuitBreakerRepository) DeleteSecondaryProvider(ctx context.Context, id string, zoneID string) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.DeleteSecondaryProvider(ctx, id, zoneID) })
+}
+
+func (r *CircuitBreakerRepository) UpdateSecondaryProviderStatus(ctx context.Context, p *domain.SecondaryProvider) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.UpdateSecondaryProviderStatus(ctx, p) })
+}
+
+func (r *CircuitBreakerRepository) SaveZoneRefresh(ctx context.Context, refresh *domain.ZoneRefresh) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.SaveZoneRefresh(ctx, refresh) })
+}
+
+func (r *CircuitBreakerRepository) GetZoneRefresh(ctx context.Context, zoneID string) (*domain.ZoneRefresh, error) {
+	return guard(r, ctx, func(ctx context.Context) (*domain.ZoneRefresh, error) {
+		return r.DNSRepository.GetZoneRefresh(ctx, zoneID)
+	})
+}
+
+func (r *CircuitBreakerRepository) CreateScheduledChange(ctx context.Context, c *domain.ScheduledChange) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.CreateScheduledChange(ctx, c) })
+}
+
+func (r *CircuitBreakerRepository) ListScheduledChanges(ctx context.Context, zoneID string) ([]domain.ScheduledChange, error) {
+	return guard(r, ctx, func(ctx context.Context) ([]domain.ScheduledChange, error) {
+		return r.DNSRepository.ListScheduledChanges(ctx, zoneID)
+	})
+}
+
+func (r *CircuitBreakerRepository) CancelScheduledChange(ctx context.Context, id string, zoneID string) (bool, error) {
+	return guard(r, ctx, func(ctx context.Context) (bool, error) {
+		return r.DNSRepository.CancelScheduledChange(ctx, id, zoneID)
+	})
+}
+
+func (r *CircuitBreakerRepository) ClaimDueScheduledChanges(ctx context.Context, now time.Time, limit int) ([]domain.ScheduledChange, error) {
+	return guard(r, ctx, func(ctx context.Context) ([]domain.ScheduledChange, error) {
+		return r.DNSRepository.ClaimDueScheduledChanges(ctx, now, limit)
+	})
+}
+
+func (r *CircuitBreakerRepository) FinishScheduledChange(ctx context.Context, c *domain.ScheduledChange) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.FinishScheduledChange(ctx, c) })
+}
+
+func (r *CircuitBreakerRepository) SetZoneReview(ctx context.Context, zoneID string, tenantID string, required bool) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.SetZoneReview(ctx, zoneID, tenantID, required) })
+}
+
+func (r *CircuitBreakerRepository) IsZoneReviewed(ctx context.Context, zoneID string) (bool, error) {
+	return guard(r, ctx, func(ctx context.Context) (bool, error) {
+		return r.DNSRepository.IsZoneReviewed(ctx, zoneID)
+	})
+}
+
+func (r *CircuitBreakerRepository) CreateChangeRequest(ctx context.Context, c *domain.ChangeRequest) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.CreateChangeRequest(ctx, c) })
+}
+
+func (r *CircuitBreakerRepository) ListChangeRequests(ctx context.Context, zoneID string) ([]domain.ChangeRequest, error) {
+	return guard(r, ctx, func(ctx context.Context) ([]domain.ChangeRequest, error) {
+		return r.DNSRepository.ListChangeRequests(ctx, zoneID)
+	})
+}
+
+func (r *CircuitBreakerRepository) GetChangeRequest(ctx context.Context, id string, zoneID string) (*domain.ChangeRequest, error) {
+	return guard(r, ctx, func(ctx context.Context) (*domain.ChangeRequest, error) {
+		return r.DNSRepository.GetChangeRequest(ctx, id, zoneID)
+	})
+}
+
+func (r *CircuitBreakerRepository) ReviewChangeRequest(ctx context.Context, c *domain.ChangeRequest) (bool, error) {
+	return guard(r, ctx, func(ctx context.Context) (bool, error) {
+		return r.DNSRepository.ReviewChangeRequest(ctx, c)
+	})
+}
+
+func (r *CircuitBreakerRepository) FinishChangeRequest(ctx context.Context, c *domain.ChangeRequest) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.FinishChangeRequest(ctx, c) })
+}
+
+func (r *CircuitBreakerRepository) CreateChangeFreeze(ctx context.Context, f *domain.ChangeFreeze) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.CreateChangeFreeze(ctx, f) })
+}
+
+func (r *CircuitBreakerRepository) ListChangeFreezes(ctx context.Context, tenantID string) ([]domain.ChangeFreeze, error) {
+	return guard(r, ctx, func(ctx context.Context) ([]domain.ChangeFreeze, error) {
+		return r.DNSRepository.ListChangeFreezes(ctx, tenantID)
+	})
+}
+
+func (r *CircuitBreakerRepository) DeleteChangeFreeze(ctx context.Context, id string, tenantID string) (bool, error) {
+	return guard(r, ctx, func(ctx context.Context) (bool, error) {
+		return r.DNSRepository.DeleteChangeFreeze(ctx, id, tenantID)
+	})
+}
+
+func (r *CircuitBreakerRepository) GetActiveChangeFreeze(ctx context.Context, tenantID string, zoneID string, at time.Time) (*domain.ChangeFreeze, error) {
+	return guard(r, ctx, func(ctx context.Context) (*domain.ChangeFreeze, error) {
+		return r.DNSRepository.GetActiveChangeFreeze(ctx, tenantID, zoneID, at)
+	})
+}
+
+func (r *CircuitBreakerRepository) SaveShadowChange(ctx context.Context, change *domain.ShadowChange) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.SaveShadowChange(ctx, change) })
+}
+
+func (r *CircuitBreakerRepository) GetShadowChange(ctx context.Context, zoneID string) (*domain.ShadowChange, error) {
+	return guard(r, ctx, func(ctx context.Context) (*domain.ShadowChange, error) {
+		return r.DNSRepository.GetShadowChange(ctx, zoneID)
+	})
+}
+
+func (r *CircuitBreakerRepository) ListRecordNetworks(ctx context.Context) ([]string, error) {
+	return guard(r, ctx, func(ctx context.Context) ([]string, error) {
+		return r.DNSRepository.ListRecordNetworks(ctx)
+	})
+}
+
+func (r *CircuitBreakerRepository) ListRecordGeoScopes(ctx context.Context) ([]string, error) {
+	return guard(r, ctx, func(ctx context.Context) ([]string, error) {
+		return r.DNSRepository.ListRecordGeoScopes(ctx)
+	})
+}
+
+func (r *CircuitBreakerRepository) ListShadowChanges(ctx context.Context) ([]domain.ShadowChange, error) {
+	return guard(r, ctx, func(ctx context.Context) ([]domain.ShadowChange, error) {
+		return r.DNSRepository.ListShadowChanges(ctx)
+	})
+}
+
+func (r *CircuitBreakerRepository) DeleteShadowChange(ctx context.Context, zoneID string) (bool, error) {
+	return guard(r, ctx, func(ctx context.Context) (bool, error) {
+		return r.DNSRepository.DeleteShadowChange(ctx, zoneID)
+	})
+}
+
+func (r *CircuitBreakerRepository) SaveZoneSLO(ctx context.Context, slo *domain.ZoneSLO) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.SaveZoneSLO(ctx, slo) })
+}
+
+func (r *CircuitBreakerRepository) GetZoneSLO(ctx context.Context, zoneID string) (*domain.ZoneSLO, error) {
+	return guard(r, ctx, func(ctx context.Context) (*domain.ZoneSLO, error) {
+		return r.DNSRepository.GetZoneSLO(ctx, zoneID)
+	})
+}
+
+func (r *CircuitBreakerRepository) ListZoneSLOs(ctx context.Context) ([]domain.ZoneSLO, error) {
+	return guard(r, ctx, func(ctx context.Context) ([]domain.ZoneSLO, error) {
+		return r.DNSRepository.ListZoneSLOs(ctx)
+	})
+}
+
+func (r *CircuitBreakerRepository) DeleteZoneSLO(ctx context.Context, zoneID string) (bool, error) {
+	return guard(r, ctx, func(ctx context.Context) (bool, error) {
+		return r.DNSRepository.DeleteZoneSLO(ctx, zoneID)
+	})
+}
+
+func (r *CircuitBreakerRepository) SaveQueryLimit(ctx context.Context, limit *domain.QueryLimit) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.SaveQueryLimit(ctx, limit) })
+}
+
+func (r *CircuitBreakerRepository) ListQueryLimits(ctx context.Context, tenantID string) ([]domain.QueryLimit, error) {
+	return guard(r, ctx, func(ctx context.Context) ([]domain.QueryLimit, error) {
+		return r.DNSRepository.ListQueryLimits(ctx, tenantID)
+	})
+}
+
+func (r *CircuitBreakerRepository) DeleteQueryLimit(ctx context.Context, tenantID string, zoneID string) (bool, error) {
+	return guard(r, ctx, func(ctx context.Context) (bool, error) {
+		return r.DNSRepository.DeleteQueryLimit(ctx, tenantID, zoneID)
+	})
+}
+
+func (r *CircuitBreakerRepository) SaveView(ctx context.Context, view *domain.View) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.SaveView(ctx, view) })
+}
+
+func (r *CircuitBreakerRepository) ListViews(ctx context.Context, tenantID string) ([]domain.View, error) {
+	return guard(r, ctx, func(ctx context.Context) ([]domain.View, error) {
+		return r.DNSRepository.ListViews(ctx, tenantID)
+	})
+}
+
+func (r *CircuitBreakerRepository) DeleteView(ctx context.Context, id string, tenantID string) (bool, error) {
+	return guard(r, ctx, func(ctx context.Context) (bool, error) {
+		return r.DNSRepository.DeleteView(ctx, id, tenantID)
+	})
+}
+
+func (r *CircuitBreakerRepository) SaveDefaultRecord(ctx context.Context, rec *domain.DefaultRecord) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.SaveDefaultRecord(ctx, rec) })
+}
+
+func (r *CircuitBreakerRepository) ListDefaultRecords(ctx context.Context, zoneID string) ([]domain.DefaultRecord, error) {
+	return guard(r, ctx, func(ctx context.Context) ([]domain.DefaultRecord, error) {
+		return r.DNSRepository.ListDefaultRecords(ctx, zoneID)
+	})
+}
+
+func (r *CircuitBreakerRepository) DeleteDefaultRecord(ctx context.Context, id string, zoneID string) (bool, error) {
+	return guard(r, ctx, func(ctx context.Context) (bool, error) {
+		return r.DNSRepository.DeleteDefaultRecord(ctx, id, zoneID)
+	})
+}
+
+func (r *CircuitBreakerRepository) SaveTarget(ctx context.Context, target *domain.Target) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.SaveTarget(ctx, target) })
+}
+
+func (r *CircuitBreakerRepository) ListTargets(ctx context.Context, tenantID string) ([]domain.Target, error) {
+	return guard(r, ctx, func(ctx context.Context) ([]domain.Target, error) {
+		return r.DNSRepository.ListTargets(ctx, tenantID)
+	})
+}
+
+func (r *CircuitBreakerRepository) DeleteTarget(ctx context.Context, id string, tenantID string) (bool, error) {
+	return guard(r, ctx, func(ctx context.Context) (bool, error) {
+		return r.DNSRepository.DeleteTarget(ctx, id, tenantID)
+	})
+}
+
+func (r *CircuitBreakerRepository) ListTargetRecords(ctx context.Context, targetID string) ([]domain.Record, error) {
+	return guard(r, ctx, func(ctx context.Context) ([]domain.Record, error) {
+		return r.DNSRepository.ListTargetRecords(ctx, targetID)
+	})
+}
+
+func (r *CircuitBreakerRepository) SaveHealthCheck(ctx context.Context, check *domain.HealthCheck) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.SaveHealthCheck(ctx, check) })
+}
+
+func (r *CircuitBreakerRepository) ListHealthChecks(ctx context.Context, tenantID string) ([]domain.HealthCheck, error) {
+	return guard(r, ctx, func(ctx context.Context) ([]domain.HealthCheck, error) {
+		return r.DNSRepository.ListHealthChecks(ctx, tenantID)
+	})
+}
+
+func (r *CircuitBreakerRepository) DeleteHealthCheck(ctx context.Context, id string, tenantID string) (bool, error) {
+	return guard(r, ctx, func(ctx context.Context) (bool, error) {
+		return r.DNSRepository.DeleteHealthCheck(ctx, id, tenantID)
+	})
+}
+
+func (r *CircuitBreakerRepository) UpdateHealthCheckStatus(ctx context.Context, id string, status domain.HealthStatus, errMsg string) error {
+	return r.do(ctx, func(ctx context.Context) error {
+		return r.DNSRepository.UpdateHealthCheckStatus(ctx, id, status, errMsg)
+	})
+}
+
+func (r *CircuitBreakerRepository) ListHealthCheckRecords(ctx context.Context, checkID string) ([]domain.Record, error) {
+	return guard(r, ctx, func(ctx context.Context) ([]domain.Record, error) {
+		return r.DNSRepository.ListHealthCheckRecords(ctx, checkID)
+	})
+}
+
+func (r *CircuitBreakerRepository) SaveSubnet(ctx context.Context, subnet *domain.Subnet) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.SaveSubnet(ctx, subnet) })
+}
+
+func (r *CircuitBreakerRepository) ListSubnets(ctx context.Context, tenantID string) ([]domain.Subnet, error) {
+	return guard(r, ctx, func(ctx context.Context) ([]domain.Subnet, error) {
+		return r.DNSRepository.ListSubnets(ctx, tenantID)
+	})
+}
+
+func (r *CircuitBreakerRepository) DeleteSubnet(ctx context.Context, id string, tenantID string) (bool, error) {
+	return guard(r, ctx, func(ctx context.Context) (bool, error) {
+		return r.DNSRepository.DeleteSubnet(ctx, id, tenantID)
+	})
+}
+
+func (r *CircuitBreakerRepository) SaveIPAllocation(ctx context.Context, alloc *domain.IPAllocation) error {
+	return r.do(ctx, func(ctx context.Context) error { return r.DNSRepository.SaveIPAllocation(ctx, alloc) })
+}
+
+func (r *CircuitBreakerRepository) ListIPAllocations(ctx context.Context, subnetID string) ([]domain.IPAllocation, error) {
+	return guard(r, ctx, func(ctx context.Context) ([]domain.IPAllocation, error) {
+		return r.DNSRepository.ListIPAllocations(ctx, subnetID)
+	})
+}
+
+func (r *CircuitBreakerRepository) DeleteIPAllocation(ctx context.Context, id string, subnetID string) (bool, error) {
+	return guard(r, ctx, func(ctx context.Context) (bool, error) {
+		return r.DNSRepository.DeleteIPAllocation(ctx, id, subnetID)
+	})
+}
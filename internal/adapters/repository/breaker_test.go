@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/core/ports"
+)
+
+type flakyRepo struct {
+	ports.DNSRepository
+	err   error
+	calls int
+}
+
+func (f *flakyRepo) GetZone(_ context.Context, name string) (*domain.Zone, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &domain.Zone{Name: name}, nil
+}
+
+func TestCircuitBreakerRepository_TripsAndRecovers(t *testing.T) {
+	inner := &flakyRepo{err: errors.New("connection refused")}
+	var events []string
+	repo := NewCircuitBreakerRepository(inner, CircuitBreakerConfig{
+		FailureThreshold: 3,
+		Cooldown:         time.Minute,
+		OnStateChange: func(_, to CircuitState) {
+			events = append(events, to.String())
+		},
+	}, nil)
+	now := time.Now()
+	repo.Breaker().now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_, _ = repo.GetZone(ctx, "example.com.")
+	}
+	if repo.Breaker().State() != CircuitOpen {
+		t.Fatalf("expected open circuit after 3 failures, got %s", repo.Breaker().State())
+	}
+
+	// Open circuit must not reach the database
+	if _, err := repo.GetZone(ctx, "example.com."); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+	if inner.calls != 3 {
+		t.Errorf("expected 3 database calls, got %d", inner.calls)
+	}
+
+	// After the cooldown a single trial call is allowed and closes the circuit
+	now = now.Add(2 * time.Minute)
+	inner.err = nil
+	if _, err := repo.GetZone(ctx, "example.com."); err != nil {
+		t.Errorf("expected trial call to succeed, got %v", err)
+	}
+	if repo.Breaker().State() != CircuitClosed {
+		t.Errorf("expected closed circuit, got %s", repo.Breaker().State())
+	}
+
+	want := []string{"open", "half-open", "closed"}
+	if len(events) != len(want) {
+		t.Fatalf("expected events %v, got %v", want, events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("event %d: expected %s, got %s", i, want[i], events[i])
+		}
+	}
+}
+
+func TestCircuitBreaker_HalfOpenFailureReopens(t *testing.T) {
+	b := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, Cooldown: time.Second}, nil)
+	now := time.Now()
+	b.now = func() time.Time { return now }
+
+	b.Record(errors.New("timeout"))
+	now = now.Add(2 * time.Second)
+
+	if err := b.Allow(); err != nil {
+		t.Fatalf("expected trial call to be allowed, got %v", err)
+	}
+	// Only one trial call at a time
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected concurrent trial to be rejected, got %v", err)
+	}
+	b.Record(context.DeadlineExceeded)
+	if b.State() != CircuitOpen {
+		t.Errorf("expected failed trial to reopen circuit, got %s", b.State())
+	}
+}
+
+func TestCircuitBreaker_IgnoresNonFailures(t *testing.T) {
+	b := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1}, nil)
+	b.Record(sql.ErrNoRows)
+	b.Record(context.Canceled)
+	if b.State() != CircuitClosed {
+		t.Errorf("expected closed circuit, got %s", b.State())
+	}
+}
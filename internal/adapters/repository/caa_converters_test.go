@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+func TestCAAConverters(t *testing.T) {
+	original := domain.Record{Name: "example.com.", Type: domain.TypeCAA, Content: `0 issue "ca.example.net; account=230123"`, TTL: 3600}
+	pRec, err := ConvertDomainToPacketRecord(original)
+	if err != nil {
+		t.Fatalf("ConvertDomainToPacketRecord failed: %v", err)
+	}
+	if pRec.Type != packet.CAA || pRec.Flags != 0 || pRec.Tag != "issue" || pRec.Value != "ca.example.net; account=230123" {
+		t.Fatalf("Unexpected packet record %+v", pRec)
+	}
+
+	decoded, err := ConvertPacketRecordToDomain(pRec, "zone-123")
+	if err != nil {
+		t.Fatalf("ConvertPacketRecordToDomain failed: %v", err)
+	}
+	if decoded.Type != domain.TypeCAA || decoded.Content != original.Content {
+		t.Errorf("Unexpected domain record %+v", decoded)
+	}
+
+	if _, err := ConvertDomainToPacketRecord(domain.Record{Name: "example.com.", Type: domain.TypeCAA, Content: "issue"}); err == nil {
+		t.Error("Expected malformed CAA content to be rejected")
+	}
+}
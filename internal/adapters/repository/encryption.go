@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/envelope"
+)
+
+// Columns holding secrets, sealed when encryption is enabled. The column and the
+// row ID are bound to each sealed value, so it cannot be copied to another row.
+const (
+	columnPrivateKey = "dnssec_keys.private_key"
+	columnTSIGSecret = "zone_secondaries.tsig_secret"
+)
+
+// errNoEncryption is returned when a sealed secret is read without a keyring.
+var errNoEncryption = errors.New("secret is encrypted but encryption is not configured")
+
+// SetEncryption seals DNSSEC private keys and TSIG secrets with keys as they are
+// written. Secrets written before encryption was enabled are still read as they
+// are until ReencryptSecrets seals them.
+func (r *PostgresRepository) SetEncryption(keys *envelope.Keyring) {
+	r.secrets = keys
+}
+
+func (r *PostgresRepository) sealSecret(column, id string, value []byte) ([]byte, error) {
+	if r.secrets == nil || len(value) == 0 {
+		return value, nil
+	}
+	sealed, err := r.secrets.Seal(value, []byte(column+":"+id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt %s: %w", column, err)
+	}
+	return sealed, nil
+}
+
+func (r *PostgresRepository) openSecret(column, id string, value []byte) ([]byte, error) {
+	if !envelope.IsSealed(value) {
+		return value, nil
+	}
+	if r.secrets == nil {
+		return nil, fmt.Errorf("%s of %s: %w", column, id, errNoEncryption)
+	}
+	plain, err := r.secrets.Open(value, []byte(column+":"+id))
+	if err != nil {
+		return nil, fmt.Errorf("%s of %s: %w", column, id, err)
+	}
+	return plain, nil
+}
+
+// ReencryptSecrets seals every secret still stored in plaintext and rewraps
+// those sealed with a key other than the current one, returning how many were
+// rewritten. Run it after enabling encryption and after rotating keys, before
+// retiring the old key.
+func (r *PostgresRepository) ReencryptSecrets(ctx context.Context) (int, error) {
+	if r.secrets == nil {
+		return 0, errors.New("encryption is not configured")
+	}
+	keys, err := r.reencryptColumn(ctx, "dnssec_keys", "private_key", columnPrivateKey)
+	if err != nil {
+		return keys, err
+	}
+	tsig, err := r.reencryptColumn(ctx, "zone_secondaries", "tsig_secret", columnTSIGSecret)
+	return keys + tsig, err
+}
+
+func (r *PostgresRepository) reencryptColumn(ctx context.Context, table, col, column string) (int, error) {
+	// table and col are constants of this package
+	// #nosec G201
+	rows, err := r.queryContext(ctx, fmt.Sprintf("SELECT id, %s FROM %s", col, table))
+	if err != nil {
+		return 0, err
+	}
+	type update struct {
+		id    string
+		value []byte
+	}
+	var updates []update
+	for rows.Next() {
+		var id string
+		var value []byte
+		if errScan := rows.Scan(&id, &value); errScan != nil {
+			_ = rows.Close()
+			return 0, errScan
+		}
+		var next []byte
+		switch {
+		case len(value) == 0:
+			continue
+		case !envelope.IsSealed(value):
+			next, err = r.sealSecret(column, id, value)
+		case r.secrets.NeedsRewrap(value):
+			next, err = r.secrets.Rewrap(value)
+		default:
+			continue
+		}
+		if err != nil {
+			_ = rows.Close()
+			return 0, fmt.Errorf("%s of %s: %w", column, id, err)
+		}
+		updates = append(updates, update{id: id, value: next})
+	}
+	if errClose := rows.Close(); errClose != nil {
+		log.Printf("failed to close rows: %v", errClose)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for i, u := range updates {
+		value := any(u.value)
+		if col == "tsig_secret" {
+			value = string(u.value)
+		}
+		// #nosec G201
+		if _, err := r.execContext(ctx, fmt.Sprintf("UPDATE %s SET %s = $1 WHERE id = $2", table, col), value, u.id); err != nil {
+			return i, err
+		}
+	}
+	return len(updates), nil
+}
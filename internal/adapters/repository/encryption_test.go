@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/envelope"
+)
+
+func testKeyring(t *testing.T, spec, current string) *envelope.Keyring {
+	t.Helper()
+	k, err := envelope.ParseKeyring(spec, current)
+	if err != nil {
+		t.Fatalf("ParseKeyring failed: %v", err)
+	}
+	return k
+}
+
+func kekSpec(id string, b byte) string {
+	return id + ":" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32))
+}
+
+// capture is a sqlmock argument matcher that keeps the value it is given.
+type capture struct{ value driver.Value }
+
+func (c *capture) Match(v driver.Value) bool {
+	c.value = v
+	return true
+}
+
+func TestEncryptedDNSSECKeys(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	repo := NewPostgresRepository(db)
+	repo.SetEncryption(testKeyring(t, kekSpec("k1", 1), ""))
+	ctx := context.Background()
+	now := time.Now()
+
+	stored := &capture{}
+	key := &domain.DNSSECKey{ID: "key1", ZoneID: "z1", KeyType: "ZSK", Algorithm: 13, PrivateKey: []byte("private"), Active: true}
+	mock.ExpectExec(`INSERT INTO dnssec_keys`).
+		WithArgs(key.ID, key.ZoneID, key.KeyType, key.Algorithm, stored, key.PublicKey, key.Active, sqlmock.AnyArg(), sqlmock.AnyArg(), key.External).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	if err := repo.CreateKey(ctx, key); err != nil {
+		t.Fatalf("CreateKey failed: %v", err)
+	}
+	sealed, _ := stored.value.([]byte)
+	if !envelope.IsSealed(sealed) {
+		t.Fatalf("Expected the private key to be stored sealed, got %q", stored.value)
+	}
+
+	// Sealed and legacy plaintext keys are both readable
+	columns := []string{"id", "zone_id", "key_type", "algorithm", "private_key", "public_key", "active", "created_at", "updated_at", "external"}
+	mock.ExpectQuery(`SELECT .* FROM dnssec_keys WHERE zone_id = \$1`).WithArgs("z1").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("key1", "z1", "ZSK", 13, sealed, []byte{}, true, now, now, false).
+			AddRow("key2", "z1", "KSK", 13, []byte("legacy"), []byte{}, true, now, now, false))
+	keys, err := repo.ListKeysForZone(ctx, "z1")
+	if err != nil || len(keys) != 2 || string(keys[0].PrivateKey) != "private" || string(keys[1].PrivateKey) != "legacy" {
+		t.Fatalf("ListKeysForZone = %+v, %v", keys, err)
+	}
+
+	// A sealed key copied to another row does not open
+	mock.ExpectQuery(`SELECT .* FROM dnssec_keys WHERE zone_id = \$1`).WithArgs("z1").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("key3", "z1", "ZSK", 13, sealed, []byte{}, true, now, now, false))
+	if _, err := repo.ListKeysForZone(ctx, "z1"); err == nil {
+		t.Error("Expected a key sealed for another row to fail")
+	}
+
+	// Without the keyring sealed keys cannot be read
+	plain := NewPostgresRepository(db)
+	mock.ExpectQuery(`SELECT .* FROM dnssec_keys WHERE zone_id = \$1`).WithArgs("z1").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("key1", "z1", "ZSK", 13, sealed, []byte{}, true, now, now, false))
+	if _, err := plain.ListKeysForZone(ctx, "z1"); !errors.Is(err, errNoEncryption) {
+		t.Errorf("Expected errNoEncryption, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestEncryptedTSIGSecrets(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	repo := NewPostgresRepository(db)
+	repo.SetEncryption(testKeyring(t, kekSpec("k1", 1), ""))
+	ctx := context.Background()
+	now := time.Now()
+
+	stored := &capture{}
+	mock.ExpectExec(`INSERT INTO zone_secondaries`).
+		WithArgs("s1", "z1", "ext", "192.0.2.1:53", "xfr.", stored, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	if err := repo.CreateSecondaryProvider(ctx, &domain.SecondaryProvider{ID: "s1", ZoneID: "z1", Name: "ext", Address: "192.0.2.1:53",
+		TSIGKeyName: "xfr.", TSIGSecret: "c2VjcmV0", CreatedAt: now}); err != nil {
+		t.Fatalf("CreateSecondaryProvider failed: %v", err)
+	}
+	sealed, _ := stored.value.(string)
+	if !envelope.IsSealed([]byte(sealed)) {
+		t.Fatalf("Expected the TSIG secret to be stored sealed, got %q", stored.value)
+	}
+
+	rows := sqlmock.NewRows([]string{"id", "zone_id", "name", "address", "tsig_key_name", "tsig_secret", "observed_serial", "last_notified_at", "last_checked_at", "last_error", "created_at"}).
+		AddRow("s1", "z1", "ext", "192.0.2.1:53", "xfr.", sealed, int64(7), nil, nil, "", now)
+	mock.ExpectQuery(`SELECT .* FROM zone_secondaries WHERE zone_id = \$1`).WithArgs("z1").WillReturnRows(rows)
+	providers, err := repo.ListSecondaryProviders(ctx, "z1")
+	if err != nil || len(providers) != 1 || providers[0].TSIGSecret != "c2VjcmV0" {
+		t.Fatalf("ListSecondaryProviders = %+v, %v", providers, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestReencryptSecrets(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	ctx := context.Background()
+
+	if _, err := NewPostgresRepository(db).ReencryptSecrets(ctx); err == nil {
+		t.Fatal("Expected ReencryptSecrets to require a keyring")
+	}
+
+	old := testKeyring(t, kekSpec("k1", 1), "")
+	oldSealed, _ := old.Seal([]byte("private"), []byte(columnPrivateKey+":key2"))
+	rotated := testKeyring(t, kekSpec("k1", 1)+","+kekSpec("k2", 2), "k2")
+	current, _ := rotated.Seal([]byte("private"), []byte(columnPrivateKey+":key3"))
+
+	repo := NewPostgresRepository(db)
+	repo.SetEncryption(rotated)
+
+	// Plaintext is sealed, k1 values are rewrapped, k2 and empty values are left alone
+	mock.ExpectQuery(`SELECT id, private_key FROM dnssec_keys`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "private_key"}).
+			AddRow("key1", []byte("legacy")).
+			AddRow("key2", oldSealed).
+			AddRow("key3", current).
+			AddRow("key4", []byte{}))
+	sealedKey, rewrapped := &capture{}, &capture{}
+	mock.ExpectExec(`UPDATE dnssec_keys SET private_key = \$1 WHERE id = \$2`).WithArgs(sealedKey, "key1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE dnssec_keys SET private_key = \$1 WHERE id = \$2`).WithArgs(rewrapped, "key2").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT id, tsig_secret FROM zone_secondaries`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tsig_secret"}).AddRow("s1", "c2VjcmV0"))
+	sealedSecret := &capture{}
+	mock.ExpectExec(`UPDATE zone_secondaries SET tsig_secret = \$1 WHERE id = \$2`).WithArgs(sealedSecret, "s1").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	n, err := repo.ReencryptSecrets(ctx)
+	if err != nil || n != 3 {
+		t.Fatalf("ReencryptSecrets = %d, %v", n, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unfulfilled expectations: %v", err)
+	}
+
+	for _, c := range []struct {
+		value driver.Value
+		aad   string
+	}{
+		{sealedKey.value, columnPrivateKey + ":key1"},
+		{rewrapped.value, columnPrivateKey + ":key2"},
+		{sealedSecret.value, columnTSIGSecret + ":s1"},
+	} {
+		var sealed []byte
+		switch v := c.value.(type) {
+		case []byte:
+			sealed = v
+		case string:
+			sealed = []byte(v)
+		}
+		if rotated.NeedsRewrap(sealed) {
+			t.Errorf("Expected %s to be sealed with k2, got %q", c.aad, sealed)
+		}
+		if _, err := rotated.Open(sealed, []byte(c.aad)); err != nil {
+			t.Errorf("Failed to open %s: %v", c.aad, err)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+)
+
+// failoverCooldown is the minimum time between two failover attempts. It keeps a
+// burst of failing queries from hammering every standby at once.
+const failoverCooldown = 5 * time.Second
+
+// DBOpener opens (but does not necessarily connect) a database handle for a DSN.
+type DBOpener func(dsn string) (*sql.DB, error)
+
+// FailoverPool tracks an ordered list of PostgreSQL endpoints (primary first, then
+// standbys) and keeps a handle to whichever one currently accepts writes.
+// When the active endpoint starts refusing connections or rejects writes because it
+// was demoted to a read-only replica, the pool probes the other endpoints and
+// switches to the promoted primary.
+type FailoverPool struct {
+	mu      sync.RWMutex
+	dsns    []string
+	current int
+	db      *sql.DB
+
+	open        DBOpener
+	logger      *slog.Logger
+	switching   atomic.Bool
+	lastAttempt atomic.Int64
+}
+
+// NewFailoverPool creates a pool over the given DSNs. The first DSN is treated as the
+// initial primary; the handle is opened lazily by the driver, matching sql.Open.
+func NewFailoverPool(dsns []string, open DBOpener, logger *slog.Logger) (*FailoverPool, error) {
+	if len(dsns) == 0 {
+		return nil, errors.New("failover pool requires at least one DSN")
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	db, err := open(dsns[0])
+	if err != nil {
+		return nil, err
+	}
+	metrics.DBActiveEndpoint.Set(0)
+	return &FailoverPool{
+		dsns:   dsns,
+		db:     db,
+		open:   open,
+		logger: logger,
+	}, nil
+}
+
+// DB returns the handle for the endpoint currently acting as primary.
+func (p *FailoverPool) DB() *sql.DB {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.db
+}
+
+// Current returns the index of the active endpoint in the configured DSN list.
+func (p *FailoverPool) Current() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.current
+}
+
+// Observe inspects an error returned by a database call and, if it indicates that the
+// active endpoint is no longer a usable primary, triggers a background failover.
+func (p *FailoverPool) Observe(err error) {
+	if !IsFailoverError(err) {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if errFailover := p.Failover(ctx); errFailover != nil {
+			p.logger.Error("database failover failed", "error", errFailover)
+		}
+	}()
+}
+
+// Failover probes the configured endpoints, starting after the active one, and
+// switches to the first that is reachable and not in recovery. Concurrent calls and
+// calls within the cooldown window are ignored.
+func (p *FailoverPool) Failover(ctx context.Context) error {
+	now := time.Now()
+	if now.UnixNano()-p.lastAttempt.Load() < int64(failoverCooldown) {
+		return nil
+	}
+	if !p.switching.CompareAndSwap(false, true) {
+		return nil
+	}
+	defer p.switching.Store(false)
+	p.lastAttempt.Store(now.UnixNano())
+
+	from := p.Current()
+	p.logger.Warn("database endpoint unhealthy, probing standbys", "endpoint", RedactDSN(p.dsns[from]))
+
+	for i := 1; i < len(p.dsns); i++ {
+		idx := (from + i) % len(p.dsns)
+		candidate, err := p.open(p.dsns[idx])
+		if err != nil {
+			p.logger.Warn("failed to open standby", "endpoint", RedactDSN(p.dsns[idx]), "error", err)
+			continue
+		}
+		if errProbe := probePrimary(ctx, candidate); errProbe != nil {
+			p.logger.Warn("standby is not a writable primary", "endpoint", RedactDSN(p.dsns[idx]), "error", errProbe)
+			_ = candidate.Close()
+			continue
+		}
+
+		p.mu.Lock()
+		old := p.db
+		p.db = candidate
+		p.current = idx
+		p.mu.Unlock()
+
+		if old != nil {
+			_ = old.Close()
+		}
+		metrics.DBFailoversTotal.WithLabelValues("success").Inc()
+		metrics.DBActiveEndpoint.Set(float64(idx))
+		p.logger.Warn("database failover completed",
+			"from", RedactDSN(p.dsns[from]),
+			"to", RedactDSN(p.dsns[idx]),
+		)
+		return nil
+	}
+
+	metrics.DBFailoversTotal.WithLabelValues("failure").Inc()
+	return fmt.Errorf("no writable primary found among %d standby endpoints", len(p.dsns)-1)
+}
+
+// Monitor periodically verifies that the active endpoint is still a writable primary
+// and fails over when it is not. It returns when the context is cancelled.
+func (p *FailoverPool) Monitor(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			checkCtx, cancel := context.WithTimeout(ctx, interval)
+			if err := probePrimary(checkCtx, p.DB()); err != nil {
+				p.logger.Warn("database primary check failed", "error", err)
+				if errFailover := p.Failover(checkCtx); errFailover != nil {
+					p.logger.Error("database failover failed", "error", errFailover)
+				}
+			}
+			cancel()
+		}
+	}
+}
+
+// Close closes the active handle.
+func (p *FailoverPool) Close() error {
+	return p.DB().Close()
+}
+
+// errInRecovery is returned by probePrimary when the endpoint is a hot standby.
+var errInRecovery = errors.New("endpoint is in recovery (read-only)")
+
+func probePrimary(ctx context.Context, db *sql.DB) error {
+	if err := db.PingContext(ctx); err != nil {
+		return err
+	}
+	var inRecovery bool
+	if err := db.QueryRowContext(ctx, "SELECT pg_is_in_recovery()").Scan(&inRecovery); err != nil {
+		return err
+	}
+	if inRecovery {
+		return errInRecovery
+	}
+	return nil
+}
+
+// IsFailoverError reports whether err indicates that the database endpoint can no
+// longer serve as primary: the connection was refused or dropped, the server is
+// shutting down, or it rejected a write because it is now read-only.
+func IsFailoverError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+	for _, errno := range connLostErrnos {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+
+	var connErr *pgconn.ConnectError
+	if errors.As(err, &connErr) {
+		return true
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "25006", // read_only_sql_transaction
+			"57P01", // admin_shutdown
+			"57P02", // crash_shutdown
+			"57P03": // cannot_connect_now
+			return true
+		}
+		// Class 08: connection exception
+		return strings.HasPrefix(pgErr.Code, "08")
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	return false
+}
+
+// RedactDSN strips credentials from a DSN so it can be logged safely.
+func RedactDSN(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err != nil || u.Host == "" {
+		return "***"
+	}
+	return fmt.Sprintf("%s://%s@%s%s", u.Scheme, "***", u.Host, u.Path)
+}
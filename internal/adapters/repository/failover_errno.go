@@ -0,0 +1,9 @@
+//go:build !windows
+
+package repository
+
+import "syscall"
+
+// connLostErrnos are the socket errors meaning the primary refused or dropped
+// the connection.
+var connLostErrnos = []error{syscall.ECONNREFUSED, syscall.ECONNRESET}
@@ -0,0 +1,13 @@
+//go:build windows
+
+package repository
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// Winsock reports refused and reset connections with its own error numbers,
+// which do not match the syscall package's POSIX-style values.
+var connLostErrnos = []error{syscall.ECONNREFUSED, syscall.ECONNRESET, windows.WSAECONNREFUSED, windows.WSAECONNRESET}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// ChangeChannel is the Postgres notification channel the clouddns_notify_change
+// trigger in schema.sql announces record and zone mutations on.
+const ChangeChannel = "clouddns_changes"
+
+// ListenDataChanges subscribes to ChangeChannel on a dedicated connection and
+// calls fn for every change until ctx is done or the connection fails. Changes
+// to a zone or its apex SOA and NS records also drop the zone from the apex
+// cache, so it no longer waits for revalidation to see writes by other nodes.
+// Callers should subscribe again after an error; changes committed in between
+// are not replayed.
+func (r *PostgresRepository) ListenDataChanges(ctx context.Context, fn func(domain.DataChange)) error {
+	conn, err := r.conn().Conn(ctx)
+	if err != nil {
+		r.observe(err)
+		return fmt.Errorf("failed to acquire listen connection: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	return conn.Raw(func(driverConn any) error {
+		sc, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return errors.New("LISTEN requires the pgx driver")
+		}
+		pc := sc.Conn()
+		if _, err := pc.Exec(ctx, "LISTEN "+ChangeChannel); err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", ChangeChannel, err)
+		}
+		// The connection goes back to the pool afterwards and must not keep listening
+		defer func() {
+			if !pc.IsClosed() {
+				_, _ = pc.Exec(context.Background(), "UNLISTEN *")
+			}
+		}()
+		for {
+			n, err := pc.WaitForNotification(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return fmt.Errorf("failed to wait for notification: %w", err)
+			}
+			var change domain.DataChange
+			if err := json.Unmarshal([]byte(n.Payload), &change); err != nil {
+				log.Printf("ignoring malformed change notification %q: %v", n.Payload, err)
+				continue
+			}
+			if change.Table == "dns_zones" || change.Type == domain.TypeSOA || change.Type == domain.TypeNS {
+				r.invalidateApex(change.ZoneID)
+			}
+			fn(change)
+		}
+	})
+}
@@ -7,21 +7,32 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
+	"encoding/json"
 	"fmt"
 	"log"
 	"math"
+	"slices"
 	"sort"
+	"strconv"
 	"net"
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
 	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+	"github.com/poyrazK/cloudDNS/internal/dns/serial"
+	"github.com/poyrazK/cloudDNS/internal/dns/svcb"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/envelope"
 )
 
 // PostgresRepository implements ports.DNSRepository using PostgreSQL.
 type PostgresRepository struct {
-	db *sql.DB
+	db      *sql.DB
+	pool    *FailoverPool
+	apex    *apexCache
+	secrets *envelope.Keyring
 }
 
 // NewPostgresRepository creates and returns a new PostgresRepository instance.
@@ -29,31 +40,87 @@ func NewPostgresRepository(db *sql.DB) *PostgresRepository {
 	return &PostgresRepository{db: db}
 }
 
+// NewPostgresRepositoryWithFailover creates a PostgresRepository that always talks to
+// the pool's active primary and reports connection/read-only errors back to the pool
+// so it can switch to a promoted standby.
+func NewPostgresRepositoryWithFailover(pool *FailoverPool) *PostgresRepository {
+	return &PostgresRepository{pool: pool}
+}
+
+// conn returns the database handle to use for the next call.
+func (r *PostgresRepository) conn() *sql.DB {
+	if r.pool != nil {
+		return r.pool.DB()
+	}
+	return r.db
+}
+
+// observe forwards query errors to the failover pool, if one is configured.
+func (r *PostgresRepository) observe(err error) {
+	if r.pool != nil && err != nil && !errors.Is(err, sql.ErrNoRows) {
+		r.pool.Observe(err)
+	}
+}
+
+func (r *PostgresRepository) execContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	res, err := r.conn().ExecContext(ctx, query, args...)
+	r.observe(err)
+	return res, err
+}
+
+func (r *PostgresRepository) queryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	rows, err := r.conn().QueryContext(ctx, query, args...)
+	r.observe(err)
+	return rows, err
+}
+
+func (r *PostgresRepository) beginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	tx, err := r.conn().BeginTx(ctx, opts)
+	r.observe(err)
+	return tx, err
+}
+
 func (r *PostgresRepository) GetRecords(ctx context.Context, name string, qType domain.RecordType, clientIP string) ([]domain.Record, error) {
+	// Zone apex SOA and NS come from memory when the apex cache is enabled
+	if r.apex != nil && (qType == domain.TypeSOA || qType == domain.TypeNS) {
+		if records, ok, err := r.apexRecords(ctx, name, qType, clientIP); err != nil || ok {
+			return records, err
+		}
+	}
+
 	// For Split-Horizon, we query records where:
 	// 1. The name and type match.
 	// 2. The clientIP is within the record's network CIDR OR the network is NULL (global).
 	// In Postgres, '$2::inet <<= network' checks if the network CIDR contains the client IP.
 	// RFC 1034: Domain name comparisons must be case-insensitive.
-	query := `SELECT r.id, r.zone_id, r.name, r.type, r.content, r.ttl, r.priority, r.weight, r.port, r.network, 
-	                 r.health_check_type, r.health_check_target, COALESCE(h.status, 'UNKNOWN')
+	query := `SELECT r.id, r.zone_id, r.name, r.type, r.content, r.ttl, r.priority, r.weight, r.port, r.network, r.view_id, r.geo, r.target_id,
+	                 r.health_check_type, r.health_check_target, r.health_check_id, COALESCE(c.status, h.status, 'UNKNOWN')
 	          FROM dns_records r
 	          LEFT JOIN record_health h ON r.id = h.record_id
+	          LEFT JOIN health_checks c ON c.id = r.health_check_id
 	          WHERE LOWER(r.name) = LOWER($1) AND (r.network IS NULL OR $2::inet <<= r.network)`
 
 	var rows *sql.Rows
 	var errQuery error
+	name = dnsname.Normalize(name)
 
 	if qType != "" {
 		query += " AND r.type = $3"
-		rows, errQuery = r.db.QueryContext(ctx, query, name, clientIP, string(qType))
+		rows, errQuery = r.queryContext(ctx, query, name, clientIP, string(qType))
 	} else {
-		rows, errQuery = r.db.QueryContext(ctx, query, name, clientIP)
+		rows, errQuery = r.queryContext(ctx, query, name, clientIP)
 	}
 
 	if errQuery != nil {
 		return nil, errQuery
 	}
+	return scanRecordRows(rows)
+}
+
+// scanRecordRows reads and closes the rows of a record query selecting id, zone_id,
+// name, type, content, ttl, priority, weight, port, network, view_id, geo,
+// target_id, the health check type, target and ID, and the health status.
+func scanRecordRows(rows *sql.Rows) ([]domain.Record, error) {
 	defer func() {
 		if errClose := rows.Close(); errClose != nil {
 			log.Printf("failed to close rows: %v", errClose)
@@ -65,9 +132,13 @@ func (r *PostgresRepository) GetRecords(ctx context.Context, name string, qType
 		var rec domain.Record
 		var priority, weight, port sql.NullInt32
 		var hcType, hcTarget, hStatus sql.NullString
-		if errScan := rows.Scan(&rec.ID, &rec.ZoneID, &rec.Name, &rec.Type, &rec.Content, &rec.TTL, &priority, &weight, &port, &rec.Network, &hcType, &hcTarget, &hStatus); errScan != nil {
+		var geo []byte
+		if errScan := rows.Scan(&rec.ID, &rec.ZoneID, &rec.Name, &rec.Type, &rec.Content, &rec.TTL, &priority, &weight, &port, &rec.Network, &rec.ViewID, &geo, &rec.TargetID, &hcType, &hcTarget, &rec.HealthCheckID, &hStatus); errScan != nil {
 			return nil, errScan
 		}
+		if errGeo := decodeGeo(geo, &rec); errGeo != nil {
+			return nil, errGeo
+		}
 		if priority.Valid {
 			p := int(priority.Int32)
 			rec.Priority = &p
@@ -104,7 +175,7 @@ func (r *PostgresRepository) GetIPsForName(ctx context.Context, name string, cli
 	query := `SELECT content FROM dns_records 
 	          WHERE LOWER(name) = LOWER($1) AND type = 'A' AND (network IS NULL OR $2::inet <<= network)`
 
-	rows, errQuery := r.db.QueryContext(ctx, query, name, clientIP)
+	rows, errQuery := r.queryContext(ctx, query, dnsname.Normalize(name), clientIP)
 	if errQuery != nil {
 		return nil, errQuery
 	}
@@ -131,10 +202,42 @@ func (r *PostgresRepository) GetIPsForName(ctx context.Context, name string, cli
 }
 
 func (r *PostgresRepository) GetZone(ctx context.Context, name string) (*domain.Zone, error) {
-	query := `SELECT id, tenant_id, name, vpc_id, description, role, master_server, created_at, updated_at FROM dns_zones WHERE LOWER(name) = LOWER($1)`
+	query := `SELECT id, tenant_id, name, vpc_id, description, role, master_server, dnssec_enabled, created_at, updated_at FROM dns_zones WHERE LOWER(name) = LOWER($1)`
+	var z domain.Zone
+	var role, masterServer sql.NullString
+	var dnssecEnabled bool
+	errRow := r.conn().QueryRowContext(ctx, query, dnsname.Normalize(name)).Scan(&z.ID, &z.TenantID, &z.Name, &z.VPCID, &z.Description, &role, &masterServer, &dnssecEnabled, &z.CreatedAt, &z.UpdatedAt)
+	r.observe(errRow)
+	if errors.Is(errRow, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if errRow != nil {
+		return nil, errRow
+	}
+	if role.Valid {
+		z.Role = role.String
+	}
+	if masterServer.Valid {
+		z.MasterServer = masterServer.String
+	}
+	z.DNSSECEnabled = &dnssecEnabled
+	return &z, nil
+}
+
+// GetZoneByID returns the zone with the given ID, restricted to tenantID when it is
+// non-empty. It returns nil, nil if no such zone exists.
+func (r *PostgresRepository) GetZoneByID(ctx context.Context, zoneID string, tenantID string) (*domain.Zone, error) {
+	query := `SELECT id, tenant_id, name, vpc_id, description, role, master_server, dnssec_enabled, created_at, updated_at FROM dns_zones WHERE id = $1`
+	args := []interface{}{zoneID}
+	if tenantID != "" {
+		query += " AND tenant_id = $2"
+		args = append(args, tenantID)
+	}
 	var z domain.Zone
 	var role, masterServer sql.NullString
-	errRow := r.db.QueryRowContext(ctx, query, name).Scan(&z.ID, &z.TenantID, &z.Name, &z.VPCID, &z.Description, &role, &masterServer, &z.CreatedAt, &z.UpdatedAt)
+	var dnssecEnabled bool
+	errRow := r.conn().QueryRowContext(ctx, query, args...).Scan(&z.ID, &z.TenantID, &z.Name, &z.VPCID, &z.Description, &role, &masterServer, &dnssecEnabled, &z.CreatedAt, &z.UpdatedAt)
+	r.observe(errRow)
 	if errors.Is(errRow, sql.ErrNoRows) {
 		return nil, nil
 	}
@@ -147,30 +250,37 @@ func (r *PostgresRepository) GetZone(ctx context.Context, name string) (*domain.
 	if masterServer.Valid {
 		z.MasterServer = masterServer.String
 	}
+	z.DNSSECEnabled = &dnssecEnabled
 	return &z, nil
 }
 
 func (r *PostgresRepository) GetRecord(ctx context.Context, id string, zoneID string, tenantID string) (*domain.Record, error) {
 	query := `
-		SELECT r.id, r.zone_id, r.name, r.type, r.content, r.ttl, r.priority, r.weight, r.port, r.network,
-		       r.health_check_type, r.health_check_target, COALESCE(h.status, 'UNKNOWN')
+		SELECT r.id, r.zone_id, r.name, r.type, r.content, r.ttl, r.priority, r.weight, r.port, r.network, r.view_id, r.geo, r.target_id,
+		       r.health_check_type, r.health_check_target, r.health_check_id, COALESCE(c.status, h.status, 'UNKNOWN'), r.protected
 		FROM dns_records r
 		JOIN dns_zones z ON r.zone_id = z.id
 		LEFT JOIN record_health h ON r.id = h.record_id
+		LEFT JOIN health_checks c ON c.id = r.health_check_id
 		WHERE r.id = $1 AND r.zone_id = $2 AND z.tenant_id = $3`
 	var rec domain.Record
 	var priority, weight, port sql.NullInt32
 	var hcType, hcTarget, hStatus sql.NullString
-	errRow := r.db.QueryRowContext(ctx, query, id, zoneID, tenantID).Scan(
-		&rec.ID, &rec.ZoneID, &rec.Name, &rec.Type, &rec.Content, &rec.TTL, &priority, &weight, &port, &rec.Network,
-		&hcType, &hcTarget, &hStatus,
+	var geo []byte
+	errRow := r.conn().QueryRowContext(ctx, query, id, zoneID, tenantID).Scan(
+		&rec.ID, &rec.ZoneID, &rec.Name, &rec.Type, &rec.Content, &rec.TTL, &priority, &weight, &port, &rec.Network, &rec.ViewID, &geo, &rec.TargetID,
+		&hcType, &hcTarget, &rec.HealthCheckID, &hStatus, &rec.Protected,
 	)
+	r.observe(errRow)
 	if errors.Is(errRow, sql.ErrNoRows) {
 		return nil, nil
 	}
 	if errRow != nil {
 		return nil, errRow
 	}
+	if errGeo := decodeGeo(geo, &rec); errGeo != nil {
+		return nil, errGeo
+	}
 	if priority.Valid {
 		p := int(priority.Int32)
 		rec.Priority = &p
@@ -193,20 +303,1186 @@ func (r *PostgresRepository) GetRecord(ctx context.Context, id string, zoneID st
 		rec.HealthStatus = domain.HealthStatus(hStatus.String)
 	}
 
-	return &rec, nil
+	return &rec, nil
+}
+
+func (r *PostgresRepository) ListRecordsForZone(ctx context.Context, zoneID string, tenantID string) ([]domain.Record, error) {
+	query := `
+		SELECT r.id, r.zone_id, r.name, r.type, r.content, r.ttl, r.priority, r.weight, r.port, r.network, r.view_id, r.geo, r.target_id,
+		       r.health_check_type, r.health_check_target, r.health_check_id, COALESCE(c.status, h.status, 'UNKNOWN'), r.protected
+		FROM dns_records r
+		JOIN dns_zones z ON r.zone_id = z.id
+		LEFT JOIN record_health h ON r.id = h.record_id
+		LEFT JOIN health_checks c ON c.id = r.health_check_id
+		WHERE r.zone_id = $1 AND z.tenant_id = $2`
+	rows, errQuery := r.queryContext(ctx, query, zoneID, tenantID)
+	if errQuery != nil {
+		return nil, errQuery
+	}
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			log.Printf("failed to close rows: %v", errClose)
+		}
+	}()
+
+	var records []domain.Record
+	for rows.Next() {
+		var rec domain.Record
+		var priority, weight, port sql.NullInt32
+		var hcType, hcTarget, hStatus sql.NullString
+		var geo []byte
+		if errScan := rows.Scan(
+			&rec.ID, &rec.ZoneID, &rec.Name, &rec.Type, &rec.Content, &rec.TTL, &priority, &weight, &port, &rec.Network, &rec.ViewID, &geo, &rec.TargetID,
+			&hcType, &hcTarget, &rec.HealthCheckID, &hStatus, &rec.Protected,
+		); errScan != nil {
+			return nil, errScan
+		}
+		if errGeo := decodeGeo(geo, &rec); errGeo != nil {
+			return nil, errGeo
+		}
+		if priority.Valid {
+			p := int(priority.Int32)
+			rec.Priority = &p
+		}
+		if weight.Valid {
+			w := int(weight.Int32)
+			rec.Weight = &w
+		}
+		if port.Valid {
+			p := int(port.Int32)
+			rec.Port = &p
+		}
+		if hcType.Valid {
+			rec.HealthCheckType = domain.HealthCheckType(hcType.String)
+		}
+		if hcTarget.Valid {
+			rec.HealthCheckTarget = hcTarget.String
+		}
+		if hStatus.Valid {
+			rec.HealthStatus = domain.HealthStatus(hStatus.String)
+		}
+		records = append(records, rec)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// NameExists reports whether the zone has records at name or below it. Names
+// below it are matched on their reversed form, which
+// idx_dns_records_zone_reversed_name serves as a prefix.
+func (r *PostgresRepository) NameExists(ctx context.Context, zoneID string, name string) (bool, error) {
+	name = dnsname.Normalize(name)
+	below := likeEscaper.Replace(reverseString("."+name)) + "%"
+	var exists bool
+	errRow := r.conn().QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM dns_records
+		WHERE zone_id = $1 AND (LOWER(name) = $2 OR reverse(LOWER(name)) LIKE $3))`, zoneID, name, below).Scan(&exists)
+	r.observe(errRow)
+	return exists, errRow
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern, such as the underscores
+// of service labels.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// reverseString returns s with its characters in reverse order.
+func reverseString(s string) string {
+	runes := []rune(s)
+	slices.Reverse(runes)
+	return string(runes)
+}
+
+func (r *PostgresRepository) CreateZone(ctx context.Context, zone *domain.Zone) error {
+	query := `INSERT INTO dns_zones (id, tenant_id, name, vpc_id, description, role, master_server, dnssec_enabled, created_at, updated_at) 
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	_, err := r.execContext(ctx, query, zone.ID, zone.TenantID, zone.Name, zone.VPCID, zone.Description, zone.Role, zone.MasterServer, zone.SignsDNSSEC(), zone.CreatedAt, zone.UpdatedAt)
+	return err
+}
+
+func (r *PostgresRepository) CreateZoneWithRecords(ctx context.Context, zone *domain.Zone, records []domain.Record) error {
+	tx, errTx := r.beginTx(ctx, nil)
+	if errTx != nil {
+		return errTx
+	}
+	defer func() {
+		if errRollback := tx.Rollback(); errRollback != nil && !errors.Is(errRollback, sql.ErrTxDone) {
+			log.Printf("failed to rollback transaction: %v", errRollback)
+		}
+	}()
+
+	// 1. Insert Zone
+	zoneQuery := `INSERT INTO dns_zones (id, tenant_id, name, vpc_id, description, role, master_server, dnssec_enabled, created_at, updated_at) 
+			      VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	_, errExec := tx.ExecContext(ctx, zoneQuery, zone.ID, zone.TenantID, zone.Name, zone.VPCID, zone.Description, zone.Role, zone.MasterServer, zone.SignsDNSSEC(), zone.CreatedAt, zone.UpdatedAt)
+	if errExec != nil {
+		r.observe(errExec)
+		return errExec
+	}
+
+	// 2. Insert Records
+	recordQuery := `INSERT INTO dns_records (id, zone_id, name, type, content, ttl, priority, weight, port, network, view_id, geo, target_id, health_check_type, health_check_target, health_check_id, created_at, updated_at) 
+			        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)`
+	for _, rec := range records {
+		healthType := rec.HealthCheckType
+		if healthType == "" {
+			healthType = domain.HealthCheckNone
+		}
+		_, errExecRecord := tx.ExecContext(ctx, recordQuery, rec.ID, rec.ZoneID, rec.Name, rec.Type, rec.Content, rec.TTL, rec.Priority, rec.Weight, rec.Port, rec.Network, rec.ViewID, encodeGeo(rec.Geo), rec.TargetID, string(healthType), rec.HealthCheckTarget, rec.HealthCheckID, rec.CreatedAt, rec.UpdatedAt)
+		if errExecRecord != nil {
+			r.observe(errExecRecord)
+			return errExecRecord
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	r.invalidateApex(zone.ID)
+	return nil
+}
+
+func (r *PostgresRepository) CreateRecord(ctx context.Context, record *domain.Record) error {
+	healthType := record.HealthCheckType
+	if healthType == "" {
+		healthType = domain.HealthCheckNone
+	}
+	query := `INSERT INTO dns_records (id, zone_id, name, type, content, ttl, priority, weight, port, network, view_id, geo, target_id, health_check_type, health_check_target, health_check_id, protected, created_at, updated_at) 
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)`
+	_, err := r.execContext(ctx, query, record.ID, record.ZoneID, record.Name, record.Type, record.Content, record.TTL, record.Priority, record.Weight, record.Port, record.Network, record.ViewID, encodeGeo(record.Geo), record.TargetID, string(healthType), record.HealthCheckTarget, record.HealthCheckID, record.Protected, record.CreatedAt, record.UpdatedAt)
+	r.invalidateApex(record.ZoneID)
+	return err
+}
+
+func (r *PostgresRepository) UpdateRecordHealth(ctx context.Context, recordID string, status domain.HealthStatus, errMsg string) error {
+	query := `
+		INSERT INTO record_health (record_id, status, last_check, error_message)
+		VALUES ($1, $2, NOW(), $3)
+		ON CONFLICT (record_id) DO UPDATE 
+		SET status = EXCLUDED.status, last_check = EXCLUDED.last_check, error_message = EXCLUDED.error_message`
+	_, err := r.execContext(ctx, query, recordID, string(status), errMsg)
+	return err
+}
+
+// SetRecordProtected marks a tenant's record as protected or not. It returns
+// false if the record does not exist.
+func (r *PostgresRepository) SetRecordProtected(ctx context.Context, recordID string, zoneID string, tenantID string, protected bool) (bool, error) {
+	query := `
+		UPDATE dns_records r SET protected = $4, updated_at = NOW()
+		FROM dns_zones z
+		WHERE r.zone_id = z.id AND r.id = $1 AND r.zone_id = $2 AND z.tenant_id = $3`
+	res, err := r.execContext(ctx, query, recordID, zoneID, tenantID, protected)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// UpsertNode records a heartbeat for node, creating its registry row on first use.
+func (r *PostgresRepository) UpsertNode(ctx context.Context, node *domain.Node) error {
+	query := `
+		INSERT INTO dns_nodes (id, version, address, healthy, qps, anycast_announced, error_rate, config_version, config_error, started_at, last_heartbeat)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (id) DO UPDATE
+		SET version = EXCLUDED.version, address = EXCLUDED.address, healthy = EXCLUDED.healthy, qps = EXCLUDED.qps,
+		    anycast_announced = EXCLUDED.anycast_announced, error_rate = EXCLUDED.error_rate,
+		    config_version = EXCLUDED.config_version, config_error = EXCLUDED.config_error,
+		    started_at = EXCLUDED.started_at, last_heartbeat = EXCLUDED.last_heartbeat`
+	_, err := r.execContext(ctx, query, node.ID, node.Version, node.Address, node.Healthy, node.QPS,
+		node.AnycastAnnounced, node.ErrorRate, node.ConfigVersion, node.ConfigError, node.StartedAt, node.LastHeartbeat)
+	return err
+}
+
+// ListNodes returns every node that has ever sent a heartbeat, ordered by ID.
+func (r *PostgresRepository) ListNodes(ctx context.Context) ([]domain.Node, error) {
+	query := `SELECT id, version, address, healthy, qps, anycast_announced, error_rate, config_version, config_error, started_at, last_heartbeat FROM dns_nodes ORDER BY id`
+	rows, err := r.queryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			log.Printf("failed to close rows: %v", errClose)
+		}
+	}()
+
+	var nodes []domain.Node
+	for rows.Next() {
+		var n domain.Node
+		if errScan := rows.Scan(&n.ID, &n.Version, &n.Address, &n.Healthy, &n.QPS, &n.AnycastAnnounced, &n.ErrorRate, &n.ConfigVersion, &n.ConfigError, &n.StartedAt, &n.LastHeartbeat); errScan != nil {
+			return nil, errScan
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, rows.Err()
+}
+
+// GetNodeConfig returns the desired configuration for a node, or nil if none is set.
+func (r *PostgresRepository) GetNodeConfig(ctx context.Context, nodeID string) (map[string]string, error) {
+	var raw []byte
+	errRow := r.conn().QueryRowContext(ctx, `SELECT config FROM dns_node_config WHERE node_id = $1`, nodeID).Scan(&raw)
+	r.observe(errRow)
+	if errors.Is(errRow, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if errRow != nil {
+		return nil, errRow
+	}
+	var cfg map[string]string
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid node config for %s: %w", nodeID, err)
+	}
+	return cfg, nil
+}
+
+// SetNodeConfig replaces the desired configuration for a node.
+func (r *PostgresRepository) SetNodeConfig(ctx context.Context, nodeID string, cfg map[string]string) error {
+	raw, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO dns_node_config (node_id, config, updated_at) VALUES ($1, $2, NOW())
+		ON CONFLICT (node_id) DO UPDATE SET config = EXCLUDED.config, updated_at = EXCLUDED.updated_at`
+	_, err = r.execContext(ctx, query, nodeID, raw)
+	return err
+}
+
+// SaveRollout inserts or updates a rollout, storing its plan and progress as JSON.
+func (r *PostgresRepository) SaveRollout(ctx context.Context, rollout *domain.Rollout) error {
+	spec, err := json.Marshal(rollout)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO dns_rollouts (id, state, spec, created_at, updated_at) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (id) DO UPDATE SET state = EXCLUDED.state, spec = EXCLUDED.spec, updated_at = EXCLUDED.updated_at`
+	_, err = r.execContext(ctx, query, rollout.ID, string(rollout.State), spec, rollout.CreatedAt, rollout.UpdatedAt)
+	return err
+}
+
+// GetRollout returns a rollout by ID, or nil if it does not exist.
+func (r *PostgresRepository) GetRollout(ctx context.Context, id string) (*domain.Rollout, error) {
+	var raw []byte
+	errRow := r.conn().QueryRowContext(ctx, `SELECT spec FROM dns_rollouts WHERE id = $1`, id).Scan(&raw)
+	r.observe(errRow)
+	if errors.Is(errRow, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if errRow != nil {
+		return nil, errRow
+	}
+	var rollout domain.Rollout
+	if err := json.Unmarshal(raw, &rollout); err != nil {
+		return nil, fmt.Errorf("invalid rollout %s: %w", id, err)
+	}
+	return &rollout, nil
+}
+
+// ListRollouts returns all rollouts, newest first.
+func (r *PostgresRepository) ListRollouts(ctx context.Context) ([]domain.Rollout, error) {
+	rows, err := r.queryContext(ctx, `SELECT spec FROM dns_rollouts ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			log.Printf("failed to close rows: %v", errClose)
+		}
+	}()
+
+	var rollouts []domain.Rollout
+	for rows.Next() {
+		var raw []byte
+		if errScan := rows.Scan(&raw); errScan != nil {
+			return nil, errScan
+		}
+		var rollout domain.Rollout
+		if errJSON := json.Unmarshal(raw, &rollout); errJSON != nil {
+			return nil, errJSON
+		}
+		rollouts = append(rollouts, rollout)
+	}
+	return rollouts, rows.Err()
+}
+
+// AddZoneUsage adds hourly usage counters to the stored totals. Usage for zones
+// deleted since it was counted is dropped.
+func (r *PostgresRepository) AddZoneUsage(ctx context.Context, usage []domain.ZoneUsage) error {
+	if len(usage) == 0 {
+		return nil
+	}
+
+	zoneIDs := make([]string, len(usage))
+	hours := make([]time.Time, len(usage))
+	queries := make([]int64, len(usage))
+	nxdomain := make([]int64, len(usage))
+	transfer := make([]int64, len(usage))
+	for i, u := range usage {
+		zoneIDs[i] = u.ZoneID
+		hours[i] = u.Hour
+		queries[i] = u.Queries
+		nxdomain[i] = u.NXDomain
+		transfer[i] = u.TransferBytes
+	}
+
+	query := `
+		INSERT INTO zone_usage_hourly (zone_id, hour, queries, nxdomain, transfer_bytes)
+		SELECT u.zone_id, u.hour, u.queries, u.nxdomain, u.transfer_bytes
+		FROM UNNEST($1::uuid[], $2::timestamptz[], $3::bigint[], $4::bigint[], $5::bigint[])
+			AS u(zone_id, hour, queries, nxdomain, transfer_bytes)
+		JOIN dns_zones z ON z.id = u.zone_id
+		ON CONFLICT (zone_id, hour) DO UPDATE SET
+			queries = zone_usage_hourly.queries + EXCLUDED.queries,
+			nxdomain = zone_usage_hourly.nxdomain + EXCLUDED.nxdomain,
+			transfer_bytes = zone_usage_hourly.transfer_bytes + EXCLUDED.transfer_bytes`
+	_, err := r.execContext(ctx, query, zoneIDs, hours, queries, nxdomain, transfer)
+	return err
+}
+
+// ListZoneUsage returns hourly usage in [from, to) for a tenant's zones, or for a
+// single zone when zoneID is set, ordered by hour and zone name.
+func (r *PostgresRepository) ListZoneUsage(ctx context.Context, tenantID string, zoneID string, from, to time.Time) ([]domain.ZoneUsage, error) {
+	query := `SELECT u.zone_id, z.name, u.hour, u.queries, u.nxdomain, u.transfer_bytes
+	          FROM zone_usage_hourly u JOIN dns_zones z ON z.id = u.zone_id
+	          WHERE z.tenant_id = $1 AND u.hour >= $2 AND u.hour < $3`
+	args := []interface{}{tenantID, from, to}
+	if zoneID != "" {
+		query += " AND u.zone_id = $4"
+		args = append(args, zoneID)
+	}
+	query += " ORDER BY u.hour, z.name"
+
+	rows, err := r.queryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			log.Printf("failed to close rows: %v", errClose)
+		}
+	}()
+
+	var usage []domain.ZoneUsage
+	for rows.Next() {
+		var u domain.ZoneUsage
+		if errScan := rows.Scan(&u.ZoneID, &u.ZoneName, &u.Hour, &u.Queries, &u.NXDomain, &u.TransferBytes); errScan != nil {
+			return nil, errScan
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
+
+func (r *PostgresRepository) CreateSecondaryProvider(ctx context.Context, p *domain.SecondaryProvider) error {
+	secret, err := r.sealSecret(columnTSIGSecret, p.ID, []byte(p.TSIGSecret))
+	if err != nil {
+		return err
+	}
+	query := `INSERT INTO zone_secondaries (id, zone_id, name, address, tsig_key_name, tsig_secret, created_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err = r.execContext(ctx, query, p.ID, p.ZoneID, p.Name, p.Address, p.TSIGKeyName, string(secret), p.CreatedAt)
+	return err
+}
+
+// ListSecondaryProviders returns the external secondaries of a zone, or of every
+// zone when zoneID is empty, in the order they were added.
+func (r *PostgresRepository) ListSecondaryProviders(ctx context.Context, zoneID string) ([]domain.SecondaryProvider, error) {
+	query := `SELECT id, zone_id, name, address, tsig_key_name, tsig_secret, observed_serial,
+	                 last_notified_at, last_checked_at, last_error, created_at
+	          FROM zone_secondaries`
+	var args []interface{}
+	if zoneID != "" {
+		query += " WHERE zone_id = $1"
+		args = append(args, zoneID)
+	}
+	query += " ORDER BY created_at, id"
+
+	rows, err := r.queryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			log.Printf("failed to close rows: %v", errClose)
+		}
+	}()
+
+	var providers []domain.SecondaryProvider
+	for rows.Next() {
+		var p domain.SecondaryProvider
+		var observed int64
+		var notified, checked sql.NullTime
+		if errScan := rows.Scan(&p.ID, &p.ZoneID, &p.Name, &p.Address, &p.TSIGKeyName, &p.TSIGSecret, &observed,
+			&notified, &checked, &p.LastError, &p.CreatedAt); errScan != nil {
+			return nil, errScan
+		}
+		secret, errOpen := r.openSecret(columnTSIGSecret, p.ID, []byte(p.TSIGSecret))
+		if errOpen != nil {
+			return nil, errOpen
+		}
+		p.TSIGSecret = string(secret)
+		p.ObservedSerial = uint32(observed) // #nosec G115
+		if notified.Valid {
+			p.LastNotifiedAt = &notified.Time
+		}
+		if checked.Valid {
+			p.LastCheckedAt = &checked.Time
+		}
+		providers = append(providers, p)
+	}
+	return providers, rows.Err()
+}
+
+func (r *PostgresRepository) DeleteSecondaryProvider(ctx context.Context, id string, zoneID string) error {
+	query := `DELETE FROM zone_secondaries WHERE id = $1 AND zone_id = $2`
+	_, err := r.execContext(ctx, query, id, zoneID)
+	return err
+}
+
+// UpdateSecondaryProviderStatus stores the latest NOTIFY and SOA check outcome.
+func (r *PostgresRepository) UpdateSecondaryProviderStatus(ctx context.Context, p *domain.SecondaryProvider) error {
+	query := `UPDATE zone_secondaries
+	          SET observed_serial = $2, last_notified_at = $3, last_checked_at = $4, last_error = $5
+	          WHERE id = $1`
+	_, err := r.execContext(ctx, query, p.ID, int64(p.ObservedSerial), p.LastNotifiedAt, p.LastCheckedAt, p.LastError)
+	return err
+}
+
+// SaveZoneRefresh upserts the zone's refresh outcome. A failed attempt carries no
+// SucceededAt and keeps the stored one.
+func (r *PostgresRepository) SaveZoneRefresh(ctx context.Context, refresh *domain.ZoneRefresh) error {
+	query := `INSERT INTO zone_refreshes (zone_id, attempted_at, succeeded_at, serial, error)
+	          VALUES ($1, $2, $3, $4, $5)
+	          ON CONFLICT (zone_id) DO UPDATE SET attempted_at = EXCLUDED.attempted_at,
+	              succeeded_at = COALESCE(EXCLUDED.succeeded_at, zone_refreshes.succeeded_at),
+	              serial = EXCLUDED.serial, error = EXCLUDED.error`
+	_, err := r.execContext(ctx, query, refresh.ZoneID, refresh.AttemptedAt, refresh.SucceededAt, int64(refresh.Serial), refresh.Error)
+	return err
+}
+
+func (r *PostgresRepository) GetZoneRefresh(ctx context.Context, zoneID string) (*domain.ZoneRefresh, error) {
+	refresh := domain.ZoneRefresh{ZoneID: zoneID}
+	var succeeded sql.NullTime
+	var serial int64
+	query := `SELECT attempted_at, succeeded_at, serial, error FROM zone_refreshes WHERE zone_id = $1`
+	err := r.conn().QueryRowContext(ctx, query, zoneID).Scan(&refresh.AttemptedAt, &succeeded, &serial, &refresh.Error)
+	r.observe(err)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	refresh.Serial = uint32(serial) // #nosec G115
+	if succeeded.Valid {
+		refresh.SucceededAt = &succeeded.Time
+	}
+	return &refresh, nil
+}
+
+const scheduledChangeColumns = `id, zone_id, tenant_id, action, record_id, record, run_at, status, error, created_at, executed_at`
+
+func (r *PostgresRepository) CreateScheduledChange(ctx context.Context, c *domain.ScheduledChange) error {
+	var record []byte
+	if c.Record != nil {
+		var err error
+		if record, err = json.Marshal(c.Record); err != nil {
+			return err
+		}
+	}
+	var recordID interface{}
+	if c.RecordID != "" {
+		recordID = c.RecordID
+	}
+	query := `INSERT INTO scheduled_changes (id, zone_id, tenant_id, action, record_id, record, run_at, status, created_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+	_, err := r.execContext(ctx, query, c.ID, c.ZoneID, c.TenantID, c.Action, recordID, record, c.RunAt, c.Status, c.CreatedAt)
+	return err
+}
+
+// ListScheduledChanges returns the scheduled changes of a zone, soonest first.
+func (r *PostgresRepository) ListScheduledChanges(ctx context.Context, zoneID string) ([]domain.ScheduledChange, error) {
+	query := `SELECT ` + scheduledChangeColumns + ` FROM scheduled_changes WHERE zone_id = $1 ORDER BY run_at, created_at`
+	rows, err := r.queryContext(ctx, query, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	return scanScheduledChanges(rows)
+}
+
+// CancelScheduledChange marks a pending change cancelled. It reports false if no
+// pending change with that ID exists in the zone.
+func (r *PostgresRepository) CancelScheduledChange(ctx context.Context, id string, zoneID string) (bool, error) {
+	query := `UPDATE scheduled_changes SET status = 'cancelled' WHERE id = $1 AND zone_id = $2 AND status = 'pending'`
+	res, err := r.execContext(ctx, query, id, zoneID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ClaimDueScheduledChanges marks up to limit pending changes due at now as running
+// and returns them. Rows claimed by another node are skipped, so each change is
+// applied once however many nodes run the scheduler.
+func (r *PostgresRepository) ClaimDueScheduledChanges(ctx context.Context, now time.Time, limit int) ([]domain.ScheduledChange, error) {
+	query := `UPDATE scheduled_changes SET status = 'running'
+	          WHERE id IN (
+	              SELECT id FROM scheduled_changes
+	              WHERE status = 'pending' AND run_at <= $1
+	              ORDER BY run_at
+	              LIMIT $2
+	              FOR UPDATE SKIP LOCKED)
+	          RETURNING ` + scheduledChangeColumns
+	rows, err := r.queryContext(ctx, query, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanScheduledChanges(rows)
+}
+
+// FinishScheduledChange stores the outcome of an applied change.
+func (r *PostgresRepository) FinishScheduledChange(ctx context.Context, c *domain.ScheduledChange) error {
+	query := `UPDATE scheduled_changes SET status = $2, error = $3, executed_at = $4 WHERE id = $1`
+	_, err := r.execContext(ctx, query, c.ID, c.Status, c.Error, c.ExecutedAt)
+	return err
+}
+
+func scanScheduledChanges(rows *sql.Rows) ([]domain.ScheduledChange, error) {
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			log.Printf("failed to close rows: %v", errClose)
+		}
+	}()
+
+	var changes []domain.ScheduledChange
+	for rows.Next() {
+		var c domain.ScheduledChange
+		var recordID sql.NullString
+		var record []byte
+		var executed sql.NullTime
+		if errScan := rows.Scan(&c.ID, &c.ZoneID, &c.TenantID, &c.Action, &recordID, &record, &c.RunAt,
+			&c.Status, &c.Error, &c.CreatedAt, &executed); errScan != nil {
+			return nil, errScan
+		}
+		c.RecordID = recordID.String
+		if len(record) > 0 {
+			c.Record = &domain.Record{}
+			if errJSON := json.Unmarshal(record, c.Record); errJSON != nil {
+				return nil, fmt.Errorf("failed to decode scheduled record %s: %w", c.ID, errJSON)
+			}
+		}
+		if executed.Valid {
+			c.ExecutedAt = &executed.Time
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+const changeFreezeColumns = `id, tenant_id, zone_id, reason, starts_at, ends_at, created_at`
+
+func (r *PostgresRepository) CreateChangeFreeze(ctx context.Context, f *domain.ChangeFreeze) error {
+	var zoneID interface{}
+	if f.ZoneID != "" {
+		zoneID = f.ZoneID
+	}
+	query := `INSERT INTO change_freezes (id, tenant_id, zone_id, reason, starts_at, ends_at, created_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := r.execContext(ctx, query, f.ID, f.TenantID, zoneID, f.Reason, f.StartsAt, f.EndsAt, f.CreatedAt)
+	return err
+}
+
+// ListChangeFreezes returns a tenant's change freezes that have not ended yet,
+// soonest first.
+func (r *PostgresRepository) ListChangeFreezes(ctx context.Context, tenantID string) ([]domain.ChangeFreeze, error) {
+	query := `SELECT ` + changeFreezeColumns + ` FROM change_freezes
+	          WHERE tenant_id = $1 AND ends_at > NOW() ORDER BY starts_at, id`
+	rows, err := r.queryContext(ctx, query, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return scanChangeFreezes(rows)
+}
+
+// DeleteChangeFreeze removes a tenant's change freeze. It reports false if the
+// tenant has no freeze with that ID.
+func (r *PostgresRepository) DeleteChangeFreeze(ctx context.Context, id string, tenantID string) (bool, error) {
+	res, err := r.execContext(ctx, `DELETE FROM change_freezes WHERE id = $1 AND tenant_id = $2`, id, tenantID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetActiveChangeFreeze returns a freeze in force at the given time that covers
+// the tenant's zone, either directly or tenant-wide, or nil if there is none.
+// An empty zoneID only matches tenant-wide freezes.
+func (r *PostgresRepository) GetActiveChangeFreeze(ctx context.Context, tenantID string, zoneID string, at time.Time) (*domain.ChangeFreeze, error) {
+	var zone interface{}
+	if zoneID != "" {
+		zone = zoneID
+	}
+	query := `SELECT ` + changeFreezeColumns + ` FROM change_freezes
+	          WHERE tenant_id = $1 AND (zone_id IS NULL OR zone_id = $2) AND starts_at <= $3 AND ends_at > $3
+	          ORDER BY ends_at DESC LIMIT 1`
+	rows, err := r.queryContext(ctx, query, tenantID, zone, at)
+	if err != nil {
+		return nil, err
+	}
+	freezes, err := scanChangeFreezes(rows)
+	if err != nil || len(freezes) == 0 {
+		return nil, err
+	}
+	return &freezes[0], nil
+}
+
+func scanChangeFreezes(rows *sql.Rows) ([]domain.ChangeFreeze, error) {
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			log.Printf("failed to close rows: %v", errClose)
+		}
+	}()
+
+	var freezes []domain.ChangeFreeze
+	for rows.Next() {
+		var f domain.ChangeFreeze
+		var zoneID sql.NullString
+		if errScan := rows.Scan(&f.ID, &f.TenantID, &zoneID, &f.Reason, &f.StartsAt, &f.EndsAt, &f.CreatedAt); errScan != nil {
+			return nil, errScan
+		}
+		f.ZoneID = zoneID.String
+		freezes = append(freezes, f)
+	}
+	return freezes, rows.Err()
+}
+
+// SetZoneReview puts a zone under review, or takes it out of review.
+func (r *PostgresRepository) SetZoneReview(ctx context.Context, zoneID string, tenantID string, required bool) error {
+	if !required {
+		_, err := r.execContext(ctx, `DELETE FROM zone_review_policies WHERE zone_id = $1`, zoneID)
+		return err
+	}
+	query := `INSERT INTO zone_review_policies (zone_id, tenant_id) VALUES ($1, $2) ON CONFLICT (zone_id) DO NOTHING`
+	_, err := r.execContext(ctx, query, zoneID, tenantID)
+	return err
+}
+
+// IsZoneReviewed reports whether a zone's changes need approval.
+func (r *PostgresRepository) IsZoneReviewed(ctx context.Context, zoneID string) (bool, error) {
+	var reviewed bool
+	errRow := r.conn().QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM zone_review_policies WHERE zone_id = $1)`, zoneID).Scan(&reviewed)
+	r.observe(errRow)
+	return reviewed, errRow
+}
+
+const changeRequestColumns = `id, zone_id, tenant_id, action, record_id, record, rrset, force, status,
+	requested_by, reviewed_by, comment, error, created_at, reviewed_at`
+
+func (r *PostgresRepository) CreateChangeRequest(ctx context.Context, c *domain.ChangeRequest) error {
+	var record, rrset []byte
+	var err error
+	if c.Record != nil {
+		if record, err = json.Marshal(c.Record); err != nil {
+			return err
+		}
+	}
+	if c.RRset != nil {
+		if rrset, err = json.Marshal(c.RRset); err != nil {
+			return err
+		}
+	}
+	var recordID interface{}
+	if c.RecordID != "" {
+		recordID = c.RecordID
+	}
+	query := `INSERT INTO change_requests (id, zone_id, tenant_id, action, record_id, record, rrset, force, status, requested_by, created_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+	_, err = r.execContext(ctx, query, c.ID, c.ZoneID, c.TenantID, c.Action, recordID, record, rrset, c.Force, c.Status, c.RequestedBy, c.CreatedAt)
+	return err
+}
+
+// ListChangeRequests returns the change requests of a zone, newest first.
+func (r *PostgresRepository) ListChangeRequests(ctx context.Context, zoneID string) ([]domain.ChangeRequest, error) {
+	query := `SELECT ` + changeRequestColumns + ` FROM change_requests WHERE zone_id = $1 ORDER BY created_at DESC, id`
+	rows, err := r.queryContext(ctx, query, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	return scanChangeRequests(rows)
+}
+
+// GetChangeRequest returns a change request of a zone, or nil if there is none.
+func (r *PostgresRepository) GetChangeRequest(ctx context.Context, id string, zoneID string) (*domain.ChangeRequest, error) {
+	query := `SELECT ` + changeRequestColumns + ` FROM change_requests WHERE id = $1 AND zone_id = $2`
+	rows, err := r.queryContext(ctx, query, id, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := scanChangeRequests(rows)
+	if err != nil || len(changes) == 0 {
+		return nil, err
+	}
+	return &changes[0], nil
+}
+
+// ReviewChangeRequest stores the decision on a pending change request. It
+// reports false if the request is no longer pending, so two reviewers cannot
+// both decide it.
+func (r *PostgresRepository) ReviewChangeRequest(ctx context.Context, c *domain.ChangeRequest) (bool, error) {
+	query := `UPDATE change_requests SET status = $3, reviewed_by = $4, comment = $5, reviewed_at = $6
+	          WHERE id = $1 AND zone_id = $2 AND status = 'pending'`
+	res, err := r.execContext(ctx, query, c.ID, c.ZoneID, c.Status, c.ReviewedBy, c.Comment, c.ReviewedAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// FinishChangeRequest stores the outcome of an approved change request.
+func (r *PostgresRepository) FinishChangeRequest(ctx context.Context, c *domain.ChangeRequest) error {
+	_, err := r.execContext(ctx, `UPDATE change_requests SET status = $2, error = $3 WHERE id = $1`, c.ID, c.Status, c.Error)
+	return err
+}
+
+func scanChangeRequests(rows *sql.Rows) ([]domain.ChangeRequest, error) {
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			log.Printf("failed to close rows: %v", errClose)
+		}
+	}()
+
+	var changes []domain.ChangeRequest
+	for rows.Next() {
+		var c domain.ChangeRequest
+		var recordID sql.NullString
+		var record, rrset []byte
+		var reviewed sql.NullTime
+		if errScan := rows.Scan(&c.ID, &c.ZoneID, &c.TenantID, &c.Action, &recordID, &record, &rrset, &c.Force, &c.Status,
+			&c.RequestedBy, &c.ReviewedBy, &c.Comment, &c.Error, &c.CreatedAt, &reviewed); errScan != nil {
+			return nil, errScan
+		}
+		c.RecordID = recordID.String
+		if len(record) > 0 {
+			c.Record = &domain.Record{}
+			if errJSON := json.Unmarshal(record, c.Record); errJSON != nil {
+				return nil, fmt.Errorf("failed to decode change request record %s: %w", c.ID, errJSON)
+			}
+		}
+		if len(rrset) > 0 {
+			c.RRset = &domain.RRset{}
+			if errJSON := json.Unmarshal(rrset, c.RRset); errJSON != nil {
+				return nil, fmt.Errorf("failed to decode change request rrset %s: %w", c.ID, errJSON)
+			}
+		}
+		if reviewed.Valid {
+			c.ReviewedAt = &reviewed.Time
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+// SaveShadowChange stages a zone's shadow change, replacing any staged before.
+func (r *PostgresRepository) SaveShadowChange(ctx context.Context, change *domain.ShadowChange) error {
+	spec, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO dns_shadow_changes (zone_id, tenant_id, spec, created_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (zone_id) DO UPDATE SET tenant_id = EXCLUDED.tenant_id, spec = EXCLUDED.spec, created_at = EXCLUDED.created_at`
+	_, err = r.execContext(ctx, query, change.ZoneID, change.TenantID, spec, change.CreatedAt)
+	return err
+}
+
+// GetShadowChange returns the shadow change staged for a zone, or nil if there is none.
+func (r *PostgresRepository) GetShadowChange(ctx context.Context, zoneID string) (*domain.ShadowChange, error) {
+	var tenantID string
+	var raw []byte
+	errRow := r.conn().QueryRowContext(ctx, `SELECT tenant_id, spec FROM dns_shadow_changes WHERE zone_id = $1`, zoneID).Scan(&tenantID, &raw)
+	r.observe(errRow)
+	if errors.Is(errRow, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if errRow != nil {
+		return nil, errRow
+	}
+	var change domain.ShadowChange
+	if err := json.Unmarshal(raw, &change); err != nil {
+		return nil, fmt.Errorf("invalid shadow change for zone %s: %w", zoneID, err)
+	}
+	change.TenantID = tenantID
+	return &change, nil
+}
+
+// decodeGeo sets the geo policy of rec from its JSONB column.
+func decodeGeo(raw []byte, rec *domain.Record) error {
+	if raw == nil {
+		return nil
+	}
+	rec.Geo = &domain.GeoPolicy{}
+	if err := json.Unmarshal(raw, rec.Geo); err != nil {
+		return fmt.Errorf("record %s: bad geo policy: %w", rec.ID, err)
+	}
+	return nil
+}
+
+// encodeGeo is the JSONB column value of a record's geo policy.
+func encodeGeo(geo *domain.GeoPolicy) *string {
+	if geo == nil {
+		return nil
+	}
+	raw, err := json.Marshal(geo)
+	if err != nil {
+		return nil
+	}
+	spec := string(raw)
+	return &spec
+}
+
+// ListRecordGeoScopes returns the distinct locations geo policies name.
+func (r *PostgresRepository) ListRecordGeoScopes(ctx context.Context) ([]string, error) {
+	rows, err := r.queryContext(ctx, `
+		SELECT 'continent:' || jsonb_array_elements_text(geo->'continents') FROM dns_records WHERE geo ? 'continents'
+		UNION SELECT 'country:' || jsonb_array_elements_text(geo->'countries') FROM dns_records WHERE geo ? 'countries'
+		UNION SELECT 'asn:' || jsonb_array_elements_text(geo->'asns') FROM dns_records WHERE geo ? 'asns'
+		ORDER BY 1`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			log.Printf("failed to close rows: %v", errClose)
+		}
+	}()
+
+	var scopes []string
+	for rows.Next() {
+		var scope string
+		if errScan := rows.Scan(&scope); errScan != nil {
+			return nil, errScan
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes, rows.Err()
+}
+
+// ListRecordNetworks returns the distinct networks split-horizon records are
+// scoped to.
+func (r *PostgresRepository) ListRecordNetworks(ctx context.Context) ([]string, error) {
+	rows, err := r.queryContext(ctx, `SELECT DISTINCT network::text FROM dns_records WHERE network IS NOT NULL ORDER BY 1`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			log.Printf("failed to close rows: %v", errClose)
+		}
+	}()
+
+	var networks []string
+	for rows.Next() {
+		var network string
+		if errScan := rows.Scan(&network); errScan != nil {
+			return nil, errScan
+		}
+		networks = append(networks, network)
+	}
+	return networks, rows.Err()
+}
+
+// ListShadowChanges returns the shadow changes staged for every zone.
+func (r *PostgresRepository) ListShadowChanges(ctx context.Context) ([]domain.ShadowChange, error) {
+	rows, err := r.queryContext(ctx, `SELECT tenant_id, spec FROM dns_shadow_changes ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			log.Printf("failed to close rows: %v", errClose)
+		}
+	}()
+
+	var changes []domain.ShadowChange
+	for rows.Next() {
+		var tenantID string
+		var raw []byte
+		if errScan := rows.Scan(&tenantID, &raw); errScan != nil {
+			return nil, errScan
+		}
+		var change domain.ShadowChange
+		if errJSON := json.Unmarshal(raw, &change); errJSON != nil {
+			return nil, errJSON
+		}
+		change.TenantID = tenantID
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
+
+// DeleteShadowChange discards a zone's shadow change and reports whether there was one.
+func (r *PostgresRepository) DeleteShadowChange(ctx context.Context, zoneID string) (bool, error) {
+	res, err := r.execContext(ctx, `DELETE FROM dns_shadow_changes WHERE zone_id = $1`, zoneID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// SaveZoneSLO creates or replaces a zone's SLO.
+func (r *PostgresRepository) SaveZoneSLO(ctx context.Context, slo *domain.ZoneSLO) error {
+	query := `
+		INSERT INTO dns_zone_slos (zone_id, tenant_id, percentile, threshold_ms, window_minutes, updated_at) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (zone_id) DO UPDATE SET tenant_id = EXCLUDED.tenant_id, percentile = EXCLUDED.percentile,
+			threshold_ms = EXCLUDED.threshold_ms, window_minutes = EXCLUDED.window_minutes, updated_at = EXCLUDED.updated_at`
+	_, err := r.execContext(ctx, query, slo.ZoneID, slo.TenantID, slo.Percentile, slo.ThresholdMs, slo.WindowMinutes, slo.UpdatedAt)
+	return err
+}
+
+// GetZoneSLO returns a zone's SLO, or nil if it has none.
+func (r *PostgresRepository) GetZoneSLO(ctx context.Context, zoneID string) (*domain.ZoneSLO, error) {
+	var slo domain.ZoneSLO
+	errRow := r.conn().QueryRowContext(ctx, `SELECT zone_id, tenant_id, percentile, threshold_ms, window_minutes, updated_at FROM dns_zone_slos WHERE zone_id = $1`, zoneID).
+		Scan(&slo.ZoneID, &slo.TenantID, &slo.Percentile, &slo.ThresholdMs, &slo.WindowMinutes, &slo.UpdatedAt)
+	r.observe(errRow)
+	if errors.Is(errRow, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if errRow != nil {
+		return nil, errRow
+	}
+	return &slo, nil
+}
+
+// ListZoneSLOs returns the SLOs of every zone.
+func (r *PostgresRepository) ListZoneSLOs(ctx context.Context) ([]domain.ZoneSLO, error) {
+	rows, err := r.queryContext(ctx, `SELECT zone_id, tenant_id, percentile, threshold_ms, window_minutes, updated_at FROM dns_zone_slos ORDER BY zone_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			log.Printf("failed to close rows: %v", errClose)
+		}
+	}()
+
+	var slos []domain.ZoneSLO
+	for rows.Next() {
+		var slo domain.ZoneSLO
+		if errScan := rows.Scan(&slo.ZoneID, &slo.TenantID, &slo.Percentile, &slo.ThresholdMs, &slo.WindowMinutes, &slo.UpdatedAt); errScan != nil {
+			return nil, errScan
+		}
+		slos = append(slos, slo)
+	}
+	return slos, rows.Err()
+}
+
+// DeleteZoneSLO removes a zone's SLO and reports whether it had one.
+func (r *PostgresRepository) DeleteZoneSLO(ctx context.Context, zoneID string) (bool, error) {
+	res, err := r.execContext(ctx, `DELETE FROM dns_zone_slos WHERE zone_id = $1`, zoneID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// SaveQueryLimit creates or replaces the limit of a tenant or one of its zones.
+func (r *PostgresRepository) SaveQueryLimit(ctx context.Context, limit *domain.QueryLimit) error {
+	query := `
+		INSERT INTO query_limits (tenant_id, zone_id, qps, burst, updated_at) VALUES ($1, NULLIF($2, '')::uuid, $3, $4, $5)
+		ON CONFLICT (tenant_id, (COALESCE(zone_id::text, ''))) DO UPDATE SET qps = EXCLUDED.qps, burst = EXCLUDED.burst, updated_at = EXCLUDED.updated_at`
+	_, err := r.execContext(ctx, query, limit.TenantID, limit.ZoneID, limit.QPS, limit.Burst, limit.UpdatedAt)
+	return err
+}
+
+// ListQueryLimits returns a tenant's query limits, or every tenant's when
+// tenantID is empty.
+func (r *PostgresRepository) ListQueryLimits(ctx context.Context, tenantID string) ([]domain.QueryLimit, error) {
+	rows, err := r.queryContext(ctx, `SELECT tenant_id, COALESCE(zone_id::text, ''), qps, burst, updated_at FROM query_limits
+		WHERE $1 = '' OR tenant_id = $1 ORDER BY tenant_id, zone_id NULLS FIRST`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			log.Printf("failed to close rows: %v", errClose)
+		}
+	}()
+
+	var limits []domain.QueryLimit
+	for rows.Next() {
+		var limit domain.QueryLimit
+		if errScan := rows.Scan(&limit.TenantID, &limit.ZoneID, &limit.QPS, &limit.Burst, &limit.UpdatedAt); errScan != nil {
+			return nil, errScan
+		}
+		limits = append(limits, limit)
+	}
+	return limits, rows.Err()
+}
+
+// DeleteQueryLimit removes the limit of a tenant or one of its zones and
+// reports whether it had one.
+func (r *PostgresRepository) DeleteQueryLimit(ctx context.Context, tenantID string, zoneID string) (bool, error) {
+	res, err := r.execContext(ctx, `DELETE FROM query_limits WHERE tenant_id = $1 AND COALESCE(zone_id::text, '') = $2`, tenantID, zoneID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// SaveView creates or replaces a tenant's view.
+func (r *PostgresRepository) SaveView(ctx context.Context, view *domain.View) error {
+	query := `
+		INSERT INTO dns_views (id, tenant_id, name, networks, priority, created_at, updated_at) VALUES ($1, $2, $3, $4::cidr[], $5, $6, $7)
+		ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, networks = EXCLUDED.networks, priority = EXCLUDED.priority, updated_at = EXCLUDED.updated_at
+		WHERE dns_views.tenant_id = EXCLUDED.tenant_id`
+	_, err := r.execContext(ctx, query, view.ID, view.TenantID, view.Name, view.Networks, view.Priority, view.CreatedAt, view.UpdatedAt)
+	return err
+}
+
+// ListViews returns a tenant's views, or every tenant's when tenantID is empty,
+// in the order clients are matched against them.
+func (r *PostgresRepository) ListViews(ctx context.Context, tenantID string) ([]domain.View, error) {
+	rows, err := r.queryContext(ctx, `SELECT id, tenant_id, name, array_to_string(networks, ','), priority, created_at, updated_at FROM dns_views
+		WHERE $1 = '' OR tenant_id = $1 ORDER BY tenant_id, priority, name`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			log.Printf("failed to close rows: %v", errClose)
+		}
+	}()
+
+	var views []domain.View
+	for rows.Next() {
+		var view domain.View
+		var networks string
+		if errScan := rows.Scan(&view.ID, &view.TenantID, &view.Name, &networks, &view.Priority, &view.CreatedAt, &view.UpdatedAt); errScan != nil {
+			return nil, errScan
+		}
+		view.Networks = strings.Split(networks, ",")
+		views = append(views, view)
+	}
+	return views, rows.Err()
+}
+
+// DeleteView removes a tenant's view with the records scoped to it, and
+// reports whether it existed.
+func (r *PostgresRepository) DeleteView(ctx context.Context, id string, tenantID string) (bool, error) {
+	res, err := r.execContext(ctx, `DELETE FROM dns_views WHERE id = $1 AND tenant_id = $2`, id, tenantID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// SaveTarget creates or replaces a tenant's target.
+func (r *PostgresRepository) SaveTarget(ctx context.Context, target *domain.Target) error {
+	query := `
+		INSERT INTO dns_targets (id, tenant_id, name, addresses, created_at, updated_at) VALUES ($1, $2, $3, $4::inet[], $5, $6)
+		ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, addresses = EXCLUDED.addresses, updated_at = EXCLUDED.updated_at
+		WHERE dns_targets.tenant_id = EXCLUDED.tenant_id`
+	_, err := r.execContext(ctx, query, target.ID, target.TenantID, target.Name, target.Addresses, target.CreatedAt, target.UpdatedAt)
+	return err
+}
+
+// ListTargets returns a tenant's targets, or every tenant's when tenantID is
+// empty, by name.
+func (r *PostgresRepository) ListTargets(ctx context.Context, tenantID string) ([]domain.Target, error) {
+	rows, err := r.queryContext(ctx, `SELECT id, tenant_id, name, array_to_string(addresses, ','), created_at, updated_at FROM dns_targets
+		WHERE $1 = '' OR tenant_id = $1 ORDER BY tenant_id, name`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			log.Printf("failed to close rows: %v", errClose)
+		}
+	}()
+
+	var targets []domain.Target
+	for rows.Next() {
+		var target domain.Target
+		var addresses string
+		if errScan := rows.Scan(&target.ID, &target.TenantID, &target.Name, &addresses, &target.CreatedAt, &target.UpdatedAt); errScan != nil {
+			return nil, errScan
+		}
+		target.Addresses = strings.Split(addresses, ",")
+		targets = append(targets, target)
+	}
+	return targets, rows.Err()
+}
+
+// DeleteTarget removes a tenant's target and reports whether it existed. It
+// fails while records reference the target.
+func (r *PostgresRepository) DeleteTarget(ctx context.Context, id string, tenantID string) (bool, error) {
+	res, err := r.execContext(ctx, `DELETE FROM dns_targets WHERE id = $1 AND tenant_id = $2`, id, tenantID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ListTargetRecords returns the records referencing a target.
+func (r *PostgresRepository) ListTargetRecords(ctx context.Context, targetID string) ([]domain.Record, error) {
+	rows, err := r.queryContext(ctx, `SELECT r.id, r.zone_id, r.name, r.type, r.content, r.ttl, r.priority, r.weight, r.port, r.network, r.view_id, r.geo, r.target_id,
+	                 r.health_check_type, r.health_check_target, r.health_check_id, COALESCE(c.status, h.status, 'UNKNOWN')
+	          FROM dns_records r
+	          LEFT JOIN record_health h ON r.id = h.record_id
+	          LEFT JOIN health_checks c ON c.id = r.health_check_id
+	          WHERE r.target_id = $1
+	          ORDER BY r.name, r.type`, targetID)
+	if err != nil {
+		return nil, err
+	}
+	return scanRecordRows(rows)
 }
 
-func (r *PostgresRepository) ListRecordsForZone(ctx context.Context, zoneID string, tenantID string) ([]domain.Record, error) {
+// SaveHealthCheck creates or replaces a tenant's health check, keeping the
+// status the monitor last recorded.
+func (r *PostgresRepository) SaveHealthCheck(ctx context.Context, check *domain.HealthCheck) error {
 	query := `
-		SELECT r.id, r.zone_id, r.name, r.type, r.content, r.ttl, r.priority, r.weight, r.port, r.network,
-		       r.health_check_type, r.health_check_target, COALESCE(h.status, 'UNKNOWN')
-		FROM dns_records r
-		JOIN dns_zones z ON r.zone_id = z.id
-		LEFT JOIN record_health h ON r.id = h.record_id
-		WHERE r.zone_id = $1 AND z.tenant_id = $2`
-	rows, errQuery := r.db.QueryContext(ctx, query, zoneID, tenantID)
-	if errQuery != nil {
-		return nil, errQuery
+		INSERT INTO health_checks (id, tenant_id, name, protocol, target, path, expected_status, expected_body,
+		                           interval_seconds, timeout_seconds, failure_threshold, success_threshold, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, protocol = EXCLUDED.protocol, target = EXCLUDED.target,
+			path = EXCLUDED.path, expected_status = EXCLUDED.expected_status, expected_body = EXCLUDED.expected_body,
+			interval_seconds = EXCLUDED.interval_seconds, timeout_seconds = EXCLUDED.timeout_seconds,
+			failure_threshold = EXCLUDED.failure_threshold, success_threshold = EXCLUDED.success_threshold,
+			updated_at = EXCLUDED.updated_at
+		WHERE health_checks.tenant_id = EXCLUDED.tenant_id`
+	_, err := r.execContext(ctx, query, check.ID, check.TenantID, check.Name, string(check.Protocol), check.Target, check.Path,
+		check.ExpectedStatus, check.ExpectedBody, check.Interval, check.Timeout, check.FailureThreshold, check.SuccessThreshold,
+		check.CreatedAt, check.UpdatedAt)
+	return err
+}
+
+// ListHealthChecks returns a tenant's health checks, or every tenant's when
+// tenantID is empty, by name.
+func (r *PostgresRepository) ListHealthChecks(ctx context.Context, tenantID string) ([]domain.HealthCheck, error) {
+	rows, err := r.queryContext(ctx, `
+		SELECT id, tenant_id, name, protocol, target, path, expected_status, expected_body, interval_seconds, timeout_seconds,
+		       failure_threshold, success_threshold, status, last_error, status_changed_at, created_at, updated_at
+		FROM health_checks
+		WHERE $1 = '' OR tenant_id = $1 ORDER BY tenant_id, name`, tenantID)
+	if err != nil {
+		return nil, err
 	}
 	defer func() {
 		if errClose := rows.Close(); errClose != nil {
@@ -214,114 +1490,210 @@ func (r *PostgresRepository) ListRecordsForZone(ctx context.Context, zoneID stri
 		}
 	}()
 
-	var records []domain.Record
+	var checks []domain.HealthCheck
 	for rows.Next() {
-		var rec domain.Record
-		var priority, weight, port sql.NullInt32
-		var hcType, hcTarget, hStatus sql.NullString
-		if errScan := rows.Scan(
-			&rec.ID, &rec.ZoneID, &rec.Name, &rec.Type, &rec.Content, &rec.TTL, &priority, &weight, &port, &rec.Network,
-			&hcType, &hcTarget, &hStatus,
-		); errScan != nil {
+		var check domain.HealthCheck
+		var changedAt sql.NullTime
+		if errScan := rows.Scan(&check.ID, &check.TenantID, &check.Name, &check.Protocol, &check.Target, &check.Path,
+			&check.ExpectedStatus, &check.ExpectedBody, &check.Interval, &check.Timeout, &check.FailureThreshold,
+			&check.SuccessThreshold, &check.Status, &check.LastError, &changedAt, &check.CreatedAt, &check.UpdatedAt); errScan != nil {
 			return nil, errScan
 		}
-		if priority.Valid {
-			p := int(priority.Int32)
-			rec.Priority = &p
-		}
-		if weight.Valid {
-			w := int(weight.Int32)
-			rec.Weight = &w
-		}
-		if port.Valid {
-			p := int(port.Int32)
-			rec.Port = &p
-		}
-		if hcType.Valid {
-			rec.HealthCheckType = domain.HealthCheckType(hcType.String)
-		}
-		if hcTarget.Valid {
-			rec.HealthCheckTarget = hcTarget.String
-		}
-		if hStatus.Valid {
-			rec.HealthStatus = domain.HealthStatus(hStatus.String)
+		if changedAt.Valid {
+			check.StatusChangedAt = &changedAt.Time
 		}
-		records = append(records, rec)
+		checks = append(checks, check)
 	}
+	return checks, rows.Err()
+}
 
-	if err := rows.Err(); err != nil {
-		return nil, err
+// DeleteHealthCheck removes a tenant's health check and reports whether it
+// existed. It fails while records reference the check.
+func (r *PostgresRepository) DeleteHealthCheck(ctx context.Context, id string, tenantID string) (bool, error) {
+	res, err := r.execContext(ctx, `DELETE FROM health_checks WHERE id = $1 AND tenant_id = $2`, id, tenantID)
+	if err != nil {
+		return false, err
 	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
 
-	return records, nil
+// UpdateHealthCheckStatus records a health check's new status. A change of
+// status is announced for every record referencing the check.
+func (r *PostgresRepository) UpdateHealthCheckStatus(ctx context.Context, id string, status domain.HealthStatus, errMsg string) error {
+	_, err := r.execContext(ctx, `UPDATE health_checks SET status = $2, last_error = $3, status_changed_at = NOW() WHERE id = $1`,
+		id, string(status), errMsg)
+	return err
 }
 
-func (r *PostgresRepository) CreateZone(ctx context.Context, zone *domain.Zone) error {
-	query := `INSERT INTO dns_zones (id, tenant_id, name, vpc_id, description, role, master_server, created_at, updated_at) 
-			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
-	_, err := r.db.ExecContext(ctx, query, zone.ID, zone.TenantID, zone.Name, zone.VPCID, zone.Description, zone.Role, zone.MasterServer, zone.CreatedAt, zone.UpdatedAt)
+// ListHealthCheckRecords returns the records referencing a health check.
+func (r *PostgresRepository) ListHealthCheckRecords(ctx context.Context, checkID string) ([]domain.Record, error) {
+	rows, err := r.queryContext(ctx, `SELECT r.id, r.zone_id, r.name, r.type, r.content, r.ttl, r.priority, r.weight, r.port, r.network, r.view_id, r.geo, r.target_id,
+	                 r.health_check_type, r.health_check_target, r.health_check_id, COALESCE(c.status, h.status, 'UNKNOWN')
+	          FROM dns_records r
+	          LEFT JOIN record_health h ON r.id = h.record_id
+	          LEFT JOIN health_checks c ON c.id = r.health_check_id
+	          WHERE r.health_check_id = $1
+	          ORDER BY r.name, r.type`, checkID)
+	if err != nil {
+		return nil, err
+	}
+	return scanRecordRows(rows)
+}
+
+// SaveSubnet stores a new subnet of a tenant.
+func (r *PostgresRepository) SaveSubnet(ctx context.Context, subnet *domain.Subnet) error {
+	query := `INSERT INTO ipam_subnets (id, tenant_id, name, cidr, zone_id, name_template, ttl, created_at)
+		VALUES ($1, $2, $3, $4::cidr, $5, $6, $7, $8)`
+	_, err := r.execContext(ctx, query, subnet.ID, subnet.TenantID, subnet.Name, subnet.CIDR, subnet.ZoneID, subnet.NameTemplate, subnet.TTL, subnet.CreatedAt)
 	return err
 }
 
-func (r *PostgresRepository) CreateZoneWithRecords(ctx context.Context, zone *domain.Zone, records []domain.Record) error {
-	tx, errTx := r.db.BeginTx(ctx, nil)
-	if errTx != nil {
-		return errTx
+// ListSubnets returns a tenant's subnets, or every tenant's when tenantID is
+// empty, by name.
+func (r *PostgresRepository) ListSubnets(ctx context.Context, tenantID string) ([]domain.Subnet, error) {
+	rows, err := r.queryContext(ctx, `SELECT id, tenant_id, name, cidr::text, zone_id, name_template, ttl, created_at FROM ipam_subnets
+		WHERE $1 = '' OR tenant_id = $1 ORDER BY tenant_id, name`, tenantID)
+	if err != nil {
+		return nil, err
 	}
 	defer func() {
-		if errRollback := tx.Rollback(); errRollback != nil && !errors.Is(errRollback, sql.ErrTxDone) {
-			log.Printf("failed to rollback transaction: %v", errRollback)
+		if errClose := rows.Close(); errClose != nil {
+			log.Printf("failed to close rows: %v", errClose)
 		}
 	}()
 
-	// 1. Insert Zone
-	zoneQuery := `INSERT INTO dns_zones (id, tenant_id, name, vpc_id, description, role, master_server, created_at, updated_at) 
-			      VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
-	_, errExec := tx.ExecContext(ctx, zoneQuery, zone.ID, zone.TenantID, zone.Name, zone.VPCID, zone.Description, zone.Role, zone.MasterServer, zone.CreatedAt, zone.UpdatedAt)
-	if errExec != nil {
-		return errExec
+	var subnets []domain.Subnet
+	for rows.Next() {
+		var subnet domain.Subnet
+		if errScan := rows.Scan(&subnet.ID, &subnet.TenantID, &subnet.Name, &subnet.CIDR, &subnet.ZoneID, &subnet.NameTemplate, &subnet.TTL, &subnet.CreatedAt); errScan != nil {
+			return nil, errScan
+		}
+		subnets = append(subnets, subnet)
 	}
+	return subnets, rows.Err()
+}
 
-	// 2. Insert Records
-	recordQuery := `INSERT INTO dns_records (id, zone_id, name, type, content, ttl, priority, weight, port, created_at, updated_at) 
-			        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
-	for _, rec := range records {
-		_, errExecRecord := tx.ExecContext(ctx, recordQuery, rec.ID, rec.ZoneID, rec.Name, rec.Type, rec.Content, rec.TTL, rec.Priority, rec.Weight, rec.Port, rec.CreatedAt, rec.UpdatedAt)
-		if errExecRecord != nil {
-			return errExecRecord
-		}
+// DeleteSubnet removes a tenant's subnet, with its allocations, and reports
+// whether it existed.
+func (r *PostgresRepository) DeleteSubnet(ctx context.Context, id string, tenantID string) (bool, error) {
+	res, err := r.execContext(ctx, `DELETE FROM ipam_subnets WHERE id = $1 AND tenant_id = $2`, id, tenantID)
+	if err != nil {
+		return false, err
 	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
 
-	return tx.Commit()
+// SaveIPAllocation stores a new allocation. It fails if the address is
+// already allocated from the subnet.
+func (r *PostgresRepository) SaveIPAllocation(ctx context.Context, alloc *domain.IPAllocation) error {
+	query := `INSERT INTO ipam_allocations (id, subnet_id, address, hostname, name, record_id, ptr_zone_id, ptr_record_id, created_at)
+		VALUES ($1, $2, $3::inet, $4, $5, $6, $7, $8, $9)`
+	_, err := r.execContext(ctx, query, alloc.ID, alloc.SubnetID, alloc.Address, alloc.Hostname, alloc.Name, alloc.RecordID, alloc.PTRZoneID, alloc.PTRRecordID, alloc.CreatedAt)
+	return err
 }
 
-func (r *PostgresRepository) CreateRecord(ctx context.Context, record *domain.Record) error {
-	healthType := record.HealthCheckType
-	if healthType == "" {
-		healthType = domain.HealthCheckNone
+// ListIPAllocations returns the allocations of a subnet by address.
+func (r *PostgresRepository) ListIPAllocations(ctx context.Context, subnetID string) ([]domain.IPAllocation, error) {
+	rows, err := r.queryContext(ctx, `
+		SELECT a.id, a.subnet_id, s.tenant_id, host(a.address), a.hostname, a.name, a.record_id, a.ptr_zone_id, a.ptr_record_id, a.created_at
+		FROM ipam_allocations a
+		JOIN ipam_subnets s ON a.subnet_id = s.id
+		WHERE a.subnet_id = $1
+		ORDER BY a.address`, subnetID)
+	if err != nil {
+		return nil, err
 	}
-	query := `INSERT INTO dns_records (id, zone_id, name, type, content, ttl, priority, weight, port, network, health_check_type, health_check_target, created_at, updated_at) 
-			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`
-	_, err := r.db.ExecContext(ctx, query, record.ID, record.ZoneID, record.Name, record.Type, record.Content, record.TTL, record.Priority, record.Weight, record.Port, record.Network, string(healthType), record.HealthCheckTarget, record.CreatedAt, record.UpdatedAt)
-	return err
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			log.Printf("failed to close rows: %v", errClose)
+		}
+	}()
+
+	var allocs []domain.IPAllocation
+	for rows.Next() {
+		var alloc domain.IPAllocation
+		if errScan := rows.Scan(&alloc.ID, &alloc.SubnetID, &alloc.TenantID, &alloc.Address, &alloc.Hostname, &alloc.Name, &alloc.RecordID,
+			&alloc.PTRZoneID, &alloc.PTRRecordID, &alloc.CreatedAt); errScan != nil {
+			return nil, errScan
+		}
+		allocs = append(allocs, alloc)
+	}
+	return allocs, rows.Err()
 }
 
-func (r *PostgresRepository) UpdateRecordHealth(ctx context.Context, recordID string, status domain.HealthStatus, errMsg string) error {
-	query := `
-		INSERT INTO record_health (record_id, status, last_check, error_message)
-		VALUES ($1, $2, NOW(), $3)
-		ON CONFLICT (record_id) DO UPDATE 
-		SET status = EXCLUDED.status, last_check = EXCLUDED.last_check, error_message = EXCLUDED.error_message`
-	_, err := r.db.ExecContext(ctx, query, recordID, string(status), errMsg)
+// DeleteIPAllocation removes an allocation of a subnet and reports whether it
+// existed.
+func (r *PostgresRepository) DeleteIPAllocation(ctx context.Context, id string, subnetID string) (bool, error) {
+	res, err := r.execContext(ctx, `DELETE FROM ipam_allocations WHERE id = $1 AND subnet_id = $2`, id, subnetID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// SaveDefaultRecord stores a new default record of a zone.
+func (r *PostgresRepository) SaveDefaultRecord(ctx context.Context, rec *domain.DefaultRecord) error {
+	query := `INSERT INTO dns_default_records (id, zone_id, type, content, ttl, priority, min_depth, max_depth, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+	_, err := r.execContext(ctx, query, rec.ID, rec.ZoneID, string(rec.Type), rec.Content, rec.TTL, rec.Priority, rec.MinDepth, rec.MaxDepth, rec.CreatedAt)
 	return err
 }
 
+// ListDefaultRecords returns the default records of a zone, or of every zone
+// when zoneID is empty.
+func (r *PostgresRepository) ListDefaultRecords(ctx context.Context, zoneID string) ([]domain.DefaultRecord, error) {
+	rows, err := r.queryContext(ctx, `
+		SELECT d.id, d.zone_id, z.tenant_id, d.type, d.content, d.ttl, d.priority, d.min_depth, d.max_depth, d.created_at
+		FROM dns_default_records d
+		JOIN dns_zones z ON d.zone_id = z.id
+		WHERE $1 = '' OR d.zone_id::text = $1
+		ORDER BY d.zone_id, d.min_depth, d.created_at`, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			log.Printf("failed to close rows: %v", errClose)
+		}
+	}()
+
+	var records []domain.DefaultRecord
+	for rows.Next() {
+		var rec domain.DefaultRecord
+		var priority sql.NullInt32
+		if errScan := rows.Scan(&rec.ID, &rec.ZoneID, &rec.TenantID, &rec.Type, &rec.Content, &rec.TTL, &priority, &rec.MinDepth, &rec.MaxDepth, &rec.CreatedAt); errScan != nil {
+			return nil, errScan
+		}
+		if priority.Valid {
+			p := int(priority.Int32)
+			rec.Priority = &p
+		}
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
+
+// DeleteDefaultRecord removes a default record of a zone and reports whether
+// it existed.
+func (r *PostgresRepository) DeleteDefaultRecord(ctx context.Context, id string, zoneID string) (bool, error) {
+	res, err := r.execContext(ctx, `DELETE FROM dns_default_records WHERE id = $1 AND zone_id = $2`, id, zoneID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
 func (r *PostgresRepository) GetRecordsToProbe(ctx context.Context) ([]domain.Record, error) {
-	query := `SELECT id, zone_id, name, type, content, ttl, priority, weight, port, network, health_check_type, health_check_target 
-	          FROM dns_records 
-	          WHERE health_check_type IN ('HTTP', 'TCP')
-	          AND health_check_target IS NOT NULL AND health_check_target <> ''`
-	rows, err := r.db.QueryContext(ctx, query)
+	query := `SELECT r.id, r.zone_id, r.name, r.type, r.content, r.ttl, r.priority, r.weight, r.port, r.network, r.health_check_type, r.health_check_target, COALESCE(h.status, 'UNKNOWN')
+	          FROM dns_records r
+	          LEFT JOIN record_health h ON h.record_id = r.id
+	          WHERE r.health_check_type IN ('HTTP', 'TCP')
+	          AND r.health_check_target IS NOT NULL AND r.health_check_target <> ''`
+	rows, err := r.queryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -335,7 +1707,7 @@ func (r *PostgresRepository) GetRecordsToProbe(ctx context.Context) ([]domain.Re
 	for rows.Next() {
 		var rec domain.Record
 		var priority, weight, port sql.NullInt32
-		if errScan := rows.Scan(&rec.ID, &rec.ZoneID, &rec.Name, &rec.Type, &rec.Content, &rec.TTL, &priority, &weight, &port, &rec.Network, &rec.HealthCheckType, &rec.HealthCheckTarget); errScan != nil {
+		if errScan := rows.Scan(&rec.ID, &rec.ZoneID, &rec.Name, &rec.Type, &rec.Content, &rec.TTL, &priority, &weight, &port, &rec.Network, &rec.HealthCheckType, &rec.HealthCheckTarget, &rec.HealthStatus); errScan != nil {
 			return nil, errScan
 		}
 		if priority.Valid {
@@ -365,7 +1737,7 @@ func (r *PostgresRepository) BatchCreateRecords(ctx context.Context, records []d
 		return nil
 	}
 
-	tx, err := r.db.BeginTx(ctx, nil)
+	tx, err := r.beginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -382,6 +1754,13 @@ func (r *PostgresRepository) BatchCreateRecords(ctx context.Context, records []d
 	types := make([]string, len(records))
 	contents := make([]string, len(records))
 	ttls := make([]int, len(records))
+	priorities := make([]*int, len(records))
+	weights := make([]*int, len(records))
+	ports := make([]*int, len(records))
+	networks := make([]*string, len(records))
+	viewIDs := make([]*string, len(records))
+	geos := make([]*string, len(records))
+	targetIDs := make([]*string, len(records))
 	createdAts := make([]time.Time, len(records))
 	updatedAts := make([]time.Time, len(records))
 
@@ -392,32 +1771,51 @@ func (r *PostgresRepository) BatchCreateRecords(ctx context.Context, records []d
 		types[i] = string(rec.Type)
 		contents[i] = rec.Content
 		ttls[i] = rec.TTL
+		priorities[i] = rec.Priority
+		weights[i] = rec.Weight
+		ports[i] = rec.Port
+		networks[i] = rec.Network
+		viewIDs[i] = rec.ViewID
+		geos[i] = encodeGeo(rec.Geo)
+		targetIDs[i] = rec.TargetID
 		createdAts[i] = rec.CreatedAt
 		updatedAts[i] = rec.UpdatedAt
 	}
 
 	query := `
-		INSERT INTO dns_records (id, zone_id, name, type, content, ttl, created_at, updated_at)
-		SELECT * FROM UNNEST($1::uuid[], $2::uuid[], $3::text[], $4::text[], $5::text[], $6::int[], $7::timestamptz[], $8::timestamptz[])
+		INSERT INTO dns_records (id, zone_id, name, type, content, ttl, priority, weight, port, network, view_id, geo, target_id, created_at, updated_at)
+		SELECT * FROM UNNEST($1::uuid[], $2::uuid[], $3::text[], $4::text[], $5::text[], $6::int[], $7::int[], $8::int[], $9::int[], $10::cidr[], $11::uuid[], $12::jsonb[], $13::uuid[], $14::timestamptz[], $15::timestamptz[])
 	`
-	_, err = tx.ExecContext(ctx, query, ids, zoneIDs, names, types, contents, ttls, createdAts, updatedAts)
+	_, err = tx.ExecContext(ctx, query, ids, zoneIDs, names, types, contents, ttls, priorities, weights, ports, networks, viewIDs, geos, targetIDs, createdAts, updatedAts)
 	if err != nil {
+		r.observe(err)
 		return fmt.Errorf("unnest batch insert failed: %w", err)
 	}
 
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	r.invalidateApex(zoneIDs...)
+	return nil
+}
+
+func (r *PostgresRepository) HasZones(ctx context.Context) (bool, error) {
+	var exists bool
+	err := r.conn().QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM dns_zones LIMIT 1)`).Scan(&exists)
+	r.observe(err)
+	return exists, err
 }
 
 func (r *PostgresRepository) ListZones(ctx context.Context, tenantID string) ([]domain.Zone, error) {
-	query := `SELECT id, tenant_id, name, vpc_id, description, role, master_server, created_at, updated_at FROM dns_zones`
+	query := `SELECT id, tenant_id, name, vpc_id, description, role, master_server, dnssec_enabled, created_at, updated_at FROM dns_zones`
 	var rows *sql.Rows
 	var errQuery error
 
 	if tenantID != "" {
 		query += " WHERE tenant_id = $1"
-		rows, errQuery = r.db.QueryContext(ctx, query, tenantID)
+		rows, errQuery = r.queryContext(ctx, query, tenantID)
 	} else {
-		rows, errQuery = r.db.QueryContext(ctx, query)
+		rows, errQuery = r.queryContext(ctx, query)
 	}
 
 	if errQuery != nil {
@@ -433,7 +1831,8 @@ func (r *PostgresRepository) ListZones(ctx context.Context, tenantID string) ([]
 	for rows.Next() {
 		var z domain.Zone
 		var role, masterServer sql.NullString
-		if errScan := rows.Scan(&z.ID, &z.TenantID, &z.Name, &z.VPCID, &z.Description, &role, &masterServer, &z.CreatedAt, &z.UpdatedAt); errScan != nil {
+		var dnssecEnabled bool
+		if errScan := rows.Scan(&z.ID, &z.TenantID, &z.Name, &z.VPCID, &z.Description, &role, &masterServer, &dnssecEnabled, &z.CreatedAt, &z.UpdatedAt); errScan != nil {
 			return nil, errScan
 		}
 		if role.Valid {
@@ -442,6 +1841,7 @@ func (r *PostgresRepository) ListZones(ctx context.Context, tenantID string) ([]
 		if masterServer.Valid {
 			z.MasterServer = masterServer.String
 		}
+		z.DNSSECEnabled = &dnssecEnabled
 		zones = append(zones, z)
 	}
 
@@ -454,7 +1854,22 @@ func (r *PostgresRepository) ListZones(ctx context.Context, tenantID string) ([]
 
 func (r *PostgresRepository) DeleteZone(ctx context.Context, zoneID string, tenantID string) error {
 	query := `DELETE FROM dns_zones WHERE id = $1 AND tenant_id = $2`
-	_, err := r.db.ExecContext(ctx, query, zoneID, tenantID)
+	_, err := r.execContext(ctx, query, zoneID, tenantID)
+	r.invalidateApex(zoneID)
+	return err
+}
+
+// SetZoneDNSSEC opts a tenant's zone in or out of DNSSEC signing.
+func (r *PostgresRepository) SetZoneDNSSEC(ctx context.Context, zoneID string, tenantID string, enabled bool) error {
+	query := `UPDATE dns_zones SET dnssec_enabled = $1, updated_at = $2 WHERE id = $3 AND tenant_id = $4`
+	_, err := r.execContext(ctx, query, enabled, time.Now(), zoneID, tenantID)
+	return err
+}
+
+// SetZoneRole makes a tenant's zone a primary or a secondary of masterServer.
+func (r *PostgresRepository) SetZoneRole(ctx context.Context, zoneID string, tenantID string, role string, masterServer string) error {
+	query := `UPDATE dns_zones SET role = $1, master_server = $2, updated_at = $3 WHERE id = $4 AND tenant_id = $5`
+	_, err := r.execContext(ctx, query, role, masterServer, time.Now(), zoneID, tenantID)
 	return err
 }
 
@@ -464,45 +1879,200 @@ func (r *PostgresRepository) DeleteRecord(ctx context.Context, recordID string,
 		WHERE id = $1 AND zone_id = $2 AND EXISTS (
 			SELECT 1 FROM dns_zones WHERE id = $2 AND tenant_id = $3
 		)`
-	_, err := r.db.ExecContext(ctx, query, recordID, zoneID, tenantID)
+	_, err := r.execContext(ctx, query, recordID, zoneID, tenantID)
+	r.invalidateApex(zoneID)
 	return err
 }
 
 func (r *PostgresRepository) DeleteRecordsByNameAndType(ctx context.Context, zoneID string, name string, qType domain.RecordType) error {
 	query := `DELETE FROM dns_records WHERE zone_id = $1 AND LOWER(name) = LOWER($2) AND type = $3`
-	_, err := r.db.ExecContext(ctx, query, zoneID, name, string(qType))
+	_, err := r.execContext(ctx, query, zoneID, name, string(qType))
+	r.invalidateApex(zoneID)
 	return err
 }
 
 func (r *PostgresRepository) DeleteRecordsByName(ctx context.Context, zoneID string, name string) error {
 	query := `DELETE FROM dns_records WHERE zone_id = $1 AND LOWER(name) = LOWER($2)`
-	_, err := r.db.ExecContext(ctx, query, zoneID, name)
+	_, err := r.execContext(ctx, query, zoneID, name)
+	r.invalidateApex(zoneID)
 	return err
 }
 
 func (r *PostgresRepository) DeleteRecordsForZone(ctx context.Context, zoneID string) error {
 	query := `DELETE FROM dns_records WHERE zone_id = $1`
-	_, err := r.db.ExecContext(ctx, query, zoneID)
+	_, err := r.execContext(ctx, query, zoneID)
+	r.invalidateApex(zoneID)
 	return err
 }
 
+// ReplaceZoneRecords swaps all records of a zone for records in one transaction,
+// so queries never see the zone half-loaded, as when applying a full zone transfer.
+func (r *PostgresRepository) ReplaceZoneRecords(ctx context.Context, zoneID string, records []domain.Record) error {
+	tx, err := r.beginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if errRollback := tx.Rollback(); errRollback != nil && !errors.Is(errRollback, sql.ErrTxDone) {
+			log.Printf("failed to rollback transaction: %v", errRollback)
+		}
+	}()
+	if err := r.replaceRecords(ctx, tx, zoneID, records); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	r.invalidateApex(zoneID)
+	return nil
+}
+
+// ApplyZoneDiff applies the difference sequences of an incremental zone transfer
+// (RFC 1995) to a zone in order, in one transaction. The SOA is replaced rather
+// than matched, so the stored serial becomes that of the last sequence. A
+// deleted record that is not in the zone fails the whole transfer with
+// ErrNotFound, since the zone is then not the version the sequences start from.
+func (r *PostgresRepository) ApplyZoneDiff(ctx context.Context, zoneID string, diffs []domain.IXFRChunk) error {
+	tx, err := r.beginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if errRollback := tx.Rollback(); errRollback != nil && !errors.Is(errRollback, sql.ErrTxDone) {
+			log.Printf("failed to rollback transaction: %v", errRollback)
+		}
+	}()
+
+	for _, diff := range diffs {
+		for _, rec := range diff.Deleted {
+			if rec.Type == domain.TypeSOA {
+				if _, err := tx.ExecContext(ctx, `DELETE FROM dns_records WHERE zone_id = $1 AND type = $2`, zoneID, string(domain.TypeSOA)); err != nil {
+					r.observe(err)
+					return err
+				}
+				continue
+			}
+			res, err := tx.ExecContext(ctx, `DELETE FROM dns_records WHERE zone_id = $1 AND LOWER(name) = LOWER($2) AND type = $3 AND content = $4`,
+				zoneID, rec.Name, string(rec.Type), rec.Content)
+			if err != nil {
+				r.observe(err)
+				return err
+			}
+			if n, err := res.RowsAffected(); err != nil || n == 0 {
+				return fmt.Errorf("serial %d deletes %s %s %s: %w", diff.Serial, rec.Name, rec.Type, rec.Content, domain.ErrNotFound)
+			}
+		}
+		if err := r.insertRecords(ctx, tx, zoneID, diff.Added); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	r.invalidateApex(zoneID)
+	return nil
+}
+
+// replaceRecords deletes the records of a zone and inserts records within tx.
+func (r *PostgresRepository) replaceRecords(ctx context.Context, tx *sql.Tx, zoneID string, records []domain.Record) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM dns_records WHERE zone_id = $1`, zoneID); err != nil {
+		r.observe(err)
+		return err
+	}
+	return r.insertRecords(ctx, tx, zoneID, records)
+}
+
+// insertRecords inserts records into a zone within tx. Records without an ID,
+// such as those received in a zone transfer, get a new one.
+func (r *PostgresRepository) insertRecords(ctx context.Context, tx *sql.Tx, zoneID string, records []domain.Record) error {
+	recordQuery := `INSERT INTO dns_records (id, zone_id, name, type, content, ttl, priority, weight, port, network, view_id, geo, target_id, health_check_type, health_check_target, health_check_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)`
+	now := time.Now()
+	for _, rec := range records {
+		if rec.ID == "" {
+			rec.ID = uuid.New().String()
+		}
+		if rec.CreatedAt.IsZero() {
+			rec.CreatedAt, rec.UpdatedAt = now, now
+		}
+		healthType := rec.HealthCheckType
+		if healthType == "" {
+			healthType = domain.HealthCheckNone
+		}
+		if _, err := tx.ExecContext(ctx, recordQuery, rec.ID, zoneID, rec.Name, rec.Type, rec.Content, rec.TTL, rec.Priority, rec.Weight, rec.Port, rec.Network, rec.ViewID, encodeGeo(rec.Geo), rec.TargetID, string(healthType), rec.HealthCheckTarget, rec.HealthCheckID, rec.CreatedAt, rec.UpdatedAt); err != nil {
+			r.observe(err)
+			return err
+		}
+	}
+	return nil
+}
+
 func (r *PostgresRepository) DeleteRecordSpecific(ctx context.Context, zoneID string, name string, qType domain.RecordType, content string) error {
 	query := `DELETE FROM dns_records WHERE zone_id = $1 AND LOWER(name) = LOWER($2) AND type = $3 AND content = $4`
-	_, err := r.db.ExecContext(ctx, query, zoneID, name, string(qType), content)
+	_, err := r.execContext(ctx, query, zoneID, name, string(qType), content)
+	r.invalidateApex(zoneID)
 	return err
 }
 
+// ReplaceRRset atomically swaps every record of a name and type in a zone for
+// records; an empty slice removes the RRset.
+func (r *PostgresRepository) ReplaceRRset(ctx context.Context, zoneID string, name string, qType domain.RecordType, records []domain.Record) error {
+	tx, errTx := r.beginTx(ctx, nil)
+	if errTx != nil {
+		return errTx
+	}
+	defer func() {
+		if errRollback := tx.Rollback(); errRollback != nil && !errors.Is(errRollback, sql.ErrTxDone) {
+			log.Printf("failed to rollback transaction: %v", errRollback)
+		}
+	}()
+
+	deleteQuery := `DELETE FROM dns_records WHERE zone_id = $1 AND LOWER(name) = LOWER($2) AND type = $3`
+	if _, errExec := tx.ExecContext(ctx, deleteQuery, zoneID, name, string(qType)); errExec != nil {
+		r.observe(errExec)
+		return errExec
+	}
+
+	insertQuery := `INSERT INTO dns_records (id, zone_id, name, type, content, ttl, priority, weight, port, network, view_id, geo, target_id, health_check_type, health_check_target, health_check_id, protected, created_at, updated_at) 
+			        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)`
+	for _, rec := range records {
+		healthType := rec.HealthCheckType
+		if healthType == "" {
+			healthType = domain.HealthCheckNone
+		}
+		if _, errExec := tx.ExecContext(ctx, insertQuery, rec.ID, zoneID, rec.Name, rec.Type, rec.Content, rec.TTL, rec.Priority, rec.Weight, rec.Port, rec.Network, rec.ViewID, encodeGeo(rec.Geo), rec.TargetID, string(healthType), rec.HealthCheckTarget, rec.HealthCheckID, rec.Protected, rec.CreatedAt, rec.UpdatedAt); errExec != nil {
+			r.observe(errExec)
+			return errExec
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	r.invalidateApex(zoneID)
+	return nil
+}
+
 func (r *PostgresRepository) RecordZoneChange(ctx context.Context, change *domain.ZoneChange) error {
 	query := `INSERT INTO dns_zone_changes (id, zone_id, serial, action, name, type, content, ttl, priority, weight, port, created_at) 
 			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
-	_, err := r.db.ExecContext(ctx, query, change.ID, change.ZoneID, change.Serial, change.Action, change.Name, string(change.Type), change.Content, change.TTL, change.Priority, change.Weight, change.Port, change.CreatedAt)
+	_, err := r.execContext(ctx, query, change.ID, change.ZoneID, change.Serial, change.Action, change.Name, string(change.Type), change.Content, change.TTL, change.Priority, change.Weight, change.Port, change.CreatedAt)
 	return err
 }
 
+// serialDistance is the distance from the serial in $2 to a journaled serial in
+// sequence space (RFC 1982): the journal entry is newer when it lies between 1
+// and 2^31-1, which keeps working after the serial wraps past 2^32-1.
+const serialDistance = `((serial - $2::bigint + 4294967296) % 4294967296)`
+
+// ListZoneChanges returns the journaled changes newer than fromSerial, oldest
+// first, comparing serials with RFC 1982 arithmetic.
 func (r *PostgresRepository) ListZoneChanges(ctx context.Context, zoneID string, fromSerial uint32) ([]domain.ZoneChange, error) {
 	query := `SELECT id, zone_id, serial, action, name, type, content, ttl, priority, weight, port, created_at 
-	          FROM dns_zone_changes WHERE zone_id = $1 AND serial > $2 ORDER BY serial ASC, created_at ASC`
-	rows, errQuery := r.db.QueryContext(ctx, query, zoneID, fromSerial)
+	          FROM dns_zone_changes WHERE zone_id = $1 AND ` + serialDistance + ` BETWEEN 1 AND 2147483647
+	          ORDER BY ` + serialDistance + ` ASC, created_at ASC`
+	rows, errQuery := r.queryContext(ctx, query, zoneID, fromSerial)
 	if errQuery != nil {
 		return nil, errQuery
 	}
@@ -541,8 +2111,23 @@ func (r *PostgresRepository) ListZoneChanges(ctx context.Context, zoneID string,
 	return changes, nil
 }
 
+// LastZoneChangeAt returns the time of the zone's newest journal entry.
+func (r *PostgresRepository) LastZoneChangeAt(ctx context.Context, zoneID string) (*time.Time, error) {
+	var last sql.NullTime
+	query := `SELECT MAX(created_at) FROM dns_zone_changes WHERE zone_id = $1`
+	err := r.conn().QueryRowContext(ctx, query, zoneID).Scan(&last)
+	r.observe(err)
+	if err != nil {
+		return nil, err
+	}
+	if !last.Valid {
+		return nil, nil
+	}
+	return &last.Time, nil
+}
+
 func (r *PostgresRepository) GetIXFRChain(ctx context.Context, zoneID string, fromSerial uint32, toSerial uint32) ([]domain.IXFRChunk, error) {
-	if fromSerial >= toSerial {
+	if !serial.Greater(toSerial, fromSerial) {
 		return nil, nil // No changes needed
 	}
 
@@ -556,7 +2141,7 @@ func (r *PostgresRepository) GetIXFRChain(ctx context.Context, zoneID string, fr
 	var serials []uint32
 
 	for _, c := range changes {
-		if c.Serial > toSerial {
+		if serial.Greater(c.Serial, toSerial) {
 			continue
 		}
 		chunk, ok := chunksMap[c.Serial]
@@ -585,7 +2170,7 @@ func (r *PostgresRepository) GetIXFRChain(ctx context.Context, zoneID string, fr
 
 	// Sort serials to ensure order
 	sort.Slice(serials, func(i, j int) bool {
-		return serials[i] < serials[j]
+		return serial.Less(serials[i], serials[j])
 	})
 
 	result := make([]domain.IXFRChunk, 0, len(serials))
@@ -599,13 +2184,13 @@ func (r *PostgresRepository) GetIXFRChain(ctx context.Context, zoneID string, fr
 func (r *PostgresRepository) SaveAuditLog(ctx context.Context, log *domain.AuditLog) error {
 	query := `INSERT INTO audit_logs (id, tenant_id, action, resource_type, resource_id, details, created_at) 
 			  VALUES ($1, $2, $3, $4, $5, $6, $7)`
-	_, err := r.db.ExecContext(ctx, query, log.ID, log.TenantID, log.Action, log.ResourceType, log.ResourceID, log.Details, log.CreatedAt)
+	_, err := r.execContext(ctx, query, log.ID, log.TenantID, log.Action, log.ResourceType, log.ResourceID, log.Details, log.CreatedAt)
 	return err
 }
 
 func (r *PostgresRepository) GetAuditLogs(ctx context.Context, tenantID string) ([]domain.AuditLog, error) {
 	query := `SELECT id, tenant_id, action, resource_type, resource_id, details, created_at FROM audit_logs WHERE tenant_id = $1 ORDER BY created_at DESC`
-	rows, errQuery := r.db.QueryContext(ctx, query, tenantID)
+	rows, errQuery := r.queryContext(ctx, query, tenantID)
 	if errQuery != nil {
 		return nil, errQuery
 	}
@@ -632,19 +2217,25 @@ func (r *PostgresRepository) GetAuditLogs(ctx context.Context, tenantID string)
 }
 
 func (r *PostgresRepository) Ping(ctx context.Context) error {
-	return r.db.PingContext(ctx)
+	err := r.conn().PingContext(ctx)
+	r.observe(err)
+	return err
 }
 
 func (r *PostgresRepository) CreateKey(ctx context.Context, key *domain.DNSSECKey) error {
-	query := `INSERT INTO dnssec_keys (id, zone_id, key_type, algorithm, private_key, public_key, active, created_at, updated_at) 
-			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
-	_, err := r.db.ExecContext(ctx, query, key.ID, key.ZoneID, key.KeyType, key.Algorithm, key.PrivateKey, key.PublicKey, key.Active, key.CreatedAt, key.UpdatedAt)
+	privateKey, err := r.sealSecret(columnPrivateKey, key.ID, key.PrivateKey)
+	if err != nil {
+		return err
+	}
+	query := `INSERT INTO dnssec_keys (id, zone_id, key_type, algorithm, private_key, public_key, active, created_at, updated_at, external) 
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	_, err = r.execContext(ctx, query, key.ID, key.ZoneID, key.KeyType, key.Algorithm, privateKey, key.PublicKey, key.Active, key.CreatedAt, key.UpdatedAt, key.External)
 	return err
 }
 
 func (r *PostgresRepository) ListKeysForZone(ctx context.Context, zoneID string) ([]domain.DNSSECKey, error) {
-	query := `SELECT id, zone_id, key_type, algorithm, private_key, public_key, active, created_at, updated_at FROM dnssec_keys WHERE zone_id = $1`
-	rows, errQuery := r.db.QueryContext(ctx, query, zoneID)
+	query := `SELECT id, zone_id, key_type, algorithm, private_key, public_key, active, created_at, updated_at, external FROM dnssec_keys WHERE zone_id = $1`
+	rows, errQuery := r.queryContext(ctx, query, zoneID)
 	if errQuery != nil {
 		return nil, errQuery
 	}
@@ -657,9 +2248,14 @@ func (r *PostgresRepository) ListKeysForZone(ctx context.Context, zoneID string)
 	var keys []domain.DNSSECKey
 	for rows.Next() {
 		var k domain.DNSSECKey
-		if errScan := rows.Scan(&k.ID, &k.ZoneID, &k.KeyType, &k.Algorithm, &k.PrivateKey, &k.PublicKey, &k.Active, &k.CreatedAt, &k.UpdatedAt); errScan != nil {
+		if errScan := rows.Scan(&k.ID, &k.ZoneID, &k.KeyType, &k.Algorithm, &k.PrivateKey, &k.PublicKey, &k.Active, &k.CreatedAt, &k.UpdatedAt, &k.External); errScan != nil {
 			return nil, errScan
 		}
+		privateKey, errOpen := r.openSecret(columnPrivateKey, k.ID, k.PrivateKey)
+		if errOpen != nil {
+			return nil, errOpen
+		}
+		k.PrivateKey = privateKey
 		keys = append(keys, k)
 	}
 
@@ -672,7 +2268,7 @@ func (r *PostgresRepository) ListKeysForZone(ctx context.Context, zoneID string)
 
 func (r *PostgresRepository) UpdateKey(ctx context.Context, key *domain.DNSSECKey) error {
 	query := `UPDATE dnssec_keys SET active = $1, updated_at = $2 WHERE id = $3`
-	_, err := r.db.ExecContext(ctx, query, key.Active, key.UpdatedAt, key.ID)
+	_, err := r.execContext(ctx, query, key.Active, key.UpdatedAt, key.ID)
 	return err
 }
 
@@ -680,9 +2276,10 @@ func (r *PostgresRepository) GetAPIKeyByHash(ctx context.Context, keyHash string
 	query := `SELECT id, tenant_id, name, key_hash, key_prefix, role, active, created_at, expires_at 
 	          FROM api_keys WHERE key_hash = $1`
 	var k domain.APIKey
-	err := r.db.QueryRowContext(ctx, query, keyHash).Scan(
+	err := r.conn().QueryRowContext(ctx, query, keyHash).Scan(
 		&k.ID, &k.TenantID, &k.Name, &k.KeyHash, &k.KeyPrefix, &k.Role, &k.Active, &k.CreatedAt, &k.ExpiresAt,
 	)
+	r.observe(err)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
 	}
@@ -692,7 +2289,7 @@ func (r *PostgresRepository) GetAPIKeyByHash(ctx context.Context, keyHash string
 func (r *PostgresRepository) CreateAPIKey(ctx context.Context, key *domain.APIKey) error {
 	query := `INSERT INTO api_keys (id, tenant_id, name, key_hash, key_prefix, role, active, created_at, expires_at) 
 	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := r.execContext(ctx, query,
 		key.ID, key.TenantID, key.Name, key.KeyHash, key.KeyPrefix, key.Role, key.Active, key.CreatedAt, key.ExpiresAt,
 	)
 	return err
@@ -701,7 +2298,7 @@ func (r *PostgresRepository) CreateAPIKey(ctx context.Context, key *domain.APIKe
 func (r *PostgresRepository) ListAPIKeys(ctx context.Context, tenantID string) ([]domain.APIKey, error) {
 	query := `SELECT id, tenant_id, name, key_hash, key_prefix, role, active, created_at, expires_at 
 	          FROM api_keys WHERE tenant_id = $1`
-	rows, errQuery := r.db.QueryContext(ctx, query, tenantID)
+	rows, errQuery := r.queryContext(ctx, query, tenantID)
 	if errQuery != nil {
 		return nil, errQuery
 	}
@@ -727,7 +2324,7 @@ func (r *PostgresRepository) ListAPIKeys(ctx context.Context, tenantID string) (
 
 func (r *PostgresRepository) DeleteAPIKey(ctx context.Context, tenantID string, id string) error {
 	query := `DELETE FROM api_keys WHERE tenant_id = $1 AND id = $2`
-	_, err := r.db.ExecContext(ctx, query, tenantID, id)
+	_, err := r.execContext(ctx, query, tenantID, id)
 	return err
 }
 
@@ -760,6 +2357,34 @@ func ConvertPacketRecordToDomain(pRec packet.DNSRecord, zoneID string) (domain.R
 		rec.Weight = &w
 		rec.Port = &port
 		rec.Content = pRec.Host
+	case packet.SVCB, packet.HTTPS:
+		rec.Type = domain.RecordType(pRec.Type.String())
+		p := int(pRec.Priority)
+		rec.Priority = &p
+		rec.Content = strings.TrimSpace(dnsname.Fqdn(pRec.Host) + " " + svcb.Format(pRec.SvcParams))
+	case packet.CAA:
+		rec.Type = domain.TypeCAA
+		rec.Content = fmt.Sprintf(`%d %s "%s"`, pRec.Flags, pRec.Tag, strings.ReplaceAll(pRec.Value, `"`, `\"`))
+	case packet.NAPTR:
+		rec.Type = domain.TypeNAPTR
+		rec.Content = domain.NAPTRData{Order: pRec.Order, Preference: pRec.Preference, Flags: pRec.NAPTRFlags,
+			Services: pRec.Services, Regexp: pRec.Regexp, Replacement: pRec.Host}.String()
+	case packet.TLSA:
+		rec.Type = domain.TypeTLSA
+		rec.Content = domain.TLSAData{Usage: pRec.Usage, Selector: pRec.Selector, MatchingType: pRec.MatchingType, Data: pRec.CertData}.String()
+	case packet.SSHFP:
+		rec.Type = domain.TypeSSHFP
+		rec.Content = domain.SSHFPData{Algorithm: pRec.Algorithm, Type: pRec.DigestType, Fingerprint: pRec.Digest}.String()
+	case packet.LOC:
+		rec.Type = domain.TypeLOC
+		rec.Content = domain.LOCData{Version: pRec.LocVersion, Size: pRec.Size, HorizPre: pRec.HorizPre, VertPre: pRec.VertPre,
+			Latitude: pRec.Latitude, Longitude: pRec.Longitude, Altitude: pRec.Altitude}.String()
+	case packet.URI:
+		rec.Type = domain.TypeURI
+		rec.Content = domain.URIData{Priority: pRec.Priority, Weight: pRec.Weight, Target: pRec.Value}.String()
+	case packet.CERT:
+		rec.Type = domain.TypeCERT
+		rec.Content = domain.CERTData{Type: pRec.CertType, KeyTag: pRec.KeyTag, Algorithm: pRec.Algorithm, Certificate: pRec.CertData}.String()
 	case packet.TXT:
 		rec.Type = domain.TypeTXT
 		rec.Content = pRec.Txt
@@ -767,13 +2392,13 @@ func ConvertPacketRecordToDomain(pRec packet.DNSRecord, zoneID string) (domain.R
 		rec.Type = domain.TypeSOA
 		rec.Content = fmt.Sprintf("%s %s %d %d %d %d %d",
 			pRec.MName, pRec.RName, pRec.Serial, pRec.Refresh, pRec.Retry, pRec.Expire, pRec.Minimum)
-	case packet.DNSKEY:
-		rec.Type = domain.RecordType("DNSKEY")
+	case packet.DNSKEY, packet.CDNSKEY:
+		rec.Type = domain.RecordType(pRec.Type.String())
 		// "flags protocol algorithm public_key_base64"
 		rec.Content = fmt.Sprintf("%d %d %d %s",
 			pRec.Flags, 3, pRec.Algorithm, base64.StdEncoding.EncodeToString(pRec.PublicKey))
-	case packet.DS:
-		rec.Type = domain.RecordType("DS")
+	case packet.DS, packet.CDS:
+		rec.Type = domain.RecordType(pRec.Type.String())
 		// "key_tag algorithm digest_type digest_hex"
 		rec.Content = fmt.Sprintf("%d %d %d %s",
 			pRec.KeyTag, pRec.Algorithm, pRec.DigestType, hex.EncodeToString(pRec.Digest))
@@ -815,10 +2440,7 @@ func ConvertPacketRecordToDomain(pRec packet.DNSRecord, zoneID string) (domain.R
 
 // ConvertDomainToPacketRecord is a helper to bridge domain model and wire format
 func ConvertDomainToPacketRecord(rec domain.Record) (packet.DNSRecord, error) {
-	name := rec.Name
-	if !strings.HasSuffix(name, ".") {
-		name += "."
-	}
+	name := dnsname.Fqdn(rec.Name)
 
 	ttl := uint32(0)
 	if rec.TTL > 0 {
@@ -843,15 +2465,11 @@ func ConvertDomainToPacketRecord(rec domain.Record) (packet.DNSRecord, error) {
 	case domain.TypeCNAME:
 		pRec.Type = packet.CNAME
 		pRec.Host = rec.Content
-		if !strings.HasSuffix(pRec.Host, ".") {
-			pRec.Host += "."
-		}
+		pRec.Host = dnsname.Fqdn(pRec.Host)
 	case domain.TypeNS:
 		pRec.Type = packet.NS
 		pRec.Host = rec.Content
-		if !strings.HasSuffix(pRec.Host, ".") {
-			pRec.Host += "."
-		}
+		pRec.Host = dnsname.Fqdn(pRec.Host)
 	case domain.TypeMX:
 		pRec.Type = packet.MX
 		if rec.Priority != nil {
@@ -866,18 +2484,14 @@ func ConvertDomainToPacketRecord(rec domain.Record) (packet.DNSRecord, error) {
 			pRec.Priority = prio
 		}
 		pRec.Host = rec.Content
-		if !strings.HasSuffix(pRec.Host, ".") {
-			pRec.Host += "."
-		}
+		pRec.Host = dnsname.Fqdn(pRec.Host)
 	case domain.TypeTXT:
 		pRec.Type = packet.TXT
 		pRec.Txt = rec.Content
 	case domain.TypePTR:
 		pRec.Type = packet.PTR
 		pRec.Host = rec.Content
-		if !strings.HasSuffix(pRec.Host, ".") {
-			pRec.Host += "."
-		}
+		pRec.Host = dnsname.Fqdn(pRec.Host)
 	case domain.TypeSRV:
 		pRec.Type = packet.SRV
 		if rec.Priority != nil {
@@ -911,25 +2525,105 @@ func ConvertDomainToPacketRecord(rec domain.Record) (packet.DNSRecord, error) {
 			pRec.Port = uint16(p) // #nosec G115
 		}
 		pRec.Host = rec.Content
-		if !strings.HasSuffix(pRec.Host, ".") {
-			pRec.Host += "."
+		pRec.Host = dnsname.Fqdn(pRec.Host)
+	case domain.TypeSVCB, domain.TypeHTTPS:
+		pRec.Type = packet.RecordTypeToQueryType(rec.Type)
+		// Content: "target [svcparams...]", or "priority target [svcparams...]"
+		// as in zone files when the priority field is unset
+		parts := strings.Fields(rec.Content)
+		prio := 0
+		if rec.Priority != nil {
+			prio = *rec.Priority
+		} else if len(parts) > 0 {
+			p, err := strconv.Atoi(parts[0])
+			if err != nil {
+				return pRec, fmt.Errorf("failed to parse %s priority: %w", rec.Type, err)
+			}
+			prio = p
+			parts = parts[1:]
 		}
+		if prio < 0 || prio > 65535 {
+			return pRec, fmt.Errorf("invalid %s priority: %d", rec.Type, prio)
+		}
+		pRec.Priority = uint16(prio) // #nosec G115
+		if len(parts) == 0 {
+			return pRec, fmt.Errorf("%s record has no target", rec.Type)
+		}
+		pRec.Host = dnsname.Fqdn(parts[0])
+		params, err := svcb.Parse(parts[1:])
+		if err != nil {
+			return pRec, fmt.Errorf("failed to parse %s SvcParams: %w", rec.Type, err)
+		}
+		pRec.SvcParams = params
+	case domain.TypeCAA:
+		pRec.Type = packet.CAA
+		// Content: `flags tag "value"`
+		flags, tag, value, err := domain.ParseCAAContent(rec.Content)
+		if err != nil {
+			return pRec, fmt.Errorf("failed to parse CAA record: %w", err)
+		}
+		pRec.Flags = uint16(flags)
+		pRec.Tag = tag
+		pRec.Value = value
+	case domain.TypeNAPTR:
+		pRec.Type = packet.NAPTR
+		d, err := domain.ParseNAPTRContent(rec.Content)
+		if err != nil {
+			return pRec, fmt.Errorf("failed to parse NAPTR record: %w", err)
+		}
+		pRec.Order, pRec.Preference = d.Order, d.Preference
+		pRec.NAPTRFlags, pRec.Services, pRec.Regexp = d.Flags, d.Services, d.Regexp
+		pRec.Host = d.Replacement
+	case domain.TypeTLSA:
+		pRec.Type = packet.TLSA
+		d, err := domain.ParseTLSAContent(rec.Content)
+		if err != nil {
+			return pRec, fmt.Errorf("failed to parse TLSA record: %w", err)
+		}
+		pRec.Usage, pRec.Selector, pRec.MatchingType, pRec.CertData = d.Usage, d.Selector, d.MatchingType, d.Data
+	case domain.TypeSSHFP:
+		pRec.Type = packet.SSHFP
+		d, err := domain.ParseSSHFPContent(rec.Content)
+		if err != nil {
+			return pRec, fmt.Errorf("failed to parse SSHFP record: %w", err)
+		}
+		pRec.Algorithm, pRec.DigestType, pRec.Digest = d.Algorithm, d.Type, d.Fingerprint
+	case domain.TypeLOC:
+		pRec.Type = packet.LOC
+		d, err := domain.ParseLOCContent(rec.Content)
+		if err != nil {
+			return pRec, fmt.Errorf("failed to parse LOC record: %w", err)
+		}
+		pRec.LocVersion, pRec.Size, pRec.HorizPre, pRec.VertPre = d.Version, d.Size, d.HorizPre, d.VertPre
+		pRec.Latitude, pRec.Longitude, pRec.Altitude = d.Latitude, d.Longitude, d.Altitude
+	case domain.TypeURI:
+		pRec.Type = packet.URI
+		d, err := domain.ParseURIContent(rec.Content)
+		if err != nil {
+			return pRec, fmt.Errorf("failed to parse URI record: %w", err)
+		}
+		pRec.Priority, pRec.Weight, pRec.Value = d.Priority, d.Weight, d.Target
+	case domain.TypeCERT:
+		pRec.Type = packet.CERT
+		d, err := domain.ParseCERTContent(rec.Content)
+		if err != nil {
+			return pRec, fmt.Errorf("failed to parse CERT record: %w", err)
+		}
+		pRec.CertType, pRec.KeyTag, pRec.Algorithm, pRec.CertData = d.Type, d.KeyTag, d.Algorithm, d.Certificate
 	case domain.TypeSOA:
 		pRec.Type = packet.SOA
 		// SOA content: "mname rname serial refresh retry expire minimum"
 		parts := strings.Fields(rec.Content)
 		if len(parts) >= 7 {
 			pRec.MName = parts[0]
-			if !strings.HasSuffix(pRec.MName, ".") {
-				pRec.MName += "."
-			}
+			pRec.MName = dnsname.Fqdn(pRec.MName)
 			pRec.RName = parts[1]
-			if !strings.HasSuffix(pRec.RName, ".") {
-				pRec.RName += "."
-			}
-			if _, err := fmt.Sscanf(parts[2], "%d", &pRec.Serial); err != nil {
+			pRec.RName = dnsname.Fqdn(pRec.RName)
+			s, err := serial.Parse(parts[2])
+			if err != nil {
 				return pRec, fmt.Errorf("failed to parse SOA serial: %w", err)
 			}
+			pRec.Serial = s
 			if _, err := fmt.Sscanf(parts[3], "%d", &pRec.Refresh); err != nil {
 				return pRec, fmt.Errorf("failed to parse SOA refresh: %w", err)
 			}
@@ -943,8 +2637,11 @@ func ConvertDomainToPacketRecord(rec domain.Record) (packet.DNSRecord, error) {
 				return pRec, fmt.Errorf("failed to parse SOA minimum: %w", err)
 			}
 		}
-	case "DNSKEY":
+	case "DNSKEY", "CDNSKEY":
 		pRec.Type = packet.DNSKEY
+		if rec.Type == "CDNSKEY" {
+			pRec.Type = packet.CDNSKEY
+		}
 		// Content: "flags protocol algorithm public_key_base64"
 		parts := strings.Fields(rec.Content)
 		if len(parts) >= 4 {
@@ -966,8 +2663,11 @@ func ConvertDomainToPacketRecord(rec domain.Record) (packet.DNSRecord, error) {
 				pRec.PublicKey = keyData
 			}
 		}
-	case "DS":
+	case "DS", "CDS":
 		pRec.Type = packet.DS
+		if rec.Type == "CDS" {
+			pRec.Type = packet.CDS
+		}
 		// Content: "key_tag algorithm digest_type digest_hex"
 		parts := strings.Fields(rec.Content)
 		if len(parts) >= 4 {
@@ -1028,9 +2728,7 @@ func ConvertDomainToPacketRecord(rec domain.Record) (packet.DNSRecord, error) {
 			pRec.Inception = inc
 			pRec.KeyTag = keyTag
 			pRec.SignerName = parts[7]
-			if !strings.HasSuffix(pRec.SignerName, ".") {
-				pRec.SignerName += "."
-			}
+			pRec.SignerName = dnsname.Fqdn(pRec.SignerName)
 
 			sig, err := base64.StdEncoding.DecodeString(parts[8])
 			if err == nil {
@@ -1043,9 +2741,7 @@ func ConvertDomainToPacketRecord(rec domain.Record) (packet.DNSRecord, error) {
 		parts := strings.Fields(rec.Content)
 		if len(parts) >= 1 {
 			pRec.NextName = parts[0]
-			if !strings.HasSuffix(pRec.NextName, ".") {
-				pRec.NextName += "."
-			}
+			pRec.NextName = dnsname.Fqdn(pRec.NextName)
 			if len(parts) > 1 {
 				bitmapHex := strings.Join(parts[1:], "")
 				bitmap, err := hex.DecodeString(bitmapHex)
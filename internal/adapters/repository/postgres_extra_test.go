@@ -2,10 +2,14 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"database/sql/driver"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
 	"github.com/poyrazK/cloudDNS/internal/dns/packet"
 )
 
@@ -15,6 +19,182 @@ func TestPostgresRepository_BatchCreateRecords(t *testing.T) {
 	if err != nil {
 		t.Errorf("Expected nil error for empty batch, got %v", err)
 	}
+
+	sqlDB, mock, errMock := sqlmock.New(sqlmock.ValueConverterOption(arrayConverter{}))
+	if errMock != nil {
+		t.Fatalf("failed to create sqlmock: %v", errMock)
+	}
+	defer func() { _ = sqlDB.Close() }()
+	repo := NewPostgresRepository(sqlDB)
+
+	now := time.Now()
+	priority, network, viewID, geo := 10, "10.0.0.0/8", "v1", `{"countries":["DE"]}`
+	records := []domain.Record{
+		{ID: "r1", ZoneID: "z1", Name: "example.com.", Type: domain.TypeMX, Content: "mail.example.com.", TTL: 300, Priority: &priority, CreatedAt: now, UpdatedAt: now},
+		{ID: "r2", ZoneID: "z1", Name: "www.example.com.", Type: domain.TypeA, Content: "10.0.0.1", TTL: 300, Network: &network, ViewID: &viewID, Geo: &domain.GeoPolicy{Countries: []string{"DE"}}, CreatedAt: now, UpdatedAt: now},
+	}
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO dns_records \(id, zone_id, name, type, content, ttl, priority, weight, port, network, view_id, geo, target_id, created_at, updated_at\)\s+SELECT \* FROM UNNEST`).
+		WithArgs([]string{"r1", "r2"}, []string{"z1", "z1"}, []string{"example.com.", "www.example.com."}, []string{"MX", "A"},
+			[]string{"mail.example.com.", "10.0.0.1"}, []int{300, 300}, []*int{&priority, nil}, []*int{nil, nil}, []*int{nil, nil},
+			[]*string{nil, &network}, []*string{nil, &viewID}, []*string{nil, &geo}, []*string{nil, nil}, []time.Time{now, now}, []time.Time{now, now}).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	if err := repo.BatchCreateRecords(context.Background(), records); err != nil {
+		t.Errorf("BatchCreateRecords failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+// arrayConverter passes slice arguments through to sqlmock, as pgx encodes them as
+// Postgres arrays.
+type arrayConverter struct{}
+
+func (arrayConverter) ConvertValue(v interface{}) (driver.Value, error) {
+	if driver.IsValue(v) {
+		return v, nil
+	}
+	switch v.(type) {
+	case []string, []time.Time, []int64, []int, []*int, []*string:
+		return v, nil
+	}
+	return driver.DefaultParameterConverter.ConvertValue(v)
+}
+
+func TestPostgresRepository_AddZoneUsage(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.ValueConverterOption(arrayConverter{}))
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	repo := NewPostgresRepository(db)
+	ctx := context.Background()
+	hour := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec(`INSERT INTO zone_usage_hourly .* FROM UNNEST.* JOIN dns_zones z ON z.id = u.zone_id\s+ON CONFLICT \(zone_id, hour\) DO UPDATE SET\s+queries = zone_usage_hourly.queries \+ EXCLUDED.queries`).
+		WithArgs([]string{"z1"}, []time.Time{hour}, []int64{5}, []int64{1}, []int64{0}).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := repo.AddZoneUsage(ctx, []domain.ZoneUsage{{ZoneID: "z1", Hour: hour, Queries: 5, NXDomain: 1}}); err != nil {
+		t.Errorf("AddZoneUsage failed: %v", err)
+	}
+	if err := repo.AddZoneUsage(ctx, nil); err != nil {
+		t.Errorf("Expected empty batch to be a no-op, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresRepository_SecondaryProviders(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	repo := NewPostgresRepository(db)
+	ctx := context.Background()
+	now := time.Now()
+
+	rows := sqlmock.NewRows([]string{"id", "zone_id", "name", "address", "tsig_key_name", "tsig_secret", "observed_serial", "last_notified_at", "last_checked_at", "last_error", "created_at"}).
+		AddRow("s1", "z1", "ext", "192.0.2.1:53", "xfr.", "c2VjcmV0", int64(7), now, nil, "", now)
+	mock.ExpectQuery(`SELECT .* FROM zone_secondaries WHERE zone_id = \$1 ORDER BY created_at, id`).
+		WithArgs("z1").WillReturnRows(rows)
+
+	providers, err := repo.ListSecondaryProviders(ctx, "z1")
+	if err != nil {
+		t.Fatalf("ListSecondaryProviders failed: %v", err)
+	}
+	if len(providers) != 1 || providers[0].ObservedSerial != 7 || providers[0].LastNotifiedAt == nil || providers[0].LastCheckedAt != nil {
+		t.Errorf("unexpected providers: %+v", providers)
+	}
+
+	mock.ExpectExec(`UPDATE zone_secondaries\s+SET observed_serial = \$2`).
+		WithArgs("s1", int64(8), nil, &now, "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := repo.UpdateSecondaryProviderStatus(ctx, &domain.SecondaryProvider{ID: "s1", ObservedSerial: 8, LastCheckedAt: &now}); err != nil {
+		t.Errorf("UpdateSecondaryProviderStatus failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresRepository_GetIXFRChain_SerialWrap(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	repo := NewPostgresRepository(db)
+	now := time.Now()
+
+	// The journal crosses the wrap from 4294967295 to 0; rows come back in
+	// sequence-space order from the query.
+	rows := sqlmock.NewRows([]string{"id", "zone_id", "serial", "action", "name", "type", "content", "ttl", "priority", "weight", "port", "created_at"}).
+		AddRow("c1", "z1", int64(4294967295), "ADD", "a.test.", "A", "192.0.2.1", 60, nil, nil, nil, now).
+		AddRow("c2", "z1", int64(0), "ADD", "b.test.", "A", "192.0.2.2", 60, nil, nil, nil, now).
+		AddRow("c3", "z1", int64(1), "ADD", "c.test.", "A", "192.0.2.3", 60, nil, nil, nil, now)
+	mock.ExpectQuery(`FROM dns_zone_changes WHERE zone_id = \$1 AND \(\(serial - \$2::bigint \+ 4294967296\) % 4294967296\) BETWEEN 1 AND 2147483647`).
+		WithArgs("z1", uint32(4294967294)).WillReturnRows(rows)
+
+	chunks, err := repo.GetIXFRChain(context.Background(), "z1", 4294967294, 0)
+	if err != nil {
+		t.Fatalf("GetIXFRChain failed: %v", err)
+	}
+	if len(chunks) != 2 || chunks[0].Serial != 4294967295 || chunks[1].Serial != 0 {
+		t.Errorf("Expected chunks 4294967295 then 0 (1 is past the target), got %+v", chunks)
+	}
+
+	// A target that is not newer than the start needs no chain
+	if chunks, err := repo.GetIXFRChain(context.Background(), "z1", 0, 4294967295); err != nil || chunks != nil {
+		t.Errorf("Expected no chain back across the wrap, got %+v, %v", chunks, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresRepository_ReplaceRRset(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	repo := NewPostgresRepository(db)
+	ctx := context.Background()
+	records := []domain.Record{
+		{ID: "r1", Name: "www.example.com.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 300},
+		{ID: "r2", Name: "www.example.com.", Type: domain.TypeA, Content: "192.0.2.2", TTL: 300},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM dns_records WHERE zone_id = \$1 AND LOWER\(name\) = LOWER\(\$2\) AND type = \$3`).
+		WithArgs("z1", "www.example.com.", "A").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(`INSERT INTO dns_records`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO dns_records`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	if err := repo.ReplaceRRset(ctx, "z1", "www.example.com.", domain.TypeA, records); err != nil {
+		t.Errorf("ReplaceRRset failed: %v", err)
+	}
+
+	// A failed insert rolls back the delete
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM dns_records`).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`INSERT INTO dns_records`).WillReturnError(sql.ErrConnDone)
+	mock.ExpectRollback()
+	if err := repo.ReplaceRRset(ctx, "z1", "www.example.com.", domain.TypeA, records); err == nil {
+		t.Error("Expected error from failed insert")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
 }
 
 func TestPostgresRepository_GetRecord_Mock(t *testing.T) {
@@ -30,8 +210,8 @@ func TestPostgresRepository_GetRecord_Mock(t *testing.T) {
 	zoneID := uuid.New().String()
 
 	// 1. Success case
-	rows := sqlmock.NewRows([]string{"id", "zone_id", "name", "type", "content", "ttl", "priority", "weight", "port", "network", "health_check_type", "health_check_target", "status"}).
-		AddRow(id, zoneID, "test.com.", "A", "1.1.1.1", 300, nil, nil, nil, nil, "NONE", nil, "UNKNOWN")
+	rows := sqlmock.NewRows([]string{"id", "zone_id", "name", "type", "content", "ttl", "priority", "weight", "port", "network", "view_id", "geo", "target_id", "health_check_type", "health_check_target", "health_check_id", "status", "protected"}).
+		AddRow(id, zoneID, "test.com.", "A", "1.1.1.1", 300, nil, nil, nil, nil, nil, nil, nil, "NONE", nil, nil, "UNKNOWN", true)
 	mock.ExpectQuery("SELECT .* FROM dns_records").WithArgs(id, zoneID, "").WillReturnRows(rows)
 
 	rec, err := repo.GetRecord(ctx, id, zoneID, "")
@@ -41,6 +221,9 @@ func TestPostgresRepository_GetRecord_Mock(t *testing.T) {
 	if rec.Name != "test.com." {
 		t.Errorf("got %s, want test.com.", rec.Name)
 	}
+	if !rec.Protected {
+		t.Error("Expected the record to be protected")
+	}
 
 	// 2. Not found
 	mock.ExpectQuery("SELECT .* FROM dns_records").WithArgs("none", zoneID, "").WillReturnRows(sqlmock.NewRows(nil))
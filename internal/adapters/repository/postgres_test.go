@@ -357,3 +357,53 @@ func TestPostgresRepositoryEdgeCases(t *testing.T) {
 		t.Errorf("Expected records for empty type query")
 	}
 }
+
+func TestPostgresRepositoryListenDataChanges(t *testing.T) {
+	if testing.Short() || !dockerAvailable() {
+		t.Skip("skipping integration test")
+	}
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	repo := NewPostgresRepository(db)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan domain.DataChange, 16)
+	done := make(chan error, 1)
+	go func() {
+		done <- repo.ListenDataChanges(ctx, func(c domain.DataChange) { changes <- c })
+	}()
+	// Give the listener time to subscribe
+	time.Sleep(200 * time.Millisecond)
+
+	zID := "550e8400-e29b-41d4-a716-446655440020"
+	zone := &domain.Zone{ID: zID, Name: "listen.test.", TenantID: "t1"}
+	if err := repo.CreateZone(context.Background(), zone); err != nil {
+		t.Fatalf("CreateZone failed: %v", err)
+	}
+	rec := &domain.Record{ID: "550e8400-e29b-41d4-a716-446655440021", ZoneID: zID, Name: "www.listen.test.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 60}
+	if err := repo.CreateRecord(context.Background(), rec); err != nil {
+		t.Fatalf("CreateRecord failed: %v", err)
+	}
+
+	var got []domain.DataChange
+	timeout := time.After(5 * time.Second)
+	for len(got) < 2 {
+		select {
+		case c := <-changes:
+			got = append(got, c)
+		case <-timeout:
+			t.Fatalf("Expected zone and record notifications, got %+v", got)
+		}
+	}
+	if got[0].Table != "dns_zones" || got[0].Zone != "listen.test." {
+		t.Errorf("Unexpected zone notification: %+v", got[0])
+	}
+	if got[1].Op != "INSERT" || got[1].Name != "www.listen.test." || got[1].Type != domain.TypeA || got[1].Zone != "listen.test." {
+		t.Errorf("Unexpected record notification: %+v", got[1])
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Expected a clean stop, got %v", err)
+	}
+}
@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"errors"
 	"testing"
 	"time"
@@ -22,8 +24,8 @@ func TestPostgresRepository_Unit(t *testing.T) {
 
 	// 1. Test GetRecords
 	t.Run("GetRecords", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "zone_id", "name", "type", "content", "ttl", "priority", "weight", "port", "network", "health_check_type", "health_check_target", "status"}).
-			AddRow("r1", "z1", "www.test.", "A", "1.2.3.4", 300, nil, nil, nil, nil, "HTTP", "http://target", "HEALTHY")
+		rows := sqlmock.NewRows([]string{"id", "zone_id", "name", "type", "content", "ttl", "priority", "weight", "port", "network", "view_id", "geo", "target_id", "health_check_type", "health_check_target", "health_check_id", "status"}).
+			AddRow("r1", "z1", "www.test.", "A", "1.2.3.4", 300, nil, nil, nil, nil, nil, nil, nil, "HTTP", "http://target", nil, "HEALTHY")
 
 		// Anchored query with WHERE predicates
 		mock.ExpectQuery(`SELECT .* FROM dns_records r .* WHERE LOWER\(r\.name\) = LOWER\(\$1\) AND \(r\.network IS NULL OR \$2::inet <<= r\.network\) AND r\.type = \$3`).
@@ -41,8 +43,8 @@ func TestPostgresRepository_Unit(t *testing.T) {
 
 	// 2. Test GetZone
 	t.Run("GetZone", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "tenant_id", "name", "vpc_id", "description", "role", "master_server", "created_at", "updated_at"}).
-			AddRow("z1", "t1", "test.com.", "", "", "master", "", time.Now(), time.Now())
+		rows := sqlmock.NewRows([]string{"id", "tenant_id", "name", "vpc_id", "description", "role", "master_server", "dnssec_enabled", "created_at", "updated_at"}).
+			AddRow("z1", "t1", "test.com.", "", "", "master", "", true, time.Now(), time.Now())
 
 		mock.ExpectQuery(`SELECT .* FROM dns_zones WHERE LOWER\(name\) = LOWER\(\$1\)`).
 			WithArgs("test.com.").
@@ -57,11 +59,111 @@ func TestPostgresRepository_Unit(t *testing.T) {
 		}
 	})
 
+	t.Run("GetZoneByID", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "tenant_id", "name", "vpc_id", "description", "role", "master_server", "dnssec_enabled", "created_at", "updated_at"}).
+			AddRow("z1", "t1", "test.com.", "", "", nil, nil, false, time.Now(), time.Now())
+
+		mock.ExpectQuery(`SELECT .* FROM dns_zones WHERE id = \$1 AND tenant_id = \$2`).
+			WithArgs("z1", "t1").
+			WillReturnRows(rows)
+
+		zone, err := repo.GetZoneByID(ctx, "z1", "t1")
+		if err != nil || zone == nil || zone.Name != "test.com." || zone.SignsDNSSEC() {
+			t.Errorf("GetZoneByID failed: %v, got %+v", err, zone)
+		}
+
+		mock.ExpectQuery(`SELECT .* FROM dns_zones WHERE id = \$1`).
+			WithArgs("missing").
+			WillReturnError(sql.ErrNoRows)
+
+		zone, err = repo.GetZoneByID(ctx, "missing", "")
+		if err != nil || zone != nil {
+			t.Errorf("Expected nil zone for missing ID, got %+v, %v", zone, err)
+		}
+	})
+
+	t.Run("NodeRegistry", func(t *testing.T) {
+		now := time.Now()
+		node := &domain.Node{ID: "node-a", Version: "v1", Address: "10.0.0.1:53", Healthy: true, QPS: 12.5, StartedAt: now, LastHeartbeat: now}
+		mock.ExpectExec(`INSERT INTO dns_nodes .* ON CONFLICT \(id\) DO UPDATE`).
+			WithArgs("node-a", "v1", "10.0.0.1:53", true, 12.5, false, 0.0, "", "", now, now).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		if err := repo.UpsertNode(ctx, node); err != nil {
+			t.Errorf("UpsertNode failed: %v", err)
+		}
+
+		rows := sqlmock.NewRows([]string{"id", "version", "address", "healthy", "qps", "anycast_announced", "error_rate", "config_version", "config_error", "started_at", "last_heartbeat"}).
+			AddRow("node-a", "v1", "10.0.0.1:53", true, 12.5, true, 0.01, "abc123", "", now, now)
+		mock.ExpectQuery(`SELECT .* FROM dns_nodes ORDER BY id`).WillReturnRows(rows)
+		nodes, err := repo.ListNodes(ctx)
+		if err != nil || len(nodes) != 1 || !nodes[0].AnycastAnnounced || nodes[0].QPS != 12.5 {
+			t.Errorf("ListNodes failed: %v, got %+v", err, nodes)
+		}
+	})
+
+	t.Run("ZoneUsage", func(t *testing.T) {
+		hour := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+		rows := sqlmock.NewRows([]string{"zone_id", "name", "hour", "queries", "nxdomain", "transfer_bytes"}).
+			AddRow("z1", "example.com.", hour, int64(5), int64(1), int64(0))
+		mock.ExpectQuery(`SELECT .* FROM zone_usage_hourly u JOIN dns_zones z .* WHERE z.tenant_id = \$1 AND u.hour >= \$2 AND u.hour < \$3 AND u.zone_id = \$4 ORDER BY u.hour, z.name`).
+			WithArgs("t1", hour, hour.Add(time.Hour), "z1").
+			WillReturnRows(rows)
+		usage, err := repo.ListZoneUsage(ctx, "t1", "z1", hour, hour.Add(time.Hour))
+		if err != nil || len(usage) != 1 || usage[0].ZoneName != "example.com." || usage[0].NXDomain != 1 {
+			t.Errorf("ListZoneUsage failed: %v, got %+v", err, usage)
+		}
+	})
+
+	t.Run("NodeConfigAndRollouts", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT config FROM dns_node_config WHERE node_id = \$1`).
+			WithArgs("node-a").
+			WillReturnRows(sqlmock.NewRows([]string{"config"}).AddRow([]byte(`{"log_level":"debug"}`)))
+		cfg, err := repo.GetNodeConfig(ctx, "node-a")
+		if err != nil || cfg["log_level"] != "debug" {
+			t.Errorf("GetNodeConfig failed: %v, got %v", err, cfg)
+		}
+
+		mock.ExpectQuery(`SELECT config FROM dns_node_config`).WithArgs("node-b").WillReturnError(sql.ErrNoRows)
+		if cfg, err = repo.GetNodeConfig(ctx, "node-b"); err != nil || cfg != nil {
+			t.Errorf("Expected nil config for unknown node, got %v, %v", cfg, err)
+		}
+
+		mock.ExpectExec(`INSERT INTO dns_node_config .* ON CONFLICT \(node_id\) DO UPDATE`).
+			WithArgs("node-a", []byte(`{"log_level":"warn"}`)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		if err := repo.SetNodeConfig(ctx, "node-a", map[string]string{"log_level": "warn"}); err != nil {
+			t.Errorf("SetNodeConfig failed: %v", err)
+		}
+
+		rollout := &domain.Rollout{ID: "r1", State: domain.RolloutRunning, Config: map[string]string{"log_level": "warn"}}
+		mock.ExpectExec(`INSERT INTO dns_rollouts .* ON CONFLICT \(id\) DO UPDATE`).
+			WithArgs("r1", "RUNNING", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		if err := repo.SaveRollout(ctx, rollout); err != nil {
+			t.Errorf("SaveRollout failed: %v", err)
+		}
+
+		mock.ExpectQuery(`SELECT spec FROM dns_rollouts WHERE id = \$1`).
+			WithArgs("r1").
+			WillReturnRows(sqlmock.NewRows([]string{"spec"}).AddRow([]byte(`{"id":"r1","state":"COMPLETED"}`)))
+		got, err := repo.GetRollout(ctx, "r1")
+		if err != nil || got == nil || got.State != domain.RolloutCompleted {
+			t.Errorf("GetRollout failed: %v, got %+v", err, got)
+		}
+
+		mock.ExpectQuery(`SELECT spec FROM dns_rollouts ORDER BY created_at DESC`).
+			WillReturnRows(sqlmock.NewRows([]string{"spec"}).AddRow([]byte(`{"id":"r2"}`)).AddRow([]byte(`{"id":"r1"}`)))
+		list, err := repo.ListRollouts(ctx)
+		if err != nil || len(list) != 2 || list[0].ID != "r2" {
+			t.Errorf("ListRollouts failed: %v, got %+v", err, list)
+		}
+	})
+
 	// 3. Test CreateZone
 	t.Run("CreateZone", func(t *testing.T) {
 		zone := &domain.Zone{ID: "z2", Name: "new.test.", TenantID: "t1", Role: "master", MasterServer: ""}
 		mock.ExpectExec(`INSERT INTO dns_zones`).
-			WithArgs(zone.ID, zone.TenantID, zone.Name, zone.VPCID, zone.Description, zone.Role, zone.MasterServer, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WithArgs(zone.ID, zone.TenantID, zone.Name, zone.VPCID, zone.Description, zone.Role, zone.MasterServer, true, sqlmock.AnyArg(), sqlmock.AnyArg()).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
 		err := repo.CreateZone(ctx, zone)
@@ -72,8 +174,8 @@ func TestPostgresRepository_Unit(t *testing.T) {
 
 	// 4. Test ListRecordsForZone
 	t.Run("ListRecordsForZone", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "zone_id", "name", "type", "content", "ttl", "priority", "weight", "port", "network", "hc_type", "hc_target", "h_status"}).
-			AddRow("r1", "z1", "www.test.", "A", "1.2.3.4", 300, 10, 5, 80, nil, "NONE", nil, "UNKNOWN")
+		rows := sqlmock.NewRows([]string{"id", "zone_id", "name", "type", "content", "ttl", "priority", "weight", "port", "network", "view_id", "geo", "target_id", "hc_type", "hc_target", "hc_id", "h_status", "protected"}).
+			AddRow("r1", "z1", "www.test.", "A", "1.2.3.4", 300, 10, 5, 80, nil, nil, []byte(`{"countries":["DE"]}`), nil, "NONE", nil, nil, "UNKNOWN", false)
 
 		mock.ExpectQuery(`SELECT .* FROM dns_records r .* WHERE r\.zone_id = \$1 AND z\.tenant_id = \$2`).
 			WithArgs("z1", "").
@@ -83,7 +185,7 @@ func TestPostgresRepository_Unit(t *testing.T) {
 		if err != nil {
 			t.Errorf("ListRecordsForZone failed: %v", err)
 		}
-		if len(recs) != 1 || *recs[0].Priority != 10 || *recs[0].Weight != 5 || *recs[0].Port != 80 {
+		if len(recs) != 1 || *recs[0].Priority != 10 || *recs[0].Weight != 5 || *recs[0].Port != 80 || recs[0].Geo == nil || recs[0].Geo.Countries[0] != "DE" {
 			t.Errorf("Unexpected records: %+v", recs)
 		}
 	})
@@ -100,11 +202,194 @@ func TestPostgresRepository_Unit(t *testing.T) {
 		}
 	})
 
+	t.Run("SetZoneDNSSEC", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE dns_zones SET dnssec_enabled = \$1, updated_at = \$2 WHERE id = \$3 AND tenant_id = \$4`).
+			WithArgs(false, sqlmock.AnyArg(), "z1", "t1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if err := repo.SetZoneDNSSEC(ctx, "z1", "t1", false); err != nil {
+			t.Errorf("SetZoneDNSSEC failed: %v", err)
+		}
+	})
+
+	t.Run("SetZoneRole", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE dns_zones SET role = \$1, master_server = \$2, updated_at = \$3 WHERE id = \$4 AND tenant_id = \$5`).
+			WithArgs("slave", "192.0.2.1,192.0.2.2:5353", sqlmock.AnyArg(), "z1", "t1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if err := repo.SetZoneRole(ctx, "z1", "t1", "slave", "192.0.2.1,192.0.2.2:5353"); err != nil {
+			t.Errorf("SetZoneRole failed: %v", err)
+		}
+	})
+
+	t.Run("ShadowChanges", func(t *testing.T) {
+		change := &domain.ShadowChange{
+			ZoneID: "z1", TenantID: "t1", Description: "move www",
+			RRsets:    []domain.RRset{{Name: "www.test.com.", Type: domain.TypeA, TTL: 300, Records: []domain.RRsetRecord{{Content: "192.0.2.2"}}}},
+			CreatedAt: time.Now(),
+		}
+		mock.ExpectExec(`INSERT INTO dns_shadow_changes .* ON CONFLICT \(zone_id\) DO UPDATE`).
+			WithArgs("z1", "t1", sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		if err := repo.SaveShadowChange(ctx, change); err != nil {
+			t.Fatalf("SaveShadowChange failed: %v", err)
+		}
+
+		spec, _ := json.Marshal(change)
+		mock.ExpectQuery(`SELECT tenant_id, spec FROM dns_shadow_changes WHERE zone_id = \$1`).WithArgs("z1").
+			WillReturnRows(sqlmock.NewRows([]string{"tenant_id", "spec"}).AddRow("t1", spec))
+		got, err := repo.GetShadowChange(ctx, "z1")
+		if err != nil || got == nil || got.TenantID != "t1" || got.RRsets[0].Records[0].Content != "192.0.2.2" {
+			t.Errorf("Unexpected shadow change %+v: %v", got, err)
+		}
+		mock.ExpectQuery(`SELECT tenant_id, spec FROM dns_shadow_changes WHERE zone_id = \$1`).WithArgs("z9").
+			WillReturnError(sql.ErrNoRows)
+		if got, err := repo.GetShadowChange(ctx, "z9"); err != nil || got != nil {
+			t.Errorf("Expected no shadow change, got %+v: %v", got, err)
+		}
+
+		mock.ExpectQuery(`SELECT tenant_id, spec FROM dns_shadow_changes ORDER BY created_at`).
+			WillReturnRows(sqlmock.NewRows([]string{"tenant_id", "spec"}).AddRow("t1", spec))
+		if list, err := repo.ListShadowChanges(ctx); err != nil || len(list) != 1 || list[0].ZoneID != "z1" {
+			t.Errorf("Unexpected shadow changes %+v: %v", list, err)
+		}
+
+		mock.ExpectExec(`DELETE FROM dns_shadow_changes WHERE zone_id = \$1`).WithArgs("z1").WillReturnResult(sqlmock.NewResult(0, 1))
+		if deleted, err := repo.DeleteShadowChange(ctx, "z1"); err != nil || !deleted {
+			t.Errorf("Expected the shadow change to be deleted, got %v: %v", deleted, err)
+		}
+		mock.ExpectExec(`DELETE FROM dns_shadow_changes WHERE zone_id = \$1`).WithArgs("z1").WillReturnResult(sqlmock.NewResult(0, 0))
+		if deleted, err := repo.DeleteShadowChange(ctx, "z1"); err != nil || deleted {
+			t.Errorf("Expected nothing to delete, got %v: %v", deleted, err)
+		}
+	})
+
+	t.Run("ListRecordNetworks", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT DISTINCT network::text FROM dns_records WHERE network IS NOT NULL`).
+			WillReturnRows(sqlmock.NewRows([]string{"network"}).AddRow("10.0.0.0/8").AddRow("2001:db8::/32"))
+		networks, err := repo.ListRecordNetworks(ctx)
+		if err != nil || len(networks) != 2 || networks[1] != "2001:db8::/32" {
+			t.Errorf("Unexpected networks %v: %v", networks, err)
+		}
+	})
+
+	t.Run("ListRecordGeoScopes", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT 'continent:' \|\| jsonb_array_elements_text\(geo->'continents'\)`).
+			WillReturnRows(sqlmock.NewRows([]string{"scope"}).AddRow("asn:64500").AddRow("country:DE"))
+		scopes, err := repo.ListRecordGeoScopes(ctx)
+		if err != nil || len(scopes) != 2 || scopes[1] != "country:DE" {
+			t.Errorf("Unexpected geo scopes %v: %v", scopes, err)
+		}
+	})
+
+	t.Run("SetRecordProtected", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE dns_records r SET protected = \$4, .* WHERE r\.zone_id = z\.id AND r\.id = \$1 AND r\.zone_id = \$2 AND z\.tenant_id = \$3`).
+			WithArgs("r1", "z1", "t1", true).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		if found, err := repo.SetRecordProtected(ctx, "r1", "z1", "t1", true); err != nil || !found {
+			t.Errorf("SetRecordProtected failed: %v %v", found, err)
+		}
+		mock.ExpectExec(`UPDATE dns_records r SET protected`).
+			WithArgs("missing", "z1", "t1", false).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		if found, err := repo.SetRecordProtected(ctx, "missing", "z1", "t1", false); err != nil || found {
+			t.Errorf("Expected a missing record to be reported, got %v %v", found, err)
+		}
+	})
+
+	t.Run("ZoneSLOs", func(t *testing.T) {
+		now := time.Now()
+		slo := &domain.ZoneSLO{ZoneID: "z1", TenantID: "t1", Percentile: 99, ThresholdMs: 5, WindowMinutes: 5, UpdatedAt: now}
+		mock.ExpectExec(`INSERT INTO dns_zone_slos .* ON CONFLICT \(zone_id\) DO UPDATE`).
+			WithArgs("z1", "t1", 99.0, 5.0, 5, now).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		if err := repo.SaveZoneSLO(ctx, slo); err != nil {
+			t.Fatalf("SaveZoneSLO failed: %v", err)
+		}
+
+		cols := []string{"zone_id", "tenant_id", "percentile", "threshold_ms", "window_minutes", "updated_at"}
+		mock.ExpectQuery(`SELECT .* FROM dns_zone_slos WHERE zone_id = \$1`).WithArgs("z1").
+			WillReturnRows(sqlmock.NewRows(cols).AddRow("z1", "t1", 99.0, 5.0, 5, now))
+		if got, err := repo.GetZoneSLO(ctx, "z1"); err != nil || got == nil || got.Percentile != 99 || got.TenantID != "t1" {
+			t.Errorf("Unexpected SLO %+v: %v", got, err)
+		}
+		mock.ExpectQuery(`SELECT .* FROM dns_zone_slos WHERE zone_id = \$1`).WithArgs("z9").WillReturnError(sql.ErrNoRows)
+		if got, err := repo.GetZoneSLO(ctx, "z9"); err != nil || got != nil {
+			t.Errorf("Expected no SLO, got %+v: %v", got, err)
+		}
+
+		mock.ExpectQuery(`SELECT .* FROM dns_zone_slos ORDER BY zone_id`).
+			WillReturnRows(sqlmock.NewRows(cols).AddRow("z1", "t1", 99.0, 5.0, 5, now).AddRow("z2", "t1", 95.0, 20.0, 15, now))
+		if list, err := repo.ListZoneSLOs(ctx); err != nil || len(list) != 2 || list[1].WindowMinutes != 15 {
+			t.Errorf("Unexpected SLOs %+v: %v", list, err)
+		}
+
+		mock.ExpectExec(`DELETE FROM dns_zone_slos WHERE zone_id = \$1`).WithArgs("z1").WillReturnResult(sqlmock.NewResult(0, 1))
+		if deleted, err := repo.DeleteZoneSLO(ctx, "z1"); err != nil || !deleted {
+			t.Errorf("Expected the SLO to be deleted, got %v: %v", deleted, err)
+		}
+	})
+
+	t.Run("ReplaceZoneRecords", func(t *testing.T) {
+		prio := 10
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM dns_records WHERE zone_id = \$1`).WithArgs("z1").WillReturnResult(sqlmock.NewResult(0, 5))
+		mock.ExpectExec(`INSERT INTO dns_records`).
+			WithArgs(sqlmock.AnyArg(), "z1", "test.com.", domain.TypeMX, "mail.test.com.", 300, &prio, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), string(domain.HealthCheckNone), "", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		// Transferred records carry no ID
+		if err := repo.ReplaceZoneRecords(ctx, "z1", []domain.Record{{ZoneID: "z1", Name: "test.com.", Type: domain.TypeMX, Content: "mail.test.com.", TTL: 300, Priority: &prio}}); err != nil {
+			t.Errorf("ReplaceZoneRecords failed: %v", err)
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM dns_records WHERE zone_id = \$1`).WithArgs("z1").WillReturnError(errors.New("db down"))
+		mock.ExpectRollback()
+		if err := repo.ReplaceZoneRecords(ctx, "z1", nil); err == nil {
+			t.Error("Expected ReplaceZoneRecords to fail")
+		}
+	})
+
+	t.Run("ApplyZoneDiff", func(t *testing.T) {
+		diff := domain.IXFRChunk{
+			Serial: 2,
+			Deleted: []domain.Record{
+				{Name: "test.com.", Type: domain.TypeSOA, Content: "ns1. admin. 1 3600 600 86400 300"},
+				{Name: "www.test.com.", Type: domain.TypeA, Content: "192.0.2.1"},
+			},
+			Added: []domain.Record{
+				{Name: "test.com.", Type: domain.TypeSOA, Content: "ns1. admin. 2 3600 600 86400 300", TTL: 300},
+			},
+		}
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM dns_records WHERE zone_id = \$1 AND type = \$2`).WithArgs("z1", "SOA").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`DELETE FROM dns_records WHERE zone_id = \$1 AND LOWER\(name\) = LOWER\(\$2\) AND type = \$3 AND content = \$4`).
+			WithArgs("z1", "www.test.com.", "A", "192.0.2.1").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO dns_records`).
+			WithArgs(sqlmock.AnyArg(), "z1", "test.com.", domain.TypeSOA, "ns1. admin. 2 3600 600 86400 300", 300, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), string(domain.HealthCheckNone), "", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		if err := repo.ApplyZoneDiff(ctx, "z1", []domain.IXFRChunk{diff}); err != nil {
+			t.Errorf("ApplyZoneDiff failed: %v", err)
+		}
+
+		// A deletion matching nothing rolls the whole diff back
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM dns_records WHERE zone_id = \$1 AND type = \$2`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`DELETE FROM dns_records WHERE zone_id = \$1 AND LOWER`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+		if err := repo.ApplyZoneDiff(ctx, "z1", []domain.IXFRChunk{diff}); !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	})
+
 	// 6. Test CreateRecord
 	t.Run("CreateRecord", func(t *testing.T) {
 		rec := &domain.Record{ID: "r2", ZoneID: "z1", Name: "new.test.", Type: domain.TypeA, Content: "1.1.1.1", TTL: 60, HealthCheckType: domain.HealthCheckHTTP, HealthCheckTarget: "http://t"}
 		mock.ExpectExec(`INSERT INTO dns_records`).
-			WithArgs(rec.ID, rec.ZoneID, rec.Name, rec.Type, rec.Content, rec.TTL, rec.Priority, rec.Weight, rec.Port, rec.Network, string(rec.HealthCheckType), rec.HealthCheckTarget, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WithArgs(rec.ID, rec.ZoneID, rec.Name, rec.Type, rec.Content, rec.TTL, rec.Priority, rec.Weight, rec.Port, rec.Network, rec.ViewID, sqlmock.AnyArg(), rec.TargetID, string(rec.HealthCheckType), rec.HealthCheckTarget, rec.HealthCheckID, rec.Protected, sqlmock.AnyArg(), sqlmock.AnyArg()).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
 		err := repo.CreateRecord(ctx, rec)
@@ -115,8 +400,8 @@ func TestPostgresRepository_Unit(t *testing.T) {
 
 	// 7. Test ListZones
 	t.Run("ListZones", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "tenant_id", "name", "vpc_id", "description", "role", "master_server", "created_at", "updated_at"}).
-			AddRow("z1", "t1", "test.com.", "", "", "master", "", time.Now(), time.Now())
+		rows := sqlmock.NewRows([]string{"id", "tenant_id", "name", "vpc_id", "description", "role", "master_server", "dnssec_enabled", "created_at", "updated_at"}).
+			AddRow("z1", "t1", "test.com.", "", "", "master", "", true, time.Now(), time.Now())
 
 		mock.ExpectQuery(`SELECT .* FROM dns_zones WHERE tenant_id = \$1`).
 			WithArgs("t1").
@@ -128,8 +413,8 @@ func TestPostgresRepository_Unit(t *testing.T) {
 		}
 
 		mock.ExpectQuery(`SELECT .* FROM dns_zones`).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id", "name", "vpc_id", "description", "role", "master_server", "created_at", "updated_at"}).
-				AddRow("z1", "t1", "test.com.", "", "", "master", "", time.Now(), time.Now()))
+			WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id", "name", "vpc_id", "description", "role", "master_server", "dnssec_enabled", "created_at", "updated_at"}).
+				AddRow("z1", "t1", "test.com.", "", "", "master", "", true, time.Now(), time.Now()))
 
 		zones, err = repo.ListZones(ctx, "")
 		if err != nil || len(zones) != 1 {
@@ -155,7 +440,7 @@ func TestPostgresRepository_Unit(t *testing.T) {
 		rows := sqlmock.NewRows([]string{"id", "zone_id", "serial", "action", "name", "type", "content", "ttl", "priority", "weight", "port", "created_at"}).
 			AddRow("c1", "z1", 1, "ADD", "test.", "A", "1.1.1.1", 60, nil, nil, nil, time.Now())
 
-		mock.ExpectQuery(`SELECT .* FROM dns_zone_changes WHERE zone_id = \$1 AND serial > \$2 ORDER BY serial ASC, created_at ASC`).
+		mock.ExpectQuery(`SELECT .* FROM dns_zone_changes WHERE zone_id = \$1 AND \(\(serial - \$2::bigint \+ 4294967296\) % 4294967296\) BETWEEN 1 AND 2147483647`).
 			WithArgs("z1", 0).
 			WillReturnRows(rows)
 
@@ -165,6 +450,57 @@ func TestPostgresRepository_Unit(t *testing.T) {
 		}
 	})
 
+	t.Run("HasZones", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM dns_zones LIMIT 1\)`).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		if exists, err := repo.HasZones(ctx); err != nil || !exists {
+			t.Errorf("HasZones = %v, %v", exists, err)
+		}
+	})
+
+	t.Run("LastZoneChangeAt", func(t *testing.T) {
+		last := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		mock.ExpectQuery(`SELECT MAX\(created_at\) FROM dns_zone_changes WHERE zone_id = \$1`).
+			WithArgs("z1").
+			WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(last))
+		got, err := repo.LastZoneChangeAt(ctx, "z1")
+		if err != nil || got == nil || !got.Equal(last) {
+			t.Errorf("LastZoneChangeAt = %v, %v", got, err)
+		}
+
+		mock.ExpectQuery(`SELECT MAX\(created_at\) FROM dns_zone_changes`).
+			WithArgs("z2").
+			WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(nil))
+		if got, err := repo.LastZoneChangeAt(ctx, "z2"); err != nil || got != nil {
+			t.Errorf("Expected no change for an empty journal, got %v, %v", got, err)
+		}
+	})
+
+	t.Run("ZoneRefresh", func(t *testing.T) {
+		at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		mock.ExpectExec(`INSERT INTO zone_refreshes .* ON CONFLICT \(zone_id\) DO UPDATE .*COALESCE\(EXCLUDED.succeeded_at, zone_refreshes.succeeded_at\)`).
+			WithArgs("z1", at, nil, int64(7), "timeout").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		if err := repo.SaveZoneRefresh(ctx, &domain.ZoneRefresh{ZoneID: "z1", AttemptedAt: at, Serial: 7, Error: "timeout"}); err != nil {
+			t.Errorf("SaveZoneRefresh failed: %v", err)
+		}
+
+		mock.ExpectQuery(`SELECT attempted_at, succeeded_at, serial, error FROM zone_refreshes WHERE zone_id = \$1`).
+			WithArgs("z1").
+			WillReturnRows(sqlmock.NewRows([]string{"attempted_at", "succeeded_at", "serial", "error"}).AddRow(at, at.Add(-time.Hour), 7, "timeout"))
+		refresh, err := repo.GetZoneRefresh(ctx, "z1")
+		if err != nil || refresh == nil || refresh.Serial != 7 || refresh.SucceededAt == nil || refresh.Error != "timeout" {
+			t.Errorf("GetZoneRefresh = %+v, %v", refresh, err)
+		}
+
+		mock.ExpectQuery(`SELECT .* FROM zone_refreshes`).
+			WithArgs("z2").
+			WillReturnError(sql.ErrNoRows)
+		if refresh, err := repo.GetZoneRefresh(ctx, "z2"); err != nil || refresh != nil {
+			t.Errorf("Expected no refresh for a zone never refreshed, got %+v, %v", refresh, err)
+		}
+	})
+
 	// 10. Test Audit Logs
 	t.Run("AuditLogs", func(t *testing.T) {
 		mock.ExpectExec(`INSERT INTO audit_logs`).
@@ -191,7 +527,7 @@ func TestPostgresRepository_Unit(t *testing.T) {
 	t.Run("DNSSECKeys", func(t *testing.T) {
 		key := &domain.DNSSECKey{ID: "k1", ZoneID: "z1", KeyType: "ZSK", Algorithm: 13, Active: true}
 		mock.ExpectExec(`INSERT INTO dnssec_keys`).
-			WithArgs(key.ID, key.ZoneID, key.KeyType, key.Algorithm, key.PrivateKey, key.PublicKey, key.Active, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WithArgs(key.ID, key.ZoneID, key.KeyType, key.Algorithm, key.PrivateKey, key.PublicKey, key.Active, sqlmock.AnyArg(), sqlmock.AnyArg(), key.External).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
 		err := repo.CreateKey(ctx, key)
@@ -201,8 +537,8 @@ func TestPostgresRepository_Unit(t *testing.T) {
 
 		mock.ExpectQuery(`SELECT .* FROM dnssec_keys WHERE zone_id = \$1`).
 			WithArgs("z1").
-			WillReturnRows(sqlmock.NewRows([]string{"id", "zone_id", "key_type", "algorithm", "private_key", "public_key", "active", "created_at", "updated_at"}).
-				AddRow("k1", "z1", "ZSK", 13, []byte{}, []byte{}, true, time.Now(), time.Now()))
+			WillReturnRows(sqlmock.NewRows([]string{"id", "zone_id", "key_type", "algorithm", "private_key", "public_key", "active", "created_at", "updated_at", "external"}).
+				AddRow("k1", "z1", "ZSK", 13, []byte{}, []byte{}, true, time.Now(), time.Now(), false))
 
 		keys, err := repo.ListKeysForZone(ctx, "z1")
 		if err != nil || len(keys) != 1 {
@@ -246,14 +582,16 @@ func TestPostgresRepository_Unit(t *testing.T) {
 		}
 
 		// GetRecordsToProbe
-		rows := sqlmock.NewRows([]string{"id", "zone_id", "name", "type", "content", "ttl", "priority", "weight", "port", "network", "health_check_type", "health_check_target"}).
-			AddRow("r1", "z1", "www.test.", "A", "1.2.3.4", 300, nil, nil, nil, nil, "HTTP", "http://target")
-		mock.ExpectQuery(`SELECT .* FROM dns_records WHERE health_check_type IN \('HTTP', 'TCP'\) AND health_check_target IS NOT NULL AND health_check_target <> ''`).
+		rows := sqlmock.NewRows([]string{"id", "zone_id", "name", "type", "content", "ttl", "priority", "weight", "port", "network", "health_check_type", "health_check_target", "status"}).
+			AddRow("r1", "z1", "www.test.", "A", "1.2.3.4", 300, nil, nil, nil, nil, "HTTP", "http://target", "UNHEALTHY")
+		mock.ExpectQuery(`SELECT .* FROM dns_records r LEFT JOIN record_health h ON h.record_id = r.id WHERE r.health_check_type IN \('HTTP', 'TCP'\) AND r.health_check_target IS NOT NULL AND r.health_check_target <> ''`).
 			WillReturnRows(rows)
 
 		recs, err := repo.GetRecordsToProbe(ctx)
 		if err != nil || len(recs) != 1 {
 			t.Errorf("GetRecordsToProbe failed: %v", err)
+		} else if recs[0].HealthStatus != domain.HealthStatusUnhealthy {
+			t.Errorf("expected the last probed status, got %q", recs[0].HealthStatus)
 		}
 	})
 
@@ -371,3 +709,25 @@ func TestPostgresRepository_Unit(t *testing.T) {
 		}
 	})
 }
+
+func TestNameExists(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer func() { _ = db.Close() }()
+	repo := NewPostgresRepository(db)
+
+	// Names below are matched on their reversed form, with LIKE wildcards escaped
+	mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM dns_records\s+WHERE zone_id = \$1 AND \(LOWER\(name\) = \$2 OR reverse\(LOWER\(name\)\) LIKE \$3\)\)`).
+		WithArgs("z1", "_tcp.example.test.", `.tset.elpmaxe.pct\_.%`).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	exists, err := repo.NameExists(context.Background(), "z1", "_TCP.Example.Test")
+	if err != nil || !exists {
+		t.Errorf("Expected the name to exist, got %v, %v", exists, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+func TestRDATAConverters(t *testing.T) {
+	records := []domain.Record{
+		{Name: "4.3.2.1.e164.arpa.", Type: domain.TypeNAPTR, Content: `100 10 "U" "E2U+sip" "!^.*$!sip:info@example.com!" .`},
+		{Name: "_443._tcp.www.example.com.", Type: domain.TypeTLSA, Content: "3 1 1 0c72ac70b745ac19998811b131d662c9ac69dbdbe7cb23e5b514b56664c5d3d6"},
+		{Name: "host.example.com.", Type: domain.TypeSSHFP, Content: "1 1 dd465c09cfa51fb45020cc83316fff21b9ec74ac"},
+		{Name: "example.com.", Type: domain.TypeLOC, Content: "52 22 23.000 N 4 53 32.000 E -2m 0m 10000m 10m"},
+		{Name: "_http._tcp.example.com.", Type: domain.TypeURI, Content: `10 1 "https://www.example.com/"`},
+		{Name: "example.com.", Type: domain.TypeCERT, Content: "PGP 0 0 aGVsbG8="},
+	}
+	for _, original := range records {
+		t.Run(string(original.Type), func(t *testing.T) {
+			original.TTL = 3600
+			pRec, err := ConvertDomainToPacketRecord(original)
+			if err != nil {
+				t.Fatalf("ConvertDomainToPacketRecord failed: %v", err)
+			}
+			if pRec.Type.String() != string(original.Type) {
+				t.Fatalf("Unexpected packet type %s", pRec.Type)
+			}
+			decoded, err := ConvertPacketRecordToDomain(pRec, "zone-123")
+			if err != nil {
+				t.Fatalf("ConvertPacketRecordToDomain failed: %v", err)
+			}
+			if decoded.Type != original.Type || decoded.Content != original.Content {
+				t.Errorf("Unexpected domain record %+v", decoded)
+			}
+		})
+	}
+
+	if _, err := ConvertDomainToPacketRecord(domain.Record{Name: "example.com.", Type: domain.TypeTLSA, Content: "3 1 1"}); err == nil {
+		t.Error("Expected malformed TLSA content to be rejected")
+	}
+}
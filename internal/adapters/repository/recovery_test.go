@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+func TestReplaceZone(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	repo := NewPostgresRepository(db)
+	ctx := context.Background()
+
+	zone := &domain.Zone{ID: "z1", TenantID: "t1", Name: "a.test."}
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO dns_zones .* ON CONFLICT \(id\) DO UPDATE`).
+		WithArgs("z1", "t1", "a.test.", sqlmock.AnyArg(), "", "", "", true, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM dns_records WHERE zone_id = \$1`).WithArgs("z1").WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(`INSERT INTO dns_records`).
+		WithArgs("r1", "z1", "www.a.test.", domain.TypeA, "192.0.2.1", 300, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), string(domain.HealthCheckNone), "", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := repo.ReplaceZone(ctx, "z1", zone, []domain.Record{{ID: "r1", Name: "www.a.test.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 300}}); err != nil {
+		t.Fatalf("ReplaceZone failed: %v", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM dns_zones WHERE id = \$1`).WithArgs("z2").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := repo.ReplaceZone(ctx, "z2", nil, nil); err != nil {
+		t.Fatalf("ReplaceZone failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
@@ -6,6 +6,7 @@ CREATE TABLE IF NOT EXISTS dns_zones (
     description TEXT,
     role TEXT DEFAULT 'master',
     master_server TEXT,
+    dnssec_enabled BOOLEAN NOT NULL DEFAULT TRUE,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
@@ -23,6 +24,7 @@ CREATE TABLE IF NOT EXISTS dns_records (
     network CIDR,
     health_check_type TEXT DEFAULT 'NONE' CHECK (health_check_type IN ('NONE', 'HTTP', 'TCP')),
     health_check_target TEXT,
+    protected BOOLEAN NOT NULL DEFAULT FALSE,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
@@ -32,6 +34,7 @@ ALTER TABLE dns_records ADD COLUMN IF NOT EXISTS weight INTEGER;
 ALTER TABLE dns_records ADD COLUMN IF NOT EXISTS port INTEGER;
 ALTER TABLE dns_records ADD COLUMN IF NOT EXISTS health_check_type TEXT DEFAULT 'NONE';
 ALTER TABLE dns_records ADD COLUMN IF NOT EXISTS health_check_target TEXT;
+ALTER TABLE dns_records ADD COLUMN IF NOT EXISTS protected BOOLEAN NOT NULL DEFAULT FALSE;
 
 CREATE TABLE IF NOT EXISTS record_health (
     record_id UUID PRIMARY KEY REFERENCES dns_records(id) ON DELETE CASCADE,
@@ -42,6 +45,7 @@ CREATE TABLE IF NOT EXISTS record_health (
 
 ALTER TABLE dns_zones ADD COLUMN IF NOT EXISTS role TEXT DEFAULT 'master';
 ALTER TABLE dns_zones ADD COLUMN IF NOT EXISTS master_server TEXT;
+ALTER TABLE dns_zones ADD COLUMN IF NOT EXISTS dnssec_enabled BOOLEAN NOT NULL DEFAULT TRUE;
 
 CREATE TABLE IF NOT EXISTS audit_logs (
     id UUID PRIMARY KEY,
@@ -71,6 +75,7 @@ CREATE TABLE IF NOT EXISTS dns_zone_changes (
 -- Migration for existing tables
 ALTER TABLE dns_zone_changes ADD COLUMN IF NOT EXISTS weight INTEGER;
 ALTER TABLE dns_zone_changes ADD COLUMN IF NOT EXISTS port INTEGER;
+CREATE INDEX IF NOT EXISTS idx_dns_zone_changes_zone_created ON dns_zone_changes (zone_id, created_at);
 
 CREATE TABLE IF NOT EXISTS dnssec_keys (
     id UUID PRIMARY KEY,
@@ -83,9 +88,12 @@ CREATE TABLE IF NOT EXISTS dnssec_keys (
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
+ALTER TABLE dnssec_keys ADD COLUMN IF NOT EXISTS external BOOLEAN NOT NULL DEFAULT FALSE; -- another signer's ZSK (RFC 8901)
 
 CREATE INDEX idx_dns_records_name ON dns_records(name);
 CREATE INDEX idx_dns_records_network ON dns_records USING gist (network inet_ops);
+-- Finds names below a name, to tell empty non-terminals from names that do not exist
+CREATE INDEX IF NOT EXISTS idx_dns_records_zone_reversed_name ON dns_records (zone_id, reverse(LOWER(name)) text_pattern_ops);
 
 CREATE TABLE IF NOT EXISTS api_keys (
     id UUID PRIMARY KEY,
@@ -99,3 +107,334 @@ CREATE TABLE IF NOT EXISTS api_keys (
     expires_at TIMESTAMPTZ,
     CONSTRAINT role_check CHECK (role IN ('admin', 'writer', 'reader'))
 );
+
+CREATE TABLE IF NOT EXISTS dns_nodes (
+    id TEXT PRIMARY KEY,            -- NODE_ID (hostname by default)
+    version TEXT NOT NULL,
+    address TEXT NOT NULL,
+    healthy BOOLEAN NOT NULL DEFAULT FALSE,
+    qps DOUBLE PRECISION NOT NULL DEFAULT 0,
+    anycast_announced BOOLEAN NOT NULL DEFAULT FALSE,
+    error_rate DOUBLE PRECISION NOT NULL DEFAULT 0,
+    config_version TEXT NOT NULL DEFAULT '',
+    config_error TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMPTZ NOT NULL,
+    last_heartbeat TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Desired per-node configuration, applied by each node on its next heartbeat
+CREATE TABLE IF NOT EXISTS dns_node_config (
+    node_id TEXT PRIMARY KEY,
+    config JSONB NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS dns_rollouts (
+    id UUID PRIMARY KEY,
+    state TEXT NOT NULL,
+    spec JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Per-zone traffic aggregated hourly across all nodes, for usage reports and billing
+CREATE TABLE IF NOT EXISTS zone_usage_hourly (
+    zone_id UUID NOT NULL REFERENCES dns_zones(id) ON DELETE CASCADE,
+    hour TIMESTAMPTZ NOT NULL,
+    queries BIGINT NOT NULL DEFAULT 0,
+    nxdomain BIGINT NOT NULL DEFAULT 0,
+    transfer_bytes BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (zone_id, hour)
+);
+
+-- External secondaries (customer-run BIND/NSD and similar) notified after zone changes
+CREATE TABLE IF NOT EXISTS zone_secondaries (
+    id UUID PRIMARY KEY,
+    zone_id UUID NOT NULL REFERENCES dns_zones(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    address TEXT NOT NULL,          -- host:port
+    tsig_key_name TEXT NOT NULL DEFAULT '',
+    tsig_secret TEXT NOT NULL DEFAULT '', -- base64 HMAC secret
+    observed_serial BIGINT NOT NULL DEFAULT 0,
+    last_notified_at TIMESTAMPTZ,
+    last_checked_at TIMESTAMPTZ,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (zone_id, address)
+);
+
+-- Outcome of the last refresh of each secondary zone from its masters
+CREATE TABLE IF NOT EXISTS zone_refreshes (
+    zone_id UUID PRIMARY KEY REFERENCES dns_zones(id) ON DELETE CASCADE,
+    attempted_at TIMESTAMPTZ NOT NULL,
+    succeeded_at TIMESTAMPTZ,
+    serial BIGINT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT ''
+);
+
+-- Record changes held back until run_at and applied by the change scheduler
+CREATE TABLE IF NOT EXISTS scheduled_changes (
+    id UUID PRIMARY KEY,
+    zone_id UUID NOT NULL REFERENCES dns_zones(id) ON DELETE CASCADE,
+    tenant_id TEXT NOT NULL,
+    action TEXT NOT NULL,           -- CREATE, UPDATE or DELETE
+    record_id UUID,                 -- record replaced or removed
+    record JSONB,                   -- new record for CREATE and UPDATE
+    run_at TIMESTAMPTZ NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    executed_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_scheduled_changes_due ON scheduled_changes (run_at) WHERE status = 'pending';
+
+-- Maintenance windows in which a tenant's zones (or one zone) reject changes
+CREATE TABLE IF NOT EXISTS change_freezes (
+    id UUID PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    zone_id UUID REFERENCES dns_zones(id) ON DELETE CASCADE, -- NULL freezes every zone of the tenant
+    reason TEXT NOT NULL DEFAULT '',
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_change_freezes_tenant ON change_freezes (tenant_id, ends_at);
+
+-- Zones whose record changes need a second key's approval
+CREATE TABLE IF NOT EXISTS zone_review_policies (
+    zone_id UUID PRIMARY KEY REFERENCES dns_zones(id) ON DELETE CASCADE,
+    tenant_id TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Record changes to zones under review, awaiting or past approval
+CREATE TABLE IF NOT EXISTS change_requests (
+    id UUID PRIMARY KEY,
+    zone_id UUID NOT NULL REFERENCES dns_zones(id) ON DELETE CASCADE,
+    tenant_id TEXT NOT NULL,
+    action TEXT NOT NULL,           -- CREATE_RECORD, DELETE_RECORD or REPLACE_RRSET
+    record_id UUID,                 -- record removed by DELETE_RECORD
+    record JSONB,                   -- new record for CREATE_RECORD
+    rrset JSONB,                    -- replacement for REPLACE_RRSET
+    force BOOLEAN NOT NULL DEFAULT FALSE,
+    status TEXT NOT NULL DEFAULT 'pending',
+    requested_by TEXT NOT NULL,
+    reviewed_by TEXT NOT NULL DEFAULT '',
+    comment TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    reviewed_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_change_requests_zone ON change_requests (zone_id, created_at);
+
+-- Record changes staged per zone and compared with live answers before commit
+CREATE TABLE IF NOT EXISTS dns_shadow_changes (
+    zone_id UUID PRIMARY KEY REFERENCES dns_zones(id) ON DELETE CASCADE,
+    tenant_id TEXT NOT NULL,
+    spec JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS dns_zone_slos (
+    zone_id UUID PRIMARY KEY REFERENCES dns_zones(id) ON DELETE CASCADE,
+    tenant_id TEXT NOT NULL,
+    percentile DOUBLE PRECISION NOT NULL,
+    threshold_ms DOUBLE PRECISION NOT NULL,
+    window_minutes INT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Query rate limits per tenant (zone_id NULL) or per zone, enforced by every node
+CREATE TABLE IF NOT EXISTS query_limits (
+    tenant_id TEXT NOT NULL,
+    zone_id UUID REFERENCES dns_zones(id) ON DELETE CASCADE,
+    qps DOUBLE PRECISION NOT NULL,
+    burst INT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_query_limits_scope ON query_limits (tenant_id, (COALESCE(zone_id::text, '')));
+
+CREATE TABLE IF NOT EXISTS dns_views (
+    id UUID PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    networks CIDR[] NOT NULL,
+    priority INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (tenant_id, name)
+);
+ALTER TABLE dns_records ADD COLUMN IF NOT EXISTS view_id UUID REFERENCES dns_views(id) ON DELETE CASCADE;
+-- {"continents": [...], "countries": [...], "asns": [...]}; NULL serves everyone
+ALTER TABLE dns_records ADD COLUMN IF NOT EXISTS geo JSONB;
+
+CREATE TABLE IF NOT EXISTS dns_default_records (
+    id UUID PRIMARY KEY,
+    zone_id UUID NOT NULL REFERENCES dns_zones(id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    content TEXT NOT NULL,
+    ttl INT NOT NULL,
+    priority INT,
+    min_depth INT NOT NULL DEFAULT 1,
+    max_depth INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_dns_default_records_zone ON dns_default_records(zone_id);
+
+CREATE TABLE IF NOT EXISTS dns_targets (
+    id UUID PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    addresses INET[] NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (tenant_id, name)
+);
+ALTER TABLE dns_records ADD COLUMN IF NOT EXISTS target_id UUID REFERENCES dns_targets(id);
+CREATE INDEX IF NOT EXISTS idx_dns_records_target ON dns_records(target_id) WHERE target_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS health_checks (
+    id UUID PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    protocol TEXT NOT NULL CHECK (protocol IN ('HTTP', 'HTTPS', 'TCP', 'GRPC')),
+    target TEXT NOT NULL,
+    path TEXT NOT NULL DEFAULT '',
+    expected_status INTEGER NOT NULL DEFAULT 0,
+    expected_body TEXT NOT NULL DEFAULT '',
+    interval_seconds INTEGER NOT NULL,
+    timeout_seconds INTEGER NOT NULL,
+    failure_threshold INTEGER NOT NULL,
+    success_threshold INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'UNKNOWN' CHECK (status IN ('HEALTHY', 'UNHEALTHY', 'UNKNOWN')),
+    last_error TEXT NOT NULL DEFAULT '',
+    status_changed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (tenant_id, name)
+);
+ALTER TABLE dns_records ADD COLUMN IF NOT EXISTS health_check_id UUID REFERENCES health_checks(id);
+CREATE INDEX IF NOT EXISTS idx_dns_records_health_check ON dns_records(health_check_id) WHERE health_check_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS ipam_subnets (
+    id UUID PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    cidr CIDR NOT NULL,
+    zone_id UUID NOT NULL REFERENCES dns_zones(id) ON DELETE CASCADE,
+    name_template TEXT NOT NULL,
+    ttl INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (tenant_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS ipam_allocations (
+    id UUID PRIMARY KEY,
+    subnet_id UUID NOT NULL REFERENCES ipam_subnets(id) ON DELETE CASCADE,
+    address INET NOT NULL,
+    hostname TEXT NOT NULL DEFAULT '',
+    name TEXT NOT NULL,
+    record_id UUID NOT NULL,
+    ptr_zone_id UUID,
+    ptr_record_id UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (subnet_id, address)
+);
+
+-- Announce record and zone mutations on the clouddns_changes channel so every
+-- DNS node (LISTEN clouddns_changes) can drop stale cache entries at once
+CREATE OR REPLACE FUNCTION clouddns_notify_change() RETURNS trigger AS $$
+BEGIN
+    IF TG_TABLE_NAME = 'dns_zones' THEN
+        PERFORM pg_notify('clouddns_changes', json_build_object(
+            'table', TG_TABLE_NAME, 'op', TG_OP,
+            'zone_id', COALESCE(NEW.id, OLD.id), 'zone', COALESCE(NEW.name, OLD.name))::text);
+        RETURN NULL;
+    END IF;
+
+    -- A target's addresses answer for records of any zone
+    IF TG_TABLE_NAME = 'dns_targets' THEN
+        PERFORM pg_notify('clouddns_changes', json_build_object('table', TG_TABLE_NAME, 'op', TG_OP)::text);
+        RETURN NULL;
+    END IF;
+
+    -- A record's health decides whether it is served, so only a change of
+    -- status is announced, for the record it belongs to
+    IF TG_TABLE_NAME = 'record_health' THEN
+        IF TG_OP = 'UPDATE' AND OLD.status IS NOT DISTINCT FROM NEW.status THEN
+            RETURN NULL;
+        END IF;
+        PERFORM pg_notify('clouddns_changes', json_build_object(
+            'table', TG_TABLE_NAME, 'op', TG_OP, 'zone_id', r.zone_id,
+            'zone', (SELECT name FROM dns_zones WHERE id = r.zone_id),
+            'name', r.name, 'type', r.type)::text)
+        FROM dns_records r WHERE r.id = NEW.record_id;
+        RETURN NULL;
+    END IF;
+
+    -- A health check's status decides whether the records referencing it
+    -- are served
+    IF TG_TABLE_NAME = 'health_checks' THEN
+        IF OLD.status IS DISTINCT FROM NEW.status THEN
+            PERFORM pg_notify('clouddns_changes', json_build_object(
+                'table', TG_TABLE_NAME, 'op', TG_OP, 'zone_id', r.zone_id,
+                'zone', (SELECT name FROM dns_zones WHERE id = r.zone_id),
+                'name', r.name, 'type', r.type)::text)
+            FROM dns_records r WHERE r.health_check_id = NEW.id;
+        END IF;
+        RETURN NULL;
+    END IF;
+
+    -- A default record may answer any name of its zone
+    IF TG_TABLE_NAME = 'dns_default_records' THEN
+        PERFORM pg_notify('clouddns_changes', json_build_object(
+            'table', TG_TABLE_NAME, 'op', TG_OP, 'zone_id', COALESCE(NEW.zone_id, OLD.zone_id),
+            'zone', (SELECT name FROM dns_zones WHERE id = COALESCE(NEW.zone_id, OLD.zone_id)))::text);
+        RETURN NULL;
+    END IF;
+
+    IF TG_OP <> 'INSERT' THEN
+        PERFORM pg_notify('clouddns_changes', json_build_object(
+            'table', TG_TABLE_NAME, 'op', TG_OP, 'zone_id', OLD.zone_id,
+            'zone', (SELECT name FROM dns_zones WHERE id = OLD.zone_id),
+            'name', OLD.name, 'type', OLD.type)::text);
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        PERFORM pg_notify('clouddns_changes', json_build_object(
+            'table', TG_TABLE_NAME, 'op', TG_OP, 'zone_id', NEW.zone_id,
+            'zone', (SELECT name FROM dns_zones WHERE id = NEW.zone_id),
+            'name', NEW.name, 'type', NEW.type)::text);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS dns_records_notify_change ON dns_records;
+CREATE TRIGGER dns_records_notify_change
+    AFTER INSERT OR UPDATE OR DELETE ON dns_records
+    FOR EACH ROW EXECUTE FUNCTION clouddns_notify_change();
+
+DROP TRIGGER IF EXISTS dns_zones_notify_change ON dns_zones;
+CREATE TRIGGER dns_zones_notify_change
+    AFTER INSERT OR UPDATE OR DELETE ON dns_zones
+    FOR EACH ROW EXECUTE FUNCTION clouddns_notify_change();
+
+DROP TRIGGER IF EXISTS dns_default_records_notify_change ON dns_default_records;
+CREATE TRIGGER dns_default_records_notify_change
+    AFTER INSERT OR UPDATE OR DELETE ON dns_default_records
+    FOR EACH ROW EXECUTE FUNCTION clouddns_notify_change();
+
+DROP TRIGGER IF EXISTS dns_targets_notify_change ON dns_targets;
+CREATE TRIGGER dns_targets_notify_change
+    AFTER INSERT OR UPDATE OR DELETE ON dns_targets
+    FOR EACH ROW EXECUTE FUNCTION clouddns_notify_change();
+
+DROP TRIGGER IF EXISTS record_health_notify_change ON record_health;
+CREATE TRIGGER record_health_notify_change
+    AFTER INSERT OR UPDATE ON record_health
+    FOR EACH ROW EXECUTE FUNCTION clouddns_notify_change();
+
+DROP TRIGGER IF EXISTS health_checks_notify_change ON health_checks;
+CREATE TRIGGER health_checks_notify_change
+    AFTER UPDATE ON health_checks
+    FOR EACH ROW EXECUTE FUNCTION clouddns_notify_change();
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+func TestSVCBConverters(t *testing.T) {
+	priority := 1
+	original := domain.Record{
+		Name:     "example.com.",
+		Type:     domain.TypeHTTPS,
+		Content:  "svc.example.com. ipv4hint=192.0.2.1 alpn=h2,h3",
+		TTL:      300,
+		Priority: &priority,
+	}
+
+	pRec, err := ConvertDomainToPacketRecord(original)
+	if err != nil {
+		t.Fatalf("ConvertDomainToPacketRecord failed: %v", err)
+	}
+	if pRec.Type != packet.HTTPS || pRec.Priority != 1 || pRec.Host != "svc.example.com." || len(pRec.SvcParams) != 2 {
+		t.Fatalf("Unexpected packet record %+v", pRec)
+	}
+
+	decoded, err := ConvertPacketRecordToDomain(pRec, "zone-123")
+	if err != nil {
+		t.Fatalf("ConvertPacketRecordToDomain failed: %v", err)
+	}
+	if decoded.Type != domain.TypeHTTPS || decoded.Priority == nil || *decoded.Priority != 1 {
+		t.Errorf("Unexpected domain record %+v", decoded)
+	}
+	if want := "svc.example.com. alpn=h2,h3 ipv4hint=192.0.2.1"; decoded.Content != want {
+		t.Errorf("Content mismatch: got %q, want %q", decoded.Content, want)
+	}
+
+	// Zone-file content carries the priority inline
+	zoneFile := domain.Record{Name: "_8443._foo.example.com.", Type: domain.TypeSVCB, Content: "0 svc.example.net."}
+	if pRec, err := ConvertDomainToPacketRecord(zoneFile); err != nil || pRec.Type != packet.SVCB || pRec.Priority != 0 || pRec.Host != "svc.example.net." {
+		t.Errorf("Unexpected zone-file conversion %+v, %v", pRec, err)
+	}
+
+	for _, content := range []string{"", "svc.example.com. color=red"} {
+		bad := domain.Record{Name: "example.com.", Type: domain.TypeHTTPS, Content: content, Priority: &priority}
+		if _, err := ConvertDomainToPacketRecord(bad); err == nil {
+			t.Errorf("Expected content %q to be rejected", content)
+		}
+	}
+}
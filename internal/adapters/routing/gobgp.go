@@ -23,6 +23,7 @@ type BGPBackend interface {
 	AddPeer(ctx context.Context, r *pb.AddPeerRequest) error
 	AddPath(req apiutil.AddPathRequest) ([]apiutil.AddPathResponse, error)
 	DeletePath(req apiutil.DeletePathRequest) error
+	ListPeer(ctx context.Context, r *pb.ListPeerRequest, fn func(*pb.Peer)) error
 }
 
 // GoBGPAdapter implements the RoutingEngine port using GoBGP.
@@ -183,6 +184,28 @@ func (a *GoBGPAdapter) Withdraw(_ context.Context, vip string) error {
 	return nil
 }
 
+// SessionEstablished returns nil if at least one configured peer has an
+// established BGP session.
+func (a *GoBGPAdapter) SessionEstablished(ctx context.Context) error {
+	if a.bgpServer == nil {
+		return errors.New("BGP server not started")
+	}
+
+	established := false
+	err := a.bgpServer.ListPeer(ctx, &pb.ListPeerRequest{}, func(p *pb.Peer) {
+		if p.GetState().GetSessionState() == pb.PeerState_SESSION_STATE_ESTABLISHED {
+			established = true
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list BGP peers: %w", err)
+	}
+	if !established {
+		return errors.New("no established BGP session")
+	}
+	return nil
+}
+
 // Stop gracefully shuts down the BGP engine.
 func (a *GoBGPAdapter) Stop() error {
 	if a.bgpServer != nil {
@@ -15,6 +15,8 @@ type mockBGPBackend struct {
 	failDeletePath bool
 	failAddPeer    bool
 	failStartBgp   bool
+	failListPeer   bool
+	peerState      pb.PeerState_SessionState
 }
 
 func (m *mockBGPBackend) Serve() {}
@@ -44,6 +46,14 @@ func (m *mockBGPBackend) DeletePath(_ apiutil.DeletePathRequest) error {
 	return nil
 }
 
+func (m *mockBGPBackend) ListPeer(_ context.Context, _ *pb.ListPeerRequest, fn func(*pb.Peer)) error {
+	if m.failListPeer {
+		return errors.New("list peer failed")
+	}
+	fn(&pb.Peer{State: &pb.PeerState{SessionState: m.peerState}})
+	return nil
+}
+
 func TestGoBGPAdapter_Mocked(t *testing.T) {
 	mock := &mockBGPBackend{}
 	adapter := &GoBGPAdapter{
@@ -97,3 +107,28 @@ func TestNewGoBGPAdapter(t *testing.T) {
 		t.Fatal("NewGoBGPAdapter failed")
 	}
 }
+
+func TestGoBGPAdapter_SessionEstablished(t *testing.T) {
+	mock := &mockBGPBackend{peerState: pb.PeerState_SESSION_STATE_ACTIVE}
+	adapter := &GoBGPAdapter{bgpServer: mock, logger: slog.Default()}
+	ctx := context.Background()
+
+	if err := adapter.SessionEstablished(ctx); err == nil {
+		t.Error("expected error while session is not established")
+	}
+
+	mock.peerState = pb.PeerState_SESSION_STATE_ESTABLISHED
+	if err := adapter.SessionEstablished(ctx); err != nil {
+		t.Errorf("expected established session, got %v", err)
+	}
+
+	mock.failListPeer = true
+	if err := adapter.SessionEstablished(ctx); err == nil {
+		t.Error("expected error when listing peers fails")
+	}
+
+	nilAdapter := &GoBGPAdapter{logger: slog.Default()}
+	if err := nilAdapter.SessionEstablished(ctx); err == nil {
+		t.Error("expected error when BGP server is not started")
+	}
+}
@@ -0,0 +1,26 @@
+package domain
+
+import "testing"
+
+func TestValidateALIASContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{"Load balancer", "lb-123.elb.example.net.", false},
+		{"Surrounding space", " cdn.example.net. ", false},
+		{"Relative", "cdn.example.net", true},
+		{"Root", ".", true},
+		{"Empty", "", true},
+		{"Bad label", "cdn_edge!.example.net.", true},
+		{"Address", "192.0.2.1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateALIASContent(tt.content); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateALIASContent(%q) error = %v, wantErr %v", tt.content, err, tt.wantErr)
+			}
+		})
+	}
+}
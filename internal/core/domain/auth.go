@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"context"
+	"encoding/json"
 	"time"
 )
 
@@ -9,8 +11,56 @@ type Role string
 const (
 	RoleAdmin  Role = "admin"  // Full CRUD on all zones/records
 	RoleReader Role = "reader" // GET-only access
+	// RoleBreakGlass has admin access and may also change zones during a change
+	// freeze; every such change is audited.
+	RoleBreakGlass Role = "break_glass"
+	// RoleApprover reviews the change requests of zones under review. It can
+	// read but not change zones itself.
+	RoleApprover Role = "approver"
 )
 
+type breakGlassKey struct{}
+
+// WithBreakGlass marks ctx as acting for a break-glass key, which bypasses
+// change freezes.
+func WithBreakGlass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, breakGlassKey{}, true)
+}
+
+// IsBreakGlass reports whether ctx acts for a break-glass key.
+func IsBreakGlass(ctx context.Context) bool {
+	v, _ := ctx.Value(breakGlassKey{}).(bool)
+	return v
+}
+
+type forceKey struct{}
+
+// WithForce marks ctx as confirming a destructive change, such as deleting a
+// protected record.
+func WithForce(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceKey{}, true)
+}
+
+// IsForced reports whether ctx confirms a destructive change.
+func IsForced(ctx context.Context) bool {
+	v, _ := ctx.Value(forceKey{}).(bool)
+	return v
+}
+
+type approvedChangeKey struct{}
+
+// WithApprovedChange marks ctx as applying an approved change request, which
+// passes the review of a zone under review.
+func WithApprovedChange(ctx context.Context) context.Context {
+	return context.WithValue(ctx, approvedChangeKey{}, true)
+}
+
+// IsApprovedChange reports whether ctx applies an approved change request.
+func IsApprovedChange(ctx context.Context) bool {
+	v, _ := ctx.Value(approvedChangeKey{}).(bool)
+	return v
+}
+
 type APIKey struct {
 	ID        string     `json:"id"`
 	TenantID  string     `json:"tenant_id"`
@@ -22,3 +72,80 @@ type APIKey struct {
 	CreatedAt time.Time  `json:"created_at"`
 	ExpiresAt *time.Time `json:"expires_at,omitempty"`
 }
+
+// Kinds of APIAnomaly.
+const (
+	// APIAnomalyRequestSpike is a key making many times its usual request rate.
+	APIAnomalyRequestSpike = "request_spike"
+	// APIAnomalyAuthFailures is a burst of rejected credentials from one client.
+	APIAnomalyAuthFailures = "auth_failures"
+)
+
+// APIKeyUsage summarizes the requests made with an API key over the last hour.
+type APIKeyUsage struct {
+	KeyID     string `json:"key_id"`
+	Name      string `json:"name"`
+	KeyPrefix string `json:"key_prefix"`
+	Requests  uint64 `json:"requests"`
+	// Errors counts requests answered with a 4xx or 5xx status.
+	Errors    uint64  `json:"errors"`
+	ErrorRate float64 `json:"error_rate"`
+	// RequestsLastMinute is the rate compared against BaselinePerMinute, the
+	// average of the preceding minutes, to detect spikes.
+	RequestsLastMinute uint64    `json:"requests_last_minute"`
+	BaselinePerMinute  float64   `json:"baseline_per_minute"`
+	LastSeen           time.Time `json:"last_seen"`
+}
+
+// APIAnomaly is unusual API activity that may mean a key has leaked.
+type APIAnomaly struct {
+	Kind      string `json:"kind"`
+	TenantID  string `json:"tenant_id,omitempty"`
+	KeyID     string `json:"key_id,omitempty"`
+	KeyPrefix string `json:"key_prefix,omitempty"`
+	ClientIP  string `json:"client_ip,omitempty"`
+	// Count is the number of requests or failures in the minute that tripped
+	// the detector; Baseline is the usual rate per minute for spikes.
+	Count      uint64    `json:"count"`
+	Baseline   float64   `json:"baseline,omitempty"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// AuthBan is a client temporarily refused API access after presenting too many
+// invalid keys. Each ban of the same client lasts twice as long as the last.
+type AuthBan struct {
+	ClientIP    string    `json:"client_ip"`
+	Failures    int       `json:"failures"`
+	Strikes     int       `json:"strikes"`
+	BannedUntil time.Time `json:"banned_until"`
+}
+
+// AuthorizationRequest is an authenticated API request put to an external
+// authorizer, such as an OPA policy or a webhook.
+type AuthorizationRequest struct {
+	TenantID string `json:"tenant_id"`
+	KeyID    string `json:"key_id"`
+	KeyName  string `json:"key_name,omitempty"`
+	Role     Role   `json:"role"`
+	// Action is the route matched, such as "POST /zones/{id}/records".
+	Action string `json:"action"`
+	// Resource is the request path and Params the values of the route's
+	// wildcards, such as {"id": "<zone id>"}.
+	Resource string            `json:"resource"`
+	Params   map[string]string `json:"params,omitempty"`
+	ClientIP string            `json:"client_ip"`
+	// ContentType is the request's Content-Type header.
+	ContentType string `json:"content_type,omitempty"`
+	// Body is the request body when it is a JSON document of at most 1 MiB,
+	// such as the record being created. BodyOmitted is set when the request
+	// has a body that is left out for being larger or not JSON, such as a
+	// zone file, so a policy that needs to see the body can deny it.
+	Body        json.RawMessage `json:"body,omitempty"`
+	BodyOmitted bool            `json:"body_omitted,omitempty"`
+}
+
+// AuthorizationDecision is an external authorizer's answer to a request.
+type AuthorizationDecision struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"`
+}
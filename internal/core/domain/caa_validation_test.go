@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"testing"
+)
+
+func TestParseCAAContent(t *testing.T) {
+	flags, tag, value, err := ParseCAAContent(`128  issue   "ca.example.net; account=230123"`)
+	if err != nil {
+		t.Fatalf("ParseCAAContent failed: %v", err)
+	}
+	if flags != 128 || tag != "issue" || value != "ca.example.net; account=230123" {
+		t.Errorf("Unexpected result %d %q %q", flags, tag, value)
+	}
+}
+
+func TestValidateCAAContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{"Issue", `0 issue "letsencrypt.org"`, false},
+		{"Issue unquoted", `0 issue letsencrypt.org`, false},
+		{"Forbid issuance", `0 issue ";"`, false},
+		{"Issuewild with params", `0 issuewild "ca.example.net; policy=ev"`, false},
+		{"Iodef mailto", `0 iodef "mailto:security@example.com"`, false},
+		{"Iodef https", `0 iodef "https://iodef.example.com/"`, false},
+		{"Unknown tag", `0 contactemail "admin@example.com"`, false},
+		{"Too few parts", `0 issue`, true},
+		{"Flags out of range", `256 issue "ca.example.net"`, true},
+		{"Non-numeric flags", `x issue "ca.example.net"`, true},
+		{"Tag too long", `0 abcdefghijklmnop "x"`, true},
+		{"Tag with hyphen", `0 is-sue "x"`, true},
+		{"Bad issuer", `0 issue "not a domain"`, true},
+		{"Bad iodef scheme", `0 iodef "ftp://example.com/"`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateCAAContent(tt.content); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCAAContent(%s) error = %v, wantErr %v", tt.content, err, tt.wantErr)
+			}
+		})
+	}
+}
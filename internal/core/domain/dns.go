@@ -1,6 +1,12 @@
 package domain
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
 	"time"
 )
 
@@ -26,6 +32,28 @@ const (
 	TypePTR RecordType = "PTR"
 	// TypeSRV represents a service locator record (RFC 2782).
 	TypeSRV RecordType = "SRV"
+	// TypeSVCB represents a service binding record (RFC 9460).
+	TypeSVCB RecordType = "SVCB"
+	// TypeHTTPS represents a service binding record for HTTPS origins (RFC 9460).
+	TypeHTTPS RecordType = "HTTPS"
+	// TypeCAA represents a certification authority authorization record (RFC 8659).
+	TypeCAA RecordType = "CAA"
+	// TypeNAPTR represents a naming authority pointer record (RFC 3403).
+	TypeNAPTR RecordType = "NAPTR"
+	// TypeTLSA represents a TLS certificate association record for DANE (RFC 6698).
+	TypeTLSA RecordType = "TLSA"
+	// TypeSSHFP represents an SSH host key fingerprint record (RFC 4255).
+	TypeSSHFP RecordType = "SSHFP"
+	// TypeLOC represents a geographic location record (RFC 1876).
+	TypeLOC RecordType = "LOC"
+	// TypeURI represents a URI record (RFC 7553).
+	TypeURI RecordType = "URI"
+	// TypeCERT represents a certificate record (RFC 4398).
+	TypeCERT RecordType = "CERT"
+	// TypeALIAS represents an alias to another name's addresses, allowed at the
+	// zone apex where a CNAME is not. It has no wire format: A and AAAA queries
+	// are answered with the target's addresses under the alias's owner name.
+	TypeALIAS RecordType = "ALIAS"
 )
 
 // HealthCheckType represents the method used to verify endpoint health.
@@ -48,15 +76,22 @@ const (
 
 // Zone represents a DNS zone.
 type Zone struct {
-	ID           string    `json:"id"`
-	TenantID     string    `json:"tenant_id"`
-	Name         string    `json:"name"` // e.g., example.com.
-	VPCID        *string   `json:"vpc_id,omitempty"`
-	Description  string    `json:"description"`
-	Role         string    `json:"role,omitempty"`          // "master" or "slave"
-	MasterServer string    `json:"master_server,omitempty"` // IP/hostname of master (for slaves)
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID            string    `json:"id"`
+	TenantID      string    `json:"tenant_id"`
+	Name          string    `json:"name"` // e.g., example.com.
+	VPCID         *string   `json:"vpc_id,omitempty"`
+	Description   string    `json:"description"`
+	Role          string    `json:"role,omitempty"`           // "master" or "slave"
+	MasterServer  string    `json:"master_server,omitempty"`  // IP/hostname of master (for slaves)
+	DNSSECEnabled *bool     `json:"dnssec_enabled,omitempty"` // nil means enabled
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// SignsDNSSEC reports whether the zone has opted in to DNSSEC, so its keys are
+// managed and its answers signed.
+func (z *Zone) SignsDNSSEC() bool {
+	return z.DNSSECEnabled == nil || *z.DNSSECEnabled
 }
 
 // Record represents a DNS resource record within a zone.
@@ -68,16 +103,21 @@ type Record struct {
 	Type      RecordType `json:"type"`
 	Content   string     `json:"content"`
 	TTL       int        `json:"ttl"`
-	Priority  *int       `json:"priority,omitempty"` // For MX, SRV records
-	Weight    *int       `json:"weight,omitempty"`   // For SRV records
-	Port      *int       `json:"port,omitempty"`     // For SRV records
-	Network   *string    `json:"network,omitempty"`  // CIDR or Scope (e.g., "10.0.0.0/8" or "public")
+	Priority  *int       `json:"priority,omitempty"`  // For MX, SRV, SVCB and HTTPS records
+	Weight    *int       `json:"weight,omitempty"`    // For SRV records
+	Port      *int       `json:"port,omitempty"`      // For SRV records
+	Network   *string    `json:"network,omitempty"`   // CIDR or Scope (e.g., "10.0.0.0/8" or "public")
+	ViewID    *string    `json:"view_id,omitempty"`   // Only served to clients in this view
+	Geo       *GeoPolicy `json:"geo,omitempty"`       // Served to clients in these locations
+	TargetID  *string    `json:"target_id,omitempty"` // Addresses come from this target
+	Protected bool       `json:"protected,omitempty"` // Deleting it needs force and a break-glass key
 	CreatedAt time.Time  `json:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at"`
 
 	// Smart Engine (GSLB) fields
 	HealthCheckType   HealthCheckType `json:"health_check_type,omitempty"`
 	HealthCheckTarget string          `json:"health_check_target,omitempty"`
+	HealthCheckID     *string         `json:"health_check_id,omitempty"` // Health comes from this check
 	HealthStatus      HealthStatus    `json:"health_status,omitempty"`
 }
 
@@ -97,6 +137,18 @@ type ZoneChange struct {
 	CreatedAt time.Time  `json:"created_at"`
 }
 
+// DataChange is a committed record or zone mutation announced by the database,
+// so every node can drop stale cache entries without polling. Name and Type are
+// empty for zone mutations.
+type DataChange struct {
+	Table  string     `json:"table"` // "dns_records", "dns_zones", "dns_default_records", "dns_targets", "record_health" or "health_checks"
+	Op     string     `json:"op"`    // "INSERT", "UPDATE" or "DELETE"
+	ZoneID string     `json:"zone_id"`
+	Zone   string     `json:"zone"`
+	Name   string     `json:"name,omitempty"`
+	Type   RecordType `json:"type,omitempty"`
+}
+
 // IXFRChunk represents a single transactional update in an IXFR sequence.
 // It consists of the SOA before the change (Deleted) and the SOA after (Added),
 // along with the list of deleted and added records.
@@ -128,4 +180,1101 @@ type DNSSECKey struct {
 	Active     bool      `json:"active"`
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
+	External   bool      `json:"external,omitempty"` // another signer's key (RFC 8901): published, never signs
+}
+
+// DNSSECKeyInfo is the public view of a zone's DNSSEC key, as served by the API.
+type DNSSECKeyInfo struct {
+	ID        string    `json:"id"`
+	ZoneID    string    `json:"zone_id"`
+	KeyType   string    `json:"key_type"`
+	Algorithm int       `json:"algorithm"`
+	Flags     uint16    `json:"flags"`
+	KeyTag    uint16    `json:"key_tag"`
+	PublicKey []byte    `json:"public_key"`
+	Active    bool      `json:"active"`
+	External  bool      `json:"external,omitempty"` // another signer's key (RFC 8901)
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DSRecord is a delegation signer record for one of a zone's KSKs, ready to be
+// handed to the parent zone.
+type DSRecord struct {
+	KeyID      string `json:"key_id"`
+	KeyTag     uint16 `json:"key_tag"`
+	Algorithm  int    `json:"algorithm"`
+	DigestType int    `json:"digest_type"`
+	Digest     string `json:"digest"`
+	Record     string `json:"record"` // presentation format, e.g. "example.com. 3600 IN DS ..."
+}
+
+// DNSSECKeyFiles is a DNSSEC key in BIND's file pair format, named
+// K<zone>+<algorithm>+<key tag>.
+type DNSSECKeyFiles struct {
+	Name       string `json:"name,omitempty"` // e.g. "Kexample.com.+013+12345"
+	PublicKey  string `json:"public_key"`     // contents of the .key file
+	PrivateKey string `json:"private_key"`    // contents of the .private file
+}
+
+// RRset is every record of one name and type in a zone, the unit DNS answers
+// with. All of its records share one TTL.
+type RRset struct {
+	ZoneID  string        `json:"zone_id"`
+	Name    string        `json:"name"`
+	Type    RecordType    `json:"type"`
+	TTL     int           `json:"ttl"`
+	Records []RRsetRecord `json:"records"`
+}
+
+// RRsetRecord is one record of an RRset.
+type RRsetRecord struct {
+	Content           string          `json:"content"`
+	Priority          *int            `json:"priority,omitempty"`
+	Weight            *int            `json:"weight,omitempty"`
+	Port              *int            `json:"port,omitempty"`
+	Network           *string         `json:"network,omitempty"`
+	HealthCheckType   HealthCheckType `json:"health_check_type,omitempty"`
+	HealthCheckTarget string          `json:"health_check_target,omitempty"`
+	HealthStatus      HealthStatus    `json:"health_status,omitempty"`
+}
+
+// ErrInvalidRRset is returned when an RRset is malformed or does not belong to
+// its zone.
+var ErrInvalidRRset = errors.New("invalid rrset")
+
+// RecordImportRow is the validation result of one row of a bulk record import.
+// Row counts from 1, not including a CSV header.
+type RecordImportRow struct {
+	Row    int        `json:"row"`
+	Name   string     `json:"name"`
+	Type   RecordType `json:"type"`
+	Status string     `json:"status"` // "created", "valid" on a dry run, or "invalid"
+	Error  string     `json:"error,omitempty"`
+}
+
+// RecordImportResult reports a bulk record import. An import is all or nothing:
+// when any row is invalid, Created is zero and the invalid rows carry their
+// errors.
+type RecordImportResult struct {
+	ZoneID  string            `json:"zone_id"`
+	DryRun  bool              `json:"dry_run"`
+	Created int               `json:"created"`
+	Invalid int               `json:"invalid"`
+	Rows    []RecordImportRow `json:"rows"`
+}
+
+// ErrInvalidRecordImport is returned when a bulk record import cannot be read or
+// has invalid rows.
+var ErrInvalidRecordImport = errors.New("invalid record import")
+
+// ErrInvalidZoneImport is returned when a zone file or the template variables
+// it is imported with cannot be used.
+var ErrInvalidZoneImport = errors.New("invalid zone import")
+
+// ZoneDiff is the structured difference between a zone and a baseline: another
+// zone or the same zone at an earlier serial. Added and Removed hold whole RRsets
+// that exist on only one side; Changed holds RRsets present on both sides whose
+// records or TTL differ.
+type ZoneDiff struct {
+	ZoneID        string           `json:"zone_id"`
+	ZoneName      string           `json:"zone_name"`
+	AgainstZoneID string           `json:"against_zone_id,omitempty"`
+	AgainstName   string           `json:"against_name,omitempty"`
+	AgainstSerial *uint32          `json:"against_serial,omitempty"`
+	Added         []RRset          `json:"added"`
+	Removed       []RRset          `json:"removed"`
+	Changed       []ZoneDiffChange `json:"changed"`
+}
+
+// ZoneDiffChange is an RRset that differs between a zone and its baseline.
+type ZoneDiffChange struct {
+	Name    string        `json:"name"`
+	Type    RecordType    `json:"type"`
+	OldTTL  int           `json:"old_ttl"`
+	NewTTL  int           `json:"new_ttl"`
+	Added   []RRsetRecord `json:"added,omitempty"`
+	Removed []RRsetRecord `json:"removed,omitempty"`
+}
+
+// ZoneChangeFeed is one page of a zone's change journal, the changes IXFR serves,
+// after SinceSerial. Every serial's changes are kept on the same page, and
+// NextSerial is the since_serial to ask for the following page or to wait on.
+type ZoneChangeFeed struct {
+	ZoneID      string       `json:"zone_id"`
+	ZoneName    string       `json:"zone_name"`
+	SinceSerial uint32       `json:"since_serial"`
+	NextSerial  uint32       `json:"next_serial"`
+	HasMore     bool         `json:"has_more"`
+	Changes     []ZoneChange `json:"changes"`
+}
+
+// ZoneCloneOptions controls how a zone is copied into a new zone.
+type ZoneCloneOptions struct {
+	Name           string // New origin; empty keeps the source zone's name
+	TargetTenantID string // Tenant that owns the copy; empty means the source tenant
+	CopyDNSSEC     bool   // Keep the source's DNSSEC setting instead of creating the copy unsigned
+}
+
+// ErrNotFound is returned when a requested resource does not exist for the tenant.
+var ErrNotFound = errors.New("not found")
+
+// ErrRecordProtected is returned when deleting a protected record without force
+// and a break-glass key, or an SOA record through the record API.
+var ErrRecordProtected = errors.New("record is protected")
+
+// ErrInvalidKeyOperation is returned when a DNSSEC key request is malformed.
+var ErrInvalidKeyOperation = errors.New("invalid key operation")
+
+// ErrKeyInUse is returned when deactivating a key would leave a signed zone
+// without an active key of that type.
+var ErrKeyInUse = errors.New("key is the last active key of its type")
+
+// ErrKeyExportDisabled is returned when private key export has not been enabled.
+var ErrKeyExportDisabled = errors.New("DNSSEC key export is disabled")
+
+// ErrInvalidZoneRole is returned when an operation does not apply to the zone's
+// role, such as pulling a transfer for a primary zone.
+var ErrInvalidZoneRole = errors.New("operation not valid for zone role")
+
+// ErrTransferFailed is returned when the master could not be reached or the
+// transfer from it failed.
+var ErrTransferFailed = errors.New("zone transfer failed")
+
+// ZoneTransferResult is the outcome of refreshing a secondary zone from its master.
+type ZoneTransferResult struct {
+	ZoneID       string `json:"zone_id"`
+	Zone         string `json:"zone"`
+	Master       string `json:"master"`
+	LocalSerial  uint32 `json:"local_serial"`
+	MasterSerial uint32 `json:"master_serial"`
+	Method       string `json:"method"` // "none" when already up to date, "IXFR" or "AXFR"
+}
+
+// NotifyTarget is a secondary server a NOTIFY was sent to.
+type NotifyTarget struct {
+	NameServer string `json:"name_server"`
+	Address    string `json:"address"`
+	Status     string `json:"status"` // "acknowledged", "sent" (no reply in time) or "failed"
+	Error      string `json:"error,omitempty"`
+}
+
+// SecondaryProvider is an external secondary server, such as a customer-run BIND
+// or NSD, that is sent NOTIFY after zone changes and transfers the zone from us.
+type SecondaryProvider struct {
+	ID             string     `json:"id"`
+	ZoneID         string     `json:"zone_id"`
+	Name           string     `json:"name"`
+	Address        string     `json:"address"` // host:port NOTIFY and SOA checks are sent to
+	TSIGKeyName    string     `json:"tsig_key_name,omitempty"`
+	TSIGSecret     string     `json:"-"` // base64 HMAC secret, never returned by the API
+	ObservedSerial uint32     `json:"observed_serial"`
+	InSync         bool       `json:"in_sync"` // observed serial matches the zone's current serial
+	LastNotifiedAt *time.Time `json:"last_notified_at,omitempty"`
+	LastCheckedAt  *time.Time `json:"last_checked_at,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// ErrInvalidSecondary is returned when a secondary provider definition is malformed.
+var ErrInvalidSecondary = errors.New("invalid secondary provider")
+
+// Scheduled change actions and states.
+const (
+	ScheduledCreate = "CREATE"
+	ScheduledUpdate = "UPDATE"
+	ScheduledDelete = "DELETE"
+
+	ScheduledPending   = "pending"
+	ScheduledRunning   = "running"
+	ScheduledDone      = "done"
+	ScheduledFailed    = "failed"
+	ScheduledCancelled = "cancelled"
+)
+
+// ScheduledChange is a record change held back until RunAt, for cutovers planned
+// at low-traffic hours. CREATE adds Record, UPDATE replaces the record RecordID
+// with Record, and DELETE removes RecordID.
+type ScheduledChange struct {
+	ID         string     `json:"id"`
+	ZoneID     string     `json:"zone_id"`
+	TenantID   string     `json:"-"`
+	Action     string     `json:"action"`
+	RecordID   string     `json:"record_id,omitempty"`
+	Record     *Record    `json:"record,omitempty"`
+	RunAt      time.Time  `json:"run_at"`
+	Status     string     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExecutedAt *time.Time `json:"executed_at,omitempty"`
+}
+
+// ErrInvalidScheduledChange is returned when a scheduled change is malformed or
+// can no longer be cancelled.
+var ErrInvalidScheduledChange = errors.New("invalid scheduled change")
+
+// ShadowChange is a set of RRset replacements staged for a zone but not served.
+// For live queries of the names and types it replaces, DNS servers work out the
+// answer it would give and log where that differs from the answer served, so a
+// large edit can be checked against real traffic before it is committed.
+// Clients in CanaryNetworks are served the staged answers instead.
+type ShadowChange struct {
+	ZoneID         string    `json:"zone_id"`
+	TenantID       string    `json:"-"`
+	Description    string    `json:"description,omitempty"`
+	RRsets         []RRset   `json:"rrsets"`
+	CanaryNetworks []string  `json:"canary_networks,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// ErrInvalidShadowChange is returned when a shadow change is malformed.
+var ErrInvalidShadowChange = errors.New("invalid shadow change")
+
+// ZoneSLO is a tenant's response time objective for a zone: the given
+// Percentile of its query latencies over the last WindowMinutes must stay
+// within ThresholdMs.
+type ZoneSLO struct {
+	ZoneID        string    `json:"zone_id"`
+	TenantID      string    `json:"-"`
+	Percentile    float64   `json:"percentile"`
+	ThresholdMs   float64   `json:"threshold_ms"`
+	WindowMinutes int       `json:"window_minutes"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ErrInvalidSLO is returned when a zone SLO is malformed.
+var ErrInvalidSLO = errors.New("invalid SLO")
+
+// QueryLimit caps the queries a node answers for a tenant per second, across
+// all its zones when ZoneID is empty or for one zone otherwise. Burst is the
+// number of queries allowed at once above the steady rate.
+type QueryLimit struct {
+	TenantID  string    `json:"tenant_id"`
+	ZoneID    string    `json:"zone_id,omitempty"`
+	QPS       float64   `json:"qps"`
+	Burst     int       `json:"burst"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ErrInvalidQueryLimit is returned when a query limit is malformed.
+var ErrInvalidQueryLimit = errors.New("invalid query limit")
+
+// View is a named set of client networks a tenant serves a variant of its
+// zones to. A client is in the tenant's view of lowest Priority whose networks
+// contain its address, and is answered with the records of that view in place
+// of the shared records of the same name and type.
+type View struct {
+	ID        string    `json:"id"`
+	TenantID  string    `json:"-"`
+	Name      string    `json:"name"`
+	Networks  []string  `json:"networks"`
+	Priority  int       `json:"priority"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ErrInvalidView is returned when a view is malformed or its name is taken.
+var ErrInvalidView = errors.New("invalid view")
+
+// Target is a named set of addresses, such as an anycast VIP pool, that A and
+// AAAA records of the tenant reference by TargetID in place of their content.
+// A record answers with the target's addresses of its family, so updating the
+// target moves every record referencing it at once.
+type Target struct {
+	ID        string    `json:"id"`
+	TenantID  string    `json:"-"`
+	Name      string    `json:"name"`
+	Addresses []string  `json:"addresses"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ErrInvalidTarget is returned when a target is malformed, its name is taken,
+// it is deleted while records reference it, or a record references it wrongly.
+var ErrInvalidTarget = errors.New("invalid target")
+
+// HealthCheckProtocol is how a health check probes its endpoint.
+type HealthCheckProtocol string
+
+const (
+	HealthCheckProtocolHTTP  HealthCheckProtocol = "HTTP"
+	HealthCheckProtocolHTTPS HealthCheckProtocol = "HTTPS"
+	HealthCheckProtocolTCP   HealthCheckProtocol = "TCP"
+	HealthCheckProtocolGRPC  HealthCheckProtocol = "GRPC" // grpc.health.v1 Check
+)
+
+// HealthCheck probes an endpoint every Interval seconds for the records of
+// the tenant that reference it by HealthCheckID; they are left out of answers
+// while it is unhealthy. Status turns unhealthy after FailureThreshold failed
+// probes in a row and healthy again after SuccessThreshold passed ones.
+type HealthCheck struct {
+	ID               string              `json:"id"`
+	TenantID         string              `json:"-"`
+	Name             string              `json:"name"`
+	Protocol         HealthCheckProtocol `json:"protocol"`
+	Target           string              `json:"target"`                    // host:port, or host for HTTP(S)
+	Path             string              `json:"path,omitempty"`            // HTTP(S) path, or the gRPC service
+	ExpectedStatus   int                 `json:"expected_status,omitempty"` // 0 accepts any 2xx or 3xx
+	ExpectedBody     string              `json:"expected_body,omitempty"`   // Must appear in the HTTP(S) body
+	Interval         int                 `json:"interval"`                  // Seconds between probes
+	Timeout          int                 `json:"timeout"`                   // Seconds a probe may take
+	FailureThreshold int                 `json:"failure_threshold"`
+	SuccessThreshold int                 `json:"success_threshold"`
+	Status           HealthStatus        `json:"status"`
+	LastError        string              `json:"last_error,omitempty"`
+	StatusChangedAt  *time.Time          `json:"status_changed_at,omitempty"`
+	CreatedAt        time.Time           `json:"created_at"`
+	UpdatedAt        time.Time           `json:"updated_at"`
+}
+
+// ErrInvalidHealthCheck is returned when a health check is malformed, its
+// name is taken, it is deleted while records reference it, or a record
+// references a check its tenant does not have.
+var ErrInvalidHealthCheck = errors.New("invalid health check")
+
+// Subnet is a network a tenant allocates addresses from. Allocating an address
+// creates an A or AAAA record in ZoneID named by NameTemplate, and a PTR record
+// in the tenant's reverse zone of the address if it hosts one; releasing the
+// address removes them. The template's placeholders are {ip}, the address with
+// dashes for its dots or colons, and {hostname}, the name given on allocation.
+type Subnet struct {
+	ID           string    `json:"id"`
+	TenantID     string    `json:"-"`
+	Name         string    `json:"name"`
+	CIDR         string    `json:"cidr"`
+	ZoneID       string    `json:"zone_id"`       // Forward zone of the records
+	NameTemplate string    `json:"name_template"` // e.g. "ip-{ip}" or "{hostname}.hosts"
+	TTL          int       `json:"ttl"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// IPAllocation is an address allocated from a subnet, with the records made
+// for it.
+type IPAllocation struct {
+	ID          string    `json:"id"`
+	SubnetID    string    `json:"subnet_id"`
+	TenantID    string    `json:"-"`
+	Address     string    `json:"address"`
+	Hostname    string    `json:"hostname,omitempty"`
+	Name        string    `json:"name"` // Owner name of the forward record
+	RecordID    string    `json:"record_id"`
+	PTRZoneID   *string   `json:"ptr_zone_id,omitempty"` // Nil when no reverse zone is hosted
+	PTRRecordID *string   `json:"ptr_record_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ErrInvalidSubnet is returned when a subnet is malformed, its name is taken,
+// it is deleted while addresses are allocated, or an address cannot be
+// allocated from it.
+var ErrInvalidSubnet = errors.New("invalid subnet")
+
+// DefaultRecord answers queries of its type for names of a zone that have no
+// records at all, after wildcards: a hosting platform can point every customer
+// label at the same ingress without a record per label. Depth is the number of
+// labels below the zone apex; a rule applies to names between MinDepth and
+// MaxDepth deep, with no upper bound when MaxDepth is 0.
+type DefaultRecord struct {
+	ID        string     `json:"id"`
+	ZoneID    string     `json:"zone_id"`
+	TenantID  string     `json:"-"`
+	Type      RecordType `json:"type"`
+	Content   string     `json:"content"`
+	TTL       int        `json:"ttl"`
+	Priority  *int       `json:"priority,omitempty"`
+	MinDepth  int        `json:"min_depth"`
+	MaxDepth  int        `json:"max_depth"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Matches reports whether the rule applies to a name depth labels below the
+// zone apex.
+func (d DefaultRecord) Matches(depth int) bool {
+	return depth >= d.MinDepth && (d.MaxDepth == 0 || depth <= d.MaxDepth)
+}
+
+// ErrInvalidDefaultRecord is returned when a default record is malformed.
+var ErrInvalidDefaultRecord = errors.New("invalid default record")
+
+// GeoPolicy scopes a record to clients in some locations. Of the records of a
+// name and type, a client gets those whose policy matches it most closely: by
+// ASN, then country, then continent, and else the records without a policy.
+type GeoPolicy struct {
+	Continents []string `json:"continents,omitempty"` // two-letter codes, e.g. "EU"
+	Countries  []string `json:"countries,omitempty"`  // ISO 3166-1 alpha-2 codes, e.g. "DE"
+	ASNs       []uint32 `json:"asns,omitempty"`
+}
+
+// Scopes returns the locations the policy names, as "continent:EU",
+// "country:DE" or "asn:64500".
+func (p *GeoPolicy) Scopes() []string {
+	var scopes []string
+	for _, c := range p.Continents {
+		scopes = append(scopes, "continent:"+c)
+	}
+	for _, c := range p.Countries {
+		scopes = append(scopes, "country:"+c)
+	}
+	for _, asn := range p.ASNs {
+		scopes = append(scopes, "asn:"+strconv.FormatUint(uint64(asn), 10))
+	}
+	return scopes
+}
+
+// GeoLocation is where a client address is, as far as the GeoIP databases
+// know; unknown fields are empty.
+type GeoLocation struct {
+	Continent string
+	Country   string
+	ASN       uint32
+}
+
+// Scopes returns the known parts of the location in the form of
+// GeoPolicy.Scopes, the most specific first.
+func (l GeoLocation) Scopes() []string {
+	var scopes []string
+	if l.ASN != 0 {
+		scopes = append(scopes, "asn:"+strconv.FormatUint(uint64(l.ASN), 10))
+	}
+	if l.Country != "" {
+		scopes = append(scopes, "country:"+l.Country)
+	}
+	if l.Continent != "" {
+		scopes = append(scopes, "continent:"+l.Continent)
+	}
+	return scopes
+}
+
+// ErrInvalidGeoPolicy is returned when a record's geo policy is malformed.
+var ErrInvalidGeoPolicy = errors.New("invalid geo policy")
+
+// ZoneLatency summarizes the response times of a zone's queries over a window.
+type ZoneLatency struct {
+	WindowMinutes int     `json:"window_minutes"`
+	Queries       uint64  `json:"queries"`
+	P50Ms         float64 `json:"p50_ms"`
+	P95Ms         float64 `json:"p95_ms"`
+	P99Ms         float64 `json:"p99_ms"`
+}
+
+// ZoneSLOStatus is a zone's latency over its SLO window, or the default window
+// if it has no SLO, and whether the SLO is being breached.
+type ZoneSLOStatus struct {
+	SLO     *ZoneSLO    `json:"slo,omitempty"`
+	Latency ZoneLatency `json:"latency"`
+	// ObservedMs is the latency at the SLO's percentile
+	ObservedMs    float64    `json:"observed_ms,omitempty"`
+	Breaching     bool       `json:"breaching"`
+	BreachedSince *time.Time `json:"breached_since,omitempty"`
+}
+
+// SLOBreach reports a zone starting (Breaching) or ceasing to breach its SLO.
+type SLOBreach struct {
+	ZoneID      string    `json:"zone_id"`
+	TenantID    string    `json:"tenant_id"`
+	Zone        string    `json:"zone"`
+	Breaching   bool      `json:"breaching"`
+	Percentile  float64   `json:"percentile"`
+	ThresholdMs float64   `json:"threshold_ms"`
+	ObservedMs  float64   `json:"observed_ms"`
+	Queries     uint64    `json:"queries"`
+	DetectedAt  time.Time `json:"detected_at"`
+}
+
+// ChangeFreeze is a maintenance window in which a tenant's zones, or only ZoneID
+// when it is set, reject API mutations and dynamic updates.
+type ChangeFreeze struct {
+	ID        string    `json:"id"`
+	TenantID  string    `json:"-"`
+	ZoneID    string    `json:"zone_id,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Active reports whether the freeze is in force at t.
+func (f *ChangeFreeze) Active(t time.Time) bool {
+	return !t.Before(f.StartsAt) && t.Before(f.EndsAt)
+}
+
+// ErrChangeFrozen is returned when a change is rejected by an active change freeze.
+var ErrChangeFrozen = errors.New("changes are frozen")
+
+// ErrInvalidChangeFreeze is returned when a change freeze window is malformed.
+var ErrInvalidChangeFreeze = errors.New("invalid change freeze")
+
+// Change request actions and states. A pending request is approved or
+// rejected by a reviewer; an approved request is then applied or failed.
+const (
+	ChangeCreateRecord = "CREATE_RECORD"
+	ChangeDeleteRecord = "DELETE_RECORD"
+	ChangeReplaceRRset = "REPLACE_RRSET"
+
+	ChangePending  = "pending"
+	ChangeApproved = "approved"
+	ChangeApplied  = "applied"
+	ChangeFailed   = "failed"
+	ChangeRejected = "rejected"
+)
+
+// ChangeRequest is a record change to a zone under review, held until a second
+// API key with the approver role approves or rejects it. CREATE_RECORD adds
+// Record, DELETE_RECORD removes RecordID and REPLACE_RRSET replaces RRset.
+// RequestedBy and ReviewedBy are API key IDs.
+type ChangeRequest struct {
+	ID          string     `json:"id"`
+	ZoneID      string     `json:"zone_id"`
+	TenantID    string     `json:"-"`
+	Action      string     `json:"action"`
+	RecordID    string     `json:"record_id,omitempty"`
+	Record      *Record    `json:"record,omitempty"`
+	RRset       *RRset     `json:"rrset,omitempty"`
+	Force       bool       `json:"force,omitempty"` // confirms dropping protected records
+	Status      string     `json:"status"`
+	RequestedBy string     `json:"requested_by"`
+	ReviewedBy  string     `json:"reviewed_by,omitempty"`
+	Comment     string     `json:"comment,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ReviewedAt  *time.Time `json:"reviewed_at,omitempty"`
+}
+
+// ErrReviewRequired is returned when a change to a zone under review is made
+// directly instead of through an approved change request.
+var ErrReviewRequired = errors.New("zone changes require approval")
+
+// ErrInvalidChangeRequest is returned when a change request is malformed or is
+// no longer pending.
+var ErrInvalidChangeRequest = errors.New("invalid change request")
+
+// ErrSelfReview is returned when a change request is reviewed by the key that
+// requested it.
+var ErrSelfReview = errors.New("a change cannot be reviewed by its requester")
+
+// NotifyResult lists the secondaries notified of a zone change.
+type NotifyResult struct {
+	ZoneID  string         `json:"zone_id"`
+	Zone    string         `json:"zone"`
+	Targets []NotifyTarget `json:"targets"`
+}
+
+// DependencyStatus is the outcome of a single readiness check.
+type DependencyStatus struct {
+	Name      string  `json:"name"`
+	Healthy   bool    `json:"healthy"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// ReadinessReport aggregates the dependency checks that decide whether a node
+// may receive traffic.
+type ReadinessReport struct {
+	Ready  bool               `json:"ready"`
+	Checks []DependencyStatus `json:"checks"`
+}
+
+// ZoneSummary is a dashboard view of a zone that avoids paging through records.
+type ZoneSummary struct {
+	ZoneID             string              `json:"zone_id"`
+	ZoneName           string              `json:"zone_name"`
+	Serial             uint32              `json:"serial"`
+	RecordCounts       map[RecordType]int  `json:"record_counts"`
+	TotalRecords       int                 `json:"total_records"`
+	DNSSECEnabled      bool                `json:"dnssec_enabled"`
+	ActiveKeys         int                 `json:"active_keys"`
+	LastChangeAt       *time.Time          `json:"last_change_at,omitempty"`
+	Role               string              `json:"role"`
+	MasterServer       string              `json:"master_server,omitempty"`
+	LastRefresh        *ZoneRefresh        `json:"last_refresh,omitempty"` // secondary zones only
+	Secondaries        []SecondaryProvider `json:"secondaries,omitempty"`  // the serials external secondaries serve
+	EstimatedWireBytes int                 `json:"estimated_wire_bytes"`
+	EstimatedRowBytes  int                 `json:"estimated_row_bytes"`
+}
+
+// ZoneRefresh is the outcome of the last attempt, by any node, to bring a
+// secondary zone up to date with its masters.
+type ZoneRefresh struct {
+	ZoneID      string     `json:"-"`
+	AttemptedAt time.Time  `json:"attempted_at"`
+	SucceededAt *time.Time `json:"succeeded_at,omitempty"` // last success, kept across failures
+	Serial      uint32     `json:"serial"`                 // the zone's serial after the attempt
+	Error       string     `json:"error,omitempty"`        // empty when the attempt succeeded
+}
+
+// EventType identifies the kind of a live event.
+type EventType string
+
+const (
+	// EventZoneChange is emitted when a zone is created, imported or deleted.
+	EventZoneChange EventType = "zone"
+	// EventRecordChange is emitted when a record is created or deleted.
+	EventRecordChange EventType = "record"
+	// EventQuery is a sampled authoritative query.
+	EventQuery EventType = "query"
+	// EventSLO is emitted when a zone starts or stops breaching its SLO.
+	EventSLO EventType = "slo"
+	// EventDropped tells a slow subscriber how many events it missed.
+	EventDropped EventType = "dropped"
+)
+
+// Event is a live notification streamed to dashboards and debugging tools.
+type Event struct {
+	Type       EventType  `json:"type"`
+	TenantID   string     `json:"tenant_id,omitempty"`
+	ZoneID     string     `json:"zone_id,omitempty"`
+	Action     string     `json:"action,omitempty"` // e.g., "CREATE_RECORD"
+	Name       string     `json:"name,omitempty"`
+	RecordType RecordType `json:"record_type,omitempty"`
+	RCode      string     `json:"rcode,omitempty"`
+	Protocol   string     `json:"protocol,omitempty"`
+	ClientIP   string     `json:"client_ip,omitempty"`
+	Dropped    uint64     `json:"dropped,omitempty"`
+	Timestamp  time.Time  `json:"timestamp"`
+}
+
+// EventFilter selects the events delivered to a subscriber. Empty fields match
+// everything except TenantID, which always scopes delivery when set.
+type EventFilter struct {
+	TenantID string
+	ZoneID   string
+	Types    []EventType
+}
+
+// Matches reports whether e passes the filter. Dropped notices always match.
+func (f EventFilter) Matches(e Event) bool {
+	if e.Type == EventDropped {
+		return true
+	}
+	if f.TenantID != "" && e.TenantID != f.TenantID {
+		return false
+	}
+	if f.ZoneID != "" && e.ZoneID != f.ZoneID {
+		return false
+	}
+	if len(f.Types) == 0 {
+		return true
+	}
+	for _, t := range f.Types {
+		if t == e.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// NodeStatus summarizes a fleet node's state as seen by the registry.
+type NodeStatus string
+
+const (
+	NodeStatusUp       NodeStatus = "UP"
+	NodeStatusDegraded NodeStatus = "DEGRADED"
+	NodeStatusStale    NodeStatus = "STALE"
+)
+
+// Node is a cloudDNS server instance as recorded by its periodic heartbeat.
+type Node struct {
+	ID               string     `json:"id"`
+	Version          string     `json:"version"`
+	Address          string     `json:"address"`
+	Healthy          bool       `json:"healthy"`
+	QPS              float64    `json:"qps"`
+	AnycastAnnounced bool       `json:"anycast_announced"`
+	ErrorRate        float64    `json:"error_rate"` // Share of SERVFAIL answers since the previous heartbeat
+	ConfigVersion    string     `json:"config_version,omitempty"`
+	ConfigError      string     `json:"config_error,omitempty"`
+	StartedAt        time.Time  `json:"started_at"`
+	LastHeartbeat    time.Time  `json:"last_heartbeat"`
+	Status           NodeStatus `json:"status"`
+}
+
+// ErrInvalidRollout is returned when a rollout request cannot be planned.
+var ErrInvalidRollout = errors.New("invalid rollout")
+
+// RolloutState is the lifecycle state of a fleet rollout.
+type RolloutState string
+
+const (
+	RolloutRunning    RolloutState = "RUNNING"
+	RolloutCompleted  RolloutState = "COMPLETED"
+	RolloutRolledBack RolloutState = "ROLLED_BACK"
+)
+
+// NodeConfigShadowZones is the node config key listing, comma-separated, the
+// zones whose shadow change the node serves to every client. Zone rollouts set
+// it batch by batch.
+const NodeConfigShadowZones = "shadow_serve_zones"
+
+// Rollout applies a node configuration update across the fleet in stages: a canary
+// node first, then batches, each gated on node health, QPS and error rate.
+//
+// A zone rollout, with ZoneID set and no Config, stages the shadow change of the
+// zone the same way: each batch serves it to every client, and it is committed
+// once the whole fleet has served it without regressing.
+type Rollout struct {
+	ID          string            `json:"id"`
+	Description string            `json:"description"`
+	Config      map[string]string `json:"config"`
+	ZoneID      string            `json:"zone_id,omitempty"`
+	TenantID    string            `json:"tenant_id,omitempty"`        // owner of ZoneID, set when the rollout starts
+	StagedAt    *time.Time        `json:"shadow_staged_at,omitempty"` // the shadow change being rolled out
+	CanaryNode  string            `json:"canary_node,omitempty"`
+	BatchSize   int               `json:"batch_size"`
+	BakeSeconds int               `json:"bake_seconds"`
+	// MaxErrorRateIncrease halts the rollout when a node's SERVFAIL share rises by
+	// more than this amount over its pre-rollout baseline.
+	MaxErrorRateIncrease float64 `json:"max_error_rate_increase"`
+	// MinQPSRatio halts the rollout when a node's QPS falls below this fraction of
+	// its pre-rollout baseline. Zero disables the check.
+	MinQPSRatio float64 `json:"min_qps_ratio"`
+
+	State     RolloutState                 `json:"state"`
+	Batches   [][]string                   `json:"batches"`
+	Completed []string                     `json:"completed"`
+	Previous  map[string]map[string]string `json:"previous,omitempty"` // Node config before the rollout, for rollback
+	Error     string                       `json:"error,omitempty"`
+	CreatedAt time.Time                    `json:"created_at"`
+	UpdatedAt time.Time                    `json:"updated_at"`
+}
+
+// ConfigVersion returns a short, order-independent fingerprint of a node config
+// that nodes report in their heartbeat once it has been applied.
+func ConfigVersion(cfg map[string]string) string {
+	if len(cfg) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(cfg))
+	for k := range cfg {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s=%s\n", k, cfg[k])
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+// ProbeStatus is the verdict of a synthetic monitoring probe.
+type ProbeStatus string
+
+const (
+	// ProbeOK means the answer matched the zone data within the latency budget.
+	ProbeOK ProbeStatus = "OK"
+	// ProbeMissing means the server answered without the expected records.
+	ProbeMissing ProbeStatus = "MISSING"
+	// ProbeWrong means the answer disagreed with the zone data.
+	ProbeWrong ProbeStatus = "WRONG"
+	// ProbeSlow means the answer was correct but exceeded the latency budget.
+	ProbeSlow ProbeStatus = "SLOW"
+	// ProbeError means the query failed or returned an error rcode.
+	ProbeError ProbeStatus = "ERROR"
+)
+
+// ProbeAnswer is the response to a single synthetic query.
+type ProbeAnswer struct {
+	Rcode   int
+	Records []Record
+}
+
+// ProbeResult is the latest outcome of probing one zone apex query from one vantage.
+type ProbeResult struct {
+	ZoneID    string        `json:"zone_id"`
+	ZoneName  string        `json:"zone_name"`
+	Type      RecordType    `json:"type"`
+	Vantage   string        `json:"vantage"`
+	Status    ProbeStatus   `json:"status"`
+	Detail    string        `json:"detail,omitempty"`
+	Latency   time.Duration `json:"latency_ns"`
+	CheckedAt time.Time     `json:"checked_at"`
+}
+
+// ConsistencyQuery is a question the consistency checker asks every node.
+type ConsistencyQuery struct {
+	Name string     `json:"name"`
+	Type RecordType `json:"type"`
+}
+
+// NodeAnswer is one node's response to a consistency query.
+type NodeAnswer struct {
+	NodeID    string   `json:"node_id"`
+	Address   string   `json:"address"`
+	Rcode     int      `json:"rcode"`
+	Answer    []string `json:"answer"`
+	Error     string   `json:"error,omitempty"`
+	Divergent bool     `json:"divergent"`
+}
+
+// ConsistencyCheck compares the answers of every node to a single query.
+type ConsistencyCheck struct {
+	ConsistencyQuery
+	Consistent bool         `json:"consistent"`
+	Answers    []NodeAnswer `json:"answers"`
+}
+
+// ConsistencyReport is the result of asking every node the same set of queries.
+type ConsistencyReport struct {
+	CheckedAt time.Time          `json:"checked_at"`
+	Nodes     int                `json:"nodes"`
+	Divergent int                `json:"divergent"`
+	Checks    []ConsistencyCheck `json:"checks"`
+}
+
+// Configuration audit checks.
+const (
+	AuditLameDelegation  = "lame_delegation"  // in-bailiwick NS host without an address
+	AuditMissingPTR      = "missing_ptr"      // address with no PTR in a reverse zone we host
+	AuditCNAMEAtApex     = "cname_at_apex"    // CNAME at a zone apex (RFC 1912 2.4)
+	AuditTTLMismatch     = "ttl_mismatch"     // records of one RRset with different TTLs (RFC 2181 5.2)
+	AuditDelegationDrift = "delegation_drift" // parent NS/DS out of step with a child zone we host
+)
+
+// ConfigFinding is one problem found by the configuration auditor.
+type ConfigFinding struct {
+	ZoneID   string     `json:"zone_id"`
+	ZoneName string     `json:"zone_name"`
+	TenantID string     `json:"-"`
+	Check    string     `json:"check"`
+	Severity string     `json:"severity"` // "error" or "warning"
+	Name     string     `json:"name"`
+	Type     RecordType `json:"type,omitempty"`
+	Detail   string     `json:"detail"`
+}
+
+// ConfigAuditReport is the outcome of auditing zone configuration.
+type ConfigAuditReport struct {
+	CheckedAt time.Time       `json:"checked_at"`
+	Zones     int             `json:"zones"`
+	Findings  []ConfigFinding `json:"findings"`
+}
+
+// DelegationStatus compares the delegation of a zone in its parent zone, when
+// both are hosted here, with the child's own NS records and DNSSEC keys.
+type DelegationStatus struct {
+	ZoneID       string   `json:"zone_id"`
+	Zone         string   `json:"zone"`
+	ParentZoneID string   `json:"parent_zone_id"`
+	ParentZone   string   `json:"parent_zone"`
+	InSync       bool     `json:"in_sync"`
+	ChildNS      []string `json:"child_ns"`
+	ParentNS     []string `json:"parent_ns"`
+	ExpectedDS   []string `json:"expected_ds"` // SHA-256 DS of the child's active KSKs
+	ParentDS     []string `json:"parent_ds"`
+}
+
+// ErrNoHostedParent is returned for delegation checks of a zone whose parent
+// zone is not hosted by the same tenant.
+var ErrNoHostedParent = errors.New("parent zone is not hosted")
+
+// Propagation statuses of one record at one resolver.
+const (
+	PropagationVisible   = "propagated" // the record is in the answer
+	PropagationDifferent = "different"  // the name answers with other data, e.g. a cached old value
+	PropagationMissing   = "missing"    // no data of the record's type, or NXDOMAIN
+	PropagationError     = "error"      // the resolver did not answer
+)
+
+// PropagationResult is what one resolver or node answered for a record.
+type PropagationResult struct {
+	Resolver string   `json:"resolver"`
+	Kind     string   `json:"kind"` // "resolver" or "node"
+	NodeID   string   `json:"node_id,omitempty"`
+	Status   string   `json:"status"`
+	Rcode    int      `json:"rcode"`
+	TTL      int      `json:"ttl,omitempty"` // remaining TTL of the record as observed
+	Answer   []string `json:"answer"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// PropagationReport shows whether a record is visible through public resolvers
+// and on every node of the fleet.
+type PropagationReport struct {
+	RecordID   string              `json:"record_id"`
+	Name       string              `json:"name"`
+	Type       RecordType          `json:"type"`
+	Content    string              `json:"content"`
+	TTL        int                 `json:"ttl"`
+	Propagated int                 `json:"propagated"`
+	Total      int                 `json:"total"`
+	CheckedAt  time.Time           `json:"checked_at"`
+	Results    []PropagationResult `json:"results"`
+}
+
+// ZoneUsage holds the traffic a zone served during one hour, across all nodes.
+type ZoneUsage struct {
+	ZoneID        string    `json:"zone_id"`
+	ZoneName      string    `json:"zone_name,omitempty"`
+	Hour          time.Time `json:"hour"`
+	Queries       int64     `json:"queries"`
+	NXDomain      int64     `json:"nxdomain"`
+	TransferBytes int64     `json:"transfer_bytes"`
+}
+
+// ZoneUsageReport is a zone's hourly usage and totals over a time range.
+type ZoneUsageReport struct {
+	ZoneID        string      `json:"zone_id"`
+	ZoneName      string      `json:"zone_name"`
+	From          time.Time   `json:"from"`
+	To            time.Time   `json:"to"`
+	Queries       int64       `json:"queries"`
+	NXDomain      int64       `json:"nxdomain"`
+	TransferBytes int64       `json:"transfer_bytes"`
+	Hourly        []ZoneUsage `json:"hourly"`
+}
+
+// ZoneUsageTotal is a zone's usage summed over a time range.
+type ZoneUsageTotal struct {
+	ZoneID        string `json:"zone_id"`
+	ZoneName      string `json:"zone_name"`
+	Queries       int64  `json:"queries"`
+	NXDomain      int64  `json:"nxdomain"`
+	TransferBytes int64  `json:"transfer_bytes"`
+}
+
+// UsageBucket is usage summed over every zone for one hour.
+type UsageBucket struct {
+	Hour          time.Time `json:"hour"`
+	Queries       int64     `json:"queries"`
+	NXDomain      int64     `json:"nxdomain"`
+	TransferBytes int64     `json:"transfer_bytes"`
+}
+
+// TenantUsageSummary aggregates a tenant's usage over a time range for
+// customer-facing dashboards: fleet-wide totals, per-zone totals from the
+// busiest zone down, and hourly totals.
+type TenantUsageSummary struct {
+	From          time.Time        `json:"from"`
+	To            time.Time        `json:"to"`
+	Queries       int64            `json:"queries"`
+	NXDomain      int64            `json:"nxdomain"`
+	TransferBytes int64            `json:"transfer_bytes"`
+	Zones         []ZoneUsageTotal `json:"zones"`
+	Hourly        []UsageBucket    `json:"hourly"`
+}
+
+// QueryStats is an estimate of the query traffic answered since WindowStart, built
+// from fixed-memory sketches. Unique counts cover every query; per-zone counts are
+// scaled up from a 1-in-SampleRate sample.
+type QueryStats struct {
+	WindowStart   time.Time        `json:"window_start"`
+	SampleRate    int              `json:"sample_rate"`
+	Queries       uint64           `json:"queries"`
+	UniqueClients uint64           `json:"unique_clients"`
+	UniqueNames   uint64           `json:"unique_names"`
+	Zones         []ZoneQueryStats `json:"zones"`
+}
+
+// ZoneQueryStats is a zone's estimated query count and its most queried names.
+type ZoneQueryStats struct {
+	Zone     string    `json:"zone"`
+	Queries  uint64    `json:"queries"`
+	HotNames []HotName `json:"hot_names"`
+}
+
+// HotName is a frequently queried name and its estimated query count.
+type HotName struct {
+	Name  string `json:"name"`
+	Count uint64 `json:"count"`
+}
+
+// ClientFingerprint describes the capabilities a query advertised.
+type ClientFingerprint struct {
+	Transport    string
+	EDNS         bool
+	UDPSize      uint16
+	DNSSECOK     bool
+	Cookie       bool
+	ClientSubnet bool
+}
+
+// ClientCapabilities counts the queries advertising each capability. UDPSizes
+// buckets the EDNS buffer sizes of EDNS queries by range.
+type ClientCapabilities struct {
+	Queries      uint64            `json:"queries"`
+	Transports   map[string]uint64 `json:"transports"`
+	EDNS         uint64            `json:"edns"`
+	UDPSizes     map[string]uint64 `json:"udp_sizes"`
+	DNSSECOK     uint64            `json:"dnssec_ok"`
+	Cookies      uint64            `json:"cookies"`
+	ClientSubnet uint64            `json:"client_subnet"`
+}
+
+// ClientSourceStats is the capabilities of the queries from one source network,
+// a /24 for IPv4 or a /48 for IPv6.
+type ClientSourceStats struct {
+	Source string `json:"source"`
+	ClientCapabilities
+}
+
+// ClientStats aggregates client capabilities since WindowStart, over all queries
+// and per source network, busiest first. Sources past the tracked limit are
+// counted under "other".
+type ClientStats struct {
+	WindowStart time.Time           `json:"window_start"`
+	Total       ClientCapabilities  `json:"total"`
+	Sources     []ClientSourceStats `json:"sources"`
+}
+
+// TransportPosture describes the security controls of one encrypted transport as
+// served by this node.
+type TransportPosture struct {
+	Transport        string `json:"transport"`
+	Enabled          bool   `json:"enabled"`
+	Address          string `json:"address,omitempty"`
+	MinTLSVersion    string `json:"min_tls_version,omitempty"`
+	PaddingBlockSize int    `json:"padding_block_size"`
+	// EarlyData reports whether TLS 1.3 early data (0-RTT) can reach the server and
+	// EarlyDataUpdates whether dynamic updates are accepted in it.
+	EarlyData        bool `json:"early_data"`
+	EarlyDataUpdates bool `json:"early_data_updates"`
+}
+
+// QueryTrace is the account of how this node answered one query: every step of
+// the resolution pipeline it went through and the response it built.
+type QueryTrace struct {
+	Name        string           `json:"name"`
+	Type        RecordType       `json:"type"`
+	ClientIP    string           `json:"client_ip"`
+	DNSSEC      bool             `json:"dnssec"`
+	Steps       []QueryTraceStep `json:"steps"`
+	Rcode       string           `json:"rcode"`
+	Answers     []string         `json:"answers"`
+	Authorities []string         `json:"authorities"`
+	Additionals []string         `json:"additionals"`
+	// Errors holds the Extended DNS Errors (RFC 8914) attached to the response.
+	Errors     []string `json:"errors,omitempty"`
+	DurationMS float64  `json:"duration_ms"`
+}
+
+// QueryTraceStep is one stage of a traced query and what it decided.
+type QueryTraceStep struct {
+	Stage  string `json:"stage"`
+	Detail string `json:"detail"`
+}
+
+// ErrInvalidQueryTrace is returned when a query to trace has a malformed name,
+// type or client address.
+var ErrInvalidQueryTrace = errors.New("invalid query trace")
+
+// ZoneState is the complete state of a zone when it was exported to the
+// disaster-recovery change log. A nil Zone records that the zone was deleted.
+type ZoneState struct {
+	ZoneID  string   `json:"zone_id"`
+	Zone    *Zone    `json:"zone,omitempty"`
+	Records []Record `json:"records,omitempty"`
+}
+
+// ChangeLogEntry is one object of the disaster-recovery change log: the zones
+// changed since the previous entry, as read at ExportedAt.
+type ChangeLogEntry struct {
+	ExportedAt time.Time   `json:"exported_at"`
+	Zones      []ZoneState `json:"zones"`
+}
+
+// RecoveryReport describes a repository rebuilt from disaster-recovery replicas.
+type RecoveryReport struct {
+	Snapshot     string    `json:"snapshot"`
+	SnapshotAt   time.Time `json:"snapshot_at"`
+	Entries      int       `json:"entries"`
+	ZonesApplied int       `json:"zones_applied"`
+	RecoveredTo  time.Time `json:"recovered_to"`
 }
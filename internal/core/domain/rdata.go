@@ -0,0 +1,493 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+)
+
+var naptrFlagsRegex = regexp.MustCompile(`^[a-zA-Z0-9]*$`)
+
+// NAPTRData is the RDATA of a NAPTR record (RFC 3403), written as
+// `order preference "flags" "services" "regexp" replacement`, for example
+// `100 10 "U" "E2U+sip" "!^.*$!sip:info@example.com!" .`.
+type NAPTRData struct {
+	Order       uint16
+	Preference  uint16
+	Flags       string
+	Services    string
+	Regexp      string
+	Replacement string
+}
+
+// ParseNAPTRContent parses and validates NAPTR content. A record rewrites the
+// name either with its regexp or with its replacement, which is then ".".
+func ParseNAPTRContent(content string) (NAPTRData, error) {
+	var d NAPTRData
+	fields, err := splitQuoted(content)
+	if err != nil {
+		return d, err
+	}
+	if len(fields) != 6 {
+		return d, fmt.Errorf("NAPTR content must be in format: order preference \"flags\" \"services\" \"regexp\" replacement")
+	}
+	order, err := parseUint(fields[0], "order", 16)
+	if err != nil {
+		return d, err
+	}
+	pref, err := parseUint(fields[1], "preference", 16)
+	if err != nil {
+		return d, err
+	}
+	d = NAPTRData{Order: uint16(order), Preference: uint16(pref), Flags: fields[2], Services: fields[3], Regexp: fields[4], Replacement: fields[5]} // #nosec G115
+	if !naptrFlagsRegex.MatchString(d.Flags) {
+		return d, fmt.Errorf("invalid flags %q (letters or digits)", d.Flags)
+	}
+	for _, s := range fields[2:5] {
+		if len(s) > 255 {
+			return d, fmt.Errorf("NAPTR strings are limited to 255 characters")
+		}
+	}
+	if !dnsname.IsFqdn(d.Replacement) {
+		return d, fmt.Errorf("NAPTR replacement must be a FQDN (end with a dot)")
+	}
+	if d.Regexp != "" && d.Replacement != "." {
+		return d, fmt.Errorf("NAPTR records take a regexp or a replacement, not both")
+	}
+	return d, nil
+}
+
+// String returns the content form of the RDATA.
+func (d NAPTRData) String() string {
+	return fmt.Sprintf("%d %d %s %s %s %s", d.Order, d.Preference, quote(d.Flags), quote(d.Services), quote(d.Regexp), dnsname.Fqdn(d.Replacement))
+}
+
+// TLSAData is the RDATA of a TLSA record (RFC 6698), written as
+// `usage selector matching-type certificate-data-hex`.
+type TLSAData struct {
+	Usage        uint8
+	Selector     uint8
+	MatchingType uint8
+	Data         []byte
+}
+
+// ParseTLSAContent parses and validates TLSA content. Data matched by SHA-256
+// or SHA-512 must have the length of the digest.
+func ParseTLSAContent(content string) (TLSAData, error) {
+	var d TLSAData
+	fields := strings.Fields(content)
+	if len(fields) < 4 {
+		return d, fmt.Errorf("TLSA content must be in format: usage selector matching-type data")
+	}
+	var codes [3]uint8
+	for i, name := range []string{"usage", "selector", "matching type"} {
+		v, err := parseUint(fields[i], name, 8)
+		if err != nil {
+			return d, err
+		}
+		codes[i] = uint8(v) // #nosec G115
+	}
+	data, err := hex.DecodeString(strings.Join(fields[3:], ""))
+	if err != nil {
+		return d, fmt.Errorf("invalid certificate data: %w", err)
+	}
+	if err := checkDigestLen(data, map[uint8]int{1: 32, 2: 64}[codes[2]]); err != nil {
+		return d, err
+	}
+	return TLSAData{Usage: codes[0], Selector: codes[1], MatchingType: codes[2], Data: data}, nil
+}
+
+// String returns the content form of the RDATA.
+func (d TLSAData) String() string {
+	return fmt.Sprintf("%d %d %d %s", d.Usage, d.Selector, d.MatchingType, hex.EncodeToString(d.Data))
+}
+
+// SSHFPData is the RDATA of an SSHFP record (RFC 4255), written as
+// `algorithm fingerprint-type fingerprint-hex`.
+type SSHFPData struct {
+	Algorithm   uint8
+	Type        uint8
+	Fingerprint []byte
+}
+
+// ParseSSHFPContent parses and validates SSHFP content. SHA-1 and SHA-256
+// fingerprints must have the length of the digest.
+func ParseSSHFPContent(content string) (SSHFPData, error) {
+	var d SSHFPData
+	fields := strings.Fields(content)
+	if len(fields) < 3 {
+		return d, fmt.Errorf("SSHFP content must be in format: algorithm type fingerprint")
+	}
+	alg, err := parseUint(fields[0], "algorithm", 8)
+	if err != nil {
+		return d, err
+	}
+	fpType, err := parseUint(fields[1], "fingerprint type", 8)
+	if err != nil {
+		return d, err
+	}
+	fp, err := hex.DecodeString(strings.Join(fields[2:], ""))
+	if err != nil {
+		return d, fmt.Errorf("invalid fingerprint: %w", err)
+	}
+	if err := checkDigestLen(fp, map[uint64]int{1: 20, 2: 32}[fpType]); err != nil {
+		return d, err
+	}
+	return SSHFPData{Algorithm: uint8(alg), Type: uint8(fpType), Fingerprint: fp}, nil // #nosec G115
+}
+
+// String returns the content form of the RDATA.
+func (d SSHFPData) String() string {
+	return fmt.Sprintf("%d %d %s", d.Algorithm, d.Type, hex.EncodeToString(d.Fingerprint))
+}
+
+// URIData is the RDATA of a URI record (RFC 7553), written as
+// `priority weight "target"`.
+type URIData struct {
+	Priority uint16
+	Weight   uint16
+	Target   string
+}
+
+// ParseURIContent parses and validates URI content. The target must be an
+// absolute URI.
+func ParseURIContent(content string) (URIData, error) {
+	var d URIData
+	fields, err := splitQuoted(content)
+	if err != nil {
+		return d, err
+	}
+	if len(fields) != 3 {
+		return d, fmt.Errorf("URI content must be in format: priority weight \"target\"")
+	}
+	prio, err := parseUint(fields[0], "priority", 16)
+	if err != nil {
+		return d, err
+	}
+	weight, err := parseUint(fields[1], "weight", 16)
+	if err != nil {
+		return d, err
+	}
+	if u, err := url.Parse(fields[2]); err != nil || u.Scheme == "" {
+		return d, fmt.Errorf("URI target %q must be an absolute URI", fields[2])
+	}
+	return URIData{Priority: uint16(prio), Weight: uint16(weight), Target: fields[2]}, nil // #nosec G115
+}
+
+// String returns the content form of the RDATA.
+func (d URIData) String() string {
+	return fmt.Sprintf("%d %d %s", d.Priority, d.Weight, quote(d.Target))
+}
+
+// certTypes are the certificate type mnemonics of RFC 4398 Section 2.1.
+var certTypes = map[string]uint16{
+	"PKIX": 1, "SPKI": 2, "PGP": 3, "IPKIX": 4, "ISPKI": 5, "IPGP": 6,
+	"ACPKIX": 7, "IACPKIX": 8, "URI": 253, "OID": 254,
+}
+
+// CERTData is the RDATA of a CERT record (RFC 4398), written as
+// `type key-tag algorithm certificate-base64`, where the type is a number or a
+// mnemonic such as PKIX or PGP.
+type CERTData struct {
+	Type        uint16
+	KeyTag      uint16
+	Algorithm   uint8
+	Certificate []byte
+}
+
+// ParseCERTContent parses and validates CERT content.
+func ParseCERTContent(content string) (CERTData, error) {
+	var d CERTData
+	fields := strings.Fields(content)
+	if len(fields) < 4 {
+		return d, fmt.Errorf("CERT content must be in format: type key-tag algorithm certificate")
+	}
+	certType, ok := certTypes[strings.ToUpper(fields[0])]
+	if !ok {
+		v, err := parseUint(fields[0], "certificate type", 16)
+		if err != nil {
+			return d, err
+		}
+		certType = uint16(v) // #nosec G115
+	}
+	tag, err := parseUint(fields[1], "key tag", 16)
+	if err != nil {
+		return d, err
+	}
+	alg, err := parseUint(fields[2], "algorithm", 8)
+	if err != nil {
+		return d, err
+	}
+	cert, err := base64.StdEncoding.DecodeString(strings.Join(fields[3:], ""))
+	if err != nil || len(cert) == 0 {
+		return d, fmt.Errorf("certificate must be base64")
+	}
+	return CERTData{Type: certType, KeyTag: uint16(tag), Algorithm: uint8(alg), Certificate: cert}, nil // #nosec G115
+}
+
+// String returns the content form of the RDATA.
+func (d CERTData) String() string {
+	certType := strconv.Itoa(int(d.Type))
+	for name, t := range certTypes {
+		if t == d.Type {
+			certType = name
+		}
+	}
+	return fmt.Sprintf("%s %d %d %s", certType, d.KeyTag, d.Algorithm, base64.StdEncoding.EncodeToString(d.Certificate))
+}
+
+// LOC coordinates are thousandths of an arc second from 2^31 at the equator
+// or prime meridian, and altitudes centimeters above 100,000 m below the WGS 84
+// reference spheroid (RFC 1876 Section 2).
+const (
+	locEquator  = 1 << 31
+	locBaseAlt  = 10000000
+	locDegreeMs = 3600000
+)
+
+// LOCData is the RDATA of a LOC record (RFC 1876), written as
+// `d1 [m1 [s1]] N|S d2 [m2 [s2]] E|W alt[m] [size[m] [hp[m] [vp[m]]]]`, for
+// example `52 22 23.000 N 4 53 32.000 E -2.00m 0.00m 10000m 10m`. Size and
+// precisions are encoded as a digit times a power of ten centimeters.
+type LOCData struct {
+	Version   uint8
+	Size      uint8
+	HorizPre  uint8
+	VertPre   uint8
+	Latitude  uint32
+	Longitude uint32
+	Altitude  uint32
+}
+
+// ParseLOCContent parses and validates LOC content. Size defaults to 1 m, the
+// horizontal precision to 10,000 m and the vertical precision to 10 m.
+func ParseLOCContent(content string) (LOCData, error) {
+	d := LOCData{Size: 0x12, HorizPre: 0x16, VertPre: 0x13}
+	fields := strings.Fields(content)
+	lat, fields, err := parseLOCCoordinate(fields, "N", "S", 90)
+	if err != nil {
+		return d, err
+	}
+	lon, fields, err := parseLOCCoordinate(fields, "E", "W", 180)
+	if err != nil {
+		return d, err
+	}
+	d.Latitude, d.Longitude = lat, lon
+	if len(fields) == 0 {
+		return d, fmt.Errorf("LOC content must be in format: latitude longitude altitude [size [hp [vp]]]")
+	}
+	if len(fields) > 4 {
+		return d, fmt.Errorf("LOC content has unexpected fields %q", fields[4:])
+	}
+	alt, err := parseLOCMeters(fields[0])
+	if err != nil || alt < -locBaseAlt || alt > math.MaxUint32-locBaseAlt {
+		return d, fmt.Errorf("invalid altitude %q (-100000.00m to 42849672.95m)", fields[0])
+	}
+	d.Altitude = uint32(alt + locBaseAlt) // #nosec G115
+	for i, pre := range []*uint8{&d.Size, &d.HorizPre, &d.VertPre} {
+		if len(fields) <= i+1 {
+			break
+		}
+		cm, err := parseLOCMeters(fields[i+1])
+		if err != nil || cm < 0 || cm > 9e9 {
+			return d, fmt.Errorf("invalid size or precision %q (0m to 90000000m)", fields[i+1])
+		}
+		*pre = encodeLOCPrecision(cm)
+	}
+	return d, nil
+}
+
+// String returns the content form of the RDATA.
+func (d LOCData) String() string {
+	lat := formatLOCCoordinate(d.Latitude, "N", "S")
+	lon := formatLOCCoordinate(d.Longitude, "E", "W")
+	return fmt.Sprintf("%s %s %s %s %s %s", lat, lon, formatLOCMeters(int64(d.Altitude)-locBaseAlt),
+		formatLOCMeters(decodeLOCPrecision(d.Size)), formatLOCMeters(decodeLOCPrecision(d.HorizPre)), formatLOCMeters(decodeLOCPrecision(d.VertPre)))
+}
+
+// parseLOCCoordinate reads degrees, optional minutes and seconds and the
+// hemisphere from the start of fields, returning the encoded coordinate and the
+// fields that follow.
+func parseLOCCoordinate(fields []string, pos, neg string, maxDeg int64) (uint32, []string, error) {
+	var parts []string
+	for len(fields) > 0 && len(parts) < 4 {
+		f := strings.ToUpper(fields[0])
+		fields = fields[1:]
+		if f == pos || f == neg {
+			ms, err := locMilliseconds(parts, maxDeg)
+			if err != nil {
+				return 0, nil, err
+			}
+			if f == neg {
+				ms = -ms
+			}
+			return uint32(locEquator + ms), fields, nil // #nosec G115
+		}
+		parts = append(parts, f)
+	}
+	return 0, nil, fmt.Errorf("LOC coordinate must be degrees [minutes [seconds]] followed by %s or %s", pos, neg)
+}
+
+func locMilliseconds(parts []string, maxDeg int64) (int64, error) {
+	if len(parts) == 0 || len(parts) > 3 {
+		return 0, fmt.Errorf("LOC coordinate must be degrees [minutes [seconds]]")
+	}
+	deg, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || deg < 0 || deg > maxDeg {
+		return 0, fmt.Errorf("invalid degrees %q (0-%d)", parts[0], maxDeg)
+	}
+	var min int64
+	if len(parts) > 1 {
+		if min, err = strconv.ParseInt(parts[1], 10, 64); err != nil || min < 0 || min > 59 {
+			return 0, fmt.Errorf("invalid minutes %q (0-59)", parts[1])
+		}
+	}
+	var sec float64
+	if len(parts) > 2 {
+		if sec, err = strconv.ParseFloat(parts[2], 64); err != nil || sec < 0 || sec >= 60 {
+			return 0, fmt.Errorf("invalid seconds %q (0-59.999)", parts[2])
+		}
+	}
+	ms := deg*locDegreeMs + min*60000 + int64(math.Round(sec*1000))
+	if ms > maxDeg*locDegreeMs {
+		return 0, fmt.Errorf("LOC coordinate exceeds %d degrees", maxDeg)
+	}
+	return ms, nil
+}
+
+func formatLOCCoordinate(v uint32, pos, neg string) string {
+	ms := int64(v) - locEquator
+	hemisphere := pos
+	if ms < 0 {
+		ms, hemisphere = -ms, neg
+	}
+	return fmt.Sprintf("%d %d %d.%03d %s", ms/locDegreeMs, ms%locDegreeMs/60000, ms%60000/1000, ms%1000, hemisphere)
+}
+
+// parseLOCMeters parses a distance in meters, with an optional "m" suffix, as
+// centimeters.
+func parseLOCMeters(s string) (int64, error) {
+	m, err := strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(s), "m"), 64)
+	if err != nil || math.IsNaN(m) || math.IsInf(m, 0) {
+		return 0, fmt.Errorf("invalid distance %q", s)
+	}
+	return int64(math.Round(m * 100)), nil
+}
+
+func formatLOCMeters(cm int64) string {
+	sign := ""
+	if cm < 0 {
+		cm, sign = -cm, "-"
+	}
+	if cm%100 == 0 {
+		return fmt.Sprintf("%s%dm", sign, cm/100)
+	}
+	return fmt.Sprintf("%s%d.%02dm", sign, cm/100, cm%100)
+}
+
+// encodeLOCPrecision encodes centimeters as a digit in the high and a power of
+// ten in the low nibble, rounding down.
+func encodeLOCPrecision(cm int64) uint8 {
+	var exp uint8
+	for cm >= 10 {
+		cm /= 10
+		exp++
+	}
+	return uint8(cm)<<4 | exp // #nosec G115
+}
+
+func decodeLOCPrecision(b uint8) int64 {
+	cm := int64(b >> 4)
+	for i := uint8(0); i < b&0x0F; i++ {
+		cm *= 10
+	}
+	return cm
+}
+
+// ValidateRDATAContent validates the content of the record types whose RDATA
+// is parsed from their content: NAPTR, TLSA, SSHFP, LOC, URI and CERT. Other
+// types are left to their own checks.
+func ValidateRDATAContent(t RecordType, content string) error {
+	var err error
+	switch t {
+	case TypeNAPTR:
+		_, err = ParseNAPTRContent(content)
+	case TypeTLSA:
+		_, err = ParseTLSAContent(content)
+	case TypeSSHFP:
+		_, err = ParseSSHFPContent(content)
+	case TypeLOC:
+		_, err = ParseLOCContent(content)
+	case TypeURI:
+		_, err = ParseURIContent(content)
+	case TypeCERT:
+		_, err = ParseCERTContent(content)
+	}
+	return err
+}
+
+// checkDigestLen checks that data is a digest of want bytes, or is not empty
+// when the digest type is unknown and want is zero.
+func checkDigestLen(data []byte, want int) error {
+	if len(data) == 0 {
+		return fmt.Errorf("data is required")
+	}
+	if want != 0 && len(data) != want {
+		return fmt.Errorf("digest is %d bytes, want %d", len(data), want)
+	}
+	return nil
+}
+
+// parseUint parses a field of the given bit size, naming it in the error.
+func parseUint(s, name string, bits int) (uint64, error) {
+	v, err := strconv.ParseUint(s, 10, bits)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %s (must be 0-%d)", name, s, uint64(1)<<bits-1)
+	}
+	return v, nil
+}
+
+// splitQuoted splits content at white space into fields, a quoted string with
+// its spaces being one field. Inside quotes \" and \\ stand for a quote and a
+// backslash.
+func splitQuoted(content string) ([]string, error) {
+	var fields []string
+	s := strings.TrimSpace(content)
+	for s != "" {
+		if s[0] != '"' {
+			end := strings.IndexAny(s, " \t")
+			if end < 0 {
+				end = len(s)
+			}
+			fields = append(fields, s[:end])
+			s = strings.TrimSpace(s[end:])
+			continue
+		}
+		var b strings.Builder
+		i := 1
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+			}
+			b.WriteByte(s[i])
+		}
+		if i == len(s) {
+			return nil, fmt.Errorf("unterminated quoted string")
+		}
+		fields = append(fields, b.String())
+		s = strings.TrimSpace(s[i+1:])
+	}
+	return fields, nil
+}
+
+// quote writes s as a quoted string for splitQuoted.
+func quote(s string) string {
+	return `"` + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), `"`, `\"`) + `"`
+}
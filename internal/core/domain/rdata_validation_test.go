@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"testing"
+)
+
+func TestValidateRDATAContent(t *testing.T) {
+	sha256 := "0c72ac70b745ac19998811b131d662c9ac69dbdbe7cb23e5b514b56664c5d3d6"
+	tests := []struct {
+		name    string
+		typ     RecordType
+		content string
+		wantErr bool
+	}{
+		{"NAPTR regexp", TypeNAPTR, `100 10 "U" "E2U+sip" "!^.*$!sip:info@example.com!" .`, false},
+		{"NAPTR replacement", TypeNAPTR, `100 50 "s" "SIP+D2U" "" _sip._udp.example.com.`, false},
+		{"NAPTR regexp and replacement", TypeNAPTR, `100 10 "U" "E2U+sip" "!^.*$!sip:x!" sip.example.com.`, true},
+		{"NAPTR relative replacement", TypeNAPTR, `100 10 "" "" "" sip.example.com`, true},
+		{"NAPTR bad flags", TypeNAPTR, `100 10 "U+" "" "" .`, true},
+		{"NAPTR unterminated", TypeNAPTR, `100 10 "U "E2U" "" .`, true},
+		{"TLSA", TypeTLSA, "3 1 1 " + sha256, false},
+		{"TLSA split data", TypeTLSA, "3 1 1 " + sha256[:32] + " " + sha256[32:], false},
+		{"TLSA full certificate", TypeTLSA, "3 0 0 3082", false},
+		{"TLSA digest length", TypeTLSA, "3 1 1 0c72", true},
+		{"TLSA usage out of range", TypeTLSA, "256 1 1 " + sha256, true},
+		{"TLSA bad hex", TypeTLSA, "3 1 1 zz", true},
+		{"SSHFP", TypeSSHFP, "4 2 " + sha256, false},
+		{"SSHFP SHA-1 length", TypeSSHFP, "1 1 " + sha256, true},
+		{"SSHFP too few parts", TypeSSHFP, "4 2", true},
+		{"LOC", TypeLOC, "52 22 23.000 N 4 53 32.000 E -2.00m 0.00m 10000m 10m", false},
+		{"LOC degrees only", TypeLOC, "42 S 73 W 10m", false},
+		{"LOC latitude out of range", TypeLOC, "91 0 0 N 4 E 0m", true},
+		{"LOC no hemisphere", TypeLOC, "52 22 23 4 53 32 E 0m", true},
+		{"LOC no altitude", TypeLOC, "52 N 4 E", true},
+		{"URI", TypeURI, `10 1 "https://www.example.com/path"`, false},
+		{"URI relative", TypeURI, `10 1 "/path"`, true},
+		{"URI weight out of range", TypeURI, `10 70000 "https://example.com/"`, true},
+		{"CERT mnemonic", TypeCERT, "PGP 0 0 aGVsbG8=", false},
+		{"CERT numeric", TypeCERT, "1 12345 8 aGVs bG8=", false},
+		{"CERT unknown mnemonic", TypeCERT, "X509 0 0 aGVsbG8=", true},
+		{"CERT bad base64", TypeCERT, "PKIX 0 0 !!", true},
+		{"Other types", TypeA, "not checked here", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateRDATAContent(tt.typ, tt.content); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateRDATAContent(%s, %q) error = %v, wantErr %v", tt.typ, tt.content, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRDATAContentRoundTrip(t *testing.T) {
+	naptr, err := ParseNAPTRContent(`100 10 "U" "E2U+sip" "!^.*$!sip:\"x\"@example.com!" .`)
+	if err != nil || naptr.Regexp != `!^.*$!sip:"x"@example.com!` {
+		t.Fatalf("Unexpected NAPTR %+v, %v", naptr, err)
+	}
+	if again, err := ParseNAPTRContent(naptr.String()); err != nil || again != naptr {
+		t.Errorf("NAPTR did not round-trip: %q, %v", naptr.String(), err)
+	}
+
+	loc, err := ParseLOCContent("52 22 23.000 N 4 53 32.000 E -2.00m 0.00m 10000m 10m")
+	if err != nil {
+		t.Fatalf("ParseLOCContent failed: %v", err)
+	}
+	if loc.Latitude != 1<<31+188543000 || loc.Longitude != 1<<31+17612000 || loc.Altitude != 9999800 || loc.HorizPre != 0x16 {
+		t.Errorf("Unexpected LOC encoding %+v", loc)
+	}
+	if got := loc.String(); got != "52 22 23.000 N 4 53 32.000 E -2m 0m 10000m 10m" {
+		t.Errorf("Unexpected LOC content %q", got)
+	}
+	south, err := ParseLOCContent("42 21 43.528 S 71 5 6.284 W 12.50m")
+	if err != nil || south.String() != "42 21 43.528 S 71 5 6.284 W 12.50m 1m 10000m 10m" {
+		t.Errorf("Unexpected LOC %q, %v", south.String(), err)
+	}
+
+	cert, err := ParseCERTContent("pkix 1 8 aGVsbG8=")
+	if err != nil || cert.Type != 1 || cert.String() != "PKIX 1 8 aGVsbG8=" {
+		t.Errorf("Unexpected CERT %+v, %v", cert, err)
+	}
+}
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"testing"
+)
+
+func TestValidateSVCBFields(t *testing.T) {
+	one, zero, big := 1, 0, 70000
+	tests := []struct {
+		name     string
+		priority *int
+		content  string
+		wantErr  bool
+	}{
+		{"ServiceMode", &one, "svc.example.com. alpn=h2,h3 ipv4hint=192.0.2.1", false},
+		{"Owner as target", &one, ". alpn=h3", false},
+		{"AliasMode", &zero, "cdn.example.net.", false},
+		{"AliasMode with params", &zero, "cdn.example.net. alpn=h2", true},
+		{"Missing priority", nil, "svc.example.com.", true},
+		{"Priority out of range", &big, "svc.example.com.", true},
+		{"Target not FQDN", &one, "svc alpn=h2", true},
+		{"Empty content", &one, "", true},
+		{"Unknown key", &one, "svc.example.com. color=red", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateSVCBFields(tt.priority, tt.content); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSVCBFields(%q) error = %v, wantErr %v", tt.content, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,13 +2,20 @@ package domain
 
 import (
 	"fmt"
+	"net"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
+
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+	"github.com/poyrazK/cloudDNS/internal/dns/svcb"
 )
 
 var validLabelRegex = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
 
+var caaTagRegex = regexp.MustCompile(`^[a-zA-Z0-9]{1,15}$`)
+
 // ValidateZoneName checks if the provided zone name is a valid FQDN.
 func ValidateZoneName(name string) error {
 	if name == "" {
@@ -17,15 +24,14 @@ func ValidateZoneName(name string) error {
 	if name == "." {
 		return nil // Root zone is valid
 	}
-	if !strings.HasSuffix(name, ".") {
+	if !dnsname.IsFqdn(name) {
 		return fmt.Errorf("zone name must end with a dot (FQDN)")
 	}
 	if len(name) > 254 {
 		return fmt.Errorf("zone name exceeds 253 characters")
 	}
 
-	// Remove trailing dot for label validation
-	labels := strings.Split(strings.TrimSuffix(name, "."), ".")
+	labels := dnsname.SplitLabels(name)
 	for _, label := range labels {
 		if len(label) > 63 {
 			return fmt.Errorf("label '%s' exceeds 63 characters", label)
@@ -56,7 +62,7 @@ func ValidateSRVContent(content string) error {
 	}
 
 	target := parts[3]
-	if !strings.HasSuffix(target, ".") {
+	if !dnsname.IsFqdn(target) {
 		return fmt.Errorf("target must be a FQDN (end with a dot)")
 	}
 
@@ -77,12 +83,111 @@ func ValidateSRVFields(priority, weight, port *int, target string) error {
 	if *port < 0 || *port > 65535 {
 		return fmt.Errorf("invalid port: %d (must be 0-65535)", *port)
 	}
-	if !strings.HasSuffix(target, ".") {
+	if !dnsname.IsFqdn(target) {
 		return fmt.Errorf("SRV target must be a FQDN (end with a dot)")
 	}
 	return nil
 }
 
+// ValidateSVCBFields validates SVCB and HTTPS fields. Used for API inputs.
+// Content holds the target name followed by SvcParams in presentation format,
+// for example "svc.example.com. alpn=h2,h3 ipv4hint=192.0.2.1"; a target of
+// "." stands for the owner name. Priority 0 is AliasMode, which takes no
+// SvcParams.
+func ValidateSVCBFields(priority *int, content string) error {
+	if priority == nil {
+		return fmt.Errorf("SVCB record requires a priority field")
+	}
+	if *priority < 0 || *priority > 65535 {
+		return fmt.Errorf("invalid priority: %d (must be 0-65535)", *priority)
+	}
+	fields := strings.Fields(content)
+	if len(fields) == 0 || !dnsname.IsFqdn(fields[0]) {
+		return fmt.Errorf("SVCB target must be a FQDN (end with a dot)")
+	}
+	if *priority == 0 && len(fields) > 1 {
+		return fmt.Errorf("AliasMode (priority 0) records take no SvcParams")
+	}
+	if _, err := svcb.Parse(fields[1:]); err != nil {
+		return fmt.Errorf("invalid SvcParams: %w", err)
+	}
+	return nil
+}
+
+// ParseCAAContent splits CAA content in "flags tag value" format, for example
+// `0 issue "letsencrypt.org"`, removing the quotes around the value.
+func ParseCAAContent(content string) (flags uint8, tag, value string, err error) {
+	// The value may contain spaces, so only the first two fields are split off
+	parts := strings.Fields(content)
+	if len(parts) >= 3 {
+		rest := strings.TrimSpace(content)
+		for _, field := range parts[:2] {
+			rest = strings.TrimSpace(rest[len(field):])
+		}
+		parts = append(parts[:2], rest)
+	}
+	if len(parts) != 3 {
+		return 0, "", "", fmt.Errorf("CAA content must be in format: flags tag value")
+	}
+	f, err := strconv.Atoi(parts[0])
+	if err != nil || f < 0 || f > 255 {
+		return 0, "", "", fmt.Errorf("invalid flags: %s (must be 0-255)", parts[0])
+	}
+	tag = parts[1]
+	if !caaTagRegex.MatchString(tag) {
+		return 0, "", "", fmt.Errorf("invalid tag %q (1-15 letters or digits)", tag)
+	}
+	value = strings.TrimSpace(parts[2])
+	if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		value = strings.ReplaceAll(value[1:len(value)-1], `\"`, `"`)
+	}
+	return uint8(f), tag, value, nil // #nosec G115
+}
+
+// ValidateCAAContent validates CAA content (RFC 8659). The issue and issuewild
+// values must name an issuer domain, or be empty to forbid issuance, and iodef
+// must be a mailto:, http: or https: URL. Other tags are stored as given.
+func ValidateCAAContent(content string) error {
+	_, tag, value, err := ParseCAAContent(content)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(tag) {
+	case "issue", "issuewild":
+		issuer, _, _ := strings.Cut(value, ";")
+		issuer = strings.TrimSpace(issuer)
+		if issuer == "" {
+			return nil
+		}
+		for _, label := range strings.Split(issuer, ".") {
+			if !validLabelRegex.MatchString(label) {
+				return fmt.Errorf("invalid issuer domain %q", issuer)
+			}
+		}
+	case "iodef":
+		u, err := url.Parse(value)
+		if err != nil || (u.Scheme != "mailto" && u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("iodef must be a mailto:, http: or https: URL")
+		}
+	}
+	return nil
+}
+
+// ValidateALIASContent validates the target of an ALIAS record, a host name
+// given as a FQDN, such as "lb-123.elb.example.net.".
+func ValidateALIASContent(content string) error {
+	target := strings.TrimSpace(content)
+	if !dnsname.IsFqdn(target) || target == "." {
+		return fmt.Errorf("ALIAS target must be a FQDN (end with a dot)")
+	}
+	for _, label := range dnsname.SplitLabels(target) {
+		if !validLabelRegex.MatchString(label) {
+			return fmt.Errorf("invalid ALIAS target %q", target)
+		}
+	}
+	return nil
+}
+
 // ValidateZoneRole checks if the role is valid and master_server is provided for slave zones.
 func ValidateZoneRole(role, masterServer string) error {
 	if role == "" {
@@ -94,5 +199,69 @@ func ValidateZoneRole(role, masterServer string) error {
 	if role == "slave" && masterServer == "" {
 		return fmt.Errorf("master server is required for slave zones")
 	}
+	if masterServer != "" {
+		if _, err := ParseMasters(masterServer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseMasters parses master_server, a comma-separated list of IP addresses or
+// host names with an optional port, into host:port addresses in order of
+// preference. The port defaults to 53.
+func ParseMasters(masterServer string) ([]string, error) {
+	var masters []string
+	for _, entry := range strings.Split(masterServer, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		host, port := entry, "53"
+		if h, p, err := net.SplitHostPort(entry); err == nil {
+			host, port = h, p
+		}
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return nil, fmt.Errorf("master server %q: invalid port", entry)
+		}
+		if net.ParseIP(host) == nil {
+			if err := ValidateZoneName(dnsname.Fqdn(host)); err != nil {
+				return nil, fmt.Errorf("master server %q: %v", entry, err)
+			}
+		}
+		masters = append(masters, net.JoinHostPort(host, port))
+	}
+	if len(masters) == 0 {
+		return nil, fmt.Errorf("master server is empty")
+	}
+	return masters, nil
+}
+
+var geoContinents = map[string]bool{"AF": true, "AN": true, "AS": true, "EU": true, "NA": true, "OC": true, "SA": true}
+
+// ValidateGeoPolicy checks that a geo policy names at least one location, by
+// continent code, ISO 3166-1 alpha-2 country code or non-zero ASN, and upper
+// cases its codes.
+func ValidateGeoPolicy(geo *GeoPolicy) error {
+	if len(geo.Continents)+len(geo.Countries)+len(geo.ASNs) == 0 {
+		return fmt.Errorf("%w: no continents, countries or ASNs", ErrInvalidGeoPolicy)
+	}
+	for i, c := range geo.Continents {
+		geo.Continents[i] = strings.ToUpper(strings.TrimSpace(c))
+		if !geoContinents[geo.Continents[i]] {
+			return fmt.Errorf("%w: unknown continent %q", ErrInvalidGeoPolicy, c)
+		}
+	}
+	for i, c := range geo.Countries {
+		geo.Countries[i] = strings.ToUpper(strings.TrimSpace(c))
+		if len(geo.Countries[i]) != 2 || strings.Trim(geo.Countries[i], "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+			return fmt.Errorf("%w: country %q is not a two-letter code", ErrInvalidGeoPolicy, c)
+		}
+	}
+	for _, asn := range geo.ASNs {
+		if asn == 0 {
+			return fmt.Errorf("%w: ASN 0", ErrInvalidGeoPolicy)
+		}
+	}
 	return nil
 }
@@ -1,6 +1,10 @@
 package domain
 
-import "testing"
+import (
+	"errors"
+	"strings"
+	"testing"
+)
 
 func TestValidateZoneName(t *testing.T) {
 	tests := []struct {
@@ -27,3 +31,54 @@ func TestValidateZoneName(t *testing.T) {
 		})
 	}
 }
+
+func TestParseMasters(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"192.0.2.1", "192.0.2.1:53", false},
+		{"192.0.2.1:5353, ns1.example.com", "192.0.2.1:5353,ns1.example.com:53", false},
+		{"[2001:db8::1]:53,2001:db8::2", "[2001:db8::1]:53,[2001:db8::2]:53", false},
+		{"", "", true},
+		{" , ", "", true},
+		{"192.0.2.1:0", "", true},
+		{"bad_host", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseMasters(tt.in)
+		if (err != nil) != tt.wantErr || strings.Join(got, ",") != tt.want {
+			t.Errorf("ParseMasters(%q) = %v, %v", tt.in, got, err)
+		}
+	}
+	if err := ValidateZoneRole("slave", "192.0.2.1:99999"); err == nil {
+		t.Error("Expected an invalid master port to be rejected")
+	}
+}
+
+func TestValidateGeoPolicy(t *testing.T) {
+	geo := &GeoPolicy{Continents: []string{"eu"}, Countries: []string{" de"}, ASNs: []uint32{64500}}
+	if err := ValidateGeoPolicy(geo); err != nil {
+		t.Fatalf("Expected a valid policy, got %v", err)
+	}
+	if geo.Continents[0] != "EU" || geo.Countries[0] != "DE" {
+		t.Errorf("Expected upper case codes, got %+v", geo)
+	}
+	if got := strings.Join(geo.Scopes(), ","); got != "continent:EU,country:DE,asn:64500" {
+		t.Errorf("Unexpected scopes %q", got)
+	}
+
+	for _, bad := range []*GeoPolicy{
+		{},
+		{Continents: []string{"Europe"}},
+		{Countries: []string{"DEU"}},
+		{Countries: []string{"D1"}},
+		{ASNs: []uint32{0}},
+	} {
+		if err := ValidateGeoPolicy(bad); !errors.Is(err, ErrInvalidGeoPolicy) {
+			t.Errorf("Expected ErrInvalidGeoPolicy for %+v, got %v", bad, err)
+		}
+	}
+}
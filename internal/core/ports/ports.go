@@ -4,30 +4,61 @@ package ports
 import (
 	"context"
 	"io"
+	"net"
+	"time"
 
 	"github.com/poyrazK/cloudDNS/internal/core/domain"
 )
 
 // DNSRepository defines the interface for DNS data persistence.
 type DNSRepository interface {
+	// GetRecords returns the records of a name visible to clientIP, an address or
+	// a client subnet in CIDR notation: global records plus those whose network
+	// contains it.
 	GetRecords(ctx context.Context, name string, qType domain.RecordType, clientIP string) ([]domain.Record, error)
 	GetIPsForName(ctx context.Context, name string, clientIP string) ([]string, error)
+	// ListRecordNetworks returns the distinct networks split-horizon records are
+	// scoped to.
+	ListRecordNetworks(ctx context.Context) ([]string, error)
+	// ListRecordGeoScopes returns the distinct locations geo policies name, as
+	// "continent:EU", "country:DE" or "asn:64500".
+	ListRecordGeoScopes(ctx context.Context) ([]string, error)
 	GetZone(ctx context.Context, name string) (*domain.Zone, error)
+	GetZoneByID(ctx context.Context, zoneID string, tenantID string) (*domain.Zone, error)
 	GetRecord(ctx context.Context, id string, zoneID string, tenantID string) (*domain.Record, error)
 	ListRecordsForZone(ctx context.Context, zoneID string, tenantID string) ([]domain.Record, error)
+	// NameExists reports whether the zone has records at name or at a name
+	// below it, which makes name exist without records of its own (an empty
+	// non-terminal).
+	NameExists(ctx context.Context, zoneID string, name string) (bool, error)
 	CreateZone(ctx context.Context, zone *domain.Zone) error
 	CreateZoneWithRecords(ctx context.Context, zone *domain.Zone, records []domain.Record) error
 	CreateRecord(ctx context.Context, record *domain.Record) error
 	BatchCreateRecords(ctx context.Context, records []domain.Record) error
 	ListZones(ctx context.Context, tenantID string) ([]domain.Zone, error)
+	// HasZones reports whether any zone exists, reading at most one row.
+	HasZones(ctx context.Context) (bool, error)
 	DeleteZone(ctx context.Context, zoneID string, tenantID string) error
+	SetZoneDNSSEC(ctx context.Context, zoneID string, tenantID string, enabled bool) error
+	SetZoneRole(ctx context.Context, zoneID string, tenantID string, role string, masterServer string) error
 	DeleteRecord(ctx context.Context, recordID string, zoneID string, tenantID string) error
+	// SetRecordProtected marks a record as protected from deletion, reporting
+	// false if it does not exist.
+	SetRecordProtected(ctx context.Context, recordID string, zoneID string, tenantID string, protected bool) (bool, error)
 	DeleteRecordsByNameAndType(ctx context.Context, zoneID string, name string, qType domain.RecordType) error
 	DeleteRecordsByName(ctx context.Context, zoneID string, name string) error
 	DeleteRecordsForZone(ctx context.Context, zoneID string) error
+	// ReplaceZoneRecords atomically swaps all records of a zone for records.
+	ReplaceZoneRecords(ctx context.Context, zoneID string, records []domain.Record) error
+	// ApplyZoneDiff atomically applies the difference sequences of an IXFR.
+	ApplyZoneDiff(ctx context.Context, zoneID string, diffs []domain.IXFRChunk) error
 	DeleteRecordSpecific(ctx context.Context, zoneID string, name string, qType domain.RecordType, content string) error
+	ReplaceRRset(ctx context.Context, zoneID string, name string, qType domain.RecordType, records []domain.Record) error
 	RecordZoneChange(ctx context.Context, change *domain.ZoneChange) error
 	ListZoneChanges(ctx context.Context, zoneID string, fromSerial uint32) ([]domain.ZoneChange, error)
+	// LastZoneChangeAt returns when the zone's journal last recorded a change,
+	// or nil if it has none.
+	LastZoneChangeAt(ctx context.Context, zoneID string) (*time.Time, error)
 	GetIXFRChain(ctx context.Context, zoneID string, fromSerial uint32, toSerial uint32) ([]domain.IXFRChunk, error)
 	SaveAuditLog(ctx context.Context, log *domain.AuditLog) error
 	GetAuditLogs(ctx context.Context, tenantID string) ([]domain.AuditLog, error)
@@ -47,6 +78,104 @@ type DNSRepository interface {
 	// Smart Engine (GSLB) Support
 	UpdateRecordHealth(ctx context.Context, recordID string, status domain.HealthStatus, errMsg string) error
 	GetRecordsToProbe(ctx context.Context) ([]domain.Record, error)
+
+	// Fleet Registry
+	UpsertNode(ctx context.Context, node *domain.Node) error
+	ListNodes(ctx context.Context) ([]domain.Node, error)
+	GetNodeConfig(ctx context.Context, nodeID string) (map[string]string, error)
+	SetNodeConfig(ctx context.Context, nodeID string, cfg map[string]string) error
+	SaveRollout(ctx context.Context, rollout *domain.Rollout) error
+	GetRollout(ctx context.Context, id string) (*domain.Rollout, error)
+	ListRollouts(ctx context.Context) ([]domain.Rollout, error)
+
+	// Usage Statistics
+	AddZoneUsage(ctx context.Context, usage []domain.ZoneUsage) error
+	ListZoneUsage(ctx context.Context, tenantID string, zoneID string, from, to time.Time) ([]domain.ZoneUsage, error)
+
+	// External Secondary Providers
+	CreateSecondaryProvider(ctx context.Context, provider *domain.SecondaryProvider) error
+	ListSecondaryProviders(ctx context.Context, zoneID string) ([]domain.SecondaryProvider, error)
+	DeleteSecondaryProvider(ctx context.Context, id string, zoneID string) error
+	UpdateSecondaryProviderStatus(ctx context.Context, provider *domain.SecondaryProvider) error
+
+	// Secondary Zone Refreshes
+	// SaveZoneRefresh stores the outcome of a refresh from the masters, keeping
+	// the time of the last success when it failed.
+	SaveZoneRefresh(ctx context.Context, refresh *domain.ZoneRefresh) error
+	// GetZoneRefresh returns nil if the zone has never been refreshed.
+	GetZoneRefresh(ctx context.Context, zoneID string) (*domain.ZoneRefresh, error)
+
+	// Scheduled Record Changes
+	CreateScheduledChange(ctx context.Context, change *domain.ScheduledChange) error
+	ListScheduledChanges(ctx context.Context, zoneID string) ([]domain.ScheduledChange, error)
+	CancelScheduledChange(ctx context.Context, id string, zoneID string) (bool, error)
+	ClaimDueScheduledChanges(ctx context.Context, now time.Time, limit int) ([]domain.ScheduledChange, error)
+	FinishScheduledChange(ctx context.Context, change *domain.ScheduledChange) error
+
+	// Change Freezes
+	CreateChangeFreeze(ctx context.Context, freeze *domain.ChangeFreeze) error
+	ListChangeFreezes(ctx context.Context, tenantID string) ([]domain.ChangeFreeze, error)
+	DeleteChangeFreeze(ctx context.Context, id string, tenantID string) (bool, error)
+	GetActiveChangeFreeze(ctx context.Context, tenantID string, zoneID string, at time.Time) (*domain.ChangeFreeze, error)
+
+	// Change Review
+	SetZoneReview(ctx context.Context, zoneID string, tenantID string, required bool) error
+	IsZoneReviewed(ctx context.Context, zoneID string) (bool, error)
+	CreateChangeRequest(ctx context.Context, change *domain.ChangeRequest) error
+	ListChangeRequests(ctx context.Context, zoneID string) ([]domain.ChangeRequest, error)
+	GetChangeRequest(ctx context.Context, id string, zoneID string) (*domain.ChangeRequest, error)
+	ReviewChangeRequest(ctx context.Context, change *domain.ChangeRequest) (bool, error)
+	FinishChangeRequest(ctx context.Context, change *domain.ChangeRequest) error
+
+	// Shadow Changes
+	SaveShadowChange(ctx context.Context, change *domain.ShadowChange) error
+	GetShadowChange(ctx context.Context, zoneID string) (*domain.ShadowChange, error)
+	ListShadowChanges(ctx context.Context) ([]domain.ShadowChange, error)
+	DeleteShadowChange(ctx context.Context, zoneID string) (bool, error)
+
+	// Zone SLOs
+	SaveZoneSLO(ctx context.Context, slo *domain.ZoneSLO) error
+	GetZoneSLO(ctx context.Context, zoneID string) (*domain.ZoneSLO, error)
+	ListZoneSLOs(ctx context.Context) ([]domain.ZoneSLO, error)
+	DeleteZoneSLO(ctx context.Context, zoneID string) (bool, error)
+
+	// Query Limits; an empty tenantID lists every tenant's limits and an empty
+	// zoneID names the tenant-wide limit
+	SaveQueryLimit(ctx context.Context, limit *domain.QueryLimit) error
+	ListQueryLimits(ctx context.Context, tenantID string) ([]domain.QueryLimit, error)
+	DeleteQueryLimit(ctx context.Context, tenantID string, zoneID string) (bool, error)
+
+	// Views; an empty tenantID lists every tenant's views. Deleting a view
+	// deletes the records scoped to it.
+	SaveView(ctx context.Context, view *domain.View) error
+	ListViews(ctx context.Context, tenantID string) ([]domain.View, error)
+	DeleteView(ctx context.Context, id string, tenantID string) (bool, error)
+
+	// Default Records; an empty zoneID lists every zone's default records
+	SaveDefaultRecord(ctx context.Context, rec *domain.DefaultRecord) error
+	ListDefaultRecords(ctx context.Context, zoneID string) ([]domain.DefaultRecord, error)
+	DeleteDefaultRecord(ctx context.Context, id string, zoneID string) (bool, error)
+
+	// Targets; an empty tenantID lists every tenant's targets
+	SaveTarget(ctx context.Context, target *domain.Target) error
+	ListTargets(ctx context.Context, tenantID string) ([]domain.Target, error)
+	DeleteTarget(ctx context.Context, id string, tenantID string) (bool, error)
+	ListTargetRecords(ctx context.Context, targetID string) ([]domain.Record, error)
+
+	// Health checks; an empty tenantID lists every tenant's checks
+	SaveHealthCheck(ctx context.Context, check *domain.HealthCheck) error
+	ListHealthChecks(ctx context.Context, tenantID string) ([]domain.HealthCheck, error)
+	DeleteHealthCheck(ctx context.Context, id string, tenantID string) (bool, error)
+	UpdateHealthCheckStatus(ctx context.Context, id string, status domain.HealthStatus, errMsg string) error
+	ListHealthCheckRecords(ctx context.Context, checkID string) ([]domain.Record, error)
+
+	// IPAM subnets and their allocated addresses
+	SaveSubnet(ctx context.Context, subnet *domain.Subnet) error
+	ListSubnets(ctx context.Context, tenantID string) ([]domain.Subnet, error)
+	DeleteSubnet(ctx context.Context, id string, tenantID string) (bool, error)
+	SaveIPAllocation(ctx context.Context, alloc *domain.IPAllocation) error
+	ListIPAllocations(ctx context.Context, subnetID string) ([]domain.IPAllocation, error)
+	DeleteIPAllocation(ctx context.Context, id string, subnetID string) (bool, error)
 }
 
 // DNSService defines the interface for core DNS business logic.
@@ -58,13 +187,328 @@ type DNSService interface {
 	UpdateRecordHealth(ctx context.Context, recordID string, status domain.HealthStatus, errMsg string) error // Added for Smart Engine
 	ListZones(ctx context.Context, tenantID string) ([]domain.Zone, error)
 	ListRecordsForZone(ctx context.Context, zoneID string, tenantID string) ([]domain.Record, error)
+	GetZoneSummary(ctx context.Context, zoneID string, tenantID string) (*domain.ZoneSummary, error)
+	SetZoneDNSSEC(ctx context.Context, zoneID string, tenantID string, enabled bool) (*domain.Zone, error)
+	SetZoneRole(ctx context.Context, zoneID string, tenantID string, role string, masterServer string) (*domain.Zone, error)
+	CloneZone(ctx context.Context, zoneID string, tenantID string, opts domain.ZoneCloneOptions) (*domain.Zone, error)
+	GetRRset(ctx context.Context, zoneID string, tenantID string, name string, qType domain.RecordType) (*domain.RRset, error)
+	ReplaceRRset(ctx context.Context, zoneID string, tenantID string, rrset *domain.RRset) (*domain.RRset, error)
+	DiffZone(ctx context.Context, zoneID string, tenantID string, against string) (*domain.ZoneDiff, error)
+	ListZoneChangeFeed(ctx context.Context, zoneID string, tenantID string, sinceSerial uint32, limit int) (*domain.ZoneChangeFeed, error)
 	DeleteZone(ctx context.Context, zoneID string, tenantID string) error
 	DeleteRecord(ctx context.Context, recordID string, zoneID string, tenantID string) error
-	ImportZone(ctx context.Context, tenantID string, r io.Reader) (*domain.Zone, error)
+	SetRecordProtection(ctx context.Context, recordID string, zoneID string, tenantID string, protected bool) (*domain.Record, error)
+	ImportZone(ctx context.Context, tenantID string, r io.Reader, vars map[string]string) (*domain.Zone, error)
+	ImportRecords(ctx context.Context, zoneID string, tenantID string, format string, r io.Reader, dryRun bool) (*domain.RecordImportResult, error)
 	ListAuditLogs(ctx context.Context, tenantID string) ([]domain.AuditLog, error)
 	HealthCheck(ctx context.Context) map[string]error
 }
 
+// DNSSECKeyManager exposes a tenant's zone keys for inspection, manual rollover,
+// delegation to the parent zone, migration in BIND's key file format and key
+// exchange with the other providers of a multi-signer zone (RFC 8901).
+type DNSSECKeyManager interface {
+	ListZoneKeys(ctx context.Context, zoneID string, tenantID string) ([]domain.DNSSECKeyInfo, error)
+	RolloverKey(ctx context.Context, zoneID string, tenantID string, keyType string) (*domain.DNSSECKeyInfo, error)
+	SetKeyActive(ctx context.Context, zoneID string, tenantID string, keyID string, active bool) (*domain.DNSSECKeyInfo, error)
+	ZoneDS(ctx context.Context, zoneID string, tenantID string, digestTypes []uint8) ([]domain.DSRecord, error)
+	ImportKey(ctx context.Context, zoneID string, tenantID string, files domain.DNSSECKeyFiles) (*domain.DNSSECKeyInfo, error)
+	ExportKey(ctx context.Context, zoneID string, tenantID string, keyID string) (*domain.DNSSECKeyFiles, error)
+	ImportSignerKey(ctx context.Context, zoneID string, tenantID string, dnskey string) (*domain.DNSSECKeyInfo, error)
+	SignerKeys(ctx context.Context, zoneID string, tenantID string) ([]string, error)
+}
+
+// ReadinessChecker reports whether the node is ready to serve traffic.
+type ReadinessChecker interface {
+	Readiness(ctx context.Context) *domain.ReadinessReport
+}
+
+// EventPublisher fans out live zone, record and query events.
+type EventPublisher interface {
+	Publish(event domain.Event)
+}
+
+// EventStream delivers live events to subscribers. The returned channel is closed
+// once ctx is done.
+type EventStream interface {
+	Subscribe(ctx context.Context, filter domain.EventFilter) <-chan domain.Event
+}
+
+// NodeLister reports the status of every node in the fleet.
+type NodeLister interface {
+	ListNodes(ctx context.Context) ([]domain.Node, error)
+}
+
+// RolloutOrchestrator applies node configuration changes to the fleet in stages.
+type RolloutOrchestrator interface {
+	StartRollout(ctx context.Context, rollout *domain.Rollout) (*domain.Rollout, error)
+	GetRollout(ctx context.Context, id string) (*domain.Rollout, error)
+	ListRollouts(ctx context.Context) ([]domain.Rollout, error)
+	AbortRollout(ctx context.Context, id string) error
+}
+
+// DNSQuerier sends a single DNS query to a server, as an external client would.
+type DNSQuerier interface {
+	Query(ctx context.Context, server string, name string, qType domain.RecordType) (*domain.ProbeAnswer, error)
+}
+
+// ProbeReporter exposes the latest synthetic monitoring results.
+type ProbeReporter interface {
+	ProbeResults() []domain.ProbeResult
+}
+
+// ConsistencyChecker compares the answers of every node in the fleet.
+type ConsistencyChecker interface {
+	Check(ctx context.Context, queries []domain.ConsistencyQuery) (*domain.ConsistencyReport, error)
+	LatestReport() *domain.ConsistencyReport
+}
+
+// DelegationSyncer compares and synchronizes the delegation of a zone in its
+// parent zone when the tenant hosts both.
+type DelegationSyncer interface {
+	CheckDelegation(ctx context.Context, zoneID string, tenantID string) (*domain.DelegationStatus, error)
+	SyncDelegation(ctx context.Context, zoneID string, tenantID string) (*domain.DelegationStatus, error)
+}
+
+// ConfigAuditor finds misconfigured zones, such as lame delegations. An empty
+// tenantID covers every tenant.
+type ConfigAuditor interface {
+	Audit(ctx context.Context, tenantID string) (*domain.ConfigAuditReport, error)
+	LatestReport(tenantID string) *domain.ConfigAuditReport
+}
+
+// PropagationChecker reports whether a record is visible through external
+// resolvers and the fleet's own nodes.
+type PropagationChecker interface {
+	CheckRecordPropagation(ctx context.Context, zoneID string, tenantID string, recordID string) (*domain.PropagationReport, error)
+}
+
+// UsageRecorder counts per-zone traffic on the query path. Implementations must be
+// cheap and safe for concurrent use.
+type UsageRecorder interface {
+	RecordQuery(zoneID string, zoneName string, rcode int)
+	RecordCachedQuery(name string, rcode int)
+	RecordTransfer(zoneName string, bytes int)
+}
+
+// UsageReporter serves aggregated per-zone usage.
+type UsageReporter interface {
+	ZoneUsage(ctx context.Context, zoneID string, tenantID string, from, to time.Time) (*domain.ZoneUsageReport, error)
+	ExportUsage(ctx context.Context, tenantID string, from, to time.Time) ([]domain.ZoneUsage, error)
+	TenantUsageSummary(ctx context.Context, tenantID string, from, to time.Time) (*domain.TenantUsageSummary, error)
+}
+
+// APIUsageTracker counts the requests of each API key and rejected credentials,
+// and reports rates and anomalies that may point to a leaked key.
+type APIUsageTracker interface {
+	RecordRequest(key domain.APIKey, status int)
+	RecordAuthFailure(clientIP string, keyPrefix string)
+	APIKeyUsage(tenantID string) []domain.APIKeyUsage
+	APIAnomalies(tenantID string) []domain.APIAnomaly
+}
+
+// AuthLockout bans clients that keep presenting invalid API keys, for longer
+// with every ban.
+type AuthLockout interface {
+	// Banned reports whether clientIP is banned and for how much longer.
+	Banned(clientIP string) (time.Duration, bool)
+	RecordFailure(clientIP string)
+	RecordSuccess(clientIP string)
+	ListBans() []domain.AuthBan
+	// ClearBan lifts the ban of clientIP, reporting whether it was banned.
+	ClearBan(clientIP string) bool
+}
+
+// Authorizer is an external policy engine that decides on authenticated API
+// requests on top of the roles of API keys. An error means no decision could be
+// made.
+type Authorizer interface {
+	Authorize(ctx context.Context, req domain.AuthorizationRequest) (domain.AuthorizationDecision, error)
+}
+
+// QueryObserver feeds answered queries into streaming analytics. An empty zoneName
+// attributes the query to the closest zone seen so far. Implementations must be
+// cheap and safe for concurrent use.
+type QueryObserver interface {
+	ObserveQuery(zoneName string, name string, clientIP string)
+}
+
+// LatencyRecorder tracks per-zone response times on the query path. An empty
+// zoneID attributes the query for name to the closest zone seen so far.
+// Implementations must be cheap and safe for concurrent use.
+type LatencyRecorder interface {
+	RecordLatency(zoneID string, zoneName string, name string, d time.Duration)
+}
+
+// SLOManager manages a tenant's zone latency objectives and reports on them.
+type SLOManager interface {
+	SetZoneSLO(ctx context.Context, zoneID string, tenantID string, slo *domain.ZoneSLO) error
+	GetZoneSLOStatus(ctx context.Context, zoneID string, tenantID string) (*domain.ZoneSLOStatus, error)
+	DeleteZoneSLO(ctx context.Context, zoneID string, tenantID string) error
+}
+
+// QueryLimitManager manages the query rate limits of tenants and their zones.
+type QueryLimitManager interface {
+	SetQueryLimit(ctx context.Context, limit *domain.QueryLimit) error
+	ListQueryLimits(ctx context.Context, tenantID string) ([]domain.QueryLimit, error)
+	DeleteQueryLimit(ctx context.Context, tenantID string, zoneID string) error
+}
+
+// ViewManager manages a tenant's split-horizon views.
+type ViewManager interface {
+	CreateView(ctx context.Context, tenantID string, view *domain.View) error
+	UpdateView(ctx context.Context, tenantID string, view *domain.View) error
+	ListViews(ctx context.Context, tenantID string) ([]domain.View, error)
+	DeleteView(ctx context.Context, tenantID string, id string) error
+}
+
+// DefaultRecordManager manages the default records of a tenant's zones.
+type DefaultRecordManager interface {
+	CreateDefaultRecord(ctx context.Context, zoneID string, tenantID string, rec *domain.DefaultRecord) error
+	ListDefaultRecords(ctx context.Context, zoneID string, tenantID string) ([]domain.DefaultRecord, error)
+	DeleteDefaultRecord(ctx context.Context, zoneID string, tenantID string, id string) error
+}
+
+// TargetManager manages a tenant's targets, the address sets records reference.
+type TargetManager interface {
+	CreateTarget(ctx context.Context, tenantID string, target *domain.Target) error
+	UpdateTarget(ctx context.Context, tenantID string, target *domain.Target) error
+	ListTargets(ctx context.Context, tenantID string) ([]domain.Target, error)
+	DeleteTarget(ctx context.Context, tenantID string, id string) error
+}
+
+// HealthCheckManager manages a tenant's health checks, the probes records
+// reference for their health.
+type HealthCheckManager interface {
+	CreateHealthCheck(ctx context.Context, tenantID string, check *domain.HealthCheck) error
+	UpdateHealthCheck(ctx context.Context, tenantID string, check *domain.HealthCheck) error
+	ListHealthChecks(ctx context.Context, tenantID string) ([]domain.HealthCheck, error)
+	DeleteHealthCheck(ctx context.Context, tenantID string, id string) error
+}
+
+// IPAMManager manages a tenant's subnets and the addresses allocated from
+// them, keeping each address's forward and reverse records in step.
+type IPAMManager interface {
+	CreateSubnet(ctx context.Context, tenantID string, subnet *domain.Subnet) error
+	ListSubnets(ctx context.Context, tenantID string) ([]domain.Subnet, error)
+	DeleteSubnet(ctx context.Context, tenantID string, id string) error
+	AllocateAddress(ctx context.Context, tenantID string, alloc *domain.IPAllocation) error
+	ListAllocations(ctx context.Context, tenantID string, subnetID string) ([]domain.IPAllocation, error)
+	ReleaseAddress(ctx context.Context, tenantID string, subnetID string, id string) error
+}
+
+// GeoLocator tells where a client address is, from GeoIP databases.
+// Implementations must be cheap and safe for concurrent use.
+type GeoLocator interface {
+	Locate(ip net.IP) (domain.GeoLocation, bool)
+}
+
+// ClientObserver aggregates the capabilities advertised by incoming queries.
+// Implementations must be cheap and safe for concurrent use.
+type ClientObserver interface {
+	ObserveClient(clientIP string, fp domain.ClientFingerprint)
+}
+
+// ClientStatsReporter serves client capability statistics, limited to the
+// busiest source networks.
+type ClientStatsReporter interface {
+	ClientStats(limit int) *domain.ClientStats
+}
+
+// QueryStatsReporter serves estimated query analytics, optionally for one zone and
+// limited to the busiest zones.
+type QueryStatsReporter interface {
+	QueryStats(zone string, limit int) *domain.QueryStats
+}
+
+// TransportPostureReporter reports the security controls of the encrypted DNS
+// transports served by this node.
+type TransportPostureReporter interface {
+	TransportPosture() []domain.TransportPosture
+}
+
+// QueryTracer answers a query as if it came from clientIP and reports every step
+// of the resolution, without touching the caches or the query statistics.
+type QueryTracer interface {
+	TraceQuery(ctx context.Context, name string, qType domain.RecordType, clientIP string, dnssec bool) (*domain.QueryTrace, error)
+}
+
+// DNSNotifier sends a NOTIFY for a zone to a secondary server and waits for it to
+// be acknowledged. A non-empty keyName signs the message with TSIG.
+type DNSNotifier interface {
+	Notify(ctx context.Context, server string, zone string, keyName string, secret []byte) error
+}
+
+// ZoneChangeListener is told about zones changed outside the DNS service, such
+// as by dynamic updates and incoming transfers.
+type ZoneChangeListener interface {
+	ZoneChanged(zoneID string)
+}
+
+// DataChangeSource delivers record and zone mutations as they are committed,
+// from any node or tool writing to the database.
+type DataChangeSource interface {
+	// ListenDataChanges calls fn for every change until ctx is done or the
+	// subscription fails.
+	ListenDataChanges(ctx context.Context, fn func(domain.DataChange)) error
+}
+
+// SecondaryManager manages the external secondaries of a tenant's zones.
+type SecondaryManager interface {
+	AddSecondary(ctx context.Context, zoneID string, tenantID string, provider *domain.SecondaryProvider) error
+	ListSecondaries(ctx context.Context, zoneID string, tenantID string) ([]domain.SecondaryProvider, error)
+	DeleteSecondary(ctx context.Context, zoneID string, tenantID string, id string) error
+}
+
+// ScheduledChangeManager manages record changes scheduled for a later time.
+type ScheduledChangeManager interface {
+	ScheduleChange(ctx context.Context, zoneID string, tenantID string, change *domain.ScheduledChange) error
+	ListScheduledChanges(ctx context.Context, zoneID string, tenantID string) ([]domain.ScheduledChange, error)
+	CancelScheduledChange(ctx context.Context, zoneID string, tenantID string, id string) error
+}
+
+// ChangeReviewManager manages zones under review and their change requests.
+type ChangeReviewManager interface {
+	SetZoneReview(ctx context.Context, zoneID string, tenantID string, required bool) error
+	GetZoneReview(ctx context.Context, zoneID string, tenantID string) (bool, error)
+	SubmitChange(ctx context.Context, zoneID string, tenantID string, change *domain.ChangeRequest) error
+	ListChangeRequests(ctx context.Context, zoneID string, tenantID string) ([]domain.ChangeRequest, error)
+	ApproveChange(ctx context.Context, zoneID string, tenantID string, id string, reviewer string, comment string) (*domain.ChangeRequest, error)
+	RejectChange(ctx context.Context, zoneID string, tenantID string, id string, reviewer string, comment string) (*domain.ChangeRequest, error)
+}
+
+// ShadowChangeManager stages record changes in shadow mode and commits them.
+type ShadowChangeManager interface {
+	StageShadowChange(ctx context.Context, zoneID string, tenantID string, change *domain.ShadowChange) error
+	GetShadowChange(ctx context.Context, zoneID string, tenantID string) (*domain.ShadowChange, error)
+	DiscardShadowChange(ctx context.Context, zoneID string, tenantID string) error
+	CommitShadowChange(ctx context.Context, zoneID string, tenantID string) (*domain.ShadowChange, error)
+}
+
+// ChangeFreezeManager manages a tenant's change freeze windows.
+type ChangeFreezeManager interface {
+	CreateChangeFreeze(ctx context.Context, tenantID string, freeze *domain.ChangeFreeze) error
+	ListChangeFreezes(ctx context.Context, tenantID string) ([]domain.ChangeFreeze, error)
+	DeleteChangeFreeze(ctx context.Context, tenantID string, id string) error
+}
+
+// ZoneTransferTrigger starts zone synchronization on demand instead of waiting
+// for a NOTIFY or a change.
+type ZoneTransferTrigger interface {
+	// TransferZone refreshes a secondary zone from its configured master.
+	TransferZone(ctx context.Context, zoneID string, tenantID string) (*domain.ZoneTransferResult, error)
+	// NotifyZone sends NOTIFY for a primary zone to the secondaries in its NS set.
+	NotifyZone(ctx context.Context, zoneID string, tenantID string) (*domain.NotifyResult, error)
+}
+
+// NodeMaintainer performs disruptive maintenance on the local DNS node.
+type NodeMaintainer interface {
+	// FlushCache drops every response cached by this node.
+	FlushCache(ctx context.Context) error
+	// Drain stops this node answering queries so traffic moves elsewhere.
+	Drain(ctx context.Context) error
+}
+
 // CacheInvalidator defines the interface for triggering cross-node cache invalidation.
 type CacheInvalidator interface {
 	Invalidate(ctx context.Context, name string, qType domain.RecordType) error
@@ -84,3 +528,28 @@ type VIPManager interface {
 	Bind(ctx context.Context, vip, iface string) error
 	Unbind(ctx context.Context, vip, iface string) error
 }
+
+// ObjectStore keeps opaque objects by key, such as in an S3-compatible bucket.
+type ObjectStore interface {
+	PutObject(ctx context.Context, key string, data []byte) error
+	// GetObject returns an error wrapping domain.ErrNotFound if key does not exist.
+	GetObject(ctx context.Context, key string) ([]byte, error)
+	// ListObjects returns the keys starting with prefix in lexical order.
+	ListObjects(ctx context.Context, prefix string) ([]string, error)
+}
+
+// RecoveryStore is the part of the repository disaster-recovery replication
+// exports from and a rebuild writes to.
+type RecoveryStore interface {
+	GetZoneByID(ctx context.Context, zoneID string, tenantID string) (*domain.Zone, error)
+	ListRecordsForZone(ctx context.Context, zoneID string, tenantID string) ([]domain.Record, error)
+	// ReplaceZone overwrites a zone and all of its records, or deletes the zone
+	// when zone is nil.
+	ReplaceZone(ctx context.Context, zoneID string, zone *domain.Zone, records []domain.Record) error
+	// ArchiveSnapshot returns a full configuration snapshot in the archive format
+	// of clouddnsctl backup.
+	ArchiveSnapshot(ctx context.Context) ([]byte, error)
+	// RestoreArchive loads an archive written by ArchiveSnapshot into an empty
+	// repository.
+	RestoreArchive(ctx context.Context, archive []byte) error
+}
@@ -14,11 +14,13 @@ type AnycastManager struct {
 	dnsSvc      ports.DNSService
 	routing     ports.RoutingEngine
 	vipManager  ports.VIPManager
+	readiness   ports.ReadinessChecker
 	vip         string
 	iface       string
 	logger      *slog.Logger
 	isAnnounced atomic.Bool
 	vipBound    atomic.Bool
+	stopped     chan struct{}
 }
 
 func NewAnycastManager(
@@ -39,11 +41,30 @@ func NewAnycastManager(
 		vip:        vip,
 		iface:      iface,
 		logger:     logger,
+		stopped:    make(chan struct{}),
 	}
 }
 
+// SetReadiness makes announcement decisions follow the node readiness report
+// instead of the plain backend health check.
+func (m *AnycastManager) SetReadiness(r ports.ReadinessChecker) {
+	m.readiness = r
+}
+
+// Stopped is closed once Start has returned after withdrawing the route on
+// shutdown, so the DNS server can be drained after traffic has moved away.
+func (m *AnycastManager) Stopped() <-chan struct{} {
+	return m.stopped
+}
+
+// IsAnnounced reports whether the anycast route is currently being announced.
+func (m *AnycastManager) IsAnnounced() bool {
+	return m.isAnnounced.Load()
+}
+
 func (m *AnycastManager) Start(ctx context.Context) {
 	m.logger.Info("starting anycast manager", "vip", m.vip, "iface", m.iface)
+	defer close(m.stopped)
 	
 	// Perform immediate check
 	m.TriggerCheck(ctx)
@@ -68,13 +89,25 @@ func (m *AnycastManager) Start(ctx context.Context) {
 
 // TriggerCheck performs an immediate health check and updates announcement state.
 func (m *AnycastManager) TriggerCheck(ctx context.Context) {
-	health := m.dnsSvc.HealthCheck(ctx)
-	
 	healthy := true
-	for backend, err := range health {
-		if err != nil {
-			m.logger.Warn("backend unhealthy", "backend", backend, "error", err)
-			healthy = false
+	if m.readiness != nil {
+		for _, c := range m.readiness.Readiness(ctx).Checks {
+			// The BGP session is ours to manage; withdrawing because of it would never help
+			if c.Name == ReadinessCheckBGP {
+				continue
+			}
+			if !c.Healthy {
+				m.logger.Warn("dependency not ready", "check", c.Name, "error", c.Error)
+				healthy = false
+			}
+		}
+	} else {
+		health := m.dnsSvc.HealthCheck(ctx)
+		for backend, err := range health {
+			if err != nil {
+				m.logger.Warn("backend unhealthy", "backend", backend, "error", err)
+				healthy = false
+			}
 		}
 	}
 
@@ -36,9 +36,39 @@ func (m *mockAnycastDNSService) ListZones(_ context.Context, _ string) ([]domain
 func (m *mockAnycastDNSService) ListRecordsForZone(_ context.Context, _, _ string) ([]domain.Record, error) {
 	return nil, nil
 }
+func (m *mockAnycastDNSService) GetZoneSummary(_ context.Context, _, _ string) (*domain.ZoneSummary, error) {
+	return nil, nil
+}
+func (m *mockAnycastDNSService) SetZoneDNSSEC(_ context.Context, _, _ string, _ bool) (*domain.Zone, error) {
+	return nil, nil
+}
+func (m *mockAnycastDNSService) SetZoneRole(_ context.Context, _, _, _, _ string) (*domain.Zone, error) {
+	return nil, nil
+}
+func (m *mockAnycastDNSService) CloneZone(_ context.Context, _, _ string, _ domain.ZoneCloneOptions) (*domain.Zone, error) {
+	return nil, nil
+}
+func (m *mockAnycastDNSService) GetRRset(_ context.Context, _, _, _ string, _ domain.RecordType) (*domain.RRset, error) {
+	return nil, nil
+}
+func (m *mockAnycastDNSService) ReplaceRRset(_ context.Context, _, _ string, _ *domain.RRset) (*domain.RRset, error) {
+	return nil, nil
+}
+func (m *mockAnycastDNSService) DiffZone(_ context.Context, _, _, _ string) (*domain.ZoneDiff, error) {
+	return nil, nil
+}
+func (m *mockAnycastDNSService) ListZoneChangeFeed(_ context.Context, _, _ string, _ uint32, _ int) (*domain.ZoneChangeFeed, error) {
+	return nil, nil
+}
 func (m *mockAnycastDNSService) DeleteZone(_ context.Context, _, _ string) error      { return nil }
 func (m *mockAnycastDNSService) DeleteRecord(_ context.Context, _, _, _ string) error { return nil }
-func (m *mockAnycastDNSService) ImportZone(_ context.Context, _ string, _ io.Reader) (*domain.Zone, error) {
+func (m *mockAnycastDNSService) SetRecordProtection(_ context.Context, _, _, _ string, _ bool) (*domain.Record, error) {
+	return nil, nil
+}
+func (m *mockAnycastDNSService) ImportZone(_ context.Context, _ string, _ io.Reader, _ map[string]string) (*domain.Zone, error) {
+	return nil, nil
+}
+func (m *mockAnycastDNSService) ImportRecords(_ context.Context, _, _, _ string, _ io.Reader, _ bool) (*domain.RecordImportResult, error) {
 	return nil, nil
 }
 func (m *mockAnycastDNSService) ListAuditLogs(_ context.Context, _ string) ([]domain.AuditLog, error) {
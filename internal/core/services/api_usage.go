@@ -0,0 +1,344 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+)
+
+const (
+	// DefaultAPISpikeFactor is how many times its baseline rate a key must reach
+	// in a minute to be reported as a spike.
+	DefaultAPISpikeFactor = 10
+	// DefaultAPIAuthFailureThreshold is how many rejected credentials from one
+	// client in a minute are reported as a burst.
+	DefaultAPIAuthFailureThreshold = 20
+
+	// apiUsageMinutes is the window usage is reported over and baselines are
+	// computed from.
+	apiUsageMinutes = 60
+	// apiSpikeMinRequests keeps keys that are barely used from tripping the spike
+	// detector with a handful of requests.
+	apiSpikeMinRequests = 30
+	// apiSpikeMinHistory is how long a key must have been seen before its
+	// baseline is trusted.
+	apiSpikeMinHistory = 10
+	// apiAlertCooldown spaces repeated alerts about the same key or client.
+	apiAlertCooldown = 15 * time.Minute
+	// maxAPIAnomalies bounds the anomalies kept for the API.
+	maxAPIAnomalies = 200
+)
+
+// APIUsageMonitor keeps per-minute request and error counts for every API key
+// that has been used within the last hour, and raises anomalies for sudden
+// request spikes and bursts of rejected credentials, posting them to an optional
+// webhook. State is per node and kept in memory.
+type APIUsageMonitor struct {
+	spikeFactor   float64
+	authThreshold uint64
+	webhook       string
+	client        *http.Client
+	logger        *slog.Logger
+	now           func() time.Time
+
+	mu        sync.Mutex
+	keys      map[string]*keyUsage
+	failures  map[string]*authFailures // By client IP
+	anomalies []domain.APIAnomaly
+}
+
+type keyUsage struct {
+	key       domain.APIKey
+	firstSeen int64 // Minute of the first request
+	lastSeen  time.Time
+	lastAlert time.Time
+	minutes   [apiUsageMinutes + 1]usageMinute
+}
+
+type usageMinute struct {
+	minute   int64
+	requests uint64
+	errors   uint64
+}
+
+type authFailures struct {
+	minute    int64
+	count     uint64
+	keyPrefix string
+	lastAlert time.Time
+}
+
+// NewAPIUsageMonitor creates a monitor with the default thresholds.
+func NewAPIUsageMonitor(logger *slog.Logger) *APIUsageMonitor {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &APIUsageMonitor{
+		spikeFactor:   DefaultAPISpikeFactor,
+		authThreshold: DefaultAPIAuthFailureThreshold,
+		client:        &http.Client{Timeout: 5 * time.Second},
+		logger:        logger,
+		now:           time.Now,
+		keys:          make(map[string]*keyUsage),
+		failures:      make(map[string]*authFailures),
+	}
+}
+
+// SetThresholds sets the spike factor and the auth failures per minute that
+// raise anomalies; non-positive values keep the current setting.
+func (m *APIUsageMonitor) SetThresholds(spikeFactor float64, authFailures int) {
+	if spikeFactor > 0 {
+		m.spikeFactor = spikeFactor
+	}
+	if authFailures > 0 {
+		m.authThreshold = uint64(authFailures)
+	}
+}
+
+// SetWebhook enables alerts: url receives every anomaly as a JSON POST.
+func (m *APIUsageMonitor) SetWebhook(url string) {
+	m.webhook = url
+}
+
+// RecordRequest counts a request authenticated with key and answered with status.
+func (m *APIUsageMonitor) RecordRequest(key domain.APIKey, status int) {
+	now := m.now()
+	minute := now.Unix() / 60
+
+	m.mu.Lock()
+	u := m.keys[key.ID]
+	if u == nil {
+		u = &keyUsage{key: key, firstSeen: minute}
+		m.keys[key.ID] = u
+	}
+	u.key = key
+	u.lastSeen = now
+	slot := u.slot(minute)
+	slot.requests++
+	if status >= 400 {
+		slot.errors++
+	}
+
+	var anomaly *domain.APIAnomaly
+	if slot.requests >= apiSpikeMinRequests && minute-u.firstSeen >= apiSpikeMinHistory && now.Sub(u.lastAlert) >= apiAlertCooldown {
+		baseline := u.baseline(minute)
+		if float64(slot.requests) >= m.spikeFactor*max(baseline, 1) {
+			u.lastAlert = now
+			anomaly = &domain.APIAnomaly{
+				Kind:       domain.APIAnomalyRequestSpike,
+				TenantID:   key.TenantID,
+				KeyID:      key.ID,
+				KeyPrefix:  key.KeyPrefix,
+				Count:      slot.requests,
+				Baseline:   baseline,
+				DetectedAt: now,
+			}
+			m.addAnomaly(*anomaly)
+		}
+	}
+	m.mu.Unlock()
+
+	if anomaly != nil {
+		m.alert(*anomaly)
+	}
+}
+
+// RecordAuthFailure counts a request from clientIP rejected for a missing,
+// unknown, inactive or expired key; keyPrefix is the start of the key presented.
+func (m *APIUsageMonitor) RecordAuthFailure(clientIP string, keyPrefix string) {
+	now := m.now()
+	minute := now.Unix() / 60
+
+	m.mu.Lock()
+	f := m.failures[clientIP]
+	if f == nil {
+		f = &authFailures{}
+		m.failures[clientIP] = f
+	}
+	if f.minute != minute {
+		f.minute, f.count = minute, 0
+	}
+	f.count++
+	if keyPrefix != "" {
+		f.keyPrefix = keyPrefix
+	}
+
+	var anomaly *domain.APIAnomaly
+	if f.count >= m.authThreshold && now.Sub(f.lastAlert) >= apiAlertCooldown {
+		f.lastAlert = now
+		anomaly = &domain.APIAnomaly{
+			Kind:       domain.APIAnomalyAuthFailures,
+			ClientIP:   clientIP,
+			KeyPrefix:  f.keyPrefix,
+			Count:      f.count,
+			DetectedAt: now,
+		}
+		m.addAnomaly(*anomaly)
+	}
+	m.mu.Unlock()
+
+	if anomaly != nil {
+		m.alert(*anomaly)
+	}
+}
+
+// APIKeyUsage returns the usage of the tenant's keys over the last hour, busiest
+// first.
+func (m *APIUsageMonitor) APIKeyUsage(tenantID string) []domain.APIKeyUsage {
+	minute := m.now().Unix() / 60
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	res := []domain.APIKeyUsage{}
+	for _, u := range m.keys {
+		if u.key.TenantID != tenantID {
+			continue
+		}
+		usage := domain.APIKeyUsage{
+			KeyID:             u.key.ID,
+			Name:              u.key.Name,
+			KeyPrefix:         u.key.KeyPrefix,
+			BaselinePerMinute: u.baseline(minute),
+			LastSeen:          u.lastSeen,
+		}
+		for _, slot := range u.minutes {
+			if slot.minute > minute-apiUsageMinutes && slot.minute <= minute {
+				usage.Requests += slot.requests
+				usage.Errors += slot.errors
+			}
+			if slot.minute == minute {
+				usage.RequestsLastMinute = slot.requests
+			}
+		}
+		if usage.Requests == 0 {
+			continue
+		}
+		usage.ErrorRate = float64(usage.Errors) / float64(usage.Requests)
+		res = append(res, usage)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Requests != res[j].Requests {
+			return res[i].Requests > res[j].Requests
+		}
+		return res[i].KeyID < res[j].KeyID
+	})
+	return res
+}
+
+// APIAnomalies returns the anomalies raised for the tenant's keys, newest first.
+// Auth failure bursts cannot be tied to a tenant and are only alerted.
+func (m *APIUsageMonitor) APIAnomalies(tenantID string) []domain.APIAnomaly {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	res := []domain.APIAnomaly{}
+	for i := len(m.anomalies) - 1; i >= 0; i-- {
+		if m.anomalies[i].TenantID == tenantID {
+			res = append(res, m.anomalies[i])
+		}
+	}
+	return res
+}
+
+// Start drops keys and clients idle for longer than the usage window every
+// interval until ctx is done.
+func (m *APIUsageMonitor) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.prune()
+		}
+	}
+}
+
+func (m *APIUsageMonitor) prune() {
+	minute := m.now().Unix() / 60
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, u := range m.keys {
+		if u.lastSeen.Unix()/60 <= minute-apiUsageMinutes {
+			delete(m.keys, id)
+		}
+	}
+	for ip, f := range m.failures {
+		if f.minute <= minute-apiUsageMinutes {
+			delete(m.failures, ip)
+		}
+	}
+}
+
+// slot returns the counters of minute, resetting a slot last used an hour ago.
+func (u *keyUsage) slot(minute int64) *usageMinute {
+	slot := &u.minutes[minute%int64(len(u.minutes))]
+	if slot.minute != minute {
+		*slot = usageMinute{minute: minute}
+	}
+	return slot
+}
+
+// baseline returns the average requests per minute before minute, over the
+// minutes of the window the key has been seen in.
+func (u *keyUsage) baseline(minute int64) float64 {
+	span := min(minute-u.firstSeen, apiUsageMinutes)
+	if span <= 0 {
+		return 0
+	}
+	var total uint64
+	for _, slot := range u.minutes {
+		if slot.minute >= minute-span && slot.minute < minute {
+			total += slot.requests
+		}
+	}
+	return float64(total) / float64(span)
+}
+
+// addAnomaly keeps a for the API. Callers hold m.mu.
+func (m *APIUsageMonitor) addAnomaly(a domain.APIAnomaly) {
+	m.anomalies = append(m.anomalies, a)
+	if len(m.anomalies) > maxAPIAnomalies {
+		m.anomalies = m.anomalies[len(m.anomalies)-maxAPIAnomalies:]
+	}
+	metrics.APIAnomalies.WithLabelValues(a.Kind).Inc()
+}
+
+func (m *APIUsageMonitor) alert(a domain.APIAnomaly) {
+	m.logger.Warn("API anomaly detected", "kind", a.Kind, "tenant", a.TenantID, "key", a.KeyID,
+		"key_prefix", a.KeyPrefix, "client", a.ClientIP, "count", a.Count, "baseline", a.Baseline)
+	if m.webhook == "" {
+		return
+	}
+
+	// Alerting must not hold up the request that tripped the detector
+	go func() {
+		body, err := json.Marshal(a)
+		if err != nil {
+			return
+		}
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, m.webhook, bytes.NewReader(body))
+		if err != nil {
+			m.logger.Error("invalid API anomaly webhook", "error", err)
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := m.client.Do(req)
+		if err != nil {
+			m.logger.Error("failed to send API anomaly alert", "error", err)
+			return
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			m.logger.Error("API anomaly webhook rejected alert", "status", resp.StatusCode)
+		}
+	}()
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+func TestAPIUsageMonitorSpike(t *testing.T) {
+	alerts := make(chan domain.APIAnomaly, 4)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var a domain.APIAnomaly
+		_ = json.NewDecoder(r.Body).Decode(&a)
+		alerts <- a
+	}))
+	defer hook.Close()
+
+	m := NewAPIUsageMonitor(nil)
+	m.SetWebhook(hook.URL)
+	now := time.Unix(1_700_000_000, 0).Truncate(time.Minute)
+	m.now = func() time.Time { return now }
+	key := domain.APIKey{ID: "k1", TenantID: "t1", Name: "ci", KeyPrefix: "cdns_abc"}
+
+	// Twenty minutes of 5 requests a minute, one of them failing
+	for i := 0; i < 20; i++ {
+		for j := 0; j < 5; j++ {
+			status := http.StatusOK
+			if j == 0 {
+				status = http.StatusBadRequest
+			}
+			m.RecordRequest(key, status)
+		}
+		now = now.Add(time.Minute)
+	}
+	if got := m.APIAnomalies("t1"); len(got) != 0 {
+		t.Fatalf("Expected no anomalies for steady traffic, got %+v", got)
+	}
+
+	usage := m.APIKeyUsage("t1")
+	if len(usage) != 1 || usage[0].BaselinePerMinute != 5 || usage[0].ErrorRate != 0.2 || usage[0].Name != "ci" {
+		t.Fatalf("Unexpected usage %+v", usage)
+	}
+	if len(m.APIKeyUsage("t2")) != 0 {
+		t.Error("Expected usage to be scoped to the tenant")
+	}
+
+	// A burst of ten times the baseline trips the detector once
+	for i := 0; i < 60; i++ {
+		m.RecordRequest(key, http.StatusOK)
+	}
+	anomalies := m.APIAnomalies("t1")
+	if len(anomalies) != 1 || anomalies[0].Kind != domain.APIAnomalyRequestSpike || anomalies[0].Count != 50 || anomalies[0].Baseline != 5 {
+		t.Fatalf("Expected one spike anomaly, got %+v", anomalies)
+	}
+	select {
+	case a := <-alerts:
+		if a.KeyID != "k1" || a.Kind != domain.APIAnomalyRequestSpike {
+			t.Errorf("Unexpected alert %+v", a)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the anomaly to be posted to the webhook")
+	}
+	if len(m.APIAnomalies("t2")) != 0 {
+		t.Error("Expected anomalies to be scoped to the tenant")
+	}
+}
+
+func TestAPIUsageMonitorNewKeyNoSpike(t *testing.T) {
+	m := NewAPIUsageMonitor(nil)
+	now := time.Unix(1_700_000_000, 0)
+	m.now = func() time.Time { return now }
+
+	// A key without history has no baseline to compare against
+	for i := 0; i < 500; i++ {
+		m.RecordRequest(domain.APIKey{ID: "k1", TenantID: "t1"}, http.StatusOK)
+	}
+	if got := m.APIAnomalies("t1"); len(got) != 0 {
+		t.Errorf("Expected no anomaly for a new key, got %+v", got)
+	}
+}
+
+func TestAPIUsageMonitorAuthFailures(t *testing.T) {
+	m := NewAPIUsageMonitor(nil)
+	m.SetThresholds(0, 5)
+	now := time.Unix(1_700_000_000, 0).Truncate(time.Minute)
+	m.now = func() time.Time { return now }
+
+	for i := 0; i < 4; i++ {
+		m.RecordAuthFailure("192.0.2.7", "cdns_lea")
+	}
+	now = now.Add(time.Minute)
+	for i := 0; i < 4; i++ {
+		m.RecordAuthFailure("192.0.2.7", "cdns_lea")
+	}
+	if len(m.anomalies) != 0 {
+		t.Fatalf("Expected failures spread over minutes not to alert, got %+v", m.anomalies)
+	}
+	for i := 0; i < 10; i++ {
+		m.RecordAuthFailure("192.0.2.7", "cdns_lea")
+	}
+	if len(m.anomalies) != 1 || m.anomalies[0].Kind != domain.APIAnomalyAuthFailures || m.anomalies[0].ClientIP != "192.0.2.7" || m.anomalies[0].KeyPrefix != "cdns_lea" {
+		t.Fatalf("Expected one auth failure anomaly, got %+v", m.anomalies)
+	}
+
+	now = now.Add(2 * time.Hour)
+	m.prune()
+	if len(m.failures) != 0 {
+		t.Error("Expected idle clients to be pruned")
+	}
+}
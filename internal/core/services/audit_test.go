@@ -78,6 +78,10 @@ func (m *auditMockRepo) UpdateRecordHealth(ctx context.Context, recordID string,
 	return m.mockRepo.UpdateRecordHealth(ctx, recordID, status, errMsg)
 }
 
+func (m *auditMockRepo) SetZoneDNSSEC(ctx context.Context, zoneID string, tenantID string, enabled bool) error {
+	return m.mockRepo.SetZoneDNSSEC(ctx, zoneID, tenantID, enabled)
+}
+
 func (m *auditMockRepo) DeleteRecord(ctx context.Context, recordID string, zoneID string, tenantID string) error {
 	return m.mockRepo.DeleteRecord(ctx, recordID, zoneID, tenantID)
 }
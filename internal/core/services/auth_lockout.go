@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+)
+
+const (
+	// DefaultAuthMaxFailures is how many invalid API keys in a row a client may
+	// present before it is banned.
+	DefaultAuthMaxFailures = 5
+	// DefaultAuthBanDuration is the length of a client's first ban.
+	DefaultAuthBanDuration = time.Minute
+	// DefaultAuthMaxBanDuration caps the doubling of repeated bans.
+	DefaultAuthMaxBanDuration = time.Hour
+
+	// authFailureWindow is how long failures are remembered between attempts.
+	authFailureWindow = 10 * time.Minute
+	// authStrikeReset is how long a client must stay out of trouble for its bans
+	// to start again from the shortest.
+	authStrikeReset = 24 * time.Hour
+)
+
+// AuthLockout bans clients that present too many invalid API keys in a row.
+// Every ban lasts twice as long as the client's previous one, up to a cap, so
+// that guessing keys becomes exponentially slower. State is per node and kept
+// in memory.
+type AuthLockout struct {
+	maxFailures int
+	banDuration time.Duration
+	maxBan      time.Duration
+	logger      *slog.Logger
+	now         func() time.Time
+
+	mu      sync.Mutex
+	clients map[string]*lockoutState
+}
+
+type lockoutState struct {
+	failures    int
+	strikes     int
+	lastFailure time.Time
+	bannedUntil time.Time
+}
+
+// NewAuthLockout creates a lockout with the default thresholds.
+func NewAuthLockout(logger *slog.Logger) *AuthLockout {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &AuthLockout{
+		maxFailures: DefaultAuthMaxFailures,
+		banDuration: DefaultAuthBanDuration,
+		maxBan:      DefaultAuthMaxBanDuration,
+		logger:      logger,
+		now:         time.Now,
+		clients:     make(map[string]*lockoutState),
+	}
+}
+
+// SetThresholds sets the failures that trigger a ban, the first ban's length and
+// the longest ban; non-positive values keep the current setting.
+func (l *AuthLockout) SetThresholds(maxFailures int, ban, maxBan time.Duration) {
+	if maxFailures > 0 {
+		l.maxFailures = maxFailures
+	}
+	if ban > 0 {
+		l.banDuration = ban
+	}
+	if maxBan > 0 {
+		l.maxBan = maxBan
+	}
+	if l.maxBan < l.banDuration {
+		l.maxBan = l.banDuration
+	}
+}
+
+// Banned reports whether clientIP is banned and for how much longer.
+func (l *AuthLockout) Banned(clientIP string) (time.Duration, bool) {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	c := l.clients[clientIP]
+	if c == nil || !now.Before(c.bannedUntil) {
+		return 0, false
+	}
+	return c.bannedUntil.Sub(now), true
+}
+
+// RecordFailure counts an invalid API key presented by clientIP, banning it once
+// it reaches the limit.
+func (l *AuthLockout) RecordFailure(clientIP string) {
+	now := l.now()
+	l.mu.Lock()
+	c := l.clients[clientIP]
+	if c == nil {
+		c = &lockoutState{}
+		l.clients[clientIP] = c
+	}
+	if now.Sub(c.lastFailure) > authFailureWindow {
+		c.failures = 0
+	}
+	if now.Sub(c.lastFailure) > authStrikeReset {
+		c.strikes = 0
+	}
+	c.lastFailure = now
+	c.failures++
+	if c.failures < l.maxFailures {
+		l.mu.Unlock()
+		return
+	}
+
+	ban := l.banDuration << c.strikes
+	if ban > l.maxBan || ban <= 0 {
+		ban = l.maxBan
+	} else {
+		c.strikes++
+	}
+	c.failures = 0
+	c.bannedUntil = now.Add(ban)
+	l.mu.Unlock()
+
+	metrics.APIAuthBans.Inc()
+	l.logger.Warn("banned API client after repeated authentication failures",
+		"client_ip", clientIP, "duration", ban)
+}
+
+// RecordSuccess forgets the failures of clientIP once it authenticates. Its
+// strikes are kept, so a client alternating guesses with a valid key is still
+// banned for longer each time.
+func (l *AuthLockout) RecordSuccess(clientIP string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if c := l.clients[clientIP]; c != nil {
+		c.failures = 0
+	}
+}
+
+// ListBans returns the clients currently banned, those banned last first.
+func (l *AuthLockout) ListBans() []domain.AuthBan {
+	now := l.now()
+	l.mu.Lock()
+	bans := []domain.AuthBan{}
+	for ip, c := range l.clients {
+		if now.Before(c.bannedUntil) {
+			bans = append(bans, domain.AuthBan{
+				ClientIP:    ip,
+				Failures:    c.failures,
+				Strikes:     c.strikes,
+				BannedUntil: c.bannedUntil,
+			})
+		}
+	}
+	l.mu.Unlock()
+	sort.Slice(bans, func(i, j int) bool { return bans[i].BannedUntil.After(bans[j].BannedUntil) })
+	return bans
+}
+
+// ClearBan lifts the ban of clientIP and forgets its history, reporting whether
+// it was banned.
+func (l *AuthLockout) ClearBan(clientIP string) bool {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	c := l.clients[clientIP]
+	if c == nil {
+		return false
+	}
+	delete(l.clients, clientIP)
+	return now.Before(c.bannedUntil)
+}
+
+// Start forgets clients that have stayed out of trouble every interval until
+// ctx is cancelled.
+func (l *AuthLockout) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			l.prune()
+		}
+	}
+}
+
+func (l *AuthLockout) prune() {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for ip, c := range l.clients {
+		if now.Before(c.bannedUntil) {
+			continue
+		}
+		if now.Sub(c.lastFailure) > authStrikeReset {
+			delete(l.clients, ip)
+		}
+	}
+}
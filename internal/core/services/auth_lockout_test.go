@@ -0,0 +1,93 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAuthLockoutBackoff(t *testing.T) {
+	l := NewAuthLockout(nil)
+	l.SetThresholds(3, time.Minute, 3*time.Minute)
+	now := time.Unix(1_700_000_000, 0)
+	l.now = func() time.Time { return now }
+	fail := func(n int) {
+		for i := 0; i < n; i++ {
+			l.RecordFailure("192.0.2.1")
+		}
+	}
+
+	fail(2)
+	if _, banned := l.Banned("192.0.2.1"); banned {
+		t.Fatal("Expected no ban below the failure limit")
+	}
+	fail(1)
+	if wait, banned := l.Banned("192.0.2.1"); !banned || wait != time.Minute {
+		t.Fatalf("Expected a 1m ban, got %v %v", wait, banned)
+	}
+	if _, banned := l.Banned("192.0.2.2"); banned {
+		t.Error("Expected other clients to be unaffected")
+	}
+
+	// Each further ban doubles, up to the cap
+	for _, want := range []time.Duration{2 * time.Minute, 3 * time.Minute, 3 * time.Minute} {
+		now = now.Add(5 * time.Minute)
+		fail(3)
+		if wait, banned := l.Banned("192.0.2.1"); !banned || wait != want {
+			t.Fatalf("Expected a %v ban, got %v %v", want, wait, banned)
+		}
+	}
+
+	bans := l.ListBans()
+	if len(bans) != 1 || bans[0].ClientIP != "192.0.2.1" || bans[0].Strikes != 2 || !bans[0].BannedUntil.Equal(now.Add(3*time.Minute)) {
+		t.Fatalf("Unexpected bans %+v", bans)
+	}
+	if !l.ClearBan("192.0.2.1") {
+		t.Fatal("Expected the ban to be cleared")
+	}
+	if _, banned := l.Banned("192.0.2.1"); banned || len(l.ListBans()) != 0 {
+		t.Error("Expected no bans after clearing")
+	}
+	if l.ClearBan("192.0.2.1") {
+		t.Error("Expected clearing an unbanned client to report false")
+	}
+
+	// A clean history starts from the shortest ban again
+	fail(3)
+	if wait, _ := l.Banned("192.0.2.1"); wait != time.Minute {
+		t.Errorf("Expected a 1m ban after clearing, got %v", wait)
+	}
+}
+
+func TestAuthLockoutForgets(t *testing.T) {
+	l := NewAuthLockout(nil)
+	l.SetThresholds(3, time.Minute, time.Hour)
+	now := time.Unix(1_700_000_000, 0)
+	l.now = func() time.Time { return now }
+
+	// A successful authentication resets the failure count
+	l.RecordFailure("192.0.2.1")
+	l.RecordFailure("192.0.2.1")
+	l.RecordSuccess("192.0.2.1")
+	l.RecordFailure("192.0.2.1")
+	l.RecordFailure("192.0.2.1")
+	if _, banned := l.Banned("192.0.2.1"); banned {
+		t.Fatal("Expected a success to reset the failure count")
+	}
+
+	// So does a quiet spell
+	now = now.Add(authFailureWindow + time.Second)
+	l.RecordFailure("192.0.2.1")
+	if _, banned := l.Banned("192.0.2.1"); banned {
+		t.Fatal("Expected old failures to be forgotten")
+	}
+
+	l.prune()
+	if len(l.clients) != 1 {
+		t.Fatal("Expected a recent client to be kept")
+	}
+	now = now.Add(authStrikeReset + time.Second)
+	l.prune()
+	if len(l.clients) != 0 {
+		t.Error("Expected a quiet client to be pruned")
+	}
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// DefaultAuthorizerTimeout bounds each call to an external authorizer.
+const DefaultAuthorizerTimeout = 2 * time.Second
+
+// maxAuthorizerResponse bounds the decision read from an authorizer.
+const maxAuthorizerResponse = 64 << 10
+
+// HTTPAuthorizer puts API requests to an external policy engine over HTTP.
+// A webhook receives the request as JSON and answers {"allowed": bool,
+// "reason": "..."}. An Open Policy Agent decision URL, such as
+// http://opa:8181/v1/data/clouddns/authz, receives it as {"input": ...} and
+// answers with a boolean result or a result object with allowed (or allow)
+// and reason.
+type HTTPAuthorizer struct {
+	url    string
+	opa    bool
+	client *http.Client
+}
+
+// NewHTTPAuthorizer returns an authorizer calling url, in OPA's data API format
+// when opa is set, with timeout for each call.
+func NewHTTPAuthorizer(url string, opa bool, timeout time.Duration) *HTTPAuthorizer {
+	if timeout <= 0 {
+		timeout = DefaultAuthorizerTimeout
+	}
+	return &HTTPAuthorizer{url: url, opa: opa, client: &http.Client{Timeout: timeout}}
+}
+
+// Authorize asks the policy engine for a decision on req. Anything but a 200
+// response with a decision is an error.
+func (a *HTTPAuthorizer) Authorize(ctx context.Context, req domain.AuthorizationRequest) (domain.AuthorizationDecision, error) {
+	var payload any = req
+	if a.opa {
+		payload = map[string]any{"input": req}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return domain.AuthorizationDecision{}, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, a.url, bytes.NewReader(body))
+	if err != nil {
+		return domain.AuthorizationDecision{}, fmt.Errorf("invalid authorizer URL: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := a.client.Do(httpReq)
+	if err != nil {
+		return domain.AuthorizationDecision{}, fmt.Errorf("authorizer unreachable: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return domain.AuthorizationDecision{}, fmt.Errorf("authorizer answered %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAuthorizerResponse))
+	if err != nil {
+		return domain.AuthorizationDecision{}, fmt.Errorf("failed to read authorizer decision: %w", err)
+	}
+	if a.opa {
+		return opaDecision(data)
+	}
+	var decision struct {
+		Allowed *bool  `json:"allowed"`
+		Reason  string `json:"reason"`
+	}
+	if err := json.Unmarshal(data, &decision); err != nil || decision.Allowed == nil {
+		return domain.AuthorizationDecision{}, fmt.Errorf("authorizer returned no decision")
+	}
+	return domain.AuthorizationDecision{Allowed: *decision.Allowed, Reason: decision.Reason}, nil
+}
+
+// opaDecision reads an OPA data API response. An undefined result, which OPA
+// returns when no rule matched, is an error rather than a denial so that a
+// mistyped policy path does not pass for a policy.
+func opaDecision(data []byte) (domain.AuthorizationDecision, error) {
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil || len(resp.Result) == 0 {
+		return domain.AuthorizationDecision{}, fmt.Errorf("OPA returned no result")
+	}
+	var allowed bool
+	if err := json.Unmarshal(resp.Result, &allowed); err == nil {
+		return domain.AuthorizationDecision{Allowed: allowed}, nil
+	}
+	var result struct {
+		Allowed *bool  `json:"allowed"`
+		Allow   *bool  `json:"allow"`
+		Reason  string `json:"reason"`
+	}
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		return domain.AuthorizationDecision{}, fmt.Errorf("OPA result is neither a boolean nor an object")
+	}
+	switch {
+	case result.Allowed != nil:
+		return domain.AuthorizationDecision{Allowed: *result.Allowed, Reason: result.Reason}, nil
+	case result.Allow != nil:
+		return domain.AuthorizationDecision{Allowed: *result.Allow, Reason: result.Reason}, nil
+	}
+	return domain.AuthorizationDecision{}, fmt.Errorf("OPA result has no allowed field")
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+func TestHTTPAuthorizerWebhook(t *testing.T) {
+	var got domain.AuthorizationRequest
+	answer := `{"allowed": false, "reason": "outside the change window"}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = io.WriteString(w, answer)
+	}))
+	defer srv.Close()
+	a := NewHTTPAuthorizer(srv.URL, false, 0)
+
+	req := domain.AuthorizationRequest{TenantID: "t1", Action: "DELETE /zones/{id}", Params: map[string]string{"id": "z1"}}
+	decision, err := a.Authorize(context.Background(), req)
+	if err != nil || decision.Allowed || decision.Reason != "outside the change window" {
+		t.Fatalf("Expected a denial with its reason, got %+v, %v", decision, err)
+	}
+	if got.TenantID != "t1" || got.Params["id"] != "z1" {
+		t.Errorf("Expected the request to be sent as JSON, got %+v", got)
+	}
+
+	answer = `{"reason": "no verdict"}`
+	if _, err := a.Authorize(context.Background(), req); err == nil {
+		t.Error("Expected an answer without a decision to be an error")
+	}
+}
+
+func TestHTTPAuthorizerOPA(t *testing.T) {
+	var input map[string]json.RawMessage
+	answer := `{"result": true}`
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&input)
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, answer)
+	}))
+	defer srv.Close()
+	a := NewHTTPAuthorizer(srv.URL, true, 0)
+	ctx := context.Background()
+
+	if decision, err := a.Authorize(ctx, domain.AuthorizationRequest{TenantID: "t1"}); err != nil || !decision.Allowed {
+		t.Fatalf("Expected a boolean result to allow, got %+v, %v", decision, err)
+	}
+	if _, ok := input["input"]; !ok {
+		t.Errorf("Expected the request under input, got %v", input)
+	}
+
+	answer = `{"result": {"allow": false, "reason": "zone is locked"}}`
+	if decision, err := a.Authorize(ctx, domain.AuthorizationRequest{}); err != nil || decision.Allowed || decision.Reason != "zone is locked" {
+		t.Errorf("Expected a result object to deny, got %+v, %v", decision, err)
+	}
+
+	for _, bad := range []string{`{}`, `{"result": "yes"}`, `{"result": {"reason": "x"}}`} {
+		answer = bad
+		if _, err := a.Authorize(ctx, domain.AuthorizationRequest{}); err == nil {
+			t.Errorf("Expected %s to be an error", bad)
+		}
+	}
+	answer, status = `{"result": true}`, http.StatusInternalServerError
+	if _, err := a.Authorize(ctx, domain.AuthorizationRequest{}); err == nil {
+		t.Error("Expected an error status to be an error")
+	}
+}
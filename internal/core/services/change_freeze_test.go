@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+func TestChangeFreeze(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepo{
+		zones: []domain.Zone{
+			{ID: "z1", TenantID: "t1", Name: "frozen.test."},
+			{ID: "z2", TenantID: "t1", Name: "open.test."},
+		},
+	}
+	freezes := NewFreezeService(repo, nil)
+	svc := NewDNSService(repo, nil)
+	now := time.Now()
+
+	if err := freezes.CreateChangeFreeze(ctx, "t1", &domain.ChangeFreeze{EndsAt: now.Add(-time.Minute)}); !errors.Is(err, domain.ErrInvalidChangeFreeze) {
+		t.Errorf("Expected a freeze that already ended to be rejected, got %v", err)
+	}
+	if err := freezes.CreateChangeFreeze(ctx, "t2", &domain.ChangeFreeze{ZoneID: "z1", EndsAt: now.Add(time.Hour)}); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected another tenant's zone to be not found, got %v", err)
+	}
+
+	zoneFreeze := &domain.ChangeFreeze{ZoneID: "z1", Reason: "launch", EndsAt: now.Add(time.Hour)}
+	if err := freezes.CreateChangeFreeze(ctx, "t1", zoneFreeze); err != nil {
+		t.Fatalf("CreateChangeFreeze failed: %v", err)
+	}
+	if zoneFreeze.ID == "" || zoneFreeze.StartsAt.IsZero() {
+		t.Errorf("Expected the freeze to start now, got %+v", zoneFreeze)
+	}
+
+	err := svc.CreateRecord(ctx, &domain.Record{ZoneID: "z1", TenantID: "t1", Name: "www.frozen.test.", Type: domain.TypeA, Content: "192.0.2.1"})
+	if !errors.Is(err, domain.ErrChangeFrozen) {
+		t.Fatalf("Expected the frozen zone to reject the record, got %v", err)
+	}
+	if err := svc.CreateRecord(ctx, &domain.Record{ZoneID: "z2", TenantID: "t1", Name: "www.open.test.", Type: domain.TypeA, Content: "192.0.2.1"}); err != nil {
+		t.Errorf("Expected other zones to stay writable, got %v", err)
+	}
+	if err := svc.CreateZone(ctx, &domain.Zone{TenantID: "t1", Name: "new.test."}); err != nil {
+		t.Errorf("Expected a zone freeze not to block new zones, got %v", err)
+	}
+	if err := svc.CreateRecord(domain.WithBreakGlass(ctx), &domain.Record{ZoneID: "z1", TenantID: "t1", Name: "fix.frozen.test.", Type: domain.TypeA, Content: "192.0.2.2"}); err != nil {
+		t.Errorf("Expected a break-glass change to pass, got %v", err)
+	}
+
+	audited := map[string]bool{}
+	for _, a := range repo.audits {
+		audited[a.Action] = true
+	}
+	for _, action := range []string{"CREATE_CHANGE_FREEZE", "CHANGE_FROZEN", "BREAK_GLASS_CHANGE"} {
+		if !audited[action] {
+			t.Errorf("Expected a %s audit entry", action)
+		}
+	}
+
+	tenantFreeze := &domain.ChangeFreeze{EndsAt: now.Add(time.Hour)}
+	if err := freezes.CreateChangeFreeze(ctx, "t1", tenantFreeze); err != nil {
+		t.Fatalf("CreateChangeFreeze failed: %v", err)
+	}
+	if err := svc.DeleteZone(ctx, "z2", "t1"); !errors.Is(err, domain.ErrChangeFrozen) {
+		t.Errorf("Expected a tenant-wide freeze to cover every zone, got %v", err)
+	}
+	if err := svc.CreateZone(ctx, &domain.Zone{TenantID: "t1", Name: "later.test."}); !errors.Is(err, domain.ErrChangeFrozen) {
+		t.Errorf("Expected a tenant-wide freeze to block new zones, got %v", err)
+	}
+
+	list, err := freezes.ListChangeFreezes(ctx, "t1")
+	if err != nil || len(list) != 2 {
+		t.Fatalf("Expected 2 freezes, got %d (%v)", len(list), err)
+	}
+	if err := freezes.DeleteChangeFreeze(ctx, "t2", tenantFreeze.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected another tenant's freeze to be not found, got %v", err)
+	}
+	for _, f := range list {
+		if err := freezes.DeleteChangeFreeze(ctx, "t1", f.ID); err != nil {
+			t.Fatalf("DeleteChangeFreeze failed: %v", err)
+		}
+	}
+	if err := svc.DeleteZone(ctx, "z2", "t1"); err != nil {
+		t.Errorf("Expected changes to resume once the freezes are lifted, got %v", err)
+	}
+}
@@ -0,0 +1,311 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/core/ports"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+)
+
+// ReviewService enforces the two-person rule on zones under review: record
+// changes are held as change requests until an API key with the approver role,
+// other than the one that asked for them, approves them. Approved changes are
+// applied through the DNS service, so they are audited, published and
+// invalidated like any change made through the API.
+type ReviewService struct {
+	repo   ports.DNSRepository
+	dns    ports.DNSService
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+// NewReviewService creates a service that applies approved changes with dns.
+func NewReviewService(repo ports.DNSRepository, dns ports.DNSService, logger *slog.Logger) *ReviewService {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &ReviewService{repo: repo, dns: dns, logger: logger, now: time.Now}
+}
+
+// SetZoneReview puts a tenant's zone under review, or takes it out of review.
+// Pending change requests stay pending either way.
+func (s *ReviewService) SetZoneReview(ctx context.Context, zoneID string, tenantID string, required bool) error {
+	zone, err := s.loadZone(ctx, zoneID, tenantID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.SetZoneReview(ctx, zone.ID, tenantID, required); err != nil {
+		return err
+	}
+	action := "ENABLE_CHANGE_REVIEW"
+	if !required {
+		action = "DISABLE_CHANGE_REVIEW"
+	}
+	auditReview(ctx, s.repo, tenantID, action, "ZONE", zone.ID, zone.Name)
+	return nil
+}
+
+// GetZoneReview reports whether a tenant's zone is under review.
+func (s *ReviewService) GetZoneReview(ctx context.Context, zoneID string, tenantID string) (bool, error) {
+	zone, err := s.loadZone(ctx, zoneID, tenantID)
+	if err != nil {
+		return false, err
+	}
+	return s.repo.IsZoneReviewed(ctx, zone.ID)
+}
+
+// SubmitChange validates a change to a tenant's zone and stores it as a pending
+// change request. CREATE_RECORD needs a record with a name, type and content,
+// DELETE_RECORD the ID of an existing record and REPLACE_RRSET an RRset with a
+// name and type. The caller sets RequestedBy.
+func (s *ReviewService) SubmitChange(ctx context.Context, zoneID string, tenantID string, c *domain.ChangeRequest) error {
+	zone, err := s.loadZone(ctx, zoneID, tenantID)
+	if err != nil {
+		return err
+	}
+
+	c.Action = strings.ToUpper(strings.TrimSpace(c.Action))
+	switch c.Action {
+	case domain.ChangeCreateRecord:
+		if c.Record == nil || c.Record.Name == "" || c.Record.Type == "" || c.Record.Content == "" {
+			return fmt.Errorf("%s needs a record with a name, type and content: %w", c.Action, domain.ErrInvalidChangeRequest)
+		}
+		c.Record.Name = rrsetOwner(zone.Name, c.Record.Name)
+		c.Record.Type = domain.RecordType(strings.ToUpper(string(c.Record.Type)))
+		c.Record.ID = ""
+		c.RecordID, c.RRset = "", nil
+	case domain.ChangeDeleteRecord:
+		if c.RecordID == "" {
+			return fmt.Errorf("%s needs a record_id: %w", c.Action, domain.ErrInvalidChangeRequest)
+		}
+		rec, err := s.repo.GetRecord(ctx, c.RecordID, zone.ID, tenantID)
+		if err != nil {
+			return fmt.Errorf("failed to load record: %w", err)
+		}
+		if rec == nil {
+			return fmt.Errorf("record %s: %w", c.RecordID, domain.ErrNotFound)
+		}
+		c.Record, c.RRset = nil, nil
+	case domain.ChangeReplaceRRset:
+		if c.RRset == nil || c.RRset.Name == "" || c.RRset.Type == "" {
+			return fmt.Errorf("%s needs an rrset with a name and type: %w", c.Action, domain.ErrInvalidChangeRequest)
+		}
+		c.RRset.ZoneID = zone.ID
+		c.RRset.Name = rrsetOwner(zone.Name, c.RRset.Name)
+		c.RRset.Type = domain.RecordType(strings.ToUpper(string(c.RRset.Type)))
+		c.RecordID, c.Record = "", nil
+	default:
+		return fmt.Errorf("action %q: %w", c.Action, domain.ErrInvalidChangeRequest)
+	}
+
+	c.ID = uuid.New().String()
+	c.ZoneID = zone.ID
+	c.TenantID = tenantID
+	c.Status = domain.ChangePending
+	c.ReviewedBy, c.Comment, c.Error = "", "", ""
+	c.CreatedAt = s.now()
+	c.ReviewedAt = nil
+	if err := s.repo.CreateChangeRequest(ctx, c); err != nil {
+		return err
+	}
+	metrics.ChangeRequests.WithLabelValues(domain.ChangePending).Inc()
+	auditReview(ctx, s.repo, tenantID, "SUBMIT_CHANGE_REQUEST", "CHANGE_REQUEST", c.ID,
+		fmt.Sprintf("%s in %s %s by %s", c.Action, zone.Name, changeSubject(c), c.RequestedBy))
+	return nil
+}
+
+// ListChangeRequests returns every change request of a tenant's zone, newest
+// first, including those already decided.
+func (s *ReviewService) ListChangeRequests(ctx context.Context, zoneID string, tenantID string) ([]domain.ChangeRequest, error) {
+	zone, err := s.loadZone(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := s.repo.ListChangeRequests(ctx, zone.ID)
+	if err != nil {
+		return nil, err
+	}
+	if changes == nil {
+		changes = []domain.ChangeRequest{}
+	}
+	return changes, nil
+}
+
+// ApproveChange approves a pending change request on behalf of reviewer and
+// applies it. A change that fails to apply, for example because a change
+// freeze began since it was submitted, is returned with the failed status and
+// its error rather than as an error.
+func (s *ReviewService) ApproveChange(ctx context.Context, zoneID string, tenantID string, id string, reviewer string, comment string) (*domain.ChangeRequest, error) {
+	c, err := s.review(ctx, zoneID, tenantID, id, reviewer, comment, domain.ChangeApproved)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Status = domain.ChangeApplied
+	if errApply := s.apply(ctx, c); errApply != nil {
+		c.Status = domain.ChangeFailed
+		c.Error = errApply.Error()
+		s.logger.Warn("approved change failed", "id", c.ID, "zone_id", c.ZoneID, "action", c.Action, "error", errApply)
+	}
+	metrics.ChangeRequests.WithLabelValues(c.Status).Inc()
+	if err := s.repo.FinishChangeRequest(ctx, c); err != nil {
+		return nil, fmt.Errorf("failed to record change request outcome: %w", err)
+	}
+	details := fmt.Sprintf("%s %s requested by %s, approved by %s", c.Action, changeSubject(c), c.RequestedBy, reviewer)
+	if c.Error != "" {
+		details += ": " + c.Error
+	}
+	auditReview(ctx, s.repo, tenantID, "APPROVE_CHANGE_REQUEST", "CHANGE_REQUEST", c.ID, details)
+	return c, nil
+}
+
+// RejectChange rejects a pending change request on behalf of reviewer.
+func (s *ReviewService) RejectChange(ctx context.Context, zoneID string, tenantID string, id string, reviewer string, comment string) (*domain.ChangeRequest, error) {
+	c, err := s.review(ctx, zoneID, tenantID, id, reviewer, comment, domain.ChangeRejected)
+	if err != nil {
+		return nil, err
+	}
+	metrics.ChangeRequests.WithLabelValues(domain.ChangeRejected).Inc()
+	auditReview(ctx, s.repo, tenantID, "REJECT_CHANGE_REQUEST", "CHANGE_REQUEST", c.ID,
+		fmt.Sprintf("%s %s requested by %s, rejected by %s: %s", c.Action, changeSubject(c), c.RequestedBy, reviewer, comment))
+	return c, nil
+}
+
+// review records reviewer's decision on a pending change request. The
+// requester cannot review its own change, and a request decided by another
+// reviewer in the meantime is no longer pending.
+func (s *ReviewService) review(ctx context.Context, zoneID, tenantID, id, reviewer, comment, status string) (*domain.ChangeRequest, error) {
+	zone, err := s.loadZone(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	c, err := s.repo.GetChangeRequest(ctx, id, zone.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load change request: %w", err)
+	}
+	if c == nil {
+		return nil, fmt.Errorf("change request %s: %w", id, domain.ErrNotFound)
+	}
+	if reviewer == "" || reviewer == c.RequestedBy {
+		auditReview(ctx, s.repo, tenantID, "SELF_REVIEW_REFUSED", "CHANGE_REQUEST", c.ID, fmt.Sprintf("%s tried to review its own change", c.RequestedBy))
+		return nil, domain.ErrSelfReview
+	}
+	if c.Status != domain.ChangePending {
+		return nil, fmt.Errorf("change request %s is %s: %w", id, c.Status, domain.ErrInvalidChangeRequest)
+	}
+
+	reviewed := s.now()
+	c.Status = status
+	c.ReviewedBy = reviewer
+	c.Comment = comment
+	c.ReviewedAt = &reviewed
+	ok, err := s.repo.ReviewChangeRequest(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("change request %s is no longer pending: %w", id, domain.ErrInvalidChangeRequest)
+	}
+	return c, nil
+}
+
+// apply performs an approved change past the zone's review.
+func (s *ReviewService) apply(ctx context.Context, c *domain.ChangeRequest) error {
+	ctx = domain.WithApprovedChange(ctx)
+	if c.Force {
+		ctx = domain.WithForce(ctx)
+	}
+	switch c.Action {
+	case domain.ChangeCreateRecord:
+		if c.Record == nil {
+			return fmt.Errorf("%s has no record", c.Action)
+		}
+		rec := *c.Record
+		rec.ZoneID = c.ZoneID
+		rec.TenantID = c.TenantID
+		if err := s.dns.CreateRecord(ctx, &rec); err != nil {
+			return fmt.Errorf("failed to create record: %w", err)
+		}
+		c.RecordID = rec.ID
+	case domain.ChangeDeleteRecord:
+		if err := s.dns.DeleteRecord(ctx, c.RecordID, c.ZoneID, c.TenantID); err != nil {
+			return fmt.Errorf("failed to delete record %s: %w", c.RecordID, err)
+		}
+	case domain.ChangeReplaceRRset:
+		if c.RRset == nil {
+			return fmt.Errorf("%s has no rrset", c.Action)
+		}
+		rrset := *c.RRset
+		if _, err := s.dns.ReplaceRRset(ctx, c.ZoneID, c.TenantID, &rrset); err != nil {
+			return fmt.Errorf("failed to replace rrset: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown action %q", c.Action)
+	}
+	return nil
+}
+
+func (s *ReviewService) loadZone(ctx context.Context, zoneID string, tenantID string) (*domain.Zone, error) {
+	zone, err := s.repo.GetZoneByID(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load zone: %w", err)
+	}
+	if zone == nil {
+		return nil, fmt.Errorf("zone %s: %w", zoneID, domain.ErrNotFound)
+	}
+	return zone, nil
+}
+
+// changeSubject names what a change request touches, for audit entries.
+func changeSubject(c *domain.ChangeRequest) string {
+	switch {
+	case c.Record != nil:
+		return fmt.Sprintf("%s %s", c.Record.Name, c.Record.Type)
+	case c.RRset != nil:
+		return fmt.Sprintf("%s %s", c.RRset.Name, c.RRset.Type)
+	}
+	return "record " + c.RecordID
+}
+
+// CheckChangeReview returns domain.ErrReviewRequired if the zone is under review
+// and ctx is not applying an approved change request. It guards every change to
+// a zone's answers: its records, its zone-level settings, its default records,
+// the targets its records reference and shadow changes served to canaries. Only
+// record changes can be submitted as change requests; the others need the review
+// lifted first. Break-glass keys pass the review, and every such change is
+// audited.
+func CheckChangeReview(ctx context.Context, repo ports.DNSRepository, tenantID string, zoneID string, action string) error {
+	if zoneID == "" || domain.IsApprovedChange(ctx) {
+		return nil
+	}
+	reviewed, err := repo.IsZoneReviewed(ctx, zoneID)
+	if err != nil {
+		return fmt.Errorf("failed to check change review: %w", err)
+	}
+	if !reviewed {
+		return nil
+	}
+	if domain.IsBreakGlass(ctx) {
+		auditReview(ctx, repo, tenantID, "BREAK_GLASS_CHANGE", "ZONE", zoneID, action+" made without review")
+		return nil
+	}
+	return fmt.Errorf("%w: %s", domain.ErrReviewRequired, action)
+}
+
+func auditReview(ctx context.Context, repo ports.DNSRepository, tenantID, action, resourceType, id, details string) {
+	_ = repo.SaveAuditLog(ctx, &domain.AuditLog{
+		ID:           uuid.New().String(),
+		TenantID:     tenantID,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   id,
+		Details:      details,
+		CreatedAt:    time.Now(),
+	})
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+func TestChangeReview(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepo{
+		zones:   []domain.Zone{{ID: "z1", TenantID: "t1", Name: "review.test."}},
+		records: []domain.Record{{ID: "r1", ZoneID: "z1", TenantID: "t1", Name: "www.review.test.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 300}},
+	}
+	dns := NewDNSService(repo, nil)
+	reviews := NewReviewService(repo, dns, nil)
+
+	if err := reviews.SetZoneReview(ctx, "z1", "t2", true); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected another tenant's zone to be not found, got %v", err)
+	}
+	if err := reviews.SetZoneReview(ctx, "z1", "t1", true); err != nil {
+		t.Fatalf("SetZoneReview failed: %v", err)
+	}
+	if on, err := reviews.GetZoneReview(ctx, "z1", "t1"); err != nil || !on {
+		t.Fatalf("Expected the zone to be under review, got %v, %v", on, err)
+	}
+
+	// Direct changes are refused; break-glass keys and approved changes pass
+	rec := &domain.Record{ZoneID: "z1", TenantID: "t1", Name: "api.review.test.", Type: domain.TypeA, Content: "192.0.2.2", TTL: 300}
+	if err := dns.CreateRecord(ctx, rec); !errors.Is(err, domain.ErrReviewRequired) {
+		t.Fatalf("Expected a direct change to need review, got %v", err)
+	}
+	if err := dns.DeleteRecord(ctx, "r1", "z1", "t1"); !errors.Is(err, domain.ErrReviewRequired) {
+		t.Errorf("Expected a direct delete to need review, got %v", err)
+	}
+	if _, err := dns.ReplaceRRset(ctx, "z1", "t1", &domain.RRset{Name: "www", Type: domain.TypeA}); !errors.Is(err, domain.ErrReviewRequired) {
+		t.Errorf("Expected a direct RRset replacement to need review, got %v", err)
+	}
+	if err := dns.CreateRecord(domain.WithBreakGlass(ctx), &domain.Record{ZoneID: "z1", TenantID: "t1", Name: "bg.review.test.", Type: domain.TypeA, Content: "192.0.2.3", TTL: 300}); err != nil {
+		t.Errorf("Expected a break-glass change to pass, got %v", err)
+	}
+
+	invalid := []*domain.ChangeRequest{
+		{Action: "RENAME"},
+		{Action: domain.ChangeCreateRecord},
+		{Action: domain.ChangeDeleteRecord},
+		{Action: domain.ChangeReplaceRRset, RRset: &domain.RRset{Name: "www"}},
+	}
+	for _, c := range invalid {
+		if err := reviews.SubmitChange(ctx, "z1", "t1", c); !errors.Is(err, domain.ErrInvalidChangeRequest) {
+			t.Errorf("Expected %+v to be rejected, got %v", c, err)
+		}
+	}
+	if err := reviews.SubmitChange(ctx, "z1", "t1", &domain.ChangeRequest{Action: domain.ChangeDeleteRecord, RecordID: "missing"}); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected an unknown record to be not found, got %v", err)
+	}
+
+	create := &domain.ChangeRequest{Action: "create_record", Record: &domain.Record{Name: "api", Type: "a", Content: "192.0.2.2", TTL: 300}, RequestedBy: "writer"}
+	if err := reviews.SubmitChange(ctx, "z1", "t1", create); err != nil {
+		t.Fatalf("SubmitChange failed: %v", err)
+	}
+	if create.Status != domain.ChangePending || create.Record.Name != "api.review.test." || create.Record.Type != domain.TypeA {
+		t.Errorf("Expected a normalized pending request, got %+v", create)
+	}
+
+	if _, err := reviews.ApproveChange(ctx, "z1", "t1", create.ID, "writer", ""); !errors.Is(err, domain.ErrSelfReview) {
+		t.Errorf("Expected the requester not to approve its own change, got %v", err)
+	}
+	approved, err := reviews.ApproveChange(ctx, "z1", "t1", create.ID, "approver", "looks good")
+	if err != nil {
+		t.Fatalf("ApproveChange failed: %v", err)
+	}
+	if approved.Status != domain.ChangeApplied || approved.ReviewedBy != "approver" || approved.ReviewedAt == nil || approved.RecordID == "" {
+		t.Errorf("Expected the change to be applied, got %+v", approved)
+	}
+	if got, _ := repo.GetRecords(ctx, "api.review.test.", domain.TypeA, ""); len(got) != 1 {
+		t.Errorf("Expected the approved record to exist, got %+v", got)
+	}
+	if _, err := reviews.RejectChange(ctx, "z1", "t1", create.ID, "approver", ""); !errors.Is(err, domain.ErrInvalidChangeRequest) {
+		t.Errorf("Expected a decided request to stay decided, got %v", err)
+	}
+
+	remove := &domain.ChangeRequest{Action: domain.ChangeDeleteRecord, RecordID: "r1", RequestedBy: "writer"}
+	if err := reviews.SubmitChange(ctx, "z1", "t1", remove); err != nil {
+		t.Fatalf("SubmitChange failed: %v", err)
+	}
+	rejected, err := reviews.RejectChange(ctx, "z1", "t1", remove.ID, "approver", "still in use")
+	if err != nil || rejected.Status != domain.ChangeRejected || rejected.Comment != "still in use" {
+		t.Fatalf("Expected the change to be rejected, got %+v, %v", rejected, err)
+	}
+	if rec, _ := repo.GetRecord(ctx, "r1", "z1", "t1"); rec == nil {
+		t.Error("Expected a rejected delete to keep the record")
+	}
+
+	changes, err := reviews.ListChangeRequests(ctx, "z1", "t1")
+	if err != nil || len(changes) != 2 {
+		t.Fatalf("Expected both change requests, got %+v, %v", changes, err)
+	}
+	actions := map[string]bool{}
+	for _, a := range repo.audits {
+		actions[a.Action] = true
+	}
+	for _, want := range []string{"ENABLE_CHANGE_REVIEW", "BREAK_GLASS_CHANGE", "SUBMIT_CHANGE_REQUEST", "SELF_REVIEW_REFUSED", "APPROVE_CHANGE_REQUEST", "REJECT_CHANGE_REQUEST"} {
+		if !actions[want] {
+			t.Errorf("Expected a %s audit entry, got %v", want, actions)
+		}
+	}
+
+	if err := reviews.SetZoneReview(ctx, "z1", "t1", false); err != nil {
+		t.Fatalf("SetZoneReview failed: %v", err)
+	}
+	if err := dns.DeleteRecord(ctx, "r1", "z1", "t1"); err != nil {
+		t.Errorf("Expected direct changes once review is off, got %v", err)
+	}
+}
+
+func TestApproveChangeRecordsFailure(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepo{
+		zones:   []domain.Zone{{ID: "z1", TenantID: "t1", Name: "review.test."}},
+		records: []domain.Record{{ID: "r1", ZoneID: "z1", TenantID: "t1", Name: "www.review.test.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 300, Protected: true}},
+	}
+	reviews := NewReviewService(repo, NewDNSService(repo, nil), nil)
+	if err := reviews.SetZoneReview(ctx, "z1", "t1", true); err != nil {
+		t.Fatalf("SetZoneReview failed: %v", err)
+	}
+	c := &domain.ChangeRequest{Action: domain.ChangeDeleteRecord, RecordID: "r1", RequestedBy: "writer"}
+	if err := reviews.SubmitChange(ctx, "z1", "t1", c); err != nil {
+		t.Fatalf("SubmitChange failed: %v", err)
+	}
+	got, err := reviews.ApproveChange(ctx, "z1", "t1", c.ID, "approver", "")
+	if err != nil {
+		t.Fatalf("ApproveChange failed: %v", err)
+	}
+	if got.Status != domain.ChangeFailed || got.Error == "" {
+		t.Errorf("Expected deleting a protected record without force to fail, got %+v", got)
+	}
+}
+
+func TestZoneChangesNeedReview(t *testing.T) {
+	ctx := context.Background()
+	targetID := "tg1"
+	repo := &mockRepo{
+		zones:    []domain.Zone{{ID: "z1", TenantID: "t1", Name: "review.test."}},
+		records:  []domain.Record{{ID: "r1", ZoneID: "z1", TenantID: "t1", Name: "www.review.test.", Type: domain.TypeA, TTL: 300, TargetID: &targetID}},
+		targets:  []domain.Target{{ID: targetID, TenantID: "t1", Name: "ingress", Addresses: []string{"192.0.2.1"}}},
+		defaults: []domain.DefaultRecord{{ID: "d1", ZoneID: "z1", TenantID: "t1", Type: domain.TypeA, Content: "192.0.2.1", MinDepth: 1, MaxDepth: 1}},
+	}
+	svc := NewDNSService(repo, nil)
+	defaults := NewDefaultRecordService(repo)
+	targets := NewTargetService(repo, nil, nil)
+	shadows := NewShadowService(repo, svc, nil)
+	if err := NewReviewService(repo, svc, nil).SetZoneReview(ctx, "z1", "t1", true); err != nil {
+		t.Fatalf("SetZoneReview failed: %v", err)
+	}
+
+	changes := map[string]func(context.Context) error{
+		"DeleteZone": func(ctx context.Context) error { return svc.DeleteZone(ctx, "z1", "t1") },
+		"ImportZone": func(ctx context.Context) error {
+			_, err := svc.ImportZone(ctx, "t1", strings.NewReader("$ORIGIN review.test.\nwww 300 IN A 192.0.2.9\n"), nil)
+			return err
+		},
+		"CloneZone": func(ctx context.Context) error {
+			_, err := svc.CloneZone(ctx, "z1", "t1", domain.ZoneCloneOptions{Name: "copy.test."})
+			return err
+		},
+		"SetRecordProtection": func(ctx context.Context) error {
+			_, err := svc.SetRecordProtection(ctx, "r1", "z1", "t1", true)
+			return err
+		},
+		"SetZoneDNSSEC": func(ctx context.Context) error {
+			_, err := svc.SetZoneDNSSEC(ctx, "z1", "t1", false)
+			return err
+		},
+		"SetZoneRole": func(ctx context.Context) error {
+			_, err := svc.SetZoneRole(ctx, "z1", "t1", "slave", "192.0.2.53")
+			return err
+		},
+		"CreateDefaultRecord": func(ctx context.Context) error {
+			return defaults.CreateDefaultRecord(ctx, "z1", "t1", &domain.DefaultRecord{Type: domain.TypeTXT, Content: "parked", MinDepth: 2})
+		},
+		"DeleteDefaultRecord": func(ctx context.Context) error {
+			return defaults.DeleteDefaultRecord(ctx, "z1", "t1", "d1")
+		},
+		"UpdateTarget": func(ctx context.Context) error {
+			return targets.UpdateTarget(ctx, "t1", &domain.Target{ID: targetID, Name: "ingress", Addresses: []string{"192.0.2.2"}})
+		},
+		"StageShadowChangeWithCanaries": func(ctx context.Context) error {
+			return shadows.StageShadowChange(ctx, "z1", "t1", &domain.ShadowChange{
+				RRsets:         []domain.RRset{{Name: "api", Type: domain.TypeA, Records: []domain.RRsetRecord{{Content: "192.0.2.3"}}}},
+				CanaryNetworks: []string{"198.51.100.0/24"},
+			})
+		},
+	}
+	for name, change := range changes {
+		t.Run(name, func(t *testing.T) {
+			if err := change(ctx); !errors.Is(err, domain.ErrReviewRequired) {
+				t.Errorf("Expected %s of a reviewed zone to need review, got %v", name, err)
+			}
+			if err := change(domain.WithBreakGlass(ctx)); errors.Is(err, domain.ErrReviewRequired) {
+				t.Errorf("Expected a break-glass %s to pass the review, got %v", name, err)
+			}
+		})
+	}
+
+	// A shadow change that no client is served only needs review to commit
+	staged := &domain.ShadowChange{RRsets: []domain.RRset{{Name: "api", Type: domain.TypeA, Records: []domain.RRsetRecord{{Content: "192.0.2.4"}}}}}
+	if err := shadows.StageShadowChange(ctx, "z1", "t1", staged); err != nil {
+		t.Errorf("Expected a shadow change without canaries to be staged, got %v", err)
+	}
+}
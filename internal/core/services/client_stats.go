@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"net/netip"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// DefaultClientStatsWindow is how long client statistics accumulate before they
+// are reset.
+const DefaultClientStatsWindow = time.Hour
+
+// maxClientSources caps the source networks tracked per window; queries from
+// further networks are counted under "other".
+const maxClientSources = 10000
+
+// clientTransports are the transports counted by name; any other is "other".
+var clientTransports = [...]string{"udp", "tcp", "dot", "doh", "doq", "other"}
+
+// udpSizeBuckets are the ranges EDNS buffer sizes are counted in, by their
+// upper bound. 1232 is the DNS Flag Day 2020 default and 1452 fits an Ethernet
+// MTU over IPv6 without fragmentation.
+var udpSizeBuckets = [...]struct {
+	label string
+	max   uint16
+}{
+	{"<=512", 512},
+	{"513-1232", 1232},
+	{"1233-1452", 1452},
+	{"1453-4095", 4095},
+	{">=4096", 65535},
+}
+
+// ClientStats counts the EDNS, DNSSEC, cookie and transport capabilities
+// advertised by queries, in total and per source network, to show which
+// defaults resolvers would support. Counters are atomic, so observing a query
+// only takes a read lock unless its network is new.
+type ClientStats struct {
+	window atomic.Pointer[clientWindow]
+	logger *slog.Logger
+}
+
+type clientWindow struct {
+	start time.Time
+	total capabilityCounters
+
+	mu      sync.RWMutex
+	sources map[netip.Prefix]*capabilityCounters
+	other   capabilityCounters
+}
+
+type capabilityCounters struct {
+	queries      atomic.Uint64
+	transports   [len(clientTransports)]atomic.Uint64
+	edns         atomic.Uint64
+	udpSizes     [len(udpSizeBuckets)]atomic.Uint64
+	dnssecOK     atomic.Uint64
+	cookies      atomic.Uint64
+	clientSubnet atomic.Uint64
+}
+
+func newClientWindow(start time.Time) *clientWindow {
+	return &clientWindow{start: start, sources: make(map[netip.Prefix]*capabilityCounters)}
+}
+
+// NewClientStats creates empty client statistics.
+func NewClientStats(logger *slog.Logger) *ClientStats {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	c := &ClientStats{logger: logger}
+	c.window.Store(newClientWindow(time.Now()))
+	return c
+}
+
+// ObserveClient counts the capabilities of a query from clientIP.
+func (c *ClientStats) ObserveClient(clientIP string, fp domain.ClientFingerprint) {
+	w := c.window.Load()
+	w.total.add(fp)
+	if source, ok := sourceNetwork(clientIP); ok {
+		w.source(source).add(fp)
+	}
+}
+
+// sourceNetwork returns the /24 or /48 holding ip.
+func sourceNetwork(ip string) (netip.Prefix, bool) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return netip.Prefix{}, false
+	}
+	addr = addr.Unmap()
+	bits := 48
+	if addr.Is4() {
+		bits = 24
+	}
+	prefix, err := addr.Prefix(bits)
+	return prefix, err == nil
+}
+
+func (w *clientWindow) source(prefix netip.Prefix) *capabilityCounters {
+	w.mu.RLock()
+	counters, ok := w.sources[prefix]
+	w.mu.RUnlock()
+	if ok {
+		return counters
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if counters, ok = w.sources[prefix]; ok {
+		return counters
+	}
+	if len(w.sources) >= maxClientSources {
+		return &w.other
+	}
+	counters = &capabilityCounters{}
+	w.sources[prefix] = counters
+	return counters
+}
+
+func (cc *capabilityCounters) add(fp domain.ClientFingerprint) {
+	cc.queries.Add(1)
+	transport := len(clientTransports) - 1
+	for i, name := range clientTransports[:transport] {
+		if name == fp.Transport {
+			transport = i
+			break
+		}
+	}
+	cc.transports[transport].Add(1)
+	if !fp.EDNS {
+		return
+	}
+	cc.edns.Add(1)
+	for i, bucket := range udpSizeBuckets {
+		if fp.UDPSize <= bucket.max {
+			cc.udpSizes[i].Add(1)
+			break
+		}
+	}
+	if fp.DNSSECOK {
+		cc.dnssecOK.Add(1)
+	}
+	if fp.Cookie {
+		cc.cookies.Add(1)
+	}
+	if fp.ClientSubnet {
+		cc.clientSubnet.Add(1)
+	}
+}
+
+func (cc *capabilityCounters) snapshot() domain.ClientCapabilities {
+	caps := domain.ClientCapabilities{
+		Queries:      cc.queries.Load(),
+		Transports:   make(map[string]uint64),
+		EDNS:         cc.edns.Load(),
+		UDPSizes:     make(map[string]uint64),
+		DNSSECOK:     cc.dnssecOK.Load(),
+		Cookies:      cc.cookies.Load(),
+		ClientSubnet: cc.clientSubnet.Load(),
+	}
+	for i, name := range clientTransports {
+		if n := cc.transports[i].Load(); n > 0 {
+			caps.Transports[name] = n
+		}
+	}
+	for i, bucket := range udpSizeBuckets {
+		if n := cc.udpSizes[i].Load(); n > 0 {
+			caps.UDPSizes[bucket.label] = n
+		}
+	}
+	return caps
+}
+
+// Start resets the statistics every window until ctx is done.
+func (c *ClientStats) Start(ctx context.Context, window time.Duration) {
+	if window <= 0 {
+		window = DefaultClientStatsWindow
+	}
+	c.logger.Info("starting client statistics", "window", window)
+
+	ticker := time.NewTicker(window)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.window.Store(newClientWindow(time.Now()))
+		}
+	}
+}
+
+// ClientStats returns the statistics of the current window. A positive limit
+// keeps only the busiest source networks.
+func (c *ClientStats) ClientStats(limit int) *domain.ClientStats {
+	w := c.window.Load()
+	stats := &domain.ClientStats{
+		WindowStart: w.start,
+		Total:       w.total.snapshot(),
+		Sources:     []domain.ClientSourceStats{},
+	}
+
+	w.mu.RLock()
+	for prefix, counters := range w.sources {
+		stats.Sources = append(stats.Sources, domain.ClientSourceStats{Source: prefix.String(), ClientCapabilities: counters.snapshot()})
+	}
+	w.mu.RUnlock()
+	if other := w.other.snapshot(); other.Queries > 0 {
+		stats.Sources = append(stats.Sources, domain.ClientSourceStats{Source: "other", ClientCapabilities: other})
+	}
+
+	sort.Slice(stats.Sources, func(i, j int) bool {
+		if stats.Sources[i].Queries != stats.Sources[j].Queries {
+			return stats.Sources[i].Queries > stats.Sources[j].Queries
+		}
+		return stats.Sources[i].Source < stats.Sources[j].Source
+	})
+	if limit > 0 && len(stats.Sources) > limit {
+		stats.Sources = stats.Sources[:limit]
+	}
+	return stats
+}
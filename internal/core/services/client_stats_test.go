@@ -0,0 +1,76 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+func TestClientStats_Observe(t *testing.T) {
+	c := NewClientStats(nil)
+	modern := domain.ClientFingerprint{Transport: "udp", EDNS: true, UDPSize: 1232, DNSSECOK: true, Cookie: true}
+	for i := 0; i < 10; i++ {
+		c.ObserveClient(fmt.Sprintf("192.0.2.%d", i), modern)
+	}
+	c.ObserveClient("192.0.2.99", domain.ClientFingerprint{Transport: "tcp"})
+	c.ObserveClient("2001:db8:1:2::53", domain.ClientFingerprint{Transport: "doh", EDNS: true, UDPSize: 4096, ClientSubnet: true})
+	c.ObserveClient("::ffff:198.51.100.7", domain.ClientFingerprint{Transport: "quic", EDNS: true, UDPSize: 512})
+	c.ObserveClient("not-an-ip", modern)
+
+	stats := c.ClientStats(0)
+	total := stats.Total
+	if total.Queries != 14 || total.EDNS != 13 || total.DNSSECOK != 11 || total.Cookies != 11 || total.ClientSubnet != 1 {
+		t.Errorf("Unexpected totals %+v", total)
+	}
+	if total.Transports["udp"] != 11 || total.Transports["tcp"] != 1 || total.Transports["doh"] != 1 || total.Transports["other"] != 1 {
+		t.Errorf("Unexpected transports %v", total.Transports)
+	}
+	if total.UDPSizes["513-1232"] != 11 || total.UDPSizes[">=4096"] != 1 || total.UDPSizes["<=512"] != 1 {
+		t.Errorf("Unexpected buffer sizes %v", total.UDPSizes)
+	}
+
+	if len(stats.Sources) != 3 {
+		t.Fatalf("Expected 3 source networks, got %+v", stats.Sources)
+	}
+	first := stats.Sources[0]
+	if first.Source != "192.0.2.0/24" || first.Queries != 11 || first.EDNS != 10 || first.Transports["tcp"] != 1 {
+		t.Errorf("Expected the /24 as the busiest source, got %+v", first)
+	}
+	if stats.Sources[1].Source != "198.51.100.0/24" || stats.Sources[2].Source != "2001:db8:1::/48" {
+		t.Errorf("Expected mapped IPv4 and IPv6 networks, got %+v", stats.Sources[1:])
+	}
+	if top := c.ClientStats(1); len(top.Sources) != 1 {
+		t.Errorf("Expected limit to keep one source, got %d", len(top.Sources))
+	}
+}
+
+func TestClientStats_SourceLimit(t *testing.T) {
+	c := NewClientStats(nil)
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < maxClientSources/2; i++ {
+				c.ObserveClient(fmt.Sprintf("10.%d.%d.1", g*(maxClientSources/2/256+1)+i/256, i%256), domain.ClientFingerprint{Transport: "udp"})
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	stats := c.ClientStats(0)
+	if stats.Total.Queries != 2*maxClientSources || len(stats.Sources) != maxClientSources+1 {
+		t.Fatalf("Expected %d tracked sources plus other, got %d", maxClientSources, len(stats.Sources))
+	}
+	var other uint64
+	for _, s := range stats.Sources {
+		if s.Source == "other" {
+			other = s.Queries
+		}
+	}
+	if other != maxClientSources {
+		t.Errorf("Expected the untracked queries under other, got %d", other)
+	}
+}
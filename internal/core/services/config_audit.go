@@ -0,0 +1,289 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/core/ports"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+)
+
+// ConfigAuditService looks for common zone misconfigurations that resolve today
+// but break resolution or tooling later: lame delegations, addresses without a
+// PTR in a reverse zone we host, CNAMEs at a zone apex and RRsets whose records
+// carry different TTLs. It also flags delegations that drifted from a child
+// zone we host. Zones are only compared with zones of the same tenant.
+type ConfigAuditService struct {
+	repo   ports.DNSRepository
+	keys   ports.DNSSECKeyManager
+	logger *slog.Logger
+
+	mu          sync.RWMutex
+	latest      *domain.ConfigAuditReport
+	tenantZones map[string]int // zones audited per tenant in latest
+}
+
+// NewConfigAuditService creates an auditor that reads zones from repo.
+func NewConfigAuditService(repo ports.DNSRepository, logger *slog.Logger) *ConfigAuditService {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &ConfigAuditService{repo: repo, logger: logger}
+}
+
+// SetDNSSECKeys lets the auditor check the DS records of delegations against the
+// child zones' keys.
+func (s *ConfigAuditService) SetDNSSECKeys(keys ports.DNSSECKeyManager) {
+	s.keys = keys
+}
+
+// Start audits every zone immediately and then every interval until ctx is done.
+func (s *ConfigAuditService) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	s.logger.Info("starting configuration auditor", "interval", interval)
+
+	s.runScheduled(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("stopping configuration auditor")
+			return
+		case <-ticker.C:
+			s.runScheduled(ctx)
+		}
+	}
+}
+
+func (s *ConfigAuditService) runScheduled(ctx context.Context) {
+	zones, err := s.repo.ListZones(ctx, "")
+	if err != nil {
+		s.logger.Error("configuration audit failed", "error", err)
+		return
+	}
+	report, err := s.audit(ctx, zones)
+	if err != nil {
+		s.logger.Error("configuration audit failed", "error", err)
+		return
+	}
+	tenantZones := map[string]int{}
+	for _, z := range zones {
+		tenantZones[z.TenantID]++
+	}
+	s.mu.Lock()
+	s.latest = report
+	s.tenantZones = tenantZones
+	s.mu.Unlock()
+
+	counts := map[string]int{domain.AuditLameDelegation: 0, domain.AuditMissingPTR: 0, domain.AuditCNAMEAtApex: 0, domain.AuditTTLMismatch: 0, domain.AuditDelegationDrift: 0}
+	for _, f := range report.Findings {
+		counts[f.Check]++
+	}
+	for check, n := range counts {
+		metrics.ConfigAuditFindings.WithLabelValues(check).Set(float64(n))
+	}
+	if len(report.Findings) > 0 {
+		s.logger.Warn("configuration audit found problems", "zones", report.Zones, "findings", len(report.Findings))
+	}
+}
+
+// LatestReport returns the tenant's findings from the most recent scheduled
+// audit, or nil before the first run. An empty tenantID returns every finding.
+func (s *ConfigAuditService) LatestReport(tenantID string) *domain.ConfigAuditReport {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.latest == nil || tenantID == "" {
+		return s.latest
+	}
+	report := &domain.ConfigAuditReport{CheckedAt: s.latest.CheckedAt, Zones: s.tenantZones[tenantID], Findings: []domain.ConfigFinding{}}
+	for _, f := range s.latest.Findings {
+		if f.TenantID == tenantID {
+			report.Findings = append(report.Findings, f)
+		}
+	}
+	return report
+}
+
+// auditZone is a zone with its records, loaded once per audit.
+type auditZone struct {
+	zone    domain.Zone
+	records []domain.Record
+}
+
+// Audit checks the tenant's zones, or every zone when tenantID is empty.
+func (s *ConfigAuditService) Audit(ctx context.Context, tenantID string) (*domain.ConfigAuditReport, error) {
+	zones, err := s.repo.ListZones(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list zones: %w", err)
+	}
+	return s.audit(ctx, zones)
+}
+
+func (s *ConfigAuditService) audit(ctx context.Context, zones []domain.Zone) (*domain.ConfigAuditReport, error) {
+	byTenant := map[string][]*auditZone{}
+	for _, z := range zones {
+		records, errRecords := s.repo.ListRecordsForZone(ctx, z.ID, z.TenantID)
+		if errRecords != nil {
+			return nil, fmt.Errorf("failed to load records of %s: %w", z.Name, errRecords)
+		}
+		byTenant[z.TenantID] = append(byTenant[z.TenantID], &auditZone{zone: z, records: records})
+	}
+
+	report := &domain.ConfigAuditReport{CheckedAt: time.Now(), Zones: len(zones), Findings: []domain.ConfigFinding{}}
+	for _, tenantZones := range byTenant {
+		for _, az := range tenantZones {
+			report.Findings = append(report.Findings, auditConfigZone(az, tenantZones)...)
+			finding, errDrift := s.auditDelegation(ctx, az, tenantZones)
+			if errDrift != nil {
+				return nil, errDrift
+			}
+			if finding != nil {
+				report.Findings = append(report.Findings, *finding)
+			}
+		}
+	}
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		a, b := report.Findings[i], report.Findings[j]
+		if a.ZoneName != b.ZoneName {
+			return a.ZoneName < b.ZoneName
+		}
+		return a.Name < b.Name
+	})
+	return report, nil
+}
+
+func auditConfigZone(az *auditZone, tenantZones []*auditZone) []domain.ConfigFinding {
+	var findings []domain.ConfigFinding
+	finding := func(check, severity, name string, t domain.RecordType, detail string) {
+		findings = append(findings, domain.ConfigFinding{
+			ZoneID: az.zone.ID, ZoneName: az.zone.Name, TenantID: az.zone.TenantID,
+			Check: check, Severity: severity, Name: name, Type: t, Detail: detail,
+		})
+	}
+
+	type rrsetKey struct {
+		name string
+		t    domain.RecordType
+	}
+	ttls := map[rrsetKey]map[int]bool{}
+	var rrsets []rrsetKey
+	for _, r := range az.records {
+		switch r.Type {
+		case domain.TypeCNAME:
+			if dnsname.Equal(r.Name, az.zone.Name) {
+				finding(domain.AuditCNAMEAtApex, "error", r.Name, r.Type,
+					fmt.Sprintf("CNAME to %s at the zone apex conflicts with the SOA and NS records", r.Content))
+			}
+		case domain.TypeNS:
+			host := dnsname.Normalize(r.Content)
+			if owner := hostingZone(host, tenantZones); owner != nil && !hasAddress(owner, host) {
+				finding(domain.AuditLameDelegation, "error", r.Name, r.Type,
+					fmt.Sprintf("name server %s has no A or AAAA record in %s", host, owner.zone.Name))
+			}
+		case domain.TypeA, domain.TypeAAAA:
+			ip := net.ParseIP(r.Content)
+			if ip == nil {
+				continue
+			}
+			reverse, _ := dnsname.ReverseAddr(ip)
+			if owner := hostingZone(reverse, tenantZones); owner != nil && !hasRecord(owner, reverse, domain.TypePTR) {
+				finding(domain.AuditMissingPTR, "warning", r.Name, r.Type,
+					fmt.Sprintf("%s has no PTR record at %s in %s", r.Content, reverse, owner.zone.Name))
+			}
+		}
+
+		key := rrsetKey{name: dnsname.Normalize(r.Name), t: r.Type}
+		if ttls[key] == nil {
+			ttls[key] = map[int]bool{}
+			rrsets = append(rrsets, key)
+		}
+		ttls[key][r.TTL] = true
+	}
+
+	for _, key := range rrsets {
+		if len(ttls[key]) < 2 {
+			continue
+		}
+		values := make([]int, 0, len(ttls[key]))
+		for ttl := range ttls[key] {
+			values = append(values, ttl)
+		}
+		sort.Ints(values)
+		parts := make([]string, len(values))
+		for i, v := range values {
+			parts[i] = fmt.Sprint(v)
+		}
+		finding(domain.AuditTTLMismatch, "warning", key.name, key.t,
+			fmt.Sprintf("records of the RRset have different TTLs (%s); resolvers use the lowest", strings.Join(parts, ", ")))
+	}
+	return findings
+}
+
+// auditDelegation reports a delegation of az in its parent zone, when the parent
+// is among zones, whose NS or DS records differ from az.
+func (s *ConfigAuditService) auditDelegation(ctx context.Context, az *auditZone, zones []*auditZone) (*domain.ConfigFinding, error) {
+	parentName, ok := dnsname.Parent(az.zone.Name)
+	if !ok {
+		return nil, nil
+	}
+	parent := hostingZone(parentName, zones)
+	if parent == nil {
+		return nil, nil
+	}
+	expectedDS, err := expectedDelegationDS(ctx, s.keys, &az.zone)
+	if err != nil {
+		return nil, err
+	}
+	status := delegationStatus(az, parent, expectedDS)
+	if status.InSync {
+		return nil, nil
+	}
+	detail := fmt.Sprintf("DS records in %s do not match the active KSKs", parent.zone.Name)
+	t := domain.RecordType("DS")
+	if len(status.ChildNS) > 0 && !sameStrings(status.ChildNS, status.ParentNS) {
+		detail = fmt.Sprintf("NS records in %s (%s) differ from the zone's (%s)", parent.zone.Name,
+			strings.Join(status.ParentNS, ", "), strings.Join(status.ChildNS, ", "))
+		t = domain.TypeNS
+	}
+	return &domain.ConfigFinding{
+		ZoneID: az.zone.ID, ZoneName: az.zone.Name, TenantID: az.zone.TenantID,
+		Check: domain.AuditDelegationDrift, Severity: "error", Name: az.zone.Name, Type: t,
+		Detail: detail + "; POST /zones/" + az.zone.ID + "/delegation/sync fixes it",
+	}, nil
+}
+
+// hostingZone returns the closest enclosing zone of name among zones, or nil
+// when none of them is authoritative for it.
+func hostingZone(name string, zones []*auditZone) *auditZone {
+	var best *auditZone
+	for _, z := range zones {
+		if dnsname.IsSubdomain(name, z.zone.Name) && (best == nil || dnsname.CountLabels(z.zone.Name) > dnsname.CountLabels(best.zone.Name)) {
+			best = z
+		}
+	}
+	return best
+}
+
+func hasAddress(z *auditZone, name string) bool {
+	return hasRecord(z, name, domain.TypeA) || hasRecord(z, name, domain.TypeAAAA)
+}
+
+func hasRecord(z *auditZone, name string, t domain.RecordType) bool {
+	for _, r := range z.records {
+		if r.Type == t && dnsname.Equal(r.Name, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+func TestConfigAudit(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepo{
+		zones: []domain.Zone{
+			{ID: "z1", TenantID: "t1", Name: "example.test."},
+			{ID: "z2", TenantID: "t1", Name: "2.0.192.in-addr.arpa."},
+			{ID: "z3", TenantID: "t2", Name: "other.test."},
+		},
+		records: []domain.Record{
+			{ZoneID: "z1", Name: "example.test.", Type: domain.TypeNS, Content: "ns1.example.test.", TTL: 3600},
+			{ZoneID: "z1", Name: "example.test.", Type: domain.TypeNS, Content: "ns2.example.test.", TTL: 3600},
+			{ZoneID: "z1", Name: "example.test.", Type: domain.TypeNS, Content: "ns.elsewhere.test.", TTL: 3600},
+			{ZoneID: "z1", Name: "ns1.example.test.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 300},
+			{ZoneID: "z1", Name: "www.example.test.", Type: domain.TypeA, Content: "192.0.2.10", TTL: 300},
+			{ZoneID: "z1", Name: "WWW.example.test.", Type: domain.TypeA, Content: "192.0.2.11", TTL: 60},
+			{ZoneID: "z1", Name: "example.test.", Type: domain.TypeCNAME, Content: "lb.cdn.test.", TTL: 300},
+			{ZoneID: "z2", Name: "1.2.0.192.in-addr.arpa.", Type: domain.TypePTR, Content: "ns1.example.test.", TTL: 300},
+			{ZoneID: "z2", Name: "10.2.0.192.in-addr.arpa.", Type: domain.TypePTR, Content: "www.example.test.", TTL: 300},
+			{ZoneID: "z3", Name: "other.test.", Type: domain.TypeCNAME, Content: "example.test.", TTL: 300},
+		},
+	}
+	auditor := NewConfigAuditService(repo, nil)
+
+	if auditor.LatestReport("t1") != nil {
+		t.Error("Expected no scheduled report before the first run")
+	}
+	auditor.runScheduled(ctx)
+	if all := auditor.LatestReport(""); all == nil || all.Zones != 3 || len(all.Findings) != 5 {
+		t.Fatalf("Expected 5 findings in 3 zones, got %+v", all)
+	}
+	if t2 := auditor.LatestReport("t2"); t2.Zones != 1 || len(t2.Findings) != 1 || t2.Findings[0].ZoneID != "z3" {
+		t.Errorf("Expected only t2's apex CNAME, got %+v", t2)
+	}
+
+	report := auditor.LatestReport("t1")
+	if report.Zones != 2 {
+		t.Errorf("Expected 2 zones audited for t1, got %d", report.Zones)
+	}
+	found := map[string]domain.ConfigFinding{}
+	for _, f := range report.Findings {
+		found[f.Check] = f
+	}
+	if len(report.Findings) != 4 {
+		t.Errorf("Expected 4 findings, got %+v", report.Findings)
+	}
+	if f := found[domain.AuditLameDelegation]; f.Severity != "error" || f.ZoneID != "z1" {
+		t.Errorf("Expected ns2.example.test. to be reported as lame, got %+v", f)
+	}
+	if f := found[domain.AuditMissingPTR]; f.Name != "WWW.example.test." {
+		t.Errorf("Expected the missing PTR of 192.0.2.11, got %+v", f)
+	}
+	if f := found[domain.AuditCNAMEAtApex]; f.Name != "example.test." {
+		t.Errorf("Expected the apex CNAME, got %+v", f)
+	}
+	if f := found[domain.AuditTTLMismatch]; f.Name != "www.example.test." || f.Type != domain.TypeA {
+		t.Errorf("Expected the www A RRset TTL mismatch, got %+v", f)
+	}
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/core/ports"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+)
+
+// ConsistencyChecker asks every UP node in the fleet the same queries and reports
+// nodes whose answers differ from the majority, such as a stuck secondary serving
+// an old serial or a node with a poisoned cache. All queries come from the same
+// client address, so routed and geo-aware records should still agree.
+type ConsistencyChecker struct {
+	repo    ports.DNSRepository
+	nodes   ports.NodeLister
+	querier ports.DNSQuerier
+	logger  *slog.Logger
+
+	mu     sync.RWMutex
+	latest *domain.ConsistencyReport
+}
+
+// NewConsistencyChecker creates a checker that reaches nodes at their registered
+// addresses.
+func NewConsistencyChecker(repo ports.DNSRepository, nodes ports.NodeLister, querier ports.DNSQuerier, logger *slog.Logger) *ConsistencyChecker {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &ConsistencyChecker{repo: repo, nodes: nodes, querier: querier, logger: logger}
+}
+
+// Start checks every zone apex immediately and then every interval until ctx is done.
+func (c *ConsistencyChecker) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	c.logger.Info("starting consistency checker", "interval", interval)
+
+	c.runScheduled(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			c.logger.Info("stopping consistency checker")
+			return
+		case <-ticker.C:
+			c.runScheduled(ctx)
+		}
+	}
+}
+
+func (c *ConsistencyChecker) runScheduled(ctx context.Context) {
+	report, err := c.Check(ctx, nil)
+	if err != nil {
+		c.logger.Error("consistency check failed", "error", err)
+		return
+	}
+	c.mu.Lock()
+	c.latest = report
+	c.mu.Unlock()
+	metrics.ConsistencyDivergent.Set(float64(report.Divergent))
+
+	for _, check := range report.Checks {
+		if check.Consistent {
+			continue
+		}
+		for _, a := range check.Answers {
+			if a.Divergent {
+				c.logger.Warn("divergent answer", "name", check.Name, "type", check.Type, "node_id", a.NodeID,
+					"answer", a.Answer, "rcode", a.Rcode, "error", a.Error)
+			}
+		}
+	}
+}
+
+// LatestReport returns the most recent scheduled report, or nil before the first run.
+func (c *ConsistencyChecker) LatestReport() *domain.ConsistencyReport {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.latest
+}
+
+// Check asks every UP node the given queries and compares their answers. Without
+// queries, the SOA and NS records of every zone apex are checked.
+func (c *ConsistencyChecker) Check(ctx context.Context, queries []domain.ConsistencyQuery) (*domain.ConsistencyReport, error) {
+	if len(queries) == 0 {
+		zones, err := c.repo.ListZones(ctx, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list zones: %w", err)
+		}
+		for _, z := range zones {
+			queries = append(queries,
+				domain.ConsistencyQuery{Name: z.Name, Type: domain.TypeSOA},
+				domain.ConsistencyQuery{Name: z.Name, Type: domain.TypeNS})
+		}
+	}
+
+	all, err := c.nodes.ListNodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	var nodes []domain.Node
+	for _, n := range all {
+		if n.Status == domain.NodeStatusUp && n.Address != "" {
+			nodes = append(nodes, n)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+
+	report := &domain.ConsistencyReport{
+		CheckedAt: time.Now(),
+		Nodes:     len(nodes),
+		Checks:    make([]domain.ConsistencyCheck, len(queries)),
+	}
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, maxProbeWorkers)
+	for i, q := range queries {
+		report.Checks[i] = domain.ConsistencyCheck{ConsistencyQuery: q, Answers: make([]domain.NodeAnswer, len(nodes))}
+		for j, n := range nodes {
+			wg.Add(1)
+			go func(i, j int, q domain.ConsistencyQuery, n domain.Node) {
+				defer wg.Done()
+				semaphore <- struct{}{}
+				defer func() { <-semaphore }()
+				report.Checks[i].Answers[j] = c.ask(ctx, n, q)
+			}(i, j, q, n)
+		}
+	}
+	wg.Wait()
+
+	for i := range report.Checks {
+		report.Checks[i].Consistent = markDivergent(report.Checks[i].Answers)
+		if !report.Checks[i].Consistent {
+			report.Divergent++
+		}
+	}
+	return report, nil
+}
+
+func (c *ConsistencyChecker) ask(ctx context.Context, n domain.Node, q domain.ConsistencyQuery) domain.NodeAnswer {
+	res := domain.NodeAnswer{NodeID: n.ID, Address: n.Address, Answer: []string{}}
+	answer, err := c.querier.Query(ctx, n.Address, dnsname.Fqdn(q.Name), q.Type)
+	if err != nil {
+		metrics.ConsistencyNodeErrors.Inc()
+		res.Error = err.Error()
+		return res
+	}
+	res.Rcode = answer.Rcode
+	for _, rec := range answer.Records {
+		// TTLs legitimately differ between caches, so only the data is compared
+		res.Answer = append(res.Answer, string(rec.Type)+" "+strings.ToLower(rec.Content))
+	}
+	sort.Strings(res.Answer)
+	return res
+}
+
+// markDivergent flags every answer that differs from the strict majority, or every
+// answer when there is none. Nodes that failed to answer are always flagged. It
+// reports whether all answering nodes agreed.
+func markDivergent(answers []domain.NodeAnswer) bool {
+	counts := make(map[string]int)
+	answered := 0
+	for _, a := range answers {
+		if a.Error == "" {
+			counts[answerFingerprint(a)]++
+			answered++
+		}
+	}
+
+	majority := ""
+	for fp, n := range counts {
+		if n*2 > answered {
+			majority = fp
+		}
+	}
+
+	consistent := len(counts) <= 1
+	for i := range answers {
+		answers[i].Divergent = answers[i].Error != "" || (!consistent && answerFingerprint(answers[i]) != majority)
+	}
+	return consistent
+}
+
+func answerFingerprint(a domain.NodeAnswer) string {
+	return fmt.Sprintf("%d|%s", a.Rcode, strings.Join(a.Answer, "\n"))
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// addrQuerier answers SOA queries with a per-address serial.
+type addrQuerier struct {
+	serials map[string]string
+	down    map[string]bool
+}
+
+func (q *addrQuerier) Query(_ context.Context, server string, _ string, qType domain.RecordType) (*domain.ProbeAnswer, error) {
+	if q.down[server] {
+		return nil, errors.New("i/o timeout")
+	}
+	if qType != domain.TypeSOA {
+		return &domain.ProbeAnswer{Records: []domain.Record{{Type: qType, Content: "NS1.example.com."}}}, nil
+	}
+	return &domain.ProbeAnswer{Records: []domain.Record{{Type: domain.TypeSOA, Content: "ns1. admin. " + q.serials[server] + " 1 1 1 1"}}}, nil
+}
+
+func TestMarkDivergent(t *testing.T) {
+	a := domain.NodeAnswer{Answer: []string{"A 192.0.2.1"}}
+	b := domain.NodeAnswer{Answer: []string{"A 192.0.2.2"}}
+	failed := domain.NodeAnswer{Error: "timeout"}
+
+	answers := []domain.NodeAnswer{a, a, b, failed}
+	if markDivergent(answers) {
+		t.Error("Expected inconsistent answers")
+	}
+	if answers[0].Divergent || answers[1].Divergent || !answers[2].Divergent || !answers[3].Divergent {
+		t.Errorf("Expected minority and failed nodes flagged, got %+v", answers)
+	}
+
+	answers = []domain.NodeAnswer{a, b}
+	if markDivergent(answers) || !answers[0].Divergent || !answers[1].Divergent {
+		t.Errorf("Expected every node flagged without a majority, got %+v", answers)
+	}
+
+	answers = []domain.NodeAnswer{a, a, failed}
+	if !markDivergent(answers) || answers[0].Divergent || !answers[2].Divergent {
+		t.Errorf("Expected agreeing nodes to be consistent, got %+v", answers)
+	}
+
+	refused := domain.NodeAnswer{Rcode: 5}
+	answers = []domain.NodeAnswer{{}, {}, refused}
+	if markDivergent(answers) || !answers[2].Divergent {
+		t.Errorf("Expected a differing rcode to diverge, got %+v", answers)
+	}
+}
+
+func TestConsistencyChecker_Check(t *testing.T) {
+	repo := &mockRepo{zones: []domain.Zone{{ID: "z1", Name: "example.com."}}}
+	nodes := &mockRepo{nodes: []domain.Node{
+		{ID: "a", Address: "10.0.0.1:53", Status: domain.NodeStatusUp},
+		{ID: "b", Address: "10.0.0.2:53", Status: domain.NodeStatusUp},
+		{ID: "c", Address: "10.0.0.3:53", Status: domain.NodeStatusUp},
+		{ID: "d", Address: "10.0.0.4:53", Status: domain.NodeStatusStale},
+	}}
+	querier := &addrQuerier{
+		serials: map[string]string{"10.0.0.1:53": "7", "10.0.0.2:53": "7", "10.0.0.3:53": "6"},
+		down:    map[string]bool{},
+	}
+	checker := NewConsistencyChecker(repo, nodes, querier, nil)
+
+	report, err := checker.Check(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if report.Nodes != 3 || len(report.Checks) != 2 || report.Divergent != 1 {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+	soa := report.Checks[0]
+	if soa.Type != domain.TypeSOA || soa.Consistent || !soa.Answers[2].Divergent || soa.Answers[2].NodeID != "c" {
+		t.Errorf("Expected node c flagged for a stale serial, got %+v", soa)
+	}
+	if ns := report.Checks[1]; !ns.Consistent || ns.Answers[0].Answer[0] != "NS ns1.example.com." {
+		t.Errorf("Expected consistent NS answers, got %+v", ns)
+	}
+
+	querier.serials["10.0.0.3:53"] = "7"
+	querier.down["10.0.0.2:53"] = true
+	report, err = checker.Check(context.Background(), []domain.ConsistencyQuery{{Name: "example.com", Type: domain.TypeSOA}})
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if c := report.Checks[0]; !c.Consistent || !c.Answers[1].Divergent || c.Answers[1].Error == "" {
+		t.Errorf("Expected unreachable node reported without breaking consistency, got %+v", c)
+	}
+
+	if checker.LatestReport() != nil {
+		t.Error("Ad-hoc checks must not replace the scheduled report")
+	}
+	checker.runScheduled(context.Background())
+	if checker.LatestReport() == nil {
+		t.Error("Expected scheduled run to store a report")
+	}
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/core/ports"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+)
+
+// DefaultRecordService manages the default records of tenants' zones. The DNS
+// servers reload them periodically, and at once on a change notification.
+type DefaultRecordService struct {
+	repo ports.DNSRepository
+	now  func() time.Time
+}
+
+// NewDefaultRecordService creates a service that stores default records in repo.
+func NewDefaultRecordService(repo ports.DNSRepository) *DefaultRecordService {
+	return &DefaultRecordService{repo: repo, now: time.Now}
+}
+
+// CreateDefaultRecord adds a default record to a tenant's zone. A zero
+// MinDepth means names one label below the apex, and a CNAME may not share a
+// depth with any other default record of the zone. It answers for names across
+// the zone, so change freezes and review apply to it like to a record change.
+func (s *DefaultRecordService) CreateDefaultRecord(ctx context.Context, zoneID string, tenantID string, rec *domain.DefaultRecord) error {
+	zone, err := s.zone(ctx, zoneID, tenantID)
+	if err != nil {
+		return err
+	}
+	existing, err := s.repo.ListDefaultRecords(ctx, zone.ID)
+	if err != nil {
+		return err
+	}
+	if err := validateDefaultRecord(rec, existing); err != nil {
+		return err
+	}
+	action := fmt.Sprintf("CREATE_DEFAULT_RECORD %s %s", rec.Type, rec.Content)
+	if err := CheckChangeFreeze(ctx, s.repo, tenantID, zone.ID, action); err != nil {
+		return err
+	}
+	if err := CheckChangeReview(ctx, s.repo, tenantID, zone.ID, action); err != nil {
+		return err
+	}
+	rec.ID = uuid.New().String()
+	rec.ZoneID = zone.ID
+	rec.TenantID = tenantID
+	rec.CreatedAt = s.now()
+	if err := s.repo.SaveDefaultRecord(ctx, rec); err != nil {
+		return err
+	}
+	s.audit(ctx, tenantID, "CREATE_DEFAULT_RECORD", rec.ID, fmt.Sprintf("Default %s %s for names of %s %s", rec.Type, rec.Content, zone.Name, depthRange(*rec)))
+	return nil
+}
+
+// ListDefaultRecords returns the default records of a tenant's zone.
+func (s *DefaultRecordService) ListDefaultRecords(ctx context.Context, zoneID string, tenantID string) ([]domain.DefaultRecord, error) {
+	zone, err := s.zone(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	records, err := s.repo.ListDefaultRecords(ctx, zone.ID)
+	if err != nil {
+		return nil, err
+	}
+	if records == nil {
+		records = []domain.DefaultRecord{}
+	}
+	return records, nil
+}
+
+// DeleteDefaultRecord removes a default record of a tenant's zone.
+func (s *DefaultRecordService) DeleteDefaultRecord(ctx context.Context, zoneID string, tenantID string, id string) error {
+	zone, err := s.zone(ctx, zoneID, tenantID)
+	if err != nil {
+		return err
+	}
+	if err := CheckChangeFreeze(ctx, s.repo, tenantID, zone.ID, "DELETE_DEFAULT_RECORD "+id); err != nil {
+		return err
+	}
+	if err := CheckChangeReview(ctx, s.repo, tenantID, zone.ID, "DELETE_DEFAULT_RECORD "+id); err != nil {
+		return err
+	}
+	deleted, err := s.repo.DeleteDefaultRecord(ctx, id, zone.ID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("default record %s: %w", id, domain.ErrNotFound)
+	}
+	s.audit(ctx, tenantID, "DELETE_DEFAULT_RECORD", id, "Deleted default record of "+zone.Name)
+	return nil
+}
+
+func (s *DefaultRecordService) zone(ctx context.Context, zoneID, tenantID string) (*domain.Zone, error) {
+	zone, err := s.repo.GetZoneByID(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load zone: %w", err)
+	}
+	if zone == nil {
+		return nil, fmt.Errorf("zone %s: %w", zoneID, domain.ErrNotFound)
+	}
+	return zone, nil
+}
+
+func (s *DefaultRecordService) audit(ctx context.Context, tenantID, action, id, details string) {
+	_ = s.repo.SaveAuditLog(ctx, &domain.AuditLog{
+		ID:           uuid.New().String(),
+		TenantID:     tenantID,
+		Action:       action,
+		ResourceType: "DEFAULT_RECORD",
+		ResourceID:   id,
+		Details:      details,
+		CreatedAt:    s.now(),
+	})
+}
+
+// validateDefaultRecord checks a default record against the zone's others and
+// normalizes its type, content, TTL and depth range.
+func validateDefaultRecord(rec *domain.DefaultRecord, existing []domain.DefaultRecord) error {
+	rec.Type = domain.RecordType(strings.ToUpper(strings.TrimSpace(string(rec.Type))))
+	rec.Content = strings.TrimSpace(rec.Content)
+	if rec.Content == "" {
+		return fmt.Errorf("content is required: %w", domain.ErrInvalidDefaultRecord)
+	}
+	switch rec.Type {
+	case domain.TypeA, domain.TypeAAAA:
+		ip := net.ParseIP(rec.Content)
+		if ip == nil || (ip.To4() != nil) != (rec.Type == domain.TypeA) {
+			return fmt.Errorf("%q is not an %s address: %w", rec.Content, rec.Type, domain.ErrInvalidDefaultRecord)
+		}
+	case domain.TypeCNAME:
+		rec.Content = dnsname.Fqdn(rec.Content)
+	case domain.TypeMX:
+		if rec.Priority == nil {
+			return fmt.Errorf("an MX default record needs a priority: %w", domain.ErrInvalidDefaultRecord)
+		}
+		rec.Content = dnsname.Fqdn(rec.Content)
+	case domain.TypeTXT:
+	default:
+		return fmt.Errorf("type %q cannot be a default record: %w", rec.Type, domain.ErrInvalidDefaultRecord)
+	}
+	if rec.TTL < 60 {
+		rec.TTL = 60
+	}
+	if rec.MinDepth == 0 {
+		rec.MinDepth = 1
+	}
+	if rec.MinDepth < 1 || rec.MaxDepth < 0 || (rec.MaxDepth != 0 && rec.MaxDepth < rec.MinDepth) {
+		return fmt.Errorf("depths %d to %d: %w", rec.MinDepth, rec.MaxDepth, domain.ErrInvalidDefaultRecord)
+	}
+	for _, other := range existing {
+		if (rec.Type == domain.TypeCNAME || other.Type == domain.TypeCNAME) && depthsOverlap(*rec, other) {
+			return fmt.Errorf("a CNAME cannot share names with another default record (%s %s): %w", other.Type, depthRange(other), domain.ErrInvalidDefaultRecord)
+		}
+	}
+	return nil
+}
+
+// depthsOverlap reports whether some name depth matches both a and b.
+func depthsOverlap(a, b domain.DefaultRecord) bool {
+	return (a.MaxDepth == 0 || a.MaxDepth >= b.MinDepth) && (b.MaxDepth == 0 || b.MaxDepth >= a.MinDepth)
+}
+
+func depthRange(rec domain.DefaultRecord) string {
+	switch {
+	case rec.MaxDepth == 0:
+		return fmt.Sprintf("at depth %d and below", rec.MinDepth)
+	case rec.MaxDepth == rec.MinDepth:
+		return fmt.Sprintf("at depth %d", rec.MinDepth)
+	default:
+		return fmt.Sprintf("at depths %d to %d", rec.MinDepth, rec.MaxDepth)
+	}
+}
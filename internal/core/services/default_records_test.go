@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+func TestDefaultRecordService(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepo{zones: []domain.Zone{{ID: "z1", TenantID: "t1", Name: "host.test."}}}
+	s := NewDefaultRecordService(repo)
+
+	if err := s.CreateDefaultRecord(ctx, "z1", "t2", &domain.DefaultRecord{Type: domain.TypeA, Content: "192.0.2.1"}); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected another tenant's zone to be missing, got %v", err)
+	}
+	for _, rec := range []domain.DefaultRecord{
+		{Type: domain.TypeA, Content: "2001:db8::1"},
+		{Type: domain.TypeAAAA, Content: "192.0.2.1"},
+		{Type: domain.TypeMX, Content: "mx.host.test."},
+		{Type: domain.TypeNS, Content: "ns.host.test."},
+		{Type: domain.TypeTXT},
+		{Type: domain.TypeA, Content: "192.0.2.1", MinDepth: 3, MaxDepth: 2},
+	} {
+		if err := s.CreateDefaultRecord(ctx, "z1", "t1", &rec); !errors.Is(err, domain.ErrInvalidDefaultRecord) {
+			t.Errorf("Expected ErrInvalidDefaultRecord for %+v, got %v", rec, err)
+		}
+	}
+
+	ingress := domain.DefaultRecord{Type: "a", Content: "192.0.2.1", MaxDepth: 1}
+	if err := s.CreateDefaultRecord(ctx, "z1", "t1", &ingress); err != nil {
+		t.Fatalf("CreateDefaultRecord failed: %v", err)
+	}
+	if ingress.ID == "" || ingress.Type != domain.TypeA || ingress.MinDepth != 1 || ingress.TTL != 60 {
+		t.Errorf("Expected a normalized default record, got %+v", ingress)
+	}
+	if err := s.CreateDefaultRecord(ctx, "z1", "t1", &domain.DefaultRecord{Type: domain.TypeCNAME, Content: "ingress.host.test", MinDepth: 1}); !errors.Is(err, domain.ErrInvalidDefaultRecord) {
+		t.Errorf("Expected a CNAME sharing a depth to be rejected, got %v", err)
+	}
+	deep := domain.DefaultRecord{Type: domain.TypeCNAME, Content: "ingress.host.test", MinDepth: 2}
+	if err := s.CreateDefaultRecord(ctx, "z1", "t1", &deep); err != nil || deep.Content != "ingress.host.test." {
+		t.Fatalf("Expected a CNAME below the A record's depths, got %+v, %v", deep, err)
+	}
+
+	records, err := s.ListDefaultRecords(ctx, "z1", "t1")
+	if err != nil || len(records) != 2 {
+		t.Fatalf("Expected both default records, got %+v, %v", records, err)
+	}
+
+	// Default records answer across the zone, so a freeze holds them too
+	repo.freezes = []domain.ChangeFreeze{{ID: "f1", TenantID: "t1", ZoneID: "z1", Reason: "release", StartsAt: time.Now().Add(-time.Hour), EndsAt: time.Now().Add(time.Hour)}}
+	if err := s.CreateDefaultRecord(ctx, "z1", "t1", &domain.DefaultRecord{Type: domain.TypeTXT, Content: "frozen", MaxDepth: 1}); !errors.Is(err, domain.ErrChangeFrozen) {
+		t.Errorf("Expected a frozen zone to refuse a new default record, got %v", err)
+	}
+	if err := s.DeleteDefaultRecord(ctx, "z1", "t1", ingress.ID); !errors.Is(err, domain.ErrChangeFrozen) {
+		t.Errorf("Expected a frozen zone to keep its default records, got %v", err)
+	}
+	repo.freezes = nil
+
+	if err := s.DeleteDefaultRecord(ctx, "z1", "t1", ingress.ID); err != nil {
+		t.Fatalf("DeleteDefaultRecord failed: %v", err)
+	}
+	if err := s.DeleteDefaultRecord(ctx, "z1", "t1", ingress.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a removed default record, got %v", err)
+	}
+}
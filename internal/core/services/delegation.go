@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/core/ports"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+)
+
+// DelegationService keeps the delegation of a zone in its parent zone in step
+// with the child when both are hosted by the same tenant: the parent's NS
+// records at the child's name must match the child's apex NS records, and its
+// DS records the child's active KSKs.
+type DelegationService struct {
+	repo   ports.DNSRepository
+	svc    ports.DNSService
+	keys   ports.DNSSECKeyManager
+	logger *slog.Logger
+}
+
+// NewDelegationService creates a service that rewrites delegations through svc,
+// so change freezes, auditing and cache invalidation apply. keys may be nil, in
+// which case DS records are left alone.
+func NewDelegationService(repo ports.DNSRepository, svc ports.DNSService, keys ports.DNSSECKeyManager, logger *slog.Logger) *DelegationService {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &DelegationService{repo: repo, svc: svc, keys: keys, logger: logger}
+}
+
+// CheckDelegation compares a zone's delegation in its hosted parent zone with
+// the zone itself.
+func (s *DelegationService) CheckDelegation(ctx context.Context, zoneID string, tenantID string) (*domain.DelegationStatus, error) {
+	child, parent, err := s.load(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	expectedDS, err := expectedDelegationDS(ctx, s.keys, &child.zone)
+	if err != nil {
+		return nil, err
+	}
+	return delegationStatus(child, parent, expectedDS), nil
+}
+
+// SyncDelegation rewrites the parent's NS records, and DS records when DNSSEC
+// keys are available, at the zone's name to match the zone, and returns the
+// resulting status. A child without apex NS records keeps its delegation.
+func (s *DelegationService) SyncDelegation(ctx context.Context, zoneID string, tenantID string) (*domain.DelegationStatus, error) {
+	status, err := s.CheckDelegation(ctx, zoneID, tenantID)
+	if err != nil || status.InSync {
+		return status, err
+	}
+	if len(status.ChildNS) > 0 && !sameStrings(status.ChildNS, status.ParentNS) {
+		if err := s.replace(ctx, tenantID, status, domain.TypeNS, status.ChildNS); err != nil {
+			return nil, err
+		}
+	}
+	if status.ExpectedDS != nil && !sameStrings(status.ExpectedDS, status.ParentDS) {
+		if err := s.replace(ctx, tenantID, status, "DS", status.ExpectedDS); err != nil {
+			return nil, err
+		}
+	}
+	s.logger.Info("synchronized delegation", "zone", status.Zone, "parent", status.ParentZone)
+	return s.CheckDelegation(ctx, zoneID, tenantID)
+}
+
+func (s *DelegationService) replace(ctx context.Context, tenantID string, status *domain.DelegationStatus, t domain.RecordType, contents []string) error {
+	rrset := &domain.RRset{Name: status.Zone, Type: t, Records: make([]domain.RRsetRecord, 0, len(contents))}
+	if t == "DS" {
+		rrset.TTL = dsTTL
+	}
+	for _, c := range contents {
+		rrset.Records = append(rrset.Records, domain.RRsetRecord{Content: c})
+	}
+	if _, err := s.svc.ReplaceRRset(ctx, status.ParentZoneID, tenantID, rrset); err != nil {
+		return fmt.Errorf("failed to update %s records in %s: %w", t, status.ParentZone, err)
+	}
+	return nil
+}
+
+// load returns the tenant's zone and its closest enclosing zone of the same
+// tenant, with their records.
+func (s *DelegationService) load(ctx context.Context, zoneID string, tenantID string) (*auditZone, *auditZone, error) {
+	zone, err := s.repo.GetZoneByID(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load zone: %w", err)
+	}
+	if zone == nil {
+		return nil, nil, fmt.Errorf("zone %s: %w", zoneID, domain.ErrNotFound)
+	}
+	zones, err := s.repo.ListZones(ctx, tenantID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list zones: %w", err)
+	}
+	candidates := make([]*auditZone, 0, len(zones))
+	for _, z := range zones {
+		if z.TenantID == tenantID && z.ID != zone.ID {
+			candidates = append(candidates, &auditZone{zone: z})
+		}
+	}
+	parentName, ok := dnsname.Parent(zone.Name)
+	if !ok {
+		return nil, nil, fmt.Errorf("zone %s: %w", zone.Name, domain.ErrNoHostedParent)
+	}
+	parent := hostingZone(parentName, candidates)
+	if parent == nil {
+		return nil, nil, fmt.Errorf("zone %s: %w", zone.Name, domain.ErrNoHostedParent)
+	}
+
+	child := &auditZone{zone: *zone}
+	if child.records, err = s.repo.ListRecordsForZone(ctx, zone.ID, tenantID); err != nil {
+		return nil, nil, fmt.Errorf("failed to load records of %s: %w", zone.Name, err)
+	}
+	if parent.records, err = s.repo.ListRecordsForZone(ctx, parent.zone.ID, tenantID); err != nil {
+		return nil, nil, fmt.Errorf("failed to load records of %s: %w", parent.zone.Name, err)
+	}
+	return child, parent, nil
+}
+
+// expectedDelegationDS returns the SHA-256 DS records the parent should hold
+// for zone, as record content. It returns nil when keys is nil, meaning DS
+// records are not checked, and an empty list for zones that do not sign.
+func expectedDelegationDS(ctx context.Context, keys ports.DNSSECKeyManager, zone *domain.Zone) ([]string, error) {
+	if keys == nil {
+		return nil, nil
+	}
+	expected := []string{}
+	if !zone.SignsDNSSEC() {
+		return expected, nil
+	}
+	records, err := keys.ZoneDS(ctx, zone.ID, zone.TenantID, []uint8{2})
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute DS records of %s: %w", zone.Name, err)
+	}
+	for _, ds := range records {
+		expected = append(expected, fmt.Sprintf("%d %d %d %s", ds.KeyTag, ds.Algorithm, ds.DigestType, ds.Digest))
+	}
+	sort.Strings(expected)
+	return expected, nil
+}
+
+// delegationStatus compares the parent's NS and DS records at the child's name
+// with the child's apex NS records and expectedDS (nil to skip DS).
+func delegationStatus(child, parent *auditZone, expectedDS []string) *domain.DelegationStatus {
+	status := &domain.DelegationStatus{
+		ZoneID:       child.zone.ID,
+		Zone:         child.zone.Name,
+		ParentZoneID: parent.zone.ID,
+		ParentZone:   parent.zone.Name,
+		ChildNS:      rrsetContents(child, child.zone.Name, domain.TypeNS, dnsname.Normalize),
+		ParentNS:     rrsetContents(parent, child.zone.Name, domain.TypeNS, dnsname.Normalize),
+		ExpectedDS:   expectedDS,
+		ParentDS:     rrsetContents(parent, child.zone.Name, "DS", normalizeDS),
+	}
+	status.InSync = (len(status.ChildNS) == 0 || sameStrings(status.ChildNS, status.ParentNS)) &&
+		(expectedDS == nil || sameStrings(expectedDS, status.ParentDS))
+	return status
+}
+
+func rrsetContents(z *auditZone, name string, t domain.RecordType, normalize func(string) string) []string {
+	contents := []string{}
+	for _, r := range z.records {
+		if r.Type == t && dnsname.Equal(r.Name, name) {
+			contents = append(contents, normalize(strings.TrimSpace(r.Content)))
+		}
+	}
+	sort.Strings(contents)
+	return contents
+}
+
+// normalizeDS writes DS content with single spaces and an upper-case digest.
+func normalizeDS(content string) string {
+	fields := strings.Fields(content)
+	if len(fields) < 4 {
+		return content
+	}
+	return strings.Join(fields[:3], " ") + " " + strings.ToUpper(strings.Join(fields[3:], ""))
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/core/ports"
+)
+
+// stubDS hands out one fixed DS record for every zone.
+type stubDS struct {
+	ports.DNSSECKeyManager
+}
+
+func (stubDS) ZoneDS(_ context.Context, _ string, _ string, _ []uint8) ([]domain.DSRecord, error) {
+	return []domain.DSRecord{{KeyTag: 12345, Algorithm: 13, DigestType: 2, Digest: "ABCDEF"}}, nil
+}
+
+func TestDelegationSync(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepo{
+		zones: []domain.Zone{
+			{ID: "z1", TenantID: "t1", Name: "example.test."},
+			{ID: "z2", TenantID: "t1", Name: "sub.example.test."},
+			{ID: "z3", TenantID: "t1", Name: "alone.test."},
+		},
+		records: []domain.Record{
+			{ID: "r1", ZoneID: "z1", Name: "sub.example.test.", Type: domain.TypeNS, Content: "ns-old.example.test.", TTL: 3600},
+			{ID: "r2", ZoneID: "z1", Name: "sub.example.test.", Type: "DS", Content: "999 8 2 abcd", TTL: 3600},
+			{ID: "r3", ZoneID: "z2", Name: "sub.example.test.", Type: domain.TypeNS, Content: "ns1.sub.example.test.", TTL: 3600},
+			{ID: "r4", ZoneID: "z2", Name: "sub.example.test.", Type: domain.TypeNS, Content: "NS2.sub.example.test.", TTL: 3600},
+			{ID: "r5", ZoneID: "z2", Name: "ns1.sub.example.test.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 3600},
+			{ID: "r6", ZoneID: "z2", Name: "ns2.sub.example.test.", Type: domain.TypeA, Content: "192.0.2.2", TTL: 3600},
+		},
+	}
+	delegations := NewDelegationService(repo, NewDNSService(repo, nil), stubDS{}, nil)
+
+	if _, err := delegations.CheckDelegation(ctx, "z3", "t1"); !errors.Is(err, domain.ErrNoHostedParent) {
+		t.Errorf("Expected a zone without a hosted parent to be rejected, got %v", err)
+	}
+	if _, err := delegations.CheckDelegation(ctx, "z2", "t2"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected another tenant's zone to be not found, got %v", err)
+	}
+
+	status, err := delegations.CheckDelegation(ctx, "z2", "t1")
+	if err != nil {
+		t.Fatalf("CheckDelegation failed: %v", err)
+	}
+	if status.InSync || status.ParentZoneID != "z1" || len(status.ChildNS) != 2 || status.ChildNS[1] != "ns2.sub.example.test." {
+		t.Errorf("Expected a drifted delegation in example.test., got %+v", status)
+	}
+
+	auditor := NewConfigAuditService(repo, nil)
+	auditor.SetDNSSECKeys(stubDS{})
+	report, err := auditor.Audit(ctx, "t1")
+	if err != nil {
+		t.Fatalf("Audit failed: %v", err)
+	}
+	drifted := 0
+	for _, f := range report.Findings {
+		if f.Check == domain.AuditDelegationDrift {
+			drifted++
+			if f.ZoneID != "z2" || f.Type != domain.TypeNS {
+				t.Errorf("Expected the NS drift of sub.example.test., got %+v", f)
+			}
+		}
+	}
+	if drifted != 1 {
+		t.Errorf("Expected one delegation drift finding, got %d", drifted)
+	}
+
+	status, err = delegations.SyncDelegation(ctx, "z2", "t1")
+	if err != nil {
+		t.Fatalf("SyncDelegation failed: %v", err)
+	}
+	if !status.InSync || len(status.ParentDS) != 1 || status.ParentDS[0] != "12345 13 2 ABCDEF" {
+		t.Errorf("Expected the parent to match the zone after a sync, got %+v", status)
+	}
+	if report, _ := auditor.Audit(ctx, "t1"); len(report.Findings) != 0 {
+		t.Errorf("Expected no findings after the sync, got %+v", report.Findings)
+	}
+}
@@ -0,0 +1,315 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/core/ports"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+)
+
+const (
+	// DefaultRecoveryExportInterval is how often changed zones are exported to
+	// the change log.
+	DefaultRecoveryExportInterval = 30 * time.Second
+	// DefaultRecoverySnapshotInterval is how often a full snapshot is uploaded.
+	DefaultRecoverySnapshotInterval = 24 * time.Hour
+
+	recoverySnapshotPrefix = "snapshots/"
+	recoveryChangePrefix   = "changes/"
+	// recoveryKeyTime names objects so that lexical order is time order.
+	recoveryKeyTime = "20060102T150405.000000000Z"
+)
+
+// recoveryListenerRetry is how long replication waits before subscribing to
+// database changes again after the subscription fails.
+var recoveryListenerRetry = 5 * time.Second
+
+// DisasterRecovery replicates the repository to an object store so it can be
+// rebuilt if the database is lost: full snapshots are uploaded periodically and,
+// in between, the complete state of every changed zone is appended to a change
+// log. Rebuilding restores the latest snapshot and replays the change log
+// entries exported after it.
+type DisasterRecovery struct {
+	store   ports.RecoveryStore
+	objects ports.ObjectStore
+	logger  *slog.Logger
+	now     func() time.Time
+
+	mu    sync.Mutex
+	dirty map[string]struct{}
+	// exportMu serializes exports so change log entries are named in order.
+	exportMu sync.Mutex
+	// resync requests a snapshot because changes may have been missed.
+	resync atomic.Bool
+}
+
+// NewDisasterRecovery creates a replicator writing store's contents to objects.
+func NewDisasterRecovery(store ports.RecoveryStore, objects ports.ObjectStore, logger *slog.Logger) *DisasterRecovery {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &DisasterRecovery{
+		store:   store,
+		objects: objects,
+		logger:  logger,
+		now:     time.Now,
+		dirty:   make(map[string]struct{}),
+	}
+}
+
+// DataChanged queues the zone of a committed change for the next export.
+func (d *DisasterRecovery) DataChanged(change domain.DataChange) {
+	if change.ZoneID == "" {
+		return
+	}
+	d.mu.Lock()
+	d.dirty[change.ZoneID] = struct{}{}
+	d.mu.Unlock()
+}
+
+// Pending returns the number of changed zones waiting to be exported.
+func (d *DisasterRecovery) Pending() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.dirty)
+}
+
+// ExportChanges appends the current state of every queued zone to the change
+// log as one entry and returns how many zones it held. Zones that could not be
+// exported stay queued.
+func (d *DisasterRecovery) ExportChanges(ctx context.Context) (int, error) {
+	d.exportMu.Lock()
+	defer d.exportMu.Unlock()
+
+	d.mu.Lock()
+	ids := make([]string, 0, len(d.dirty))
+	for id := range d.dirty {
+		ids = append(ids, id)
+	}
+	d.dirty = make(map[string]struct{})
+	d.mu.Unlock()
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	sort.Strings(ids)
+
+	// Zones are read after this instant, so the entry never predates their state
+	entry := domain.ChangeLogEntry{ExportedAt: d.now().UTC()}
+	for _, id := range ids {
+		state, err := d.zoneState(ctx, id)
+		if err != nil {
+			d.requeue(ids)
+			metrics.RecoveryUploads.WithLabelValues("changes", "error").Inc()
+			return 0, fmt.Errorf("failed to read zone %s: %w", id, err)
+		}
+		entry.Zones = append(entry.Zones, state)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		d.requeue(ids)
+		return 0, err
+	}
+	key := recoveryChangePrefix + entry.ExportedAt.Format(recoveryKeyTime) + ".json"
+	if err := d.objects.PutObject(ctx, key, data); err != nil {
+		d.requeue(ids)
+		metrics.RecoveryUploads.WithLabelValues("changes", "error").Inc()
+		return 0, fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	metrics.RecoveryUploads.WithLabelValues("changes", "success").Inc()
+	metrics.RecoveryLastUpload.WithLabelValues("changes").Set(float64(entry.ExportedAt.Unix()))
+	return len(ids), nil
+}
+
+func (d *DisasterRecovery) zoneState(ctx context.Context, zoneID string) (domain.ZoneState, error) {
+	state := domain.ZoneState{ZoneID: zoneID}
+	zone, err := d.store.GetZoneByID(ctx, zoneID, "")
+	if err != nil || zone == nil {
+		return state, err
+	}
+	records, err := d.store.ListRecordsForZone(ctx, zoneID, zone.TenantID)
+	if err != nil {
+		return state, err
+	}
+	state.Zone = zone
+	state.Records = records
+	return state, nil
+}
+
+func (d *DisasterRecovery) requeue(ids []string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, id := range ids {
+		d.dirty[id] = struct{}{}
+	}
+}
+
+// Snapshot uploads a full snapshot of the repository and returns its key.
+func (d *DisasterRecovery) Snapshot(ctx context.Context) (string, error) {
+	// Named before it is taken, so change log entries exported while it runs are
+	// replayed on top of it
+	at := d.now().UTC()
+	archive, err := d.store.ArchiveSnapshot(ctx)
+	if err != nil {
+		metrics.RecoveryUploads.WithLabelValues("snapshot", "error").Inc()
+		return "", fmt.Errorf("failed to take snapshot: %w", err)
+	}
+	key := recoverySnapshotPrefix + at.Format(recoveryKeyTime) + ".json.gz"
+	if err := d.objects.PutObject(ctx, key, archive); err != nil {
+		metrics.RecoveryUploads.WithLabelValues("snapshot", "error").Inc()
+		return "", fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	metrics.RecoveryUploads.WithLabelValues("snapshot", "success").Inc()
+	metrics.RecoveryLastUpload.WithLabelValues("snapshot").Set(float64(at.Unix()))
+	return key, nil
+}
+
+// Start uploads a snapshot, then exports changes from source every
+// exportInterval and uploads a snapshot every snapshotInterval until ctx is
+// done. Changes committed while the subscription to source is down are not
+// delivered, so a snapshot is taken as soon as it is restored.
+func (d *DisasterRecovery) Start(ctx context.Context, source ports.DataChangeSource, exportInterval, snapshotInterval time.Duration) {
+	if exportInterval <= 0 {
+		exportInterval = DefaultRecoveryExportInterval
+	}
+	if snapshotInterval <= 0 {
+		snapshotInterval = DefaultRecoverySnapshotInterval
+	}
+	d.logger.Info("starting disaster-recovery replication", "export_interval", exportInterval, "snapshot_interval", snapshotInterval)
+
+	go d.listen(ctx, source)
+	d.resync.Store(true)
+	exports := time.NewTicker(exportInterval)
+	defer exports.Stop()
+	snapshots := time.NewTicker(snapshotInterval)
+	defer snapshots.Stop()
+	for {
+		if d.resync.Swap(false) {
+			d.snapshot(ctx)
+		}
+		select {
+		case <-ctx.Done():
+			// Export what is queued so a clean shutdown loses nothing
+			flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+			if _, err := d.ExportChanges(flushCtx); err != nil {
+				d.logger.Error("failed to export changes on shutdown", "error", err)
+			}
+			cancel()
+			d.logger.Info("stopping disaster-recovery replication")
+			return
+		case <-exports.C:
+			if n, err := d.ExportChanges(ctx); err != nil {
+				d.logger.Error("failed to export changes", "error", err)
+			} else if n > 0 {
+				d.logger.Debug("exported zone changes", "zones", n)
+			}
+		case <-snapshots.C:
+			d.resync.Store(true)
+		}
+	}
+}
+
+func (d *DisasterRecovery) snapshot(ctx context.Context) {
+	key, err := d.Snapshot(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			d.logger.Error("disaster-recovery snapshot failed", "error", err)
+			d.resync.Store(true)
+		}
+		return
+	}
+	d.logger.Info("uploaded disaster-recovery snapshot", "key", key)
+}
+
+func (d *DisasterRecovery) listen(ctx context.Context, source ports.DataChangeSource) {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			d.resync.Store(true)
+		}
+		err := source.ListenDataChanges(ctx, d.DataChanged)
+		if ctx.Err() != nil {
+			return
+		}
+		d.logger.Warn("disaster-recovery change listener failed", "error", err, "retry_in", recoveryListenerRetry)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(recoveryListenerRetry):
+		}
+	}
+}
+
+// Bootstrap rebuilds an empty repository from the object store: the latest
+// snapshot is restored, then every change log entry exported since it was
+// taken is applied in order.
+func (d *DisasterRecovery) Bootstrap(ctx context.Context) (*domain.RecoveryReport, error) {
+	snapshots, err := d.objects.ListObjects(ctx, recoverySnapshotPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	if len(snapshots) == 0 {
+		return nil, fmt.Errorf("no snapshot to recover from: %w", domain.ErrNotFound)
+	}
+	latest := snapshots[len(snapshots)-1]
+	stamp := objectTime(latest, recoverySnapshotPrefix)
+	at, err := time.Parse(recoveryKeyTime, stamp)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected snapshot name %s", latest)
+	}
+
+	archive, err := d.objects.GetObject(ctx, latest)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.store.RestoreArchive(ctx, archive); err != nil {
+		return nil, fmt.Errorf("failed to restore %s: %w", latest, err)
+	}
+	report := &domain.RecoveryReport{Snapshot: latest, SnapshotAt: at, RecoveredTo: at}
+
+	changes, err := d.objects.ListObjects(ctx, recoveryChangePrefix)
+	if err != nil {
+		return report, fmt.Errorf("failed to list changes: %w", err)
+	}
+	for _, key := range changes {
+		if objectTime(key, recoveryChangePrefix) < stamp {
+			continue
+		}
+		data, err := d.objects.GetObject(ctx, key)
+		if err != nil {
+			return report, err
+		}
+		var entry domain.ChangeLogEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return report, fmt.Errorf("malformed change log entry %s: %w", key, err)
+		}
+		for _, state := range entry.Zones {
+			if err := d.store.ReplaceZone(ctx, state.ZoneID, state.Zone, state.Records); err != nil {
+				return report, fmt.Errorf("failed to apply zone %s from %s: %w", state.ZoneID, key, err)
+			}
+			report.ZonesApplied++
+		}
+		report.Entries++
+		report.RecoveredTo = entry.ExportedAt
+	}
+	return report, nil
+}
+
+// objectTime returns the timestamp an object key was named with.
+func objectTime(key, prefix string) string {
+	stamp := strings.TrimPrefix(key, prefix)
+	if i := strings.IndexByte(stamp, '.'); i >= 0 {
+		// The timestamp holds one dot, before the nanoseconds
+		if j := strings.IndexByte(stamp[i+1:], '.'); j >= 0 {
+			return stamp[:i+1+j]
+		}
+	}
+	return stamp
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// memObjects is an in-memory object store.
+type memObjects struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	err     error
+}
+
+func (m *memObjects) PutObject(_ context.Context, key string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.objects[key] = data
+	return nil
+}
+
+func (m *memObjects) GetObject(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.objects[key]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return data, nil
+}
+
+func (m *memObjects) ListObjects(_ context.Context, prefix string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var keys []string
+	for k := range m.objects {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// recoveryRepo adds whole-zone writes and JSON archives to mockRepo.
+type recoveryRepo struct {
+	*mockRepo
+}
+
+func (r recoveryRepo) ReplaceZone(_ context.Context, zoneID string, zone *domain.Zone, records []domain.Record) error {
+	var zones []domain.Zone
+	for _, z := range r.zones {
+		if z.ID != zoneID {
+			zones = append(zones, z)
+		}
+	}
+	var kept []domain.Record
+	for _, rec := range r.records {
+		if rec.ZoneID != zoneID {
+			kept = append(kept, rec)
+		}
+	}
+	if zone != nil {
+		zones = append(zones, *zone)
+		kept = append(kept, records...)
+	}
+	r.zones, r.records = zones, kept
+	return nil
+}
+
+type recoveryArchive struct {
+	Zones   []domain.Zone
+	Records []domain.Record
+}
+
+func (r recoveryRepo) ArchiveSnapshot(context.Context) ([]byte, error) {
+	return json.Marshal(recoveryArchive{Zones: r.zones, Records: r.records})
+}
+
+func (r recoveryRepo) RestoreArchive(_ context.Context, archive []byte) error {
+	if len(r.zones) > 0 {
+		return errors.New("not empty")
+	}
+	var a recoveryArchive
+	if err := json.Unmarshal(archive, &a); err != nil {
+		return err
+	}
+	r.zones, r.records = a.Zones, a.Records
+	return nil
+}
+
+func TestDisasterRecoveryRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	source := recoveryRepo{&mockRepo{
+		zones: []domain.Zone{{ID: "z1", TenantID: "t1", Name: "a.test."}, {ID: "z2", TenantID: "t1", Name: "b.test."}},
+		records: []domain.Record{
+			{ID: "r1", ZoneID: "z1", Name: "www.a.test.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 300},
+			{ID: "r2", ZoneID: "z2", Name: "www.b.test.", Type: domain.TypeA, Content: "192.0.2.2", TTL: 300},
+		},
+	}}
+	objects := &memObjects{objects: map[string][]byte{}}
+	dr := NewDisasterRecovery(source, objects, nil)
+	clock := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	dr.now = func() time.Time { return clock }
+
+	// An entry exported before the snapshot is already part of it
+	stale, _ := json.Marshal(domain.ChangeLogEntry{ExportedAt: clock.Add(-time.Minute), Zones: []domain.ZoneState{{ZoneID: "z1"}}})
+	objects.objects["changes/20260501T115900.000000000Z.json"] = stale
+
+	if nothing, err := dr.ExportChanges(ctx); err != nil || nothing != 0 {
+		t.Fatalf("Expected nothing to export, got %d, %v", nothing, err)
+	}
+	key, err := dr.Snapshot(ctx)
+	if err != nil || key != "snapshots/20260501T120000.000000000Z.json.gz" {
+		t.Fatalf("Snapshot = %s, %v", key, err)
+	}
+
+	// z1 gains a record and z2 is deleted
+	clock = clock.Add(time.Second)
+	source.records = append(source.records, domain.Record{ID: "r3", ZoneID: "z1", Name: "mail.a.test.", Type: domain.TypeA, Content: "192.0.2.3", TTL: 300})
+	source.zones = source.zones[:1]
+	dr.DataChanged(domain.DataChange{Table: "dns_records", Op: "INSERT", ZoneID: "z1"})
+	dr.DataChanged(domain.DataChange{Table: "dns_zones", Op: "DELETE", ZoneID: "z2"})
+	dr.DataChanged(domain.DataChange{Table: "dns_records", Op: "INSERT"})
+
+	// A failed upload keeps the zones queued
+	objects.err = errors.New("unavailable")
+	if _, err := dr.ExportChanges(ctx); err == nil || dr.Pending() != 2 {
+		t.Fatalf("Expected the failed export to be retried, got %v with %d pending", err, dr.Pending())
+	}
+	objects.err = nil
+	if n, err := dr.ExportChanges(ctx); err != nil || n != 2 || dr.Pending() != 0 {
+		t.Fatalf("ExportChanges = %d, %v", n, err)
+	}
+
+	// A fresh repository is rebuilt from the snapshot and the newer entry
+	target := recoveryRepo{&mockRepo{}}
+	report, err := NewDisasterRecovery(target, objects, nil).Bootstrap(ctx)
+	if err != nil {
+		t.Fatalf("Bootstrap failed: %v", err)
+	}
+	if report.Snapshot != key || report.Entries != 1 || report.ZonesApplied != 2 || !report.RecoveredTo.Equal(clock) {
+		t.Errorf("Unexpected report %+v", report)
+	}
+	if len(target.zones) != 1 || target.zones[0].ID != "z1" || len(target.records) != 2 {
+		t.Errorf("Expected z1 with both records only, got %+v %+v", target.zones, target.records)
+	}
+
+	if _, err := NewDisasterRecovery(recoveryRepo{&mockRepo{}}, &memObjects{objects: map[string][]byte{}}, nil).Bootstrap(ctx); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound without a snapshot, got %v", err)
+	}
+}
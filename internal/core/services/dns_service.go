@@ -10,22 +10,39 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
 	"github.com/poyrazK/cloudDNS/internal/core/ports"
 	"github.com/poyrazK/cloudDNS/internal/dns/master"
+	"github.com/poyrazK/cloudDNS/internal/dns/serial"
 )
 
 type dnsService struct {
 	repo   ports.DNSRepository
 	cache  ports.CacheInvalidator // Used for cross-node invalidation
+	events ports.EventPublisher   // Optional live change stream
 	logger *slog.Logger
 }
 
-func NewDNSService(repo ports.DNSRepository, cache ports.CacheInvalidator) ports.DNSService {
-	return &dnsService{
+// DNSServiceOption configures optional dependencies of the DNS service.
+type DNSServiceOption func(*dnsService)
+
+// WithEventPublisher publishes zone and record changes to p.
+func WithEventPublisher(p ports.EventPublisher) DNSServiceOption {
+	return func(s *dnsService) {
+		s.events = p
+	}
+}
+
+func NewDNSService(repo ports.DNSRepository, cache ports.CacheInvalidator, opts ...DNSServiceOption) ports.DNSService {
+	s := &dnsService{
 		repo:   repo,
 		cache:  cache,
 		logger: slog.Default(),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 func (s *dnsService) CreateZone(ctx context.Context, zone *domain.Zone) error {
@@ -34,8 +51,10 @@ func (s *dnsService) CreateZone(ctx context.Context, zone *domain.Zone) error {
 	zone.UpdatedAt = time.Now()
 
 	// Ensure zone name ends with a dot
-	if !strings.HasSuffix(zone.Name, ".") {
-		zone.Name += "."
+	zone.Name = dnsname.Fqdn(zone.Name)
+
+	if err := CheckChangeFreeze(ctx, s.repo, zone.TenantID, "", "CREATE_ZONE "+zone.Name); err != nil {
+		return err
 	}
 
 	// 1. Create Default SOA Record
@@ -67,19 +86,47 @@ func (s *dnsService) CreateZone(ctx context.Context, zone *domain.Zone) error {
 		UpdatedAt: zone.UpdatedAt,
 	}
 
-	if err := s.repo.CreateZoneWithRecords(ctx, zone, []domain.Record{*soaRecord, *nsRecord}); err != nil {
+	records := []domain.Record{*soaRecord, *nsRecord}
+	if zone.Role == "slave" {
+		// A secondary gets every record, SOA included, from its master; a local
+		// SOA would make its first transfer look out of date
+		records = nil
+	}
+	if err := s.repo.CreateZoneWithRecords(ctx, zone, records); err != nil {
 		return err
 	}
 
 	// 3. Audit Log
 	s.audit(ctx, zone.TenantID, "CREATE_ZONE", "ZONE", zone.ID, fmt.Sprintf("Created zone %s", zone.Name))
+	s.publish(domain.Event{Type: domain.EventZoneChange, TenantID: zone.TenantID, ZoneID: zone.ID, Action: "CREATE_ZONE", Name: zone.Name})
 	return nil
 }
 
 func (s *dnsService) CreateRecord(ctx context.Context, record *domain.Record) error {
+	if err := CheckChangeFreeze(ctx, s.repo, record.TenantID, record.ZoneID, "CREATE_RECORD "+record.Name); err != nil {
+		return err
+	}
+	if err := checkRecordView(ctx, s.repo, record.TenantID, record); err != nil {
+		return err
+	}
+	if err := checkRecordTarget(ctx, s.repo, record.TenantID, record); err != nil {
+		return err
+	}
+	if err := checkRecordHealthCheck(ctx, s.repo, record.TenantID, record); err != nil {
+		return err
+	}
+	if record.Geo != nil {
+		if err := domain.ValidateGeoPolicy(record.Geo); err != nil {
+			return err
+		}
+	}
+	if err := CheckChangeReview(ctx, s.repo, record.TenantID, record.ZoneID, "CREATE_RECORD "+record.Name); err != nil {
+		return err
+	}
 	record.ID = uuid.New().String()
 	record.CreatedAt = time.Now()
 	record.UpdatedAt = time.Now()
+	record.Name = dnsname.Fqdn(record.Name)
 
 	if record.TTL < 60 {
 		record.TTL = 60
@@ -97,9 +144,19 @@ func (s *dnsService) CreateRecord(ctx context.Context, record *domain.Record) er
 	}
 
 	s.audit(ctx, "unknown", "CREATE_RECORD", "RECORD", record.ID, fmt.Sprintf("Created %s record for %s", record.Type, record.Name))
+	s.publish(domain.Event{Type: domain.EventRecordChange, TenantID: record.TenantID, ZoneID: record.ZoneID, Action: "CREATE_RECORD", Name: record.Name, RecordType: record.Type})
 	return nil
 }
 
+// publish emits a live event if an event publisher is configured.
+func (s *dnsService) publish(event domain.Event) {
+	if s.events == nil {
+		return
+	}
+	event.Timestamp = time.Now()
+	s.events.Publish(event)
+}
+
 func (s *dnsService) audit(ctx context.Context, tenantID, action, resType, resID, details string) {
 	logEntry := &domain.AuditLog{
 		ID:           uuid.New().String(),
@@ -114,6 +171,8 @@ func (s *dnsService) audit(ctx context.Context, tenantID, action, resType, resID
 }
 
 func (s *dnsService) Resolve(ctx context.Context, name string, qType domain.RecordType, clientIP string) ([]domain.Record, error) {
+	name = dnsname.Fqdn(name)
+
 	// 1. Direct Match
 	records, err := s.repo.GetRecords(ctx, name, qType, clientIP)
 	if err != nil {
@@ -127,9 +186,9 @@ func (s *dnsService) Resolve(ctx context.Context, name string, qType domain.Reco
 	// 2. Wildcard Matching (*.domain.com)
 	// We iteratively strip labels from the left and replace with '*'
 	// e.g. "a.b.example.com." -> "*.b.example.com." -> "*.example.com." -> "*.com."
-	labels := strings.Split(strings.TrimSuffix(name, "."), ".")
+	labels := dnsname.SplitLabels(name)
 	for i := 0; i < len(labels)-1; i++ {
-		wildcardName := "*." + strings.Join(labels[i+1:], ".") + "."
+		wildcardName := dnsname.Join(append([]string{"*"}, labels[i+1:]...))
 
 		wildcardRecords, err := s.repo.GetRecords(ctx, wildcardName, qType, clientIP)
 		if err != nil {
@@ -171,19 +230,38 @@ func (s *dnsService) ListRecordsForZone(ctx context.Context, zoneID string, tena
 }
 
 func (s *dnsService) DeleteZone(ctx context.Context, zoneID string, tenantID string) error {
+	if err := CheckChangeFreeze(ctx, s.repo, tenantID, zoneID, "DELETE_ZONE "+zoneID); err != nil {
+		return err
+	}
+	if err := CheckChangeReview(ctx, s.repo, tenantID, zoneID, "DELETE_ZONE "+zoneID); err != nil {
+		return err
+	}
 	if err := s.repo.DeleteZone(ctx, zoneID, tenantID); err != nil {
 		return err
 	}
 	s.audit(ctx, tenantID, "DELETE_ZONE", "ZONE", zoneID, "Deleted zone")
+	s.publish(domain.Event{Type: domain.EventZoneChange, TenantID: tenantID, ZoneID: zoneID, Action: "DELETE_ZONE"})
 	return nil
 }
 
 func (s *dnsService) DeleteRecord(ctx context.Context, recordID string, zoneID string, tenantID string) error {
+	if err := CheckChangeFreeze(ctx, s.repo, tenantID, zoneID, "DELETE_RECORD "+recordID); err != nil {
+		return err
+	}
+
 	// Fetch record details to invalidate the cache
 	record, err := s.repo.GetRecord(ctx, recordID, zoneID, tenantID)
 	if err != nil {
 		return fmt.Errorf("failed to fetch record before deletion: %w", err)
 	}
+	if record != nil {
+		if err := checkRecordDeletion(ctx, record); err != nil {
+			return err
+		}
+	}
+	if err := CheckChangeReview(ctx, s.repo, tenantID, zoneID, "DELETE_RECORD "+recordID); err != nil {
+		return err
+	}
 
 	if record != nil && s.cache != nil {
 		if errInv := s.cache.Invalidate(ctx, record.Name, record.Type); errInv != nil {
@@ -196,19 +274,50 @@ func (s *dnsService) DeleteRecord(ctx context.Context, recordID string, zoneID s
 	}
 
 	subject := "unknown"
+	event := domain.Event{Type: domain.EventRecordChange, TenantID: tenantID, ZoneID: zoneID, Action: "DELETE_RECORD"}
+	details := ""
 	if record != nil {
 		subject = record.Name
+		event.Name = record.Name
+		event.RecordType = record.Type
+		if record.Protected {
+			details = " (protected, forced)"
+		}
 	}
-	s.audit(ctx, tenantID, "DELETE_RECORD", "RECORD", recordID, fmt.Sprintf("Deleted record for %s", subject))
+	s.audit(ctx, tenantID, "DELETE_RECORD", "RECORD", recordID, fmt.Sprintf("Deleted record for %s%s", subject, details))
+	s.publish(event)
 	return nil
 }
 
-func (s *dnsService) ImportZone(ctx context.Context, tenantID string, r io.Reader) (*domain.Zone, error) {
+// ImportZone creates a zone from a master file. The file may be a template:
+// every {{name}} placeholder is replaced with the value vars gives for it before
+// the file is parsed, so one template can provision many environments. A
+// placeholder without a value, or a file that cannot be parsed, is an error
+// wrapping domain.ErrInvalidZoneImport.
+func (s *dnsService) ImportZone(ctx context.Context, tenantID string, r io.Reader, vars map[string]string) (*domain.Zone, error) {
+	r, used, err := expandZoneTemplate(r, vars)
+	if err != nil {
+		return nil, err
+	}
 	parser := master.NewMasterParser()
 	data, err := parser.Parse(r)
 	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidZoneImport, err)
+	}
+
+	if err := CheckChangeFreeze(ctx, s.repo, tenantID, "", "IMPORT_ZONE "+data.Zone.Name); err != nil {
 		return nil, err
 	}
+	// Re-importing a zone the tenant has replaces its records
+	existing, err := s.repo.GetZone(ctx, data.Zone.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up existing zone: %w", err)
+	}
+	if existing != nil && existing.TenantID == tenantID {
+		if err := CheckChangeReview(ctx, s.repo, tenantID, existing.ID, "IMPORT_ZONE "+data.Zone.Name); err != nil {
+			return nil, err
+		}
+	}
 
 	zone := &data.Zone
 	zone.ID = uuid.New().String()
@@ -224,14 +333,73 @@ func (s *dnsService) ImportZone(ctx context.Context, tenantID string, r io.Reade
 		data.Records[i].UpdatedAt = zone.UpdatedAt
 	}
 
+	details := fmt.Sprintf("Imported zone %s with %d records", zone.Name, len(data.Records))
+	if len(used) > 0 {
+		details += fmt.Sprintf("; template variables: %s", strings.Join(used, ", "))
+	}
+	bumped, err := s.preventSerialRegression(ctx, tenantID, zone.Name, data.Records)
+	if err != nil {
+		return nil, err
+	}
+	if bumped != "" {
+		details += "; " + bumped
+	}
+
 	if err := s.repo.CreateZoneWithRecords(ctx, zone, data.Records); err != nil {
 		return nil, err
 	}
 
-	s.audit(ctx, tenantID, "IMPORT_ZONE", "ZONE", zone.ID, fmt.Sprintf("Imported zone %s with %d records", zone.Name, len(data.Records)))
+	s.audit(ctx, tenantID, "IMPORT_ZONE", "ZONE", zone.ID, details)
+	s.publish(domain.Event{Type: domain.EventZoneChange, TenantID: tenantID, ZoneID: zone.ID, Action: "IMPORT_ZONE", Name: zone.Name})
 	return zone, nil
 }
 
+// preventSerialRegression makes sure re-importing a zone the tenant already has
+// does not move its serial backwards, which secondaries would ignore (RFC 1982).
+// An imported SOA serial that is not newer than the existing one is raised to
+// the serial following it; the returned note describes the change.
+func (s *dnsService) preventSerialRegression(ctx context.Context, tenantID string, zoneName string, records []domain.Record) (string, error) {
+	existing, err := s.repo.GetZone(ctx, zoneName)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up existing zone: %w", err)
+	}
+	if existing == nil || existing.TenantID != tenantID {
+		return "", nil
+	}
+	current, err := s.repo.ListRecordsForZone(ctx, existing.ID, tenantID)
+	if err != nil {
+		return "", fmt.Errorf("failed to read existing zone: %w", err)
+	}
+
+	var currentSerial uint32
+	found := false
+	for _, rec := range current {
+		if rec.Type == domain.TypeSOA {
+			currentSerial, found = serial.FromSOA(rec.Content)
+			break
+		}
+	}
+	if !found {
+		return "", nil
+	}
+
+	for i := range records {
+		if records[i].Type != domain.TypeSOA || !dnsname.Equal(records[i].Name, zoneName) {
+			continue
+		}
+		imported, ok := serial.FromSOA(records[i].Content)
+		if !ok || serial.Greater(imported, currentSerial) {
+			return "", nil
+		}
+		next := serial.Next(currentSerial)
+		records[i].Content = serial.SetSOA(records[i].Content, next)
+		s.logger.Warn("imported SOA serial is not newer than the existing zone, raising it",
+			"zone", zoneName, "imported", imported, "existing", currentSerial, "serial", next)
+		return fmt.Sprintf("serial %d raised to %d above existing %d", imported, next, currentSerial), nil
+	}
+	return "", nil
+}
+
 // ListAuditLogs retrieves audit trail entries for a specific tenant.
 func (s *dnsService) ListAuditLogs(ctx context.Context, tenantID string) ([]domain.AuditLog, error) {
 	return s.repo.GetAuditLogs(ctx, tenantID)
@@ -3,179 +3,1204 @@ package services
 import (
 	"context"
 	"errors"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+	"github.com/poyrazK/cloudDNS/internal/dns/serial"
 )
 
 type mockRepo struct {
 	zones   []domain.Zone
 	records []domain.Record
+	nodes   []domain.Node
+	configs map[string]map[string]string
+	rolls   map[string]domain.Rollout
+	usage   []domain.ZoneUsage
+	keys    []domain.DNSSECKey
+	audits  []domain.AuditLog
+	changes []domain.ZoneChange
 	err     error
+
+	mu          sync.Mutex
+	secondaries []domain.SecondaryProvider
+	refreshes   map[string]domain.ZoneRefresh
+	scheduled   []domain.ScheduledChange
+	freezes     []domain.ChangeFreeze
+	shadows     map[string]domain.ShadowChange
+	slos        map[string]domain.ZoneSLO
+	limits      []domain.QueryLimit
+	views       []domain.View
+	defaults    []domain.DefaultRecord
+	targets     []domain.Target
+	checks      []domain.HealthCheck
+	subnets     []domain.Subnet
+	allocs      []domain.IPAllocation
+	reviewed    map[string]bool
+	requests    []domain.ChangeRequest
+}
+
+func (m *mockRepo) GetRecords(_ context.Context, name string, qType domain.RecordType, _ string) ([]domain.Record, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var res []domain.Record
+	for _, r := range m.records {
+		if r.Name == name && (qType == "" || r.Type == qType) {
+			res = append(res, r)
+		}
+	}
+	return res, nil
+}
+
+func (m *mockRepo) GetIPsForName(_ context.Context, name string, _ string) ([]string, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var res []string
+	for _, r := range m.records {
+		if r.Name == name && r.Type == domain.TypeA {
+			res = append(res, r.Content)
+		}
+	}
+	return res, nil
+}
+
+func (m *mockRepo) GetZone(_ context.Context, name string) (*domain.Zone, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	for _, z := range m.zones {
+		if z.Name == name {
+			return &z, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockRepo) GetZoneByID(_ context.Context, zoneID string, tenantID string) (*domain.Zone, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	for _, z := range m.zones {
+		if z.ID == zoneID && (tenantID == "" || z.TenantID == tenantID) {
+			return &z, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockRepo) GetRecord(_ context.Context, id string, zoneID string, tenantID string) (*domain.Record, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	for _, r := range m.records {
+		if r.ID == id && r.ZoneID == zoneID {
+			return &r, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockRepo) NameExists(_ context.Context, zoneID string, name string) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+	for _, r := range m.records {
+		if r.ZoneID == zoneID && dnsname.IsSubdomain(r.Name, name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockRepo) ListRecordsForZone(_ context.Context, zoneID string, tenantID string) ([]domain.Record, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var res []domain.Record
+	for _, r := range m.records {
+		if r.ZoneID == zoneID {
+			res = append(res, r)
+		}
+	}
+	return res, nil
+}
+
+func (m *mockRepo) CreateZone(_ context.Context, zone *domain.Zone) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.zones = append(m.zones, *zone)
+	return nil
+}
+
+func (m *mockRepo) CreateZoneWithRecords(_ context.Context, zone *domain.Zone, records []domain.Record) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.zones = append(m.zones, *zone)
+	m.records = append(m.records, records...)
+	return nil
+}
+
+func (m *mockRepo) CreateRecord(_ context.Context, record *domain.Record) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.records = append(m.records, *record)
+	return nil
+}
+
+func (m *mockRepo) BatchCreateRecords(_ context.Context, records []domain.Record) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.records = append(m.records, records...)
+	return nil
+}
+
+func (m *mockRepo) HasZones(_ context.Context) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+	return len(m.zones) > 0, nil
+}
+
+func (m *mockRepo) ListZones(_ context.Context, _ string) ([]domain.Zone, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.zones, nil
+}
+
+func (m *mockRepo) DeleteZone(_ context.Context, _, _ string) error { return m.err }
+
+func (m *mockRepo) DeleteRecord(_ context.Context, recordID, zoneID, _ string) error {
+	if m.err != nil {
+		return m.err
+	}
+	for i, r := range m.records {
+		if r.ID == recordID && r.ZoneID == zoneID {
+			m.records = append(m.records[:i], m.records[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (m *mockRepo) SetRecordProtected(_ context.Context, recordID, zoneID, _ string, protected bool) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+	for i := range m.records {
+		if m.records[i].ID == recordID && m.records[i].ZoneID == zoneID {
+			m.records[i].Protected = protected
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockRepo) SetZoneDNSSEC(_ context.Context, zoneID, tenantID string, enabled bool) error {
+	if m.err != nil {
+		return m.err
+	}
+	for i := range m.zones {
+		if m.zones[i].ID == zoneID && m.zones[i].TenantID == tenantID {
+			m.zones[i].DNSSECEnabled = &enabled
+		}
+	}
+	return nil
+}
+
+func (m *mockRepo) SetZoneRole(_ context.Context, zoneID, tenantID, role, masterServer string) error {
+	if m.err != nil {
+		return m.err
+	}
+	for i := range m.zones {
+		if m.zones[i].ID == zoneID && m.zones[i].TenantID == tenantID {
+			m.zones[i].Role = role
+			m.zones[i].MasterServer = masterServer
+		}
+	}
+	return nil
+}
+
+func (m *mockRepo) DeleteRecordsByNameAndType(_ context.Context, zoneID, name string, qType domain.RecordType) error {
+	if m.err != nil {
+		return m.err
+	}
+	var next []domain.Record
+	for _, r := range m.records {
+		if r.ZoneID == zoneID && r.Name == name && r.Type == qType {
+			continue
+		}
+		next = append(next, r)
+	}
+	m.records = next
+	return nil
+}
+
+func (m *mockRepo) DeleteRecordsByName(_ context.Context, _, _ string) error {
+	return m.err
+}
+
+func (m *mockRepo) DeleteRecordsForZone(_ context.Context, _ string) error {
+	return m.err
+}
+
+func (m *mockRepo) ReplaceZoneRecords(_ context.Context, zoneID string, records []domain.Record) error {
+	if m.err != nil {
+		return m.err
+	}
+	var kept []domain.Record
+	for _, r := range m.records {
+		if r.ZoneID != zoneID {
+			kept = append(kept, r)
+		}
+	}
+	m.records = append(kept, records...)
+	return nil
+}
+
+func (m *mockRepo) ApplyZoneDiff(_ context.Context, _ string, _ []domain.IXFRChunk) error {
+	return m.err
+}
+
+func (m *mockRepo) DeleteRecordSpecific(_ context.Context, _, _ string, _ domain.RecordType, _ string) error {
+	return m.err
+}
+
+func (m *mockRepo) ReplaceRRset(ctx context.Context, zoneID, name string, qType domain.RecordType, records []domain.Record) error {
+	if err := m.DeleteRecordsByNameAndType(ctx, zoneID, name, qType); err != nil {
+		return err
+	}
+	m.records = append(m.records, records...)
+	return nil
+}
+
+func (m *mockRepo) RecordZoneChange(_ context.Context, _ *domain.ZoneChange) error {
+	return m.err
+}
+
+func (m *mockRepo) ListZoneChanges(_ context.Context, zoneID string, fromSerial uint32) ([]domain.ZoneChange, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var res []domain.ZoneChange
+	for _, c := range m.changes {
+		if c.ZoneID == zoneID && serial.Greater(c.Serial, fromSerial) {
+			res = append(res, c)
+		}
+	}
+	return res, nil
+}
+
+func (m *mockRepo) LastZoneChangeAt(_ context.Context, zoneID string) (*time.Time, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var last *time.Time
+	for i := range m.changes {
+		if c := &m.changes[i]; c.ZoneID == zoneID && (last == nil || c.CreatedAt.After(*last)) {
+			t := c.CreatedAt
+			last = &t
+		}
+	}
+	return last, nil
+}
+
+func (m *mockRepo) GetIXFRChain(_ context.Context, _ string, _, _ uint32) ([]domain.IXFRChunk, error) {
+	return nil, m.err
+}
+
+func (m *mockRepo) SaveAuditLog(_ context.Context, log *domain.AuditLog) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.audits = append(m.audits, *log)
+	return nil
+}
+func (m *mockRepo) GetAuditLogs(_ context.Context, _ string) ([]domain.AuditLog, error) {
+	return nil, m.err
+}
+func (m *mockRepo) Ping(_ context.Context) error { return m.err }
+
+func (m *mockRepo) CreateKey(_ context.Context, key *domain.DNSSECKey) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.keys = append(m.keys, *key)
+	return nil
+}
+func (m *mockRepo) ListKeysForZone(_ context.Context, zoneID string) ([]domain.DNSSECKey, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var res []domain.DNSSECKey
+	for _, k := range m.keys {
+		if k.ZoneID == zoneID {
+			res = append(res, k)
+		}
+	}
+	return res, nil
+}
+func (m *mockRepo) UpdateKey(_ context.Context, key *domain.DNSSECKey) error {
+	if m.err != nil {
+		return m.err
+	}
+	for i := range m.keys {
+		if m.keys[i].ID == key.ID {
+			m.keys[i] = *key
+		}
+	}
+	return nil
+}
+
+func (m *mockRepo) GetAPIKeyByHash(_ context.Context, _ string) (*domain.APIKey, error) {
+	return nil, m.err
+}
+func (m *mockRepo) CreateAPIKey(_ context.Context, _ *domain.APIKey) error { return m.err }
+func (m *mockRepo) ListAPIKeys(_ context.Context, _ string) ([]domain.APIKey, error) {
+	return nil, m.err
+}
+func (m *mockRepo) DeleteAPIKey(_ context.Context, _ string, _ string) error { return m.err }
+
+func (m *mockRepo) GetRecordsToProbe(_ context.Context) ([]domain.Record, error) {
+	return nil, m.err
+}
+
+func (m *mockRepo) UpdateRecordHealth(_ context.Context, _ string, _ domain.HealthStatus, _ string) error {
+	return m.err
+}
+
+func (m *mockRepo) UpsertNode(_ context.Context, node *domain.Node) error {
+	if m.err != nil {
+		return m.err
+	}
+	for i := range m.nodes {
+		if m.nodes[i].ID == node.ID {
+			m.nodes[i] = *node
+			return nil
+		}
+	}
+	m.nodes = append(m.nodes, *node)
+	return nil
+}
+
+func (m *mockRepo) ListNodes(_ context.Context) ([]domain.Node, error) {
+	return m.nodes, m.err
+}
+
+func (m *mockRepo) GetNodeConfig(_ context.Context, nodeID string) (map[string]string, error) {
+	return m.configs[nodeID], m.err
+}
+
+func (m *mockRepo) SetNodeConfig(_ context.Context, nodeID string, cfg map[string]string) error {
+	if m.err != nil {
+		return m.err
+	}
+	if m.configs == nil {
+		m.configs = make(map[string]map[string]string)
+	}
+	m.configs[nodeID] = cfg
+	return nil
+}
+
+func (m *mockRepo) SaveRollout(_ context.Context, rollout *domain.Rollout) error {
+	if m.err != nil {
+		return m.err
+	}
+	if m.rolls == nil {
+		m.rolls = make(map[string]domain.Rollout)
+	}
+	m.rolls[rollout.ID] = *rollout
+	return nil
+}
+
+func (m *mockRepo) GetRollout(_ context.Context, id string) (*domain.Rollout, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	if r, ok := m.rolls[id]; ok {
+		return &r, nil
+	}
+	return nil, nil
+}
+
+func (m *mockRepo) AddZoneUsage(_ context.Context, usage []domain.ZoneUsage) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.usage = append(m.usage, usage...)
+	return nil
+}
+
+func (m *mockRepo) ListZoneUsage(_ context.Context, _ string, zoneID string, from, to time.Time) ([]domain.ZoneUsage, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var res []domain.ZoneUsage
+	for _, u := range m.usage {
+		if (zoneID == "" || u.ZoneID == zoneID) && !u.Hour.Before(from) && u.Hour.Before(to) {
+			res = append(res, u)
+		}
+	}
+	return res, nil
+}
+
+func (m *mockRepo) CreateSecondaryProvider(_ context.Context, p *domain.SecondaryProvider) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.secondaries = append(m.secondaries, *p)
+	return nil
+}
+
+func (m *mockRepo) ListSecondaryProviders(_ context.Context, zoneID string) ([]domain.SecondaryProvider, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []domain.SecondaryProvider
+	for _, p := range m.secondaries {
+		if zoneID == "" || p.ZoneID == zoneID {
+			res = append(res, p)
+		}
+	}
+	return res, nil
+}
+
+func (m *mockRepo) DeleteSecondaryProvider(_ context.Context, id string, zoneID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, p := range m.secondaries {
+		if p.ID == id && p.ZoneID == zoneID {
+			m.secondaries = append(m.secondaries[:i], m.secondaries[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (m *mockRepo) UpdateSecondaryProviderStatus(_ context.Context, p *domain.SecondaryProvider) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.secondaries {
+		if m.secondaries[i].ID == p.ID {
+			m.secondaries[i] = *p
+		}
+	}
+	return nil
+}
+
+func (m *mockRepo) SaveZoneRefresh(_ context.Context, refresh *domain.ZoneRefresh) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.refreshes == nil {
+		m.refreshes = make(map[string]domain.ZoneRefresh)
+	}
+	m.refreshes[refresh.ZoneID] = *refresh
+	return nil
+}
+
+func (m *mockRepo) GetZoneRefresh(_ context.Context, zoneID string) (*domain.ZoneRefresh, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	refresh, ok := m.refreshes[zoneID]
+	if !ok {
+		return nil, nil
+	}
+	return &refresh, nil
+}
+
+func (m *mockRepo) CreateScheduledChange(_ context.Context, c *domain.ScheduledChange) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.scheduled = append(m.scheduled, *c)
+	return nil
+}
+
+func (m *mockRepo) ListScheduledChanges(_ context.Context, zoneID string) ([]domain.ScheduledChange, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []domain.ScheduledChange
+	for _, c := range m.scheduled {
+		if c.ZoneID == zoneID {
+			res = append(res, c)
+		}
+	}
+	return res, nil
+}
+
+func (m *mockRepo) CancelScheduledChange(_ context.Context, id string, zoneID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.scheduled {
+		if m.scheduled[i].ID == id && m.scheduled[i].ZoneID == zoneID && m.scheduled[i].Status == domain.ScheduledPending {
+			m.scheduled[i].Status = domain.ScheduledCancelled
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockRepo) ClaimDueScheduledChanges(_ context.Context, now time.Time, limit int) ([]domain.ScheduledChange, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []domain.ScheduledChange
+	for i := range m.scheduled {
+		if len(res) < limit && m.scheduled[i].Status == domain.ScheduledPending && !m.scheduled[i].RunAt.After(now) {
+			m.scheduled[i].Status = domain.ScheduledRunning
+			res = append(res, m.scheduled[i])
+		}
+	}
+	return res, nil
+}
+
+func (m *mockRepo) FinishScheduledChange(_ context.Context, c *domain.ScheduledChange) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.scheduled {
+		if m.scheduled[i].ID == c.ID {
+			m.scheduled[i] = *c
+		}
+	}
+	return nil
+}
+
+func (m *mockRepo) CreateChangeFreeze(_ context.Context, f *domain.ChangeFreeze) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.freezes = append(m.freezes, *f)
+	return nil
+}
+
+func (m *mockRepo) ListChangeFreezes(_ context.Context, tenantID string) ([]domain.ChangeFreeze, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []domain.ChangeFreeze
+	for _, f := range m.freezes {
+		if f.TenantID == tenantID {
+			res = append(res, f)
+		}
+	}
+	return res, nil
+}
+
+func (m *mockRepo) DeleteChangeFreeze(_ context.Context, id string, tenantID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, f := range m.freezes {
+		if f.ID == id && f.TenantID == tenantID {
+			m.freezes = append(m.freezes[:i], m.freezes[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockRepo) SaveShadowChange(_ context.Context, change *domain.ShadowChange) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.shadows == nil {
+		m.shadows = make(map[string]domain.ShadowChange)
+	}
+	m.shadows[change.ZoneID] = *change
+	return nil
+}
+
+func (m *mockRepo) GetShadowChange(_ context.Context, zoneID string) (*domain.ShadowChange, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if change, ok := m.shadows[zoneID]; ok {
+		return &change, nil
+	}
+	return nil, nil
+}
+
+func (m *mockRepo) ListRecordNetworks(_ context.Context) ([]string, error) {
+	return nil, m.err
+}
+
+func (m *mockRepo) ListRecordGeoScopes(_ context.Context) ([]string, error) {
+	return nil, m.err
+}
+
+func (m *mockRepo) ListShadowChanges(_ context.Context) ([]domain.ShadowChange, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var changes []domain.ShadowChange
+	for _, change := range m.shadows {
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+func (m *mockRepo) DeleteShadowChange(_ context.Context, zoneID string) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.shadows[zoneID]
+	delete(m.shadows, zoneID)
+	return ok, nil
 }
 
-func (m *mockRepo) GetRecords(_ context.Context, name string, qType domain.RecordType, _ string) ([]domain.Record, error) {
+func (m *mockRepo) SaveZoneSLO(_ context.Context, slo *domain.ZoneSLO) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.slos == nil {
+		m.slos = make(map[string]domain.ZoneSLO)
+	}
+	m.slos[slo.ZoneID] = *slo
+	return nil
+}
+
+func (m *mockRepo) GetZoneSLO(_ context.Context, zoneID string) (*domain.ZoneSLO, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
-	var res []domain.Record
-	for _, r := range m.records {
-		if r.Name == name && (qType == "" || r.Type == qType) {
-			res = append(res, r)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if slo, ok := m.slos[zoneID]; ok {
+		return &slo, nil
+	}
+	return nil, nil
+}
+
+func (m *mockRepo) ListZoneSLOs(_ context.Context) ([]domain.ZoneSLO, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var slos []domain.ZoneSLO
+	for _, slo := range m.slos {
+		slos = append(slos, slo)
+	}
+	return slos, nil
+}
+
+func (m *mockRepo) DeleteZoneSLO(_ context.Context, zoneID string) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.slos[zoneID]
+	delete(m.slos, zoneID)
+	return ok, nil
+}
+
+func (m *mockRepo) SaveQueryLimit(_ context.Context, limit *domain.QueryLimit) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.limits {
+		if m.limits[i].TenantID == limit.TenantID && m.limits[i].ZoneID == limit.ZoneID {
+			m.limits[i] = *limit
+			return nil
 		}
 	}
-	return res, nil
+	m.limits = append(m.limits, *limit)
+	return nil
 }
 
-func (m *mockRepo) GetIPsForName(_ context.Context, name string, _ string) ([]string, error) {
+func (m *mockRepo) ListQueryLimits(_ context.Context, tenantID string) ([]domain.QueryLimit, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
-	var res []string
-	for _, r := range m.records {
-		if r.Name == name && r.Type == domain.TypeA {
-			res = append(res, r.Content)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var limits []domain.QueryLimit
+	for _, l := range m.limits {
+		if tenantID == "" || l.TenantID == tenantID {
+			limits = append(limits, l)
 		}
 	}
-	return res, nil
+	return limits, nil
 }
 
-func (m *mockRepo) GetZone(_ context.Context, name string) (*domain.Zone, error) {
+func (m *mockRepo) DeleteQueryLimit(_ context.Context, tenantID string, zoneID string) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, l := range m.limits {
+		if l.TenantID == tenantID && l.ZoneID == zoneID {
+			m.limits = append(m.limits[:i], m.limits[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockRepo) SaveView(_ context.Context, view *domain.View) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.views {
+		if m.views[i].ID == view.ID {
+			m.views[i] = *view
+			return nil
+		}
+	}
+	m.views = append(m.views, *view)
+	return nil
+}
+
+func (m *mockRepo) ListViews(_ context.Context, tenantID string) ([]domain.View, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
-	for _, z := range m.zones {
-		if z.Name == name {
-			return &z, nil
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var views []domain.View
+	for _, v := range m.views {
+		if tenantID == "" || v.TenantID == tenantID {
+			views = append(views, v)
 		}
 	}
-	return nil, nil
+	sort.SliceStable(views, func(i, j int) bool { return views[i].Priority < views[j].Priority })
+	return views, nil
 }
 
-func (m *mockRepo) GetRecord(_ context.Context, id string, zoneID string, tenantID string) (*domain.Record, error) {
+func (m *mockRepo) DeleteView(_ context.Context, id string, tenantID string) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, v := range m.views {
+		if v.ID == id && v.TenantID == tenantID {
+			m.views = append(m.views[:i], m.views[i+1:]...)
+			kept := m.records[:0]
+			for _, r := range m.records {
+				if r.ViewID == nil || *r.ViewID != id {
+					kept = append(kept, r)
+				}
+			}
+			m.records = kept
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockRepo) SaveDefaultRecord(_ context.Context, rec *domain.DefaultRecord) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.defaults = append(m.defaults, *rec)
+	return nil
+}
+
+func (m *mockRepo) ListDefaultRecords(_ context.Context, zoneID string) ([]domain.DefaultRecord, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
-	for _, r := range m.records {
-		if r.ID == id && r.ZoneID == zoneID {
-			return &r, nil
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []domain.DefaultRecord
+	for _, d := range m.defaults {
+		if zoneID == "" || d.ZoneID == zoneID {
+			res = append(res, d)
 		}
 	}
-	return nil, nil
+	return res, nil
 }
 
-func (m *mockRepo) ListRecordsForZone(_ context.Context, zoneID string, tenantID string) ([]domain.Record, error) {
+func (m *mockRepo) DeleteDefaultRecord(_ context.Context, id string, zoneID string) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, d := range m.defaults {
+		if d.ID == id && d.ZoneID == zoneID {
+			m.defaults = append(m.defaults[:i], m.defaults[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockRepo) SaveTarget(_ context.Context, target *domain.Target) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.targets {
+		if m.targets[i].ID == target.ID {
+			m.targets[i] = *target
+			return nil
+		}
+	}
+	m.targets = append(m.targets, *target)
+	return nil
+}
+
+func (m *mockRepo) ListTargets(_ context.Context, tenantID string) ([]domain.Target, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []domain.Target
+	for _, t := range m.targets {
+		if tenantID == "" || t.TenantID == tenantID {
+			res = append(res, t)
+		}
+	}
+	return res, nil
+}
+
+func (m *mockRepo) DeleteTarget(_ context.Context, id string, tenantID string) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, t := range m.targets {
+		if t.ID == id && t.TenantID == tenantID {
+			m.targets = append(m.targets[:i], m.targets[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockRepo) ListTargetRecords(_ context.Context, targetID string) ([]domain.Record, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	var res []domain.Record
 	for _, r := range m.records {
-		if r.ZoneID == zoneID {
+		if r.TargetID != nil && *r.TargetID == targetID {
 			res = append(res, r)
 		}
 	}
 	return res, nil
 }
 
-func (m *mockRepo) CreateZone(_ context.Context, zone *domain.Zone) error {
+func (m *mockRepo) SaveHealthCheck(_ context.Context, check *domain.HealthCheck) error {
 	if m.err != nil {
 		return m.err
 	}
-	m.zones = append(m.zones, *zone)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.checks {
+		if m.checks[i].ID == check.ID {
+			m.checks[i] = *check
+			return nil
+		}
+	}
+	m.checks = append(m.checks, *check)
 	return nil
 }
 
-func (m *mockRepo) CreateZoneWithRecords(_ context.Context, zone *domain.Zone, records []domain.Record) error {
+func (m *mockRepo) ListHealthChecks(_ context.Context, tenantID string) ([]domain.HealthCheck, error) {
 	if m.err != nil {
-		return m.err
+		return nil, m.err
 	}
-	m.zones = append(m.zones, *zone)
-	m.records = append(m.records, records...)
-	return nil
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []domain.HealthCheck
+	for _, c := range m.checks {
+		if tenantID == "" || c.TenantID == tenantID {
+			res = append(res, c)
+		}
+	}
+	return res, nil
 }
 
-func (m *mockRepo) CreateRecord(_ context.Context, record *domain.Record) error {
+func (m *mockRepo) DeleteHealthCheck(_ context.Context, id string, tenantID string) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, c := range m.checks {
+		if c.ID == id && c.TenantID == tenantID {
+			m.checks = append(m.checks[:i], m.checks[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockRepo) UpdateHealthCheckStatus(_ context.Context, id string, status domain.HealthStatus, errMsg string) error {
 	if m.err != nil {
 		return m.err
 	}
-	m.records = append(m.records, *record)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.checks {
+		if m.checks[i].ID == id {
+			m.checks[i].Status = status
+			m.checks[i].LastError = errMsg
+		}
+	}
 	return nil
 }
 
-func (m *mockRepo) BatchCreateRecords(_ context.Context, records []domain.Record) error {
+func (m *mockRepo) ListHealthCheckRecords(_ context.Context, checkID string) ([]domain.Record, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []domain.Record
+	for _, r := range m.records {
+		if r.HealthCheckID != nil && *r.HealthCheckID == checkID {
+			res = append(res, r)
+		}
+	}
+	return res, nil
+}
+
+func (m *mockRepo) SaveSubnet(_ context.Context, subnet *domain.Subnet) error {
 	if m.err != nil {
 		return m.err
 	}
-	m.records = append(m.records, records...)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subnets = append(m.subnets, *subnet)
 	return nil
 }
 
-func (m *mockRepo) ListZones(_ context.Context, _ string) ([]domain.Zone, error) {
+func (m *mockRepo) ListSubnets(_ context.Context, tenantID string) ([]domain.Subnet, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
-	return m.zones, nil
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []domain.Subnet
+	for _, s := range m.subnets {
+		if tenantID == "" || s.TenantID == tenantID {
+			res = append(res, s)
+		}
+	}
+	return res, nil
 }
 
-func (m *mockRepo) DeleteZone(_ context.Context, _, _ string) error      { return m.err }
-func (m *mockRepo) DeleteRecord(_ context.Context, _, _, _ string) error { return m.err }
-
-func (m *mockRepo) DeleteRecordsByNameAndType(_ context.Context, _, _ string, _ domain.RecordType) error {
-	return m.err
+func (m *mockRepo) DeleteSubnet(_ context.Context, id string, tenantID string) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, s := range m.subnets {
+		if s.ID == id && s.TenantID == tenantID {
+			m.subnets = append(m.subnets[:i], m.subnets[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
-func (m *mockRepo) DeleteRecordsByName(_ context.Context, _, _ string) error {
-	return m.err
+func (m *mockRepo) SaveIPAllocation(_ context.Context, alloc *domain.IPAllocation) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, a := range m.allocs {
+		if a.SubnetID == alloc.SubnetID && a.Address == alloc.Address {
+			return errors.New("duplicate address")
+		}
+	}
+	m.allocs = append(m.allocs, *alloc)
+	return nil
 }
 
-func (m *mockRepo) DeleteRecordsForZone(_ context.Context, _ string) error {
-	return m.err
+func (m *mockRepo) ListIPAllocations(_ context.Context, subnetID string) ([]domain.IPAllocation, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []domain.IPAllocation
+	for _, a := range m.allocs {
+		if a.SubnetID == subnetID {
+			res = append(res, a)
+		}
+	}
+	return res, nil
 }
 
-func (m *mockRepo) DeleteRecordSpecific(_ context.Context, _, _ string, _ domain.RecordType, _ string) error {
-	return m.err
+func (m *mockRepo) DeleteIPAllocation(_ context.Context, id string, subnetID string) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, a := range m.allocs {
+		if a.ID == id && a.SubnetID == subnetID {
+			m.allocs = append(m.allocs[:i], m.allocs[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
-func (m *mockRepo) RecordZoneChange(_ context.Context, _ *domain.ZoneChange) error {
-	return m.err
+func (m *mockRepo) GetActiveChangeFreeze(_ context.Context, tenantID string, zoneID string, at time.Time) (*domain.ChangeFreeze, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, f := range m.freezes {
+		if f.TenantID == tenantID && (f.ZoneID == "" || f.ZoneID == zoneID) && f.Active(at) {
+			return &f, nil
+		}
+	}
+	return nil, nil
 }
 
-func (m *mockRepo) ListZoneChanges(_ context.Context, _ string, _ uint32) ([]domain.ZoneChange, error) {
-	return nil, m.err
+func (m *mockRepo) SetZoneReview(_ context.Context, zoneID string, _ string, required bool) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.reviewed == nil {
+		m.reviewed = make(map[string]bool)
+	}
+	m.reviewed[zoneID] = required
+	return nil
 }
 
-func (m *mockRepo) GetIXFRChain(_ context.Context, _ string, _, _ uint32) ([]domain.IXFRChunk, error) {
-	return nil, m.err
+func (m *mockRepo) IsZoneReviewed(_ context.Context, zoneID string) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.reviewed[zoneID], nil
 }
 
-func (m *mockRepo) SaveAuditLog(_ context.Context, _ *domain.AuditLog) error { return m.err }
-func (m *mockRepo) GetAuditLogs(_ context.Context, _ string) ([]domain.AuditLog, error) {
-	return nil, m.err
+func (m *mockRepo) CreateChangeRequest(_ context.Context, c *domain.ChangeRequest) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests = append(m.requests, *c)
+	return nil
 }
-func (m *mockRepo) Ping(_ context.Context) error { return m.err }
 
-func (m *mockRepo) CreateKey(_ context.Context, _ *domain.DNSSECKey) error { return m.err }
-func (m *mockRepo) ListKeysForZone(_ context.Context, _ string) ([]domain.DNSSECKey, error) {
-	return nil, m.err
+func (m *mockRepo) ListChangeRequests(_ context.Context, zoneID string) ([]domain.ChangeRequest, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []domain.ChangeRequest
+	for _, c := range m.requests {
+		if c.ZoneID == zoneID {
+			res = append(res, c)
+		}
+	}
+	return res, nil
 }
-func (m *mockRepo) UpdateKey(_ context.Context, _ *domain.DNSSECKey) error { return m.err }
 
-func (m *mockRepo) GetAPIKeyByHash(_ context.Context, _ string) (*domain.APIKey, error) {
-	return nil, m.err
+func (m *mockRepo) GetChangeRequest(_ context.Context, id string, zoneID string) (*domain.ChangeRequest, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, c := range m.requests {
+		if c.ID == id && c.ZoneID == zoneID {
+			return &c, nil
+		}
+	}
+	return nil, nil
 }
-func (m *mockRepo) CreateAPIKey(_ context.Context, _ *domain.APIKey) error { return m.err }
-func (m *mockRepo) ListAPIKeys(_ context.Context, _ string) ([]domain.APIKey, error) {
-	return nil, m.err
+
+func (m *mockRepo) ReviewChangeRequest(_ context.Context, c *domain.ChangeRequest) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.requests {
+		if m.requests[i].ID == c.ID && m.requests[i].ZoneID == c.ZoneID && m.requests[i].Status == domain.ChangePending {
+			m.requests[i] = *c
+			return true, nil
+		}
+	}
+	return false, nil
 }
-func (m *mockRepo) DeleteAPIKey(_ context.Context, _ string, _ string) error { return m.err }
 
-func (m *mockRepo) GetRecordsToProbe(_ context.Context) ([]domain.Record, error) {
-	return nil, m.err
+func (m *mockRepo) FinishChangeRequest(_ context.Context, c *domain.ChangeRequest) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.requests {
+		if m.requests[i].ID == c.ID {
+			m.requests[i] = *c
+		}
+	}
+	return nil
 }
 
-func (m *mockRepo) UpdateRecordHealth(_ context.Context, _ string, _ domain.HealthStatus, _ string) error {
-	return m.err
+func (m *mockRepo) ListRollouts(_ context.Context) ([]domain.Rollout, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var res []domain.Rollout
+	for _, r := range m.rolls {
+		res = append(res, r)
+	}
+	return res, nil
 }
 
 func TestCreateZone(t *testing.T) {
@@ -252,7 +1277,7 @@ $TTL 3600
 www IN  A   1.2.3.4
 `
 	ctx := context.Background()
-	zone, err := svc.ImportZone(ctx, "t1", strings.NewReader(zoneFile))
+	zone, err := svc.ImportZone(ctx, "t1", strings.NewReader(zoneFile), nil)
 	if err != nil {
 		t.Fatalf("ImportZone failed: %v", err)
 	}
@@ -267,13 +1292,55 @@ www IN  A   1.2.3.4
 	}
 }
 
+func TestImportZone_SerialRegression(t *testing.T) {
+	repo := &mockRepo{
+		zones:   []domain.Zone{{ID: "z1", TenantID: "t1", Name: "import.test."}},
+		records: []domain.Record{{ID: "soa", ZoneID: "z1", Name: "import.test.", Type: domain.TypeSOA, Content: "ns1.import.test. admin.import.test. 4294967295 2 3 4 5"}},
+	}
+	svc := NewDNSService(repo, nil)
+
+	importSOA := func(zoneSerial string) string {
+		t.Helper()
+		zoneFile := "$ORIGIN import.test.\n$TTL 3600\n@ IN SOA ns1.import.test. admin.import.test. " + zoneSerial + " 2 3 4 5\n"
+		zone, err := svc.ImportZone(context.Background(), "t1", strings.NewReader(zoneFile), nil)
+		if err != nil {
+			t.Fatalf("ImportZone failed: %v", err)
+		}
+		for _, rec := range repo.records {
+			if rec.ZoneID == zone.ID && rec.Type == domain.TypeSOA {
+				return strings.Fields(rec.Content)[2]
+			}
+		}
+		t.Fatal("imported zone has no SOA")
+		return ""
+	}
+
+	// An older serial is raised past the existing one
+	repo.records[0].Content = "ns1.import.test. admin.import.test. 1000 2 3 4 5"
+	if got := importSOA("100"); got != "1001" {
+		t.Errorf("Expected the serial raised to 1001, got %s", got)
+	}
+
+	// Comparisons wrap: 5 is newer than 4294967295 and is kept, while
+	// 4294967290 is older and raised to the wrapped successor
+	repo.records[0].Content = "ns1.import.test. admin.import.test. 4294967295 2 3 4 5"
+	repo.zones = repo.zones[:1]
+	if got := importSOA("5"); got != "5" {
+		t.Errorf("Expected a newer serial kept, got %s", got)
+	}
+	repo.zones = repo.zones[:1]
+	if got := importSOA("4294967290"); got != "0" {
+		t.Errorf("Expected the serial raised to 0, got %s", got)
+	}
+}
+
 func TestImportZone_Error(t *testing.T) {
 	repo := &mockRepo{}
 	svc := NewDNSService(repo, nil)
 
 	// Malformed (missing fields)
 	malformed := "$ORIGIN test.com.\nwww A"
-	_, err := svc.ImportZone(context.Background(), "t1", strings.NewReader(malformed))
+	_, err := svc.ImportZone(context.Background(), "t1", strings.NewReader(malformed), nil)
 
 	if err != nil {
 		t.Errorf("Expected skip/partial rather than fatal err, got %v", err)
@@ -347,6 +1414,24 @@ func TestListRecordsForZone(t *testing.T) {
 	}
 }
 
+func TestCreateRecordGeoPolicy(t *testing.T) {
+	repo := &mockRepo{}
+	svc := NewDNSService(repo, nil)
+	ctx := context.Background()
+
+	bad := &domain.Record{ZoneID: "z1", Name: "geo.test.", Type: domain.TypeA, Content: "192.0.2.1", Geo: &domain.GeoPolicy{Countries: []string{"Germany"}}}
+	if err := svc.CreateRecord(ctx, bad); !errors.Is(err, domain.ErrInvalidGeoPolicy) {
+		t.Errorf("Expected ErrInvalidGeoPolicy, got %v", err)
+	}
+	rec := &domain.Record{ZoneID: "z1", Name: "geo.test.", Type: domain.TypeA, Content: "192.0.2.1", Geo: &domain.GeoPolicy{Countries: []string{"de"}}}
+	if err := svc.CreateRecord(ctx, rec); err != nil {
+		t.Fatalf("CreateRecord failed: %v", err)
+	}
+	if len(repo.records) != 1 || repo.records[0].Geo.Countries[0] != "DE" {
+		t.Errorf("Expected the record stored with its normalized policy, got %+v", repo.records)
+	}
+}
+
 func TestServiceErrorPaths(t *testing.T) {
 	repo := &mockRepo{err: errors.New("db error")}
 	svc := NewDNSService(repo, nil)
@@ -370,7 +1455,7 @@ func TestServiceErrorPaths(t *testing.T) {
 	if err := svc.DeleteRecord(ctx, "r1", "", ""); err == nil {
 		t.Errorf("Expected error in DeleteRecord")
 	}
-	if _, err := svc.ImportZone(ctx, "", strings.NewReader("")); err == nil {
+	if _, err := svc.ImportZone(ctx, "", strings.NewReader(""), nil); err == nil {
 		t.Errorf("Expected error in ImportZone")
 	}
 }
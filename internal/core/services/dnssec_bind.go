@@ -0,0 +1,245 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+// bindTimeFormat is the timestamp layout of BIND key metadata.
+const bindTimeFormat = "20060102150405"
+
+// bindKeyName returns the K<zone>+<alg>+<tag> base name BIND gives a key's files.
+func bindKeyName(zoneName string, algorithm int, keyTag uint16) string {
+	return fmt.Sprintf("K%s+%03d+%05d", zoneName, algorithm, keyTag)
+}
+
+// parseBINDPublicKey reads the DNSKEY record from a .key file.
+func parseBINDPublicKey(zoneName string, content string) (packet.DNSRecord, error) {
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+		fields := strings.Fields(line)
+		idx := -1
+		for i, f := range fields {
+			if strings.EqualFold(f, "DNSKEY") {
+				idx = i
+				break
+			}
+		}
+		if idx < 1 || len(fields) < idx+5 {
+			return packet.DNSRecord{}, fmt.Errorf("%w: malformed DNSKEY record in .key file", domain.ErrInvalidKeyOperation)
+		}
+		if !strings.EqualFold(strings.TrimSuffix(fields[0], ".")+".", zoneName) {
+			return packet.DNSRecord{}, fmt.Errorf("%w: key is for %s, not %s", domain.ErrInvalidKeyOperation, fields[0], zoneName)
+		}
+		flags, errFlags := strconv.ParseUint(fields[idx+1], 10, 16)
+		protocol, errProto := strconv.ParseUint(fields[idx+2], 10, 8)
+		alg, errAlg := strconv.ParseUint(fields[idx+3], 10, 8)
+		pub, errPub := base64.StdEncoding.DecodeString(strings.Join(fields[idx+4:], ""))
+		if errFlags != nil || errProto != nil || errAlg != nil || errPub != nil || protocol != 3 {
+			return packet.DNSRecord{}, fmt.Errorf("%w: malformed DNSKEY record in .key file", domain.ErrInvalidKeyOperation)
+		}
+		return packet.DNSRecord{
+			Name:      zoneName,
+			Type:      packet.DNSKEY,
+			Class:     1,
+			Flags:     uint16(flags),
+			Algorithm: uint8(alg),
+			PublicKey: pub,
+		}, nil
+	}
+	return packet.DNSRecord{}, fmt.Errorf("%w: no DNSKEY record in .key file", domain.ErrInvalidKeyOperation)
+}
+
+// parseBINDPrivateKey reads the "Field: value" lines of a .private file.
+func parseBINDPrivateKey(content string) map[string]string {
+	fields := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(scanner.Text(), ":")
+		if ok {
+			fields[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+	}
+	return fields
+}
+
+// ImportKey stores a key from BIND's .key/.private file pair, so a zone migrating
+// from BIND keeps the DS published at its parent. Only ECDSAP256SHA256 keys are
+// supported, and the private key must match the DNSKEY.
+func (s *DNSSECService) ImportKey(ctx context.Context, zoneID string, tenantID string, files domain.DNSSECKeyFiles) (*domain.DNSSECKeyInfo, error) {
+	zone, err := s.tenantZone(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !zone.SignsDNSSEC() {
+		return nil, fmt.Errorf("%w: DNSSEC is disabled for zone %s", domain.ErrInvalidKeyOperation, zone.Name)
+	}
+
+	dnskey, err := parseBINDPublicKey(zone.Name, files.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	keyType := "ZSK"
+	switch dnskey.Flags {
+	case 257:
+		keyType = "KSK"
+	case 256:
+	default:
+		return nil, fmt.Errorf("%w: unsupported DNSKEY flags %d", domain.ErrInvalidKeyOperation, dnskey.Flags)
+	}
+	if dnskey.Algorithm != 13 {
+		return nil, fmt.Errorf("%w: unsupported algorithm %d, only 13 (ECDSAP256SHA256) is supported", domain.ErrInvalidKeyOperation, dnskey.Algorithm)
+	}
+
+	private := parseBINDPrivateKey(files.PrivateKey)
+	if !strings.HasPrefix(private["Private-key-format"], "v1.") {
+		return nil, fmt.Errorf("%w: unsupported private key format %q", domain.ErrInvalidKeyOperation, private["Private-key-format"])
+	}
+	if alg, _, _ := strings.Cut(private["Algorithm"], " "); alg != "13" {
+		return nil, fmt.Errorf("%w: private key algorithm %q does not match the DNSKEY", domain.ErrInvalidKeyOperation, private["Algorithm"])
+	}
+	scalar, err := base64.StdEncoding.DecodeString(private["PrivateKey"])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed PrivateKey", domain.ErrInvalidKeyOperation)
+	}
+	priv, err := ecdsa.ParseRawPrivateKey(elliptic.P256(), scalar)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid private key: %v", domain.ErrInvalidKeyOperation, err)
+	}
+	point, err := priv.PublicKey.Bytes()
+	if err != nil || string(point[1:]) != string(dnskey.PublicKey) {
+		return nil, fmt.Errorf("%w: private key does not match the DNSKEY", domain.ErrInvalidKeyOperation)
+	}
+
+	keys, err := s.repo.ListKeysForZone(ctx, zone.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		if string(dnskeyPublicKey(k.PublicKey)) == string(dnskey.PublicKey) {
+			return nil, fmt.Errorf("%w: key %s is already present", domain.ErrInvalidKeyOperation, k.ID)
+		}
+	}
+
+	privBytes, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode private key: %w", err)
+	}
+	pubBytes, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode public key: %w", err)
+	}
+	now := time.Now()
+	key := &domain.DNSSECKey{
+		ID:         uuid.New().String(),
+		ZoneID:     zone.ID,
+		KeyType:    keyType,
+		Algorithm:  13,
+		PrivateKey: privBytes,
+		PublicKey:  pubBytes,
+		Active:     true,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := s.repo.CreateKey(ctx, key); err != nil {
+		return nil, err
+	}
+
+	info := keyInfo(zone.Name, *key)
+	s.audit(ctx, tenantID, "IMPORT_DNSSEC_KEY", key.ID,
+		fmt.Sprintf("Imported %s %s for zone %s", keyType, bindKeyName(zone.Name, key.Algorithm, info.KeyTag), zone.Name))
+	return &info, nil
+}
+
+// ExportKey returns one of a zone's keys as a BIND .key/.private file pair for
+// disaster recovery. It fails with domain.ErrKeyExportDisabled unless
+// AllowKeyExport is set, and every export is audited.
+func (s *DNSSECService) ExportKey(ctx context.Context, zoneID string, tenantID string, keyID string) (*domain.DNSSECKeyFiles, error) {
+	if !s.AllowKeyExport {
+		return nil, domain.ErrKeyExportDisabled
+	}
+	zone, err := s.tenantZone(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := s.repo.ListKeysForZone(ctx, zone.ID)
+	if err != nil {
+		return nil, err
+	}
+	var key *domain.DNSSECKey
+	for i := range keys {
+		if keys[i].ID == keyID {
+			key = &keys[i]
+		}
+	}
+	if key == nil {
+		return nil, fmt.Errorf("key %s: %w", keyID, domain.ErrNotFound)
+	}
+	if key.External {
+		return nil, fmt.Errorf("%w: key %s belongs to another signer", domain.ErrInvalidKeyOperation, keyID)
+	}
+
+	priv, err := x509.ParseECPrivateKey(key.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode private key: %w", err)
+	}
+	scalar, err := priv.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode private key: %w", err)
+	}
+
+	info := keyInfo(zone.Name, *key)
+	role := "zone-signing"
+	if key.KeyType == "KSK" {
+		role = "key-signing"
+	}
+	created := key.CreatedAt.UTC().Format(bindTimeFormat)
+
+	var pub strings.Builder
+	fmt.Fprintf(&pub, "; This is a %s key, keyid %d, for %s\n", role, info.KeyTag, zone.Name)
+	fmt.Fprintf(&pub, "; Created: %s\n", created)
+	fmt.Fprintf(&pub, "%s IN DNSKEY %d 3 %d %s\n", zone.Name, info.Flags, key.Algorithm, base64.StdEncoding.EncodeToString(info.PublicKey))
+
+	var private strings.Builder
+	private.WriteString("Private-key-format: v1.3\n")
+	fmt.Fprintf(&private, "Algorithm: %d (ECDSAP256SHA256)\n", key.Algorithm)
+	fmt.Fprintf(&private, "PrivateKey: %s\n", base64.StdEncoding.EncodeToString(scalar))
+	fmt.Fprintf(&private, "Created: %s\nPublish: %s\nActivate: %s\n", created, created, created)
+	if !key.Active {
+		fmt.Fprintf(&private, "Inactive: %s\n", key.UpdatedAt.UTC().Format(bindTimeFormat))
+	}
+
+	name := bindKeyName(zone.Name, key.Algorithm, info.KeyTag)
+	s.audit(ctx, tenantID, "EXPORT_DNSSEC_KEY", key.ID, fmt.Sprintf("Exported %s %s for zone %s", key.KeyType, name, zone.Name))
+	return &domain.DNSSECKeyFiles{Name: name, PublicKey: pub.String(), PrivateKey: private.String()}, nil
+}
+
+// audit records a key management action. Like the zone service it does not fail
+// the operation if the audit log cannot be written.
+func (s *DNSSECService) audit(ctx context.Context, tenantID, action, keyID, details string) {
+	_ = s.repo.SaveAuditLog(ctx, &domain.AuditLog{
+		ID:           uuid.New().String(),
+		TenantID:     tenantID,
+		Action:       action,
+		ResourceType: "DNSSEC_KEY",
+		ResourceID:   keyID,
+		Details:      details,
+		CreatedAt:    time.Now(),
+	})
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// rfc6605Key is the ECDSAP256SHA256 example key from RFC 6605, Section 6.1, in the
+// file format written by BIND's dnssec-keygen.
+var rfc6605Key = domain.DNSSECKeyFiles{
+	PublicKey: "; This is a key-signing key, keyid 55648, for example.net.\n" +
+		"example.net. 3600 IN DNSKEY 257 3 13 GojIhhXUN/u4v54ZQqGSnyhWJwaubCvTmeexv7bR6edbkrSqQpF64cYbcB7wNcP+e+MAnLr+Wi9xMWyQLc8NAA==\n",
+	PrivateKey: "Private-key-format: v1.2\nAlgorithm: 13 (ECDSAP256SHA256)\nPrivateKey: GU6SnQ/Ou+xC5RumuIUIuJZteXT2z0O/ok1s38Et6mQ=\n",
+}
+
+func TestImportBINDKey(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepo{zones: []domain.Zone{{ID: "z1", TenantID: "t1", Name: "example.net."}}}
+	svc := NewDNSSECService(repo)
+
+	if _, err := svc.ImportKey(ctx, "z1", "other", rfc6605Key); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for another tenant, got %v", err)
+	}
+
+	key, err := svc.ImportKey(ctx, "z1", "t1", rfc6605Key)
+	if err != nil {
+		t.Fatalf("ImportKey failed: %v", err)
+	}
+	if key.KeyType != "KSK" || key.KeyTag != 55648 || !key.Active {
+		t.Errorf("Unexpected imported key %+v", key)
+	}
+
+	// The DS published for the key under BIND must not change
+	ds, err := svc.ZoneDS(ctx, "z1", "t1", []uint8{2})
+	if err != nil || len(ds) != 1 || ds[0].Digest != strings.ToUpper("b4c8c1fe2e7477127b27115656ad6256f424625bf5c1e2770ce6d6e37df61d17") {
+		t.Errorf("Expected the RFC 6605 DS, got %+v (%v)", ds, err)
+	}
+
+	if _, err := svc.ImportKey(ctx, "z1", "t1", rfc6605Key); !errors.Is(err, domain.ErrInvalidKeyOperation) {
+		t.Errorf("Expected duplicate import rejected, got %v", err)
+	}
+	if len(repo.audits) != 1 || repo.audits[0].Action != "IMPORT_DNSSEC_KEY" {
+		t.Errorf("Expected one import audit entry, got %+v", repo.audits)
+	}
+
+	bad := []domain.DNSSECKeyFiles{
+		{PublicKey: strings.Replace(rfc6605Key.PublicKey, "example.net.", "example.org.", 2), PrivateKey: rfc6605Key.PrivateKey},
+		{PublicKey: strings.Replace(rfc6605Key.PublicKey, " 13 ", " 8 ", 1), PrivateKey: rfc6605Key.PrivateKey},
+		{PublicKey: rfc6605Key.PublicKey, PrivateKey: strings.Replace(rfc6605Key.PrivateKey, "GU6S", "AU6S", 1)},
+		{PublicKey: rfc6605Key.PublicKey, PrivateKey: "Algorithm: 13\nPrivateKey: x\n"},
+	}
+	for i, files := range bad {
+		if _, err := svc.ImportKey(ctx, "z1", "t1", files); !errors.Is(err, domain.ErrInvalidKeyOperation) {
+			t.Errorf("case %d: expected ErrInvalidKeyOperation, got %v", i, err)
+		}
+	}
+}
+
+func TestExportBINDKey(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepo{zones: []domain.Zone{{ID: "z1", TenantID: "t1", Name: "example.net."}}}
+	svc := NewDNSSECService(repo)
+	imported, err := svc.ImportKey(ctx, "z1", "t1", rfc6605Key)
+	if err != nil {
+		t.Fatalf("ImportKey failed: %v", err)
+	}
+
+	if _, err := svc.ExportKey(ctx, "z1", "t1", imported.ID); !errors.Is(err, domain.ErrKeyExportDisabled) {
+		t.Errorf("Expected export disabled by default, got %v", err)
+	}
+
+	svc.AllowKeyExport = true
+	if _, err := svc.ExportKey(ctx, "z1", "other", imported.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for another tenant, got %v", err)
+	}
+	files, err := svc.ExportKey(ctx, "z1", "t1", imported.ID)
+	if err != nil {
+		t.Fatalf("ExportKey failed: %v", err)
+	}
+	if files.Name != "Kexample.net.+013+55648" {
+		t.Errorf("Unexpected key name %q", files.Name)
+	}
+	if !strings.Contains(files.PrivateKey, "PrivateKey: GU6SnQ/Ou+xC5RumuIUIuJZteXT2z0O/ok1s38Et6mQ=") ||
+		!strings.Contains(files.PublicKey, "example.net. IN DNSKEY 257 3 13 GojIhhXUN/u4v54ZQqGSnyhWJwaubCvTmeexv7bR6edbkrSqQpF64cYbcB7wNcP+e+MAnLr+Wi9xMWyQLc8NAA==") {
+		t.Errorf("Unexpected exported files %+v", files)
+	}
+	if last := repo.audits[len(repo.audits)-1]; last.Action != "EXPORT_DNSSEC_KEY" || last.ResourceID != imported.ID {
+		t.Errorf("Expected export audited, got %+v", last)
+	}
+
+	// An exported key imports cleanly into another deployment
+	other := &mockRepo{zones: []domain.Zone{{ID: "z9", TenantID: "t9", Name: "example.net."}}}
+	again, err := NewDNSSECService(other).ImportKey(ctx, "z9", "t9", *files)
+	if err != nil || again.KeyTag != 55648 {
+		t.Errorf("Expected exported files to round-trip, got %+v (%v)", again, err)
+	}
+}
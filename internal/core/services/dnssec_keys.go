@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+// dsTTL is the TTL suggested for DS records handed to the parent zone.
+const dsTTL = 3600
+
+// keyFlags returns the DNSKEY flags for a key type: 257 (SEP) for KSKs, 256 for ZSKs.
+func keyFlags(keyType string) uint16 {
+	if keyType == "KSK" {
+		return 257
+	}
+	return 256
+}
+
+// dnskeyPublicKey converts a stored PKIX public key to its DNSKEY wire form. For
+// ECDSA (RFC 6605) that is the uncompressed point without its 0x04 prefix; keys
+// that cannot be parsed are returned unchanged.
+func dnskeyPublicKey(pkix []byte) []byte {
+	pub, err := x509.ParsePKIXPublicKey(pkix)
+	if err != nil {
+		return pkix
+	}
+	ec, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return pkix
+	}
+	raw, err := ec.Bytes()
+	if err != nil {
+		return pkix
+	}
+	return raw[1:]
+}
+
+// dnskeyRecord builds the DNSKEY record a stored key is published and tagged as.
+func dnskeyRecord(zoneName string, key domain.DNSSECKey) packet.DNSRecord {
+	return packet.DNSRecord{
+		Name:      zoneName,
+		Type:      packet.DNSKEY,
+		Class:     1,
+		TTL:       dsTTL,
+		Flags:     keyFlags(key.KeyType),
+		Algorithm: uint8(key.Algorithm), // #nosec G115 -- DNSSEC algorithm numbers fit in a byte
+		PublicKey: dnskeyPublicKey(key.PublicKey),
+	}
+}
+
+func keyInfo(zoneName string, key domain.DNSSECKey) domain.DNSSECKeyInfo {
+	rec := dnskeyRecord(zoneName, key)
+	return domain.DNSSECKeyInfo{
+		ID:        key.ID,
+		ZoneID:    key.ZoneID,
+		KeyType:   key.KeyType,
+		Algorithm: key.Algorithm,
+		Flags:     rec.Flags,
+		KeyTag:    rec.ComputeKeyTag(),
+		PublicKey: rec.PublicKey,
+		Active:    key.Active,
+		External:  key.External,
+		CreatedAt: key.CreatedAt,
+		UpdatedAt: key.UpdatedAt,
+	}
+}
+
+// tenantZone loads a zone, failing with domain.ErrNotFound if the tenant does not own it.
+func (s *DNSSECService) tenantZone(ctx context.Context, zoneID string, tenantID string) (*domain.Zone, error) {
+	zone, err := s.repo.GetZoneByID(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load zone: %w", err)
+	}
+	if zone == nil {
+		return nil, fmt.Errorf("zone %s: %w", zoneID, domain.ErrNotFound)
+	}
+	return zone, nil
+}
+
+// ListZoneKeys returns the public data of every key of a tenant's zone, oldest first.
+func (s *DNSSECService) ListZoneKeys(ctx context.Context, zoneID string, tenantID string) ([]domain.DNSSECKeyInfo, error) {
+	zone, err := s.tenantZone(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := s.repo.ListKeysForZone(ctx, zone.ID)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+
+	infos := make([]domain.DNSSECKeyInfo, 0, len(keys))
+	for _, k := range keys {
+		infos = append(infos, keyInfo(zone.Name, k))
+	}
+	return infos, nil
+}
+
+// RolloverKey starts a manual rollover by generating a new active key of the given
+// type. The previous keys stay active so both sign during the overlap; they are
+// phased out by the lifecycle automation or can be deactivated explicitly.
+func (s *DNSSECService) RolloverKey(ctx context.Context, zoneID string, tenantID string, keyType string) (*domain.DNSSECKeyInfo, error) {
+	if keyType != "KSK" && keyType != "ZSK" {
+		return nil, fmt.Errorf("%w: key type must be KSK or ZSK", domain.ErrInvalidKeyOperation)
+	}
+	zone, err := s.tenantZone(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !zone.SignsDNSSEC() {
+		return nil, fmt.Errorf("%w: DNSSEC is disabled for zone %s", domain.ErrInvalidKeyOperation, zone.Name)
+	}
+	key, err := s.GenerateKey(ctx, zone.ID, keyType)
+	if err != nil {
+		return nil, err
+	}
+	info := keyInfo(zone.Name, *key)
+	return &info, nil
+}
+
+// SetKeyActive activates or deactivates one of a zone's keys. Deactivating the last
+// active key of its type in a signed zone fails with domain.ErrKeyInUse.
+func (s *DNSSECService) SetKeyActive(ctx context.Context, zoneID string, tenantID string, keyID string, active bool) (*domain.DNSSECKeyInfo, error) {
+	zone, err := s.tenantZone(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := s.repo.ListKeysForZone(ctx, zone.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var key *domain.DNSSECKey
+	for i := range keys {
+		if keys[i].ID == keyID {
+			key = &keys[i]
+		}
+	}
+	if key == nil {
+		return nil, fmt.Errorf("key %s: %w", keyID, domain.ErrNotFound)
+	}
+	activeOfType := 0
+	for _, k := range keys {
+		if k.KeyType == key.KeyType && k.Active && !k.External {
+			activeOfType++
+		}
+	}
+	if !active && key.Active && !key.External && activeOfType == 1 && zone.SignsDNSSEC() {
+		return nil, fmt.Errorf("%w: roll over the %s before deactivating it", domain.ErrKeyInUse, key.KeyType)
+	}
+
+	if key.Active != active {
+		key.Active = active
+		key.UpdatedAt = time.Now()
+		if err := s.repo.UpdateKey(ctx, key); err != nil {
+			return nil, err
+		}
+	}
+	info := keyInfo(zone.Name, *key)
+	return &info, nil
+}
+
+// ZoneDS returns the DS records for a zone's active KSKs in each requested digest
+// type (1 SHA-1, 2 SHA-256, 4 SHA-384).
+func (s *DNSSECService) ZoneDS(ctx context.Context, zoneID string, tenantID string, digestTypes []uint8) ([]domain.DSRecord, error) {
+	for _, dt := range digestTypes {
+		if dt != 1 && dt != 2 && dt != 4 {
+			return nil, fmt.Errorf("%w: unsupported digest type %d", domain.ErrInvalidKeyOperation, dt)
+		}
+	}
+	zone, err := s.tenantZone(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := s.repo.ListKeysForZone(ctx, zone.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []domain.DSRecord{}
+	for _, k := range keys {
+		if k.KeyType != "KSK" || !k.Active {
+			continue
+		}
+		dnskey := dnskeyRecord(zone.Name, k)
+		for _, dt := range digestTypes {
+			ds, err := dnskey.ComputeDS(dt)
+			if err != nil {
+				return nil, fmt.Errorf("failed to compute DS: %w", err)
+			}
+			digest := strings.ToUpper(hex.EncodeToString(ds.Digest))
+			records = append(records, domain.DSRecord{
+				KeyID:      k.ID,
+				KeyTag:     ds.KeyTag,
+				Algorithm:  k.Algorithm,
+				DigestType: int(dt),
+				Digest:     digest,
+				Record:     fmt.Sprintf("%s %d IN DS %d %d %d %s", zone.Name, dsTTL, ds.KeyTag, k.Algorithm, dt, digest),
+			})
+		}
+	}
+	return records, nil
+}
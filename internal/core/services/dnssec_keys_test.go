@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+func TestDNSSECKeyManagement(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepo{zones: []domain.Zone{{ID: "z1", TenantID: "t1", Name: "example.com."}}}
+	svc := NewDNSSECService(repo)
+	if err := svc.AutomateLifecycle(ctx, "z1"); err != nil {
+		t.Fatalf("AutomateLifecycle failed: %v", err)
+	}
+
+	if _, err := svc.ListZoneKeys(ctx, "z1", "other"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for another tenant, got %v", err)
+	}
+	keys, err := svc.ListZoneKeys(ctx, "z1", "t1")
+	if err != nil || len(keys) != 2 {
+		t.Fatalf("Expected KSK and ZSK, got %+v (%v)", keys, err)
+	}
+	var ksk, zsk domain.DNSSECKeyInfo
+	for _, k := range keys {
+		if k.KeyType == "KSK" {
+			ksk = k
+		} else {
+			zsk = k
+		}
+	}
+	if ksk.Flags != 257 || zsk.Flags != 256 || ksk.KeyTag == 0 || len(ksk.PublicKey) == 0 {
+		t.Errorf("Unexpected key data KSK %+v ZSK %+v", ksk, zsk)
+	}
+
+	if _, err := svc.SetKeyActive(ctx, "z1", "t1", zsk.ID, false); !errors.Is(err, domain.ErrKeyInUse) {
+		t.Errorf("Expected ErrKeyInUse deactivating the only ZSK, got %v", err)
+	}
+	if _, err := svc.RolloverKey(ctx, "z1", "t1", "XSK"); !errors.Is(err, domain.ErrInvalidKeyOperation) {
+		t.Errorf("Expected ErrInvalidKeyOperation for a bad key type, got %v", err)
+	}
+	next, err := svc.RolloverKey(ctx, "z1", "t1", "ZSK")
+	if err != nil || !next.Active || next.ID == zsk.ID {
+		t.Fatalf("Expected a new active ZSK, got %+v (%v)", next, err)
+	}
+	old, err := svc.SetKeyActive(ctx, "z1", "t1", zsk.ID, false)
+	if err != nil || old.Active {
+		t.Errorf("Expected old ZSK deactivated after rollover, got %+v (%v)", old, err)
+	}
+	if _, err := svc.SetKeyActive(ctx, "z1", "t1", "missing", true); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an unknown key, got %v", err)
+	}
+
+	ds, err := svc.ZoneDS(ctx, "z1", "t1", []uint8{1, 2, 4})
+	if err != nil || len(ds) != 3 {
+		t.Fatalf("Expected one DS per digest type, got %+v (%v)", ds, err)
+	}
+	for i, want := range []int{40, 64, 96} {
+		if ds[i].KeyTag != ksk.KeyTag || len(ds[i].Digest) != want {
+			t.Errorf("Unexpected DS %+v", ds[i])
+		}
+	}
+	if !strings.HasPrefix(ds[1].Record, "example.com. 3600 IN DS ") {
+		t.Errorf("Unexpected presentation format %q", ds[1].Record)
+	}
+	if _, err := svc.ZoneDS(ctx, "z1", "t1", []uint8{3}); !errors.Is(err, domain.ErrInvalidKeyOperation) {
+		t.Errorf("Expected ErrInvalidKeyOperation for GOST, got %v", err)
+	}
+}
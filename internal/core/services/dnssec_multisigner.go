@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+// ImportSignerKey adds another provider's ZSK, given as a DNSKEY record such as
+// "example.com. 3600 IN DNSKEY 256 3 13 <key>", to a zone's DNSKEY RRset. This is
+// the multi-signer model of RFC 8901 Section 2.1.2: each provider signs the zone
+// with its own keys, and resolvers validate either provider's signatures because
+// both ZSKs are published by both. The key never signs here and can be dropped
+// from the RRset by deactivating it.
+func (s *DNSSECService) ImportSignerKey(ctx context.Context, zoneID string, tenantID string, dnskey string) (*domain.DNSSECKeyInfo, error) {
+	zone, err := s.tenantZone(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !zone.SignsDNSSEC() {
+		return nil, fmt.Errorf("%w: DNSSEC is disabled for zone %s", domain.ErrInvalidKeyOperation, zone.Name)
+	}
+
+	rec, err := parseBINDPublicKey(zone.Name, dnskey)
+	if err != nil {
+		return nil, err
+	}
+	if rec.Flags != 256 {
+		return nil, fmt.Errorf("%w: only ZSKs (flags 256) of other signers can be imported", domain.ErrInvalidKeyOperation)
+	}
+	// All signers of a zone must use the same algorithm (RFC 8901 Section 5)
+	if rec.Algorithm != 13 {
+		return nil, fmt.Errorf("%w: unsupported algorithm %d, only 13 (ECDSAP256SHA256) is supported", domain.ErrInvalidKeyOperation, rec.Algorithm)
+	}
+	pub, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), append([]byte{4}, rec.PublicKey...))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid public key: %v", domain.ErrInvalidKeyOperation, err)
+	}
+
+	keys, err := s.repo.ListKeysForZone(ctx, zone.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		if string(dnskeyPublicKey(k.PublicKey)) == string(rec.PublicKey) {
+			return nil, fmt.Errorf("%w: key %s is already present", domain.ErrInvalidKeyOperation, k.ID)
+		}
+	}
+
+	pubBytes, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode public key: %w", err)
+	}
+	now := time.Now()
+	key := &domain.DNSSECKey{
+		ID:         uuid.New().String(),
+		ZoneID:     zone.ID,
+		KeyType:    "ZSK",
+		Algorithm:  13,
+		PrivateKey: []byte{},
+		PublicKey:  pubBytes,
+		Active:     true,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		External:   true,
+	}
+	if err := s.repo.CreateKey(ctx, key); err != nil {
+		return nil, err
+	}
+
+	info := keyInfo(zone.Name, *key)
+	s.audit(ctx, tenantID, "IMPORT_SIGNER_KEY", key.ID,
+		fmt.Sprintf("Imported signer ZSK %d for zone %s", info.KeyTag, zone.Name))
+	return &info, nil
+}
+
+// SignerKeys returns the DNSKEY records of a zone's own active ZSKs in zone file
+// format, for import into the other providers of a multi-signer zone.
+func (s *DNSSECService) SignerKeys(ctx context.Context, zoneID string, tenantID string) ([]string, error) {
+	zone, err := s.tenantZone(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := s.repo.ListKeysForZone(ctx, zone.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []string{}
+	for _, k := range keys {
+		if k.KeyType != "ZSK" || !k.Active || k.External {
+			continue
+		}
+		rec := dnskeyRecord(zone.Name, k)
+		records = append(records, fmt.Sprintf("%s %d IN DNSKEY %d 3 %d %s",
+			zone.Name, rec.TTL, rec.Flags, rec.Algorithm, base64.StdEncoding.EncodeToString(rec.PublicKey)))
+	}
+	return records, nil
+}
+
+// DNSKEYRRSet returns the DNSKEY RRset published at a zone's apex: its active
+// keys, including those imported from other signers.
+func (s *DNSSECService) DNSKEYRRSet(ctx context.Context, zone *domain.Zone) ([]packet.DNSRecord, error) {
+	keys, err := s.repo.ListKeysForZone(ctx, zone.ID)
+	if err != nil {
+		return nil, err
+	}
+	var records []packet.DNSRecord
+	for _, k := range keys {
+		if k.Active {
+			records = append(records, dnskeyRecord(zone.Name, k))
+		}
+	}
+	return records, nil
+}
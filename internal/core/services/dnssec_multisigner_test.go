@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+// otherSignerZSK is the RFC 6605 example key published as another provider's ZSK.
+const otherSignerZSK = "example.net. 3600 IN DNSKEY 256 3 13 GojIhhXUN/u4v54ZQqGSnyhWJwaubCvTmeexv7bR6edbkrSqQpF64cYbcB7wNcP+e+MAnLr+Wi9xMWyQLc8NAA=="
+
+func TestMultiSignerKeys(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepo{zones: []domain.Zone{{ID: "z1", TenantID: "t1", Name: "example.net."}}}
+	svc := NewDNSSECService(repo)
+	if err := svc.AutomateLifecycle(ctx, "z1"); err != nil {
+		t.Fatalf("AutomateLifecycle failed: %v", err)
+	}
+
+	if _, err := svc.ImportSignerKey(ctx, "z1", "other", otherSignerZSK); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for another tenant, got %v", err)
+	}
+	imported, err := svc.ImportSignerKey(ctx, "z1", "t1", otherSignerZSK)
+	if err != nil {
+		t.Fatalf("ImportSignerKey failed: %v", err)
+	}
+	if !imported.External || imported.KeyType != "ZSK" || imported.Flags != 256 || !imported.Active {
+		t.Errorf("Unexpected imported key %+v", imported)
+	}
+	if _, err := svc.ImportSignerKey(ctx, "z1", "t1", otherSignerZSK); !errors.Is(err, domain.ErrInvalidKeyOperation) {
+		t.Errorf("Expected duplicate import rejected, got %v", err)
+	}
+	for _, bad := range []string{
+		strings.Replace(otherSignerZSK, " 256 ", " 257 ", 1),
+		strings.Replace(otherSignerZSK, " 13 ", " 8 ", 1),
+		strings.Replace(otherSignerZSK, "example.net.", "example.org.", 1),
+		"example.net. 3600 IN DNSKEY 256 3 13 AAAA",
+	} {
+		if _, err := svc.ImportSignerKey(ctx, "z1", "t1", bad); !errors.Is(err, domain.ErrInvalidKeyOperation) {
+			t.Errorf("Expected %q rejected, got %v", bad, err)
+		}
+	}
+
+	// Only our own ZSK is exported, and only our keys sign
+	exported, err := svc.SignerKeys(ctx, "z1", "t1")
+	if err != nil || len(exported) != 1 || !strings.HasPrefix(exported[0], "example.net. 3600 IN DNSKEY 256 3 13 ") || exported[0] == otherSignerZSK {
+		t.Errorf("Unexpected exported keys %v (%v)", exported, err)
+	}
+	zsks, err := svc.GetActiveKeys(ctx, "z1", "ZSK")
+	if err != nil || len(zsks) != 1 || zsks[0].External {
+		t.Errorf("Expected only our ZSK to sign, got %+v (%v)", zsks, err)
+	}
+
+	zone := &repo.zones[0]
+	dnskeys, err := svc.DNSKEYRRSet(ctx, zone)
+	if err != nil || len(dnskeys) != 3 {
+		t.Fatalf("Expected KSK, ZSK and the imported ZSK in the DNSKEY RRset, got %d (%v)", len(dnskeys), err)
+	}
+	// The DNSKEY RRset is signed by the KSK
+	sigs, err := svc.SignRRSet(ctx, zone.Name, zone.ID, dnskeys)
+	if err != nil || len(sigs) != 1 {
+		t.Fatalf("SignRRSet failed: %v", err)
+	}
+	ksks, _ := svc.GetActiveKeys(ctx, "z1", "KSK")
+	if ksk := dnskeyRecord(zone.Name, ksks[0]); sigs[0].KeyTag != ksk.ComputeKeyTag() || sigs[0].TypeCovered != uint16(packet.DNSKEY) {
+		t.Errorf("Expected the DNSKEY RRset signed by the KSK, got %+v", sigs[0])
+	}
+
+	// Other signers' keys are not aged out or counted as ours
+	for i := range repo.keys {
+		if repo.keys[i].External {
+			repo.keys[i].CreatedAt = time.Now().Add(-365 * 24 * time.Hour)
+		}
+	}
+	if err := svc.AutomateLifecycle(ctx, "z1"); err != nil {
+		t.Fatalf("AutomateLifecycle failed: %v", err)
+	}
+	if keys, _ := svc.ListZoneKeys(ctx, "z1", "t1"); len(keys) != 3 || !keys[0].Active || !keys[1].Active || !keys[2].Active {
+		t.Errorf("Expected all three keys to stay active, got %+v", keys)
+	}
+	if _, err := svc.SetKeyActive(ctx, "z1", "t1", zsks[0].ID, false); !errors.Is(err, domain.ErrKeyInUse) {
+		t.Errorf("Expected our last ZSK to stay in use, got %v", err)
+	}
+	if key, err := svc.SetKeyActive(ctx, "z1", "t1", imported.ID, false); err != nil || key.Active {
+		t.Errorf("Expected the imported key to be withdrawn, got %+v (%v)", key, err)
+	}
+
+	svc.AllowKeyExport = true
+	if _, err := svc.ExportKey(ctx, "z1", "t1", imported.ID); !errors.Is(err, domain.ErrInvalidKeyOperation) {
+		t.Errorf("Expected another signer's key not to be exportable, got %v", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"math"
 	"time"
@@ -17,9 +18,16 @@ import (
 	"github.com/poyrazK/cloudDNS/internal/dns/packet"
 )
 
+// ErrNoActiveKey is returned when a zone has no active key of the requested type,
+// which for ZSKs means the zone is not signed.
+var ErrNoActiveKey = errors.New("no active key found")
+
 // DNSSECService provides functionality for managing DNSSEC keys and signing RRsets.
 type DNSSECService struct {
 	repo ports.DNSRepository
+
+	// AllowKeyExport permits ExportKey to hand out private keys.
+	AllowKeyExport bool
 }
 
 // NewDNSSECService creates and returns a new DNSSECService instance.
@@ -74,7 +82,8 @@ func (s *DNSSECService) AutomateLifecycle(ctx context.Context, zoneID string) er
 	processType := func(keyType string, rollover, overlap time.Duration) error {
 		var activeKeys []domain.DNSSECKey
 		for _, k := range keys {
-			if k.KeyType == keyType && k.Active {
+			// Other signers roll their own keys
+			if k.KeyType == keyType && k.Active && !k.External {
 				activeKeys = append(activeKeys, k)
 			}
 		}
@@ -125,6 +134,7 @@ func (s *DNSSECService) AutomateLifecycle(ctx context.Context, zoneID string) er
 }
 
 // GetActiveKeys returns all currently active keys of a specific type for a zone
+// that we can sign with, leaving out other signers' keys.
 func (s *DNSSECService) GetActiveKeys(ctx context.Context, zoneID string, keyType string) ([]domain.DNSSECKey, error) {
 	keys, err := s.repo.ListKeysForZone(ctx, zoneID)
 	if err != nil {
@@ -133,23 +143,28 @@ func (s *DNSSECService) GetActiveKeys(ctx context.Context, zoneID string, keyTyp
 
 	var active []domain.DNSSECKey
 	for _, k := range keys {
-		if k.KeyType == keyType && k.Active {
+		if k.KeyType == keyType && k.Active && !k.External {
 			active = append(active, k)
 		}
 	}
 	if len(active) == 0 {
-		return nil, fmt.Errorf("no active %s key found", keyType)
+		return nil, fmt.Errorf("%w: %s", ErrNoActiveKey, keyType)
 	}
 	return active, nil
 }
 
-// SignRRSet signs a list of packet records using all active ZSKs for the zone
+// SignRRSet signs a list of packet records using all active ZSKs for the zone,
+// or its active KSKs for the DNSKEY RRset
 func (s *DNSSECService) SignRRSet(ctx context.Context, zoneName string, zoneID string, records []packet.DNSRecord) ([]packet.DNSRecord, error) {
 	if len(records) == 0 {
 		return nil, nil
 	}
 
-	keys, err := s.GetActiveKeys(ctx, zoneID, "ZSK")
+	keyType := "ZSK"
+	if records[0].Type == packet.DNSKEY {
+		keyType = "KSK"
+	}
+	keys, err := s.GetActiveKeys(ctx, zoneID, keyType)
 	if err != nil {
 		return nil, err
 	}
@@ -164,9 +179,9 @@ func (s *DNSSECService) SignRRSet(ctx context.Context, zoneName string, zoneID s
 		// Calculate key tag
 		tempKeyRec := packet.DNSRecord{
 			Type:      packet.DNSKEY,
-			Flags:     256, // ZSK
+			Flags:     keyFlags(keyType),
 			Algorithm: 13,
-			PublicKey: key.PublicKey,
+			PublicKey: dnskeyPublicKey(key.PublicKey),
 		}
 		keyTag := tempKeyRec.ComputeKeyTag()
 
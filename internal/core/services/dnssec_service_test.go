@@ -23,35 +23,58 @@ func (m *mockDNSSECRepo) GetIPsForName(_ context.Context, _ string, _ string) ([
 	return nil, nil
 }
 func (m *mockDNSSECRepo) GetZone(_ context.Context, _ string) (*domain.Zone, error) { return nil, nil }
+func (m *mockDNSSECRepo) GetZoneByID(_ context.Context, _ string, _ string) (*domain.Zone, error) {
+	return nil, nil
+}
 func (m *mockDNSSECRepo) GetRecord(_ context.Context, _ string, _ string, _ string) (*domain.Record, error) {
 	return nil, nil
 }
 func (m *mockDNSSECRepo) ListRecordsForZone(_ context.Context, _ string, _ string) ([]domain.Record, error) {
 	return nil, nil
 }
+func (m *mockDNSSECRepo) NameExists(_ context.Context, _ string, _ string) (bool, error) {
+	return false, nil
+}
 func (m *mockDNSSECRepo) CreateZone(_ context.Context, _ *domain.Zone) error { return nil }
 func (m *mockDNSSECRepo) CreateZoneWithRecords(_ context.Context, _ *domain.Zone, _ []domain.Record) error {
 	return nil
 }
 func (m *mockDNSSECRepo) CreateRecord(_ context.Context, _ *domain.Record) error        { return nil }
 func (m *mockDNSSECRepo) BatchCreateRecords(_ context.Context, _ []domain.Record) error { return nil }
+func (m *mockDNSSECRepo) HasZones(_ context.Context) (bool, error) {
+	return false, nil
+}
 func (m *mockDNSSECRepo) ListZones(_ context.Context, _ string) ([]domain.Zone, error) {
 	return nil, nil
 }
 func (m *mockDNSSECRepo) DeleteZone(_ context.Context, _, _ string) error      { return nil }
 func (m *mockDNSSECRepo) DeleteRecord(_ context.Context, _, _, _ string) error { return nil }
+func (m *mockDNSSECRepo) SetZoneDNSSEC(_ context.Context, _, _ string, _ bool) error { return nil }
 func (m *mockDNSSECRepo) DeleteRecordsByNameAndType(_ context.Context, _, _ string, _ domain.RecordType) error {
 	return nil
 }
 func (m *mockDNSSECRepo) DeleteRecordsByName(_ context.Context, _, _ string) error { return nil }
 func (m *mockDNSSECRepo) DeleteRecordsForZone(_ context.Context, _ string) error { return m.err }
+func (m *mockDNSSECRepo) ReplaceZoneRecords(_ context.Context, _ string, _ []domain.Record) error {
+	return m.err
+}
+func (m *mockDNSSECRepo) SetZoneRole(_ context.Context, _, _, _, _ string) error { return nil }
+func (m *mockDNSSECRepo) ApplyZoneDiff(_ context.Context, _ string, _ []domain.IXFRChunk) error {
+	return m.err
+}
 func (m *mockDNSSECRepo) DeleteRecordSpecific(_ context.Context, _, _ string, _ domain.RecordType, _ string) error {
 	return nil
 }
+func (m *mockDNSSECRepo) ReplaceRRset(_ context.Context, _, _ string, _ domain.RecordType, _ []domain.Record) error {
+	return nil
+}
 func (m *mockDNSSECRepo) RecordZoneChange(_ context.Context, _ *domain.ZoneChange) error { return nil }
 func (m *mockDNSSECRepo) ListZoneChanges(_ context.Context, _ string, _ uint32) ([]domain.ZoneChange, error) {
 	return nil, nil
 }
+func (m *mockDNSSECRepo) LastZoneChangeAt(_ context.Context, _ string) (*time.Time, error) {
+	return nil, nil
+}
 func (m *mockDNSSECRepo) GetIXFRChain(_ context.Context, _ string, _, _ uint32) ([]domain.IXFRChunk, error) {
 	return nil, m.err
 }
@@ -62,6 +85,10 @@ func (m *mockDNSSECRepo) GetAuditLogs(_ context.Context, _ string) ([]domain.Aud
 func (m *mockDNSSECRepo) DeleteAPIKey(_ context.Context, _, _ string) error { return nil }
 func (m *mockDNSSECRepo) Ping(_ context.Context) error                      { return nil }
 
+func (m *mockDNSSECRepo) SetRecordProtected(_ context.Context, _, _, _ string, _ bool) (bool, error) {
+	return false, nil
+}
+
 func (m *mockDNSSECRepo) UpdateRecordHealth(_ context.Context, _ string, _ domain.HealthStatus, _ string) error {
 	return nil
 }
@@ -70,6 +97,235 @@ func (m *mockDNSSECRepo) GetRecordsToProbe(_ context.Context) ([]domain.Record,
 	return nil, nil
 }
 
+func (m *mockDNSSECRepo) UpsertNode(_ context.Context, _ *domain.Node) error { return nil }
+
+func (m *mockDNSSECRepo) ListNodes(_ context.Context) ([]domain.Node, error) {
+	return nil, nil
+}
+
+func (m *mockDNSSECRepo) GetNodeConfig(_ context.Context, _ string) (map[string]string, error) {
+	return nil, nil
+}
+func (m *mockDNSSECRepo) SetNodeConfig(_ context.Context, _ string, _ map[string]string) error {
+	return nil
+}
+func (m *mockDNSSECRepo) SaveRollout(_ context.Context, _ *domain.Rollout) error { return nil }
+func (m *mockDNSSECRepo) GetRollout(_ context.Context, _ string) (*domain.Rollout, error) {
+	return nil, nil
+}
+func (m *mockDNSSECRepo) ListRollouts(_ context.Context) ([]domain.Rollout, error) {
+	return nil, nil
+}
+func (m *mockDNSSECRepo) AddZoneUsage(_ context.Context, _ []domain.ZoneUsage) error { return nil }
+func (m *mockDNSSECRepo) ListZoneUsage(_ context.Context, _, _ string, _, _ time.Time) ([]domain.ZoneUsage, error) {
+	return nil, nil
+}
+func (m *mockDNSSECRepo) CreateSecondaryProvider(_ context.Context, _ *domain.SecondaryProvider) error {
+	return nil
+}
+func (m *mockDNSSECRepo) ListSecondaryProviders(_ context.Context, _ string) ([]domain.SecondaryProvider, error) {
+	return nil, nil
+}
+func (m *mockDNSSECRepo) DeleteSecondaryProvider(_ context.Context, _, _ string) error { return nil }
+func (m *mockDNSSECRepo) SaveZoneRefresh(_ context.Context, _ *domain.ZoneRefresh) error  { return nil }
+func (m *mockDNSSECRepo) GetZoneRefresh(_ context.Context, _ string) (*domain.ZoneRefresh, error) {
+	return nil, nil
+}
+func (m *mockDNSSECRepo) UpdateSecondaryProviderStatus(_ context.Context, _ *domain.SecondaryProvider) error {
+	return nil
+}
+func (m *mockDNSSECRepo) CreateScheduledChange(_ context.Context, _ *domain.ScheduledChange) error {
+	return nil
+}
+func (m *mockDNSSECRepo) ListScheduledChanges(_ context.Context, _ string) ([]domain.ScheduledChange, error) {
+	return nil, nil
+}
+func (m *mockDNSSECRepo) CancelScheduledChange(_ context.Context, _, _ string) (bool, error) {
+	return false, nil
+}
+func (m *mockDNSSECRepo) ClaimDueScheduledChanges(_ context.Context, _ time.Time, _ int) ([]domain.ScheduledChange, error) {
+	return nil, nil
+}
+func (m *mockDNSSECRepo) FinishScheduledChange(_ context.Context, _ *domain.ScheduledChange) error {
+	return nil
+}
+func (m *mockDNSSECRepo) CreateChangeFreeze(_ context.Context, _ *domain.ChangeFreeze) error {
+	return nil
+}
+func (m *mockDNSSECRepo) ListChangeFreezes(_ context.Context, _ string) ([]domain.ChangeFreeze, error) {
+	return nil, nil
+}
+func (m *mockDNSSECRepo) DeleteChangeFreeze(_ context.Context, _, _ string) (bool, error) {
+	return false, nil
+}
+func (m *mockDNSSECRepo) GetActiveChangeFreeze(_ context.Context, _, _ string, _ time.Time) (*domain.ChangeFreeze, error) {
+	return nil, nil
+}
+
+func (m *mockDNSSECRepo) SetZoneReview(_ context.Context, _, _ string, _ bool) error {
+	return nil
+}
+
+func (m *mockDNSSECRepo) IsZoneReviewed(_ context.Context, _ string) (bool, error) {
+	return false, nil
+}
+
+func (m *mockDNSSECRepo) CreateChangeRequest(_ context.Context, _ *domain.ChangeRequest) error {
+	return nil
+}
+
+func (m *mockDNSSECRepo) ListChangeRequests(_ context.Context, _ string) ([]domain.ChangeRequest, error) {
+	return nil, nil
+}
+
+func (m *mockDNSSECRepo) GetChangeRequest(_ context.Context, _, _ string) (*domain.ChangeRequest, error) {
+	return nil, nil
+}
+
+func (m *mockDNSSECRepo) ReviewChangeRequest(_ context.Context, _ *domain.ChangeRequest) (bool, error) {
+	return false, nil
+}
+
+func (m *mockDNSSECRepo) FinishChangeRequest(_ context.Context, _ *domain.ChangeRequest) error {
+	return nil
+}
+
+func (m *mockDNSSECRepo) SaveShadowChange(_ context.Context, _ *domain.ShadowChange) error {
+	return nil
+}
+
+func (m *mockDNSSECRepo) GetShadowChange(_ context.Context, _ string) (*domain.ShadowChange, error) {
+	return nil, nil
+}
+
+func (m *mockDNSSECRepo) ListRecordNetworks(_ context.Context) ([]string, error) {
+	return nil, nil
+}
+
+func (m *mockDNSSECRepo) ListRecordGeoScopes(_ context.Context) ([]string, error) {
+	return nil, nil
+}
+
+func (m *mockDNSSECRepo) ListShadowChanges(_ context.Context) ([]domain.ShadowChange, error) {
+	return nil, nil
+}
+
+func (m *mockDNSSECRepo) DeleteShadowChange(_ context.Context, _ string) (bool, error) {
+	return false, nil
+}
+
+func (m *mockDNSSECRepo) SaveZoneSLO(_ context.Context, _ *domain.ZoneSLO) error {
+	return nil
+}
+
+func (m *mockDNSSECRepo) GetZoneSLO(_ context.Context, _ string) (*domain.ZoneSLO, error) {
+	return nil, nil
+}
+
+func (m *mockDNSSECRepo) ListZoneSLOs(_ context.Context) ([]domain.ZoneSLO, error) {
+	return nil, nil
+}
+
+func (m *mockDNSSECRepo) DeleteZoneSLO(_ context.Context, _ string) (bool, error) {
+	return false, nil
+}
+
+func (m *mockDNSSECRepo) SaveQueryLimit(_ context.Context, _ *domain.QueryLimit) error {
+	return nil
+}
+
+func (m *mockDNSSECRepo) ListQueryLimits(_ context.Context, _ string) ([]domain.QueryLimit, error) {
+	return nil, nil
+}
+
+func (m *mockDNSSECRepo) DeleteQueryLimit(_ context.Context, _, _ string) (bool, error) {
+	return false, nil
+}
+
+func (m *mockDNSSECRepo) SaveView(_ context.Context, _ *domain.View) error {
+	return nil
+}
+
+func (m *mockDNSSECRepo) ListViews(_ context.Context, _ string) ([]domain.View, error) {
+	return nil, nil
+}
+
+func (m *mockDNSSECRepo) DeleteView(_ context.Context, _, _ string) (bool, error) {
+	return false, nil
+}
+
+func (m *mockDNSSECRepo) SaveDefaultRecord(_ context.Context, _ *domain.DefaultRecord) error {
+	return nil
+}
+
+func (m *mockDNSSECRepo) ListDefaultRecords(_ context.Context, _ string) ([]domain.DefaultRecord, error) {
+	return nil, nil
+}
+
+func (m *mockDNSSECRepo) DeleteDefaultRecord(_ context.Context, _, _ string) (bool, error) {
+	return false, nil
+}
+
+func (m *mockDNSSECRepo) SaveTarget(_ context.Context, _ *domain.Target) error {
+	return nil
+}
+
+func (m *mockDNSSECRepo) ListTargets(_ context.Context, _ string) ([]domain.Target, error) {
+	return nil, nil
+}
+
+func (m *mockDNSSECRepo) DeleteTarget(_ context.Context, _, _ string) (bool, error) {
+	return false, nil
+}
+
+func (m *mockDNSSECRepo) ListTargetRecords(_ context.Context, _ string) ([]domain.Record, error) {
+	return nil, nil
+}
+
+func (m *mockDNSSECRepo) SaveHealthCheck(_ context.Context, _ *domain.HealthCheck) error {
+	return nil
+}
+
+func (m *mockDNSSECRepo) ListHealthChecks(_ context.Context, _ string) ([]domain.HealthCheck, error) {
+	return nil, nil
+}
+
+func (m *mockDNSSECRepo) DeleteHealthCheck(_ context.Context, _, _ string) (bool, error) {
+	return false, nil
+}
+
+func (m *mockDNSSECRepo) UpdateHealthCheckStatus(_ context.Context, _ string, _ domain.HealthStatus, _ string) error {
+	return nil
+}
+
+func (m *mockDNSSECRepo) ListHealthCheckRecords(_ context.Context, _ string) ([]domain.Record, error) {
+	return nil, nil
+}
+
+func (m *mockDNSSECRepo) SaveSubnet(_ context.Context, _ *domain.Subnet) error {
+	return nil
+}
+
+func (m *mockDNSSECRepo) ListSubnets(_ context.Context, _ string) ([]domain.Subnet, error) {
+	return nil, nil
+}
+
+func (m *mockDNSSECRepo) DeleteSubnet(_ context.Context, _, _ string) (bool, error) {
+	return false, nil
+}
+
+func (m *mockDNSSECRepo) SaveIPAllocation(_ context.Context, _ *domain.IPAllocation) error {
+	return nil
+}
+
+func (m *mockDNSSECRepo) ListIPAllocations(_ context.Context, _ string) ([]domain.IPAllocation, error) {
+	return nil, nil
+}
+
+func (m *mockDNSSECRepo) DeleteIPAllocation(_ context.Context, _, _ string) (bool, error) {
+	return false, nil
+}
+
 func (m *mockDNSSECRepo) CreateKey(_ context.Context, key *domain.DNSSECKey) error {
 	if m.err != nil {
 		return m.err
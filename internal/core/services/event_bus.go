@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+)
+
+// defaultEventBuffer is the number of events queued per subscriber before new
+// events are dropped for it.
+const defaultEventBuffer = 256
+
+// EventBus is an in-process fan-out of live events. Publishing never blocks: a
+// subscriber whose buffer is full loses events and is told how many it missed by
+// an EventDropped notice once it catches up.
+type EventBus struct {
+	mu     sync.RWMutex
+	subs   map[*eventSubscriber]struct{}
+	buffer int
+}
+
+type eventSubscriber struct {
+	filter  domain.EventFilter
+	ch      chan domain.Event
+	dropped atomic.Uint64
+}
+
+// NewEventBus creates an EventBus that queues up to buffer events per subscriber.
+// A non-positive buffer selects the default.
+func NewEventBus(buffer int) *EventBus {
+	if buffer <= 0 {
+		buffer = defaultEventBuffer
+	}
+	return &EventBus{
+		subs:   make(map[*eventSubscriber]struct{}),
+		buffer: buffer,
+	}
+}
+
+// Subscribe registers a subscriber for events matching filter until ctx is done.
+func (b *EventBus) Subscribe(ctx context.Context, filter domain.EventFilter) <-chan domain.Event {
+	sub := &eventSubscriber{
+		filter: filter,
+		ch:     make(chan domain.Event, b.buffer),
+	}
+
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+	metrics.EventSubscribers.Inc()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		delete(b.subs, sub)
+		close(sub.ch)
+		b.mu.Unlock()
+		metrics.EventSubscribers.Dec()
+	}()
+
+	return sub.ch
+}
+
+// Publish delivers event to every matching subscriber without blocking.
+func (b *EventBus) Publish(event domain.Event) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for sub := range b.subs {
+		if sub.filter.Matches(event) {
+			sub.deliver(event)
+		}
+	}
+}
+
+// Subscribers returns the number of open subscriptions.
+func (b *EventBus) Subscribers() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs)
+}
+
+func (s *eventSubscriber) deliver(event domain.Event) {
+	// Report earlier losses first so the client sees them in order
+	if missed := s.dropped.Swap(0); missed > 0 {
+		notice := domain.Event{Type: domain.EventDropped, Dropped: missed, Timestamp: event.Timestamp}
+		select {
+		case s.ch <- notice:
+		default:
+			s.dropped.Add(missed)
+		}
+	}
+
+	select {
+	case s.ch <- event:
+	default:
+		s.dropped.Add(1)
+		metrics.EventsDropped.Inc()
+	}
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+func TestEventBus_TenantFiltering(t *testing.T) {
+	bus := NewEventBus(8)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	t1 := bus.Subscribe(ctx, domain.EventFilter{TenantID: "t1"})
+	queries := bus.Subscribe(ctx, domain.EventFilter{TenantID: "t2", Types: []domain.EventType{domain.EventQuery}})
+
+	bus.Publish(domain.Event{Type: domain.EventRecordChange, TenantID: "t1", Name: "www.example.com."})
+	bus.Publish(domain.Event{Type: domain.EventRecordChange, TenantID: "t2", Name: "www.other.com."})
+	bus.Publish(domain.Event{Type: domain.EventQuery, TenantID: "t2", Name: "api.other.com."})
+
+	evt := <-t1
+	if evt.Name != "www.example.com." || evt.Timestamp.IsZero() {
+		t.Errorf("Unexpected event for t1: %+v", evt)
+	}
+	if len(t1) != 0 {
+		t.Errorf("Tenant t1 received %d foreign events", len(t1))
+	}
+
+	evt = <-queries
+	if evt.Type != domain.EventQuery || len(queries) != 0 {
+		t.Errorf("Type filter not applied: %+v (queued %d)", evt, len(queries))
+	}
+}
+
+func TestEventBus_Backpressure(t *testing.T) {
+	bus := NewEventBus(2)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := bus.Subscribe(ctx, domain.EventFilter{})
+
+	// Publishing past the buffer must not block
+	for i := 0; i < 5; i++ {
+		bus.Publish(domain.Event{Type: domain.EventQuery})
+	}
+	<-ch
+	<-ch
+
+	bus.Publish(domain.Event{Type: domain.EventZoneChange})
+	notice := <-ch
+	if notice.Type != domain.EventDropped || notice.Dropped != 3 {
+		t.Fatalf("Expected dropped notice for 3 events, got %+v", notice)
+	}
+	if evt := <-ch; evt.Type != domain.EventZoneChange {
+		t.Errorf("Expected the new event after the notice, got %+v", evt)
+	}
+}
+
+func TestEventBus_UnsubscribeOnCancel(t *testing.T) {
+	bus := NewEventBus(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := bus.Subscribe(ctx, domain.EventFilter{})
+	cancel()
+
+	select {
+	case _, open := <-ch:
+		if open {
+			t.Error("Expected channel to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Subscription was not closed after cancel")
+	}
+	if n := bus.Subscribers(); n != 0 {
+		t.Errorf("Expected no subscribers, got %d", n)
+	}
+	bus.Publish(domain.Event{Type: domain.EventQuery}) // must not panic on closed channel
+}
+
+func TestDNSService_PublishesChanges(t *testing.T) {
+	bus := NewEventBus(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := bus.Subscribe(ctx, domain.EventFilter{TenantID: "t1"})
+
+	svc := NewDNSService(&mockRepo{}, nil, WithEventPublisher(bus))
+	if err := svc.CreateRecord(ctx, &domain.Record{TenantID: "t1", ZoneID: "z1", Name: "www.example.com", Type: domain.TypeA, Content: "1.2.3.4"}); err != nil {
+		t.Fatalf("CreateRecord failed: %v", err)
+	}
+	evt := <-ch
+	if evt.Type != domain.EventRecordChange || evt.Action != "CREATE_RECORD" || evt.Name != "www.example.com." || evt.ZoneID != "z1" {
+		t.Errorf("Unexpected event: %+v", evt)
+	}
+}
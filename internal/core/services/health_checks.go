@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/core/ports"
+)
+
+// Health check defaults and bounds, in seconds and probes.
+const (
+	defaultCheckInterval         = 30
+	minCheckInterval             = 10
+	maxCheckInterval             = 3600
+	defaultCheckTimeout          = 5
+	defaultCheckFailureThreshold = 3
+	defaultCheckSuccessThreshold = 2
+	maxCheckThreshold            = 10
+)
+
+// HealthCheckService manages a tenant's health checks. The health monitor
+// reloads them periodically and probes each on its own interval.
+type HealthCheckService struct {
+	repo ports.DNSRepository
+	now  func() time.Time
+}
+
+// NewHealthCheckService creates a service that stores health checks in repo.
+func NewHealthCheckService(repo ports.DNSRepository) *HealthCheckService {
+	return &HealthCheckService{repo: repo, now: time.Now}
+}
+
+// CreateHealthCheck stores a new health check of the tenant. Its status is
+// unknown until the monitor has probed it.
+func (s *HealthCheckService) CreateHealthCheck(ctx context.Context, tenantID string, check *domain.HealthCheck) error {
+	checks, err := s.repo.ListHealthChecks(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	check.ID = uuid.New().String()
+	if err := validateHealthCheck(check, checks); err != nil {
+		return err
+	}
+	check.TenantID = tenantID
+	check.Status = domain.HealthStatusUnknown
+	check.LastError = ""
+	check.StatusChangedAt = nil
+	check.CreatedAt = s.now()
+	check.UpdatedAt = check.CreatedAt
+	if err := s.repo.SaveHealthCheck(ctx, check); err != nil {
+		return err
+	}
+	s.audit(ctx, tenantID, "CREATE_HEALTH_CHECK", check.ID, fmt.Sprintf("Created %s health check %s of %s", check.Protocol, check.Name, check.Target))
+	return nil
+}
+
+// UpdateHealthCheck replaces the settings of one of the tenant's health
+// checks. Its status is kept until the monitor probes it again.
+func (s *HealthCheckService) UpdateHealthCheck(ctx context.Context, tenantID string, check *domain.HealthCheck) error {
+	checks, err := s.repo.ListHealthChecks(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(checks, func(c domain.HealthCheck) bool { return c.ID == check.ID })
+	if i < 0 {
+		return fmt.Errorf("health check %s: %w", check.ID, domain.ErrNotFound)
+	}
+	if err := validateHealthCheck(check, checks); err != nil {
+		return err
+	}
+	existing := checks[i]
+	check.TenantID = tenantID
+	check.Status = existing.Status
+	check.LastError = existing.LastError
+	check.StatusChangedAt = existing.StatusChangedAt
+	check.CreatedAt = existing.CreatedAt
+	check.UpdatedAt = s.now()
+	if err := s.repo.SaveHealthCheck(ctx, check); err != nil {
+		return err
+	}
+	s.audit(ctx, tenantID, "UPDATE_HEALTH_CHECK", check.ID, fmt.Sprintf("Updated %s health check %s of %s", check.Protocol, check.Name, check.Target))
+	return nil
+}
+
+// ListHealthChecks returns the tenant's health checks by name.
+func (s *HealthCheckService) ListHealthChecks(ctx context.Context, tenantID string) ([]domain.HealthCheck, error) {
+	checks, err := s.repo.ListHealthChecks(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if checks == nil {
+		checks = []domain.HealthCheck{}
+	}
+	return checks, nil
+}
+
+// DeleteHealthCheck removes one of the tenant's health checks that no record
+// references.
+func (s *HealthCheckService) DeleteHealthCheck(ctx context.Context, tenantID string, id string) error {
+	checks, err := s.repo.ListHealthChecks(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(checks, func(c domain.HealthCheck) bool { return c.ID == id }) {
+		return fmt.Errorf("health check %s: %w", id, domain.ErrNotFound)
+	}
+	records, err := s.repo.ListHealthCheckRecords(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to load the health check's records: %w", err)
+	}
+	if len(records) > 0 {
+		return fmt.Errorf("health check %s is referenced by %d records: %w", id, len(records), domain.ErrInvalidHealthCheck)
+	}
+	deleted, err := s.repo.DeleteHealthCheck(ctx, id, tenantID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("health check %s: %w", id, domain.ErrNotFound)
+	}
+	s.audit(ctx, tenantID, "DELETE_HEALTH_CHECK", id, "Deleted health check")
+	return nil
+}
+
+func (s *HealthCheckService) audit(ctx context.Context, tenantID, action, id, details string) {
+	_ = s.repo.SaveAuditLog(ctx, &domain.AuditLog{
+		ID:           uuid.New().String(),
+		TenantID:     tenantID,
+		Action:       action,
+		ResourceType: "HEALTH_CHECK",
+		ResourceID:   id,
+		Details:      details,
+		CreatedAt:    s.now(),
+	})
+}
+
+// validateHealthCheck checks a health check against the tenant's others and
+// fills in the defaults of its path, interval, timeout and thresholds.
+func validateHealthCheck(check *domain.HealthCheck, checks []domain.HealthCheck) error {
+	check.Name = strings.TrimSpace(check.Name)
+	if check.Name == "" {
+		return fmt.Errorf("name is required: %w", domain.ErrInvalidHealthCheck)
+	}
+	for _, other := range checks {
+		if other.ID != check.ID && strings.EqualFold(other.Name, check.Name) {
+			return fmt.Errorf("a health check named %s already exists: %w", check.Name, domain.ErrInvalidHealthCheck)
+		}
+	}
+	check.Protocol = domain.HealthCheckProtocol(strings.ToUpper(strings.TrimSpace(string(check.Protocol))))
+	check.Target = strings.TrimSpace(check.Target)
+	switch check.Protocol {
+	case domain.HealthCheckProtocolHTTP, domain.HealthCheckProtocolHTTPS:
+		if check.Target == "" || strings.ContainsAny(check.Target, "/?# ") {
+			return fmt.Errorf("target %q must be a host or host:port: %w", check.Target, domain.ErrInvalidHealthCheck)
+		}
+		if check.Path == "" {
+			check.Path = "/"
+		}
+		if !strings.HasPrefix(check.Path, "/") {
+			return fmt.Errorf("path %q must start with /: %w", check.Path, domain.ErrInvalidHealthCheck)
+		}
+		if check.ExpectedStatus != 0 && (check.ExpectedStatus < 100 || check.ExpectedStatus > 599) {
+			return fmt.Errorf("expected status %d: %w", check.ExpectedStatus, domain.ErrInvalidHealthCheck)
+		}
+	case domain.HealthCheckProtocolTCP, domain.HealthCheckProtocolGRPC:
+		host, port, err := net.SplitHostPort(check.Target)
+		if err != nil || host == "" || port == "" {
+			return fmt.Errorf("target %q must be host:port: %w", check.Target, domain.ErrInvalidHealthCheck)
+		}
+		if check.Protocol == domain.HealthCheckProtocolTCP && check.Path != "" {
+			return fmt.Errorf("a TCP health check has no path: %w", domain.ErrInvalidHealthCheck)
+		}
+		if check.ExpectedStatus != 0 || check.ExpectedBody != "" {
+			return fmt.Errorf("only HTTP and HTTPS health checks match a status or body: %w", domain.ErrInvalidHealthCheck)
+		}
+	default:
+		return fmt.Errorf("protocol %q is not HTTP, HTTPS, TCP or GRPC: %w", check.Protocol, domain.ErrInvalidHealthCheck)
+	}
+	if check.Interval == 0 {
+		check.Interval = defaultCheckInterval
+	}
+	if check.Interval < minCheckInterval || check.Interval > maxCheckInterval {
+		return fmt.Errorf("interval must be %d to %d seconds: %w", minCheckInterval, maxCheckInterval, domain.ErrInvalidHealthCheck)
+	}
+	if check.Timeout == 0 {
+		check.Timeout = min(defaultCheckTimeout, check.Interval)
+	}
+	if check.Timeout < 1 || check.Timeout > check.Interval {
+		return fmt.Errorf("timeout must be 1 second to the interval: %w", domain.ErrInvalidHealthCheck)
+	}
+	if check.FailureThreshold == 0 {
+		check.FailureThreshold = defaultCheckFailureThreshold
+	}
+	if check.SuccessThreshold == 0 {
+		check.SuccessThreshold = defaultCheckSuccessThreshold
+	}
+	if check.FailureThreshold < 1 || check.FailureThreshold > maxCheckThreshold || check.SuccessThreshold < 1 || check.SuccessThreshold > maxCheckThreshold {
+		return fmt.Errorf("thresholds must be 1 to %d probes: %w", maxCheckThreshold, domain.ErrInvalidHealthCheck)
+	}
+	return nil
+}
+
+// checkRecordHealthCheck returns domain.ErrInvalidHealthCheck if a record
+// references a health check the tenant does not have, or also probes its own
+// health check target.
+func checkRecordHealthCheck(ctx context.Context, repo ports.DNSRepository, tenantID string, record *domain.Record) error {
+	if record.HealthCheckID == nil {
+		return nil
+	}
+	if record.HealthCheckType != "" && record.HealthCheckType != domain.HealthCheckNone {
+		return fmt.Errorf("a record references a health check or has its own, not both: %w", domain.ErrInvalidHealthCheck)
+	}
+	checks, err := repo.ListHealthChecks(ctx, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to load health checks: %w", err)
+	}
+	if !slices.ContainsFunc(checks, func(c domain.HealthCheck) bool { return c.ID == *record.HealthCheckID }) {
+		return fmt.Errorf("health check %s does not exist: %w", *record.HealthCheckID, domain.ErrInvalidHealthCheck)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+func TestHealthCheckService(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepo{}
+	s := NewHealthCheckService(repo)
+
+	for _, check := range []domain.HealthCheck{
+		{Protocol: domain.HealthCheckProtocolHTTP, Target: "app.example"},
+		{Name: "app", Protocol: "UDP", Target: "app.example:53"},
+		{Name: "app", Protocol: domain.HealthCheckProtocolHTTPS, Target: "https://app.example/"},
+		{Name: "app", Protocol: domain.HealthCheckProtocolHTTP, Target: "app.example", Path: "healthz"},
+		{Name: "app", Protocol: domain.HealthCheckProtocolHTTP, Target: "app.example", ExpectedStatus: 700},
+		{Name: "app", Protocol: domain.HealthCheckProtocolTCP, Target: "app.example"},
+		{Name: "app", Protocol: domain.HealthCheckProtocolTCP, Target: "app.example:443", ExpectedBody: "ok"},
+		{Name: "app", Protocol: domain.HealthCheckProtocolGRPC, Target: "app.example:50051", Interval: 5},
+		{Name: "app", Protocol: domain.HealthCheckProtocolGRPC, Target: "app.example:50051", Interval: 10, Timeout: 11},
+		{Name: "app", Protocol: domain.HealthCheckProtocolTCP, Target: "app.example:443", FailureThreshold: 11},
+	} {
+		if err := s.CreateHealthCheck(ctx, "t1", &check); !errors.Is(err, domain.ErrInvalidHealthCheck) {
+			t.Errorf("Expected ErrInvalidHealthCheck for %+v, got %v", check, err)
+		}
+	}
+
+	check := domain.HealthCheck{Name: "app", Protocol: "https", Target: "app.example:8443", ExpectedBody: "ok"}
+	if err := s.CreateHealthCheck(ctx, "t1", &check); err != nil {
+		t.Fatalf("CreateHealthCheck failed: %v", err)
+	}
+	if check.ID == "" || check.Protocol != domain.HealthCheckProtocolHTTPS || check.Path != "/" || check.Interval != 30 ||
+		check.Timeout != 5 || check.FailureThreshold != 3 || check.SuccessThreshold != 2 || check.Status != domain.HealthStatusUnknown {
+		t.Errorf("Expected an ID and defaults, got %+v", check)
+	}
+	if err := s.CreateHealthCheck(ctx, "t1", &domain.HealthCheck{Name: "APP", Protocol: domain.HealthCheckProtocolTCP, Target: "app.example:443"}); !errors.Is(err, domain.ErrInvalidHealthCheck) {
+		t.Errorf("Expected a duplicate name to be rejected, got %v", err)
+	}
+
+	// Records reference health checks of their own tenant, instead of a probe
+	// of their own
+	svc := NewDNSService(repo, nil)
+	for _, rec := range []domain.Record{
+		{TenantID: "t2", ZoneID: "z1", Name: "www.check.test.", Type: domain.TypeA, Content: "192.0.2.1", HealthCheckID: &check.ID},
+		{TenantID: "t1", ZoneID: "z1", Name: "www.check.test.", Type: domain.TypeA, Content: "192.0.2.1", HealthCheckID: &check.ID, HealthCheckType: domain.HealthCheckTCP, HealthCheckTarget: "192.0.2.1:80"},
+	} {
+		if err := svc.CreateRecord(ctx, &rec); !errors.Is(err, domain.ErrInvalidHealthCheck) {
+			t.Errorf("Expected ErrInvalidHealthCheck for %+v, got %v", rec, err)
+		}
+	}
+	if err := svc.CreateRecord(ctx, &domain.Record{TenantID: "t1", ZoneID: "z1", Name: "www.check.test.", Type: domain.TypeA, Content: "192.0.2.1", HealthCheckID: &check.ID}); err != nil {
+		t.Fatalf("CreateRecord failed: %v", err)
+	}
+
+	// An update keeps the status the monitor recorded
+	_ = repo.UpdateHealthCheckStatus(ctx, check.ID, domain.HealthStatusUnhealthy, "timeout")
+	check.Interval = 60
+	if err := s.UpdateHealthCheck(ctx, "t2", &check); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected another tenant's health check to be missing, got %v", err)
+	}
+	if err := s.UpdateHealthCheck(ctx, "t1", &check); err != nil {
+		t.Fatalf("UpdateHealthCheck failed: %v", err)
+	}
+	if checks, _ := s.ListHealthChecks(ctx, "t1"); len(checks) != 1 || checks[0].Interval != 60 || checks[0].Status != domain.HealthStatusUnhealthy || checks[0].CreatedAt.IsZero() {
+		t.Errorf("Expected the updated health check with its status, got %+v", checks)
+	}
+
+	if err := s.DeleteHealthCheck(ctx, "t1", check.ID); !errors.Is(err, domain.ErrInvalidHealthCheck) {
+		t.Errorf("Expected a referenced health check to be kept, got %v", err)
+	}
+	repo.records = nil
+	if err := s.DeleteHealthCheck(ctx, "t2", check.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected another tenant's health check to be missing, got %v", err)
+	}
+	if err := s.DeleteHealthCheck(ctx, "t1", check.ID); err != nil {
+		t.Fatalf("DeleteHealthCheck failed: %v", err)
+	}
+}
@@ -13,24 +13,61 @@ import (
 	"github.com/poyrazK/cloudDNS/internal/core/ports"
 )
 
-// HealthMonitor manages background health checks for DNS records.
+// HealthMonitor manages background health checks for DNS records: the probes
+// records carry themselves, and the tenants' health checks records reference.
+// The DNS servers leave records failing their check out of answers.
 type HealthMonitor struct {
 	repo   ports.DNSRepository
 	logger *slog.Logger
 	client *http.Client
+	cache  ports.CacheInvalidator
+
+	// checkClient probes HTTP and HTTPS health checks, which bound each
+	// request by their own timeout and see redirects as they are.
+	checkClient *http.Client
+	probes      chan struct{}
+	checksMu    sync.Mutex
+	checks      map[string]*checkState
+	checksSync  time.Time
+	now         func() time.Time
+}
+
+// checkState is the monitor's view of a health check between probes.
+type checkState struct {
+	check     domain.HealthCheck
+	successes int // Passed probes in a row
+	failures  int // Failed probes in a row
+	next      time.Time
+	running   bool
 }
 
 // NewHealthMonitor creates a new HealthMonitor with a default HTTP client.
 func NewHealthMonitor(repo ports.DNSRepository, logger *slog.Logger) *HealthMonitor {
+	if logger == nil {
+		logger = slog.Default()
+	}
 	return &HealthMonitor{
 		repo:   repo,
 		logger: logger,
 		client: &http.Client{
 			Timeout: 5 * time.Second,
 		},
+		checkClient: &http.Client{
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		probes: make(chan struct{}, maxProbeWorkers),
+		checks: make(map[string]*checkState),
+		now:    time.Now,
 	}
 }
 
+// SetCacheInvalidator makes a record becoming healthy or unhealthy drop the
+// shared cache entries of its name. The nodes' own caches are flushed by the
+// database's change notification.
+func (m *HealthMonitor) SetCacheInvalidator(cache ports.CacheInvalidator) {
+	m.cache = cache
+}
+
 const maxProbeWorkers = 10
 
 // Start runs the health monitoring loop at the specified interval until the context is cancelled.
@@ -42,6 +79,7 @@ func (m *HealthMonitor) Start(ctx context.Context, interval time.Duration) {
 	defer ticker.Stop()
 
 	m.logger.Info("starting health monitor", "interval", interval)
+	go m.scheduleHealthChecks(ctx)
 
 	for {
 		select {
@@ -91,7 +129,15 @@ func (m *HealthMonitor) probeRecord(ctx context.Context, rec domain.Record) {
 
 	if err := m.repo.UpdateRecordHealth(ctx, rec.ID, status, errMsg); err != nil {
 		m.logger.Error("failed to update record health", "record_id", rec.ID, "error", err)
+		return
+	}
+	// Healthy and unknown records are both served, so only a move into or out
+	// of unhealthy changes answers.
+	if (rec.HealthStatus == domain.HealthStatusUnhealthy) == (status == domain.HealthStatusUnhealthy) {
+		return
 	}
+	m.logger.Info("record health changed", "record_id", rec.ID, "name", rec.Name, "type", rec.Type, "from", rec.HealthStatus, "to", status, "reason", errMsg)
+	invalidateRecords(ctx, m.cache, m.logger, []domain.Record{rec}, "health change")
 }
 
 func (m *HealthMonitor) probeHTTP(target string) (domain.HealthStatus, string) {
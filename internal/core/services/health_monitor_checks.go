@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+var (
+	// healthCheckTick is how often due health checks are started.
+	healthCheckTick = time.Second
+	// healthCheckSyncInterval is how often the health checks are reloaded.
+	healthCheckSyncInterval = 15 * time.Second
+)
+
+// maxCheckBody bounds how much of an HTTP(S) response is searched for the
+// expected body.
+const maxCheckBody = 64 << 10
+
+// scheduleHealthChecks probes every health check when it is due until ctx is
+// done.
+func (m *HealthMonitor) scheduleHealthChecks(ctx context.Context) {
+	ticker := time.NewTicker(healthCheckTick)
+	defer ticker.Stop()
+	for {
+		for _, st := range m.dueHealthChecks(ctx) {
+			go func(st *checkState) {
+				select {
+				case m.probes <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+				defer func() { <-m.probes }()
+				m.runHealthCheck(ctx, st)
+			}(st)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dueHealthChecks reloads the health checks when they are stale and returns
+// those due for a probe, marking them running.
+func (m *HealthMonitor) dueHealthChecks(ctx context.Context) []*checkState {
+	now := m.now()
+	m.checksMu.Lock()
+	stale := now.Sub(m.checksSync) >= healthCheckSyncInterval
+	m.checksMu.Unlock()
+	if stale {
+		m.syncHealthChecks(ctx)
+	}
+
+	m.checksMu.Lock()
+	defer m.checksMu.Unlock()
+	var due []*checkState
+	for _, st := range m.checks {
+		if st.running || now.Before(st.next) {
+			continue
+		}
+		st.running = true
+		st.next = now.Add(time.Duration(st.check.Interval) * time.Second)
+		due = append(due, st)
+	}
+	return due
+}
+
+// syncHealthChecks loads every tenant's health checks, keeping the probe
+// counts of those that did not change and the current set if they fail to
+// load. The stored status wins, as another node may have changed it.
+func (m *HealthMonitor) syncHealthChecks(ctx context.Context) {
+	checks, err := m.repo.ListHealthChecks(ctx, "")
+	if err != nil {
+		m.logger.Error("failed to load health checks", "error", err)
+		return
+	}
+	m.checksMu.Lock()
+	defer m.checksMu.Unlock()
+	m.checksSync = m.now()
+	states := make(map[string]*checkState, len(checks))
+	for _, check := range checks {
+		st, ok := m.checks[check.ID]
+		if !ok {
+			st = &checkState{}
+		} else if !st.check.UpdatedAt.Equal(check.UpdatedAt) {
+			st.successes, st.failures, st.next = 0, 0, time.Time{}
+		}
+		st.check = check
+		states[check.ID] = st
+	}
+	m.checks = states
+}
+
+// runHealthCheck probes a health check once and moves its status when the
+// probe completes a run of FailureThreshold failures or SuccessThreshold
+// passes. Records referencing it are then served or left out, and their
+// shared cache entries dropped.
+func (m *HealthMonitor) runHealthCheck(ctx context.Context, st *checkState) {
+	status, errMsg := m.probeHealthCheck(ctx, st.check)
+
+	m.checksMu.Lock()
+	st.running = false
+	check := st.check
+	if status == domain.HealthStatusHealthy {
+		st.successes++
+		st.failures = 0
+	} else {
+		st.failures++
+		st.successes = 0
+	}
+	changed := (status == domain.HealthStatusHealthy && check.Status != status && st.successes >= check.SuccessThreshold) ||
+		(status == domain.HealthStatusUnhealthy && check.Status != status && st.failures >= check.FailureThreshold)
+	m.checksMu.Unlock()
+	if !changed {
+		return
+	}
+
+	if err := m.repo.UpdateHealthCheckStatus(ctx, check.ID, status, errMsg); err != nil {
+		m.logger.Error("failed to update health check status", "check_id", check.ID, "error", err)
+		return
+	}
+	m.checksMu.Lock()
+	st.check.Status = status
+	st.check.LastError = errMsg
+	m.checksMu.Unlock()
+	m.logger.Info("health check status changed", "check_id", check.ID, "name", check.Name, "from", check.Status, "to", status, "reason", errMsg)
+
+	records, err := m.repo.ListHealthCheckRecords(ctx, check.ID)
+	if err != nil {
+		m.logger.Error("failed to load the health check's records", "check_id", check.ID, "error", err)
+		return
+	}
+	invalidateRecords(ctx, m.cache, m.logger, records, "health change")
+}
+
+// probeHealthCheck probes a health check's endpoint within its timeout.
+func (m *HealthMonitor) probeHealthCheck(ctx context.Context, check domain.HealthCheck) (domain.HealthStatus, string) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(check.Timeout)*time.Second)
+	defer cancel()
+	switch check.Protocol {
+	case domain.HealthCheckProtocolHTTP, domain.HealthCheckProtocolHTTPS:
+		return m.probeHTTPCheck(ctx, check)
+	case domain.HealthCheckProtocolTCP:
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", check.Target)
+		if err != nil {
+			return domain.HealthStatusUnhealthy, err.Error()
+		}
+		_ = conn.Close()
+		return domain.HealthStatusHealthy, ""
+	case domain.HealthCheckProtocolGRPC:
+		return probeGRPCCheck(ctx, check)
+	default:
+		return domain.HealthStatusUnhealthy, fmt.Sprintf("unknown protocol %q", check.Protocol)
+	}
+}
+
+// probeHTTPCheck passes when the response has the expected status, any 2xx
+// or 3xx if none is set, and contains the expected body.
+func (m *HealthMonitor) probeHTTPCheck(ctx context.Context, check domain.HealthCheck) (domain.HealthStatus, string) {
+	url := strings.ToLower(string(check.Protocol)) + "://" + check.Target + check.Path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return domain.HealthStatusUnhealthy, err.Error()
+	}
+	resp, err := m.checkClient.Do(req)
+	if err != nil {
+		return domain.HealthStatusUnhealthy, err.Error()
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if check.ExpectedStatus != 0 && resp.StatusCode != check.ExpectedStatus {
+		return domain.HealthStatusUnhealthy, fmt.Sprintf("HTTP status: %d, expected %d", resp.StatusCode, check.ExpectedStatus)
+	}
+	if check.ExpectedStatus == 0 && (resp.StatusCode < 200 || resp.StatusCode >= 400) {
+		return domain.HealthStatusUnhealthy, fmt.Sprintf("HTTP status: %d", resp.StatusCode)
+	}
+	if check.ExpectedBody != "" {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxCheckBody))
+		if err != nil {
+			return domain.HealthStatusUnhealthy, err.Error()
+		}
+		if !strings.Contains(string(body), check.ExpectedBody) {
+			return domain.HealthStatusUnhealthy, fmt.Sprintf("body does not contain %q", check.ExpectedBody)
+		}
+	}
+	return domain.HealthStatusHealthy, ""
+}
+
+// probeGRPCCheck asks the endpoint's gRPC health service (grpc.health.v1)
+// whether the service named by the check's path, or the whole server, is
+// serving.
+func probeGRPCCheck(ctx context.Context, check domain.HealthCheck) (domain.HealthStatus, string) {
+	conn, err := grpc.NewClient(check.Target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return domain.HealthStatusUnhealthy, err.Error()
+	}
+	defer func() { _ = conn.Close() }()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: check.Path})
+	if err != nil {
+		return domain.HealthStatusUnhealthy, err.Error()
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return domain.HealthStatusUnhealthy, "gRPC status: " + resp.GetStatus().String()
+	}
+	return domain.HealthStatusHealthy, ""
+}
@@ -2,14 +2,19 @@ package services
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/poyrazK/cloudDNS/internal/core/domain"
 	"github.com/poyrazK/cloudDNS/internal/testutil"
 	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 func TestHealthMonitor_ProbeHTTP(t *testing.T) {
@@ -102,3 +107,125 @@ func TestHealthMonitor_RunChecks(t *testing.T) {
 
 	repo.AssertExpectations(t)
 }
+
+func TestHealthMonitor_TransitionInvalidatesCache(t *testing.T) {
+	repo := &testutil.MockRepo{}
+	cache := &recordingInvalidator{}
+	m := NewHealthMonitor(repo, nil)
+	m.SetCacheInvalidator(cache)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	repo.On("UpdateRecordHealth", mock.Anything, "r1", domain.HealthStatusHealthy, "").Return(nil)
+	rec := domain.Record{ID: "r1", Name: "www.test.", Type: domain.TypeA, HealthCheckType: domain.HealthCheckHTTP, HealthCheckTarget: ts.URL}
+
+	// Unknown and healthy records are both served
+	rec.HealthStatus = domain.HealthStatusUnknown
+	m.probeRecord(context.Background(), rec)
+	if len(cache.invalidated) != 0 {
+		t.Fatalf("Expected no invalidation for a record already served, got %v", cache.invalidated)
+	}
+
+	rec.HealthStatus = domain.HealthStatusUnhealthy
+	m.probeRecord(context.Background(), rec)
+	if len(cache.invalidated) != 1 || cache.invalidated[0] != "www.test. A" {
+		t.Errorf("Expected the recovered record's name invalidated, got %v", cache.invalidated)
+	}
+}
+
+func TestHealthMonitor_HealthCheckThresholds(t *testing.T) {
+	ctx := context.Background()
+	var failing atomic.Bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() || r.URL.Path != "/healthz" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("status: ok"))
+	}))
+	defer ts.Close()
+
+	checkID := "c1"
+	repo := &mockRepo{
+		checks: []domain.HealthCheck{{
+			ID: checkID, TenantID: "t1", Name: "app", Protocol: domain.HealthCheckProtocolHTTP, Target: ts.Listener.Addr().String(),
+			Path: "/healthz", ExpectedBody: "ok", Interval: 30, Timeout: 2, FailureThreshold: 2, SuccessThreshold: 1,
+			Status: domain.HealthStatusHealthy,
+		}},
+		records: []domain.Record{{ID: "r1", Name: "www.check.test.", Type: domain.TypeA, HealthCheckID: &checkID}},
+	}
+	cache := &recordingInvalidator{}
+	m := NewHealthMonitor(repo, nil)
+	m.SetCacheInvalidator(cache)
+
+	due := m.dueHealthChecks(ctx)
+	if len(due) != 1 || len(m.dueHealthChecks(ctx)) != 0 {
+		t.Fatalf("Expected the check due once, got %d", len(due))
+	}
+	st := due[0]
+
+	failing.Store(true)
+	m.runHealthCheck(ctx, st)
+	if repo.checks[0].Status != domain.HealthStatusHealthy || len(cache.invalidated) != 0 {
+		t.Fatalf("Expected one failure to stay under the threshold, got %+v", repo.checks[0])
+	}
+	m.runHealthCheck(ctx, st)
+	if repo.checks[0].Status != domain.HealthStatusUnhealthy || repo.checks[0].LastError == "" {
+		t.Fatalf("Expected the check unhealthy after two failures, got %+v", repo.checks[0])
+	}
+	if len(cache.invalidated) != 1 || cache.invalidated[0] != "www.check.test. A" {
+		t.Errorf("Expected the referencing record invalidated, got %v", cache.invalidated)
+	}
+
+	failing.Store(false)
+	m.runHealthCheck(ctx, st)
+	if repo.checks[0].Status != domain.HealthStatusHealthy || len(cache.invalidated) != 2 {
+		t.Errorf("Expected the check healthy again after one pass, got %+v", repo.checks[0])
+	}
+}
+
+func TestHealthMonitor_ProbeHealthCheck(t *testing.T) {
+	ctx := context.Background()
+	m := NewHealthMonitor(nil, nil)
+
+	tls := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer tls.Close()
+	m.checkClient = tls.Client()
+	https := domain.HealthCheck{Protocol: domain.HealthCheckProtocolHTTPS, Target: tls.Listener.Addr().String(), Path: "/", Timeout: 2, ExpectedStatus: http.StatusNoContent}
+	if status, msg := m.probeHealthCheck(ctx, https); status != domain.HealthStatusHealthy {
+		t.Errorf("Expected the HTTPS check to pass, got %s (%s)", status, msg)
+	}
+	https.ExpectedStatus = http.StatusOK
+	if status, _ := m.probeHealthCheck(ctx, https); status != domain.HealthStatusUnhealthy {
+		t.Errorf("Expected an unexpected status to fail, got %s", status)
+	}
+
+	if status, _ := m.probeHealthCheck(ctx, domain.HealthCheck{Protocol: domain.HealthCheckProtocolTCP, Target: "localhost:1", Timeout: 1}); status != domain.HealthStatusUnhealthy {
+		t.Errorf("Expected a closed TCP port to fail, got %s", status)
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	hs := health.NewServer()
+	healthpb.RegisterHealthServer(srv, hs)
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	check := domain.HealthCheck{Protocol: domain.HealthCheckProtocolGRPC, Target: lis.Addr().String(), Path: "dns.Resolver", Timeout: 2}
+	hs.SetServingStatus("dns.Resolver", healthpb.HealthCheckResponse_NOT_SERVING)
+	if status, msg := m.probeHealthCheck(ctx, check); status != domain.HealthStatusUnhealthy || msg != "gRPC status: NOT_SERVING" {
+		t.Errorf("Expected a service not serving to fail, got %s (%s)", status, msg)
+	}
+	hs.SetServingStatus("dns.Resolver", healthpb.HealthCheckResponse_SERVING)
+	if status, msg := m.probeHealthCheck(ctx, check); status != domain.HealthStatusHealthy {
+		t.Errorf("Expected a serving service to pass, got %s (%s)", status, msg)
+	}
+}
@@ -0,0 +1,419 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/core/ports"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+)
+
+// Subnet defaults.
+const (
+	defaultNameTemplate = "ip-{ip}"
+	defaultSubnetTTL    = 3600
+	minSubnetTTL        = 60
+)
+
+// IPAMService manages a tenant's subnets and the addresses allocated from
+// them. Each allocation owns an A or AAAA record in its subnet's zone and a
+// PTR record in the tenant's reverse zone of the address, if it hosts one.
+type IPAMService struct {
+	repo   ports.DNSRepository
+	svc    ports.DNSService
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+// NewIPAMService creates a service that stores subnets in repo and writes the
+// records of allocations through svc, so change freezes, auditing and cache
+// invalidation apply.
+func NewIPAMService(repo ports.DNSRepository, svc ports.DNSService, logger *slog.Logger) *IPAMService {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &IPAMService{repo: repo, svc: svc, logger: logger, now: time.Now}
+}
+
+// CreateSubnet stores a new subnet of the tenant. It may not overlap the
+// tenant's other subnets.
+func (s *IPAMService) CreateSubnet(ctx context.Context, tenantID string, subnet *domain.Subnet) error {
+	subnets, err := s.repo.ListSubnets(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	if err := validateSubnet(subnet, subnets); err != nil {
+		return err
+	}
+	zone, err := s.repo.GetZoneByID(ctx, subnet.ZoneID, tenantID)
+	if err != nil {
+		return err
+	}
+	if zone == nil {
+		return fmt.Errorf("zone %s does not exist: %w", subnet.ZoneID, domain.ErrInvalidSubnet)
+	}
+	if zone.Role == "slave" {
+		return fmt.Errorf("zone %s is a secondary zone: %w", zone.Name, domain.ErrInvalidSubnet)
+	}
+	subnet.ID = uuid.New().String()
+	subnet.TenantID = tenantID
+	subnet.CreatedAt = s.now()
+	if err := s.repo.SaveSubnet(ctx, subnet); err != nil {
+		return err
+	}
+	s.audit(ctx, tenantID, "CREATE_SUBNET", subnet.ID, fmt.Sprintf("Created subnet %s of %s in %s", subnet.Name, subnet.CIDR, zone.Name))
+	return nil
+}
+
+// ListSubnets returns the tenant's subnets by name.
+func (s *IPAMService) ListSubnets(ctx context.Context, tenantID string) ([]domain.Subnet, error) {
+	subnets, err := s.repo.ListSubnets(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if subnets == nil {
+		subnets = []domain.Subnet{}
+	}
+	return subnets, nil
+}
+
+// DeleteSubnet removes one of the tenant's subnets that has no allocated
+// addresses.
+func (s *IPAMService) DeleteSubnet(ctx context.Context, tenantID string, id string) error {
+	if _, err := s.subnet(ctx, tenantID, id); err != nil {
+		return err
+	}
+	allocs, err := s.repo.ListIPAllocations(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to load the subnet's allocations: %w", err)
+	}
+	if len(allocs) > 0 {
+		return fmt.Errorf("subnet %s has %d allocated addresses: %w", id, len(allocs), domain.ErrInvalidSubnet)
+	}
+	deleted, err := s.repo.DeleteSubnet(ctx, id, tenantID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("subnet %s: %w", id, domain.ErrNotFound)
+	}
+	s.audit(ctx, tenantID, "DELETE_SUBNET", id, "Deleted subnet")
+	return nil
+}
+
+// AllocateAddress allocates alloc.Address, or the lowest free address if it is
+// empty, from the subnet alloc.SubnetID and creates its forward record and,
+// when the tenant hosts the address's reverse zone, its PTR record.
+func (s *IPAMService) AllocateAddress(ctx context.Context, tenantID string, alloc *domain.IPAllocation) error {
+	subnet, err := s.subnet(ctx, tenantID, alloc.SubnetID)
+	if err != nil {
+		return err
+	}
+	prefix, err := netip.ParsePrefix(subnet.CIDR)
+	if err != nil {
+		return fmt.Errorf("subnet %s has CIDR %q: %w", subnet.ID, subnet.CIDR, domain.ErrInvalidSubnet)
+	}
+	allocs, err := s.repo.ListIPAllocations(ctx, subnet.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load the subnet's allocations: %w", err)
+	}
+	used := make(map[netip.Addr]bool, len(allocs))
+	for _, a := range allocs {
+		if addr, err := netip.ParseAddr(a.Address); err == nil {
+			used[addr] = true
+		}
+	}
+	addr, err := pickAddress(prefix, strings.TrimSpace(alloc.Address), used)
+	if err != nil {
+		return err
+	}
+	alloc.Hostname = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(alloc.Hostname), "."))
+	if alloc.Hostname != "" && !validHostLabels(alloc.Hostname) {
+		return fmt.Errorf("hostname %q is not a valid DNS name: %w", alloc.Hostname, domain.ErrInvalidSubnet)
+	}
+	zone, err := s.repo.GetZoneByID(ctx, subnet.ZoneID, tenantID)
+	if err != nil {
+		return err
+	}
+	if zone == nil {
+		return fmt.Errorf("zone %s no longer exists: %w", subnet.ZoneID, domain.ErrInvalidSubnet)
+	}
+	label, err := renderNameTemplate(subnet.NameTemplate, addr, alloc.Hostname)
+	if err != nil {
+		return err
+	}
+
+	alloc.ID = uuid.New().String()
+	alloc.TenantID = tenantID
+	alloc.Address = addr.String()
+	alloc.Name = label + "." + dnsname.Fqdn(zone.Name)
+	alloc.PTRZoneID, alloc.PTRRecordID = nil, nil
+	alloc.CreatedAt = s.now()
+
+	recordType := domain.TypeA
+	if addr.Is6() {
+		recordType = domain.TypeAAAA
+	}
+	forward := &domain.Record{TenantID: tenantID, ZoneID: zone.ID, Name: alloc.Name, Type: recordType, Content: alloc.Address, TTL: subnet.TTL}
+	if err := s.svc.CreateRecord(ctx, forward); err != nil {
+		return fmt.Errorf("failed to create the forward record: %w", err)
+	}
+	alloc.RecordID = forward.ID
+
+	reverse, err := s.reverseZone(ctx, tenantID, addr)
+	if err != nil {
+		s.undo(ctx, tenantID, zone.ID, alloc)
+		return err
+	}
+	if reverse != nil {
+		ptrName, _ := dnsname.ReverseAddr(net.IP(addr.AsSlice()))
+		ptr := &domain.Record{TenantID: tenantID, ZoneID: reverse.ID, Name: ptrName, Type: domain.TypePTR, Content: alloc.Name, TTL: subnet.TTL}
+		if err := s.svc.CreateRecord(ctx, ptr); err != nil {
+			s.undo(ctx, tenantID, zone.ID, alloc)
+			return fmt.Errorf("failed to create the PTR record: %w", err)
+		}
+		alloc.PTRZoneID, alloc.PTRRecordID = &reverse.ID, &ptr.ID
+	}
+
+	if err := s.repo.SaveIPAllocation(ctx, alloc); err != nil {
+		s.undo(ctx, tenantID, zone.ID, alloc)
+		return err
+	}
+	s.audit(ctx, tenantID, "ALLOCATE_ADDRESS", alloc.ID, fmt.Sprintf("Allocated %s from subnet %s as %s", alloc.Address, subnet.Name, alloc.Name))
+	return nil
+}
+
+// ListAllocations returns the addresses allocated from one of the tenant's
+// subnets.
+func (s *IPAMService) ListAllocations(ctx context.Context, tenantID string, subnetID string) ([]domain.IPAllocation, error) {
+	if _, err := s.subnet(ctx, tenantID, subnetID); err != nil {
+		return nil, err
+	}
+	allocs, err := s.repo.ListIPAllocations(ctx, subnetID)
+	if err != nil {
+		return nil, err
+	}
+	if allocs == nil {
+		allocs = []domain.IPAllocation{}
+	}
+	return allocs, nil
+}
+
+// ReleaseAddress removes an allocation of one of the tenant's subnets after
+// deleting its PTR and forward records. The allocation is kept if they cannot
+// be deleted, as under a change freeze.
+func (s *IPAMService) ReleaseAddress(ctx context.Context, tenantID string, subnetID string, id string) error {
+	subnet, err := s.subnet(ctx, tenantID, subnetID)
+	if err != nil {
+		return err
+	}
+	allocs, err := s.repo.ListIPAllocations(ctx, subnetID)
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(allocs, func(a domain.IPAllocation) bool { return a.ID == id })
+	if i < 0 {
+		return fmt.Errorf("allocation %s: %w", id, domain.ErrNotFound)
+	}
+	alloc := allocs[i]
+	if alloc.PTRRecordID != nil && alloc.PTRZoneID != nil {
+		if err := s.svc.DeleteRecord(ctx, *alloc.PTRRecordID, *alloc.PTRZoneID, tenantID); err != nil {
+			return fmt.Errorf("failed to delete the PTR record: %w", err)
+		}
+	}
+	if err := s.svc.DeleteRecord(ctx, alloc.RecordID, subnet.ZoneID, tenantID); err != nil {
+		return fmt.Errorf("failed to delete the forward record: %w", err)
+	}
+	deleted, err := s.repo.DeleteIPAllocation(ctx, id, subnetID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("allocation %s: %w", id, domain.ErrNotFound)
+	}
+	s.audit(ctx, tenantID, "RELEASE_ADDRESS", id, fmt.Sprintf("Released %s from subnet %s", alloc.Address, subnet.Name))
+	return nil
+}
+
+// subnet returns one of the tenant's subnets.
+func (s *IPAMService) subnet(ctx context.Context, tenantID string, id string) (*domain.Subnet, error) {
+	subnets, err := s.repo.ListSubnets(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(subnets, func(n domain.Subnet) bool { return n.ID == id })
+	if i < 0 {
+		return nil, fmt.Errorf("subnet %s: %w", id, domain.ErrNotFound)
+	}
+	return &subnets[i], nil
+}
+
+// reverseZone returns the tenant's primary zone most closely enclosing the
+// PTR owner name of addr, or nil if it hosts none.
+func (s *IPAMService) reverseZone(ctx context.Context, tenantID string, addr netip.Addr) (*domain.Zone, error) {
+	ptrName, ok := dnsname.ReverseAddr(net.IP(addr.AsSlice()))
+	if !ok {
+		return nil, nil
+	}
+	zones, err := s.repo.ListZones(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load zones: %w", err)
+	}
+	var best *domain.Zone
+	for i := range zones {
+		z := &zones[i]
+		if z.Role == "slave" || !dnsname.IsSubdomain(ptrName, z.Name) {
+			continue
+		}
+		if best == nil || dnsname.CountLabels(z.Name) > dnsname.CountLabels(best.Name) {
+			best = z
+		}
+	}
+	return best, nil
+}
+
+// undo deletes the records created for an allocation that failed.
+func (s *IPAMService) undo(ctx context.Context, tenantID string, zoneID string, alloc *domain.IPAllocation) {
+	if alloc.PTRRecordID != nil && alloc.PTRZoneID != nil {
+		if err := s.svc.DeleteRecord(ctx, *alloc.PTRRecordID, *alloc.PTRZoneID, tenantID); err != nil {
+			s.logger.Warn("failed to delete the PTR record of a failed allocation", "address", alloc.Address, "error", err)
+		}
+	}
+	if err := s.svc.DeleteRecord(ctx, alloc.RecordID, zoneID, tenantID); err != nil {
+		s.logger.Warn("failed to delete the forward record of a failed allocation", "address", alloc.Address, "error", err)
+	}
+}
+
+func (s *IPAMService) audit(ctx context.Context, tenantID, action, id, details string) {
+	_ = s.repo.SaveAuditLog(ctx, &domain.AuditLog{
+		ID:           uuid.New().String(),
+		TenantID:     tenantID,
+		Action:       action,
+		ResourceType: "SUBNET",
+		ResourceID:   id,
+		Details:      details,
+		CreatedAt:    s.now(),
+	})
+}
+
+// validateSubnet checks a subnet against the tenant's others and fills in the
+// defaults of its name template and TTL.
+func validateSubnet(subnet *domain.Subnet, subnets []domain.Subnet) error {
+	subnet.Name = strings.TrimSpace(subnet.Name)
+	if subnet.Name == "" {
+		return fmt.Errorf("name is required: %w", domain.ErrInvalidSubnet)
+	}
+	prefix, err := netip.ParsePrefix(strings.TrimSpace(subnet.CIDR))
+	if err != nil {
+		return fmt.Errorf("CIDR %q: %w", subnet.CIDR, domain.ErrInvalidSubnet)
+	}
+	prefix = prefix.Masked()
+	subnet.CIDR = prefix.String()
+	for _, other := range subnets {
+		if strings.EqualFold(other.Name, subnet.Name) {
+			return fmt.Errorf("a subnet named %s already exists: %w", subnet.Name, domain.ErrInvalidSubnet)
+		}
+		if o, err := netip.ParsePrefix(other.CIDR); err == nil && o.Overlaps(prefix) {
+			return fmt.Errorf("%s overlaps subnet %s (%s): %w", subnet.CIDR, other.Name, other.CIDR, domain.ErrInvalidSubnet)
+		}
+	}
+	subnet.NameTemplate = strings.ToLower(strings.TrimSpace(subnet.NameTemplate))
+	if subnet.NameTemplate == "" {
+		subnet.NameTemplate = defaultNameTemplate
+	}
+	if _, err := renderNameTemplate(subnet.NameTemplate, prefix.Addr(), "host"); err != nil {
+		return err
+	}
+	if subnet.TTL == 0 {
+		subnet.TTL = defaultSubnetTTL
+	}
+	if subnet.TTL < minSubnetTTL {
+		return fmt.Errorf("TTL must be at least %d seconds: %w", minSubnetTTL, domain.ErrInvalidSubnet)
+	}
+	return nil
+}
+
+// pickAddress returns the requested address if it is free and usable in
+// prefix, or the lowest such address when none is requested.
+func pickAddress(prefix netip.Prefix, requested string, used map[netip.Addr]bool) (netip.Addr, error) {
+	if requested != "" {
+		addr, err := netip.ParseAddr(requested)
+		if err != nil || addr.Zone() != "" {
+			return netip.Addr{}, fmt.Errorf("address %q: %w", requested, domain.ErrInvalidSubnet)
+		}
+		if !usableAddress(prefix, addr) {
+			return netip.Addr{}, fmt.Errorf("address %s is not usable in %s: %w", addr, prefix, domain.ErrInvalidSubnet)
+		}
+		if used[addr] {
+			return netip.Addr{}, fmt.Errorf("address %s is already allocated: %w", addr, domain.ErrInvalidSubnet)
+		}
+		return addr, nil
+	}
+	for addr := prefix.Addr(); addr.IsValid() && prefix.Contains(addr); addr = addr.Next() {
+		if !used[addr] && usableAddress(prefix, addr) {
+			return addr, nil
+		}
+	}
+	return netip.Addr{}, fmt.Errorf("no free address in %s: %w", prefix, domain.ErrInvalidSubnet)
+}
+
+// usableAddress reports whether addr can be allocated from prefix. The
+// network address, and for IPv4 the broadcast address, are reserved except in
+// point-to-point prefixes.
+func usableAddress(prefix netip.Prefix, addr netip.Addr) bool {
+	if !prefix.Contains(addr) {
+		return false
+	}
+	if prefix.Bits() >= addr.BitLen()-1 {
+		return true
+	}
+	if addr == prefix.Addr() {
+		return false
+	}
+	return addr.Is6() || prefix.Contains(addr.Next())
+}
+
+// renderNameTemplate fills in a subnet's name template for an address,
+// returning the labels of the forward record below its zone.
+func renderNameTemplate(template string, addr netip.Addr, hostname string) (string, error) {
+	if strings.Contains(template, "{hostname}") && hostname == "" {
+		return "", fmt.Errorf("the name template %q needs a hostname: %w", template, domain.ErrInvalidSubnet)
+	}
+	ip := addr.String()
+	if addr.Is6() {
+		ip = addr.StringExpanded()
+	}
+	ip = strings.NewReplacer(".", "-", ":", "-").Replace(ip)
+	name := strings.NewReplacer("{ip}", ip, "{hostname}", hostname).Replace(template)
+	if !validHostLabels(name) {
+		return "", fmt.Errorf("the name template %q gives %q, not a valid DNS name: %w", template, name, domain.ErrInvalidSubnet)
+	}
+	return name, nil
+}
+
+// validHostLabels reports whether name is a sequence of dot-separated host
+// name labels of letters, digits, hyphens and underscores.
+func validHostLabels(name string) bool {
+	if name == "" || len(name) > 200 {
+		return false
+	}
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+func TestIPAMService(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepo{zones: []domain.Zone{
+		{ID: "fwd", TenantID: "t1", Name: "hosts.test."},
+		{ID: "rev", TenantID: "t1", Name: "2.0.192.in-addr.arpa."},
+	}}
+	s := NewIPAMService(repo, NewDNSService(repo, nil), nil)
+
+	for _, subnet := range []domain.Subnet{
+		{CIDR: "192.0.2.0/24", ZoneID: "fwd"},
+		{Name: "lan", CIDR: "192.0.2.0/33", ZoneID: "fwd"},
+		{Name: "lan", CIDR: "192.0.2.0/24", ZoneID: "missing"},
+		{Name: "lan", CIDR: "192.0.2.0/24", ZoneID: "fwd", NameTemplate: "host_{ip}!"},
+	} {
+		if err := s.CreateSubnet(ctx, "t1", &subnet); !errors.Is(err, domain.ErrInvalidSubnet) {
+			t.Errorf("Expected ErrInvalidSubnet for %+v, got %v", subnet, err)
+		}
+	}
+
+	lan := domain.Subnet{Name: "lan", CIDR: "192.0.2.7/30", ZoneID: "fwd"}
+	if err := s.CreateSubnet(ctx, "t1", &lan); err != nil {
+		t.Fatalf("CreateSubnet failed: %v", err)
+	}
+	if lan.CIDR != "192.0.2.4/30" || lan.NameTemplate != "ip-{ip}" || lan.TTL != 3600 {
+		t.Errorf("Expected a masked CIDR and defaults, got %+v", lan)
+	}
+	if err := s.CreateSubnet(ctx, "t1", &domain.Subnet{Name: "other", CIDR: "192.0.2.0/24", ZoneID: "fwd"}); !errors.Is(err, domain.ErrInvalidSubnet) {
+		t.Errorf("Expected an overlapping subnet to be rejected, got %v", err)
+	}
+
+	// The lowest usable address gets a forward record and, in the hosted
+	// reverse zone, a PTR record
+	first := domain.IPAllocation{SubnetID: lan.ID}
+	if err := s.AllocateAddress(ctx, "t1", &first); err != nil {
+		t.Fatalf("AllocateAddress failed: %v", err)
+	}
+	if first.Address != "192.0.2.5" || first.Name != "ip-192-0-2-5.hosts.test." || first.PTRZoneID == nil || *first.PTRZoneID != "rev" {
+		t.Errorf("Unexpected allocation %+v", first)
+	}
+	if len(repo.records) != 2 || repo.records[0].Type != domain.TypeA || repo.records[0].Content != "192.0.2.5" ||
+		repo.records[1].Name != "5.2.0.192.in-addr.arpa." || repo.records[1].Content != "ip-192-0-2-5.hosts.test." {
+		t.Errorf("Expected A and PTR records, got %+v", repo.records)
+	}
+
+	for _, address := range []string{"192.0.2.5", "192.0.2.7", "192.0.2.9"} {
+		if err := s.AllocateAddress(ctx, "t1", &domain.IPAllocation{SubnetID: lan.ID, Address: address}); !errors.Is(err, domain.ErrInvalidSubnet) {
+			t.Errorf("Expected %s to be refused, got %v", address, err)
+		}
+	}
+	second := domain.IPAllocation{SubnetID: lan.ID}
+	if err := s.AllocateAddress(ctx, "t1", &second); err != nil || second.Address != "192.0.2.6" {
+		t.Fatalf("Expected the next address, got %+v: %v", second, err)
+	}
+	if err := s.AllocateAddress(ctx, "t1", &domain.IPAllocation{SubnetID: lan.ID}); !errors.Is(err, domain.ErrInvalidSubnet) {
+		t.Errorf("Expected a full subnet to be refused, got %v", err)
+	}
+
+	if err := s.DeleteSubnet(ctx, "t1", lan.ID); !errors.Is(err, domain.ErrInvalidSubnet) {
+		t.Errorf("Expected a subnet with allocations to be kept, got %v", err)
+	}
+	if err := s.ReleaseAddress(ctx, "t1", lan.ID, "missing"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an unknown allocation, got %v", err)
+	}
+	for _, alloc := range []domain.IPAllocation{first, second} {
+		if err := s.ReleaseAddress(ctx, "t1", lan.ID, alloc.ID); err != nil {
+			t.Fatalf("ReleaseAddress failed: %v", err)
+		}
+	}
+	if len(repo.records) != 0 {
+		t.Errorf("Expected the records to be removed, got %+v", repo.records)
+	}
+	if err := s.DeleteSubnet(ctx, "t1", lan.ID); err != nil {
+		t.Errorf("DeleteSubnet failed: %v", err)
+	}
+}
+
+func TestIPAMServiceHostnameTemplate(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepo{zones: []domain.Zone{{ID: "fwd", TenantID: "t1", Name: "hosts.test."}}}
+	s := NewIPAMService(repo, NewDNSService(repo, nil), nil)
+
+	v6 := domain.Subnet{Name: "v6", CIDR: "2001:db8::/64", ZoneID: "fwd", NameTemplate: "{hostname}.v6"}
+	if err := s.CreateSubnet(ctx, "t1", &v6); err != nil {
+		t.Fatalf("CreateSubnet failed: %v", err)
+	}
+	if err := s.AllocateAddress(ctx, "t1", &domain.IPAllocation{SubnetID: v6.ID}); !errors.Is(err, domain.ErrInvalidSubnet) {
+		t.Errorf("Expected a hostname to be required, got %v", err)
+	}
+	alloc := domain.IPAllocation{SubnetID: v6.ID, Hostname: "DB-1"}
+	if err := s.AllocateAddress(ctx, "t1", &alloc); err != nil {
+		t.Fatalf("AllocateAddress failed: %v", err)
+	}
+	if alloc.Address != "2001:db8::1" || alloc.Name != "db-1.v6.hosts.test." || alloc.PTRRecordID != nil {
+		t.Errorf("Expected a forward record only, without a hosted reverse zone, got %+v", alloc)
+	}
+	if len(repo.records) != 1 || repo.records[0].Type != domain.TypeAAAA {
+		t.Errorf("Expected one AAAA record, got %+v", repo.records)
+	}
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/core/ports"
+)
+
+// DefaultHeartbeatInterval is how often a node refreshes its registry row.
+const DefaultHeartbeatInterval = 15 * time.Second
+
+// staleHeartbeats is the number of missed heartbeats after which a node is reported
+// as STALE rather than trusted to be up.
+const staleHeartbeats = 3
+
+// NodeRegistry publishes this node's heartbeat to the shared repository and reports
+// the status of the whole fleet.
+type NodeRegistry struct {
+	repo      ports.DNSRepository
+	self      domain.Node
+	readiness ports.ReadinessChecker
+	queries   func() uint64
+	failures  func() uint64
+	announced func() bool
+	apply     func(cfg map[string]string) error
+	interval  time.Duration
+	logger    *slog.Logger
+	now       func() time.Time
+
+	mu             sync.Mutex
+	lastCount      uint64
+	lastFailures   uint64
+	lastAt         time.Time
+	appliedVersion string
+	configErr      string
+}
+
+// NewNodeRegistry creates a registry for the node identified by self.ID. Version,
+// Address and StartedAt are taken from self; StartedAt defaults to now.
+func NewNodeRegistry(repo ports.DNSRepository, self domain.Node, logger *slog.Logger) *NodeRegistry {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if self.StartedAt.IsZero() {
+		self.StartedAt = time.Now()
+	}
+	return &NodeRegistry{
+		repo:     repo,
+		self:     self,
+		interval: DefaultHeartbeatInterval,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+// SetReadiness makes the heartbeat report the node readiness verdict as health.
+func (n *NodeRegistry) SetReadiness(r ports.ReadinessChecker) {
+	n.readiness = r
+}
+
+// SetQueryCounter provides the node's cumulative query count, used to derive QPS.
+func (n *NodeRegistry) SetQueryCounter(fn func() uint64) {
+	n.queries = fn
+}
+
+// SetErrorCounter provides the node's cumulative SERVFAIL count, used to derive the
+// error rate between heartbeats.
+func (n *NodeRegistry) SetErrorCounter(fn func() uint64) {
+	n.failures = fn
+}
+
+// SetConfigApplier enables desired-config polling: on each heartbeat the node's
+// config is fetched and, when it has changed, handed to fn. The applied version or
+// the apply error is reported in the heartbeat for rollout gating.
+func (n *NodeRegistry) SetConfigApplier(fn func(cfg map[string]string) error) {
+	n.apply = fn
+}
+
+// SetAnnouncementState provides whether the node is announcing its anycast route.
+func (n *NodeRegistry) SetAnnouncementState(fn func() bool) {
+	n.announced = fn
+}
+
+// Start sends a heartbeat immediately and then every interval until ctx is done.
+func (n *NodeRegistry) Start(ctx context.Context, interval time.Duration) {
+	if interval > 0 {
+		n.interval = interval
+	}
+	n.logger.Info("starting node registry", "node_id", n.self.ID, "interval", n.interval)
+
+	if err := n.Heartbeat(ctx); err != nil {
+		n.logger.Warn("node heartbeat failed", "error", err)
+	}
+	ticker := time.NewTicker(n.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := n.Heartbeat(ctx); err != nil {
+				n.logger.Warn("node heartbeat failed", "error", err)
+			}
+		}
+	}
+}
+
+// Heartbeat records this node's current status in the registry.
+func (n *NodeRegistry) Heartbeat(ctx context.Context) error {
+	node := n.self
+	node.LastHeartbeat = n.now()
+	node.Healthy = true
+	if n.readiness != nil {
+		node.Healthy = n.readiness.Readiness(ctx).Ready
+	}
+	if n.announced != nil {
+		node.AnycastAnnounced = n.announced()
+	}
+	node.QPS, node.ErrorRate = n.sampleRates(node.LastHeartbeat)
+	node.ConfigVersion, node.ConfigError = n.syncConfig(ctx)
+	return n.repo.UpsertNode(ctx, &node)
+}
+
+// syncConfig applies the node's desired config if it changed since the last
+// successful apply, and returns the applied version and any apply error.
+func (n *NodeRegistry) syncConfig(ctx context.Context) (string, string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.apply == nil {
+		return "", ""
+	}
+
+	cfg, err := n.repo.GetNodeConfig(ctx, n.self.ID)
+	if err != nil {
+		n.logger.Warn("failed to fetch node config", "error", err)
+		return n.appliedVersion, n.configErr
+	}
+	version := domain.ConfigVersion(cfg)
+	if version == n.appliedVersion {
+		n.configErr = ""
+		return n.appliedVersion, ""
+	}
+	if errApply := n.apply(cfg); errApply != nil {
+		n.logger.Error("failed to apply node config", "version", version, "error", errApply)
+		n.configErr = errApply.Error()
+		return n.appliedVersion, n.configErr
+	}
+	n.logger.Info("applied node config", "version", version)
+	n.appliedVersion = version
+	n.configErr = ""
+	return n.appliedVersion, ""
+}
+
+// sampleRates returns the query rate and SERVFAIL share since the previous heartbeat.
+func (n *NodeRegistry) sampleRates(now time.Time) (qps float64, errorRate float64) {
+	if n.queries == nil {
+		return 0, 0
+	}
+	count := n.queries()
+	var failures uint64
+	if n.failures != nil {
+		failures = n.failures()
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if !n.lastAt.IsZero() && count >= n.lastCount && failures >= n.lastFailures {
+		if elapsed := now.Sub(n.lastAt).Seconds(); elapsed > 0 {
+			qps = float64(count-n.lastCount) / elapsed
+		}
+		if count > n.lastCount {
+			errorRate = float64(failures-n.lastFailures) / float64(count-n.lastCount)
+		}
+	}
+	n.lastCount = count
+	n.lastFailures = failures
+	n.lastAt = now
+	return qps, errorRate
+}
+
+// ListNodes returns every registered node with its status derived from health and
+// heartbeat age.
+func (n *NodeRegistry) ListNodes(ctx context.Context) ([]domain.Node, error) {
+	nodes, err := n.repo.ListNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	staleBefore := n.now().Add(-staleHeartbeats * n.interval)
+	for i := range nodes {
+		switch {
+		case nodes[i].LastHeartbeat.Before(staleBefore):
+			nodes[i].Status = domain.NodeStatusStale
+		case !nodes[i].Healthy:
+			nodes[i].Status = domain.NodeStatusDegraded
+		default:
+			nodes[i].Status = domain.NodeStatusUp
+		}
+	}
+	return nodes, nil
+}
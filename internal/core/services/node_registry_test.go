@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+type fixedReadiness struct {
+	ready bool
+}
+
+func (f fixedReadiness) Readiness(_ context.Context) *domain.ReadinessReport {
+	return &domain.ReadinessReport{Ready: f.ready}
+}
+
+func TestNodeRegistry_Heartbeat(t *testing.T) {
+	repo := &mockRepo{}
+	reg := NewNodeRegistry(repo, domain.Node{ID: "node-a", Version: "v1.2.3", Address: "10.0.0.1:53"}, nil)
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	reg.now = func() time.Time { return now }
+	var served uint64
+	reg.SetQueryCounter(func() uint64 { return served })
+	reg.SetAnnouncementState(func() bool { return true })
+	reg.SetReadiness(fixedReadiness{ready: false})
+
+	if err := reg.Heartbeat(context.Background()); err != nil {
+		t.Fatalf("Heartbeat failed: %v", err)
+	}
+	served = 500
+	now = now.Add(10 * time.Second)
+	if err := reg.Heartbeat(context.Background()); err != nil {
+		t.Fatalf("Heartbeat failed: %v", err)
+	}
+
+	if len(repo.nodes) != 1 {
+		t.Fatalf("Expected a single registry row, got %d", len(repo.nodes))
+	}
+	n := repo.nodes[0]
+	if n.QPS != 50 || !n.AnycastAnnounced || n.Healthy || n.Version != "v1.2.3" || !n.LastHeartbeat.Equal(now) {
+		t.Errorf("Unexpected heartbeat row: %+v", n)
+	}
+}
+
+func TestNodeRegistry_ListNodesStatus(t *testing.T) {
+	now := time.Now()
+	repo := &mockRepo{nodes: []domain.Node{
+		{ID: "up", Healthy: true, LastHeartbeat: now},
+		{ID: "degraded", Healthy: false, LastHeartbeat: now},
+		{ID: "stale", Healthy: true, LastHeartbeat: now.Add(-time.Hour)},
+	}}
+	reg := NewNodeRegistry(repo, domain.Node{ID: "up"}, nil)
+
+	nodes, err := reg.ListNodes(context.Background())
+	if err != nil {
+		t.Fatalf("ListNodes failed: %v", err)
+	}
+	want := []domain.NodeStatus{domain.NodeStatusUp, domain.NodeStatusDegraded, domain.NodeStatusStale}
+	for i, n := range nodes {
+		if n.Status != want[i] {
+			t.Errorf("Node %s: status %s, want %s", n.ID, n.Status, want[i])
+		}
+	}
+
+	repo.err = errors.New("db down")
+	if _, err := reg.ListNodes(context.Background()); err == nil {
+		t.Error("Expected repository error")
+	}
+}
+
+func TestNodeRegistry_ConfigSync(t *testing.T) {
+	repo := &mockRepo{configs: map[string]map[string]string{"node-a": {"log_level": "debug"}}}
+	reg := NewNodeRegistry(repo, domain.Node{ID: "node-a"}, nil)
+	var applied []map[string]string
+	reg.SetConfigApplier(func(cfg map[string]string) error {
+		if cfg["log_level"] == "bogus" {
+			return errors.New("invalid log level")
+		}
+		applied = append(applied, cfg)
+		return nil
+	})
+	var served, failed uint64
+	reg.SetQueryCounter(func() uint64 { return served })
+	reg.SetErrorCounter(func() uint64 { return failed })
+
+	ctx := context.Background()
+	if err := reg.Heartbeat(ctx); err != nil {
+		t.Fatalf("Heartbeat failed: %v", err)
+	}
+	served, failed = 200, 50
+	if err := reg.Heartbeat(ctx); err != nil {
+		t.Fatalf("Heartbeat failed: %v", err)
+	}
+	n := repo.nodes[0]
+	if len(applied) != 1 || n.ConfigVersion != domain.ConfigVersion(repo.configs["node-a"]) || n.ConfigError != "" {
+		t.Errorf("Expected config applied once, got %d applies, row %+v", len(applied), n)
+	}
+	if n.ErrorRate != 0.25 {
+		t.Errorf("Expected error rate 0.25, got %v", n.ErrorRate)
+	}
+
+	goodVersion := n.ConfigVersion
+	repo.configs["node-a"] = map[string]string{"log_level": "bogus"}
+	if err := reg.Heartbeat(ctx); err != nil {
+		t.Fatalf("Heartbeat failed: %v", err)
+	}
+	n = repo.nodes[0]
+	if n.ConfigVersion != goodVersion || n.ConfigError != "invalid log level" {
+		t.Errorf("Expected previous version with apply error, got %+v", n)
+	}
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/core/ports"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+)
+
+// DefaultPropagationResolvers are the public resolvers asked by propagation
+// checks unless others are configured.
+var DefaultPropagationResolvers = []string{"8.8.8.8:53", "1.1.1.1:53"}
+
+// PropagationService checks whether a record has reached the public resolvers
+// users depend on and every node of the fleet, so a change can be confirmed as
+// globally visible instead of waiting out TTLs blindly.
+type PropagationService struct {
+	repo      ports.DNSRepository
+	nodes     ports.NodeLister
+	querier   ports.DNSQuerier // asks our nodes directly
+	resolver  ports.DNSQuerier // asks recursive resolvers
+	resolvers []string
+	logger    *slog.Logger
+}
+
+// NewPropagationService creates a checker that asks resolvers through resolver
+// and the UP nodes listed by nodes through querier. nodes may be nil, and an
+// empty resolver list uses DefaultPropagationResolvers.
+func NewPropagationService(repo ports.DNSRepository, nodes ports.NodeLister, querier ports.DNSQuerier, resolver ports.DNSQuerier, resolvers []string, logger *slog.Logger) *PropagationService {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if len(resolvers) == 0 {
+		resolvers = DefaultPropagationResolvers
+	}
+	return &PropagationService{repo: repo, nodes: nodes, querier: querier, resolver: resolver, resolvers: resolvers, logger: logger}
+}
+
+type propagationTarget struct {
+	address string
+	kind    string
+	nodeID  string
+	querier ports.DNSQuerier
+}
+
+// CheckRecordPropagation asks every resolver and node for a tenant's record and
+// reports which of them return it, with the remaining TTL they observed.
+func (s *PropagationService) CheckRecordPropagation(ctx context.Context, zoneID string, tenantID string, recordID string) (*domain.PropagationReport, error) {
+	rec, err := s.repo.GetRecord(ctx, recordID, zoneID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load record: %w", err)
+	}
+	if rec == nil {
+		return nil, fmt.Errorf("record %s: %w", recordID, domain.ErrNotFound)
+	}
+
+	targets := make([]propagationTarget, 0, len(s.resolvers))
+	for _, r := range s.resolvers {
+		targets = append(targets, propagationTarget{address: r, kind: "resolver", querier: s.resolver})
+	}
+	if s.nodes != nil {
+		nodes, errNodes := s.nodes.ListNodes(ctx)
+		if errNodes != nil {
+			s.logger.Warn("propagation check without fleet nodes", "error", errNodes)
+		}
+		sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+		for _, n := range nodes {
+			if n.Status == domain.NodeStatusUp && n.Address != "" {
+				targets = append(targets, propagationTarget{address: n.Address, kind: "node", nodeID: n.ID, querier: s.querier})
+			}
+		}
+	}
+
+	report := &domain.PropagationReport{
+		RecordID:  rec.ID,
+		Name:      rec.Name,
+		Type:      rec.Type,
+		Content:   rec.Content,
+		TTL:       rec.TTL,
+		Total:     len(targets),
+		CheckedAt: time.Now(),
+		Results:   make([]domain.PropagationResult, len(targets)),
+	}
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, maxProbeWorkers)
+	for i, t := range targets {
+		wg.Add(1)
+		go func(i int, t propagationTarget) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			report.Results[i] = checkPropagation(ctx, t, rec)
+		}(i, t)
+	}
+	wg.Wait()
+
+	for _, r := range report.Results {
+		metrics.PropagationResults.WithLabelValues(r.Status).Inc()
+		if r.Status == domain.PropagationVisible {
+			report.Propagated++
+		}
+	}
+	return report, nil
+}
+
+func checkPropagation(ctx context.Context, t propagationTarget, rec *domain.Record) domain.PropagationResult {
+	res := domain.PropagationResult{Resolver: t.address, Kind: t.kind, NodeID: t.nodeID, Answer: []string{}}
+	answer, err := t.querier.Query(ctx, t.address, dnsname.Fqdn(rec.Name), rec.Type)
+	if err != nil {
+		res.Status = domain.PropagationError
+		res.Error = err.Error()
+		return res
+	}
+	res.Rcode = answer.Rcode
+	res.Status = domain.PropagationMissing
+	for _, a := range answer.Records {
+		res.Answer = append(res.Answer, string(a.Type)+" "+a.Content)
+		if a.Type != rec.Type {
+			continue // e.g. the CNAME chain in front of the record
+		}
+		if sameContent(rec.Type, a.Content, rec.Content) {
+			res.Status = domain.PropagationVisible
+			res.TTL = a.TTL
+		} else if res.Status == domain.PropagationMissing {
+			res.Status = domain.PropagationDifferent
+		}
+	}
+	return res
+}
+
+// sameContent compares record data the way it is written on the wire: addresses
+// by value, and names and text regardless of case, trailing dots and quotes.
+func sameContent(t domain.RecordType, a, b string) bool {
+	if t == domain.TypeA || t == domain.TypeAAAA {
+		ipA, ipB := net.ParseIP(a), net.ParseIP(b)
+		return ipA != nil && ipA.Equal(ipB)
+	}
+	normalize := func(s string) string {
+		return strings.TrimSuffix(strings.Trim(strings.TrimSpace(s), `"`), ".")
+	}
+	return strings.EqualFold(normalize(a), normalize(b))
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// answerQuerier returns a fixed answer per server address.
+type answerQuerier struct {
+	answers map[string][]domain.Record
+	down    map[string]bool
+}
+
+func (q *answerQuerier) Query(_ context.Context, server string, _ string, _ domain.RecordType) (*domain.ProbeAnswer, error) {
+	if q.down[server] {
+		return nil, errors.New("i/o timeout")
+	}
+	if q.answers[server] == nil {
+		return &domain.ProbeAnswer{Rcode: 3}, nil
+	}
+	return &domain.ProbeAnswer{Records: q.answers[server]}, nil
+}
+
+func TestCheckRecordPropagation(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepo{
+		records: []domain.Record{{ID: "r1", ZoneID: "z1", Name: "www.example.com.", Type: domain.TypeAAAA, Content: "2001:DB8::1", TTL: 300}},
+		nodes: []domain.Node{
+			{ID: "b", Address: "10.0.0.2:53", Status: domain.NodeStatusUp},
+			{ID: "a", Address: "10.0.0.1:53", Status: domain.NodeStatusUp},
+			{ID: "c", Address: "10.0.0.3:53", Status: domain.NodeStatusStale},
+		},
+	}
+	resolver := &answerQuerier{
+		answers: map[string][]domain.Record{
+			"8.8.8.8:53": {
+				{Type: domain.TypeCNAME, Content: "edge.example.net."},
+				{Type: domain.TypeAAAA, Content: "2001:db8::1", TTL: 120},
+			},
+			"1.1.1.1:53": {{Type: domain.TypeAAAA, Content: "2001:db8::99", TTL: 40}},
+		},
+		down: map[string]bool{"9.9.9.9:53": true},
+	}
+	nodes := &answerQuerier{answers: map[string][]domain.Record{
+		"10.0.0.1:53": {{Type: domain.TypeAAAA, Content: "2001:db8::1", TTL: 300}},
+	}}
+	svc := NewPropagationService(repo, repo, nodes, resolver, []string{"8.8.8.8:53", "1.1.1.1:53", "9.9.9.9:53"}, nil)
+
+	if _, err := svc.CheckRecordPropagation(ctx, "z1", "t1", "missing"); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("Expected an unknown record to be not found, got %v", err)
+	}
+
+	report, err := svc.CheckRecordPropagation(ctx, "z1", "t1", "r1")
+	if err != nil {
+		t.Fatalf("CheckRecordPropagation failed: %v", err)
+	}
+	if report.Total != 5 || report.Propagated != 2 {
+		t.Fatalf("Expected 2 of 5 targets to see the record, got %d of %d", report.Propagated, report.Total)
+	}
+	want := []struct {
+		resolver, kind, status string
+		ttl                    int
+	}{
+		{"8.8.8.8:53", "resolver", domain.PropagationVisible, 120},
+		{"1.1.1.1:53", "resolver", domain.PropagationDifferent, 0},
+		{"9.9.9.9:53", "resolver", domain.PropagationError, 0},
+		{"10.0.0.1:53", "node", domain.PropagationVisible, 300},
+		{"10.0.0.2:53", "node", domain.PropagationMissing, 0},
+	}
+	for i, w := range want {
+		r := report.Results[i]
+		if r.Resolver != w.resolver || r.Kind != w.kind || r.Status != w.status || r.TTL != w.ttl {
+			t.Errorf("Result %d: expected %+v, got %+v", i, w, r)
+		}
+	}
+	if report.Results[4].Rcode != 3 {
+		t.Errorf("Expected the NXDOMAIN rcode to be reported, got %d", report.Results[4].Rcode)
+	}
+}
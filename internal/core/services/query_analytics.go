@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/sketch"
+)
+
+// DefaultQueryAnalyticsWindow is how long query analytics accumulate before they
+// are reset.
+const DefaultQueryAnalyticsWindow = time.Hour
+
+const (
+	// analyticsPrecision sizes the unique client and name estimators: 16384
+	// registers, about 0.8% standard error.
+	analyticsPrecision = 14
+	// analyticsNameWidth and analyticsNameDepth size the shared per-name counter
+	// sketch at 2 MiB.
+	analyticsNameWidth = 1 << 16
+	analyticsNameDepth = 4
+	// hotNamesPerZone is how many of each zone's most queried names are tracked.
+	hotNamesPerZone = 10
+)
+
+// QueryAnalytics estimates unique clients, unique names and each zone's hot names
+// without keeping per-query state, so memory stays fixed regardless of query rate
+// or name cardinality. Unique counts see every query; hot name tracking, which
+// takes a lock, only sees a 1-in-sampleRate sample and scales its counts back up.
+type QueryAnalytics struct {
+	sampleRate uint64
+	seq        atomic.Uint64
+	window     atomic.Pointer[analyticsWindow]
+	logger     *slog.Logger
+
+	zonesMu sync.RWMutex
+	zones   map[string]struct{} // Normalized names of zones seen answering queries
+}
+
+// analyticsWindow holds the sketches of one reporting window. Windows are replaced
+// whole, so observers never see a partially reset window.
+type analyticsWindow struct {
+	start      time.Time
+	queries    atomic.Uint64
+	clients    *sketch.HyperLogLog
+	names      *sketch.HyperLogLog
+	nameCounts *sketch.CountMin
+
+	mu    sync.Mutex
+	zones map[string]*zoneWindow
+}
+
+type zoneWindow struct {
+	queries uint64 // Sampled queries
+	hot     *sketch.TopK
+}
+
+func newAnalyticsWindow(start time.Time) *analyticsWindow {
+	return &analyticsWindow{
+		start:      start,
+		clients:    sketch.NewHyperLogLog(analyticsPrecision),
+		names:      sketch.NewHyperLogLog(analyticsPrecision),
+		nameCounts: sketch.NewCountMin(analyticsNameWidth, analyticsNameDepth),
+		zones:      make(map[string]*zoneWindow),
+	}
+}
+
+// NewQueryAnalytics creates analytics that track hot names from one in every
+// sampleRate queries. A non-positive rate tracks every query.
+func NewQueryAnalytics(sampleRate int, logger *slog.Logger) *QueryAnalytics {
+	if sampleRate < 1 {
+		sampleRate = 1
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	a := &QueryAnalytics{
+		sampleRate: uint64(sampleRate),
+		logger:     logger,
+		zones:      make(map[string]struct{}),
+	}
+	a.window.Store(newAnalyticsWindow(time.Now()))
+	return a
+}
+
+// ObserveQuery records an answered query.
+func (a *QueryAnalytics) ObserveQuery(zoneName string, name string, clientIP string) {
+	w := a.window.Load()
+	name = dnsname.Normalize(name)
+	w.queries.Add(1)
+	w.clients.Add(clientIP)
+	w.names.Add(name)
+
+	if zoneName != "" {
+		zoneName = a.learnZone(zoneName)
+	}
+	if a.seq.Add(1)%a.sampleRate != 0 {
+		return
+	}
+	if zoneName == "" {
+		var ok bool
+		if zoneName, ok = a.lookupZone(name); !ok {
+			return
+		}
+	}
+
+	count := w.nameCounts.Add(name, 1)
+	w.mu.Lock()
+	z := w.zones[zoneName]
+	if z == nil {
+		z = &zoneWindow{hot: sketch.NewTopK(hotNamesPerZone)}
+		w.zones[zoneName] = z
+	}
+	z.queries++
+	z.hot.Offer(name, count)
+	w.mu.Unlock()
+}
+
+func (a *QueryAnalytics) learnZone(zoneName string) string {
+	zoneName = dnsname.Normalize(zoneName)
+	a.zonesMu.RLock()
+	_, known := a.zones[zoneName]
+	a.zonesMu.RUnlock()
+	if !known {
+		a.zonesMu.Lock()
+		a.zones[zoneName] = struct{}{}
+		a.zonesMu.Unlock()
+	}
+	return zoneName
+}
+
+// lookupZone finds the closest enclosing zone of a cached answer's name.
+func (a *QueryAnalytics) lookupZone(name string) (string, bool) {
+	a.zonesMu.RLock()
+	defer a.zonesMu.RUnlock()
+	for {
+		if _, ok := a.zones[name]; ok {
+			return name, true
+		}
+		parent, ok := dnsname.Parent(name)
+		if !ok {
+			return "", false
+		}
+		name = parent
+	}
+}
+
+// Start resets the analytics every window until ctx is done.
+func (a *QueryAnalytics) Start(ctx context.Context, window time.Duration) {
+	if window <= 0 {
+		window = DefaultQueryAnalyticsWindow
+	}
+	a.logger.Info("starting query analytics", "window", window, "sample_rate", a.sampleRate)
+
+	ticker := time.NewTicker(window)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.window.Store(newAnalyticsWindow(time.Now()))
+		}
+	}
+}
+
+// QueryStats returns the estimates for the current window. A non-empty zone limits
+// the result to that zone; a positive limit keeps only the busiest zones.
+func (a *QueryAnalytics) QueryStats(zone string, limit int) *domain.QueryStats {
+	w := a.window.Load()
+	stats := &domain.QueryStats{
+		WindowStart:   w.start,
+		SampleRate:    int(a.sampleRate), // #nosec G115
+		Queries:       w.queries.Load(),
+		UniqueClients: w.clients.Estimate(),
+		UniqueNames:   w.names.Estimate(),
+		Zones:         []domain.ZoneQueryStats{},
+	}
+	if zone != "" {
+		zone = dnsname.Normalize(zone)
+	}
+
+	w.mu.Lock()
+	for name, z := range w.zones {
+		if zone != "" && name != zone {
+			continue
+		}
+		zs := domain.ZoneQueryStats{Zone: name, Queries: z.queries * a.sampleRate, HotNames: []domain.HotName{}}
+		for _, item := range z.hot.List() {
+			zs.HotNames = append(zs.HotNames, domain.HotName{Name: item.Key, Count: item.Count * a.sampleRate})
+		}
+		stats.Zones = append(stats.Zones, zs)
+	}
+	w.mu.Unlock()
+
+	sort.Slice(stats.Zones, func(i, j int) bool {
+		if stats.Zones[i].Queries != stats.Zones[j].Queries {
+			return stats.Zones[i].Queries > stats.Zones[j].Queries
+		}
+		return stats.Zones[i].Zone < stats.Zones[j].Zone
+	})
+	if limit > 0 && len(stats.Zones) > limit {
+		stats.Zones = stats.Zones[:limit]
+	}
+	return stats
+}
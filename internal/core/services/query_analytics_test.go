@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestQueryAnalytics_Observe(t *testing.T) {
+	a := NewQueryAnalytics(1, nil)
+	for i := 0; i < 100; i++ {
+		a.ObserveQuery("Example.com.", "WWW.example.com.", fmt.Sprintf("192.0.2.%d", i%10))
+	}
+	for i := 0; i < 30; i++ {
+		a.ObserveQuery("example.com.", fmt.Sprintf("host%d.example.com.", i), "192.0.2.1")
+	}
+	a.ObserveQuery("other.test.", "other.test.", "198.51.100.1")
+
+	stats := a.QueryStats("", 0)
+	if stats.Queries != 131 || stats.UniqueClients != 11 || stats.UniqueNames != 32 {
+		t.Errorf("Unexpected totals: %+v", stats)
+	}
+	if len(stats.Zones) != 2 || stats.Zones[0].Zone != "example.com." || stats.Zones[0].Queries != 130 {
+		t.Fatalf("Expected zones ordered by queries, got %+v", stats.Zones)
+	}
+	hot := stats.Zones[0].HotNames
+	if len(hot) != hotNamesPerZone || hot[0].Name != "www.example.com." || hot[0].Count != 100 {
+		t.Errorf("Expected www as the hottest of %d names, got %+v", hotNamesPerZone, hot)
+	}
+
+	if one := a.QueryStats("OTHER.test", 0); len(one.Zones) != 1 || one.Zones[0].Zone != "other.test." {
+		t.Errorf("Expected zone filter to match case-insensitively, got %+v", one.Zones)
+	}
+	if top := a.QueryStats("", 1); len(top.Zones) != 1 {
+		t.Errorf("Expected limit to keep one zone, got %d", len(top.Zones))
+	}
+}
+
+func TestQueryAnalytics_CachedAndSampled(t *testing.T) {
+	a := NewQueryAnalytics(4, nil)
+
+	// Cache hits before the zone has been seen cannot be attributed
+	for i := 0; i < 8; i++ {
+		a.ObserveQuery("", "www.example.com.", "192.0.2.1")
+	}
+	if stats := a.QueryStats("", 0); stats.Queries != 8 || len(stats.Zones) != 0 {
+		t.Errorf("Expected unattributed cache hits, got %+v", stats)
+	}
+
+	a.ObserveQuery("example.com.", "example.com.", "192.0.2.1")
+	for i := 0; i < 39; i++ {
+		a.ObserveQuery("", "www.example.com.", "192.0.2.1")
+	}
+	stats := a.QueryStats("example.com.", 0)
+	if len(stats.Zones) != 1 || stats.SampleRate != 4 {
+		t.Fatalf("Expected cached queries attributed to example.com., got %+v", stats)
+	}
+	// 40 queries after learning the zone at a 1-in-4 sample scale back to about 40
+	if z := stats.Zones[0]; z.Queries != 40 || z.HotNames[0].Name != "www.example.com." || z.HotNames[0].Count%4 != 0 {
+		t.Errorf("Unexpected sampled zone stats: %+v", z)
+	}
+}
+
+func TestQueryAnalytics_WindowReset(t *testing.T) {
+	a := NewQueryAnalytics(1, nil)
+	a.ObserveQuery("example.com.", "example.com.", "192.0.2.1")
+	first := a.QueryStats("", 0).WindowStart
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		a.Start(ctx, 10*time.Millisecond)
+	}()
+	deadline := time.Now().Add(time.Second)
+	for a.QueryStats("", 0).Queries != 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	wg.Wait()
+
+	stats := a.QueryStats("", 0)
+	if stats.Queries != 0 || len(stats.Zones) != 0 || !stats.WindowStart.After(first) {
+		t.Errorf("Expected a fresh window, got %+v", stats)
+	}
+
+	// Zones learned in earlier windows still attribute cache hits
+	a.ObserveQuery("", "www.example.com.", "192.0.2.1")
+	if stats := a.QueryStats("", 0); len(stats.Zones) != 1 {
+		t.Errorf("Expected cache hit attributed after reset, got %+v", stats.Zones)
+	}
+}
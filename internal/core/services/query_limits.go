@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/core/ports"
+)
+
+// QueryLimitService manages the query rate limits of tenants and their zones.
+// The DNS servers reload the limits periodically and enforce them per node.
+type QueryLimitService struct {
+	repo ports.DNSRepository
+	now  func() time.Time
+}
+
+// NewQueryLimitService creates a service that stores query limits in repo.
+func NewQueryLimitService(repo ports.DNSRepository) *QueryLimitService {
+	return &QueryLimitService{repo: repo, now: time.Now}
+}
+
+// SetQueryLimit creates or replaces the limit of a tenant, or of one of its
+// zones when ZoneID is set. A missing burst allows one second's worth of queries.
+func (s *QueryLimitService) SetQueryLimit(ctx context.Context, limit *domain.QueryLimit) error {
+	if limit.TenantID == "" {
+		return fmt.Errorf("tenant is required: %w", domain.ErrInvalidQueryLimit)
+	}
+	if limit.QPS <= 0 || math.IsInf(limit.QPS, 0) || math.IsNaN(limit.QPS) {
+		return fmt.Errorf("qps must be positive: %w", domain.ErrInvalidQueryLimit)
+	}
+	if limit.Burst == 0 {
+		limit.Burst = int(math.Ceil(limit.QPS))
+	}
+	if limit.Burst < 1 {
+		return fmt.Errorf("burst must be positive: %w", domain.ErrInvalidQueryLimit)
+	}
+	scope := "all zones"
+	if limit.ZoneID != "" {
+		zone, err := s.repo.GetZoneByID(ctx, limit.ZoneID, limit.TenantID)
+		if err != nil {
+			return fmt.Errorf("failed to load zone: %w", err)
+		}
+		if zone == nil {
+			return fmt.Errorf("zone %s: %w", limit.ZoneID, domain.ErrNotFound)
+		}
+		scope = zone.Name
+	}
+	limit.UpdatedAt = s.now()
+	if err := s.repo.SaveQueryLimit(ctx, limit); err != nil {
+		return err
+	}
+	s.audit(ctx, limit.TenantID, "SET_QUERY_LIMIT", limit.ZoneID, fmt.Sprintf("%g qps (burst %d) for %s", limit.QPS, limit.Burst, scope))
+	return nil
+}
+
+// ListQueryLimits returns a tenant's query limits, or every tenant's when
+// tenantID is empty.
+func (s *QueryLimitService) ListQueryLimits(ctx context.Context, tenantID string) ([]domain.QueryLimit, error) {
+	limits, err := s.repo.ListQueryLimits(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if limits == nil {
+		limits = []domain.QueryLimit{}
+	}
+	return limits, nil
+}
+
+// DeleteQueryLimit removes the limit of a tenant, or of one of its zones.
+func (s *QueryLimitService) DeleteQueryLimit(ctx context.Context, tenantID string, zoneID string) error {
+	deleted, err := s.repo.DeleteQueryLimit(ctx, tenantID, zoneID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("no query limit for tenant %s and zone %q: %w", tenantID, zoneID, domain.ErrNotFound)
+	}
+	s.audit(ctx, tenantID, "DELETE_QUERY_LIMIT", zoneID, "")
+	return nil
+}
+
+func (s *QueryLimitService) audit(ctx context.Context, tenantID, action, id, details string) {
+	_ = s.repo.SaveAuditLog(ctx, &domain.AuditLog{
+		ID:           uuid.New().String(),
+		TenantID:     tenantID,
+		Action:       action,
+		ResourceType: "QUERY_LIMIT",
+		ResourceID:   id,
+		Details:      details,
+		CreatedAt:    s.now(),
+	})
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+func TestQueryLimitService(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepo{zones: []domain.Zone{{ID: "z1", TenantID: "t1", Name: "limit.test."}}}
+	s := NewQueryLimitService(repo)
+
+	for _, limit := range []domain.QueryLimit{
+		{QPS: 10},
+		{TenantID: "t1"},
+		{TenantID: "t1", QPS: -1},
+		{TenantID: "t1", QPS: 10, Burst: -5},
+	} {
+		if err := s.SetQueryLimit(ctx, &limit); !errors.Is(err, domain.ErrInvalidQueryLimit) {
+			t.Errorf("Expected ErrInvalidQueryLimit for %+v, got %v", limit, err)
+		}
+	}
+	if err := s.SetQueryLimit(ctx, &domain.QueryLimit{TenantID: "t2", ZoneID: "z1", QPS: 10}); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected another tenant's zone to be rejected, got %v", err)
+	}
+
+	if err := s.SetQueryLimit(ctx, &domain.QueryLimit{TenantID: "t1", QPS: 2.5}); err != nil {
+		t.Fatalf("SetQueryLimit failed: %v", err)
+	}
+	if err := s.SetQueryLimit(ctx, &domain.QueryLimit{TenantID: "t1", ZoneID: "z1", QPS: 1, Burst: 4}); err != nil {
+		t.Fatalf("SetQueryLimit failed: %v", err)
+	}
+	if err := s.SetQueryLimit(ctx, &domain.QueryLimit{TenantID: "t1", ZoneID: "z1", QPS: 5}); err != nil {
+		t.Fatalf("SetQueryLimit failed: %v", err)
+	}
+
+	limits, err := s.ListQueryLimits(ctx, "t1")
+	if err != nil || len(limits) != 2 {
+		t.Fatalf("Expected two limits, got %+v, %v", limits, err)
+	}
+	if limits[0].Burst != 3 || limits[0].UpdatedAt.IsZero() {
+		t.Errorf("Expected the burst to default to one second of queries, got %+v", limits[0])
+	}
+	if limits[1].QPS != 5 || limits[1].Burst != 5 {
+		t.Errorf("Expected the zone limit to be replaced, got %+v", limits[1])
+	}
+	if other, _ := s.ListQueryLimits(ctx, "t2"); other == nil || len(other) != 0 {
+		t.Errorf("Expected an empty list for another tenant, got %+v", other)
+	}
+
+	if err := s.DeleteQueryLimit(ctx, "t1", "z1"); err != nil {
+		t.Fatalf("DeleteQueryLimit failed: %v", err)
+	}
+	if err := s.DeleteQueryLimit(ctx, "t1", "z1"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a removed limit, got %v", err)
+	}
+	if len(repo.audits) != 4 {
+		t.Errorf("Expected the changes to be audited, got %d entries", len(repo.audits))
+	}
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/core/ports"
+)
+
+// ReadinessCheckBGP is the name of the BGP session check. The anycast manager
+// ignores it when deciding whether to announce, since it owns the announcement.
+const ReadinessCheckBGP = "bgp"
+
+// readinessTimeout bounds each dependency check so a single hung backend cannot
+// stall the probe past typical orchestrator timeouts.
+const readinessTimeout = 2 * time.Second
+
+type readinessCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// ReadinessService runs dependency checks that decide whether the node is ready
+// to receive traffic, recording per-dependency latency.
+type ReadinessService struct {
+	mu     sync.RWMutex
+	checks []readinessCheck
+	logger *slog.Logger
+}
+
+// NewReadinessService creates a ReadinessService with checks for the database,
+// zone data availability and, if configured, the Redis cache.
+func NewReadinessService(repo ports.DNSRepository, cache ports.CacheInvalidator, logger *slog.Logger) *ReadinessService {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	s := &ReadinessService{logger: logger}
+	if repo != nil {
+		s.AddCheck("postgres", repo.Ping)
+		// Zone data is read on demand, so "loaded" means the zone table is queryable,
+		// checked by reading at most one row however many zones there are. An empty
+		// zone set is valid for a fresh install that still needs API traffic.
+		s.AddCheck("zones", func(ctx context.Context) error {
+			_, err := repo.HasZones(ctx)
+			return err
+		})
+	}
+	if cache != nil {
+		s.AddCheck("redis", cache.Ping)
+	}
+	return s
+}
+
+// AddCheck registers an additional named dependency check.
+func (s *ReadinessService) AddCheck(name string, check func(ctx context.Context) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checks = append(s.checks, readinessCheck{name: name, check: check})
+}
+
+// Readiness runs all checks concurrently and reports the node ready only if every
+// check passed.
+func (s *ReadinessService) Readiness(ctx context.Context) *domain.ReadinessReport {
+	s.mu.RLock()
+	checks := make([]readinessCheck, len(s.checks))
+	copy(checks, s.checks)
+	s.mu.RUnlock()
+
+	report := &domain.ReadinessReport{
+		Ready:  true,
+		Checks: make([]domain.DependencyStatus, len(checks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c readinessCheck) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, readinessTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := c.check(checkCtx)
+			status := domain.DependencyStatus{
+				Name:      c.name,
+				Healthy:   err == nil,
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				status.Error = err.Error()
+			}
+			report.Checks[i] = status
+		}(i, c)
+	}
+	wg.Wait()
+
+	for _, c := range report.Checks {
+		if !c.Healthy {
+			report.Ready = false
+			s.logger.Warn("readiness check failed", "check", c.Name, "error", c.Error)
+		}
+	}
+	return report
+}
+
+var _ ports.ReadinessChecker = (*ReadinessService)(nil)
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/testutil"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestReadinessService(t *testing.T) {
+	repo := &mockRepo{err: errors.New("connection refused")}
+	svc := NewReadinessService(repo, nil, nil)
+	ctx := context.Background()
+
+	report := svc.Readiness(ctx)
+	if report.Ready {
+		t.Error("Expected node not ready while database is down")
+	}
+	if len(report.Checks) != 2 || report.Checks[0].Name != "postgres" || report.Checks[0].Healthy {
+		t.Errorf("Unexpected checks: %+v", report.Checks)
+	}
+
+	// An empty zone set is still ready
+	repo.err = nil
+	if report = svc.Readiness(ctx); !report.Ready {
+		t.Errorf("Expected node ready, got %+v", report.Checks)
+	}
+
+	svc.AddCheck("bgp", func(_ context.Context) error { return errors.New("no established BGP session") })
+	report = svc.Readiness(ctx)
+	if report.Ready {
+		t.Error("Expected failing extra check to make node not ready")
+	}
+	if last := report.Checks[len(report.Checks)-1]; last.Name != "bgp" || last.Error == "" {
+		t.Errorf("Expected bgp failure in report, got %+v", last)
+	}
+}
+
+func TestReadinessDoesNotListZones(t *testing.T) {
+	// The mock fails the test on any call it does not expect, such as ListZones
+	repo := new(testutil.MockRepo)
+	repo.On("Ping").Return(nil)
+	repo.On("HasZones", mock.Anything).Return(true, nil)
+	if report := NewReadinessService(repo, nil, nil).Readiness(context.Background()); !report.Ready {
+		t.Errorf("Expected node ready, got %+v", report.Checks)
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestAnycastManager_UsesReadiness(t *testing.T) {
+	repo := &mockRepo{zones: []domain.Zone{{Name: "example.com."}}}
+	readiness := NewReadinessService(repo, nil, nil)
+	// BGP session state must not gate announcement
+	readiness.AddCheck(ReadinessCheckBGP, func(_ context.Context) error { return errors.New("idle") })
+
+	routing := &testutil.MockRoutingEngine{}
+	vipMgr := &testutil.MockVIPManager{}
+	mgr := NewAnycastManager(&mockAnycastDNSService{healthy: false}, routing, vipMgr, "1.1.1.1", "lo", nil)
+	mgr.SetReadiness(readiness)
+	ctx := context.Background()
+
+	mgr.TriggerCheck(ctx)
+	if !routing.Announced {
+		t.Error("Expected announcement when node is ready")
+	}
+
+	repo.err = errors.New("connection refused")
+	mgr.TriggerCheck(ctx)
+	if routing.Announced {
+		t.Error("Expected withdrawal when database is unavailable")
+	}
+}
@@ -0,0 +1,324 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+)
+
+// maxImportRows bounds a single bulk record import.
+const maxImportRows = 10000
+
+// importRecordTypes are the record types a bulk import may create. SOA records
+// belong to their zone and DNSSEC records to the signer.
+var importRecordTypes = map[domain.RecordType]bool{
+	domain.TypeA:     true,
+	domain.TypeAAAA:  true,
+	domain.TypeCNAME: true,
+	domain.TypeMX:    true,
+	domain.TypeTXT:   true,
+	domain.TypeNS:    true,
+	domain.TypePTR:   true,
+	domain.TypeSRV:   true,
+	domain.TypeSVCB:  true,
+	domain.TypeHTTPS: true,
+	domain.TypeCAA:   true,
+	domain.TypeNAPTR: true,
+	domain.TypeTLSA:  true,
+	domain.TypeSSHFP: true,
+	domain.TypeLOC:   true,
+	domain.TypeURI:   true,
+	domain.TypeCERT:  true,
+	domain.TypeALIAS: true,
+}
+
+// importRow is one record of a bulk import as uploaded. A CSV row whose fields
+// cannot be read carries the error instead.
+type importRow struct {
+	Name     string  `json:"name"`
+	Type     string  `json:"type"`
+	Content  string  `json:"content"`
+	TTL      int     `json:"ttl"`
+	Priority *int    `json:"priority"`
+	Weight   *int    `json:"weight"`
+	Port     *int    `json:"port"`
+	Network  *string `json:"network"`
+	err      error
+}
+
+// ImportRecords adds records to an existing zone from a bulk upload, either CSV
+// with a header row naming the columns (name, type, content, ttl, priority,
+// weight, port, network) or a JSON array of objects with the same fields. Names
+// resolve like RRset names: "@" or empty is the apex and relative names are
+// under the zone. Every row is validated first and the import is all or
+// nothing; a dry run only validates. It returns the per-row results, along with
+// an error wrapping domain.ErrInvalidRecordImport when any row is invalid, and
+// an error wrapping domain.ErrNotFound for an unknown zone.
+func (s *dnsService) ImportRecords(ctx context.Context, zoneID string, tenantID string, format string, r io.Reader, dryRun bool) (*domain.RecordImportResult, error) {
+	rows, err := parseImportRows(format, r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidRecordImport, err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: no records to import", domain.ErrInvalidRecordImport)
+	}
+	if len(rows) > maxImportRows {
+		return nil, fmt.Errorf("%w: %d rows exceed the limit of %d", domain.ErrInvalidRecordImport, len(rows), maxImportRows)
+	}
+
+	zone, existing, err := s.loadRRsetZone(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !dryRun {
+		if err := CheckChangeFreeze(ctx, s.repo, tenantID, zone.ID, "IMPORT_RECORDS "+zone.Name); err != nil {
+			return nil, err
+		}
+		if err := CheckChangeReview(ctx, s.repo, tenantID, zone.ID, "IMPORT_RECORDS "+zone.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &domain.RecordImportResult{ZoneID: zone.ID, DryRun: dryRun, Rows: make([]domain.RecordImportRow, 0, len(rows))}
+	seen := make(map[string]bool, len(existing)+len(rows))
+	for _, rec := range existing {
+		seen[importKey(rec.Name, rec.Type, rec.Content, rec.Network)] = true
+	}
+	now := time.Now()
+	records := make([]domain.Record, 0, len(rows))
+	for i, row := range rows {
+		rec, errRow := validateImportRow(zone, row, existing, seen)
+		res := domain.RecordImportRow{Row: i + 1, Name: rec.Name, Type: rec.Type, Status: "valid"}
+		if errRow != nil {
+			res.Status = "invalid"
+			res.Error = errRow.Error()
+			result.Invalid++
+			result.Rows = append(result.Rows, res)
+			continue
+		}
+		rec.ID = uuid.New().String()
+		rec.ZoneID = zone.ID
+		rec.TenantID = zone.TenantID
+		rec.CreatedAt, rec.UpdatedAt = now, now
+		existing = append(existing, rec)
+		records = append(records, rec)
+		result.Rows = append(result.Rows, res)
+	}
+	if result.Invalid > 0 {
+		return result, fmt.Errorf("%w: %d of %d rows are invalid", domain.ErrInvalidRecordImport, result.Invalid, len(rows))
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	if err := s.repo.BatchCreateRecords(ctx, records); err != nil {
+		return nil, err
+	}
+	invalidated := make(map[string]bool, len(records))
+	for _, rec := range records {
+		key := strings.ToLower(rec.Name) + " " + string(rec.Type)
+		if !invalidated[key] {
+			invalidated[key] = true
+			s.invalidate(ctx, rec.Name, rec.Type)
+		}
+	}
+	for i := range result.Rows {
+		result.Rows[i].Status = "created"
+	}
+	result.Created = len(records)
+
+	s.audit(ctx, tenantID, "IMPORT_RECORDS", "ZONE", zone.ID, fmt.Sprintf("Imported %d records into %s", len(records), zone.Name))
+	s.publish(domain.Event{Type: domain.EventZoneChange, TenantID: tenantID, ZoneID: zone.ID, Action: "IMPORT_RECORDS", Name: zone.Name})
+	return result, nil
+}
+
+// validateImportRow turns an uploaded row into a record of the zone and checks
+// it the way a single-record RRset update is checked, against the zone's records
+// and the rows before it.
+func validateImportRow(zone *domain.Zone, row importRow, existing []domain.Record, seen map[string]bool) (domain.Record, error) {
+	rec := domain.Record{
+		Name:     rrsetOwner(zone.Name, row.Name),
+		Type:     domain.RecordType(strings.ToUpper(strings.TrimSpace(row.Type))),
+		Content:  strings.TrimSpace(row.Content),
+		TTL:      row.TTL,
+		Priority: row.Priority,
+		Weight:   row.Weight,
+		Port:     row.Port,
+		Network:  row.Network,
+	}
+	if row.err != nil {
+		return rec, row.err
+	}
+	if rec.Type == domain.TypeSOA {
+		return rec, errors.New("SOA records cannot be imported; edit the zone's SOA instead")
+	}
+	if !importRecordTypes[rec.Type] {
+		return rec, fmt.Errorf("unsupported record type %q", rec.Type)
+	}
+	if rec.TTL < 0 {
+		return rec, fmt.Errorf("invalid ttl %d", rec.TTL)
+	}
+	if rec.TTL == 0 {
+		rec.TTL = defaultRRsetTTL
+	}
+	if rec.TTL < 60 {
+		rec.TTL = 60
+	}
+	if rec.Network != nil {
+		if _, _, err := net.ParseCIDR(*rec.Network); err != nil {
+			return rec, fmt.Errorf("invalid network %q", *rec.Network)
+		}
+	}
+	switch ip := net.ParseIP(rec.Content); rec.Type {
+	case domain.TypeA:
+		if ip == nil || ip.To4() == nil {
+			return rec, fmt.Errorf("invalid IPv4 address %q", rec.Content)
+		}
+	case domain.TypeAAAA:
+		if ip == nil || ip.To4() != nil {
+			return rec, fmt.Errorf("invalid IPv6 address %q", rec.Content)
+		}
+	case domain.TypeMX:
+		if rec.Priority == nil {
+			return rec, errors.New("MX records need a priority")
+		}
+	}
+
+	rr := domain.RRsetRecord{Content: rec.Content, Priority: rec.Priority, Weight: rec.Weight, Port: rec.Port, Network: rec.Network}
+	if err := validateRRset(zone, rec.Name, rec.Type, []domain.RRsetRecord{rr}, existing); err != nil {
+		return rec, errors.New(strings.TrimSuffix(err.Error(), ": "+domain.ErrInvalidRRset.Error()))
+	}
+	if rec.Type == domain.TypeCNAME || rec.Type == domain.TypeALIAS {
+		for _, other := range existing {
+			if other.Type == rec.Type && dnsname.Equal(other.Name, rec.Name) && sameNetwork(other.Network, rec.Network) {
+				return rec, fmt.Errorf("only one %s per name and network is allowed", rec.Type)
+			}
+		}
+	}
+	key := importKey(rec.Name, rec.Type, rec.Content, rec.Network)
+	if seen[key] {
+		return rec, fmt.Errorf("duplicate record %s %s %q", rec.Name, rec.Type, rec.Content)
+	}
+	seen[key] = true
+	return rec, nil
+}
+
+func importKey(name string, qType domain.RecordType, content string, network *string) string {
+	key := strings.ToLower(dnsname.Fqdn(name)) + " " + string(qType) + " " + strings.TrimSpace(content)
+	if network != nil {
+		key += " " + *network
+	}
+	return key
+}
+
+// parseImportRows reads the rows of a bulk import in the given format, "csv" or
+// "json".
+func parseImportRows(format string, r io.Reader) ([]importRow, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "csv":
+		return parseImportCSV(r)
+	case "json":
+		var rows []importRow
+		if err := json.NewDecoder(r).Decode(&rows); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %v", err)
+		}
+		return rows, nil
+	default:
+		return nil, fmt.Errorf("unsupported format %q, want csv or json", format)
+	}
+}
+
+// parseImportCSV reads CSV rows under a header naming their columns in any
+// order. Only type and content are required.
+func parseImportCSV(r io.Reader) ([]importRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %v", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, col := range header {
+		col = strings.ToLower(strings.TrimSpace(col))
+		switch col {
+		case "name", "type", "content", "ttl", "priority", "weight", "port", "network":
+		default:
+			return nil, fmt.Errorf("unknown CSV column %q", col)
+		}
+		if _, dup := columns[col]; dup {
+			return nil, fmt.Errorf("duplicate CSV column %q", col)
+		}
+		columns[col] = i
+	}
+	for _, col := range []string{"type", "content"} {
+		if _, ok := columns[col]; !ok {
+			return nil, fmt.Errorf("CSV header is missing the %s column", col)
+		}
+	}
+
+	var rows []importRow
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %v", err)
+		}
+		rows = append(rows, csvImportRow(columns, fields))
+	}
+}
+
+func csvImportRow(columns map[string]int, fields []string) importRow {
+	var row importRow
+	field := func(col string) string {
+		i, ok := columns[col]
+		if !ok || i >= len(fields) {
+			return ""
+		}
+		return strings.TrimSpace(fields[i])
+	}
+	number := func(col string) *int {
+		v := field(col)
+		if v == "" || row.err != nil {
+			return nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			row.err = fmt.Errorf("invalid %s %q", col, v)
+			return nil
+		}
+		return &n
+	}
+
+	row.Name = field("name")
+	row.Type = field("type")
+	row.Content = field("content")
+	if ttl := number("ttl"); ttl != nil {
+		row.TTL = *ttl
+	}
+	row.Priority = number("priority")
+	row.Weight = number("weight")
+	row.Port = number("port")
+	if network := field("network"); network != "" {
+		row.Network = &network
+	}
+	return row
+}
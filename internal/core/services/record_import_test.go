@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+func importRepo() *mockRepo {
+	return &mockRepo{
+		zones: []domain.Zone{{ID: "z1", TenantID: "t1", Name: "example.com."}},
+		records: []domain.Record{
+			{ID: "soa", ZoneID: "z1", Name: "example.com.", Type: domain.TypeSOA, Content: "ns1. admin. 1 3600 600 86400 300", TTL: 3600},
+			{ID: "www", ZoneID: "z1", Name: "www.example.com.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 300},
+		},
+	}
+}
+
+func TestImportRecordsCSV(t *testing.T) {
+	repo := importRepo()
+	svc := NewDNSService(repo, nil)
+
+	csv := `name,type,content,ttl,priority,network
+@,MX,mail.example.com.,300,10,
+api,A,192.0.2.10,,,
+api.example.com.,A,10.0.0.10,60,,10.0.0.0/8
+# comments are skipped
+_sip._tcp,SRV,sip.example.com.,300,10,
+`
+	// SRV needs weight and port, so the last row fails and nothing is written
+	res, err := svc.ImportRecords(context.Background(), "z1", "t1", "csv", strings.NewReader(csv), false)
+	if !errors.Is(err, domain.ErrInvalidRecordImport) {
+		t.Fatalf("Expected ErrInvalidRecordImport, got %v", err)
+	}
+	if res == nil || res.Invalid != 1 || res.Created != 0 || len(res.Rows) != 4 {
+		t.Fatalf("Unexpected result: %+v", res)
+	}
+	if res.Rows[3].Status != "invalid" || res.Rows[3].Name != "_sip._tcp.example.com." || res.Rows[3].Error == "" {
+		t.Errorf("Expected the SRV row to be reported, got %+v", res.Rows[3])
+	}
+	if len(repo.records) != 2 {
+		t.Fatalf("Expected nothing imported, got %d records", len(repo.records))
+	}
+
+	csv = strings.Replace(csv, "_sip._tcp,SRV,sip.example.com.,300,10,\n", "", 1)
+	res, err = svc.ImportRecords(context.Background(), "z1", "t1", "CSV", strings.NewReader(csv), false)
+	if err != nil {
+		t.Fatalf("ImportRecords failed: %v", err)
+	}
+	if res.Created != 3 || res.Rows[0].Status != "created" {
+		t.Errorf("Unexpected result: %+v", res)
+	}
+	if len(repo.records) != 5 {
+		t.Fatalf("Expected 3 records imported, got %d records", len(repo.records))
+	}
+	for _, rec := range repo.records[2:] {
+		switch {
+		case rec.Type == domain.TypeMX && (rec.Priority == nil || *rec.Priority != 10 || rec.Name != "example.com."):
+			t.Errorf("Unexpected MX record: %+v", rec)
+		case rec.Content == "192.0.2.10" && rec.TTL != defaultRRsetTTL:
+			t.Errorf("Expected the default TTL, got %d", rec.TTL)
+		case rec.Content == "10.0.0.10" && (rec.Network == nil || *rec.Network != "10.0.0.0/8"):
+			t.Errorf("Expected the network kept, got %+v", rec.Network)
+		}
+	}
+}
+
+func TestImportRecordsJSONValidation(t *testing.T) {
+	repo := importRepo()
+	svc := NewDNSService(repo, nil)
+
+	body := `[
+		{"name": "www", "type": "A", "content": "192.0.2.1"},
+		{"name": "www", "type": "CNAME", "content": "other.example.com."},
+		{"name": "v6", "type": "AAAA", "content": "192.0.2.2"},
+		{"name": "@", "type": "SOA", "content": "ns1. admin. 2 3600 600 86400 300"},
+		{"name": "other.org.", "type": "A", "content": "192.0.2.3"},
+		{"name": "mail", "type": "MX", "content": "mx.example.com."},
+		{"name": "x", "type": "DNAME", "content": "y.example.com."},
+		{"name": "ok", "type": "TXT", "content": "v=spf1 -all"},
+		{"name": "ok", "type": "TXT", "content": "v=spf1 -all"}
+	]`
+	res, err := svc.ImportRecords(context.Background(), "z1", "t1", "json", strings.NewReader(body), true)
+	if !errors.Is(err, domain.ErrInvalidRecordImport) {
+		t.Fatalf("Expected ErrInvalidRecordImport, got %v", err)
+	}
+	for i, row := range res.Rows {
+		want := "invalid"
+		if i == 7 {
+			want = "valid"
+		}
+		if row.Status != want {
+			t.Errorf("Row %d: expected %s, got %+v", row.Row, want, row)
+		}
+	}
+
+	res, err = svc.ImportRecords(context.Background(), "z1", "t1", "json", strings.NewReader(`[{"name": "ok", "type": "txt", "content": "hello"}]`), true)
+	if err != nil || !res.DryRun || res.Rows[0].Status != "valid" || res.Created != 0 {
+		t.Fatalf("Expected a valid dry run, got %+v (%v)", res, err)
+	}
+	if len(repo.records) != 2 {
+		t.Errorf("Expected a dry run to write nothing, got %d records", len(repo.records))
+	}
+}
+
+func TestImportRecordsErrors(t *testing.T) {
+	svc := NewDNSService(importRepo(), nil)
+	ctx := context.Background()
+
+	tests := map[string]struct {
+		format string
+		body   string
+	}{
+		"format":         {"yaml", "- a"},
+		"json":           {"json", "{"},
+		"empty":          {"json", "[]"},
+		"unknown column": {"csv", "name,type,content,comment\n"},
+		"missing column": {"csv", "name,type\nwww,A\n"},
+		"no header":      {"csv", ""},
+	}
+	for name, tt := range tests {
+		if _, err := svc.ImportRecords(ctx, "z1", "t1", tt.format, strings.NewReader(tt.body), false); !errors.Is(err, domain.ErrInvalidRecordImport) {
+			t.Errorf("%s: expected ErrInvalidRecordImport, got %v", name, err)
+		}
+	}
+
+	res, err := svc.ImportRecords(ctx, "z1", "t1", "csv", strings.NewReader("type,content,ttl\nA,192.0.2.9,soon\n"), false)
+	if !errors.Is(err, domain.ErrInvalidRecordImport) || res.Rows[0].Error != `invalid ttl "soon"` {
+		t.Errorf("Expected the bad TTL reported on its row, got %+v (%v)", res, err)
+	}
+	if _, err := svc.ImportRecords(ctx, "missing", "t1", "json", strings.NewReader(`[{"type": "A", "content": "192.0.2.9"}]`), false); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+)
+
+// SetRecordProtection marks a record as protected from deletion or lifts the
+// protection. Any admin may protect a record, but only a break-glass key may lift
+// it, as it could otherwise delete the record right after. It returns an error
+// wrapping domain.ErrNotFound for an unknown record.
+func (s *dnsService) SetRecordProtection(ctx context.Context, recordID string, zoneID string, tenantID string, protected bool) (*domain.Record, error) {
+	record, err := s.repo.GetRecord(ctx, recordID, zoneID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch record: %w", err)
+	}
+	if record == nil {
+		return nil, fmt.Errorf("record %s: %w", recordID, domain.ErrNotFound)
+	}
+	if record.Protected == protected {
+		return record, nil
+	}
+	if !protected && !domain.IsBreakGlass(ctx) {
+		return nil, fmt.Errorf("%w: only a break-glass key can lift the protection of %s %s", domain.ErrRecordProtected, record.Name, record.Type)
+	}
+
+	action := "PROTECT_RECORD"
+	if !protected {
+		action = "UNPROTECT_RECORD"
+	}
+	if err := CheckChangeFreeze(ctx, s.repo, tenantID, zoneID, action+" "+recordID); err != nil {
+		return nil, err
+	}
+	if err := CheckChangeReview(ctx, s.repo, tenantID, zoneID, action+" "+recordID); err != nil {
+		return nil, err
+	}
+	found, err := s.repo.SetRecordProtected(ctx, recordID, zoneID, tenantID, protected)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("record %s: %w", recordID, domain.ErrNotFound)
+	}
+	record.Protected = protected
+
+	s.audit(ctx, tenantID, action, "RECORD", recordID, fmt.Sprintf("Set protection of %s %s to %t", record.Name, record.Type, protected))
+	return record, nil
+}
+
+// checkRecordDeletion refuses to delete an SOA record, which only goes with its
+// zone, and a protected record unless a break-glass key forces it.
+func checkRecordDeletion(ctx context.Context, record *domain.Record) error {
+	if record.Type == domain.TypeSOA {
+		return fmt.Errorf("%w: the SOA record of a zone cannot be deleted; delete or replace the zone instead", domain.ErrRecordProtected)
+	}
+	if record.Protected && !(domain.IsForced(ctx) && domain.IsBreakGlass(ctx)) {
+		return fmt.Errorf("%w: %s %s can only be deleted by a break-glass key with force", domain.ErrRecordProtected, record.Name, record.Type)
+	}
+	return nil
+}
+
+// protectReplacement carries the protection of an RRset's records over to the
+// replacement records with the same content, and checks the protected records
+// the replacement drops may be deleted. A zone's single SOA is edited in place,
+// so its replacement stays protected whatever its content.
+func protectReplacement(ctx context.Context, owner string, qType domain.RecordType, current []domain.Record, replacement []domain.Record) error {
+	for _, rec := range current {
+		if !rec.Protected || rec.Type != qType || !dnsname.Equal(rec.Name, owner) {
+			continue
+		}
+		if qType == domain.TypeSOA {
+			for i := range replacement {
+				replacement[i].Protected = true
+			}
+			continue
+		}
+		kept := false
+		for i := range replacement {
+			if strings.TrimSpace(rec.Content) == replacement[i].Content && sameNetwork(rec.Network, replacement[i].Network) {
+				replacement[i].Protected = true
+				kept = true
+			}
+		}
+		if !kept {
+			if err := checkRecordDeletion(ctx, &rec); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func sameNetwork(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+func protectionRepo() *mockRepo {
+	return &mockRepo{
+		zones: []domain.Zone{{ID: "z1", TenantID: "t1", Name: "example.com."}},
+		records: []domain.Record{
+			{ID: "soa", ZoneID: "z1", Name: "example.com.", Type: domain.TypeSOA, Content: "ns1. admin. 1 3600 600 86400 300", TTL: 3600},
+			{ID: "ns1", ZoneID: "z1", Name: "example.com.", Type: domain.TypeNS, Content: "ns1.example.com.", TTL: 3600, Protected: true},
+			{ID: "ns2", ZoneID: "z1", Name: "example.com.", Type: domain.TypeNS, Content: "ns2.example.com.", TTL: 3600},
+			{ID: "www", ZoneID: "z1", Name: "www.example.com.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 300},
+		},
+	}
+}
+
+func TestDeleteRecordProtection(t *testing.T) {
+	svc := NewDNSService(protectionRepo(), nil)
+	ctx := context.Background()
+	forced := domain.WithForce(ctx)
+	breakGlass := domain.WithBreakGlass(ctx)
+
+	if err := svc.DeleteRecord(domain.WithForce(breakGlass), "soa", "z1", "t1"); !errors.Is(err, domain.ErrRecordProtected) {
+		t.Errorf("Expected the SOA to be undeletable, got %v", err)
+	}
+	for name, c := range map[string]context.Context{"plain": ctx, "forced": forced, "break-glass": breakGlass} {
+		if err := svc.DeleteRecord(c, "ns1", "z1", "t1"); !errors.Is(err, domain.ErrRecordProtected) {
+			t.Errorf("%s: expected the protected NS to be kept, got %v", name, err)
+		}
+	}
+	if err := svc.DeleteRecord(domain.WithForce(breakGlass), "ns1", "z1", "t1"); err != nil {
+		t.Errorf("Expected a forced break-glass deletion to pass, got %v", err)
+	}
+	if err := svc.DeleteRecord(ctx, "www", "z1", "t1"); err != nil {
+		t.Errorf("Expected an unprotected record to be deleted, got %v", err)
+	}
+}
+
+func TestSetRecordProtection(t *testing.T) {
+	repo := protectionRepo()
+	svc := NewDNSService(repo, nil)
+	ctx := context.Background()
+
+	rec, err := svc.SetRecordProtection(ctx, "www", "z1", "t1", true)
+	if err != nil || !rec.Protected {
+		t.Fatalf("Expected the record protected, got %+v (%v)", rec, err)
+	}
+	if err := svc.DeleteRecord(ctx, "www", "z1", "t1"); !errors.Is(err, domain.ErrRecordProtected) {
+		t.Errorf("Expected the newly protected record to be kept, got %v", err)
+	}
+
+	if _, err := svc.SetRecordProtection(ctx, "www", "z1", "t1", false); !errors.Is(err, domain.ErrRecordProtected) {
+		t.Errorf("Expected only break-glass keys to lift protection, got %v", err)
+	}
+	rec, err = svc.SetRecordProtection(domain.WithBreakGlass(ctx), "www", "z1", "t1", false)
+	if err != nil || rec.Protected {
+		t.Fatalf("Expected the protection lifted, got %+v (%v)", rec, err)
+	}
+	if _, err := svc.SetRecordProtection(ctx, "missing", "z1", "t1", true); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestReplaceRRsetProtection(t *testing.T) {
+	repo := protectionRepo()
+	svc := NewDNSService(repo, nil)
+	ctx := context.Background()
+
+	// Dropping the protected NS is refused
+	drop := &domain.RRset{Name: "@", Type: domain.TypeNS, Records: []domain.RRsetRecord{{Content: "ns2.example.com."}}}
+	if _, err := svc.ReplaceRRset(ctx, "z1", "t1", drop); !errors.Is(err, domain.ErrRecordProtected) {
+		t.Fatalf("Expected the protected NS to be kept, got %v", err)
+	}
+
+	// Keeping it carries the protection over to the new record
+	keep := &domain.RRset{Name: "@", Type: domain.TypeNS, Records: []domain.RRsetRecord{{Content: "ns1.example.com."}, {Content: "ns3.example.com."}}}
+	if _, err := svc.ReplaceRRset(ctx, "z1", "t1", keep); err != nil {
+		t.Fatalf("ReplaceRRset failed: %v", err)
+	}
+	for _, rec := range repo.records {
+		if rec.Type == domain.TypeNS && rec.Protected != (rec.Content == "ns1.example.com.") {
+			t.Errorf("Unexpected protection of %s: %v", rec.Content, rec.Protected)
+		}
+	}
+
+	if _, err := svc.ReplaceRRset(domain.WithForce(domain.WithBreakGlass(ctx)), "z1", "t1", drop); err != nil {
+		t.Errorf("Expected a forced break-glass replacement to pass, got %v", err)
+	}
+}
@@ -0,0 +1,445 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/core/ports"
+)
+
+const (
+	defaultRolloutBakeSeconds = 30
+	defaultMaxErrorIncrease   = 0.05
+)
+
+var errRolloutAborted = errors.New("rollout aborted by operator")
+
+// RolloutService stages node configuration updates across the fleet. Rollouts
+// target the per-node configuration that each node applies on its next heartbeat.
+// Zone data is shared through PostgreSQL and reaches every node at once, so a
+// zone change is rolled out as a shadow change that nodes start serving to every
+// client when their config lists the zone, and that is committed once the whole
+// fleet serves it.
+//
+// A rollout updates the canary node first and then the remaining nodes in batches.
+// After each step it waits for the nodes to report the new config version, lets
+// them bake, and compares health, QPS and error rate against the pre-step baseline.
+// Any regression halts the rollout and restores the previous config on every node
+// it touched.
+type RolloutService struct {
+	repo    ports.DNSRepository
+	nodes   ports.NodeLister
+	shadows ports.ShadowChangeManager
+	logger  *slog.Logger
+
+	pollInterval time.Duration
+	applyTimeout time.Duration
+	bakeUnit     time.Duration // Unit of Rollout.BakeSeconds, shortened in tests
+
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+// NewRolloutService creates a rollout orchestrator reading fleet state from nodes.
+func NewRolloutService(repo ports.DNSRepository, nodes ports.NodeLister, logger *slog.Logger) *RolloutService {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &RolloutService{
+		repo:         repo,
+		nodes:        nodes,
+		logger:       logger,
+		pollInterval: time.Second,
+		applyTimeout: staleHeartbeats * DefaultHeartbeatInterval,
+		bakeUnit:     time.Second,
+		cancels:      make(map[string]context.CancelFunc),
+	}
+}
+
+// SetShadows enables zone rollouts, whose shadow change is committed through m
+// once every node serves it.
+func (s *RolloutService) SetShadows(m ports.ShadowChangeManager) {
+	s.shadows = m
+}
+
+// StartRollout validates and plans a rollout, persists it and runs it in the
+// background. The returned rollout carries the batch plan.
+func (s *RolloutService) StartRollout(ctx context.Context, spec *domain.Rollout) (*domain.Rollout, error) {
+	spec.TenantID, spec.StagedAt = "", nil
+	if spec.ZoneID != "" {
+		if err := s.planZone(ctx, spec); err != nil {
+			return nil, err
+		}
+	} else if len(spec.Config) == 0 {
+		return nil, fmt.Errorf("%w: config must not be empty", domain.ErrInvalidRollout)
+	}
+	if spec.BatchSize <= 0 {
+		spec.BatchSize = 1
+	}
+	if spec.BakeSeconds <= 0 {
+		spec.BakeSeconds = defaultRolloutBakeSeconds
+	}
+	if spec.MaxErrorRateIncrease <= 0 {
+		spec.MaxErrorRateIncrease = defaultMaxErrorIncrease
+	}
+	if spec.MinQPSRatio < 0 || spec.MinQPSRatio > 1 {
+		return nil, fmt.Errorf("%w: min_qps_ratio must be between 0 and 1", domain.ErrInvalidRollout)
+	}
+
+	nodes, err := s.nodes.ListNodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	batches, err := planBatches(nodes, spec.CanaryNode, spec.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	spec.ID = uuid.New().String()
+	spec.State = domain.RolloutRunning
+	spec.Batches = batches
+	spec.Completed = nil
+	spec.Error = ""
+	spec.CanaryNode = batches[0][0]
+	spec.Previous = make(map[string]map[string]string)
+	for _, batch := range batches {
+		for _, id := range batch {
+			prev, errCfg := s.repo.GetNodeConfig(ctx, id)
+			if errCfg != nil {
+				return nil, fmt.Errorf("failed to read config for node %s: %w", id, errCfg)
+			}
+			spec.Previous[id] = prev
+		}
+	}
+	spec.CreatedAt = time.Now()
+	spec.UpdatedAt = spec.CreatedAt
+	if err := s.repo.SaveRollout(ctx, spec); err != nil {
+		return nil, fmt.Errorf("failed to save rollout: %w", err)
+	}
+
+	// The rollout outlives the request that started it
+	runCtx, cancel := context.WithCancelCause(context.WithoutCancel(ctx))
+	s.mu.Lock()
+	s.cancels[spec.ID] = func() { cancel(errRolloutAborted) }
+	s.mu.Unlock()
+
+	rollout := *spec
+	go s.run(runCtx, &rollout)
+	return spec, nil
+}
+
+// planZone checks that a zone rollout has a shadow change to roll out and pins
+// it, so that restaging the change during the rollout halts it.
+func (s *RolloutService) planZone(ctx context.Context, spec *domain.Rollout) error {
+	if s.shadows == nil {
+		return fmt.Errorf("%w: zone rollouts are not enabled", domain.ErrInvalidRollout)
+	}
+	if len(spec.Config) > 0 {
+		return fmt.Errorf("%w: a zone rollout takes no config", domain.ErrInvalidRollout)
+	}
+	change, err := s.repo.GetShadowChange(ctx, spec.ZoneID)
+	if err != nil {
+		return fmt.Errorf("failed to load shadow change: %w", err)
+	}
+	if change == nil {
+		return fmt.Errorf("%w: zone %s has no staged shadow change", domain.ErrInvalidRollout, spec.ZoneID)
+	}
+	spec.TenantID = change.TenantID
+	spec.StagedAt = &change.CreatedAt
+	return nil
+}
+
+// planBatches orders healthy nodes into a canary batch followed by batches of size.
+func planBatches(nodes []domain.Node, canary string, size int) ([][]string, error) {
+	var healthy []string
+	canaryFound := false
+	for _, n := range nodes {
+		if n.Status != domain.NodeStatusUp {
+			continue
+		}
+		if n.ID == canary {
+			canaryFound = true
+			continue
+		}
+		healthy = append(healthy, n.ID)
+	}
+	if canary != "" && !canaryFound {
+		return nil, fmt.Errorf("%w: canary node %q is not up", domain.ErrInvalidRollout, canary)
+	}
+	if canary == "" {
+		if len(healthy) == 0 {
+			return nil, fmt.Errorf("%w: no healthy nodes to roll out to", domain.ErrInvalidRollout)
+		}
+		canary, healthy = healthy[0], healthy[1:]
+	}
+
+	batches := [][]string{{canary}}
+	for start := 0; start < len(healthy); start += size {
+		end := start + size
+		if end > len(healthy) {
+			end = len(healthy)
+		}
+		batches = append(batches, healthy[start:end])
+	}
+	return batches, nil
+}
+
+// GetRollout returns a rollout by ID, or nil if it does not exist.
+func (s *RolloutService) GetRollout(ctx context.Context, id string) (*domain.Rollout, error) {
+	return s.repo.GetRollout(ctx, id)
+}
+
+// ListRollouts returns all rollouts.
+func (s *RolloutService) ListRollouts(ctx context.Context) ([]domain.Rollout, error) {
+	return s.repo.ListRollouts(ctx)
+}
+
+// AbortRollout stops a running rollout and rolls back every node it has touched.
+// A rollout left RUNNING by a restarted orchestrator is rolled back directly.
+func (s *RolloutService) AbortRollout(ctx context.Context, id string) error {
+	s.mu.Lock()
+	cancel, running := s.cancels[id]
+	s.mu.Unlock()
+	if running {
+		cancel()
+		return nil
+	}
+
+	rollout, err := s.repo.GetRollout(ctx, id)
+	if err != nil {
+		return err
+	}
+	if rollout == nil || rollout.State != domain.RolloutRunning {
+		return fmt.Errorf("%w: rollout %s is not running", domain.ErrInvalidRollout, id)
+	}
+	s.rollback(rollout, flattenBatches(rollout.Batches), errRolloutAborted)
+	return nil
+}
+
+func (s *RolloutService) run(ctx context.Context, r *domain.Rollout) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.cancels, r.ID)
+		s.mu.Unlock()
+	}()
+
+	s.logger.Info("starting rollout", "rollout_id", r.ID, "batches", len(r.Batches))
+	for i, batch := range r.Batches {
+		if err := s.applyBatch(ctx, r, batch); err != nil {
+			if cause := context.Cause(ctx); cause != nil {
+				err = cause
+			}
+			s.logger.Error("rollout halted", "rollout_id", r.ID, "batch", i, "error", err)
+			s.rollback(r, append(append([]string{}, r.Completed...), batch...), err)
+			return
+		}
+		r.Completed = append(r.Completed, batch...)
+		s.save(r)
+		s.logger.Info("rollout batch completed", "rollout_id", r.ID, "batch", i, "nodes", batch)
+	}
+
+	if r.ZoneID != "" {
+		if err := s.commitZone(ctx, r); err != nil {
+			s.logger.Error("rollout halted", "rollout_id", r.ID, "error", err)
+			s.rollback(r, r.Completed, err)
+			return
+		}
+		// The records now answer what the nodes served from the shadow change
+		s.restore(r, r.Completed)
+	}
+	r.State = domain.RolloutCompleted
+	s.save(r)
+	s.logger.Info("rollout completed", "rollout_id", r.ID)
+}
+
+// applyBatch pushes the new config to a batch and checks the nodes afterwards.
+func (s *RolloutService) applyBatch(ctx context.Context, r *domain.Rollout, batch []string) error {
+	baseline, err := s.nodeMap(ctx)
+	if err != nil {
+		return err
+	}
+
+	want := make(map[string]string, len(batch))
+	for _, id := range batch {
+		cfg := rolloutConfig(r, r.Previous[id])
+		if err := s.repo.SetNodeConfig(ctx, id, cfg); err != nil {
+			return fmt.Errorf("failed to set config on node %s: %w", id, err)
+		}
+		want[id] = domain.ConfigVersion(cfg)
+	}
+
+	if err := s.awaitApplied(ctx, want); err != nil {
+		return err
+	}
+	if err := sleepCtx(ctx, time.Duration(r.BakeSeconds)*s.bakeUnit); err != nil {
+		return err
+	}
+
+	current, err := s.nodeMap(ctx)
+	if err != nil {
+		return err
+	}
+	for _, id := range batch {
+		if err := checkNodeGates(r, baseline[id], current[id]); err != nil {
+			return fmt.Errorf("node %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// awaitApplied waits until every node reports the wanted config version.
+func (s *RolloutService) awaitApplied(ctx context.Context, want map[string]string) error {
+	deadline := time.Now().Add(s.applyTimeout)
+	for {
+		nodes, err := s.nodeMap(ctx)
+		if err != nil {
+			return err
+		}
+		pending := 0
+		for id, version := range want {
+			n, ok := nodes[id]
+			switch {
+			case ok && n.ConfigError != "":
+				return fmt.Errorf("node %s failed to apply config: %s", id, n.ConfigError)
+			case !ok || n.ConfigVersion != version:
+				pending++
+			}
+		}
+		if pending == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%d node(s) did not apply the config within %s", pending, s.applyTimeout)
+		}
+		if err := sleepCtx(ctx, s.pollInterval); err != nil {
+			return err
+		}
+	}
+}
+
+// checkNodeGates compares a node after the bake period against its baseline.
+func checkNodeGates(r *domain.Rollout, before, after domain.Node) error {
+	if after.ID == "" {
+		return errors.New("node disappeared from the registry")
+	}
+	if after.Status != domain.NodeStatusUp {
+		return fmt.Errorf("node is %s", after.Status)
+	}
+	if increase := after.ErrorRate - before.ErrorRate; increase > r.MaxErrorRateIncrease {
+		return fmt.Errorf("error rate rose from %.3f to %.3f", before.ErrorRate, after.ErrorRate)
+	}
+	if r.MinQPSRatio > 0 && before.QPS > 0 && after.QPS < before.QPS*r.MinQPSRatio {
+		return fmt.Errorf("QPS dropped from %.1f to %.1f", before.QPS, after.QPS)
+	}
+	return nil
+}
+
+// commitZone commits the shadow change a zone rollout has served to the whole
+// fleet, unless it was restaged or discarded since the rollout started.
+func (s *RolloutService) commitZone(ctx context.Context, r *domain.Rollout) error {
+	change, err := s.repo.GetShadowChange(ctx, r.ZoneID)
+	if err != nil {
+		return fmt.Errorf("failed to load shadow change: %w", err)
+	}
+	if change == nil || r.StagedAt == nil || !change.CreatedAt.Equal(*r.StagedAt) {
+		return errors.New("the shadow change was restaged or discarded during the rollout")
+	}
+	if _, err := s.shadows.CommitShadowChange(ctx, r.ZoneID, r.TenantID); err != nil {
+		return fmt.Errorf("failed to commit shadow change: %w", err)
+	}
+	return nil
+}
+
+// rollback restores the pre-rollout config on the given nodes.
+func (s *RolloutService) rollback(r *domain.Rollout, nodes []string, cause error) {
+	s.restore(r, nodes)
+	r.State = domain.RolloutRolledBack
+	r.Error = cause.Error()
+	s.save(r)
+	s.logger.Warn("rollout rolled back", "rollout_id", r.ID, "nodes", nodes, "reason", cause)
+}
+
+// restore puts the pre-rollout config back on the given nodes.
+func (s *RolloutService) restore(r *domain.Rollout, nodes []string) {
+	ctx := context.Background()
+	for _, id := range nodes {
+		prev := r.Previous[id]
+		if prev == nil {
+			prev = map[string]string{}
+		}
+		if err := s.repo.SetNodeConfig(ctx, id, prev); err != nil {
+			s.logger.Error("failed to roll back node config", "rollout_id", r.ID, "node_id", id, "error", err)
+		}
+	}
+}
+
+func (s *RolloutService) save(r *domain.Rollout) {
+	r.UpdatedAt = time.Now()
+	if err := s.repo.SaveRollout(context.Background(), r); err != nil {
+		s.logger.Error("failed to save rollout", "rollout_id", r.ID, "error", err)
+	}
+}
+
+func (s *RolloutService) nodeMap(ctx context.Context) (map[string]domain.Node, error) {
+	nodes, err := s.nodes.ListNodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	m := make(map[string]domain.Node, len(nodes))
+	for _, n := range nodes {
+		m[n.ID] = n
+	}
+	return m, nil
+}
+
+// rolloutConfig is the config a node runs once rollout r reaches it: its previous
+// config with the rollout's keys, or with the rollout's zone added to the shadow
+// changes it serves.
+func rolloutConfig(r *domain.Rollout, prev map[string]string) map[string]string {
+	if r.ZoneID == "" {
+		return mergeConfig(prev, r.Config)
+	}
+	zones := strings.FieldsFunc(prev[domain.NodeConfigShadowZones], func(c rune) bool { return c == ',' })
+	if !slices.Contains(zones, r.ZoneID) {
+		zones = append(zones, r.ZoneID)
+	}
+	return mergeConfig(prev, map[string]string{domain.NodeConfigShadowZones: strings.Join(zones, ",")})
+}
+
+func mergeConfig(base, overlay map[string]string) map[string]string {
+	merged := make(map[string]string, len(base)+len(overlay))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range overlay {
+		merged[k] = v
+	}
+	return merged
+}
+
+func flattenBatches(batches [][]string) []string {
+	var ids []string
+	for _, b := range batches {
+		ids = append(ids, b...)
+	}
+	return ids
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,387 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/core/ports"
+)
+
+// fakeFleet is a thread-safe repository and node lister whose nodes apply their
+// desired config instantly. Nodes listed in reject fail to apply it, and nodes in
+// regress report a high error rate once they run the new config or serve a zone
+// rollout's shadow change.
+type fakeFleet struct {
+	mockRepo
+	mu      sync.Mutex
+	reject  map[string]bool
+	regress map[string]bool
+}
+
+func newFakeFleet(ids ...string) *fakeFleet {
+	f := &fakeFleet{reject: map[string]bool{}, regress: map[string]bool{}}
+	f.configs = make(map[string]map[string]string)
+	for _, id := range ids {
+		f.nodes = append(f.nodes, domain.Node{ID: id, Status: domain.NodeStatusUp, QPS: 100})
+		f.configs[id] = map[string]string{"log_level": "info"}
+	}
+	return f
+}
+
+func (f *fakeFleet) ListNodes(_ context.Context) ([]domain.Node, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	nodes := make([]domain.Node, len(f.nodes))
+	for i, n := range f.nodes {
+		cfg := f.configs[n.ID]
+		changed := cfg["log_level"] != "info" || cfg[domain.NodeConfigShadowZones] != ""
+		if f.reject[n.ID] && changed {
+			n.ConfigError = "unsupported config"
+		} else {
+			n.ConfigVersion = domain.ConfigVersion(cfg)
+		}
+		if f.regress[n.ID] && changed {
+			n.ErrorRate = 0.5
+		}
+		nodes[i] = n
+	}
+	return nodes, nil
+}
+
+func (f *fakeFleet) GetNodeConfig(ctx context.Context, nodeID string) (map[string]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.mockRepo.GetNodeConfig(ctx, nodeID)
+}
+
+func (f *fakeFleet) SetNodeConfig(ctx context.Context, nodeID string, cfg map[string]string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.mockRepo.SetNodeConfig(ctx, nodeID, cfg)
+}
+
+func (f *fakeFleet) SaveRollout(ctx context.Context, r *domain.Rollout) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.mockRepo.SaveRollout(ctx, r)
+}
+
+func (f *fakeFleet) GetRollout(ctx context.Context, id string) (*domain.Rollout, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.mockRepo.GetRollout(ctx, id)
+}
+
+func (f *fakeFleet) config(id string) map[string]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.configs[id]
+}
+
+// fakeShadows commits shadow changes by removing them from the fleet's repository
+// and recording the zone and tenant.
+type fakeShadows struct {
+	ports.ShadowChangeManager
+	fleet     *fakeFleet
+	mu        sync.Mutex
+	committed []string
+}
+
+func (m *fakeShadows) CommitShadowChange(ctx context.Context, zoneID, tenantID string) (*domain.ShadowChange, error) {
+	change, _ := m.fleet.GetShadowChange(ctx, zoneID)
+	if change == nil {
+		return nil, domain.ErrNotFound
+	}
+	_, _ = m.fleet.DeleteShadowChange(ctx, zoneID)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.committed = append(m.committed, zoneID+"/"+tenantID)
+	return change, nil
+}
+
+func (m *fakeShadows) commits() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.committed
+}
+
+func newTestRolloutService(f *fakeFleet) *RolloutService {
+	s := NewRolloutService(f, f, nil)
+	s.pollInterval = time.Millisecond
+	s.applyTimeout = 200 * time.Millisecond
+	s.bakeUnit = time.Millisecond
+	return s
+}
+
+func waitRolloutDone(t *testing.T, s *RolloutService, id string) *domain.Rollout {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		r, err := s.GetRollout(context.Background(), id)
+		if err != nil {
+			t.Fatalf("GetRollout failed: %v", err)
+		}
+		if r != nil && r.State != domain.RolloutRunning {
+			return r
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("rollout %s did not finish", id)
+	return nil
+}
+
+func TestPlanBatches(t *testing.T) {
+	nodes := []domain.Node{
+		{ID: "a", Status: domain.NodeStatusUp},
+		{ID: "b", Status: domain.NodeStatusUp},
+		{ID: "c", Status: domain.NodeStatusStale},
+		{ID: "d", Status: domain.NodeStatusUp},
+		{ID: "e", Status: domain.NodeStatusUp},
+	}
+
+	batches, err := planBatches(nodes, "d", 2)
+	if err != nil {
+		t.Fatalf("planBatches failed: %v", err)
+	}
+	want := [][]string{{"d"}, {"a", "b"}, {"e"}}
+	if !reflect.DeepEqual(batches, want) {
+		t.Errorf("Expected %v, got %v", want, batches)
+	}
+
+	batches, _ = planBatches(nodes, "", 5)
+	if want := [][]string{{"a"}, {"b", "d", "e"}}; !reflect.DeepEqual(batches, want) {
+		t.Errorf("Expected first healthy node as canary %v, got %v", want, batches)
+	}
+
+	if _, err := planBatches(nodes, "c", 1); !errors.Is(err, domain.ErrInvalidRollout) {
+		t.Errorf("Expected ErrInvalidRollout for stale canary, got %v", err)
+	}
+	if _, err := planBatches(nil, "", 1); !errors.Is(err, domain.ErrInvalidRollout) {
+		t.Errorf("Expected ErrInvalidRollout for empty fleet, got %v", err)
+	}
+}
+
+func TestRolloutService_StartValidation(t *testing.T) {
+	s := newTestRolloutService(newFakeFleet("a"))
+	ctx := context.Background()
+
+	if _, err := s.StartRollout(ctx, &domain.Rollout{}); !errors.Is(err, domain.ErrInvalidRollout) {
+		t.Errorf("Expected ErrInvalidRollout for empty config, got %v", err)
+	}
+	spec := &domain.Rollout{Config: map[string]string{"log_level": "debug"}, MinQPSRatio: 1.5}
+	if _, err := s.StartRollout(ctx, spec); !errors.Is(err, domain.ErrInvalidRollout) {
+		t.Errorf("Expected ErrInvalidRollout for min_qps_ratio, got %v", err)
+	}
+}
+
+func TestRolloutService_Completes(t *testing.T) {
+	fleet := newFakeFleet("a", "b", "c")
+	s := newTestRolloutService(fleet)
+
+	r, err := s.StartRollout(context.Background(), &domain.Rollout{
+		Config:      map[string]string{"log_level": "debug"},
+		CanaryNode:  "b",
+		BatchSize:   2,
+		BakeSeconds: 1,
+	})
+	if err != nil {
+		t.Fatalf("StartRollout failed: %v", err)
+	}
+	if r.CanaryNode != "b" || len(r.Batches) != 2 || r.Previous["a"]["log_level"] != "info" {
+		t.Errorf("Unexpected rollout plan: %+v", r)
+	}
+
+	done := waitRolloutDone(t, s, r.ID)
+	if done.State != domain.RolloutCompleted || len(done.Completed) != 3 {
+		t.Fatalf("Expected COMPLETED on all nodes, got %+v", done)
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		if got := fleet.config(id)["log_level"]; got != "debug" {
+			t.Errorf("Expected node %s on new config, got %q", id, got)
+		}
+	}
+}
+
+func TestRolloutService_RollsBackOnRegression(t *testing.T) {
+	fleet := newFakeFleet("a", "b", "c")
+	fleet.regress["b"] = true
+	s := newTestRolloutService(fleet)
+
+	r, err := s.StartRollout(context.Background(), &domain.Rollout{
+		Config:      map[string]string{"log_level": "debug"},
+		CanaryNode:  "a",
+		BatchSize:   1,
+		BakeSeconds: 1,
+	})
+	if err != nil {
+		t.Fatalf("StartRollout failed: %v", err)
+	}
+
+	done := waitRolloutDone(t, s, r.ID)
+	if done.State != domain.RolloutRolledBack || done.Error == "" {
+		t.Fatalf("Expected ROLLED_BACK with an error, got %+v", done)
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		if got := fleet.config(id)["log_level"]; got != "info" {
+			t.Errorf("Expected node %s restored to previous config, got %q", id, got)
+		}
+	}
+}
+
+func TestRolloutService_RollsBackOnApplyError(t *testing.T) {
+	fleet := newFakeFleet("a", "b")
+	fleet.reject["a"] = true
+	s := newTestRolloutService(fleet)
+
+	r, err := s.StartRollout(context.Background(), &domain.Rollout{
+		Config:      map[string]string{"log_level": "debug"},
+		CanaryNode:  "a",
+		BakeSeconds: 1,
+	})
+	if err != nil {
+		t.Fatalf("StartRollout failed: %v", err)
+	}
+
+	done := waitRolloutDone(t, s, r.ID)
+	if done.State != domain.RolloutRolledBack || len(done.Completed) != 0 {
+		t.Fatalf("Expected canary failure to roll back, got %+v", done)
+	}
+	if got := fleet.config("b")["log_level"]; got != "info" {
+		t.Errorf("Expected node b untouched, got %q", got)
+	}
+}
+
+func TestRolloutService_Abort(t *testing.T) {
+	fleet := newFakeFleet("a", "b")
+	s := newTestRolloutService(fleet)
+	s.bakeUnit = time.Hour
+	ctx := context.Background()
+
+	r, err := s.StartRollout(ctx, &domain.Rollout{Config: map[string]string{"log_level": "debug"}, BakeSeconds: 1})
+	if err != nil {
+		t.Fatalf("StartRollout failed: %v", err)
+	}
+	if err := s.AbortRollout(ctx, r.ID); err != nil {
+		t.Fatalf("AbortRollout failed: %v", err)
+	}
+
+	done := waitRolloutDone(t, s, r.ID)
+	if done.State != domain.RolloutRolledBack || done.Error != errRolloutAborted.Error() {
+		t.Fatalf("Expected aborted rollout to roll back, got %+v", done)
+	}
+	if got := fleet.config("a")["log_level"]; got != "info" {
+		t.Errorf("Expected canary restored, got %q", got)
+	}
+	if err := s.AbortRollout(ctx, r.ID); !errors.Is(err, domain.ErrInvalidRollout) {
+		t.Errorf("Expected ErrInvalidRollout when aborting a finished rollout, got %v", err)
+	}
+}
+
+func TestRolloutService_ZoneValidation(t *testing.T) {
+	fleet := newFakeFleet("a")
+	s := newTestRolloutService(fleet)
+	ctx := context.Background()
+
+	if _, err := s.StartRollout(ctx, &domain.Rollout{ZoneID: "z1"}); !errors.Is(err, domain.ErrInvalidRollout) {
+		t.Errorf("Expected ErrInvalidRollout without a shadow manager, got %v", err)
+	}
+	s.SetShadows(&fakeShadows{fleet: fleet})
+	if _, err := s.StartRollout(ctx, &domain.Rollout{ZoneID: "z1"}); !errors.Is(err, domain.ErrInvalidRollout) {
+		t.Errorf("Expected ErrInvalidRollout without a staged shadow change, got %v", err)
+	}
+	_ = fleet.SaveShadowChange(ctx, &domain.ShadowChange{ZoneID: "z1", TenantID: "t1", CreatedAt: time.Now()})
+	spec := &domain.Rollout{ZoneID: "z1", Config: map[string]string{"log_level": "debug"}}
+	if _, err := s.StartRollout(ctx, spec); !errors.Is(err, domain.ErrInvalidRollout) {
+		t.Errorf("Expected ErrInvalidRollout for a zone rollout with config, got %v", err)
+	}
+}
+
+func TestRolloutService_ZoneCompletes(t *testing.T) {
+	fleet := newFakeFleet("a", "b", "c")
+	fleet.configs["b"][domain.NodeConfigShadowZones] = "z0"
+	shadows := &fakeShadows{fleet: fleet}
+	s := newTestRolloutService(fleet)
+	s.SetShadows(shadows)
+	ctx := context.Background()
+	_ = fleet.SaveShadowChange(ctx, &domain.ShadowChange{ZoneID: "z1", TenantID: "t1", CreatedAt: time.Now()})
+
+	r, err := s.StartRollout(ctx, &domain.Rollout{ZoneID: "z1", BatchSize: 2, BakeSeconds: 1})
+	if err != nil {
+		t.Fatalf("StartRollout failed: %v", err)
+	}
+	if r.TenantID != "t1" || r.StagedAt == nil {
+		t.Errorf("Expected the shadow change pinned on the rollout, got %+v", r)
+	}
+
+	done := waitRolloutDone(t, s, r.ID)
+	if done.State != domain.RolloutCompleted || len(done.Completed) != 3 {
+		t.Fatalf("Expected COMPLETED on all nodes, got %+v", done)
+	}
+	if got := shadows.commits(); !reflect.DeepEqual(got, []string{"z1/t1"}) {
+		t.Errorf("Expected the shadow change committed once for its tenant, got %v", got)
+	}
+	if got := fleet.config("a")[domain.NodeConfigShadowZones]; got != "" {
+		t.Errorf("Expected node a to stop serving the committed shadow change, got %q", got)
+	}
+	if got := fleet.config("b")[domain.NodeConfigShadowZones]; got != "z0" {
+		t.Errorf("Expected node b back on its previous shadow zones, got %q", got)
+	}
+}
+
+func TestRolloutService_ZoneRollsBackOnRegression(t *testing.T) {
+	fleet := newFakeFleet("a", "b")
+	fleet.regress["b"] = true
+	shadows := &fakeShadows{fleet: fleet}
+	s := newTestRolloutService(fleet)
+	s.SetShadows(shadows)
+	ctx := context.Background()
+	_ = fleet.SaveShadowChange(ctx, &domain.ShadowChange{ZoneID: "z1", TenantID: "t1", CreatedAt: time.Now()})
+
+	r, err := s.StartRollout(ctx, &domain.Rollout{ZoneID: "z1", CanaryNode: "a", BakeSeconds: 1})
+	if err != nil {
+		t.Fatalf("StartRollout failed: %v", err)
+	}
+
+	done := waitRolloutDone(t, s, r.ID)
+	if done.State != domain.RolloutRolledBack {
+		t.Fatalf("Expected ROLLED_BACK, got %+v", done)
+	}
+	if got := shadows.commits(); len(got) != 0 {
+		t.Errorf("Expected no commit, got %v", got)
+	}
+	if change, _ := fleet.GetShadowChange(ctx, "z1"); change == nil {
+		t.Error("Expected the shadow change to stay staged")
+	}
+	for _, id := range []string{"a", "b"} {
+		if got := fleet.config(id)[domain.NodeConfigShadowZones]; got != "" {
+			t.Errorf("Expected node %s to stop serving the shadow change, got %q", id, got)
+		}
+	}
+}
+
+func TestRolloutService_ZoneHaltsWhenRestaged(t *testing.T) {
+	fleet := newFakeFleet("a")
+	shadows := &fakeShadows{fleet: fleet}
+	s := newTestRolloutService(fleet)
+	s.SetShadows(shadows)
+	s.bakeUnit = 50 * time.Millisecond
+	ctx := context.Background()
+	staged := time.Now()
+	_ = fleet.SaveShadowChange(ctx, &domain.ShadowChange{ZoneID: "z1", TenantID: "t1", CreatedAt: staged})
+
+	r, err := s.StartRollout(ctx, &domain.Rollout{ZoneID: "z1", BakeSeconds: 1})
+	if err != nil {
+		t.Fatalf("StartRollout failed: %v", err)
+	}
+	_ = fleet.SaveShadowChange(ctx, &domain.ShadowChange{ZoneID: "z1", TenantID: "t1", CreatedAt: staged.Add(time.Second)})
+
+	done := waitRolloutDone(t, s, r.ID)
+	if done.State != domain.RolloutRolledBack || len(shadows.commits()) != 0 {
+		t.Fatalf("Expected a restaged shadow change to halt the rollout, got %+v", done)
+	}
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+)
+
+// defaultRRsetTTL is used when a new RRset is written without a TTL.
+const defaultRRsetTTL = 3600
+
+// GetRRset returns every record of a name and type in a zone. name may be
+// absolute, relative to the zone or "@" for the apex. It returns an error
+// wrapping domain.ErrNotFound if the zone or the RRset does not exist.
+func (s *dnsService) GetRRset(ctx context.Context, zoneID string, tenantID string, name string, qType domain.RecordType) (*domain.RRset, error) {
+	zone, records, err := s.loadRRsetZone(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	owner := rrsetOwner(zone.Name, name)
+	qType = domain.RecordType(strings.ToUpper(string(qType)))
+
+	rrset := buildRRset(zone.ID, owner, qType, records)
+	if len(rrset.Records) == 0 {
+		return nil, fmt.Errorf("rrset %s %s: %w", owner, qType, domain.ErrNotFound)
+	}
+	return rrset, nil
+}
+
+// ReplaceRRset atomically replaces every record of rrset.Name and rrset.Type with
+// rrset.Records, the way DNS itself treats an RRset as one unit. An empty record
+// list removes the RRset. A missing TTL keeps the current one. It returns an error
+// wrapping domain.ErrNotFound for an unknown zone and domain.ErrInvalidRRset for
+// an RRset that is malformed or would break the zone. Records whose content is
+// kept stay protected; dropping a protected record fails with
+// domain.ErrRecordProtected unless a break-glass key forces it.
+func (s *dnsService) ReplaceRRset(ctx context.Context, zoneID string, tenantID string, rrset *domain.RRset) (*domain.RRset, error) {
+	zone, records, err := s.loadRRsetZone(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	owner := rrsetOwner(zone.Name, rrset.Name)
+	qType := domain.RecordType(strings.ToUpper(string(rrset.Type)))
+	current := buildRRset(zone.ID, owner, qType, records)
+	if err := CheckChangeFreeze(ctx, s.repo, tenantID, zone.ID, fmt.Sprintf("REPLACE_RRSET %s %s", owner, qType)); err != nil {
+		return nil, err
+	}
+
+	if err := validateRRset(zone, owner, qType, rrset.Records, records); err != nil {
+		return nil, err
+	}
+
+	ttl := rrset.TTL
+	if ttl == 0 {
+		ttl = current.TTL
+		if ttl == 0 {
+			ttl = defaultRRsetTTL
+		}
+	}
+	if ttl < 60 {
+		ttl = 60
+	}
+
+	now := time.Now()
+	replacement := make([]domain.Record, 0, len(rrset.Records))
+	for _, rr := range rrset.Records {
+		replacement = append(replacement, domain.Record{
+			ID:                uuid.New().String(),
+			ZoneID:            zone.ID,
+			TenantID:          zone.TenantID,
+			Name:              owner,
+			Type:              qType,
+			Content:           strings.TrimSpace(rr.Content),
+			TTL:               ttl,
+			Priority:          rr.Priority,
+			Weight:            rr.Weight,
+			Port:              rr.Port,
+			Network:           rr.Network,
+			HealthCheckType:   rr.HealthCheckType,
+			HealthCheckTarget: rr.HealthCheckTarget,
+			CreatedAt:         now,
+			UpdatedAt:         now,
+		})
+	}
+
+	if err := protectReplacement(ctx, owner, qType, records, replacement); err != nil {
+		return nil, err
+	}
+	if err := CheckChangeReview(ctx, s.repo, tenantID, zone.ID, fmt.Sprintf("REPLACE_RRSET %s %s", owner, qType)); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.ReplaceRRset(ctx, zone.ID, owner, qType, replacement); err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx, owner, qType)
+
+	action := "REPLACE_RRSET"
+	details := fmt.Sprintf("Replaced %s %s with %d records", owner, qType, len(replacement))
+	if len(replacement) == 0 {
+		action = "DELETE_RRSET"
+		details = fmt.Sprintf("Deleted %s %s", owner, qType)
+	}
+	s.audit(ctx, tenantID, action, "RRSET", zone.ID, details)
+	s.publish(domain.Event{Type: domain.EventRecordChange, TenantID: tenantID, ZoneID: zone.ID, Action: action, Name: owner, RecordType: qType})
+
+	return buildRRset(zone.ID, owner, qType, replacement), nil
+}
+
+// loadRRsetZone returns a tenant's zone and its records.
+func (s *dnsService) loadRRsetZone(ctx context.Context, zoneID string, tenantID string) (*domain.Zone, []domain.Record, error) {
+	zone, err := s.repo.GetZoneByID(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load zone: %w", err)
+	}
+	if zone == nil {
+		return nil, nil, fmt.Errorf("zone %s: %w", zoneID, domain.ErrNotFound)
+	}
+	records, err := s.repo.ListRecordsForZone(ctx, zone.ID, tenantID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list records: %w", err)
+	}
+	return zone, records, nil
+}
+
+// rrsetOwner resolves an RRset name against its zone: "@" is the apex, names
+// ending in a dot are absolute, names already under the zone are taken as given
+// and anything else is relative to the zone.
+func rrsetOwner(zoneName, name string) string {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "" || name == "@":
+		return zoneName
+	case dnsname.IsFqdn(name):
+		return name
+	case dnsname.IsSubdomain(name, zoneName):
+		return dnsname.Fqdn(name)
+	default:
+		return name + "." + zoneName
+	}
+}
+
+// buildRRset collects the records of one name and type.
+func buildRRset(zoneID, owner string, qType domain.RecordType, records []domain.Record) *domain.RRset {
+	rrset := &domain.RRset{ZoneID: zoneID, Name: owner, Type: qType, Records: []domain.RRsetRecord{}}
+	for _, rec := range records {
+		if rec.Type != qType || !dnsname.Equal(rec.Name, owner) {
+			continue
+		}
+		if rrset.TTL == 0 || rec.TTL < rrset.TTL {
+			rrset.TTL = rec.TTL
+		}
+		rrset.Records = append(rrset.Records, domain.RRsetRecord{
+			Content:           rec.Content,
+			Priority:          rec.Priority,
+			Weight:            rec.Weight,
+			Port:              rec.Port,
+			Network:           rec.Network,
+			HealthCheckType:   rec.HealthCheckType,
+			HealthCheckTarget: rec.HealthCheckTarget,
+			HealthStatus:      rec.HealthStatus,
+		})
+	}
+	return rrset
+}
+
+// addressTypes are the record types an ALIAS record answers for its owner.
+var addressTypes = map[domain.RecordType]bool{domain.TypeA: true, domain.TypeAAAA: true}
+
+// validateRRset checks a replacement RRset against the zone: the owner must be in
+// the zone, SOA must stay a single record at the apex, CNAME may not share its
+// name with any other type (RFC 1034, Section 3.6.2) and ALIAS, which stands in
+// for the owner's addresses, not with A or AAAA records.
+func validateRRset(zone *domain.Zone, owner string, qType domain.RecordType, rrs []domain.RRsetRecord, existing []domain.Record) error {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%s: %w", fmt.Sprintf(format, args...), domain.ErrInvalidRRset)
+	}
+
+	if qType == "" {
+		return invalid("type is required")
+	}
+	if !dnsname.IsSubdomain(owner, zone.Name) {
+		return invalid("%s is not in zone %s", owner, zone.Name)
+	}
+	if qType == domain.TypeSOA && (len(rrs) != 1 || !dnsname.Equal(owner, zone.Name)) {
+		return invalid("a zone has exactly one SOA record, at its apex")
+	}
+	if qType == domain.TypeCNAME && dnsname.Equal(owner, zone.Name) && len(rrs) > 0 {
+		return invalid("CNAME is not allowed at the zone apex")
+	}
+
+	seen := make(map[string]bool, len(rrs))
+	for _, rr := range rrs {
+		content := strings.TrimSpace(rr.Content)
+		if content == "" {
+			return invalid("record content is required")
+		}
+		key := content
+		if rr.Network != nil {
+			key += " " + *rr.Network
+		}
+		if seen[key] {
+			return invalid("duplicate record %q", content)
+		}
+		seen[key] = true
+		if qType == domain.TypeSRV {
+			if err := domain.ValidateSRVFields(rr.Priority, rr.Weight, rr.Port, content); err != nil {
+				return invalid("invalid SRV record: %v", err)
+			}
+		}
+		if qType == domain.TypeSVCB || qType == domain.TypeHTTPS {
+			if err := domain.ValidateSVCBFields(rr.Priority, content); err != nil {
+				return invalid("invalid %s record: %v", qType, err)
+			}
+		}
+		if qType == domain.TypeCAA {
+			if err := domain.ValidateCAAContent(content); err != nil {
+				return invalid("invalid CAA record: %v", err)
+			}
+		}
+		if qType == domain.TypeALIAS {
+			if err := domain.ValidateALIASContent(content); err != nil {
+				return invalid("invalid ALIAS record: %v", err)
+			}
+		}
+		if err := domain.ValidateRDATAContent(qType, content); err != nil {
+			return invalid("invalid %s record: %v", qType, err)
+		}
+	}
+
+	if len(rrs) == 0 {
+		return nil
+	}
+	for _, rec := range existing {
+		if !dnsname.Equal(rec.Name, owner) || rec.Type == qType || dnssecRecordTypes[rec.Type] {
+			continue
+		}
+		if qType == domain.TypeCNAME || rec.Type == domain.TypeCNAME {
+			return invalid("CNAME at %s cannot coexist with %s records", owner, rec.Type)
+		}
+		if (qType == domain.TypeALIAS && addressTypes[rec.Type]) || (rec.Type == domain.TypeALIAS && addressTypes[qType]) {
+			return invalid("ALIAS at %s cannot coexist with A or AAAA records", owner)
+		}
+	}
+	if qType == domain.TypeCNAME || qType == domain.TypeALIAS {
+		// Split-horizon views may each carry their own CNAME or ALIAS
+		views := make(map[string]bool, len(rrs))
+		for _, rr := range rrs {
+			network := ""
+			if rr.Network != nil {
+				network = *rr.Network
+			}
+			if views[network] {
+				return invalid("only one %s per name and network is allowed", qType)
+			}
+			views[network] = true
+		}
+	}
+	return nil
+}
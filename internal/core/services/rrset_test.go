@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+func TestReplaceRRset(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepo{
+		zones: []domain.Zone{{ID: "z1", TenantID: "t1", Name: "example.com."}},
+		records: []domain.Record{
+			{ID: "r1", ZoneID: "z1", Name: "example.com.", Type: domain.TypeSOA, Content: "ns1. admin. 1 3600 600 86400 300", TTL: 3600},
+			{ID: "r2", ZoneID: "z1", Name: "www.example.com.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 300},
+			{ID: "r3", ZoneID: "z1", Name: "www.example.com.", Type: domain.TypeA, Content: "192.0.2.2", TTL: 300},
+			{ID: "r4", ZoneID: "z1", Name: "alias.example.com.", Type: domain.TypeCNAME, Content: "www.example.com.", TTL: 300},
+		},
+	}
+	svc := NewDNSService(repo, nil)
+
+	if _, err := svc.GetRRset(ctx, "missing", "t1", "www", domain.TypeA); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for unknown zone, got %v", err)
+	}
+	if _, err := svc.GetRRset(ctx, "z1", "t1", "www", domain.TypeAAAA); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for empty RRset, got %v", err)
+	}
+	rrset, err := svc.GetRRset(ctx, "z1", "t1", "www", "a")
+	if err != nil || rrset.Name != "www.example.com." || rrset.TTL != 300 || len(rrset.Records) != 2 {
+		t.Fatalf("Expected both A records of www, got %+v (%v)", rrset, err)
+	}
+
+	// Replacing keeps the TTL when none is given and swaps the whole set
+	rrset, err = svc.ReplaceRRset(ctx, "z1", "t1", &domain.RRset{Name: "www.example.com.", Type: domain.TypeA, Records: []domain.RRsetRecord{{Content: "198.51.100.7"}}})
+	if err != nil || rrset.TTL != 300 || len(rrset.Records) != 1 {
+		t.Fatalf("ReplaceRRset failed: %+v (%v)", rrset, err)
+	}
+	if got, _ := svc.GetRRset(ctx, "z1", "t1", "www.example.com", domain.TypeA); len(got.Records) != 1 || got.Records[0].Content != "198.51.100.7" {
+		t.Errorf("Expected the RRset replaced, got %+v", got)
+	}
+
+	invalid := []domain.RRset{
+		{Name: "@", Type: domain.TypeSOA},
+		{Name: "www.other.org.", Type: domain.TypeA, Records: []domain.RRsetRecord{{Content: "192.0.2.1"}}},
+		{Name: "@", Type: domain.TypeCNAME, Records: []domain.RRsetRecord{{Content: "elsewhere.org."}}},
+		{Name: "alias", Type: domain.TypeTXT, Records: []domain.RRsetRecord{{Content: "\"hello\""}}},
+		{Name: "www", Type: domain.TypeCNAME, Records: []domain.RRsetRecord{{Content: "elsewhere.org."}}},
+		{Name: "www", Type: domain.TypeA, Records: []domain.RRsetRecord{{Content: "192.0.2.1"}, {Content: "192.0.2.1"}}},
+		{Name: "_sip._tcp", Type: domain.TypeSRV, Records: []domain.RRsetRecord{{Content: "sip.example.com."}}},
+	}
+	for _, rr := range invalid {
+		if _, err := svc.ReplaceRRset(ctx, "z1", "t1", &rr); !errors.Is(err, domain.ErrInvalidRRset) {
+			t.Errorf("Expected ErrInvalidRRset for %s %s, got %v", rr.Name, rr.Type, err)
+		}
+	}
+
+	// An empty record list deletes the RRset
+	if _, err := svc.ReplaceRRset(ctx, "z1", "t1", &domain.RRset{Name: "alias", Type: domain.TypeCNAME}); err != nil {
+		t.Fatalf("Expected delete to succeed, got %v", err)
+	}
+	if _, err := svc.GetRRset(ctx, "z1", "t1", "alias", domain.TypeCNAME); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected CNAME removed, got %v", err)
+	}
+	var actions []string
+	for _, a := range repo.audits {
+		actions = append(actions, a.Action)
+	}
+	if len(actions) != 2 || actions[0] != "REPLACE_RRSET" || actions[1] != "DELETE_RRSET" {
+		t.Errorf("Expected replace and delete audited, got %v", actions)
+	}
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/core/ports"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+)
+
+// DefaultScheduleInterval is how often the scheduler looks for due changes.
+const DefaultScheduleInterval = 15 * time.Second
+
+// scheduleBatch caps how many due changes one scheduler pass claims.
+const scheduleBatch = 50
+
+// ScheduleService holds record changes until their scheduled time and then
+// applies them through the DNS service, so they are audited, published and
+// invalidated like any change made through the API.
+type ScheduleService struct {
+	repo   ports.DNSRepository
+	dns    ports.DNSService
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+// NewScheduleService creates a scheduler that applies due changes with dns.
+func NewScheduleService(repo ports.DNSRepository, dns ports.DNSService, logger *slog.Logger) *ScheduleService {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &ScheduleService{repo: repo, dns: dns, logger: logger, now: time.Now}
+}
+
+// ScheduleChange validates a change to a tenant's zone and stores it as pending.
+// run_at must be in the future, CREATE and UPDATE need a record with a name,
+// type and content, and UPDATE and DELETE need the ID of an existing record.
+func (s *ScheduleService) ScheduleChange(ctx context.Context, zoneID string, tenantID string, c *domain.ScheduledChange) error {
+	zone, err := s.repo.GetZoneByID(ctx, zoneID, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to load zone: %w", err)
+	}
+	if zone == nil {
+		return fmt.Errorf("zone %s: %w", zoneID, domain.ErrNotFound)
+	}
+
+	c.Action = strings.ToUpper(strings.TrimSpace(c.Action))
+	switch c.Action {
+	case domain.ScheduledCreate, domain.ScheduledUpdate, domain.ScheduledDelete:
+	default:
+		return fmt.Errorf("action %q: %w", c.Action, domain.ErrInvalidScheduledChange)
+	}
+	if !c.RunAt.After(s.now()) {
+		return fmt.Errorf("run_at must be in the future: %w", domain.ErrInvalidScheduledChange)
+	}
+
+	if c.Action == domain.ScheduledDelete {
+		c.Record = nil
+	} else {
+		if c.Record == nil || c.Record.Name == "" || c.Record.Type == "" || c.Record.Content == "" {
+			return fmt.Errorf("%s needs a record with a name, type and content: %w", c.Action, domain.ErrInvalidScheduledChange)
+		}
+		c.Record.Name = rrsetOwner(zone.Name, c.Record.Name)
+		c.Record.Type = domain.RecordType(strings.ToUpper(string(c.Record.Type)))
+		c.Record.ID = ""
+	}
+	if c.Action == domain.ScheduledCreate {
+		c.RecordID = ""
+	} else {
+		if c.RecordID == "" {
+			return fmt.Errorf("%s needs a record_id: %w", c.Action, domain.ErrInvalidScheduledChange)
+		}
+		rec, err := s.repo.GetRecord(ctx, c.RecordID, zone.ID, tenantID)
+		if err != nil {
+			return fmt.Errorf("failed to load record: %w", err)
+		}
+		if rec == nil {
+			return fmt.Errorf("record %s: %w", c.RecordID, domain.ErrNotFound)
+		}
+	}
+
+	c.ID = uuid.New().String()
+	c.ZoneID = zone.ID
+	c.TenantID = tenantID
+	c.Status = domain.ScheduledPending
+	c.Error = ""
+	c.CreatedAt = s.now()
+	c.ExecutedAt = nil
+	if err := s.repo.CreateScheduledChange(ctx, c); err != nil {
+		return err
+	}
+	s.audit(ctx, tenantID, "SCHEDULE_CHANGE", c.ID, fmt.Sprintf("%s in %s at %s", c.Action, zone.Name, c.RunAt.UTC().Format(time.RFC3339)))
+	return nil
+}
+
+// ListScheduledChanges returns every scheduled change of a tenant's zone, soonest
+// first, including those already applied or cancelled.
+func (s *ScheduleService) ListScheduledChanges(ctx context.Context, zoneID string, tenantID string) ([]domain.ScheduledChange, error) {
+	zone, err := s.repo.GetZoneByID(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load zone: %w", err)
+	}
+	if zone == nil {
+		return nil, fmt.Errorf("zone %s: %w", zoneID, domain.ErrNotFound)
+	}
+	changes, err := s.repo.ListScheduledChanges(ctx, zone.ID)
+	if err != nil {
+		return nil, err
+	}
+	if changes == nil {
+		changes = []domain.ScheduledChange{}
+	}
+	return changes, nil
+}
+
+// CancelScheduledChange cancels a pending change. Changes that already ran or
+// are running cannot be cancelled.
+func (s *ScheduleService) CancelScheduledChange(ctx context.Context, zoneID string, tenantID string, id string) error {
+	zone, err := s.repo.GetZoneByID(ctx, zoneID, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to load zone: %w", err)
+	}
+	if zone == nil {
+		return fmt.Errorf("zone %s: %w", zoneID, domain.ErrNotFound)
+	}
+	cancelled, err := s.repo.CancelScheduledChange(ctx, id, zone.ID)
+	if err != nil {
+		return err
+	}
+	if !cancelled {
+		return fmt.Errorf("no pending change %s: %w", id, domain.ErrInvalidScheduledChange)
+	}
+	s.audit(ctx, tenantID, "CANCEL_SCHEDULED_CHANGE", id, zone.Name)
+	return nil
+}
+
+// Start applies due changes immediately and then every interval until ctx is done.
+func (s *ScheduleService) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultScheduleInterval
+	}
+	s.logger.Info("starting change scheduler", "interval", interval)
+
+	s.RunDue(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("stopping change scheduler")
+			return
+		case <-ticker.C:
+			s.RunDue(ctx)
+		}
+	}
+}
+
+// RunDue claims the changes that are due and applies them, oldest first, until
+// none are left. It returns how many were applied successfully.
+func (s *ScheduleService) RunDue(ctx context.Context) int {
+	applied := 0
+	for {
+		due, err := s.repo.ClaimDueScheduledChanges(ctx, s.now(), scheduleBatch)
+		if err != nil {
+			s.logger.Error("failed to claim scheduled changes", "error", err)
+			return applied
+		}
+		for i := range due {
+			c := &due[i]
+			errApply := s.apply(ctx, c)
+			executed := s.now()
+			c.ExecutedAt = &executed
+			c.Status = domain.ScheduledDone
+			c.Error = ""
+			if errApply != nil {
+				c.Status = domain.ScheduledFailed
+				c.Error = errApply.Error()
+				s.logger.Warn("scheduled change failed", "id", c.ID, "zone_id", c.ZoneID, "action", c.Action, "error", errApply)
+			} else {
+				applied++
+				s.logger.Info("applied scheduled change", "id", c.ID, "zone_id", c.ZoneID, "action", c.Action)
+			}
+			metrics.ScheduledChangesApplied.WithLabelValues(c.Status).Inc()
+			if errFinish := s.repo.FinishScheduledChange(ctx, c); errFinish != nil {
+				s.logger.Error("failed to record scheduled change outcome", "id", c.ID, "error", errFinish)
+			}
+		}
+		if len(due) < scheduleBatch {
+			return applied
+		}
+	}
+}
+
+// apply performs one change. UPDATE adds the new record before removing the old
+// one, so the name keeps answering throughout the cutover.
+func (s *ScheduleService) apply(ctx context.Context, c *domain.ScheduledChange) error {
+	if c.Action != domain.ScheduledDelete {
+		if c.Record == nil {
+			return fmt.Errorf("%s has no record", c.Action)
+		}
+		rec := *c.Record
+		rec.ZoneID = c.ZoneID
+		rec.TenantID = c.TenantID
+		if err := s.dns.CreateRecord(ctx, &rec); err != nil {
+			return fmt.Errorf("failed to create record: %w", err)
+		}
+	}
+	if c.Action != domain.ScheduledCreate {
+		if err := s.dns.DeleteRecord(ctx, c.RecordID, c.ZoneID, c.TenantID); err != nil {
+			return fmt.Errorf("failed to delete record %s: %w", c.RecordID, err)
+		}
+	}
+	return nil
+}
+
+func (s *ScheduleService) audit(ctx context.Context, tenantID, action, id, details string) {
+	_ = s.repo.SaveAuditLog(ctx, &domain.AuditLog{
+		ID:           uuid.New().String(),
+		TenantID:     tenantID,
+		Action:       action,
+		ResourceType: "SCHEDULED_CHANGE",
+		ResourceID:   id,
+		Details:      details,
+		CreatedAt:    time.Now(),
+	})
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+func TestScheduleChange(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo := &mockRepo{
+		zones:   []domain.Zone{{ID: "z1", TenantID: "t1", Name: "sched.test."}},
+		records: []domain.Record{{ID: "r1", ZoneID: "z1", Name: "www.sched.test.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 300}},
+	}
+	sched := NewScheduleService(repo, NewDNSService(repo, nil), nil)
+	sched.now = func() time.Time { return now }
+	later := now.Add(time.Hour)
+
+	invalid := []*domain.ScheduledChange{
+		{Action: "RENAME", RunAt: later},
+		{Action: "DELETE", RecordID: "r1", RunAt: now.Add(-time.Minute)},
+		{Action: "CREATE", RunAt: later},
+		{Action: "UPDATE", RunAt: later, Record: &domain.Record{Name: "www", Type: domain.TypeA, Content: "192.0.2.2"}},
+	}
+	for _, c := range invalid {
+		if err := sched.ScheduleChange(ctx, "z1", "t1", c); !errors.Is(err, domain.ErrInvalidScheduledChange) {
+			t.Errorf("Expected %+v to be rejected, got %v", c, err)
+		}
+	}
+	if err := sched.ScheduleChange(ctx, "z1", "t1", &domain.ScheduledChange{Action: "delete", RecordID: "missing", RunAt: later}); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected an unknown record to be not found, got %v", err)
+	}
+	if err := sched.ScheduleChange(ctx, "z1", "t2", &domain.ScheduledChange{Action: "DELETE", RecordID: "r1", RunAt: later}); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected another tenant's zone to be not found, got %v", err)
+	}
+
+	update := &domain.ScheduledChange{Action: "update", RecordID: "r1", RunAt: later, Record: &domain.Record{Name: "www", Type: "a", Content: "192.0.2.2", TTL: 300}}
+	if err := sched.ScheduleChange(ctx, "z1", "t1", update); err != nil {
+		t.Fatalf("ScheduleChange failed: %v", err)
+	}
+	if update.Action != domain.ScheduledUpdate || update.Status != domain.ScheduledPending || update.Record.Name != "www.sched.test." || update.Record.Type != domain.TypeA {
+		t.Errorf("Expected a normalized pending update, got %+v", update)
+	}
+	cancel := &domain.ScheduledChange{Action: "CREATE", RunAt: later, Record: &domain.Record{Name: "new", Type: domain.TypeA, Content: "192.0.2.9"}}
+	if err := sched.ScheduleChange(ctx, "z1", "t1", cancel); err != nil {
+		t.Fatalf("ScheduleChange failed: %v", err)
+	}
+	if err := sched.CancelScheduledChange(ctx, "z1", "t1", cancel.ID); err != nil {
+		t.Fatalf("CancelScheduledChange failed: %v", err)
+	}
+	if err := sched.CancelScheduledChange(ctx, "z1", "t1", cancel.ID); !errors.Is(err, domain.ErrInvalidScheduledChange) {
+		t.Errorf("Expected a second cancel to fail, got %v", err)
+	}
+
+	// Nothing is due yet
+	if n := sched.RunDue(ctx); n != 0 {
+		t.Fatalf("Expected no changes before run_at, applied %d", n)
+	}
+
+	now = later
+	if n := sched.RunDue(ctx); n != 1 {
+		t.Fatalf("Expected the update to be applied, applied %d", n)
+	}
+	changes, err := sched.ListScheduledChanges(ctx, "z1", "t1")
+	if err != nil {
+		t.Fatalf("ListScheduledChanges failed: %v", err)
+	}
+	statuses := map[string]string{}
+	for _, c := range changes {
+		statuses[c.ID] = c.Status
+	}
+	if statuses[update.ID] != domain.ScheduledDone || statuses[cancel.ID] != domain.ScheduledCancelled {
+		t.Errorf("Unexpected outcomes: %v", statuses)
+	}
+	found := false
+	for _, r := range repo.records {
+		if r.Name == "www.sched.test." && r.Content == "192.0.2.2" && r.TenantID == "t1" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected the replacement record to be created")
+	}
+}
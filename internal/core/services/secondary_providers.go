@@ -0,0 +1,321 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/core/ports"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+)
+
+// DefaultSecondaryCheckInterval is how often external secondaries are asked for
+// the serial they serve.
+const DefaultSecondaryCheckInterval = 5 * time.Minute
+
+// defaultSecondaryNotifyDelay collects a burst of changes to a zone into one NOTIFY
+const defaultSecondaryNotifyDelay = 2 * time.Second
+
+// SecondaryService keeps external secondaries, such as customer-run BIND or NSD
+// servers, in step with their zones: it sends them NOTIFY after zone changes and
+// periodically queries their SOA to record the serial they actually serve.
+type SecondaryService struct {
+	repo     ports.DNSRepository
+	querier  ports.DNSQuerier
+	notifier ports.DNSNotifier
+	logger   *slog.Logger
+	delay    time.Duration
+
+	mu      sync.Mutex
+	pending map[string]*time.Timer
+}
+
+// NewSecondaryService creates a service that checks serials with querier and
+// sends NOTIFY with notifier.
+func NewSecondaryService(repo ports.DNSRepository, querier ports.DNSQuerier, notifier ports.DNSNotifier, logger *slog.Logger) *SecondaryService {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SecondaryService{
+		repo:     repo,
+		querier:  querier,
+		notifier: notifier,
+		logger:   logger,
+		delay:    defaultSecondaryNotifyDelay,
+		pending:  make(map[string]*time.Timer),
+	}
+}
+
+func (s *SecondaryService) tenantZone(ctx context.Context, zoneID string, tenantID string) (*domain.Zone, error) {
+	zone, err := s.repo.GetZoneByID(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load zone: %w", err)
+	}
+	if zone == nil {
+		return nil, fmt.Errorf("zone %s: %w", zoneID, domain.ErrNotFound)
+	}
+	return zone, nil
+}
+
+// AddSecondary registers an external secondary for a tenant's zone. The address
+// defaults to port 53, and a TSIG key needs both a name and a base64 secret.
+func (s *SecondaryService) AddSecondary(ctx context.Context, zoneID string, tenantID string, p *domain.SecondaryProvider) error {
+	zone, err := s.tenantZone(ctx, zoneID, tenantID)
+	if err != nil {
+		return err
+	}
+
+	addr := strings.TrimSpace(p.Address)
+	if _, _, errSplit := net.SplitHostPort(addr); errSplit != nil {
+		addr = net.JoinHostPort(strings.Trim(addr, "[]"), "53")
+	}
+	host, _, errSplit := net.SplitHostPort(addr)
+	if errSplit != nil || host == "" {
+		return fmt.Errorf("address %q: %w", p.Address, domain.ErrInvalidSecondary)
+	}
+	if (p.TSIGKeyName == "") != (p.TSIGSecret == "") {
+		return fmt.Errorf("a TSIG key needs both a name and a secret: %w", domain.ErrInvalidSecondary)
+	}
+	if p.TSIGSecret != "" {
+		if _, errDecode := base64.StdEncoding.DecodeString(p.TSIGSecret); errDecode != nil {
+			return fmt.Errorf("TSIG secret is not base64: %w", domain.ErrInvalidSecondary)
+		}
+		p.TSIGKeyName = dnsname.Fqdn(p.TSIGKeyName)
+	}
+	if p.Name == "" {
+		p.Name = addr
+	}
+
+	p.ID = uuid.New().String()
+	p.ZoneID = zone.ID
+	p.Address = addr
+	p.CreatedAt = time.Now()
+	if err := s.repo.CreateSecondaryProvider(ctx, p); err != nil {
+		return err
+	}
+	s.audit(ctx, tenantID, "CREATE_SECONDARY", p.ID, fmt.Sprintf("%s %s for %s", p.Name, p.Address, zone.Name))
+	return nil
+}
+
+// ListSecondaries returns a zone's external secondaries with whether each was
+// last seen serving the zone's current serial.
+func (s *SecondaryService) ListSecondaries(ctx context.Context, zoneID string, tenantID string) ([]domain.SecondaryProvider, error) {
+	zone, err := s.tenantZone(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	providers, err := s.repo.ListSecondaryProviders(ctx, zone.ID)
+	if err != nil {
+		return nil, err
+	}
+	serial := s.zoneSerial(ctx, zone)
+	for i := range providers {
+		providers[i].InSync = providers[i].LastCheckedAt != nil && providers[i].ObservedSerial == serial
+	}
+	if providers == nil {
+		providers = []domain.SecondaryProvider{}
+	}
+	return providers, nil
+}
+
+// DeleteSecondary removes an external secondary from a tenant's zone.
+func (s *SecondaryService) DeleteSecondary(ctx context.Context, zoneID string, tenantID string, id string) error {
+	zone, err := s.tenantZone(ctx, zoneID, tenantID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteSecondaryProvider(ctx, id, zone.ID); err != nil {
+		return err
+	}
+	s.audit(ctx, tenantID, "DELETE_SECONDARY", id, zone.Name)
+	return nil
+}
+
+// ZoneChanged schedules a NOTIFY to the zone's external secondaries. Changes
+// arriving before it is sent are covered by the same NOTIFY.
+func (s *SecondaryService) ZoneChanged(zoneID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.pending[zoneID]; ok {
+		return
+	}
+	s.pending[zoneID] = time.AfterFunc(s.delay, func() {
+		s.mu.Lock()
+		delete(s.pending, zoneID)
+		s.mu.Unlock()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		s.NotifyZone(ctx, zoneID)
+	})
+}
+
+// WatchChanges calls ZoneChanged for every zone or record change published on
+// events until ctx is done.
+func (s *SecondaryService) WatchChanges(ctx context.Context, events ports.EventStream) {
+	ch := events.Subscribe(ctx, domain.EventFilter{Types: []domain.EventType{domain.EventZoneChange, domain.EventRecordChange}})
+	for e := range ch {
+		if e.ZoneID == "" || e.Action == "DELETE_ZONE" {
+			continue
+		}
+		s.ZoneChanged(e.ZoneID)
+	}
+}
+
+// NotifyZone sends NOTIFY to every external secondary of the zone now and
+// records the outcome.
+func (s *SecondaryService) NotifyZone(ctx context.Context, zoneID string) {
+	providers, err := s.repo.ListSecondaryProviders(ctx, zoneID)
+	if err != nil {
+		s.logger.Error("failed to list secondaries for NOTIFY", "zone_id", zoneID, "error", err)
+		return
+	}
+	if len(providers) == 0 {
+		return
+	}
+	zone, err := s.repo.GetZoneByID(ctx, zoneID, "")
+	if err != nil || zone == nil {
+		s.logger.Error("failed to load zone for NOTIFY", "zone_id", zoneID, "error", err)
+		return
+	}
+
+	for i := range providers {
+		p := &providers[i]
+		secret, _ := base64.StdEncoding.DecodeString(p.TSIGSecret)
+		errNotify := s.notifier.Notify(ctx, p.Address, zone.Name, p.TSIGKeyName, secret)
+		now := time.Now()
+		p.LastNotifiedAt = &now
+		p.LastError = ""
+		if errNotify != nil {
+			p.LastError = "notify: " + errNotify.Error()
+			s.logger.Warn("NOTIFY to external secondary failed", "zone", zone.Name, "secondary", p.Name, "address", p.Address, "error", errNotify)
+		} else {
+			s.logger.Info("notified external secondary", "zone", zone.Name, "secondary", p.Name, "address", p.Address)
+		}
+		if errUpdate := s.repo.UpdateSecondaryProviderStatus(ctx, p); errUpdate != nil {
+			s.logger.Error("failed to record NOTIFY status", "secondary", p.ID, "error", errUpdate)
+		}
+	}
+}
+
+// Start checks serials immediately and then every interval until ctx is done.
+func (s *SecondaryService) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultSecondaryCheckInterval
+	}
+	s.logger.Info("starting external secondary checks", "interval", interval)
+
+	s.CheckSerials(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("stopping external secondary checks")
+			return
+		case <-ticker.C:
+			s.CheckSerials(ctx)
+		}
+	}
+}
+
+// CheckSerials queries the SOA of every zone from each of its external
+// secondaries and records the serial they serve.
+func (s *SecondaryService) CheckSerials(ctx context.Context) {
+	providers, err := s.repo.ListSecondaryProviders(ctx, "")
+	if err != nil {
+		s.logger.Error("failed to list external secondaries", "error", err)
+		return
+	}
+	if len(providers) == 0 {
+		metrics.SecondariesOutOfSync.Set(0)
+		return
+	}
+	zones, err := s.repo.ListZones(ctx, "")
+	if err != nil {
+		s.logger.Error("failed to list zones for secondary checks", "error", err)
+		return
+	}
+	byID := make(map[string]*domain.Zone, len(zones))
+	for i := range zones {
+		byID[zones[i].ID] = &zones[i]
+	}
+
+	serials := make(map[string]uint32)
+	outOfSync := 0
+	for i := range providers {
+		p := &providers[i]
+		zone := byID[p.ZoneID]
+		if zone == nil {
+			continue
+		}
+		serial, ok := serials[zone.ID]
+		if !ok {
+			serial = s.zoneSerial(ctx, zone)
+			serials[zone.ID] = serial
+		}
+
+		now := time.Now()
+		p.LastCheckedAt = &now
+		p.LastError = ""
+		observed, errCheck := s.observedSerial(ctx, p.Address, zone.Name)
+		if errCheck != nil {
+			p.LastError = "soa check: " + errCheck.Error()
+		} else {
+			p.ObservedSerial = observed
+		}
+		if errCheck != nil || p.ObservedSerial != serial {
+			outOfSync++
+			s.logger.Warn("external secondary is out of sync", "zone", zone.Name, "secondary", p.Name,
+				"observed", p.ObservedSerial, "expected", serial, "error", errCheck)
+		}
+		if errUpdate := s.repo.UpdateSecondaryProviderStatus(ctx, p); errUpdate != nil {
+			s.logger.Error("failed to record secondary serial", "secondary", p.ID, "error", errUpdate)
+		}
+	}
+	metrics.SecondariesOutOfSync.Set(float64(outOfSync))
+}
+
+func (s *SecondaryService) observedSerial(ctx context.Context, addr string, zoneName string) (uint32, error) {
+	answer, err := s.querier.Query(ctx, addr, zoneName, domain.TypeSOA)
+	if err != nil {
+		return 0, err
+	}
+	if answer.Rcode != 0 {
+		return 0, fmt.Errorf("rcode %d", answer.Rcode)
+	}
+	for _, rec := range answer.Records {
+		if rec.Type == domain.TypeSOA {
+			return soaSerial(rec.Content), nil
+		}
+	}
+	return 0, fmt.Errorf("no SOA in answer")
+}
+
+func (s *SecondaryService) zoneSerial(ctx context.Context, zone *domain.Zone) uint32 {
+	records, err := s.repo.GetRecords(ctx, zone.Name, domain.TypeSOA, "")
+	if err != nil || len(records) == 0 {
+		return 0
+	}
+	return soaSerial(records[0].Content)
+}
+
+// audit records a change to a zone's secondaries without failing the operation.
+func (s *SecondaryService) audit(ctx context.Context, tenantID, action, id, details string) {
+	_ = s.repo.SaveAuditLog(ctx, &domain.AuditLog{
+		ID:           uuid.New().String(),
+		TenantID:     tenantID,
+		Action:       action,
+		ResourceType: "SECONDARY",
+		ResourceID:   id,
+		Details:      details,
+		CreatedAt:    time.Now(),
+	})
+}
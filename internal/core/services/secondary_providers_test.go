@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+type notifyCall struct {
+	server, zone, key string
+	secret            []byte
+}
+
+type fakeNotifier struct {
+	mu    sync.Mutex
+	calls []notifyCall
+	fail  map[string]bool
+}
+
+func (f *fakeNotifier) Notify(_ context.Context, server, zone, keyName string, secret []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, notifyCall{server, zone, keyName, secret})
+	if f.fail[server] {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func (f *fakeNotifier) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.calls)
+}
+
+func secondaryFixture() (*mockRepo, *addrQuerier, *fakeNotifier, *SecondaryService) {
+	repo := &mockRepo{
+		zones: []domain.Zone{{ID: "z1", TenantID: "t1", Name: "example.com."}},
+		records: []domain.Record{
+			{ZoneID: "z1", Name: "example.com.", Type: domain.TypeSOA, Content: "ns1.example.com. admin.example.com. 42 3600 600 86400 300"},
+		},
+	}
+	querier := &addrQuerier{serials: map[string]string{}, down: map[string]bool{}}
+	notifier := &fakeNotifier{fail: map[string]bool{}}
+	return repo, querier, notifier, NewSecondaryService(repo, querier, notifier, nil)
+}
+
+func TestAddSecondary(t *testing.T) {
+	repo, _, _, svc := secondaryFixture()
+	ctx := context.Background()
+
+	p := &domain.SecondaryProvider{Name: "customer-bind", Address: "192.0.2.53", TSIGKeyName: "xfer-key", TSIGSecret: "c2VjcmV0"}
+	if err := svc.AddSecondary(ctx, "z1", "t1", p); err != nil {
+		t.Fatalf("AddSecondary failed: %v", err)
+	}
+	if p.ID == "" || p.ZoneID != "z1" || p.Address != "192.0.2.53:53" || p.TSIGKeyName != "xfer-key." {
+		t.Errorf("Expected defaults applied, got %+v", p)
+	}
+	if err := svc.AddSecondary(ctx, "z1", "t1", &domain.SecondaryProvider{Address: "[2001:db8::53]:5353"}); err != nil {
+		t.Errorf("Expected IPv6 address with port accepted, got %v", err)
+	}
+	if len(repo.secondaries) != 2 || len(repo.audits) != 2 || repo.audits[0].Action != "CREATE_SECONDARY" {
+		t.Errorf("Expected two secondaries and audit entries, got %d / %+v", len(repo.secondaries), repo.audits)
+	}
+
+	invalid := []*domain.SecondaryProvider{
+		{Address: ":53"},
+		{Address: "192.0.2.1", TSIGKeyName: "key"},
+		{Address: "192.0.2.1", TSIGKeyName: "key", TSIGSecret: "not base64!"},
+	}
+	for _, p := range invalid {
+		if err := svc.AddSecondary(ctx, "z1", "t1", p); !errors.Is(err, domain.ErrInvalidSecondary) {
+			t.Errorf("Expected ErrInvalidSecondary for %+v, got %v", p, err)
+		}
+	}
+	if err := svc.AddSecondary(ctx, "z1", "other", &domain.SecondaryProvider{Address: "192.0.2.1"}); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for another tenant's zone, got %v", err)
+	}
+}
+
+func TestSecondaryNotifyAndSerialChecks(t *testing.T) {
+	repo, querier, notifier, svc := secondaryFixture()
+	ctx := context.Background()
+	_ = svc.AddSecondary(ctx, "z1", "t1", &domain.SecondaryProvider{Name: "signed", Address: "192.0.2.1:53", TSIGKeyName: "k.", TSIGSecret: "c2VjcmV0"})
+	_ = svc.AddSecondary(ctx, "z1", "t1", &domain.SecondaryProvider{Name: "down", Address: "192.0.2.2:53"})
+
+	notifier.fail["192.0.2.2:53"] = true
+	svc.NotifyZone(ctx, "z1")
+	if len(notifier.calls) != 2 || notifier.calls[0].zone != "example.com." || notifier.calls[0].key != "k." || string(notifier.calls[0].secret) != "secret" {
+		t.Fatalf("Expected a signed NOTIFY per secondary, got %+v", notifier.calls)
+	}
+	if notifier.calls[1].key != "" {
+		t.Errorf("Expected an unsigned NOTIFY without a key, got %+v", notifier.calls[1])
+	}
+	if repo.secondaries[0].LastNotifiedAt == nil || repo.secondaries[0].LastError != "" || repo.secondaries[1].LastError == "" {
+		t.Errorf("Expected NOTIFY outcome recorded, got %+v", repo.secondaries)
+	}
+
+	querier.serials["192.0.2.1:53"] = "42"
+	querier.down["192.0.2.2:53"] = true
+	svc.CheckSerials(ctx)
+	list, err := svc.ListSecondaries(ctx, "z1", "t1")
+	if err != nil {
+		t.Fatalf("ListSecondaries failed: %v", err)
+	}
+	if len(list) != 2 || !list[0].InSync || list[0].ObservedSerial != 42 || list[0].LastCheckedAt == nil {
+		t.Errorf("Expected the first secondary in sync at 42, got %+v", list[0])
+	}
+	if list[1].InSync || list[1].LastError == "" {
+		t.Errorf("Expected the unreachable secondary out of sync, got %+v", list[1])
+	}
+
+	// A lagging secondary keeps reporting its own serial
+	querier.serials["192.0.2.1:53"] = "41"
+	svc.CheckSerials(ctx)
+	if list, _ := svc.ListSecondaries(ctx, "z1", "t1"); list[0].InSync || list[0].ObservedSerial != 41 {
+		t.Errorf("Expected the secondary to lag at 41, got %+v", list[0])
+	}
+
+	if err := svc.DeleteSecondary(ctx, "z1", "t1", list[1].ID); err != nil || len(repo.secondaries) != 1 {
+		t.Errorf("Expected the secondary deleted, got %v (%d left)", err, len(repo.secondaries))
+	}
+}
+
+func TestSecondaryZoneChangedCoalesces(t *testing.T) {
+	_, _, notifier, svc := secondaryFixture()
+	svc.delay = 20 * time.Millisecond
+	_ = svc.AddSecondary(context.Background(), "z1", "t1", &domain.SecondaryProvider{Address: "192.0.2.1"})
+
+	for i := 0; i < 5; i++ {
+		svc.ZoneChanged("z1")
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for notifier.count() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := notifier.count(); n != 1 {
+		t.Errorf("Expected a burst of changes to send one NOTIFY, got %d", n)
+	}
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/core/ports"
+)
+
+// ShadowService stages RRset replacements in shadow mode, where DNS servers
+// compare them with live answers without serving them, and commits them through
+// the DNS service once they have been validated.
+type ShadowService struct {
+	repo   ports.DNSRepository
+	dns    ports.DNSService
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+// NewShadowService creates a shadow change manager that commits with dns.
+func NewShadowService(repo ports.DNSRepository, dns ports.DNSService, logger *slog.Logger) *ShadowService {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &ShadowService{repo: repo, dns: dns, logger: logger, now: time.Now}
+}
+
+// StageShadowChange validates a set of RRset replacements for a tenant's zone and
+// stores it as the zone's shadow change, replacing any staged before. Each RRset
+// is checked the way ReplaceRRset would check it, and canary networks must be
+// CIDRs. Canary clients are served the staged answers, so a change with canary
+// networks needs the zone's review to pass.
+func (s *ShadowService) StageShadowChange(ctx context.Context, zoneID string, tenantID string, change *domain.ShadowChange) error {
+	zone, err := s.repo.GetZoneByID(ctx, zoneID, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to load zone: %w", err)
+	}
+	if zone == nil {
+		return fmt.Errorf("zone %s: %w", zoneID, domain.ErrNotFound)
+	}
+	if len(change.RRsets) == 0 {
+		return fmt.Errorf("at least one rrset is required: %w", domain.ErrInvalidShadowChange)
+	}
+	records, err := s.repo.ListRecordsForZone(ctx, zone.ID, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to list records: %w", err)
+	}
+
+	seen := make(map[string]bool, len(change.RRsets))
+	for i := range change.RRsets {
+		rrset := &change.RRsets[i]
+		rrset.ZoneID = zone.ID
+		rrset.Name = rrsetOwner(zone.Name, rrset.Name)
+		rrset.Type = domain.RecordType(strings.ToUpper(string(rrset.Type)))
+		key := strings.ToLower(rrset.Name) + " " + string(rrset.Type)
+		if seen[key] {
+			return fmt.Errorf("rrset %s %s is staged twice: %w", rrset.Name, rrset.Type, domain.ErrInvalidShadowChange)
+		}
+		seen[key] = true
+		if err := validateRRset(zone, rrset.Name, rrset.Type, rrset.Records, records); err != nil {
+			return err
+		}
+		if rrset.TTL == 0 {
+			rrset.TTL = buildRRset(zone.ID, rrset.Name, rrset.Type, records).TTL
+			if rrset.TTL == 0 {
+				rrset.TTL = defaultRRsetTTL
+			}
+		}
+		if rrset.TTL < 60 {
+			rrset.TTL = 60
+		}
+		for j := range rrset.Records {
+			rrset.Records[j].Content = strings.TrimSpace(rrset.Records[j].Content)
+			rrset.Records[j].HealthStatus = ""
+		}
+	}
+	for _, cidr := range change.CanaryNetworks {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("canary network %q: %w", cidr, domain.ErrInvalidShadowChange)
+		}
+	}
+	if len(change.CanaryNetworks) > 0 {
+		if err := CheckChangeReview(ctx, s.repo, tenantID, zone.ID, "STAGE_SHADOW_CHANGE "+strings.Join(change.CanaryNetworks, ",")); err != nil {
+			return err
+		}
+	}
+
+	change.ZoneID = zone.ID
+	change.TenantID = tenantID
+	change.CreatedAt = s.now()
+	if err := s.repo.SaveShadowChange(ctx, change); err != nil {
+		return err
+	}
+	s.audit(ctx, tenantID, "STAGE_SHADOW_CHANGE", zone.ID, fmt.Sprintf("%d rrsets in %s", len(change.RRsets), zone.Name))
+	return nil
+}
+
+// GetShadowChange returns the shadow change staged for a tenant's zone, or an
+// error wrapping domain.ErrNotFound if there is none.
+func (s *ShadowService) GetShadowChange(ctx context.Context, zoneID string, tenantID string) (*domain.ShadowChange, error) {
+	zone, err := s.repo.GetZoneByID(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load zone: %w", err)
+	}
+	if zone == nil {
+		return nil, fmt.Errorf("zone %s: %w", zoneID, domain.ErrNotFound)
+	}
+	change, err := s.repo.GetShadowChange(ctx, zone.ID)
+	if err != nil {
+		return nil, err
+	}
+	if change == nil {
+		return nil, fmt.Errorf("no shadow change for zone %s: %w", zone.Name, domain.ErrNotFound)
+	}
+	return change, nil
+}
+
+// DiscardShadowChange drops the shadow change staged for a tenant's zone without
+// applying it.
+func (s *ShadowService) DiscardShadowChange(ctx context.Context, zoneID string, tenantID string) error {
+	zone, err := s.repo.GetZoneByID(ctx, zoneID, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to load zone: %w", err)
+	}
+	if zone == nil {
+		return fmt.Errorf("zone %s: %w", zoneID, domain.ErrNotFound)
+	}
+	deleted, err := s.repo.DeleteShadowChange(ctx, zone.ID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("no shadow change for zone %s: %w", zone.Name, domain.ErrNotFound)
+	}
+	s.audit(ctx, tenantID, "DISCARD_SHADOW_CHANGE", zone.ID, zone.Name)
+	return nil
+}
+
+// CommitShadowChange applies the shadow change staged for a tenant's zone,
+// replacing each RRset through the DNS service, and removes it from shadow mode.
+// If an RRset fails, the ones before it stay applied and the shadow change is
+// kept so that it can be fixed and committed again.
+func (s *ShadowService) CommitShadowChange(ctx context.Context, zoneID string, tenantID string) (*domain.ShadowChange, error) {
+	change, err := s.GetShadowChange(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range change.RRsets {
+		rrset := change.RRsets[i]
+		if _, err := s.dns.ReplaceRRset(ctx, change.ZoneID, tenantID, &rrset); err != nil {
+			return nil, fmt.Errorf("failed to commit %s %s: %w", rrset.Name, rrset.Type, err)
+		}
+	}
+	if _, err := s.repo.DeleteShadowChange(ctx, change.ZoneID); err != nil {
+		return nil, err
+	}
+	s.audit(ctx, tenantID, "COMMIT_SHADOW_CHANGE", change.ZoneID, fmt.Sprintf("%d rrsets", len(change.RRsets)))
+	return change, nil
+}
+
+func (s *ShadowService) audit(ctx context.Context, tenantID, action, id, details string) {
+	_ = s.repo.SaveAuditLog(ctx, &domain.AuditLog{
+		ID:           uuid.New().String(),
+		TenantID:     tenantID,
+		Action:       action,
+		ResourceType: "SHADOW_CHANGE",
+		ResourceID:   id,
+		Details:      details,
+		CreatedAt:    time.Now(),
+	})
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+func TestShadowChange(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepo{
+		zones:   []domain.Zone{{ID: "z1", TenantID: "t1", Name: "shadow.test."}},
+		records: []domain.Record{{ID: "r1", ZoneID: "z1", Name: "www.shadow.test.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 300}},
+	}
+	shadows := NewShadowService(repo, NewDNSService(repo, nil), nil)
+
+	invalid := []*domain.ShadowChange{
+		{},
+		{RRsets: []domain.RRset{{Name: "www", Type: "A", Records: []domain.RRsetRecord{{Content: "192.0.2.2"}}}, {Name: "www.shadow.test.", Type: "a"}}},
+		{RRsets: []domain.RRset{{Name: "www", Type: "A", Records: []domain.RRsetRecord{{Content: "192.0.2.2"}}}}, CanaryNetworks: []string{"10.0.0.1"}},
+	}
+	for _, c := range invalid {
+		if err := shadows.StageShadowChange(ctx, "z1", "t1", c); !errors.Is(err, domain.ErrInvalidShadowChange) {
+			t.Errorf("Expected %+v to be rejected, got %v", c, err)
+		}
+	}
+	bad := &domain.ShadowChange{RRsets: []domain.RRset{{Name: "www.other.test.", Type: "A", Records: []domain.RRsetRecord{{Content: "192.0.2.2"}}}}}
+	if err := shadows.StageShadowChange(ctx, "z1", "t1", bad); !errors.Is(err, domain.ErrInvalidRRset) {
+		t.Errorf("Expected an out-of-zone RRset to be rejected, got %v", err)
+	}
+	if _, err := shadows.GetShadowChange(ctx, "z1", "t1"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected no shadow change yet, got %v", err)
+	}
+
+	change := &domain.ShadowChange{
+		Description:    "move www",
+		RRsets:         []domain.RRset{{Name: "www", Type: "a", Records: []domain.RRsetRecord{{Content: " 192.0.2.2 "}}}},
+		CanaryNetworks: []string{"10.0.0.0/8"},
+	}
+	if err := shadows.StageShadowChange(ctx, "z1", "t1", change); err != nil {
+		t.Fatalf("StageShadowChange failed: %v", err)
+	}
+	got, err := shadows.GetShadowChange(ctx, "z1", "t1")
+	if err != nil {
+		t.Fatalf("GetShadowChange failed: %v", err)
+	}
+	rrset := got.RRsets[0]
+	if rrset.Name != "www.shadow.test." || rrset.Type != domain.TypeA || rrset.TTL != 300 || rrset.Records[0].Content != "192.0.2.2" {
+		t.Errorf("Expected a normalized RRset keeping the current TTL, got %+v", rrset)
+	}
+	if _, err := shadows.GetShadowChange(ctx, "z1", "t2"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected another tenant's zone to be not found, got %v", err)
+	}
+	// Staging serves nothing
+	if repo.records[0].Content != "192.0.2.1" {
+		t.Errorf("Expected the live record to be untouched, got %+v", repo.records)
+	}
+
+	if _, err := shadows.CommitShadowChange(ctx, "z1", "t1"); err != nil {
+		t.Fatalf("CommitShadowChange failed: %v", err)
+	}
+	if len(repo.records) != 1 || repo.records[0].Content != "192.0.2.2" || repo.records[0].TTL != 300 {
+		t.Errorf("Expected the staged RRset to be live, got %+v", repo.records)
+	}
+	if _, err := shadows.GetShadowChange(ctx, "z1", "t1"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected the committed shadow change to be gone, got %v", err)
+	}
+	if err := shadows.DiscardShadowChange(ctx, "z1", "t1"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected nothing to discard, got %v", err)
+	}
+
+	if err := shadows.StageShadowChange(ctx, "z1", "t1", &domain.ShadowChange{RRsets: []domain.RRset{{Name: "new", Type: "A", Records: []domain.RRsetRecord{{Content: "192.0.2.9"}}}}}); err != nil {
+		t.Fatalf("StageShadowChange failed: %v", err)
+	}
+	if err := shadows.DiscardShadowChange(ctx, "z1", "t1"); err != nil {
+		t.Fatalf("DiscardShadowChange failed: %v", err)
+	}
+	if len(repo.records) != 1 {
+		t.Errorf("Expected a discarded change not to be applied, got %+v", repo.records)
+	}
+}
+
+func TestCommitShadowChangeRespectsFreeze(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepo{
+		zones: []domain.Zone{{ID: "z1", TenantID: "t1", Name: "shadow.test."}},
+	}
+	shadows := NewShadowService(repo, NewDNSService(repo, nil), nil)
+	if err := shadows.StageShadowChange(ctx, "z1", "t1", &domain.ShadowChange{RRsets: []domain.RRset{{Name: "www", Type: "A", Records: []domain.RRsetRecord{{Content: "192.0.2.2"}}}}}); err != nil {
+		t.Fatalf("StageShadowChange failed: %v", err)
+	}
+	repo.freezes = []domain.ChangeFreeze{{ID: "f1", TenantID: "t1", Reason: "release", StartsAt: time.Now().Add(-time.Hour), EndsAt: time.Now().Add(time.Hour)}}
+	if _, err := shadows.CommitShadowChange(ctx, "z1", "t1"); !errors.Is(err, domain.ErrChangeFrozen) {
+		t.Fatalf("Expected the freeze to block the commit, got %v", err)
+	}
+	if _, err := shadows.GetShadowChange(ctx, "z1", "t1"); err != nil {
+		t.Errorf("Expected the shadow change to be kept, got %v", err)
+	}
+}
@@ -0,0 +1,438 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/core/ports"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+)
+
+const (
+	// DefaultSLOCheckInterval is how often zone latencies are checked against
+	// their SLOs.
+	DefaultSLOCheckInterval = time.Minute
+	// DefaultSLOWindowMinutes is the latency window of SLOs that do not set one,
+	// and of zones without an SLO.
+	DefaultSLOWindowMinutes = 5
+
+	// sloMaxWindowMinutes is the longest window latencies are kept for.
+	sloMaxWindowMinutes = 60
+	// sloMinQueries keeps a handful of slow queries to a quiet zone from counting
+	// as a breach.
+	sloMinQueries = 20
+	// latencyBuckets is the number of latency histogram buckets, including the
+	// one for latencies above the last bound.
+	latencyBuckets = 24
+)
+
+// latencyBoundsMs are the upper bounds of the latency histogram buckets.
+var latencyBoundsMs = [latencyBuckets - 1]float64{
+	0.1, 0.25, 0.5, 1, 2, 3, 5, 7.5, 10, 15, 20, 30, 50, 75, 100, 150, 200, 300, 500, 750, 1000, 2000, 5000,
+}
+
+// SLOMonitor keeps a histogram of each zone's query latencies per minute for the
+// last hour, checks them against the zones' SLOs and reports zones starting or
+// ceasing to breach theirs as live events, metrics and an optional webhook.
+// Latencies are measured and kept per node, so each node judges its own.
+type SLOMonitor struct {
+	repo    ports.DNSRepository
+	events  ports.EventPublisher
+	webhook string
+	client  *http.Client
+	logger  *slog.Logger
+	now     func() time.Time
+
+	zones   sync.Map // Zone ID -> *zoneLatency
+	namesMu sync.RWMutex
+	names   map[string]string // Normalized zone name -> zone ID
+
+	mu       sync.Mutex
+	breaches map[string]time.Time // Zone ID -> breaching since
+}
+
+type zoneLatency struct {
+	mu      sync.Mutex
+	name    string
+	minutes [sloMaxWindowMinutes + 1]latencyMinute
+}
+
+type latencyMinute struct {
+	minute  int64
+	count   uint64
+	maxMs   float64
+	buckets [latencyBuckets]uint64
+}
+
+// NewSLOMonitor creates a monitor that publishes breaches to events, which may
+// be nil.
+func NewSLOMonitor(repo ports.DNSRepository, events ports.EventPublisher, logger *slog.Logger) *SLOMonitor {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SLOMonitor{
+		repo:     repo,
+		events:   events,
+		client:   &http.Client{Timeout: 5 * time.Second},
+		logger:   logger,
+		now:      time.Now,
+		names:    make(map[string]string),
+		breaches: make(map[string]time.Time),
+	}
+}
+
+// SetWebhook enables alerts: url receives a JSON POST whenever a zone starts or
+// stops breaching its SLO.
+func (m *SLOMonitor) SetWebhook(url string) {
+	m.webhook = url
+}
+
+// RecordLatency adds the response time of a query to its zone's histogram.
+func (m *SLOMonitor) RecordLatency(zoneID string, zoneName string, name string, d time.Duration) {
+	if zoneID == "" {
+		var ok bool
+		if zoneID, ok = m.lookupZone(name); !ok {
+			return
+		}
+	} else {
+		m.learnZone(zoneID, zoneName)
+	}
+	v, ok := m.zones.Load(zoneID)
+	if !ok {
+		v, _ = m.zones.LoadOrStore(zoneID, &zoneLatency{name: zoneName})
+	}
+	z := v.(*zoneLatency)
+
+	ms := float64(d) / float64(time.Millisecond)
+	minute := m.now().Unix() / 60
+	z.mu.Lock()
+	slot := &z.minutes[minute%int64(len(z.minutes))]
+	if slot.minute != minute {
+		*slot = latencyMinute{minute: minute}
+	}
+	slot.count++
+	slot.maxMs = max(slot.maxMs, ms)
+	slot.buckets[sort.SearchFloat64s(latencyBoundsMs[:], ms)]++
+	z.mu.Unlock()
+}
+
+func (m *SLOMonitor) learnZone(zoneID, zoneName string) {
+	name := dnsname.Normalize(zoneName)
+	m.namesMu.RLock()
+	known := m.names[name] == zoneID
+	m.namesMu.RUnlock()
+	if !known {
+		m.namesMu.Lock()
+		m.names[name] = zoneID
+		m.namesMu.Unlock()
+	}
+}
+
+func (m *SLOMonitor) lookupZone(name string) (string, bool) {
+	name = dnsname.Normalize(name)
+	m.namesMu.RLock()
+	defer m.namesMu.RUnlock()
+	for {
+		if id, ok := m.names[name]; ok {
+			return id, true
+		}
+		parent, ok := dnsname.Parent(name)
+		if !ok {
+			return "", false
+		}
+		name = parent
+	}
+}
+
+// window merges a zone's histograms of the last minutes into one, and returns
+// the zone's name.
+func (m *SLOMonitor) window(zoneID string, minutes int) (latencyMinute, string) {
+	var sum latencyMinute
+	v, ok := m.zones.Load(zoneID)
+	if !ok {
+		return sum, ""
+	}
+	z := v.(*zoneLatency)
+	now := m.now().Unix() / 60
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	for _, slot := range z.minutes {
+		if slot.minute <= now-int64(minutes) || slot.minute > now {
+			continue
+		}
+		sum.count += slot.count
+		sum.maxMs = max(sum.maxMs, slot.maxMs)
+		for i, n := range slot.buckets {
+			sum.buckets[i] += n
+		}
+	}
+	return sum, z.name
+}
+
+// percentile returns the upper bound of the bucket holding the p-th percentile
+// latency in milliseconds, or the slowest latency if that is past the last bound.
+func (l *latencyMinute) percentile(p float64) float64 {
+	if l.count == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(p / 100 * float64(l.count)))
+	var seen uint64
+	for i, n := range l.buckets {
+		seen += n
+		if seen >= rank && n > 0 {
+			if i < len(latencyBoundsMs) {
+				return min(latencyBoundsMs[i], l.maxMs)
+			}
+			break
+		}
+	}
+	return l.maxMs
+}
+
+// summary describes l as a window of minutes.
+func (l *latencyMinute) summary(minutes int) domain.ZoneLatency {
+	return domain.ZoneLatency{
+		WindowMinutes: minutes,
+		Queries:       l.count,
+		P50Ms:         l.percentile(50),
+		P95Ms:         l.percentile(95),
+		P99Ms:         l.percentile(99),
+	}
+}
+
+// SetZoneSLO sets the latency objective of a tenant's zone. The percentile must
+// be between 0 and 100, the threshold positive and the window, five minutes if
+// unset, at most an hour.
+func (m *SLOMonitor) SetZoneSLO(ctx context.Context, zoneID string, tenantID string, slo *domain.ZoneSLO) error {
+	zone, err := m.zone(ctx, zoneID, tenantID)
+	if err != nil {
+		return err
+	}
+	if slo.Percentile <= 0 || slo.Percentile >= 100 {
+		return fmt.Errorf("percentile must be between 0 and 100: %w", domain.ErrInvalidSLO)
+	}
+	if slo.ThresholdMs <= 0 {
+		return fmt.Errorf("threshold_ms must be positive: %w", domain.ErrInvalidSLO)
+	}
+	if slo.WindowMinutes == 0 {
+		slo.WindowMinutes = DefaultSLOWindowMinutes
+	}
+	if slo.WindowMinutes < 1 || slo.WindowMinutes > sloMaxWindowMinutes {
+		return fmt.Errorf("window_minutes must be between 1 and %d: %w", sloMaxWindowMinutes, domain.ErrInvalidSLO)
+	}
+	slo.ZoneID = zone.ID
+	slo.TenantID = tenantID
+	slo.UpdatedAt = m.now()
+	if err := m.repo.SaveZoneSLO(ctx, slo); err != nil {
+		return err
+	}
+	m.audit(ctx, tenantID, "SET_ZONE_SLO", zone.ID, fmt.Sprintf("p%g <= %gms over %dm for %s", slo.Percentile, slo.ThresholdMs, slo.WindowMinutes, zone.Name))
+	return nil
+}
+
+// GetZoneSLOStatus returns the latency of a tenant's zone on this node over its
+// SLO window, with the SLO and whether it was breached at the last check.
+func (m *SLOMonitor) GetZoneSLOStatus(ctx context.Context, zoneID string, tenantID string) (*domain.ZoneSLOStatus, error) {
+	zone, err := m.zone(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	slo, err := m.repo.GetZoneSLO(ctx, zone.ID)
+	if err != nil {
+		return nil, err
+	}
+	minutes := DefaultSLOWindowMinutes
+	if slo != nil {
+		minutes = slo.WindowMinutes
+	}
+	latency, _ := m.window(zone.ID, minutes)
+	status := &domain.ZoneSLOStatus{SLO: slo, Latency: latency.summary(minutes)}
+	if slo != nil {
+		status.ObservedMs = latency.percentile(slo.Percentile)
+		m.mu.Lock()
+		if since, ok := m.breaches[zone.ID]; ok {
+			status.Breaching = true
+			status.BreachedSince = &since
+		}
+		m.mu.Unlock()
+	}
+	return status, nil
+}
+
+// DeleteZoneSLO removes the latency objective of a tenant's zone.
+func (m *SLOMonitor) DeleteZoneSLO(ctx context.Context, zoneID string, tenantID string) error {
+	zone, err := m.zone(ctx, zoneID, tenantID)
+	if err != nil {
+		return err
+	}
+	deleted, err := m.repo.DeleteZoneSLO(ctx, zone.ID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("no SLO for zone %s: %w", zone.Name, domain.ErrNotFound)
+	}
+	m.mu.Lock()
+	delete(m.breaches, zone.ID)
+	metrics.SLOZonesBreaching.Set(float64(len(m.breaches)))
+	m.mu.Unlock()
+	m.audit(ctx, tenantID, "DELETE_ZONE_SLO", zone.ID, zone.Name)
+	return nil
+}
+
+func (m *SLOMonitor) zone(ctx context.Context, zoneID string, tenantID string) (*domain.Zone, error) {
+	zone, err := m.repo.GetZoneByID(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load zone: %w", err)
+	}
+	if zone == nil {
+		return nil, fmt.Errorf("zone %s: %w", zoneID, domain.ErrNotFound)
+	}
+	return zone, nil
+}
+
+// Start checks the SLOs every interval until ctx is done.
+func (m *SLOMonitor) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultSLOCheckInterval
+	}
+	m.logger.Info("starting SLO monitor", "interval", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Evaluate(ctx)
+		}
+	}
+}
+
+// Evaluate checks every zone's latency against its SLO, alerting on zones that
+// started or stopped breaching it, and forgets zones idle for the longest window.
+// A window with fewer than sloMinQueries queries is not in breach.
+func (m *SLOMonitor) Evaluate(ctx context.Context) {
+	slos, err := m.repo.ListZoneSLOs(ctx)
+	if err != nil {
+		m.logger.Error("failed to list zone SLOs", "error", err)
+		return
+	}
+	now := m.now()
+	active := make(map[string]struct{}, len(slos))
+	var alerts []domain.SLOBreach
+	for _, slo := range slos {
+		active[slo.ZoneID] = struct{}{}
+		latency, name := m.window(slo.ZoneID, slo.WindowMinutes)
+		observed := latency.percentile(slo.Percentile)
+		breaching := latency.count >= sloMinQueries && observed > slo.ThresholdMs
+
+		m.mu.Lock()
+		_, wasBreaching := m.breaches[slo.ZoneID]
+		if breaching && !wasBreaching {
+			m.breaches[slo.ZoneID] = now
+		} else if !breaching && wasBreaching {
+			delete(m.breaches, slo.ZoneID)
+		}
+		m.mu.Unlock()
+		if breaching == wasBreaching {
+			continue
+		}
+		if breaching {
+			metrics.SLOBreaches.Inc()
+		}
+		alerts = append(alerts, domain.SLOBreach{
+			ZoneID:      slo.ZoneID,
+			TenantID:    slo.TenantID,
+			Zone:        name,
+			Breaching:   breaching,
+			Percentile:  slo.Percentile,
+			ThresholdMs: slo.ThresholdMs,
+			ObservedMs:  observed,
+			Queries:     latency.count,
+			DetectedAt:  now,
+		})
+	}
+
+	m.mu.Lock()
+	for id := range m.breaches {
+		if _, ok := active[id]; !ok {
+			delete(m.breaches, id)
+		}
+	}
+	metrics.SLOZonesBreaching.Set(float64(len(m.breaches)))
+	m.mu.Unlock()
+
+	m.zones.Range(func(key, value any) bool {
+		if latency, _ := m.window(key.(string), sloMaxWindowMinutes); latency.count == 0 {
+			m.zones.Delete(key)
+		}
+		return true
+	})
+
+	for _, a := range alerts {
+		m.alert(a)
+	}
+}
+
+func (m *SLOMonitor) alert(b domain.SLOBreach) {
+	action := "SLO_RECOVERED"
+	if b.Breaching {
+		action = "SLO_BREACH"
+		m.logger.Warn("zone is breaching its latency SLO", "zone", b.Zone, "tenant", b.TenantID,
+			"percentile", b.Percentile, "threshold_ms", b.ThresholdMs, "observed_ms", b.ObservedMs, "queries", b.Queries)
+	} else {
+		m.logger.Info("zone latency is back within its SLO", "zone", b.Zone, "tenant", b.TenantID, "observed_ms", b.ObservedMs)
+	}
+	if m.events != nil {
+		m.events.Publish(domain.Event{Type: domain.EventSLO, TenantID: b.TenantID, ZoneID: b.ZoneID, Action: action, Name: b.Zone, Timestamp: b.DetectedAt})
+	}
+	if m.webhook == "" {
+		return
+	}
+
+	go func() {
+		body, err := json.Marshal(b)
+		if err != nil {
+			return
+		}
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, m.webhook, bytes.NewReader(body))
+		if err != nil {
+			m.logger.Error("invalid SLO webhook", "error", err)
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := m.client.Do(req)
+		if err != nil {
+			m.logger.Error("failed to send SLO alert", "error", err)
+			return
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			m.logger.Error("SLO webhook rejected alert", "status", resp.StatusCode)
+		}
+	}()
+}
+
+func (m *SLOMonitor) audit(ctx context.Context, tenantID, action, id, details string) {
+	_ = m.repo.SaveAuditLog(ctx, &domain.AuditLog{
+		ID:           uuid.New().String(),
+		TenantID:     tenantID,
+		Action:       action,
+		ResourceType: "ZONE_SLO",
+		ResourceID:   id,
+		Details:      details,
+		CreatedAt:    time.Now(),
+	})
+}
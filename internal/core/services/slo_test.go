@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+func TestSLOMonitor(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepo{zones: []domain.Zone{{ID: "z1", TenantID: "t1", Name: "slo.test."}}}
+	bus := NewEventBus(0)
+	subCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	events := bus.Subscribe(subCtx, domain.EventFilter{TenantID: "t1"})
+
+	alerts := make(chan domain.SLOBreach, 4)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var b domain.SLOBreach
+		_ = json.NewDecoder(r.Body).Decode(&b)
+		alerts <- b
+	}))
+	defer hook.Close()
+
+	m := NewSLOMonitor(repo, bus, nil)
+	m.SetWebhook(hook.URL)
+	now := time.Unix(1_700_000_000, 0).Truncate(time.Minute)
+	m.now = func() time.Time { return now }
+
+	invalid := []domain.ZoneSLO{
+		{Percentile: 0, ThresholdMs: 5},
+		{Percentile: 100, ThresholdMs: 5},
+		{Percentile: 99, ThresholdMs: 0},
+		{Percentile: 99, ThresholdMs: 5, WindowMinutes: 61},
+	}
+	for _, slo := range invalid {
+		if err := m.SetZoneSLO(ctx, "z1", "t1", &slo); !errors.Is(err, domain.ErrInvalidSLO) {
+			t.Errorf("Expected %+v to be rejected, got %v", slo, err)
+		}
+	}
+	if err := m.SetZoneSLO(ctx, "z1", "t2", &domain.ZoneSLO{Percentile: 99, ThresholdMs: 5}); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected another tenant's zone to be hidden, got %v", err)
+	}
+	slo := &domain.ZoneSLO{Percentile: 95, ThresholdMs: 10}
+	if err := m.SetZoneSLO(ctx, "z1", "t1", slo); err != nil {
+		t.Fatalf("SetZoneSLO failed: %v", err)
+	}
+	if slo.WindowMinutes != DefaultSLOWindowMinutes || slo.ZoneID != "z1" {
+		t.Errorf("Expected the default window to be filled in, got %+v", slo)
+	}
+
+	// A quiet zone is never in breach, however slow
+	for i := 0; i < sloMinQueries-1; i++ {
+		m.RecordLatency("z1", "slo.test.", "www.slo.test.", 50*time.Millisecond)
+	}
+	m.Evaluate(ctx)
+	if status, _ := m.GetZoneSLOStatus(ctx, "z1", "t1"); status.Breaching {
+		t.Fatal("Expected too few queries not to breach")
+	}
+
+	// Cache hits are attributed to the zone by name; 20 fast and 20 slow
+	// queries put the 95th percentile on the slow ones
+	for i := 0; i < 2*sloMinQueries+1; i++ {
+		m.RecordLatency("", "", "cached.slo.test.", 200*time.Microsecond)
+	}
+	m.RecordLatency("", "", "www.other.test.", time.Second)
+	m.Evaluate(ctx)
+	status, err := m.GetZoneSLOStatus(ctx, "z1", "t1")
+	if err != nil {
+		t.Fatalf("GetZoneSLOStatus failed: %v", err)
+	}
+	if status.Latency.Queries != 3*sloMinQueries || status.Latency.P50Ms != 0.25 || status.ObservedMs != 50 {
+		t.Errorf("Unexpected latency %+v, observed %v", status.Latency, status.ObservedMs)
+	}
+	if !status.Breaching || status.BreachedSince == nil || !status.BreachedSince.Equal(now) {
+		t.Fatalf("Expected the zone to be breaching, got %+v", status)
+	}
+	select {
+	case b := <-alerts:
+		if !b.Breaching || b.Zone != "slo.test." || b.ObservedMs != 50 || b.ThresholdMs != 10 {
+			t.Errorf("Unexpected breach alert %+v", b)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the breach to be posted to the webhook")
+	}
+	select {
+	case e := <-events:
+		if e.Type != domain.EventSLO || e.Action != "SLO_BREACH" || e.ZoneID != "z1" {
+			t.Errorf("Unexpected event %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a breach event")
+	}
+
+	// A second check raises nothing new; once the slow minute leaves the
+	// window the zone recovers
+	m.Evaluate(ctx)
+	now = now.Add(time.Duration(DefaultSLOWindowMinutes) * time.Minute)
+	for i := 0; i < sloMinQueries; i++ {
+		m.RecordLatency("z1", "slo.test.", "www.slo.test.", time.Millisecond)
+	}
+	m.Evaluate(ctx)
+	select {
+	case b := <-alerts:
+		if b.Breaching || b.Queries != sloMinQueries {
+			t.Errorf("Expected a recovery alert, got %+v", b)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the recovery to be posted to the webhook")
+	}
+	if e := <-events; e.Action != "SLO_RECOVERED" {
+		t.Errorf("Expected a recovery event, got %+v", e)
+	}
+	if status, _ := m.GetZoneSLOStatus(ctx, "z1", "t1"); status.Breaching || status.Latency.P99Ms != 1 {
+		t.Errorf("Expected a healthy zone, got %+v", status)
+	}
+
+	if err := m.DeleteZoneSLO(ctx, "z1", "t1"); err != nil {
+		t.Fatalf("DeleteZoneSLO failed: %v", err)
+	}
+	if err := m.DeleteZoneSLO(ctx, "z1", "t1"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected a second delete to report not found, got %v", err)
+	}
+	status, _ = m.GetZoneSLOStatus(ctx, "z1", "t1")
+	if status.SLO != nil || status.Latency.Queries != sloMinQueries {
+		t.Errorf("Expected latency without an SLO, got %+v", status)
+	}
+
+	// Zones idle for an hour are forgotten
+	now = now.Add(time.Hour)
+	m.Evaluate(ctx)
+	if _, ok := m.zones.Load("z1"); ok {
+		t.Error("Expected the idle zone to be pruned")
+	}
+}
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/core/ports"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+)
+
+// DefaultProbeSlowThreshold is the round-trip time above which a correct answer is
+// still reported as SLOW.
+const DefaultProbeSlowThreshold = 500 * time.Millisecond
+
+// SyntheticProber periodically queries every zone apex (SOA, NS and, when the zone
+// has one, A) through the public DNS path and compares the answers with the zone
+// data. Verdicts are exported as metrics and, when a probe changes state, posted to
+// an optional webhook.
+type SyntheticProber struct {
+	repo    ports.DNSRepository
+	querier ports.DNSQuerier
+	targets []string
+	slow    time.Duration
+	webhook string
+	client  *http.Client
+	logger  *slog.Logger
+
+	mu      sync.RWMutex
+	results map[string]domain.ProbeResult
+}
+
+// probeAlert is the webhook payload sent when a probe starts or stops failing.
+type probeAlert struct {
+	Event    string             `json:"event"`
+	Previous domain.ProbeStatus `json:"previous,omitempty"`
+	Result   domain.ProbeResult `json:"result"`
+}
+
+// NewSyntheticProber creates a prober that queries each of targets, given as
+// host:port vantage addresses.
+func NewSyntheticProber(repo ports.DNSRepository, querier ports.DNSQuerier, targets []string, logger *slog.Logger) *SyntheticProber {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SyntheticProber{
+		repo:    repo,
+		querier: querier,
+		targets: targets,
+		slow:    DefaultProbeSlowThreshold,
+		client:  &http.Client{Timeout: 5 * time.Second},
+		logger:  logger,
+		results: make(map[string]domain.ProbeResult),
+	}
+}
+
+// SetSlowThreshold sets the latency above which answers are reported as SLOW.
+func (p *SyntheticProber) SetSlowThreshold(d time.Duration) {
+	if d > 0 {
+		p.slow = d
+	}
+}
+
+// SetWebhook enables alerts: url receives a JSON POST whenever a probe changes
+// between OK and a failing status.
+func (p *SyntheticProber) SetWebhook(url string) {
+	p.webhook = url
+}
+
+// Start probes immediately and then every interval until ctx is done.
+func (p *SyntheticProber) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	p.logger.Info("starting synthetic prober", "interval", interval, "targets", p.targets)
+
+	p.RunProbes(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			p.logger.Info("stopping synthetic prober")
+			return
+		case <-ticker.C:
+			p.RunProbes(ctx)
+		}
+	}
+}
+
+// RunProbes probes every zone from every target once and records the results.
+func (p *SyntheticProber) RunProbes(ctx context.Context) {
+	zones, err := p.repo.ListZones(ctx, "")
+	if err != nil {
+		p.logger.Error("failed to list zones for probing", "error", err)
+		return
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		collected = make(map[string]domain.ProbeResult)
+		semaphore = make(chan struct{}, maxProbeWorkers)
+	)
+	for _, zone := range zones {
+		records, errList := p.repo.ListRecordsForZone(ctx, zone.ID, zone.TenantID)
+		if errList != nil {
+			p.logger.Error("failed to load zone for probing", "zone", zone.Name, "error", errList)
+			continue
+		}
+		expected := apexRecords(zone.Name, records)
+		types := []domain.RecordType{domain.TypeSOA, domain.TypeNS}
+		if len(expected[domain.TypeA]) > 0 {
+			types = append(types, domain.TypeA)
+		}
+
+		for _, target := range p.targets {
+			for _, qType := range types {
+				wg.Add(1)
+				go func(zone domain.Zone, target string, qType domain.RecordType) {
+					defer wg.Done()
+					semaphore <- struct{}{}
+					defer func() { <-semaphore }()
+					res := p.probe(ctx, zone, target, qType, expected[qType])
+					mu.Lock()
+					collected[probeKey(res)] = res
+					mu.Unlock()
+				}(zone, target, qType)
+			}
+		}
+	}
+	wg.Wait()
+
+	p.mu.Lock()
+	previous := p.results
+	p.results = collected
+	p.mu.Unlock()
+
+	failing := 0
+	for key, res := range collected {
+		if res.Status != domain.ProbeOK {
+			failing++
+		}
+		prev, seen := previous[key]
+		switch {
+		case !seen && res.Status != domain.ProbeOK:
+			p.alert(ctx, "probe_failing", "", res)
+		case seen && prev.Status != res.Status && res.Status == domain.ProbeOK:
+			p.alert(ctx, "probe_recovered", prev.Status, res)
+		case seen && prev.Status != res.Status:
+			p.alert(ctx, "probe_failing", prev.Status, res)
+		}
+	}
+	metrics.SyntheticProbesFailing.Set(float64(failing))
+}
+
+// ProbeResults returns the latest result of every probe, ordered by zone, type
+// and vantage.
+func (p *SyntheticProber) ProbeResults() []domain.ProbeResult {
+	p.mu.RLock()
+	results := make([]domain.ProbeResult, 0, len(p.results))
+	for _, res := range p.results {
+		results = append(results, res)
+	}
+	p.mu.RUnlock()
+
+	sort.Slice(results, func(i, j int) bool {
+		return probeKey(results[i]) < probeKey(results[j])
+	})
+	return results
+}
+
+func (p *SyntheticProber) probe(ctx context.Context, zone domain.Zone, target string, qType domain.RecordType, expected []domain.Record) domain.ProbeResult {
+	res := domain.ProbeResult{
+		ZoneID:    zone.ID,
+		ZoneName:  zone.Name,
+		Type:      qType,
+		Vantage:   target,
+		CheckedAt: time.Now(),
+	}
+
+	start := time.Now()
+	answer, err := p.querier.Query(ctx, target, dnsname.Fqdn(zone.Name), qType)
+	res.Latency = time.Since(start)
+	metrics.SyntheticProbeDuration.WithLabelValues(target).Observe(res.Latency.Seconds())
+
+	if err != nil {
+		res.Status, res.Detail = domain.ProbeError, err.Error()
+	} else {
+		res.Status, res.Detail = evaluateProbe(qType, expected, answer)
+		if res.Status == domain.ProbeOK && res.Latency > p.slow {
+			res.Status = domain.ProbeSlow
+			res.Detail = fmt.Sprintf("answered in %s, budget %s", res.Latency.Round(time.Millisecond), p.slow)
+		}
+	}
+	metrics.SyntheticProbes.WithLabelValues(string(qType), target, string(res.Status)).Inc()
+	return res
+}
+
+// evaluateProbe compares an apex answer with the records the zone should serve.
+func evaluateProbe(qType domain.RecordType, expected []domain.Record, answer *domain.ProbeAnswer) (domain.ProbeStatus, string) {
+	if answer.Rcode != 0 {
+		return domain.ProbeError, fmt.Sprintf("rcode %d", answer.Rcode)
+	}
+	var got []domain.Record
+	for _, rec := range answer.Records {
+		if rec.Type == qType {
+			got = append(got, rec)
+		}
+	}
+	if len(got) == 0 {
+		return domain.ProbeMissing, fmt.Sprintf("no %s records in answer", qType)
+	}
+
+	switch qType {
+	case domain.TypeSOA:
+		if len(expected) == 0 {
+			return domain.ProbeOK, ""
+		}
+		want, have := soaSerial(expected[0].Content), soaSerial(got[0].Content)
+		if want != have {
+			return domain.ProbeWrong, fmt.Sprintf("serial %d, expected %d", have, want)
+		}
+	case domain.TypeNS:
+		want, have := contentSet(expected), contentSet(got)
+		if len(want) != len(have) {
+			return domain.ProbeWrong, fmt.Sprintf("%d NS records, expected %d", len(have), len(want))
+		}
+		for host := range have {
+			if !want[host] {
+				return domain.ProbeWrong, fmt.Sprintf("unexpected NS %s", host)
+			}
+		}
+	default:
+		// Routed and health-gated records may answer with a subset of the RRset
+		want := contentSet(expected)
+		for _, rec := range got {
+			if !want[dnsname.Normalize(rec.Content)] {
+				return domain.ProbeWrong, fmt.Sprintf("unexpected %s %s", qType, rec.Content)
+			}
+		}
+	}
+	return domain.ProbeOK, ""
+}
+
+func (p *SyntheticProber) alert(ctx context.Context, event string, previous domain.ProbeStatus, res domain.ProbeResult) {
+	p.logger.Warn("synthetic probe state changed", "event", event, "zone", res.ZoneName, "type", res.Type,
+		"vantage", res.Vantage, "status", res.Status, "detail", res.Detail)
+	if p.webhook == "" {
+		return
+	}
+
+	body, err := json.Marshal(probeAlert{Event: event, Previous: previous, Result: res})
+	if err != nil {
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.webhook, bytes.NewReader(body))
+	if err != nil {
+		p.logger.Error("invalid probe webhook", "error", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		p.logger.Error("failed to send probe alert", "error", err)
+		return
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		p.logger.Error("probe webhook rejected alert", "status", resp.StatusCode)
+	}
+}
+
+// apexRecords groups the zone's apex records by type.
+func apexRecords(zoneName string, records []domain.Record) map[domain.RecordType][]domain.Record {
+	apex := make(map[domain.RecordType][]domain.Record)
+	for _, rec := range records {
+		if dnsname.Equal(rec.Name, zoneName) {
+			apex[rec.Type] = append(apex[rec.Type], rec)
+		}
+	}
+	return apex
+}
+
+func contentSet(records []domain.Record) map[string]bool {
+	set := make(map[string]bool, len(records))
+	for _, rec := range records {
+		set[dnsname.Normalize(rec.Content)] = true
+	}
+	return set
+}
+
+func probeKey(res domain.ProbeResult) string {
+	return res.ZoneName + "|" + string(res.Type) + "|" + res.Vantage
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+type fakeQuerier struct {
+	mu      sync.Mutex
+	answers map[domain.RecordType]*domain.ProbeAnswer
+	err     error
+	delay   time.Duration
+}
+
+func (f *fakeQuerier) Query(_ context.Context, _ string, _ string, qType domain.RecordType) (*domain.ProbeAnswer, error) {
+	time.Sleep(f.delay)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	if a, ok := f.answers[qType]; ok {
+		return a, nil
+	}
+	return &domain.ProbeAnswer{}, nil
+}
+
+func probeFixture() (*mockRepo, *fakeQuerier) {
+	repo := &mockRepo{
+		zones: []domain.Zone{{ID: "z1", Name: "example.com."}},
+		records: []domain.Record{
+			{ZoneID: "z1", Name: "example.com.", Type: domain.TypeSOA, Content: "ns1.example.com. admin.example.com. 42 3600 600 86400 300"},
+			{ZoneID: "z1", Name: "example.com.", Type: domain.TypeNS, Content: "ns1.example.com."},
+			{ZoneID: "z1", Name: "example.com.", Type: domain.TypeNS, Content: "ns2.example.com."},
+			{ZoneID: "z1", Name: "example.com.", Type: domain.TypeA, Content: "192.0.2.1"},
+			{ZoneID: "z1", Name: "www.example.com.", Type: domain.TypeA, Content: "192.0.2.2"},
+		},
+	}
+	querier := &fakeQuerier{answers: map[domain.RecordType]*domain.ProbeAnswer{
+		domain.TypeSOA: {Records: []domain.Record{{Type: domain.TypeSOA, Content: "ns1.example.com. admin.example.com. 42 3600 600 86400 300"}}},
+		domain.TypeNS:  {Records: []domain.Record{{Type: domain.TypeNS, Content: "NS2.example.com."}, {Type: domain.TypeNS, Content: "ns1.example.com"}}},
+		domain.TypeA:   {Records: []domain.Record{{Type: domain.TypeA, Content: "192.0.2.1"}}},
+	}}
+	return repo, querier
+}
+
+func TestEvaluateProbe(t *testing.T) {
+	soa := []domain.Record{{Type: domain.TypeSOA, Content: "ns1. admin. 10 1 1 1 1"}}
+	ns := []domain.Record{{Type: domain.TypeNS, Content: "ns1.example.com."}, {Type: domain.TypeNS, Content: "ns2.example.com."}}
+	a := []domain.Record{{Type: domain.TypeA, Content: "192.0.2.1"}, {Type: domain.TypeA, Content: "192.0.2.2"}}
+
+	tests := []struct {
+		name     string
+		qType    domain.RecordType
+		expected []domain.Record
+		answer   *domain.ProbeAnswer
+		want     domain.ProbeStatus
+	}{
+		{"SOA match", domain.TypeSOA, soa, &domain.ProbeAnswer{Records: soa}, domain.ProbeOK},
+		{"SOA stale serial", domain.TypeSOA, soa, &domain.ProbeAnswer{Records: []domain.Record{{Type: domain.TypeSOA, Content: "ns1. admin. 9 1 1 1 1"}}}, domain.ProbeWrong},
+		{"REFUSED", domain.TypeSOA, soa, &domain.ProbeAnswer{Rcode: 5}, domain.ProbeError},
+		{"empty answer", domain.TypeNS, ns, &domain.ProbeAnswer{}, domain.ProbeMissing},
+		{"NS missing one", domain.TypeNS, ns, &domain.ProbeAnswer{Records: ns[:1]}, domain.ProbeWrong},
+		{"NS foreign", domain.TypeNS, ns, &domain.ProbeAnswer{Records: []domain.Record{ns[0], {Type: domain.TypeNS, Content: "evil.test."}}}, domain.ProbeWrong},
+		{"A subset", domain.TypeA, a, &domain.ProbeAnswer{Records: a[1:]}, domain.ProbeOK},
+		{"A unexpected", domain.TypeA, a, &domain.ProbeAnswer{Records: []domain.Record{{Type: domain.TypeA, Content: "203.0.113.9"}}}, domain.ProbeWrong},
+		{"CNAME instead of A", domain.TypeA, a, &domain.ProbeAnswer{Records: []domain.Record{{Type: domain.TypeCNAME, Content: "x."}}}, domain.ProbeMissing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, detail := evaluateProbe(tt.qType, tt.expected, tt.answer); got != tt.want {
+				t.Errorf("Expected %s, got %s (%s)", tt.want, got, detail)
+			}
+		})
+	}
+}
+
+func TestSyntheticProber_RunProbes(t *testing.T) {
+	repo, querier := probeFixture()
+	prober := NewSyntheticProber(repo, querier, []string{"127.0.0.1:53", "198.51.100.1:53"}, nil)
+
+	prober.RunProbes(context.Background())
+	results := prober.ProbeResults()
+	if len(results) != 6 {
+		t.Fatalf("Expected SOA, NS and A probes from two vantages, got %d", len(results))
+	}
+	for _, res := range results {
+		if res.Status != domain.ProbeOK || res.ZoneID != "z1" {
+			t.Errorf("Expected OK probe, got %+v", res)
+		}
+	}
+	if results[0].Type != domain.TypeA || results[0].Vantage != "127.0.0.1:53" {
+		t.Errorf("Expected results ordered by type and vantage, got %+v", results[0])
+	}
+
+	// Zones without an apex A record are not probed for one
+	repo.records = repo.records[:3]
+	prober.RunProbes(context.Background())
+	if n := len(prober.ProbeResults()); n != 4 {
+		t.Errorf("Expected 4 probes without apex A, got %d", n)
+	}
+}
+
+func TestSyntheticProber_SlowAndErrors(t *testing.T) {
+	repo, querier := probeFixture()
+	querier.delay = 5 * time.Millisecond
+	prober := NewSyntheticProber(repo, querier, []string{"127.0.0.1:53"}, nil)
+	prober.SetSlowThreshold(time.Millisecond)
+
+	prober.RunProbes(context.Background())
+	for _, res := range prober.ProbeResults() {
+		if res.Status != domain.ProbeSlow || res.Detail == "" {
+			t.Errorf("Expected SLOW probe, got %+v", res)
+		}
+	}
+
+	querier.err = errors.New("i/o timeout")
+	prober.RunProbes(context.Background())
+	for _, res := range prober.ProbeResults() {
+		if res.Status != domain.ProbeError || res.Detail != "i/o timeout" {
+			t.Errorf("Expected ERROR probe, got %+v", res)
+		}
+	}
+}
+
+func TestSyntheticProber_Webhook(t *testing.T) {
+	var mu sync.Mutex
+	var alerts []probeAlert
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var a probeAlert
+		if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+			t.Errorf("Invalid alert payload: %v", err)
+		}
+		mu.Lock()
+		alerts = append(alerts, a)
+		mu.Unlock()
+	}))
+	defer hook.Close()
+
+	repo, querier := probeFixture()
+	prober := NewSyntheticProber(repo, querier, []string{"127.0.0.1:53"}, nil)
+	prober.SetWebhook(hook.URL)
+	ctx := context.Background()
+
+	prober.RunProbes(ctx)
+	if len(alerts) != 0 {
+		t.Fatalf("Expected no alerts while healthy, got %+v", alerts)
+	}
+
+	querier.answers[domain.TypeSOA] = &domain.ProbeAnswer{}
+	prober.RunProbes(ctx)
+	prober.RunProbes(ctx)
+	if len(alerts) != 1 || alerts[0].Event != "probe_failing" || alerts[0].Previous != domain.ProbeOK || alerts[0].Result.Status != domain.ProbeMissing {
+		t.Fatalf("Expected a single failing alert, got %+v", alerts)
+	}
+
+	querier.answers[domain.TypeSOA] = &domain.ProbeAnswer{Records: []domain.Record{{Type: domain.TypeSOA, Content: repo.records[0].Content}}}
+	prober.RunProbes(ctx)
+	if len(alerts) != 2 || alerts[1].Event != "probe_recovered" || alerts[1].Result.Type != domain.TypeSOA {
+		t.Errorf("Expected a recovery alert, got %+v", alerts)
+	}
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/core/ports"
+)
+
+// TargetService manages a tenant's targets, the named address sets A and AAAA
+// records reference. The DNS servers reload targets at once on a change
+// notification, so an update moves every referencing record together.
+type TargetService struct {
+	repo   ports.DNSRepository
+	cache  ports.CacheInvalidator
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+// NewTargetService creates a service that stores targets in repo and drops
+// the shared cache entries of referencing records through cache, if not nil.
+func NewTargetService(repo ports.DNSRepository, cache ports.CacheInvalidator, logger *slog.Logger) *TargetService {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &TargetService{repo: repo, cache: cache, logger: logger, now: time.Now}
+}
+
+// CreateTarget stores a new target of the tenant.
+func (s *TargetService) CreateTarget(ctx context.Context, tenantID string, target *domain.Target) error {
+	targets, err := s.repo.ListTargets(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	target.ID = uuid.New().String()
+	if err := validateTarget(target, targets); err != nil {
+		return err
+	}
+	target.TenantID = tenantID
+	target.CreatedAt = s.now()
+	target.UpdatedAt = target.CreatedAt
+	if err := s.repo.SaveTarget(ctx, target); err != nil {
+		return err
+	}
+	s.audit(ctx, tenantID, "CREATE_TARGET", target.ID, fmt.Sprintf("Created target %s with %s", target.Name, strings.Join(target.Addresses, ", ")))
+	return nil
+}
+
+// UpdateTarget replaces the name and addresses of one of the tenant's targets,
+// and with them the answers of every record referencing it. Each zone of those
+// records must be open to changes: not frozen and not under review.
+func (s *TargetService) UpdateTarget(ctx context.Context, tenantID string, target *domain.Target) error {
+	targets, err := s.repo.ListTargets(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	var existing *domain.Target
+	for i := range targets {
+		if targets[i].ID == target.ID {
+			existing = &targets[i]
+		}
+	}
+	if existing == nil {
+		return fmt.Errorf("target %s: %w", target.ID, domain.ErrNotFound)
+	}
+	if err := validateTarget(target, targets); err != nil {
+		return err
+	}
+	records, err := s.repo.ListTargetRecords(ctx, target.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load the target's records: %w", err)
+	}
+	if err := checkTargetFamilies(target, records); err != nil {
+		return err
+	}
+	var zones []string
+	for _, rec := range records {
+		if slices.Contains(zones, rec.ZoneID) {
+			continue
+		}
+		zones = append(zones, rec.ZoneID)
+		if err := CheckChangeFreeze(ctx, s.repo, tenantID, rec.ZoneID, "UPDATE_TARGET "+target.Name); err != nil {
+			return err
+		}
+		if err := CheckChangeReview(ctx, s.repo, tenantID, rec.ZoneID, "UPDATE_TARGET "+target.Name); err != nil {
+			return err
+		}
+	}
+	target.TenantID = tenantID
+	target.CreatedAt = existing.CreatedAt
+	target.UpdatedAt = s.now()
+	if err := s.repo.SaveTarget(ctx, target); err != nil {
+		return err
+	}
+	s.invalidate(ctx, records)
+	s.audit(ctx, tenantID, "UPDATE_TARGET", target.ID, fmt.Sprintf("Updated target %s to %s for %d records", target.Name, strings.Join(target.Addresses, ", "), len(records)))
+	return nil
+}
+
+// ListTargets returns the tenant's targets by name.
+func (s *TargetService) ListTargets(ctx context.Context, tenantID string) ([]domain.Target, error) {
+	targets, err := s.repo.ListTargets(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if targets == nil {
+		targets = []domain.Target{}
+	}
+	return targets, nil
+}
+
+// DeleteTarget removes one of the tenant's targets that no record references.
+func (s *TargetService) DeleteTarget(ctx context.Context, tenantID string, id string) error {
+	targets, err := s.repo.ListTargets(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(targets, func(t domain.Target) bool { return t.ID == id }) {
+		return fmt.Errorf("target %s: %w", id, domain.ErrNotFound)
+	}
+	records, err := s.repo.ListTargetRecords(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to load the target's records: %w", err)
+	}
+	if len(records) > 0 {
+		return fmt.Errorf("target %s is referenced by %d records: %w", id, len(records), domain.ErrInvalidTarget)
+	}
+	deleted, err := s.repo.DeleteTarget(ctx, id, tenantID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("target %s: %w", id, domain.ErrNotFound)
+	}
+	s.audit(ctx, tenantID, "DELETE_TARGET", id, "Deleted target")
+	return nil
+}
+
+// invalidate drops the shared cache entries of records. The nodes' own caches
+// are flushed by the target's change notification.
+func (s *TargetService) invalidate(ctx context.Context, records []domain.Record) {
+	invalidateRecords(ctx, s.cache, s.logger, records, "target update")
+}
+
+// invalidateRecords drops the shared cache entries of the names and types of
+// records through cache, if not nil, logging failures with the change that
+// made them stale.
+func invalidateRecords(ctx context.Context, cache ports.CacheInvalidator, logger *slog.Logger, records []domain.Record, change string) {
+	if cache == nil {
+		return
+	}
+	seen := make(map[string]bool)
+	for _, rec := range records {
+		key := strings.ToLower(rec.Name) + ":" + string(rec.Type)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if err := cache.Invalidate(ctx, rec.Name, rec.Type); err != nil {
+			logger.Warn("failed to invalidate cache after "+change, "name", rec.Name, "type", rec.Type, "error", err)
+		}
+	}
+}
+
+func (s *TargetService) audit(ctx context.Context, tenantID, action, id, details string) {
+	_ = s.repo.SaveAuditLog(ctx, &domain.AuditLog{
+		ID:           uuid.New().String(),
+		TenantID:     tenantID,
+		Action:       action,
+		ResourceType: "TARGET",
+		ResourceID:   id,
+		Details:      details,
+		CreatedAt:    s.now(),
+	})
+}
+
+// validateTarget checks a target against the tenant's other targets and
+// normalizes its addresses.
+func validateTarget(target *domain.Target, targets []domain.Target) error {
+	target.Name = strings.TrimSpace(target.Name)
+	if target.Name == "" {
+		return fmt.Errorf("name is required: %w", domain.ErrInvalidTarget)
+	}
+	for _, other := range targets {
+		if other.ID != target.ID && strings.EqualFold(other.Name, target.Name) {
+			return fmt.Errorf("a target named %s already exists: %w", target.Name, domain.ErrInvalidTarget)
+		}
+	}
+	if len(target.Addresses) == 0 {
+		return fmt.Errorf("at least one address is required: %w", domain.ErrInvalidTarget)
+	}
+	addresses := make([]string, 0, len(target.Addresses))
+	for _, addr := range target.Addresses {
+		ip := net.ParseIP(strings.TrimSpace(addr))
+		if ip == nil {
+			return fmt.Errorf("address %q: %w", addr, domain.ErrInvalidTarget)
+		}
+		addresses = append(addresses, ip.String())
+	}
+	target.Addresses = addresses
+	return nil
+}
+
+// checkTargetFamilies returns domain.ErrInvalidTarget if records of a type
+// would be left without an address of their family.
+func checkTargetFamilies(target *domain.Target, records []domain.Record) error {
+	for _, rec := range records {
+		if !hasFamily(target.Addresses, rec.Type) {
+			return fmt.Errorf("%s record %s needs an address of its family: %w", rec.Type, rec.Name, domain.ErrInvalidTarget)
+		}
+	}
+	return nil
+}
+
+func hasFamily(addresses []string, recordType domain.RecordType) bool {
+	for _, addr := range addresses {
+		if ip := net.ParseIP(addr); ip != nil && (ip.To4() != nil) == (recordType == domain.TypeA) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRecordTarget returns domain.ErrInvalidTarget if a record references a
+// target the tenant does not have, or is not an A or AAAA record the target
+// has addresses for. Its content is left empty, as the target supplies it.
+func checkRecordTarget(ctx context.Context, repo ports.DNSRepository, tenantID string, record *domain.Record) error {
+	if record.TargetID == nil {
+		return nil
+	}
+	if record.Type != domain.TypeA && record.Type != domain.TypeAAAA {
+		return fmt.Errorf("only A and AAAA records can reference a target: %w", domain.ErrInvalidTarget)
+	}
+	targets, err := repo.ListTargets(ctx, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to load targets: %w", err)
+	}
+	for _, target := range targets {
+		if target.ID == *record.TargetID {
+			if !hasFamily(target.Addresses, record.Type) {
+				return fmt.Errorf("target %s has no address for an %s record: %w", target.Name, record.Type, domain.ErrInvalidTarget)
+			}
+			record.Content = ""
+			return nil
+		}
+	}
+	return fmt.Errorf("target %s does not exist: %w", *record.TargetID, domain.ErrInvalidTarget)
+}
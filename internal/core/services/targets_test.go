@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+type recordingInvalidator struct {
+	invalidated []string
+}
+
+func (r *recordingInvalidator) Invalidate(_ context.Context, name string, qType domain.RecordType) error {
+	r.invalidated = append(r.invalidated, name+" "+string(qType))
+	return nil
+}
+
+func (r *recordingInvalidator) Ping(_ context.Context) error { return nil }
+
+func TestTargetService(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepo{}
+	cache := &recordingInvalidator{}
+	s := NewTargetService(repo, cache, nil)
+
+	for _, target := range []domain.Target{
+		{Addresses: []string{"192.0.2.1"}},
+		{Name: "ingress"},
+		{Name: "ingress", Addresses: []string{"192.0.2.0/24"}},
+	} {
+		if err := s.CreateTarget(ctx, "t1", &target); !errors.Is(err, domain.ErrInvalidTarget) {
+			t.Errorf("Expected ErrInvalidTarget for %+v, got %v", target, err)
+		}
+	}
+
+	ingress := domain.Target{Name: "ingress", Addresses: []string{"192.0.2.1", "2001:DB8::1"}}
+	if err := s.CreateTarget(ctx, "t1", &ingress); err != nil {
+		t.Fatalf("CreateTarget failed: %v", err)
+	}
+	if ingress.ID == "" || ingress.Addresses[1] != "2001:db8::1" {
+		t.Errorf("Expected an ID and canonical addresses, got %+v", ingress)
+	}
+	if err := s.CreateTarget(ctx, "t1", &domain.Target{Name: "Ingress", Addresses: []string{"192.0.2.2"}}); !errors.Is(err, domain.ErrInvalidTarget) {
+		t.Errorf("Expected a duplicate name to be rejected, got %v", err)
+	}
+
+	// Records reference targets of their own tenant, of a family the target has
+	svc := NewDNSService(repo, nil)
+	for _, rec := range []domain.Record{
+		{TenantID: "t2", ZoneID: "z1", Name: "shop.target.test.", Type: domain.TypeA, TargetID: &ingress.ID},
+		{TenantID: "t1", ZoneID: "z1", Name: "shop.target.test.", Type: domain.TypeCNAME, TargetID: &ingress.ID},
+	} {
+		if err := svc.CreateRecord(ctx, &rec); !errors.Is(err, domain.ErrInvalidTarget) {
+			t.Errorf("Expected ErrInvalidTarget for %+v, got %v", rec, err)
+		}
+	}
+	for _, name := range []string{"shop.target.test.", "blog.target.test."} {
+		if err := svc.CreateRecord(ctx, &domain.Record{TenantID: "t1", ZoneID: "z1", Name: name, Type: domain.TypeAAAA, Content: "ignored", TargetID: &ingress.ID}); err != nil {
+			t.Fatalf("CreateRecord failed: %v", err)
+		}
+	}
+	if repo.records[0].Content != "" {
+		t.Errorf("Expected the target to supply the content, got %q", repo.records[0].Content)
+	}
+
+	ingress.Addresses = []string{"192.0.2.10"}
+	if err := s.UpdateTarget(ctx, "t1", &ingress); !errors.Is(err, domain.ErrInvalidTarget) {
+		t.Errorf("Expected dropping the addresses AAAA records need to be rejected, got %v", err)
+	}
+	ingress.Addresses = []string{"192.0.2.10", "2001:db8::10"}
+	if err := s.UpdateTarget(ctx, "t2", &ingress); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected another tenant's target to be missing, got %v", err)
+	}
+	// Moving the addresses changes the answers of the records' zone
+	repo.freezes = []domain.ChangeFreeze{{ID: "f1", TenantID: "t1", ZoneID: "z1", Reason: "release", StartsAt: time.Now().Add(-time.Hour), EndsAt: time.Now().Add(time.Hour)}}
+	if err := s.UpdateTarget(ctx, "t1", &ingress); !errors.Is(err, domain.ErrChangeFrozen) {
+		t.Errorf("Expected a freeze of a referencing record's zone to hold the target, got %v", err)
+	}
+	repo.freezes = nil
+	if err := s.UpdateTarget(ctx, "t1", &ingress); err != nil {
+		t.Fatalf("UpdateTarget failed: %v", err)
+	}
+	if len(cache.invalidated) != 2 || cache.invalidated[0] != "shop.target.test. AAAA" {
+		t.Errorf("Expected every referencing record invalidated, got %v", cache.invalidated)
+	}
+	if targets, _ := s.ListTargets(ctx, "t1"); len(targets) != 1 || targets[0].CreatedAt.IsZero() || targets[0].Addresses[0] != "192.0.2.10" {
+		t.Errorf("Expected the updated target with its creation time, got %+v", targets)
+	}
+
+	if err := s.DeleteTarget(ctx, "t1", ingress.ID); !errors.Is(err, domain.ErrInvalidTarget) {
+		t.Errorf("Expected a referenced target to be kept, got %v", err)
+	}
+	repo.records = nil
+	if err := s.DeleteTarget(ctx, "t2", ingress.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected another tenant's target to be missing, got %v", err)
+	}
+	if err := s.DeleteTarget(ctx, "t1", ingress.ID); err != nil {
+		t.Fatalf("DeleteTarget failed: %v", err)
+	}
+}
@@ -0,0 +1,398 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/core/ports"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+)
+
+// DefaultUsageFlushInterval is how often in-memory usage counters are added to the
+// hourly stats table.
+const DefaultUsageFlushInterval = time.Minute
+
+// DefaultMetricTopZones is how many of the busiest zones get tenant and zone
+// labels of their own in the per-tenant query metrics.
+const DefaultMetricTopZones = 10
+
+// otherLabel is the metric label value shared by tenants and zones that are not
+// allowed a label of their own.
+const otherLabel = "other"
+
+// rcodeNXDomain is the DNS response code for a name that does not exist.
+const rcodeNXDomain = 3
+
+// UsageService counts per-zone queries, NXDOMAIN answers and zone transfer bytes in
+// memory on the query path and periodically adds them to hourly buckets shared by
+// every node. It also serves the aggregated usage to the API.
+type UsageService struct {
+	repo   ports.DNSRepository
+	logger *slog.Logger
+	now    func() time.Time
+
+	counters sync.Map // usageKey -> *usageCounter
+
+	zonesMu sync.RWMutex
+	zones   map[string]string      // Normalized zone name -> zone ID
+	owners  map[string]domain.Zone // Zone ID -> zone, for metric labels
+
+	labelsMu   sync.Mutex
+	labelAllow map[string]bool      // Zone names and tenant IDs always labelled
+	labelTopN  int                  // Busiest zones labelled in addition
+	zoneTotals map[string]int64     // Zone ID -> queries flushed since start
+	labelled   map[string][2]string // Zone ID -> tenant and zone labels in use
+}
+
+type usageKey struct {
+	zoneID string
+	hour   int64 // Unix seconds of the start of the hour
+}
+
+type usageCounter struct {
+	queries  atomic.Int64
+	nxdomain atomic.Int64
+	transfer atomic.Int64
+}
+
+// NewUsageService creates a usage aggregator backed by repo.
+func NewUsageService(repo ports.DNSRepository, logger *slog.Logger) *UsageService {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &UsageService{
+		repo:   repo,
+		logger: logger,
+		now:    time.Now,
+		zones:  make(map[string]string),
+		owners: make(map[string]domain.Zone),
+
+		labelTopN:  DefaultMetricTopZones,
+		zoneTotals: make(map[string]int64),
+		labelled:   make(map[string][2]string),
+	}
+}
+
+// SetMetricLabels limits which tenants and zones get labels of their own in the
+// per-tenant query metrics, to keep their cardinality bounded. allow lists zone
+// names, labelled with their tenant, and tenant IDs, whose zones are labelled
+// "other" within the tenant. The topN zones with the most queries since start
+// are labelled too; everything else is counted under "other".
+func (s *UsageService) SetMetricLabels(allow []string, topN int) {
+	s.labelsMu.Lock()
+	defer s.labelsMu.Unlock()
+	s.labelAllow = make(map[string]bool, len(allow))
+	for _, a := range allow {
+		s.labelAllow[a] = true
+		s.labelAllow[dnsname.Normalize(a)] = true
+	}
+	s.labelTopN = topN
+}
+
+// RecordQuery counts a query answered from zone data.
+func (s *UsageService) RecordQuery(zoneID string, zoneName string, rcode int) {
+	s.learnZone(zoneID, zoneName)
+	c := s.counter(zoneID)
+	c.queries.Add(1)
+	if rcode == rcodeNXDomain {
+		c.nxdomain.Add(1)
+	}
+}
+
+// RecordCachedQuery counts a query answered from cache, attributing it to the
+// closest enclosing zone the service knows about.
+func (s *UsageService) RecordCachedQuery(name string, rcode int) {
+	zoneID, ok := s.lookupZone(name)
+	if !ok {
+		return
+	}
+	c := s.counter(zoneID)
+	c.queries.Add(1)
+	if rcode == rcodeNXDomain {
+		c.nxdomain.Add(1)
+	}
+}
+
+// RecordTransfer counts bytes sent in an AXFR or IXFR of zoneName.
+func (s *UsageService) RecordTransfer(zoneName string, bytes int) {
+	zoneID, ok := s.lookupZone(zoneName)
+	if !ok || bytes <= 0 {
+		return
+	}
+	s.counter(zoneID).transfer.Add(int64(bytes))
+}
+
+func (s *UsageService) counter(zoneID string) *usageCounter {
+	key := usageKey{zoneID: zoneID, hour: s.now().Truncate(time.Hour).Unix()}
+	if c, ok := s.counters.Load(key); ok {
+		return c.(*usageCounter)
+	}
+	c, _ := s.counters.LoadOrStore(key, &usageCounter{})
+	return c.(*usageCounter)
+}
+
+func (s *UsageService) learnZone(zoneID, zoneName string) {
+	name := dnsname.Normalize(zoneName)
+	s.zonesMu.RLock()
+	known := s.zones[name] == zoneID
+	s.zonesMu.RUnlock()
+	if !known {
+		s.zonesMu.Lock()
+		s.zones[name] = zoneID
+		s.zonesMu.Unlock()
+	}
+}
+
+func (s *UsageService) lookupZone(name string) (string, bool) {
+	name = dnsname.Normalize(name)
+	s.zonesMu.RLock()
+	defer s.zonesMu.RUnlock()
+	for {
+		if id, ok := s.zones[name]; ok {
+			return id, true
+		}
+		parent, ok := dnsname.Parent(name)
+		if !ok {
+			return "", false
+		}
+		name = parent
+	}
+}
+
+// Start loads the zone index and flushes counters every interval until ctx is done,
+// with a final flush on shutdown.
+func (s *UsageService) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultUsageFlushInterval
+	}
+	s.logger.Info("starting usage aggregator", "interval", interval)
+	s.refreshZones(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+			if err := s.Flush(flushCtx); err != nil {
+				s.logger.Error("failed to flush usage on shutdown", "error", err)
+			}
+			cancel()
+			return
+		case <-ticker.C:
+			if err := s.Flush(ctx); err != nil {
+				s.logger.Error("failed to flush usage", "error", err)
+			}
+			s.refreshZones(ctx)
+		}
+	}
+}
+
+// refreshZones rebuilds the zone index so cached answers and transfers of zones
+// not yet seen on this node are attributed, and deleted zones are forgotten.
+func (s *UsageService) refreshZones(ctx context.Context) {
+	zones, err := s.repo.ListZones(ctx, "")
+	if err != nil {
+		s.logger.Warn("failed to load zones for usage attribution", "error", err)
+		return
+	}
+	index := make(map[string]string, len(zones))
+	owners := make(map[string]domain.Zone, len(zones))
+	for _, z := range zones {
+		index[dnsname.Normalize(z.Name)] = z.ID
+		owners[z.ID] = z
+	}
+	s.zonesMu.Lock()
+	s.zones = index
+	s.owners = owners
+	s.zonesMu.Unlock()
+}
+
+// Flush adds the counted usage to the stats table and resets the counters. Counts
+// that fail to persist are kept for the next flush.
+func (s *UsageService) Flush(ctx context.Context) error {
+	currentHour := s.now().Truncate(time.Hour).Unix()
+
+	var batch []domain.ZoneUsage
+	s.counters.Range(func(k, v any) bool {
+		key, c := k.(usageKey), v.(*usageCounter)
+		u := domain.ZoneUsage{
+			ZoneID:        key.zoneID,
+			Hour:          time.Unix(key.hour, 0).UTC(),
+			Queries:       c.queries.Swap(0),
+			NXDomain:      c.nxdomain.Swap(0),
+			TransferBytes: c.transfer.Swap(0),
+		}
+		if key.hour < currentHour {
+			// Past hours receive no new counts beyond stragglers racing this delete
+			s.counters.Delete(key)
+		}
+		if u.Queries > 0 || u.NXDomain > 0 || u.TransferBytes > 0 {
+			batch = append(batch, u)
+		}
+		return true
+	})
+	if len(batch) == 0 {
+		return nil
+	}
+
+	if err := s.repo.AddZoneUsage(ctx, batch); err != nil {
+		for _, u := range batch {
+			key := usageKey{zoneID: u.ZoneID, hour: u.Hour.Unix()}
+			v, _ := s.counters.LoadOrStore(key, &usageCounter{})
+			c := v.(*usageCounter)
+			c.queries.Add(u.Queries)
+			c.nxdomain.Add(u.NXDomain)
+			c.transfer.Add(u.TransferBytes)
+		}
+		return err
+	}
+	s.exportMetrics(batch)
+	return nil
+}
+
+// exportMetrics adds flushed usage to the per-tenant query metrics and drops the
+// series of zones that are no longer allowed a label of their own.
+func (s *UsageService) exportMetrics(batch []domain.ZoneUsage) {
+	s.labelsMu.Lock()
+	defer s.labelsMu.Unlock()
+	for _, u := range batch {
+		s.zoneTotals[u.ZoneID] += u.Queries
+	}
+	top := make(map[string]bool, s.labelTopN)
+	if s.labelTopN > 0 {
+		ids := make([]string, 0, len(s.zoneTotals))
+		for id := range s.zoneTotals {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool {
+			if s.zoneTotals[ids[i]] != s.zoneTotals[ids[j]] {
+				return s.zoneTotals[ids[i]] > s.zoneTotals[ids[j]]
+			}
+			return ids[i] < ids[j]
+		})
+		for i := 0; i < len(ids) && i < s.labelTopN; i++ {
+			top[ids[i]] = true
+		}
+	}
+
+	s.zonesMu.RLock()
+	labels := make(map[string][2]string, len(s.labelled))
+	for id := range s.zoneTotals {
+		zone, known := s.owners[id]
+		switch {
+		case known && (top[id] || s.labelAllow[dnsname.Normalize(zone.Name)]):
+			labels[id] = [2]string{zone.TenantID, dnsname.Normalize(zone.Name)}
+		case known && s.labelAllow[zone.TenantID]:
+			labels[id] = [2]string{zone.TenantID, otherLabel}
+		}
+	}
+	s.zonesMu.RUnlock()
+
+	for id, l := range s.labelled {
+		if labels[id] != l && l[1] != otherLabel {
+			metrics.TenantZoneQueries.DeleteLabelValues(l[0], l[1])
+			metrics.TenantZoneNXDomain.DeleteLabelValues(l[0], l[1])
+		}
+	}
+	s.labelled = labels
+
+	for _, u := range batch {
+		l, ok := labels[u.ZoneID]
+		if !ok {
+			l = [2]string{otherLabel, otherLabel}
+		}
+		metrics.TenantZoneQueries.WithLabelValues(l[0], l[1]).Add(float64(u.Queries))
+		metrics.TenantZoneNXDomain.WithLabelValues(l[0], l[1]).Add(float64(u.NXDomain))
+	}
+}
+
+// ZoneUsage returns a tenant zone's hourly usage in [from, to), or nil if the zone
+// does not exist.
+func (s *UsageService) ZoneUsage(ctx context.Context, zoneID string, tenantID string, from, to time.Time) (*domain.ZoneUsageReport, error) {
+	zone, err := s.repo.GetZoneByID(ctx, zoneID, tenantID)
+	if err != nil || zone == nil {
+		return nil, err
+	}
+	hourly, err := s.repo.ListZoneUsage(ctx, tenantID, zoneID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &domain.ZoneUsageReport{
+		ZoneID:   zone.ID,
+		ZoneName: zone.Name,
+		From:     from,
+		To:       to,
+		Hourly:   hourly,
+	}
+	if report.Hourly == nil {
+		report.Hourly = []domain.ZoneUsage{}
+	}
+	for _, u := range hourly {
+		report.Queries += u.Queries
+		report.NXDomain += u.NXDomain
+		report.TransferBytes += u.TransferBytes
+	}
+	return report, nil
+}
+
+// ExportUsage returns the hourly usage in [from, to) of every zone owned by tenantID.
+func (s *UsageService) ExportUsage(ctx context.Context, tenantID string, from, to time.Time) ([]domain.ZoneUsage, error) {
+	return s.repo.ListZoneUsage(ctx, tenantID, "", from, to)
+}
+
+// TenantUsageSummary sums the tenant's usage in [from, to) across the fleet, per
+// zone and per hour.
+func (s *UsageService) TenantUsageSummary(ctx context.Context, tenantID string, from, to time.Time) (*domain.TenantUsageSummary, error) {
+	usage, err := s.repo.ListZoneUsage(ctx, tenantID, "", from, to)
+	if err != nil {
+		return nil, err
+	}
+	summary := &domain.TenantUsageSummary{From: from, To: to, Zones: []domain.ZoneUsageTotal{}, Hourly: []domain.UsageBucket{}}
+	zones := map[string]*domain.ZoneUsageTotal{}
+	hours := map[int64]*domain.UsageBucket{}
+	for _, u := range usage {
+		summary.Queries += u.Queries
+		summary.NXDomain += u.NXDomain
+		summary.TransferBytes += u.TransferBytes
+
+		z, ok := zones[u.ZoneID]
+		if !ok {
+			z = &domain.ZoneUsageTotal{ZoneID: u.ZoneID, ZoneName: u.ZoneName}
+			zones[u.ZoneID] = z
+		}
+		z.Queries += u.Queries
+		z.NXDomain += u.NXDomain
+		z.TransferBytes += u.TransferBytes
+
+		h, ok := hours[u.Hour.Unix()]
+		if !ok {
+			h = &domain.UsageBucket{Hour: u.Hour}
+			hours[u.Hour.Unix()] = h
+		}
+		h.Queries += u.Queries
+		h.NXDomain += u.NXDomain
+		h.TransferBytes += u.TransferBytes
+	}
+	for _, z := range zones {
+		summary.Zones = append(summary.Zones, *z)
+	}
+	sort.Slice(summary.Zones, func(i, j int) bool {
+		if summary.Zones[i].Queries != summary.Zones[j].Queries {
+			return summary.Zones[i].Queries > summary.Zones[j].Queries
+		}
+		return summary.Zones[i].ZoneName < summary.Zones[j].ZoneName
+	})
+	for _, h := range hours {
+		summary.Hourly = append(summary.Hourly, *h)
+	}
+	sort.Slice(summary.Hourly, func(i, j int) bool { return summary.Hourly[i].Hour.Before(summary.Hourly[j].Hour) })
+	return summary, nil
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestUsageService_RecordAndFlush(t *testing.T) {
+	repo := &mockRepo{zones: []domain.Zone{{ID: "z1", Name: "example.com."}, {ID: "z2", Name: "sub.example.com."}}}
+	svc := NewUsageService(repo, nil)
+	now := time.Date(2026, 5, 1, 10, 30, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	svc.refreshZones(context.Background())
+
+	svc.RecordQuery("z1", "example.com.", 0)
+	svc.RecordQuery("z1", "example.com.", 3)
+	svc.RecordCachedQuery("WWW.Example.com.", 0)
+	svc.RecordCachedQuery("deep.sub.example.com.", 3)
+	svc.RecordCachedQuery("other.test.", 0)
+	svc.RecordTransfer("example.com.", 2048)
+
+	if err := svc.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	byZone := map[string]domain.ZoneUsage{}
+	for _, u := range repo.usage {
+		byZone[u.ZoneID] = u
+	}
+	if u := byZone["z1"]; u.Queries != 3 || u.NXDomain != 1 || u.TransferBytes != 2048 || !u.Hour.Equal(now.Truncate(time.Hour)) {
+		t.Errorf("Unexpected z1 usage: %+v", u)
+	}
+	if u := byZone["z2"]; u.Queries != 1 || u.NXDomain != 1 {
+		t.Errorf("Expected cache hit attributed to the closest zone, got %+v", u)
+	}
+
+	// Nothing new to flush
+	repo.usage = nil
+	if err := svc.Flush(context.Background()); err != nil || len(repo.usage) != 0 {
+		t.Errorf("Expected empty flush, got %v, %+v", err, repo.usage)
+	}
+}
+
+func TestUsageService_HourBucketsAndRetry(t *testing.T) {
+	repo := &mockRepo{}
+	svc := NewUsageService(repo, nil)
+	now := time.Date(2026, 5, 1, 10, 59, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	svc.RecordQuery("z1", "example.com.", 0)
+	now = now.Add(2 * time.Minute)
+	svc.RecordQuery("z1", "example.com.", 0)
+
+	repo.err = errors.New("db down")
+	if err := svc.Flush(context.Background()); err == nil {
+		t.Fatal("Expected flush error")
+	}
+	repo.err = nil
+	if err := svc.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if len(repo.usage) != 2 || repo.usage[0].Queries != 1 || repo.usage[1].Queries != 1 || repo.usage[0].Hour.Equal(repo.usage[1].Hour) {
+		t.Errorf("Expected failed counts kept in separate hourly buckets, got %+v", repo.usage)
+	}
+
+	count := 0
+	svc.counters.Range(func(_, _ any) bool { count++; return true })
+	if count != 1 {
+		t.Errorf("Expected the past hour's counter to be dropped after flushing, got %d counters", count)
+	}
+}
+
+func TestUsageService_ZoneUsage(t *testing.T) {
+	hour := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	repo := &mockRepo{
+		zones: []domain.Zone{{ID: "z1", TenantID: "t1", Name: "example.com."}},
+		usage: []domain.ZoneUsage{
+			{ZoneID: "z1", Hour: hour, Queries: 10, NXDomain: 2},
+			{ZoneID: "z1", Hour: hour.Add(time.Hour), Queries: 5, TransferBytes: 100},
+			{ZoneID: "z1", Hour: hour.Add(48 * time.Hour), Queries: 99},
+		},
+	}
+	svc := NewUsageService(repo, nil)
+
+	report, err := svc.ZoneUsage(context.Background(), "z1", "t1", hour, hour.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("ZoneUsage failed: %v", err)
+	}
+	if report.ZoneName != "example.com." || report.Queries != 15 || report.NXDomain != 2 || report.TransferBytes != 100 || len(report.Hourly) != 2 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+
+	if report, err := svc.ZoneUsage(context.Background(), "missing", "t1", hour, hour.Add(time.Hour)); err != nil || report != nil {
+		t.Errorf("Expected nil report for unknown zone, got %+v, %v", report, err)
+	}
+}
+
+func TestUsageService_MetricLabels(t *testing.T) {
+	repo := &mockRepo{zones: []domain.Zone{
+		{ID: "lz1", TenantID: "lt1", Name: "busy.test."},
+		{ID: "lz2", TenantID: "lt1", Name: "quiet.test."},
+		{ID: "lz3", TenantID: "lt2", Name: "pinned.test."},
+		{ID: "lz4", TenantID: "lt3", Name: "small.test."},
+		{ID: "lz5", TenantID: "lt4", Name: "tiny.test."},
+	}}
+	svc := NewUsageService(repo, nil)
+	svc.SetMetricLabels([]string{"pinned.test", "lt3"}, 1)
+	svc.refreshZones(context.Background())
+	queries := func(tenant, zone string) float64 {
+		return testutil.ToFloat64(metrics.TenantZoneQueries.WithLabelValues(tenant, zone))
+	}
+	other := queries("other", "other")
+
+	for i := 0; i < 5; i++ {
+		svc.RecordQuery("lz1", "busy.test.", 0)
+	}
+	svc.RecordQuery("lz2", "quiet.test.", 3)
+	svc.RecordQuery("lz3", "pinned.test.", 0)
+	svc.RecordQuery("lz4", "small.test.", 0)
+	svc.RecordQuery("lz5", "tiny.test.", 0)
+	if err := svc.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	if got := queries("lt1", "busy.test."); got != 5 {
+		t.Errorf("Expected the busiest zone labelled with 5 queries, got %v", got)
+	}
+	if got := queries("lt2", "pinned.test."); got != 1 {
+		t.Errorf("Expected the allowlisted zone labelled, got %v", got)
+	}
+	if got := queries("lt3", "other"); got != 1 {
+		t.Errorf("Expected the allowlisted tenant's zone counted within the tenant, got %v", got)
+	}
+	if got := queries("other", "other") - other; got != 2 {
+		t.Errorf("Expected the quiet and tiny zones counted as other, got %v", got)
+	}
+
+	// quiet.test. overtakes busy.test., whose series is dropped
+	for i := 0; i < 10; i++ {
+		svc.RecordQuery("lz2", "quiet.test.", 0)
+	}
+	if err := svc.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if got := queries("lt1", "quiet.test."); got != 10 {
+		t.Errorf("Expected the new busiest zone labelled, got %v", got)
+	}
+	if got := queries("lt1", "busy.test."); got != 0 {
+		t.Errorf("Expected the series of a zone that left the top to be dropped, got %v", got)
+	}
+}
+
+func TestUsageService_TenantUsageSummary(t *testing.T) {
+	hour := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	repo := &mockRepo{usage: []domain.ZoneUsage{
+		{ZoneID: "z1", ZoneName: "a.test.", Hour: hour, Queries: 10, NXDomain: 2},
+		{ZoneID: "z2", ZoneName: "b.test.", Hour: hour, Queries: 30},
+		{ZoneID: "z1", ZoneName: "a.test.", Hour: hour.Add(time.Hour), Queries: 5, TransferBytes: 100},
+	}}
+	svc := NewUsageService(repo, nil)
+
+	summary, err := svc.TenantUsageSummary(context.Background(), "t1", hour, hour.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("TenantUsageSummary failed: %v", err)
+	}
+	if summary.Queries != 45 || summary.NXDomain != 2 || summary.TransferBytes != 100 {
+		t.Errorf("Unexpected totals: %+v", summary)
+	}
+	if len(summary.Zones) != 2 || summary.Zones[0].ZoneName != "b.test." || summary.Zones[1].Queries != 15 {
+		t.Errorf("Expected zones from the busiest down, got %+v", summary.Zones)
+	}
+	if len(summary.Hourly) != 2 || summary.Hourly[0].Queries != 40 || !summary.Hourly[1].Hour.Equal(hour.Add(time.Hour)) {
+		t.Errorf("Expected hourly totals in order, got %+v", summary.Hourly)
+	}
+}
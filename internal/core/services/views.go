@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/core/ports"
+)
+
+// ViewService manages a tenant's split-horizon views. The DNS servers reload
+// the views periodically and match every client against them.
+type ViewService struct {
+	repo ports.DNSRepository
+	now  func() time.Time
+}
+
+// NewViewService creates a service that stores views in repo.
+func NewViewService(repo ports.DNSRepository) *ViewService {
+	return &ViewService{repo: repo, now: time.Now}
+}
+
+// CreateView stores a new view of the tenant.
+func (s *ViewService) CreateView(ctx context.Context, tenantID string, view *domain.View) error {
+	views, err := s.repo.ListViews(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	view.ID = uuid.New().String()
+	if err := validateView(view, views); err != nil {
+		return err
+	}
+	view.TenantID = tenantID
+	view.CreatedAt = s.now()
+	view.UpdatedAt = view.CreatedAt
+	if err := s.repo.SaveView(ctx, view); err != nil {
+		return err
+	}
+	s.audit(ctx, tenantID, "CREATE_VIEW", view.ID, fmt.Sprintf("Created view %s for %s", view.Name, strings.Join(view.Networks, ", ")))
+	return nil
+}
+
+// UpdateView replaces the name, networks and priority of one of the tenant's
+// views.
+func (s *ViewService) UpdateView(ctx context.Context, tenantID string, view *domain.View) error {
+	views, err := s.repo.ListViews(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	var existing *domain.View
+	for i := range views {
+		if views[i].ID == view.ID {
+			existing = &views[i]
+		}
+	}
+	if existing == nil {
+		return fmt.Errorf("view %s: %w", view.ID, domain.ErrNotFound)
+	}
+	if err := validateView(view, views); err != nil {
+		return err
+	}
+	view.TenantID = tenantID
+	view.CreatedAt = existing.CreatedAt
+	view.UpdatedAt = s.now()
+	if err := s.repo.SaveView(ctx, view); err != nil {
+		return err
+	}
+	s.audit(ctx, tenantID, "UPDATE_VIEW", view.ID, fmt.Sprintf("Updated view %s for %s", view.Name, strings.Join(view.Networks, ", ")))
+	return nil
+}
+
+// ListViews returns the tenant's views in the order clients are matched
+// against them.
+func (s *ViewService) ListViews(ctx context.Context, tenantID string) ([]domain.View, error) {
+	views, err := s.repo.ListViews(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if views == nil {
+		views = []domain.View{}
+	}
+	return views, nil
+}
+
+// DeleteView removes one of the tenant's views together with the records
+// scoped to it.
+func (s *ViewService) DeleteView(ctx context.Context, tenantID string, id string) error {
+	deleted, err := s.repo.DeleteView(ctx, id, tenantID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("view %s: %w", id, domain.ErrNotFound)
+	}
+	s.audit(ctx, tenantID, "DELETE_VIEW", id, "Deleted view and its records")
+	return nil
+}
+
+func (s *ViewService) audit(ctx context.Context, tenantID, action, id, details string) {
+	_ = s.repo.SaveAuditLog(ctx, &domain.AuditLog{
+		ID:           uuid.New().String(),
+		TenantID:     tenantID,
+		Action:       action,
+		ResourceType: "VIEW",
+		ResourceID:   id,
+		Details:      details,
+		CreatedAt:    s.now(),
+	})
+}
+
+// validateView checks a view against the tenant's other views and normalizes
+// its networks to their canonical CIDR form.
+func validateView(view *domain.View, views []domain.View) error {
+	view.Name = strings.TrimSpace(view.Name)
+	if view.Name == "" {
+		return fmt.Errorf("name is required: %w", domain.ErrInvalidView)
+	}
+	for _, other := range views {
+		if other.ID != view.ID && strings.EqualFold(other.Name, view.Name) {
+			return fmt.Errorf("a view named %s already exists: %w", view.Name, domain.ErrInvalidView)
+		}
+	}
+	if len(view.Networks) == 0 {
+		return fmt.Errorf("at least one network is required: %w", domain.ErrInvalidView)
+	}
+	networks := make([]string, 0, len(view.Networks))
+	for _, cidr := range view.Networks {
+		_, network, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return fmt.Errorf("network %q: %w", cidr, domain.ErrInvalidView)
+		}
+		networks = append(networks, network.String())
+	}
+	view.Networks = networks
+	return nil
+}
+
+// checkRecordView returns domain.ErrInvalidView if a record is scoped to a view
+// the tenant does not have.
+func checkRecordView(ctx context.Context, repo ports.DNSRepository, tenantID string, record *domain.Record) error {
+	if record.ViewID == nil {
+		return nil
+	}
+	views, err := repo.ListViews(ctx, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to load views: %w", err)
+	}
+	for _, view := range views {
+		if view.ID == *record.ViewID {
+			return nil
+		}
+	}
+	return fmt.Errorf("view %s does not exist: %w", *record.ViewID, domain.ErrInvalidView)
+}
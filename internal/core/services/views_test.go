@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+func TestViewService(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepo{}
+	s := NewViewService(repo)
+
+	for _, view := range []domain.View{
+		{Networks: []string{"10.0.0.0/8"}},
+		{Name: "internal"},
+		{Name: "internal", Networks: []string{"10.0.0.1"}},
+	} {
+		if err := s.CreateView(ctx, "t1", &view); !errors.Is(err, domain.ErrInvalidView) {
+			t.Errorf("Expected ErrInvalidView for %+v, got %v", view, err)
+		}
+	}
+
+	internal := domain.View{Name: "internal", Networks: []string{"10.1.2.3/8"}, Priority: 10}
+	if err := s.CreateView(ctx, "t1", &internal); err != nil {
+		t.Fatalf("CreateView failed: %v", err)
+	}
+	if internal.ID == "" || internal.Networks[0] != "10.0.0.0/8" {
+		t.Errorf("Expected an ID and a canonical network, got %+v", internal)
+	}
+	office := domain.View{Name: "office", Networks: []string{"10.8.0.0/16", "2001:db8::/32"}, Priority: 5}
+	if err := s.CreateView(ctx, "t1", &office); err != nil {
+		t.Fatalf("CreateView failed: %v", err)
+	}
+	if err := s.CreateView(ctx, "t1", &domain.View{Name: "Internal", Networks: []string{"192.0.2.0/24"}}); !errors.Is(err, domain.ErrInvalidView) {
+		t.Errorf("Expected a duplicate name to be rejected, got %v", err)
+	}
+	if err := s.CreateView(ctx, "t2", &domain.View{Name: "internal", Networks: []string{"192.0.2.0/24"}}); err != nil {
+		t.Errorf("Expected names to be scoped to the tenant, got %v", err)
+	}
+
+	views, err := s.ListViews(ctx, "t1")
+	if err != nil || len(views) != 2 || views[0].Name != "office" {
+		t.Fatalf("Expected both views by priority, got %+v, %v", views, err)
+	}
+
+	office.Priority = 20
+	if err := s.UpdateView(ctx, "t1", &office); err != nil {
+		t.Fatalf("UpdateView failed: %v", err)
+	}
+	if views, _ := s.ListViews(ctx, "t1"); views[0].Name != "internal" || views[1].CreatedAt.IsZero() {
+		t.Errorf("Expected the update to reorder the views and keep the creation time, got %+v", views)
+	}
+	if err := s.UpdateView(ctx, "t2", &office); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected another tenant's view to be missing, got %v", err)
+	}
+	office.Name = "internal"
+	if err := s.UpdateView(ctx, "t1", &office); !errors.Is(err, domain.ErrInvalidView) {
+		t.Errorf("Expected a rename onto another view to be rejected, got %v", err)
+	}
+
+	// Records are scoped to views of their own tenant, and go with the view
+	svc := NewDNSService(repo, nil)
+	if err := svc.CreateRecord(ctx, &domain.Record{TenantID: "t2", ZoneID: "z1", Name: "www.view.test.", Type: domain.TypeA, Content: "10.0.0.1", ViewID: &internal.ID}); !errors.Is(err, domain.ErrInvalidView) {
+		t.Errorf("Expected another tenant's view to be rejected, got %v", err)
+	}
+	if err := svc.CreateRecord(ctx, &domain.Record{TenantID: "t1", ZoneID: "z1", Name: "www.view.test.", Type: domain.TypeA, Content: "10.0.0.1", ViewID: &internal.ID}); err != nil {
+		t.Fatalf("CreateRecord failed: %v", err)
+	}
+	if err := s.DeleteView(ctx, "t1", internal.ID); err != nil {
+		t.Fatalf("DeleteView failed: %v", err)
+	}
+	if len(repo.records) != 0 {
+		t.Errorf("Expected the view's records to be deleted with it, got %+v", repo.records)
+	}
+	if err := s.DeleteView(ctx, "t1", internal.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a removed view, got %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+const (
+	// DefaultChangeFeedLimit is how many changes a change feed page holds when no
+	// limit is given.
+	DefaultChangeFeedLimit = 100
+	// MaxChangeFeedLimit caps the limit a caller may ask for.
+	MaxChangeFeedLimit = 1000
+)
+
+// ListZoneChangeFeed returns the journaled changes of a zone after sinceSerial,
+// oldest first, as served by IXFR. A page holds about limit changes but never
+// splits the changes of one serial, so NextSerial is always a serial the zone
+// actually had. It returns an error wrapping domain.ErrNotFound if the zone does
+// not exist for the tenant.
+func (s *dnsService) ListZoneChangeFeed(ctx context.Context, zoneID string, tenantID string, sinceSerial uint32, limit int) (*domain.ZoneChangeFeed, error) {
+	zone, err := s.repo.GetZoneByID(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load zone: %w", err)
+	}
+	if zone == nil {
+		return nil, fmt.Errorf("zone %s: %w", zoneID, domain.ErrNotFound)
+	}
+	if limit <= 0 {
+		limit = DefaultChangeFeedLimit
+	}
+	limit = min(limit, MaxChangeFeedLimit)
+
+	changes, err := s.repo.ListZoneChanges(ctx, zone.ID, sinceSerial)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list zone changes: %w", err)
+	}
+
+	feed := &domain.ZoneChangeFeed{
+		ZoneID:      zone.ID,
+		ZoneName:    zone.Name,
+		SinceSerial: sinceSerial,
+		NextSerial:  sinceSerial,
+		Changes:     []domain.ZoneChange{},
+	}
+	for _, c := range changes {
+		if len(feed.Changes) >= limit && c.Serial != feed.NextSerial {
+			feed.HasMore = true
+			break
+		}
+		feed.Changes = append(feed.Changes, c)
+		feed.NextSerial = c.Serial
+	}
+	return feed, nil
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+func TestListZoneChangeFeed(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepo{
+		zones: []domain.Zone{
+			{ID: "z1", TenantID: "t1", Name: "feed.test."},
+			{ID: "z2", TenantID: "t2", Name: "other.test."},
+		},
+		changes: []domain.ZoneChange{
+			{ZoneID: "z1", Serial: 2, Action: "ADD", Name: "a.feed.test.", Type: domain.TypeA, Content: "192.0.2.1"},
+			{ZoneID: "z1", Serial: 3, Action: "DELETE", Name: "a.feed.test.", Type: domain.TypeA, Content: "192.0.2.1"},
+			{ZoneID: "z1", Serial: 3, Action: "ADD", Name: "a.feed.test.", Type: domain.TypeA, Content: "192.0.2.2"},
+			{ZoneID: "z1", Serial: 4, Action: "ADD", Name: "b.feed.test.", Type: domain.TypeA, Content: "192.0.2.3"},
+		},
+	}
+	svc := NewDNSService(repo, nil)
+
+	if _, err := svc.ListZoneChangeFeed(ctx, "z2", "t1", 0, 0); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected another tenant's zone to be not found, got %v", err)
+	}
+
+	// A page of two keeps both changes of serial 3 together
+	feed, err := svc.ListZoneChangeFeed(ctx, "z1", "t1", 1, 2)
+	if err != nil {
+		t.Fatalf("ListZoneChangeFeed failed: %v", err)
+	}
+	if len(feed.Changes) != 3 || feed.NextSerial != 3 || !feed.HasMore {
+		t.Fatalf("Expected serials 2 and 3 with more to come, got %+v", feed)
+	}
+
+	feed, err = svc.ListZoneChangeFeed(ctx, "z1", "t1", feed.NextSerial, 2)
+	if err != nil {
+		t.Fatalf("ListZoneChangeFeed failed: %v", err)
+	}
+	if len(feed.Changes) != 1 || feed.NextSerial != 4 || feed.HasMore {
+		t.Fatalf("Expected only serial 4, got %+v", feed)
+	}
+
+	// Caught up: nothing new and the cursor stays put
+	feed, err = svc.ListZoneChangeFeed(ctx, "z1", "t1", 4, 0)
+	if err != nil {
+		t.Fatalf("ListZoneChangeFeed failed: %v", err)
+	}
+	if len(feed.Changes) != 0 || feed.NextSerial != 4 || feed.HasMore {
+		t.Errorf("Expected an empty page at serial 4, got %+v", feed)
+	}
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+)
+
+// nameTargetTypes are the record types whose content holds domain names that are
+// rewritten when a zone is cloned under a new origin.
+var nameTargetTypes = map[domain.RecordType]bool{
+	domain.TypeSOA:   true,
+	domain.TypeNS:    true,
+	domain.TypeCNAME: true,
+	domain.TypeMX:    true,
+	domain.TypeSRV:   true,
+	domain.TypePTR:   true,
+	domain.TypeSVCB:  true,
+	domain.TypeHTTPS: true,
+	domain.TypeNAPTR: true,
+	"DNAME":          true,
+}
+
+// CloneZone copies a zone and all of its records into a new primary zone, for
+// example to stand up a staging copy of a production zone or to hand a zone to
+// another tenant. When opts.Name is set the origin is renamed, along with every
+// owner name and in-zone target below it. Stored DNSSEC records are never copied:
+// the copy is created unsigned unless opts.CopyDNSSEC is set, in which case it
+// keeps the source's setting and gets keys of its own. It returns nil, nil if the
+// source zone does not exist for the tenant.
+func (s *dnsService) CloneZone(ctx context.Context, zoneID string, tenantID string, opts domain.ZoneCloneOptions) (*domain.Zone, error) {
+	source, err := s.repo.GetZoneByID(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load zone: %w", err)
+	}
+	if source == nil {
+		return nil, nil
+	}
+
+	records, err := s.repo.ListRecordsForZone(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list records: %w", err)
+	}
+
+	now := time.Now()
+	clone := &domain.Zone{
+		ID:          uuid.New().String(),
+		TenantID:    tenantID,
+		Name:        source.Name,
+		Description: source.Description,
+		Role:        "master",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if opts.TargetTenantID != "" {
+		clone.TenantID = opts.TargetTenantID
+	}
+	if opts.Name != "" {
+		clone.Name = dnsname.Fqdn(opts.Name)
+	}
+	// A VPC belongs to its tenant, so only same-tenant copies stay private to it
+	if clone.TenantID == source.TenantID {
+		clone.VPCID = source.VPCID
+	}
+	if err := CheckChangeFreeze(ctx, s.repo, clone.TenantID, "", "CLONE_ZONE "+clone.Name); err != nil {
+		return nil, err
+	}
+	// The copy would not be under review, so copying a reviewed zone needs its own approval
+	if err := CheckChangeReview(ctx, s.repo, tenantID, source.ID, "CLONE_ZONE "+source.Name); err != nil {
+		return nil, err
+	}
+	signed := opts.CopyDNSSEC && source.SignsDNSSEC()
+	clone.DNSSECEnabled = &signed
+
+	copies := make([]domain.Record, 0, len(records))
+	for _, rec := range records {
+		if dnssecRecordTypes[rec.Type] {
+			continue
+		}
+		rec.ID = uuid.New().String()
+		rec.ZoneID = clone.ID
+		rec.TenantID = clone.TenantID
+		rec.Name = renameOrigin(rec.Name, source.Name, clone.Name)
+		if nameTargetTypes[rec.Type] {
+			rec.Content = renameContent(rec.Content, source.Name, clone.Name)
+		}
+		rec.HealthStatus = ""
+		rec.CreatedAt = now
+		rec.UpdatedAt = now
+		copies = append(copies, rec)
+	}
+
+	if err := s.repo.CreateZoneWithRecords(ctx, clone, copies); err != nil {
+		return nil, err
+	}
+	if signed {
+		if err := NewDNSSECService(s.repo).AutomateLifecycle(ctx, clone.ID); err != nil {
+			return nil, fmt.Errorf("failed to create DNSSEC keys: %w", err)
+		}
+	}
+
+	details := fmt.Sprintf("Cloned zone %s (%s) as %s with %d records", source.Name, source.ID, clone.Name, len(copies))
+	s.audit(ctx, clone.TenantID, "CLONE_ZONE", "ZONE", clone.ID, details)
+	if clone.TenantID != tenantID {
+		s.audit(ctx, tenantID, "CLONE_ZONE", "ZONE", source.ID, fmt.Sprintf("Copied zone %s to tenant %s", source.Name, clone.TenantID))
+	}
+	s.publish(domain.Event{Type: domain.EventZoneChange, TenantID: clone.TenantID, ZoneID: clone.ID, Action: "CLONE_ZONE", Name: clone.Name})
+	return clone, nil
+}
+
+// renameOrigin moves name from the old origin to the new one if it is at or below
+// the old origin, and returns it unchanged otherwise.
+func renameOrigin(name, oldOrigin, newOrigin string) string {
+	if dnsname.Equal(oldOrigin, newOrigin) || !dnsname.IsFqdn(name) || !dnsname.IsSubdomain(name, oldOrigin) {
+		return name
+	}
+	prefix := name[:len(name)-len(dnsname.Fqdn(oldOrigin))]
+	return prefix + dnsname.Fqdn(newOrigin)
+}
+
+// renameContent renames every fully qualified in-zone name in a record's content,
+// such as a CNAME target or the MNAME and RNAME of an SOA.
+func renameContent(content, oldOrigin, newOrigin string) string {
+	if dnsname.Equal(oldOrigin, newOrigin) {
+		return content
+	}
+	fields := strings.Fields(content)
+	for i, f := range fields {
+		fields[i] = renameOrigin(f, oldOrigin, newOrigin)
+	}
+	return strings.Join(fields, " ")
+}
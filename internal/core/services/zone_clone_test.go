@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+func TestCloneZone(t *testing.T) {
+	ctx := context.Background()
+	network := "10.0.0.0/8"
+	repo := &mockRepo{
+		zones: []domain.Zone{{ID: "z1", TenantID: "t1", Name: "example.com.", Description: "production", Role: "master"}},
+		records: []domain.Record{
+			{ID: "r1", ZoneID: "z1", Name: "example.com.", Type: domain.TypeSOA, Content: "ns1.example.com. admin.example.com. 7 3600 600 86400 300"},
+			{ID: "r2", ZoneID: "z1", Name: "www.example.com.", Type: domain.TypeCNAME, Content: "app.example.com."},
+			{ID: "r3", ZoneID: "z1", Name: "app.example.com.", Type: domain.TypeA, Content: "10.0.0.1", Network: &network},
+			{ID: "r4", ZoneID: "z1", Name: "ext.example.com.", Type: domain.TypeCNAME, Content: "cdn.example.net."},
+			{ID: "r5", ZoneID: "z1", Name: "example.com.", Type: "DNSKEY", Content: "257 3 13 AAAA"},
+		},
+	}
+	svc := NewDNSService(repo, nil)
+
+	if zone, err := svc.CloneZone(ctx, "missing", "t1", domain.ZoneCloneOptions{}); err != nil || zone != nil {
+		t.Fatalf("Expected nil for unknown zone, got %+v (%v)", zone, err)
+	}
+
+	clone, err := svc.CloneZone(ctx, "z1", "t1", domain.ZoneCloneOptions{Name: "staging.example.com"})
+	if err != nil || clone == nil {
+		t.Fatalf("CloneZone failed: %v", err)
+	}
+	if clone.ID == "z1" || clone.Name != "staging.example.com." || clone.TenantID != "t1" || clone.Description != "production" {
+		t.Errorf("Unexpected clone: %+v", clone)
+	}
+	if clone.SignsDNSSEC() {
+		t.Error("Expected the copy unsigned without copy_dnssec")
+	}
+
+	got := map[string]domain.Record{}
+	for _, r := range repo.records {
+		if r.ZoneID == clone.ID {
+			got[r.Name+" "+string(r.Type)] = r
+		}
+	}
+	if len(got) != 4 {
+		t.Fatalf("Expected 4 records copied without DNSSEC records, got %v", got)
+	}
+	if soa := got["staging.example.com. SOA"]; soa.Content != "ns1.staging.example.com. admin.staging.example.com. 7 3600 600 86400 300" {
+		t.Errorf("Expected SOA names renamed, got %q", soa.Content)
+	}
+	if cname := got["www.staging.example.com. CNAME"]; cname.Content != "app.staging.example.com." {
+		t.Errorf("Expected in-zone target renamed, got %q", cname.Content)
+	}
+	if cname := got["ext.staging.example.com. CNAME"]; cname.Content != "cdn.example.net." {
+		t.Errorf("Expected external target kept, got %q", cname.Content)
+	}
+	if a := got["app.staging.example.com. A"]; a.Network == nil || *a.Network != network || a.TenantID != "t1" {
+		t.Errorf("Expected split-horizon network kept, got %+v", a)
+	}
+
+	// Copy to another tenant, keeping the name and the DNSSEC setting
+	clone, err = svc.CloneZone(ctx, "z1", "t1", domain.ZoneCloneOptions{TargetTenantID: "t2", CopyDNSSEC: true})
+	if err != nil || clone.TenantID != "t2" || clone.Name != "example.com." || !clone.SignsDNSSEC() {
+		t.Fatalf("Expected signed copy for t2, got %+v (%v)", clone, err)
+	}
+	var keys int
+	for _, k := range repo.keys {
+		if k.ZoneID == clone.ID {
+			keys++
+		}
+	}
+	if keys == 0 {
+		t.Error("Expected fresh DNSSEC keys for the signed copy")
+	}
+	var audited bool
+	for _, a := range repo.audits {
+		if a.Action == "CLONE_ZONE" && a.TenantID == "t2" && a.ResourceID == clone.ID {
+			audited = true
+		}
+	}
+	if !audited {
+		t.Error("Expected the copy audited for the target tenant")
+	}
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+	"github.com/poyrazK/cloudDNS/internal/dns/serial"
+)
+
+// rrsetKey identifies an RRset by its normalized owner name and type.
+type rrsetKey struct {
+	name  string
+	rtype domain.RecordType
+}
+
+// DiffZone compares a zone with a baseline and lists the RRsets that were added,
+// removed or changed. against is either the ID of another zone of the tenant,
+// whose names are mapped onto this zone's origin first, or an SOA serial of this
+// zone, in which case the baseline is rebuilt by undoing the change journal kept
+// for IXFR. Stored DNSSEC records are left out of zone-to-zone comparisons since
+// every zone signs with its own keys. It returns an error wrapping
+// domain.ErrNotFound if either zone does not exist for the tenant.
+func (s *dnsService) DiffZone(ctx context.Context, zoneID string, tenantID string, against string) (*domain.ZoneDiff, error) {
+	zone, records, err := s.loadRRsetZone(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	diff := &domain.ZoneDiff{ZoneID: zone.ID, ZoneName: zone.Name}
+
+	var baseline []domain.Record
+	if from, errParse := serial.Parse(against); errParse == nil {
+		diff.AgainstSerial = &from
+		changes, err := s.repo.ListZoneChanges(ctx, zone.ID, from)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list zone changes: %w", err)
+		}
+		baseline = revertZoneChanges(records, changes)
+	} else {
+		other, otherRecords, err := s.loadRRsetZone(ctx, against, tenantID)
+		if err != nil {
+			return nil, err
+		}
+		diff.AgainstZoneID = other.ID
+		diff.AgainstName = other.Name
+		for _, rec := range otherRecords {
+			if dnssecRecordTypes[rec.Type] {
+				continue
+			}
+			rec.Name = renameOrigin(rec.Name, other.Name, zone.Name)
+			if nameTargetTypes[rec.Type] {
+				rec.Content = renameContent(rec.Content, other.Name, zone.Name)
+			}
+			baseline = append(baseline, rec)
+		}
+		var current []domain.Record
+		for _, rec := range records {
+			if !dnssecRecordTypes[rec.Type] {
+				current = append(current, rec)
+			}
+		}
+		records = current
+	}
+
+	compareRRsets(diff, baseline, records)
+	return diff, nil
+}
+
+// revertZoneChanges rebuilds the records of a zone before a run of journaled
+// changes by undoing them, newest first.
+func revertZoneChanges(records []domain.Record, changes []domain.ZoneChange) []domain.Record {
+	old := append([]domain.Record(nil), records...)
+	for i := len(changes) - 1; i >= 0; i-- {
+		c := changes[i]
+		switch c.Action {
+		case "ADD":
+			for j := range old {
+				if old[j].Type == c.Type && old[j].Content == c.Content && dnsname.Equal(old[j].Name, c.Name) {
+					old = append(old[:j], old[j+1:]...)
+					break
+				}
+			}
+		case "DELETE":
+			old = append(old, domain.Record{
+				ZoneID:   c.ZoneID,
+				Name:     c.Name,
+				Type:     c.Type,
+				Content:  c.Content,
+				TTL:      c.TTL,
+				Priority: c.Priority,
+				Weight:   c.Weight,
+				Port:     c.Port,
+			})
+		}
+	}
+	return old
+}
+
+// compareRRsets fills diff with the RRsets that differ between the baseline and
+// the current records, sorted by name and type.
+func compareRRsets(diff *domain.ZoneDiff, baseline, current []domain.Record) {
+	diff.Added = []domain.RRset{}
+	diff.Removed = []domain.RRset{}
+	diff.Changed = []domain.ZoneDiffChange{}
+
+	oldSets := groupRRsets(diff.ZoneID, baseline)
+	newSets := groupRRsets(diff.ZoneID, current)
+	keys := make([]rrsetKey, 0, len(oldSets)+len(newSets))
+	for k := range newSets {
+		keys = append(keys, k)
+	}
+	for k := range oldSets {
+		if _, ok := newSets[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].name != keys[j].name {
+			return keys[i].name < keys[j].name
+		}
+		return keys[i].rtype < keys[j].rtype
+	})
+
+	for _, k := range keys {
+		oldSet, newSet := oldSets[k], newSets[k]
+		switch {
+		case oldSet == nil:
+			diff.Added = append(diff.Added, *newSet)
+		case newSet == nil:
+			diff.Removed = append(diff.Removed, *oldSet)
+		default:
+			change := domain.ZoneDiffChange{Name: newSet.Name, Type: newSet.Type, OldTTL: oldSet.TTL, NewTTL: newSet.TTL}
+			change.Added = missingRecords(newSet.Records, oldSet.Records)
+			change.Removed = missingRecords(oldSet.Records, newSet.Records)
+			if change.OldTTL != change.NewTTL || len(change.Added) > 0 || len(change.Removed) > 0 {
+				diff.Changed = append(diff.Changed, change)
+			}
+		}
+	}
+}
+
+// groupRRsets collects records into RRsets keyed by normalized name and type.
+func groupRRsets(zoneID string, records []domain.Record) map[rrsetKey]*domain.RRset {
+	sets := make(map[rrsetKey]*domain.RRset)
+	for _, rec := range records {
+		k := rrsetKey{dnsname.Normalize(rec.Name), rec.Type}
+		set, ok := sets[k]
+		if !ok {
+			set = &domain.RRset{ZoneID: zoneID, Name: rec.Name, Type: rec.Type, TTL: rec.TTL}
+			sets[k] = set
+		}
+		if rec.TTL < set.TTL {
+			set.TTL = rec.TTL
+		}
+		set.Records = append(set.Records, domain.RRsetRecord{
+			Content:           rec.Content,
+			Priority:          rec.Priority,
+			Weight:            rec.Weight,
+			Port:              rec.Port,
+			Network:           rec.Network,
+			HealthCheckType:   rec.HealthCheckType,
+			HealthCheckTarget: rec.HealthCheckTarget,
+			HealthStatus:      rec.HealthStatus,
+		})
+	}
+	return sets
+}
+
+// missingRecords returns the records of a that are not in b.
+func missingRecords(a, b []domain.RRsetRecord) []domain.RRsetRecord {
+	have := make(map[string]int, len(b))
+	for _, rr := range b {
+		have[rrsetRecordKey(rr)]++
+	}
+	var missing []domain.RRsetRecord
+	for _, rr := range a {
+		k := rrsetRecordKey(rr)
+		if have[k] > 0 {
+			have[k]--
+			continue
+		}
+		missing = append(missing, rr)
+	}
+	return missing
+}
+
+// rrsetRecordKey identifies a record by its data, ignoring health state.
+func rrsetRecordKey(rr domain.RRsetRecord) string {
+	opt := func(v *int) string {
+		if v == nil {
+			return "-"
+		}
+		return strconv.Itoa(*v)
+	}
+	network := ""
+	if rr.Network != nil {
+		network = *rr.Network
+	}
+	return strings.Join([]string{rr.Content, opt(rr.Priority), opt(rr.Weight), opt(rr.Port), network}, "|")
+}
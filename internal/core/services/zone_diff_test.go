@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+func TestDiffZone(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepo{
+		zones: []domain.Zone{
+			{ID: "prod", TenantID: "t1", Name: "example.com."},
+			{ID: "staging", TenantID: "t1", Name: "staging.example.com."},
+			{ID: "other", TenantID: "t2", Name: "example.org."},
+		},
+		records: []domain.Record{
+			{ZoneID: "prod", Name: "www.example.com.", Type: domain.TypeCNAME, Content: "app.example.com.", TTL: 300},
+			{ZoneID: "prod", Name: "app.example.com.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 300},
+			{ZoneID: "prod", Name: "app.example.com.", Type: domain.TypeA, Content: "192.0.2.2", TTL: 300},
+			{ZoneID: "prod", Name: "old.example.com.", Type: domain.TypeTXT, Content: "\"legacy\"", TTL: 300},
+			{ZoneID: "prod", Name: "example.com.", Type: "DNSKEY", Content: "257 3 13 AAAA", TTL: 3600},
+
+			{ZoneID: "staging", Name: "www.staging.example.com.", Type: domain.TypeCNAME, Content: "app.staging.example.com.", TTL: 300},
+			{ZoneID: "staging", Name: "app.staging.example.com.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 60},
+			{ZoneID: "staging", Name: "app.staging.example.com.", Type: domain.TypeA, Content: "192.0.2.3", TTL: 60},
+			{ZoneID: "staging", Name: "new.staging.example.com.", Type: domain.TypeA, Content: "192.0.2.9", TTL: 300},
+		},
+	}
+	svc := NewDNSService(repo, nil)
+
+	if _, err := svc.DiffZone(ctx, "staging", "t1", "other"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected another tenant's zone to be not found, got %v", err)
+	}
+
+	diff, err := svc.DiffZone(ctx, "staging", "t1", "prod")
+	if err != nil {
+		t.Fatalf("DiffZone failed: %v", err)
+	}
+	if diff.AgainstZoneID != "prod" || diff.AgainstSerial != nil {
+		t.Errorf("Unexpected baseline: %+v", diff)
+	}
+	if len(diff.Added) != 1 || diff.Added[0].Name != "new.staging.example.com." {
+		t.Errorf("Expected new.staging added, got %+v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].Name != "old.staging.example.com." {
+		t.Errorf("Expected old TXT removed (and DNSKEY ignored), got %+v", diff.Removed)
+	}
+	if len(diff.Changed) != 1 {
+		t.Fatalf("Expected only the A RRset changed (CNAME target matches after renaming), got %+v", diff.Changed)
+	}
+	change := diff.Changed[0]
+	if change.OldTTL != 300 || change.NewTTL != 60 || len(change.Added) != 1 || change.Added[0].Content != "192.0.2.3" ||
+		len(change.Removed) != 1 || change.Removed[0].Content != "192.0.2.2" {
+		t.Errorf("Unexpected change: %+v", change)
+	}
+
+	// Against a serial, journaled changes since then are undone to form the baseline
+	repo.changes = []domain.ZoneChange{
+		{ZoneID: "prod", Serial: 5, Action: "ADD", Name: "old.example.com.", Type: domain.TypeTXT, Content: "\"ignored\"", TTL: 300},
+		{ZoneID: "prod", Serial: 11, Action: "DELETE", Name: "app.example.com.", Type: domain.TypeA, Content: "192.0.2.9", TTL: 300},
+		{ZoneID: "prod", Serial: 12, Action: "ADD", Name: "old.example.com.", Type: domain.TypeTXT, Content: "\"legacy\"", TTL: 300},
+	}
+	diff, err = svc.DiffZone(ctx, "prod", "t1", "10")
+	if err != nil || diff.AgainstSerial == nil || *diff.AgainstSerial != 10 {
+		t.Fatalf("Expected serial baseline, got %+v (%v)", diff, err)
+	}
+	if len(diff.Added) != 1 || diff.Added[0].Type != domain.TypeTXT {
+		t.Errorf("Expected the TXT RRset added since serial 10, got %+v", diff.Added)
+	}
+	if len(diff.Removed) != 0 || len(diff.Changed) != 1 || len(diff.Changed[0].Removed) != 1 || diff.Changed[0].Removed[0].Content != "192.0.2.9" {
+		t.Errorf("Expected 192.0.2.9 removed from the A RRset, got %+v / %+v", diff.Removed, diff.Changed)
+	}
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// dnssecRecordTypes are the record types that only make sense in a signed zone.
+var dnssecRecordTypes = map[domain.RecordType]bool{
+	"DNSKEY":     true,
+	"RRSIG":      true,
+	"NSEC":       true,
+	"NSEC3":      true,
+	"NSEC3PARAM": true,
+	"CDS":        true,
+	"CDNSKEY":    true,
+}
+
+// SetZoneDNSSEC opts a zone in or out of DNSSEC. Disabling takes the zone insecure:
+// its keys are deactivated, stored DNSSEC records removed and a CDS/CDNSKEY "delete"
+// pair (RFC 8078, Section 4) published so the parent can drop its DS. Enabling
+// removes the delete signal and creates keys right away. It returns nil, nil if the
+// zone does not exist for the tenant.
+func (s *dnsService) SetZoneDNSSEC(ctx context.Context, zoneID string, tenantID string, enabled bool) (*domain.Zone, error) {
+	zone, err := s.repo.GetZoneByID(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load zone: %w", err)
+	}
+	if zone == nil {
+		return nil, nil
+	}
+	if err := CheckChangeFreeze(ctx, s.repo, tenantID, zone.ID, "SET_ZONE_DNSSEC "+zone.Name); err != nil {
+		return nil, err
+	}
+	if err := CheckChangeReview(ctx, s.repo, tenantID, zone.ID, "SET_ZONE_DNSSEC "+zone.Name); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SetZoneDNSSEC(ctx, zoneID, tenantID, enabled); err != nil {
+		return nil, err
+	}
+	zone.DNSSECEnabled = &enabled
+	zone.UpdatedAt = time.Now()
+
+	action := "ENABLE_DNSSEC"
+	if enabled {
+		if err := s.removeDNSSECRecords(ctx, zone, map[domain.RecordType]bool{"CDS": true, "CDNSKEY": true}); err != nil {
+			return nil, err
+		}
+		if err := NewDNSSECService(s.repo).AutomateLifecycle(ctx, zone.ID); err != nil {
+			return nil, fmt.Errorf("failed to create DNSSEC keys: %w", err)
+		}
+	} else {
+		action = "DISABLE_DNSSEC"
+		if err := s.unsignZone(ctx, zone); err != nil {
+			return nil, err
+		}
+	}
+
+	s.audit(ctx, tenantID, action, "ZONE", zone.ID, fmt.Sprintf("Set DNSSEC for zone %s to %t", zone.Name, enabled))
+	s.publish(domain.Event{Type: domain.EventZoneChange, TenantID: tenantID, ZoneID: zone.ID, Action: action, Name: zone.Name})
+	return zone, nil
+}
+
+// unsignZone deactivates a zone's keys, removes its stored DNSSEC records and
+// publishes the CDS/CDNSKEY delete signal at the apex.
+func (s *dnsService) unsignZone(ctx context.Context, zone *domain.Zone) error {
+	keys, err := s.repo.ListKeysForZone(ctx, zone.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list DNSSEC keys: %w", err)
+	}
+	now := time.Now()
+	for i := range keys {
+		if !keys[i].Active {
+			continue
+		}
+		keys[i].Active = false
+		keys[i].UpdatedAt = now
+		if err := s.repo.UpdateKey(ctx, &keys[i]); err != nil {
+			return fmt.Errorf("failed to deactivate DNSSEC key: %w", err)
+		}
+	}
+
+	if err := s.removeDNSSECRecords(ctx, zone, dnssecRecordTypes); err != nil {
+		return err
+	}
+
+	// Algorithm 0 CDS and CDNSKEY records request removal of the DS at the parent
+	deleteSignal := []domain.Record{
+		{Type: "CDS", Content: "0 0 0 00"},
+		{Type: "CDNSKEY", Content: "0 3 0 AA=="},
+	}
+	for _, rec := range deleteSignal {
+		rec.ID = uuid.New().String()
+		rec.ZoneID = zone.ID
+		rec.TenantID = zone.TenantID
+		rec.Name = zone.Name
+		rec.TTL = 3600
+		rec.CreatedAt = now
+		rec.UpdatedAt = now
+		if err := s.repo.CreateRecord(ctx, &rec); err != nil {
+			return fmt.Errorf("failed to publish %s delete record: %w", rec.Type, err)
+		}
+		s.invalidate(ctx, rec.Name, rec.Type)
+	}
+	return nil
+}
+
+// removeDNSSECRecords deletes every stored RRset of the given types from a zone.
+func (s *dnsService) removeDNSSECRecords(ctx context.Context, zone *domain.Zone, types map[domain.RecordType]bool) error {
+	records, err := s.repo.ListRecordsForZone(ctx, zone.ID, zone.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to list records: %w", err)
+	}
+	type rrset struct {
+		name  string
+		rtype domain.RecordType
+	}
+	removed := make(map[rrset]bool)
+	for _, rec := range records {
+		key := rrset{rec.Name, rec.Type}
+		if !types[rec.Type] || removed[key] {
+			continue
+		}
+		if err := s.repo.DeleteRecordsByNameAndType(ctx, zone.ID, rec.Name, rec.Type); err != nil {
+			return fmt.Errorf("failed to remove %s records: %w", rec.Type, err)
+		}
+		removed[key] = true
+		s.invalidate(ctx, rec.Name, rec.Type)
+	}
+	return nil
+}
+
+// invalidate drops a name and type from every node's cache, if caching is shared.
+func (s *dnsService) invalidate(ctx context.Context, name string, qType domain.RecordType) {
+	if s.cache == nil {
+		return
+	}
+	if err := s.cache.Invalidate(ctx, name, qType); err != nil {
+		s.logger.Warn("failed to invalidate cache", "name", name, "type", qType, "error", err)
+	}
+}
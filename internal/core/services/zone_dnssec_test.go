@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+func TestSetZoneDNSSEC(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepo{
+		zones: []domain.Zone{{ID: "z1", TenantID: "t1", Name: "example.com."}},
+		records: []domain.Record{
+			{ID: "r1", ZoneID: "z1", Name: "example.com.", Type: domain.TypeSOA, Content: "ns1. admin. 1 3600 600 86400 300"},
+			{ID: "r2", ZoneID: "z1", Name: "example.com.", Type: "DNSKEY", Content: "257 3 13 AAAA"},
+			{ID: "r3", ZoneID: "z1", Name: "www.example.com.", Type: "RRSIG", Content: "1 13 3 300 0 0 1 example.com. AAAA"},
+			{ID: "r4", ZoneID: "z1", Name: "www.example.com.", Type: domain.TypeA, Content: "192.0.2.1"},
+		},
+		keys: []domain.DNSSECKey{{ID: "k1", ZoneID: "z1", KeyType: "KSK", Active: true}, {ID: "k2", ZoneID: "z1", KeyType: "ZSK", Active: true}},
+	}
+	svc := NewDNSService(repo, nil)
+
+	zone, err := svc.SetZoneDNSSEC(ctx, "z1", "t1", false)
+	if err != nil || zone == nil || zone.SignsDNSSEC() {
+		t.Fatalf("Expected zone returned with DNSSEC disabled, got %+v (%v)", zone, err)
+	}
+	if repo.zones[0].SignsDNSSEC() {
+		t.Error("Expected the opt-out stored")
+	}
+	for _, k := range repo.keys {
+		if k.Active {
+			t.Errorf("Expected key %s deactivated", k.ID)
+		}
+	}
+	types := map[domain.RecordType]string{}
+	for _, r := range repo.records {
+		types[r.Type] = r.Content
+	}
+	if _, ok := types["DNSKEY"]; ok {
+		t.Error("Expected DNSKEY records removed")
+	}
+	if _, ok := types["RRSIG"]; ok {
+		t.Error("Expected RRSIG records removed")
+	}
+	if types[domain.TypeA] == "" || types[domain.TypeSOA] == "" {
+		t.Error("Expected other records kept")
+	}
+	if types["CDS"] != "0 0 0 00" || types["CDNSKEY"] != "0 3 0 AA==" {
+		t.Errorf("Expected CDS/CDNSKEY delete signal published, got %v", types)
+	}
+
+	zone, err = svc.SetZoneDNSSEC(ctx, "z1", "t1", true)
+	if err != nil || !zone.SignsDNSSEC() {
+		t.Fatalf("Expected DNSSEC enabled, got %+v (%v)", zone, err)
+	}
+	for _, r := range repo.records {
+		if r.Type == "CDS" || r.Type == "CDNSKEY" {
+			t.Errorf("Expected delete signal removed, found %s", r.Type)
+		}
+	}
+	active := map[string]int{}
+	for _, k := range repo.keys {
+		if k.Active {
+			active[k.KeyType]++
+		}
+	}
+	if active["KSK"] != 1 || active["ZSK"] != 1 {
+		t.Errorf("Expected fresh KSK and ZSK, got %v", active)
+	}
+
+	if zone, err := svc.SetZoneDNSSEC(ctx, "z1", "other-tenant", false); zone != nil || err != nil {
+		t.Errorf("Expected nil for another tenant's zone, got %+v (%v)", zone, err)
+	}
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// SetZoneRole makes a zone a primary ("master") or a secondary ("slave") pulling
+// its contents from masterServer, a comma-separated list of master addresses.
+// A secondary keeps its records until the first transfer replaces them. It
+// returns nil, nil if the zone does not exist for the tenant.
+func (s *dnsService) SetZoneRole(ctx context.Context, zoneID string, tenantID string, role string, masterServer string) (*domain.Zone, error) {
+	if role == "" {
+		return nil, fmt.Errorf("role is required: %w", domain.ErrInvalidZoneRole)
+	}
+	if err := domain.ValidateZoneRole(role, masterServer); err != nil {
+		return nil, fmt.Errorf("%v: %w", err, domain.ErrInvalidZoneRole)
+	}
+	zone, err := s.repo.GetZoneByID(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load zone: %w", err)
+	}
+	if zone == nil {
+		return nil, nil
+	}
+	if err := CheckChangeFreeze(ctx, s.repo, tenantID, zone.ID, "SET_ZONE_ROLE "+zone.Name); err != nil {
+		return nil, err
+	}
+	if err := CheckChangeReview(ctx, s.repo, tenantID, zone.ID, "SET_ZONE_ROLE "+zone.Name); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SetZoneRole(ctx, zone.ID, tenantID, role, masterServer); err != nil {
+		return nil, err
+	}
+	zone.Role = role
+	zone.MasterServer = masterServer
+	zone.UpdatedAt = time.Now()
+
+	details := fmt.Sprintf("Set zone %s to %s", zone.Name, role)
+	if role == "slave" {
+		details += " of " + masterServer
+	}
+	s.audit(ctx, tenantID, "SET_ZONE_ROLE", "ZONE", zone.ID, details)
+	s.publish(domain.Event{Type: domain.EventZoneChange, TenantID: tenantID, ZoneID: zone.ID, Action: "SET_ZONE_ROLE", Name: zone.Name})
+	return zone, nil
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+func TestSetZoneRole(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepo{zones: []domain.Zone{{ID: "z1", TenantID: "t1", Name: "example.com.", Role: "master"}}}
+	svc := NewDNSService(repo, nil)
+
+	for _, tc := range [][2]string{{"", ""}, {"primary", ""}, {"slave", ""}, {"slave", "192.0.2.1:0"}} {
+		if _, err := svc.SetZoneRole(ctx, "z1", "t1", tc[0], tc[1]); !errors.Is(err, domain.ErrInvalidZoneRole) {
+			t.Errorf("Expected ErrInvalidZoneRole for %v, got %v", tc, err)
+		}
+	}
+
+	zone, err := svc.SetZoneRole(ctx, "z1", "t1", "slave", "192.0.2.1,192.0.2.2:5353")
+	if err != nil || zone == nil || zone.Role != "slave" || zone.MasterServer != "192.0.2.1,192.0.2.2:5353" {
+		t.Fatalf("Expected a secondary zone, got %+v (%v)", zone, err)
+	}
+	if repo.zones[0].Role != "slave" || repo.zones[0].MasterServer != "192.0.2.1,192.0.2.2:5353" {
+		t.Errorf("Expected the role stored, got %+v", repo.zones[0])
+	}
+	if len(repo.audits) == 0 || repo.audits[len(repo.audits)-1].Action != "SET_ZONE_ROLE" {
+		t.Error("Expected the role change audited")
+	}
+
+	if zone, err := svc.SetZoneRole(ctx, "z1", "other-tenant", "master", ""); zone != nil || err != nil {
+		t.Errorf("Expected nil for another tenant's zone, got %+v (%v)", zone, err)
+	}
+}
+
+func TestCreateSecondaryZoneHasNoRecords(t *testing.T) {
+	repo := &mockRepo{}
+	svc := NewDNSService(repo, nil)
+	zone := &domain.Zone{TenantID: "t1", Name: "secondary.test", Role: "slave", MasterServer: "192.0.2.1"}
+	if err := svc.CreateZone(context.Background(), zone); err != nil {
+		t.Fatalf("CreateZone failed: %v", err)
+	}
+	if len(repo.zones) != 1 || len(repo.records) != 0 {
+		t.Errorf("Expected a secondary without local records, got %+v", repo.records)
+	}
+}
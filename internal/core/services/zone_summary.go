@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/serial"
+)
+
+// rrFixedOverhead is the TYPE, CLASS, TTL and RDLENGTH fields of a wire RR.
+const rrFixedOverhead = 10
+
+// GetZoneSummary aggregates record counts, SOA serial, DNSSEC state, the time of the
+// last change, transfer status and size estimates for a zone. Transfer status is
+// the zone's role, the last refresh from its masters for a secondary zone, and
+// the serials its external secondaries were last seen serving. It returns nil,
+// nil if the zone does not exist for the tenant.
+func (s *dnsService) GetZoneSummary(ctx context.Context, zoneID string, tenantID string) (*domain.ZoneSummary, error) {
+	zone, err := s.repo.GetZoneByID(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load zone: %w", err)
+	}
+	if zone == nil {
+		return nil, nil
+	}
+
+	records, err := s.repo.ListRecordsForZone(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list records: %w", err)
+	}
+
+	role := zone.Role
+	if role == "" {
+		role = "master"
+	}
+	summary := &domain.ZoneSummary{
+		ZoneID:       zone.ID,
+		ZoneName:     zone.Name,
+		RecordCounts: make(map[domain.RecordType]int),
+		TotalRecords: len(records),
+		Role:         role,
+		MasterServer: zone.MasterServer,
+	}
+	for _, rec := range records {
+		summary.RecordCounts[rec.Type]++
+		summary.EstimatedWireBytes += estimateWireSize(rec)
+		summary.EstimatedRowBytes += len(rec.ID) + len(rec.Name) + len(rec.Type) + len(rec.Content)
+		if rec.Network != nil {
+			summary.EstimatedRowBytes += len(*rec.Network)
+		}
+		if rec.Type == domain.TypeSOA {
+			summary.Serial = soaSerial(rec.Content)
+		}
+	}
+
+	keys, err := s.repo.ListKeysForZone(ctx, zoneID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list DNSSEC keys: %w", err)
+	}
+	for _, k := range keys {
+		if k.Active {
+			summary.ActiveKeys++
+		}
+	}
+	summary.DNSSECEnabled = zone.SignsDNSSEC() && summary.ActiveKeys > 0
+
+	summary.LastChangeAt, err = s.repo.LastZoneChangeAt(ctx, zoneID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load last zone change: %w", err)
+	}
+	if summary.LastChangeAt == nil && !zone.UpdatedAt.IsZero() {
+		t := zone.UpdatedAt
+		summary.LastChangeAt = &t
+	}
+
+	if role == "slave" {
+		if summary.LastRefresh, err = s.repo.GetZoneRefresh(ctx, zoneID); err != nil {
+			return nil, fmt.Errorf("failed to load zone refresh: %w", err)
+		}
+	}
+	secondaries, err := s.repo.ListSecondaryProviders(ctx, zoneID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secondaries: %w", err)
+	}
+	for i := range secondaries {
+		secondaries[i].InSync = secondaries[i].LastCheckedAt != nil && secondaries[i].ObservedSerial == summary.Serial
+	}
+	summary.Secondaries = secondaries
+
+	return summary, nil
+}
+
+// soaSerial extracts the serial from SOA content ("mname rname serial ...").
+// It returns 0 for malformed content.
+func soaSerial(content string) uint32 {
+	s, _ := serial.FromSOA(content)
+	return s
+}
+
+// estimateWireSize approximates the uncompressed wire size of a record, which is an
+// upper bound on what it contributes to a zone transfer.
+func estimateWireSize(rec domain.Record) int {
+	size := nameWireLen(rec.Name) + rrFixedOverhead
+	switch rec.Type {
+	case domain.TypeA:
+		size += 4
+	case domain.TypeAAAA:
+		size += 16
+	case domain.TypeCNAME, domain.TypeNS, domain.TypePTR:
+		size += nameWireLen(rec.Content)
+	case domain.TypeMX:
+		size += 2 + nameWireLen(rec.Content)
+	case domain.TypeSRV:
+		size += 6 + nameWireLen(rec.Content)
+	case domain.TypeSVCB, domain.TypeHTTPS:
+		// Presentation-format SvcParams are never shorter than their wire form
+		size += 2 + len(rec.Content) + 1
+	case domain.TypeTXT:
+		// One length octet per 255-byte character-string
+		size += len(rec.Content) + len(rec.Content)/255 + 1
+	case domain.TypeSOA:
+		size += len(rec.Content) + 20
+	default:
+		size += len(rec.Content)
+	}
+	return size
+}
+
+// nameWireLen is the length of name in uncompressed wire format.
+func nameWireLen(name string) int {
+	name = strings.TrimSuffix(name, ".")
+	if name == "" {
+		return 1
+	}
+	return len(name) + 2
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+func TestGetZoneSummary(t *testing.T) {
+	updated := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	repo := &mockRepo{
+		zones: []domain.Zone{{ID: "z1", TenantID: "t1", Name: "example.com.", UpdatedAt: updated}},
+		records: []domain.Record{
+			{ID: "r1", ZoneID: "z1", Name: "example.com.", Type: domain.TypeSOA, Content: "ns1.example.com. admin.example.com. 2026010201 3600 600 86400 300"},
+			{ID: "r2", ZoneID: "z1", Name: "www.example.com.", Type: domain.TypeA, Content: "1.2.3.4"},
+			{ID: "r3", ZoneID: "z1", Name: "api.example.com.", Type: domain.TypeA, Content: "1.2.3.5"},
+			{ID: "r4", ZoneID: "z1", Name: "example.com.", Type: domain.TypeMX, Content: "mail.example.com."},
+			{ID: "r5", ZoneID: "other", Name: "x.other.", Type: domain.TypeA, Content: "9.9.9.9"},
+		},
+	}
+	svc := NewDNSService(repo, nil)
+
+	summary, err := svc.GetZoneSummary(context.Background(), "z1", "t1")
+	if err != nil {
+		t.Fatalf("GetZoneSummary failed: %v", err)
+	}
+	if summary.TotalRecords != 4 || summary.RecordCounts[domain.TypeA] != 2 || summary.RecordCounts[domain.TypeMX] != 1 {
+		t.Errorf("Unexpected counts: %+v", summary)
+	}
+	if summary.Serial != 2026010201 {
+		t.Errorf("Expected serial 2026010201, got %d", summary.Serial)
+	}
+	if summary.Role != "master" || summary.DNSSECEnabled {
+		t.Errorf("Unexpected role/DNSSEC state: %+v", summary)
+	}
+	if summary.LastChangeAt == nil || !summary.LastChangeAt.Equal(updated) {
+		t.Errorf("Expected last change to fall back to zone update time, got %v", summary.LastChangeAt)
+	}
+	// www.example.com. A: 17 (name) + 10 + 4
+	if summary.EstimatedWireBytes <= 31 {
+		t.Errorf("Size estimate too small: %d", summary.EstimatedWireBytes)
+	}
+
+	// Wrong tenant hides the zone
+	summary, err = svc.GetZoneSummary(context.Background(), "z1", "t2")
+	if err != nil || summary != nil {
+		t.Errorf("Expected nil summary for other tenant, got %+v, %v", summary, err)
+	}
+
+	repo.err = errors.New("db down")
+	if _, err = svc.GetZoneSummary(context.Background(), "z1", "t1"); err == nil {
+		t.Error("Expected error from repository failure")
+	}
+}
+
+func TestGetZoneSummaryTransferStatus(t *testing.T) {
+	changed := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	checked := changed.Add(time.Minute)
+	repo := &mockRepo{
+		zones: []domain.Zone{{ID: "z1", TenantID: "t1", Name: "example.com.", Role: "slave", MasterServer: "192.0.2.53:53", UpdatedAt: changed.Add(-time.Hour)}},
+		records: []domain.Record{
+			{ID: "r1", ZoneID: "z1", Name: "example.com.", Type: domain.TypeSOA, Content: "ns1.example.com. admin.example.com. 42 3600 600 86400 300"},
+		},
+		changes: []domain.ZoneChange{
+			{ZoneID: "z1", Serial: 41, CreatedAt: changed.Add(-time.Minute)},
+			{ZoneID: "z1", Serial: 42, CreatedAt: changed},
+			{ZoneID: "other", Serial: 43, CreatedAt: changed.Add(time.Hour)},
+		},
+		secondaries: []domain.SecondaryProvider{
+			{ID: "s1", ZoneID: "z1", Name: "bind", ObservedSerial: 42, LastCheckedAt: &checked},
+			{ID: "s2", ZoneID: "z1", Name: "nsd", ObservedSerial: 41, LastCheckedAt: &checked},
+		},
+	}
+	svc := NewDNSService(repo, nil)
+	if err := repo.SaveZoneRefresh(context.Background(), &domain.ZoneRefresh{ZoneID: "z1", AttemptedAt: checked, Serial: 42, Error: "master timed out"}); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := svc.GetZoneSummary(context.Background(), "z1", "t1")
+	if err != nil {
+		t.Fatalf("GetZoneSummary failed: %v", err)
+	}
+	if summary.LastChangeAt == nil || !summary.LastChangeAt.Equal(changed) {
+		t.Errorf("Expected the newest journal entry of the zone, got %v", summary.LastChangeAt)
+	}
+	if summary.LastRefresh == nil || summary.LastRefresh.Error != "master timed out" || summary.LastRefresh.Serial != 42 {
+		t.Errorf("Expected the last refresh, got %+v", summary.LastRefresh)
+	}
+	if len(summary.Secondaries) != 2 || !summary.Secondaries[0].InSync || summary.Secondaries[1].InSync {
+		t.Errorf("Expected the secondaries with their sync state, got %+v", summary.Secondaries)
+	}
+}
+
+func TestEstimateWireSize(t *testing.T) {
+	rec := domain.Record{Name: "www.example.com.", Type: domain.TypeA, Content: "1.2.3.4"}
+	if got := estimateWireSize(rec); got != 17+10+4 {
+		t.Errorf("A record size = %d, want 31", got)
+	}
+	if got := soaSerial("ns. admin. notanumber 1 2 3 4"); got != 0 {
+		t.Errorf("soaSerial on malformed content = %d, want 0", got)
+	}
+}
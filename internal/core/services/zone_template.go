@@ -0,0 +1,77 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// zoneTemplateVar matches a {{name}} placeholder in a zone file template.
+var zoneTemplateVar = regexp.MustCompile(`\{\{\s*([^{}\s]*)\s*\}\}`)
+
+// zoneTemplateName is the form of a template variable's name.
+var zoneTemplateName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// expandZoneTemplate replaces the {{name}} placeholders of a zone file with
+// their values from vars and returns the expanded file along with the names of
+// the variables it used, sorted. A file without placeholders is returned as it
+// is. Values are confined to one field of one line: they cannot hold line
+// breaks, comments or parentheses, so a variable cannot add records or
+// directives to the zone.
+func expandZoneTemplate(r io.Reader, vars map[string]string) (io.Reader, []string, error) {
+	for name, value := range vars {
+		if !zoneTemplateName.MatchString(name) {
+			return nil, nil, fmt.Errorf("%w: invalid template variable name %q", domain.ErrInvalidZoneImport, name)
+		}
+		if strings.ContainsAny(value, ";()\\\"") || strings.IndexFunc(value, func(c rune) bool { return c < ' ' || c == 0x7f }) >= 0 {
+			return nil, nil, fmt.Errorf("%w: template variable %q has characters not allowed in a zone file field", domain.ErrInvalidZoneImport, name)
+		}
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read zone file: %w", err)
+	}
+	if !bytes.Contains(data, []byte("{{")) {
+		return bytes.NewReader(data), nil, nil
+	}
+
+	used := make(map[string]bool)
+	missing := make(map[string]bool)
+	malformed := false
+	expanded := zoneTemplateVar.ReplaceAllFunc(data, func(m []byte) []byte {
+		name := string(zoneTemplateVar.FindSubmatch(m)[1])
+		if !zoneTemplateName.MatchString(name) {
+			malformed = true
+			return m
+		}
+		value, ok := vars[name]
+		if !ok {
+			missing[name] = true
+			return m
+		}
+		used[name] = true
+		return []byte(value)
+	})
+	if len(missing) > 0 {
+		return nil, nil, fmt.Errorf("%w: no value for template variables %s", domain.ErrInvalidZoneImport, strings.Join(sortedKeys(missing), ", "))
+	}
+	if malformed || bytes.Contains(expanded, []byte("{{")) {
+		return nil, nil, fmt.Errorf("%w: malformed template placeholder", domain.ErrInvalidZoneImport)
+	}
+	return bytes.NewReader(expanded), sortedKeys(used), nil
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+const zoneTemplate = `
+$ORIGIN {{env}}.example.test.
+$TTL 3600
+@   IN  SOA ns1.example.test. admin.example.test. 1 2 3 4 5
+www IN  A   {{ lb_ip }}
+txt IN  TXT "env={{env}}"
+`
+
+func TestImportZoneTemplate(t *testing.T) {
+	repo := &mockRepo{}
+	svc := NewDNSService(repo, nil)
+	ctx := context.Background()
+
+	for _, env := range []struct{ name, ip string }{{"staging", "10.0.1.10"}, {"prod", "10.0.2.10"}} {
+		zone, err := svc.ImportZone(ctx, "t1", strings.NewReader(zoneTemplate), map[string]string{"env": env.name, "lb_ip": env.ip, "unused": "x"})
+		if err != nil {
+			t.Fatalf("ImportZone(%s) failed: %v", env.name, err)
+		}
+		if zone.Name != env.name+".example.test." {
+			t.Errorf("Expected zone %s.example.test., got %s", env.name, zone.Name)
+		}
+		found := 0
+		for _, rec := range repo.records {
+			if rec.ZoneID != zone.ID {
+				continue
+			}
+			switch {
+			case rec.Type == domain.TypeA && rec.Content == env.ip:
+				found++
+			case rec.Type == domain.TypeTXT && strings.Contains(rec.Content, "env="+env.name):
+				found++
+			}
+		}
+		if found != 2 {
+			t.Errorf("Expected the %s values substituted, got %+v", env.name, repo.records)
+		}
+	}
+	if len(repo.audits) == 0 || !strings.Contains(repo.audits[len(repo.audits)-1].Details, "template variables: env, lb_ip") {
+		t.Errorf("Expected the variables used in the audit log, got %+v", repo.audits)
+	}
+}
+
+func TestImportZoneTemplate_Errors(t *testing.T) {
+	svc := NewDNSService(&mockRepo{}, nil)
+	ctx := context.Background()
+
+	tests := map[string]struct {
+		file string
+		vars map[string]string
+		want string
+	}{
+		"missing":      {zoneTemplate, map[string]string{"env": "dev"}, "lb_ip"},
+		"no vars":      {zoneTemplate, nil, "env, lb_ip"},
+		"newline":      {zoneTemplate, map[string]string{"env": "dev", "lb_ip": "10.0.0.1\nevil IN A 10.6.6.6"}, "lb_ip"},
+		"comment":      {zoneTemplate, map[string]string{"env": "dev", "lb_ip": "10.0.0.1 ; x"}, "lb_ip"},
+		"bad name":     {zoneTemplate, map[string]string{"env": "dev", "lb_ip": "10.0.0.1", "a b": "x"}, "a b"},
+		"malformed":    {"$ORIGIN x.test.\nwww A {{ lb ip }}\n", map[string]string{"lb": "10.0.0.1"}, "malformed"},
+		"unterminated": {"$ORIGIN x.test.\nwww A {{lb_ip\n", map[string]string{"lb_ip": "10.0.0.1"}, "malformed"},
+	}
+	for name, tt := range tests {
+		_, err := svc.ImportZone(ctx, "t1", strings.NewReader(tt.file), tt.vars)
+		if !errors.Is(err, domain.ErrInvalidZoneImport) || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected ErrInvalidZoneImport mentioning %q, got %v", name, tt.want, err)
+		}
+	}
+}
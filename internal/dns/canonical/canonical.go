@@ -0,0 +1,97 @@
+// Package canonical implements the canonical DNS name and RR ordering of
+// RFC 4034, Section 6, shared by NSEC chain generation and zone transfer output.
+//
+// Labels are compared as unescaped octet strings with only ASCII letters folded to
+// lowercase, so escaped dots, "\DDD" binary octets and punycode ("xn--") labels all
+// sort the way a validating resolver expects. Non-ASCII bytes are never case-folded.
+package canonical
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+// CompareNames orders two domain names canonically: labels are compared from the
+// rightmost one, each as a lowercased octet string, and a name sorts before any
+// name below it. It returns -1, 0 or +1.
+func CompareNames(a, b string) int {
+	aLabels := dnsname.SplitLabels(a)
+	bLabels := dnsname.SplitLabels(b)
+
+	i := len(aLabels) - 1
+	j := len(bLabels) - 1
+	for i >= 0 && j >= 0 {
+		if cmp := bytes.Compare(labelKey(aLabels[i]), labelKey(bLabels[j])); cmp != 0 {
+			return cmp
+		}
+		i--
+		j--
+	}
+
+	switch {
+	case len(aLabels) < len(bLabels):
+		return -1
+	case len(aLabels) > len(bLabels):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// labelKey returns the wire octets of a label with ASCII uppercase letters folded.
+func labelKey(label string) []byte {
+	raw := dnsname.UnescapeLabel(label)
+	for i, c := range raw {
+		if c >= 'A' && c <= 'Z' {
+			raw[i] = c + ('a' - 'A')
+		}
+	}
+	return raw
+}
+
+// SortNames sorts names in canonical order.
+func SortNames(names []string) {
+	sort.SliceStable(names, func(i, j int) bool {
+		return CompareNames(names[i], names[j]) < 0
+	})
+}
+
+// CompareRecords orders records by owner name, then by RR type code, then by
+// content so that output is deterministic within an RRset.
+func CompareRecords(a, b domain.Record) int {
+	if cmp := CompareNames(a.Name, b.Name); cmp != 0 {
+		return cmp
+	}
+	if ta, tb := packet.RecordTypeToQueryType(a.Type), packet.RecordTypeToQueryType(b.Type); ta != tb {
+		if ta < tb {
+			return -1
+		}
+		return 1
+	}
+	if a.Type != b.Type {
+		// Types without a known code compare by mnemonic
+		if a.Type < b.Type {
+			return -1
+		}
+		return 1
+	}
+	switch {
+	case a.Content < b.Content:
+		return -1
+	case a.Content > b.Content:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// SortRecords sorts records in canonical order.
+func SortRecords(records []domain.Record) {
+	sort.SliceStable(records, func(i, j int) bool {
+		return CompareRecords(records[i], records[j]) < 0
+	})
+}
@@ -0,0 +1,75 @@
+package canonical
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// rfc4034Order is the example ordering from RFC 4034, Section 6.1.
+var rfc4034Order = []string{
+	"example.",
+	"a.example.",
+	"yljkjljk.a.example.",
+	"Z.a.example.",
+	"zABC.a.EXAMPLE.",
+	"z.example.",
+	`\001.z.example.`,
+	"*.z.example.",
+	`\200.z.example.`,
+}
+
+func TestSortNames_RFC4034Example(t *testing.T) {
+	names := make([]string, len(rfc4034Order))
+	copy(names, rfc4034Order)
+	r := rand.New(rand.NewSource(1))
+	r.Shuffle(len(names), func(i, j int) { names[i], names[j] = names[j], names[i] })
+
+	SortNames(names)
+	if !reflect.DeepEqual(names, rfc4034Order) {
+		t.Errorf("unexpected canonical order:\n got %q\nwant %q", names, rfc4034Order)
+	}
+}
+
+func TestCompareNames(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"Example.COM.", "example.com", 0},
+		{"example.com.", "www.example.com.", -1},
+		{`a\.b.example.`, "b.example.", -1}, // single label "a.b" under example.
+		{`a\.b.example.`, "a.example.", 1},
+		{"xn--bcher-kva.example.", "zz.example.", -1},
+		{`\195\164.example.`, "z.example.", 1}, // non-ASCII octets are not case-folded
+		{".", "com.", -1},
+	}
+	for _, tc := range cases {
+		if got := CompareNames(tc.a, tc.b); got != tc.want {
+			t.Errorf("CompareNames(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestSortRecords(t *testing.T) {
+	records := []domain.Record{
+		{Name: "www.example.com.", Type: domain.TypeAAAA, Content: "::1"},
+		{Name: "www.example.com.", Type: domain.TypeA, Content: "192.0.2.2"},
+		{Name: "example.com.", Type: domain.TypeNS, Content: "ns1.example.com."},
+		{Name: "www.example.com.", Type: domain.TypeA, Content: "192.0.2.1"},
+		{Name: "example.com.", Type: domain.TypeSOA, Content: "ns1 admin 1 2 3 4 5"},
+	}
+	SortRecords(records)
+
+	want := []string{"example.com./NS", "example.com./SOA", "www.example.com./A", "www.example.com./A", "www.example.com./AAAA"}
+	for i, r := range records {
+		if got := r.Name + "/" + string(r.Type); got != want[i] {
+			t.Errorf("position %d: got %s, want %s", i, got, want[i])
+		}
+	}
+	if records[2].Content != "192.0.2.1" {
+		t.Errorf("expected RRset members ordered by content, got %s first", records[2].Content)
+	}
+}
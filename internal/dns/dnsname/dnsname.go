@@ -0,0 +1,162 @@
+// Package dnsname provides canonical handling of DNS domain names in presentation
+// format: FQDN enforcement, case folding, comparison and label splitting.
+//
+// Names are compared case-insensitively (RFC 4343) and the trailing root dot is
+// optional on input but always present on output.
+package dnsname
+
+import (
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Root is the name of the DNS root.
+const Root = "."
+
+// Fqdn returns name with a trailing dot, preserving its case. An empty name is
+// treated as the root.
+func Fqdn(name string) string {
+	if name == "" {
+		return Root
+	}
+	if IsFqdn(name) {
+		return name
+	}
+	return name + "."
+}
+
+// IsFqdn reports whether name ends with an unescaped root dot.
+func IsFqdn(name string) bool {
+	if !strings.HasSuffix(name, ".") {
+		return false
+	}
+	// Count the backslashes before the final dot: an odd number escapes it.
+	backslashes := 0
+	for i := len(name) - 2; i >= 0 && name[i] == '\\'; i-- {
+		backslashes++
+	}
+	return backslashes%2 == 0
+}
+
+// Normalize returns the canonical form of name used for lookups, map keys and
+// cache keys: lowercased and fully qualified.
+func Normalize(name string) string {
+	return strings.ToLower(Fqdn(name))
+}
+
+// Equal reports whether a and b are the same domain name, ignoring case and the
+// presence of the trailing dot.
+func Equal(a, b string) bool {
+	return strings.EqualFold(Fqdn(a), Fqdn(b))
+}
+
+// IsSubdomain reports whether child is equal to or below parent.
+func IsSubdomain(child, parent string) bool {
+	c := Normalize(child)
+	p := Normalize(parent)
+	if p == Root || c == p {
+		return true
+	}
+	return strings.HasSuffix(c, "."+p) && IsFqdn(c[:len(c)-len(p)])
+}
+
+// SplitLabels returns the labels of name from left to right, without the root.
+// Escaped dots ("\.") do not split labels and are kept escaped. The root name
+// yields no labels.
+func SplitLabels(name string) []string {
+	name = Fqdn(name)
+	if name == Root {
+		return nil
+	}
+	var labels []string
+	start := 0
+	for i := 0; i < len(name); i++ {
+		switch name[i] {
+		case '\\':
+			i++ // skip the escaped character
+		case '.':
+			labels = append(labels, name[start:i])
+			start = i + 1
+		}
+	}
+	return labels
+}
+
+// UnescapeLabel converts a presentation-format label into its wire octets,
+// decoding "\X" and "\DDD" escapes (RFC 1035, Section 5.1). Malformed escapes are
+// kept literally.
+func UnescapeLabel(label string) []byte {
+	if !strings.Contains(label, "\\") {
+		return []byte(label)
+	}
+	out := make([]byte, 0, len(label))
+	for i := 0; i < len(label); i++ {
+		c := label[i]
+		if c != '\\' || i+1 >= len(label) {
+			out = append(out, c)
+			continue
+		}
+		if i+3 < len(label) && isDigit(label[i+1]) && isDigit(label[i+2]) && isDigit(label[i+3]) {
+			v := int(label[i+1]-'0')*100 + int(label[i+2]-'0')*10 + int(label[i+3]-'0')
+			if v <= 255 {
+				out = append(out, byte(v))
+				i += 3
+				continue
+			}
+		}
+		out = append(out, label[i+1])
+		i++
+	}
+	return out
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// CountLabels returns the number of labels in name, excluding the root.
+func CountLabels(name string) int {
+	return len(SplitLabels(name))
+}
+
+// Parent returns name with its leftmost label removed. The parent of a top-level
+// name is the root, and the root has no parent (ok is false).
+func Parent(name string) (parent string, ok bool) {
+	labels := SplitLabels(name)
+	if len(labels) == 0 {
+		return "", false
+	}
+	return Join(labels[1:]), true
+}
+
+// Join builds a fully qualified name from labels.
+func Join(labels []string) string {
+	if len(labels) == 0 {
+		return Root
+	}
+	return strings.Join(labels, ".") + "."
+}
+
+// ReverseAddr returns the PTR owner name of ip under in-addr.arpa. or ip6.arpa.
+// (RFC 1035 3.5, RFC 3596 2.5). ok is false if ip is not a valid address.
+func ReverseAddr(ip net.IP) (name string, ok bool) {
+	if v4 := ip.To4(); v4 != nil {
+		return strconv.Itoa(int(v4[3])) + "." + strconv.Itoa(int(v4[2])) + "." +
+			strconv.Itoa(int(v4[1])) + "." + strconv.Itoa(int(v4[0])) + ".in-addr.arpa.", true
+	}
+	v6 := ip.To16()
+	if v6 == nil {
+		return "", false
+	}
+	const hexDigits = "0123456789abcdef"
+	var b strings.Builder
+	for i := len(v6) - 1; i >= 0; i-- {
+		b.WriteByte(hexDigits[v6[i]&0x0f])
+		b.WriteByte('.')
+		b.WriteByte(hexDigits[v6[i]>>4])
+		b.WriteByte('.')
+	}
+	b.WriteString("ip6.arpa.")
+	return b.String(), true
+}
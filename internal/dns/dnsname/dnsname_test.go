@@ -0,0 +1,130 @@
+package dnsname
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestFqdnAndNormalize(t *testing.T) {
+	cases := []struct {
+		in, fqdn, norm string
+	}{
+		{"", ".", "."},
+		{".", ".", "."},
+		{"Example.COM", "Example.COM.", "example.com."},
+		{"example.com.", "example.com.", "example.com."},
+		{`a\.`, `a\..`, `a\..`},
+		{`a\\.`, `a\\.`, `a\\.`},
+	}
+	for _, tc := range cases {
+		if got := Fqdn(tc.in); got != tc.fqdn {
+			t.Errorf("Fqdn(%q) = %q, want %q", tc.in, got, tc.fqdn)
+		}
+		if got := Normalize(tc.in); got != tc.norm {
+			t.Errorf("Normalize(%q) = %q, want %q", tc.in, got, tc.norm)
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	if !Equal("WWW.Example.com", "www.example.com.") {
+		t.Error("expected names to be equal")
+	}
+	if Equal("www.example.com.", "example.com.") {
+		t.Error("expected names to differ")
+	}
+}
+
+func TestIsSubdomain(t *testing.T) {
+	cases := []struct {
+		child, parent string
+		want          bool
+	}{
+		{"www.example.com.", "example.com.", true},
+		{"example.com", "EXAMPLE.com.", true},
+		{"badexample.com.", "example.com.", false},
+		{`www\.example.com.`, "example.com.", false},
+		{`a.b\.example.com.`, "example.com.", false},
+		{`a.b\.example.com.`, `b\.example.com.`, true},
+		{`x\.example.com.`, `\.example.com.`, false},
+		{"anything.", ".", true},
+		{"example.com.", "www.example.com.", false},
+	}
+	for _, tc := range cases {
+		if got := IsSubdomain(tc.child, tc.parent); got != tc.want {
+			t.Errorf("IsSubdomain(%q, %q) = %v, want %v", tc.child, tc.parent, got, tc.want)
+		}
+	}
+}
+
+func TestSplitLabels(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []string
+	}{
+		{".", nil},
+		{"", nil},
+		{"com", []string{"com"}},
+		{"www.Example.com.", []string{"www", "Example", "com"}},
+		{`a\.b.example.`, []string{`a\.b`, "example"}},
+		{`a\\.b.`, []string{`a\\`, "b"}},
+	}
+	for _, tc := range cases {
+		if got := SplitLabels(tc.in); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("SplitLabels(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+	if n := CountLabels("a.b.c."); n != 3 {
+		t.Errorf("CountLabels = %d, want 3", n)
+	}
+}
+
+func TestParentAndJoin(t *testing.T) {
+	p, ok := Parent("www.example.com.")
+	if !ok || p != "example.com." {
+		t.Errorf("Parent = %q, %v", p, ok)
+	}
+	p, ok = Parent("com.")
+	if !ok || p != "." {
+		t.Errorf("Parent of TLD = %q, %v", p, ok)
+	}
+	if _, ok = Parent("."); ok {
+		t.Error("root must have no parent")
+	}
+	if got := Join([]string{"*", "example", "com"}); got != "*.example.com." {
+		t.Errorf("Join = %q", got)
+	}
+}
+
+func TestUnescapeLabel(t *testing.T) {
+	cases := map[string][]byte{
+		"www":    []byte("www"),
+		`a\.b`:   []byte("a.b"),
+		`\000x`:  {0, 'x'},
+		`\200`:   {200},
+		`\\`:     []byte(`\`),
+		`\999`:   []byte("999"),
+		`trail\`: []byte(`trail\`),
+	}
+	for in, want := range cases {
+		if got := UnescapeLabel(in); !reflect.DeepEqual(got, want) {
+			t.Errorf("UnescapeLabel(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestReverseAddr(t *testing.T) {
+	cases := map[string]string{
+		"192.0.2.10":  "10.2.0.192.in-addr.arpa.",
+		"2001:db8::1": "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.",
+	}
+	for in, want := range cases {
+		if got, ok := ReverseAddr(net.ParseIP(in)); !ok || got != want {
+			t.Errorf("ReverseAddr(%s) = %q, %v, want %q", in, got, ok, want)
+		}
+	}
+	if _, ok := ReverseAddr(nil); ok {
+		t.Error("ReverseAddr(nil) must fail")
+	}
+}
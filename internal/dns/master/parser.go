@@ -4,11 +4,12 @@ package master
 import (
 	"bufio"
 	"io"
-	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/canonical"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
 )
 
 // MasterParser implements a parser for DNS master zone files.
@@ -89,7 +90,7 @@ func (p *MasterParser) Parse(r io.Reader) (*ZoneData, error) {
 			switch strings.ToUpper(parts[0]) {
 			case "$ORIGIN":
 				p.Origin = parts[1]
-				if !strings.HasSuffix(p.Origin, ".") { p.Origin += "." }
+				p.Origin = dnsname.Fqdn(p.Origin)
 				data.Zone.Name = p.Origin
 			case "$TTL":
 				ttl, _ := strconv.Atoi(parts[1])
@@ -109,7 +110,7 @@ func (p *MasterParser) Parse(r io.Reader) (*ZoneData, error) {
 			fields = fields[1:]
 			if name == "@" {
 				name = p.Origin
-			} else if !strings.HasSuffix(name, ".") && p.Origin != "" {
+			} else if !dnsname.IsFqdn(name) && p.Origin != "" {
 				name = name + "." + p.Origin
 			}
 			lastName = name
@@ -147,41 +148,18 @@ func (p *MasterParser) Parse(r io.Reader) (*ZoneData, error) {
 	return data, scanner.Err()
 }
 
-// RFC 4034 Section 6.1: Canonical DNS Name Order
+// CompareNamesCanonically orders names per RFC 4034 Section 6.1.
+//
+// Deprecated: use canonical.CompareNames.
 func CompareNamesCanonically(a, b string) int {
-	a = strings.TrimSuffix(strings.ToLower(a), ".")
-	b = strings.TrimSuffix(strings.ToLower(b), ".")
-
-	if a == b { return 0 }
-	if a == "" { return -1 }
-	if b == "" { return 1 }
-
-	aLabels := strings.Split(a, ".")
-	bLabels := strings.Split(b, ".")
-
-	i := len(aLabels) - 1
-	j := len(bLabels) - 1
-
-	for i >= 0 && j >= 0 {
-		if aLabels[i] < bLabels[j] { return -1 }
-		if aLabels[i] > bLabels[j] { return 1 }
-		i--
-		j--
-	}
-
-	if len(aLabels) < len(bLabels) { return -1 }
-	if len(aLabels) > len(bLabels) { return 1 }
-	return 0
+	return canonical.CompareNames(a, b)
 }
 
+// SortRecordsCanonically sorts records per RFC 4034 Section 6.
+//
+// Deprecated: use canonical.SortRecords.
 func SortRecordsCanonically(records []domain.Record) {
-	sort.Slice(records, func(i, j int) bool {
-		cmp := CompareNamesCanonically(records[i].Name, records[j].Name)
-		if cmp == 0 {
-			return records[i].Type < records[j].Type
-		}
-		return cmp < 0
-	})
+	canonical.SortRecords(records)
 }
 
 func RecordTypeToQueryType(t domain.RecordType) uint16 {
@@ -194,6 +172,15 @@ func RecordTypeToQueryType(t domain.RecordType) uint16 {
 	case domain.TypeTXT: return 16
 	case domain.TypeAAAA: return 28
 	case domain.TypePTR: return 12
+	case domain.TypeSVCB: return 64
+	case domain.TypeHTTPS: return 65
+	case domain.TypeCAA: return 257
+	case domain.TypeNAPTR: return 35
+	case domain.TypeTLSA: return 52
+	case domain.TypeSSHFP: return 44
+	case domain.TypeLOC: return 29
+	case domain.TypeURI: return 256
+	case domain.TypeCERT: return 37
 	default: return 0
 	}
 }
@@ -332,6 +332,21 @@ func (b *BytePacketBuffer) WriteName(name string) error {
 	}
 }
 
+// Truncate discards everything written from pos on, forgetting the names
+// recorded there for compression, so a write that did not fit can be undone.
+func (b *BytePacketBuffer) Truncate(pos int) {
+	if pos < 0 || pos > b.Pos {
+		return
+	}
+	for name, at := range b.names {
+		if at >= pos {
+			delete(b.names, name)
+		}
+	}
+	b.Pos = pos
+	b.Len = pos
+}
+
 // WriteRange writes a slice of bytes at a specific position
 func (b *BytePacketBuffer) WriteRange(start int, data []byte) error {
 	if start+len(data) > MaxPacketSize {
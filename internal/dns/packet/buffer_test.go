@@ -92,3 +92,32 @@ func TestBuffer_ReadErrors(t *testing.T) {
 		t.Errorf("ReadRange out of bounds should fail")
 	}
 }
+
+func TestBufferTruncate(t *testing.T) {
+	buf := NewBytePacketBuffer()
+	buf.HasNames = true
+	_ = buf.WriteName("www.example.com.")
+	mark := buf.Position()
+	_ = buf.WriteName("mail.other.org.")
+
+	buf.Truncate(mark)
+	if buf.Position() != mark || buf.Len != mark {
+		t.Fatalf("expected position %d after truncate, got %d", mark, buf.Position())
+	}
+	// A name only written after the mark must not be compressed against it
+	_ = buf.WriteName("other.org.")
+	if buf.Position() != mark+len("other.org.")+1 {
+		t.Errorf("expected other.org. to be written in full, position %d", buf.Position())
+	}
+	// Names before the mark still compress
+	end := buf.Position()
+	_ = buf.WriteName("example.com.")
+	if buf.Position() != end+2 {
+		t.Errorf("expected example.com. to be a pointer, wrote %d bytes", buf.Position()-end)
+	}
+
+	buf.Truncate(end + 100)
+	if buf.Position() != end+2 {
+		t.Errorf("truncating past the end should be a no-op")
+	}
+}
@@ -0,0 +1,40 @@
+package packet
+
+import (
+	"testing"
+)
+
+func TestCAARecord_ReadWrite(t *testing.T) {
+	buffer := NewBytePacketBuffer()
+	original := DNSRecord{Name: "example.com.", Type: CAA, Class: 1, TTL: 3600, Flags: 128, Tag: "issue", Value: "ca.example.net; account=230123"}
+	if _, err := original.Write(buffer); err != nil {
+		t.Fatalf("Failed to write CAA record: %v", err)
+	}
+
+	buffer.Pos = 0
+	var decoded DNSRecord
+	if err := decoded.Read(buffer); err != nil {
+		t.Fatalf("Failed to read CAA record: %v", err)
+	}
+	if decoded.Type != CAA || decoded.Flags != 128 || decoded.Tag != "issue" || decoded.Value != original.Value {
+		t.Errorf("Unexpected CAA record %+v", decoded)
+	}
+	if CAA.String() != "CAA" {
+		t.Errorf("Unexpected name %s", CAA)
+	}
+}
+
+func TestCAARecord_ReadTagOverrun(t *testing.T) {
+	buffer := NewBytePacketBuffer()
+	rec := DNSRecord{Name: "example.com.", Type: CAA, Class: 1, Tag: "issue", Value: "x"}
+	if _, err := rec.Write(buffer); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	// Claim a tag longer than the RDATA
+	buffer.Buf[buffer.Position()-7] = 9
+	buffer.Pos = 0
+	var decoded DNSRecord
+	if err := decoded.Read(buffer); err == nil {
+		t.Error("Expected a tag overrunning the RDATA to be rejected")
+	}
+}
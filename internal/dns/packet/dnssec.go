@@ -6,6 +6,7 @@ import (
 	"crypto/rand"
 	"crypto/sha1" // #nosec G505 -- SHA-1 required for DNSSEC DS records (RFC 4034)
 	"crypto/sha256"
+	"crypto/sha512"
 	"strings"
 )
 
@@ -41,6 +42,7 @@ func (r *DNSRecord) ComputeKeyTag() uint16 {
 // Supported digest types:
 //   - 1: SHA-1
 //   - 2: SHA-256
+//   - 4: SHA-384
 func (r *DNSRecord) ComputeDS(digestType uint8) (DNSRecord, error) {
 	if r.Type != DNSKEY {
 		return DNSRecord{}, nil
@@ -66,6 +68,9 @@ func (r *DNSRecord) ComputeDS(digestType uint8) (DNSRecord, error) {
 	case 2: // SHA-256
 		hashed := sha256.Sum256(buf.Buf[:buf.Position()])
 		digest = hashed[:]
+	case 4: // SHA-384
+		hashed := sha512.Sum384(buf.Buf[:buf.Position()])
+		digest = hashed[:]
 	default:
 		// Unsupported digest type - return empty record per RFC 4034 expectations in some contexts
 		return DNSRecord{}, nil
@@ -46,6 +46,12 @@ func TestComputeDS(t *testing.T) {
 	if len(ds1.Digest) == 0 { 
 		t.Errorf("Invalid DS record generated for SHA-1") 
 	}
+
+	// Test SHA-384 (Type 4)
+	ds4, _ := record.ComputeDS(4)
+	if len(ds4.Digest) != 48 || ds4.DigestType != 4 {
+		t.Errorf("Invalid DS record generated for SHA-384")
+	}
 }
 
 // TestSignRRSet_ECDSA ensures that an RRSet can be correctly signed using 
@@ -4,23 +4,23 @@ package packet
 import (
 	"crypto/sha1" // #nosec G505
 	"strings"
+
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
 )
 
 // HashName performs NSEC3 name hashing according to RFC 5155.
 // It implements the iterative hashing process with salt.
 func HashName(name string, _ uint8, iterations uint16, salt []byte) []byte {
 	// 1. Canonicalize name (lowercase and wire format)
-	name = strings.ToLower(name)
-	if !strings.HasSuffix(name, ".") {
-		name += "."
-	}
+	name = dnsname.Normalize(name)
 
 	// Manual wire format conversion for hashing (simplified)
-	labels := strings.Split(strings.TrimSuffix(name, "."), ".")
+	labels := dnsname.SplitLabels(name)
 	wire := make([]byte, 0, 1024)
 	for _, l := range labels {
-		wire = append(wire, byte(len(l)))
-		wire = append(wire, []byte(l)...)
+		raw := dnsname.UnescapeLabel(l)
+		wire = append(wire, byte(len(raw)))
+		wire = append(wire, raw...)
 	}
 	wire = append(wire, 0) // Null terminator
 
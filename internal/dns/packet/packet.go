@@ -2,9 +2,11 @@
 package packet
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/svcb"
 )
 
 // QueryType represents the DNS record type field (e.g., A, NS, MX).
@@ -47,10 +49,18 @@ const (
 	TXT        QueryType = 16
 	// AAAA represents an IPv6 address record.
 	AAAA       QueryType = 28
+	// LOC represents a geographic location record (RFC 1876).
+	LOC        QueryType = 29
 	// SRV represents service location records (RFC 2782).
 	SRV        QueryType = 33
+	// NAPTR represents a naming authority pointer record (RFC 3403).
+	NAPTR      QueryType = 35
+	// CERT represents a certificate record (RFC 4398).
+	CERT       QueryType = 37
 	// DS represents a delegation signer record (RFC 4034).
 	DS         QueryType = 43
+	// SSHFP represents an SSH host key fingerprint record (RFC 4255).
+	SSHFP      QueryType = 44
 	// RRSIG represents a DNSSEC signature record (RFC 4034).
 	RRSIG      QueryType = 46
 	// NSEC represents a next secure record (RFC 4034).
@@ -61,6 +71,20 @@ const (
 	NSEC3      QueryType = 50
 	// NSEC3PARAM represents NSEC3 parameters (RFC 5155).
 	NSEC3PARAM QueryType = 51
+	// TLSA represents a TLS certificate association record (RFC 6698).
+	TLSA       QueryType = 52
+	// CDS represents a child copy of a DS record for the parent (RFC 7344).
+	CDS        QueryType = 59
+	// CDNSKEY represents a child copy of a DNSKEY record for the parent (RFC 7344).
+	CDNSKEY    QueryType = 60
+	// SVCB represents a service binding record (RFC 9460).
+	SVCB       QueryType = 64
+	// HTTPS represents a service binding record for HTTPS origins (RFC 9460).
+	HTTPS      QueryType = 65
+	// URI represents a URI record (RFC 7553).
+	URI        QueryType = 256
+	// CAA represents a certification authority authorization record (RFC 8659).
+	CAA        QueryType = 257
 	// AXFR represents a request for a full zone transfer.
 	AXFR       QueryType = 252
 	// IXFR represents a request for an incremental zone transfer.
@@ -101,12 +125,20 @@ const (
 	EdeUnsupportedAlg     uint16 = 11
 	// EdeProhibited indicates the query is prohibited.
 	EdeProhibited          uint16 = 18
+	// EdeStaleNXDomainAnswer indicates an NXDOMAIN answer is stale.
+	EdeStaleNXDomainAnswer uint16 = 19
 	// EdeBlocked indicates the query was blocked by policy.
 	EdeBlocked             uint16 = 15
 	// EdeCensored indicates the query was censored.
 	EdeCensored            uint16 = 16
 	// EdeFiltered indicates the query was filtered.
 	EdeFiltered            uint16 = 17
+	// EdeNotAuthoritative indicates the server is not authoritative for the name and did not recurse.
+	EdeNotAuthoritative     uint16 = 20
+	// EdeNoReachableAuthority indicates the authoritative data source could not be reached.
+	EdeNoReachableAuthority uint16 = 22
+	// EdeNetworkError indicates an upstream server could not be reached over the network.
+	EdeNetworkError         uint16 = 23
 )
 
 // RecordTypeToQueryType converts a domain model RecordType to its corresponding packet QueryType.
@@ -121,6 +153,15 @@ func RecordTypeToQueryType(t domain.RecordType) QueryType {
 	case domain.TypeAAAA: return AAAA
 	case domain.TypePTR: return PTR
 	case domain.TypeSRV: return SRV
+	case domain.TypeSVCB: return SVCB
+	case domain.TypeHTTPS: return HTTPS
+	case domain.TypeCAA: return CAA
+	case domain.TypeNAPTR: return NAPTR
+	case domain.TypeTLSA: return TLSA
+	case domain.TypeSSHFP: return SSHFP
+	case domain.TypeLOC: return LOC
+	case domain.TypeURI: return URI
+	case domain.TypeCERT: return CERT
 	default: return UNKNOWN
 	}
 }
@@ -142,6 +183,17 @@ func (t QueryType) String() string {
 	case DNSKEY: return "DNSKEY"
 	case NSEC3: return "NSEC3"
 	case NSEC3PARAM: return "NSEC3PARAM"
+	case CDS: return "CDS"
+	case CDNSKEY: return "CDNSKEY"
+	case SVCB: return "SVCB"
+	case HTTPS: return "HTTPS"
+	case CAA: return "CAA"
+	case NAPTR: return "NAPTR"
+	case TLSA: return "TLSA"
+	case SSHFP: return "SSHFP"
+	case LOC: return "LOC"
+	case URI: return "URI"
+	case CERT: return "CERT"
 	case AXFR: return "AXFR"
 	case IXFR: return "IXFR"
 	case ANY: return "ANY"
@@ -346,9 +398,9 @@ type DNSRecord struct {
 	TTL      uint32
 	Data     []byte
 	IP       net.IP   // A/AAAA
-	Host     string   // NS/CNAME/PTR/MD/MF/MB/MG/MR/SRV
-	Priority uint16   // MX, SRV
-	Weight   uint16   // SRV
+	Host     string   // NS/CNAME/PTR/MD/MF/MB/MG/MR/SRV, SVCB/HTTPS target, NAPTR replacement
+	Priority uint16   // MX, SRV, SVCB/HTTPS, URI
+	Weight   uint16   // SRV, URI
 	Port     uint16   // SRV
 	Txt      string   // TXT
 	MName    string   // SOA
@@ -367,9 +419,9 @@ type DNSRecord struct {
 	// NSEC
 	NextName   string
 	TypeBitMap []byte
-	// DNSKEY
+	// DNSKEY, CAA
 	Flags     uint16
-	Algorithm uint8
+	Algorithm uint8 // also SSHFP, CERT
 	PublicKey []byte
 	// RRSIG
 	TypeCovered uint16
@@ -377,7 +429,7 @@ type DNSRecord struct {
 	OrigTTL     uint32
 	Expiration  uint32
 	Inception   uint32
-	KeyTag      uint16
+	KeyTag      uint16 // also CERT
 	SignerName  string
 	Signature   []byte
 	// NSEC3
@@ -385,9 +437,35 @@ type DNSRecord struct {
 	Iterations uint16
 	Salt       []byte
 	NextHash   []byte
-	// DS
+	// DS, SSHFP (fingerprint type and fingerprint)
 	DigestType uint8
 	Digest     []byte
+	// SVCB/HTTPS
+	SvcParams []svcb.Param
+	// CAA, URI (target as Value)
+	Tag   string
+	Value string
+	// NAPTR
+	Order      uint16
+	Preference uint16
+	NAPTRFlags string
+	Services   string
+	Regexp     string
+	// TLSA
+	Usage        uint8
+	Selector     uint8
+	MatchingType uint8
+	// TLSA, CERT
+	CertType uint16
+	CertData []byte
+	// LOC
+	LocVersion uint8
+	Size       uint8
+	HorizPre   uint8
+	VertPre    uint8
+	Latitude   uint32
+	Longitude  uint32
+	Altitude   uint32
 	// EDNS
 	UDPPayloadSize uint16
 	ExtendedRcode  uint8
@@ -406,11 +484,16 @@ type DNSRecord struct {
 
 // AddEDE adds an Extended DNS Error (RFC 8914) option to an OPT record.
 func (r *DNSRecord) AddEDE(code uint16, text string) {
+	r.Options = append(r.Options, NewEDEOption(code, text))
+}
+
+// NewEDEOption builds an Extended DNS Error (RFC 8914) option.
+func NewEDEOption(code uint16, text string) EdnsOption {
 	data := []byte{byte(code >> 8), byte(code & 0xFF)}
 	if text != "" {
 		data = append(data, []byte(text)...)
 	}
-	r.Options = append(r.Options, EdnsOption{Code: 15, Data: data})
+	return EdnsOption{Code: 15, Data: data}
 }
 
 // Read populates the DNSRecord fields by reading from the provided buffer.
@@ -495,7 +578,7 @@ func (r *DNSRecord) Read(buffer *BytePacketBuffer) error {
 		remaining := int(dataLen) - (buffer.Position() - startPos)
 		if r.TypeBitMap, err = buffer.ReadRange(buffer.Position(), remaining); err != nil { return err }
 		if errStep := buffer.Step(remaining); errStep != nil { return errStep }
-	case DNSKEY:
+	case DNSKEY, CDNSKEY:
 		if r.Flags, err = buffer.Readu16(); err != nil { return err }
 		if _, errReadProto := buffer.Read(); errReadProto != nil { return errReadProto } // Protocol
 		if r.Algorithm, err = buffer.Read(); err != nil { return err }
@@ -537,13 +620,92 @@ func (r *DNSRecord) Read(buffer *BytePacketBuffer) error {
 		if errReadSalt != nil { return errReadSalt }
 		if r.Salt, err = buffer.ReadRange(buffer.Position(), int(saltLen)); err != nil { return err }
 		if errStep := buffer.Step(int(saltLen)); errStep != nil { return errStep }
-	case DS:
+	case DS, CDS:
 		if r.KeyTag, err = buffer.Readu16(); err != nil { return err }
 		if r.Algorithm, err = buffer.Read(); err != nil { return err }
 		if r.DigestType, err = buffer.Read(); err != nil { return err }
 		remaining := int(dataLen) - (buffer.Position() - startPos)
 		if r.Digest, err = buffer.ReadRange(buffer.Position(), remaining); err != nil { return err }
 		if errStep := buffer.Step(remaining); errStep != nil { return errStep }
+	case SVCB, HTTPS:
+		if r.Priority, err = buffer.Readu16(); err != nil { return err }
+		if r.Host, err = buffer.ReadName(); err != nil { return err }
+		remaining := int(dataLen) - (buffer.Position() - startPos)
+		for remaining > 0 {
+			if remaining < 4 { return errors.New("truncated SvcParam") }
+			key, errReadKey := buffer.Readu16()
+			if errReadKey != nil { return errReadKey }
+			valLen, errReadLen2 := buffer.Readu16()
+			if errReadLen2 != nil { return errReadLen2 }
+			if int(valLen) > remaining-4 { return errors.New("truncated SvcParam") }
+			val, errReadVal := buffer.ReadRange(buffer.Position(), int(valLen))
+			if errReadVal != nil { return errReadVal }
+			if errStep := buffer.Step(int(valLen)); errStep != nil { return errStep }
+			r.SvcParams = append(r.SvcParams, svcb.Param{Key: key, Value: val})
+			remaining -= 4 + int(valLen)
+		}
+	case CAA:
+		flags, errReadFlags := buffer.Read()
+		if errReadFlags != nil { return errReadFlags }
+		r.Flags = uint16(flags)
+		tagLen, errReadTag := buffer.Read()
+		if errReadTag != nil { return errReadTag }
+		tag, errRange := buffer.ReadRange(buffer.Position(), int(tagLen))
+		if errRange != nil { return errRange }
+		r.Tag = string(tag)
+		if errStep := buffer.Step(int(tagLen)); errStep != nil { return errStep }
+		remaining := int(dataLen) - (buffer.Position() - startPos)
+		if remaining < 0 { return errors.New("CAA tag exceeds RDATA") }
+		value, errRange2 := buffer.ReadRange(buffer.Position(), remaining)
+		if errRange2 != nil { return errRange2 }
+		r.Value = string(value)
+		if errStep2 := buffer.Step(remaining); errStep2 != nil { return errStep2 }
+	case NAPTR:
+		if r.Order, err = buffer.Readu16(); err != nil { return err }
+		if r.Preference, err = buffer.Readu16(); err != nil { return err }
+		if r.NAPTRFlags, err = readCharacterString(buffer); err != nil { return err }
+		if r.Services, err = readCharacterString(buffer); err != nil { return err }
+		if r.Regexp, err = readCharacterString(buffer); err != nil { return err }
+		if r.Host, err = buffer.ReadName(); err != nil { return err }
+	case TLSA:
+		if r.Usage, err = buffer.Read(); err != nil { return err }
+		if r.Selector, err = buffer.Read(); err != nil { return err }
+		if r.MatchingType, err = buffer.Read(); err != nil { return err }
+		remaining := int(dataLen) - (buffer.Position() - startPos)
+		if r.CertData, err = buffer.ReadRange(buffer.Position(), remaining); err != nil { return err }
+		if errStep := buffer.Step(remaining); errStep != nil { return errStep }
+	case SSHFP:
+		if r.Algorithm, err = buffer.Read(); err != nil { return err }
+		if r.DigestType, err = buffer.Read(); err != nil { return err }
+		remaining := int(dataLen) - (buffer.Position() - startPos)
+		if r.Digest, err = buffer.ReadRange(buffer.Position(), remaining); err != nil { return err }
+		if errStep := buffer.Step(remaining); errStep != nil { return errStep }
+	case CERT:
+		if r.CertType, err = buffer.Readu16(); err != nil { return err }
+		if r.KeyTag, err = buffer.Readu16(); err != nil { return err }
+		if r.Algorithm, err = buffer.Read(); err != nil { return err }
+		remaining := int(dataLen) - (buffer.Position() - startPos)
+		if r.CertData, err = buffer.ReadRange(buffer.Position(), remaining); err != nil { return err }
+		if errStep := buffer.Step(remaining); errStep != nil { return errStep }
+	case LOC:
+		if dataLen != 16 { return errors.New("LOC RDATA must be 16 bytes") }
+		if r.LocVersion, err = buffer.Read(); err != nil { return err }
+		if r.LocVersion != 0 { return fmt.Errorf("unsupported LOC version %d", r.LocVersion) }
+		if r.Size, err = buffer.Read(); err != nil { return err }
+		if r.HorizPre, err = buffer.Read(); err != nil { return err }
+		if r.VertPre, err = buffer.Read(); err != nil { return err }
+		if r.Latitude, err = buffer.Readu32(); err != nil { return err }
+		if r.Longitude, err = buffer.Readu32(); err != nil { return err }
+		if r.Altitude, err = buffer.Readu32(); err != nil { return err }
+	case URI:
+		if r.Priority, err = buffer.Readu16(); err != nil { return err }
+		if r.Weight, err = buffer.Readu16(); err != nil { return err }
+		remaining := int(dataLen) - (buffer.Position() - startPos)
+		if remaining < 0 { return errors.New("URI target exceeds RDATA") }
+		target, errRange := buffer.ReadRange(buffer.Position(), remaining)
+		if errRange != nil { return errRange }
+		r.Value = string(target)
+		if errStep := buffer.Step(remaining); errStep != nil { return errStep }
 	case TSIG:
 		if r.AlgorithmName, err = buffer.ReadName(); err != nil { return err }
 		timeHigh, errReadHigh := buffer.Readu16()
@@ -746,7 +908,7 @@ func (r *DNSRecord) Write(buffer *BytePacketBuffer) (int, error) {
 		if err := buffer.Seek(lenPos); err != nil { return 0, err }
 		if err := buffer.Writeu16(uint16(currPos - (lenPos + 2))); err != nil { return 0, err } // #nosec G115
 		if err := buffer.Seek(currPos); err != nil { return 0, err }
-	case DNSKEY:
+	case DNSKEY, CDNSKEY:
 		if err := buffer.Writeu16(uint16(4 + len(r.PublicKey))); err != nil { return 0, err } // #nosec G115
 		if err := buffer.Writeu16(r.Flags); err != nil { return 0, err }
 		if err := buffer.Write(3); err != nil { return 0, err } // Protocol
@@ -807,7 +969,7 @@ func (r *DNSRecord) Write(buffer *BytePacketBuffer) (int, error) {
 		if err := buffer.Seek(lenPos); err != nil { return 0, err }
 		if err := buffer.Writeu16(uint16(currPos - (lenPos + 2))); err != nil { return 0, err } // #nosec G115
 		if err := buffer.Seek(currPos); err != nil { return 0, err }
-	case DS:
+	case DS, CDS:
 		if err := buffer.Writeu16(uint16(4 + len(r.Digest))); err != nil { return 0, err } // #nosec G115
 		if err := buffer.Writeu16(r.KeyTag); err != nil { return 0, err }
 		if err := buffer.Write(r.Algorithm); err != nil { return 0, err }
@@ -815,6 +977,93 @@ func (r *DNSRecord) Write(buffer *BytePacketBuffer) (int, error) {
 		for _, b := range r.Digest {
 			if err := buffer.Write(b); err != nil { return 0, err }
 		}
+	case SVCB, HTTPS:
+		lenPos := buffer.Position()
+		if err := buffer.Writeu16(0); err != nil { return 0, err }
+		if err := buffer.Writeu16(r.Priority); err != nil { return 0, err }
+		// RFC 9460 Section 2.2: the target name is never compressed
+		compress := buffer.HasNames
+		buffer.HasNames = false
+		err := buffer.WriteName(r.Host)
+		buffer.HasNames = compress
+		if err != nil { return 0, err }
+		for _, p := range r.SvcParams {
+			if err := buffer.Writeu16(p.Key); err != nil { return 0, err }
+			if err := buffer.Writeu16(uint16(len(p.Value))); err != nil { return 0, err } // #nosec G115
+			for _, b := range p.Value {
+				if err := buffer.Write(b); err != nil { return 0, err }
+			}
+		}
+		currPos := buffer.Position()
+		if err := buffer.Seek(lenPos); err != nil { return 0, err }
+		if err := buffer.Writeu16(uint16(currPos - (lenPos + 2))); err != nil { return 0, err } // #nosec G115
+		if err := buffer.Seek(currPos); err != nil { return 0, err }
+	case CAA:
+		if err := buffer.Writeu16(uint16(2 + len(r.Tag) + len(r.Value))); err != nil { return 0, err } // #nosec G115
+		if err := buffer.Write(uint8(r.Flags)); err != nil { return 0, err } // #nosec G115
+		if err := buffer.Write(byte(len(r.Tag))); err != nil { return 0, err } // #nosec G115
+		for i := 0; i < len(r.Tag); i++ {
+			if err := buffer.Write(r.Tag[i]); err != nil { return 0, err }
+		}
+		for i := 0; i < len(r.Value); i++ {
+			if err := buffer.Write(r.Value[i]); err != nil { return 0, err }
+		}
+	case NAPTR:
+		lenPos := buffer.Position()
+		if err := buffer.Writeu16(0); err != nil { return 0, err }
+		if err := buffer.Writeu16(r.Order); err != nil { return 0, err }
+		if err := buffer.Writeu16(r.Preference); err != nil { return 0, err }
+		for _, s := range []string{r.NAPTRFlags, r.Services, r.Regexp} {
+			if err := writeCharacterString(buffer, s); err != nil { return 0, err }
+		}
+		// RFC 3403 Section 4.1: the replacement is never compressed
+		compress := buffer.HasNames
+		buffer.HasNames = false
+		err := buffer.WriteName(r.Host)
+		buffer.HasNames = compress
+		if err != nil { return 0, err }
+		currPos := buffer.Position()
+		if err := buffer.Seek(lenPos); err != nil { return 0, err }
+		if err := buffer.Writeu16(uint16(currPos - (lenPos + 2))); err != nil { return 0, err } // #nosec G115
+		if err := buffer.Seek(currPos); err != nil { return 0, err }
+	case TLSA:
+		if err := buffer.Writeu16(uint16(3 + len(r.CertData))); err != nil { return 0, err } // #nosec G115
+		if err := buffer.Write(r.Usage); err != nil { return 0, err }
+		if err := buffer.Write(r.Selector); err != nil { return 0, err }
+		if err := buffer.Write(r.MatchingType); err != nil { return 0, err }
+		for _, b := range r.CertData {
+			if err := buffer.Write(b); err != nil { return 0, err }
+		}
+	case SSHFP:
+		if err := buffer.Writeu16(uint16(2 + len(r.Digest))); err != nil { return 0, err } // #nosec G115
+		if err := buffer.Write(r.Algorithm); err != nil { return 0, err }
+		if err := buffer.Write(r.DigestType); err != nil { return 0, err }
+		for _, b := range r.Digest {
+			if err := buffer.Write(b); err != nil { return 0, err }
+		}
+	case CERT:
+		if err := buffer.Writeu16(uint16(5 + len(r.CertData))); err != nil { return 0, err } // #nosec G115
+		if err := buffer.Writeu16(r.CertType); err != nil { return 0, err }
+		if err := buffer.Writeu16(r.KeyTag); err != nil { return 0, err }
+		if err := buffer.Write(r.Algorithm); err != nil { return 0, err }
+		for _, b := range r.CertData {
+			if err := buffer.Write(b); err != nil { return 0, err }
+		}
+	case LOC:
+		if err := buffer.Writeu16(16); err != nil { return 0, err }
+		for _, b := range []uint8{r.LocVersion, r.Size, r.HorizPre, r.VertPre} {
+			if err := buffer.Write(b); err != nil { return 0, err }
+		}
+		if err := buffer.Writeu32(r.Latitude); err != nil { return 0, err }
+		if err := buffer.Writeu32(r.Longitude); err != nil { return 0, err }
+		if err := buffer.Writeu32(r.Altitude); err != nil { return 0, err }
+	case URI:
+		if err := buffer.Writeu16(uint16(4 + len(r.Value))); err != nil { return 0, err } // #nosec G115
+		if err := buffer.Writeu16(r.Priority); err != nil { return 0, err }
+		if err := buffer.Writeu16(r.Weight); err != nil { return 0, err }
+		for i := 0; i < len(r.Value); i++ {
+			if err := buffer.Write(r.Value[i]); err != nil { return 0, err }
+		}
 	default:
 		// RFC 2136: Delete RRset (ANY/ANY) or record (NONE/type) has RDLENGTH = 0
 		if len(r.Data) == 0 && (r.Class == 255 || r.Class == 254) {
@@ -830,6 +1079,26 @@ func (r *DNSRecord) Write(buffer *BytePacketBuffer) (int, error) {
 	return buffer.Position() - startPos, nil
 }
 
+// readCharacterString reads a length-prefixed <character-string> (RFC 1035 Section 3.3).
+func readCharacterString(buffer *BytePacketBuffer) (string, error) {
+	n, err := buffer.Read()
+	if err != nil { return "", err }
+	data, err := buffer.ReadRange(buffer.Position(), int(n))
+	if err != nil { return "", err }
+	if err := buffer.Step(int(n)); err != nil { return "", err }
+	return string(data), nil
+}
+
+// writeCharacterString writes s as a length-prefixed <character-string>.
+func writeCharacterString(buffer *BytePacketBuffer, s string) error {
+	if len(s) > 255 { return fmt.Errorf("character-string of %d bytes exceeds 255", len(s)) }
+	if err := buffer.Write(byte(len(s))); err != nil { return err }
+	for i := 0; i < len(s); i++ {
+		if err := buffer.Write(s[i]); err != nil { return err }
+	}
+	return nil
+}
+
 // DNSPacket represents a complete DNS packet.
 type DNSPacket struct {
 	Header      DNSHeader
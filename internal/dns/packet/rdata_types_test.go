@@ -0,0 +1,72 @@
+package packet
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRDATATypes_ReadWrite(t *testing.T) {
+	digest := bytes.Repeat([]byte{0xab}, 32)
+	records := []DNSRecord{
+		{Name: "4.3.2.1.e164.arpa.", Type: NAPTR, Class: 1, TTL: 300, Order: 100, Preference: 10, NAPTRFlags: "U", Services: "E2U+sip", Regexp: "!^.*$!sip:info@example.com!", Host: "."},
+		{Name: "_443._tcp.www.example.com.", Type: TLSA, Class: 1, TTL: 300, Usage: 3, Selector: 1, MatchingType: 1, CertData: digest},
+		{Name: "host.example.com.", Type: SSHFP, Class: 1, TTL: 300, Algorithm: 4, DigestType: 2, Digest: digest},
+		{Name: "example.com.", Type: CERT, Class: 1, TTL: 300, CertType: 3, KeyTag: 12345, Algorithm: 8, CertData: []byte("hello")},
+		{Name: "example.com.", Type: LOC, Class: 1, TTL: 300, Size: 0x12, HorizPre: 0x16, VertPre: 0x13, Latitude: 1<<31 + 188543000, Longitude: 1<<31 + 17612000, Altitude: 9999800},
+		{Name: "_http._tcp.example.com.", Type: URI, Class: 1, TTL: 300, Priority: 10, Weight: 1, Value: "https://www.example.com/"},
+	}
+	for _, original := range records {
+		t.Run(original.Type.String(), func(t *testing.T) {
+			buffer := NewBytePacketBuffer()
+			if _, err := original.Write(buffer); err != nil {
+				t.Fatalf("Failed to write record: %v", err)
+			}
+			end := buffer.Position()
+			buffer.Pos = 0
+			var decoded DNSRecord
+			if err := decoded.Read(buffer); err != nil {
+				t.Fatalf("Failed to read record: %v", err)
+			}
+			if buffer.Position() != end {
+				t.Errorf("Read %d bytes, wrote %d", buffer.Position(), end)
+			}
+			decoded.Name, decoded.Class, decoded.TTL = original.Name, original.Class, original.TTL
+			// Compare the wire forms, which cover every RDATA field
+			again := NewBytePacketBuffer()
+			if _, err := decoded.Write(again); err != nil {
+				t.Fatalf("Failed to write decoded record: %v", err)
+			}
+			if !bytes.Equal(again.Buf[:again.Position()], buffer.Buf[:end]) {
+				t.Errorf("Record did not round-trip: %+v", decoded)
+			}
+		})
+	}
+}
+
+func TestNAPTRRecord_ReplacementNotCompressed(t *testing.T) {
+	buffer := NewBytePacketBuffer()
+	buffer.HasNames = true
+	rec := DNSRecord{Name: "sip.example.com.", Type: NAPTR, Class: 1, Order: 1, Host: "sip.example.com."}
+	if _, err := rec.Write(buffer); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	// The replacement is written in full after the empty flags, services and regexp
+	if !bytes.HasSuffix(buffer.Buf[:buffer.Position()], []byte("\x00\x00\x00\x03sip\x07example\x03com\x00")) {
+		t.Errorf("Expected an uncompressed replacement, got % x", buffer.Buf[:buffer.Position()])
+	}
+}
+
+func TestLOCRecord_ReadRejectsBadLength(t *testing.T) {
+	buffer := NewBytePacketBuffer()
+	rec := DNSRecord{Name: "example.com.", Type: URI, Class: 1, Value: "x"}
+	if _, err := rec.Write(buffer); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	// Relabel the 5-byte URI RDATA as LOC
+	buffer.Buf[13], buffer.Buf[14] = 0, byte(LOC)
+	buffer.Pos = 0
+	var decoded DNSRecord
+	if err := decoded.Read(buffer); err == nil {
+		t.Error("Expected LOC RDATA that is not 16 bytes to be rejected")
+	}
+}
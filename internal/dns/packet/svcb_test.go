@@ -0,0 +1,60 @@
+package packet
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/dns/svcb"
+)
+
+func TestSVCBRecord_ReadWrite(t *testing.T) {
+	params, err := svcb.Parse([]string{"alpn=h2,h3", "ipv4hint=192.0.2.1", "ech=AEX+DQ=="})
+	if err != nil {
+		t.Fatalf("svcb.Parse failed: %v", err)
+	}
+	for _, qType := range []QueryType{SVCB, HTTPS} {
+		buffer := NewBytePacketBuffer()
+		buffer.HasNames = true
+		original := DNSRecord{Name: "example.com.", Type: qType, Class: 1, TTL: 300, Priority: 1, Host: "svc.example.com.", SvcParams: params}
+		if _, err := original.Write(buffer); err != nil {
+			t.Fatalf("Failed to write %s record: %v", qType, err)
+		}
+		// The target must be written in full even though its suffix was seen
+		if !bytes.Contains(buffer.Buf[:buffer.Position()], []byte("\x03svc\x07example\x03com\x00")) {
+			t.Errorf("%s target was compressed", qType)
+		}
+
+		buffer.Pos = 0
+		var decoded DNSRecord
+		if err := decoded.Read(buffer); err != nil {
+			t.Fatalf("Failed to read %s record: %v", qType, err)
+		}
+		if decoded.Type != qType || decoded.Priority != 1 || decoded.Host != "svc.example.com." {
+			t.Errorf("Unexpected %s record %+v", qType, decoded)
+		}
+		if got := svcb.Format(decoded.SvcParams); got != "alpn=h2,h3 ipv4hint=192.0.2.1 ech=AEX+DQ==" {
+			t.Errorf("SvcParams mismatch: %s", got)
+		}
+	}
+}
+
+func TestSVCBRecord_ReadTruncated(t *testing.T) {
+	buffer := NewBytePacketBuffer()
+	rec := DNSRecord{Name: "example.com.", Type: HTTPS, Class: 1, Priority: 1, Host: ".", SvcParams: []svcb.Param{{Key: svcb.KeyPort, Value: []byte{0x01, 0xBB}}}}
+	if _, err := rec.Write(buffer); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	// Claim a longer value than the RDATA holds
+	buffer.Buf[buffer.Position()-3] = 9
+	buffer.Pos = 0
+	var decoded DNSRecord
+	if err := decoded.Read(buffer); err == nil {
+		t.Error("Expected a truncated SvcParam to be rejected")
+	}
+}
+
+func TestSVCBQueryTypeString(t *testing.T) {
+	if SVCB.String() != "SVCB" || HTTPS.String() != "HTTPS" {
+		t.Errorf("Unexpected names %s and %s", SVCB, HTTPS)
+	}
+}
@@ -5,9 +5,33 @@ import (
 	"crypto/hmac"
 	"crypto/md5" // #nosec G501
 	"errors"
+	"fmt"
+	"hash"
+	"strings"
 	"time"
 )
 
+// TSIGAlgorithm is the only TSIG algorithm supported, HMAC-MD5 (RFC 8945).
+const TSIGAlgorithm = "hmac-md5.sig-alg.reg.int."
+
+// TSIG error codes carried in the Error field of a TSIG record (RFC 8945, Section 3).
+const (
+	TSIGErrBadSig  uint16 = 16
+	TSIGErrBadKey  uint16 = 17
+	TSIGErrBadTime uint16 = 18
+)
+
+// tsigMaxUnsigned is how many messages of a stream may follow each other without
+// a TSIG record (RFC 8945, Section 5.3.1).
+const tsigMaxUnsigned = 99
+
+var (
+	// ErrTSIGBadSig is returned when a TSIG MAC does not verify.
+	ErrTSIGBadSig = errors.New("TSIG MAC mismatch")
+	// ErrTSIGBadTime is returned when a message was signed outside its fudge window.
+	ErrTSIGBadTime = errors.New("TSIG time drift exceeded")
+)
+
 // VerifyTSIG checks if the TSIG record in the packet matches the provided key and secret (RFC 2845).
 // It validates the signature and ensures the time drift is within acceptable limits.
 func (p *DNSPacket) VerifyTSIG(rawBuffer []byte, tsigStart int, secret []byte) error {
@@ -21,56 +45,20 @@ func (p *DNSPacket) VerifyTSIG(rawBuffer []byte, tsigStart int, secret []byte) e
 	}
 
 	// 2. Check time drift (Fudge)
-	unixNow := time.Now().Unix()
-	var now uint64
-	if unixNow >= 0 {
-		now = uint64(unixNow)
-	}
-	drift := uint64(0)
-	if now > tsig.TimeSigned {
-		drift = now - tsig.TimeSigned
-	} else {
-		drift = tsig.TimeSigned - now
-	}
-	if drift > uint64(tsig.Fudge) {
-		return errors.New("TSIG time drift exceeded")
+	if !tsigTimeValid(tsig) {
+		return ErrTSIGBadTime
 	}
 
-	// 3. Compute HMAC
+	// 3. Compute HMAC over the message as it was before signing
 	h := hmac.New(md5.New, secret)
-	
-	// Create a copy of the buffer before TSIG and adjust ARCOUNT
-	// Header is 12 bytes, ARCOUNT is at offset 10 (2 bytes)
-	prefix := make([]byte, tsigStart)
-	copy(prefix, rawBuffer[:tsigStart])
-	if len(prefix) >= 12 {
-		arCount := uint16(len(p.Resources) - 1) // #nosec G115
-		prefix[10] = byte(arCount >> 8)
-		prefix[11] = byte(arCount & 0xFF)
-	}
-	h.Write(prefix)
-	
-	// Add TSIG variables (RFC 2845 Section 3.4.1)
-	// Note: Names and Algorithms should be in canonical wire format
-	vBuf := NewBytePacketBuffer()
-	if err := vBuf.WriteName(tsig.Name); err != nil { return err }
-	if err := vBuf.Writeu16(tsig.Class); err != nil { return err }
-	if err := vBuf.Writeu32(tsig.TTL); err != nil { return err }
-	if err := vBuf.WriteName(tsig.AlgorithmName); err != nil { return err }
-	if err := vBuf.Writeu16(uint16(tsig.TimeSigned >> 32)); err != nil { return err } // #nosec G115
-	if err := vBuf.Writeu32(uint32(tsig.TimeSigned & 0xFFFFFFFF)); err != nil { return err } // #nosec G115
-	if err := vBuf.Writeu16(tsig.Fudge); err != nil { return err }
-	if err := vBuf.Writeu16(tsig.Error); err != nil { return err }
-	if err := vBuf.Writeu16(uint16(len(tsig.Other))); err != nil { return err } // #nosec G115
-	if err := vBuf.WriteRange(vBuf.Position(), tsig.Other); err != nil { return err }
-	
-	h.Write(vBuf.Buf[:vBuf.Position()])
-	
-	expectedMAC := h.Sum(nil)
-	if !hmac.Equal(tsig.MAC, expectedMAC) {
-		return errors.New("TSIG MAC mismatch")
+	h.Write(tsigUnsignedMessage(rawBuffer, tsigStart, tsig.OriginalID))
+	if err := writeTSIGVariables(h, tsig, false); err != nil {
+		return err
 	}
 
+	if !hmac.Equal(tsig.MAC, h.Sum(nil)) {
+		return ErrTSIGBadSig
+	}
 	return nil
 }
 
@@ -78,38 +66,14 @@ func (p *DNSPacket) VerifyTSIG(rawBuffer []byte, tsigStart int, secret []byte) e
 // It appends the TSIG record to the additional section and updates the packet header.
 func (p *DNSPacket) SignTSIG(buffer *BytePacketBuffer, keyName string, secret []byte) error {
 	// 1. Prepare TSIG Record (without MAC yet)
-	tsig := DNSRecord{
-		Name:          keyName,
-		Type:          TSIG,
-		Class:         255, // ANY
-		TTL:           0,
-		AlgorithmName: "hmac-md5.sig-alg.reg.int.",
-		TimeSigned:    (func() uint64 { u := time.Now().Unix(); if u < 0 { return 0 }; return uint64(u) })(),
-		Fudge:         300,
-		OriginalID:    p.Header.ID,
-	}
+	tsig := newTSIGRecord(keyName, p.Header.ID)
 
-	// 2. Compute MAC
+	// 2. Compute MAC over the packet without TSIG and the TSIG variables
 	h := hmac.New(md5.New, secret)
-	
-	// Write current buffer content (packet without TSIG)
 	h.Write(buffer.Buf[:buffer.Position()])
-	
-	// Add TSIG variables to HMAC
-	vBuf := NewBytePacketBuffer()
-	if err := vBuf.WriteName(tsig.Name); err != nil { return err }
-	if err := vBuf.Writeu16(tsig.Class); err != nil { return err }
-	if err := vBuf.Writeu32(tsig.TTL); err != nil { return err }
-	if err := vBuf.WriteName(tsig.AlgorithmName); err != nil { return err }
-	if err := vBuf.Writeu16(uint16(tsig.TimeSigned >> 32)); err != nil { return err } // #nosec G115
-	if err := vBuf.Writeu32(uint32(tsig.TimeSigned & 0xFFFFFFFF)); err != nil { return err } // #nosec G115
-	if err := vBuf.Writeu16(tsig.Fudge); err != nil { return err }
-	if err := vBuf.Writeu16(tsig.Error); err != nil { return err }
-	if err := vBuf.Writeu16(uint16(len(tsig.Other))); err != nil { return err } // #nosec G115
-	if err := vBuf.WriteRange(vBuf.Position(), tsig.Other); err != nil { return err }
-	
-	h.Write(vBuf.Buf[:vBuf.Position()])
-	
+	if err := writeTSIGVariables(h, tsig, false); err != nil {
+		return err
+	}
 	tsig.MAC = h.Sum(nil)
 
 	// 3. Update the packet state before writing to buffer
@@ -127,3 +91,241 @@ func (p *DNSPacket) SignTSIG(buffer *BytePacketBuffer, keyName string, secret []
 	_, err := tsig.Write(buffer)
 	return err
 }
+
+// TSIGStream signs or verifies the messages of one TSIG-authenticated exchange
+// after its request (RFC 8945, Section 5.3). The first message covers the
+// request MAC and all TSIG variables; every later one covers the previous MAC,
+// the messages since then and only the timers. Verification accepts up to 99
+// unsigned messages between signed ones, folding them into the next MAC.
+type TSIGStream struct {
+	KeyName string
+	Secret  []byte
+	// Error is the TSIG error code placed in signed responses, such as
+	// TSIGErrBadTime for a request outside its fudge window.
+	Error uint16
+
+	priorMAC []byte
+	messages int
+	pending  [][]byte
+}
+
+// NewTSIGStream starts a stream answering a request that carried requestMAC,
+// signed with keyName and secret.
+func NewTSIGStream(keyName string, secret []byte, requestMAC []byte) *TSIGStream {
+	return &TSIGStream{KeyName: keyName, Secret: secret, priorMAC: append([]byte(nil), requestMAC...)}
+}
+
+// SignMessage returns msg, a complete message without TSIG, with a TSIG record
+// appended and ARCOUNT raised to match.
+func (s *TSIGStream) SignMessage(msg []byte) ([]byte, error) {
+	if len(msg) < 12 {
+		return nil, errors.New("message too short to sign")
+	}
+	tsig := newTSIGRecord(s.KeyName, uint16(msg[0])<<8|uint16(msg[1]))
+	tsig.Error = s.Error
+	if s.Error == TSIGErrBadTime {
+		// The server's time lets the client see how far off it is
+		tsig.Other = []byte{0, 0, 0, 0, 0, 0}
+		for i := 0; i < 6; i++ {
+			tsig.Other[5-i] = byte(tsig.TimeSigned >> (8 * i))
+		}
+	}
+
+	h := s.digest()
+	h.Write(msg)
+	if err := writeTSIGVariables(h, tsig, s.messages > 0); err != nil {
+		return nil, err
+	}
+	tsig.MAC = h.Sum(nil)
+
+	signed, err := appendTSIG(msg, tsig)
+	if err != nil {
+		return nil, err
+	}
+	s.priorMAC = tsig.MAC
+	s.messages++
+	return signed, nil
+}
+
+// AppendUnsignedTSIG returns msg with an unsigned TSIG record carrying errCode
+// appended, the answer to a request whose key is unknown (TSIGErrBadKey) or
+// whose MAC did not verify (TSIGErrBadSig), since no MAC can be computed for
+// those (RFC 8945, Section 5.3.2).
+func AppendUnsignedTSIG(msg []byte, keyName string, errCode uint16) ([]byte, error) {
+	if len(msg) < 12 {
+		return nil, errors.New("message too short to sign")
+	}
+	tsig := newTSIGRecord(keyName, uint16(msg[0])<<8|uint16(msg[1]))
+	tsig.Error = errCode
+	return appendTSIG(msg, tsig)
+}
+
+// appendTSIG appends a TSIG record to msg and raises its ARCOUNT.
+func appendTSIG(msg []byte, tsig DNSRecord) ([]byte, error) {
+	rrBuf := NewBytePacketBuffer()
+	if _, err := tsig.Write(rrBuf); err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(msg)+rrBuf.Position())
+	out = append(out, msg...)
+	out = append(out, rrBuf.Buf[:rrBuf.Position()]...)
+	arCount := uint16(out[10])<<8 | uint16(out[11])
+	arCount++
+	out[10] = byte(arCount >> 8)
+	out[11] = byte(arCount)
+	return out, nil
+}
+
+// Verify checks the next message of the stream, already parsed into p from
+// raw. Only the first message must be signed; later unsigned messages are
+// remembered and covered by the next signature.
+func (s *TSIGStream) Verify(p *DNSPacket, raw []byte) error {
+	if p.TSIGStart == -1 {
+		if s.messages == 0 {
+			return errors.New("first message of a TSIG stream is not signed")
+		}
+		if len(s.pending) >= tsigMaxUnsigned {
+			return fmt.Errorf("more than %d consecutive unsigned messages", tsigMaxUnsigned)
+		}
+		s.pending = append(s.pending, append([]byte(nil), raw...))
+		return nil
+	}
+
+	tsig := p.Resources[len(p.Resources)-1]
+	if !strings.EqualFold(strings.TrimSuffix(tsig.Name, "."), strings.TrimSuffix(s.KeyName, ".")) {
+		return fmt.Errorf("message signed with key %s, expected %s", tsig.Name, s.KeyName)
+	}
+	if !tsigTimeValid(tsig) {
+		return ErrTSIGBadTime
+	}
+	h := s.digest()
+	h.Write(tsigUnsignedMessage(raw, p.TSIGStart, tsig.OriginalID))
+	if err := writeTSIGVariables(h, tsig, s.messages > 0); err != nil {
+		return err
+	}
+	if !hmac.Equal(tsig.MAC, h.Sum(nil)) {
+		return ErrTSIGBadSig
+	}
+	if tsig.Error != 0 {
+		return fmt.Errorf("TSIG error %d", tsig.Error)
+	}
+
+	s.priorMAC = tsig.MAC
+	s.pending = nil
+	s.messages++
+	return nil
+}
+
+// Done reports an error if the stream ended with unsigned messages, which the
+// last message of an exchange must never be.
+func (s *TSIGStream) Done() error {
+	if len(s.pending) > 0 {
+		return fmt.Errorf("%d trailing messages are not signed", len(s.pending))
+	}
+	return nil
+}
+
+// digest starts an HMAC over the prior MAC, prefixed with its length, and any
+// unsigned messages received since.
+func (s *TSIGStream) digest() hash.Hash {
+	h := hmac.New(md5.New, s.Secret)
+	h.Write([]byte{byte(len(s.priorMAC) >> 8), byte(len(s.priorMAC))})
+	h.Write(s.priorMAC)
+	for _, msg := range s.pending {
+		h.Write(msg)
+	}
+	return h
+}
+
+// newTSIGRecord returns an unsigned TSIG record for a message with the given ID.
+func newTSIGRecord(keyName string, id uint16) DNSRecord {
+	return DNSRecord{
+		Name:          keyName,
+		Type:          TSIG,
+		Class:         255, // ANY
+		TTL:           0,
+		AlgorithmName: TSIGAlgorithm,
+		TimeSigned:    tsigNow(),
+		Fudge:         300,
+		OriginalID:    id,
+	}
+}
+
+func tsigNow() uint64 {
+	u := time.Now().Unix()
+	if u < 0 {
+		return 0
+	}
+	return uint64(u)
+}
+
+// tsigTimeValid reports whether a TSIG record was signed within its fudge window.
+func tsigTimeValid(tsig DNSRecord) bool {
+	now := tsigNow()
+	drift := now - tsig.TimeSigned
+	if tsig.TimeSigned > now {
+		drift = tsig.TimeSigned - now
+	}
+	return drift <= uint64(tsig.Fudge)
+}
+
+// tsigUnsignedMessage rebuilds a message as it was before the TSIG record at
+// tsigStart was added: without the record, with ARCOUNT lowered by one and with
+// the original message ID.
+func tsigUnsignedMessage(raw []byte, tsigStart int, originalID uint16) []byte {
+	msg := make([]byte, tsigStart)
+	copy(msg, raw[:tsigStart])
+	if len(msg) >= 12 {
+		msg[0] = byte(originalID >> 8)
+		msg[1] = byte(originalID)
+		arCount := uint16(msg[10])<<8 | uint16(msg[11])
+		if arCount > 0 {
+			arCount--
+		}
+		msg[10] = byte(arCount >> 8)
+		msg[11] = byte(arCount)
+	}
+	return msg
+}
+
+// writeTSIGVariables adds the TSIG variables to a MAC (RFC 8945, Section 4.3.3),
+// with names in canonical form. Later messages of a stream cover only the timers.
+func writeTSIGVariables(h hash.Hash, tsig DNSRecord, timersOnly bool) error {
+	vBuf := NewBytePacketBuffer()
+	if !timersOnly {
+		if err := vBuf.WriteName(strings.ToLower(tsig.Name)); err != nil {
+			return err
+		}
+		if err := vBuf.Writeu16(tsig.Class); err != nil {
+			return err
+		}
+		if err := vBuf.Writeu32(tsig.TTL); err != nil {
+			return err
+		}
+		if err := vBuf.WriteName(strings.ToLower(tsig.AlgorithmName)); err != nil {
+			return err
+		}
+	}
+	if err := vBuf.Writeu16(uint16(tsig.TimeSigned >> 32)); err != nil {
+		return err
+	} // #nosec G115
+	if err := vBuf.Writeu32(uint32(tsig.TimeSigned & 0xFFFFFFFF)); err != nil {
+		return err
+	} // #nosec G115
+	if err := vBuf.Writeu16(tsig.Fudge); err != nil {
+		return err
+	}
+	if !timersOnly {
+		if err := vBuf.Writeu16(tsig.Error); err != nil {
+			return err
+		}
+		if err := vBuf.Writeu16(uint16(len(tsig.Other))); err != nil {
+			return err
+		} // #nosec G115
+		if err := vBuf.WriteRange(vBuf.Position(), tsig.Other); err != nil {
+			return err
+		}
+	}
+	h.Write(vBuf.Buf[:vBuf.Position()])
+	return nil
+}
@@ -1,6 +1,7 @@
 package packet
 
 import (
+	"errors"
 	"testing"
 	"time"
 )
@@ -59,11 +60,90 @@ func TestVerifyTSIG_ErrorPaths(t *testing.T) {
 	// Correctly sign the packet first.
 	_ = pClean.SignTSIG(bufClean, keyName, secret)
 	
-	// Tamper with the raw buffer (change the first byte of the header).
-	bufClean.Buf[0] = 0xFF 
+	// Tamper with the raw buffer (flip the header flags). The ID is not covered
+	// as such: the MAC uses the original ID from the TSIG record.
+	bufClean.Buf[2] ^= 0xFF
 	
 	err = pClean.VerifyTSIG(bufClean.Buf[:bufClean.Position()], pClean.TSIGStart, secret)
 	if err == nil || err.Error() != "TSIG MAC mismatch" {
 		t.Errorf("Expected 'TSIG MAC mismatch', got %v", err)
 	}
 }
+
+func TestTSIGStream(t *testing.T) {
+	keyName := "xfer-key."
+	secret := []byte("secret")
+
+	// A signed request opens the stream
+	req := NewDNSPacket()
+	req.Header.ID = 77
+	req.Questions = append(req.Questions, DNSQuestion{Name: "example.com.", QType: AXFR, QClass: 1})
+	reqBuf := NewBytePacketBuffer()
+	_ = req.Write(reqBuf)
+	if err := req.SignTSIG(reqBuf, keyName, secret); err != nil {
+		t.Fatalf("SignTSIG failed: %v", err)
+	}
+	requestMAC := req.Resources[len(req.Resources)-1].MAC
+
+	message := func(host string) []byte {
+		resp := NewDNSPacket()
+		resp.Header.ID = 77
+		resp.Header.Response = true
+		resp.Answers = append(resp.Answers, DNSRecord{Name: host, Type: A, Class: 1, TTL: 60, IP: []byte{192, 0, 2, 1}})
+		buf := NewBytePacketBuffer()
+		_ = resp.Write(buf)
+		return append([]byte(nil), buf.Buf[:buf.Position()]...)
+	}
+	parse := func(raw []byte) *DNSPacket {
+		p := NewDNSPacket()
+		buf := NewBytePacketBuffer()
+		buf.Load(raw)
+		if err := p.FromBuffer(buf); err != nil {
+			t.Fatalf("failed to parse signed message: %v", err)
+		}
+		return p
+	}
+
+	signer := NewTSIGStream(keyName, secret, requestMAC)
+	verifier := NewTSIGStream(keyName, secret, requestMAC)
+	for _, host := range []string{"a.example.com.", "b.example.com.", "c.example.com."} {
+		signed, err := signer.SignMessage(message(host))
+		if err != nil {
+			t.Fatalf("SignMessage failed: %v", err)
+		}
+		p := parse(signed)
+		if p.TSIGStart == -1 || p.Header.ResourceEntries != 1 {
+			t.Fatalf("Expected a TSIG record appended, got %+v", p.Header)
+		}
+		if err := verifier.Verify(p, signed); err != nil {
+			t.Errorf("Verify of %s failed: %v", host, err)
+		}
+	}
+
+	// Unsigned messages may follow, but the stream must end signed
+	unsigned := message("d.example.com.")
+	if err := verifier.Verify(parse(unsigned), unsigned); err != nil {
+		t.Errorf("Expected an unsigned message inside the stream to be accepted, got %v", err)
+	}
+	if err := verifier.Done(); err == nil {
+		t.Error("Expected a stream ending unsigned to be rejected")
+	}
+
+	// Responses only verify against the MAC of the request they answer
+	signed, _ := NewTSIGStream(keyName, secret, []byte("other request")).SignMessage(message("a.example.com."))
+	if err := NewTSIGStream(keyName, secret, requestMAC).Verify(parse(signed), signed); !errors.Is(err, ErrTSIGBadSig) {
+		t.Errorf("Expected a MAC chained to another request to fail, got %v", err)
+	}
+	if err := NewTSIGStream(keyName, secret, requestMAC).Verify(parse(unsigned), unsigned); err == nil {
+		t.Error("Expected an unsigned first message to be rejected")
+	}
+
+	// Error replies carry the code without a MAC
+	out, err := AppendUnsignedTSIG(message("a.example.com."), keyName, TSIGErrBadKey)
+	if err != nil {
+		t.Fatalf("AppendUnsignedTSIG failed: %v", err)
+	}
+	if tsig := parse(out).Resources[0]; tsig.Error != TSIGErrBadKey || len(tsig.MAC) != 0 {
+		t.Errorf("Expected an unsigned BADKEY record, got %+v", tsig)
+	}
+}
@@ -0,0 +1,134 @@
+package packet
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"strings"
+)
+
+var errTruncatedMessage = errors.New("truncated DNS message")
+
+// AgeTTLs subtracts elapsed seconds from the TTL of every resource record in a
+// wire-format message, in place, flooring at zero. OPT pseudo-records are skipped
+// because their TTL field carries EDNS flags, and RRSIG RDATA is never touched so
+// the Original TTL field keeps matching the signature (RFC 4034, Section 3.1.4).
+// It returns the lowest TTL left after aging, or 0 if the message has no records.
+func AgeTTLs(msg []byte, elapsed uint32) (uint32, error) {
+	return rewriteTTLs(msg, func(ttl uint32) uint32 {
+		if ttl > elapsed {
+			return ttl - elapsed
+		}
+		return 0
+	})
+}
+
+// SetTTLs sets the TTL of every resource record in a wire-format message to
+// ttl, in place, skipping OPT and RRSIG RDATA like AgeTTLs. It is used for stale
+// answers, which are served with a short fixed TTL (RFC 8767, Section 4).
+func SetTTLs(msg []byte, ttl uint32) error {
+	_, err := rewriteTTLs(msg, func(uint32) uint32 { return ttl })
+	return err
+}
+
+// rewriteTTLs replaces the TTL of every resource record but OPT with rewrite's
+// result and returns the lowest, or 0 if the message has no records.
+func rewriteTTLs(msg []byte, rewrite func(uint32) uint32) (uint32, error) {
+	if len(msg) < 12 {
+		return 0, errTruncatedMessage
+	}
+	qdCount := int(binary.BigEndian.Uint16(msg[4:6]))
+	rrCount := int(binary.BigEndian.Uint16(msg[6:8])) +
+		int(binary.BigEndian.Uint16(msg[8:10])) +
+		int(binary.BigEndian.Uint16(msg[10:12]))
+
+	pos := 12
+	for i := 0; i < qdCount; i++ {
+		next, err := skipWireName(msg, pos)
+		if err != nil {
+			return 0, err
+		}
+		pos = next + 4 // QTYPE + QCLASS
+	}
+
+	minTTL := uint32(math.MaxUint32)
+	seen := false
+	for i := 0; i < rrCount; i++ {
+		next, err := skipWireName(msg, pos)
+		if err != nil {
+			return 0, err
+		}
+		if next+10 > len(msg) {
+			return 0, errTruncatedMessage
+		}
+		rrType := QueryType(binary.BigEndian.Uint16(msg[next : next+2]))
+		rdLen := int(binary.BigEndian.Uint16(msg[next+8 : next+10]))
+
+		if rrType != OPT {
+			ttl := rewrite(binary.BigEndian.Uint32(msg[next+4 : next+8]))
+			binary.BigEndian.PutUint32(msg[next+4:next+8], ttl)
+			if ttl < minTTL {
+				minTTL = ttl
+			}
+			seen = true
+		}
+
+		pos = next + 10 + rdLen
+		if pos > len(msg) {
+			return 0, errTruncatedMessage
+		}
+	}
+
+	if !seen {
+		return 0, nil
+	}
+	return minTTL, nil
+}
+
+// skipWireName returns the offset just past the (possibly compressed) name at pos.
+func skipWireName(msg []byte, pos int) (int, error) {
+	for {
+		if pos >= len(msg) {
+			return 0, errTruncatedMessage
+		}
+		l := int(msg[pos])
+		switch {
+		case l == 0:
+			return pos + 1, nil
+		case l&0xC0 == 0xC0:
+			if pos+2 > len(msg) {
+				return 0, errTruncatedMessage
+			}
+			return pos + 2, nil
+		default:
+			pos += l + 1
+		}
+	}
+}
+
+// HarmonizeTTLs lowers the TTL of every record in an RRset to the smallest TTL in
+// that set, since RRsets must not carry differing TTLs (RFC 2181, Section 5.2).
+// OPT pseudo-records are left alone.
+func HarmonizeTTLs(records []DNSRecord) {
+	type rrsetKey struct {
+		name  string
+		qtype QueryType
+		class uint16
+	}
+	minTTL := make(map[rrsetKey]uint32)
+	for _, r := range records {
+		if r.Type == OPT {
+			continue
+		}
+		k := rrsetKey{strings.ToLower(r.Name), r.Type, r.Class}
+		if ttl, ok := minTTL[k]; !ok || r.TTL < ttl {
+			minTTL[k] = r.TTL
+		}
+	}
+	for i := range records {
+		if records[i].Type == OPT {
+			continue
+		}
+		records[i].TTL = minTTL[rrsetKey{strings.ToLower(records[i].Name), records[i].Type, records[i].Class}]
+	}
+}
@@ -0,0 +1,118 @@
+package packet
+
+import (
+	"net"
+	"testing"
+)
+
+func buildTTLTestMessage(t *testing.T) []byte {
+	t.Helper()
+	p := NewDNSPacket()
+	p.Header.Response = true
+	p.Questions = append(p.Questions, DNSQuestion{Name: "example.com.", QType: A})
+	p.Answers = append(p.Answers,
+		DNSRecord{Name: "example.com.", Type: A, Class: 1, TTL: 300, IP: net.ParseIP("192.0.2.1")},
+		DNSRecord{Name: "example.com.", Type: RRSIG, Class: 1, TTL: 300, TypeCovered: uint16(A), Algorithm: 13,
+			Labels: 2, OrigTTL: 300, Expiration: 2000000000, Inception: 1000000000, KeyTag: 1, SignerName: "example.com.", Signature: []byte{1, 2, 3}},
+	)
+	p.Resources = append(p.Resources, DNSRecord{Name: ".", Type: OPT, UDPPayloadSize: 4096, Z: 0x8000})
+
+	buf := NewBytePacketBuffer()
+	buf.HasNames = true
+	if err := p.Write(buf); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	return buf.Buf[:buf.Position()]
+}
+
+func TestAgeTTLs(t *testing.T) {
+	msg := buildTTLTestMessage(t)
+
+	minTTL, err := AgeTTLs(msg, 100)
+	if err != nil {
+		t.Fatalf("AgeTTLs failed: %v", err)
+	}
+	if minTTL != 200 {
+		t.Errorf("Expected min TTL 200, got %d", minTTL)
+	}
+
+	buf := NewBytePacketBuffer()
+	buf.Load(msg)
+	p := NewDNSPacket()
+	if err := p.FromBuffer(buf); err != nil {
+		t.Fatalf("FromBuffer failed: %v", err)
+	}
+	for _, a := range p.Answers {
+		if a.TTL != 200 {
+			t.Errorf("Expected %s TTL 200, got %d", a.Type, a.TTL)
+		}
+		if a.Type == RRSIG && a.OrigTTL != 300 {
+			t.Errorf("RRSIG original TTL must stay 300, got %d", a.OrigTTL)
+		}
+	}
+	if len(p.Resources) != 1 || p.Resources[0].Z != 0x8000 {
+		t.Errorf("OPT record flags must be preserved")
+	}
+
+	// Floors at zero
+	if minTTL, _ = AgeTTLs(msg, 1000); minTTL != 0 {
+		t.Errorf("Expected TTL floored at 0, got %d", minTTL)
+	}
+}
+
+func TestSetTTLs(t *testing.T) {
+	msg := buildTTLTestMessage(t)
+	if err := SetTTLs(msg, 30); err != nil {
+		t.Fatalf("SetTTLs failed: %v", err)
+	}
+
+	buf := NewBytePacketBuffer()
+	buf.Load(msg)
+	p := NewDNSPacket()
+	if err := p.FromBuffer(buf); err != nil {
+		t.Fatalf("FromBuffer failed: %v", err)
+	}
+	for _, a := range p.Answers {
+		if a.TTL != 30 {
+			t.Errorf("Expected %s TTL 30, got %d", a.Type, a.TTL)
+		}
+		if a.Type == RRSIG && a.OrigTTL != 300 {
+			t.Errorf("RRSIG original TTL must stay 300, got %d", a.OrigTTL)
+		}
+	}
+	if len(p.Resources) != 1 || p.Resources[0].Z != 0x8000 {
+		t.Errorf("OPT record flags must be preserved")
+	}
+	if err := SetTTLs(msg[:len(msg)-5], 30); err == nil {
+		t.Error("Expected error for truncated message")
+	}
+}
+
+func TestAgeTTLsMalformed(t *testing.T) {
+	if _, err := AgeTTLs([]byte{1, 2, 3}, 1); err == nil {
+		t.Error("Expected error for short message")
+	}
+	msg := buildTTLTestMessage(t)
+	if _, err := AgeTTLs(msg[:len(msg)-5], 1); err == nil {
+		t.Error("Expected error for truncated message")
+	}
+}
+
+func TestHarmonizeTTLs(t *testing.T) {
+	records := []DNSRecord{
+		{Name: "a.example.", Type: A, Class: 1, TTL: 300},
+		{Name: "A.example.", Type: A, Class: 1, TTL: 60},
+		{Name: "a.example.", Type: AAAA, Class: 1, TTL: 600},
+		{Name: ".", Type: OPT, TTL: 0x8000},
+	}
+	HarmonizeTTLs(records)
+	if records[0].TTL != 60 || records[1].TTL != 60 {
+		t.Errorf("Expected A RRset harmonized to 60, got %d/%d", records[0].TTL, records[1].TTL)
+	}
+	if records[2].TTL != 600 {
+		t.Errorf("Expected AAAA RRset untouched, got %d", records[2].TTL)
+	}
+	if records[3].TTL != 0x8000 {
+		t.Errorf("Expected OPT untouched, got %d", records[3].TTL)
+	}
+}
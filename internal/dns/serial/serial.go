@@ -0,0 +1,76 @@
+// Package serial implements SOA serial number arithmetic (RFC 1982).
+//
+// Serials are 32-bit counters that wrap around, so they must never be compared
+// with plain integer operators: 1 comes after 4294967295. Two serials are only
+// ordered when they are less than 2^31 apart; at exactly 2^31 the comparison is
+// undefined and neither is greater.
+package serial
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// half is 2^31, the distance at which serial comparison becomes undefined.
+const half = 1 << 31
+
+// MaxIncrement is the largest amount a serial may be increased by in one step.
+const MaxIncrement = half - 1
+
+// Greater reports whether a is newer than b.
+func Greater(a, b uint32) bool {
+	return (a < b && b-a > half) || (a > b && a-b < half)
+}
+
+// Less reports whether a is older than b.
+func Less(a, b uint32) bool {
+	return Greater(b, a)
+}
+
+// Add returns s increased by n, wrapping around. Increments larger than
+// MaxIncrement are not allowed since the result would compare as older.
+func Add(s uint32, n uint32) (uint32, error) {
+	if n > MaxIncrement {
+		return s, fmt.Errorf("serial increment %d exceeds %d", n, MaxIncrement)
+	}
+	return s + n, nil
+}
+
+// Next returns the serial following s.
+func Next(s uint32) uint32 {
+	return s + 1
+}
+
+// Parse parses a serial in decimal presentation format.
+func Parse(s string) (uint32, error) {
+	v, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid serial %q: %w", s, err)
+	}
+	return uint32(v), nil // #nosec G115 -- parsed with a 32-bit limit
+}
+
+// FromSOA extracts the serial from SOA content ("mname rname serial ...").
+func FromSOA(content string) (uint32, bool) {
+	fields := strings.Fields(content)
+	if len(fields) < 3 {
+		return 0, false
+	}
+	s, err := Parse(fields[2])
+	if err != nil {
+		return 0, false
+	}
+	return s, true
+}
+
+// SetSOA returns SOA content with its serial replaced by s. Content with fewer
+// than three fields is returned unchanged.
+func SetSOA(content string, s uint32) string {
+	fields := strings.Fields(content)
+	if len(fields) < 3 {
+		return content
+	}
+	fields[2] = strconv.FormatUint(uint64(s), 10)
+	return strings.Join(fields, " ")
+}
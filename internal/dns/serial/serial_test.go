@@ -0,0 +1,61 @@
+package serial
+
+import "testing"
+
+func TestGreater(t *testing.T) {
+	cases := []struct {
+		a, b uint32
+		want bool
+	}{
+		{2, 1, true},
+		{1, 2, false},
+		{5, 5, false},
+		{0, 4294967295, true}, // Wrapped past the maximum
+		{4294967295, 0, false},
+		{100, 4294967200, true},
+		{half, 0, false}, // Undefined at exactly 2^31
+		{0, half, false},
+		{half - 1, 0, true},
+	}
+	for _, tc := range cases {
+		if got := Greater(tc.a, tc.b); got != tc.want {
+			t.Errorf("Greater(%d, %d) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+		if tc.a != tc.b && tc.a-tc.b != half && Less(tc.b, tc.a) != tc.want {
+			t.Errorf("Less(%d, %d) disagrees with Greater", tc.b, tc.a)
+		}
+	}
+}
+
+func TestAddAndNext(t *testing.T) {
+	if got := Next(4294967295); got != 0 {
+		t.Errorf("Next(max) = %d, want 0", got)
+	}
+	got, err := Add(4294967290, 10)
+	if err != nil || got != 4 || !Greater(got, 4294967290) {
+		t.Errorf("Add wrapped to %d, %v", got, err)
+	}
+	if _, err := Add(1, MaxIncrement+1); err == nil {
+		t.Error("Expected an increment of 2^31 to be refused")
+	}
+}
+
+func TestSOA(t *testing.T) {
+	content := "ns1.example.com. admin.example.com. 2024010101 3600 600 86400 300"
+	s, ok := FromSOA(content)
+	if !ok || s != 2024010101 {
+		t.Fatalf("FromSOA = %d, %v", s, ok)
+	}
+	if got := SetSOA(content, 7); got != "ns1.example.com. admin.example.com. 7 3600 600 86400 300" {
+		t.Errorf("SetSOA = %q", got)
+	}
+	if _, ok := FromSOA("ns1. admin. 4294967296"); ok {
+		t.Error("Expected a serial over 32 bits to be rejected")
+	}
+	if _, ok := FromSOA("ns1. admin."); ok {
+		t.Error("Expected short content to be rejected")
+	}
+	if _, err := Parse("-1"); err == nil {
+		t.Error("Expected a negative serial to be rejected")
+	}
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+var errAffinityUnsupported = errors.New("CPU affinity is only supported on Linux")
+
+// parseCPUList parses a kernel CPU list such as "0-3,8,10-11".
+func parseCPUList(list string) ([]int, error) {
+	var cpus []int
+	for _, part := range strings.Split(strings.TrimSpace(list), ",") {
+		if part == "" {
+			continue
+		}
+		first, last, isRange := strings.Cut(part, "-")
+		lo, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CPU list %q", list)
+		}
+		hi := lo
+		if isRange {
+			if hi, err = strconv.Atoi(last); err != nil || hi < lo {
+				return nil, fmt.Errorf("invalid CPU list %q", list)
+			}
+		}
+		for cpu := lo; cpu <= hi; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus, nil
+}
+
+// cpuGroups returns the CPUs UDP listeners are pinned to, one group per NUMA
+// node the process may run on, or nil when CPUAffinity is off or unavailable.
+func (s *Server) cpuGroups() [][]int {
+	if !s.CPUAffinity {
+		return nil
+	}
+	groups, err := numaCPUGroups()
+	if err != nil {
+		s.Logger.Warn("CPU affinity disabled", "error", err)
+		return nil
+	}
+	s.Logger.Info("pinning UDP listeners and workers to NUMA nodes", "nodes", len(groups))
+	return groups
+}
+
+// groupCPUs returns the CPU group of the i-th listener, or nil without groups.
+func groupCPUs(groups [][]int, i int) []int {
+	if len(groups) == 0 {
+		return nil
+	}
+	return groups[i%len(groups)]
+}
+
+// pinGoroutine locks the calling goroutine to its OS thread and restricts the
+// thread to cpus. The goroutine keeps the thread for its lifetime, and the
+// runtime discards the thread when the goroutine exits.
+func (s *Server) pinGoroutine(cpus []int) {
+	if cpus == nil {
+		return
+	}
+	runtime.LockOSThread()
+	if err := pinThread(cpus); err != nil {
+		s.Logger.Warn("failed to set CPU affinity", "cpus", cpus, "error", err)
+	}
+}
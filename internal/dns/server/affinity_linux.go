@@ -0,0 +1,60 @@
+//go:build linux
+
+package server
+
+import (
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+// numaCPUGroups lists the CPUs of each NUMA node that the process is allowed to
+// run on. Machines without NUMA information form a single group.
+func numaCPUGroups() ([][]int, error) {
+	var allowed unix.CPUSet
+	if err := unix.SchedGetaffinity(0, &allowed); err != nil {
+		return nil, err
+	}
+	nodes, _ := filepath.Glob("/sys/devices/system/node/node[0-9]*/cpulist")
+	var groups [][]int
+	for _, path := range nodes {
+		data, err := os.ReadFile(path) // #nosec G304 -- fixed sysfs path
+		if err != nil {
+			return nil, err
+		}
+		cpus, err := parseCPUList(string(data))
+		if err != nil {
+			return nil, err
+		}
+		var group []int
+		for _, cpu := range cpus {
+			if allowed.IsSet(cpu) {
+				group = append(group, cpu)
+			}
+		}
+		if len(group) > 0 {
+			groups = append(groups, group)
+		}
+	}
+	if len(groups) > 0 {
+		return groups, nil
+	}
+
+	var all []int
+	for cpu := 0; len(all) < allowed.Count(); cpu++ {
+		if allowed.IsSet(cpu) {
+			all = append(all, cpu)
+		}
+	}
+	return [][]int{all}, nil
+}
+
+// pinThread restricts the calling OS thread to cpus.
+func pinThread(cpus []int) error {
+	var set unix.CPUSet
+	for _, cpu := range cpus {
+		set.Set(cpu)
+	}
+	return unix.SchedSetaffinity(0, &set)
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package server
+
+func numaCPUGroups() ([][]int, error) {
+	return nil, errAffinityUnsupported
+}
+
+func pinThread(cpus []int) error {
+	return errAffinityUnsupported
+}
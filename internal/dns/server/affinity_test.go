@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+func TestParseCPUList(t *testing.T) {
+	cpus, err := parseCPUList("0-3,8,10-11\n")
+	if err != nil {
+		t.Fatalf("parseCPUList failed: %v", err)
+	}
+	if want := []int{0, 1, 2, 3, 8, 10, 11}; !reflect.DeepEqual(cpus, want) {
+		t.Errorf("Expected %v, got %v", want, cpus)
+	}
+	for _, bad := range []string{"a", "3-1", "0-x"} {
+		if _, err := parseCPUList(bad); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestGroupCPUs(t *testing.T) {
+	groups := [][]int{{0, 1}, {2, 3}}
+	if got := groupCPUs(groups, 3); !reflect.DeepEqual(got, []int{2, 3}) {
+		t.Errorf("Expected listener 3 on the second node, got %v", got)
+	}
+	if got := groupCPUs(nil, 3); got != nil {
+		t.Errorf("Expected no CPUs without groups, got %v", got)
+	}
+}
+
+func TestRunWithCPUAffinity(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("CPU affinity is only supported on Linux")
+	}
+	probe, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to reserve a port: %v", err)
+	}
+	addr := probe.LocalAddr().String()
+	_ = probe.Close()
+
+	repo := &mockServerRepo{records: []domain.Record{{Name: "pinned.test.", Type: domain.TypeA, Content: "192.0.2.7", TTL: 60}}}
+	srv := NewServer(addr, repo, nil)
+	srv.CPUAffinity = true
+	srv.WorkerCount = 4
+	go func() { _ = srv.Run(context.Background()) }()
+	defer func() { _ = srv.Shutdown(context.Background()) }()
+
+	req := packet.NewDNSPacket()
+	req.Header.ID = 9
+	req.Questions = append(req.Questions, packet.DNSQuestion{Name: "pinned.test.", QType: packet.A})
+	buf := packet.NewBytePacketBuffer()
+	_ = req.Write(buf)
+
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	resp := make([]byte, 512)
+	for attempt := 0; attempt < 20; attempt++ {
+		_, _ = conn.Write(buf.Buf[:buf.Position()])
+		_ = conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		n, errRead := conn.Read(resp)
+		if errRead != nil {
+			// Refused until the listener is up
+			time.Sleep(50 * time.Millisecond)
+			continue
+		}
+		p := parseMessage(t, resp[:n])
+		if len(p.Answers) != 1 || p.Answers[0].IP.String() != "192.0.2.7" {
+			t.Fatalf("Unexpected answer %+v", p.Answers)
+		}
+		srv.mu.Lock()
+		queues := len(srv.udpQueues)
+		srv.mu.Unlock()
+		if queues != runtime.NumCPU() {
+			t.Errorf("Expected one queue per listener, got %d", queues)
+		}
+		return
+	}
+	t.Fatal("No answer from a server with CPU affinity")
+}
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+)
+
+// Bounds on ALIAS answers.
+const (
+	// maxAliasChain is how many ALIAS and CNAME records in a row are followed
+	// through our own zones from an ALIAS target.
+	maxAliasChain = 8
+	// minAliasTTL is the least TTL of an ALIAS answer, so a target with a very
+	// short TTL does not cost a lookup for almost every query.
+	minAliasTTL = 30
+)
+
+// errAliasLoop is returned when an ALIAS target leads back to a name already
+// followed, or through more than maxAliasChain names.
+var errAliasLoop = errors.New("ALIAS loop")
+
+// aliasAnswer answers an A or AAAA query for name from its ALIAS record, if it
+// has one: with the target's addresses of the type under the owner name. Each
+// address keeps the ALIAS record's view and geo scope, and the TTL is the
+// lower of the ALIAS record's and the target's, but at least minAliasTTL.
+func (s *Server) aliasAnswer(ctx context.Context, name string, qType domain.RecordType, clientIP string) ([]domain.Record, error) {
+	aliases, err := s.lookupRecords(ctx, name, domain.TypeALIAS, clientIP)
+	if err != nil || len(aliases) == 0 {
+		return nil, err
+	}
+	alias := aliases[0]
+	addrs, ttl, err := s.resolveAlias(ctx, name, alias.Content, qType, clientIP)
+	if err != nil {
+		return nil, err
+	}
+	ttl = min(alias.TTL, max(ttl, minAliasTTL))
+	records := make([]domain.Record, 0, len(addrs))
+	for _, addr := range addrs {
+		rec := alias
+		rec.Type, rec.Content, rec.TTL = qType, addr, ttl
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// resolveAlias returns the addresses of qType of an ALIAS target and their
+// TTL. A target in one of our zones is looked up there, following its ALIAS or
+// CNAME record when it has no addresses; any other target is resolved
+// recursively. No addresses and no error means the target has none.
+func (s *Server) resolveAlias(ctx context.Context, owner, target string, qType domain.RecordType, clientIP string) ([]string, int, error) {
+	seen := map[string]bool{dnsname.Normalize(owner): true}
+	chainTTL := -1
+	for {
+		key := dnsname.Normalize(target)
+		if seen[key] || len(seen) > maxAliasChain {
+			return nil, 0, fmt.Errorf("resolving the ALIAS target of %s: %w", owner, errAliasLoop)
+		}
+		seen[key] = true
+		if s.Repo == nil || s.hostedZone(ctx, target) == nil {
+			addrs, ttl, err := s.resolveAliasRecursive(target, qType)
+			return addrs, clampTTL(ttl, chainTTL), err
+		}
+
+		records, err := s.lookupRecords(ctx, target, qType, clientIP)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(records) > 0 {
+			addrs := make([]string, 0, len(records))
+			ttl := records[0].TTL
+			for _, rec := range records {
+				addrs = append(addrs, rec.Content)
+				ttl = min(ttl, rec.TTL)
+			}
+			return addrs, clampTTL(ttl, chainTTL), nil
+		}
+		next := ""
+		for _, t := range []domain.RecordType{domain.TypeALIAS, domain.TypeCNAME} {
+			links, err := s.lookupRecords(ctx, target, t, clientIP)
+			if err != nil {
+				return nil, 0, err
+			}
+			if len(links) > 0 {
+				next, chainTTL = links[0].Content, clampTTL(links[0].TTL, chainTTL)
+				break
+			}
+		}
+		if next == "" {
+			return nil, 0, nil
+		}
+		target = dnsname.Fqdn(next)
+	}
+}
+
+// resolveAliasRecursive resolves a target outside our zones from the root
+// hints, answering from the resolver cache when it can. Concurrent lookups of
+// the same target share one resolution.
+func (s *Server) resolveAliasRecursive(target string, qType domain.RecordType) ([]string, int, error) {
+	type result struct {
+		addrs []string
+		ttl   int
+	}
+	leader := false
+	v, err, _ := s.lookups.Do("alias\x00"+dnsname.Normalize(target)+"\x00"+string(qType), func() (interface{}, error) {
+		leader = true
+		resp, err := s.resolveRecursive(target, packet.RecordTypeToQueryType(qType))
+		if err != nil {
+			return nil, err
+		}
+		if resp.Header.ResCode != packet.RcodeNoError || len(resp.Answers) == 0 {
+			return result{}, nil
+		}
+		res := result{ttl: int(minRecordTTL(resp.Answers))}
+		for _, rr := range resp.Answers {
+			if rr.Type == packet.RecordTypeToQueryType(qType) && rr.IP != nil {
+				res.addrs = append(res.addrs, rr.IP.String())
+			}
+		}
+		return res, nil
+	})
+	if !leader {
+		metrics.CoalescedLookups.WithLabelValues("alias").Inc()
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	res := v.(result)
+	return res.addrs, res.ttl, nil
+}
+
+// clampTTL returns the lower of ttl and the TTL of the chain leading to it,
+// which is negative before any link.
+func clampTTL(ttl, chainTTL int) int {
+	if chainTTL < 0 {
+		return ttl
+	}
+	return min(ttl, chainTTL)
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+func TestAliasAnswers(t *testing.T) {
+	repo := &mockServerRepo{
+		zones: []domain.Zone{{ID: "z1", Name: "alias.test."}},
+		records: []domain.Record{
+			{ID: "1", ZoneID: "z1", Name: "alias.test.", Type: domain.TypeALIAS, Content: "www.example.com.", TTL: 3600},
+			{ID: "2", ZoneID: "z1", Name: "local.alias.test.", Type: domain.TypeALIAS, Content: "edge.alias.test.", TTL: 600},
+			{ID: "3", ZoneID: "z1", Name: "edge.alias.test.", Type: domain.TypeCNAME, Content: "origin.alias.test.", TTL: 120},
+			{ID: "4", ZoneID: "z1", Name: "origin.alias.test.", Type: domain.TypeA, Content: "192.0.2.20", TTL: 900},
+			{ID: "5", ZoneID: "z1", Name: "a.alias.test.", Type: domain.TypeALIAS, Content: "b.alias.test.", TTL: 300},
+			{ID: "6", ZoneID: "z1", Name: "b.alias.test.", Type: domain.TypeALIAS, Content: "a.alias.test.", TTL: 300},
+		},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+	queries := 0
+	srv.queryFn = fakeHierarchy(&queries)
+
+	// An external target is resolved recursively and answered under the apex
+	resp := queryFrom(t, srv, "alias.test.", "192.0.2.99:5353", false)
+	if len(resp.Answers) != 1 || resp.Answers[0].Name != "alias.test." || resp.Answers[0].IP.String() != "192.0.2.10" || resp.Answers[0].TTL != 300 {
+		t.Fatalf("Expected the target's address with its TTL under the apex, got %+v", resp.Answers)
+	}
+
+	// A local target is followed through its CNAME, and the chain's lowest TTL wins
+	resp = queryFrom(t, srv, "local.alias.test.", "192.0.2.99:5353", false)
+	if len(resp.Answers) != 1 || resp.Answers[0].Name != "local.alias.test." || resp.Answers[0].IP.String() != "192.0.2.20" || resp.Answers[0].TTL != 120 {
+		t.Fatalf("Expected the chased local address, got %+v", resp.Answers)
+	}
+
+	if _, err := srv.aliasAnswer(context.Background(), "a.alias.test.", domain.TypeA, ""); !errors.Is(err, errAliasLoop) {
+		t.Errorf("Expected errAliasLoop, got %v", err)
+	}
+	if resp := queryFrom(t, srv, "a.alias.test.", "192.0.2.99:5353", false); resp.Header.ResCode != packet.RcodeServFail {
+		t.Errorf("Expected SERVFAIL for an ALIAS loop, got rcode %d", resp.Header.ResCode)
+	}
+}
+
+func TestClampTTL(t *testing.T) {
+	if got := clampTTL(300, -1); got != 300 {
+		t.Errorf("Expected the TTL without a chain, got %d", got)
+	}
+	if got := clampTTL(300, 60); got != 60 {
+		t.Errorf("Expected the chain's TTL, got %d", got)
+	}
+}
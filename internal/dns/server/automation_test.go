@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
 )
 
 func TestServer_AutomateDNSSEC(t *testing.T) {
@@ -16,7 +17,7 @@ func TestServer_AutomateDNSSEC(t *testing.T) {
 	srv := NewServer("127.0.0.1:0", repo, nil)
 
 	// Manually trigger automation
-	srv.automateDNSSEC()
+	srv.automateDNSSEC(context.Background())
 
 	// Verify keys were generated for the zone
 	keys, _ := repo.ListKeysForZone(context.Background(), "z1")
@@ -24,3 +25,35 @@ func TestServer_AutomateDNSSEC(t *testing.T) {
 		t.Errorf("Expected at least 2 keys (KSK+ZSK), got %d", len(keys))
 	}
 }
+
+func TestServer_SecondaryZonesAreNotSigned(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockServerRepo{
+		zones: []domain.Zone{
+			{ID: "z1", Name: "secondary.test.", TenantID: "t1", Role: "slave", MasterServer: "192.0.2.53"},
+		},
+		records: []domain.Record{
+			{ID: "r1", ZoneID: "z1", Name: "www.secondary.test.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 300},
+		},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+
+	srv.automateDNSSEC(ctx)
+	if keys, _ := repo.ListKeysForZone(ctx, "z1"); len(keys) != 0 {
+		t.Fatalf("Expected no keys for a secondary zone, got %d", len(keys))
+	}
+
+	// Keys left over from when the zone was a primary do not sign its answers
+	if err := srv.DNSSEC.AutomateLifecycle(ctx, "z1"); err != nil {
+		t.Fatalf("AutomateLifecycle failed: %v", err)
+	}
+	resp := queryFrom(t, srv, "www.secondary.test.", "192.0.2.99:5353", true)
+	if len(resp.Answers) == 0 {
+		t.Fatal("Expected the secondary to answer")
+	}
+	for _, rr := range resp.Answers {
+		if rr.Type == packet.RRSIG {
+			t.Errorf("Expected an unsigned answer from a secondary, got %+v", rr)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+func TestHandlePacketCAA(t *testing.T) {
+	repo := &mockServerRepo{
+		zones: []domain.Zone{{ID: "z1", Name: "example.com."}},
+		records: []domain.Record{
+			{Name: "example.com.", Type: domain.TypeCAA, Content: `0 issue "letsencrypt.org"`, TTL: 3600},
+		},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+
+	req := packet.NewDNSPacket()
+	req.Questions = append(req.Questions, packet.DNSQuestion{Name: "example.com.", QType: packet.CAA})
+	buf := packet.NewBytePacketBuffer()
+	_ = req.Write(buf)
+	var raw []byte
+	if err := srv.handlePacket(buf.Buf[:buf.Position()], &net.UDPAddr{IP: net.ParseIP("192.0.2.10"), Port: 5353}, func(b []byte) error {
+		raw = append([]byte(nil), b...)
+		return nil
+	}, "udp"); err != nil {
+		t.Fatalf("handlePacket failed: %v", err)
+	}
+	resp := parseMessage(t, raw)
+	if resp.Header.ResCode != 0 || len(resp.Answers) != 1 {
+		t.Fatalf("Expected one CAA answer, got rcode %d and %+v", resp.Header.ResCode, resp.Answers)
+	}
+	if ans := resp.Answers[0]; ans.Type != packet.CAA || ans.Tag != "issue" || ans.Value != "letsencrypt.org" {
+		t.Errorf("Unexpected CAA answer %+v", ans)
+	}
+}
+
+func TestGenerateTypeBitMapWindows(t *testing.T) {
+	srv := NewServer("127.0.0.1:0", &mockServerRepo{}, nil)
+	got := srv.generateTypeBitMap([]domain.RecordType{domain.TypeA, domain.TypeCAA, "NSEC"})
+	// Window 0 holds A (1) and NSEC (47); window 1 holds CAA (257)
+	want := []byte{0, 6, 0x40, 0, 0, 0, 0, 0x01, 1, 1, 0x40}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Bitmap mismatch: got %x, want %x", got, want)
+	}
+}
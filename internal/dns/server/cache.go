@@ -4,122 +4,460 @@ import "context"
 
 import (
 	"hash/fnv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// DefaultCacheMaxEntries is how many entries each response cache of a
+	// server holds at most.
+	DefaultCacheMaxEntries = 500000
+	// DefaultCacheMaxBytes is about how much memory each response cache of a
+	// server takes at most.
+	DefaultCacheMaxBytes = 256 << 20
+	// DefaultServeStaleWindow is how long an expired entry is kept to be served
+	// stale (RFC 8767) while the database is unreachable.
+	DefaultServeStaleWindow = 5 * time.Minute
+	// ServeStaleTTL is the TTL of stale answers, so clients come back soon for
+	// a fresh one (RFC 8767, Section 4).
+	ServeStaleTTL = 30 * time.Second
 )
 
 // shardCount determines the number of internal shards to reduce lock contention.
 const shardCount = 256
 
+const (
+	// wheelSlots is the number of one-second slots in each shard's expiry
+	// wheel. Entries living longer than a revolution are looked at once per
+	// revolution until they are due.
+	wheelSlots = 512
+	// cacheSweepInterval is how often idle shards are swept, as busy shards
+	// already expire entries whenever they are written to.
+	cacheSweepInterval = time.Minute
+	// cacheEntryOverhead approximates the memory an entry takes beyond its key
+	// and data, for the byte limit.
+	cacheEntryOverhead = 128
+)
+
 type cacheEntry struct {
+	key       string
 	data      []byte
+	kind      string
+	storedAt  time.Time
 	expiresAt time.Time
+	evictAt   time.Time
+	slot      int64 // the expiry wheel slot holding the entry
+
+	// prev and next link the shard's LRU list, most recently used first
+	prev, next *cacheEntry
 }
 
+// aged returns a copy of the cached response with every TTL reduced by the time the
+// entry has spent in the cache, so downstream resolvers see a decreasing TTL.
+func (e cacheEntry) aged(now time.Time) []byte {
+	data := make([]byte, len(e.data))
+	copy(data, e.data)
+	if elapsed := now.Sub(e.storedAt); elapsed >= time.Second {
+		_, _ = packet.AgeTTLs(data, uint32(elapsed/time.Second)) // #nosec G115
+	}
+	return data
+}
+
+func (e *cacheEntry) size() int {
+	return len(e.key) + len(e.data) + cacheEntryOverhead
+}
+
+// cacheShard holds a share of the entries in an LRU list bounded by the cache
+// limits, and a timing wheel of the entries by the second they are removed.
 type cacheShard struct {
-	mu    sync.RWMutex
-	items map[string]cacheEntry
+	mu         sync.Mutex
+	items      map[string]*cacheEntry
+	head, tail *cacheEntry
+	bytes      int
+	wheel      map[int64]map[*cacheEntry]struct{} // by slot, allocated as used
+	tick       int64                              // last second the wheel was advanced to
+}
+
+func newCacheShard(now time.Time) *cacheShard {
+	return &cacheShard{
+		items: make(map[string]*cacheEntry),
+		wheel: make(map[int64]map[*cacheEntry]struct{}),
+		tick:  now.Unix(),
+	}
+}
+
+// CacheStats reports the size of a cache and what it has done since it was
+// created.
+type CacheStats struct {
+	Entries     int
+	Bytes       int
+	Hits        uint64
+	Misses      uint64
+	Evictions   uint64 // entries removed to stay within the limits
+	Expirations uint64 // entries removed by the expiry wheel
 }
 
 // DNSCache implements a sharded, thread-safe, in-memory cache for DNS responses.
 // Sharding is used to minimize lock contention during high-concurrency access.
+// Each shard evicts its least recently used entries beyond its share of the
+// configured limits and expires entries with a timing wheel instead of
+// scanning for them.
 type DNSCache struct {
-	shards [shardCount]*cacheShard
+	shards   [shardCount]*cacheShard
+	shardKey func(string) string
+
+	maxEntries  atomic.Int64 // per shard; 0 is unlimited
+	maxBytes    atomic.Int64 // per shard; 0 is unlimited
+	staleWindow atomic.Int64 // how long expired entries are kept, in nanoseconds
+
+	name                                 string
+	hits, misses, evictions, expirations atomic.Uint64
+	hitCounter, missCounter              prometheus.Counter
+	evictionCounter, expirationCounter   prometheus.Counter
+	staleAge                             prometheus.Observer
 }
 
-// NewDNSCache initializes a new DNSCache with pre-allocated shards and starts 
-// the background expiration cleanup loop.
+// NewDNSCache initializes a new unbounded DNSCache with pre-allocated shards
+// and starts the background expiration sweep.
 func NewDNSCache() *DNSCache {
-	c := &DNSCache{}
+	return newDNSCache("default", baseCacheKey)
+}
+
+// newDNSCache creates a cache reporting its metrics as name, placing keys in
+// shards by shardKey.
+func newDNSCache(name string, shardKey func(string) string) *DNSCache {
+	c := &DNSCache{
+		shardKey:          shardKey,
+		name:              name,
+		hitCounter:        metrics.CacheLookups.WithLabelValues(name, "hit"),
+		missCounter:       metrics.CacheLookups.WithLabelValues(name, "miss"),
+		evictionCounter:   metrics.CacheEvictions.WithLabelValues(name, "lru"),
+		expirationCounter: metrics.CacheEvictions.WithLabelValues(name, "expired"),
+		staleAge:          metrics.StaleAnswerAge.WithLabelValues(name),
+	}
+	c.staleWindow.Store(int64(DefaultServeStaleWindow))
+	now := time.Now()
 	for i := 0; i < shardCount; i++ {
-		c.shards[i] = &cacheShard{
-			items: make(map[string]cacheEntry),
-		}
+		c.shards[i] = newCacheShard(now)
 	}
-	// Background goroutine to periodically clean up expired items from all shards.
+	// Background goroutine to periodically expire items from idle shards.
 	go c.cleanupLoop()
 	return c
 }
 
+// baseCacheKey drops the variant of a key, such as the subnet of
+// "name:type|subnet".
+func baseCacheKey(key string) string {
+	if i := strings.IndexByte(key, '|'); i >= 0 {
+		key = key[:i]
+	}
+	return key
+}
+
+// SetLimits bounds the cache to about maxEntries entries and maxBytes bytes of
+// keys and responses, split evenly over the shards. Zero removes a limit.
+// Shards over their share drop their least recently used entries on the next
+// write.
+func (c *DNSCache) SetLimits(maxEntries, maxBytes int) {
+	c.maxEntries.Store(int64(perShard(maxEntries)))
+	c.maxBytes.Store(int64(perShard(maxBytes)))
+}
+
+// SetStaleWindow sets how long entries stored from now on are kept past their
+// TTL to be served stale; zero stops serving stale answers.
+func (c *DNSCache) SetStaleWindow(window time.Duration) {
+	c.staleWindow.Store(int64(max(window, 0)))
+}
+
+func perShard(limit int) int {
+	if limit <= 0 {
+		return 0
+	}
+	return (limit + shardCount - 1) / shardCount
+}
+
 // getShard returns the specific cacheShard responsible for the given key based on its hash.
+// Variants of a key, such as "name:type|subnet", share the shard of the key itself.
 func (c *DNSCache) getShard(key string) *cacheShard {
 	h := fnv.New32a()
-	h.Write([]byte(key)) // #nosec G104
+	h.Write([]byte(c.shardKey(key))) // #nosec G104
 	return c.shards[h.Sum32()%shardCount]
 }
 
-// Get retrieves a response from the cache. It returns (nil, false) if the key is missing 
-// or has already expired.
-func (c *DNSCache) Get(key string) ([]byte, bool) {
+// find returns a copy of the entry for key, marking it recently used. Expired
+// entries are only returned with stale, within their stale window.
+func (c *DNSCache) find(key string, now time.Time, stale bool) (cacheEntry, bool) {
 	shard := c.getShard(key)
-	shard.mu.RLock()
-	defer shard.mu.RUnlock()
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
 
 	item, found := shard.items[key]
+	if !found || (!stale && now.After(item.expiresAt)) || now.After(item.evictAt) {
+		c.misses.Add(1)
+		c.missCounter.Inc()
+		return cacheEntry{}, false
+	}
+	shard.touch(item)
+	c.hits.Add(1)
+	c.hitCounter.Inc()
+	return *item, true
+}
+
+// Get retrieves a copy of a response from the cache with its TTLs decremented by the
+// entry's age. It returns (nil, false) if the key is missing or has already expired.
+func (c *DNSCache) Get(key string) ([]byte, bool) {
+	now := time.Now()
+	item, found := c.find(key, now, false)
 	if !found {
 		return nil, false
 	}
+	return item.aged(now), true
+}
+
+// Lookup returns a copy of an unexpired response exactly as stored, along with how
+// long ago it was stored. Unlike Get the TTLs are not decremented, for callers that
+// report the age separately, such as the DoH Age header.
+func (c *DNSCache) Lookup(key string) ([]byte, time.Duration, bool) {
+	now := time.Now()
+	item, found := c.find(key, now, false)
+	if !found {
+		return nil, 0, false
+	}
+	data := make([]byte, len(item.data))
+	copy(data, item.data)
+	return data, now.Sub(item.storedAt), true
+}
 
-	// Check if the item is still valid.
-	if time.Now().After(item.expiresAt) {
+// GetStale retrieves a copy of a response even if its TTL has passed, as long
+// as its stale window has not. It is used to keep answering while the backend
+// is down.
+func (c *DNSCache) GetStale(key string) ([]byte, bool) {
+	now := time.Now()
+	item, found := c.find(key, now, true)
+	if !found {
 		return nil, false
 	}
+	return c.stale(item, now), true
+}
 
-	return item.data, true
+// stale returns a copy of a response served stale: aged if it has not expired
+// yet, otherwise with every TTL set to ServeStaleTTL. How long it had expired
+// is recorded.
+func (c *DNSCache) stale(item cacheEntry, now time.Time) []byte {
+	if !now.After(item.expiresAt) {
+		return item.aged(now)
+	}
+	c.staleAge.Observe(now.Sub(item.expiresAt).Seconds())
+	data := make([]byte, len(item.data))
+	copy(data, item.data)
+	_ = packet.SetTTLs(data, uint32(ServeStaleTTL/time.Second))
+	return data
 }
 
 // Set stores a response in the cache with a specific TTL.
 func (c *DNSCache) Set(key string, data []byte, ttl time.Duration) {
+	c.set(key, data, "", ttl)
+}
+
+func (c *DNSCache) set(key string, data []byte, kind string, ttl time.Duration) {
 	shard := c.getShard(key)
 	shard.mu.Lock()
 	defer shard.mu.Unlock()
 
-	shard.items[key] = cacheEntry{
+	now := time.Now()
+	c.advance(shard, now)
+	if old, found := shard.items[key]; found {
+		shard.remove(old)
+	}
+	item := &cacheEntry{
+		key:       key,
 		data:      data,
-		expiresAt: time.Now().Add(ttl),
+		kind:      kind,
+		storedAt:  now,
+		expiresAt: now.Add(ttl),
+		evictAt:   now.Add(ttl + time.Duration(c.staleWindow.Load())),
+	}
+	shard.insert(item)
+
+	maxEntries, maxBytes := int(c.maxEntries.Load()), int(c.maxBytes.Load())
+	for shard.tail != nil && ((maxEntries > 0 && len(shard.items) > maxEntries) || (maxBytes > 0 && shard.bytes > maxBytes)) {
+		shard.remove(shard.tail)
+		c.evictions.Add(1)
+		c.evictionCounter.Inc()
 	}
 }
 
-// Invalidate removes a specific key from the cache.
+// Invalidate removes a specific key, and its variants, from the cache.
 func (c *DNSCache) Invalidate(key string) {
+	prefix := key + "|"
+	c.removeIf(key, func(e *cacheEntry) bool {
+		return e.key == key || strings.HasPrefix(e.key, prefix)
+	})
+}
+
+// removeIf removes the entries of key's shard that match.
+func (c *DNSCache) removeIf(key string, match func(*cacheEntry) bool) {
 	shard := c.getShard(key)
 	shard.mu.Lock()
 	defer shard.mu.Unlock()
-	delete(shard.items, key)
+	for _, item := range shard.items {
+		if match(item) {
+			shard.remove(item)
+		}
+	}
 }
 
-// Flush removes all items from all shards in the cache.
 func (c *DNSCache) Ping(_ context.Context) error { return nil }
 
+// Flush removes all items from all shards in the cache.
 func (c *DNSCache) Flush() {
+	now := time.Now()
+	for i := 0; i < shardCount; i++ {
+		shard := c.shards[i]
+		shard.mu.Lock()
+		fresh := newCacheShard(now)
+		shard.items, shard.head, shard.tail, shard.bytes, shard.wheel, shard.tick = fresh.items, nil, nil, 0, fresh.wheel, fresh.tick
+		shard.mu.Unlock()
+	}
+}
+
+// Stats returns the cache's current size and counters.
+func (c *DNSCache) Stats() CacheStats {
+	stats := CacheStats{
+		Hits:        c.hits.Load(),
+		Misses:      c.misses.Load(),
+		Evictions:   c.evictions.Load(),
+		Expirations: c.expirations.Load(),
+	}
 	for i := 0; i < shardCount; i++ {
 		shard := c.shards[i]
 		shard.mu.Lock()
-		shard.items = make(map[string]cacheEntry)
+		stats.Entries += len(shard.items)
+		stats.Bytes += shard.bytes
 		shard.mu.Unlock()
 	}
+	return stats
 }
 
 // cleanupLoop periodically triggers the cache-wide cleanup process.
 func (c *DNSCache) cleanupLoop() {
-	ticker := time.NewTicker(5 * time.Minute)
+	ticker := time.NewTicker(cacheSweepInterval)
 	defer ticker.Stop()
 	for range ticker.C {
 		c.Cleanup()
 	}
 }
 
-// Cleanup scans all shards and deletes items that have passed their expiration time.
+// Cleanup advances the expiry wheel of every shard, removing the entries whose
+// stale retention has passed, and reports the cache size.
 func (c *DNSCache) Cleanup() {
 	now := time.Now()
+	entries, size := 0, 0
 	for i := 0; i < shardCount; i++ {
 		shard := c.shards[i]
 		shard.mu.Lock()
-		for k, v := range shard.items {
-			if now.After(v.expiresAt) {
-				delete(shard.items, k)
+		c.advance(shard, now)
+		entries += len(shard.items)
+		size += shard.bytes
+		shard.mu.Unlock()
+	}
+	metrics.CacheEntries.WithLabelValues(c.name).Set(float64(entries))
+	metrics.CacheBytes.WithLabelValues(c.name).Set(float64(size))
+}
+
+// advance turns a shard's expiry wheel to now, removing the entries that are
+// due in the slots it passes. After an idle period longer than a revolution
+// every slot is visited once.
+func (c *DNSCache) advance(shard *cacheShard, now time.Time) {
+	sec := now.Unix()
+	from := shard.tick + 1
+	if sec-from >= wheelSlots {
+		from = sec - wheelSlots + 1
+	}
+	for t := from; t <= sec; t++ {
+		for item := range shard.wheel[t%wheelSlots] {
+			if !item.evictAt.After(now) {
+				shard.remove(item)
+				c.expirations.Add(1)
+				c.expirationCounter.Inc()
 			}
 		}
-		shard.mu.Unlock()
 	}
+	if sec > shard.tick {
+		shard.tick = sec
+	}
+}
+
+// insert adds an entry at the front of the LRU list and into the wheel slot
+// of the second it is due, or the next slot to be visited if that has passed.
+func (s *cacheShard) insert(e *cacheEntry) {
+	s.items[e.key] = e
+	s.bytes += e.size()
+	s.pushFront(e)
+	e.slot = s.wheelSlot(e)
+	if _, ok := s.wheel[e.slot]; !ok {
+		s.wheel[e.slot] = make(map[*cacheEntry]struct{})
+	}
+	s.wheel[e.slot][e] = struct{}{}
+}
+
+func (s *cacheShard) wheelSlot(e *cacheEntry) int64 {
+	sec := e.evictAt.Unix()
+	if sec <= s.tick {
+		sec = s.tick + 1
+	}
+	return sec % wheelSlots
+}
+
+func (s *cacheShard) remove(e *cacheEntry) {
+	if s.items[e.key] != e {
+		return
+	}
+	delete(s.items, e.key)
+	s.bytes -= e.size()
+	s.unlink(e)
+	delete(s.wheel[e.slot], e)
+	if len(s.wheel[e.slot]) == 0 {
+		delete(s.wheel, e.slot)
+	}
+}
+
+// touch moves an entry to the front of the LRU list.
+func (s *cacheShard) touch(e *cacheEntry) {
+	if s.head == e {
+		return
+	}
+	s.unlink(e)
+	s.pushFront(e)
+}
+
+func (s *cacheShard) pushFront(e *cacheEntry) {
+	e.prev, e.next = nil, s.head
+	if s.head != nil {
+		s.head.prev = e
+	}
+	s.head = e
+	if s.tail == nil {
+		s.tail = e
+	}
+}
+
+func (s *cacheShard) unlink(e *cacheEntry) {
+	if e.prev != nil {
+		e.prev.next = e.next
+	} else {
+		s.head = e.next
+	}
+	if e.next != nil {
+		e.next.prev = e.prev
+	} else {
+		s.tail = e.prev
+	}
+	e.prev, e.next = nil, nil
 }
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+)
+
+const (
+	// DefaultCacheMaxResponseBytes is the largest response admitted to the caches.
+	DefaultCacheMaxResponseBytes = 4096
+	// DefaultCacheMaxResponseRecords is the most resource records a cached response
+	// may carry.
+	DefaultCacheMaxResponseRecords = 100
+)
+
+// CacheAdmission keeps oversized responses, such as giant TXT RRsets or wide
+// ANY-style answers, out of the response caches so they cannot evict many small,
+// hot entries. Sizes are measured on the name-compressed wire form that is
+// actually stored. A zero limit disables that check.
+type CacheAdmission struct {
+	MaxBytes   int
+	MaxRecords int
+}
+
+// defaultCacheAdmission returns the admission policy servers start with.
+func defaultCacheAdmission() CacheAdmission {
+	return CacheAdmission{MaxBytes: DefaultCacheMaxResponseBytes, MaxRecords: DefaultCacheMaxResponseRecords}
+}
+
+// admit reports whether a response of size bytes and records resource records
+// may be stored in the cache at level, counting rejections by reason. A negative
+// record count skips the record limit, for callers that only have the wire form.
+func (p CacheAdmission) admit(level string, size, records int) bool {
+	switch {
+	case p.MaxBytes > 0 && size > p.MaxBytes:
+		metrics.CacheAdmissionRejected.WithLabelValues(level, "size").Inc()
+		return false
+	case p.MaxRecords > 0 && records > p.MaxRecords:
+		metrics.CacheAdmissionRejected.WithLabelValues(level, "records").Inc()
+		return false
+	}
+	metrics.CachedResponseSize.WithLabelValues(level).Observe(float64(size))
+	return true
+}
+
+// responseRecordCount counts the resource records of a response, leaving out the
+// EDNS OPT pseudo-record.
+func responseRecordCount(p *packet.DNSPacket) int {
+	n := len(p.Answers) + len(p.Authorities)
+	for _, rr := range p.Resources {
+		if rr.Type != packet.OPT {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCacheAdmission(t *testing.T) {
+	var records []domain.Record
+	for i := 0; i < 40; i++ {
+		records = append(records, domain.Record{Name: "big.test.", Type: domain.TypeTXT, Content: fmt.Sprintf("%03d%s", i, strings.Repeat("x", 200)), TTL: 60})
+	}
+	for i := 0; i < 10; i++ {
+		records = append(records, domain.Record{Name: "wide.test.", Type: domain.TypeA, Content: fmt.Sprintf("192.0.2.%d", i+1), TTL: 60})
+	}
+	records = append(records, domain.Record{Name: "small.test.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 60})
+	srv := NewServer("127.0.0.1:0", &mockServerRepo{records: records}, nil)
+
+	query := func(name string, qType packet.QueryType) {
+		t.Helper()
+		req := packet.NewDNSPacket()
+		req.Header.ID = 7
+		req.Questions = append(req.Questions, packet.DNSQuestion{Name: name, QType: qType, QClass: 1})
+		req.Resources = append(req.Resources, packet.DNSRecord{Name: ".", Type: packet.OPT, UDPPayloadSize: 65000})
+		buf := packet.NewBytePacketBuffer()
+		if err := req.Write(buf); err != nil {
+			t.Fatalf("failed to write query: %v", err)
+		}
+		if err := srv.handlePacket(buf.Buf[:buf.Position()], "127.0.0.1:5353", func([]byte) error { return nil }, "tcp"); err != nil {
+			t.Fatalf("handlePacket failed: %v", err)
+		}
+	}
+
+	sizeRejects := testutil.ToFloat64(metrics.CacheAdmissionRejected.WithLabelValues("l1", "size"))
+	query("big.test.", packet.TXT)
+	if _, ok := srv.Cache.Get("big.test.:16"); ok {
+		t.Error("Expected a response over the size limit to stay out of the cache")
+	}
+	if got := testutil.ToFloat64(metrics.CacheAdmissionRejected.WithLabelValues("l1", "size")); got != sizeRejects+1 {
+		t.Errorf("Expected one size rejection, got %v", got-sizeRejects)
+	}
+
+	srv.CacheAdmission = CacheAdmission{MaxRecords: 5}
+	recordRejects := testutil.ToFloat64(metrics.CacheAdmissionRejected.WithLabelValues("l1", "records"))
+	query("wide.test.", packet.A)
+	if _, ok := srv.Cache.Get("wide.test.:1"); ok {
+		t.Error("Expected a response over the record limit to stay out of the cache")
+	}
+	if got := testutil.ToFloat64(metrics.CacheAdmissionRejected.WithLabelValues("l1", "records")); got != recordRejects+1 {
+		t.Errorf("Expected one record count rejection, got %v", got-recordRejects)
+	}
+
+	query("small.test.", packet.A)
+	if _, ok := srv.Cache.Get("small.test.:1"); !ok {
+		t.Error("Expected a small response to be cached")
+	}
+
+	// Without limits everything is admitted
+	srv.CacheAdmission = CacheAdmission{}
+	query("big.test.", packet.TXT)
+	if _, ok := srv.Cache.Get("big.test.:16"); !ok {
+		t.Error("Expected the large response cached with admission disabled")
+	}
+}
@@ -0,0 +1,245 @@
+package server
+
+import (
+	"context"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+// viewSyncInterval is how often the networks of split-horizon records, the
+// tenants' views and the locations of geo records are reloaded.
+var viewSyncInterval = 15 * time.Second
+
+// viewSet holds the networks split-horizon records are scoped to, the views
+// clients are matched against and the locations geo policies name.
+type viewSet struct {
+	networks []*net.IPNet
+	// views are in match order: by tenant, then priority
+	views     []clientViewRule
+	geoScopes map[string]bool
+}
+
+type clientViewRule struct {
+	id       string
+	tenantID string
+	networks []*net.IPNet
+}
+
+// scheduleViews reloads the split-horizon networks, views and geo locations
+// until ctx is done.
+func (s *Server) scheduleViews(ctx context.Context) {
+	if s.Repo == nil {
+		return
+	}
+	ticker := time.NewTicker(viewSyncInterval)
+	defer ticker.Stop()
+	for {
+		s.syncViews(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// syncViews loads the networks split-horizon records are scoped to, the views
+// of every tenant and the locations of geo records. The previous set is kept
+// if any of them fails to load.
+func (s *Server) syncViews(ctx context.Context) {
+	cidrs, err := s.Repo.ListRecordNetworks(ctx)
+	if err != nil {
+		s.Logger.Error("failed to load split-horizon networks", "error", err)
+		return
+	}
+	tenantViews, err := s.Repo.ListViews(ctx, "")
+	if err != nil {
+		s.Logger.Error("failed to load views", "error", err)
+		return
+	}
+	geoScopes, err := s.Repo.ListRecordGeoScopes(ctx)
+	if err != nil {
+		s.Logger.Error("failed to load geo record locations", "error", err)
+		return
+	}
+	views := &viewSet{geoScopes: make(map[string]bool, len(geoScopes))}
+	for _, scope := range geoScopes {
+		views.geoScopes[scope] = true
+	}
+	for _, cidr := range cidrs {
+		if _, network, errParse := net.ParseCIDR(cidr); errParse == nil {
+			views.networks = append(views.networks, network)
+		}
+	}
+	for _, v := range tenantViews {
+		rule := clientViewRule{id: v.ID, tenantID: v.TenantID}
+		for _, cidr := range v.Networks {
+			if _, network, errParse := net.ParseCIDR(cidr); errParse == nil {
+				rule.networks = append(rule.networks, network)
+			}
+		}
+		views.views = append(views.views, rule)
+	}
+	s.views.Store(views)
+}
+
+// clientView identifies the split-horizon records clientIP sees by the
+// networks containing it, the views it is in and the parts of its location geo
+// policies name, comma separated. Clients with the same view get the same
+// answer to every query; clients outside every network and view, and away from
+// every geo location, have the empty view.
+func (s *Server) clientView(clientIP string) string {
+	views := s.views.Load()
+	if views == nil {
+		return ""
+	}
+	ip := net.ParseIP(clientIP)
+	if ip == nil {
+		return ""
+	}
+	var matched []string
+	for _, network := range views.networks {
+		if network.Contains(ip) {
+			matched = append(matched, network.String())
+		}
+	}
+	for _, id := range s.matchViews(clientIP) {
+		matched = append(matched, "view:"+id)
+	}
+	if geo := s.clientGeo(clientIP); geo != "" {
+		matched = append(matched, geo)
+	}
+	return strings.Join(matched, ",")
+}
+
+// matchViews returns the IDs of the views addr is in, an address or a client
+// subnet in CIDR notation: for each tenant, the first of its views with a
+// network containing it.
+func (s *Server) matchViews(addr string) []string {
+	views := s.views.Load()
+	if views == nil || len(views.views) == 0 {
+		return nil
+	}
+	ip, bits := net.ParseIP(addr), -1
+	if ip == nil {
+		var subnet *net.IPNet
+		if _, subnet, _ = net.ParseCIDR(addr); subnet == nil {
+			return nil
+		}
+		ip = subnet.IP
+		bits, _ = subnet.Mask.Size()
+	}
+	var matched []string
+	tenant := ""
+	for _, v := range views.views {
+		if len(matched) > 0 && v.tenantID == tenant {
+			continue
+		}
+		for _, network := range v.networks {
+			ones, _ := network.Mask.Size()
+			if network.Contains(ip) && (bits < 0 || ones <= bits) {
+				matched = append(matched, v.id)
+				tenant = v.tenantID
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// selectView keeps the records a client in views sees. Records of the client's
+// views take the place of the shared records with the same name and type, and
+// records of other views are dropped.
+func selectView(records []domain.Record, views []string) []domain.Record {
+	scoped := false
+	for _, rec := range records {
+		if rec.ViewID != nil {
+			scoped = true
+			break
+		}
+	}
+	if !scoped {
+		return records
+	}
+	in := func(rec domain.Record) bool {
+		return rec.ViewID != nil && slices.Contains(views, *rec.ViewID)
+	}
+	type rrset struct {
+		name  string
+		rType domain.RecordType
+	}
+	overridden := make(map[rrset]bool)
+	for _, rec := range records {
+		if in(rec) {
+			overridden[rrset{strings.ToLower(rec.Name), rec.Type}] = true
+		}
+	}
+	kept := make([]domain.Record, 0, len(records))
+	for _, rec := range records {
+		if in(rec) || rec.ViewID == nil && !overridden[rrset{strings.ToLower(rec.Name), rec.Type}] {
+			kept = append(kept, rec)
+		}
+	}
+	return kept
+}
+
+// viewIncludes reports whether a record scoped to network belongs to view.
+// One that does not was added after the networks were last loaded, so the
+// answer holding it must not be cached under the view.
+func viewIncludes(view string, network *string) bool {
+	if network == nil {
+		return true
+	}
+	_, parsed, err := net.ParseCIDR(*network)
+	if err != nil {
+		return false
+	}
+	for _, cidr := range strings.Split(view, ",") {
+		if cidr == parsed.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheVariant returns what the cache key of a query adds to its "name:type":
+// the client's view and the DO bit, as DNSSEC records are only sent to
+// clients asking for them. Variants follow a "|", so invalidating "name:type"
+// drops them all.
+func cacheVariant(view string, dnssecOK bool) string {
+	var b strings.Builder
+	if view != "" {
+		b.WriteString("|view=")
+		b.WriteString(view)
+	}
+	if dnssecOK {
+		b.WriteString("|do")
+	}
+	return b.String()
+}
+
+// requestsDNSSEC reports whether a query set the DO bit (RFC 3225).
+func requestsDNSSEC(request *packet.DNSPacket) bool {
+	for _, res := range request.Resources {
+		if res.Type == packet.OPT {
+			return res.Z&0x8000 != 0
+		}
+	}
+	return false
+}
+
+// transferViews returns the views of the client at the other end of a zone
+// transfer, which sees the same variant of the zone its queries are answered
+// from.
+func (s *Server) transferViews(conn net.Conn) []string {
+	host, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+	if err != nil {
+		return nil
+	}
+	return s.matchViews(host)
+}
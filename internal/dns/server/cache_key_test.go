@@ -0,0 +1,291 @@
+package server
+
+import (
+	"context"
+	"net"
+	"slices"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+// splitHorizonRepo answers like the database: records scoped to a network are
+// only returned to clients inside it.
+type splitHorizonRepo struct {
+	*mockServerRepo
+}
+
+func (m *splitHorizonRepo) GetRecords(ctx context.Context, name string, qType domain.RecordType, clientIP string) ([]domain.Record, error) {
+	records, err := m.mockServerRepo.GetRecords(ctx, name, qType, clientIP)
+	if err != nil {
+		return nil, err
+	}
+	var res []domain.Record
+	for _, r := range records {
+		if r.Network != nil {
+			_, network, errParse := net.ParseCIDR(*r.Network)
+			if errParse != nil || !network.Contains(net.ParseIP(clientIP)) {
+				continue
+			}
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
+
+func TestCacheVariant(t *testing.T) {
+	tests := []struct {
+		view     string
+		dnssecOK bool
+		want     string
+	}{
+		{"", false, ""},
+		{"", true, "|do"},
+		{"10.0.0.0/8", false, "|view=10.0.0.0/8"},
+		{"10.0.0.0/8,10.1.0.0/16", true, "|view=10.0.0.0/8,10.1.0.0/16|do"},
+	}
+	for _, tt := range tests {
+		if got := cacheVariant(tt.view, tt.dnssecOK); got != tt.want {
+			t.Errorf("cacheVariant(%q, %v) = %q, want %q", tt.view, tt.dnssecOK, got, tt.want)
+		}
+	}
+	if got := baseCacheKey("www.test.:1" + cacheVariant("10.0.0.0/8", true)); got != "www.test.:1" {
+		t.Errorf("Expected variants to share the base key, got %q", got)
+	}
+}
+
+func TestClientView(t *testing.T) {
+	repo := &mockServerRepo{records: []domain.Record{
+		{Name: "a.test.", Type: domain.TypeA, Content: "10.1.1.1", Network: strPtr("10.0.0.0/8")},
+		{Name: "b.test.", Type: domain.TypeA, Content: "10.1.1.2", Network: strPtr("10.1.0.0/16")},
+		{Name: "c.test.", Type: domain.TypeA, Content: "192.0.2.1"},
+	}}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+	if view := srv.clientView("10.1.2.3"); view != "" {
+		t.Errorf("Expected no view before the networks are loaded, got %q", view)
+	}
+	srv.syncViews(context.Background())
+
+	tests := map[string]string{
+		"10.1.2.3":   "10.0.0.0/8,10.1.0.0/16",
+		"10.2.0.1":   "10.0.0.0/8",
+		"192.0.2.50": "",
+		"invalid":    "",
+	}
+	for ip, want := range tests {
+		if got := srv.clientView(ip); got != want {
+			t.Errorf("clientView(%q) = %q, want %q", ip, got, want)
+		}
+	}
+
+	if !viewIncludes("", nil) {
+		t.Error("Expected a global record in every view")
+	}
+	if !viewIncludes("10.0.0.0/8,10.1.0.0/16", strPtr("10.1.0.0/16")) {
+		t.Error("Expected a record scoped to a network of the view to belong to it")
+	}
+	if viewIncludes("10.0.0.0/8", strPtr("10.1.0.0/16")) {
+		t.Error("Expected a record from an unloaded network to fall outside the view")
+	}
+}
+
+func TestRequestsDNSSEC(t *testing.T) {
+	req := packet.NewDNSPacket()
+	if requestsDNSSEC(req) {
+		t.Error("Expected no DO bit without EDNS")
+	}
+	req.Resources = append(req.Resources, packet.DNSRecord{Name: ".", Type: packet.OPT, UDPPayloadSize: 4096})
+	if requestsDNSSEC(req) {
+		t.Error("Expected no DO bit in a plain OPT record")
+	}
+	req.Resources[0].Z = 0x8000
+	if !requestsDNSSEC(req) {
+		t.Error("Expected the DO bit to be read from the OPT record")
+	}
+}
+
+func queryFrom(t *testing.T, srv *Server, name string, clientAddr string, dnssecOK bool) *packet.DNSPacket {
+	t.Helper()
+	req := packet.NewDNSPacket()
+	req.Header.ID = 11
+	req.Questions = append(req.Questions, packet.DNSQuestion{Name: name, QType: packet.A})
+	if dnssecOK {
+		req.Resources = append(req.Resources, packet.DNSRecord{Name: ".", Type: packet.OPT, UDPPayloadSize: 4096, Z: 0x8000})
+	}
+	buffer := packet.NewBytePacketBuffer()
+	_ = req.Write(buffer)
+
+	var captured []byte
+	send := func(resp []byte) error {
+		captured = resp
+		return nil
+	}
+	if err := srv.handlePacket(buffer.Buf[:buffer.Position()], clientAddr, send, "udp"); err != nil {
+		t.Fatalf("HandlePacket failed: %v", err)
+	}
+	resp := packet.NewDNSPacket()
+	pb := packet.NewBytePacketBuffer()
+	pb.Load(captured)
+	if err := resp.FromBuffer(pb); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	return resp
+}
+
+func TestHandlePacketSplitHorizonCache(t *testing.T) {
+	base := &mockServerRepo{
+		zones: []domain.Zone{{ID: "z1", Name: "split.test."}},
+		records: []domain.Record{
+			{ZoneID: "z1", Name: "www.split.test.", Type: domain.TypeA, Content: "10.1.1.1", TTL: 300, Network: strPtr("10.0.0.0/8")},
+			{ZoneID: "z1", Name: "www.split.test.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 300},
+		},
+	}
+	srv := NewServer("127.0.0.1:0", &splitHorizonRepo{base}, nil)
+	srv.syncViews(context.Background())
+
+	inside := queryFrom(t, srv, "www.split.test.", "10.1.2.3:5353", false)
+	if len(inside.Answers) != 2 {
+		t.Fatalf("Expected the internal and global answers, got %d", len(inside.Answers))
+	}
+	outside := queryFrom(t, srv, "www.split.test.", "192.0.2.50:5353", false)
+	if len(outside.Answers) != 1 || outside.Answers[0].IP.String() != "192.0.2.1" {
+		t.Fatalf("Expected only the global answer outside the network, got %v", outside.Answers)
+	}
+
+	if _, found := srv.Cache.Get("www.split.test.:1|view=10.0.0.0/8"); !found {
+		t.Error("Expected the internal answer cached under its view")
+	}
+	if _, found := srv.Cache.Get("www.split.test.:1"); !found {
+		t.Error("Expected the global answer cached without a view")
+	}
+
+	// Both views go when the name changes
+	srv.invalidateL1("www.split.test.:1")
+	if _, found := srv.Cache.Get("www.split.test.:1|view=10.0.0.0/8"); found {
+		t.Error("Expected invalidation to drop every view")
+	}
+}
+
+func TestHandlePacketUnloadedViewNotCached(t *testing.T) {
+	base := &mockServerRepo{
+		zones: []domain.Zone{{ID: "z1", Name: "split.test."}},
+		records: []domain.Record{
+			{ZoneID: "z1", Name: "new.split.test.", Type: domain.TypeA, Content: "10.1.1.1", TTL: 300, Network: strPtr("10.0.0.0/8")},
+		},
+	}
+	srv := NewServer("127.0.0.1:0", &splitHorizonRepo{base}, nil)
+
+	// The network is not loaded yet, so the answer cannot be keyed by it
+	resp := queryFrom(t, srv, "new.split.test.", "10.1.2.3:5353", false)
+	if len(resp.Answers) != 1 {
+		t.Fatalf("Expected the internal answer, got %d", len(resp.Answers))
+	}
+	if _, found := srv.Cache.Get("new.split.test.:1"); found {
+		t.Error("Expected the answer from an unloaded network to stay uncached")
+	}
+}
+
+func TestHandlePacketDNSSECOKCacheKey(t *testing.T) {
+	repo := &mockServerRepo{
+		zones: []domain.Zone{{ID: "z1", Name: "do.test."}},
+		records: []domain.Record{
+			{ZoneID: "z1", Name: "www.do.test.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 300},
+		},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+
+	queryFrom(t, srv, "www.do.test.", "192.0.2.50:5353", true)
+	if _, found := srv.Cache.Get("www.do.test.:1|do"); !found {
+		t.Fatal("Expected the DO answer cached under its own key")
+	}
+	if _, found := srv.Cache.Get("www.do.test.:1"); found {
+		t.Error("Expected the DO answer kept apart from plain queries")
+	}
+	queryFrom(t, srv, "www.do.test.", "192.0.2.50:5353", false)
+	if _, found := srv.Cache.Get("www.do.test.:1"); !found {
+		t.Error("Expected the plain answer cached under the plain key")
+	}
+}
+
+func TestMatchViews(t *testing.T) {
+	repo := &mockServerRepo{views: []domain.View{
+		{ID: "office", TenantID: "t1", Networks: []string{"10.8.0.0/16"}, Priority: 5},
+		{ID: "internal", TenantID: "t1", Networks: []string{"10.0.0.0/8", "2001:db8::/32"}, Priority: 10},
+		{ID: "corp", TenantID: "t2", Networks: []string{"10.0.0.0/8"}, Priority: 1},
+	}}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+	srv.syncViews(context.Background())
+
+	tests := map[string][]string{
+		"10.8.1.1":       {"office", "corp"},
+		"10.9.1.1":       {"internal", "corp"},
+		"2001:db8::1":    {"internal"},
+		"192.0.2.1":      nil,
+		"10.8.1.0/24":    {"office", "corp"},
+		"10.0.0.0/7":     nil,
+		"not-an-address": nil,
+	}
+	for addr, want := range tests {
+		if got := srv.matchViews(addr); !slices.Equal(got, want) {
+			t.Errorf("matchViews(%q) = %v, want %v", addr, got, want)
+		}
+	}
+	if got := srv.clientView("10.9.1.1"); got != "view:internal,view:corp" {
+		t.Errorf("Expected the views in the client view, got %q", got)
+	}
+}
+
+func TestSelectView(t *testing.T) {
+	internal := "internal"
+	external := "external"
+	records := []domain.Record{
+		{Name: "www.view.test.", Type: domain.TypeA, Content: "192.0.2.1"},
+		{Name: "www.view.test.", Type: domain.TypeA, Content: "10.0.0.1", ViewID: &internal},
+		{Name: "www.view.test.", Type: domain.TypeA, Content: "198.51.100.1", ViewID: &external},
+		{Name: "www.view.test.", Type: domain.TypeTXT, Content: "shared"},
+		{Name: "api.view.test.", Type: domain.TypeA, Content: "192.0.2.2"},
+	}
+
+	got := selectView(records, []string{"internal"})
+	if len(got) != 3 || got[0].Content != "10.0.0.1" || got[1].Content != "shared" || got[2].Content != "192.0.2.2" {
+		t.Errorf("Expected the view's A record to replace the shared one, got %+v", got)
+	}
+	got = selectView(records, nil)
+	if len(got) != 3 || got[0].Content != "192.0.2.1" {
+		t.Errorf("Expected clients outside every view to see the shared records, got %+v", got)
+	}
+	if len(records) != 5 || records[1].Content != "10.0.0.1" {
+		t.Error("Expected the records passed in to be left alone")
+	}
+}
+
+func TestHandlePacketViews(t *testing.T) {
+	internal := "internal"
+	repo := &mockServerRepo{
+		zones: []domain.Zone{{ID: "z1", TenantID: "t1", Name: "view.test."}},
+		records: []domain.Record{
+			{ZoneID: "z1", Name: "www.view.test.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 300},
+			{ZoneID: "z1", Name: "www.view.test.", Type: domain.TypeA, Content: "10.0.0.1", TTL: 300, ViewID: &internal},
+		},
+		views: []domain.View{{ID: internal, TenantID: "t1", Name: "internal", Networks: []string{"10.0.0.0/8"}}},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+	srv.syncViews(context.Background())
+
+	inside := queryFrom(t, srv, "www.view.test.", "10.1.2.3:5353", false)
+	if len(inside.Answers) != 1 || inside.Answers[0].IP.String() != "10.0.0.1" {
+		t.Fatalf("Expected the internal variant inside the view, got %v", inside.Answers)
+	}
+	outside := queryFrom(t, srv, "www.view.test.", "192.0.2.50:5353", false)
+	if len(outside.Answers) != 1 || outside.Answers[0].IP.String() != "192.0.2.1" {
+		t.Fatalf("Expected the external variant outside the view, got %v", outside.Answers)
+	}
+	if _, found := srv.Cache.Get("www.view.test.:1|view=view:internal"); !found {
+		t.Error("Expected the internal variant cached under its view")
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
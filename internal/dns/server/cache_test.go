@@ -1,8 +1,11 @@
 package server
 
 import (
+	"net"
 	"testing"
 	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
 )
 
 func TestCacheSetGet(t *testing.T) {
@@ -84,3 +87,159 @@ func TestCacheInvalidate(t *testing.T) {
 		t.Error("key should be invalidated")
 	}
 }
+
+func TestCacheTTLDecrement(t *testing.T) {
+	cache := NewDNSCache()
+	key := "ttl.test.:1"
+
+	p := packet.NewDNSPacket()
+	p.Header.Response = true
+	p.Questions = append(p.Questions, packet.DNSQuestion{Name: "ttl.test.", QType: packet.A})
+	p.Answers = append(p.Answers, packet.DNSRecord{Name: "ttl.test.", Type: packet.A, Class: 1, TTL: 300, IP: net.ParseIP("192.0.2.1")})
+	buf := packet.NewBytePacketBuffer()
+	_ = p.Write(buf)
+	cache.Set(key, buf.Buf[:buf.Position()], 300*time.Second)
+
+	// Pretend the entry was stored 120 seconds ago
+	shard := cache.getShard(key)
+	entry := shard.items[key]
+	entry.storedAt = entry.storedAt.Add(-120 * time.Second)
+	shard.items[key] = entry
+
+	data, found := cache.Get(key)
+	if !found {
+		t.Fatalf("Expected to find key %s", key)
+	}
+	resBuf := packet.NewBytePacketBuffer()
+	resBuf.Load(data)
+	resp := packet.NewDNSPacket()
+	_ = resp.FromBuffer(resBuf)
+	if len(resp.Answers) != 1 || resp.Answers[0].TTL != 180 {
+		t.Errorf("Expected decremented TTL 180, got %+v", resp.Answers)
+	}
+
+	// The stored entry itself must keep the original TTL
+	again, _ := cache.Get(key)
+	resBuf.Load(again)
+	resp = packet.NewDNSPacket()
+	_ = resp.FromBuffer(resBuf)
+	if resp.Answers[0].TTL != 180 {
+		t.Errorf("Expected repeated reads to decrement from the original TTL, got %d", resp.Answers[0].TTL)
+	}
+}
+
+func TestCacheLRUEviction(t *testing.T) {
+	cache := NewDNSCache()
+	cache.SetLimits(2*shardCount, 0)
+
+	// Keys of one name share a shard, which holds two entries
+	cache.Set("lru.test.:1", []byte{1}, time.Hour)
+	cache.Set("lru.test.:1|10.0.0.0", []byte{2}, time.Hour)
+	cache.Get("lru.test.:1")
+	cache.Set("lru.test.:1|10.0.1.0", []byte{3}, time.Hour)
+
+	if _, found := cache.Get("lru.test.:1|10.0.0.0"); found {
+		t.Error("Expected the least recently used entry to be evicted")
+	}
+	for _, key := range []string{"lru.test.:1", "lru.test.:1|10.0.1.0"} {
+		if _, found := cache.Get(key); !found {
+			t.Errorf("Expected %s to stay cached", key)
+		}
+	}
+	if stats := cache.Stats(); stats.Entries != 2 || stats.Evictions != 1 || stats.Hits != 3 || stats.Misses != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestCacheByteLimit(t *testing.T) {
+	cache := NewDNSCache()
+	cache.SetLimits(0, shardCount*(2*cacheEntryOverhead+100))
+
+	cache.Set("big.test.:1", make([]byte, 60), time.Hour)
+	cache.Set("big.test.:1|a", make([]byte, 60), time.Hour)
+	if _, found := cache.Get("big.test.:1"); found {
+		t.Error("Expected the oldest entry to be evicted over the byte limit")
+	}
+	if stats := cache.Stats(); stats.Entries != 1 || stats.Bytes > 2*cacheEntryOverhead+100 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestCacheExpiryWheel(t *testing.T) {
+	cache := NewDNSCache()
+	cache.Set("gone.test.:1", []byte{1}, -DefaultServeStaleWindow-time.Second)
+	cache.Set("stale.test.:1", []byte{2}, -time.Second)
+	cache.Set("fresh.test.:1", []byte{3}, time.Hour)
+
+	// Entries already due sit in the next slot, reached a second later
+	later := time.Now().Add(2 * time.Second)
+	for _, shard := range cache.shards {
+		shard.mu.Lock()
+		cache.advance(shard, later)
+		shard.mu.Unlock()
+	}
+
+	if _, found := cache.GetStale("gone.test.:1"); found {
+		t.Error("Expected the wheel to remove an entry past its stale retention")
+	}
+	if _, found := cache.GetStale("stale.test.:1"); !found {
+		t.Error("Expected an expired entry to be kept for serving stale")
+	}
+	if _, found := cache.Get("fresh.test.:1"); !found {
+		t.Error("Expected a fresh entry to stay cached")
+	}
+	if stats := cache.Stats(); stats.Expirations != 1 || stats.Entries != 2 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func staleTestResponse(t *testing.T, name string, ttl uint32) []byte {
+	t.Helper()
+	resp := packet.NewDNSPacket()
+	resp.Header.Response = true
+	resp.Questions = append(resp.Questions, packet.DNSQuestion{Name: name, QType: packet.A})
+	resp.Answers = append(resp.Answers, packet.DNSRecord{Name: name, Type: packet.A, Class: 1, TTL: ttl, IP: net.ParseIP("192.0.2.1")})
+	buf := packet.NewBytePacketBuffer()
+	if err := resp.Write(buf); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	return buf.Buf[:buf.Position()]
+}
+
+func TestCacheServeStaleTTL(t *testing.T) {
+	cache := NewDNSCache()
+	cache.Set("stale.test.:1", staleTestResponse(t, "stale.test.", 3600), -time.Minute)
+
+	data, found := cache.GetStale("stale.test.:1")
+	if !found {
+		t.Fatal("Expected the expired entry within its stale window")
+	}
+	resp := packet.NewDNSPacket()
+	buf := packet.NewBytePacketBuffer()
+	buf.Load(data)
+	if err := resp.FromBuffer(buf); err != nil {
+		t.Fatalf("FromBuffer failed: %v", err)
+	}
+	if len(resp.Answers) != 1 || resp.Answers[0].TTL != uint32(ServeStaleTTL/time.Second) {
+		t.Errorf("Expected the stale answer with a %v TTL, got %+v", ServeStaleTTL, resp.Answers)
+	}
+}
+
+func TestCacheStaleWindow(t *testing.T) {
+	cache := NewDNSCache()
+	cache.SetStaleWindow(time.Minute)
+	cache.Set("recent.test.:1", []byte{1}, -30*time.Second)
+	cache.Set("old.test.:1", []byte{2}, -2*time.Minute)
+	if _, found := cache.GetStale("recent.test.:1"); !found {
+		t.Error("Expected an entry expired 30s ago to be served within a 1m window")
+	}
+	if _, found := cache.GetStale("old.test.:1"); found {
+		t.Error("Expected an entry expired 2m ago to be past a 1m window")
+	}
+
+	cache.SetStaleWindow(0)
+	cache.Set("off.test.:1", []byte{3}, -time.Second)
+	if _, found := cache.GetStale("off.test.:1"); found {
+		t.Error("Expected no stale answers with a zero window")
+	}
+}
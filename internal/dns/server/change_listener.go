@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+)
+
+// changeListenerRetry is how long the server waits before subscribing to
+// database changes again after the subscription fails.
+var changeListenerRetry = 5 * time.Second
+
+// startChangeListener keeps a subscription to DataChanges open until ctx is done.
+// Changes committed while the subscription was down are lost, so the cache is
+// flushed every time it is re-established.
+func (s *Server) startChangeListener(ctx context.Context) {
+	s.Logger.Info("started database change listener")
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			s.flushL1()
+		}
+		err := s.DataChanges.ListenDataChanges(ctx, s.applyDataChange)
+		if ctx.Err() != nil {
+			s.Logger.Info("stopping database change listener")
+			return
+		}
+		s.Logger.Warn("database change listener failed", "error", err, "retry_in", changeListenerRetry)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(changeListenerRetry):
+		}
+	}
+}
+
+// applyDataChange drops the cached answers a committed change made stale and,
+// with NotifyOnDataChange, sends NOTIFY for the zone. Zone changes, default
+// records, targets, CNAMEs and wildcards affect answers for other names and
+// types, so they flush the cache; anything else only invalidates its own name
+// and type.
+func (s *Server) applyDataChange(change domain.DataChange) {
+	metrics.DataChangesReceived.WithLabelValues(change.Table).Inc()
+	s.Logger.Debug("received database change", "table", change.Table, "op", change.Op, "zone", change.Zone, "name", change.Name, "type", change.Type)
+
+	// Reload default records and targets before the flush, or a query in
+	// between would cache an answer from the old ones again
+	var reload func(context.Context)
+	switch change.Table {
+	case "dns_default_records":
+		reload = s.syncDefaultRecords
+	case "dns_targets":
+		reload = s.syncTargets
+	}
+	if reload != nil && s.Repo != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		reload(ctx)
+		cancel()
+	}
+
+	if change.Name == "" || change.Type == domain.TypeCNAME || strings.HasPrefix(change.Name, "*.") {
+		s.flushL1()
+	} else if change.Type == domain.TypeALIAS {
+		// ALIAS records answer for the addresses of their owner
+		s.invalidateLocal(cacheChange{Name: dnsname.Normalize(change.Name)})
+	} else {
+		s.invalidateL1(fmt.Sprintf("%s:%d", dnsname.Normalize(change.Name), packet.RecordTypeToQueryType(change.Type)))
+	}
+
+	if s.NotifyOnDataChange && change.Table == "dns_records" && change.Zone != "" && !s.DisableAsync {
+		s.dataChanges.trigger(change.Zone, s.UpdateDebounce, func() {
+			go func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+				defer cancel()
+				s.sendNotifies(ctx, change.Zone, 0)
+			}()
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// fakeChangeSource fails its first subscription and then delivers changes until
+// the context ends.
+type fakeChangeSource struct {
+	calls   atomic.Int32
+	changes []domain.DataChange
+}
+
+func (f *fakeChangeSource) ListenDataChanges(ctx context.Context, fn func(domain.DataChange)) error {
+	if f.calls.Add(1) == 1 {
+		return errors.New("connection refused")
+	}
+	for _, c := range f.changes {
+		fn(c)
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func TestApplyDataChange(t *testing.T) {
+	srv := NewServer("127.0.0.1:0", &mockServerRepo{}, nil)
+	srv.Cache.Set("www.example.com.:1", []byte{1}, time.Minute)
+	srv.Cache.Set("www.example.com.:28", []byte{2}, time.Minute)
+	srv.Cache.Set("mail.example.com.:15", []byte{3}, time.Minute)
+
+	srv.applyDataChange(domain.DataChange{Table: "dns_records", Op: "UPDATE", Zone: "example.com.", Name: "WWW.example.com.", Type: domain.TypeA})
+	if _, ok := srv.Cache.Get("www.example.com.:1"); ok {
+		t.Error("Expected the changed A RRset to be invalidated")
+	}
+	if _, ok := srv.Cache.Get("www.example.com.:28"); !ok {
+		t.Error("Expected other types at the name to stay cached")
+	}
+
+	srv.applyDataChange(domain.DataChange{Table: "dns_records", Op: "INSERT", Zone: "example.com.", Name: "*.example.com.", Type: domain.TypeA})
+	if _, ok := srv.Cache.Get("mail.example.com.:15"); ok {
+		t.Error("Expected a wildcard change to flush the cache")
+	}
+}
+
+func TestStartChangeListener(t *testing.T) {
+	changeListenerRetry = 10 * time.Millisecond
+	defer func() { changeListenerRetry = 5 * time.Second }()
+
+	srv := NewServer("127.0.0.1:0", &mockServerRepo{}, nil)
+	src := &fakeChangeSource{changes: []domain.DataChange{{Table: "dns_zones", Op: "DELETE", Zone: "gone.test."}}}
+	srv.DataChanges = src
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		srv.startChangeListener(ctx)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for src.calls.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the listener to subscribe again after a failure")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the listener to stop with its context")
+	}
+}
@@ -85,9 +85,10 @@ func TestChaos_DBError_Query(t *testing.T) {
 
 	assert.NoError(t, err)
 	assert.Equal(t, uint16(5678), res.Header.ID)
-	// With no zone and no recursion enabled, the server defaults to NXDOMAIN (RCODE 3).
-	// Crucially, it must not panic and should still form a valid response packet.
-	assert.Equal(t, uint8(packet.RcodeNxDomain), res.Header.ResCode)
+	// With nothing cached to serve stale, the query fails with SERVFAIL rather than
+	// being mistaken for a name outside our zones. Crucially, it must not panic and
+	// should still form a valid response packet.
+	assert.Equal(t, uint8(packet.RcodeServFail), res.Header.ResCode)
 }
 
 func TestChaos_DBError_Update(t *testing.T) {
@@ -132,3 +133,20 @@ func TestChaos_DBError_Update(t *testing.T) {
 	// it should return SERVFAIL.
 	assert.Equal(t, uint8(packet.RcodeServFail), res.Header.ResCode)
 }
+
+func TestChaos_DBError_ServeStale(t *testing.T) {
+	mockRepo := new(testutil.MockRepo)
+	srv := NewServer("127.0.0.1:0", mockRepo, nil)
+	srv.DisableAsync = true
+	mockRepo.On("GetZone", mock.Anything).Return((*domain.Zone)(nil), errors.New("simulated db connection lost"))
+
+	// The answer expired a minute ago, before the database went down
+	srv.Cache.Set("stale.test.:1", staleTestResponse(t, "stale.test.", 300), -time.Minute)
+
+	res := ednsQuery(t, srv, "stale.test.", 1, false, false)
+	assert.Equal(t, uint8(packet.RcodeNoError), res.Header.ResCode)
+	if assert.Len(t, res.Answers, 1) {
+		assert.Equal(t, uint32(ServeStaleTTL/time.Second), res.Answers[0].TTL)
+	}
+	assert.Equal(t, []uint16{packet.EdeStaleAnswer}, edeCodes(res))
+}
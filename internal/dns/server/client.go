@@ -5,60 +5,92 @@ import (
 	"fmt"
 	"io"
 	"net"
-	"strings"
 	"time"
 
 	"github.com/poyrazK/cloudDNS/internal/adapters/repository"
 	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
 	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+	"github.com/poyrazK/cloudDNS/internal/dns/serial"
 )
 
 func (s *Server) refreshZone(zone *domain.Zone) {
-	if zone.MasterServer == "" {
-		s.Logger.Warn("slave zone has no master server configured", "zone", zone.Name)
-		return
+	if _, err := s.transferFromMaster(context.Background(), zone); err != nil {
+		s.Logger.Error("zone refresh failed", "zone", zone.Name, "error", err)
 	}
+}
+
+// transferFromMaster brings a secondary zone up to date with the first of its
+// masters that answers, and reschedules its next refresh.
+func (s *Server) transferFromMaster(ctx context.Context, zone *domain.Zone) (*domain.ZoneTransferResult, error) {
+	masters, err := domain.ParseMasters(zone.MasterServer)
+	if err != nil {
+		s.Logger.Warn("slave zone has no usable master server configured", "zone", zone.Name, "error", err)
+		return nil, fmt.Errorf("zone %s: %v: %w", zone.Name, err, domain.ErrInvalidZoneRole)
+	}
+	defer s.secondaries.lockTransfer(zone.ID)()
+
+	var errTransfer error
+	for _, masterAddr := range masters {
+		var res *domain.ZoneTransferResult
+		if res, errTransfer = s.transferFrom(ctx, zone, masterAddr); errTransfer == nil {
+			s.secondaryRefreshed(ctx, zone, nil)
+			return res, nil
+		}
+		s.Logger.Warn("zone refresh from master failed", "zone", zone.Name, "master", masterAddr, "error", errTransfer)
+	}
+	s.secondaryRefreshed(ctx, zone, errTransfer)
+	return nil, errTransfer
+}
 
-	masterAddr := net.JoinHostPort(zone.MasterServer, "53")
+// transferFrom brings a secondary zone up to date with one master, trying IXFR
+// first and falling back to AXFR.
+func (s *Server) transferFrom(ctx context.Context, zone *domain.Zone, masterAddr string) (*domain.ZoneTransferResult, error) {
 	s.Logger.Info("initiating zone refresh", "zone", zone.Name, "master", masterAddr)
+	res := &domain.ZoneTransferResult{ZoneID: zone.ID, Zone: zone.Name, Master: masterAddr, Method: "none"}
 
 	// 1. Query master for SOA
 	masterPacket, err := s.queryFn(masterAddr, zone.Name, packet.SOA)
 	if err != nil {
-		s.Logger.Error("failed to query master SOA", "zone", zone.Name, "error", err)
-		return
+		return nil, fmt.Errorf("failed to query master SOA: %v: %w", err, domain.ErrTransferFailed)
 	}
 
-	if len(masterPacket.Answers) == 0 || masterPacket.Answers[0].Type != packet.SOA {
-		s.Logger.Warn("master returned no SOA for zone", "zone", zone.Name)
-		return
+	// Only trust an SOA owned by the zone apex; anything else the master put in
+	// the answer is unsolicited.
+	scrubResponse(masterPacket, zone.Name, zone.Name)
+	var masterSOA *packet.DNSRecord
+	for i := range masterPacket.Answers {
+		if rr := &masterPacket.Answers[i]; rr.Type == packet.SOA && dnsname.Equal(rr.Name, zone.Name) {
+			masterSOA = rr
+			break
+		}
+	}
+	if masterSOA == nil {
+		return nil, fmt.Errorf("master returned no SOA for zone %s: %w", zone.Name, domain.ErrTransferFailed)
 	}
 
-	masterSOA := masterPacket.Answers[0]
+	res.MasterSerial = masterSOA.Serial
 
 	// 2. Get local SOA
-	records, err := s.Repo.GetRecords(context.Background(), zone.Name, domain.TypeSOA, "")
+	records, err := s.Repo.GetRecords(ctx, zone.Name, domain.TypeSOA, "")
 	if err != nil {
-		s.Logger.Error("failed to get local records for refresh", "zone", zone.Name, "error", err)
-		return
+		return nil, fmt.Errorf("failed to get local records for refresh: %w", err)
 	}
 
 	var localSerial uint32
 	if len(records) > 0 {
-		// Parse serial from SOA content
-		parts := strings.Fields(records[0].Content)
-		if len(parts) >= 3 {
-			if _, err := fmt.Sscanf(parts[2], "%d", &localSerial); err != nil {
-				s.Logger.Warn("failed to parse local SOA serial", "content", records[0].Content, "error", err)
-			}
+		var ok bool
+		if localSerial, ok = serial.FromSOA(records[0].Content); !ok {
+			s.Logger.Warn("failed to parse local SOA serial", "content", records[0].Content)
 		}
 	}
+	res.LocalSerial = localSerial
 
 	s.Logger.Info("comparing serials", "zone", zone.Name, "local", localSerial, "master", masterSOA.Serial)
 
-	if localSerial >= masterSOA.Serial && localSerial != 0 {
+	if localSerial != 0 && !serial.Greater(masterSOA.Serial, localSerial) {
 		s.Logger.Info("zone is up to date", "zone", zone.Name)
-		return
+		return res, nil
 	}
 
 	// 3. Initiate transfer: Try IXFR first, then fall back to AXFR
@@ -66,14 +98,28 @@ func (s *Server) refreshZone(zone *domain.Zone) {
 		s.Logger.Info("attempting IXFR", "zone", zone.Name, "from", localSerial)
 		if err := s.performIXFR(zone, masterAddr, localSerial); err == nil {
 			s.Logger.Info("IXFR successful", "zone", zone.Name)
-			return
+			s.zoneTransferred(zone)
+			res.Method = "IXFR"
+			return res, nil
 		} else {
 			s.Logger.Warn("IXFR failed, falling back to AXFR", "zone", zone.Name, "error", err)
 		}
 	}
 
 	if err := s.performAXFR(zone, masterAddr); err != nil {
-		s.Logger.Error("AXFR failed", "zone", zone.Name, "error", err)
+		return nil, fmt.Errorf("AXFR failed: %v: %w", err, domain.ErrTransferFailed)
+	}
+	s.zoneTransferred(zone)
+	res.Method = "AXFR"
+	return res, nil
+}
+
+// zoneTransferred drops answers cached from the previous version of a zone and
+// reports the change.
+func (s *Server) zoneTransferred(zone *domain.Zone) {
+	s.flushL1()
+	if s.ZoneChanges != nil {
+		s.ZoneChanges.ZoneChanged(zone.ID)
 	}
 }
 
@@ -154,7 +200,7 @@ func (s *Server) performIXFR(zone *domain.Zone, masterAddr string, localSerial u
 					return fmt.Errorf("first record must be SOA")
 				}
 				masterSerial = ans.Serial
-				if ans.Serial <= localSerial {
+				if !serial.Greater(ans.Serial, localSerial) {
 					return nil // Already up to date
 				}
 				first = false
@@ -206,17 +252,15 @@ func (s *Server) performIXFR(zone *domain.Zone, masterAddr string, localSerial u
 			dRec.TenantID = zone.TenantID
 			newRecords = append(newRecords, dRec)
 		}
-		if err := s.Repo.DeleteRecordsForZone(ctx, zone.ID); err != nil {
-			return fmt.Errorf("AXFR fallback failed to clear zone: %w", err)
-		}
-		if err := s.Repo.BatchCreateRecords(ctx, newRecords); err != nil {
+		if err := s.Repo.ReplaceZoneRecords(ctx, zone.ID, newRecords); err != nil {
 			return fmt.Errorf("AXFR fallback failed to import records: %w", err)
 		}
 		return nil
 	}
 
-	// Incremental logic: Apply Deletions then Additions
-	// The sequence is [SOA(old), deleted..., SOA(new), added...]
+	// Incremental: each sequence is [SOA(old), deleted..., SOA(new), added...],
+	// applied all or nothing so a failure leaves the previous version served
+	var diffs []domain.IXFRChunk
 	deleting := false
 	for _, r := range allRecords {
 		dRec, errConv := repository.ConvertPacketRecordToDomain(r, zone.ID)
@@ -224,34 +268,28 @@ func (s *Server) performIXFR(zone *domain.Zone, masterAddr string, localSerial u
 			s.Logger.Warn("failed to convert record in IXFR delta", "error", errConv)
 			return errConv
 		}
+		dRec.TenantID = zone.TenantID
 		if r.Type == packet.SOA {
 			deleting = !deleting
-			if !deleting {
-				// This is SOA(new), save it to update local serial
-				dRec.TenantID = zone.TenantID
-				if err := s.Repo.CreateRecord(ctx, &dRec); err != nil {
-					return fmt.Errorf("IXFR failed to save new SOA: %w", err)
-				}
+			if deleting {
+				diffs = append(diffs, domain.IXFRChunk{})
 			} else {
-				// This is SOA(old), delete it
-				if err := s.Repo.DeleteRecordSpecific(ctx, zone.ID, dRec.Name, dRec.Type, dRec.Content); err != nil {
-					return fmt.Errorf("IXFR failed to delete old SOA: %w", err)
-				}
+				diffs[len(diffs)-1].Serial = r.Serial
 			}
-			continue
 		}
+		if len(diffs) == 0 {
+			return fmt.Errorf("IXFR sequence does not start with an SOA")
+		}
+		diff := &diffs[len(diffs)-1]
 		if deleting {
-			if err := s.Repo.DeleteRecordSpecific(ctx, zone.ID, dRec.Name, dRec.Type, dRec.Content); err != nil {
-				return fmt.Errorf("IXFR failed to delete record: %w", err)
-			}
+			diff.Deleted = append(diff.Deleted, dRec)
 		} else {
-			dRec.TenantID = zone.TenantID
-			if err := s.Repo.CreateRecord(ctx, &dRec); err != nil {
-				return fmt.Errorf("IXFR failed to create record: %w", err)
-			}
+			diff.Added = append(diff.Added, dRec)
 		}
 	}
-
+	if err := s.Repo.ApplyZoneDiff(ctx, zone.ID, diffs); err != nil {
+		return fmt.Errorf("IXFR failed to apply changes: %w", err)
+	}
 	return nil
 }
 
@@ -322,8 +360,12 @@ func (s *Server) performAXFR(zone *domain.Zone, masterAddr string) error {
 		for _, ans := range resp.Answers {
 			if ans.Type == packet.SOA {
 				soaCount++
+				// The closing SOA repeats the opening one
+				if soaCount > 1 {
+					break
+				}
 			}
-			
+
 			dRec, err := repository.ConvertPacketRecordToDomain(ans, zone.ID)
 			if err != nil {
 				s.Logger.Warn("failed to convert packet record", "error", err)
@@ -341,11 +383,6 @@ func (s *Server) performAXFR(zone *domain.Zone, masterAddr string) error {
 
 	s.Logger.Info("AXFR received all records, updating repository", "zone", zone.Name, "count", len(newRecords))
 
-	// Atomic-ish update: delete all and batch create
-	ctx := context.Background()
-	if err := s.Repo.DeleteRecordsForZone(ctx, zone.ID); err != nil {
-		return fmt.Errorf("failed to clear old records: %w", err)
-	}
-
-	return s.Repo.BatchCreateRecords(ctx, newRecords)
+	// The old contents stay served until the new ones are committed
+	return s.Repo.ReplaceZoneRecords(context.Background(), zone.ID, newRecords)
 }
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"context"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+)
+
+// cnameChain answers a query for name and qType when name has a CNAME record
+// instead of records of the type (RFC 1034 3.6.2). It returns the CNAME and,
+// while targets are in zones we host, the CNAMEs of the names it leads to
+// followed by the records of qType of the last one. The chain stops at a
+// target outside our zones, which the resolver chases itself, and after
+// maxCNAMEChain links or on a loop. In a signed answer each RRset must be
+// signed by its own zone, so with sameZone the chain also stops at a target
+// in another zone. A name without a CNAME returns nothing.
+func (s *Server) cnameChain(ctx context.Context, zone *domain.Zone, name string, qType domain.RecordType, clientIP string, sameZone bool) ([]domain.Record, error) {
+	var chain []domain.Record
+	seen := map[string]bool{dnsname.Normalize(name): true}
+	owner := name
+	for {
+		links, err := s.lookupRecords(ctx, owner, domain.TypeCNAME, clientIP)
+		if err != nil {
+			return nil, err
+		}
+		if len(links) == 0 {
+			return chain, nil
+		}
+		// A name has one CNAME; extra ones scoped to views or regions are alternatives
+		link := links[0]
+		chain = append(chain, link)
+
+		target := dnsname.Fqdn(link.Content)
+		key := dnsname.Normalize(target)
+		if seen[key] || len(seen) > maxCNAMEChain {
+			return chain, nil
+		}
+		seen[key] = true
+		targetZone := s.hostedZone(ctx, target)
+		if targetZone == nil || (sameZone && targetZone.ID != zone.ID) {
+			return chain, nil
+		}
+
+		records, err := s.lookupRecords(ctx, target, qType, clientIP)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) > 0 {
+			return append(chain, records...), nil
+		}
+		owner = target
+	}
+}
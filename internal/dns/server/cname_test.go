@@ -0,0 +1,77 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+func TestCNAMEChasing(t *testing.T) {
+	repo := &mockServerRepo{
+		zones: []domain.Zone{{ID: "z1", Name: "chase.test."}, {ID: "z2", Name: "other.test."}},
+		records: []domain.Record{
+			{ID: "1", ZoneID: "z1", Name: "www.chase.test.", Type: domain.TypeCNAME, Content: "web.chase.test.", TTL: 300},
+			{ID: "2", ZoneID: "z1", Name: "web.chase.test.", Type: domain.TypeCNAME, Content: "origin.other.test.", TTL: 300},
+			{ID: "3", ZoneID: "z2", Name: "origin.other.test.", Type: domain.TypeA, Content: "192.0.2.30", TTL: 60},
+			{ID: "4", ZoneID: "z1", Name: "cdn.chase.test.", Type: domain.TypeCNAME, Content: "edge.example.net.", TTL: 300},
+			{ID: "5", ZoneID: "z1", Name: "a.chase.test.", Type: domain.TypeCNAME, Content: "b.chase.test.", TTL: 300},
+			{ID: "6", ZoneID: "z1", Name: "b.chase.test.", Type: domain.TypeCNAME, Content: "a.chase.test.", TTL: 300},
+		},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+
+	// The chain is followed across our zones to the target's addresses
+	resp := queryFrom(t, srv, "www.chase.test.", "192.0.2.99:5353", false)
+	if resp.Header.ResCode != packet.RcodeNoError || len(resp.Answers) != 3 {
+		t.Fatalf("Expected two CNAMEs and the address, got rcode %d: %+v", resp.Header.ResCode, resp.Answers)
+	}
+	if resp.Answers[0].Type != packet.CNAME || resp.Answers[0].Host != "web.chase.test." ||
+		resp.Answers[1].Type != packet.CNAME || resp.Answers[1].Host != "origin.other.test." ||
+		resp.Answers[2].Name != "origin.other.test." || resp.Answers[2].IP.String() != "192.0.2.30" {
+		t.Errorf("Expected the chain in order, got %+v", resp.Answers)
+	}
+
+	// The answer depends on other names, whose changes would not reach it
+	if _, found := srv.Cache.Get("www.chase.test.:1"); found {
+		t.Error("Expected a followed chain to stay out of the cache")
+	}
+	repo.mu.Lock()
+	repo.records[2].Content = "192.0.2.31"
+	repo.mu.Unlock()
+	srv.invalidateL1("origin.other.test.:1")
+	resp = queryFrom(t, srv, "www.chase.test.", "192.0.2.99:5353", false)
+	if len(resp.Answers) != 3 || resp.Answers[2].IP.String() != "192.0.2.31" {
+		t.Errorf("Expected the chain to reach the changed address, got %+v", resp.Answers)
+	}
+
+	// A signed answer stops at the zone boundary
+	resp = queryFrom(t, srv, "www.chase.test.", "192.0.2.99:5353", true)
+	cnames := 0
+	for _, rr := range resp.Answers {
+		if rr.Type == packet.A {
+			t.Errorf("Expected no record from another zone in a signed answer, got %+v", rr)
+		}
+		if rr.Type == packet.CNAME {
+			cnames++
+		}
+	}
+	if cnames != 2 {
+		t.Errorf("Expected both CNAMEs of the zone, got %+v", resp.Answers)
+	}
+
+	// A target outside our zones is left to the resolver
+	resp = queryFrom(t, srv, "cdn.chase.test.", "192.0.2.99:5353", false)
+	if resp.Header.ResCode != packet.RcodeNoError || len(resp.Answers) != 1 || resp.Answers[0].Host != "edge.example.net." {
+		t.Errorf("Expected only the CNAME, got rcode %d: %+v", resp.Header.ResCode, resp.Answers)
+	}
+	if _, found := srv.Cache.Get("cdn.chase.test.:1"); !found {
+		t.Error("Expected a lone CNAME, which is the name's own record, to be cached")
+	}
+
+	// A loop ends after each name has been answered once
+	resp = queryFrom(t, srv, "a.chase.test.", "192.0.2.99:5353", false)
+	if resp.Header.ResCode != packet.RcodeNoError || len(resp.Answers) != 2 {
+		t.Errorf("Expected the loop's two CNAMEs, got rcode %d: %+v", resp.Header.ResCode, resp.Answers)
+	}
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"context"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+)
+
+// lookupZone is Repo.GetZone with concurrent lookups of the same name sharing
+// one database query, so a burst of identical cache misses costs one round trip.
+func (s *Server) lookupZone(ctx context.Context, name string) (*domain.Zone, error) {
+	leader := false
+	v, err, _ := s.lookups.Do("zone\x00"+name, func() (interface{}, error) {
+		leader = true
+		return s.Repo.GetZone(ctx, name)
+	})
+	if !leader {
+		metrics.CoalescedLookups.WithLabelValues("zone").Inc()
+	}
+	zone, _ := v.(*domain.Zone)
+	if err != nil || zone == nil {
+		return nil, err
+	}
+	shared := *zone
+	return &shared, nil
+}
+
+// lookupRecords is Repo.GetRecords with concurrent lookups sharing one database
+// query. The client address is part of the key, as split-horizon records depend
+// on it; every caller gets its own copy of the records, narrowed to the views
+// the client is in and the geo records closest to it.
+func (s *Server) lookupRecords(ctx context.Context, name string, qType domain.RecordType, clientIP string) ([]domain.Record, error) {
+	leader := false
+	v, err, _ := s.lookups.Do("records\x00"+name+"\x00"+string(qType)+"\x00"+clientIP, func() (interface{}, error) {
+		leader = true
+		return s.Repo.GetRecords(ctx, name, qType, clientIP)
+	})
+	if !leader {
+		metrics.CoalescedLookups.WithLabelValues("records").Inc()
+	}
+	records, _ := v.([]domain.Record)
+	if err != nil || records == nil {
+		return nil, err
+	}
+	records = s.expandTargets(selectView(append([]domain.Record(nil), records...), s.matchViews(clientIP)))
+	records = selectHealthy(records, s.HealthFallback != HealthFallbackNone)
+	loc, located := s.locate(clientIP)
+	return selectGeo(records, loc, located), nil
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// blockingRepo holds every GetRecords call until release is closed.
+type blockingRepo struct {
+	*mockServerRepo
+	calls   atomic.Int32
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (m *blockingRepo) GetRecords(ctx context.Context, name string, qType domain.RecordType, clientIP string) ([]domain.Record, error) {
+	if m.calls.Add(1) == 1 {
+		close(m.entered)
+	}
+	<-m.release
+	return m.mockServerRepo.GetRecords(ctx, name, qType, clientIP)
+}
+
+func TestLookupRecordsCoalesced(t *testing.T) {
+	repo := &blockingRepo{
+		mockServerRepo: &mockServerRepo{records: []domain.Record{
+			{Name: "www.test.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 300},
+		}},
+		entered: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+
+	const callers = 8
+	results := make([][]domain.Record, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = srv.lookupRecords(context.Background(), "www.test.", domain.TypeA, "192.0.2.50")
+		}(i)
+	}
+	<-repo.entered
+	time.Sleep(50 * time.Millisecond) // let the other callers join the lookup in flight
+	close(repo.release)
+	wg.Wait()
+
+	if n := repo.calls.Load(); n != 1 {
+		t.Errorf("Expected one database lookup for identical queries, got %d", n)
+	}
+	for i, res := range results {
+		if len(res) != 1 || res[0].Content != "192.0.2.1" {
+			t.Fatalf("Caller %d got %v", i, res)
+		}
+	}
+	results[0][0].Name = "changed.test."
+	if results[1][0].Name != "www.test." {
+		t.Error("Expected every caller to get its own copy of the records")
+	}
+
+	// Lookups from other clients are not shared, as split-horizon answers differ
+	if _, err := srv.lookupRecords(context.Background(), "www.test.", domain.TypeA, "10.0.0.1"); err != nil {
+		t.Fatalf("lookupRecords failed: %v", err)
+	}
+	if n := repo.calls.Load(); n != 2 {
+		t.Errorf("Expected a new lookup once the first completed, got %d calls", n)
+	}
+}
+
+func TestLookupZone(t *testing.T) {
+	srv := NewServer("127.0.0.1:0", &mockServerRepo{zones: []domain.Zone{{ID: "z1", Name: "test."}}}, nil)
+	zone, err := srv.lookupZone(context.Background(), "test.")
+	if err != nil || zone == nil || zone.ID != "z1" {
+		t.Fatalf("Expected the zone, got %+v (%v)", zone, err)
+	}
+	if zone, err := srv.lookupZone(context.Background(), "other."); err != nil || zone != nil {
+		t.Errorf("Expected no zone, got %+v (%v)", zone, err)
+	}
+}
@@ -0,0 +1,205 @@
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+)
+
+const (
+	// ednsOptionCookie is the EDNS option code of DNS Cookies (RFC 7873).
+	ednsOptionCookie = 10
+	// rcodeBadCookie is the extended RCODE asking a client to retry with the
+	// server cookie it was just given (RFC 7873 Section 8).
+	rcodeBadCookie = 23
+
+	// DefaultCookieRotation is how often the server cookie secret changes.
+	DefaultCookieRotation = 24 * time.Hour
+
+	clientCookieLen = 8
+	serverCookieLen = 16
+	// cookieLifetime bounds the age of the timestamp in a server cookie, and
+	// cookieClockSkew how far it may lie in the future (RFC 9018 Section 4.3).
+	cookieLifetime  = time.Hour
+	cookieClockSkew = 5 * time.Minute
+)
+
+// CookieJar issues and checks server cookies. A cookie follows the layout of
+// RFC 9018: version 1, three reserved bytes, a timestamp and an 8-byte hash of
+// the client cookie, those fields and the client address, here keyed
+// HMAC-SHA256. The secret is per node and rotated; cookies made with the
+// previous secret stay valid, so a rotation does not reject any client.
+type CookieJar struct {
+	mu       sync.RWMutex
+	current  []byte
+	previous []byte
+	now      func() time.Time
+}
+
+// NewCookieJar returns a jar with a random secret.
+func NewCookieJar() *CookieJar {
+	j := &CookieJar{now: time.Now}
+	j.Rotate()
+	return j
+}
+
+// Rotate replaces the secret, keeping the old one for validation.
+func (j *CookieJar) Rotate() {
+	secret := make([]byte, 32)
+	_, _ = rand.Read(secret)
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.previous, j.current = j.current, secret
+}
+
+// Start rotates the secret every interval until ctx is done.
+func (j *CookieJar) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			j.Rotate()
+		}
+	}
+}
+
+// serverCookie returns a fresh server cookie for a client cookie and address.
+func (j *CookieJar) serverCookie(client []byte, ip net.IP) []byte {
+	cookie := make([]byte, 8, serverCookieLen)
+	cookie[0] = 1
+	binary.BigEndian.PutUint32(cookie[4:], uint32(j.now().Unix())) // #nosec G115
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return append(cookie, cookieHash(j.current, client, cookie, ip)...)
+}
+
+// valid reports whether server is a current cookie this node issued for the
+// client cookie and address.
+func (j *CookieJar) valid(client, server []byte, ip net.IP) bool {
+	if len(server) != serverCookieLen || server[0] != 1 {
+		return false
+	}
+	issued := time.Unix(int64(binary.BigEndian.Uint32(server[4:8])), 0)
+	now := j.now()
+	if issued.Before(now.Add(-cookieLifetime)) || issued.After(now.Add(cookieClockSkew)) {
+		return false
+	}
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	for _, secret := range [][]byte{j.current, j.previous} {
+		if secret != nil && hmac.Equal(server[8:], cookieHash(secret, client, server[:8], ip)) {
+			return true
+		}
+	}
+	return false
+}
+
+func cookieHash(secret, client, header []byte, ip net.IP) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(client)
+	mac.Write(header)
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	mac.Write(ip)
+	return mac.Sum(nil)[:8]
+}
+
+// queryCookie is the outcome of checking the COOKIE option of a query.
+type queryCookie struct {
+	client    []byte // nil when the query has no cookie
+	valid     bool   // the server cookie is one we issued to this client
+	malformed bool
+}
+
+// checkCookie reads and verifies the COOKIE option of request.
+func (j *CookieJar) checkCookie(request *packet.DNSPacket, clientIP string) queryCookie {
+	var data []byte
+	found := false
+	for _, res := range request.Resources {
+		if res.Type != packet.OPT {
+			continue
+		}
+		for _, opt := range res.Options {
+			if opt.Code == ednsOptionCookie {
+				data, found = opt.Data, true
+				break
+			}
+		}
+		break
+	}
+	if !found {
+		return queryCookie{}
+	}
+	// A client cookie alone, or with a server cookie of 8 to 32 bytes (Section 4)
+	if len(data) != clientCookieLen && (len(data) < clientCookieLen+8 || len(data) > clientCookieLen+32) {
+		metrics.DNSCookies.WithLabelValues("malformed").Inc()
+		return queryCookie{malformed: true}
+	}
+	c := queryCookie{client: data[:clientCookieLen]}
+	switch {
+	case len(data) == clientCookieLen:
+		metrics.DNSCookies.WithLabelValues("client_only").Inc()
+	case j.valid(c.client, data[clientCookieLen:], net.ParseIP(clientIP)):
+		c.valid = true
+		metrics.DNSCookies.WithLabelValues("valid").Inc()
+	default:
+		metrics.DNSCookies.WithLabelValues("invalid").Inc()
+	}
+	return c
+}
+
+// cookieOption returns the COOKIE option of a response to a client: its client
+// cookie followed by a fresh server cookie.
+func (j *CookieJar) cookieOption(client []byte, clientIP string) packet.EdnsOption {
+	data := append([]byte{}, client...)
+	return packet.EdnsOption{Code: ednsOptionCookie, Data: append(data, j.serverCookie(client, net.ParseIP(clientIP))...)}
+}
+
+// cookieResponder returns a sendFn that adds the client's cookie and a fresh
+// server cookie to every response.
+func (s *Server) cookieResponder(cookie queryCookie, clientIP string, sendFn func([]byte) error) func([]byte) error {
+	opt := s.Cookies.cookieOption(cookie.client, clientIP)
+	return func(resp []byte) error {
+		return sendFn(withEDNSOptions(resp, opt))
+	}
+}
+
+// sendCookieError answers a query whose cookie was malformed with FORMERR, or
+// one refused for want of a valid server cookie with BADCOOKIE and a fresh
+// cookie to retry with (RFC 7873 Section 5.2).
+func (s *Server) sendCookieError(request *packet.DNSPacket, cookie queryCookie, clientIP string, sendFn func([]byte) error) error {
+	response := packet.NewDNSPacket()
+	response.Header.ID = request.Header.ID
+	response.Header.Response = true
+	response.Header.Opcode = request.Header.Opcode
+	response.Header.RecursionDesired = request.Header.RecursionDesired
+	response.Questions = request.Questions
+	opt := packet.DNSRecord{Name: ".", Type: packet.OPT, UDPPayloadSize: 4096}
+	if cookie.malformed {
+		response.Header.ResCode = packet.RcodeFormErr
+	} else {
+		response.Header.ResCode = rcodeBadCookie & 0x0F
+		opt.ExtendedRcode = rcodeBadCookie >> 4
+		opt.Options = append(opt.Options, s.Cookies.cookieOption(cookie.client, clientIP))
+	}
+	response.Resources = append(response.Resources, opt)
+
+	resBuffer := packet.GetBuffer()
+	defer packet.PutBuffer(resBuffer)
+	if err := response.Write(resBuffer); err != nil {
+		return err
+	}
+	return sendFn(resBuffer.Buf[:resBuffer.Position()])
+}
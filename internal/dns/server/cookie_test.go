@@ -0,0 +1,132 @@
+package server
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+func TestCookieJar(t *testing.T) {
+	jar := NewCookieJar()
+	now := time.Unix(1_700_000_000, 0)
+	jar.now = func() time.Time { return now }
+	client := []byte("12345678")
+	ip := net.ParseIP("192.0.2.10")
+
+	server := jar.serverCookie(client, ip)
+	if len(server) != serverCookieLen || server[0] != 1 {
+		t.Fatalf("Unexpected server cookie %x", server)
+	}
+	if !jar.valid(client, server, ip) {
+		t.Error("Expected a fresh cookie to be valid")
+	}
+	if jar.valid(client, server, net.ParseIP("192.0.2.11")) {
+		t.Error("Expected a cookie to be bound to the client address")
+	}
+	if jar.valid([]byte("87654321"), server, ip) {
+		t.Error("Expected a cookie to be bound to the client cookie")
+	}
+
+	jar.Rotate()
+	if !jar.valid(client, server, ip) {
+		t.Error("Expected a cookie of the previous secret to stay valid")
+	}
+	jar.Rotate()
+	if jar.valid(client, server, ip) {
+		t.Error("Expected a cookie to expire after two rotations")
+	}
+
+	server = jar.serverCookie(client, ip)
+	now = now.Add(cookieLifetime + time.Second)
+	if jar.valid(client, server, ip) {
+		t.Error("Expected an old timestamp to be rejected")
+	}
+	now = now.Add(-2*cookieLifetime - cookieClockSkew)
+	if jar.valid(client, server, ip) {
+		t.Error("Expected a future timestamp to be rejected")
+	}
+}
+
+func TestHandlePacketCookies(t *testing.T) {
+	repo := &mockServerRepo{
+		zones:   []domain.Zone{{ID: "z1", Name: "example.com."}},
+		records: []domain.Record{{Name: "www.example.com.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 300}},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+	srv.Cookies = NewCookieJar()
+	addr := &net.UDPAddr{IP: net.ParseIP("192.0.2.10"), Port: 5353}
+	client := []byte("abcdefgh")
+
+	query := func(cookie []byte) *packet.DNSPacket {
+		t.Helper()
+		req := packet.NewDNSPacket()
+		req.Header.ID = 42
+		req.Questions = append(req.Questions, packet.DNSQuestion{Name: "www.example.com.", QType: packet.A})
+		opt := packet.DNSRecord{Name: ".", Type: packet.OPT, UDPPayloadSize: 1232}
+		if cookie != nil {
+			opt.Options = append(opt.Options, packet.EdnsOption{Code: ednsOptionCookie, Data: cookie})
+		}
+		req.Resources = append(req.Resources, opt)
+		buf := packet.NewBytePacketBuffer()
+		_ = req.Write(buf)
+		var raw []byte
+		if err := srv.handlePacket(buf.Buf[:buf.Position()], addr, func(b []byte) error {
+			raw = append([]byte(nil), b...)
+			return nil
+		}, "udp"); err != nil {
+			t.Fatalf("handlePacket failed: %v", err)
+		}
+		if raw == nil {
+			return nil
+		}
+		return parseMessage(t, raw)
+	}
+	responseCookie := func(resp *packet.DNSPacket) []byte {
+		for _, res := range resp.Resources {
+			for _, opt := range res.Options {
+				if res.Type == packet.OPT && opt.Code == ednsOptionCookie {
+					return opt.Data
+				}
+			}
+		}
+		return nil
+	}
+
+	resp := query(client)
+	if resp == nil || resp.Header.ResCode != 0 || len(resp.Answers) != 1 {
+		t.Fatalf("Expected an answer to a client cookie, got %+v", resp)
+	}
+	cookie := responseCookie(resp)
+	if len(cookie) != clientCookieLen+serverCookieLen || !bytes.Equal(cookie[:clientCookieLen], client) {
+		t.Fatalf("Expected the client cookie and a server cookie, got %x", cookie)
+	}
+
+	if resp := query([]byte("short")); resp == nil || resp.Header.ResCode != packet.RcodeFormErr {
+		t.Errorf("Expected FORMERR for a malformed cookie, got %+v", resp)
+	}
+
+	// Over its rate limit, only a client proving its address is answered
+	srv.limiter = newRateLimiter(0, 0)
+	if resp := query(nil); resp != nil {
+		t.Errorf("Expected a limited query without a cookie to be dropped, got %+v", resp)
+	}
+	if resp := query(cookie); resp == nil || resp.Header.ResCode != 0 || len(resp.Answers) != 1 {
+		t.Errorf("Expected a valid cookie to bypass the rate limiter, got %+v", resp)
+	}
+	resp = query(client)
+	if resp == nil || resp.Header.ResCode != rcodeBadCookie&0x0F || resp.Resources[0].ExtendedRcode != rcodeBadCookie>>4 {
+		t.Fatalf("Expected BADCOOKIE for a limited client without a server cookie, got %+v", resp)
+	}
+	if fresh := responseCookie(resp); len(fresh) != clientCookieLen+serverCookieLen ||
+		!srv.Cookies.valid(client, fresh[clientCookieLen:], addr.IP) {
+		t.Errorf("Expected BADCOOKIE to carry a valid cookie, got %x", fresh)
+	}
+	bad := append(append([]byte{}, client...), make([]byte, serverCookieLen)...)
+	if resp := query(bad); resp == nil || resp.Header.ResCode != rcodeBadCookie&0x0F {
+		t.Errorf("Expected BADCOOKIE for a forged server cookie, got %+v", resp)
+	}
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+)
+
+// defaultRecordSyncInterval is how often the zones' default records are
+// reloaded.
+var defaultRecordSyncInterval = 15 * time.Second
+
+// defaultRecordSet holds the default records of every zone that has some.
+type defaultRecordSet struct {
+	zones map[string][]domain.DefaultRecord // Zone ID -> default records
+}
+
+// scheduleDefaultRecords reloads the default records until ctx is done.
+func (s *Server) scheduleDefaultRecords(ctx context.Context) {
+	if s.Repo == nil {
+		return
+	}
+	ticker := time.NewTicker(defaultRecordSyncInterval)
+	defer ticker.Stop()
+	for {
+		s.syncDefaultRecords(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// syncDefaultRecords loads the default records of every zone, keeping the
+// current set if they fail to load.
+func (s *Server) syncDefaultRecords(ctx context.Context) {
+	records, err := s.Repo.ListDefaultRecords(ctx, "")
+	if err != nil {
+		s.Logger.Error("failed to load default records", "error", err)
+		return
+	}
+	if len(records) == 0 {
+		s.defaults.Store(nil)
+		return
+	}
+	set := &defaultRecordSet{zones: make(map[string][]domain.DefaultRecord)}
+	for _, rec := range records {
+		set.zones[rec.ZoneID] = append(set.zones[rec.ZoneID], rec)
+	}
+	s.defaults.Store(set)
+}
+
+// matchDefaultRecords returns the default records of zone for a query of
+// qType for name: those of the query type, or a CNAME, whose depth range
+// holds name. The apex never gets default records.
+func (s *Server) matchDefaultRecords(zone *domain.Zone, name string, qType domain.RecordType) []domain.Record {
+	set := s.defaults.Load()
+	if set == nil || zone == nil {
+		return nil
+	}
+	rules := set.zones[zone.ID]
+	if len(rules) == 0 || !dnsname.IsSubdomain(name, zone.Name) {
+		return nil
+	}
+	depth := dnsname.CountLabels(name) - dnsname.CountLabels(zone.Name)
+	if depth < 1 {
+		return nil
+	}
+	var records []domain.Record
+	for _, rule := range rules {
+		if (rule.Type != qType && rule.Type != domain.TypeCNAME) || !rule.Matches(depth) {
+			continue
+		}
+		records = append(records, domain.Record{
+			ID:       rule.ID,
+			ZoneID:   rule.ZoneID,
+			Name:     name,
+			Type:     rule.Type,
+			Content:  rule.Content,
+			TTL:      rule.TTL,
+			Priority: rule.Priority,
+		})
+	}
+	return records
+}
+
+// defaultAnswer returns the default records answering a query for name, a
+// name of zone that has no records of any type, as seen from clientIP.
+func (s *Server) defaultAnswer(ctx context.Context, zone *domain.Zone, name string, qType domain.RecordType, clientIP string) []domain.Record {
+	records := s.matchDefaultRecords(zone, name, qType)
+	if len(records) == 0 {
+		return nil
+	}
+	existing, err := s.lookupRecords(ctx, name, "", clientIP)
+	if err != nil || len(existing) > 0 {
+		return nil
+	}
+	return records
+}
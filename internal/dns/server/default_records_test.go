@@ -0,0 +1,49 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+func TestHandlePacketDefaultRecords(t *testing.T) {
+	repo := &mockServerRepo{
+		zones: []domain.Zone{{ID: "z1", TenantID: "t1", Name: "host.test."}},
+		records: []domain.Record{
+			{ZoneID: "z1", Name: "www.host.test.", Type: domain.TypeTXT, Content: "v=1", TTL: 300},
+			{ZoneID: "z1", Name: "*.wild.host.test.", Type: domain.TypeA, Content: "192.0.2.9", TTL: 300},
+		},
+		defaults: []domain.DefaultRecord{
+			{ID: "d1", ZoneID: "z1", Type: domain.TypeA, Content: "192.0.2.100", TTL: 60, MinDepth: 1, MaxDepth: 1},
+			{ID: "d2", ZoneID: "z1", Type: domain.TypeA, Content: "192.0.2.200", TTL: 60, MinDepth: 2},
+		},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+	srv.syncDefaultRecords(context.Background())
+
+	for name, want := range map[string]string{
+		"shop.host.test.":   "192.0.2.100",
+		"a.b.host.test.":    "192.0.2.200",
+		"x.wild.host.test.": "192.0.2.9",
+	} {
+		resp := queryFrom(t, srv, name, "192.0.2.50:5353", false)
+		if len(resp.Answers) != 1 || resp.Answers[0].IP.String() != want || resp.Answers[0].Name != name {
+			t.Errorf("Expected %s for %s, got %v", want, name, resp.Answers)
+		}
+	}
+	for _, name := range []string{"www.host.test.", "host.test."} {
+		if resp := queryFrom(t, srv, name, "192.0.2.50:5353", false); len(resp.Answers) != 0 {
+			t.Errorf("Expected no default record for %s, got %v", name, resp.Answers)
+		}
+	}
+
+	// A change notification reloads the default records before the caches are flushed
+	repo.mu.Lock()
+	repo.defaults[0].Content = "192.0.2.101"
+	repo.mu.Unlock()
+	srv.applyDataChange(domain.DataChange{Table: "dns_default_records", Op: "UPDATE", ZoneID: "z1", Zone: "host.test."})
+	if resp := queryFrom(t, srv, "shop.host.test.", "192.0.2.50:5353", false); len(resp.Answers) != 1 || resp.Answers[0].IP.String() != "192.0.2.101" {
+		t.Errorf("Expected the changed default record, got %v", resp.Answers)
+	}
+}
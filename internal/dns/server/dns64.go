@@ -0,0 +1,159 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/poyrazK/cloudDNS/internal/adapters/repository"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+)
+
+// DNS64View synthesizes AAAA records from A records (RFC 6147) for the clients
+// of an IPv6-only network, whose NAT64 gateway translates Prefix.
+type DNS64View struct {
+	Name string
+	// Clients are the networks the view serves; empty serves every client.
+	Clients []*net.IPNet
+	// Prefix is the NAT64 prefix, /32, /40, /48, /56, /64 or /96 (RFC 6052).
+	Prefix *net.IPNet
+	// Exclude lists AAAA addresses treated as absent, so the name is still
+	// synthesized, and A addresses that are never synthesized from.
+	// IPv4-mapped addresses (::ffff:0:0/96) are always excluded.
+	Exclude []*net.IPNet
+}
+
+var mappedIPv4 = &net.IPNet{IP: net.ParseIP("::ffff:0:0"), Mask: net.CIDRMask(96, 128)}
+
+// ParseDNS64Views parses semicolon-separated views, each a NAT64 prefix followed
+// by optional name=, clients= and exclude= fields with comma-separated CIDRs,
+// for example "64:ff9b::/96 clients=2001:db8:100::/40 exclude=2001:db8:ff::/48".
+// Views are matched against the client address in order.
+func ParseDNS64Views(spec string) ([]DNS64View, error) {
+	var views []DNS64View
+	for i, part := range strings.Split(spec, ";") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		view := DNS64View{Name: fmt.Sprintf("view%d", i)}
+		_, prefix, err := net.ParseCIDR(fields[0])
+		if err != nil || prefix.IP.To4() != nil {
+			return nil, fmt.Errorf("DNS64 view %d: %q is not an IPv6 prefix", i, fields[0])
+		}
+		switch ones, _ := prefix.Mask.Size(); ones {
+		case 32, 40, 48, 56, 64, 96:
+		default:
+			return nil, fmt.Errorf("DNS64 view %d: prefix %s must be /32, /40, /48, /56, /64 or /96", i, prefix)
+		}
+		if prefix.IP[8] != 0 {
+			return nil, fmt.Errorf("DNS64 view %d: bits 64 to 71 of prefix %s must be zero", i, prefix)
+		}
+		view.Prefix = prefix
+
+		for _, field := range fields[1:] {
+			key, val, ok := strings.Cut(field, "=")
+			if !ok {
+				return nil, fmt.Errorf("DNS64 view %d: %q is not key=value", i, field)
+			}
+			switch key {
+			case "name":
+				view.Name = val
+			case "clients", "exclude":
+				networks, errCIDR := parseCIDRs(val)
+				if errCIDR != nil {
+					return nil, fmt.Errorf("DNS64 view %d: %s: %w", i, key, errCIDR)
+				}
+				if key == "clients" {
+					view.Clients = networks
+				} else {
+					view.Exclude = networks
+				}
+			default:
+				return nil, fmt.Errorf("DNS64 view %d: unknown field %q", i, key)
+			}
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+// dns64View returns the first view serving clientIP, or nil.
+func (s *Server) dns64View(clientIP string) *DNS64View {
+	if len(s.DNS64) == 0 {
+		return nil
+	}
+	ip := net.ParseIP(clientIP)
+	for i := range s.DNS64 {
+		if clientMatches(s.DNS64[i].Clients, ip) {
+			return &s.DNS64[i]
+		}
+	}
+	return nil
+}
+
+// excluded reports whether an address of an AAAA (aaaa set) or A record is
+// excluded from the view.
+func (v *DNS64View) excluded(ip net.IP, aaaa bool) bool {
+	if aaaa && mappedIPv4.Contains(ip) {
+		return true
+	}
+	for _, network := range v.Exclude {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// synthesize embeds an IPv4 address in the view's prefix as RFC 6052 2.2 lays
+// out, skipping bits 64 to 71.
+func (v *DNS64View) synthesize(ip4 net.IP) net.IP {
+	out := make(net.IP, net.IPv6len)
+	copy(out, v.Prefix.IP.To16())
+	ones, _ := v.Prefix.Mask.Size()
+	pos := ones / 8
+	for _, b := range ip4.To4() {
+		if pos == 8 {
+			pos++
+		}
+		out[pos] = b
+		pos++
+	}
+	return out
+}
+
+// applyDNS64 returns the answers to an AAAA query for name under view: the AAAA
+// records that are not excluded or, when none are left, AAAA records
+// synthesized from the name's A records. synthesized reports the latter.
+func (s *Server) applyDNS64(ctx context.Context, view *DNS64View, name string, answers []packet.DNSRecord, clientIP string) (result []packet.DNSRecord, synthesized bool) {
+	for _, rec := range answers {
+		if rec.Type != packet.AAAA || !view.excluded(rec.IP, true) {
+			result = append(result, rec)
+		}
+	}
+	if len(result) > 0 {
+		return result, false
+	}
+
+	records, err := s.Repo.GetRecords(ctx, name, domain.TypeA, clientIP)
+	if err != nil {
+		return nil, false
+	}
+	for _, rec := range records {
+		pRec, errConv := repository.ConvertDomainToPacketRecord(rec)
+		if errConv != nil || pRec.IP.To4() == nil || view.excluded(pRec.IP, false) {
+			continue
+		}
+		pRec.Type = packet.AAAA
+		pRec.IP = view.synthesize(pRec.IP)
+		result = append(result, pRec)
+	}
+	if len(result) > 0 {
+		metrics.DNS64Synthesized.WithLabelValues(view.Name).Inc()
+	}
+	return result, len(result) > 0
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"net"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+func TestParseDNS64Views(t *testing.T) {
+	views, err := ParseDNS64Views("2001:db8:64::/48 name=lab clients=2001:db8:100::/40,10.0.0.0/8 exclude=2001:db8:ff::/48; 64:ff9b::/96")
+	if err != nil {
+		t.Fatalf("ParseDNS64Views failed: %v", err)
+	}
+	if len(views) != 2 {
+		t.Fatalf("Expected 2 views, got %d", len(views))
+	}
+	if views[0].Name != "lab" || len(views[0].Clients) != 2 || len(views[0].Exclude) != 1 || views[0].Prefix.String() != "2001:db8:64::/48" {
+		t.Errorf("Unexpected first view %+v", views[0])
+	}
+	if views[1].Name != "view1" || len(views[1].Clients) != 0 {
+		t.Errorf("Unexpected second view %+v", views[1])
+	}
+
+	for _, bad := range []string{"10.0.0.0/8", "64:ff9b::/80", "64:ff9b:0:0:ff00::/96", "64:ff9b::/96 clients=nope", "64:ff9b::/96 color=red", "64:ff9b::/96 clients"} {
+		if _, err := ParseDNS64Views(bad); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestDNS64Synthesize(t *testing.T) {
+	// RFC 6052 2.4 examples for 192.0.2.33
+	for prefix, want := range map[string]string{
+		"2001:db8::/32":         "2001:db8:c000:221::",
+		"2001:db8:100::/40":     "2001:db8:1c0:2:21::",
+		"2001:db8:122::/48":     "2001:db8:122:c000:2:2100::",
+		"2001:db8:122:300::/56": "2001:db8:122:3c0:0:221::",
+		"2001:db8:122:344::/64": "2001:db8:122:344:c0:2:2100:0",
+		"64:ff9b::/96":          "64:ff9b::c000:221",
+	} {
+		views, err := ParseDNS64Views(prefix)
+		if err != nil {
+			t.Fatalf("ParseDNS64Views(%s) failed: %v", prefix, err)
+		}
+		if got := views[0].synthesize(net.ParseIP("192.0.2.33")); !got.Equal(net.ParseIP(want)) {
+			t.Errorf("%s: expected %s, got %s", prefix, want, got)
+		}
+	}
+}
+
+func TestHandlePacketDNS64(t *testing.T) {
+	repo := &mockServerRepo{
+		zones: []domain.Zone{{ID: "z1", Name: "dns64.test."}},
+		records: []domain.Record{
+			{Name: "v4only.dns64.test.", Type: domain.TypeA, Content: "192.0.2.33", TTL: 120},
+			{Name: "v4only.dns64.test.", Type: domain.TypeA, Content: "10.1.2.3", TTL: 120},
+			{Name: "dual.dns64.test.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 60},
+			{Name: "dual.dns64.test.", Type: domain.TypeAAAA, Content: "2001:db8::1", TTL: 60},
+			{Name: "mapped.dns64.test.", Type: domain.TypeA, Content: "192.0.2.2", TTL: 60},
+			{Name: "mapped.dns64.test.", Type: domain.TypeAAAA, Content: "::ffff:192.0.2.2", TTL: 60},
+		},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+	views, err := ParseDNS64Views("64:ff9b::/96 clients=2001:db8:100::/40 exclude=10.0.0.0/8")
+	if err != nil {
+		t.Fatalf("ParseDNS64Views failed: %v", err)
+	}
+	srv.DNS64 = views
+
+	query := func(name, client string, cd bool) *packet.DNSPacket {
+		t.Helper()
+		req := packet.NewDNSPacket()
+		req.Header.CheckingDisabled = cd
+		req.Questions = append(req.Questions, packet.DNSQuestion{Name: name, QType: packet.AAAA})
+		buf := packet.NewBytePacketBuffer()
+		_ = req.Write(buf)
+		var resp []byte
+		if err := srv.handlePacket(buf.Buf[:buf.Position()], &net.UDPAddr{IP: net.ParseIP(client), Port: 5353}, func(b []byte) error {
+			resp = append([]byte(nil), b...)
+			return nil
+		}, "udp"); err != nil {
+			t.Fatalf("handlePacket failed: %v", err)
+		}
+		return parseMessage(t, resp)
+	}
+	addresses := func(p *packet.DNSPacket) []string {
+		var out []string
+		for _, a := range p.Answers {
+			out = append(out, a.IP.String())
+		}
+		return out
+	}
+
+	if got := addresses(query("v4only.dns64.test.", "2001:db8:100::5", false)); len(got) != 1 || got[0] != "64:ff9b::c000:221" {
+		t.Errorf("Expected one synthesized AAAA skipping the excluded A, got %v", got)
+	}
+	if got := addresses(query("dual.dns64.test.", "2001:db8:100::5", false)); len(got) != 1 || got[0] != "2001:db8::1" {
+		t.Errorf("Expected the real AAAA, got %v", got)
+	}
+	if got := addresses(query("mapped.dns64.test.", "2001:db8:100::5", false)); len(got) != 1 || got[0] != "64:ff9b::c000:202" {
+		t.Errorf("Expected an IPv4-mapped AAAA to be replaced, got %v", got)
+	}
+	if got := addresses(query("v4only.dns64.test.", "2001:db8:100::5", true)); len(got) != 0 {
+		t.Errorf("Expected no synthesis with CD set, got %v", got)
+	}
+	if got := addresses(query("v4only.dns64.test.", "2001:db8:200::5", false)); len(got) != 0 {
+		t.Errorf("Expected no synthesis outside the view, got %v", got)
+	}
+	// Clients outside the view must not be served the view's answer from cache;
+	// net.IP prints the IPv4-mapped address in dotted form
+	if got := addresses(query("mapped.dns64.test.", "2001:db8:200::5", false)); len(got) != 1 || got[0] != "192.0.2.2" {
+		t.Errorf("Expected the stored AAAA outside the view, got %v", got)
+	}
+}
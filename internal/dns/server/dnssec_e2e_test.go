@@ -31,7 +31,7 @@ func TestEndToEndDNSSEC_Lifecycle(t *testing.T) {
 
 	dnsSrv := NewServer(dnsAddr, repo, nil)
 	go func() {
-		_ = dnsSrv.Run()
+		_ = dnsSrv.Run(context.Background())
 	}()
 
 	apiHandler := api.NewAPIHandler(dnsSvc, repo)
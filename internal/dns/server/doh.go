@@ -0,0 +1,129 @@
+package server
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+// maxDoHMessageSize is the largest DNS message a DoH request may carry.
+const maxDoHMessageSize = 65535
+
+// maxDoHQueryLength is the longest dns parameter of a GET request: a message of
+// maxDoHMessageSize in padded base64url.
+var maxDoHQueryLength = base64.URLEncoding.EncodedLen(maxDoHMessageSize)
+
+// newDoHServer returns the HTTP server for the /dns-query endpoint on addr. With
+// tlsConfig it speaks HTTP/1.1 and HTTP/2 negotiated by ALPN; without, HTTP/1.1
+// and HTTP/2 with prior knowledge (RFC 9113, Section 3.3), for a TLS-terminating
+// proxy in front.
+func (s *Server) newDoHServer(addr string, tlsConfig *tls.Config) *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/dns-query", s.handleDoH)
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	if tlsConfig != nil {
+		protocols.SetHTTP2(true)
+	} else {
+		protocols.SetUnencryptedHTTP2(true)
+	}
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		TLSConfig:         tlsConfig,
+		Protocols:         protocols,
+		ReadHeaderTimeout: 5 * time.Second,
+		// Stub resolvers keep one connection open and multiplex queries over it
+		IdleTimeout: 2 * time.Minute,
+		// A GET carries the whole message in the URL
+		MaxHeaderBytes: maxDoHQueryLength + 8<<10,
+		HTTP2: &http.HTTP2Config{
+			MaxConcurrentStreams: 256,
+			MaxReadFrameSize:     maxDoHMessageSize + 1,
+			SendPingTimeout:      30 * time.Second,
+		},
+	}
+}
+
+// DoHResponseStore caches DoH GET responses. Lookup returns the response exactly as
+// stored together with its age, which is reported in the Age header instead of
+// being subtracted from the TTLs. DNSCache is the default in-memory store.
+type DoHResponseStore interface {
+	Lookup(key string) ([]byte, time.Duration, bool)
+	Set(key string, data []byte, ttl time.Duration)
+}
+
+// dohCacheKey returns the cache key of a DoH query: the message with its ID cleared.
+func dohCacheKey(msg []byte) string {
+	key := make([]byte, len(msg))
+	copy(key, msg)
+	key[0], key[1] = 0, 0
+	return string(key)
+}
+
+// writeDoHResponse writes a DNS response with HTTP caching headers derived from it.
+// age is how long the response has been cached. It returns the freshness lifetime
+// advertised in Cache-Control, in seconds, or 0 if the response must not be stored.
+func writeDoHResponse(w http.ResponseWriter, resp []byte, age time.Duration) uint32 {
+	cacheControl, maxAge := dohCacheControl(resp, time.Now().Add(-age))
+	w.Header().Set("Content-Type", "application/dns-message")
+	w.Header().Set("Content-Length", strconv.Itoa(len(resp)))
+	w.Header().Set("Cache-Control", cacheControl)
+	if age >= time.Second {
+		w.Header().Set("Age", strconv.FormatInt(int64(age/time.Second), 10))
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(resp)
+	return maxAge
+}
+
+// dohCacheControl returns the Cache-Control value for a response generated at
+// generatedAt. The freshness lifetime is the lowest record TTL (RFC 8484, Section
+// 5.1). Errors, truncated answers and answers without records get no-store, as do
+// DNSSEC (DO) answers carrying a signature that is not yet valid or expires before
+// the response would go stale in a shared cache.
+func dohCacheControl(resp []byte, generatedAt time.Time) (string, uint32) {
+	msg := packet.NewDNSPacket()
+	buf := packet.NewBytePacketBuffer()
+	buf.Load(resp)
+	if err := msg.FromBuffer(buf); err != nil {
+		return "no-store", 0
+	}
+	if msg.Header.TruncatedMessage || (msg.Header.ResCode != packet.RcodeNoError && msg.Header.ResCode != packet.RcodeNxDomain) {
+		return "no-store", 0
+	}
+
+	// Aging by zero leaves the message untouched and only reports the lowest TTL
+	minTTL, err := packet.AgeTTLs(resp, 0)
+	if err != nil || minTTL == 0 {
+		return "no-store", 0
+	}
+
+	if hasDOBit(msg) {
+		now := uint32(generatedAt.Unix())              // #nosec G115
+		staleAt := uint32(generatedAt.Unix()) + minTTL // #nosec G115
+		for _, sections := range [][]packet.DNSRecord{msg.Answers, msg.Authorities} {
+			for _, rec := range sections {
+				if rec.Type == packet.RRSIG && (rec.Inception > now || rec.Expiration <= staleAt) {
+					return "no-store", 0
+				}
+			}
+		}
+	}
+	return fmt.Sprintf("max-age=%d", minTTL), minTTL
+}
+
+// hasDOBit reports whether a message's OPT record has the DO bit set.
+func hasDOBit(msg *packet.DNSPacket) bool {
+	for _, res := range msg.Resources {
+		if res.Type == packet.OPT {
+			return res.Z&0x8000 != 0
+		}
+	}
+	return false
+}
@@ -0,0 +1,206 @@
+package server
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+// stubDoHStore is a DoH response store whose entries always report the same age.
+type stubDoHStore struct {
+	entries map[string][]byte
+	ttls    map[string]time.Duration
+	age     time.Duration
+}
+
+func (s *stubDoHStore) Lookup(key string) ([]byte, time.Duration, bool) {
+	data, ok := s.entries[key]
+	if !ok {
+		return nil, 0, false
+	}
+	return append([]byte(nil), data...), s.age, true
+}
+
+func (s *stubDoHStore) Set(key string, data []byte, ttl time.Duration) {
+	s.entries[key] = data
+	s.ttls[key] = ttl
+}
+
+func dohQuery(t *testing.T, id uint16, name string) []byte {
+	t.Helper()
+	req := packet.NewDNSPacket()
+	req.Header.ID = id
+	req.Questions = append(req.Questions, packet.DNSQuestion{Name: name, QType: packet.A})
+	buf := packet.NewBytePacketBuffer()
+	if err := req.Write(buf); err != nil {
+		t.Fatalf("failed to write query: %v", err)
+	}
+	return append([]byte(nil), buf.Buf[:buf.Position()]...)
+}
+
+func TestDoHGetCache(t *testing.T) {
+	repo := &mockServerRepo{
+		records: []domain.Record{{Name: "doh-cache.test.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 120}},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+	store := &stubDoHStore{entries: map[string][]byte{}, ttls: map[string]time.Duration{}, age: 7 * time.Second}
+	srv.DoHCache = store
+
+	get := func(id uint16) *httptest.ResponseRecorder {
+		q := base64.RawURLEncoding.EncodeToString(dohQuery(t, id, "doh-cache.test."))
+		w := httptest.NewRecorder()
+		srv.handleDoH(w, httptest.NewRequest(http.MethodGet, "/dns-query?dns="+q, nil))
+		return w
+	}
+
+	w := get(1)
+	if w.Code != http.StatusOK || w.Header().Get("Cache-Control") != "max-age=120" || w.Header().Get("Age") != "" {
+		t.Fatalf("Unexpected miss response %d, headers %v", w.Code, w.Header())
+	}
+	key := dohCacheKey(dohQuery(t, 1, "doh-cache.test."))
+	if store.ttls[key] != 120*time.Second {
+		t.Fatalf("Expected response stored under the ID-less query for 120s, got %v", store.ttls)
+	}
+
+	// A different ID shares the entry and gets its own ID back, with the age reported
+	// in the Age header rather than in the TTLs
+	w = get(2)
+	body := w.Body.Bytes()
+	if binary.BigEndian.Uint16(body[:2]) != 2 {
+		t.Errorf("Expected cached response rewritten to ID 2, got %d", binary.BigEndian.Uint16(body[:2]))
+	}
+	if w.Header().Get("Age") != "7" || w.Header().Get("Cache-Control") != "max-age=120" {
+		t.Errorf("Unexpected hit headers %v", w.Header())
+	}
+	if minTTL, _ := packet.AgeTTLs(body, 0); minTTL != 120 {
+		t.Errorf("Expected TTLs left as stored, got %d", minTTL)
+	}
+
+	// POST responses carry caching headers but are not stored
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/dns-query", bytes.NewReader(dohQuery(t, 3, "post.doh-cache.test.")))
+	r.Header.Set("Content-Type", "application/dns-message")
+	srv.handleDoH(w, r)
+	if len(store.entries) != 1 {
+		t.Errorf("Expected POST responses not to be stored, got %d entries", len(store.entries))
+	}
+}
+
+func TestDoHCacheControl(t *testing.T) {
+	now := time.Unix(1_800_000_000, 0)
+	unix := uint32(now.Unix()) // #nosec G115
+	a := packet.DNSRecord{Name: "example.com.", Type: packet.A, Class: 1, TTL: 300, IP: net.ParseIP("192.0.2.1")}
+	opt := packet.DNSRecord{Name: ".", Type: packet.OPT, UDPPayloadSize: 4096, Z: 0x8000}
+	sig := func(inception, expiration uint32) packet.DNSRecord {
+		return packet.DNSRecord{Name: "example.com.", Type: packet.RRSIG, Class: 1, TTL: 300, TypeCovered: uint16(packet.A),
+			Algorithm: 13, Labels: 2, OrigTTL: 300, Inception: inception, Expiration: expiration, SignerName: "example.com.", Signature: []byte{1, 2, 3}}
+	}
+
+	tests := []struct {
+		name   string
+		rcode  uint8
+		tc     bool
+		answer []packet.DNSRecord
+		extra  []packet.DNSRecord
+		want   string
+	}{
+		{"answer", packet.RcodeNoError, false, []packet.DNSRecord{a}, nil, "max-age=300"},
+		{"SERVFAIL", packet.RcodeServFail, false, []packet.DNSRecord{a}, nil, "no-store"},
+		{"truncated", packet.RcodeNoError, true, nil, nil, "no-store"},
+		{"no records", packet.RcodeNoError, false, nil, nil, "no-store"},
+		{"DO with valid signature", packet.RcodeNoError, false, []packet.DNSRecord{a, sig(unix-3600, unix+86400)}, []packet.DNSRecord{opt}, "max-age=300"},
+		{"DO with signature expiring while fresh", packet.RcodeNoError, false, []packet.DNSRecord{a, sig(unix-3600, unix+60)}, []packet.DNSRecord{opt}, "no-store"},
+		{"DO with future signature", packet.RcodeNoError, false, []packet.DNSRecord{a, sig(unix+60, unix+86400)}, []packet.DNSRecord{opt}, "no-store"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := packet.NewDNSPacket()
+			msg.Header.Response = true
+			msg.Header.ResCode = tt.rcode
+			msg.Header.TruncatedMessage = tt.tc
+			msg.Questions = append(msg.Questions, packet.DNSQuestion{Name: "example.com.", QType: packet.A})
+			msg.Answers = tt.answer
+			msg.Resources = tt.extra
+			buf := packet.NewBytePacketBuffer()
+			if err := msg.Write(buf); err != nil {
+				t.Fatalf("failed to write response: %v", err)
+			}
+			if got, _ := dohCacheControl(buf.Buf[:buf.Position()], now); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDoHMessageLimits(t *testing.T) {
+	srv := NewServer("127.0.0.1:0", &mockServerRepo{}, nil)
+	srv.DoHAltSvc = `h3=":443"; ma=86400`
+
+	post := func(body io.Reader, length int64) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/dns-query", body)
+		r.Header.Set("Content-Type", "application/dns-message")
+		r.ContentLength = length
+		w := httptest.NewRecorder()
+		srv.handleDoH(w, r)
+		return w
+	}
+
+	big := make([]byte, maxDoHMessageSize+1)
+	if w := post(bytes.NewReader(big), int64(len(big))); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for a declared oversized body, got %d", w.Code)
+	}
+	// A chunked body is cut off while it is read
+	if w := post(bytes.NewReader(big), -1); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for a chunked oversized body, got %d", w.Code)
+	}
+
+	w := httptest.NewRecorder()
+	srv.handleDoH(w, httptest.NewRequest(http.MethodGet, "/dns-query?dns="+strings.Repeat("A", maxDoHQueryLength+1), nil))
+	if w.Code != http.StatusRequestURITooLong {
+		t.Errorf("Expected 414 for an oversized dns parameter, got %d", w.Code)
+	}
+
+	query := dohQuery(t, 1, "example.test.")
+	w = post(bytes.NewReader(query), int64(len(query)))
+	if w.Code != http.StatusOK || w.Header().Get("Alt-Svc") != `h3=":443"; ma=86400` {
+		t.Errorf("Expected 200 advertising HTTP/3, got %d with headers %v", w.Code, w.Header())
+	}
+}
+
+func TestDoHCleartextHTTP2(t *testing.T) {
+	repo := &mockServerRepo{
+		records: []domain.Record{{Name: "h2c.test.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 60}},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+	hs := srv.newDoHServer("127.0.0.1:0", nil)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	go func() { _ = hs.Serve(l) }()
+	defer func() { _ = hs.Close() }()
+
+	// Prior knowledge: the client speaks HTTP/2 from the first byte, without an upgrade
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: protocols}, Timeout: 5 * time.Second}
+	q := base64.RawURLEncoding.EncodeToString(dohQuery(t, 0, "h2c.test."))
+	resp, err := client.Get("http://" + l.Addr().String() + "/dns-query?dns=" + q)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.ProtoMajor != 2 || resp.StatusCode != http.StatusOK || resp.Header.Get("Cache-Control") != "max-age=60" {
+		t.Fatalf("Expected an HTTP/2 200 with max-age=60, got %s %d %v", resp.Proto, resp.StatusCode, resp.Header)
+	}
+}
@@ -0,0 +1,274 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// DefaultDrainTimeout bounds how long Shutdown waits for in-flight queries.
+const DefaultDrainTimeout = 10 * time.Second
+
+// ErrDraining is reported by Ready once the server has started shutting down.
+var ErrDraining = errors.New("dns server is draining")
+
+// connTracker tracks TCP and DoT connections so they can be drained: idle
+// connections are closed at once and busy ones right after their in-flight
+// response has been sent (RFC 7766, Section 6.2.3).
+type connTracker struct {
+	mu       sync.Mutex
+	conns    map[net.Conn]*connState
+	draining bool
+	wg       sync.WaitGroup
+}
+
+type connState struct {
+	tls   bool
+	busy  bool
+	drain bool // Close once the in-flight response is sent
+}
+
+// add starts tracking c, or reports false if the server is shutting down.
+func (t *connTracker) add(c net.Conn, isTLS bool) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.draining {
+		return false
+	}
+	if t.conns == nil {
+		t.conns = make(map[net.Conn]*connState)
+	}
+	t.conns[c] = &connState{tls: isTLS}
+	t.wg.Add(1)
+	return true
+}
+
+func (t *connTracker) remove(c net.Conn) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.conns[c]; ok {
+		delete(t.conns, c)
+		t.wg.Done()
+	}
+}
+
+// busy marks c as processing a query.
+func (t *connTracker) busy(c net.Conn) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if st := t.conns[c]; st != nil {
+		st.busy = true
+	}
+}
+
+// idle marks c as waiting for its next query and reports whether it may stay open.
+func (t *connTracker) idle(c net.Conn) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	st := t.conns[c]
+	if st == nil || st.drain || t.draining {
+		return false
+	}
+	st.busy = false
+	return true
+}
+
+// drain closes idle connections and has busy ones close after their response. With
+// tlsOnly, plain TCP connections are left alone.
+func (t *connTracker) drain(tlsOnly bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for c, st := range t.conns {
+		if tlsOnly && !st.tls {
+			continue
+		}
+		if st.busy {
+			st.drain = true
+		} else {
+			_ = c.Close()
+		}
+	}
+}
+
+// shutdown refuses new connections and drains existing ones.
+func (t *connTracker) shutdown() {
+	t.mu.Lock()
+	t.draining = true
+	t.mu.Unlock()
+	t.drain(false)
+}
+
+func (t *connTracker) closeAll() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for c := range t.conns {
+		_ = c.Close()
+	}
+}
+
+// waitGroup waits for wg until ctx is done.
+func waitGroup(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Ready reports ErrDraining once Shutdown has been called, so load balancers and
+// the anycast manager stop sending queries to this node.
+func (s *Server) Ready(_ context.Context) error {
+	if s.draining.Load() {
+		return ErrDraining
+	}
+	return nil
+}
+
+// Shutdown stops accepting queries and waits until ctx is done for in-flight ones
+// to be answered: UDP queries already received, TCP and DoT queries being
+// processed and DoH requests. Idle connections are closed immediately. If ctx
+// expires first, the remaining connections are closed and ctx's error returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	var err error
+	s.shutdownOnce.Do(func() {
+		err = s.shutdown(ctx)
+	})
+	return err
+}
+
+func (s *Server) shutdown(ctx context.Context) error {
+	s.Logger.Info("draining DNS server")
+	s.draining.Store(true)
+	s.conns.shutdown()
+
+	s.mu.Lock()
+	for _, l := range s.listeners {
+		_ = l.Close()
+	}
+	for _, pc := range s.packetConns {
+		// Unblock readers without closing the socket queued answers are sent from
+		_ = pc.SetReadDeadline(time.Now())
+	}
+	doh := s.dohServers
+	s.mu.Unlock()
+
+	dohErr := make(chan error, len(doh))
+	for _, srv := range doh {
+		go func() { dohErr <- srv.Shutdown(ctx) }()
+	}
+
+	// Readers have stopped enqueueing once they exit, so the queue can be closed and
+	// workers exit after answering what is left in it
+	s.udpReaders.Wait()
+	s.mu.Lock()
+	queues := s.udpQueues
+	s.mu.Unlock()
+	if queues == nil {
+		queues = []chan udpTask{s.udpQueue}
+	}
+	for _, q := range queues {
+		close(q)
+	}
+	err := waitGroup(ctx, &s.udpWorkers)
+	if errConns := waitGroup(ctx, &s.conns.wg); errConns != nil {
+		err = errConns
+		s.conns.closeAll()
+	}
+	for range doh {
+		if errDoH := <-dohErr; errDoH != nil && err == nil {
+			err = errDoH
+		}
+	}
+
+	s.mu.Lock()
+	for _, pc := range s.packetConns {
+		_ = pc.Close()
+	}
+	s.mu.Unlock()
+	close(s.stopped)
+
+	if err != nil {
+		s.Logger.Warn("DNS server drain incomplete, remaining connections closed", "error", err)
+	} else {
+		s.Logger.Info("DNS server drained")
+	}
+	return err
+}
+
+// ReloadCertificate serves cert on new DoT and DoH handshakes and drains DoT
+// connections and idle DoH connections opened with the old one, so clients
+// reconnect and pick it up without losing in-flight queries.
+func (s *Server) ReloadCertificate(cert tls.Certificate) {
+	s.certificate.Store(&cert)
+	s.conns.drain(true)
+
+	s.mu.Lock()
+	doh := s.dohServers
+	s.mu.Unlock()
+	for _, srv := range doh {
+		if srv.TLSConfig == nil {
+			continue
+		}
+		// Disabling keep-alives closes idle connections; busy ones get Connection: close
+		srv.SetKeepAlivesEnabled(false)
+		srv.SetKeepAlivesEnabled(true)
+	}
+	s.Logger.Info("reloaded TLS certificate")
+}
+
+// serveTLSConfig returns the config for the DoT and DoH listeners. Unless TLSConfig
+// selects certificates itself, the certificate is read on every handshake so
+// ReloadCertificate takes effect without restarting listeners.
+func (s *Server) serveTLSConfig() *tls.Config {
+	cfg := s.TLSConfig.Clone()
+	if cfg.GetCertificate != nil {
+		return cfg
+	}
+	if len(cfg.Certificates) > 0 {
+		s.certificate.CompareAndSwap(nil, &cfg.Certificates[0])
+	}
+	cfg.Certificates = nil
+	cfg.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		if cert := s.certificate.Load(); cert != nil {
+			return cert, nil
+		}
+		return nil, errors.New("no TLS certificate configured")
+	}
+	return cfg
+}
+
+// trackPacketConn registers a UDP socket and its reader goroutine for shutdown. It
+// reports false, after closing pc, if the server is already shutting down.
+func (s *Server) trackPacketConn(pc net.PacketConn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.draining.Load() {
+		_ = pc.Close()
+		return false
+	}
+	s.packetConns = append(s.packetConns, pc)
+	s.udpReaders.Add(1)
+	return true
+}
+
+// trackListener registers a listener to be closed on shutdown. It reports false,
+// after closing l, if the server is already shutting down.
+func (s *Server) trackListener(l net.Listener) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.draining.Load() {
+		_ = l.Close()
+		return false
+	}
+	s.listeners = append(s.listeners, l)
+	return true
+}
@@ -0,0 +1,244 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+// drainFixture serves TCP queries from a fresh server whose lookups take about
+// latency, returning the server and its listening address.
+func drainFixture(t *testing.T, latency time.Duration) (*Server, string) {
+	t.Helper()
+	repo := &mockServerRepo{records: []domain.Record{{Name: "drain.test.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 60}}}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+	srv.SimulateDBLatency = latency
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	if !srv.trackListener(l) {
+		t.Fatal("Expected listener to be tracked")
+	}
+	go srv.acceptLoop(l)
+	return srv, l.Addr().String()
+}
+
+func sendTCPQuery(t *testing.T, conn net.Conn) {
+	t.Helper()
+	req := packet.NewDNSPacket()
+	req.Header.ID = 7
+	req.Questions = append(req.Questions, packet.DNSQuestion{Name: "drain.test.", QType: packet.A})
+	buf := packet.NewBytePacketBuffer()
+	_ = req.Write(buf)
+	msg := buf.Buf[:buf.Position()]
+	if _, err := conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(msg))), msg...)); err != nil { // #nosec G115
+		t.Fatalf("failed to send query: %v", err)
+	}
+}
+
+func readTCPResponse(conn net.Conn) ([]byte, error) {
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var length [2]byte
+	if _, err := io.ReadFull(conn, length[:]); err != nil {
+		return nil, err
+	}
+	resp := make([]byte, binary.BigEndian.Uint16(length[:]))
+	_, err := io.ReadFull(conn, resp)
+	return resp, err
+}
+
+func TestShutdownDrainsTCP(t *testing.T) {
+	srv, addr := drainFixture(t, 200*time.Millisecond)
+
+	idle, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer func() { _ = idle.Close() }()
+	busy, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer func() { _ = busy.Close() }()
+
+	sendTCPQuery(t, busy)
+	time.Sleep(50 * time.Millisecond) // Let the query reach the slow lookup
+
+	done := make(chan error, 1)
+	go func() { done <- srv.Shutdown(context.Background()) }()
+
+	// The idle connection is closed right away
+	if _, err := readTCPResponse(idle); !errors.Is(err, io.EOF) {
+		t.Errorf("Expected idle connection closed, got %v", err)
+	}
+	if !errors.Is(srv.Ready(context.Background()), ErrDraining) {
+		t.Error("Expected Ready to report draining")
+	}
+
+	// The in-flight query is answered before its connection is closed
+	resp, err := readTCPResponse(busy)
+	if err != nil || binary.BigEndian.Uint16(resp[:2]) != 7 {
+		t.Fatalf("Expected the in-flight query to be answered, got %v", err)
+	}
+	if _, err := readTCPResponse(busy); !errors.Is(err, io.EOF) {
+		t.Errorf("Expected connection closed after the final response, got %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected clean drain, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Shutdown did not return")
+	}
+	if _, err := net.DialTimeout("tcp", addr, 200*time.Millisecond); err == nil {
+		t.Error("Expected new connections to be refused after shutdown")
+	}
+	if err := srv.Shutdown(context.Background()); err != nil {
+		t.Errorf("Expected repeated Shutdown to be a no-op, got %v", err)
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	srv, addr := drainFixture(t, 500*time.Millisecond)
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	sendTCPQuery(t, conn)
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := srv.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected drain timeout, got %v", err)
+	}
+	if _, err := readTCPResponse(conn); err == nil {
+		t.Error("Expected the connection to be closed when the drain timed out")
+	}
+}
+
+func TestRunStopsWhenContextDone(t *testing.T) {
+	probe, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to reserve a port: %v", err)
+	}
+	addr := probe.LocalAddr().String()
+	_ = probe.Close()
+
+	repo := &mockServerRepo{records: []domain.Record{{Name: "drain.test.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 60}}}
+	srv := NewServer(addr, repo, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- srv.Run(ctx) }()
+
+	// Wait until TCP is being served
+	var conn net.Conn
+	for attempt := 0; attempt < 40; attempt++ {
+		if conn, err = net.Dial("tcp", addr); err == nil {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("server did not start: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	sendTCPQuery(t, conn)
+	if _, err := readTCPResponse(conn); err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected Run to return nil, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Run to return once its context was done")
+	}
+	if _, err := readTCPResponse(conn); err == nil {
+		t.Error("Expected the connection to be closed")
+	}
+
+	// Every socket was closed, so the address can be bound again
+	pc, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		t.Fatalf("Expected the UDP socket to be closed: %v", err)
+	}
+	_ = pc.Close()
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatalf("Expected the TCP listener to be closed: %v", err)
+	}
+	_ = l.Close()
+}
+
+func testCertificate(t *testing.T, name string) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestReloadCertificate(t *testing.T) {
+	srv := NewServer("127.0.0.1:0", &mockServerRepo{}, nil)
+	oldCert, newCert := testCertificate(t, "old"), testCertificate(t, "new")
+	srv.TLSConfig = &tls.Config{Certificates: []tls.Certificate{oldCert}, MinVersion: tls.VersionTLS12}
+
+	cfg := srv.serveTLSConfig()
+	if got, _ := cfg.GetCertificate(nil); got == nil || string(got.Certificate[0]) != string(oldCert.Certificate[0]) {
+		t.Fatal("Expected the configured certificate to be served first")
+	}
+
+	// Idle DoT connections are closed so clients reconnect; plain TCP is untouched
+	dotServer, dotClient := net.Pipe()
+	tcpServer, tcpClient := net.Pipe()
+	defer func() { _ = tcpClient.Close(); _ = dotClient.Close() }()
+	srv.conns.add(dotServer, true)
+	srv.conns.add(tcpServer, false)
+
+	srv.ReloadCertificate(newCert)
+	if got, _ := cfg.GetCertificate(nil); string(got.Certificate[0]) != string(newCert.Certificate[0]) {
+		t.Error("Expected new handshakes to use the reloaded certificate")
+	}
+	if _, err := dotClient.Write([]byte{0}); err == nil {
+		t.Error("Expected idle DoT connection closed on reload")
+	}
+	go func() { _, _ = io.ReadFull(tcpServer, make([]byte, 1)) }()
+	if _, err := tcpClient.Write([]byte{0}); err != nil {
+		t.Errorf("Expected TCP connection kept open, got %v", err)
+	}
+}
@@ -27,7 +27,7 @@ func TestEndToEndDNSAdvanced(t *testing.T) {
 
 	dnsSrv := NewServer(dnsAddr, repo, nil)
 	go func() {
-		_ = dnsSrv.Run()
+		_ = dnsSrv.Run(context.Background())
 	}()
 
 	apiHandler := api.NewAPIHandler(svc, repo)
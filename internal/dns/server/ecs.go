@@ -0,0 +1,122 @@
+package server
+
+import (
+	"encoding/binary"
+	"net"
+
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+const (
+	// ednsOptionClientSubnet is the EDNS option code of EDNS Client Subnet (RFC 7871).
+	ednsOptionClientSubnet = 8
+
+	// Longest prefixes answers are tailored to, as recommended by RFC 7871
+	// Section 11.1; longer source prefixes are shortened, which also bounds the
+	// number of cached variants of an answer.
+	ecsMaxIPv4Prefix = 24
+	ecsMaxIPv6Prefix = 56
+)
+
+// clientSubnet is the EDNS Client Subnet option of a query.
+type clientSubnet struct {
+	family  uint16
+	source  uint8
+	address []byte
+	// subnet is the network answers are tailored to; nil when the resolver asked
+	// for the client address not to be used (SOURCE PREFIX-LENGTH 0).
+	subnet    *net.IPNet
+	malformed bool
+}
+
+// parseClientSubnet returns the ECS option of request, or nil if it has none. An
+// option that violates RFC 7871 Section 6 is returned marked malformed.
+func parseClientSubnet(request *packet.DNSPacket) *clientSubnet {
+	for _, res := range request.Resources {
+		if res.Type != packet.OPT {
+			continue
+		}
+		for _, opt := range res.Options {
+			if opt.Code == ednsOptionClientSubnet {
+				return decodeClientSubnet(opt.Data)
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+func decodeClientSubnet(data []byte) *clientSubnet {
+	bad := &clientSubnet{malformed: true}
+	if len(data) < 4 {
+		return bad
+	}
+	c := &clientSubnet{
+		family:  binary.BigEndian.Uint16(data),
+		source:  data[2],
+		address: data[4:],
+	}
+	var size, limit int
+	switch c.family {
+	case 1:
+		size, limit = net.IPv4len, ecsMaxIPv4Prefix
+	case 2:
+		size, limit = net.IPv6len, ecsMaxIPv6Prefix
+	default:
+		return bad
+	}
+	// The scope is for responses only, and the address must be exactly as long as
+	// the source prefix, with no bits set beyond it
+	if data[3] != 0 || int(c.source) > size*8 || len(c.address) != (int(c.source)+7)/8 {
+		return bad
+	}
+	if rem := c.source % 8; rem != 0 && c.address[len(c.address)-1]&(0xFF>>rem) != 0 {
+		return bad
+	}
+	if c.source == 0 {
+		return c
+	}
+
+	ip := make(net.IP, size)
+	copy(ip, c.address)
+	ones := min(int(c.source), limit)
+	mask := net.CIDRMask(ones, size*8)
+	c.subnet = &net.IPNet{IP: ip.Mask(mask), Mask: mask}
+	return c
+}
+
+// scope returns the SCOPE PREFIX-LENGTH of answers to the query: the prefix
+// they were tailored to.
+func (c *clientSubnet) scope() uint8 {
+	if c.subnet == nil {
+		return 0
+	}
+	ones, _ := c.subnet.Mask.Size()
+	return uint8(ones) // #nosec G115
+}
+
+// option returns the ECS option of the response, which echoes the query's
+// family, source prefix and address (RFC 7871 Section 7.2.1).
+func (c *clientSubnet) option() packet.EdnsOption {
+	data := binary.BigEndian.AppendUint16(nil, c.family)
+	data = append(data, c.source, c.scope())
+	return packet.EdnsOption{Code: ednsOptionClientSubnet, Data: append(data, c.address...)}
+}
+
+// sendFormErr answers a malformed query with FORMERR.
+func sendFormErr(request *packet.DNSPacket, sendFn func([]byte) error) error {
+	response := packet.NewDNSPacket()
+	response.Header.ID = request.Header.ID
+	response.Header.Response = true
+	response.Header.Opcode = request.Header.Opcode
+	response.Header.ResCode = packet.RcodeFormErr
+	response.Questions = request.Questions
+	response.Resources = append(response.Resources, packet.DNSRecord{Name: ".", Type: packet.OPT, UDPPayloadSize: 4096})
+
+	resBuffer := packet.GetBuffer()
+	defer packet.PutBuffer(resBuffer)
+	if err := response.Write(resBuffer); err != nil {
+		return err
+	}
+	return sendFn(resBuffer.Buf[:resBuffer.Position()])
+}
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+func TestDecodeClientSubnet(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		subnet string
+		bad    bool
+	}{
+		{"IPv4 /24", []byte{0, 1, 24, 0, 198, 51, 100}, "198.51.100.0/24", false},
+		{"IPv4 /32 shortened", []byte{0, 1, 32, 0, 198, 51, 100, 7}, "198.51.100.0/24", false},
+		{"IPv6 /48", []byte{0, 2, 48, 0, 0x20, 0x01, 0x0d, 0xb8, 0, 1}, "2001:db8:1::/48", false},
+		{"source 0", []byte{0, 1, 0, 0}, "", false},
+		{"unknown family", []byte{0, 3, 8, 0, 1}, "", true},
+		{"nonzero scope", []byte{0, 1, 24, 24, 198, 51, 100}, "", true},
+		{"address too long", []byte{0, 1, 16, 0, 198, 51, 100}, "", true},
+		{"bits beyond prefix", []byte{0, 1, 20, 0, 198, 51, 100}, "", true},
+		{"prefix too long", []byte{0, 1, 33, 0, 1, 2, 3, 4, 5}, "", true},
+		{"truncated", []byte{0, 1}, "", true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := decodeClientSubnet(tc.data)
+			if c.malformed != tc.bad {
+				t.Fatalf("malformed = %v, want %v", c.malformed, tc.bad)
+			}
+			got := ""
+			if c.subnet != nil {
+				got = c.subnet.String()
+			}
+			if !tc.bad && got != tc.subnet {
+				t.Errorf("subnet = %q, want %q", got, tc.subnet)
+			}
+		})
+	}
+}
+
+// subnetRepo answers A queries for www.example.com. with 10.0.0.1 for clients in
+// 198.51.100.0/24 and 192.0.2.1 for everyone else, recording the lookups.
+type subnetRepo struct {
+	mockServerRepo
+	mu      sync.Mutex
+	lookups []string
+}
+
+func (r *subnetRepo) GetRecords(ctx context.Context, name string, qType domain.RecordType, clientIP string) ([]domain.Record, error) {
+	if qType != domain.TypeA || name != "www.example.com." {
+		return r.mockServerRepo.GetRecords(ctx, name, qType, clientIP)
+	}
+	r.mu.Lock()
+	r.lookups = append(r.lookups, clientIP)
+	r.mu.Unlock()
+	content := "192.0.2.1"
+	if clientIP == "198.51.100.0/24" {
+		content = "10.0.0.1"
+	}
+	return []domain.Record{{Name: name, Type: domain.TypeA, Content: content, TTL: 300}}, nil
+}
+
+func TestHandlePacketClientSubnet(t *testing.T) {
+	repo := &subnetRepo{mockServerRepo: mockServerRepo{zones: []domain.Zone{{ID: "z1", Name: "example.com."}}}}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+	srv.ClientSubnet = true
+	resolver := &net.UDPAddr{IP: net.ParseIP("203.0.113.53"), Port: 5353}
+
+	query := func(ecs []byte) *packet.DNSPacket {
+		t.Helper()
+		req := packet.NewDNSPacket()
+		req.Questions = append(req.Questions, packet.DNSQuestion{Name: "www.example.com.", QType: packet.A})
+		opt := packet.DNSRecord{Name: ".", Type: packet.OPT, UDPPayloadSize: 1232}
+		if ecs != nil {
+			opt.Options = append(opt.Options, packet.EdnsOption{Code: ednsOptionClientSubnet, Data: ecs})
+		}
+		req.Resources = append(req.Resources, opt)
+		buf := packet.NewBytePacketBuffer()
+		_ = req.Write(buf)
+		var raw []byte
+		if err := srv.handlePacket(buf.Buf[:buf.Position()], resolver, func(b []byte) error {
+			raw = append([]byte(nil), b...)
+			return nil
+		}, "udp"); err != nil {
+			t.Fatalf("handlePacket failed: %v", err)
+		}
+		return parseMessage(t, raw)
+	}
+	answer := func(resp *packet.DNSPacket) string {
+		if len(resp.Answers) != 1 {
+			return ""
+		}
+		return resp.Answers[0].IP.String()
+	}
+	ecsOption := func(resp *packet.DNSPacket) []byte {
+		for _, res := range resp.Resources {
+			for _, opt := range res.Options {
+				if res.Type == packet.OPT && opt.Code == ednsOptionClientSubnet {
+					return opt.Data
+				}
+			}
+		}
+		return nil
+	}
+
+	inside := []byte{0, 1, 24, 0, 198, 51, 100}
+	resp := query(inside)
+	if answer(resp) != "10.0.0.1" {
+		t.Fatalf("Expected the answer for the client subnet, got %+v", resp.Answers)
+	}
+	if got := ecsOption(resp); !bytes.Equal(got, []byte{0, 1, 24, 24, 198, 51, 100}) {
+		t.Errorf("Expected the ECS option echoed with scope 24, got %v", got)
+	}
+
+	// Other subnets and plain queries must not get the cached per-subnet answer
+	if resp := query([]byte{0, 1, 24, 0, 192, 0, 2}); answer(resp) != "192.0.2.1" {
+		t.Errorf("Expected the global answer for another subnet, got %+v", resp.Answers)
+	}
+	if resp := query(nil); answer(resp) != "192.0.2.1" || ecsOption(resp) != nil {
+		t.Errorf("Expected the global answer without ECS, got %+v", resp)
+	}
+	resp = query([]byte{0, 1, 0, 0})
+	if got := ecsOption(resp); !bytes.Equal(got, []byte{0, 1, 0, 0}) {
+		t.Errorf("Expected scope 0 for source prefix 0, got %v", got)
+	}
+
+	repo.mu.Lock()
+	lookups := len(repo.lookups)
+	repo.mu.Unlock()
+	if resp := query(inside); answer(resp) != "10.0.0.1" {
+		t.Errorf("Expected the cached per-subnet answer, got %+v", resp.Answers)
+	}
+	repo.mu.Lock()
+	if len(repo.lookups) != lookups {
+		t.Errorf("Expected the per-subnet answer from the cache, got lookups %v", repo.lookups)
+	}
+	repo.mu.Unlock()
+
+	if resp := query([]byte{0, 1, 20, 0, 198, 51, 100}); resp.Header.ResCode != packet.RcodeFormErr {
+		t.Errorf("Expected FORMERR for a malformed ECS option, got rcode %d", resp.Header.ResCode)
+	}
+}
+
+func TestCacheInvalidateVariants(t *testing.T) {
+	c := NewDNSCache()
+	c.Set("www.example.com.:1", []byte{1}, time.Minute)
+	c.Set("www.example.com.:1|198.51.100.0/24", []byte{2}, time.Minute)
+	c.Set("www.example.com.:28|198.51.100.0/24", []byte{3}, time.Minute)
+
+	c.Invalidate("www.example.com.:1")
+	if _, ok := c.Get("www.example.com.:1"); ok {
+		t.Error("Expected the key to be invalidated")
+	}
+	if _, ok := c.Get("www.example.com.:1|198.51.100.0/24"); ok {
+		t.Error("Expected the per-subnet variant to be invalidated")
+	}
+	if _, ok := c.Get("www.example.com.:28|198.51.100.0/24"); !ok {
+		t.Error("Expected other keys to be kept")
+	}
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"errors"
+	"net"
+
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+// addEDE attaches an Extended DNS Error (RFC 8914) to a response. Clients that did
+// not send EDNS get no OPT record, so the error is only logged on our side.
+func addEDE(response *packet.DNSPacket, code uint16, text string) {
+	for i := range response.Resources {
+		if response.Resources[i].Type == packet.OPT {
+			response.Resources[i].AddEDE(code, text)
+			return
+		}
+	}
+}
+
+// recursionEDE maps a failed recursive resolution to its EDE code: timeouts and
+// unreachable upstreams are network errors, anything else means no authority
+// answered usefully.
+func recursionEDE(err error) uint16 {
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return packet.EdeNetworkError
+	}
+	return packet.EdeNoReachableAuthority
+}
+
+// withEDNSOptions appends options to the OPT record of an encoded response, adding
+// one if the response was cached from a query without EDNS. Callers only use it for
+// EDNS queries. The response is returned unchanged if it cannot be re-encoded.
+func withEDNSOptions(resp []byte, opts ...packet.EdnsOption) []byte {
+	buf := packet.NewBytePacketBuffer()
+	buf.Load(resp)
+	response := packet.NewDNSPacket()
+	if err := response.FromBuffer(buf); err != nil {
+		return resp
+	}
+	found := false
+	for i := range response.Resources {
+		if response.Resources[i].Type == packet.OPT {
+			response.Resources[i].Options = append(response.Resources[i].Options, opts...)
+			found = true
+			break
+		}
+	}
+	if !found {
+		response.Resources = append(response.Resources, packet.DNSRecord{Name: ".", Type: packet.OPT, UDPPayloadSize: 4096, Options: opts})
+	}
+
+	out := packet.NewBytePacketBuffer()
+	out.HasNames = true
+	if err := response.Write(out); err != nil {
+		return resp
+	}
+	return out.Buf[:out.Position()]
+}
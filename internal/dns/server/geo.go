@@ -0,0 +1,114 @@
+package server
+
+import (
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// locate returns where addr, an address or a client subnet in CIDR notation,
+// is according to the GeoIP databases.
+func (s *Server) locate(addr string) (domain.GeoLocation, bool) {
+	if s.Geo == nil {
+		return domain.GeoLocation{}, false
+	}
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		_, subnet, err := net.ParseCIDR(addr)
+		if err != nil {
+			return domain.GeoLocation{}, false
+		}
+		ip = subnet.IP
+	}
+	return s.Geo.Locate(ip)
+}
+
+// clientGeo identifies the geo records addr sees by the parts of its location
+// some geo policy names, comma separated.
+func (s *Server) clientGeo(addr string) string {
+	views := s.views.Load()
+	if views == nil || len(views.geoScopes) == 0 {
+		return ""
+	}
+	loc, ok := s.locate(addr)
+	if !ok {
+		return ""
+	}
+	var matched []string
+	for _, scope := range loc.Scopes() {
+		if views.geoScopes[scope] {
+			matched = append(matched, "geo:"+scope)
+		}
+	}
+	return strings.Join(matched, ",")
+}
+
+// geoCached reports whether every location a record's geo policy names was
+// loaded. One that was not belongs to a record added since, so clients it is
+// meant for are not told apart in cache keys yet.
+func (s *Server) geoCached(rec domain.Record) bool {
+	if rec.Geo == nil {
+		return true
+	}
+	views := s.views.Load()
+	if views == nil {
+		return false
+	}
+	for _, scope := range rec.Geo.Scopes() {
+		if !views.geoScopes[scope] {
+			return false
+		}
+	}
+	return true
+}
+
+// geoRank is how closely a geo policy matches a location: 3 for its ASN, 2
+// for its country, 1 for its continent, 0 without a policy and -1 if the
+// policy does not match.
+func geoRank(geo *domain.GeoPolicy, loc domain.GeoLocation, located bool) int {
+	switch {
+	case geo == nil:
+		return 0
+	case !located:
+		return -1
+	case loc.ASN != 0 && slices.Contains(geo.ASNs, loc.ASN):
+		return 3
+	case loc.Country != "" && slices.ContainsFunc(geo.Countries, func(c string) bool { return strings.EqualFold(c, loc.Country) }):
+		return 2
+	case loc.Continent != "" && slices.ContainsFunc(geo.Continents, func(c string) bool { return strings.EqualFold(c, loc.Continent) }):
+		return 1
+	}
+	return -1
+}
+
+// selectGeo keeps, of each name and type with geo records, the records whose
+// policy matches the client's location most closely, or those without a
+// policy if none does. A name and type whose records all have policies that
+// miss the client is answered in full rather than left empty.
+func selectGeo(records []domain.Record, loc domain.GeoLocation, located bool) []domain.Record {
+	if !slices.ContainsFunc(records, func(rec domain.Record) bool { return rec.Geo != nil }) {
+		return records
+	}
+	type rrset struct {
+		name  string
+		rType domain.RecordType
+	}
+	best := make(map[rrset]int)
+	for _, rec := range records {
+		key := rrset{strings.ToLower(rec.Name), rec.Type}
+		rank := geoRank(rec.Geo, loc, located)
+		if current, ok := best[key]; !ok || rank > current {
+			best[key] = rank
+		}
+	}
+	kept := make([]domain.Record, 0, len(records))
+	for _, rec := range records {
+		rank := best[rrset{strings.ToLower(rec.Name), rec.Type}]
+		if rank < 0 || geoRank(rec.Geo, loc, located) == rank {
+			kept = append(kept, rec)
+		}
+	}
+	return kept
+}
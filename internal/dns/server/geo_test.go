@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// stubGeo locates addresses by the networks containing them.
+type stubGeo map[string]domain.GeoLocation
+
+func (g stubGeo) Locate(ip net.IP) (domain.GeoLocation, bool) {
+	for cidr, loc := range g {
+		if _, network, _ := net.ParseCIDR(cidr); network.Contains(ip) {
+			return loc, true
+		}
+	}
+	return domain.GeoLocation{}, false
+}
+
+func TestSelectGeo(t *testing.T) {
+	records := []domain.Record{
+		{Name: "www.geo.test.", Type: domain.TypeA, Content: "192.0.2.1"},
+		{Name: "www.geo.test.", Type: domain.TypeA, Content: "192.0.2.2", Geo: &domain.GeoPolicy{Continents: []string{"EU"}}},
+		{Name: "www.geo.test.", Type: domain.TypeA, Content: "192.0.2.3", Geo: &domain.GeoPolicy{Countries: []string{"DE"}}},
+		{Name: "www.geo.test.", Type: domain.TypeA, Content: "192.0.2.4", Geo: &domain.GeoPolicy{ASNs: []uint32{64500}}},
+		{Name: "www.geo.test.", Type: domain.TypeTXT, Content: "eu only", Geo: &domain.GeoPolicy{Continents: []string{"EU"}}},
+	}
+	tests := []struct {
+		loc     domain.GeoLocation
+		located bool
+		want    []string
+	}{
+		{domain.GeoLocation{Continent: "EU", Country: "DE", ASN: 64500}, true, []string{"192.0.2.4", "eu only"}},
+		{domain.GeoLocation{Continent: "EU", Country: "DE", ASN: 64501}, true, []string{"192.0.2.3", "eu only"}},
+		{domain.GeoLocation{Continent: "EU", Country: "FR"}, true, []string{"192.0.2.2", "eu only"}},
+		{domain.GeoLocation{Continent: "NA", Country: "US"}, true, []string{"192.0.2.1", "eu only"}},
+		{domain.GeoLocation{}, false, []string{"192.0.2.1", "eu only"}},
+	}
+	for _, tt := range tests {
+		got := selectGeo(records, tt.loc, tt.located)
+		var contents []string
+		for _, rec := range got {
+			contents = append(contents, rec.Content)
+		}
+		if len(contents) != len(tt.want) || contents[0] != tt.want[0] || contents[1] != tt.want[1] {
+			t.Errorf("selectGeo(%+v) = %v, want %v", tt.loc, contents, tt.want)
+		}
+	}
+}
+
+func TestHandlePacketGeo(t *testing.T) {
+	repo := &mockServerRepo{
+		zones: []domain.Zone{{ID: "z1", Name: "geo.test."}},
+		records: []domain.Record{
+			{ZoneID: "z1", Name: "www.geo.test.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 300},
+			{ZoneID: "z1", Name: "www.geo.test.", Type: domain.TypeA, Content: "192.0.2.2", TTL: 300, Geo: &domain.GeoPolicy{Countries: []string{"DE"}}},
+		},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+	srv.Geo = stubGeo{
+		"81.0.0.0/8": {Continent: "EU", Country: "DE"},
+		"82.0.0.0/8": {Continent: "EU", Country: "FR"},
+	}
+
+	// Before the locations are loaded the German answer must not be cached
+	if resp := queryFrom(t, srv, "www.geo.test.", "81.1.1.1:5353", false); len(resp.Answers) != 1 || resp.Answers[0].IP.String() != "192.0.2.2" {
+		t.Fatalf("Expected the German answer, got %v", resp.Answers)
+	}
+	if _, found := srv.Cache.Get("www.geo.test.:1"); found {
+		t.Fatal("Expected the answer of an unloaded location to stay uncached")
+	}
+
+	srv.syncViews(context.Background())
+	if resp := queryFrom(t, srv, "www.geo.test.", "81.1.1.1:5353", false); len(resp.Answers) != 1 || resp.Answers[0].IP.String() != "192.0.2.2" {
+		t.Fatalf("Expected the German answer, got %v", resp.Answers)
+	}
+	if resp := queryFrom(t, srv, "www.geo.test.", "82.1.1.1:5353", false); len(resp.Answers) != 1 || resp.Answers[0].IP.String() != "192.0.2.1" {
+		t.Fatalf("Expected the default answer in France, got %v", resp.Answers)
+	}
+	if _, found := srv.Cache.Get("www.geo.test.:1|view=geo:country:DE"); !found {
+		t.Error("Expected the German answer cached under its location")
+	}
+	if _, found := srv.Cache.Get("www.geo.test.:1"); !found {
+		t.Error("Expected the default answer cached without a location")
+	}
+}
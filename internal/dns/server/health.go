@@ -0,0 +1,28 @@
+package server
+
+import "github.com/poyrazK/cloudDNS/internal/core/domain"
+
+// selectHealthy leaves out the records failing their health check. An RRset
+// whose every record is failing is kept whole if fallback is set, so a check
+// that fails everywhere does not take the name down, and dropped otherwise.
+func selectHealthy(records []domain.Record, fallback bool) []domain.Record {
+	unhealthy := false
+	served := make(map[domain.RecordType]bool)
+	for _, rec := range records {
+		if rec.HealthStatus == domain.HealthStatusUnhealthy {
+			unhealthy = true
+		} else {
+			served[rec.Type] = true
+		}
+	}
+	if !unhealthy {
+		return records
+	}
+	healthy := make([]domain.Record, 0, len(records))
+	for _, rec := range records {
+		if rec.HealthStatus != domain.HealthStatusUnhealthy || (fallback && !served[rec.Type]) {
+			healthy = append(healthy, rec)
+		}
+	}
+	return healthy
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+func TestSelectHealthy(t *testing.T) {
+	records := []domain.Record{
+		{Type: domain.TypeA, Content: "192.0.2.1", HealthStatus: domain.HealthStatusUnhealthy},
+		{Type: domain.TypeA, Content: "192.0.2.2", HealthStatus: domain.HealthStatusUnknown},
+		{Type: domain.TypeTXT, Content: "down", HealthStatus: domain.HealthStatusUnhealthy},
+	}
+	got := selectHealthy(records, true)
+	if len(got) != 2 || got[0].Content != "192.0.2.2" || got[1].Content != "down" {
+		t.Errorf("Expected the healthy A record and the failing TXT set as a fallback, got %v", got)
+	}
+	if got := selectHealthy(records, false); len(got) != 1 || got[0].Content != "192.0.2.2" {
+		t.Errorf("Expected only the healthy record without a fallback, got %v", got)
+	}
+}
+
+func TestHandlePacketHealthGated(t *testing.T) {
+	repo := &mockServerRepo{
+		zones: []domain.Zone{{ID: "z1", TenantID: "t1", Name: "health.test."}},
+		records: []domain.Record{
+			{ZoneID: "z1", Name: "www.health.test.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 300, HealthStatus: domain.HealthStatusUnhealthy},
+			{ZoneID: "z1", Name: "www.health.test.", Type: domain.TypeA, Content: "192.0.2.2", TTL: 300, HealthStatus: domain.HealthStatusHealthy},
+		},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+
+	if resp := queryFrom(t, srv, "www.health.test.", "192.0.2.99:5353", false); len(resp.Answers) != 1 || resp.Answers[0].IP.String() != "192.0.2.2" {
+		t.Fatalf("Expected only the healthy address, got %v", resp.Answers)
+	}
+
+	// The last healthy record failing serves them all, after the change
+	// notification drops the cached answer
+	repo.mu.Lock()
+	repo.records[1].HealthStatus = domain.HealthStatusUnhealthy
+	repo.mu.Unlock()
+	srv.applyDataChange(domain.DataChange{Table: "record_health", Op: "UPDATE", ZoneID: "z1", Zone: "health.test.", Name: "www.health.test.", Type: domain.TypeA})
+	if resp := queryFrom(t, srv, "www.health.test.", "192.0.2.99:5353", false); len(resp.Answers) != 2 {
+		t.Errorf("Expected every address as a fallback, got %v", resp.Answers)
+	}
+
+	srv.HealthFallback = HealthFallbackNone
+	srv.applyDataChange(domain.DataChange{Table: "record_health", Op: "UPDATE", ZoneID: "z1", Zone: "health.test.", Name: "www.health.test.", Type: domain.TypeA})
+	if resp := queryFrom(t, srv, "www.health.test.", "192.0.2.99:5353", false); len(resp.Answers) != 0 {
+		t.Errorf("Expected no address without a fallback, got %v", resp.Answers)
+	}
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+)
+
+// ednsOptionNSID is the EDNS option code of the name server identifier (RFC 5001).
+const ednsOptionNSID = 3
+
+// nsidOption returns the NSID option identifying this node. Queries carry an empty
+// option; the response payload is the node identity.
+func (s *Server) nsidOption() packet.EdnsOption {
+	return packet.EdnsOption{Code: ednsOptionNSID, Data: []byte(s.Identity)}
+}
+
+// handleChaos answers CHAOS-class queries. id.server. and hostname.bind. return the
+// node identity as TXT so operators can tell which anycast instance answered; any
+// other CHAOS name is refused so such queries never reach zone data or the cache.
+func (s *Server) handleChaos(request *packet.DNSPacket, q packet.DNSQuestion, sendFn func([]byte) error, protocol string) error {
+	response := packet.NewDNSPacket()
+	response.Header.ID = request.Header.ID
+	response.Header.Response = true
+	response.Questions = append(response.Questions, q)
+
+	known := dnsname.Equal(q.Name, "id.server.") || dnsname.Equal(q.Name, "hostname.bind.")
+	refused := !known || s.Identity == ""
+	switch {
+	case refused:
+		response.Header.ResCode = packet.RcodeRefused
+	case q.QType == packet.TXT || q.QType == packet.ANY:
+		response.Header.AuthoritativeAnswer = true
+		response.Answers = append(response.Answers, packet.DNSRecord{
+			Name:  q.Name,
+			Type:  packet.TXT,
+			Class: ClassCHAOS,
+			TTL:   0,
+			Txt:   s.Identity,
+		})
+	default:
+		response.Header.AuthoritativeAnswer = true
+	}
+
+	for _, res := range request.Resources {
+		if res.Type == packet.OPT {
+			opt := packet.DNSRecord{Name: ".", Type: packet.OPT, UDPPayloadSize: 4096}
+			if s.Identity != "" && hasEDNSOption(request, ednsOptionNSID) {
+				opt.Options = append(opt.Options, s.nsidOption())
+			}
+			response.Resources = append(response.Resources, opt)
+			break
+		}
+	}
+	if refused {
+		// Server details beyond the identity are withheld by policy
+		addEDE(response, packet.EdeBlocked, "")
+	}
+
+	metrics.QueriesTotal.WithLabelValues(q.QType.String(), "0", protocol).Inc()
+	resBuffer := packet.GetBuffer()
+	defer packet.PutBuffer(resBuffer)
+	if err := response.Write(resBuffer); err != nil {
+		return err
+	}
+	return sendFn(resBuffer.Buf[:resBuffer.Position()])
+}
+
+// withNSID adds this node's NSID option to a response when the query asked for it.
+// The option is kept out of cached responses, which other nodes may serve from
+// Redis, and added to each reply instead.
+func (s *Server) withNSID(request *packet.DNSPacket, resp []byte) []byte {
+	if s.Identity == "" || !hasEDNSOption(request, ednsOptionNSID) {
+		return resp
+	}
+	return withEDNSOptions(resp, s.nsidOption())
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+// identityQuery sends a query through handlePacket and returns the parsed response.
+func identityQuery(t *testing.T, srv *Server, name string, qType packet.QueryType, class uint16, nsid bool) *packet.DNSPacket {
+	t.Helper()
+	req := packet.NewDNSPacket()
+	req.Header.ID = 99
+	req.Questions = append(req.Questions, packet.DNSQuestion{Name: name, QType: qType, QClass: class})
+	if nsid {
+		req.Resources = append(req.Resources, packet.DNSRecord{
+			Name: ".", Type: packet.OPT, UDPPayloadSize: 1232,
+			Options: []packet.EdnsOption{{Code: ednsOptionNSID}},
+		})
+	}
+	buf := packet.NewBytePacketBuffer()
+	if err := req.Write(buf); err != nil {
+		t.Fatalf("failed to write query: %v", err)
+	}
+
+	var raw []byte
+	if err := srv.handlePacket(buf.Buf[:buf.Position()], "127.0.0.1:5353", func(b []byte) error {
+		raw = append([]byte(nil), b...)
+		return nil
+	}, "udp"); err != nil {
+		t.Fatalf("handlePacket failed: %v", err)
+	}
+	resp := packet.NewDNSPacket()
+	rb := packet.NewBytePacketBuffer()
+	rb.Load(raw)
+	if err := resp.FromBuffer(rb); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	return resp
+}
+
+func responseNSID(resp *packet.DNSPacket) (string, bool) {
+	for _, res := range resp.Resources {
+		if res.Type != packet.OPT {
+			continue
+		}
+		for _, opt := range res.Options {
+			if opt.Code == ednsOptionNSID {
+				return string(opt.Data), true
+			}
+		}
+	}
+	return "", false
+}
+
+func TestChaosIdentity(t *testing.T) {
+	srv := NewServer("127.0.0.1:0", &mockServerRepo{}, nil)
+	srv.Identity = "fra1-a"
+
+	for _, name := range []string{"id.server.", "HOSTNAME.BIND."} {
+		resp := identityQuery(t, srv, name, packet.TXT, ClassCHAOS, true)
+		if resp.Header.ResCode != packet.RcodeNoError || len(resp.Answers) != 1 || resp.Answers[0].Txt != "fra1-a" {
+			t.Errorf("%s: expected TXT with the identity, got rcode %d, %+v", name, resp.Header.ResCode, resp.Answers)
+		}
+		if nsid, ok := responseNSID(resp); !ok || nsid != "fra1-a" {
+			t.Errorf("%s: expected NSID on the CHAOS answer, got %q", name, nsid)
+		}
+	}
+
+	if resp := identityQuery(t, srv, "id.server.", packet.A, ClassCHAOS, false); resp.Header.ResCode != packet.RcodeNoError || len(resp.Answers) != 0 {
+		t.Errorf("Expected NODATA for a non-TXT identity query, got rcode %d, %+v", resp.Header.ResCode, resp.Answers)
+	}
+	if resp := identityQuery(t, srv, "version.bind.", packet.TXT, ClassCHAOS, false); resp.Header.ResCode != packet.RcodeRefused {
+		t.Errorf("Expected REFUSED for other CHAOS names, got %d", resp.Header.ResCode)
+	}
+
+	srv.Identity = ""
+	if resp := identityQuery(t, srv, "id.server.", packet.TXT, ClassCHAOS, false); resp.Header.ResCode != packet.RcodeRefused {
+		t.Errorf("Expected REFUSED with the identity hidden, got %d", resp.Header.ResCode)
+	}
+}
+
+func TestNSIDOnCachedAnswers(t *testing.T) {
+	repo := &mockServerRepo{records: []domain.Record{{Name: "nsid.test.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 60}}}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+	srv.Identity = "ams2-b"
+
+	// The first answer is resolved, the second served from cache
+	for i := 0; i < 2; i++ {
+		resp := identityQuery(t, srv, "nsid.test.", packet.A, 1, true)
+		if nsid, ok := responseNSID(resp); !ok || nsid != "ams2-b" || len(resp.Answers) != 1 {
+			t.Errorf("Query %d: expected the answer with NSID ams2-b, got %q, %+v", i, nsid, resp.Answers)
+		}
+	}
+
+	// The cached copy carries no identity, so other nodes sharing it do not leak ours
+	cached, ok := srv.Cache.Get("nsid.test.:1")
+	if !ok {
+		t.Fatal("Expected the answer to be cached")
+	}
+	parsed := packet.NewDNSPacket()
+	buf := packet.NewBytePacketBuffer()
+	buf.Load(cached)
+	_ = parsed.FromBuffer(buf)
+	if _, found := responseNSID(parsed); found {
+		t.Error("Expected NSID to be kept out of the cache")
+	}
+
+	// Without the option no NSID is returned
+	if _, found := responseNSID(identityQuery(t, srv, "nsid.test.", packet.A, 1, false)); found {
+		t.Error("Expected no NSID when it was not requested")
+	}
+}
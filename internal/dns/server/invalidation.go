@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+)
+
+// cacheTypes are the query types whose cached answers are dropped when every
+// type of a name changes.
+var cacheTypes = []packet.QueryType{
+	packet.A, packet.NS, packet.CNAME, packet.SOA, packet.PTR, packet.MX, packet.TXT,
+	packet.AAAA, packet.SRV, packet.DS, packet.RRSIG, packet.NSEC, packet.DNSKEY,
+	packet.NSEC3, packet.NSEC3PARAM, packet.CDS, packet.CDNSKEY, packet.SVCB,
+	packet.HTTPS, packet.CAA, packet.NAPTR, packet.TLSA, packet.SSHFP, packet.LOC,
+	packet.URI, packet.CERT, packet.ANY,
+}
+
+// cacheChange names the cached answers a change made stale: one name and type,
+// every type of a name when Type is zero, or everything when Name is empty.
+type cacheChange struct {
+	Name string
+	Type packet.QueryType
+}
+
+// parseQueryType reads a query type given as a mnemonic such as "AAAA", a type
+// number or "TYPE<n>" (RFC 3597).
+func parseQueryType(s string) (packet.QueryType, bool) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if n, err := strconv.ParseUint(strings.TrimPrefix(s, "TYPE"), 10, 16); err == nil && n > 0 {
+		return packet.QueryType(n), true
+	}
+	for _, t := range cacheTypes {
+		if s != "" && t.String() == s {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+// parseInvalidation reads an InvalidationChannel payload. A type that cannot
+// be read invalidates every type of the name.
+func parseInvalidation(payload string) (cacheChange, bool) {
+	if payload == "*" {
+		return cacheChange{}, true
+	}
+	i := strings.LastIndexByte(payload, ':')
+	if i <= 0 {
+		return cacheChange{}, false
+	}
+	change := cacheChange{Name: dnsname.Normalize(payload[:i])}
+	if qt, ok := parseQueryType(payload[i+1:]); ok {
+		change.Type = qt
+	}
+	return change, true
+}
+
+// updateChanges returns the cached answers a dynamic update to zoneName made
+// stale. Deleting every RRset of a name or changing a CNAME affects every type
+// of the name, while wildcards and NS records shape answers for other names,
+// so they invalidate everything. The zone's SOA changes with its serial.
+func updateChanges(zoneName string, updates []packet.DNSRecord) []cacheChange {
+	if len(updates) == 0 {
+		return nil
+	}
+	seen := make(map[cacheChange]bool, len(updates)+1)
+	changes := make([]cacheChange, 0, len(updates)+1)
+	add := func(c cacheChange) {
+		if !seen[c] {
+			seen[c] = true
+			changes = append(changes, c)
+		}
+	}
+	for _, up := range updates {
+		name := dnsname.Normalize(up.Name)
+		switch {
+		case strings.HasPrefix(name, "*.") || up.Type == packet.NS:
+			return []cacheChange{{}}
+		case up.Type == packet.ANY || up.Type == packet.CNAME:
+			add(cacheChange{Name: name})
+		default:
+			add(cacheChange{Name: name, Type: up.Type})
+		}
+	}
+	add(cacheChange{Name: dnsname.Normalize(zoneName), Type: packet.SOA})
+	return changes
+}
+
+// invalidateLocal drops the answers a change made stale from this node's
+// in-memory caches.
+func (s *Server) invalidateLocal(c cacheChange) {
+	switch {
+	case c.Name == "":
+		metrics.CacheInvalidations.WithLabelValues("all").Inc()
+		s.flushL1()
+	case c.Type == 0:
+		metrics.CacheInvalidations.WithLabelValues("name").Inc()
+		for _, t := range cacheTypes {
+			s.invalidateL1(fmt.Sprintf("%s:%d", c.Name, t))
+		}
+	default:
+		metrics.CacheInvalidations.WithLabelValues("key").Inc()
+		s.invalidateL1(fmt.Sprintf("%s:%d", c.Name, c.Type))
+	}
+}
+
+// publishInvalidations deletes the stale answers from Redis and tells the other
+// nodes to drop them too.
+func (s *Server) publishInvalidations(changes []cacheChange) {
+	if s.Redis == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, c := range changes {
+		var err error
+		switch {
+		case c.Name == "":
+			err = s.Redis.InvalidateAll(ctx)
+		case c.Type == 0:
+			err = s.Redis.Invalidate(ctx, c.Name, "")
+		default:
+			err = s.Redis.Invalidate(ctx, c.Name, domain.RecordType(c.Type.String()))
+		}
+		if err != nil {
+			s.Logger.Warn("failed to publish cache invalidation", "name", c.Name, "type", c.Type.String(), "error", err)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+func TestParseInvalidation(t *testing.T) {
+	tests := []struct {
+		payload string
+		want    cacheChange
+		ok      bool
+	}{
+		{"www.Test.:A", cacheChange{Name: "www.test.", Type: packet.A}, true},
+		{"www.test.:aaaa", cacheChange{Name: "www.test.", Type: packet.AAAA}, true},
+		{"www.test.:TYPE65", cacheChange{Name: "www.test.", Type: packet.HTTPS}, true},
+		{"www.test.:16", cacheChange{Name: "www.test.", Type: packet.TXT}, true},
+		{"www.test.:*", cacheChange{Name: "www.test."}, true},
+		{"*", cacheChange{}, true},
+		{"www.test.", cacheChange{}, false},
+		{":A", cacheChange{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseInvalidation(tt.payload)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("parseInvalidation(%q) = %+v, %v; want %+v, %v", tt.payload, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestUpdateChanges(t *testing.T) {
+	got := updateChanges("Test.", []packet.DNSRecord{
+		{Name: "WWW.test.", Type: packet.A},
+		{Name: "www.test.", Type: packet.A},
+		{Name: "alias.test.", Type: packet.CNAME},
+		{Name: "gone.test.", Type: packet.ANY},
+	})
+	want := []cacheChange{
+		{Name: "www.test.", Type: packet.A},
+		{Name: "alias.test."},
+		{Name: "gone.test."},
+		{Name: "test.", Type: packet.SOA},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %+v, got %+v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Change %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+
+	for _, rec := range []packet.DNSRecord{{Name: "*.test.", Type: packet.A}, {Name: "sub.test.", Type: packet.NS}} {
+		got := updateChanges("test.", []packet.DNSRecord{{Name: "www.test.", Type: packet.A}, rec})
+		if len(got) != 1 || got[0] != (cacheChange{}) {
+			t.Errorf("Expected %s %s to invalidate everything, got %+v", rec.Name, rec.Type, got)
+		}
+	}
+	if got := updateChanges("test.", nil); got != nil {
+		t.Errorf("Expected no changes for an empty update, got %+v", got)
+	}
+}
+
+func TestZoneUpdatedInvalidatesOnlyChangedKeys(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to run miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	srv := NewServer("127.0.0.1:0", &mockServerRepo{}, nil)
+	srv.DisableAsync = true
+	srv.Redis = NewRedisCache(mr.Addr(), "", 0)
+
+	data := []byte{0, 1, 0x81, 0x80}
+	keys := []string{"www.test.:1", "www.test.:1|do", "www.test.:28", "mail.test.:15", "test.:6"}
+	for _, key := range keys {
+		srv.Cache.Set(key, data, time.Minute)
+	}
+
+	srv.zoneUpdated("test.", updateChanges("test.", []packet.DNSRecord{{Name: "www.test.", Type: packet.A}}))
+
+	for key, want := range map[string]bool{
+		"www.test.:1":    false,
+		"www.test.:1|do": false,
+		"test.:6":        false,
+		"www.test.:28":   true,
+		"mail.test.:15":  true,
+	} {
+		if _, found := srv.Cache.Get(key); found != want {
+			t.Errorf("%s: expected found=%v after updating www.test. A", key, want)
+		}
+	}
+
+	// An event from another node naming every type of the name
+	srv.invalidateLocal(cacheChange{Name: "www.test."})
+	if _, found := srv.Cache.Get("www.test.:28"); found {
+		t.Error("Expected every type of www.test. invalidated")
+	}
+	if _, found := srv.Cache.Get("mail.test.:15"); !found {
+		t.Error("Expected mail.test. kept")
+	}
+	srv.invalidateLocal(cacheChange{})
+	if _, found := srv.Cache.Get("mail.test.:15"); found {
+		t.Error("Expected everything flushed")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -157,3 +158,50 @@ func TestIXFR_FallbackToAXFR(t *testing.T) {
 	}
 	assert.True(t, foundWWW)
 }
+
+func TestIXFR_MismatchedDiffFallsBackToAXFR(t *testing.T) {
+	masterRepo := &mockServerRepo{}
+	zoneID := "zone-1"
+	zoneName := "example.com."
+	masterRepo.zones = append(masterRepo.zones, domain.Zone{ID: zoneID, Name: zoneName})
+	masterRepo.records = append(masterRepo.records,
+		domain.Record{ZoneID: zoneID, Name: zoneName, Type: domain.TypeSOA, Content: "ns1.example.com. admin.example.com. 2 3600 600 604800 300"},
+		domain.Record{ZoneID: zoneID, Name: "www.example.com.", Type: domain.TypeA, Content: "2.2.2.2", TTL: 300},
+	)
+	masterRepo.changes = append(masterRepo.changes,
+		domain.ZoneChange{ZoneID: zoneID, Serial: 2, Action: "DELETE", Name: zoneName, Type: domain.TypeSOA, Content: "ns1.example.com. admin.example.com. 1 3600 600 604800 300"},
+		domain.ZoneChange{ZoneID: zoneID, Serial: 2, Action: "DELETE", Name: "www.example.com.", Type: domain.TypeA, Content: "1.1.1.1", TTL: 300},
+		domain.ZoneChange{ZoneID: zoneID, Serial: 2, Action: "ADD", Name: "www.example.com.", Type: domain.TypeA, Content: "2.2.2.2", TTL: 300},
+		domain.ZoneChange{ZoneID: zoneID, Serial: 2, Action: "ADD", Name: zoneName, Type: domain.TypeSOA, Content: "ns1.example.com. admin.example.com. 2 3600 600 604800 300"},
+	)
+	masterAddr, cleanup := startMasterListener(t, NewServer("127.0.0.1:0", masterRepo, nil))
+	defer cleanup()
+
+	// The slave claims serial 1 but does not hold the record serial 2 deletes
+	slaveRepo := &mockServerRepo{}
+	slaveRepo.zones = append(slaveRepo.zones, domain.Zone{ID: zoneID, TenantID: "t1", Name: zoneName, Role: "slave", MasterServer: masterAddr})
+	slaveRepo.records = append(slaveRepo.records,
+		domain.Record{ZoneID: zoneID, Name: zoneName, Type: domain.TypeSOA, Content: "ns1.example.com. admin.example.com. 1 3600 600 604800 300"},
+		domain.Record{ZoneID: zoneID, Name: "www.example.com.", Type: domain.TypeA, Content: "9.9.9.9", TTL: 300},
+	)
+	slaveSrv := NewServer("127.0.0.1:0", slaveRepo, nil)
+
+	err := slaveSrv.performIXFR(&slaveRepo.zones[0], masterAddr, 1)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.Equal(t, "9.9.9.9", slaveRepo.records[1].Content, "a failed diff must leave the zone untouched")
+
+	slaveSrv.queryFn = func(server string, name string, qtype packet.QueryType) (*packet.DNSPacket, error) {
+		p := packet.NewDNSPacket()
+		p.Answers = append(p.Answers, packet.DNSRecord{Name: name, Type: packet.SOA, Serial: 2})
+		return p, nil
+	}
+	res, err := slaveSrv.TransferZone(context.Background(), zoneID, "t1")
+	require.NoError(t, err)
+	assert.Equal(t, "AXFR", res.Method)
+	contents := map[string]bool{}
+	for _, r := range slaveRepo.records {
+		contents[r.Content] = true
+	}
+	assert.Len(t, slaveRepo.records, 2)
+	assert.True(t, contents["2.2.2.2"] && contents["ns1.example.com. admin.example.com. 2 3600 600 604800 300"], "expected the master's version, got %+v", slaveRepo.records)
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+	"time"
+)
+
+// FlushCache empties the node's in-memory response, negative and DoH caches.
+// The shared Redis tier is left alone as it is kept consistent by invalidation.
+func (s *Server) FlushCache(_ context.Context) error {
+	if s.Cache != nil {
+		s.Cache.Flush()
+	}
+	if s.NegativeCache != nil {
+		s.NegativeCache.Flush()
+	}
+	if c, ok := s.DoHCache.(interface{ Flush() }); ok {
+		c.Flush()
+	}
+	s.Logger.Info("response caches flushed")
+	return nil
+}
+
+// SetCacheLimits bounds each of the node's in-memory response caches to about
+// maxEntries entries and maxBytes bytes; zero removes a limit.
+func (s *Server) SetCacheLimits(maxEntries, maxBytes int) {
+	for _, c := range []*DNSCache{s.Cache, s.recursionCache} {
+		if c != nil {
+			c.SetLimits(maxEntries, maxBytes)
+		}
+	}
+	if s.NegativeCache != nil {
+		s.NegativeCache.SetLimits(maxEntries, maxBytes)
+	}
+	if c, ok := s.DoHCache.(*DNSCache); ok {
+		c.SetLimits(maxEntries, maxBytes)
+	}
+}
+
+// SetServeStaleWindow sets how long answers are kept past their TTL to be
+// served while the database is unreachable (RFC 8767); zero disables it.
+func (s *Server) SetServeStaleWindow(window time.Duration) {
+	s.Cache.SetStaleWindow(window)
+	if s.NegativeCache != nil {
+		s.NegativeCache.SetStaleWindow(window)
+	}
+}
+
+// Drain takes the node out of service for maintenance: readiness starts failing,
+// which withdraws the anycast route, and in-flight queries are answered before
+// the listeners close. The process and its management API keep running. Without
+// a deadline on ctx, in-flight queries get DefaultDrainTimeout.
+func (s *Server) Drain(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultDrainTimeout)
+		defer cancel()
+	}
+	return s.Shutdown(ctx)
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFlushCache(t *testing.T) {
+	srv := NewServer("127.0.0.1:0", &mockServerRepo{}, nil)
+	srv.Cache.Set("a", []byte{1}, time.Minute)
+	srv.DoHCache.Set("b", []byte{2}, time.Minute)
+
+	if err := srv.FlushCache(context.Background()); err != nil {
+		t.Fatalf("FlushCache: %v", err)
+	}
+	if _, ok := srv.Cache.Get("a"); ok {
+		t.Error("response cache was not flushed")
+	}
+	if _, _, ok := srv.DoHCache.Lookup("b"); ok {
+		t.Error("DoH cache was not flushed")
+	}
+}
+
+func TestDrain(t *testing.T) {
+	srv := NewServer("127.0.0.1:0", &mockServerRepo{}, nil)
+	if err := srv.Ready(context.Background()); err != nil {
+		t.Fatalf("Ready before drain: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := srv.Drain(ctx); err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+	if err := srv.Ready(context.Background()); !errors.Is(err, ErrDraining) {
+		t.Errorf("Ready after drain = %v, want ErrDraining", err)
+	}
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+func TestHandlePacketDNSKEYMultiSigner(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockServerRepo{zones: []domain.Zone{{ID: "z1", TenantID: "t1", Name: "example.net."}}}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+	if err := srv.DNSSEC.AutomateLifecycle(ctx, "z1"); err != nil {
+		t.Fatalf("AutomateLifecycle failed: %v", err)
+	}
+	imported, err := srv.DNSSEC.ImportSignerKey(ctx, "z1", "t1", "example.net. 3600 IN DNSKEY 256 3 13 GojIhhXUN/u4v54ZQqGSnyhWJwaubCvTmeexv7bR6edbkrSqQpF64cYbcB7wNcP+e+MAnLr+Wi9xMWyQLc8NAA==")
+	if err != nil {
+		t.Fatalf("ImportSignerKey failed: %v", err)
+	}
+
+	req := packet.NewDNSPacket()
+	req.Questions = append(req.Questions, packet.DNSQuestion{Name: "example.net.", QType: packet.DNSKEY})
+	req.Resources = append(req.Resources, packet.DNSRecord{Name: ".", Type: packet.OPT, UDPPayloadSize: 4096, Z: 0x8000})
+	buf := packet.NewBytePacketBuffer()
+	_ = req.Write(buf)
+	var raw []byte
+	if err := srv.handlePacket(buf.Buf[:buf.Position()], &net.UDPAddr{IP: net.ParseIP("192.0.2.10"), Port: 5353}, func(b []byte) error {
+		raw = append([]byte(nil), b...)
+		return nil
+	}, "udp"); err != nil {
+		t.Fatalf("handlePacket failed: %v", err)
+	}
+	resp := parseMessage(t, raw)
+
+	var tags []uint16
+	var sigs []packet.DNSRecord
+	for _, ans := range resp.Answers {
+		switch ans.Type {
+		case packet.DNSKEY:
+			tags = append(tags, ans.ComputeKeyTag())
+		case packet.RRSIG:
+			sigs = append(sigs, ans)
+		}
+	}
+	if len(tags) != 3 {
+		t.Fatalf("Expected our KSK and ZSK plus the other signer's ZSK, got %d keys", len(tags))
+	}
+	found := false
+	for _, tag := range tags {
+		found = found || tag == imported.KeyTag
+	}
+	if !found {
+		t.Errorf("Expected the imported key %d in the DNSKEY RRset, got %v", imported.KeyTag, tags)
+	}
+	// Signed once, by our KSK
+	if len(sigs) != 1 || sigs[0].KeyTag == imported.KeyTag {
+		t.Errorf("Expected one RRSIG from our KSK, got %+v", sigs)
+	}
+}
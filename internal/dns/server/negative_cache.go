@@ -0,0 +1,156 @@
+package server
+
+import (
+	"strings"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+const (
+	// NegativeNXDomain marks a cached answer saying the name does not exist.
+	NegativeNXDomain = "nxdomain"
+	// NegativeNoData marks a cached answer saying the name exists without
+	// records of the queried type.
+	NegativeNoData = "nodata"
+)
+
+// NegativeCache holds NXDOMAIN and NODATA responses (RFC 2308) apart from the
+// positive answers in DNSCache, keyed by name and type like it and bounded
+// the same way. Entries are sharded by name, so a change to a name also drops
+// the NXDOMAIN answers cached for its other types.
+type NegativeCache struct {
+	entries *DNSCache
+}
+
+// NewNegativeCache initializes an empty, unbounded NegativeCache.
+func NewNegativeCache() *NegativeCache {
+	return &NegativeCache{entries: newDNSCache("negative", negativeKeyName)}
+}
+
+// negativeKeyName returns the name part of a "name:type|subnet" cache key.
+func negativeKeyName(key string) string {
+	key = baseCacheKey(key)
+	if i := strings.LastIndexByte(key, ':'); i >= 0 {
+		key = key[:i]
+	}
+	return key
+}
+
+// SetLimits bounds the cache like DNSCache.SetLimits.
+func (c *NegativeCache) SetLimits(maxEntries, maxBytes int) {
+	c.entries.SetLimits(maxEntries, maxBytes)
+}
+
+// SetStaleWindow sets how long negative answers are served stale like
+// DNSCache.SetStaleWindow.
+func (c *NegativeCache) SetStaleWindow(window time.Duration) {
+	c.entries.SetStaleWindow(window)
+}
+
+// Get retrieves a copy of a negative response with its TTLs decremented by the
+// entry's age, along with its kind. It returns false if the key is missing or
+// has expired.
+func (c *NegativeCache) Get(key string) ([]byte, string, bool) {
+	now := time.Now()
+	item, found := c.entries.find(key, now, false)
+	if !found {
+		return nil, "", false
+	}
+	return item.aged(now), item.kind, true
+}
+
+// GetStale retrieves a negative response even if its TTL has passed, as long
+// as its stale window has not, like DNSCache.GetStale.
+func (c *NegativeCache) GetStale(key string) ([]byte, string, bool) {
+	now := time.Now()
+	item, found := c.entries.find(key, now, true)
+	if !found {
+		return nil, "", false
+	}
+	return c.entries.stale(item, now), item.kind, true
+}
+
+// Set stores a negative response of the given kind for ttl.
+func (c *NegativeCache) Set(key string, data []byte, kind string, ttl time.Duration) {
+	c.entries.set(key, data, kind, ttl)
+}
+
+// Invalidate removes a key and its variants. Records of any type make the name
+// exist, so the NXDOMAIN answers cached for the name's other types go too.
+func (c *NegativeCache) Invalidate(key string) {
+	name := negativeKeyName(key)
+	prefix := key + "|"
+	c.entries.removeIf(key, func(e *cacheEntry) bool {
+		return e.key == key || strings.HasPrefix(e.key, prefix) || (e.kind == NegativeNXDomain && negativeKeyName(e.key) == name)
+	})
+}
+
+// Flush removes every negative response.
+func (c *NegativeCache) Flush() {
+	c.entries.Flush()
+}
+
+// Cleanup removes the entries whose stale retention has passed.
+func (c *NegativeCache) Cleanup() {
+	c.entries.Cleanup()
+}
+
+// Stats returns the cache's current size and counters.
+func (c *NegativeCache) Stats() CacheStats {
+	return c.entries.Stats()
+}
+
+// negativeKind reports whether a response is negative and which kind it is:
+// NXDOMAIN, or an authoritative NOERROR without answers. Referrals are not
+// authoritative, so they are not mistaken for NODATA.
+func negativeKind(resp []byte) (string, bool) {
+	if len(resp) < 12 {
+		return "", false
+	}
+	switch rcode := resp[3] & 0x0F; {
+	case rcode == packet.RcodeNxDomain:
+		return NegativeNXDomain, true
+	case rcode == packet.RcodeNoError && resp[2]&0x04 != 0 && resp[6] == 0 && resp[7] == 0:
+		return NegativeNoData, true
+	}
+	return "", false
+}
+
+// negativeTTL returns how long a negative answer may be cached: the lesser of
+// the TTL and MINIMUM of the SOA in its authority section (RFC 2308 5). Without
+// an SOA the answer must not be cached.
+func negativeTTL(authorities []packet.DNSRecord) (uint32, bool) {
+	for _, rr := range authorities {
+		if rr.Type == packet.SOA {
+			return min(rr.TTL, rr.Minimum), true
+		}
+	}
+	return 0, false
+}
+
+// storeL1 keeps a response in this node's cache, negative answers apart from
+// positive ones.
+func (s *Server) storeL1(key string, data []byte, ttl time.Duration) {
+	if kind, negative := negativeKind(data); negative && s.NegativeCache != nil {
+		s.NegativeCache.Set(key, data, kind, ttl)
+		return
+	}
+	s.Cache.Set(key, data, ttl)
+}
+
+// invalidateL1 drops the answers cached on this node for a "name:type" key.
+func (s *Server) invalidateL1(key string) {
+	s.Cache.Invalidate(key)
+	if s.NegativeCache != nil {
+		s.NegativeCache.Invalidate(key)
+	}
+}
+
+// flushL1 empties this node's response caches.
+func (s *Server) flushL1() {
+	s.Cache.Flush()
+	if s.NegativeCache != nil {
+		s.NegativeCache.Flush()
+	}
+}
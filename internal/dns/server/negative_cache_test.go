@@ -0,0 +1,188 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+func TestNegativeCacheInvalidate(t *testing.T) {
+	cache := NewNegativeCache()
+	cache.Set("gone.test.:1", []byte{1}, NegativeNXDomain, time.Hour)
+	cache.Set("gone.test.:28", []byte{2}, NegativeNXDomain, time.Hour)
+	cache.Set("gone.test.:28|10.0.0.0", []byte{3}, NegativeNXDomain, time.Hour)
+	cache.Set("host.test.:16", []byte{4}, NegativeNoData, time.Hour)
+	cache.Set("host.test.:15", []byte{5}, NegativeNoData, time.Hour)
+
+	// Adding an A record makes gone.test. exist for every type
+	cache.Invalidate("gone.test.:1")
+	for _, key := range []string{"gone.test.:1", "gone.test.:28", "gone.test.:28|10.0.0.0"} {
+		if _, _, found := cache.Get(key); found {
+			t.Errorf("Expected %s to be invalidated", key)
+		}
+	}
+
+	// NODATA for other types of an existing name stays valid
+	cache.Invalidate("host.test.:16")
+	if _, _, found := cache.Get("host.test.:16"); found {
+		t.Error("Expected host.test.:16 to be invalidated")
+	}
+	if _, kind, found := cache.Get("host.test.:15"); !found || kind != NegativeNoData {
+		t.Errorf("Expected host.test.:15 to stay cached as NODATA, got %q %v", kind, found)
+	}
+}
+
+func TestNegativeCacheExpiration(t *testing.T) {
+	cache := NewNegativeCache()
+	cache.Set("short.test.:1", []byte{1}, NegativeNXDomain, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if _, _, found := cache.Get("short.test.:1"); found {
+		t.Error("Expected the entry to expire")
+	}
+	if _, kind, found := cache.GetStale("short.test.:1"); !found || kind != NegativeNXDomain {
+		t.Error("Expected the expired entry to be served stale")
+	}
+	cache.Cleanup()
+	if _, _, found := cache.GetStale("short.test.:1"); !found {
+		t.Error("Expected cleanup to keep the entry for its stale retention")
+	}
+}
+
+func TestNegativeKind(t *testing.T) {
+	header := func(flags, rcode, ancount byte) []byte {
+		resp := make([]byte, 12)
+		resp[2], resp[3], resp[7] = flags, rcode, ancount
+		return resp
+	}
+	tests := []struct {
+		name     string
+		resp     []byte
+		kind     string
+		negative bool
+	}{
+		{"nxdomain", header(0x84, packet.RcodeNxDomain, 0), NegativeNXDomain, true},
+		{"nodata", header(0x84, packet.RcodeNoError, 0), NegativeNoData, true},
+		{"answer", header(0x84, packet.RcodeNoError, 1), "", false},
+		{"referral", header(0x80, packet.RcodeNoError, 0), "", false},
+		{"servfail", header(0x80, packet.RcodeServFail, 0), "", false},
+		{"short", []byte{0, 1}, "", false},
+	}
+	for _, tt := range tests {
+		kind, negative := negativeKind(tt.resp)
+		if kind != tt.kind || negative != tt.negative {
+			t.Errorf("%s: got %q %v, want %q %v", tt.name, kind, negative, tt.kind, tt.negative)
+		}
+	}
+}
+
+func TestNegativeTTL(t *testing.T) {
+	if _, ok := negativeTTL([]packet.DNSRecord{{Type: packet.NS, TTL: 60}}); ok {
+		t.Error("Expected no negative TTL without an SOA")
+	}
+	ttl, ok := negativeTTL([]packet.DNSRecord{{Type: packet.SOA, TTL: 3600, Minimum: 30}})
+	if !ok || ttl != 30 {
+		t.Errorf("Expected the SOA minimum of 30s, got %d", ttl)
+	}
+	ttl, _ = negativeTTL([]packet.DNSRecord{{Type: packet.SOA, TTL: 20, Minimum: 300}})
+	if ttl != 20 {
+		t.Errorf("Expected the SOA TTL of 20s, got %d", ttl)
+	}
+}
+
+func TestHandlePacketNegativeCache(t *testing.T) {
+	repo := &mockServerRepo{
+		zones: []domain.Zone{{ID: "z1", Name: "neg.test."}},
+		records: []domain.Record{
+			{ZoneID: "z1", Name: "neg.test.", Type: domain.TypeSOA, Content: "ns1.neg.test. admin.neg.test. 1 3600 600 604800 30", TTL: 3600},
+		},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+
+	req := packet.NewDNSPacket()
+	req.Header.ID = 7
+	req.Questions = append(req.Questions, packet.DNSQuestion{Name: "missing.neg.test.", QType: packet.A})
+	buffer := packet.NewBytePacketBuffer()
+	_ = req.Write(buffer)
+
+	var captured []byte
+	send := func(resp []byte) error {
+		captured = resp
+		return nil
+	}
+	if err := srv.handlePacket(buffer.Buf[:buffer.Position()], "10.0.0.5:5353", send, "udp"); err != nil {
+		t.Fatalf("HandlePacket failed: %v", err)
+	}
+
+	resp := packet.NewDNSPacket()
+	pb := packet.NewBytePacketBuffer()
+	pb.Load(captured)
+	if err := resp.FromBuffer(pb); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if resp.Header.ResCode != packet.RcodeNxDomain || len(resp.Authorities) == 0 {
+		t.Fatalf("Expected NXDOMAIN with an SOA, got rcode %d", resp.Header.ResCode)
+	}
+	if resp.Authorities[0].TTL != 30 {
+		t.Errorf("Expected the SOA TTL lowered to its minimum, got %d", resp.Authorities[0].TTL)
+	}
+
+	if _, found := srv.Cache.Get("missing.neg.test.:1"); found {
+		t.Error("Expected the NXDOMAIN to stay out of the positive cache")
+	}
+	if _, kind, found := srv.NegativeCache.Get("missing.neg.test.:1"); !found || kind != NegativeNXDomain {
+		t.Fatalf("Expected a cached NXDOMAIN, got %q %v", kind, found)
+	}
+
+	// A cached answer is served without the repository
+	repo.mu.Lock()
+	repo.zones = nil
+	repo.mu.Unlock()
+	captured = nil
+	if err := srv.handlePacket(buffer.Buf[:buffer.Position()], "10.0.0.5:5353", send, "udp"); err != nil {
+		t.Fatalf("HandlePacket failed: %v", err)
+	}
+	if len(captured) < 4 || captured[3]&0x0F != packet.RcodeNxDomain {
+		t.Errorf("Expected the cached NXDOMAIN, got %v", captured)
+	}
+
+	srv.invalidateL1("missing.neg.test.:28")
+	if _, _, found := srv.NegativeCache.Get("missing.neg.test.:1"); found {
+		t.Error("Expected a change to the name to drop its NXDOMAIN")
+	}
+}
+
+func TestHandlePacketNoData(t *testing.T) {
+	repo := &mockServerRepo{
+		zones: []domain.Zone{{ID: "z1", Name: "nodata.test."}},
+		records: []domain.Record{
+			{ZoneID: "z1", Name: "nodata.test.", Type: domain.TypeSOA, Content: "ns1.nodata.test. admin.nodata.test. 1 3600 600 604800 30", TTL: 3600},
+			{ZoneID: "z1", Name: "mail.nodata.test.", Type: domain.TypeMX, Content: "mx.nodata.test.", TTL: 300},
+			{ZoneID: "z1", Name: "host.lab.nodata.test.", Type: domain.TypeA, Content: "192.0.2.7", TTL: 300},
+		},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+
+	// A name with records of other types, and an empty non-terminal, exist
+	for _, name := range []string{"mail.nodata.test.", "lab.nodata.test."} {
+		resp := queryFrom(t, srv, name, "10.0.0.5:5353", false)
+		if resp.Header.ResCode != packet.RcodeNoError || len(resp.Answers) != 0 {
+			t.Fatalf("Expected NODATA for %s, got rcode %d: %+v", name, resp.Header.ResCode, resp.Answers)
+		}
+		if len(resp.Authorities) != 1 || resp.Authorities[0].Type != packet.SOA || resp.Authorities[0].TTL != 30 {
+			t.Errorf("Expected the zone's SOA for %s, got %+v", name, resp.Authorities)
+		}
+		if _, kind, found := srv.NegativeCache.Get(name + ":1"); !found || kind != NegativeNoData {
+			t.Errorf("Expected a cached NODATA for %s, got %q %v", name, kind, found)
+		}
+	}
+
+	resp := queryFrom(t, srv, "missing.nodata.test.", "10.0.0.5:5353", false)
+	if resp.Header.ResCode != packet.RcodeNxDomain {
+		t.Errorf("Expected NXDOMAIN for a name that does not exist, got rcode %d", resp.Header.ResCode)
+	}
+	if n := repo.zoneListings.Load(); n != 0 {
+		t.Errorf("Expected negative answers to look the name up without listing the zone, listed it %d times", n)
+	}
+}
@@ -35,7 +35,7 @@ func TestEndToEnd_Protocols(t *testing.T) {
 	dnsSrv.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
 
 	go func() {
-		_ = dnsSrv.Run()
+		_ = dnsSrv.Run(context.Background())
 	}()
 
 	apiHandler := api.NewAPIHandler(svc, repo)
@@ -0,0 +1,235 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+)
+
+const (
+	// QueryTypeRefuse refuses matching queries on every transport.
+	QueryTypeRefuse = "refuse"
+	// QueryTypeRefuseUDP refuses matching queries over UDP and answers them
+	// over TCP and the encrypted transports.
+	QueryTypeRefuseUDP = "refuse-udp"
+	// QueryTypeTCP answers matching queries over UDP with a truncated response,
+	// so clients retry over TCP where their address is verified.
+	QueryTypeTCP = "tcp"
+)
+
+// policyQueryTypes are the query types a policy can name; others are written
+// as TYPEnnn.
+var policyQueryTypes = []packet.QueryType{
+	packet.A, packet.NS, packet.CNAME, packet.SOA, packet.PTR, packet.HINFO, packet.MX, packet.TXT,
+	packet.AAAA, packet.SRV, packet.DS, packet.RRSIG, packet.NSEC, packet.DNSKEY, packet.NSEC3,
+	packet.NSEC3PARAM, packet.CDS, packet.CDNSKEY, packet.SVCB, packet.HTTPS, packet.CAA,
+	packet.NAPTR, packet.TLSA, packet.SSHFP, packet.LOC, packet.URI, packet.CERT,
+	packet.AXFR, packet.IXFR, packet.ANY,
+}
+
+// QueryTypePolicy limits how queries of expensive types are answered, so the
+// server cannot be used to amplify spoofed UDP traffic.
+type QueryTypePolicy struct {
+	Name string
+	// Types are the query types the policy applies to.
+	Types []packet.QueryType
+	// Action is QueryTypeRefuse, QueryTypeRefuseUDP or QueryTypeTCP.
+	Action string
+	// MinSize limits QueryTypeTCP to UDP responses larger than MinSize bytes;
+	// 0 truncates every UDP response.
+	MinSize int
+	// Zones limits the policy to names at or below these; empty applies to
+	// every name.
+	Zones []string
+	// Clients are the networks the policy applies to; empty applies to every
+	// client.
+	Clients []*net.IPNet
+}
+
+// ParseQueryTypePolicies parses semicolon-separated policies, each a
+// comma-separated list of query types and an action followed by optional
+// name=, size=, zones= and clients= fields, for example
+// "ANY refuse-udp; TXT tcp size=1232 zones=big.example.com". The first policy
+// matching a query applies.
+func ParseQueryTypePolicies(spec string) ([]QueryTypePolicy, error) {
+	var policies []QueryTypePolicy
+	for i, part := range strings.Split(spec, ";") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("query type policy %d: expected types and an action", i)
+		}
+		policy := QueryTypePolicy{Name: fmt.Sprintf("policy%d", i), Action: strings.ToLower(fields[1])}
+		for _, name := range strings.Split(fields[0], ",") {
+			qType, err := parsePolicyQueryType(name)
+			if err != nil {
+				return nil, fmt.Errorf("query type policy %d: %w", i, err)
+			}
+			policy.Types = append(policy.Types, qType)
+		}
+		switch policy.Action {
+		case QueryTypeRefuse, QueryTypeRefuseUDP, QueryTypeTCP:
+		default:
+			return nil, fmt.Errorf("query type policy %d: unknown action %q", i, fields[1])
+		}
+		for _, field := range fields[2:] {
+			key, val, ok := strings.Cut(field, "=")
+			if !ok {
+				return nil, fmt.Errorf("query type policy %d: %q is not key=value", i, field)
+			}
+			switch key {
+			case "name":
+				policy.Name = val
+			case "size":
+				size, err := strconv.Atoi(val)
+				if err != nil || size < 0 || size > packet.MaxPacketSize {
+					return nil, fmt.Errorf("query type policy %d: invalid size %q", i, val)
+				}
+				if policy.Action != QueryTypeTCP {
+					return nil, fmt.Errorf("query type policy %d: size only applies to the %s action", i, QueryTypeTCP)
+				}
+				policy.MinSize = size
+			case "zones":
+				for _, zone := range strings.Split(val, ",") {
+					policy.Zones = append(policy.Zones, dnsname.Normalize(dnsname.Fqdn(zone)))
+				}
+			case "clients":
+				clients, err := parseCIDRs(val)
+				if err != nil {
+					return nil, fmt.Errorf("query type policy %d: clients: %w", i, err)
+				}
+				policy.Clients = clients
+			default:
+				return nil, fmt.Errorf("query type policy %d: unknown field %q", i, key)
+			}
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+func parsePolicyQueryType(name string) (packet.QueryType, error) {
+	name = strings.ToUpper(name)
+	for _, qType := range policyQueryTypes {
+		if qType.String() == name {
+			return qType, nil
+		}
+	}
+	if n, ok := strings.CutPrefix(name, "TYPE"); ok {
+		if v, err := strconv.ParseUint(n, 10, 16); err == nil {
+			return packet.QueryType(v), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown query type %q", name)
+}
+
+// queryTypePolicy returns the first policy applying to a query of name and
+// qType from clientIP over protocol, or nil. The UDP-only actions do not
+// apply to other transports.
+func (s *Server) queryTypePolicy(name string, qType packet.QueryType, protocol, clientIP string) *QueryTypePolicy {
+	if len(s.QueryTypePolicies) == 0 {
+		return nil
+	}
+	name = dnsname.Normalize(name)
+	ip := net.ParseIP(clientIP)
+	for i := range s.QueryTypePolicies {
+		policy := &s.QueryTypePolicies[i]
+		if policy.Action != QueryTypeRefuse && protocol != "udp" {
+			continue
+		}
+		if policy.matches(name, qType) && clientMatches(policy.Clients, ip) {
+			return policy
+		}
+	}
+	return nil
+}
+
+func (p *QueryTypePolicy) matches(name string, qType packet.QueryType) bool {
+	typeMatches := false
+	for _, t := range p.Types {
+		if t == qType {
+			typeMatches = true
+			break
+		}
+	}
+	if !typeMatches {
+		return false
+	}
+	if len(p.Zones) == 0 {
+		return true
+	}
+	for _, zone := range p.Zones {
+		if dnsname.IsSubdomain(name, zone) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyQueryTypePolicy refuses a query its policy refuses, reporting true, or
+// wraps sendFn to truncate UDP responses over the policy's size.
+func (s *Server) applyQueryTypePolicy(policy *QueryTypePolicy, request *packet.DNSPacket, sendFn func([]byte) error) (func([]byte) error, bool, error) {
+	if policy.Action != QueryTypeTCP {
+		metrics.QueryTypePolicyActions.WithLabelValues(policy.Action).Inc()
+		return sendFn, true, sendRefused(request, sendFn)
+	}
+	return func(resp []byte) error {
+		if policy.MinSize > 0 && len(resp) <= policy.MinSize {
+			return sendFn(resp)
+		}
+		metrics.QueryTypePolicyActions.WithLabelValues(policy.Action).Inc()
+		return sendTruncated(request, resp, sendFn)
+	}, false, nil
+}
+
+// sendRefused answers a query with REFUSED.
+func sendRefused(request *packet.DNSPacket, sendFn func([]byte) error) error {
+	response := packet.NewDNSPacket()
+	response.Header.ID = request.Header.ID
+	response.Header.Response = true
+	response.Header.Opcode = request.Header.Opcode
+	response.Header.RecursionDesired = request.Header.RecursionDesired
+	response.Header.ResCode = packet.RcodeRefused
+	response.Questions = request.Questions
+	return writeWithEDNS(request, response, sendFn)
+}
+
+// sendTruncated replaces a UDP response with an empty one flagged TC, keeping
+// its authority bit and rcode, so the client retries over TCP.
+func sendTruncated(request *packet.DNSPacket, resp []byte, sendFn func([]byte) error) error {
+	response := packet.NewDNSPacket()
+	response.Header.ID = request.Header.ID
+	response.Header.Response = true
+	response.Header.Opcode = request.Header.Opcode
+	response.Header.RecursionDesired = request.Header.RecursionDesired
+	response.Header.TruncatedMessage = true
+	if len(resp) >= 4 {
+		response.Header.AuthoritativeAnswer = resp[2]&0x04 != 0
+		response.Header.ResCode = resp[3] & 0x0F
+	}
+	response.Questions = request.Questions
+	return writeWithEDNS(request, response, sendFn)
+}
+
+// writeWithEDNS sends response, with an OPT record if the request had one.
+func writeWithEDNS(request, response *packet.DNSPacket, sendFn func([]byte) error) error {
+	for _, res := range request.Resources {
+		if res.Type == packet.OPT {
+			response.Resources = append(response.Resources, packet.DNSRecord{Name: ".", Type: packet.OPT, UDPPayloadSize: 4096})
+			break
+		}
+	}
+	resBuffer := packet.GetBuffer()
+	defer packet.PutBuffer(resBuffer)
+	if err := response.Write(resBuffer); err != nil {
+		return err
+	}
+	return sendFn(resBuffer.Buf[:resBuffer.Position()])
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+func TestParseQueryTypePolicies(t *testing.T) {
+	policies, err := ParseQueryTypePolicies("any refuse-udp; TXT,TYPE99 tcp size=1232 zones=Big.Example.com name=txt clients=10.0.0.0/8")
+	if err != nil {
+		t.Fatalf("ParseQueryTypePolicies failed: %v", err)
+	}
+	if len(policies) != 2 {
+		t.Fatalf("Expected 2 policies, got %d", len(policies))
+	}
+	if p := policies[0]; p.Name != "policy0" || p.Action != QueryTypeRefuseUDP || len(p.Types) != 1 || p.Types[0] != packet.ANY {
+		t.Errorf("Unexpected first policy %+v", p)
+	}
+	p := policies[1]
+	if p.Name != "txt" || p.Action != QueryTypeTCP || p.MinSize != 1232 || len(p.Clients) != 1 ||
+		len(p.Types) != 2 || p.Types[1] != packet.QueryType(99) || p.Zones[0] != "big.example.com." {
+		t.Errorf("Unexpected second policy %+v", p)
+	}
+
+	for _, bad := range []string{"ANY", "ANY drop", "FOO refuse", "ANY refuse size=100", "TXT tcp size=-1", "TXT tcp clients=10/8", "TXT tcp zone=x"} {
+		if _, err := ParseQueryTypePolicies(bad); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestHandlePacketQueryTypePolicies(t *testing.T) {
+	records := []domain.Record{{ZoneID: "z1", Name: "small.test.", Type: domain.TypeTXT, Content: "short", TTL: 60}}
+	for i := 0; i < 10; i++ {
+		records = append(records, domain.Record{ZoneID: "z2", Name: "big.test.", Type: domain.TypeTXT, Content: strings.Repeat(fmt.Sprint(i), 200), TTL: 60})
+	}
+	repo := &mockServerRepo{zones: []domain.Zone{{ID: "z1", Name: "small.test."}, {ID: "z2", Name: "big.test."}}, records: records}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+	policies, err := ParseQueryTypePolicies("ANY refuse-udp; TXT tcp size=1000; A refuse zones=small.test clients=192.0.2.0/24")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.QueryTypePolicies = policies
+
+	query := func(name string, qType packet.QueryType, protocol, src string) *packet.DNSPacket {
+		t.Helper()
+		req := packet.NewDNSPacket()
+		req.Header.ID = 7
+		req.Questions = append(req.Questions, packet.DNSQuestion{Name: name, QType: qType})
+		req.Resources = append(req.Resources, packet.DNSRecord{Name: ".", Type: packet.OPT, UDPPayloadSize: 4096})
+		reqBuf := packet.NewBytePacketBuffer()
+		_ = req.Write(reqBuf)
+		var captured []byte
+		if err := srv.handlePacket(reqBuf.Buf[:reqBuf.Position()], src+":5353", func(resp []byte) error {
+			captured = resp
+			return nil
+		}, protocol); err != nil {
+			t.Fatalf("handlePacket failed: %v", err)
+		}
+		resBuf := packet.NewBytePacketBuffer()
+		resBuf.Load(captured)
+		resp := packet.NewDNSPacket()
+		if err := resp.FromBuffer(resBuf); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		return resp
+	}
+
+	if resp := query("small.test.", packet.ANY, "udp", "10.0.0.1"); resp.Header.ResCode != packet.RcodeRefused || resp.Header.ID != 7 || len(resp.Resources) != 1 {
+		t.Errorf("Expected ANY over UDP to be refused with EDNS, got %+v", resp.Header)
+	}
+	if resp := query("small.test.", packet.ANY, "tcp", "10.0.0.1"); resp.Header.ResCode == packet.RcodeRefused {
+		t.Error("Expected ANY over TCP to be answered")
+	}
+
+	for i := 0; i < 2; i++ {
+		resp := query("big.test.", packet.TXT, "udp", "10.0.0.1")
+		if !resp.Header.TruncatedMessage || !resp.Header.AuthoritativeAnswer || len(resp.Answers) != 0 {
+			t.Errorf("Expected a large TXT answer over UDP to be truncated (attempt %d), got %+v", i, resp.Header)
+		}
+	}
+	if resp := query("big.test.", packet.TXT, "tcp", "10.0.0.1"); resp.Header.TruncatedMessage || len(resp.Answers) != 10 {
+		t.Errorf("Expected the full TXT answer over TCP, got %d answers", len(resp.Answers))
+	}
+	if resp := query("small.test.", packet.TXT, "udp", "10.0.0.1"); resp.Header.TruncatedMessage || len(resp.Answers) != 1 {
+		t.Errorf("Expected a small TXT answer over UDP, got %+v", resp.Header)
+	}
+
+	if resp := query("small.test.", packet.A, "tcp", "192.0.2.1"); resp.Header.ResCode != packet.RcodeRefused {
+		t.Errorf("Expected A in small.test to be refused on every transport, got rcode %d", resp.Header.ResCode)
+	}
+	if resp := query("small.test.", packet.A, "udp", "10.0.0.1"); resp.Header.ResCode == packet.RcodeRefused {
+		t.Error("Expected the policy to apply only to its clients")
+	}
+	if resp := query("big.test.", packet.A, "udp", "192.0.2.1"); resp.Header.ResCode == packet.RcodeRefused {
+		t.Error("Expected the policy to apply only to its zones")
+	}
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/adapters/repository"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+// UDPQuerier sends non-recursive queries over UDP the way an external resolver
+// would, retrying over TCP when the answer is truncated.
+type UDPQuerier struct {
+	Timeout time.Duration
+	// Recursive sets the RD bit, for asking recursive resolvers rather than
+	// the authoritative servers of a zone.
+	Recursive bool
+}
+
+// NewUDPQuerier creates a querier that gives up on a server after timeout.
+func NewUDPQuerier(timeout time.Duration) *UDPQuerier {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &UDPQuerier{Timeout: timeout}
+}
+
+// Query sends a single question for name and qType to server and returns the rcode
+// and answer records.
+func (q *UDPQuerier) Query(ctx context.Context, server string, name string, qType domain.RecordType) (*domain.ProbeAnswer, error) {
+	req := packet.NewDNSPacket()
+	req.Header.ID = generateTransactionID()
+	req.Header.RecursionDesired = q.Recursive
+	req.Questions = append(req.Questions, *packet.NewDNSQuestion(name, packet.RecordTypeToQueryType(qType)))
+	buffer := packet.NewBytePacketBuffer()
+	if err := req.Write(buffer); err != nil {
+		return nil, err
+	}
+	msg := buffer.Buf[:buffer.Position()]
+
+	resp, err := q.exchange(ctx, "udp", server, req, msg)
+	if err == nil && resp.Header.TruncatedMessage {
+		resp, err = q.exchange(ctx, "tcp", server, req, msg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	answer := &domain.ProbeAnswer{Rcode: int(resp.Header.ResCode)}
+	for _, rr := range resp.Answers {
+		rec, errConv := repository.ConvertPacketRecordToDomain(rr, "")
+		if errConv != nil {
+			continue // Signatures and other types the probe does not compare
+		}
+		answer.Records = append(answer.Records, rec)
+	}
+	return answer, nil
+}
+
+// Notify sends a NOTIFY for zone to server and waits for it to be acknowledged
+// (RFC 1996). A non-empty keyName signs the message with TSIG.
+func (q *UDPQuerier) Notify(ctx context.Context, server string, zone string, keyName string, secret []byte) error {
+	req := packet.NewDNSPacket()
+	req.Header.ID = generateTransactionID()
+	req.Header.Opcode = packet.OpcodeNotify
+	req.Header.AuthoritativeAnswer = true
+	req.Questions = append(req.Questions, *packet.NewDNSQuestion(zone, packet.SOA))
+	buffer := packet.NewBytePacketBuffer()
+	if err := req.Write(buffer); err != nil {
+		return err
+	}
+	if keyName != "" {
+		if err := req.SignTSIG(buffer, keyName, secret); err != nil {
+			return err
+		}
+	}
+
+	resp, err := q.exchange(ctx, "udp", server, req, buffer.Buf[:buffer.Position()])
+	if err != nil {
+		return err
+	}
+	if resp.Header.ResCode != packet.RcodeNoError {
+		return fmt.Errorf("NOTIFY answered with rcode %d", resp.Header.ResCode)
+	}
+	return nil
+}
+
+// exchange sends the encoded msg for req to server and returns the reply that
+// answers it. Over UDP, datagrams that do not match req are skipped until the
+// timeout; over TCP the connection is ours, so a mismatch is an error.
+func (q *UDPQuerier) exchange(ctx context.Context, network, server string, req *packet.DNSPacket, msg []byte) (*packet.DNSPacket, error) {
+	ctx, cancel := context.WithTimeout(ctx, q.Timeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, server)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	var raw []byte
+	if network == "tcp" {
+		prefix := binary.BigEndian.AppendUint16(nil, uint16(len(msg)))
+		if _, err := conn.Write(append(prefix, msg...)); err != nil {
+			return nil, err
+		}
+		var length [2]byte
+		if _, err := io.ReadFull(conn, length[:]); err != nil {
+			return nil, err
+		}
+		raw = make([]byte, binary.BigEndian.Uint16(length[:]))
+		if _, err := io.ReadFull(conn, raw); err != nil {
+			return nil, err
+		}
+	} else {
+		if _, err := conn.Write(msg); err != nil {
+			return nil, err
+		}
+		return readUDPResponse(conn, req)
+	}
+
+	resBuffer := packet.NewBytePacketBuffer()
+	resBuffer.Load(raw)
+	resp := packet.NewDNSPacket()
+	if err := resp.FromBuffer(resBuffer); err != nil {
+		return nil, err
+	}
+	if err := matchResponse(req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
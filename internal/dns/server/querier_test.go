@@ -0,0 +1,161 @@
+package server
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+// answerFor parses a query and builds a response answering it with a single A record.
+func answerFor(t *testing.T, raw []byte, truncated bool) []byte {
+	t.Helper()
+	buf := packet.NewBytePacketBuffer()
+	buf.Load(raw)
+	req := packet.NewDNSPacket()
+	if err := req.FromBuffer(buf); err != nil {
+		t.Errorf("failed to parse query: %v", err)
+		return nil
+	}
+
+	resp := packet.NewDNSPacket()
+	resp.Header.ID = req.Header.ID
+	resp.Header.Response = true
+	resp.Header.AuthoritativeAnswer = true
+	resp.Header.TruncatedMessage = truncated
+	resp.Questions = req.Questions
+	if !truncated {
+		resp.Answers = append(resp.Answers, packet.DNSRecord{
+			Name: req.Questions[0].Name, Type: packet.A, Class: 1, TTL: 60, IP: net.ParseIP("192.0.2.7"),
+		})
+	}
+	out := packet.NewBytePacketBuffer()
+	if err := resp.Write(out); err != nil {
+		t.Errorf("failed to write response: %v", err)
+		return nil
+	}
+	return out.Buf[:out.Position()]
+}
+
+func TestUDPQuerier(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer func() { _ = pc.Close() }()
+
+	var truncate atomic.Bool
+	go func() {
+		tmp := make([]byte, 512)
+		for {
+			n, addr, errRead := pc.ReadFrom(tmp)
+			if errRead != nil {
+				return
+			}
+			_, _ = pc.WriteTo(answerFor(t, tmp[:n], truncate.Load()), addr)
+		}
+	}()
+
+	q := NewUDPQuerier(time.Second)
+	answer, err := q.Query(context.Background(), pc.LocalAddr().String(), "example.com.", domain.TypeA)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if answer.Rcode != 0 || len(answer.Records) != 1 || answer.Records[0].Content != "192.0.2.7" || answer.Records[0].Type != domain.TypeA {
+		t.Errorf("Unexpected answer: %+v", answer)
+	}
+
+	// A truncated UDP answer is retried over TCP on the same address
+	ln, err := net.Listen("tcp", pc.LocalAddr().String())
+	if err != nil {
+		t.Skipf("TCP port not available: %v", err)
+	}
+	defer func() { _ = ln.Close() }()
+	go func() {
+		conn, errAccept := ln.Accept()
+		if errAccept != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		var length [2]byte
+		if _, errRead := io.ReadFull(conn, length[:]); errRead != nil {
+			return
+		}
+		raw := make([]byte, binary.BigEndian.Uint16(length[:]))
+		if _, errRead := io.ReadFull(conn, raw); errRead != nil {
+			return
+		}
+		resp := answerFor(t, raw, false)
+		_, _ = conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(resp))), resp...))
+	}()
+
+	truncate.Store(true)
+	answer, err = q.Query(context.Background(), pc.LocalAddr().String(), "example.com.", domain.TypeA)
+	if err != nil || len(answer.Records) != 1 {
+		t.Errorf("Expected TCP fallback to return the answer, got %+v, %v", answer, err)
+	}
+}
+
+func TestUDPQuerier_Timeout(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer func() { _ = pc.Close() }()
+
+	q := NewUDPQuerier(50 * time.Millisecond)
+	if _, err := q.Query(context.Background(), pc.LocalAddr().String(), "example.com.", domain.TypeSOA); err == nil {
+		t.Error("Expected timeout error from silent server")
+	}
+}
+
+func TestUDPQuerierNotify(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer func() { _ = pc.Close() }()
+
+	secret := []byte("secret")
+	go func() {
+		tmp := make([]byte, 512)
+		for {
+			n, addr, errRead := pc.ReadFrom(tmp)
+			if errRead != nil {
+				return
+			}
+			buf := packet.NewBytePacketBuffer()
+			buf.Load(tmp[:n])
+			req := packet.NewDNSPacket()
+			if errParse := req.FromBuffer(buf); errParse != nil {
+				continue
+			}
+			resp := packet.NewDNSPacket()
+			resp.Header.ID = req.Header.ID
+			resp.Header.Response = true
+			resp.Header.Opcode = req.Header.Opcode
+			resp.Questions = req.Questions
+			// Only NOTIFYs signed with the shared key are accepted
+			if req.Header.Opcode != packet.OpcodeNotify || req.TSIGStart == -1 || req.VerifyTSIG(tmp[:n], req.TSIGStart, secret) != nil {
+				resp.Header.ResCode = packet.RcodeNotAuth
+			}
+			out := packet.NewBytePacketBuffer()
+			_ = resp.Write(out)
+			_, _ = pc.WriteTo(out.Buf[:out.Position()], addr)
+		}
+	}()
+
+	q := NewUDPQuerier(time.Second)
+	if err := q.Notify(context.Background(), pc.LocalAddr().String(), "example.test.", "xfer-key.", secret); err != nil {
+		t.Errorf("Expected a signed NOTIFY to be acknowledged, got %v", err)
+	}
+	if err := q.Notify(context.Background(), pc.LocalAddr().String(), "example.test.", "", nil); err == nil {
+		t.Error("Expected an unsigned NOTIFY to be refused")
+	}
+}
@@ -0,0 +1,202 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+)
+
+// queryLimitSyncInterval is how often the query limits of tenants and zones
+// are reloaded.
+var queryLimitSyncInterval = 15 * time.Second
+
+// limitedZone is a zone of a tenant with query limits.
+type limitedZone struct {
+	id       string
+	tenantID string
+}
+
+// limitBucket is the token bucket of one query limit.
+type limitBucket struct {
+	rate  float64
+	burst int
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newLimitBucket(limit domain.QueryLimit) *limitBucket {
+	return &limitBucket{rate: limit.QPS, burst: limit.Burst, tokens: float64(limit.Burst)}
+}
+
+func (b *limitBucket) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.last.IsZero() {
+		b.tokens = min(float64(b.burst), b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// queryLimitSet holds the query limits in force, with the zones of the limited
+// tenants indexed by name so that every query, cached or not, is attributed.
+type queryLimitSet struct {
+	zones   map[string]limitedZone  // Normalized zone name -> zone
+	tenants map[string]*limitBucket // Tenant ID -> tenant-wide bucket
+	zoneIDs map[string]*limitBucket // Zone ID -> zone bucket
+}
+
+// scheduleQueryLimits reloads the query limits until ctx is done.
+func (s *Server) scheduleQueryLimits(ctx context.Context) {
+	if s.Repo == nil {
+		return
+	}
+	ticker := time.NewTicker(queryLimitSyncInterval)
+	defer ticker.Stop()
+	for {
+		s.syncQueryLimits(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// syncQueryLimits loads the query limits and the zones of the tenants they
+// apply to. Buckets whose limit is unchanged carry over, so a reload neither
+// refills nor drains them.
+func (s *Server) syncQueryLimits(ctx context.Context) {
+	limits, err := s.Repo.ListQueryLimits(ctx, "")
+	if err != nil {
+		s.Logger.Error("failed to load query limits", "error", err)
+		return
+	}
+	if len(limits) == 0 {
+		s.queryLimits.Store(nil)
+		return
+	}
+
+	current := s.queryLimits.Load()
+	set := &queryLimitSet{
+		zones:   make(map[string]limitedZone),
+		tenants: make(map[string]*limitBucket),
+		zoneIDs: make(map[string]*limitBucket),
+	}
+	keep := func(buckets map[string]*limitBucket, key string, limit domain.QueryLimit) *limitBucket {
+		if b, ok := buckets[key]; ok && b.rate == limit.QPS && b.burst == limit.Burst {
+			return b
+		}
+		return newLimitBucket(limit)
+	}
+	for _, limit := range limits {
+		if limit.ZoneID == "" {
+			var old map[string]*limitBucket
+			if current != nil {
+				old = current.tenants
+			}
+			set.tenants[limit.TenantID] = keep(old, limit.TenantID, limit)
+			continue
+		}
+		var old map[string]*limitBucket
+		if current != nil {
+			old = current.zoneIDs
+		}
+		set.zoneIDs[limit.ZoneID] = keep(old, limit.ZoneID, limit)
+	}
+
+	tenants := make(map[string]struct{})
+	for _, limit := range limits {
+		tenants[limit.TenantID] = struct{}{}
+	}
+	for tenantID := range tenants {
+		zones, err := s.Repo.ListZones(ctx, tenantID)
+		if err != nil {
+			s.Logger.Error("failed to load the zones of a limited tenant", "tenant", tenantID, "error", err)
+			return
+		}
+		for _, zone := range zones {
+			set.zones[dnsname.Normalize(zone.Name)] = limitedZone{id: zone.ID, tenantID: zone.TenantID}
+		}
+	}
+	s.queryLimits.Store(set)
+}
+
+// limitedZoneFor returns the closest enclosing zone of name among those of the
+// limited tenants.
+func (set *queryLimitSet) limitedZoneFor(name string) (limitedZone, bool) {
+	name = dnsname.Normalize(name)
+	for {
+		if zone, ok := set.zones[name]; ok {
+			return zone, true
+		}
+		parent, ok := dnsname.Parent(name)
+		if !ok {
+			return limitedZone{}, false
+		}
+		name = parent
+	}
+}
+
+// allowQuery takes a token from the limits of the zone holding name and of its
+// tenant. It reports the exceeded limit's tenant and scope ("zone" or "tenant")
+// when the query is over one.
+func (s *Server) allowQuery(name string, trace *queryTrace) (string, string, bool) {
+	set := s.queryLimits.Load()
+	if set == nil {
+		return "", "", true
+	}
+	zone, ok := set.limitedZoneFor(name)
+	if !ok {
+		return "", "", true
+	}
+	zoneBucket, tenantBucket := set.zoneIDs[zone.id], set.tenants[zone.tenantID]
+	if trace != nil {
+		trace.queryLimits(zone, zoneBucket, tenantBucket)
+		return "", "", true
+	}
+	now := time.Now()
+	if zoneBucket != nil && !zoneBucket.allow(now) {
+		return zone.tenantID, "zone", false
+	}
+	if tenantBucket != nil && !tenantBucket.allow(now) {
+		return zone.tenantID, "tenant", false
+	}
+	return "", "", true
+}
+
+// sendQueryLimited refuses a query over its tenant's or zone's query limit.
+func sendQueryLimited(request *packet.DNSPacket, tenantID, scope string, sendFn func([]byte) error) error {
+	metrics.QueryLimitExceeded.WithLabelValues(tenantID, scope).Inc()
+	response := packet.NewDNSPacket()
+	response.Header.ID = request.Header.ID
+	response.Header.Response = true
+	response.Header.Opcode = request.Header.Opcode
+	response.Header.RecursionDesired = request.Header.RecursionDesired
+	response.Header.ResCode = packet.RcodeRefused
+	response.Questions = request.Questions
+	for _, res := range request.Resources {
+		if res.Type == packet.OPT {
+			response.Resources = append(response.Resources, packet.DNSRecord{Name: ".", Type: packet.OPT, UDPPayloadSize: 4096})
+			addEDE(response, packet.EdeProhibited, scope+" query rate limit exceeded")
+			break
+		}
+	}
+	resBuffer := packet.GetBuffer()
+	defer packet.PutBuffer(resBuffer)
+	if err := response.Write(resBuffer); err != nil {
+		return err
+	}
+	return sendFn(resBuffer.Buf[:resBuffer.Position()])
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+func TestQueryLimits(t *testing.T) {
+	repo := &mockServerRepo{
+		zones: []domain.Zone{
+			{ID: "z1", TenantID: "t1", Name: "limited.test."},
+			{ID: "z2", TenantID: "t1", Name: "other.test."},
+			{ID: "z3", TenantID: "t2", Name: "free.test."},
+		},
+		records: []domain.Record{
+			{ZoneID: "z1", Name: "www.limited.test.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 300},
+			{ZoneID: "z2", Name: "www.other.test.", Type: domain.TypeA, Content: "192.0.2.2", TTL: 300},
+			{ZoneID: "z3", Name: "www.free.test.", Type: domain.TypeA, Content: "192.0.2.3", TTL: 300},
+		},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+
+	query := func(name string) *packet.DNSPacket {
+		t.Helper()
+		req := packet.NewDNSPacket()
+		req.Header.ID = 7
+		req.Questions = append(req.Questions, packet.DNSQuestion{Name: name, QType: packet.A, QClass: 1})
+		req.Resources = append(req.Resources, packet.DNSRecord{Name: ".", Type: packet.OPT, UDPPayloadSize: 4096})
+		reqBuf := packet.NewBytePacketBuffer()
+		_ = req.Write(reqBuf)
+		var captured []byte
+		if err := srv.handlePacket(reqBuf.Buf[:reqBuf.Position()], &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 12345}, func(resp []byte) error {
+			captured = resp
+			return nil
+		}, "udp"); err != nil {
+			t.Fatalf("handlePacket failed: %v", err)
+		}
+		resBuf := packet.NewBytePacketBuffer()
+		resBuf.Load(captured)
+		resp := packet.NewDNSPacket()
+		if err := resp.FromBuffer(resBuf); err != nil {
+			t.Fatalf("failed to parse response: %v", err)
+		}
+		return resp
+	}
+
+	ctx := context.Background()
+	_ = repo.SaveQueryLimit(ctx, &domain.QueryLimit{TenantID: "t1", ZoneID: "z1", QPS: 0.001, Burst: 2})
+	_ = repo.SaveQueryLimit(ctx, &domain.QueryLimit{TenantID: "t1", QPS: 0.001, Burst: 4})
+	srv.syncQueryLimits(ctx)
+
+	// The second query is answered from the cache and still counts
+	for i := 0; i < 2; i++ {
+		if resp := query("WWW.limited.test."); resp.Header.ResCode != packet.RcodeNoError || len(resp.Answers) != 1 {
+			t.Fatalf("Expected query %d within the zone limit to be answered, got rcode %d", i, resp.Header.ResCode)
+		}
+	}
+	resp := query("www.limited.test.")
+	if resp.Header.ResCode != packet.RcodeRefused || len(resp.Answers) != 0 {
+		t.Fatalf("Expected REFUSED over the zone limit, got rcode %d", resp.Header.ResCode)
+	}
+	if codes := edeCodes(resp); len(codes) != 1 || codes[0] != packet.EdeProhibited {
+		t.Errorf("Expected EDE Prohibited, got %v", codes)
+	}
+
+	// The tenant-wide limit has two tokens left after the zone's queries
+	for i := 0; i < 2; i++ {
+		if resp := query("www.other.test."); resp.Header.ResCode != packet.RcodeNoError {
+			t.Fatalf("Expected query %d within the tenant limit to be answered, got rcode %d", i, resp.Header.ResCode)
+		}
+	}
+	if resp := query("www.other.test."); resp.Header.ResCode != packet.RcodeRefused {
+		t.Errorf("Expected REFUSED over the tenant limit, got rcode %d", resp.Header.ResCode)
+	}
+	for i := 0; i < 5; i++ {
+		if resp := query("www.free.test."); resp.Header.ResCode != packet.RcodeNoError {
+			t.Fatalf("Expected another tenant's zone to be unlimited, got rcode %d", resp.Header.ResCode)
+		}
+	}
+
+	// A reload keeps spent buckets whose limit is unchanged
+	srv.syncQueryLimits(ctx)
+	if resp := query("www.other.test."); resp.Header.ResCode != packet.RcodeRefused {
+		t.Errorf("Expected the tenant bucket to survive a reload, got rcode %d", resp.Header.ResCode)
+	}
+
+	trace, err := srv.TraceQuery(ctx, "www.limited.test.", domain.TypeA, "127.0.0.1", false)
+	if err != nil || trace.Rcode != "NOERROR" {
+		t.Fatalf("Expected a traced query to bypass the limits, got %+v, %v", trace, err)
+	}
+	found := false
+	for _, step := range trace.Steps {
+		found = found || step.Stage == "limit"
+	}
+	if !found {
+		t.Errorf("Expected the limits in the trace, got %+v", trace.Steps)
+	}
+
+	repo.mu.Lock()
+	repo.limits = nil
+	repo.mu.Unlock()
+	srv.syncQueryLimits(ctx)
+	if resp := query("www.other.test."); resp.Header.ResCode != packet.RcodeNoError {
+		t.Errorf("Expected removed limits to stop applying, got rcode %d", resp.Header.ResCode)
+	}
+}
+
+func TestLimitBucket(t *testing.T) {
+	b := newLimitBucket(domain.QueryLimit{QPS: 10, Burst: 2})
+	now := time.Now()
+	if !b.allow(now) || !b.allow(now) || b.allow(now) {
+		t.Fatal("Expected the burst to be spent after two queries")
+	}
+	if !b.allow(now.Add(100 * time.Millisecond)) {
+		t.Error("Expected a token to be refilled after a tenth of a second")
+	}
+	if b.allow(now.Add(100 * time.Millisecond)) {
+		t.Error("Expected a single token to be refilled")
+	}
+}
@@ -1,21 +1,64 @@
 package server
 
 import (
+	"context"
 	"sync"
 	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+)
+
+const (
+	// DefaultRateLimitQPS and DefaultRateLimitBurst are the per-client token
+	// bucket unless SetRateLimit changes it.
+	DefaultRateLimitQPS   = 500000
+	DefaultRateLimitBurst = 200000
+
+	// DefaultRateLimitLease is the number of tokens a node takes from the shared
+	// bucket at a time, so most queries are decided without a Redis round trip.
+	DefaultRateLimitLease = 20
+
+	// sharedLimitRetry is how long a client the shared bucket refused stays
+	// limited before the node asks Redis again, so a flood of refused queries
+	// does not turn into a flood of Redis calls.
+	sharedLimitRetry = 100 * time.Millisecond
+
+	// maxRateLimitLeases caps the leases being fetched from the shared bucket at
+	// once. Clients beyond it, such as the sources of a spoofed flood, are
+	// limited by the local buckets alone until a lease can be fetched.
+	maxRateLimitLeases = 64
 )
 
-// rateLimiter implements a simple per-IP token bucket
+// tokenStore hands out tokens from per-client buckets shared by every node.
+type tokenStore interface {
+	// TakeTokens refills the bucket for key and takes up to n tokens from it,
+	// returning how many were granted.
+	TakeTokens(ctx context.Context, key string, rate float64, burst int, n int) (int, error)
+}
+
+// rateLimiter implements a simple per-IP token bucket. With a shared store the
+// bucket lives in Redis and holds for the whole fleet; the local buckets then
+// cache leased tokens. Leases are fetched in the background, before the last
+// ones run out, and queries are never held up by Redis: until a client's lease
+// arrives, or when the store fails, its local bucket decides.
 type rateLimiter struct {
 	mu      sync.Mutex
 	buckets map[string]*bucket
 	rate    float64 // tokens per second
 	burst   int     // max tokens
+
+	store  tokenStore
+	lease  int
+	leases chan struct{} // one slot per lease being fetched
 }
 
 type bucket struct {
 	tokens float64
 	last   time.Time
+
+	leased       int       // tokens taken from the shared bucket, not yet spent
+	leasing      bool      // a lease is being fetched
+	limitedUntil time.Time // the shared bucket refused the last lease; retry after
 }
 
 func newRateLimiter(rate float64, burst int) *rateLimiter {
@@ -26,30 +69,110 @@ func newRateLimiter(rate float64, burst int) *rateLimiter {
 	}
 }
 
+// setStore makes the limiter enforce its limits fleet-wide through store,
+// taking lease tokens at a time.
+func (rl *rateLimiter) setStore(store tokenStore, lease int) {
+	if lease <= 0 {
+		lease = DefaultRateLimitLease
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.store = store
+	rl.lease = lease
+	rl.leases = make(chan struct{}, maxRateLimitLeases)
+}
+
+// setLimit changes the rate and burst of every client's bucket.
+func (rl *rateLimiter) setLimit(rate float64, burst int) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.rate = rate
+	rl.burst = burst
+}
+
 func (rl *rateLimiter) Allow(ip string) bool {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
+	now := time.Now()
+	b := rl.bucketFor(ip, now)
+	rl.refill(b, now)
+	if rl.store != nil {
+		if b.leased > 0 {
+			b.leased--
+			if b.leased < (rl.lease+1)/2 {
+				rl.fetchLease(ip, b)
+			}
+			return rateLimitDecision(true, "redis")
+		}
+		rl.fetchLease(ip, b)
+		// A client the shared bucket refused stays limited until a lease
+		// is granted again, rather than falling back to its local bucket.
+		if !b.limitedUntil.IsZero() && (now.Before(b.limitedUntil) || b.leasing) {
+			return rateLimitDecision(false, "redis")
+		}
+	}
+	return rateLimitDecision(rl.allowLocal(b), "local")
+}
+
+// fetchLease takes a lease of tokens for ip from the shared bucket in the
+// background, unless one is already on its way or too many are. A refused
+// lease limits the client for sharedLimitRetry. Callers hold rl.mu.
+func (rl *rateLimiter) fetchLease(ip string, b *bucket) {
+	if b.leasing || time.Now().Before(b.limitedUntil) {
+		return
+	}
+	select {
+	case rl.leases <- struct{}{}:
+	default:
+		return
+	}
+	b.leasing = true
+	store, lease, rate, burst := rl.store, rl.lease, rl.rate, rl.burst
+	go func() {
+		defer func() { <-rl.leases }()
+		granted, err := store.TakeTokens(context.Background(), ip, rate, burst, lease)
+		rl.mu.Lock()
+		defer rl.mu.Unlock()
+		b.leasing = false
+		if err != nil {
+			metrics.RedisErrors.WithLabelValues("ratelimit").Inc()
+			return
+		}
+		b.leased += granted
+		if granted == 0 {
+			b.limitedUntil = time.Now().Add(sharedLimitRetry)
+		} else {
+			b.limitedUntil = time.Time{}
+		}
+	}()
+}
 
+// bucketFor returns the bucket of ip, creating a full one. Callers hold rl.mu.
+func (rl *rateLimiter) bucketFor(ip string, now time.Time) *bucket {
 	b, exists := rl.buckets[ip]
 	if !exists {
 		b = &bucket{
 			tokens: float64(rl.burst),
-			last:   time.Now(),
+			last:   now,
 		}
 		rl.buckets[ip] = b
 	}
+	return b
+}
 
-	now := time.Now()
+// refill adds the tokens the node's own bucket earned since it was last used.
+// Callers hold rl.mu.
+func (rl *rateLimiter) refill(b *bucket, now time.Time) {
 	elapsed := now.Sub(b.last).Seconds()
 	b.last = now
-
-	// Refill
 	b.tokens += elapsed * rl.rate
 	if b.tokens > float64(rl.burst) {
 		b.tokens = float64(rl.burst)
 	}
+}
 
-	// Consume
+// allowLocal spends a token from the node's own bucket. Callers hold rl.mu.
+func (rl *rateLimiter) allowLocal(b *bucket) bool {
 	if b.tokens >= 1 {
 		b.tokens--
 		return true
@@ -58,6 +181,15 @@ func (rl *rateLimiter) Allow(ip string) bool {
 	return false
 }
 
+func rateLimitDecision(allowed bool, backend string) bool {
+	decision := "allowed"
+	if !allowed {
+		decision = "limited"
+	}
+	metrics.RateLimitDecisions.WithLabelValues(decision, backend).Inc()
+	return allowed
+}
+
 // Cleanup removes old buckets to prevent memory leaks
 func (rl *rateLimiter) Cleanup() {
 	rl.mu.Lock()
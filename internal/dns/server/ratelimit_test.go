@@ -1,8 +1,15 @@
 package server
 
 import (
+	"context"
+	"errors"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
 )
 
 func TestRateLimiter(t *testing.T) {
@@ -45,6 +52,34 @@ func TestRateLimiter_Isolation(t *testing.T) {
 	}
 }
 
+func TestServeQueryRateLimit(t *testing.T) {
+	repo := &mockServerRepo{
+		zones:   []domain.Zone{{ID: "z1", Name: "limit.test."}},
+		records: []domain.Record{{ZoneID: "z1", Name: "www.limit.test.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 300}},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+	srv.SetRateLimit(0.001, 2)
+
+	req := packet.NewDNSPacket()
+	req.Header.ID = 7
+	req.Questions = append(req.Questions, packet.DNSQuestion{Name: "www.limit.test.", QType: packet.A})
+	buf := packet.NewBytePacketBuffer()
+	_ = req.Write(buf)
+	query := buf.Buf[:buf.Position()]
+
+	for i := 0; i < 2; i++ {
+		if resp, err := srv.ServeQuery(query, "198.51.100.1:5353"); err != nil || resp == nil {
+			t.Fatalf("Expected query %d within the burst answered, got %v", i, err)
+		}
+	}
+	if resp, _ := srv.ServeQuery(query, "198.51.100.1:5353"); resp != nil {
+		t.Error("Expected the query over the burst to be dropped")
+	}
+	if resp, _ := srv.ServeQuery(query, "198.51.100.2:5353"); resp == nil {
+		t.Error("Expected another client to have its own bucket")
+	}
+}
+
 func TestRateLimiter_Cleanup(t *testing.T) {
 	rl := newRateLimiter(10, 5)
 	rl.Allow("old.ip")
@@ -64,3 +99,110 @@ func TestRateLimiter_Cleanup(t *testing.T) {
 		t.Errorf("Old bucket should have been cleaned up")
 	}
 }
+
+type failingTokenStore struct{}
+
+func (failingTokenStore) TakeTokens(_ context.Context, _ string, _ float64, _ int, _ int) (int, error) {
+	return 0, errors.New("redis down")
+}
+
+// waitForLease waits until no lease for ip is being fetched.
+func waitForLease(t *testing.T, rl *rateLimiter, ip string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		rl.mu.Lock()
+		leasing := rl.buckets[ip].leasing
+		rl.mu.Unlock()
+		if !leasing {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for a lease")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRateLimiter_Shared(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to run miniredis: %v", err)
+	}
+	defer mr.Close()
+	store := NewRedisCache(mr.Addr(), "", 0)
+	defer func() { _ = store.Close(context.Background()) }()
+
+	// Two nodes sharing a bucket of 10 tokens, refilled at 1 per second
+	nodeA, nodeB := newRateLimiter(1, 10), newRateLimiter(1, 10)
+	nodeA.setStore(store, 4)
+	nodeB.setStore(store, 4)
+
+	// Each node decides a client's first query locally while its lease is
+	// fetched, then spends leased tokens until the shared bucket is empty.
+	allowed := 0
+	for i := 0; i < 10; i++ {
+		for _, rl := range []*rateLimiter{nodeA, nodeB} {
+			if rl.Allow("192.0.2.1") {
+				allowed++
+			}
+			waitForLease(t, rl, "192.0.2.1")
+		}
+	}
+	if allowed != 12 {
+		t.Errorf("Expected the fleet to allow the burst of 10 once plus a local first query per node, got %d", allowed)
+	}
+	if !nodeA.Allow("192.0.2.2") {
+		t.Error("Expected other clients to keep their own bucket")
+	}
+	if mr.TTL(redisRateLimitPrefix+"192.0.2.1") <= 0 {
+		t.Error("Expected shared buckets to expire")
+	}
+
+	// Without Redis, the node enforces the limit on its own
+	nodeC := newRateLimiter(1, 2)
+	nodeC.setStore(failingTokenStore{}, 4)
+	if !nodeC.Allow("192.0.2.1") || !nodeC.Allow("192.0.2.1") || nodeC.Allow("192.0.2.1") {
+		t.Error("Expected the local bucket when the shared store fails")
+	}
+}
+
+// blockingTokenStore holds every lease until release is closed.
+type blockingTokenStore struct {
+	release chan struct{}
+	calls   atomic.Int32
+}
+
+func (s *blockingTokenStore) TakeTokens(_ context.Context, _ string, _ float64, _ int, lease int) (int, error) {
+	s.calls.Add(1)
+	<-s.release
+	return lease, nil
+}
+
+func TestRateLimiter_SharedDoesNotBlock(t *testing.T) {
+	store := &blockingTokenStore{release: make(chan struct{})}
+	rl := newRateLimiter(1, 2)
+	rl.setStore(store, 4)
+
+	done := make(chan []bool)
+	go func() {
+		done <- []bool{rl.Allow("192.0.2.1"), rl.Allow("192.0.2.1"), rl.Allow("192.0.2.1")}
+	}()
+	select {
+	case got := <-done:
+		if !got[0] || !got[1] || got[2] {
+			t.Errorf("Expected the local bucket while the lease is fetched, got %v", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Allow blocked on the shared store")
+	}
+	if n := store.calls.Load(); n > 1 {
+		t.Errorf("Expected a single lease in flight per client, got %d", n)
+	}
+
+	close(store.release)
+	waitForLease(t, rl, "192.0.2.1")
+	if !rl.Allow("192.0.2.1") {
+		t.Error("Expected the leased tokens once the lease arrives")
+	}
+}
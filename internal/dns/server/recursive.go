@@ -3,11 +3,14 @@ package server
 import (
 	"crypto/rand"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	mrand "math/rand"
 	"net"
+	"strings"
 	"time"
 
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
 	"github.com/poyrazK/cloudDNS/internal/dns/packet"
 )
 
@@ -45,53 +48,265 @@ func (r *recursiveResolver) getShuffledRoots() []string {
 	return shuffled
 }
 
-func (s *Server) resolveRecursive(name string) (*packet.DNSPacket, error) {
-	// Start with a random root server for load balancing and resilience.
-	resolver := newRecursiveResolver()
-	roots := resolver.getShuffledRoots()
+// Bounds on the work one recursive lookup may cause, so a hostile or broken
+// delegation cannot keep the resolver busy.
+const (
+	// maxReferrals is how many referrals a single lookup follows.
+	maxReferrals = 16
+	// maxCNAMEChain is how many CNAMEs in a row are chased.
+	maxCNAMEChain = 8
+	// maxGluelessDepth is how deeply name servers without glue are resolved.
+	maxGluelessDepth = 3
+	// maxGluelessServers is how many glueless name servers of a referral are
+	// resolved before giving up on it.
+	maxGluelessServers = 2
+	// maxRecursionCacheTTL caps how long resolved answers are cached.
+	maxRecursionCacheTTL = time.Hour
+)
 
-	var lastErr error
+var (
+	errTooManyReferrals = errors.New("too many referrals")
+	errCNAMELoop        = errors.New("CNAME chain loops or is too long")
+	errLameDelegation   = errors.New("no address for any delegated name server")
+)
 
-	// Failover logic: Iterate through all available root servers.
-	// If one fails (timeout, unreachable), proceed to the next.
-	for _, rootNS := range roots {
-		ns := rootNS
-		
-		for {
-			s.Logger.Info("recursive lookup", "name", name, "ns", ns)
-
-			// Query the current authoritative name server
-			serverAddr := net.JoinHostPort(ns, "53")
-			resp, err := s.queryFn(serverAddr, name, packet.A)
-			if err != nil {
-				// Record the error and break the inner loop to try the next root server
-				lastErr = err
-				s.Logger.Warn("recursive query failed", "ns", ns, "error", err)
-				break 
-			}
+// ParseRecursionClients parses a comma-separated list of the networks allowed
+// to recurse. An empty list allows every client.
+func ParseRecursionClients(list string) ([]*net.IPNet, error) {
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+	return parseCIDRs(strings.ReplaceAll(list, " ", ""))
+}
+
+// recursionAllowed reports whether clientIP may have queries for names outside
+// our zones resolved recursively.
+func (s *Server) recursionAllowed(clientIP string) bool {
+	return s.RecursionEnabled && clientMatches(s.RecursionClients, net.ParseIP(clientIP))
+}
+
+// setRecursionAvailable sets the RA bit of a cached response for the client it
+// is served to, since it was cached for whichever client asked first.
+func (s *Server) setRecursionAvailable(resp []byte, clientIP string) {
+	if !s.RecursionEnabled || len(resp) < 4 {
+		return
+	}
+	if s.recursionAllowed(clientIP) {
+		resp[3] |= 0x80
+	} else {
+		resp[3] &^= 0x80
+	}
+}
+
+// resolveRecursive resolves name and qType iteratively from the root hints,
+// following referrals and chasing CNAMEs into other zones. The answer section
+// of the result holds the whole CNAME chain.
+func (s *Server) resolveRecursive(name string, qType packet.QueryType) (*packet.DNSPacket, error) {
+	return s.resolveChain(name, qType, 0)
+}
+
+func (s *Server) resolveChain(name string, qType packet.QueryType, depth int) (*packet.DNSPacket, error) {
+	var chain []packet.DNSRecord
+	seen := map[string]bool{}
+	target := name
+	for {
+		seen[dnsname.Normalize(target)] = true
+		resp, err := s.lookupIterative(target, qType, depth)
+		if err != nil {
+			return nil, err
+		}
+		resp.Answers = append(chain, resp.Answers...)
+		next, chase := cnameTarget(resp.Answers, name, qType)
+		if !chase || resp.Header.ResCode != packet.RcodeNoError {
+			return resp, nil
+		}
+		if seen[dnsname.Normalize(next)] || len(seen) > maxCNAMEChain {
+			return nil, fmt.Errorf("resolving %s: %w", name, errCNAMELoop)
+		}
+		chain, target = resp.Answers, next
+	}
+}
 
-			// If we got a valid answer with NOERROR, we are done
-			if len(resp.Answers) > 0 && resp.Header.ResCode == 0 {
-				return resp, nil
+// cnameTarget follows the CNAME chain of answers from name. It returns the name
+// the chain ends at and true when that name still has to be resolved, that is
+// when no answer of qType was given for it.
+func cnameTarget(answers []packet.DNSRecord, name string, qType packet.QueryType) (string, bool) {
+	owner := name
+	for hops := 0; hops <= len(answers); hops++ {
+		var cname string
+		for _, rr := range answers {
+			if !dnsname.Equal(rr.Name, owner) {
+				continue
+			}
+			if rr.Type == qType || qType == packet.ANY {
+				return "", false
+			}
+			if rr.Type == packet.CNAME && qType != packet.CNAME {
+				cname = rr.Host
 			}
+		}
+		if cname == "" {
+			return owner, !dnsname.Equal(owner, name)
+		}
+		owner = cname
+	}
+	return owner, true
+}
 
-			// NXDOMAIN is a definitive answer, so we stop here
-			if resp.Header.ResCode == 3 {
-				return resp, nil
+// lookupIterative queries from the root down for name and qType, answering from
+// the resolver cache when it can.
+func (s *Server) lookupIterative(name string, qType packet.QueryType, depth int) (*packet.DNSPacket, error) {
+	cacheKey := fmt.Sprintf("%s:%d", dnsname.Normalize(name), qType)
+	if cached, ok := s.recursionCache.Get(cacheKey); ok {
+		buf := packet.NewBytePacketBuffer()
+		buf.Load(cached)
+		resp := packet.NewDNSPacket()
+		if resp.FromBuffer(buf) == nil {
+			return resp, nil
+		}
+	}
+
+	servers := newRecursiveResolver().getShuffledRoots()
+	// bailiwick is the zone the current servers were delegated; records they
+	// send for names outside that zone are not trusted.
+	bailiwick := "."
+	for referrals := 0; referrals <= maxReferrals; referrals++ {
+		resp, err := s.queryServers(servers, name, qType)
+		if err != nil {
+			if bailiwick == "." {
+				return nil, fmt.Errorf("recursion failed after trying all roots: %w", err)
 			}
+			return nil, fmt.Errorf("no server for %s answered: %w", bailiwick, err)
+		}
+		scrubResponse(resp, name, bailiwick)
 
-			// Follow the referral chain: check Authority section for the next NS
-			if nsIP, found := s.findNextNS(resp); found {
-				ns = nsIP
+		// Answers and NXDOMAIN are final, as is a response that delegates no
+		// deeper, which is how NODATA looks
+		zone := referralZone(resp, bailiwick)
+		if len(resp.Answers) > 0 || resp.Header.ResCode != packet.RcodeNoError || dnsname.Equal(zone, bailiwick) {
+			s.cacheRecursive(cacheKey, resp)
+			return resp, nil
+		}
+
+		next := s.referralServers(resp, zone, depth)
+		if len(next) == 0 {
+			return nil, fmt.Errorf("%s: %w", zone, errLameDelegation)
+		}
+		servers, bailiwick = next, zone
+	}
+	return nil, fmt.Errorf("resolving %s: %w", name, errTooManyReferrals)
+}
+
+// queryServers asks the servers in turn until one gives a usable response;
+// servers failing with SERVFAIL or REFUSED are skipped like unreachable ones.
+func (s *Server) queryServers(servers []string, name string, qType packet.QueryType) (*packet.DNSPacket, error) {
+	var lastErr error
+	for _, ns := range servers {
+		s.Logger.Info("recursive lookup", "name", name, "type", qType, "ns", ns)
+		resp, err := s.queryFn(net.JoinHostPort(ns, "53"), name, qType)
+		if err == nil && (resp.Header.ResCode == packet.RcodeServFail || resp.Header.ResCode == packet.RcodeRefused) {
+			err = fmt.Errorf("%s answered rcode %d", ns, resp.Header.ResCode)
+		}
+		if err != nil {
+			lastErr = err
+			s.Logger.Warn("recursive query failed", "ns", ns, "error", err)
+			continue
+		}
+		return resp, nil
+	}
+	if lastErr == nil {
+		lastErr = errLameDelegation
+	}
+	return nil, lastErr
+}
+
+// referralServers returns the addresses of the name servers a referral
+// delegates zone to: their A and AAAA glue, IPv4 first, or, for a few name
+// servers without glue, the addresses they resolve to.
+func (s *Server) referralServers(resp *packet.DNSPacket, zone string, depth int) []string {
+	var v4, v6, glueless []string
+	for _, auth := range resp.Authorities {
+		if auth.Type != packet.NS || !dnsname.Equal(auth.Name, zone) {
+			continue
+		}
+		found := false
+		for _, res := range resp.Resources {
+			if !dnsname.Equal(res.Name, auth.Host) {
 				continue
 			}
+			switch res.Type {
+			case packet.A:
+				v4 = append(v4, res.IP.String())
+				found = true
+			case packet.AAAA:
+				v6 = append(v6, res.IP.String())
+				found = true
+			}
+		}
+		if !found {
+			glueless = append(glueless, auth.Host)
+		}
+	}
+	addrs := append(v4, v6...)
+	if len(addrs) > 0 || depth >= maxGluelessDepth {
+		return addrs
+	}
+	for i, host := range glueless {
+		if i >= maxGluelessServers {
+			break
+		}
+		addrs = s.resolveServerAddrs(host, depth+1)
+		if len(addrs) > 0 {
+			break
+		}
+	}
+	return addrs
+}
 
-			// No more referrals or answers, return what we have
-			return resp, nil
+// resolveServerAddrs resolves the addresses of a name server without glue,
+// asking for AAAA only when it has no IPv4 address.
+func (s *Server) resolveServerAddrs(host string, depth int) []string {
+	var addrs []string
+	for _, qType := range []packet.QueryType{packet.A, packet.AAAA} {
+		r, err := s.resolveChain(host, qType, depth)
+		if err != nil {
+			s.Logger.Warn("failed to resolve name server", "ns", host, "type", qType, "error", err)
+			continue
+		}
+		for _, rr := range r.Answers {
+			if rr.Type == qType {
+				addrs = append(addrs, rr.IP.String())
+			}
+		}
+		if len(addrs) > 0 {
+			break
 		}
 	}
+	return addrs
+}
 
-	return nil, fmt.Errorf("recursion failed after trying all roots: %w", lastErr)
+// cacheRecursive keeps a final response for its shortest TTL, or that of the
+// SOA record of a negative answer.
+func (s *Server) cacheRecursive(key string, resp *packet.DNSPacket) {
+	var ttl uint32
+	switch {
+	case len(resp.Answers) > 0:
+		ttl = minRecordTTL(resp.Answers)
+	case len(resp.Authorities) > 0:
+		ttl = minRecordTTL(resp.Authorities)
+	default:
+		return
+	}
+	if ttl == 0 {
+		return
+	}
+	buf := packet.NewBytePacketBuffer()
+	if err := resp.Write(buf); err != nil {
+		return
+	}
+	data := make([]byte, buf.Position())
+	copy(data, buf.Buf[:buf.Position()])
+	s.recursionCache.Set(key, data, min(time.Duration(ttl)*time.Second, maxRecursionCacheTTL))
 }
 
 func generateTransactionID() uint16 {
@@ -100,7 +315,7 @@ func generateTransactionID() uint16 {
 	return id
 }
 
-func (s *Server) sendQuery(server string, name string, _ packet.QueryType) (*packet.DNSPacket, error) {
+func (s *Server) sendQuery(server string, name string, qType packet.QueryType) (*packet.DNSPacket, error) {
 	conn, err := net.DialTimeout("udp", server, 5*time.Second)
 	if err != nil {
 		return nil, err
@@ -111,7 +326,7 @@ func (s *Server) sendQuery(server string, name string, _ packet.QueryType) (*pac
 	req.Header.ID = generateTransactionID()
 	req.Header.Questions = 1
 	req.Header.RecursionDesired = false // Iterative
-	req.Questions = append(req.Questions, *packet.NewDNSQuestion(name, packet.A))
+	req.Questions = append(req.Questions, *packet.NewDNSQuestion(name, qType))
 
 	buffer := packet.NewBytePacketBuffer()
 	if errWrite := req.Write(buffer); errWrite != nil {
@@ -123,45 +338,19 @@ func (s *Server) sendQuery(server string, name string, _ packet.QueryType) (*pac
 		return nil, err
 	}
 
-	resBuffer := packet.NewBytePacketBuffer()
 	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
-	
-	// Read into a temporary buffer first
-	tmp := make([]byte, packet.MaxPacketSize)
-	n, errRead := conn.Read(tmp)
-	if errRead != nil {
-		return nil, errRead
-	}
-	
-	// Use Load() to correctly update resBuffer.Len and parsing flag
-	resBuffer.Load(tmp[:n])
-
-	resp := packet.NewDNSPacket()
-	if errFromBuf := resp.FromBuffer(resBuffer); errFromBuf != nil {
-		return nil, errFromBuf
-	}
-
-	if resp.Header.ID != req.Header.ID {
-		return nil, fmt.Errorf("transaction ID mismatch: expected %d, got %d", req.Header.ID, resp.Header.ID)
-	}
-
-	return resp, nil
+	return readUDPResponse(conn, req)
 }
 
-func (s *Server) findNextNS(resp *packet.DNSPacket) (string, bool) {
+// referralZone returns the zone a referral delegates to, the owner of its NS
+// records, falling back to the current bailiwick when there is none. Scrubbing
+// already limited those owners to ancestors of the query name inside the
+// current bailiwick.
+func referralZone(resp *packet.DNSPacket, bailiwick string) string {
 	for _, auth := range resp.Authorities {
 		if auth.Type == packet.NS {
-			for _, res := range resp.Resources {
-				if res.Name == auth.Host && res.Type == packet.A {
-					return res.IP.String(), true
-				}
-			}
-		}
-	}
-	for _, res := range resp.Resources {
-		if res.Type == packet.A {
-			return res.IP.String(), true
+			return auth.Name
 		}
 	}
-	return "", false
+	return bailiwick
 }
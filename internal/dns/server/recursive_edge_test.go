@@ -1,20 +1,32 @@
 package server
 
 import (
+	"net"
 	"testing"
 
 	"github.com/poyrazK/cloudDNS/internal/dns/packet"
 )
 
-// TestFindNextNS_Logic verifies the internal findNextNS helper logic
+// TestReferralServers_Logic verifies the internal referralServers helper logic
 // used by the recursive resolver to discover authoritative servers.
-func TestFindNextNS_Logic(t *testing.T) {
-	srv := &Server{}
+func TestReferralServers_Logic(t *testing.T) {
+	srv := NewServer(":0", nil, nil)
+	srv.queryFn = func(server string, name string, qtype packet.QueryType) (*packet.DNSPacket, error) {
+		resp := packet.NewDNSPacket()
+		resp.Header.Response = true
+		if name == "ns.elsewhere." && qtype == packet.A {
+			resp.Answers = append(resp.Answers, packet.DNSRecord{Name: name, Type: packet.A, TTL: 60, IP: net.ParseIP("5.6.7.8")})
+		}
+		if name == "ns.v6only." && qtype == packet.AAAA {
+			resp.Answers = append(resp.Answers, packet.DNSRecord{Name: name, Type: packet.AAAA, TTL: 60, IP: net.ParseIP("2001:db8::53")})
+		}
+		return resp, nil
+	}
 	resp := packet.NewDNSPacket()
-	
+
 	// 1. Case: No authorities
-	if _, found := srv.findNextNS(resp); found {
-		t.Errorf("Expected not found for empty packet")
+	if addrs := srv.referralServers(resp, "test.", 0); len(addrs) != 0 {
+		t.Errorf("Expected no servers for empty packet, got %v", addrs)
 	}
 
 	// 2. Case: Authority present with glue
@@ -24,8 +36,38 @@ func TestFindNextNS_Logic(t *testing.T) {
 	resp.Resources = append(resp.Resources, packet.DNSRecord{
 		Name: "ns1.test.", Type: packet.A, IP: []byte{1, 2, 3, 4},
 	})
-	
-	if ns, found := srv.findNextNS(resp); !found || ns != "1.2.3.4" {
-		t.Errorf("Expected 1.2.3.4, got %s (found=%v)", ns, found)
+	if addrs := srv.referralServers(resp, "test.", 0); len(addrs) != 1 || addrs[0] != "1.2.3.4" {
+		t.Errorf("Expected 1.2.3.4, got %v", addrs)
+	}
+
+	// 3. Case: Name server without glue is resolved, unless too deep
+	resp.Resources = nil
+	resp.Authorities[0].Host = "ns.elsewhere."
+	if addrs := srv.referralServers(resp, "test.", 0); len(addrs) != 1 || addrs[0] != "5.6.7.8" {
+		t.Errorf("Expected the glueless name server to be resolved, got %v", addrs)
+	}
+	if addrs := srv.referralServers(resp, "test.", maxGluelessDepth); len(addrs) != 0 {
+		t.Errorf("Expected no lookups past the depth limit, got %v", addrs)
+	}
+
+	// 4. Case: AAAA glue is used after the A glue
+	resp.Authorities[0].Host = "ns1.test."
+	resp.Resources = []packet.DNSRecord{
+		{Name: "ns1.test.", Type: packet.AAAA, IP: net.ParseIP("2001:db8::1")},
+		{Name: "ns1.test.", Type: packet.A, IP: net.ParseIP("1.2.3.4")},
+	}
+	if addrs := srv.referralServers(resp, "test.", 0); len(addrs) != 2 || addrs[0] != "1.2.3.4" || addrs[1] != "2001:db8::1" {
+		t.Errorf("Expected the A then the AAAA glue, got %v", addrs)
+	}
+
+	// 5. Case: IPv6-only name servers are reached through their AAAA glue or records
+	resp.Resources = resp.Resources[:1]
+	if addrs := srv.referralServers(resp, "test.", 0); len(addrs) != 1 || addrs[0] != "2001:db8::1" {
+		t.Errorf("Expected the AAAA glue, got %v", addrs)
+	}
+	resp.Resources = nil
+	resp.Authorities[0].Host = "ns.v6only."
+	if addrs := srv.referralServers(resp, "test.", 0); len(addrs) != 1 || addrs[0] != "2001:db8::53" {
+		t.Errorf("Expected the IPv6-only name server to be resolved, got %v", addrs)
 	}
 }
@@ -1,8 +1,8 @@
 package server
 
 import (
+	"errors"
 	"net"
-	"strings"
 	"testing"
 
 	"github.com/poyrazK/cloudDNS/internal/dns/packet"
@@ -51,7 +51,8 @@ func TestSendQuery_IDMismatch(t *testing.T) {
 		t.Fatalf("Expected error due to transaction ID mismatch, but got nil")
 	}
 	
-	if !strings.Contains(err.Error(), "transaction ID mismatch") {
-		t.Errorf("Expected 'transaction ID mismatch' error, got: %v", err)
+	// The forged reply is discarded and the wait times out
+	if !errors.Is(err, errMismatchedResponse) {
+		t.Errorf("Expected the mismatched reply to be discarded, got: %v", err)
 	}
 }
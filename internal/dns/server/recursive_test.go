@@ -1,6 +1,7 @@
 package server
 
 import (
+	"errors"
 	"net"
 	"strings"
 	"testing"
@@ -48,7 +49,7 @@ func TestResolveRecursive(t *testing.T) {
 		return resp, nil
 	}
 
-	resp, err := s.resolveRecursive("test.com.")
+	resp, err := s.resolveRecursive("test.com.", packet.A)
 	if err != nil {
 		t.Fatalf("Recursive resolve failed: %v", err)
 	}
@@ -70,7 +71,7 @@ func TestResolveRecursive_NXDOMAIN(t *testing.T) {
 		return resp, nil
 	}
 
-	resp, err := s.resolveRecursive("nonexistent.io.")
+	resp, err := s.resolveRecursive("nonexistent.io.", packet.A)
 	if err != nil {
 		t.Fatalf("Expected no error for NXDOMAIN, got %v", err)
 	}
@@ -90,7 +91,7 @@ func TestRecursive_NoNextNS(t *testing.T) {
 		return resp, nil
 	}
 
-	resp, err := s.resolveRecursive("deadend.test.")
+	resp, err := s.resolveRecursive("deadend.test.", packet.A)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -157,3 +158,179 @@ func TestNewRecursiveResolver(t *testing.T) {
 		t.Errorf("Expected root hints to be initialized")
 	}
 }
+
+// fakeHierarchy answers iterative queries like a small slice of the DNS:
+// the roots delegate com. and net., example.com. is served by a name server
+// without glue in example.net., and www.example.com. is a CNAME into it.
+func fakeHierarchy(queries *int) func(server string, name string, qtype packet.QueryType) (*packet.DNSPacket, error) {
+	return func(server string, name string, qtype packet.QueryType) (*packet.DNSPacket, error) {
+		*queries++
+		host, _, _ := net.SplitHostPort(server)
+		resp := packet.NewDNSPacket()
+		resp.Header.Response = true
+		refer := func(zone, ns, glue string) {
+			resp.Authorities = append(resp.Authorities, packet.DNSRecord{Name: zone, Type: packet.NS, TTL: 3600, Host: ns})
+			if glue != "" {
+				resp.Resources = append(resp.Resources, packet.DNSRecord{Name: ns, Type: packet.A, TTL: 3600, IP: net.ParseIP(glue)})
+			}
+		}
+		answer := func(rr packet.DNSRecord) {
+			rr.TTL = 300
+			resp.Header.AuthoritativeAnswer = true
+			resp.Answers = append(resp.Answers, rr)
+		}
+		switch host {
+		case "10.0.0.1": // com.
+			refer("example.com.", "ns1.example.net.", "")
+		case "10.0.0.2": // net.
+			refer("example.net.", "ns.example.net.", "10.0.0.3")
+		case "10.0.0.3": // example.net.
+			switch {
+			case name == "ns1.example.net." && qtype == packet.A:
+				answer(packet.DNSRecord{Name: name, Type: packet.A, IP: net.ParseIP("10.0.0.4")})
+			case name == "target.example.net." && qtype == packet.A:
+				answer(packet.DNSRecord{Name: name, Type: packet.A, IP: net.ParseIP("192.0.2.10")})
+			default:
+				resp.Header.AuthoritativeAnswer = true
+				resp.Header.ResCode = packet.RcodeNxDomain
+				resp.Authorities = append(resp.Authorities, packet.DNSRecord{Name: "example.net.", Type: packet.SOA, TTL: 60})
+			}
+		case "10.0.0.4": // example.com.
+			switch name {
+			case "www.example.com.":
+				answer(packet.DNSRecord{Name: name, Type: packet.CNAME, Host: "target.example.net."})
+			case "txt.example.com.":
+				if qtype == packet.TXT {
+					answer(packet.DNSRecord{Name: name, Type: packet.TXT, Txt: "hello"})
+				}
+			case "loop1.example.com.":
+				answer(packet.DNSRecord{Name: name, Type: packet.CNAME, Host: "loop2.example.com."})
+			case "loop2.example.com.":
+				answer(packet.DNSRecord{Name: name, Type: packet.CNAME, Host: "loop1.example.com."})
+			}
+		default: // a root server
+			if strings.HasSuffix(name, ".com.") {
+				refer("com.", "a.gtld.test.", "10.0.0.1")
+			} else {
+				refer("net.", "b.gtld.test.", "10.0.0.2")
+			}
+		}
+		return resp, nil
+	}
+}
+
+func TestResolveRecursive_CNAMEChase(t *testing.T) {
+	s := NewServer(":0", nil, nil)
+	queries := 0
+	s.queryFn = fakeHierarchy(&queries)
+
+	resp, err := s.resolveRecursive("www.example.com.", packet.A)
+	if err != nil {
+		t.Fatalf("Recursive resolve failed: %v", err)
+	}
+	if len(resp.Answers) != 2 || resp.Answers[0].Type != packet.CNAME || resp.Answers[1].IP.String() != "192.0.2.10" {
+		t.Fatalf("Expected the CNAME and its target's address, got %+v", resp.Answers)
+	}
+
+	// Everything on the way is cached
+	before := queries
+	if resp, err := s.resolveRecursive("www.example.com.", packet.A); err != nil || len(resp.Answers) != 2 || queries != before {
+		t.Errorf("Expected a cached answer without new queries, got %d queries: %v", queries-before, err)
+	}
+
+	resp, err = s.resolveRecursive("txt.example.com.", packet.TXT)
+	if err != nil || len(resp.Answers) != 1 || resp.Answers[0].Txt != "hello" {
+		t.Errorf("Expected the TXT record to be asked for, got %+v: %v", resp, err)
+	}
+	if resp, err := s.resolveRecursive("txt.example.com.", packet.A); err != nil || len(resp.Answers) != 0 || resp.Header.ResCode != 0 {
+		t.Errorf("Expected NODATA for a missing type, got %+v: %v", resp, err)
+	}
+
+	if _, err := s.resolveRecursive("loop1.example.com.", packet.A); !errors.Is(err, errCNAMELoop) {
+		t.Errorf("Expected a CNAME loop to fail, got %v", err)
+	}
+}
+
+func TestResolveRecursive_ReferralLoop(t *testing.T) {
+	s := NewServer(":0", nil, nil)
+	s.queryFn = func(server string, name string, qtype packet.QueryType) (*packet.DNSPacket, error) {
+		resp := packet.NewDNSPacket()
+		resp.Header.Response = true
+		// Each server refers one label deeper, forever
+		host, _, _ := net.SplitHostPort(server)
+		zone := "test."
+		if strings.HasPrefix(host, "10.") {
+			zone = strings.Repeat("a.", int(net.ParseIP(host).To4()[3])+1) + "test."
+		}
+		next := net.ParseIP(host).To4()
+		if !strings.HasPrefix(host, "10.") {
+			next = net.IPv4(10, 0, 0, 0).To4()
+		} else {
+			next[3]++
+		}
+		resp.Authorities = append(resp.Authorities, packet.DNSRecord{Name: zone, Type: packet.NS, Host: "ns." + zone})
+		resp.Resources = append(resp.Resources, packet.DNSRecord{Name: "ns." + zone, Type: packet.A, IP: next})
+		return resp, nil
+	}
+	if _, err := s.resolveRecursive(strings.Repeat("a.", 40)+"test.", packet.A); !errors.Is(err, errTooManyReferrals) {
+		t.Errorf("Expected the referral chain to be cut off, got %v", err)
+	}
+}
+
+func TestHandlePacket_RecursionClients(t *testing.T) {
+	srv := NewServer("127.0.0.1:0", &mockServerRepo{}, nil)
+	srv.RecursionEnabled = true
+	clients, err := ParseRecursionClients("10.0.0.0/8, 127.0.0.1/32")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.RecursionClients = clients
+	queries := 0
+	srv.queryFn = fakeHierarchy(&queries)
+
+	query := func(src string) *packet.DNSPacket {
+		t.Helper()
+		req := packet.NewDNSPacket()
+		req.Header.ID = 5
+		req.Header.RecursionDesired = true
+		req.Questions = append(req.Questions, packet.DNSQuestion{Name: "www.example.com.", QType: packet.A})
+		req.Resources = append(req.Resources, packet.DNSRecord{Name: ".", Type: packet.OPT, UDPPayloadSize: 4096})
+		reqBuf := packet.NewBytePacketBuffer()
+		_ = req.Write(reqBuf)
+		var captured []byte
+		_ = srv.handlePacket(reqBuf.Buf[:reqBuf.Position()], src+":5353", func(resp []byte) error {
+			captured = append([]byte(nil), resp...)
+			return nil
+		}, "udp")
+		res := packet.NewDNSPacket()
+		resBuf := packet.NewBytePacketBuffer()
+		resBuf.Load(captured)
+		if err := res.FromBuffer(resBuf); err != nil {
+			t.Fatalf("failed to parse response: %v", err)
+		}
+		return res
+	}
+
+	res := query("10.1.2.3")
+	if res.Header.ResCode != 0 || !res.Header.RecursionAvailable || res.Header.AuthoritativeAnswer || len(res.Answers) != 2 {
+		t.Fatalf("Expected a recursive answer for an allowed client, got %+v with %d answers", res.Header, len(res.Answers))
+	}
+
+	// The resolved answer is not served from the caches to other clients
+	res = query("192.0.2.1")
+	if res.Header.ResCode != packet.RcodeRefused || res.Header.RecursionAvailable || len(res.Answers) != 0 {
+		t.Errorf("Expected an outside client to be refused, got %+v", res.Header)
+	}
+	if codes := edeCodes(res); len(codes) != 1 || codes[0] != packet.EdeProhibited {
+		t.Errorf("Expected EDE prohibited, got %v", codes)
+	}
+}
+
+func TestParseRecursionClients(t *testing.T) {
+	if clients, err := ParseRecursionClients(""); err != nil || clients != nil {
+		t.Errorf("Expected no ACL for an empty list, got %v: %v", clients, err)
+	}
+	if _, err := ParseRecursionClients("10.0.0.0/8,bogus"); err == nil {
+		t.Error("Expected an invalid network to be rejected")
+	}
+}
@@ -2,51 +2,540 @@ package server
 
 import (
 	"context"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
 	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
 )
 
+// InvalidationChannel carries cache invalidation events between nodes. A payload
+// is "name:TYPE" for one name and type, "name:*" for every type of a name, or
+// "*" to flush every node's in-memory caches. TYPE is a mnemonic such as AAAA or
+// a type number.
 const InvalidationChannel = "dns:invalidation"
 
+// redisKeyPrefix namespaces cached responses. Values carry an 8-byte insertion
+// timestamp header, so the prefix is versioned to keep older nodes from reading them.
+const redisKeyPrefix = "dns:v2:"
+
+// redisIndexPrefix names the set of cached keys of each owner name, which lets
+// an invalidation find every variant of a key. A name's index lives on the
+// shard of its keys and expires after the last of them.
+const redisIndexPrefix = "dns:index:v2:"
+
+// redisIndexScript adds a key to its name's index and extends the index's
+// lifetime to cover it.
+var redisIndexScript = `
+redis.call('SADD', KEYS[1], ARGV[1])
+local ttl = tonumber(ARGV[2])
+if redis.call('PTTL', KEYS[1]) < ttl then
+	redis.call('PEXPIRE', KEYS[1], ttl)
+end
+return 1`
+
+// redisEvictScript deletes the indexed keys of a name matching a base key and
+// its variants, or every key of the name when the base is empty. NXDOMAIN
+// answers for the name's other types go too, as records of any type make the
+// name exist. Keys that already expired are dropped from the index.
+var redisEvictScript = redis.NewScript(`
+local prefix, base = ARGV[1], ARGV[2]
+local evicted = 0
+for _, member in ipairs(redis.call('SMEMBERS', KEYS[1])) do
+	local drop = base == '' or member == base or string.sub(member, 1, #base + 1) == base .. '|'
+	local val = redis.call('GET', prefix .. member)
+	if not drop and val and #val >= 12 then
+		drop = string.byte(val, 12) % 16 == 3
+	end
+	if drop or not val then
+		redis.call('DEL', prefix .. member)
+		redis.call('SREM', KEYS[1], member)
+		if drop and val then
+			evicted = evicted + 1
+		end
+	end
+end
+if base ~= '' and redis.call('DEL', prefix .. base) == 1 then
+	evicted = evicted + 1
+end
+return evicted`)
+
+const (
+	// DefaultRedisWriteQueue is the number of responses waiting to be written
+	// to Redis before further writes are dropped.
+	DefaultRedisWriteQueue = 4096
+	// DefaultRedisTimeout bounds how long a query waits on a Redis read.
+	DefaultRedisTimeout = 50 * time.Millisecond
+
+	// redisWriteBatch caps the number of SETs sent in one pipeline
+	redisWriteBatch = 256
+	// redisWriteTimeout bounds one pipelined batch; writes are off the query path
+	redisWriteTimeout = time.Second
+)
+
+// RedisOptions tunes how RedisCache talks to Redis.
+type RedisOptions struct {
+	// WriteQueue is the capacity of the asynchronous write queue.
+	WriteQueue int
+	// Timeout bounds each read made on the query path.
+	Timeout time.Duration
+	// HealthInterval is how often every shard is pinged.
+	HealthInterval time.Duration
+
+	// PoolSize is the maximum number of connections to each shard; zero keeps
+	// the client's default of 10 per CPU.
+	PoolSize int
+	// MinIdleConns is the number of idle connections kept open to each shard,
+	// so bursts do not wait on new connections.
+	MinIdleConns int
+	// MaxIdleConns caps the idle connections kept open to each shard.
+	MaxIdleConns int
+	// PoolTimeout bounds how long an operation waits for a free connection when
+	// the pool is exhausted; zero keeps the client's default.
+	PoolTimeout time.Duration
+}
+
+// redisWrite is a queued SET. A write with done set carries no data and marks
+// the point a Flush waits for.
+type redisWrite struct {
+	key  string
+	val  []byte
+	ttl  time.Duration
+	done chan struct{}
+}
+
+// RedisCache is the shared L2 response cache. Reads are bounded by a short
+// timeout and writes are queued and sent in pipelined batches by a background
+// writer, so a slow or unavailable Redis never adds to query latency.
+//
+// Keys may be spread over several independent Redis instances with consistent
+// hashing. Shards that keep failing are left out of the ring until a health
+// check succeeds again; their keys fall to the next shard meanwhile.
+// Invalidation events are published through the first healthy shard and
+// received from all of them. Concurrent reads of the same keys share one round
+// trip.
 type RedisCache struct {
-	client *redis.Client
+	shards  []*redisShard
+	ring    hashRing
+	timeout time.Duration
+	writes  chan redisWrite
+	reads   singleflight.Group
+
+	stop      context.CancelFunc
+	stopped   chan struct{}
+	closeOnce sync.Once
 }
 
 func NewRedisCache(addr string, password string, db int) *RedisCache {
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     addr,
-		Password: password,
-		DB:       db,
-	})
-	return &RedisCache{client: rdb}
+	return NewRedisCacheWithOptions(addr, password, db, RedisOptions{})
+}
+
+// NewRedisCacheWithOptions is NewRedisCache with custom queue, timeout and
+// health check settings. Zero values fall back to the defaults.
+func NewRedisCacheWithOptions(addr string, password string, db int, opts RedisOptions) *RedisCache {
+	return newShardedRedisCache([]string{addr}, password, db, opts)
 }
 
+// NewShardedRedisCache spreads the cache over the Redis instances at addrs,
+// which must not be empty.
+func NewShardedRedisCache(addrs []string, password string, db int, opts RedisOptions) (*RedisCache, error) {
+	if len(addrs) == 0 {
+		return nil, errors.New("no redis shard addresses")
+	}
+	return newShardedRedisCache(addrs, password, db, opts), nil
+}
+
+func newShardedRedisCache(addrs []string, password string, db int, opts RedisOptions) *RedisCache {
+	if opts.WriteQueue <= 0 {
+		opts.WriteQueue = DefaultRedisWriteQueue
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultRedisTimeout
+	}
+	if opts.HealthInterval <= 0 {
+		opts.HealthInterval = DefaultRedisHealthInterval
+	}
+	shards := make([]*redisShard, 0, len(addrs))
+	for _, addr := range addrs {
+		shards = append(shards, &redisShard{
+			addr: addr,
+			client: redis.NewClient(&redis.Options{
+				Addr:         addr,
+				Password:     password,
+				DB:           db,
+				PoolSize:     opts.PoolSize,
+				MinIdleConns: opts.MinIdleConns,
+				MaxIdleConns: opts.MaxIdleConns,
+				PoolTimeout:  opts.PoolTimeout,
+			}),
+		})
+		metrics.RedisShardHealthy.WithLabelValues(addr).Set(1)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &RedisCache{
+		shards:  shards,
+		ring:    newHashRing(shards),
+		timeout: opts.Timeout,
+		writes:  make(chan redisWrite, opts.WriteQueue),
+		stop:    cancel,
+		stopped: make(chan struct{}),
+	}
+	go r.writeLoop(ctx)
+	go r.healthLoop(ctx, opts.HealthInterval)
+	return r
+}
+
+// shardFor returns the healthy shard owning key, or nil if none is available.
+func (r *RedisCache) shardFor(key string) *redisShard {
+	if i := r.ring.lookup(key, r.shards); i >= 0 {
+		return r.shards[i]
+	}
+	return nil
+}
+
+// Get returns the cached response with its TTLs decremented by the time it has
+// spent in Redis.
 func (r *RedisCache) Get(ctx context.Context, key string) ([]byte, bool) {
-	val, err := r.client.Get(ctx, "dns:"+key).Bytes()
+	shard := r.shardFor(key)
+	if shard == nil {
+		return nil, false
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	start := time.Now()
+	val, err := shard.client.Get(ctx, redisKeyPrefix+key).Bytes()
+	metrics.RedisOperationDuration.WithLabelValues("get").Observe(time.Since(start).Seconds())
+	shard.observe(err)
 	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			metrics.RedisErrors.WithLabelValues("get").Inc()
+		}
 		return nil, false
 	}
-	return val, true
+	return decodeRedisValue(val)
 }
 
-func (r *RedisCache) Set(ctx context.Context, key string, data []byte, ttl time.Duration) {
-	r.client.Set(ctx, "dns:"+key, data, ttl)
+// GetMany fetches several keys with one MGET round trip per shard involved;
+// keys for the same name always share a shard. The result is aligned with keys
+// and holds nil for every key that was not found. Callers asking for the same
+// keys at the same time share one fetch and each get their own copy.
+func (r *RedisCache) GetMany(ctx context.Context, keys ...string) [][]byte {
+	if len(keys) == 0 {
+		return [][]byte{}
+	}
+	leader := false
+	v, _, _ := r.reads.Do(strings.Join(keys, "\x00"), func() (interface{}, error) {
+		leader = true
+		return r.getMany(ctx, keys), nil
+	})
+	if !leader {
+		metrics.CoalescedLookups.WithLabelValues("redis").Inc()
+	}
+	shared := v.([][]byte)
+	res := make([][]byte, len(shared))
+	for i, data := range shared {
+		if data != nil {
+			res[i] = append([]byte(nil), data...)
+		}
+	}
+	return res
+}
+
+func (r *RedisCache) getMany(ctx context.Context, keys []string) [][]byte {
+	res := make([][]byte, len(keys))
+	byShard := make(map[*redisShard][]int)
+	for i, k := range keys {
+		if shard := r.shardFor(k); shard != nil {
+			byShard[shard] = append(byShard[shard], i)
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	for shard, idx := range byShard {
+		prefixed := make([]string, len(idx))
+		for j, i := range idx {
+			prefixed[j] = redisKeyPrefix + keys[i]
+		}
+		start := time.Now()
+		vals, err := shard.client.MGet(ctx, prefixed...).Result()
+		metrics.RedisOperationDuration.WithLabelValues("mget").Observe(time.Since(start).Seconds())
+		shard.observe(err)
+		if err != nil {
+			metrics.RedisErrors.WithLabelValues("mget").Inc()
+			continue
+		}
+		for j, v := range vals {
+			if s, ok := v.(string); ok && j < len(idx) {
+				if data, found := decodeRedisValue([]byte(s)); found {
+					res[idx[j]] = data
+				}
+			}
+		}
+	}
+	return res
 }
 
+func decodeRedisValue(val []byte) ([]byte, bool) {
+	if len(val) < 8 {
+		return nil, false
+	}
+	storedAt := time.Unix(0, int64(binary.BigEndian.Uint64(val[:8]))) // #nosec G115
+	data := val[8:]
+	if elapsed := time.Since(storedAt); elapsed >= time.Second {
+		_, _ = packet.AgeTTLs(data, uint32(elapsed/time.Second)) // #nosec G115
+	}
+	return data, true
+}
+
+// Set queues a response to be stored together with its insertion time. It never
+// blocks: when the write queue is full the response is only kept in L1.
+func (r *RedisCache) Set(_ context.Context, key string, data []byte, ttl time.Duration) {
+	val := make([]byte, 8+len(data))
+	binary.BigEndian.PutUint64(val[:8], uint64(time.Now().UnixNano())) // #nosec G115
+	copy(val[8:], data)
+	select {
+	case r.writes <- redisWrite{key: key, val: val, ttl: ttl}:
+		metrics.RedisWriteQueueDepth.Set(float64(len(r.writes)))
+	default:
+		metrics.RedisWritesDropped.Inc()
+	}
+}
+
+// Flush waits until every write queued before the call has been sent to Redis.
+func (r *RedisCache) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	select {
+	case r.writes <- redisWrite{done: done}:
+	case <-r.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-done:
+		return nil
+	case <-r.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close flushes queued writes, stops the background writer and closes the clients.
+func (r *RedisCache) Close(ctx context.Context) error {
+	err := r.Flush(ctx)
+	r.closeOnce.Do(func() {
+		r.stop()
+		<-r.stopped
+		for _, s := range r.shards {
+			if errClose := s.client.Close(); errClose != nil && err == nil {
+				err = errClose
+			}
+		}
+	})
+	return err
+}
+
+func (r *RedisCache) writeLoop(ctx context.Context) {
+	defer close(r.stopped)
+	batch := make([]redisWrite, 0, redisWriteBatch)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case w := <-r.writes:
+			batch = append(batch[:0], w)
+		}
+		// Take whatever else is already queued, up to one batch
+	fill:
+		for len(batch) < redisWriteBatch {
+			select {
+			case w := <-r.writes:
+				batch = append(batch, w)
+			default:
+				break fill
+			}
+		}
+		metrics.RedisWriteQueueDepth.Set(float64(len(r.writes)))
+		r.writeBatch(batch)
+	}
+}
+
+// writeBatch sends the batch with one pipeline per shard. Writes for keys with
+// no healthy shard are dropped.
+func (r *RedisCache) writeBatch(batch []redisWrite) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisWriteTimeout)
+	defer cancel()
+	pipes := make(map[*redisShard]redis.Pipeliner)
+	for _, w := range batch {
+		if w.done != nil {
+			continue
+		}
+		shard := r.shardFor(w.key)
+		if shard == nil {
+			metrics.RedisWritesDropped.Inc()
+			continue
+		}
+		pipe, ok := pipes[shard]
+		if !ok {
+			pipe = shard.client.Pipeline()
+			pipes[shard] = pipe
+		}
+		pipe.Set(ctx, redisKeyPrefix+w.key, w.val, w.ttl)
+		pipe.Eval(ctx, redisIndexScript, []string{redisIndexPrefix + ringKey(w.key)}, w.key, w.ttl.Milliseconds())
+	}
+	for shard, pipe := range pipes {
+		start := time.Now()
+		_, err := pipe.Exec(ctx)
+		metrics.RedisOperationDuration.WithLabelValues("pipeline").Observe(time.Since(start).Seconds())
+		shard.observe(err)
+		if err != nil {
+			metrics.RedisErrors.WithLabelValues("pipeline").Inc()
+		}
+	}
+	for _, w := range batch {
+		if w.done != nil {
+			close(w.done)
+		}
+	}
+}
+
+// Ping reports an error only when no shard answers, since the cache keeps
+// working on the remaining shards.
 func (r *RedisCache) Ping(ctx context.Context) error {
-	return r.client.Ping(ctx).Err()
+	var errs []error
+	for _, s := range r.shards {
+		err := s.client.Ping(ctx).Err()
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", s.addr, err))
+	}
+	return errors.Join(errs...)
 }
 
-// Invalidate publishes an invalidation event to all nodes.
+// Invalidate deletes the responses cached in Redis for a name and type, with
+// all of their variants, and tells every node to drop them from memory. An
+// empty type, or one no cache key uses, invalidates every type of the name.
+// Writes queued before the call are sent first, so an answer cached before the
+// change cannot land after the eviction and outlive it.
 func (r *RedisCache) Invalidate(ctx context.Context, name string, qType domain.RecordType) error {
-	msg := fmt.Sprintf("%s:%s", name, string(qType))
-	return r.client.Publish(ctx, InvalidationChannel, msg).Err()
+	name = dnsname.Normalize(name)
+	base, payload := "", name+":*"
+	if qt, ok := parseQueryType(string(qType)); ok {
+		base = fmt.Sprintf("%s:%d", name, qt)
+		payload = fmt.Sprintf("%s:%s", name, qType)
+	}
+	errFlush := r.Flush(ctx)
+	errEvict := r.evict(ctx, name, base)
+	errPublish := r.publish(ctx, payload)
+	return errors.Join(errFlush, errEvict, errPublish)
+}
+
+// InvalidateAll tells every node to flush its in-memory caches, for changes
+// such as a new wildcard that affect answers for names nobody can list. Redis
+// entries are left to expire with their TTL.
+func (r *RedisCache) InvalidateAll(ctx context.Context) error {
+	return r.publish(ctx, "*")
 }
 
-// Subscribe returns a PubSub instance that receives invalidation keys.
-func (r *RedisCache) Subscribe(ctx context.Context) *redis.PubSub {
-	return r.client.Subscribe(ctx, InvalidationChannel)
+// publish sends an invalidation event through the first shard that takes it,
+// trying healthy shards before excluded ones. Every node listens on all
+// shards, so one copy reaches them all.
+func (r *RedisCache) publish(ctx context.Context, payload string) error {
+	shards := make([]*redisShard, 0, len(r.shards))
+	for _, s := range r.shards {
+		if s.healthy() {
+			shards = append(shards, s)
+		}
+	}
+	for _, s := range r.shards {
+		if !s.healthy() {
+			shards = append(shards, s)
+		}
+	}
+	var errs []error
+	for _, s := range shards {
+		err := s.client.Publish(ctx, InvalidationChannel, payload).Err()
+		s.observe(err)
+		if err == nil {
+			return nil
+		}
+		metrics.RedisErrors.WithLabelValues("publish").Inc()
+		errs = append(errs, fmt.Errorf("failed to publish invalidation on redis shard %s: %w", s.addr, err))
+	}
+	return errors.Join(errs...)
+}
+
+// evict deletes the keys of name matching base, or all of them, from the shard
+// holding the name.
+func (r *RedisCache) evict(ctx context.Context, name string, base string) error {
+	shard := r.shardFor(name)
+	if shard == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, redisWriteTimeout)
+	defer cancel()
+	start := time.Now()
+	n, err := redisEvictScript.Run(ctx, shard.client, []string{redisIndexPrefix + name}, redisKeyPrefix, base).Int()
+	metrics.RedisOperationDuration.WithLabelValues("evict").Observe(time.Since(start).Seconds())
+	shard.observe(err)
+	if err != nil {
+		metrics.RedisErrors.WithLabelValues("evict").Inc()
+		return fmt.Errorf("failed to evict %s from redis shard %s: %w", name, shard.addr, err)
+	}
+	metrics.RedisEvictions.Add(float64(n))
+	return nil
+}
+
+// Subscribe delivers the invalidation keys published through any shard until
+// ctx is done, then closes the channel. The error names the shards whose
+// subscription could not be confirmed; they keep reconnecting in the
+// background and deliver events once they are back.
+func (r *RedisCache) Subscribe(ctx context.Context) (<-chan string, error) {
+	out := make(chan string)
+	var errs []error
+	var wg sync.WaitGroup
+	for _, s := range r.shards {
+		pubsub := s.client.Subscribe(ctx, InvalidationChannel)
+		confirmCtx, cancel := context.WithTimeout(ctx, r.timeout*4)
+		if _, err := pubsub.Receive(confirmCtx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to subscribe on redis shard %s: %w", s.addr, err))
+		}
+		cancel()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { _ = pubsub.Close() }()
+			ch := pubsub.Channel()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case msg, ok := <-ch:
+					if !ok {
+						return
+					}
+					select {
+					case out <- msg.Payload:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out, errors.Join(errs...)
 }
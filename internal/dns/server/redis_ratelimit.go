@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+	"github.com/redis/go-redis/v9"
+)
+
+// redisRateLimitPrefix namespaces the shared rate limiter buckets.
+const redisRateLimitPrefix = "ratelimit:v1:"
+
+// takeTokensScript refills and drains a token bucket atomically, using the Redis
+// clock so skew between nodes cannot mint tokens. Buckets expire once they would
+// have refilled completely anyway.
+var takeTokensScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local want = tonumber(ARGV[3])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) + tonumber(t[2]) / 1000000
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+if now > ts then
+	tokens = math.min(burst, tokens + (now - ts) * rate)
+end
+local granted = math.min(want, math.floor(tokens))
+tokens = tokens - granted
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('EXPIRE', KEYS[1], math.ceil(burst / rate) + 1)
+return granted
+`)
+
+// errNoRedisShard is returned when every shard is excluded from the ring.
+var errNoRedisShard = errors.New("no healthy redis shard")
+
+// TakeTokens takes up to n tokens from the shared bucket for key, refilled at
+// rate tokens per second up to burst. Buckets are spread over the shards like
+// cached responses, and the call is bounded by the read timeout.
+func (r *RedisCache) TakeTokens(ctx context.Context, key string, rate float64, burst int, n int) (int, error) {
+	shard := r.shardFor(redisRateLimitPrefix + key)
+	if shard == nil {
+		return 0, errNoRedisShard
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	start := time.Now()
+	granted, err := takeTokensScript.Run(ctx, shard.client, []string{redisRateLimitPrefix + key},
+		strconv.FormatFloat(rate, 'f', -1, 64), burst, n).Int()
+	metrics.RedisOperationDuration.WithLabelValues("ratelimit").Observe(time.Since(start).Seconds())
+	shard.observe(err)
+	if err != nil {
+		return 0, err
+	}
+	return granted, nil
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// DefaultRedisHealthInterval is how often every Redis shard is pinged.
+	DefaultRedisHealthInterval = 5 * time.Second
+
+	// redisShardReplicas is the number of points each shard owns on the hash ring
+	redisShardReplicas = 128
+	// redisShardMaxFailures is the number of consecutive failures after which a
+	// shard is excluded until a health check succeeds again
+	redisShardMaxFailures = 3
+)
+
+// redisShard is one independent Redis instance holding part of the L2 cache.
+type redisShard struct {
+	addr     string
+	client   *redis.Client
+	failures atomic.Int32
+	excluded atomic.Bool
+	timeouts uint32 // pool timeouts already reported
+}
+
+func (s *redisShard) healthy() bool {
+	return !s.excluded.Load()
+}
+
+// observe records the outcome of an operation on the shard. Redis misses are
+// not failures.
+func (s *redisShard) observe(err error) {
+	if err == nil || errors.Is(err, redis.Nil) {
+		s.failures.Store(0)
+		if s.excluded.CompareAndSwap(true, false) {
+			metrics.RedisShardHealthy.WithLabelValues(s.addr).Set(1)
+		}
+		return
+	}
+	if s.failures.Add(1) >= redisShardMaxFailures && s.excluded.CompareAndSwap(false, true) {
+		metrics.RedisShardHealthy.WithLabelValues(s.addr).Set(0)
+	}
+}
+
+// observePool reports the shard's connection pool, so an exhausted pool shows
+// up before it slows down queries. It is only called from the health loop.
+func (s *redisShard) observePool() {
+	stats := s.client.PoolStats()
+	metrics.RedisPoolConnections.WithLabelValues(s.addr, "total").Set(float64(stats.TotalConns))
+	metrics.RedisPoolConnections.WithLabelValues(s.addr, "idle").Set(float64(stats.IdleConns))
+	if stats.Timeouts > s.timeouts {
+		metrics.RedisPoolTimeouts.WithLabelValues(s.addr).Add(float64(stats.Timeouts - s.timeouts))
+	}
+	s.timeouts = stats.Timeouts
+}
+
+type ringPoint struct {
+	hash  uint32
+	shard int
+}
+
+// hashRing maps cache keys to shards with consistent hashing, so adding,
+// removing or excluding a shard only moves the keys that shard owned.
+type hashRing struct {
+	points []ringPoint
+}
+
+func newHashRing(shards []*redisShard) hashRing {
+	points := make([]ringPoint, 0, len(shards)*redisShardReplicas)
+	for i, s := range shards {
+		for r := 0; r < redisShardReplicas; r++ {
+			points = append(points, ringPoint{hash: ringHash(s.addr + "#" + strconv.Itoa(r)), shard: i})
+		}
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].hash < points[j].hash })
+	return hashRing{points: points}
+}
+
+// lookup returns the first healthy shard at or after key's position on the
+// ring, or -1 if every shard is excluded.
+func (h hashRing) lookup(key string, shards []*redisShard) int {
+	if len(h.points) == 0 {
+		return -1
+	}
+	hash := ringHash(ringKey(key))
+	i := sort.Search(len(h.points), func(i int) bool { return h.points[i].hash >= hash })
+	for n := 0; n < len(h.points); n++ {
+		p := h.points[(i+n)%len(h.points)]
+		if shards[p.shard].healthy() {
+			return p.shard
+		}
+	}
+	return -1
+}
+
+// ringKey hashes only the owner name of a "name:type" cache key and its
+// variants, so every type cached for a name lives on the same shard and can be
+// read in one MGET.
+func ringKey(key string) string {
+	key = baseCacheKey(key)
+	if i := strings.LastIndexByte(key, ':'); i > 0 {
+		return key[:i]
+	}
+	return key
+}
+
+func ringHash(s string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(s)) // #nosec G104
+	return h.Sum32()
+}
+
+// healthLoop pings every shard each interval so excluded shards rejoin the ring
+// once they recover and idle failed shards are noticed before queries hit them.
+func (r *RedisCache) healthLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.checkShards(ctx)
+		}
+	}
+}
+
+func (r *RedisCache) checkShards(ctx context.Context) {
+	for _, s := range r.shards {
+		s.observePool()
+		pingCtx, cancel := context.WithTimeout(ctx, r.timeout*4)
+		err := s.client.Ping(pingCtx).Err()
+		cancel()
+		if err == nil {
+			s.observe(nil)
+			continue
+		}
+		// A failed health check excludes the shard at once
+		s.failures.Store(redisShardMaxFailures - 1)
+		s.observe(err)
+	}
+}
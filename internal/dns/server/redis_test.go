@@ -2,11 +2,20 @@ package server
 
 import (
 	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/alicebob/miniredis/v2"
 	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
 )
 
 func TestRedisCache(t *testing.T) {
@@ -27,6 +36,9 @@ func TestRedisCache(t *testing.T) {
 	ttl := 10 * time.Second
 
 	cache.Set(ctx, key, data, ttl)
+	if err := cache.Flush(ctx); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
 
 	val, found := cache.Get(ctx, key)
 	if !found {
@@ -47,9 +59,6 @@ func TestRedisCache(t *testing.T) {
 	if err != nil {
 		t.Errorf("Invalidate failed: %v", err)
 	}
-	// Note: Invalidate in RedisCache only publishes to Pub/Sub, 
-	// it doesn't delete the key from Redis itself (L3 is common).
-	// Actually, the implementation should probably delete it too.
 }
 
 func TestRedisCache_Ping(t *testing.T) {
@@ -65,8 +74,444 @@ func TestRedisCache_Subscribe(t *testing.T) {
 	mr, _ := miniredis.Run()
 	defer mr.Close()
 	cache := NewRedisCache(mr.Addr(), "", 0)
-	ch := cache.Subscribe(context.Background())
-	if ch == nil {
-		t.Error("Subscribe returned nil channel")
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := cache.Subscribe(ctx)
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	cancel()
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Error("Expected no events")
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected the channel closed once the context is done")
+	}
+}
+
+// receiveInvalidation waits for the next invalidation key on ch.
+func receiveInvalidation(t *testing.T, ch <-chan string) string {
+	t.Helper()
+	select {
+	case payload := <-ch:
+		return payload
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for an invalidation event")
+		return ""
+	}
+}
+
+func TestRedisCache_InvalidationSkipsFailedShard(t *testing.T) {
+	mr1, _ := miniredis.Run()
+	addr1 := mr1.Addr()
+	mr2, _ := miniredis.Run()
+	defer mr2.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cache, err := NewShardedRedisCache([]string{addr1, mr2.Addr()}, "", 0, RedisOptions{HealthInterval: time.Hour, Timeout: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewShardedRedisCache failed: %v", err)
+	}
+	defer func() { _ = cache.Close(context.Background()) }()
+
+	mr1.Close()
+	cache.checkShards(ctx)
+	ch, err := cache.Subscribe(ctx)
+	if err == nil || !strings.Contains(err.Error(), addr1) {
+		t.Errorf("Expected the failed shard reported, got %v", err)
+	}
+	if err := cache.InvalidateAll(ctx); err != nil {
+		t.Fatalf("Expected the event published through the healthy shard, got %v", err)
+	}
+	if got := receiveInvalidation(t, ch); got != "*" {
+		t.Errorf("Expected the * event, got %q", got)
+	}
+
+	if _, err := NewShardedRedisCache(nil, "", 0, RedisOptions{}); err == nil {
+		t.Error("Expected an empty shard list rejected")
+	}
+}
+
+func TestRedisCache_TTLDecrement(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to run miniredis: %v", err)
+	}
+	defer mr.Close()
+	cache := NewRedisCache(mr.Addr(), "", 0)
+
+	p := packet.NewDNSPacket()
+	p.Header.Response = true
+	p.Questions = append(p.Questions, packet.DNSQuestion{Name: "ttl.test.", QType: packet.A})
+	p.Answers = append(p.Answers, packet.DNSRecord{Name: "ttl.test.", Type: packet.A, Class: 1, TTL: 300, IP: net.ParseIP("192.0.2.1")})
+	buf := packet.NewBytePacketBuffer()
+	_ = p.Write(buf)
+
+	// Entry written by another node 100 seconds ago
+	val := make([]byte, 8+buf.Position())
+	binary.BigEndian.PutUint64(val[:8], uint64(time.Now().Add(-100*time.Second).UnixNano()))
+	copy(val[8:], buf.Buf[:buf.Position()])
+	if err := mr.Set(redisKeyPrefix+"ttl.test.:1", string(val)); err != nil {
+		t.Fatalf("Failed to seed miniredis: %v", err)
+	}
+
+	data, found := cache.Get(context.Background(), "ttl.test.:1")
+	if !found {
+		t.Fatal("Expected key to be found in Redis")
+	}
+	resBuf := packet.NewBytePacketBuffer()
+	resBuf.Load(data)
+	resp := packet.NewDNSPacket()
+	_ = resp.FromBuffer(resBuf)
+	if len(resp.Answers) != 1 || resp.Answers[0].TTL != 200 {
+		t.Errorf("Expected decremented TTL 200, got %+v", resp.Answers)
+	}
+}
+
+func TestRedisCache_PipelinedWritesAndMGet(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to run miniredis: %v", err)
+	}
+	defer mr.Close()
+	cache := NewRedisCache(mr.Addr(), "", 0)
+	ctx := context.Background()
+
+	cache.Set(ctx, "a.test.:1", []byte{1}, time.Minute)
+	cache.Set(ctx, "a.test.:28", []byte{2}, time.Minute)
+	if err := cache.Close(ctx); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if !mr.Exists(redisKeyPrefix+"a.test.:1") || !mr.Exists(redisKeyPrefix+"a.test.:28") {
+		t.Fatal("Expected queued writes to be flushed on Close")
+	}
+	if ttl := mr.TTL(redisKeyPrefix + "a.test.:1"); ttl != time.Minute {
+		t.Errorf("Expected TTL of 1m, got %v", ttl)
+	}
+
+	reader := NewRedisCache(mr.Addr(), "", 0)
+	defer func() { _ = reader.Close(ctx) }()
+	vals := reader.GetMany(ctx, "a.test.:1", "missing.test.:1", "a.test.:28")
+	if len(vals) != 3 || string(vals[0]) != "\x01" || vals[1] != nil || string(vals[2]) != "\x02" {
+		t.Errorf("Unexpected MGET result: %v", vals)
+	}
+}
+
+func TestRedisCache_InvalidateDropsQueuedWrites(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to run miniredis: %v", err)
+	}
+	defer mr.Close()
+	// The writer starts late, so the write is still queued when the name changes
+	shards := []*redisShard{{addr: mr.Addr(), client: redis.NewClient(&redis.Options{Addr: mr.Addr()})}}
+	writerCtx, stop := context.WithCancel(context.Background())
+	cache := &RedisCache{shards: shards, ring: newHashRing(shards), timeout: DefaultRedisTimeout, writes: make(chan redisWrite, 16), stop: stop, stopped: make(chan struct{})}
+	ctx := context.Background()
+	defer func() { _ = cache.Close(ctx) }()
+
+	cache.Set(ctx, "www.test.:1", []byte{1}, time.Minute)
+	invalidated := make(chan error, 1)
+	go func() { invalidated <- cache.Invalidate(ctx, "www.test.", domain.TypeA) }()
+	time.Sleep(50 * time.Millisecond)
+	go cache.writeLoop(writerCtx)
+	if err := <-invalidated; err != nil {
+		t.Fatalf("Invalidate failed: %v", err)
+	}
+	if err := cache.Flush(ctx); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if mr.Exists(redisKeyPrefix + "www.test.:1") {
+		t.Error("Expected the queued write to be evicted, not written after the invalidation")
+	}
+}
+
+func TestRedisCache_FullQueueDropsWrites(t *testing.T) {
+	// No writer goroutine, so the queue never drains
+	cache := &RedisCache{writes: make(chan redisWrite, 1)}
+	cache.Set(context.Background(), "one", []byte{1}, time.Minute)
+	cache.Set(context.Background(), "two", []byte{2}, time.Minute)
+	if len(cache.writes) != 1 {
+		t.Fatalf("Expected the second write to be dropped, queue holds %d", len(cache.writes))
+	}
+	if w := <-cache.writes; w.key != "one" {
+		t.Errorf("Expected the first write to be kept, got %q", w.key)
+	}
+}
+
+func TestRedisCache_UnavailableIsAMiss(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to run miniredis: %v", err)
+	}
+	addr := mr.Addr()
+	mr.Close()
+
+	cache := NewRedisCacheWithOptions(addr, "", 0, RedisOptions{Timeout: 20 * time.Millisecond})
+	defer func() { _ = cache.Close(context.Background()) }()
+	start := time.Now()
+	if _, found := cache.Get(context.Background(), "k"); found {
+		t.Error("Expected a miss while Redis is down")
+	}
+	if vals := cache.GetMany(context.Background(), "k", "l"); vals[0] != nil || vals[1] != nil {
+		t.Errorf("Expected misses while Redis is down, got %v", vals)
+	}
+	cache.Set(context.Background(), "k", []byte{1}, time.Minute)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected Redis failures not to stall queries, took %v", elapsed)
+	}
+}
+
+func TestSiblingCacheKey(t *testing.T) {
+	if k, ok := siblingCacheKey("WWW.Example.com.", packet.A); !ok || k != "www.example.com.:28" {
+		t.Errorf("Expected AAAA sibling, got %q %v", k, ok)
+	}
+	if k, ok := siblingCacheKey("www.example.com.", packet.AAAA); !ok || k != "www.example.com.:1" {
+		t.Errorf("Expected A sibling, got %q %v", k, ok)
+	}
+	if _, ok := siblingCacheKey("www.example.com.", packet.MX); ok {
+		t.Error("Expected no sibling for MX")
+	}
+}
+
+func TestRedisCache_Sharding(t *testing.T) {
+	mr1, _ := miniredis.Run()
+	defer mr1.Close()
+	mr2, _ := miniredis.Run()
+	defer mr2.Close()
+	ctx := context.Background()
+
+	cache, err := NewShardedRedisCache([]string{mr1.Addr(), mr2.Addr()}, "", 0, RedisOptions{HealthInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewShardedRedisCache failed: %v", err)
+	}
+	defer func() { _ = cache.Close(ctx) }()
+
+	for i := 0; i < 50; i++ {
+		name := fmt.Sprintf("host%d.example.com.", i)
+		cache.Set(ctx, name+":1", []byte{1}, time.Minute)
+		cache.Set(ctx, name+":28", []byte{28}, time.Minute)
+	}
+	if err := cache.Flush(ctx); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if len(mr1.Keys()) == 0 || len(mr2.Keys()) == 0 {
+		t.Fatalf("Expected keys on both shards, got %d and %d", len(mr1.Keys()), len(mr2.Keys()))
+	}
+	stored := 0
+	for _, key := range append(mr1.Keys(), mr2.Keys()...) {
+		if !strings.HasPrefix(key, redisIndexPrefix) {
+			stored++
+		}
+	}
+	if stored != 100 {
+		t.Errorf("Expected every key stored exactly once, got %d", stored)
+	}
+	// Every type cached for a name shares its shard
+	for i := 0; i < 50; i++ {
+		name := fmt.Sprintf("host%d.example.com.", i)
+		if mr1.Exists(redisKeyPrefix+name+":1") != mr1.Exists(redisKeyPrefix+name+":28") {
+			t.Errorf("Expected A and AAAA for %s on the same shard", name)
+		}
+		vals := cache.GetMany(ctx, name+":1", name+":28")
+		if vals[0] == nil || vals[1] == nil {
+			t.Errorf("Expected both types for %s, got %v", name, vals)
+		}
+	}
+}
+
+func TestRedisCache_ShardExclusion(t *testing.T) {
+	mr1, _ := miniredis.Run()
+	defer mr1.Close()
+	mr2, _ := miniredis.Run()
+	addr2 := mr2.Addr()
+	ctx := context.Background()
+
+	cache, err := NewShardedRedisCache([]string{mr1.Addr(), addr2}, "", 0, RedisOptions{HealthInterval: time.Hour, Timeout: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewShardedRedisCache failed: %v", err)
+	}
+	defer func() { _ = cache.Close(ctx) }()
+
+	// Find a key owned by the second shard
+	var key string
+	for i := 0; key == ""; i++ {
+		k := fmt.Sprintf("host%d.example.com.:1", i)
+		if cache.shardFor(k) == cache.shards[1] {
+			key = k
+		}
+	}
+
+	mr2.Close()
+	cache.checkShards(ctx)
+	if cache.shards[1].healthy() {
+		t.Fatal("Expected the failed shard to be excluded after a health check")
+	}
+	if cache.shardFor(key) != cache.shards[0] {
+		t.Fatal("Expected keys of the excluded shard to move to the remaining one")
+	}
+	if err := cache.Ping(ctx); err != nil {
+		t.Errorf("Expected Ping to succeed while one shard is up, got %v", err)
+	}
+
+	cache.Set(ctx, key, []byte{1}, time.Minute)
+	if err := cache.Flush(ctx); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if _, found := cache.Get(ctx, key); !found {
+		t.Error("Expected the key to be served by the remaining shard")
+	}
+
+	// The shard rejoins once it answers health checks again
+	mr2b := miniredis.NewMiniRedis()
+	if err := mr2b.StartAddr(addr2); err != nil {
+		t.Skipf("Cannot restart shard on %s: %v", addr2, err)
+	}
+	defer mr2b.Close()
+	cache.checkShards(ctx)
+	if !cache.shards[1].healthy() || cache.shardFor(key) != cache.shards[1] {
+		t.Error("Expected the recovered shard to rejoin the ring")
+	}
+}
+
+func TestRedisShard_ExcludedAfterRepeatedFailures(t *testing.T) {
+	s := &redisShard{addr: "test"}
+	for i := 0; i < redisShardMaxFailures-1; i++ {
+		s.observe(context.DeadlineExceeded)
+	}
+	if !s.healthy() {
+		t.Fatal("Expected the shard to tolerate isolated failures")
+	}
+	s.observe(redis.Nil)
+	s.observe(context.DeadlineExceeded)
+	if !s.healthy() {
+		t.Fatal("Expected a miss to reset the failure count")
+	}
+	for i := 0; i < redisShardMaxFailures; i++ {
+		s.observe(context.DeadlineExceeded)
+	}
+	if s.healthy() {
+		t.Error("Expected the shard to be excluded after consecutive failures")
+	}
+}
+
+func TestRedisCache_PoolOptionsAndCoalescedReads(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to run miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	cache, err := NewShardedRedisCache([]string{mr.Addr()}, "", 0, RedisOptions{PoolSize: 3, MinIdleConns: 1, PoolTimeout: time.Second})
+	if err != nil {
+		t.Fatalf("NewShardedRedisCache failed: %v", err)
+	}
+	defer func() { _ = cache.Close(context.Background()) }()
+	opts := cache.shards[0].client.Options()
+	if opts.PoolSize != 3 || opts.MinIdleConns != 1 || opts.PoolTimeout != time.Second {
+		t.Errorf("Expected the pool options applied, got size %d, idle %d, timeout %v", opts.PoolSize, opts.MinIdleConns, opts.PoolTimeout)
+	}
+
+	ctx := context.Background()
+	cache.Set(ctx, "www.test.:1", []byte{0, 1, 2, 3}, time.Minute)
+	if err := cache.Flush(ctx); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	const readers = 16
+	results := make([][][]byte, readers)
+	var wg sync.WaitGroup
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = cache.GetMany(ctx, "www.test.:1", "www.test.:28")
+		}(i)
+	}
+	wg.Wait()
+	for i, res := range results {
+		if len(res) != 2 || string(res[0]) != string([]byte{0, 1, 2, 3}) || res[1] != nil {
+			t.Fatalf("Reader %d got %v", i, res)
+		}
+	}
+	// Callers rewrite the transaction ID in place, so they must not share buffers
+	results[0][0][0] = 0xFF
+	if results[1][0][0] != 0 {
+		t.Error("Expected every reader to get its own copy")
+	}
+
+	cache.shards[0].observePool()
+	if got := testutil.ToFloat64(metrics.RedisPoolConnections.WithLabelValues(mr.Addr(), "total")); got < 1 {
+		t.Errorf("Expected the pool connections reported, got %v", got)
+	}
+}
+
+func TestRedisCache_InvalidateEvictsIndexedKeys(t *testing.T) {
+	mr, _ := miniredis.Run()
+	defer mr.Close()
+	cache := NewRedisCache(mr.Addr(), "", 0)
+	ctx := context.Background()
+	defer func() { _ = cache.Close(ctx) }()
+
+	ch, err := cache.Subscribe(ctx)
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	answer := []byte{0, 1, 0x81, 0x80}
+	nxdomain := []byte{0, 1, 0x81, 0x83}
+	for key, data := range map[string][]byte{
+		"www.test.:1":        answer,
+		"www.test.:1|do":     answer,
+		"www.test.:28":       answer,
+		"www.test.:16":       nxdomain,
+		"other.test.:1":      answer,
+		"www.test.:1|view=a": answer,
+	} {
+		cache.Set(ctx, key, data, time.Minute)
+	}
+	if err := cache.Flush(ctx); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	if err := cache.Invalidate(ctx, "WWW.test.", domain.TypeA); err != nil {
+		t.Fatalf("Invalidate failed: %v", err)
+	}
+	for key, want := range map[string]bool{
+		"www.test.:1":        false,
+		"www.test.:1|do":     false,
+		"www.test.:1|view=a": false,
+		"www.test.:16":       false, // the name exists now
+		"www.test.:28":       true,
+		"other.test.:1":      true,
+	} {
+		if _, found := cache.Get(ctx, key); found != want {
+			t.Errorf("%s: expected found=%v after invalidating www.test. A", key, want)
+		}
+	}
+	if got := receiveInvalidation(t, ch); got != "www.test.:A" {
+		t.Fatalf("Expected the www.test.:A event, got %q", got)
+	}
+
+	if err := cache.Invalidate(ctx, "www.test.", ""); err != nil {
+		t.Fatalf("Invalidate failed: %v", err)
+	}
+	if _, found := cache.Get(ctx, "www.test.:28"); found {
+		t.Error("Expected every type of the name evicted")
+	}
+	if _, found := cache.Get(ctx, "other.test.:1"); !found {
+		t.Error("Expected other names kept")
+	}
+	if got := receiveInvalidation(t, ch); got != "www.test.:*" {
+		t.Fatalf("Expected the www.test.:* event, got %q", got)
+	}
+
+	if err := cache.InvalidateAll(ctx); err != nil {
+		t.Fatalf("InvalidateAll failed: %v", err)
+	}
+	if got := receiveInvalidation(t, ch); got != "*" {
+		t.Fatalf("Expected the * event, got %q", got)
 	}
 }
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+)
+
+// errMismatchedResponse is returned when a reply does not answer the query that
+// was sent, which is how off-path spoofing attempts show up.
+var errMismatchedResponse = errors.New("response does not match query")
+
+// matchResponse checks that resp answers req: it must be a response with the same
+// ID and opcode that echoes the question (RFC 5452, Section 4.2).
+func matchResponse(req, resp *packet.DNSPacket) error {
+	reason := ""
+	switch {
+	case !resp.Header.Response:
+		reason = "not_response"
+	case resp.Header.ID != req.Header.ID:
+		reason = "id"
+	case resp.Header.Opcode != req.Header.Opcode:
+		reason = "opcode"
+	case len(req.Questions) > 0 && (len(resp.Questions) != 1 || !sameQuestion(req.Questions[0], resp.Questions[0])):
+		reason = "question"
+	default:
+		return nil
+	}
+	metrics.UpstreamResponsesRejected.WithLabelValues(reason).Inc()
+	return fmt.Errorf("%w: %s (id %d)", errMismatchedResponse, strings.ReplaceAll(reason, "_", " "), resp.Header.ID)
+}
+
+func sameQuestion(a, b packet.DNSQuestion) bool {
+	return a.QType == b.QType && a.QClass == b.QClass && dnsname.Equal(a.Name, b.Name)
+}
+
+// readUDPResponse reads from a UDP socket connected to the server until a reply
+// to req arrives or the read deadline passes. Since the socket is connected, the
+// kernel already drops datagrams from any other address or port; replies that
+// fail to parse or do not match req are discarded rather than ending the wait, so
+// a spoofed packet cannot cut a legitimate exchange short.
+func readUDPResponse(conn net.Conn, req *packet.DNSPacket) (*packet.DNSPacket, error) {
+	tmp := make([]byte, packet.MaxPacketSize)
+	var lastErr error
+	for {
+		n, err := conn.Read(tmp)
+		if err != nil {
+			if lastErr != nil {
+				return nil, fmt.Errorf("%w; last discarded reply: %w", err, lastErr)
+			}
+			return nil, err
+		}
+		buf := packet.NewBytePacketBuffer()
+		buf.Load(tmp[:n])
+		resp := packet.NewDNSPacket()
+		if errParse := resp.FromBuffer(buf); errParse != nil {
+			metrics.UpstreamResponsesRejected.WithLabelValues("malformed").Inc()
+			lastErr = errParse
+			continue
+		}
+		if errMatch := matchResponse(req, resp); errMatch != nil {
+			lastErr = errMatch
+			continue
+		}
+		return resp, nil
+	}
+}
+
+// scrubResponse removes records a server had no business sending for qname when
+// it is authoritative for bailiwick: answers for other names (except along a
+// CNAME chain), authority records for zones that do not contain qname and any
+// record outside the bailiwick, such as out-of-zone glue. It returns the number
+// of records removed.
+func scrubResponse(resp *packet.DNSPacket, qname, bailiwick string) int {
+	dropped := 0
+	inBailiwick := func(name string) bool { return dnsname.IsSubdomain(name, bailiwick) }
+
+	owners := map[string]bool{dnsname.Normalize(qname): true}
+	answers := resp.Answers[:0]
+	for _, rr := range resp.Answers {
+		if !owners[dnsname.Normalize(rr.Name)] || !inBailiwick(rr.Name) {
+			dropped++
+			metrics.UpstreamRecordsDropped.WithLabelValues("answer").Inc()
+			continue
+		}
+		if rr.Type == packet.CNAME {
+			owners[dnsname.Normalize(rr.Host)] = true
+		}
+		answers = append(answers, rr)
+	}
+	resp.Answers = answers
+
+	authorities := resp.Authorities[:0]
+	for _, rr := range resp.Authorities {
+		zoneRecord := rr.Type == packet.NS || rr.Type == packet.SOA
+		if !inBailiwick(rr.Name) || (zoneRecord && !dnsname.IsSubdomain(qname, rr.Name)) {
+			dropped++
+			metrics.UpstreamRecordsDropped.WithLabelValues("authority").Inc()
+			continue
+		}
+		authorities = append(authorities, rr)
+	}
+	resp.Authorities = authorities
+
+	resources := resp.Resources[:0]
+	for _, rr := range resp.Resources {
+		if rr.Type != packet.OPT && !inBailiwick(rr.Name) {
+			dropped++
+			metrics.UpstreamRecordsDropped.WithLabelValues("additional").Inc()
+			continue
+		}
+		resources = append(resources, rr)
+	}
+	resp.Resources = resources
+	return dropped
+}
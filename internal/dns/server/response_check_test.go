@@ -0,0 +1,121 @@
+package server
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+func TestMatchResponse(t *testing.T) {
+	req := packet.NewDNSPacket()
+	req.Header.ID = 42
+	req.Questions = append(req.Questions, *packet.NewDNSQuestion("example.com.", packet.SOA))
+
+	reply := func(mutate func(p *packet.DNSPacket)) *packet.DNSPacket {
+		p := packet.NewDNSPacket()
+		p.Header.ID = 42
+		p.Header.Response = true
+		p.Questions = append(p.Questions, *packet.NewDNSQuestion("EXAMPLE.com.", packet.SOA))
+		if mutate != nil {
+			mutate(p)
+		}
+		return p
+	}
+
+	if err := matchResponse(req, reply(nil)); err != nil {
+		t.Errorf("Expected a matching reply (case-insensitive name) to pass, got %v", err)
+	}
+	cases := map[string]func(p *packet.DNSPacket){
+		"query":    func(p *packet.DNSPacket) { p.Header.Response = false },
+		"id":       func(p *packet.DNSPacket) { p.Header.ID = 43 },
+		"opcode":   func(p *packet.DNSPacket) { p.Header.Opcode = packet.OpcodeNotify },
+		"name":     func(p *packet.DNSPacket) { p.Questions[0].Name = "evil.com." },
+		"type":     func(p *packet.DNSPacket) { p.Questions[0].QType = packet.A },
+		"none":     func(p *packet.DNSPacket) { p.Questions = nil },
+		"multiple": func(p *packet.DNSPacket) { p.Questions = append(p.Questions, p.Questions[0]) },
+	}
+	for name, mutate := range cases {
+		if err := matchResponse(req, reply(mutate)); !errors.Is(err, errMismatchedResponse) {
+			t.Errorf("%s: expected errMismatchedResponse, got %v", name, err)
+		}
+	}
+}
+
+func TestScrubResponse(t *testing.T) {
+	resp := packet.NewDNSPacket()
+	resp.Answers = []packet.DNSRecord{
+		{Name: "www.example.com.", Type: packet.CNAME, Host: "web.example.com."},
+		{Name: "web.example.com.", Type: packet.A, IP: net.ParseIP("192.0.2.1")},
+		{Name: "other.example.com.", Type: packet.A, IP: net.ParseIP("192.0.2.2")}, // Not asked for
+		{Name: "www.bank.test.", Type: packet.A, IP: net.ParseIP("192.0.2.3")},     // Out of bailiwick
+	}
+	resp.Authorities = []packet.DNSRecord{
+		{Name: "example.com.", Type: packet.NS, Host: "ns1.example.com."},
+		{Name: "bank.test.", Type: packet.NS, Host: "ns1.example.com."},       // Hijacks another zone
+		{Name: "sub.example.com.", Type: packet.NS, Host: "ns1.example.com."}, // Does not contain www
+	}
+	resp.Resources = []packet.DNSRecord{
+		{Name: "ns1.example.com.", Type: packet.A, IP: net.ParseIP("192.0.2.53")},
+		{Name: "ns1.bank.test.", Type: packet.A, IP: net.ParseIP("192.0.2.66")}, // Poisoned glue
+		{Name: ".", Type: packet.OPT},
+	}
+
+	if dropped := scrubResponse(resp, "www.example.com.", "example.com."); dropped != 5 {
+		t.Errorf("Expected 5 records dropped, got %d", dropped)
+	}
+	if len(resp.Answers) != 2 || resp.Answers[1].Name != "web.example.com." {
+		t.Errorf("Expected the CNAME chain to be kept, got %+v", resp.Answers)
+	}
+	if len(resp.Authorities) != 1 || resp.Authorities[0].Name != "example.com." {
+		t.Errorf("Expected only the zone's own NS kept, got %+v", resp.Authorities)
+	}
+	if len(resp.Resources) != 2 || resp.Resources[0].Name != "ns1.example.com." || resp.Resources[1].Type != packet.OPT {
+		t.Errorf("Expected in-bailiwick glue and OPT kept, got %+v", resp.Resources)
+	}
+}
+
+func TestSendQuery_IgnoresSpoofedReplies(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer func() { _ = pc.Close() }()
+
+	go func() {
+		tmp := make([]byte, 512)
+		n, addr, errRead := pc.ReadFrom(tmp)
+		if errRead != nil {
+			return
+		}
+		buf := packet.NewBytePacketBuffer()
+		buf.Load(tmp[:n])
+		req := packet.NewDNSPacket()
+		if errParse := req.FromBuffer(buf); errParse != nil {
+			return
+		}
+		send := func(id uint16, name string) {
+			resp := packet.NewDNSPacket()
+			resp.Header.ID = id
+			resp.Header.Response = true
+			resp.Questions = append(resp.Questions, packet.DNSQuestion{Name: name, QType: req.Questions[0].QType, QClass: 1})
+			resp.Answers = append(resp.Answers, packet.DNSRecord{Name: name, Type: packet.SOA, Class: 1, TTL: 60, MName: "ns1." + name, RName: "admin." + name, Serial: uint32(id)})
+			out := packet.NewBytePacketBuffer()
+			_ = resp.Write(out)
+			_, _ = pc.WriteTo(out.Buf[:out.Position()], addr)
+		}
+		send(req.Header.ID+1, req.Questions[0].Name) // Guessed the wrong ID
+		send(req.Header.ID, "attacker.test.")        // Right ID, wrong question
+		send(req.Header.ID, req.Questions[0].Name)   // The real answer
+	}()
+
+	srv := NewServer("127.0.0.1:0", &mockServerRepo{}, nil)
+	resp, err := srv.sendQuery(pc.LocalAddr().String(), "example.test.", packet.SOA)
+	if err != nil {
+		t.Fatalf("sendQuery failed: %v", err)
+	}
+	if resp.Questions[0].QType != packet.SOA || len(resp.Answers) != 1 || resp.Answers[0].Name != "example.test." {
+		t.Errorf("Expected the genuine SOA answer, got %+v", resp)
+	}
+}
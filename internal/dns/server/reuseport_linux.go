@@ -0,0 +1,13 @@
+//go:build linux
+
+package server
+
+import "golang.org/x/sys/unix"
+
+// reusePortBalances reports whether the kernel spreads datagrams over sockets
+// sharing a port, so one UDP listener per CPU pays off.
+const reusePortBalances = true
+
+func setReusePort(fd uintptr) error {
+	return unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package server
+
+// Windows has no SO_REUSEPORT; SO_REUSEADDR there lets another process steal
+// the port, so the socket is left exclusive and a single listener is used.
+const reusePortBalances = false
+
+func setReusePort(fd uintptr) error {
+	return nil
+}
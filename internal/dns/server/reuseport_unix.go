@@ -1,9 +1,13 @@
-//go:build !windows
+//go:build unix && !linux
 
 package server
 
 import "golang.org/x/sys/unix"
 
+// The BSDs, macOS included, accept SO_REUSEPORT but deliver unicast datagrams
+// to a single socket, so a second listener would sit idle.
+const reusePortBalances = false
+
 func setReusePort(fd uintptr) error {
 	return unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
 }
@@ -1,7 +0,0 @@
-//go:build windows
-
-package server
-
-func setReusePort(fd uintptr) error {
-	return nil
-}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"io"
 	"net"
 	"testing"
 
@@ -120,30 +121,37 @@ func TestRFC1035_AXFR(t *testing.T) {
 	go srv.handleAXFR(serverConn, req)
 
 	// Read stream: RFC 1035 requires SOA first and SOA last
-	// We expect: SOA, NS, A, SOA (4 packets)
-	receivedCount := 0
-	var firstRecordType, lastRecordType packet.QueryType
-
-	for i := 0; i < 4; i++ {
+	// We expect: SOA, NS, A, SOA, packed into as few messages as fit (RFC 5936 2.2)
+	var received []packet.QueryType
+	messages := 0
+	for len(received) < 4 {
 		lenBuf := make([]byte, 2)
-		n, _ := clientConn.Read(lenBuf)
-		if n != 2 { break }
-		
+		if _, err := io.ReadFull(clientConn, lenBuf); err != nil {
+			break
+		}
 		respLen := uint16(lenBuf[0])<<8 | uint16(lenBuf[1]) // #nosec G602
 		respData := make([]byte, respLen)
-		_, _ = clientConn.Read(respData)
+		if _, err := io.ReadFull(clientConn, respData); err != nil {
+			break
+		}
+		messages++
 
 		respPacket := packet.NewDNSPacket()
 		pBuf := packet.NewBytePacketBuffer()
 		pBuf.Load(respData)
 		_ = respPacket.FromBuffer(pBuf)
-
-		if len(respPacket.Answers) > 0 {
-			if i == 0 { firstRecordType = respPacket.Answers[0].Type }
-			lastRecordType = respPacket.Answers[0].Type
-			receivedCount++
+		for _, ans := range respPacket.Answers {
+			received = append(received, ans.Type)
 		}
 	}
+	receivedCount := len(received)
+	var firstRecordType, lastRecordType packet.QueryType
+	if receivedCount > 0 {
+		firstRecordType, lastRecordType = received[0], received[receivedCount-1]
+	}
+	if messages != 1 {
+		t.Errorf("Expected a small zone to be transferred in one message, got %d", messages)
+	}
 
 	if receivedCount != 4 {
 		t.Errorf("RFC 1035 Violation: Expected 4 records in AXFR stream, got %d", receivedCount)
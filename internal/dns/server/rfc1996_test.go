@@ -1,6 +1,8 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"net"
 	"testing"
 	"time"
@@ -94,3 +96,103 @@ func TestNotifySlaves(t *testing.T) {
 		t.Errorf("Expected NOTIFY opcode, got %d", p.Header.Opcode)
 	}
 }
+
+// TestNotifyZoneReportsAcknowledgement verifies that an on-demand NOTIFY waits for
+// the secondary's answer and reports it per target.
+func TestNotifyZoneReportsAcknowledgement(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer func() { _ = pc.Close() }()
+	port := pc.LocalAddr().(*net.UDPAddr).Port
+
+	// Secondary that acknowledges every NOTIFY
+	go func() {
+		buf := make([]byte, 512)
+		n, addr, errRead := pc.ReadFrom(buf)
+		if errRead != nil {
+			return
+		}
+		req := packet.NewDNSPacket()
+		reqBuf := packet.NewBytePacketBuffer()
+		reqBuf.Load(buf[:n])
+		_ = req.FromBuffer(reqBuf)
+		resp := packet.NewDNSPacket()
+		resp.Header.ID = req.Header.ID
+		resp.Header.Response = true
+		resp.Header.Opcode = packet.OpcodeNotify
+		resp.Questions = req.Questions
+		out := packet.NewBytePacketBuffer()
+		_ = resp.Write(out)
+		_, _ = pc.WriteTo(out.Buf[:out.Position()], addr)
+	}()
+
+	repo := &mockServerRepo{
+		zones: []domain.Zone{
+			{ID: "z1", Name: "example.test.", Role: "master"},
+			{ID: "z2", Name: "secondary.test.", Role: "slave", MasterServer: "127.0.0.1"},
+		},
+		records: []domain.Record{
+			{ZoneID: "z1", Name: "example.test.", Type: domain.TypeNS, Content: "ns1.slave.test."},
+			{Name: "ns1.slave.test.", Type: domain.TypeA, Content: "127.0.0.1"},
+		},
+	}
+	srv := NewServer("127.0.0.1:5353", repo, nil)
+	srv.NotifyPortOverride = port
+
+	res, err := srv.NotifyZone(context.Background(), "z1", "t1")
+	if err != nil {
+		t.Fatalf("NotifyZone failed: %v", err)
+	}
+	if len(res.Targets) != 1 || res.Targets[0].Status != "acknowledged" || res.Targets[0].NameServer != "ns1.slave.test." {
+		t.Errorf("Expected one acknowledged target, got %+v", res.Targets)
+	}
+
+	if _, err := srv.NotifyZone(context.Background(), "z2", "t1"); !errors.Is(err, domain.ErrInvalidZoneRole) {
+		t.Errorf("Expected ErrInvalidZoneRole for a secondary, got %v", err)
+	}
+	if _, err := srv.NotifyZone(context.Background(), "missing", "t1"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+// TestTransferZone verifies on-demand refreshes of a secondary zone.
+func TestTransferZone(t *testing.T) {
+	repo := &mockServerRepo{
+		zones: []domain.Zone{
+			{ID: "z1", Name: "example.test.", Role: "master"},
+			{ID: "z2", Name: "secondary.test.", Role: "slave", MasterServer: "192.0.2.53"},
+		},
+		records: []domain.Record{
+			{ZoneID: "z2", Name: "secondary.test.", Type: domain.TypeSOA, Content: "ns1.secondary.test. admin.secondary.test. 5 3600 600 86400 300"},
+		},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+
+	if _, err := srv.TransferZone(context.Background(), "z1", "t1"); !errors.Is(err, domain.ErrInvalidZoneRole) {
+		t.Errorf("Expected ErrInvalidZoneRole for a primary, got %v", err)
+	}
+
+	var asked string
+	srv.queryFn = func(server string, name string, qtype packet.QueryType) (*packet.DNSPacket, error) {
+		asked = server
+		p := packet.NewDNSPacket()
+		p.Answers = append(p.Answers, packet.DNSRecord{Name: name, Type: packet.SOA, Serial: 5})
+		return p, nil
+	}
+	res, err := srv.TransferZone(context.Background(), "z2", "t1")
+	if err != nil {
+		t.Fatalf("TransferZone failed: %v", err)
+	}
+	if asked != "192.0.2.53:53" || res.Method != "none" || res.LocalSerial != 5 || res.MasterSerial != 5 {
+		t.Errorf("Expected an up-to-date zone, got %+v (asked %s)", res, asked)
+	}
+
+	srv.queryFn = func(string, string, packet.QueryType) (*packet.DNSPacket, error) {
+		return nil, errors.New("timeout")
+	}
+	if _, err := srv.TransferZone(context.Background(), "z2", "t1"); !errors.Is(err, domain.ErrTransferFailed) {
+		t.Errorf("Expected ErrTransferFailed when the master is down, got %v", err)
+	}
+}
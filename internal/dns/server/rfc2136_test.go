@@ -407,3 +407,42 @@ func TestCheckPrerequisite_RRset(t *testing.T) {
 	err = srv.checkPrerequisite(ctx, packet.DNSRecord{Name: "exists.test.", Type: packet.A, Class: 254})
 	if err == nil { t.Errorf("Expected error for existing RRset check") }
 }
+
+// TestHandleUpdateReviewedZone verifies that a zone under review refuses
+// dynamic updates, which cannot wait for an approver.
+func TestHandleUpdateReviewedZone(t *testing.T) {
+	repo := &mockServerRepo{
+		zones: []domain.Zone{{ID: "zone-1", Name: "example.test."}},
+		records: []domain.Record{
+			{ID: "soa1", ZoneID: "zone-1", Name: "example.test.", Type: domain.TypeSOA, Content: "ns1.example.test. host. 1 3600 600 604800 300"},
+		},
+		reviewed: map[string]bool{"zone-1": true},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+
+	req := packet.NewDNSPacket()
+	req.Header.ID = 101
+	req.Header.Opcode = packet.OpcodeUpdate
+	req.Questions = append(req.Questions, packet.DNSQuestion{Name: "example.test.", QType: packet.SOA})
+	req.Authorities = append(req.Authorities, packet.DNSRecord{Name: "new.example.test.", Type: packet.A, Class: 1, TTL: 3600, IP: net.ParseIP("192.168.1.10")})
+	buffer := packet.NewBytePacketBuffer()
+	_ = req.Write(buffer)
+
+	var capturedResp []byte
+	if err := srv.handlePacket(buffer.Buf[:buffer.Position()], "127.0.0.1:12345", func(resp []byte) error {
+		capturedResp = resp
+		return nil
+	}, "udp"); err != nil {
+		t.Fatalf("HandlePacket failed: %v", err)
+	}
+	resPacket := packet.NewDNSPacket()
+	pBuf := packet.NewBytePacketBuffer()
+	pBuf.Load(capturedResp)
+	_ = resPacket.FromBuffer(pBuf)
+	if resPacket.Header.ResCode != packet.RcodeRefused {
+		t.Errorf("Expected REFUSED, got %d", resPacket.Header.ResCode)
+	}
+	if len(repo.records) != 1 {
+		t.Errorf("Expected the zone to be unchanged, got %+v", repo.records)
+	}
+}
@@ -1,57 +1,260 @@
 package server
 
 import (
+	"errors"
 	"net"
 	"testing"
+	"time"
 
+	"github.com/poyrazK/cloudDNS/internal/adapters/repository"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/core/services"
 	"github.com/poyrazK/cloudDNS/internal/dns/packet"
 )
 
-func TestRFC8914_EDE(t *testing.T) {
-	repo := &mockServerRepo{} // Empty repo
-	srv := NewServer("127.0.0.1:0", repo, nil)
-
+// ednsQuery sends a query with an OPT record through handlePacket and returns the
+// parsed response.
+func ednsQuery(t *testing.T, srv *Server, name string, class uint16, rd, do bool) *packet.DNSPacket {
+	t.Helper()
 	req := packet.NewDNSPacket()
-	req.Questions = append(req.Questions, packet.DNSQuestion{Name: "not-here.test.", QType: packet.A})
-	// Include OPT to enable EDE
-	req.Resources = append(req.Resources, packet.DNSRecord{
-		Type: packet.OPT,
-		UDPPayloadSize: 4096,
-	})
+	req.Header.ID = 42
+	req.Header.RecursionDesired = rd
+	req.Questions = append(req.Questions, packet.DNSQuestion{Name: name, QType: packet.A, QClass: class})
+	opt := packet.DNSRecord{Name: ".", Type: packet.OPT, UDPPayloadSize: 4096}
+	if do {
+		opt.Z = 0x8000
+	}
+	req.Resources = append(req.Resources, opt)
 
 	reqBuf := packet.NewBytePacketBuffer()
 	_ = req.Write(reqBuf)
 
-	var capturedResp []byte
+	var captured []byte
 	_ = srv.handlePacket(reqBuf.Buf[:reqBuf.Position()], &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 53}, func(resp []byte) error {
-		capturedResp = resp
+		captured = append([]byte(nil), resp...)
 		return nil
 	}, "udp")
 
 	res := packet.NewDNSPacket()
 	resBuf := packet.NewBytePacketBuffer()
-	resBuf.Load(capturedResp)
-	_ = res.FromBuffer(resBuf)
+	resBuf.Load(captured)
+	if err := res.FromBuffer(resBuf); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	return res
+}
 
-	// Check for EDE in OPT
-	foundEDE := false
+// edeCodes returns the info codes of all EDE options in a response.
+func edeCodes(res *packet.DNSPacket) []uint16 {
+	var codes []uint16
 	for _, r := range res.Resources {
-		if r.Type == packet.OPT {
-			for _, opt := range r.Options {
-				if opt.Code == 15 {
-					foundEDE = true
-					if len(opt.Data) < 2 {
-						t.Errorf("EDE data too short")
-					}
-					infoCode := uint16(opt.Data[0])<<8 | uint16(opt.Data[1])
-					if infoCode != packet.EdeOther {
-						t.Errorf("Expected EdeOther (0), got %d", infoCode)
-					}
-				}
+		if r.Type != packet.OPT {
+			continue
+		}
+		for _, opt := range r.Options {
+			if opt.Code == 15 && len(opt.Data) >= 2 {
+				codes = append(codes, uint16(opt.Data[0])<<8|uint16(opt.Data[1]))
 			}
 		}
 	}
-	if !foundEDE {
-		t.Errorf("EDE option not found in response")
+	return codes
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestRFC8914_EDE(t *testing.T) {
+	zone := domain.Zone{ID: "z1", Name: "example.test."}
+	soa := domain.Record{ZoneID: "z1", Name: "example.test.", Type: domain.TypeSOA, Content: "ns1.example.test. admin.example.test. 1 3600 600 86400 300", TTL: 300}
+	www := domain.Record{ZoneID: "z1", Name: "www.example.test.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 300}
+
+	openBreaker := func() *repository.CircuitBreakerRepository {
+		repo := repository.NewCircuitBreakerRepository(&mockServerRepo{}, repository.CircuitBreakerConfig{FailureThreshold: 1, Cooldown: time.Hour}, nil)
+		repo.Breaker().Record(errors.New("connection refused"))
+		return repo
+	}
+
+	tests := []struct {
+		name      string
+		setup     func() *Server
+		query     string
+		class     uint16
+		rd, do    bool
+		wantRcode uint8
+		wantEDE   []uint16
+	}{
+		{
+			name: "authoritative NXDOMAIN carries no EDE",
+			setup: func() *Server {
+				return NewServer("127.0.0.1:0", &mockServerRepo{zones: []domain.Zone{zone}, records: []domain.Record{soa}}, nil)
+			},
+			query:     "missing.example.test.",
+			wantRcode: packet.RcodeNxDomain,
+		},
+		{
+			name:      "recursion disabled is prohibited",
+			setup:     func() *Server { return NewServer("127.0.0.1:0", &mockServerRepo{}, nil) },
+			query:     "elsewhere.test.",
+			rd:        true,
+			wantRcode: packet.RcodeRefused,
+			wantEDE:   []uint16{packet.EdeProhibited},
+		},
+		{
+			name: "legacy out-of-zone NXDOMAIN keeps its EDE",
+			setup: func() *Server {
+				srv := NewServer("127.0.0.1:0", &mockServerRepo{}, nil)
+				srv.OutOfZone = OutOfZoneNXDomain
+				return srv
+			},
+			query:     "elsewhere.test.",
+			rd:        true,
+			wantRcode: packet.RcodeNxDomain,
+			wantEDE:   []uint16{packet.EdeProhibited},
+		},
+		{
+			name: "recursion not desired is not authoritative",
+			setup: func() *Server {
+				srv := NewServer("127.0.0.1:0", &mockServerRepo{}, nil)
+				srv.RecursionEnabled = true
+				return srv
+			},
+			query:     "elsewhere.test.",
+			wantRcode: packet.RcodeRefused,
+			wantEDE:   []uint16{packet.EdeNotAuthoritative},
+		},
+		{
+			name: "upstream timeout is a network error",
+			setup: func() *Server {
+				srv := NewServer("127.0.0.1:0", &mockServerRepo{}, nil)
+				srv.RecursionEnabled = true
+				srv.queryFn = func(string, string, packet.QueryType) (*packet.DNSPacket, error) {
+					return nil, &net.OpError{Op: "read", Net: "udp", Err: timeoutError{}}
+				}
+				return srv
+			},
+			query:     "elsewhere.test.",
+			rd:        true,
+			wantRcode: packet.RcodeServFail,
+			wantEDE:   []uint16{packet.EdeNetworkError},
+		},
+		{
+			name: "unusable upstream answers mean no reachable authority",
+			setup: func() *Server {
+				srv := NewServer("127.0.0.1:0", &mockServerRepo{}, nil)
+				srv.RecursionEnabled = true
+				srv.queryFn = func(string, string, packet.QueryType) (*packet.DNSPacket, error) {
+					return nil, errors.New("malformed response")
+				}
+				return srv
+			},
+			query:     "elsewhere.test.",
+			rd:        true,
+			wantRcode: packet.RcodeServFail,
+			wantEDE:   []uint16{packet.EdeNoReachableAuthority},
+		},
+		{
+			name:      "database down without stale data",
+			setup:     func() *Server { return NewServer("127.0.0.1:0", openBreaker(), nil) },
+			query:     "www.example.test.",
+			wantRcode: packet.RcodeServFail,
+			wantEDE:   []uint16{packet.EdeNoReachableAuthority},
+		},
+		{
+			name: "database down with stale data",
+			setup: func() *Server {
+				srv := NewServer("127.0.0.1:0", openBreaker(), nil)
+				stale := packet.NewDNSPacket()
+				stale.Header.Response = true
+				stale.Questions = append(stale.Questions, packet.DNSQuestion{Name: "www.example.test.", QType: packet.A})
+				stale.Answers = append(stale.Answers, packet.DNSRecord{Name: "www.example.test.", Type: packet.A, Class: 1, TTL: 60, IP: net.ParseIP("192.0.2.1")})
+				buf := packet.NewBytePacketBuffer()
+				_ = stale.Write(buf)
+				srv.Cache.Set("www.example.test.:1", buf.Buf[:buf.Position()], time.Millisecond)
+				time.Sleep(5 * time.Millisecond)
+				return srv
+			},
+			query:     "www.example.test.",
+			wantRcode: packet.RcodeNoError,
+			wantEDE:   []uint16{packet.EdeStaleAnswer},
+		},
+		{
+			name:      "no zone data source",
+			setup:     func() *Server { return NewServer("127.0.0.1:0", nil, nil) },
+			query:     "www.example.test.",
+			wantRcode: packet.RcodeServFail,
+			wantEDE:   []uint16{packet.EdeNoReachableAuthority},
+		},
+		{
+			name: "signing failure is bogus",
+			setup: func() *Server {
+				repo := &mockServerRepo{
+					zones:   []domain.Zone{zone},
+					records: []domain.Record{soa, www},
+					keys:    []domain.DNSSECKey{{ZoneID: "z1", KeyType: "ZSK", Active: true, PrivateKey: []byte("corrupt")}},
+				}
+				srv := NewServer("127.0.0.1:0", repo, nil)
+				srv.DNSSEC = services.NewDNSSECService(repo)
+				return srv
+			},
+			query:     "www.example.test.",
+			do:        true,
+			wantRcode: packet.RcodeServFail,
+			wantEDE:   []uint16{packet.EdeDnssecBogus},
+		},
+		{
+			name: "unsigned zone with DO is answered",
+			setup: func() *Server {
+				repo := &mockServerRepo{zones: []domain.Zone{zone}, records: []domain.Record{soa, www}}
+				srv := NewServer("127.0.0.1:0", repo, nil)
+				srv.DNSSEC = services.NewDNSSECService(repo)
+				return srv
+			},
+			query:     "www.example.test.",
+			do:        true,
+			wantRcode: packet.RcodeNoError,
+		},
+		{
+			name:      "withheld CHAOS names are blocked",
+			setup:     func() *Server { return NewServer("127.0.0.1:0", &mockServerRepo{}, nil) },
+			query:     "version.bind.",
+			class:     ClassCHAOS,
+			wantRcode: packet.RcodeRefused,
+			wantEDE:   []uint16{packet.EdeBlocked},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			class := tt.class
+			if class == 0 {
+				class = 1
+			}
+			res := ednsQuery(t, tt.setup(), tt.query, class, tt.rd, tt.do)
+			if res.Header.ResCode != tt.wantRcode {
+				t.Errorf("Expected rcode %d, got %d", tt.wantRcode, res.Header.ResCode)
+			}
+			got := edeCodes(res)
+			if len(got) != len(tt.wantEDE) {
+				t.Fatalf("Expected EDE %v, got %v", tt.wantEDE, got)
+			}
+			for i := range got {
+				if got[i] != tt.wantEDE[i] {
+					t.Errorf("Expected EDE %v, got %v", tt.wantEDE, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRFC8914_NoEDEWithoutEDNS(t *testing.T) {
+	srv := NewServer("127.0.0.1:0", &mockServerRepo{}, nil)
+	res := identityQuery(t, srv, "elsewhere.test.", packet.A, 1, false)
+	for _, r := range res.Resources {
+		if r.Type == packet.OPT {
+			t.Error("Expected no OPT record for a query without EDNS")
+		}
 	}
 }
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/poyrazK/cloudDNS/internal/adapters/repository"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+)
+
+// MaxSearchSuffixes bounds the lookups a single-label query can cost.
+const MaxSearchSuffixes = 8
+
+// specialUseLabels are single-label names reserved by RFC 6761, RFC 6762,
+// RFC 7686 and RFC 9476 that must never be completed with a search suffix.
+var specialUseLabels = map[string]bool{
+	"localhost": true, "invalid": true, "test": true, "example": true,
+	"local": true, "onion": true, "alt": true, "arpa": true,
+}
+
+// SearchView completes single-label queries from misconfigured stub clients,
+// which would otherwise fail, with search suffixes. A suffix only applies
+// when it falls in a zone we host, so guesses are never sent elsewhere.
+type SearchView struct {
+	Name string
+	// Clients are the networks the view serves; empty serves every client.
+	Clients []*net.IPNet
+	// Suffixes are tried in order; the first that answers wins.
+	Suffixes []string
+}
+
+// ParseSearchViews parses semicolon-separated views, each a comma-separated
+// list of suffixes followed by optional name= and clients= fields, for example
+// "corp.example.com,lab.example.com clients=10.0.0.0/8". Views are matched
+// against the client address in order.
+func ParseSearchViews(spec string) ([]SearchView, error) {
+	var views []SearchView
+	for i, part := range strings.Split(spec, ";") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		view := SearchView{Name: fmt.Sprintf("view%d", i)}
+		for _, suffix := range strings.Split(fields[0], ",") {
+			suffix = dnsname.Fqdn(strings.ToLower(suffix))
+			labels := dnsname.SplitLabels(suffix)
+			if len(labels) == 0 || specialUseLabels[labels[len(labels)-1]] {
+				return nil, fmt.Errorf("search view %d: %q cannot be a search suffix", i, suffix)
+			}
+			view.Suffixes = append(view.Suffixes, suffix)
+		}
+		if len(view.Suffixes) > MaxSearchSuffixes {
+			return nil, fmt.Errorf("search view %d: at most %d suffixes are allowed", i, MaxSearchSuffixes)
+		}
+		for _, field := range fields[1:] {
+			key, val, ok := strings.Cut(field, "=")
+			if !ok {
+				return nil, fmt.Errorf("search view %d: %q is not key=value", i, field)
+			}
+			switch key {
+			case "name":
+				view.Name = val
+			case "clients":
+				clients, err := parseCIDRs(val)
+				if err != nil {
+					return nil, fmt.Errorf("search view %d: clients: %w", i, err)
+				}
+				view.Clients = clients
+			default:
+				return nil, fmt.Errorf("search view %d: unknown field %q", i, key)
+			}
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+// searchView returns the first view serving clientIP when name is a single
+// label that is not reserved for special use, or nil.
+func (s *Server) searchView(name, clientIP string) *SearchView {
+	if len(s.SearchSuffixes) == 0 {
+		return nil
+	}
+	labels := dnsname.SplitLabels(dnsname.Normalize(name))
+	if len(labels) != 1 || specialUseLabels[labels[0]] {
+		return nil
+	}
+	ip := net.ParseIP(clientIP)
+	for i := range s.SearchSuffixes {
+		if clientMatches(s.SearchSuffixes[i].Clients, ip) {
+			return &s.SearchSuffixes[i]
+		}
+	}
+	return nil
+}
+
+// searchExpand looks name up under each of the view's suffixes that falls in a
+// zone we host. For the first that has records of the query type it returns
+// that zone and the answer: a CNAME from name to the completed name followed by
+// its records. Completed names are never resolved recursively and never
+// completed again.
+func (s *Server) searchExpand(ctx context.Context, view *SearchView, name string, qType domain.RecordType, clientIP string) (*domain.Zone, []packet.DNSRecord) {
+	for _, suffix := range view.Suffixes {
+		target := dnsname.Normalize(name) + suffix
+		zone := s.hostedZone(ctx, target)
+		if zone == nil {
+			continue
+		}
+		records, err := s.Repo.GetRecords(ctx, target, qType, clientIP)
+		if err != nil || len(records) == 0 {
+			continue
+		}
+		answers := []packet.DNSRecord{{Name: name, Type: packet.CNAME, Class: 1, Host: target}}
+		for _, rec := range records {
+			if pRec, errConv := repository.ConvertDomainToPacketRecord(rec); errConv == nil {
+				answers = append(answers, pRec)
+			}
+		}
+		// The CNAME lives no longer than what it points to
+		answers[0].TTL = minRecordTTL(answers[1:])
+		metrics.SearchSuffixAnswers.WithLabelValues(view.Name).Inc()
+		return zone, answers
+	}
+	return nil, nil
+}
+
+// hostedZone returns the closest zone we host that contains name, or nil.
+func (s *Server) hostedZone(ctx context.Context, name string) *domain.Zone {
+	for {
+		if z, err := s.Repo.GetZone(ctx, name); err == nil && z != nil {
+			return z
+		}
+		parent, ok := dnsname.Parent(name)
+		if !ok || parent == dnsname.Root {
+			return nil
+		}
+		name = parent
+	}
+}
+
+func parseCIDRs(list string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, cidr := range strings.Split(list, ",") {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a CIDR", cidr)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// clientMatches reports whether ip is in one of clients; no clients match all.
+func clientMatches(clients []*net.IPNet, ip net.IP) bool {
+	if len(clients) == 0 {
+		return true
+	}
+	for _, network := range clients {
+		if ip != nil && network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
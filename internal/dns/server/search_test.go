@@ -0,0 +1,86 @@
+package server
+
+import (
+	"net"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+func TestParseSearchViews(t *testing.T) {
+	views, err := ParseSearchViews("corp.example.com,Lab.Example.com name=office clients=10.0.0.0/8; internal.example.net")
+	if err != nil {
+		t.Fatalf("ParseSearchViews failed: %v", err)
+	}
+	if len(views) != 2 {
+		t.Fatalf("Expected 2 views, got %d", len(views))
+	}
+	if v := views[0]; v.Name != "office" || len(v.Clients) != 1 || len(v.Suffixes) != 2 || v.Suffixes[1] != "lab.example.com." {
+		t.Errorf("Unexpected first view %+v", v)
+	}
+	if v := views[1]; v.Name != "view1" || len(v.Clients) != 0 {
+		t.Errorf("Unexpected second view %+v", v)
+	}
+
+	for _, bad := range []string{".", "corp.local", "home.arpa", "corp.example.com clients=10/8", "corp.example.com zone=x", "a.com,b.com,c.com,d.com,e.com,f.com,g.com,h.com,i.com"} {
+		if _, err := ParseSearchViews(bad); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestHandlePacketSearchSuffix(t *testing.T) {
+	repo := &mockServerRepo{
+		zones: []domain.Zone{{ID: "z1", Name: "corp.example.com."}, {ID: "z2", Name: "lab.example.com."}},
+		records: []domain.Record{
+			{Name: "printer.lab.example.com.", Type: domain.TypeA, Content: "10.9.9.9", TTL: 300},
+			{Name: "printer.lab.example.com.", Type: domain.TypeA, Content: "10.9.9.8", TTL: 120},
+			{Name: "localhost.corp.example.com.", Type: domain.TypeA, Content: "10.0.0.1", TTL: 60},
+		},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+	views, err := ParseSearchViews("corp.example.com,lab.example.com,elsewhere.example.org clients=10.0.0.0/8")
+	if err != nil {
+		t.Fatalf("ParseSearchViews failed: %v", err)
+	}
+	srv.SearchSuffixes = views
+
+	query := func(name, client string) *packet.DNSPacket {
+		t.Helper()
+		req := packet.NewDNSPacket()
+		req.Questions = append(req.Questions, packet.DNSQuestion{Name: name, QType: packet.A})
+		buf := packet.NewBytePacketBuffer()
+		_ = req.Write(buf)
+		var resp []byte
+		if err := srv.handlePacket(buf.Buf[:buf.Position()], &net.UDPAddr{IP: net.ParseIP(client), Port: 5353}, func(b []byte) error {
+			resp = append([]byte(nil), b...)
+			return nil
+		}, "udp"); err != nil {
+			t.Fatalf("handlePacket failed: %v", err)
+		}
+		return parseMessage(t, resp)
+	}
+
+	resp := query("printer.", "10.1.1.1")
+	if resp.Header.ResCode != 0 || len(resp.Answers) != 3 {
+		t.Fatalf("Expected a CNAME and two A records, got rcode %d and %+v", resp.Header.ResCode, resp.Answers)
+	}
+	if cname := resp.Answers[0]; cname.Type != packet.CNAME || cname.Host != "printer.lab.example.com." || cname.TTL != 120 {
+		t.Errorf("Unexpected CNAME %+v", cname)
+	}
+
+	// Outside the view, reserved names and multi-label names are left alone
+	if resp := query("printer.", "192.0.2.1"); len(resp.Answers) != 0 {
+		t.Errorf("Expected no completion outside the view, got %+v", resp.Answers)
+	}
+	if resp := query("localhost.", "10.1.1.1"); len(resp.Answers) != 0 {
+		t.Errorf("Expected localhost not to be completed, got %+v", resp.Answers)
+	}
+	if resp := query("printer.lab.", "10.1.1.1"); len(resp.Answers) != 0 {
+		t.Errorf("Expected a multi-label name not to be completed, got %+v", resp.Answers)
+	}
+	if resp := query("scanner.", "10.1.1.1"); len(resp.Answers) != 0 || resp.Header.ResCode == 0 {
+		t.Errorf("Expected an unknown name to fail as before, got rcode %d and %+v", resp.Header.ResCode, resp.Answers)
+	}
+}
@@ -0,0 +1,293 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/serial"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+)
+
+// SOA timers assumed for a secondary zone that has not been transferred yet
+// (RFC 1912 2.2).
+const (
+	defaultSOARefresh = time.Hour
+	defaultSOARetry   = 15 * time.Minute
+	defaultSOAExpire  = 7 * 24 * time.Hour
+)
+
+// secondaryCheckInterval is how often secondary zones are checked for a due
+// refresh.
+var secondaryCheckInterval = 30 * time.Second
+
+// soaTimers are the refresh, retry and expire fields of a zone's SOA (RFC 1035
+// 3.3.13).
+type soaTimers struct {
+	refresh, retry, expire time.Duration
+}
+
+// timersFromSOA extracts the timers from SOA content ("mname rname serial
+// refresh retry expire minimum"), keeping the defaults for missing or invalid
+// fields.
+func timersFromSOA(content string) soaTimers {
+	t := soaTimers{refresh: defaultSOARefresh, retry: defaultSOARetry, expire: defaultSOAExpire}
+	fields := strings.Fields(content)
+	for i, d := range []*time.Duration{&t.refresh, &t.retry, &t.expire} {
+		if len(fields) <= 3+i {
+			break
+		}
+		if v, err := strconv.ParseUint(fields[3+i], 10, 32); err == nil && v > 0 {
+			*d = time.Duration(v) * time.Second
+		}
+	}
+	return t
+}
+
+// secondaryTimers schedules the refreshes of secondary zones by their SOA
+// timers: a zone is refreshed every refresh interval, retried every retry
+// interval after a failure, and expires once it has gone unrefreshed for the
+// expire interval (RFC 1034 4.3.5).
+type secondaryTimers struct {
+	mu    sync.Mutex
+	zones map[string]*secondaryState
+	now   func() time.Time
+	// transfers serialises the transfers of each zone, so NOTIFYs arriving
+	// close together do not apply the same IXFR twice
+	transfers map[string]*sync.Mutex
+}
+
+type secondaryState struct {
+	next time.Time
+	// loaded is when the zone was last refreshed, or first seen
+	loaded  time.Time
+	expired bool
+}
+
+func newSecondaryTimers() *secondaryTimers {
+	return &secondaryTimers{zones: make(map[string]*secondaryState), now: time.Now, transfers: make(map[string]*sync.Mutex)}
+}
+
+// lockTransfer waits for any transfer of zoneID in progress and returns the
+// function that ends the caller's.
+func (t *secondaryTimers) lockTransfer(zoneID string) func() {
+	t.mu.Lock()
+	l, ok := t.transfers[zoneID]
+	if !ok {
+		l = &sync.Mutex{}
+		t.transfers[zoneID] = l
+	}
+	t.mu.Unlock()
+	l.Lock()
+	return l.Unlock
+}
+
+// state returns the state of zoneID, starting its expire timer if it is new.
+// The caller holds t.mu.
+func (t *secondaryTimers) state(zoneID string) *secondaryState {
+	st, ok := t.zones[zoneID]
+	if !ok {
+		st = &secondaryState{loaded: t.now()}
+		t.zones[zoneID] = st
+	}
+	return st
+}
+
+// due reports whether zoneID should be refreshed now. Zones not seen before are
+// due at once.
+func (t *secondaryTimers) due(zoneID string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return !t.now().Before(t.state(zoneID).next)
+}
+
+// succeeded records a refresh of zoneID, which is now current with timers.
+func (t *secondaryTimers) succeeded(zoneID string, timers soaTimers) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	st := t.state(zoneID)
+	st.loaded = t.now()
+	st.next = st.loaded.Add(timers.refresh)
+	st.expired = false
+}
+
+// failed records a failed refresh of zoneID and reports whether the zone has
+// just expired.
+func (t *secondaryTimers) failed(zoneID string, timers soaTimers) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	st := t.state(zoneID)
+	now := t.now()
+	st.next = now.Add(timers.retry)
+	if st.expired || now.Sub(st.loaded) < timers.expire {
+		return false
+	}
+	st.expired = true
+	return true
+}
+
+// isExpired reports whether zoneID has expired and must not be answered from.
+func (t *secondaryTimers) isExpired(zoneID string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	st, ok := t.zones[zoneID]
+	return ok && st.expired
+}
+
+// retain forgets every zone not in active, such as zones deleted or made
+// primaries, and returns how many of the rest have expired.
+func (t *secondaryTimers) retain(active map[string]struct{}) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	expired := 0
+	for id, st := range t.zones {
+		if _, ok := active[id]; !ok {
+			delete(t.zones, id)
+			continue
+		}
+		if st.expired {
+			expired++
+		}
+	}
+	for id := range t.transfers {
+		if _, ok := active[id]; !ok {
+			delete(t.transfers, id)
+		}
+	}
+	return expired
+}
+
+// scheduleSecondaries refreshes secondary zones as their SOA timers fall due
+// until ctx is done.
+func (s *Server) scheduleSecondaries(ctx context.Context) {
+	if s.Repo == nil {
+		return
+	}
+	ticker := time.NewTicker(secondaryCheckInterval)
+	defer ticker.Stop()
+	for {
+		s.refreshSecondaries(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refreshSecondaries refreshes every secondary zone that is due.
+func (s *Server) refreshSecondaries(ctx context.Context) {
+	zones, err := s.Repo.ListZones(ctx, "")
+	if err != nil {
+		s.Logger.Error("failed to list zones for secondary refresh", "error", err)
+		return
+	}
+	active := make(map[string]struct{})
+	for i := range zones {
+		zone := &zones[i]
+		if zone.Role != "slave" {
+			continue
+		}
+		active[zone.ID] = struct{}{}
+		if ctx.Err() != nil || !s.secondaries.due(zone.ID) {
+			continue
+		}
+		if _, err := s.transferFromMaster(ctx, zone); err != nil {
+			s.Logger.Error("zone refresh failed", "zone", zone.Name, "error", err)
+		}
+	}
+	metrics.SecondaryZonesExpired.Set(float64(s.secondaries.retain(active)))
+}
+
+// secondaryRefreshed reschedules a secondary zone after a refresh attempt by
+// the timers of its current SOA, and records the outcome for the zone summary.
+func (s *Server) secondaryRefreshed(ctx context.Context, zone *domain.Zone, errRefresh error) {
+	timers := timersFromSOA("")
+	refresh := &domain.ZoneRefresh{ZoneID: zone.ID, AttemptedAt: time.Now()}
+	if records, err := s.Repo.GetRecords(ctx, zone.Name, domain.TypeSOA, ""); err == nil && len(records) > 0 {
+		timers = timersFromSOA(records[0].Content)
+		refresh.Serial, _ = serial.FromSOA(records[0].Content)
+	}
+	if errRefresh == nil {
+		refresh.SucceededAt = &refresh.AttemptedAt
+	} else {
+		refresh.Error = errRefresh.Error()
+	}
+	if errSave := s.Repo.SaveZoneRefresh(ctx, refresh); errSave != nil {
+		s.Logger.Warn("failed to record zone refresh", "zone", zone.Name, "error", errSave)
+	}
+	if errRefresh == nil {
+		metrics.SecondaryRefreshes.WithLabelValues("success").Inc()
+		s.secondaries.succeeded(zone.ID, timers)
+		return
+	}
+	metrics.SecondaryRefreshes.WithLabelValues("error").Inc()
+	if s.secondaries.failed(zone.ID, timers) {
+		s.Logger.Error("secondary zone expired, answering SERVFAIL until it is refreshed", "zone", zone.Name, "expire", timers.expire)
+	}
+}
+
+// scheduleFullSync transfers every secondary zone in full each
+// FullSyncInterval until ctx is done.
+func (s *Server) scheduleFullSync(ctx context.Context) {
+	if s.Repo == nil || s.FullSyncInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.FullSyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.FullSync(ctx)
+		}
+	}
+}
+
+// FullSync transfers every secondary zone in full (AXFR) from the first of its
+// masters that answers, even when the serials say it is up to date.
+func (s *Server) FullSync(ctx context.Context) {
+	zones, err := s.Repo.ListZones(ctx, "")
+	if err != nil {
+		s.Logger.Error("failed to list zones for full sync", "error", err)
+		return
+	}
+	for i := range zones {
+		zone := &zones[i]
+		if zone.Role != "slave" || ctx.Err() != nil {
+			continue
+		}
+		if err := s.fullTransfer(ctx, zone); err != nil {
+			s.Logger.Error("full zone sync failed", "zone", zone.Name, "error", err)
+		}
+	}
+}
+
+// fullTransfer replaces a secondary zone with a full transfer from its masters
+// and reschedules its next refresh.
+func (s *Server) fullTransfer(ctx context.Context, zone *domain.Zone) error {
+	masters, err := domain.ParseMasters(zone.MasterServer)
+	if err != nil {
+		return fmt.Errorf("zone %s: %v: %w", zone.Name, err, domain.ErrInvalidZoneRole)
+	}
+	defer s.secondaries.lockTransfer(zone.ID)()
+
+	var errTransfer error
+	for _, masterAddr := range masters {
+		if errTransfer = s.performAXFR(zone, masterAddr); errTransfer == nil {
+			s.zoneTransferred(zone)
+			s.secondaryRefreshed(ctx, zone, nil)
+			metrics.SecondaryFullSyncs.WithLabelValues("success").Inc()
+			return nil
+		}
+		s.Logger.Warn("full zone sync from master failed", "zone", zone.Name, "master", masterAddr, "error", errTransfer)
+	}
+	s.secondaryRefreshed(ctx, zone, errTransfer)
+	metrics.SecondaryFullSyncs.WithLabelValues("error").Inc()
+	return fmt.Errorf("AXFR failed: %v: %w", errTransfer, domain.ErrTransferFailed)
+}
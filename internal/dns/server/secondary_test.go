@@ -0,0 +1,190 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+func TestTimersFromSOA(t *testing.T) {
+	got := timersFromSOA("ns1.test. admin.test. 5 1800 300 86400 60")
+	if got.refresh != 30*time.Minute || got.retry != 5*time.Minute || got.expire != 24*time.Hour {
+		t.Errorf("Unexpected timers %+v", got)
+	}
+	got = timersFromSOA("ns1.test. admin.test. 5 0 x")
+	if got.refresh != defaultSOARefresh || got.retry != defaultSOARetry || got.expire != defaultSOAExpire {
+		t.Errorf("Expected defaults for invalid fields, got %+v", got)
+	}
+}
+
+func TestSecondaryTimers(t *testing.T) {
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	timers := newSecondaryTimers()
+	timers.now = func() time.Time { return clock }
+	soa := soaTimers{refresh: time.Hour, retry: 10 * time.Minute, expire: 2 * time.Hour}
+
+	if !timers.due("z1") {
+		t.Fatal("Expected a new zone to be due at once")
+	}
+	timers.succeeded("z1", soa)
+	clock = clock.Add(59 * time.Minute)
+	if timers.due("z1") {
+		t.Error("Expected no refresh before the refresh interval")
+	}
+	clock = clock.Add(time.Minute)
+	if !timers.due("z1") {
+		t.Error("Expected a refresh after the refresh interval")
+	}
+
+	// Failures are retried every retry interval until the zone expires
+	if timers.failed("z1", soa) || timers.due("z1") {
+		t.Error("Expected a retry to be scheduled without expiring")
+	}
+	clock = clock.Add(time.Hour)
+	if !timers.failed("z1", soa) || !timers.isExpired("z1") {
+		t.Error("Expected the zone to expire after the expire interval")
+	}
+	if timers.failed("z1", soa) {
+		t.Error("Expected expiry to be reported once")
+	}
+	if n := timers.retain(map[string]struct{}{"z1": {}}); n != 1 {
+		t.Errorf("Expected one expired zone, got %d", n)
+	}
+	timers.succeeded("z1", soa)
+	if timers.isExpired("z1") {
+		t.Error("Expected a refresh to revive the zone")
+	}
+	timers.retain(nil)
+	if len(timers.zones) != 0 {
+		t.Error("Expected zones no longer secondaries to be forgotten")
+	}
+}
+
+// TestRefreshSecondaries verifies that a due secondary is transferred from the
+// first master that answers and its contents replaced.
+func TestSecondaryTransfersAreSerialised(t *testing.T) {
+	timers := newSecondaryTimers()
+	unlock := timers.lockTransfer("z1")
+
+	// Other zones transfer independently
+	timers.lockTransfer("z2")()
+
+	acquired := make(chan struct{})
+	go func() {
+		defer timers.lockTransfer("z1")()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("Expected a second transfer of the zone to wait for the first")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the second transfer to start once the first ended")
+	}
+}
+
+func TestRefreshSecondaries(t *testing.T) {
+	prio := 10
+	masterRepo := &mockServerRepo{
+		zones: []domain.Zone{{ID: "m1", Name: "secondary.test."}},
+		records: []domain.Record{
+			{ZoneID: "m1", Name: "secondary.test.", Type: domain.TypeSOA, Content: "ns1.secondary.test. admin.secondary.test. 7 1800 300 86400 60", TTL: 300},
+			{ZoneID: "m1", Name: "secondary.test.", Type: domain.TypeMX, Content: "mail.secondary.test.", Priority: &prio, TTL: 300},
+			{ZoneID: "m1", Name: "www.secondary.test.", Type: domain.TypeA, Content: "192.0.2.7", TTL: 300},
+		},
+	}
+	masterAddr, cleanup := startMasterListener(t, NewServer("127.0.0.1:0", masterRepo, nil))
+	defer cleanup()
+
+	repo := &mockServerRepo{
+		zones: []domain.Zone{
+			{ID: "z1", Name: "primary.test.", Role: "master"},
+			{ID: "z2", Name: "secondary.test.", Role: "slave", MasterServer: "192.0.2.53:53," + masterAddr},
+		},
+		records: []domain.Record{{ZoneID: "z2", Name: "old.secondary.test.", Type: domain.TypeA, Content: "192.0.2.1"}},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+	var asked []string
+	srv.queryFn = func(server string, name string, qtype packet.QueryType) (*packet.DNSPacket, error) {
+		asked = append(asked, server)
+		if server != masterAddr {
+			return nil, errors.New("timeout")
+		}
+		p := packet.NewDNSPacket()
+		p.Answers = append(p.Answers, packet.DNSRecord{Name: name, Type: packet.SOA, Serial: 7})
+		return p, nil
+	}
+
+	srv.refreshSecondaries(context.Background())
+	if len(asked) != 2 || asked[0] != "192.0.2.53:53" {
+		t.Fatalf("Expected both masters asked in order, got %v", asked)
+	}
+	types := map[domain.RecordType]int{}
+	for _, r := range repo.records {
+		if r.ZoneID != "z2" || r.Name == "old.secondary.test." {
+			t.Errorf("Unexpected record %+v", r)
+		}
+		types[r.Type]++
+		if r.Type == domain.TypeMX && (r.Priority == nil || *r.Priority != 10) {
+			t.Errorf("Expected the MX priority kept, got %+v", r)
+		}
+	}
+	if types[domain.TypeSOA] != 1 || types[domain.TypeMX] != 1 || types[domain.TypeA] != 1 {
+		t.Errorf("Expected the master's records with one SOA, got %v", types)
+	}
+
+	// The next refresh is due after the SOA refresh interval
+	if srv.secondaries.due("z2") {
+		t.Error("Expected no refresh due right after a transfer")
+	}
+	srv.secondaries.now = func() time.Time { return time.Now().Add(31 * time.Minute) }
+	if !srv.secondaries.due("z2") {
+		t.Error("Expected a refresh due after the SOA refresh interval")
+	}
+	if _, ok := srv.secondaries.zones["z1"]; ok {
+		t.Error("Expected primaries not to be scheduled")
+	}
+	refresh := repo.refreshes["z2"]
+	if refresh.SucceededAt == nil || refresh.Serial != 7 || refresh.Error != "" {
+		t.Errorf("Expected the successful refresh recorded, got %+v", refresh)
+	}
+}
+
+func TestExpiredSecondaryAnswersServfail(t *testing.T) {
+	repo := &mockServerRepo{
+		zones:   []domain.Zone{{ID: "z2", Name: "secondary.test.", Role: "slave", MasterServer: "192.0.2.53"}},
+		records: []domain.Record{{ZoneID: "z2", Name: "www.secondary.test.", Type: domain.TypeA, Content: "192.0.2.7", TTL: 300}},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+	srv.secondaries.zones["z2"] = &secondaryState{expired: true}
+
+	req := packet.NewDNSPacket()
+	req.Header.ID = 7
+	req.Questions = append(req.Questions, packet.DNSQuestion{Name: "www.secondary.test.", QType: packet.A})
+	reqBuf := packet.NewBytePacketBuffer()
+	_ = req.Write(reqBuf)
+
+	var captured []byte
+	if err := srv.handlePacket(reqBuf.Buf[:reqBuf.Position()], &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 12345}, func(resp []byte) error {
+		captured = resp
+		return nil
+	}, "udp"); err != nil {
+		t.Fatalf("handlePacket failed: %v", err)
+	}
+	resBuf := packet.NewBytePacketBuffer()
+	resBuf.Load(captured)
+	resp := packet.NewDNSPacket()
+	_ = resp.FromBuffer(resBuf)
+	if resp.Header.ResCode != packet.RcodeServFail || len(resp.Answers) != 0 {
+		t.Errorf("Expected SERVFAIL from an expired secondary, got rcode %d with %d answers", resp.Header.ResCode, len(resp.Answers))
+	}
+}
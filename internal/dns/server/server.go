@@ -18,6 +18,8 @@ import (
 	"runtime"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -25,36 +27,184 @@ import (
 	"github.com/poyrazK/cloudDNS/internal/core/domain"
 	"github.com/poyrazK/cloudDNS/internal/core/ports"
 	"github.com/poyrazK/cloudDNS/internal/core/services"
+	"github.com/poyrazK/cloudDNS/internal/dns/canonical"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
 	"github.com/poyrazK/cloudDNS/internal/dns/master"
 	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+	"github.com/poyrazK/cloudDNS/internal/dns/serial"
 	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+	"golang.org/x/sync/singleflight"
 )
 
 // ClassCHAOS is the DNS class for server identity and metadata.
 const ClassCHAOS = 3
 
+// Answers to queries for names outside our zones; see Server.OutOfZone.
+const (
+	OutOfZoneRefused  = "refused"
+	OutOfZoneNXDomain = "nxdomain"
+)
+
+// Answers for an RRset whose every record is failing its health check; see
+// Server.HealthFallback.
+const (
+	HealthFallbackAll  = "all"
+	HealthFallbackNone = "none"
+)
+
 type Server struct {
 	Addr             string
 	Repo             ports.DNSRepository
 	Cache            *DNSCache
+	// NegativeCache holds this node's NXDOMAIN and NODATA answers, cached for
+	// the lifetime their SOA allows (RFC 2308)
+	NegativeCache *NegativeCache
 	Redis            *RedisCache
 	DNSSEC           *services.DNSSECService
 	WorkerCount      int
 	udpQueue         chan udpTask
+	udpQueues        []chan udpTask // per-listener queues with CPUAffinity
 	Logger           *slog.Logger
 	queryFn          func(server string, name string, qtype packet.QueryType) (*packet.DNSPacket, error)
 	limiter          *rateLimiter
 	TsigKeys         map[string][]byte
 	NodeID           string
 	RecursionEnabled bool
+	// RecursionClients are the networks allowed to recurse when recursion is
+	// enabled; empty allows every client.
+	RecursionClients []*net.IPNet
+	// recursionCache holds the responses of recursive lookups, apart from the
+	// authoritative response caches
+	recursionCache *DNSCache
+	// OutOfZone selects the answer to queries for names outside every zone that
+	// are not resolved recursively: OutOfZoneRefused (the default) or the legacy
+	// OutOfZoneNXDomain.
+	OutOfZone string
+	// HealthFallback selects the answer for an RRset whose every record is
+	// failing its health check: HealthFallbackAll (the default) serves them all
+	// rather than none, HealthFallbackNone serves none.
+	HealthFallback string
+	// Identity is reported in CHAOS id.server./hostname.bind. answers and the NSID
+	// option (RFC 5001); empty hides it.
+	Identity string
+	// CPUAffinity gives each UDP listener its own queue and share of the workers
+	// and pins them to the CPUs of one NUMA node, listeners taking the nodes in
+	// turn, so a query stays in one node's caches. Linux only; each pinned
+	// goroutine holds an OS thread.
+	CPUAffinity bool
+	// DNS64 synthesizes AAAA answers for clients of IPv6-only networks; the first
+	// view serving the client applies. AAAA queries of those clients bypass the
+	// response caches, since their answers depend on the view.
+	DNS64 []DNS64View
+	// SearchSuffixes completes single-label queries of the clients of each view
+	// with search suffixes inside our zones; those queries bypass the caches.
+	SearchSuffixes []SearchView
+	// QueryTypePolicies refuse or force TCP for expensive query types, such as
+	// ANY over UDP; the first matching policy applies.
+	QueryTypePolicies []QueryTypePolicy
+	// Cookies issues and checks DNS Cookies (RFC 7873); nil ignores them. Queries
+	// with a valid server cookie are exempt from the rate limiter.
+	Cookies *CookieJar
+	// ClientSubnet matches split-horizon records against the EDNS Client Subnet
+	// (RFC 7871) a resolver forwards instead of the resolver's own address.
+	ClientSubnet bool
+	// Geo locates clients, or their EDNS Client Subnet, for records with a geo
+	// policy; nil answers every client with the records without one.
+	Geo ports.GeoLocator
+
+	// Events receives a sample of authoritative queries as live events; see
+	// SetQueryEventSampleRate.
+	Events         ports.EventPublisher
+	queryEventRate atomic.Int64
+	queryEventSeq  atomic.Uint64
+	queriesServed  atomic.Uint64
+	queriesFailed  atomic.Uint64
+
+	// Usage counts per-zone queries and transfer bytes for usage reports.
+	Usage ports.UsageRecorder
+	// Analytics estimates unique clients and hot names from answered queries.
+	Analytics ports.QueryObserver
+	// Clients counts the EDNS, DNSSEC, cookie and transport capabilities of
+	// incoming queries.
+	Clients ports.ClientObserver
+	// Latency receives the response time of each answered query for SLO tracking.
+	Latency ports.LatencyRecorder
+	// ZoneChanges is told about zones changed by dynamic updates and transfers,
+	// for example to notify external secondaries.
+	ZoneChanges ports.ZoneChangeListener
+	// DataChanges announces record and zone writes committed by any node, so
+	// cached answers are dropped as soon as they go stale.
+	DataChanges ports.DataChangeSource
+	// NotifyOnDataChange sends NOTIFY for zones whose records DataChanges
+	// reports, so secondaries also learn about changes made through the API.
+	NotifyOnDataChange bool
+	// FullSyncInterval, when positive, transfers every secondary zone in full
+	// this often whatever its serial, so a copy that drifted from its master
+	// through a missed or misapplied IXFR is repaired.
+	FullSyncInterval time.Duration
+	// secondaries schedules refreshes of secondary zones by their SOA timers
+	secondaries *secondaryTimers
+	// shadows holds the record changes staged in shadow mode, compared with
+	// live answers and served to canary clients
+	shadows atomic.Pointer[shadowSet]
+	// servedShadows holds the zones whose shadow change a zone rollout has
+	// made this node serve to every client
+	servedShadows atomic.Pointer[map[string]struct{}]
+	// queryLimits holds the query rate limits of tenants and zones
+	queryLimits atomic.Pointer[queryLimitSet]
+	// views holds the networks of split-horizon records and the tenants' views,
+	// which tell the views of clients apart in cache keys and select the
+	// variant of a zone they are answered from
+	views atomic.Pointer[viewSet]
+	// defaults holds the zones' default records, which answer names that
+	// have no records after wildcards
+	defaults atomic.Pointer[defaultRecordSet]
+	// targets holds the addresses of the tenants' targets, which answer for
+	// the records referencing them
+	targets atomic.Pointer[targetSet]
+	// lookups collapses concurrent identical zone and record lookups of the
+	// query path into one database query
+	lookups singleflight.Group
 
 	// Testing/Chaos flags
 	SimulateDBLatency  time.Duration
 	NotifyPortOverride int
 	DisableAsync       bool // If true, NOTIFY and UPDATE handlers won't spawn goroutines
 
+	// UpdateDebounce coalesces the cache flushes and NOTIFYs of bursts of
+	// dynamic updates to one zone; zero flushes and notifies on every update.
+	UpdateDebounce time.Duration
+	updates        *zoneDebouncer
+	dataChanges    *zoneDebouncer
+
 	// TLS Config for DoT and DoH
 	TLSConfig *tls.Config
+	// DoHCache stores cacheable DoH GET responses; nil disables it.
+	DoHCache DoHResponseStore
+	// CacheAdmission limits the size and record count of cached responses.
+	CacheAdmission CacheAdmission
+	// Padding and early data controls for DoT and DoH
+	DoTPolicy TransportPolicy
+	DoHPolicy TransportPolicy
+	// DoHCleartextAddr, if set, also serves DoH without TLS, over HTTP/1.1 and
+	// HTTP/2 with prior knowledge, for a TLS-terminating proxy in front.
+	DoHCleartextAddr string
+	// DoHAltSvc is advertised in the Alt-Svc header of DoH responses, such as
+	// `h3=":443"; ma=86400` when an HTTP/3 frontend serves the endpoint too.
+	DoHAltSvc string
+
+	// Draining state; see Shutdown and ReloadCertificate
+	mu           sync.Mutex
+	listeners    []net.Listener
+	packetConns  []net.PacketConn
+	dohServers   []*http.Server
+	conns        connTracker
+	certificate  atomic.Pointer[tls.Certificate]
+	draining     atomic.Bool
+	shutdownOnce sync.Once
+	udpReaders   sync.WaitGroup
+	udpWorkers   sync.WaitGroup
+	stopped      chan struct{}
 }
 
 type udpTask struct {
@@ -79,43 +229,85 @@ func NewServer(addr string, repo ports.DNSRepository, logger *slog.Logger) *Serv
 	}
 
 	recursion := os.Getenv("RECURSION_ENABLED") == "true"
+	outOfZone := OutOfZoneRefused
+	if strings.EqualFold(os.Getenv("OUT_OF_ZONE_RESPONSE"), OutOfZoneNXDomain) {
+		outOfZone = OutOfZoneNXDomain
+	}
+
+	healthFallback := HealthFallbackAll
+	if strings.EqualFold(os.Getenv("HEALTH_FALLBACK"), HealthFallbackNone) {
+		healthFallback = HealthFallbackNone
+	}
+
+	identity := os.Getenv("SERVER_IDENTITY")
+	switch identity {
+	case "":
+		identity = nodeID
+	case "none":
+		identity = ""
+	}
 
 	s := &Server{
 		Addr:             addr,
 		Repo:             repo,
-		Cache:            NewDNSCache(),
+		Cache:            newDNSCache("l1", baseCacheKey),
+		NegativeCache:    NewNegativeCache(),
+		DoHCache:         newDNSCache("doh", baseCacheKey),
+		recursionCache:   newDNSCache("recursion", baseCacheKey),
 		DNSSEC:           services.NewDNSSECService(repo),
 		WorkerCount:      runtime.NumCPU() * 32, // High concurrency tuning
 		udpQueue:         make(chan udpTask, 50000),
 		Logger:           logger,
-		limiter:          newRateLimiter(500000, 200000),
+		limiter:          newRateLimiter(DefaultRateLimitQPS, DefaultRateLimitBurst),
 		TsigKeys:         make(map[string][]byte),
 		NodeID:           nodeID,
 		RecursionEnabled: recursion,
+		OutOfZone:        outOfZone,
+		HealthFallback:   healthFallback,
+		Identity:         identity,
+		DoTPolicy:        defaultTransportPolicy(),
+		DoHPolicy:        defaultTransportPolicy(),
+		CacheAdmission:   defaultCacheAdmission(),
+		UpdateDebounce:   DefaultUpdateDebounce,
+		updates:          newZoneDebouncer(),
+		dataChanges:      newZoneDebouncer(),
+		secondaries:      newSecondaryTimers(),
+		stopped:          make(chan struct{}),
 	}
 	s.queryFn = s.sendQuery
+	s.SetCacheLimits(DefaultCacheMaxEntries, DefaultCacheMaxBytes)
 
-	// Periodic cleanup of rate limiter buckets
-	go func() {
-		for {
-			time.Sleep(5 * time.Minute)
-			s.limiter.Cleanup()
-		}
-	}()
+	return s
+}
 
-	// Background DNSSEC automation: Run every hour
-	go func() {
-		for {
-			time.Sleep(1 * time.Hour)
-			s.automateDNSSEC()
+// maintain cleans up rate limiter buckets every five minutes and runs DNSSEC
+// automation every hour until ctx is done.
+func (s *Server) maintain(ctx context.Context) {
+	cleanup := time.NewTicker(5 * time.Minute)
+	defer cleanup.Stop()
+	dnssec := time.NewTicker(time.Hour)
+	defer dnssec.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-cleanup.C:
+			s.limiter.Cleanup()
+		case <-dnssec.C:
+			s.automateDNSSEC(ctx)
 		}
-	}()
+	}
+}
 
-	return s
+// signsLocally reports whether this server manages the zone's keys and signs its
+// answers. A secondary's keys stay with its primary, which does not transfer
+// them, so keys made here would not match the DS at the parent; its answers are
+// served unsigned instead.
+func signsLocally(zone *domain.Zone) bool {
+	return zone.SignsDNSSEC() && zone.Role != "slave"
 }
 
-func (s *Server) automateDNSSEC() {
-	ctx := context.Background()
+func (s *Server) automateDNSSEC(ctx context.Context) {
 	// Get all zones
 	zones, errList := s.Repo.ListZones(ctx, "")
 	if errList != nil {
@@ -123,6 +315,9 @@ func (s *Server) automateDNSSEC() {
 	}
 
 	for _, z := range zones {
+		if !signsLocally(&z) {
+			continue
+		}
 		if errAutomate := s.DNSSEC.AutomateLifecycle(ctx, z.ID); errAutomate != nil {
 			s.Logger.Error("DNSSEC automation failed for zone", "zone", z.Name, "error", errAutomate)
 		}
@@ -130,14 +325,10 @@ func (s *Server) automateDNSSEC() {
 }
 
 func (s *Server) startInvalidationListener(ctx context.Context) {
-	pubsub := s.Redis.Subscribe(ctx)
-	defer func() {
-		if errClose := pubsub.Close(); errClose != nil {
-			s.Logger.Error("failed to close pubsub", "error", errClose)
-		}
-	}()
-
-	ch := pubsub.Channel()
+	ch, errSubscribe := s.Redis.Subscribe(ctx)
+	if errSubscribe != nil {
+		s.Logger.Warn("cache invalidation listener is not subscribed on every redis shard yet", "error", errSubscribe)
+	}
 	s.Logger.Info("started global cache invalidation listener")
 
 	for {
@@ -145,32 +336,72 @@ func (s *Server) startInvalidationListener(ctx context.Context) {
 		case <-ctx.Done():
 			s.Logger.Info("stopping global cache invalidation listener")
 			return
-		case msg := <-ch:
-			// msg.Payload format is "name:type"
-			s.Logger.Debug("received cache invalidation event", "key", msg.Payload)
-
-			// Standardize key for L1 cache lookup (lowercase name)
-			parts := strings.SplitN(msg.Payload, ":", 2)
-			if len(parts) == 2 {
-				l1Key := strings.ToLower(parts[0]) + ":" + parts[1]
-				s.Cache.Invalidate(l1Key)
-			} else {
-				s.Logger.Warn("received malformed cache invalidation payload", "payload", msg.Payload)
+		case payload, ok := <-ch:
+			if !ok {
+				return
+			}
+			s.Logger.Debug("received cache invalidation event", "payload", payload)
+			change, ok := parseInvalidation(payload)
+			if !ok {
+				s.Logger.Warn("received malformed cache invalidation payload", "payload", payload)
+				continue
 			}
+			s.invalidateLocal(change)
 		}
 	}
 }
 
-func (s *Server) Run() error {
-	ctx, cancel := context.WithCancel(context.Background())
+// Run serves DNS on all configured listeners until the server is shut down,
+// either by Shutdown or by ctx being done, in which case in-flight queries get
+// DefaultDrainTimeout to be answered. Background goroutines stop when Run
+// returns.
+func (s *Server) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
-
-	s.Logger.Info("starting parallel server", "addr", s.Addr, "listeners", runtime.NumCPU())
+	go func() {
+		select {
+		case <-ctx.Done():
+			if errDrain := s.Drain(context.WithoutCancel(ctx)); errDrain != nil {
+				s.Logger.Error("failed to shut down DNS server", "error", errDrain)
+			}
+		case <-s.stopped:
+		}
+	}()
+	go s.maintain(ctx)
+	go s.scheduleSecondaries(ctx)
+	go s.scheduleFullSync(ctx)
+	go s.scheduleShadows(ctx)
+	go s.scheduleQueryLimits(ctx)
+	go s.scheduleViews(ctx)
+	go s.scheduleDefaultRecords(ctx)
+	go s.scheduleTargets(ctx)
+
+	// Without kernel load balancing extra sockets would never see a query
+	listeners := 1
+	if reusePortBalances {
+		listeners = runtime.NumCPU()
+	}
+	s.Logger.Info("starting parallel server", "addr", s.Addr, "listeners", listeners)
+
+	groups := s.cpuGroups()
+	queues := []chan udpTask{s.udpQueue}
+	if groups != nil {
+		queues = make([]chan udpTask, listeners)
+		for i := range queues {
+			queues[i] = make(chan udpTask, max(cap(s.udpQueue)/listeners, 1))
+		}
+	}
+	s.mu.Lock()
+	s.udpQueues = queues
+	s.mu.Unlock()
 
 	// Start cache invalidation listener if Redis is enabled
 	if s.Redis != nil {
 		go s.startInvalidationListener(ctx)
 	}
+	if s.DataChanges != nil {
+		go s.startChangeListener(ctx)
+	}
 
 	lc := net.ListenConfig{
 		Control: func(network, address string, c syscall.RawConn) error {
@@ -184,28 +415,33 @@ func (s *Server) Run() error {
 
 	// 1. Parallel UDP
 	started := 0
-	for i := 0; i < runtime.NumCPU(); i++ {
+	for i := 0; i < listeners; i++ {
 		conn, errListen := lc.ListenPacket(ctx, "udp", s.Addr)
 		if errListen != nil {
 			s.Logger.Error("failed to start UDP listener", "id", i, "error", errListen)
 			continue
 		}
+		if !s.trackPacketConn(conn) {
+			return nil
+		}
 		started++
+		queue, cpus := queues[i%len(queues)], groupCPUs(groups, i)
+		// The socket is closed by Shutdown once queued queries have been answered
 		go func(c net.PacketConn) {
-			defer func() {
-				if errClose := c.Close(); errClose != nil {
-					s.Logger.Error("failed to close UDP connection", "error", errClose)
-				}
-			}()
+			defer s.udpReaders.Done()
+			s.pinGoroutine(cpus)
 			for {
 				buf := make([]byte, 512)
 				n, addr, errRead := c.ReadFrom(buf)
 				if errRead != nil {
+					if s.draining.Load() {
+						return
+					}
 					continue
 				}
 				data := make([]byte, n)
 				copy(data, buf[:n])
-				s.udpQueue <- udpTask{addr: addr, data: data, conn: c}
+				queue <- udpTask{addr: addr, data: data, conn: c}
 			}
 		}(conn)
 	}
@@ -215,75 +451,85 @@ func (s *Server) Run() error {
 	}
 
 	// 2. UDP Workers
-	for i := 0; i < s.WorkerCount; i++ {
-		go s.udpWorker()
+	s.mu.Lock()
+	if s.draining.Load() {
+		s.mu.Unlock()
+		return nil
 	}
+	perQueue := max(s.WorkerCount/len(queues), 1)
+	for i, queue := range queues {
+		cpus := groupCPUs(groups, i)
+		for j := 0; j < perQueue; j++ {
+			s.udpWorkers.Add(1)
+			go func() {
+				defer s.udpWorkers.Done()
+				s.pinGoroutine(cpus)
+				s.serveUDPQueue(queue)
+			}()
+		}
+	}
+	s.mu.Unlock()
 
 	// 3. TCP Listener
 	tcpListener, errTCP := lc.Listen(ctx, "tcp", s.Addr)
-	if errTCP == nil {
-		go func() {
-			defer func() {
-				if errClose := tcpListener.Close(); errClose != nil {
-					s.Logger.Error("failed to close TCP listener", "error", errClose)
-				}
-			}()
-			for {
-				conn, errAccept := tcpListener.Accept()
-				if errAccept != nil {
-					continue
-				}
-				go s.handleTCPConnection(conn)
-			}
-		}()
+	if errTCP == nil && s.trackListener(tcpListener) {
+		go s.acceptLoop(tcpListener)
 	}
 
 	// 4. DoT Listener (Port 853)
 	if s.TLSConfig != nil {
-		host, _, _ := net.SplitHostPort(s.Addr)
-		dotAddr := net.JoinHostPort(host, "853")
-		dotListener, errDoT := tls.Listen("tcp", dotAddr, s.TLSConfig)
-		if errDoT == nil {
+		dotAddr := s.dotAddr()
+		tlsConfig := s.serveTLSConfig()
+		dotListener, errDoT := tls.Listen("tcp", dotAddr, tlsConfig)
+		if errDoT == nil && s.trackListener(dotListener) {
 			s.Logger.Info("DNS over TLS (DoT) starting", "addr", dotAddr)
-			go func() {
-				defer func() {
-					if errClose := dotListener.Close(); errClose != nil {
-						s.Logger.Error("failed to close DoT listener", "error", errClose)
-					}
-				}()
-				for {
-					conn, errAccept := dotListener.Accept()
-					if errAccept != nil {
-						continue
-					}
-					go s.handleTCPConnection(conn)
-				}
-			}()
+			go s.acceptLoop(dotListener)
 		}
 
 		// 5. DoH Listener
-		dohPort := os.Getenv("DOH_PORT")
-		if dohPort == "" {
-			dohPort = "443"
-		}
-		dohAddr := net.JoinHostPort(host, dohPort)
-		mux := http.NewServeMux()
-		mux.HandleFunc("/dns-query", s.handleDoH)
-		dohServer := &http.Server{
-			Addr:              dohAddr,
-			Handler:           mux,
-			TLSConfig:         s.TLSConfig,
-			ReadHeaderTimeout: 5 * time.Second,
-		}
+		dohAddr := s.dohAddr()
+		dohServer := s.newDoHServer(dohAddr, tlsConfig)
+		s.mu.Lock()
+		s.dohServers = append(s.dohServers, dohServer)
+		s.mu.Unlock()
 		s.Logger.Info("DNS over HTTPS (DoH) starting", "addr", dohAddr)
 		go func() {
-			if errDoH := dohServer.ListenAndServeTLS("", ""); errDoH != nil {
+			if errDoH := dohServer.ListenAndServeTLS("", ""); errDoH != nil && !errors.Is(errDoH, http.ErrServerClosed) {
 				s.Logger.Error("DoH server failed", "error", errDoH)
 			}
 		}()
 	}
 
-	select {}
+	// 6. Cleartext DoH Listener behind a TLS-terminating proxy
+	if s.DoHCleartextAddr != "" {
+		cleartext := s.newDoHServer(s.DoHCleartextAddr, nil)
+		s.mu.Lock()
+		s.dohServers = append(s.dohServers, cleartext)
+		s.mu.Unlock()
+		s.Logger.Info("cleartext DoH starting", "addr", s.DoHCleartextAddr)
+		go func() {
+			if errDoH := cleartext.ListenAndServe(); errDoH != nil && !errors.Is(errDoH, http.ErrServerClosed) {
+				s.Logger.Error("cleartext DoH server failed", "error", errDoH)
+			}
+		}()
+	}
+
+	<-s.stopped
+	return nil
+}
+
+// acceptLoop serves TCP or DoT connections until the listener is closed.
+func (s *Server) acceptLoop(l net.Listener) {
+	for {
+		conn, errAccept := l.Accept()
+		if errAccept != nil {
+			if s.draining.Load() || errors.Is(errAccept, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		go s.handleTCPConnection(conn)
+	}
 }
 
 func (s *Server) handleDoH(w http.ResponseWriter, r *http.Request) {
@@ -297,6 +543,10 @@ func (s *Server) handleDoH(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "missing dns parameter", http.StatusBadRequest)
 			return
 		}
+		if len(query) > maxDoHQueryLength {
+			http.Error(w, "dns parameter too long", http.StatusRequestURITooLong)
+			return
+		}
 		dnsMsg, errDoH = base64.RawURLEncoding.DecodeString(query)
 		if errDoH != nil {
 			// Try with padding if raw fails
@@ -311,8 +561,17 @@ func (s *Server) handleDoH(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "invalid request", http.StatusBadRequest)
 			return
 		}
-		dnsMsg, errDoH = io.ReadAll(r.Body)
+		if r.ContentLength > maxDoHMessageSize {
+			http.Error(w, "message too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		dnsMsg, errDoH = io.ReadAll(http.MaxBytesReader(w, r.Body, maxDoHMessageSize))
 		if errDoH != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(errDoH, &tooLarge) {
+				http.Error(w, "message too large", http.StatusRequestEntityTooLarge)
+				return
+			}
 			http.Error(w, "failed to read body", http.StatusBadRequest)
 			return
 		}
@@ -321,18 +580,50 @@ func (s *Server) handleDoH(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if errHandle := s.handlePacket(dnsMsg, r.RemoteAddr, func(resp []byte) error {
-		w.Header().Set("Content-Type", "application/dns-message")
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write(resp)
+	if s.DoHAltSvc != "" {
+		w.Header().Set("Alt-Svc", s.DoHAltSvc)
+	}
+
+	// A TLS-terminating proxy marks requests received as early data (RFC 8470); an
+	// update replayed from 0-RTT would be applied twice
+	if r.Header.Get("Early-Data") == "1" && isUpdate(dnsMsg) && !s.DoHPolicy.AllowEarlyUpdates {
+		http.Error(w, "dynamic update not allowed in early data", http.StatusTooEarly)
+		return
+	}
+
+	// Responses are cached by the query with its ID cleared, so clients that do not
+	// use ID 0 (RFC 8484, Section 4.1) still share entries
+	cacheable := r.Method == http.MethodGet && s.DoHCache != nil && len(dnsMsg) >= 12
+	var cacheKey string
+	if cacheable {
+		cacheKey = dohCacheKey(dnsMsg)
+		if cached, age, found := s.DoHCache.Lookup(cacheKey); found {
+			metrics.CacheOperations.WithLabelValues("doh", "hit").Inc()
+			copy(cached[:2], dnsMsg[:2])
+			writeDoHResponse(w, cached, age)
+			return
+		}
+		metrics.CacheOperations.WithLabelValues("doh", "miss").Inc()
+	}
+
+	if errHandle := s.handlePacket(dnsMsg, r.RemoteAddr, withPadding(s.DoHPolicy, dnsMsg, func(resp []byte) error {
+		if maxAge := writeDoHResponse(w, resp, 0); cacheable && maxAge > 0 && s.CacheAdmission.admit("doh", len(resp), -1) {
+			stored := make([]byte, len(resp))
+			copy(stored, resp)
+			s.DoHCache.Set(cacheKey, stored, time.Duration(maxAge)*time.Second)
+		}
 		return nil
-	}, "doh"); errHandle != nil {
+	}), "doh"); errHandle != nil {
 		http.Error(w, "server error", http.StatusInternalServerError)
 	}
 }
 
 func (s *Server) udpWorker() {
-	for task := range s.udpQueue {
+	s.serveUDPQueue(s.udpQueue)
+}
+
+func (s *Server) serveUDPQueue(queue <-chan udpTask) {
+	for task := range queue {
 		metrics.ActiveWorkers.Inc()
 		s.handleUDPConnection(task.conn, task.addr, task.data)
 		metrics.ActiveWorkers.Dec()
@@ -350,11 +641,21 @@ func (s *Server) handleUDPConnection(pc net.PacketConn, addr net.Addr, data []by
 
 func (s *Server) handleTCPConnection(conn net.Conn) {
 	defer func() { _ = conn.Close() }()
+	_, isTLS := conn.(*tls.Conn)
+	if !s.conns.add(conn, isTLS) {
+		return
+	}
+	defer s.conns.remove(conn)
+	protocol := "tcp"
+	if isTLS {
+		protocol = "dot"
+	}
 	for {
 		lenBuf := make([]byte, 2)
 		if _, errRead := io.ReadFull(conn, lenBuf); errRead != nil {
 			return
 		}
+		s.conns.busy(conn)
 		packetLen := uint16(lenBuf[0])<<8 | uint16(lenBuf[1])
 		data := make([]byte, packetLen)
 		if _, errRead := io.ReadFull(conn, data); errRead != nil {
@@ -365,36 +666,56 @@ func (s *Server) handleTCPConnection(conn net.Conn) {
 		reqBuffer := packet.GetBuffer()
 		reqBuffer.Load(data)
 		request := packet.NewDNSPacket()
-		if errFromBuf := request.FromBuffer(reqBuffer); errFromBuf == nil && len(request.Questions) > 0 {
-			if request.Questions[0].QType == packet.AXFR {
-				s.handleAXFR(conn, request)
-				packet.PutBuffer(reqBuffer)
-				continue
+		if errFromBuf := request.FromBuffer(reqBuffer); errFromBuf == nil && len(request.Questions) > 0 &&
+			(request.Questions[0].QType == packet.AXFR || request.Questions[0].QType == packet.IXFR) {
+			// A signed transfer request gets every message of the transfer signed
+			xfrConn := conn
+			if request.TSIGStart != -1 {
+				send, ok := s.tsigResponder(request, data, tcpSend(conn))
+				if !ok {
+					_ = sendNotAuth(request, send)
+					packet.PutBuffer(reqBuffer)
+					if !s.conns.idle(conn) {
+						return
+					}
+					continue
+				}
+				xfrConn = &tsigConn{Conn: conn, send: send}
 			}
+			handle := s.handleAXFR
 			if request.Questions[0].QType == packet.IXFR {
-				s.handleIXFR(conn, request)
-				packet.PutBuffer(reqBuffer)
-				continue
+				handle = s.handleIXFR
+			}
+			s.countTransfer(xfrConn, request, handle)
+			packet.PutBuffer(reqBuffer)
+			if !s.conns.idle(conn) {
+				return
 			}
+			continue
 		}
 		packet.PutBuffer(reqBuffer)
 
-		if errHandle := s.handlePacket(data, conn.RemoteAddr(), func(resp []byte) error {
+		sendFn := func(resp []byte) error {
 			resLen := uint16(len(resp)) // #nosec G115
 			fullResp := append([]byte{byte(resLen >> 8), byte(resLen & 0xFF)}, resp...)
 			_, errWrite := conn.Write(fullResp)
 			return errWrite
-		}, "tcp"); errHandle != nil {
+		}
+		if isTLS {
+			sendFn = withPadding(s.DoTPolicy, data, sendFn)
+		}
+		if errHandle := s.handlePacket(data, conn.RemoteAddr(), sendFn, protocol); errHandle != nil {
 			s.Logger.Error("Failed to handle TCP packet", "error", errHandle)
 		}
+		if !s.conns.idle(conn) {
+			return
+		}
 	}
 }
 
 func (s *Server) handleAXFR(conn net.Conn, request *packet.DNSPacket) {
 	q := request.Questions[0]
-	if !strings.HasSuffix(q.Name, ".") {
-		q.Name += "."
-	}
+	q.Name = dnsname.Fqdn(q.Name)
 
 	ctx := context.Background()
 	zone, _ := s.Repo.GetZone(ctx, q.Name)
@@ -410,6 +731,7 @@ func (s *Server) handleAXFR(conn net.Conn, request *packet.DNSPacket) {
 		s.sendTCPError(conn, request.Header.ID, 2) // SERVFAIL
 		return
 	}
+	records = s.expandTargets(selectView(records, s.transferViews(conn)))
 
 	var soa *domain.Record
 	for _, rec := range records {
@@ -433,6 +755,9 @@ func (s *Server) handleAXFR(conn net.Conn, request *packet.DNSPacket) {
 		}
 	}
 
+	// Canonical order keeps transfers deterministic across nodes and runs
+	canonical.SortRecords(otherRecords)
+
 	// Stream packets: SOA -> [all other records] -> SOA
 	stream := make([]domain.Record, 0, len(otherRecords)+2)
 	stream = append(stream, *soa)
@@ -441,40 +766,28 @@ func (s *Server) handleAXFR(conn net.Conn, request *packet.DNSPacket) {
 
 	s.Logger.Info("AXFR starting", "zone", zone.Name, "records", len(stream))
 
-	for i, rec := range stream {
+	xfr := newXFRWriter(conn, request.Header.ID, q)
+	defer xfr.close()
+	for _, rec := range stream {
 		pRec, errConv := repository.ConvertDomainToPacketRecord(rec)
 		if errConv != nil {
 			s.Logger.Error("AXFR failed to convert record", "type", rec.Type, "error", errConv)
 			continue
 		}
-
-		response := packet.NewDNSPacket()
-		response.Header.ID = request.Header.ID
-		response.Header.Response = true
-		response.Header.AuthoritativeAnswer = true
-		response.Questions = append(response.Questions, q)
-		response.Answers = append(response.Answers, pRec)
-
-		resBuffer := packet.GetBuffer()
-		resBuffer.HasNames = true
-		if errWrite := response.Write(resBuffer); errWrite != nil {
-			s.Logger.Error("AXFR failed to write response", "error", errWrite)
-			packet.PutBuffer(resBuffer)
-			continue
-		}
-		resData := resBuffer.Buf[:resBuffer.Position()]
-
-		resLen := uint16(len(resData)) // #nosec G115
-		fullResp := append([]byte{byte(resLen >> 8), byte(resLen & 0xFF)}, resData...)
-		if _, errW := conn.Write(fullResp); errW != nil {
-			s.Logger.Error("AXFR connection broken", "error", errW)
-			packet.PutBuffer(resBuffer)
+		if errAdd := xfr.add(pRec); errAdd != nil {
+			if errors.Is(errAdd, errRecordTooLarge) {
+				s.Logger.Error("AXFR skipped record", "name", rec.Name, "type", rec.Type, "error", errAdd)
+				continue
+			}
+			s.Logger.Error("AXFR connection broken", "error", errAdd)
 			return
 		}
-		s.Logger.Debug("AXFR sent packet", "index", i, "type", pRec.Type)
-		packet.PutBuffer(resBuffer)
 	}
-	s.Logger.Info("AXFR completed", "zone", zone.Name)
+	if errFlush := xfr.flush(); errFlush != nil {
+		s.Logger.Error("AXFR connection broken", "error", errFlush)
+		return
+	}
+	s.Logger.Info("AXFR completed", "zone", zone.Name, "messages", xfr.messages)
 }
 
 func (s *Server) sendTCPError(conn net.Conn, id uint16, rcode uint8) {
@@ -492,6 +805,12 @@ func (s *Server) sendTCPError(conn net.Conn, id uint16, rcode uint8) {
 }
 
 func (s *Server) handlePacket(data []byte, srcAddr interface{}, sendFn func([]byte) error, protocol string) error {
+	return s.servePacket(data, srcAddr, sendFn, protocol, nil)
+}
+
+// servePacket answers one DNS message. A traced query records every step of its
+// resolution and stays out of the caches, rate limits and usage statistics.
+func (s *Server) servePacket(data []byte, srcAddr interface{}, sendFn func([]byte) error, protocol string, trace *queryTrace) error {
 	start := time.Now()
 	defer func() {
 		metrics.QueryDuration.WithLabelValues("total").Observe(time.Since(start).Seconds())
@@ -505,8 +824,13 @@ func (s *Server) handlePacket(data []byte, srcAddr interface{}, sendFn func([]by
 		clientIP, _, _ = net.SplitHostPort(addr.String())
 	}
 
-	if !s.limiter.Allow(clientIP) {
-		return nil
+	// With cookies the limiter waits for the parsed query, since clients that
+	// prove their address with a valid cookie are not limited
+	if s.Cookies == nil && trace == nil {
+		if !s.limiter.Allow(clientIP) {
+			return nil
+		}
+		s.queriesServed.Add(1)
 	}
 
 	reqBuffer := packet.GetBuffer()
@@ -524,6 +848,27 @@ func (s *Server) handlePacket(data []byte, srcAddr interface{}, sendFn func([]by
 	if len(request.Questions) > 0 {
 		qTypeLabel = request.Questions[0].QType.String()
 	}
+	if s.Clients != nil && request.Header.Opcode == packet.OpcodeQuery && trace == nil {
+		s.Clients.ObserveClient(clientIP, clientFingerprint(request, protocol))
+	}
+
+	var cookie queryCookie
+	if s.Cookies != nil && trace == nil {
+		cookie = s.Cookies.checkCookie(request, clientIP)
+		if !cookie.valid && !s.limiter.Allow(clientIP) {
+			if cookie.client == nil {
+				return nil
+			}
+			// A real client gets a cookie to retry with; a spoofed one never sees it
+			metrics.QueriesTotal.WithLabelValues(qTypeLabel, "23", protocol).Inc()
+			return s.sendCookieError(request, cookie, clientIP, sendFn)
+		}
+		s.queriesServed.Add(1)
+		if cookie.malformed {
+			metrics.QueriesTotal.WithLabelValues(qTypeLabel, "1", protocol).Inc()
+			return s.sendCookieError(request, cookie, clientIP, sendFn)
+		}
+	}
 
 	if request.Header.Opcode == packet.OpcodeUpdate {
 		err := s.handleUpdate(request, data, clientIP, sendFn)
@@ -536,11 +881,22 @@ func (s *Server) handlePacket(data []byte, srcAddr interface{}, sendFn func([]by
 	}
 
 	if request.Header.Opcode == packet.OpcodeNotify {
+		if request.TSIGStart != -1 {
+			var ok bool
+			if sendFn, ok = s.tsigResponder(request, data, sendFn); !ok {
+				metrics.QueriesTotal.WithLabelValues("NOTIFY", fmt.Sprintf("%d", packet.RcodeNotAuth), protocol).Inc()
+				return sendNotAuth(request, sendFn)
+			}
+		}
 		err := s.handleNotify(request, clientIP, sendFn)
 		metrics.QueriesTotal.WithLabelValues("NOTIFY", "0", protocol).Inc()
 		return err
 	}
 
+	if cookie.client != nil {
+		sendFn = s.cookieResponder(cookie, clientIP, sendFn)
+	}
+
 	if len(request.Questions) == 0 {
 		response := packet.NewDNSPacket()
 		response.Header.ID = request.Header.ID
@@ -554,40 +910,75 @@ func (s *Server) handlePacket(data []byte, srcAddr interface{}, sendFn func([]by
 	}
 
 	q := request.Questions[0]
-	// 1. Handle CHAOS class queries for node identity (NSID readiness)
+	// 1. Handle CHAOS class queries for node identity
 	if q.QClass == ClassCHAOS {
-		if strings.ToLower(q.Name) == "id.server." || strings.ToLower(q.Name) == "hostname.bind." {
-			response := packet.NewDNSPacket()
-			response.Header.ID = request.Header.ID
-			response.Header.Response = true
-			response.Header.AuthoritativeAnswer = true
-			response.Questions = append(response.Questions, q)
-
-			txtRec := packet.DNSRecord{
-				Name:  q.Name,
-				Type:  packet.TXT,
-				Class: ClassCHAOS,
-				TTL:   0,
-				Txt:   s.NodeID,
-			}
-			response.Answers = append(response.Answers, txtRec)
+		return s.handleChaos(request, q, sendFn, protocol)
+	}
 
-			metrics.QueriesTotal.WithLabelValues(qTypeLabel, "0", protocol).Inc()
-			resBuffer := packet.GetBuffer()
-			defer packet.PutBuffer(resBuffer)
-			_ = response.Write(resBuffer)
-			return sendFn(resBuffer.Buf[:resBuffer.Position()])
+	// Standardize name for lookup
+	q.Name = dnsname.Fqdn(q.Name)
+	cacheKey := fmt.Sprintf("%s:%d", dnsname.Normalize(q.Name), q.QType)
+
+	// RFC 7871: a resolver's client subnet replaces its own address for
+	// split-horizon matching, and answers tailored to it are cached per subnet
+	// on this node only
+	lookupIP := clientIP
+	subnetKeyed := false
+	if s.ClientSubnet {
+		if ecs := parseClientSubnet(request); ecs != nil {
+			if ecs.malformed {
+				metrics.QueriesTotal.WithLabelValues(qTypeLabel, "1", protocol).Inc()
+				return sendFormErr(request, sendFn)
+			}
+			if ecs.subnet != nil {
+				lookupIP = ecs.subnet.String()
+				cacheKey += "|" + lookupIP
+				subnetKeyed = true
+			}
+			ecsOpt, send := ecs.option(), sendFn
+			sendFn = func(resp []byte) error { return send(withEDNSOptions(resp, ecsOpt)) }
 		}
 	}
 
-	// Standardize name for lookup
-	if !strings.HasSuffix(q.Name, ".") {
-		q.Name += "."
+	// Without a client subnet the answer depends on the split-horizon view of
+	// the client's own address, and DNSSEC records are only sent with DO
+	view := ""
+	if !subnetKeyed {
+		view = s.clientView(clientIP)
+	}
+	variant := cacheVariant(view, requestsDNSSEC(request))
+	cacheKey += variant
+	trace.view(view)
+
+	if policy := s.queryTypePolicy(q.Name, q.QType, protocol, clientIP); policy != nil {
+		var refused bool
+		var err error
+		trace.policy(policy)
+		if sendFn, refused, err = s.applyQueryTypePolicy(policy, request, sendFn); refused {
+			metrics.QueriesTotal.WithLabelValues(qTypeLabel, fmt.Sprintf("%d", packet.RcodeRefused), protocol).Inc()
+			return err
+		}
+	}
+
+	if tenantID, scope, ok := s.allowQuery(q.Name, trace); !ok {
+		metrics.QueriesTotal.WithLabelValues(qTypeLabel, fmt.Sprintf("%d", packet.RcodeRefused), protocol).Inc()
+		return sendQueryLimited(request, tenantID, scope, sendFn)
+	}
+
+	// RFC 6147 5.5: a validating client sets CD and must get the real answer
+	var dns64 *DNS64View
+	if q.QType == packet.AAAA && !request.Header.CheckingDisabled {
+		dns64 = s.dns64View(clientIP)
+	}
+	search := s.searchView(q.Name, clientIP)
+	useCache := dns64 == nil && search == nil && !s.shadowTouches(q.Name)
+	if trace != nil {
+		s.traceCache(trace, cacheKey, useCache, dns64 != nil, search != nil)
+		useCache = false
 	}
-	cacheKey := fmt.Sprintf("%s:%d", strings.ToLower(q.Name), q.QType)
 
 	// L1/L2 Check
-	if cachedData, found := s.Cache.Get(cacheKey); found {
+	if cachedData, found := s.Cache.Get(cacheKey); found && useCache {
 		metrics.CacheOperations.WithLabelValues("l1", "hit").Inc()
 		metrics.QueriesTotal.WithLabelValues(qTypeLabel, "0", protocol).Inc()
 		metrics.QueryDuration.WithLabelValues("cache_l1").Observe(time.Since(start).Seconds())
@@ -596,12 +987,41 @@ func (s *Server) handlePacket(data []byte, srcAddr interface{}, sendFn func([]by
 			cachedData[0] = byte(request.Header.ID >> 8)
 			cachedData[1] = byte(request.Header.ID & 0xFF)
 		}
-		return sendFn(cachedData)
+		s.setRecursionAvailable(cachedData, clientIP)
+		s.recordCachedUsage(q.Name, cachedData)
+		s.observeQuery("", q.Name, clientIP)
+		s.recordLatency("", "", q.Name, start)
+		return sendFn(s.withNSID(request, cachedData))
+	}
+	if s.NegativeCache != nil && useCache {
+		if cachedData, kind, found := s.NegativeCache.Get(cacheKey); found {
+			metrics.NegativeCacheHits.WithLabelValues(kind).Inc()
+			metrics.QueriesTotal.WithLabelValues(qTypeLabel, fmt.Sprintf("%d", cachedData[3]&0x0F), protocol).Inc()
+			metrics.QueryDuration.WithLabelValues("cache_negative").Observe(time.Since(start).Seconds())
+			cachedData[0] = byte(request.Header.ID >> 8)
+			cachedData[1] = byte(request.Header.ID & 0xFF)
+			s.setRecursionAvailable(cachedData, clientIP)
+			s.recordCachedUsage(q.Name, cachedData)
+			s.observeQuery("", q.Name, clientIP)
+			s.recordLatency("", "", q.Name, start)
+			return sendFn(s.withNSID(request, cachedData))
+		}
 	}
 	metrics.CacheOperations.WithLabelValues("l1", "miss").Inc()
 
-	if s.Redis != nil {
-		if cachedData, found := s.Redis.Get(context.Background(), cacheKey); found {
+	if s.Redis != nil && useCache && !subnetKeyed {
+		// Clients usually ask for A and AAAA together, so both are fetched in one round trip
+		keys := []string{cacheKey}
+		if sibling, ok := siblingCacheKey(q.Name, q.QType); ok {
+			keys = append(keys, sibling+variant)
+		}
+		found := s.Redis.GetMany(context.Background(), keys...)
+		if len(found) > 1 && found[1] != nil {
+			if _, cached := s.Cache.Get(keys[1]); !cached {
+				s.storeL1(keys[1], found[1], l1TTLFromL2(found[1]))
+			}
+		}
+		if cachedData := found[0]; cachedData != nil {
 			metrics.CacheOperations.WithLabelValues("l2", "hit").Inc()
 			metrics.QueriesTotal.WithLabelValues(qTypeLabel, "0", protocol).Inc()
 			metrics.QueryDuration.WithLabelValues("cache_l2").Observe(time.Since(start).Seconds())
@@ -610,8 +1030,14 @@ func (s *Server) handlePacket(data []byte, srcAddr interface{}, sendFn func([]by
 				cachedData[0] = byte(request.Header.ID >> 8)
 				cachedData[1] = byte(request.Header.ID & 0xFF)
 			}
-			s.Cache.Set(cacheKey, cachedData, 60*time.Second)
-			return sendFn(cachedData)
+			s.setRecursionAvailable(cachedData, clientIP)
+			l1Data := make([]byte, len(cachedData))
+			copy(l1Data, cachedData)
+			s.storeL1(cacheKey, l1Data, l1TTLFromL2(cachedData))
+			s.recordCachedUsage(q.Name, cachedData)
+			s.observeQuery("", q.Name, clientIP)
+			s.recordLatency("", "", q.Name, start)
+			return sendFn(s.withNSID(request, cachedData))
 		}
 		metrics.CacheOperations.WithLabelValues("l2", "miss").Inc()
 	}
@@ -628,7 +1054,6 @@ func (s *Server) handlePacket(data []byte, srcAddr interface{}, sendFn func([]by
 	// EDNS(0) Support (RFC 6891)
 	maxSize := 512
 	dnssecOK := false
-	nsidRequested := false
 	var clientOPT *packet.DNSRecord
 	for _, res := range request.Resources {
 		if res.Type == packet.OPT {
@@ -639,14 +1064,6 @@ func (s *Server) handlePacket(data []byte, srcAddr interface{}, sendFn func([]by
 			}
 			// DO bit is the first bit of the Z field (TTL bits 15-0)
 			dnssecOK = (res.Z & 0x8000) != 0
-
-			// Check for NSID option (RFC 5001)
-			for _, opt := range res.Options {
-				if opt.Code == 3 { // NSID
-					nsidRequested = true
-					break
-				}
-			}
 			break
 		}
 	}
@@ -655,7 +1072,7 @@ func (s *Server) handlePacket(data []byte, srcAddr interface{}, sendFn func([]by
 	response.Header.ID = request.Header.ID
 	response.Header.Response = true
 	response.Header.AuthoritativeAnswer = true
-	response.Header.RecursionAvailable = s.RecursionEnabled
+	response.Header.RecursionAvailable = s.recursionAllowed(clientIP)
 	response.Questions = append(response.Questions, q)
 
 	// If query had EDNS, response MUST have EDNS
@@ -669,12 +1086,6 @@ func (s *Server) handlePacket(data []byte, srcAddr interface{}, sendFn func([]by
 		if dnssecOK {
 			opt.Z = 0x8000 // Set DO bit if client set it
 		}
-		if nsidRequested {
-			opt.Options = append(opt.Options, packet.EdnsOption{
-				Code: 3, // NSID
-				Data: []byte(s.NodeID),
-			})
-		}
 		response.Resources = append(response.Resources, opt)
 	}
 
@@ -683,8 +1094,11 @@ func (s *Server) handlePacket(data []byte, srcAddr interface{}, sendFn func([]by
 
 	// Guard against nil repository (useful for identity-only nodes or tests)
 	if s.Repo == nil {
+		trace.add("zone", "no zone data source is configured")
 		response.Header.ResCode = packet.RcodeServFail
+		addEDE(response, packet.EdeNoReachableAuthority, "no zone data source")
 		metrics.QueriesTotal.WithLabelValues(qTypeLabel, "2", protocol).Inc()
+		s.queriesFailed.Add(1)
 		resBuffer := packet.GetBuffer()
 		defer packet.PutBuffer(resBuffer)
 		_ = response.Write(resBuffer)
@@ -695,26 +1109,85 @@ func (s *Server) handlePacket(data []byte, srcAddr interface{}, sendFn func([]by
 	zoneName := q.Name
 	var zone *domain.Zone
 	for {
-		z, _ := s.Repo.GetZone(ctx, zoneName)
+		z, errZone := s.lookupZone(ctx, zoneName)
+		if errZone != nil {
+			// Database is unreachable: answer from stale cache or fail fast
+			trace.add("zone", "zone lookup failed (%v); answering from the stale cache or with SERVFAIL", errZone)
+			return s.sendDegraded(response, clientOPT != nil, cacheKey, qTypeLabel, protocol, sendFn)
+		}
 		if z != nil {
 			zone = z
 			break
 		}
-		idx := strings.Index(zoneName, ".")
-		if idx == -1 || idx == len(zoneName)-1 {
+		parent, ok := dnsname.Parent(zoneName)
+		if !ok || parent == dnsname.Root {
 			break
 		}
-		zoneName = zoneName[idx+1:]
+		zoneName = parent
+	}
+	trace.zone(zone)
+
+	// RFC 1034 4.3.5: an expired secondary zone is no longer authoritative
+	if zone != nil && zone.Role == "slave" && s.secondaries.isExpired(zone.ID) {
+		trace.add("zone", "secondary zone %s has expired and is no longer served", zone.Name)
+		response.Header.ResCode = packet.RcodeServFail
+		response.Header.AuthoritativeAnswer = false
+		addEDE(response, packet.EdeNoReachableAuthority, "zone expired")
+		metrics.QueriesTotal.WithLabelValues(qTypeLabel, "2", protocol).Inc()
+		s.queriesFailed.Add(1)
+		resBuffer := packet.GetBuffer()
+		defer packet.PutBuffer(resBuffer)
+		_ = response.Write(resBuffer)
+		return sendFn(resBuffer.Buf[:resBuffer.Position()])
 	}
 
 	// 2. Resolve Main Records
 	dbStart := time.Now()
 	qTypeStr := queryTypeToRecordType(q.QType)
-	records, errRepo := s.Repo.GetRecords(ctx, q.Name, qTypeStr, clientIP)
+	records, errRepo := s.lookupRecords(ctx, q.Name, qTypeStr, lookupIP)
 	metrics.QueryDuration.WithLabelValues("database").Observe(time.Since(dbStart).Seconds())
+	if errRepo != nil {
+		trace.add("records", "record lookup failed (%v); answering from the stale cache or with SERVFAIL", errRepo)
+		return s.sendDegraded(response, clientOPT != nil, cacheKey, qTypeLabel, protocol, sendFn)
+	}
+
+	trace.records("records", q.Name, qTypeStr, lookupIP, records)
+
+	// RFC 1034 3.6.2: a name with a CNAME answers with the chain, followed
+	// through our own zones to the records of the query type
+	var chain []domain.Record
+	if len(records) == 0 && zone != nil && q.QType != packet.CNAME && q.QType != packet.ANY {
+		var errChain error
+		chain, errChain = s.cnameChain(ctx, zone, q.Name, qTypeStr, lookupIP, dnssecOK && signsLocally(zone))
+		if errChain != nil {
+			trace.add("cname", "following the CNAME chain failed (%v); answering from the stale cache or with SERVFAIL", errChain)
+			return s.sendDegraded(response, clientOPT != nil, cacheKey, qTypeLabel, protocol, sendFn)
+		}
+	}
 
 	if errRepo == nil && len(records) > 0 {
 		for _, rec := range records {
+			if !subnetKeyed && (!viewIncludes(view, rec.Network) || !s.geoCached(rec)) {
+				useCache = false
+			}
+			pRec, errConv := repository.ConvertDomainToPacketRecord(rec)
+			if errConv == nil {
+				response.Answers = append(response.Answers, pRec)
+			}
+		}
+	} else if len(chain) > 0 {
+		trace.records("cname", q.Name, qTypeStr, lookupIP, chain)
+		source = "cname"
+		// The records after the first CNAME are other names', and a change to
+		// them drops only their own cache entries, so a followed chain is not
+		// cached under the queried name
+		if len(chain) > 1 {
+			useCache = false
+		}
+		for _, rec := range chain {
+			if !subnetKeyed && (!viewIncludes(view, rec.Network) || !s.geoCached(rec)) {
+				useCache = false
+			}
 			pRec, errConv := repository.ConvertDomainToPacketRecord(rec)
 			if errConv == nil {
 				response.Answers = append(response.Answers, pRec)
@@ -722,13 +1195,17 @@ func (s *Server) handlePacket(data []byte, srcAddr interface{}, sendFn func([]by
 		}
 	} else if zone != nil {
 		// Try wildcard matching if no direct records found
-		labels := strings.Split(strings.TrimSuffix(q.Name, "."), ".")
+		labels := dnsname.SplitLabels(q.Name)
 		for i := 0; i < len(labels)-1; i++ {
-			wildcardName := "*." + strings.Join(labels[i+1:], ".") + "."
-			wildcardRecords, errWildcard := s.Repo.GetRecords(ctx, wildcardName, qTypeStr, clientIP)
+			wildcardName := dnsname.Join(append([]string{"*"}, labels[i+1:]...))
+			wildcardRecords, errWildcard := s.lookupRecords(ctx, wildcardName, qTypeStr, lookupIP)
 			if errWildcard == nil && len(wildcardRecords) > 0 {
+				trace.records("wildcard", wildcardName, qTypeStr, lookupIP, wildcardRecords)
 				source = "wildcard"
 				for _, rec := range wildcardRecords {
+					if !subnetKeyed && (!viewIncludes(view, rec.Network) || !s.geoCached(rec)) {
+						useCache = false
+					}
 					rec.Name = q.Name // RFC: Rewrite wildcard to query name
 					pRec, errConv := repository.ConvertDomainToPacketRecord(rec)
 					if errConv == nil {
@@ -740,89 +1217,197 @@ func (s *Server) handlePacket(data []byte, srcAddr interface{}, sendFn func([]by
 		}
 	}
 
+	// An ALIAS record answers address queries with its target's addresses
+	if len(response.Answers) == 0 && zone != nil && (qTypeStr == domain.TypeA || qTypeStr == domain.TypeAAAA) {
+		aliased, errAlias := s.aliasAnswer(ctx, q.Name, qTypeStr, lookupIP)
+		if errAlias != nil {
+			trace.add("alias", "resolving the ALIAS target failed (%v); answering from the stale cache or with SERVFAIL", errAlias)
+			s.Logger.Warn("failed to resolve ALIAS target", "name", q.Name, "error", errAlias)
+			return s.sendDegraded(response, clientOPT != nil, cacheKey, qTypeLabel, protocol, sendFn)
+		}
+		if len(aliased) > 0 {
+			trace.records("alias", q.Name, qTypeStr, lookupIP, aliased)
+			source = "alias"
+			for _, rec := range aliased {
+				if !subnetKeyed && (!viewIncludes(view, rec.Network) || !s.geoCached(rec)) {
+					useCache = false
+				}
+				pRec, errConv := repository.ConvertDomainToPacketRecord(rec)
+				if errConv == nil {
+					response.Answers = append(response.Answers, pRec)
+				}
+			}
+		}
+	}
+
+	// Default records answer names of the zone that have no records at all
+	if len(response.Answers) == 0 && zone != nil {
+		if defaults := s.defaultAnswer(ctx, zone, q.Name, qTypeStr, lookupIP); len(defaults) > 0 {
+			trace.records("default", q.Name, qTypeStr, lookupIP, defaults)
+			source = "default"
+			for _, rec := range defaults {
+				pRec, errConv := repository.ConvertDomainToPacketRecord(rec)
+				if errConv == nil {
+					response.Answers = append(response.Answers, pRec)
+				}
+			}
+		}
+	}
+
+	// Shadow mode: compare with the staged answer, or serve it to canary clients
+	if shadowed, canary := s.applyShadow(zone, q.Name, qTypeStr, response.Answers, lookupIP); canary {
+		response.Answers, source = shadowed, "shadow"
+		trace.add("shadow", "client is a canary of the staged change and gets its %d records", len(shadowed))
+	}
+
+	// The apex DNSKEY RRset is built from the zone's keys, including other signers'
+	if len(response.Answers) == 0 && q.QType == packet.DNSKEY && s.DNSSEC != nil && zone != nil && signsLocally(zone) && dnsname.Equal(q.Name, zone.Name) {
+		if dnskeys, errKeys := s.DNSSEC.DNSKEYRRSet(ctx, zone); errKeys == nil {
+			response.Answers = append(response.Answers, dnskeys...)
+			trace.add("dnssec", "built the apex DNSKEY RRset from %d zone keys", len(dnskeys))
+		}
+	}
+
+	// DNS64: answer IPv6-only clients with AAAA records synthesized from A records
+	synthesized := false
+	if dns64 != nil && zone != nil {
+		response.Answers, synthesized = s.applyDNS64(ctx, dns64, q.Name, response.Answers, lookupIP)
+		if synthesized {
+			source = "dns64"
+			trace.add("dns64", "synthesized %d AAAA records from A records", len(response.Answers))
+		}
+	}
+
+	// Misconfigured stubs send bare hostnames; complete them within our own zones
+	if len(response.Answers) == 0 && search != nil {
+		if searchZone, answers := s.searchExpand(ctx, search, q.Name, qTypeStr, lookupIP); searchZone != nil {
+			zone, response.Answers, synthesized, source = searchZone, answers, true, "search"
+			trace.add("search", "completed the bare name within zone %s", searchZone.Name)
+		}
+	}
+
 	// 3. Handle NXDOMAIN / No Data
 	if len(response.Answers) == 0 {
 		if zone != nil {
-			response.Header.ResCode = 3 // NXDOMAIN
-			// RFC: Include SOA in Authority section for negative caching
-			soaRecords, _ := s.Repo.GetRecords(ctx, zone.Name, domain.TypeSOA, clientIP)
+			// RFC 2308 2.2: a name that exists without records of the queried
+			// type answers NODATA, which leaves its other types cacheable
+			exists, errExists := s.Repo.NameExists(ctx, zone.ID, q.Name)
+			if errExists != nil {
+				trace.add("negative", "checking whether the name exists failed (%v); answering from the stale cache or with SERVFAIL", errExists)
+				return s.sendDegraded(response, clientOPT != nil, cacheKey, qTypeLabel, protocol, sendFn)
+			}
+			if exists {
+				trace.add("negative", "the name exists in zone %s without records of the queried type; NODATA with the zone's SOA", zone.Name)
+			} else {
+				trace.add("negative", "no records answer the query in zone %s; NXDOMAIN with the zone's SOA", zone.Name)
+				response.Header.ResCode = 3 // NXDOMAIN
+			}
+			// RFC 2308 3: the SOA in the authority section bounds negative
+			// caching, with its TTL lowered to its MINIMUM
+			soaRecords, _ := s.lookupRecords(ctx, zone.Name, domain.TypeSOA, lookupIP)
 			for _, rec := range soaRecords {
 				pRec, errConv := repository.ConvertDomainToPacketRecord(rec)
 				if errConv == nil {
+					pRec.TTL = min(pRec.TTL, pRec.Minimum)
 					response.Authorities = append(response.Authorities, pRec)
 				}
 			}
 
 			// DNSSEC: If DO bit is set, include NSEC or NSEC3 record
-			if dnssecOK {
+			if dnssecOK && signsLocally(zone) {
 				// Check for NSEC3PARAM to decide between NSEC and NSEC3
-				nsec3params, _ := s.Repo.GetRecords(ctx, zone.Name, "NSEC3PARAM", "")
+				nsec3params, _ := s.lookupRecords(ctx, zone.Name, "NSEC3PARAM", "")
 				if len(nsec3params) > 0 {
 					nsec3, errNsec := s.generateNSEC3(ctx, zone, q.Name)
 					if errNsec == nil {
 						response.Authorities = append(response.Authorities, nsec3)
+						trace.add("dnssec", "added NSEC3 proof of non-existence")
 					}
 				} else {
 					nsec, errNsec := s.generateNSEC(ctx, zone, q.Name)
 					if errNsec == nil {
 						response.Authorities = append(response.Authorities, nsec)
+						trace.add("dnssec", "added NSEC proof of non-existence")
 					}
 				}
 			}
 		} else {
 			// Not authoritative for this zone - try recursive resolution if enabled
-			if s.RecursionEnabled && request.Header.RecursionDesired {
+			if s.recursionAllowed(clientIP) && request.Header.RecursionDesired {
 				s.Logger.Info("fallback to recursive resolution", "name", q.Name)
-				recursiveResp, errRecurse := s.resolveRecursive(q.Name)
+				// Resolved answers stay out of the authoritative caches, which
+				// serve clients that may not recurse
+				useCache = false
+				recursiveResp, errRecurse := s.resolveRecursive(q.Name, q.QType)
+				trace.recursion(recursiveResp, errRecurse)
 				if errRecurse == nil && recursiveResp != nil {
 					response.Header.AuthoritativeAnswer = false
 					response.Header.ResCode = recursiveResp.Header.ResCode
 					response.Answers = recursiveResp.Answers
 					response.Authorities = recursiveResp.Authorities
-					// Internal recursion doesn't set recursion available in the response usually,
-					// but our upstream root hints might. We already set RA in the header earlier.
 				} else {
 					s.Logger.Error("recursive resolution failed", "name", q.Name, "error", errRecurse)
 					response.Header.AuthoritativeAnswer = false
 					response.Header.ResCode = 2 // SERVFAIL
+					addEDE(response, recursionEDE(errRecurse), "")
 				}
 			} else {
+				// An authoritative-only server refuses names it has no zone for
+				// (RFC 1034 4.3.2); a negative answer would be cached by resolvers
 				response.Header.AuthoritativeAnswer = false
-				response.Header.ResCode = 3 // NXDOMAIN
-			}
-		}
-
-		// RFC 8914: Extended DNS Error (EDE)
-		if clientOPT != nil {
-			for i := range response.Resources {
-				if response.Resources[i].Type == packet.OPT {
-					response.Resources[i].AddEDE(packet.EdeOther, "")
+				response.Header.ResCode = packet.RcodeRefused
+				if s.OutOfZone == OutOfZoneNXDomain {
+					response.Header.ResCode = 3 // NXDOMAIN
+				}
+				trace.add("recursion", "not authoritative and recursion is not allowed for this client")
+				// RFC 8914: say why no answer was looked up
+				switch {
+				case s.recursionAllowed(clientIP):
+					addEDE(response, packet.EdeNotAuthoritative, "")
+				case s.RecursionEnabled:
+					addEDE(response, packet.EdeProhibited, "recursion not allowed")
+				default:
+					addEDE(response, packet.EdeProhibited, "recursion disabled")
 				}
 			}
 		}
 	} else if zone != nil {
 		// 4. Populate Authority Section (NS records)
-		nsRecords, _ := s.Repo.GetRecords(ctx, zone.Name, domain.TypeNS, clientIP)
+		nsRecords, _ := s.lookupRecords(ctx, zone.Name, domain.TypeNS, lookupIP)
+		trace.add("authority", "added %d NS records of zone %s with their glue", len(nsRecords), zone.Name)
 		for _, rec := range nsRecords {
 			pRec, errConv := repository.ConvertDomainToPacketRecord(rec)
 			if errConv == nil {
 				response.Authorities = append(response.Authorities, pRec)
 
 				// 5. Populate Additional Section (Glue records)
-				glueRecords, _ := s.Repo.GetRecords(ctx, pRec.Host, domain.TypeA, clientIP)
-				for _, gRec := range glueRecords {
-					gpRec, errGlue := repository.ConvertDomainToPacketRecord(gRec)
-					if errGlue == nil {
-						response.Resources = append(response.Resources, gpRec)
-					}
-				}
+				response.Resources = append(response.Resources, s.glueRecords(ctx, pRec.Host, lookupIP)...)
 			}
 		}
 	}
 
+	// RFC 2181 5.2: all records of an RRset share one TTL
+	packet.HarmonizeTTLs(response.Answers)
+	packet.HarmonizeTTLs(response.Authorities)
+	packet.HarmonizeTTLs(response.Resources)
+
 	// Dynamic RRSIG generation if DO bit is set
-	if dnssecOK && zone != nil {
-		s.signResponse(ctx, zone, response)
+	// Synthesized records are not the zone's data and are left unsigned (RFC 6147 5.5)
+	trace.signing(dnssecOK, zone, synthesized)
+	if dnssecOK && zone != nil && signsLocally(zone) && !synthesized {
+		if errSign := s.signResponse(ctx, zone, response); errSign != nil && !errors.Is(errSign, services.ErrNoActiveKey) {
+			trace.add("dnssec", "signing failed (%v); SERVFAIL", errSign)
+			// Unsigned data from a signed zone fails validation downstream; say so instead
+			s.Logger.Error("failed to sign response", "zone", zone.Name, "error", errSign)
+			response.Header.ResCode = packet.RcodeServFail
+			response.Answers = nil
+			response.Authorities = nil
+			response.Resources = response.Resources[:0]
+			if clientOPT != nil {
+				response.Resources = append(response.Resources, packet.DNSRecord{Name: ".", Type: packet.OPT, UDPPayloadSize: 4096, Z: 0x8000})
+			}
+			addEDE(response, packet.EdeDnssecBogus, "signing failed")
+		}
 	}
 
 	// Handle Truncation
@@ -842,6 +1427,7 @@ func (s *Server) handlePacket(data []byte, srcAddr interface{}, sendFn func([]by
 	_ = response.Write(resBuffer)
 
 	if resBuffer.Position() > maxSize {
+		trace.add("truncation", "the %d byte response exceeds the client's %d byte limit; truncated", resBuffer.Position(), maxSize)
 		response.Header.TruncatedMessage = true
 		response.Answers = nil
 		response.Authorities = nil
@@ -853,26 +1439,279 @@ func (s *Server) handlePacket(data []byte, srcAddr interface{}, sendFn func([]by
 
 	resData := resBuffer.Buf[:resBuffer.Position()]
 
-	// Cache the result
+	// Cache the result no longer than its shortest-lived record
 	var ttl uint32 = 300
-	if len(response.Answers) > 0 {
-		ttl = response.Answers[0].TTL
+	cacheable := true
+	if _, negative := negativeKind(resData); negative {
+		ttl, cacheable = negativeTTL(response.Authorities)
+	} else if len(response.Answers) > 0 {
+		ttl = minRecordTTL(response.Answers)
 	} else if len(response.Authorities) > 0 {
-		ttl = response.Authorities[0].TTL
+		ttl = minRecordTTL(response.Authorities)
 	}
 
-	if (response.Header.ResCode == 0 || response.Header.ResCode == 3) && !response.Header.TruncatedMessage {
+	if useCache && cacheable && (response.Header.ResCode == 0 || response.Header.ResCode == 3) && !response.Header.TruncatedMessage &&
+		s.CacheAdmission.admit("l1", len(resData), responseRecordCount(response)) {
 		cacheData := make([]byte, len(resData))
 		copy(cacheData, resData)
-		s.Cache.Set(cacheKey, cacheData, time.Duration(ttl)*time.Second)
-		if s.Redis != nil {
+		s.storeL1(cacheKey, cacheData, time.Duration(ttl)*time.Second)
+		if s.Redis != nil && !subnetKeyed {
 			s.Redis.Set(ctx, cacheKey, cacheData, time.Duration(ttl)*time.Second)
 		}
 	}
 
 	metrics.QueriesTotal.WithLabelValues(qTypeLabel, fmt.Sprintf("%d", response.Header.ResCode), protocol).Inc()
+	if response.Header.ResCode == packet.RcodeServFail {
+		s.queriesFailed.Add(1)
+	}
+	if zone != nil && trace == nil {
+		if s.Usage != nil {
+			s.Usage.RecordQuery(zone.ID, zone.Name, int(response.Header.ResCode))
+		}
+		s.observeQuery(zone.Name, q.Name, clientIP)
+		s.recordLatency(zone.ID, zone.Name, q.Name, start)
+		s.sampleQueryEvent(zone, q, response.Header.ResCode, protocol, clientIP)
+	}
 	s.Logger.Info("query processed", "name", q.Name, "src", source, "lat", time.Since(start).Milliseconds())
-	return sendFn(resData)
+	return sendFn(s.withNSID(request, resData))
+}
+
+// QueriesServed returns the number of packets accepted for processing since start.
+func (s *Server) QueriesServed() uint64 {
+	return s.queriesServed.Load()
+}
+
+// QueriesFailed returns the number of queries answered with SERVFAIL since start.
+func (s *Server) QueriesFailed() uint64 {
+	return s.queriesFailed.Load()
+}
+
+// SetSharedRateLimit enforces the per-client rate limits across the fleet with
+// token buckets kept in Redis, taking lease tokens at a time so that most
+// queries are decided locally. When Redis fails, each node falls back to its
+// own buckets. Safe to call while serving.
+func (s *Server) SetSharedRateLimit(r *RedisCache, lease int) {
+	s.limiter.setStore(r, lease)
+}
+
+// SetRateLimit sets the per-client token bucket: rate queries per second,
+// with bursts of up to burst queries. Safe to call while serving.
+func (s *Server) SetRateLimit(rate float64, burst int) {
+	s.limiter.setLimit(rate, burst)
+}
+
+// ServeQuery answers one UDP query as if it came from clientAddr ("ip:port"),
+// through the whole query path, rate limiter included. A nil response means
+// the query was dropped. Load generators use it to simulate traffic from
+// addresses they cannot send from, such as spoofed sources.
+func (s *Server) ServeQuery(data []byte, clientAddr string) ([]byte, error) {
+	var resp []byte
+	err := s.handlePacket(data, clientAddr, func(b []byte) error {
+		resp = append([]byte(nil), b...)
+		return nil
+	}, "udp")
+	return resp, err
+}
+
+// SetQueryEventSampleRate publishes one in every n answered queries to Events. A
+// non-positive n disables query events. Safe to call while serving.
+func (s *Server) SetQueryEventSampleRate(n int) {
+	s.queryEventRate.Store(int64(n))
+}
+
+// sampleQueryEvent publishes a sample of answered queries to the live event
+// stream, scoped to the tenant that owns the zone.
+func (s *Server) sampleQueryEvent(zone *domain.Zone, q packet.DNSQuestion, rcode uint8, protocol, clientIP string) {
+	rate := s.queryEventRate.Load()
+	if s.Events == nil || rate <= 0 {
+		return
+	}
+	if s.queryEventSeq.Add(1)%uint64(rate) != 0 { // #nosec G115
+		return
+	}
+	s.Events.Publish(domain.Event{
+		Type:       domain.EventQuery,
+		TenantID:   zone.TenantID,
+		ZoneID:     zone.ID,
+		Name:       q.Name,
+		RecordType: domain.RecordType(q.QType.String()),
+		RCode:      fmt.Sprintf("%d", rcode),
+		Protocol:   protocol,
+		ClientIP:   clientIP,
+		Timestamp:  time.Now(),
+	})
+}
+
+// recordCachedUsage attributes a cache hit to its zone; the rcode is read from the
+// cached response header.
+func (s *Server) recordCachedUsage(name string, cached []byte) {
+	if s.Usage == nil || len(cached) < 4 {
+		return
+	}
+	s.Usage.RecordCachedQuery(name, int(cached[3]&0x0F))
+}
+
+// observeQuery feeds an answered query to Analytics; cache hits pass no zone.
+func (s *Server) observeQuery(zoneName, name, clientIP string) {
+	if s.Analytics != nil {
+		s.Analytics.ObserveQuery(zoneName, name, clientIP)
+	}
+}
+
+// clientFingerprint describes the capabilities a query advertises in its OPT
+// record and by the transport it arrived on.
+func clientFingerprint(request *packet.DNSPacket, protocol string) domain.ClientFingerprint {
+	fp := domain.ClientFingerprint{Transport: protocol}
+	for _, res := range request.Resources {
+		if res.Type != packet.OPT {
+			continue
+		}
+		fp.EDNS = true
+		fp.UDPSize = res.UDPPayloadSize
+		fp.DNSSECOK = res.Z&0x8000 != 0
+		for _, opt := range res.Options {
+			switch opt.Code {
+			case ednsOptionCookie:
+				fp.Cookie = true
+			case ednsOptionClientSubnet:
+				fp.ClientSubnet = true
+			}
+		}
+		break
+	}
+	return fp
+}
+
+// recordLatency reports the time since start to Latency; cache hits pass no zone.
+func (s *Server) recordLatency(zoneID, zoneName, name string, start time.Time) {
+	if s.Latency != nil {
+		s.Latency.RecordLatency(zoneID, zoneName, name, time.Since(start))
+	}
+}
+
+// countingConn counts the bytes written to a connection.
+type countingConn struct {
+	net.Conn
+	written int
+}
+
+func (c *countingConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.written += n
+	return n, err
+}
+
+// countTransfer runs a zone transfer handler and records the bytes it sent.
+func (s *Server) countTransfer(conn net.Conn, request *packet.DNSPacket, handle func(net.Conn, *packet.DNSPacket)) {
+	if s.Usage == nil {
+		handle(conn, request)
+		return
+	}
+	cc := &countingConn{Conn: conn}
+	handle(cc, request)
+	s.Usage.RecordTransfer(request.Questions[0].Name, cc.written)
+}
+
+// l1TTLFromL2 keeps an L1 copy of an L2 response no longer than the records it
+// holds remain valid, and at most a minute.
+func l1TTLFromL2(data []byte) time.Duration {
+	l1TTL := 60 * time.Second
+	if minTTL, errAge := packet.AgeTTLs(data, 0); errAge == nil && time.Duration(minTTL)*time.Second < l1TTL {
+		l1TTL = time.Duration(minTTL) * time.Second
+	}
+	return l1TTL
+}
+
+// siblingCacheKey returns the cache key of the address query usually sent
+// alongside an A or AAAA query for the same name.
+func siblingCacheKey(name string, qType packet.QueryType) (string, bool) {
+	switch qType {
+	case packet.A:
+		return fmt.Sprintf("%s:%d", dnsname.Normalize(name), packet.AAAA), true
+	case packet.AAAA:
+		return fmt.Sprintf("%s:%d", dnsname.Normalize(name), packet.A), true
+	}
+	return "", false
+}
+
+// glueRecords returns the A and then the AAAA records of a name server host,
+// so resolvers on either address family can reach it. Both are read in one
+// query.
+func (s *Server) glueRecords(ctx context.Context, host, clientIP string) []packet.DNSRecord {
+	records, err := s.lookupRecords(ctx, host, "", clientIP)
+	if err != nil {
+		return nil
+	}
+	var v4, v6 []packet.DNSRecord
+	for _, rec := range records {
+		if rec.Type != domain.TypeA && rec.Type != domain.TypeAAAA {
+			continue
+		}
+		pRec, errConv := repository.ConvertDomainToPacketRecord(rec)
+		if errConv != nil {
+			continue
+		}
+		if rec.Type == domain.TypeA {
+			v4 = append(v4, pRec)
+		} else {
+			v6 = append(v6, pRec)
+		}
+	}
+	return append(v4, v6...)
+}
+
+// minRecordTTL returns the lowest TTL among the given records.
+func minRecordTTL(records []packet.DNSRecord) uint32 {
+	ttl := records[0].TTL
+	for _, r := range records[1:] {
+		if r.TTL < ttl {
+			ttl = r.TTL
+		}
+	}
+	return ttl
+}
+
+// sendDegraded answers a query the database could not, because it failed or its
+// circuit breaker is open. An expired cache entry is served with a short TTL if
+// it is still within its stale window (RFC 8767); otherwise the query fails
+// immediately with SERVFAIL instead of waiting on the database.
+func (s *Server) sendDegraded(response *packet.DNSPacket, edns bool, cacheKey, qTypeLabel, protocol string, sendFn func([]byte) error) error {
+	if staleData, found := s.Cache.GetStale(cacheKey); found && len(staleData) >= 2 {
+		metrics.CacheOperations.WithLabelValues("l1", "stale").Inc()
+		metrics.QueriesTotal.WithLabelValues(qTypeLabel, "0", protocol).Inc()
+		staleData[0] = byte(response.Header.ID >> 8)
+		staleData[1] = byte(response.Header.ID & 0xFF)
+		if edns {
+			staleData = withEDNSOptions(staleData, packet.NewEDEOption(packet.EdeStaleAnswer, ""))
+		}
+		return sendFn(staleData)
+	}
+	if s.NegativeCache != nil {
+		if staleData, kind, found := s.NegativeCache.GetStale(cacheKey); found {
+			metrics.CacheOperations.WithLabelValues("negative", "stale").Inc()
+			metrics.QueriesTotal.WithLabelValues(qTypeLabel, fmt.Sprintf("%d", staleData[3]&0x0F), protocol).Inc()
+			staleData[0] = byte(response.Header.ID >> 8)
+			staleData[1] = byte(response.Header.ID & 0xFF)
+			if edns {
+				code := packet.EdeStaleAnswer
+				if kind == NegativeNXDomain {
+					code = packet.EdeStaleNXDomainAnswer
+				}
+				staleData = withEDNSOptions(staleData, packet.NewEDEOption(code, ""))
+			}
+			return sendFn(staleData)
+		}
+	}
+
+	response.Header.ResCode = packet.RcodeServFail
+	response.Header.AuthoritativeAnswer = false
+	addEDE(response, packet.EdeNoReachableAuthority, "database unavailable")
+	metrics.QueriesTotal.WithLabelValues(qTypeLabel, "2", protocol).Inc()
+	s.queriesFailed.Add(1)
+	resBuffer := packet.GetBuffer()
+	defer packet.PutBuffer(resBuffer)
+	_ = response.Write(resBuffer)
+	return sendFn(resBuffer.Buf[:resBuffer.Position()])
 }
 
 func (s *Server) handleNotify(request *packet.DNSPacket, clientIP string, sendFn func([]byte) error) error {
@@ -914,17 +1753,10 @@ func (s *Server) handleUpdate(request *packet.DNSPacket, rawData []byte, clientI
 	response.Header.Response = true
 	response.Header.Opcode = packet.OpcodeUpdate
 
-	// 1. Validate TSIG if present
+	// 1. Validate TSIG if present; the response is then signed as well
 	if request.TSIGStart != -1 {
-		tsig := request.Resources[len(request.Resources)-1]
-		secret, ok := s.TsigKeys[tsig.Name]
-		if !ok {
-			s.Logger.Warn("update failed: unknown TSIG key", "key", tsig.Name)
-			response.Header.ResCode = packet.RcodeNotAuth
-			return s.sendUpdateResponse(response, sendFn)
-		}
-		if errVerify := request.VerifyTSIG(rawData, request.TSIGStart, secret); errVerify != nil {
-			s.Logger.Warn("update failed: TSIG verification failed", "error", errVerify)
+		var ok bool
+		if sendFn, ok = s.tsigResponder(request, rawData, sendFn); !ok {
 			response.Header.ResCode = packet.RcodeNotAuth
 			return s.sendUpdateResponse(response, sendFn)
 		}
@@ -938,9 +1770,7 @@ func (s *Server) handleUpdate(request *packet.DNSPacket, rawData []byte, clientI
 	}
 
 	zone := request.Questions[0]
-	if !strings.HasSuffix(zone.Name, ".") {
-		zone.Name += "."
-	}
+	zone.Name = dnsname.Fqdn(zone.Name)
 	response.Questions = append(response.Questions, zone)
 
 	ctx := context.Background()
@@ -965,6 +1795,26 @@ func (s *Server) handleUpdate(request *packet.DNSPacket, rawData []byte, clientI
 		}
 	}
 
+	// Change freezes apply to dynamic updates as they do to the API
+	if errFreeze := services.CheckChangeFreeze(ctx, s.Repo, dbZone.TenantID, dbZone.ID, "DNS UPDATE from "+clientIP); errFreeze != nil {
+		s.Logger.Warn("update refused", "zone", zone.Name, "error", errFreeze)
+		response.Header.ResCode = packet.RcodeServFail
+		if errors.Is(errFreeze, domain.ErrChangeFrozen) {
+			response.Header.ResCode = packet.RcodeRefused
+		}
+		return s.sendUpdateResponse(response, sendFn)
+	}
+
+	// Zones under review only change through approved change requests
+	if errReview := services.CheckChangeReview(ctx, s.Repo, dbZone.TenantID, dbZone.ID, "DNS UPDATE from "+clientIP); errReview != nil {
+		s.Logger.Warn("update refused", "zone", zone.Name, "error", errReview)
+		response.Header.ResCode = packet.RcodeServFail
+		if errors.Is(errReview, domain.ErrReviewRequired) {
+			response.Header.ResCode = packet.RcodeRefused
+		}
+		return s.sendUpdateResponse(response, sendFn)
+	}
+
 	// 3. Perform Updates (UPCOUNT)
 	var newSerial uint32
 	changes := make([]domain.ZoneChange, 0, len(request.Authorities))
@@ -1006,75 +1856,67 @@ func (s *Server) handleUpdate(request *packet.DNSPacket, rawData []byte, clientI
 		soaRecords, err := s.Repo.GetRecords(ctx, dbZone.Name, domain.TypeSOA, "")
 		if err == nil && len(soaRecords) > 0 {
 			oldSOA := soaRecords[0]
-			parts := strings.Fields(oldSOA.Content)
-			if len(parts) >= 3 {
-				var currentSerial uint32
-				if _, errParse := fmt.Sscanf(parts[2], "%d", &currentSerial); errParse == nil {
-					// Log Old SOA as DELETE using original values
-					changes = append([]domain.ZoneChange{{
-						ID:        fmt.Sprintf("%d-soa-old", time.Now().UnixNano()),
-						ZoneID:    dbZone.ID,
-						Action:    "DELETE",
-						Name:      oldSOA.Name,
-						Type:      domain.TypeSOA,
-						Content:   oldSOA.Content,
-						TTL:       oldSOA.TTL,
-						CreatedAt: time.Now(),
-					}}, changes...)
-
-					newSerial = currentSerial + 1
-					parts[2] = fmt.Sprintf("%d", newSerial)
-					newSOAContent := strings.Join(parts, " ")
-					updatedSOA := oldSOA
-					updatedSOA.Content = newSOAContent
-
-					// Delete old SOA and create new one
-					if errDel := s.Repo.DeleteRecord(ctx, oldSOA.ID, dbZone.ID, dbZone.TenantID); errDel == nil {
-						if errCreate := s.Repo.CreateRecord(ctx, &updatedSOA); errCreate == nil {
-							// Log New SOA as ADD
-							changes = append(changes, domain.ZoneChange{
-								ID:        fmt.Sprintf("%d-soa-new", time.Now().UnixNano()),
-								ZoneID:    dbZone.ID,
-								Action:    "ADD",
-								Name:      updatedSOA.Name,
-								Type:      domain.TypeSOA,
-								Content:   newSOAContent,
-								TTL:       updatedSOA.TTL,
-								CreatedAt: time.Now(),
-							})
-
-							// Persist all changes with the new serial
-							persistSuccess := true
-							for i := range changes {
-								changes[i].Serial = newSerial
-								if errRecord := s.Repo.RecordZoneChange(ctx, &changes[i]); errRecord != nil {
-									s.Logger.Error("failed to record zone change", "zone", dbZone.Name, "error", errRecord)
-									persistSuccess = false
-									break
-								}
-							}
-
-							if !persistSuccess {
-								response.Header.ResCode = packet.RcodeServFail
-								return s.sendUpdateResponse(response, sendFn)
+			if currentSerial, ok := serial.FromSOA(oldSOA.Content); ok {
+				// Log Old SOA as DELETE using original values
+				changes = append([]domain.ZoneChange{{
+					ID:        fmt.Sprintf("%d-soa-old", time.Now().UnixNano()),
+					ZoneID:    dbZone.ID,
+					Action:    "DELETE",
+					Name:      oldSOA.Name,
+					Type:      domain.TypeSOA,
+					Content:   oldSOA.Content,
+					TTL:       oldSOA.TTL,
+					CreatedAt: time.Now(),
+				}}, changes...)
+
+				newSerial = serial.Next(currentSerial)
+				newSOAContent := serial.SetSOA(oldSOA.Content, newSerial)
+				updatedSOA := oldSOA
+				updatedSOA.Content = newSOAContent
+
+				// Delete old SOA and create new one
+				if errDel := s.Repo.DeleteRecord(ctx, oldSOA.ID, dbZone.ID, dbZone.TenantID); errDel == nil {
+					if errCreate := s.Repo.CreateRecord(ctx, &updatedSOA); errCreate == nil {
+						// Log New SOA as ADD
+						changes = append(changes, domain.ZoneChange{
+							ID:        fmt.Sprintf("%d-soa-new", time.Now().UnixNano()),
+							ZoneID:    dbZone.ID,
+							Action:    "ADD",
+							Name:      updatedSOA.Name,
+							Type:      domain.TypeSOA,
+							Content:   newSOAContent,
+							TTL:       updatedSOA.TTL,
+							CreatedAt: time.Now(),
+						})
+
+						// Persist all changes with the new serial
+						persistSuccess := true
+						for i := range changes {
+							changes[i].Serial = newSerial
+							if errRecord := s.Repo.RecordZoneChange(ctx, &changes[i]); errRecord != nil {
+								s.Logger.Error("failed to record zone change", "zone", dbZone.Name, "error", errRecord)
+								persistSuccess = false
+								break
 							}
+						}
 
-							s.Logger.Info("dynamic update successful", "zone", zone.Name, "new_serial", newSerial)
-							s.Cache.Flush()
-							if !s.DisableAsync {
-								go s.notifySlaves(zone.Name)
-							}
-							response.Header.ResCode = packet.RcodeNoError
+						if !persistSuccess {
+							response.Header.ResCode = packet.RcodeServFail
 							return s.sendUpdateResponse(response, sendFn)
-						} else {
-							s.Logger.Error("failed to create new SOA during update", "zone", dbZone.Name, "error", errCreate)
 						}
+
+						s.Logger.Info("dynamic update successful", "zone", zone.Name, "new_serial", newSerial)
+						s.zoneUpdated(zone.Name, updateChanges(zone.Name, request.Authorities))
+						response.Header.ResCode = packet.RcodeNoError
+						return s.sendUpdateResponse(response, sendFn)
 					} else {
-						s.Logger.Error("failed to delete old SOA during update", "zone", dbZone.Name, "error", errDel)
+						s.Logger.Error("failed to create new SOA during update", "zone", dbZone.Name, "error", errCreate)
 					}
 				} else {
-					s.Logger.Error("failed to parse SOA serial during update", "zone", dbZone.Name, "error", errParse)
+					s.Logger.Error("failed to delete old SOA during update", "zone", dbZone.Name, "error", errDel)
 				}
+			} else {
+				s.Logger.Error("failed to parse SOA serial during update", "zone", dbZone.Name, "content", oldSOA.Content)
 			}
 			response.Header.ResCode = packet.RcodeServFail
 			return s.sendUpdateResponse(response, sendFn)
@@ -1088,20 +1930,14 @@ func (s *Server) handleUpdate(request *packet.DNSPacket, rawData []byte, clientI
 	// 5. Success (no changes)
 	response.Header.ResCode = packet.RcodeNoError
 	s.Logger.Info("dynamic update processed", "zone", zone.Name)
-	s.Cache.Flush()
-
-	if !s.DisableAsync {
-		go s.notifySlaves(zone.Name)
-	}
+	s.zoneUpdated(zone.Name, nil)
 
 	return s.sendUpdateResponse(response, sendFn)
 }
 
 func (s *Server) handleIXFR(conn net.Conn, request *packet.DNSPacket) {
 	q := request.Questions[0]
-	if !strings.HasSuffix(q.Name, ".") {
-		q.Name += "."
-	}
+	q.Name = dnsname.Fqdn(q.Name)
 
 	// RFC 1995: The client's current SOA is in the Authority section
 	if len(request.Authorities) == 0 || request.Authorities[0].Type != packet.SOA {
@@ -1128,22 +1964,15 @@ func (s *Server) handleIXFR(conn net.Conn, request *packet.DNSPacket) {
 		return
 	}
 	currentSOA := soaRecords[0]
-	fields := strings.Fields(currentSOA.Content)
-	if len(fields) < 3 {
+	currentSerial, ok := serial.FromSOA(currentSOA.Content)
+	if !ok {
 		s.Logger.Error("IXFR failed: malformed SOA content", "zone", zone.Name, "content", currentSOA.Content)
 		s.sendTCPError(conn, request.Header.ID, 2)
 		return
 	}
 
-	var currentSerial uint32
-	if _, err := fmt.Sscanf(fields[2], "%d", &currentSerial); err != nil {
-		s.Logger.Error("IXFR failed: invalid SOA serial", "zone", zone.Name, "serial", fields[2], "error", err)
-		s.sendTCPError(conn, request.Header.ID, 2)
-		return
-	}
-
-	if clientSerial == currentSerial {
-		// Client is up to date, just send current SOA
+	if !serial.Greater(currentSerial, clientSerial) {
+		// Client is up to date (or claims a newer serial), just send current SOA
 		s.Logger.Info("IXFR client is up to date", "zone", zone.Name, "serial", clientSerial)
 		pSOA, err := repository.ConvertDomainToPacketRecord(currentSOA)
 		if err == nil {
@@ -1156,10 +1985,10 @@ func (s *Server) handleIXFR(conn net.Conn, request *packet.DNSPacket) {
 	chunks, err := s.Repo.GetIXFRChain(ctx, zone.ID, clientSerial, currentSerial)
 
 	// RFC 1995: Verify full IXFR chain continuity
-	historyValid := len(chunks) > 0 && chunks[0].Serial == clientSerial+1
+	historyValid := len(chunks) > 0 && chunks[0].Serial == serial.Next(clientSerial)
 	if historyValid {
 		for i := 1; i < len(chunks); i++ {
-			if chunks[i].Serial != chunks[i-1].Serial+1 {
+			if chunks[i].Serial != serial.Next(chunks[i-1].Serial) {
 				historyValid = false
 				break
 			}
@@ -1178,6 +2007,7 @@ func (s *Server) handleIXFR(conn net.Conn, request *packet.DNSPacket) {
 			s.sendTCPError(conn, request.Header.ID, 2) // SERVFAIL
 			return
 		}
+		records = s.expandTargets(selectView(records, s.transferViews(conn)))
 
 		pSOA, errConv := repository.ConvertDomainToPacketRecord(currentSOA)
 		if errConv != nil {
@@ -1186,8 +2016,13 @@ func (s *Server) handleIXFR(conn net.Conn, request *packet.DNSPacket) {
 			return
 		}
 
-		// 2. Send Current SOA (start)
-		s.sendSingleRecordResponse(conn, request.Header.ID, q, pSOA)
+		// 2. Send Current SOA (start), packing records into as few messages as fit
+		xfr := newXFRWriter(conn, request.Header.ID, q)
+		defer xfr.close()
+		if errAdd := xfr.add(pSOA); errAdd != nil {
+			s.Logger.Error("IXFR/AXFR fallback connection broken", "error", errAdd)
+			return
+		}
 
 		// 3. Send all records in the zone
 		for _, rec := range records {
@@ -1195,13 +2030,23 @@ func (s *Server) handleIXFR(conn net.Conn, request *packet.DNSPacket) {
 				continue
 			} // skip SOA, we send it as bounds
 			pRec, errConv := repository.ConvertDomainToPacketRecord(rec)
-			if errConv == nil {
-				s.sendSingleRecordResponse(conn, request.Header.ID, q, pRec)
+			if errConv != nil {
+				continue
+			}
+			if errAdd := xfr.add(pRec); errAdd != nil && !errors.Is(errAdd, errRecordTooLarge) {
+				s.Logger.Error("IXFR/AXFR fallback connection broken", "error", errAdd)
+				return
 			}
 		}
 
 		// 4. Send Current SOA (end)
-		s.sendSingleRecordResponse(conn, request.Header.ID, q, pSOA)
+		errEnd := xfr.add(pSOA)
+		if errEnd == nil {
+			errEnd = xfr.flush()
+		}
+		if errEnd != nil {
+			s.Logger.Error("IXFR/AXFR fallback connection broken", "error", errEnd)
+		}
 		return
 	}
 
@@ -1232,11 +2077,7 @@ func (s *Server) handleIXFR(conn net.Conn, request *packet.DNSPacket) {
 			oldSOA = currentSOA
 			// We don't have the original old SOA in the chunk, fallback to current but with old serial
 			// (This shouldn't happen with our bounded delta logger)
-			parts := strings.Fields(oldSOA.Content)
-			if len(parts) >= 3 {
-				parts[2] = fmt.Sprintf("%d", clientSerial)
-				oldSOA.Content = strings.Join(parts, " ")
-			}
+			oldSOA.Content = serial.SetSOA(oldSOA.Content, clientSerial)
 		}
 		pOldSOA, err := repository.ConvertDomainToPacketRecord(oldSOA)
 		if err == nil {
@@ -1266,11 +2107,7 @@ func (s *Server) handleIXFR(conn net.Conn, request *packet.DNSPacket) {
 		}
 		if !foundNew {
 			newSOA = currentSOA
-			parts := strings.Fields(newSOA.Content)
-			if len(parts) >= 3 {
-				parts[2] = fmt.Sprintf("%d", chunk.Serial)
-				newSOA.Content = strings.Join(parts, " ")
-			}
+			newSOA.Content = serial.SetSOA(newSOA.Content, chunk.Serial)
 		}
 		pNewSOA, err := repository.ConvertDomainToPacketRecord(newSOA)
 		if err == nil {
@@ -1299,15 +2136,16 @@ func (s *Server) handleIXFR(conn net.Conn, request *packet.DNSPacket) {
 	s.Logger.Info("IXFR completed", "zone", zone.Name)
 }
 
-func (s *Server) signResponse(ctx context.Context, zone *domain.Zone, response *packet.DNSPacket) {
+func (s *Server) signResponse(ctx context.Context, zone *domain.Zone, response *packet.DNSPacket) error {
 	// Sign Answers
 	if len(response.Answers) > 0 {
 		groups := s.groupRecords(response.Answers)
 		for _, group := range groups {
 			sigs, errSign := s.DNSSEC.SignRRSet(ctx, zone.Name, zone.ID, group)
-			if errSign == nil {
-				response.Answers = append(response.Answers, sigs...)
+			if errSign != nil {
+				return errSign
 			}
+			response.Answers = append(response.Answers, sigs...)
 		}
 	}
 	// Sign Authorities
@@ -1315,11 +2153,13 @@ func (s *Server) signResponse(ctx context.Context, zone *domain.Zone, response *
 		groups := s.groupRecords(response.Authorities)
 		for _, group := range groups {
 			sigs, errSign := s.DNSSEC.SignRRSet(ctx, zone.Name, zone.ID, group)
-			if errSign == nil {
-				response.Authorities = append(response.Authorities, sigs...)
+			if errSign != nil {
+				return errSign
 			}
+			response.Authorities = append(response.Authorities, sigs...)
 		}
 	}
+	return nil
 }
 
 func (s *Server) groupRecords(records []packet.DNSRecord) [][]packet.DNSRecord {
@@ -1329,7 +2169,7 @@ func (s *Server) groupRecords(records []packet.DNSRecord) [][]packet.DNSRecord {
 		if r.Type == packet.RRSIG || r.Type == packet.OPT || r.Type == packet.TSIG {
 			continue
 		}
-		key := fmt.Sprintf("%s:%d", strings.ToLower(r.Name), r.Type)
+		key := fmt.Sprintf("%s:%d", dnsname.Normalize(r.Name), r.Type)
 		if _, ok := groups[key]; !ok {
 			keys = append(keys, key)
 		}
@@ -1419,10 +2259,7 @@ func (s *Server) checkPrerequisite(ctx context.Context, pr packet.DNSRecord) err
 //   - Default Class (IN): Adds or replaces a record.
 func (s *Server) applyUpdate(ctx context.Context, zone *domain.Zone, up packet.DNSRecord) error {
 	// Standardize name for database lookups to ensure consistency.
-	upName := up.Name
-	if !strings.HasSuffix(upName, ".") {
-		upName += "."
-	}
+	upName := dnsname.Fqdn(up.Name)
 
 	switch up.Class {
 	case 255: // ANY: Delete RRset (RFC 2136 Section 2.5.2)
@@ -1465,17 +2302,29 @@ func (s *Server) applyUpdate(ctx context.Context, zone *domain.Zone, up packet.D
 func (s *Server) notifySlaves(zoneName string) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
+	if s.ZoneChanges != nil {
+		if zone, errZone := s.Repo.GetZone(ctx, zoneName); errZone == nil && zone != nil {
+			s.ZoneChanges.ZoneChanged(zone.ID)
+		}
+	}
+	s.sendNotifies(ctx, zoneName, 0)
+}
 
+// sendNotifies sends a NOTIFY for the zone to every address of its name servers
+// except this node. With a positive ackTimeout it waits that long for each
+// secondary to acknowledge.
+func (s *Server) sendNotifies(ctx context.Context, zoneName string, ackTimeout time.Duration) []domain.NotifyTarget {
 	dbZone, errZone := s.Repo.GetZone(ctx, zoneName)
 	if errZone != nil || dbZone == nil {
-		return
+		return nil
 	}
 
 	nsRecords, errNS := s.Repo.GetRecords(ctx, zoneName, domain.TypeNS, "")
 	if errNS != nil {
-		return
+		return nil
 	}
 
+	targets := []domain.NotifyTarget{}
 	for _, ns := range nsRecords {
 		ips, errIPs := s.Repo.GetIPsForName(ctx, ns.Content, "")
 		if errIPs != nil || len(ips) == 0 {
@@ -1495,30 +2344,69 @@ func (s *Server) notifySlaves(zoneName string) {
 			}
 
 			s.Logger.Info("sending NOTIFY", "zone", zoneName, "slave", targetAddr)
+			target := domain.NotifyTarget{NameServer: ns.Content, Address: targetAddr, Status: "sent"}
+			if errSend := s.sendNotify(zoneName, targetAddr, ackTimeout); errSend != nil {
+				if errors.Is(errSend, os.ErrDeadlineExceeded) {
+					target.Error = "no acknowledgement"
+				} else {
+					target.Status = "failed"
+					target.Error = errSend.Error()
+				}
+			} else if ackTimeout > 0 {
+				target.Status = "acknowledged"
+			}
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
 
-			notify := packet.NewDNSPacket()
-			// Use crand for secure NOTIFY ID (G404)
-			var bid [2]byte
-			_, _ = crand.Read(bid[:])
-			notify.Header.ID = binary.LittleEndian.Uint16(bid[:])
+// sendNotify sends one NOTIFY over UDP and, with a positive ackTimeout, waits
+// for the matching response.
+func (s *Server) sendNotify(zoneName, targetAddr string, ackTimeout time.Duration) error {
+	notify := packet.NewDNSPacket()
+	// Use crand for secure NOTIFY ID (G404)
+	var bid [2]byte
+	_, _ = crand.Read(bid[:])
+	notify.Header.ID = binary.LittleEndian.Uint16(bid[:])
+
+	notify.Header.Opcode = packet.OpcodeNotify
+	notify.Header.AuthoritativeAnswer = true
+	notify.Questions = append(notify.Questions, packet.DNSQuestion{
+		Name:  zoneName,
+		QType: packet.SOA,
+	})
 
-			notify.Header.Opcode = packet.OpcodeNotify
-			notify.Header.AuthoritativeAnswer = true
-			notify.Questions = append(notify.Questions, packet.DNSQuestion{
-				Name:  zoneName,
-				QType: packet.SOA,
-			})
+	buf := packet.GetBuffer()
+	defer packet.PutBuffer(buf)
+	_ = notify.Write(buf)
+	data := buf.Buf[:buf.Position()]
 
-			buf := packet.GetBuffer()
-			_ = notify.Write(buf)
-			data := buf.Buf[:buf.Position()]
+	conn, errDial := net.Dial("udp", targetAddr)
+	if errDial != nil {
+		return errDial
+	}
+	defer func() { _ = conn.Close() }()
+	if _, errWrite := conn.Write(data); errWrite != nil {
+		return errWrite
+	}
+	if ackTimeout <= 0 {
+		return nil
+	}
 
-			conn, errDial := net.Dial("udp", targetAddr)
-			if errDial == nil {
-				_, _ = conn.Write(data)
-				_ = conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(ackTimeout))
+	ack := make([]byte, 512)
+	for {
+		n, errRead := conn.Read(ack)
+		if errRead != nil {
+			return errRead
+		}
+		// Ignore anything that is not the reply to this NOTIFY
+		if n >= 4 && binary.BigEndian.Uint16(ack[:2]) == notify.Header.ID && ack[2]&0x80 != 0 {
+			if rcode := ack[3] & 0x0F; rcode != packet.RcodeNoError {
+				return fmt.Errorf("NOTIFY answered with rcode %d", rcode)
 			}
-			packet.PutBuffer(buf)
+			return nil
 		}
 	}
 }
@@ -1529,17 +2417,21 @@ func (s *Server) generateNSEC(ctx context.Context, zone *domain.Zone, queryName
 		return packet.DNSRecord{}, errZoneRecs
 	}
 
-	master.SortRecordsCanonically(records)
+	canonical.SortRecords(records)
 
 	nameToTypes := make(map[string][]domain.RecordType)
 	var uniqueNames []string
-	seen := make(map[string]bool)
+	seen := make(map[string]string)
 	for _, r := range records {
-		if !seen[r.Name] {
-			uniqueNames = append(uniqueNames, r.Name)
-			seen[r.Name] = true
+		// Owner names differing only in case belong to the same NSEC node
+		key := dnsname.Normalize(r.Name)
+		owner, ok := seen[key]
+		if !ok {
+			owner = r.Name
+			uniqueNames = append(uniqueNames, owner)
+			seen[key] = owner
 		}
-		nameToTypes[r.Name] = append(nameToTypes[r.Name], r.Type)
+		nameToTypes[owner] = append(nameToTypes[owner], r.Type)
 	}
 
 	if len(uniqueNames) == 0 {
@@ -1549,7 +2441,7 @@ func (s *Server) generateNSEC(ctx context.Context, zone *domain.Zone, queryName
 	var ownerName, nextName string
 	found := false
 	for i := 0; i < len(uniqueNames); i++ {
-		cmp := master.CompareNamesCanonically(queryName, uniqueNames[i])
+		cmp := canonical.CompareNames(queryName, uniqueNames[i])
 		if cmp < 0 {
 			if i == 0 {
 				ownerName = uniqueNames[len(uniqueNames)-1]
@@ -1699,8 +2591,8 @@ type hashEntry struct {
 }
 
 func (s *Server) generateTypeBitMap(types []domain.RecordType) []byte {
-	bits := make([]byte, 32)
-	maxType := 0
+	// One 32-byte block per window of 256 types (RFC 4034 Section 4.1.2)
+	var windows [256][]byte
 	for _, t := range types {
 		qt := master.RecordTypeToQueryType(t)
 		if qt == 0 {
@@ -1711,21 +2603,30 @@ func (s *Server) generateTypeBitMap(types []domain.RecordType) []byte {
 				qt = 50
 			}
 		}
-		if qt == 0 || qt > 255 {
+		if qt == 0 {
 			continue
 		}
 
-		byteIdx := qt / 8
-		bitIdx := 7 - (qt % 8)
-		bits[byteIdx] |= (1 << bitIdx) // #nosec G602
-		if int(byteIdx) > maxType {
-			maxType = int(byteIdx)
+		window := qt >> 8
+		if windows[window] == nil {
+			windows[window] = make([]byte, 32)
 		}
+		low := qt & 0xFF
+		windows[window][low/8] |= 1 << (7 - low%8)
 	}
 
-	res := make([]byte, 0, 2+(maxType+1))
-	res = append(res, 0, byte(maxType+1))
-	res = append(res, bits[:maxType+1]...)
+	var res []byte
+	for window, bits := range windows {
+		if bits == nil {
+			continue
+		}
+		n := 32
+		for n > 0 && bits[n-1] == 0 {
+			n--
+		}
+		res = append(res, byte(window), byte(n))
+		res = append(res, bits[:n]...)
+	}
 	return res
 }
 
@@ -1747,6 +2648,24 @@ func queryTypeToRecordType(qType packet.QueryType) domain.RecordType {
 		return domain.TypeTXT
 	case packet.SRV:
 		return domain.TypeSRV
+	case packet.SVCB:
+		return domain.TypeSVCB
+	case packet.HTTPS:
+		return domain.TypeHTTPS
+	case packet.CAA:
+		return domain.TypeCAA
+	case packet.NAPTR:
+		return domain.TypeNAPTR
+	case packet.TLSA:
+		return domain.TypeTLSA
+	case packet.SSHFP:
+		return domain.TypeSSHFP
+	case packet.LOC:
+		return domain.TypeLOC
+	case packet.URI:
+		return domain.TypeURI
+	case packet.CERT:
+		return domain.TypeCERT
 	case packet.PTR:
 		return domain.TypePTR
 	case packet.DS:
@@ -1759,6 +2678,10 @@ func queryTypeToRecordType(qType packet.QueryType) domain.RecordType {
 		return domain.RecordType("NSEC")
 	case packet.NSEC3:
 		return domain.RecordType("NSEC3")
+	case packet.CDS:
+		return domain.RecordType("CDS")
+	case packet.CDNSKEY:
+		return domain.RecordType("CDNSKEY")
 	case packet.ANY:
 		return ""
 	default:
@@ -4,26 +4,44 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/poyrazK/cloudDNS/internal/adapters/repository"
 	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/core/services"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
 	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+	"github.com/poyrazK/cloudDNS/internal/dns/serial"
 )
 
 type mockServerRepo struct {
-	mu      sync.RWMutex
-	records []domain.Record
-	zones   []domain.Zone
-	changes []domain.ZoneChange
-	keys    []domain.DNSSECKey
-	apiKeys []domain.APIKey
-	pingErr error
+	mu       sync.RWMutex
+	records  []domain.Record
+	zones    []domain.Zone
+	changes  []domain.ZoneChange
+	keys     []domain.DNSSECKey
+	apiKeys  []domain.APIKey
+	shadows  []domain.ShadowChange
+	limits   []domain.QueryLimit
+	views    []domain.View
+	defaults []domain.DefaultRecord
+	targets  []domain.Target
+	checks   []domain.HealthCheck
+	reviewed map[string]bool
+	// refreshes holds the saved outcomes of secondary zone refreshes
+	refreshes map[string]domain.ZoneRefresh
+	pingErr   error
+	// zoneListings counts the calls listing a whole zone
+	zoneListings atomic.Int32
 }
 
 func (m *mockServerRepo) GetAPIKeyByHash(_ context.Context, keyHash string) (*domain.APIKey, error) {
@@ -111,6 +129,17 @@ func (m *mockServerRepo) GetZone(_ context.Context, name string) (*domain.Zone,
 	return nil, nil
 }
 
+func (m *mockServerRepo) GetZoneByID(_ context.Context, zoneID string, _ string) (*domain.Zone, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, z := range m.zones {
+		if z.ID == zoneID {
+			return &z, nil
+		}
+	}
+	return nil, nil
+}
+
 func (m *mockServerRepo) GetRecord(ctx context.Context, id string, zoneID string, tenantID string) (*domain.Record, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -122,7 +151,19 @@ func (m *mockServerRepo) GetRecord(ctx context.Context, id string, zoneID string
 	return nil, nil
 }
 
+func (m *mockServerRepo) NameExists(_ context.Context, zoneID string, name string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, r := range m.records {
+		if r.ZoneID == zoneID && dnsname.IsSubdomain(r.Name, name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func (m *mockServerRepo) ListRecordsForZone(ctx context.Context, zoneID string, tenantID string) ([]domain.Record, error) {
+	m.zoneListings.Add(1)
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 	var res []domain.Record
@@ -149,6 +190,18 @@ func (m *mockServerRepo) CreateZoneWithRecords(ctx context.Context, zone *domain
 	return nil
 }
 
+func (m *mockServerRepo) SetRecordProtected(_ context.Context, recordID, zoneID, _ string, protected bool) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, r := range m.records {
+		if r.ID == recordID && r.ZoneID == zoneID {
+			m.records[i].Protected = protected
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func (m *mockServerRepo) UpdateRecordHealth(ctx context.Context, recordID string, status domain.HealthStatus, errMsg string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -173,6 +226,337 @@ func (m *mockServerRepo) GetRecordsToProbe(ctx context.Context) ([]domain.Record
 	return res, nil
 }
 
+func (m *mockServerRepo) UpsertNode(_ context.Context, _ *domain.Node) error { return nil }
+
+func (m *mockServerRepo) ListNodes(_ context.Context) ([]domain.Node, error) {
+	return nil, nil
+}
+
+func (m *mockServerRepo) GetNodeConfig(_ context.Context, _ string) (map[string]string, error) {
+	return nil, nil
+}
+
+func (m *mockServerRepo) SetNodeConfig(_ context.Context, _ string, _ map[string]string) error {
+	return nil
+}
+
+func (m *mockServerRepo) SaveRollout(_ context.Context, _ *domain.Rollout) error { return nil }
+
+func (m *mockServerRepo) GetRollout(_ context.Context, _ string) (*domain.Rollout, error) {
+	return nil, nil
+}
+
+func (m *mockServerRepo) ListRollouts(_ context.Context) ([]domain.Rollout, error) {
+	return nil, nil
+}
+
+func (m *mockServerRepo) AddZoneUsage(_ context.Context, _ []domain.ZoneUsage) error { return nil }
+
+func (m *mockServerRepo) ListZoneUsage(_ context.Context, _, _ string, _, _ time.Time) ([]domain.ZoneUsage, error) {
+	return nil, nil
+}
+
+func (m *mockServerRepo) CreateSecondaryProvider(_ context.Context, _ *domain.SecondaryProvider) error {
+	return nil
+}
+
+func (m *mockServerRepo) ListSecondaryProviders(_ context.Context, _ string) ([]domain.SecondaryProvider, error) {
+	return nil, nil
+}
+
+func (m *mockServerRepo) DeleteSecondaryProvider(_ context.Context, _, _ string) error { return nil }
+
+func (m *mockServerRepo) SaveZoneRefresh(_ context.Context, refresh *domain.ZoneRefresh) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.refreshes == nil {
+		m.refreshes = make(map[string]domain.ZoneRefresh)
+	}
+	m.refreshes[refresh.ZoneID] = *refresh
+	return nil
+}
+
+func (m *mockServerRepo) GetZoneRefresh(_ context.Context, zoneID string) (*domain.ZoneRefresh, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	refresh, ok := m.refreshes[zoneID]
+	if !ok {
+		return nil, nil
+	}
+	return &refresh, nil
+}
+
+func (m *mockServerRepo) UpdateSecondaryProviderStatus(_ context.Context, _ *domain.SecondaryProvider) error {
+	return nil
+}
+
+func (m *mockServerRepo) CreateScheduledChange(_ context.Context, _ *domain.ScheduledChange) error {
+	return nil
+}
+
+func (m *mockServerRepo) ListScheduledChanges(_ context.Context, _ string) ([]domain.ScheduledChange, error) {
+	return nil, nil
+}
+
+func (m *mockServerRepo) CancelScheduledChange(_ context.Context, _, _ string) (bool, error) {
+	return false, nil
+}
+
+func (m *mockServerRepo) ClaimDueScheduledChanges(_ context.Context, _ time.Time, _ int) ([]domain.ScheduledChange, error) {
+	return nil, nil
+}
+
+func (m *mockServerRepo) FinishScheduledChange(_ context.Context, _ *domain.ScheduledChange) error {
+	return nil
+}
+
+func (m *mockServerRepo) CreateChangeFreeze(_ context.Context, _ *domain.ChangeFreeze) error {
+	return nil
+}
+
+func (m *mockServerRepo) ListChangeFreezes(_ context.Context, _ string) ([]domain.ChangeFreeze, error) {
+	return nil, nil
+}
+
+func (m *mockServerRepo) DeleteChangeFreeze(_ context.Context, _, _ string) (bool, error) {
+	return false, nil
+}
+
+func (m *mockServerRepo) GetActiveChangeFreeze(_ context.Context, _, _ string, _ time.Time) (*domain.ChangeFreeze, error) {
+	return nil, nil
+}
+
+func (m *mockServerRepo) SetZoneReview(_ context.Context, _, _ string, _ bool) error {
+	return nil
+}
+
+func (m *mockServerRepo) IsZoneReviewed(_ context.Context, zoneID string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.reviewed[zoneID], nil
+}
+
+func (m *mockServerRepo) CreateChangeRequest(_ context.Context, _ *domain.ChangeRequest) error {
+	return nil
+}
+
+func (m *mockServerRepo) ListChangeRequests(_ context.Context, _ string) ([]domain.ChangeRequest, error) {
+	return nil, nil
+}
+
+func (m *mockServerRepo) GetChangeRequest(_ context.Context, _, _ string) (*domain.ChangeRequest, error) {
+	return nil, nil
+}
+
+func (m *mockServerRepo) ReviewChangeRequest(_ context.Context, _ *domain.ChangeRequest) (bool, error) {
+	return false, nil
+}
+
+func (m *mockServerRepo) FinishChangeRequest(_ context.Context, _ *domain.ChangeRequest) error {
+	return nil
+}
+
+func (m *mockServerRepo) SaveShadowChange(_ context.Context, change *domain.ShadowChange) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.shadows = append(m.shadows, *change)
+	return nil
+}
+
+func (m *mockServerRepo) GetShadowChange(_ context.Context, zoneID string) (*domain.ShadowChange, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for i := range m.shadows {
+		if m.shadows[i].ZoneID == zoneID {
+			change := m.shadows[i]
+			return &change, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockServerRepo) ListRecordNetworks(_ context.Context) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	seen := make(map[string]bool)
+	var networks []string
+	for _, r := range m.records {
+		if r.Network != nil && !seen[*r.Network] {
+			seen[*r.Network] = true
+			networks = append(networks, *r.Network)
+		}
+	}
+	sort.Strings(networks)
+	return networks, nil
+}
+
+func (m *mockServerRepo) ListRecordGeoScopes(_ context.Context) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	seen := make(map[string]bool)
+	var scopes []string
+	for _, r := range m.records {
+		if r.Geo == nil {
+			continue
+		}
+		for _, scope := range r.Geo.Scopes() {
+			if !seen[scope] {
+				seen[scope] = true
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	sort.Strings(scopes)
+	return scopes, nil
+}
+
+func (m *mockServerRepo) ListShadowChanges(_ context.Context) ([]domain.ShadowChange, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]domain.ShadowChange(nil), m.shadows...), nil
+}
+
+func (m *mockServerRepo) DeleteShadowChange(_ context.Context, _ string) (bool, error) {
+	return false, nil
+}
+
+func (m *mockServerRepo) SaveZoneSLO(_ context.Context, _ *domain.ZoneSLO) error {
+	return nil
+}
+
+func (m *mockServerRepo) GetZoneSLO(_ context.Context, _ string) (*domain.ZoneSLO, error) {
+	return nil, nil
+}
+
+func (m *mockServerRepo) ListZoneSLOs(_ context.Context) ([]domain.ZoneSLO, error) {
+	return nil, nil
+}
+
+func (m *mockServerRepo) DeleteZoneSLO(_ context.Context, _ string) (bool, error) {
+	return false, nil
+}
+
+func (m *mockServerRepo) SaveQueryLimit(_ context.Context, limit *domain.QueryLimit) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.limits = append(m.limits, *limit)
+	return nil
+}
+
+func (m *mockServerRepo) ListQueryLimits(_ context.Context, _ string) ([]domain.QueryLimit, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]domain.QueryLimit(nil), m.limits...), nil
+}
+
+func (m *mockServerRepo) DeleteQueryLimit(_ context.Context, _, _ string) (bool, error) {
+	return false, nil
+}
+
+func (m *mockServerRepo) SaveView(_ context.Context, view *domain.View) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.views = append(m.views, *view)
+	return nil
+}
+
+func (m *mockServerRepo) ListViews(_ context.Context, _ string) ([]domain.View, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]domain.View(nil), m.views...), nil
+}
+
+func (m *mockServerRepo) DeleteView(_ context.Context, _, _ string) (bool, error) {
+	return false, nil
+}
+
+func (m *mockServerRepo) SaveDefaultRecord(_ context.Context, rec *domain.DefaultRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.defaults = append(m.defaults, *rec)
+	return nil
+}
+
+func (m *mockServerRepo) ListDefaultRecords(_ context.Context, _ string) ([]domain.DefaultRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]domain.DefaultRecord(nil), m.defaults...), nil
+}
+
+func (m *mockServerRepo) DeleteDefaultRecord(_ context.Context, _, _ string) (bool, error) {
+	return false, nil
+}
+
+func (m *mockServerRepo) SaveTarget(_ context.Context, target *domain.Target) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.targets = append(m.targets, *target)
+	return nil
+}
+
+func (m *mockServerRepo) ListTargets(_ context.Context, _ string) ([]domain.Target, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]domain.Target(nil), m.targets...), nil
+}
+
+func (m *mockServerRepo) DeleteTarget(_ context.Context, _, _ string) (bool, error) {
+	return false, nil
+}
+
+func (m *mockServerRepo) ListTargetRecords(_ context.Context, _ string) ([]domain.Record, error) {
+	return nil, nil
+}
+
+func (m *mockServerRepo) SaveHealthCheck(_ context.Context, check *domain.HealthCheck) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checks = append(m.checks, *check)
+	return nil
+}
+
+func (m *mockServerRepo) ListHealthChecks(_ context.Context, _ string) ([]domain.HealthCheck, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]domain.HealthCheck(nil), m.checks...), nil
+}
+
+func (m *mockServerRepo) DeleteHealthCheck(_ context.Context, _, _ string) (bool, error) {
+	return false, nil
+}
+
+func (m *mockServerRepo) UpdateHealthCheckStatus(_ context.Context, _ string, _ domain.HealthStatus, _ string) error {
+	return nil
+}
+
+func (m *mockServerRepo) ListHealthCheckRecords(_ context.Context, _ string) ([]domain.Record, error) {
+	return nil, nil
+}
+
+func (m *mockServerRepo) SaveSubnet(_ context.Context, _ *domain.Subnet) error {
+	return nil
+}
+
+func (m *mockServerRepo) ListSubnets(_ context.Context, _ string) ([]domain.Subnet, error) {
+	return nil, nil
+}
+
+func (m *mockServerRepo) DeleteSubnet(_ context.Context, _, _ string) (bool, error) {
+	return false, nil
+}
+
+func (m *mockServerRepo) SaveIPAllocation(_ context.Context, _ *domain.IPAllocation) error {
+	return nil
+}
+
+func (m *mockServerRepo) ListIPAllocations(_ context.Context, _ string) ([]domain.IPAllocation, error) {
+	return nil, nil
+}
+
+func (m *mockServerRepo) DeleteIPAllocation(_ context.Context, _, _ string) (bool, error) {
+	return false, nil
+}
+
 func (m *mockServerRepo) CreateRecord(ctx context.Context, record *domain.Record) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -198,6 +582,12 @@ func (m *mockServerRepo) BatchCreateRecords(ctx context.Context, records []domai
 	return nil
 }
 
+func (m *mockServerRepo) HasZones(_ context.Context) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.zones) > 0, nil
+}
+
 func (m *mockServerRepo) ListZones(ctx context.Context, tenantID string) ([]domain.Zone, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -235,6 +625,29 @@ func (m *mockServerRepo) DeleteZone(ctx context.Context, zoneID string, tenantID
 
 	return nil
 }
+func (m *mockServerRepo) SetZoneDNSSEC(ctx context.Context, zoneID string, tenantID string, enabled bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.zones {
+		if m.zones[i].ID == zoneID && m.zones[i].TenantID == tenantID {
+			m.zones[i].DNSSECEnabled = &enabled
+		}
+	}
+	return nil
+}
+
+func (m *mockServerRepo) SetZoneRole(_ context.Context, zoneID, tenantID, role, masterServer string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.zones {
+		if m.zones[i].ID == zoneID && m.zones[i].TenantID == tenantID {
+			m.zones[i].Role = role
+			m.zones[i].MasterServer = masterServer
+		}
+	}
+	return nil
+}
+
 func (m *mockServerRepo) DeleteRecord(ctx context.Context, recordID string, zoneID string, tenantID string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -314,6 +727,61 @@ func (m *mockServerRepo) DeleteRecordsForZone(ctx context.Context, zoneID string
 	return nil
 }
 
+func (m *mockServerRepo) ReplaceZoneRecords(_ context.Context, zoneID string, records []domain.Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var next []domain.Record
+	for _, r := range m.records {
+		if r.ZoneID == zoneID {
+			continue
+		}
+		next = append(next, r)
+	}
+	m.records = append(next, records...)
+	return nil
+}
+
+func (m *mockServerRepo) ApplyZoneDiff(_ context.Context, zoneID string, diffs []domain.IXFRChunk) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	records := append([]domain.Record(nil), m.records...)
+	for _, diff := range diffs {
+		for _, del := range diff.Deleted {
+			found := false
+			for i, r := range records {
+				if r.ZoneID == zoneID && r.Type == del.Type && (r.Type == domain.TypeSOA || strings.EqualFold(r.Name, del.Name) && r.Content == del.Content) {
+					records = append(records[:i], records[i+1:]...)
+					found = true
+					break
+				}
+			}
+			if !found && del.Type != domain.TypeSOA {
+				return domain.ErrNotFound
+			}
+		}
+		for _, add := range diff.Added {
+			add.ZoneID = zoneID
+			records = append(records, add)
+		}
+	}
+	m.records = records
+	return nil
+}
+
+func (m *mockServerRepo) ReplaceRRset(_ context.Context, zoneID string, name string, qType domain.RecordType, records []domain.Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var next []domain.Record
+	for _, r := range m.records {
+		if r.ZoneID == zoneID && strings.EqualFold(r.Name, name) && r.Type == qType {
+			continue
+		}
+		next = append(next, r)
+	}
+	m.records = append(next, records...)
+	return nil
+}
+
 func (m *mockServerRepo) RecordZoneChange(ctx context.Context, change *domain.ZoneChange) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -326,13 +794,26 @@ func (m *mockServerRepo) ListZoneChanges(ctx context.Context, zoneID string, fro
 	defer m.mu.RUnlock()
 	var res []domain.ZoneChange
 	for _, c := range m.changes {
-		if c.ZoneID == zoneID && c.Serial > fromSerial {
+		if c.ZoneID == zoneID && serial.Greater(c.Serial, fromSerial) {
 			res = append(res, c)
 		}
 	}
 	return res, nil
 }
 
+func (m *mockServerRepo) LastZoneChangeAt(_ context.Context, zoneID string) (*time.Time, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var last *time.Time
+	for i := range m.changes {
+		if c := &m.changes[i]; c.ZoneID == zoneID && (last == nil || c.CreatedAt.After(*last)) {
+			t := c.CreatedAt
+			last = &t
+		}
+	}
+	return last, nil
+}
+
 func (m *mockServerRepo) GetIXFRChain(ctx context.Context, zoneID string, fromSerial uint32, toSerial uint32) ([]domain.IXFRChunk, error) {
 	changes, err := m.ListZoneChanges(ctx, zoneID, fromSerial)
 	if err != nil {
@@ -343,7 +824,7 @@ func (m *mockServerRepo) GetIXFRChain(ctx context.Context, zoneID string, fromSe
 	var serials []uint32
 
 	for _, c := range changes {
-		if c.Serial > toSerial {
+		if serial.Greater(c.Serial, toSerial) {
 			continue
 		}
 		chunk, ok := chunksMap[c.Serial]
@@ -371,7 +852,7 @@ func (m *mockServerRepo) GetIXFRChain(ctx context.Context, zoneID string, fromSe
 	}
 
 	sort.Slice(serials, func(i, j int) bool {
-		return serials[i] < serials[j]
+		return serial.Less(serials[i], serials[j])
 	})
 
 	var result []domain.IXFRChunk
@@ -551,30 +1032,39 @@ func TestWorkerPoolProcessing(t *testing.T) {
 	}
 }
 
-func TestHandlePacketNXDOMAIN(t *testing.T) {
-	repo := &mockServerRepo{}
-	srv := NewServer("127.0.0.1:0", repo, nil)
-
-	req := packet.NewDNSPacket()
-	req.Questions = append(req.Questions, packet.DNSQuestion{Name: "missing.test.", QType: packet.A})
-	reqBuf := packet.NewBytePacketBuffer()
-	_ = req.Write(reqBuf)
-
-	var capturedResp []byte
-	if err := srv.handlePacket(reqBuf.Buf[:reqBuf.Position()], &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 12345}, func(resp []byte) error {
-		capturedResp = resp
-		return nil
-	}, "udp"); err != nil {
-		t.Fatalf("handlePacket failed: %v", err)
-	}
+func TestHandlePacketOutOfZone(t *testing.T) {
+	for _, tt := range []struct {
+		mode      string
+		wantRcode uint8
+	}{
+		{OutOfZoneRefused, packet.RcodeRefused},
+		{OutOfZoneNXDomain, packet.RcodeNxDomain},
+	} {
+		repo := &mockServerRepo{}
+		srv := NewServer("127.0.0.1:0", repo, nil)
+		srv.OutOfZone = tt.mode
+
+		req := packet.NewDNSPacket()
+		req.Questions = append(req.Questions, packet.DNSQuestion{Name: "missing.test.", QType: packet.A})
+		reqBuf := packet.NewBytePacketBuffer()
+		_ = req.Write(reqBuf)
+
+		var capturedResp []byte
+		if err := srv.handlePacket(reqBuf.Buf[:reqBuf.Position()], &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 12345}, func(resp []byte) error {
+			capturedResp = resp
+			return nil
+		}, "udp"); err != nil {
+			t.Fatalf("handlePacket failed: %v", err)
+		}
 
-	resPacket := packet.NewDNSPacket()
-	pBuf := packet.NewBytePacketBuffer()
-	pBuf.Load(capturedResp)
-	_ = resPacket.FromBuffer(pBuf)
+		resPacket := packet.NewDNSPacket()
+		pBuf := packet.NewBytePacketBuffer()
+		pBuf.Load(capturedResp)
+		_ = resPacket.FromBuffer(pBuf)
 
-	if resPacket.Header.ResCode != 3 {
-		t.Errorf("Expected NXDOMAIN (3), got %d", resPacket.Header.ResCode)
+		if resPacket.Header.ResCode != tt.wantRcode || resPacket.Header.AuthoritativeAnswer {
+			t.Errorf("%s: expected rcode %d without AA, got %d (AA %v)", tt.mode, tt.wantRcode, resPacket.Header.ResCode, resPacket.Header.AuthoritativeAnswer)
+		}
 	}
 }
 
@@ -714,7 +1204,7 @@ func TestSendTCPError(t *testing.T) {
 func TestServer_RunError(t *testing.T) {
 	// Privileged port should fail on non-root
 	srv := NewServer("127.0.0.1:1", nil, nil)
-	err := srv.Run()
+	err := srv.Run(context.Background())
 	if err == nil {
 		t.Errorf("Expected error when running on privileged port 1")
 	}
@@ -749,3 +1239,364 @@ func TestHealthCheck_PingError(t *testing.T) {
 		t.Errorf("Expected 'db down' error, got %v", checks)
 	}
 }
+
+func TestHandlePacketCircuitOpen(t *testing.T) {
+	repo := repository.NewCircuitBreakerRepository(&mockServerRepo{}, repository.CircuitBreakerConfig{FailureThreshold: 1, Cooldown: time.Hour}, nil)
+	repo.Breaker().Record(errors.New("connection refused"))
+	srv := NewServer("127.0.0.1:0", repo, nil)
+
+	// Expired entry must still be served while the database is unreachable
+	stale := packet.NewDNSPacket()
+	stale.Header.Response = true
+	stale.Questions = append(stale.Questions, packet.DNSQuestion{Name: "stale.test.", QType: packet.A})
+	stale.Answers = append(stale.Answers, packet.DNSRecord{Name: "stale.test.", Type: packet.A, IP: net.ParseIP("3.3.3.3"), TTL: 60, Class: 1})
+	buf := packet.NewBytePacketBuffer()
+	_ = stale.Write(buf)
+	srv.Cache.Set("stale.test.:1", buf.Buf[:buf.Position()], time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	query := func(name string) *packet.DNSPacket {
+		req := packet.NewDNSPacket()
+		req.Header.ID = 777
+		req.Questions = append(req.Questions, packet.DNSQuestion{Name: name, QType: packet.A})
+		reqBuf := packet.NewBytePacketBuffer()
+		_ = req.Write(reqBuf)
+
+		var captured []byte
+		if err := srv.handlePacket(reqBuf.Buf[:reqBuf.Position()], "127.0.0.1:5353", func(resp []byte) error {
+			captured = resp
+			return nil
+		}, "udp"); err != nil {
+			t.Fatalf("HandlePacket failed: %v", err)
+		}
+		resBuf := packet.NewBytePacketBuffer()
+		resBuf.Load(captured)
+		resp := packet.NewDNSPacket()
+		_ = resp.FromBuffer(resBuf)
+		return resp
+	}
+
+	resp := query("stale.test.")
+	if resp.Header.ID != 777 || len(resp.Answers) != 1 || resp.Answers[0].IP.String() != "3.3.3.3" {
+		t.Errorf("Expected stale answer with rewritten ID, got id=%d answers=%d", resp.Header.ID, len(resp.Answers))
+	}
+
+	resp = query("uncached.test.")
+	if resp.Header.ResCode != packet.RcodeServFail {
+		t.Errorf("Expected SERVFAIL while circuit is open, got %d", resp.Header.ResCode)
+	}
+}
+
+type recordingPublisher struct {
+	mu     sync.Mutex
+	events []domain.Event
+}
+
+func (p *recordingPublisher) Publish(e domain.Event) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, e)
+}
+
+func TestHandlePacketQueryEventSampling(t *testing.T) {
+	repo := &mockServerRepo{
+		zones: []domain.Zone{{ID: "z1", TenantID: "t1", Name: "sample.test."}},
+		records: []domain.Record{
+			{Name: "www.sample.test.", Type: domain.TypeA, Content: "1.1.1.1", TTL: 60},
+		},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+	pub := &recordingPublisher{}
+	srv.Events = pub
+	srv.SetQueryEventSampleRate(2)
+
+	for i := 0; i < 4; i++ {
+		srv.Cache.Flush()
+		req := packet.NewDNSPacket()
+		req.Header.ID = uint16(i)
+		req.Questions = append(req.Questions, packet.DNSQuestion{Name: "www.sample.test.", QType: packet.A})
+		buffer := packet.NewBytePacketBuffer()
+		_ = req.Write(buffer)
+		if err := srv.handlePacket(buffer.Buf[:buffer.Position()], "10.0.0.1:5353", func([]byte) error { return nil }, "udp"); err != nil {
+			t.Fatalf("HandlePacket failed: %v", err)
+		}
+	}
+
+	if len(pub.events) != 2 {
+		t.Fatalf("Expected 2 sampled query events, got %d", len(pub.events))
+	}
+	evt := pub.events[0]
+	if evt.Type != domain.EventQuery || evt.TenantID != "t1" || evt.ZoneID != "z1" || evt.RecordType != domain.TypeA || evt.ClientIP != "10.0.0.1" {
+		t.Errorf("Unexpected query event: %+v", evt)
+	}
+}
+
+type recordingUsage struct {
+	mu        sync.Mutex
+	queries   []string
+	cached    []string
+	transfers map[string]int
+}
+
+func (u *recordingUsage) RecordQuery(zoneID string, _ string, rcode int) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.queries = append(u.queries, fmt.Sprintf("%s:%d", zoneID, rcode))
+}
+
+func (u *recordingUsage) RecordCachedQuery(name string, rcode int) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.cached = append(u.cached, fmt.Sprintf("%s:%d", name, rcode))
+}
+
+func (u *recordingUsage) RecordTransfer(zoneName string, bytes int) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.transfers == nil {
+		u.transfers = make(map[string]int)
+	}
+	u.transfers[zoneName] += bytes
+}
+
+func TestHandlePacketRecordsUsage(t *testing.T) {
+	repo := &mockServerRepo{
+		zones: []domain.Zone{{ID: "z1", TenantID: "t1", Name: "usage.test."}},
+		records: []domain.Record{
+			{Name: "www.usage.test.", Type: domain.TypeA, Content: "1.1.1.1", TTL: 60},
+		},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+	usage := &recordingUsage{}
+	srv.Usage = usage
+
+	query := func(name string) {
+		req := packet.NewDNSPacket()
+		req.Questions = append(req.Questions, packet.DNSQuestion{Name: name, QType: packet.A})
+		buffer := packet.NewBytePacketBuffer()
+		_ = req.Write(buffer)
+		if err := srv.handlePacket(buffer.Buf[:buffer.Position()], "10.0.0.1:5353", func([]byte) error { return nil }, "udp"); err != nil {
+			t.Fatalf("HandlePacket failed: %v", err)
+		}
+	}
+	query("www.usage.test.")
+	query("www.usage.test.")
+	query("missing.usage.test.")
+	query("other.invalid.")
+
+	if len(usage.queries) != 2 || usage.queries[0] != "z1:0" || usage.queries[1] != "z1:3" {
+		t.Errorf("Expected an answered and an NXDOMAIN query for z1, got %v", usage.queries)
+	}
+	if len(usage.cached) != 1 || usage.cached[0] != "www.usage.test.:0" {
+		t.Errorf("Expected the repeated query counted as a cache hit, got %v", usage.cached)
+	}
+}
+
+type recordingAnalytics struct {
+	observed []string
+}
+
+func (r *recordingAnalytics) ObserveQuery(zoneName string, name string, clientIP string) {
+	r.observed = append(r.observed, zoneName+"|"+name+"|"+clientIP)
+}
+
+func TestHandlePacketObservesQueries(t *testing.T) {
+	repo := &mockServerRepo{
+		zones:   []domain.Zone{{ID: "z1", Name: "stats.test."}},
+		records: []domain.Record{{Name: "www.stats.test.", Type: domain.TypeA, Content: "1.1.1.1", TTL: 60}},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+	analytics := &recordingAnalytics{}
+	srv.Analytics = analytics
+
+	for i := 0; i < 2; i++ {
+		req := packet.NewDNSPacket()
+		req.Questions = append(req.Questions, packet.DNSQuestion{Name: "www.stats.test.", QType: packet.A})
+		buffer := packet.NewBytePacketBuffer()
+		_ = req.Write(buffer)
+		if err := srv.handlePacket(buffer.Buf[:buffer.Position()], "10.0.0.2:5353", func([]byte) error { return nil }, "udp"); err != nil {
+			t.Fatalf("HandlePacket failed: %v", err)
+		}
+	}
+
+	want := []string{"stats.test.|www.stats.test.|10.0.0.2", "|www.stats.test.|10.0.0.2"}
+	if fmt.Sprint(analytics.observed) != fmt.Sprint(want) {
+		t.Errorf("Expected an authoritative and a cached observation, got %v", analytics.observed)
+	}
+}
+
+type recordingLatency struct {
+	recorded []string
+}
+
+func (r *recordingLatency) RecordLatency(zoneID string, zoneName string, name string, d time.Duration) {
+	if d > 0 {
+		r.recorded = append(r.recorded, zoneID+"|"+zoneName+"|"+name)
+	}
+}
+
+func TestHandlePacketRecordsLatency(t *testing.T) {
+	repo := &mockServerRepo{
+		zones:   []domain.Zone{{ID: "z1", Name: "slo.test."}},
+		records: []domain.Record{{Name: "www.slo.test.", Type: domain.TypeA, Content: "1.1.1.1", TTL: 60}},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+	latency := &recordingLatency{}
+	srv.Latency = latency
+
+	for i := 0; i < 2; i++ {
+		req := packet.NewDNSPacket()
+		req.Questions = append(req.Questions, packet.DNSQuestion{Name: "www.slo.test.", QType: packet.A})
+		buffer := packet.NewBytePacketBuffer()
+		_ = req.Write(buffer)
+		if err := srv.handlePacket(buffer.Buf[:buffer.Position()], "10.0.0.3:5353", func([]byte) error { return nil }, "udp"); err != nil {
+			t.Fatalf("HandlePacket failed: %v", err)
+		}
+	}
+
+	want := []string{"z1|slo.test.|www.slo.test.", "||www.slo.test."}
+	if fmt.Sprint(latency.recorded) != fmt.Sprint(want) {
+		t.Errorf("Expected an authoritative and a cached latency, got %v", latency.recorded)
+	}
+}
+
+type recordingClients struct {
+	observed []domain.ClientFingerprint
+}
+
+func (r *recordingClients) ObserveClient(_ string, fp domain.ClientFingerprint) {
+	r.observed = append(r.observed, fp)
+}
+
+func TestHandlePacketObservesClients(t *testing.T) {
+	srv := NewServer("127.0.0.1:0", &mockServerRepo{}, nil)
+	clients := &recordingClients{}
+	srv.Clients = clients
+
+	send := func(req *packet.DNSPacket, protocol string) {
+		buffer := packet.NewBytePacketBuffer()
+		_ = req.Write(buffer)
+		_ = srv.handlePacket(buffer.Buf[:buffer.Position()], "10.0.0.4:5353", func([]byte) error { return nil }, protocol)
+	}
+	plain := packet.NewDNSPacket()
+	plain.Questions = append(plain.Questions, packet.DNSQuestion{Name: "www.fp.test.", QType: packet.A})
+	send(plain, "udp")
+
+	edns := packet.NewDNSPacket()
+	edns.Questions = append(edns.Questions, packet.DNSQuestion{Name: "www.fp.test.", QType: packet.A})
+	edns.Resources = append(edns.Resources, packet.DNSRecord{
+		Name: ".", Type: packet.OPT, UDPPayloadSize: 1232, Z: 0x8000,
+		Options: []packet.EdnsOption{{Code: ednsOptionCookie, Data: make([]byte, 8)}, {Code: ednsOptionClientSubnet, Data: []byte{0, 1, 24, 0, 192, 0, 2}}},
+	})
+	send(edns, "tcp")
+
+	notify := packet.NewDNSPacket()
+	notify.Header.Opcode = packet.OpcodeNotify
+	notify.Questions = append(notify.Questions, packet.DNSQuestion{Name: "fp.test.", QType: packet.SOA})
+	send(notify, "udp")
+
+	want := []domain.ClientFingerprint{
+		{Transport: "udp"},
+		{Transport: "tcp", EDNS: true, UDPSize: 1232, DNSSECOK: true, Cookie: true, ClientSubnet: true},
+	}
+	if fmt.Sprint(clients.observed) != fmt.Sprint(want) {
+		t.Errorf("Expected %v, got %v", want, clients.observed)
+	}
+}
+
+func TestCountTransfer(t *testing.T) {
+	srv := NewServer("127.0.0.1:0", &mockServerRepo{}, nil)
+	usage := &recordingUsage{}
+	srv.Usage = usage
+
+	client, peer := net.Pipe()
+	defer func() { _ = client.Close() }()
+	go func() { _, _ = io.Copy(io.Discard, client) }()
+
+	req := packet.NewDNSPacket()
+	req.Questions = append(req.Questions, packet.DNSQuestion{Name: "xfr.test.", QType: packet.AXFR})
+	srv.countTransfer(peer, req, func(conn net.Conn, _ *packet.DNSPacket) {
+		_, _ = conn.Write(make([]byte, 100))
+		_, _ = conn.Write(make([]byte, 23))
+	})
+	_ = peer.Close()
+
+	if usage.transfers["xfr.test."] != 123 {
+		t.Errorf("Expected 123 transfer bytes, got %v", usage.transfers)
+	}
+}
+
+func TestOptedOutZoneIsServedUnsigned(t *testing.T) {
+	disabled := false
+	repo := &mockServerRepo{
+		zones: []domain.Zone{{ID: "z1", Name: "insecure.test.", DNSSECEnabled: &disabled}},
+		records: []domain.Record{
+			{ZoneID: "z1", Name: "insecure.test.", Type: domain.TypeSOA, Content: "ns1.insecure.test. admin.insecure.test. 1 3600 600 86400 300", TTL: 300},
+			{ZoneID: "z1", Name: "www.insecure.test.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 300},
+		},
+		// A key that would fail to sign proves signing is skipped entirely
+		keys: []domain.DNSSECKey{{ZoneID: "z1", KeyType: "ZSK", Active: true, PrivateKey: []byte("corrupt")}},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+	srv.DNSSEC = services.NewDNSSECService(repo)
+
+	for _, name := range []string{"www.insecure.test.", "missing.insecure.test."} {
+		res := ednsQuery(t, srv, name, 1, false, true)
+		if res.Header.ResCode == packet.RcodeServFail {
+			t.Fatalf("Expected %s answered without signing, got SERVFAIL", name)
+		}
+		for _, rr := range append(res.Answers, res.Authorities...) {
+			if rr.Type == packet.RRSIG || rr.Type == packet.NSEC {
+				t.Errorf("Expected no DNSSEC records for %s, got %v", name, rr.Type)
+			}
+		}
+	}
+}
+
+func TestHandlePacketDualStackGlue(t *testing.T) {
+	repo := &mockServerRepo{
+		zones: []domain.Zone{{ID: "z1", Name: "glue.test."}},
+		records: []domain.Record{
+			{ZoneID: "z1", Name: "www.glue.test.", Type: domain.TypeA, Content: "192.0.2.10", TTL: 300},
+			{ZoneID: "z1", Name: "glue.test.", Type: domain.TypeNS, Content: "ns1.glue.test.", TTL: 3600},
+			{ZoneID: "z1", Name: "glue.test.", Type: domain.TypeNS, Content: "ns2.glue.test.", TTL: 3600},
+			{ZoneID: "z1", Name: "ns1.glue.test.", Type: domain.TypeAAAA, Content: "2001:db8::1", TTL: 3600},
+			{ZoneID: "z1", Name: "ns1.glue.test.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 3600},
+			{ZoneID: "z1", Name: "ns1.glue.test.", Type: domain.TypeTXT, Content: "not glue", TTL: 3600},
+			{ZoneID: "z1", Name: "ns2.glue.test.", Type: domain.TypeAAAA, Content: "2001:db8::2", TTL: 3600},
+		},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+
+	req := packet.NewDNSPacket()
+	req.Questions = append(req.Questions, packet.DNSQuestion{Name: "www.glue.test.", QType: packet.A})
+	buffer := packet.NewBytePacketBuffer()
+	_ = req.Write(buffer)
+
+	var captured []byte
+	if err := srv.handlePacket(buffer.Buf[:buffer.Position()], "10.0.0.6:5353", func(resp []byte) error {
+		captured = resp
+		return nil
+	}, "udp"); err != nil {
+		t.Fatalf("HandlePacket failed: %v", err)
+	}
+
+	resp := packet.NewDNSPacket()
+	pb := packet.NewBytePacketBuffer()
+	pb.Load(captured)
+	if err := resp.FromBuffer(pb); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	var glue []string
+	for _, rr := range resp.Resources {
+		glue = append(glue, rr.Name+" "+rr.Type.String()+" "+rr.IP.String())
+	}
+	want := []string{
+		"ns1.glue.test. A 192.0.2.1",
+		"ns1.glue.test. AAAA 2001:db8::1",
+		"ns2.glue.test. AAAA 2001:db8::2",
+	}
+	if fmt.Sprint(glue) != fmt.Sprint(want) {
+		t.Errorf("Expected dual-stack glue %v, got %v", want, glue)
+	}
+}
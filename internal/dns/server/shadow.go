@@ -0,0 +1,261 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/adapters/repository"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+)
+
+// shadowSyncInterval is how often staged shadow changes are reloaded.
+var shadowSyncInterval = 15 * time.Second
+
+// shadowLogLimit caps how many differing answers are logged per set of shadow
+// changes, so a large edit under heavy traffic cannot flood the log.
+const shadowLogLimit = 1000
+
+type shadowKey struct {
+	name  string
+	qType domain.RecordType
+}
+
+// shadowZone is the shadow change of one zone, indexed for lookups.
+type shadowZone struct {
+	rrsets map[shadowKey]domain.RRset
+	canary []*net.IPNet
+}
+
+// shadowSet holds the shadow changes staged for every zone. Each differing
+// name and type is logged once per set.
+type shadowSet struct {
+	version string
+	zones   map[string]*shadowZone
+	names   map[string]struct{}
+
+	mu     sync.Mutex
+	logged map[string]struct{}
+}
+
+func newShadowSet(changes []domain.ShadowChange) *shadowSet {
+	set := &shadowSet{
+		version: shadowVersion(changes),
+		zones:   make(map[string]*shadowZone, len(changes)),
+		names:   make(map[string]struct{}),
+		logged:  make(map[string]struct{}),
+	}
+	for _, change := range changes {
+		sz := &shadowZone{rrsets: make(map[shadowKey]domain.RRset, len(change.RRsets))}
+		for _, rrset := range change.RRsets {
+			name := dnsname.Normalize(rrset.Name)
+			sz.rrsets[shadowKey{name: name, qType: rrset.Type}] = rrset
+			set.names[name] = struct{}{}
+		}
+		for _, cidr := range change.CanaryNetworks {
+			if _, network, err := net.ParseCIDR(cidr); err == nil {
+				sz.canary = append(sz.canary, network)
+			}
+		}
+		set.zones[change.ZoneID] = sz
+	}
+	return set
+}
+
+// shadowVersion identifies a set of shadow changes by their zones and staging
+// times, which change whenever a change is restaged, committed or discarded.
+func shadowVersion(changes []domain.ShadowChange) string {
+	ids := make([]string, 0, len(changes))
+	for _, change := range changes {
+		ids = append(ids, fmt.Sprintf("%s@%d", change.ZoneID, change.CreatedAt.UnixNano()))
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, ",")
+}
+
+// firstDifference reports whether a differing answer for key has not been
+// logged yet, and records it.
+func (set *shadowSet) firstDifference(key string) bool {
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	if _, ok := set.logged[key]; ok || len(set.logged) >= shadowLogLimit {
+		return false
+	}
+	set.logged[key] = struct{}{}
+	return true
+}
+
+// isCanary reports whether ip is in one of the zone's canary networks.
+func (sz *shadowZone) isCanary(ip net.IP) bool {
+	for _, network := range sz.canary {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// scheduleShadows reloads the staged shadow changes until ctx is done.
+func (s *Server) scheduleShadows(ctx context.Context) {
+	if s.Repo == nil {
+		return
+	}
+	ticker := time.NewTicker(shadowSyncInterval)
+	defer ticker.Stop()
+	for {
+		s.syncShadows(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// syncShadows loads the staged shadow changes, keeping the current set (and
+// what it has logged) when nothing changed.
+func (s *Server) syncShadows(ctx context.Context) {
+	changes, err := s.Repo.ListShadowChanges(ctx)
+	if err != nil {
+		s.Logger.Error("failed to load shadow changes", "error", err)
+		return
+	}
+	if current := s.shadows.Load(); current != nil && current.version == shadowVersion(changes) {
+		return
+	}
+	if len(changes) == 0 {
+		s.shadows.Store(nil)
+		return
+	}
+	s.shadows.Store(newShadowSet(changes))
+}
+
+// ServeShadowZones makes the node serve the shadow changes staged for zoneIDs
+// to every client, as a zone rollout reaches it. The shadow changes of other
+// zones are only compared with live answers and served to canary clients.
+func (s *Server) ServeShadowZones(zoneIDs []string) {
+	served := make(map[string]struct{}, len(zoneIDs))
+	for _, id := range zoneIDs {
+		served[id] = struct{}{}
+	}
+	s.servedShadows.Store(&served)
+}
+
+// servesShadow reports whether a zone rollout has made the node serve the
+// zone's shadow change to every client.
+func (s *Server) servesShadow(zoneID string) bool {
+	served := s.servedShadows.Load()
+	if served == nil {
+		return false
+	}
+	_, ok := (*served)[zoneID]
+	return ok
+}
+
+// shadowTouches reports whether a shadow change stages records for name. Such
+// queries bypass the response caches so that every one is compared.
+func (s *Server) shadowTouches(name string) bool {
+	set := s.shadows.Load()
+	if set == nil {
+		return false
+	}
+	_, ok := set.names[dnsname.Normalize(name)]
+	return ok
+}
+
+// applyShadow compares the answers to a query with those the zone's shadow
+// change would give, logging the first difference for each name and type.
+// Clients in the change's canary networks, or every client once a zone rollout
+// has reached the node, get the shadow answers instead, which is reported by the
+// second result.
+func (s *Server) applyShadow(zone *domain.Zone, name string, qType domain.RecordType, answers []packet.DNSRecord, clientIP string) ([]packet.DNSRecord, bool) {
+	set := s.shadows.Load()
+	if set == nil || zone == nil {
+		return answers, false
+	}
+	sz, ok := set.zones[zone.ID]
+	if !ok {
+		return answers, false
+	}
+	rrset, ok := sz.rrsets[shadowKey{name: dnsname.Normalize(name), qType: qType}]
+	if !ok {
+		return answers, false
+	}
+
+	ip := net.ParseIP(clientIP)
+	proposed := shadowAnswers(rrset, name, ip)
+	if s.servesShadow(zone.ID) {
+		metrics.ShadowQueries.WithLabelValues("rollout").Inc()
+		return proposed, true
+	}
+	if ip != nil && sz.isCanary(ip) {
+		metrics.ShadowQueries.WithLabelValues("canary").Inc()
+		return proposed, true
+	}
+
+	live, shadow := answerKeys(answers, zone.ID), answerKeys(proposed, zone.ID)
+	if strings.Join(live, "\n") == strings.Join(shadow, "\n") {
+		metrics.ShadowQueries.WithLabelValues("match").Inc()
+		return answers, false
+	}
+	metrics.ShadowQueries.WithLabelValues("differ").Inc()
+	if set.firstDifference(zone.ID + " " + dnsname.Normalize(name) + " " + string(qType)) {
+		s.Logger.Info("shadow answer differs", "zone", zone.Name, "name", name, "type", qType, "live", live, "shadow", shadow)
+	}
+	return answers, false
+}
+
+// shadowAnswers builds the answer records of a staged RRset for a client,
+// leaving out split-horizon records of networks the client is not in.
+func shadowAnswers(rrset domain.RRset, name string, ip net.IP) []packet.DNSRecord {
+	answers := make([]packet.DNSRecord, 0, len(rrset.Records))
+	for _, rr := range rrset.Records {
+		if rr.Network != nil {
+			_, network, err := net.ParseCIDR(*rr.Network)
+			if err != nil || ip == nil || !network.Contains(ip) {
+				continue
+			}
+		}
+		pRec, err := repository.ConvertDomainToPacketRecord(domain.Record{
+			Name:     name,
+			Type:     rrset.Type,
+			Content:  rr.Content,
+			TTL:      rrset.TTL,
+			Priority: rr.Priority,
+			Weight:   rr.Weight,
+			Port:     rr.Port,
+		})
+		if err == nil {
+			answers = append(answers, pRec)
+		}
+	}
+	return answers
+}
+
+// answerKeys describes answer records in a canonical, sorted form, so that
+// live and shadow answers compare equal whatever order or case they come in.
+func answerKeys(answers []packet.DNSRecord, zoneID string) []string {
+	keys := make([]string, 0, len(answers))
+	for _, pRec := range answers {
+		rec, err := repository.ConvertPacketRecordToDomain(pRec, zoneID)
+		if err != nil {
+			continue
+		}
+		key := fmt.Sprintf("%s %d", rec.Type, rec.TTL)
+		for _, v := range []*int{rec.Priority, rec.Weight, rec.Port} {
+			if v != nil {
+				key += fmt.Sprintf(" %d", *v)
+			}
+		}
+		keys = append(keys, key+" "+strings.ToLower(rec.Content))
+	}
+	sort.Strings(keys)
+	return keys
+}
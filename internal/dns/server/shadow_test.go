@@ -0,0 +1,120 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+func TestShadowAnswers(t *testing.T) {
+	repo := &mockServerRepo{
+		zones:   []domain.Zone{{ID: "z1", Name: "shadow.test."}},
+		records: []domain.Record{{ZoneID: "z1", Name: "www.shadow.test.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 300}},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+	var logs bytes.Buffer
+	srv.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+	query := func(name string, src string) []packet.DNSRecord {
+		t.Helper()
+		req := packet.NewDNSPacket()
+		req.Header.ID = 9
+		req.Questions = append(req.Questions, packet.DNSQuestion{Name: name, QType: packet.A})
+		reqBuf := packet.NewBytePacketBuffer()
+		_ = req.Write(reqBuf)
+		var captured []byte
+		if err := srv.handlePacket(reqBuf.Buf[:reqBuf.Position()], &net.UDPAddr{IP: net.ParseIP(src), Port: 12345}, func(resp []byte) error {
+			captured = resp
+			return nil
+		}, "udp"); err != nil {
+			t.Fatalf("handlePacket failed: %v", err)
+		}
+		resBuf := packet.NewBytePacketBuffer()
+		resBuf.Load(captured)
+		resp := packet.NewDNSPacket()
+		_ = resp.FromBuffer(resBuf)
+		return resp.Answers
+	}
+
+	ctx := context.Background()
+	srv.syncShadows(ctx)
+	if srv.shadowTouches("www.shadow.test.") {
+		t.Fatal("Expected no shadowed names without shadow changes")
+	}
+
+	_ = repo.SaveShadowChange(ctx, &domain.ShadowChange{
+		ZoneID: "z1",
+		RRsets: []domain.RRset{{
+			Name: "www.shadow.test.", Type: domain.TypeA, TTL: 300,
+			Records: []domain.RRsetRecord{{Content: "192.0.2.2"}},
+		}},
+		CanaryNetworks: []string{"10.0.0.0/8"},
+		CreatedAt:      time.Now(),
+	})
+	srv.syncShadows(ctx)
+	if !srv.shadowTouches("WWW.shadow.test.") {
+		t.Fatal("Expected the staged name to be shadowed")
+	}
+
+	for i := 0; i < 2; i++ {
+		answers := query("www.shadow.test.", "127.0.0.1")
+		if len(answers) != 1 || !answers[0].IP.Equal(net.ParseIP("192.0.2.1")) {
+			t.Fatalf("Expected the live answer outside the canary networks, got %+v", answers)
+		}
+	}
+	if n := strings.Count(logs.String(), "shadow answer differs"); n != 1 {
+		t.Errorf("Expected the difference to be logged once, got %d times:\n%s", n, logs.String())
+	}
+
+	answers := query("www.shadow.test.", "10.1.2.3")
+	if len(answers) != 1 || !answers[0].IP.Equal(net.ParseIP("192.0.2.2")) {
+		t.Errorf("Expected the shadow answer for a canary client, got %+v", answers)
+	}
+
+	srv.ServeShadowZones([]string{"z1"})
+	answers = query("www.shadow.test.", "127.0.0.1")
+	if len(answers) != 1 || !answers[0].IP.Equal(net.ParseIP("192.0.2.2")) {
+		t.Errorf("Expected the shadow answer for every client once a rollout serves the zone, got %+v", answers)
+	}
+	srv.ServeShadowZones(nil)
+
+	// Reloading an unchanged set keeps what it has logged
+	srv.syncShadows(ctx)
+	query("www.shadow.test.", "127.0.0.1")
+	if n := strings.Count(logs.String(), "shadow answer differs"); n != 1 {
+		t.Errorf("Expected no new log after an unchanged reload, got %d", n)
+	}
+
+	repo.shadows = nil
+	srv.syncShadows(ctx)
+	if srv.shadowTouches("www.shadow.test.") {
+		t.Error("Expected the discarded change to be dropped")
+	}
+	answers = query("www.shadow.test.", "10.1.2.3")
+	if len(answers) != 1 || !answers[0].IP.Equal(net.ParseIP("192.0.2.1")) {
+		t.Errorf("Expected the live answer once the change is gone, got %+v", answers)
+	}
+}
+
+func TestAnswerKeysIgnoreOrderAndCase(t *testing.T) {
+	a := []packet.DNSRecord{
+		{Name: "mx.test.", Type: packet.MX, TTL: 60, Priority: 10, Host: "B.example."},
+		{Name: "mx.test.", Type: packet.MX, TTL: 60, Priority: 20, Host: "a.example."},
+	}
+	b := []packet.DNSRecord{a[1], a[0]}
+	b[1].Host = "b.example."
+	if strings.Join(answerKeys(a, "z1"), ",") != strings.Join(answerKeys(b, "z1"), ",") {
+		t.Errorf("Expected equal keys, got %v and %v", answerKeys(a, "z1"), answerKeys(b, "z1"))
+	}
+	b[0].Priority = 30
+	if strings.Join(answerKeys(a, "z1"), ",") == strings.Join(answerKeys(b, "z1"), ",") {
+		t.Error("Expected a priority change to differ")
+	}
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"net"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+	"github.com/poyrazK/cloudDNS/internal/dns/svcb"
+)
+
+func TestHandlePacketHTTPS(t *testing.T) {
+	priority := 1
+	repo := &mockServerRepo{
+		zones: []domain.Zone{{ID: "z1", Name: "example.com."}},
+		records: []domain.Record{
+			{Name: "example.com.", Type: domain.TypeHTTPS, Content: ". alpn=h2,h3 ipv4hint=192.0.2.1", TTL: 300, Priority: &priority},
+		},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+
+	req := packet.NewDNSPacket()
+	req.Questions = append(req.Questions, packet.DNSQuestion{Name: "example.com.", QType: packet.HTTPS})
+	buf := packet.NewBytePacketBuffer()
+	_ = req.Write(buf)
+	var raw []byte
+	if err := srv.handlePacket(buf.Buf[:buf.Position()], &net.UDPAddr{IP: net.ParseIP("192.0.2.10"), Port: 5353}, func(b []byte) error {
+		raw = append([]byte(nil), b...)
+		return nil
+	}, "udp"); err != nil {
+		t.Fatalf("handlePacket failed: %v", err)
+	}
+	resp := parseMessage(t, raw)
+	if resp.Header.ResCode != 0 || len(resp.Answers) != 1 {
+		t.Fatalf("Expected one HTTPS answer, got rcode %d and %+v", resp.Header.ResCode, resp.Answers)
+	}
+	ans := resp.Answers[0]
+	if ans.Type != packet.HTTPS || ans.Priority != 1 || ans.Host != "." || svcb.Format(ans.SvcParams) != "alpn=h2,h3 ipv4hint=192.0.2.1" {
+		t.Errorf("Unexpected HTTPS answer %+v", ans)
+	}
+}
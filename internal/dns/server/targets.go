@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// targetSyncInterval is how often the tenants' targets are reloaded.
+var targetSyncInterval = 15 * time.Second
+
+// targetSet holds the addresses of every target, by target ID.
+type targetSet struct {
+	addresses map[string][]net.IP
+}
+
+// scheduleTargets reloads the targets until ctx is done.
+func (s *Server) scheduleTargets(ctx context.Context) {
+	if s.Repo == nil {
+		return
+	}
+	ticker := time.NewTicker(targetSyncInterval)
+	defer ticker.Stop()
+	for {
+		s.syncTargets(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// syncTargets loads the targets of every tenant, keeping the current set if
+// they fail to load.
+func (s *Server) syncTargets(ctx context.Context) {
+	targets, err := s.Repo.ListTargets(ctx, "")
+	if err != nil {
+		s.Logger.Error("failed to load targets", "error", err)
+		return
+	}
+	set := &targetSet{addresses: make(map[string][]net.IP, len(targets))}
+	for _, target := range targets {
+		var ips []net.IP
+		for _, addr := range target.Addresses {
+			if ip := net.ParseIP(addr); ip != nil {
+				ips = append(ips, ip)
+			}
+		}
+		set.addresses[target.ID] = ips
+	}
+	s.targets.Store(set)
+}
+
+// expandTargets replaces every record referencing a target with one record per
+// address of the target in the record's family. A record whose target is not
+// loaded, or has no address of its family, is left out.
+func (s *Server) expandTargets(records []domain.Record) []domain.Record {
+	referenced := false
+	for _, rec := range records {
+		if rec.TargetID != nil {
+			referenced = true
+			break
+		}
+	}
+	if !referenced {
+		return records
+	}
+	set := s.targets.Load()
+	expanded := make([]domain.Record, 0, len(records))
+	for _, rec := range records {
+		if rec.TargetID == nil {
+			expanded = append(expanded, rec)
+			continue
+		}
+		if set == nil {
+			continue
+		}
+		for _, ip := range set.addresses[*rec.TargetID] {
+			if (ip.To4() != nil) != (rec.Type == domain.TypeA) {
+				continue
+			}
+			addr := rec
+			addr.Content = ip.String()
+			expanded = append(expanded, addr)
+		}
+	}
+	return expanded
+}
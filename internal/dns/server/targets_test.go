@@ -0,0 +1,42 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+func TestHandlePacketTargets(t *testing.T) {
+	ingress := "ingress"
+	repo := &mockServerRepo{
+		zones: []domain.Zone{{ID: "z1", TenantID: "t1", Name: "target.test."}},
+		records: []domain.Record{
+			{ZoneID: "z1", Name: "shop.target.test.", Type: domain.TypeA, TTL: 300, TargetID: &ingress},
+			{ZoneID: "z1", Name: "blog.target.test.", Type: domain.TypeA, TTL: 300, TargetID: &ingress},
+			{ZoneID: "z1", Name: "blog.target.test.", Type: domain.TypeA, Content: "192.0.2.50", TTL: 300},
+		},
+		targets: []domain.Target{{ID: ingress, TenantID: "t1", Name: "ingress", Addresses: []string{"192.0.2.1", "192.0.2.2", "2001:db8::1"}}},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+	srv.syncTargets(context.Background())
+
+	if resp := queryFrom(t, srv, "shop.target.test.", "192.0.2.99:5353", false); len(resp.Answers) != 2 || resp.Answers[0].IP.String() != "192.0.2.1" {
+		t.Fatalf("Expected the target's IPv4 addresses, got %v", resp.Answers)
+	}
+	if resp := queryFrom(t, srv, "blog.target.test.", "192.0.2.99:5353", false); len(resp.Answers) != 3 {
+		t.Errorf("Expected the target's addresses next to the record's own, got %v", resp.Answers)
+	}
+
+	// Updating the target moves every referencing record at once
+	repo.mu.Lock()
+	repo.targets[0].Addresses = []string{"198.51.100.1"}
+	repo.mu.Unlock()
+	srv.applyDataChange(domain.DataChange{Table: "dns_targets", Op: "UPDATE"})
+	for name, want := range map[string]int{"shop.target.test.": 1, "blog.target.test.": 2} {
+		resp := queryFrom(t, srv, name, "192.0.2.99:5353", false)
+		if len(resp.Answers) != want || resp.Answers[0].IP.String() != "198.51.100.1" {
+			t.Errorf("Expected %s to follow the target, got %v", name, resp.Answers)
+		}
+	}
+}
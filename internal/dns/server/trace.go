@@ -0,0 +1,265 @@
+package server
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/adapters/repository"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+// rcodeNames are the mnemonics of the response codes a traced query can get.
+var rcodeNames = map[uint8]string{
+	packet.RcodeNoError:  "NOERROR",
+	packet.RcodeFormErr:  "FORMERR",
+	packet.RcodeServFail: "SERVFAIL",
+	packet.RcodeNxDomain: "NXDOMAIN",
+	packet.RcodeNotImp:   "NOTIMP",
+	packet.RcodeRefused:  "REFUSED",
+	packet.RcodeNotAuth:  "NOTAUTH",
+}
+
+// queryTrace collects the steps of a traced query. The query path calls its
+// methods unconditionally: on a nil trace they do nothing.
+type queryTrace struct {
+	steps []domain.QueryTraceStep
+}
+
+func (t *queryTrace) add(stage, format string, args ...interface{}) {
+	if t == nil {
+		return
+	}
+	t.steps = append(t.steps, domain.QueryTraceStep{Stage: stage, Detail: fmt.Sprintf(format, args...)})
+}
+
+func (t *queryTrace) view(view string) {
+	if view == "" {
+		t.add("view", "client is in no split-horizon network or view and sees records without one")
+		return
+	}
+	t.add("view", "client sees the split-horizon records of %s", view)
+}
+
+func (t *queryTrace) policy(p *QueryTypePolicy) {
+	switch p.Action {
+	case QueryTypeTCP:
+		t.add("policy", "query type policy %q sends truncated UDP answers larger than %d bytes to force TCP", p.Name, p.MinSize)
+	default:
+		t.add("policy", "query type policy %q refuses the query (%s)", p.Name, p.Action)
+	}
+}
+
+func (t *queryTrace) zone(zone *domain.Zone) {
+	if zone == nil {
+		t.add("zone", "no zone on this server contains the name")
+		return
+	}
+	role := zone.Role
+	if role == "" {
+		role = "master"
+	}
+	t.add("zone", "authoritative zone %s (%s, DNSSEC %t)", zone.Name, role, zone.SignsDNSSEC())
+}
+
+func (t *queryTrace) records(stage, name string, qType domain.RecordType, clientIP string, records []domain.Record) {
+	if t == nil {
+		return
+	}
+	if len(records) == 0 {
+		t.add(stage, "no %s records for %s", qType, name)
+		return
+	}
+	var b strings.Builder
+	for i, rec := range records {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(rec.Content)
+		if rec.Network != nil {
+			fmt.Fprintf(&b, " (network %s)", *rec.Network)
+		}
+		if rec.HealthStatus != "" {
+			fmt.Fprintf(&b, " [%s]", rec.HealthStatus)
+		}
+	}
+	t.add(stage, "%d %s records for %s as seen from %s: %s", len(records), qType, name, clientIP, b.String())
+}
+
+func (t *queryTrace) recursion(resp *packet.DNSPacket, err error) {
+	switch {
+	case err != nil:
+		t.add("recursion", "not authoritative; recursive resolution failed: %v", err)
+	case resp == nil:
+		t.add("recursion", "not authoritative; recursive resolution returned nothing")
+	default:
+		t.add("recursion", "not authoritative; resolved recursively with %d answers", len(resp.Answers))
+	}
+}
+
+func (t *queryTrace) signing(dnssecOK bool, zone *domain.Zone, synthesized bool) {
+	switch {
+	case zone == nil:
+	case !dnssecOK:
+		t.add("dnssec", "client did not set DO; the response is not signed")
+	case !zone.SignsDNSSEC():
+		t.add("dnssec", "zone %s is not signed", zone.Name)
+	case !signsLocally(zone):
+		t.add("dnssec", "zone %s is a secondary whose keys stay with its primary; the response is not signed", zone.Name)
+	case synthesized:
+		t.add("dnssec", "synthesized records are left unsigned")
+	default:
+		t.add("dnssec", "signing the response with the keys of %s", zone.Name)
+	}
+}
+
+func (t *queryTrace) queryLimits(zone limitedZone, zoneBucket, tenantBucket *limitBucket) {
+	if zoneBucket != nil {
+		t.add("limit", "zone %s is limited to %g queries per second (burst %d) on this node", zone.id, zoneBucket.rate, zoneBucket.burst)
+	}
+	if tenantBucket != nil {
+		t.add("limit", "tenant %s is limited to %g queries per second (burst %d) on this node", zone.tenantID, tenantBucket.rate, tenantBucket.burst)
+	}
+}
+
+// traceCache records how the caches would treat a query. A traced query is
+// always resolved, so the answer it shows is the one the data gives now.
+func (s *Server) traceCache(trace *queryTrace, key string, useCache, dns64, search bool) {
+	switch {
+	case dns64:
+		trace.add("cache", "DNS64 applies to the client; the answer is never cached")
+	case search:
+		trace.add("cache", "the bare name may be completed from the search list; the answer is never cached")
+	case !useCache:
+		trace.add("cache", "a staged shadow change covers the name; the answer is never cached")
+	default:
+		if _, found := s.Cache.Get(key); found {
+			trace.add("cache", "clients in this view are answered from the cache under %s until it expires; resolving anyway", key)
+		} else {
+			trace.add("cache", "nothing cached under %s", key)
+		}
+	}
+}
+
+// TraceQuery answers a query for name and qType as if it came from clientIP,
+// through the same pipeline as real queries, and reports each step along with
+// the response. With dnssec the query sets the DO bit.
+func (s *Server) TraceQuery(ctx context.Context, name string, qType domain.RecordType, clientIP string, dnssec bool) (*domain.QueryTrace, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(name) == "" {
+		return nil, fmt.Errorf("%w: name is required", domain.ErrInvalidQueryTrace)
+	}
+	name = dnsname.Fqdn(strings.TrimSpace(name))
+	qt, ok := parseQueryType(string(qType))
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown query type %q", domain.ErrInvalidQueryTrace, qType)
+	}
+	ip := net.ParseIP(clientIP)
+	if ip == nil {
+		return nil, fmt.Errorf("%w: invalid client IP %q", domain.ErrInvalidQueryTrace, clientIP)
+	}
+
+	request := packet.NewDNSPacket()
+	request.Header.RecursionDesired = true
+	request.Questions = append(request.Questions, packet.DNSQuestion{Name: name, QType: qt, QClass: 1})
+	opt := packet.DNSRecord{Name: ".", Type: packet.OPT, UDPPayloadSize: 4096}
+	if dnssec {
+		opt.Z = 0x8000
+	}
+	request.Resources = append(request.Resources, opt)
+	reqBuffer := packet.NewBytePacketBuffer()
+	if err := request.Write(reqBuffer); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidQueryTrace, err)
+	}
+
+	trace := &queryTrace{}
+	result := &domain.QueryTrace{Name: name, Type: domain.RecordType(qt.String()), ClientIP: ip.String(), DNSSEC: dnssec}
+	trace.add("query", "%s %s from %s, recursion desired, DO %t", name, qt, ip, dnssec)
+
+	start := time.Now()
+	var resp []byte
+	err := s.servePacket(reqBuffer.Buf[:reqBuffer.Position()], net.JoinHostPort(ip.String(), "0"), func(b []byte) error {
+		resp = append([]byte(nil), b...)
+		return nil
+	}, "udp", trace)
+	result.DurationMS = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return nil, fmt.Errorf("query for %s %s was dropped without a response", name, qt)
+	}
+
+	buf := packet.NewBytePacketBuffer()
+	buf.Load(resp)
+	response := packet.NewDNSPacket()
+	if err := response.FromBuffer(buf); err != nil {
+		return nil, fmt.Errorf("failed to read the traced response: %w", err)
+	}
+	result.Rcode = rcodeNames[response.Header.ResCode]
+	if result.Rcode == "" {
+		result.Rcode = fmt.Sprintf("RCODE%d", response.Header.ResCode)
+	}
+	result.Answers = presentRecords(response.Answers)
+	result.Authorities = presentRecords(response.Authorities)
+	for _, rec := range response.Resources {
+		if rec.Type != packet.OPT {
+			result.Additionals = append(result.Additionals, presentRecord(rec))
+			continue
+		}
+		for _, o := range rec.Options {
+			if o.Code == 15 && len(o.Data) >= 2 {
+				result.Errors = append(result.Errors, strings.TrimSpace(fmt.Sprintf("EDE %d %s", binary.BigEndian.Uint16(o.Data), o.Data[2:])))
+			}
+		}
+	}
+	if result.Additionals == nil {
+		result.Additionals = []string{}
+	}
+	trace.add("response", "%s with %d answers, %d authority and %d additional records%s", result.Rcode,
+		len(result.Answers), len(result.Authorities), len(result.Additionals), truncatedNote(response.Header.TruncatedMessage))
+	result.Steps = trace.steps
+	return result, nil
+}
+
+func truncatedNote(truncated bool) string {
+	if truncated {
+		return ", truncated"
+	}
+	return ""
+}
+
+// presentRecords renders records in zone file presentation format.
+func presentRecords(records []packet.DNSRecord) []string {
+	out := make([]string, 0, len(records))
+	for _, rec := range records {
+		out = append(out, presentRecord(rec))
+	}
+	return out
+}
+
+func presentRecord(rec packet.DNSRecord) string {
+	dRec, err := repository.ConvertPacketRecordToDomain(rec, "")
+	if err != nil {
+		return fmt.Sprintf("%s %d IN %s", rec.Name, rec.TTL, rec.Type)
+	}
+	var fields []string
+	if dRec.Priority != nil {
+		fields = append(fields, fmt.Sprint(*dRec.Priority))
+	}
+	if dRec.Weight != nil {
+		fields = append(fields, fmt.Sprint(*dRec.Weight))
+	}
+	if dRec.Port != nil {
+		fields = append(fields, fmt.Sprint(*dRec.Port))
+	}
+	fields = append(fields, dRec.Content)
+	return fmt.Sprintf("%s %d IN %s %s", dnsname.Fqdn(rec.Name), rec.TTL, dRec.Type, strings.Join(fields, " "))
+}
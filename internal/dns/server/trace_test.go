@@ -0,0 +1,114 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+func TestTraceQuery(t *testing.T) {
+	network, priority := "10.0.0.0/8", 10
+	repo := &mockServerRepo{
+		zones: []domain.Zone{{ID: "z1", Name: "trace.test."}},
+		records: []domain.Record{
+			{ZoneID: "z1", Name: "trace.test.", Type: domain.TypeSOA, Content: "ns1.trace.test. admin.trace.test. 1 3600 600 86400 300", TTL: 3600},
+			{ZoneID: "z1", Name: "trace.test.", Type: domain.TypeNS, Content: "ns1.trace.test.", TTL: 3600},
+			{ZoneID: "z1", Name: "www.trace.test.", Type: domain.TypeA, Content: "10.1.1.1", TTL: 300, Network: &network},
+			{ZoneID: "z1", Name: "*.trace.test.", Type: domain.TypeMX, Content: "mail.trace.test.", TTL: 300, Priority: &priority},
+		},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+	ctx := context.Background()
+
+	trace, err := srv.TraceQuery(ctx, "WWW.trace.test", "a", "10.2.3.4", false)
+	if err != nil {
+		t.Fatalf("TraceQuery failed: %v", err)
+	}
+	if trace.Name != "WWW.trace.test." || trace.Type != domain.TypeA || trace.Rcode != "NOERROR" {
+		t.Errorf("Unexpected trace header: %+v", trace)
+	}
+	if len(trace.Answers) != 1 || trace.Answers[0] != "www.trace.test. 300 IN A 10.1.1.1" {
+		t.Errorf("Unexpected answers: %v", trace.Answers)
+	}
+	stages := make(map[string]string)
+	for _, step := range trace.Steps {
+		stages[step.Stage] += step.Detail + "\n"
+	}
+	for stage, want := range map[string]string{
+		"zone":      "authoritative zone trace.test.",
+		"records":   "10.1.1.1 (network 10.0.0.0/8)",
+		"authority": "1 NS records",
+		"response":  "NOERROR with 1 answers",
+	} {
+		if !strings.Contains(stages[stage], want) {
+			t.Errorf("Expected the %s step to mention %q, got %q", stage, want, stages[stage])
+		}
+	}
+
+	// Wildcard answers say which wildcard matched
+	trace, err = srv.TraceQuery(ctx, "any.trace.test.", "MX", "192.0.2.1", false)
+	if err != nil || len(trace.Answers) != 1 || trace.Answers[0] != "any.trace.test. 300 IN MX 10 mail.trace.test." {
+		t.Fatalf("Unexpected wildcard trace: %+v (%v)", trace, err)
+	}
+	if !strings.Contains(trace.Steps[len(trace.Steps)-1].Detail, "NOERROR") || !hasStage(trace, "wildcard") {
+		t.Errorf("Expected a wildcard step, got %+v", trace.Steps)
+	}
+
+	trace, err = srv.TraceQuery(ctx, "missing.trace.test.", "TXT", "192.0.2.1", false)
+	if err != nil || trace.Rcode != "NXDOMAIN" || len(trace.Authorities) != 1 || !hasStage(trace, "negative") {
+		t.Errorf("Expected NXDOMAIN with the SOA, got %+v (%v)", trace, err)
+	}
+
+	trace, err = srv.TraceQuery(ctx, "other.test.", "A", "192.0.2.1", false)
+	if err != nil || trace.Rcode != "REFUSED" || len(trace.Errors) == 0 {
+		t.Errorf("Expected REFUSED with an EDE, got %+v (%v)", trace, err)
+	}
+
+	for _, bad := range [][3]string{{"", "A", "192.0.2.1"}, {"www.trace.test.", "BOGUS", "192.0.2.1"}, {"www.trace.test.", "A", "nope"}} {
+		if _, err := srv.TraceQuery(ctx, bad[0], domain.RecordType(bad[1]), bad[2], false); !errors.Is(err, domain.ErrInvalidQueryTrace) {
+			t.Errorf("TraceQuery(%q, %q, %q): expected ErrInvalidQueryTrace, got %v", bad[0], bad[1], bad[2], err)
+		}
+	}
+}
+
+func TestTraceQueryBypassesCache(t *testing.T) {
+	repo := &mockServerRepo{
+		zones: []domain.Zone{{ID: "z1", Name: "trace.test."}},
+		records: []domain.Record{
+			{ZoneID: "z1", Name: "www.trace.test.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 300},
+			{ZoneID: "z1", Name: "api.trace.test.", Type: domain.TypeA, Content: "192.0.2.2", TTL: 300},
+		},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+	srv.Cache.Set("www.trace.test.:1", []byte{0, 0, 0x81, 0x80}, time.Minute)
+
+	trace, err := srv.TraceQuery(context.Background(), "www.trace.test.", "A", "192.0.2.1", false)
+	if err != nil || len(trace.Answers) != 1 {
+		t.Fatalf("Expected the answer resolved from the data, got %+v (%v)", trace, err)
+	}
+	if !strings.Contains(trace.Steps[2].Detail, "answered from the cache") {
+		t.Errorf("Expected the cached answer reported, got %+v", trace.Steps)
+	}
+	if _, err := srv.TraceQuery(context.Background(), "api.trace.test.", "A", "192.0.2.1", false); err != nil {
+		t.Fatalf("TraceQuery failed: %v", err)
+	}
+	if _, found := srv.Cache.Get("api.trace.test.:1"); found {
+		t.Error("Expected a traced query to stay out of the cache")
+	}
+	if srv.QueriesServed() != 0 {
+		t.Errorf("Expected a traced query not counted as served, got %d", srv.QueriesServed())
+	}
+}
+
+func hasStage(trace *domain.QueryTrace, stage string) bool {
+	for _, step := range trace.Steps {
+		if step.Stage == stage {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"crypto/tls"
+	"net"
+	"os"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+// ednsOptionPadding is the EDNS option code of the padding option (RFC 7830).
+const ednsOptionPadding = 12
+
+// DefaultPaddingBlockSize is the response block size recommended by RFC 8467.
+const DefaultPaddingBlockSize = 468
+
+// TransportPolicy holds the security controls of an encrypted transport.
+type TransportPolicy struct {
+	// PaddingBlockSize pads responses to a multiple of this many bytes when the query
+	// carried a padding option (RFC 8467); zero disables padding.
+	PaddingBlockSize int
+	// AllowEarlyUpdates accepts dynamic updates sent as TLS 1.3 early data. Early data
+	// can be replayed and updates are not idempotent, so they are refused by default.
+	AllowEarlyUpdates bool
+}
+
+// defaultTransportPolicy pads responses and refuses updates in early data.
+func defaultTransportPolicy() TransportPolicy {
+	return TransportPolicy{PaddingBlockSize: DefaultPaddingBlockSize}
+}
+
+// hasEDNSOption reports whether a query's OPT record carries an option with code.
+func hasEDNSOption(request *packet.DNSPacket, code uint16) bool {
+	for _, res := range request.Resources {
+		if res.Type != packet.OPT {
+			continue
+		}
+		for _, opt := range res.Options {
+			if opt.Code == code {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// isUpdate reports whether an encoded message is a dynamic update (RFC 2136).
+func isUpdate(msg []byte) bool {
+	return len(msg) >= 3 && (msg[2]>>3)&0x0F == packet.OpcodeUpdate
+}
+
+// withPadding wraps sendFn so responses are padded per policy if the query in msg
+// asked for padding.
+func withPadding(policy TransportPolicy, msg []byte, sendFn func([]byte) error) func([]byte) error {
+	if policy.PaddingBlockSize <= 0 {
+		return sendFn
+	}
+	buf := packet.NewBytePacketBuffer()
+	buf.Load(msg)
+	request := packet.NewDNSPacket()
+	if err := request.FromBuffer(buf); err != nil || !hasEDNSOption(request, ednsOptionPadding) {
+		return sendFn
+	}
+	return func(resp []byte) error {
+		return sendFn(padResponse(resp, policy.PaddingBlockSize))
+	}
+}
+
+// padResponse pads an encoded response with an EDNS padding option so its length is
+// a multiple of block. Responses without an OPT record, or that would outgrow a DNS
+// message, are returned unchanged.
+func padResponse(resp []byte, block int) []byte {
+	buf := packet.NewBytePacketBuffer()
+	buf.Load(resp)
+	response := packet.NewDNSPacket()
+	if err := response.FromBuffer(buf); err != nil {
+		return resp
+	}
+	opt := -1
+	for i := range response.Resources {
+		if response.Resources[i].Type == packet.OPT {
+			opt = i
+			break
+		}
+	}
+	if opt < 0 {
+		return resp
+	}
+
+	// Encode with an empty option first; the padding only adds its own length
+	options := response.Resources[opt].Options[:0:0]
+	for _, o := range response.Resources[opt].Options {
+		if o.Code != ednsOptionPadding {
+			options = append(options, o)
+		}
+	}
+	response.Resources[opt].Options = append(options, packet.EdnsOption{Code: ednsOptionPadding})
+	out := packet.NewBytePacketBuffer()
+	out.HasNames = true
+	if err := response.Write(out); err != nil {
+		return resp
+	}
+	size := out.Position()
+	pad := (block - size%block) % block
+	if pad == 0 {
+		return out.Buf[:size]
+	}
+	if size+pad > packet.MaxPacketSize {
+		return resp
+	}
+
+	response.Resources[opt].Options[len(options)].Data = make([]byte, pad)
+	out = packet.NewBytePacketBuffer()
+	out.HasNames = true
+	if err := response.Write(out); err != nil {
+		return resp
+	}
+	return out.Buf[:out.Position()]
+}
+
+// dotAddr returns the address of the DNS over TLS listener.
+func (s *Server) dotAddr() string {
+	host, _, _ := net.SplitHostPort(s.Addr)
+	return net.JoinHostPort(host, "853")
+}
+
+// dohAddr returns the address of the DNS over HTTPS listener, on DOH_PORT or 443.
+func (s *Server) dohAddr() string {
+	host, _, _ := net.SplitHostPort(s.Addr)
+	port := os.Getenv("DOH_PORT")
+	if port == "" {
+		port = "443"
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// TransportPosture reports the security controls in force on each encrypted
+// transport. DNS over QUIC is not served and is listed as disabled.
+func (s *Server) TransportPosture() []domain.TransportPosture {
+	enabled := s.TLSConfig != nil
+	minVersion := ""
+	if enabled {
+		v := s.TLSConfig.MinVersion
+		if v == 0 {
+			v = tls.VersionTLS12
+		}
+		minVersion = tls.VersionName(v)
+	}
+	posture := func(transport, addr string, policy TransportPolicy) domain.TransportPosture {
+		p := domain.TransportPosture{Transport: transport, Enabled: enabled}
+		if !enabled {
+			return p
+		}
+		p.Address = addr
+		p.MinTLSVersion = minVersion
+		p.PaddingBlockSize = policy.PaddingBlockSize
+		// crypto/tls never accepts early data itself; it can only arrive through a
+		// TLS-terminating proxy in front of DoH (RFC 8470)
+		p.EarlyData = transport == "doh"
+		p.EarlyDataUpdates = p.EarlyData && policy.AllowEarlyUpdates
+		return p
+	}
+	return []domain.TransportPosture{
+		posture("dot", s.dotAddr(), s.DoTPolicy),
+		posture("doh", s.dohAddr(), s.DoHPolicy),
+		{Transport: "doq"},
+	}
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"bytes"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+// paddedQuery encodes a query whose OPT record optionally carries a padding option.
+func paddedQuery(t *testing.T, name string, opcode uint8, padding bool) []byte {
+	t.Helper()
+	req := packet.NewDNSPacket()
+	req.Header.ID = 7
+	req.Header.Opcode = opcode
+	req.Questions = append(req.Questions, packet.DNSQuestion{Name: name, QType: packet.A, QClass: 1})
+	opt := packet.DNSRecord{Name: ".", Type: packet.OPT, UDPPayloadSize: 4096}
+	if padding {
+		opt.Options = append(opt.Options, packet.EdnsOption{Code: ednsOptionPadding})
+	}
+	req.Resources = append(req.Resources, opt)
+	buf := packet.NewBytePacketBuffer()
+	if err := req.Write(buf); err != nil {
+		t.Fatalf("failed to write query: %v", err)
+	}
+	return append([]byte(nil), buf.Buf[:buf.Position()]...)
+}
+
+func TestResponsePadding(t *testing.T) {
+	repo := &mockServerRepo{
+		records: []domain.Record{{Name: "pad.test.", Type: domain.TypeA, Content: "192.0.2.1", TTL: 60}},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+
+	doh := func(msg []byte) []byte {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/dns-query", bytes.NewReader(msg))
+		r.Header.Set("Content-Type", "application/dns-message")
+		srv.handleDoH(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", w.Code)
+		}
+		return w.Body.Bytes()
+	}
+
+	resp := doh(paddedQuery(t, "pad.test.", packet.OpcodeQuery, true))
+	if len(resp)%DefaultPaddingBlockSize != 0 {
+		t.Errorf("Expected response padded to %d bytes, got %d", DefaultPaddingBlockSize, len(resp))
+	}
+	parsed := packet.NewDNSPacket()
+	buf := packet.NewBytePacketBuffer()
+	buf.Load(resp)
+	if err := parsed.FromBuffer(buf); err != nil || len(parsed.Answers) != 1 || !hasEDNSOption(parsed, ednsOptionPadding) {
+		t.Errorf("Expected a parseable answer with a padding option, got %+v (%v)", parsed, err)
+	}
+
+	if resp := doh(paddedQuery(t, "other.pad.test.", packet.OpcodeQuery, false)); len(resp)%DefaultPaddingBlockSize == 0 {
+		t.Errorf("Expected no padding without a padding option, got %d bytes", len(resp))
+	}
+
+	srv.DoHPolicy.PaddingBlockSize = 0
+	if resp := doh(paddedQuery(t, "third.pad.test.", packet.OpcodeQuery, true)); len(resp)%DefaultPaddingBlockSize == 0 {
+		t.Errorf("Expected no padding when disabled, got %d bytes", len(resp))
+	}
+}
+
+func TestPadResponse(t *testing.T) {
+	resp := packet.NewDNSPacket()
+	resp.Header.Response = true
+	resp.Resources = append(resp.Resources, packet.DNSRecord{Name: ".", Type: packet.OPT, UDPPayloadSize: 4096,
+		Options: []packet.EdnsOption{{Code: ednsOptionPadding, Data: make([]byte, 3)}}})
+	buf := packet.NewBytePacketBuffer()
+	_ = resp.Write(buf)
+	raw := buf.Buf[:buf.Position()]
+
+	for _, block := range []int{128, 468, 4096} {
+		if got := len(padResponse(raw, block)); got%block != 0 {
+			t.Errorf("Expected a multiple of %d, got %d", block, got)
+		}
+	}
+
+	// Without an OPT record there is nowhere to put padding
+	plain := packet.NewDNSPacket()
+	plain.Header.Response = true
+	buf = packet.NewBytePacketBuffer()
+	_ = plain.Write(buf)
+	if got := padResponse(buf.Buf[:buf.Position()], 128); len(got) != buf.Position() {
+		t.Errorf("Expected response without OPT unchanged, got %d bytes", len(got))
+	}
+}
+
+func TestDoHEarlyDataUpdate(t *testing.T) {
+	srv := NewServer("127.0.0.1:0", &mockServerRepo{}, nil)
+	srv.DisableAsync = true
+
+	post := func(msg []byte, early bool) int {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/dns-query", bytes.NewReader(msg))
+		r.Header.Set("Content-Type", "application/dns-message")
+		if early {
+			r.Header.Set("Early-Data", "1")
+		}
+		srv.handleDoH(w, r)
+		return w.Code
+	}
+
+	if code := post(paddedQuery(t, "example.test.", packet.OpcodeUpdate, false), true); code != http.StatusTooEarly {
+		t.Errorf("Expected 425 for an update in early data, got %d", code)
+	}
+	if code := post(paddedQuery(t, "example.test.", packet.OpcodeQuery, false), true); code != http.StatusOK {
+		t.Errorf("Expected queries in early data answered, got %d", code)
+	}
+	if code := post(paddedQuery(t, "example.test.", packet.OpcodeUpdate, false), false); code != http.StatusOK {
+		t.Errorf("Expected updates outside early data handled, got %d", code)
+	}
+
+	srv.DoHPolicy.AllowEarlyUpdates = true
+	if code := post(paddedQuery(t, "example.test.", packet.OpcodeUpdate, false), true); code != http.StatusOK {
+		t.Errorf("Expected update in early data handled when allowed, got %d", code)
+	}
+}
+
+func TestTransportPosture(t *testing.T) {
+	t.Setenv("DOH_PORT", "8443")
+	srv := NewServer("127.0.0.1:53", &mockServerRepo{}, nil)
+
+	for _, p := range srv.TransportPosture() {
+		if p.Enabled {
+			t.Errorf("Expected %s disabled without TLS", p.Transport)
+		}
+	}
+
+	srv.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS13}
+	srv.DoTPolicy.PaddingBlockSize = 128
+	posture := srv.TransportPosture()
+	if len(posture) != 3 {
+		t.Fatalf("Expected dot, doh and doq, got %+v", posture)
+	}
+	dot, doh, doq := posture[0], posture[1], posture[2]
+	if !dot.Enabled || dot.Address != "127.0.0.1:853" || dot.MinTLSVersion != "TLS 1.3" || dot.PaddingBlockSize != 128 || dot.EarlyData {
+		t.Errorf("Unexpected DoT posture %+v", dot)
+	}
+	if !doh.Enabled || doh.Address != "127.0.0.1:8443" || doh.PaddingBlockSize != DefaultPaddingBlockSize || !doh.EarlyData || doh.EarlyDataUpdates {
+		t.Errorf("Unexpected DoH posture %+v", doh)
+	}
+	if doq.Transport != "doq" || doq.Enabled {
+		t.Errorf("Expected DoQ reported as disabled, got %+v", doq)
+	}
+}
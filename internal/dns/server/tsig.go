@@ -0,0 +1,103 @@
+package server
+
+import (
+	"errors"
+	"net"
+
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+// tsigResponder verifies the TSIG record of request against the configured keys
+// and returns sendFn wrapped so every response carries TSIG as RFC 8945
+// requires: signed over the request MAC (and, for a multi-message transfer,
+// chained over each previous MAC) when the request verified, unsigned with the
+// error code when the key is unknown or the MAC is wrong. A request signed
+// outside its time window gets a signed BADTIME reply. ok is false whenever
+// the request itself must be refused with NOTAUTH.
+func (s *Server) tsigResponder(request *packet.DNSPacket, raw []byte, sendFn func([]byte) error) (send func([]byte) error, ok bool) {
+	tsig := request.Resources[len(request.Resources)-1]
+	secret, known := s.TsigKeys[tsig.Name]
+	if !known {
+		s.Logger.Warn("unknown TSIG key", "key", tsig.Name)
+		return unsignedTSIGSend(tsig.Name, packet.TSIGErrBadKey, sendFn), false
+	}
+
+	stream := packet.NewTSIGStream(tsig.Name, secret, tsig.MAC)
+	errVerify := request.VerifyTSIG(raw, request.TSIGStart, secret)
+	switch {
+	case errVerify == nil:
+		return signedSend(stream, sendFn), true
+	case errors.Is(errVerify, packet.ErrTSIGBadTime):
+		s.Logger.Warn("TSIG verification failed", "key", tsig.Name, "error", errVerify)
+		stream.Error = packet.TSIGErrBadTime
+		return signedSend(stream, sendFn), false
+	default:
+		s.Logger.Warn("TSIG verification failed", "key", tsig.Name, "error", errVerify)
+		return unsignedTSIGSend(tsig.Name, packet.TSIGErrBadSig, sendFn), false
+	}
+}
+
+// signedSend signs each message with stream before handing it to sendFn.
+func signedSend(stream *packet.TSIGStream, sendFn func([]byte) error) func([]byte) error {
+	return func(msg []byte) error {
+		signed, err := stream.SignMessage(msg)
+		if err != nil {
+			return err
+		}
+		return sendFn(signed)
+	}
+}
+
+// unsignedTSIGSend adds an unsigned TSIG record carrying errCode to each message.
+func unsignedTSIGSend(keyName string, errCode uint16, sendFn func([]byte) error) func([]byte) error {
+	return func(msg []byte) error {
+		out, err := packet.AppendUnsignedTSIG(msg, keyName, errCode)
+		if err != nil {
+			return err
+		}
+		return sendFn(out)
+	}
+}
+
+// sendNotAuth answers request with an empty NOTAUTH response through sendFn.
+func sendNotAuth(request *packet.DNSPacket, sendFn func([]byte) error) error {
+	response := packet.NewDNSPacket()
+	response.Header.ID = request.Header.ID
+	response.Header.Response = true
+	response.Header.Opcode = request.Header.Opcode
+	response.Header.ResCode = packet.RcodeNotAuth
+	response.Questions = append(response.Questions, request.Questions...)
+	resBuffer := packet.GetBuffer()
+	defer packet.PutBuffer(resBuffer)
+	if err := response.Write(resBuffer); err != nil {
+		return err
+	}
+	return sendFn(resBuffer.Buf[:resBuffer.Position()])
+}
+
+// tcpSend writes a message to conn with its two-byte length prefix.
+func tcpSend(conn net.Conn) func([]byte) error {
+	return func(msg []byte) error {
+		resLen := uint16(len(msg)) // #nosec G115
+		_, err := conn.Write(append([]byte{byte(resLen >> 8), byte(resLen & 0xFF)}, msg...))
+		return err
+	}
+}
+
+// tsigConn signs the messages a zone transfer handler writes. Transfer handlers
+// write each length-prefixed message with a single Write, so every call is one
+// message to pass through send.
+type tsigConn struct {
+	net.Conn
+	send func([]byte) error
+}
+
+func (c *tsigConn) Write(b []byte) (int, error) {
+	if len(b) < 2 {
+		return 0, errors.New("short DNS message")
+	}
+	if err := c.send(b[2:]); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
@@ -0,0 +1,163 @@
+package server
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+func parseMessage(t *testing.T, raw []byte) *packet.DNSPacket {
+	t.Helper()
+	p := packet.NewDNSPacket()
+	buf := packet.NewBytePacketBuffer()
+	buf.Load(raw)
+	if err := p.FromBuffer(buf); err != nil {
+		t.Fatalf("failed to parse message: %v", err)
+	}
+	return p
+}
+
+func TestTSIGSignedAXFR(t *testing.T) {
+	repo := &mockServerRepo{
+		zones: []domain.Zone{{ID: "z1", Name: "xfr.test."}},
+		records: []domain.Record{
+			{ZoneID: "z1", Name: "xfr.test.", Type: domain.TypeSOA, TTL: 3600, Content: "ns1.xfr.test. admin.xfr.test. 5 3600 600 604800 300"},
+			{ZoneID: "z1", Name: "a.xfr.test.", Type: domain.TypeA, TTL: 300, Content: "192.0.2.1"},
+			{ZoneID: "z1", Name: "b.xfr.test.", Type: domain.TypeA, TTL: 300, Content: "192.0.2.2"},
+		},
+	}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+	secret := []byte("xfr-secret")
+	srv.TsigKeys["xfr-key."] = secret
+	addr, cleanup := startMasterListener(t, srv)
+	defer cleanup()
+
+	transfer := func(keyName string, key []byte) (*packet.TSIGStream, []*packet.DNSPacket, [][]byte) {
+		t.Helper()
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatalf("failed to dial: %v", err)
+		}
+		defer func() { _ = conn.Close() }()
+		_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+		req := packet.NewDNSPacket()
+		req.Header.ID = 4321
+		req.Questions = append(req.Questions, packet.DNSQuestion{Name: "xfr.test.", QType: packet.AXFR, QClass: 1})
+		buf := packet.NewBytePacketBuffer()
+		_ = req.Write(buf)
+		if err := req.SignTSIG(buf, keyName, key); err != nil {
+			t.Fatalf("SignTSIG failed: %v", err)
+		}
+		msg := buf.Buf[:buf.Position()]
+		if _, err := conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(msg))), msg...)); err != nil {
+			t.Fatalf("failed to send request: %v", err)
+		}
+
+		var msgs []*packet.DNSPacket
+		var raws [][]byte
+		soas := 0
+		for soas < 2 {
+			var length [2]byte
+			if _, err := io.ReadFull(conn, length[:]); err != nil {
+				break
+			}
+			raw := make([]byte, binary.BigEndian.Uint16(length[:]))
+			if _, err := io.ReadFull(conn, raw); err != nil {
+				t.Fatalf("failed to read message: %v", err)
+			}
+			p := parseMessage(t, raw)
+			msgs = append(msgs, p)
+			raws = append(raws, raw)
+			if p.Header.ResCode != packet.RcodeNoError {
+				break
+			}
+			for _, rr := range p.Answers {
+				if rr.Type == packet.SOA {
+					soas++
+				}
+			}
+		}
+		return packet.NewTSIGStream(keyName, key, req.Resources[len(req.Resources)-1].MAC), msgs, raws
+	}
+
+	stream, msgs, raws := transfer("xfr-key.", secret)
+	answers := 0
+	for _, p := range msgs {
+		answers += len(p.Answers)
+	}
+	if answers != 4 {
+		t.Fatalf("Expected SOA, two records and SOA, got %d records in %d messages", answers, len(msgs))
+	}
+	for i, p := range msgs {
+		if p.TSIGStart == -1 {
+			t.Errorf("message %d is not signed", i)
+			continue
+		}
+		if err := stream.Verify(p, raws[i]); err != nil {
+			t.Errorf("message %d failed verification: %v", i, err)
+		}
+	}
+	if err := stream.Done(); err != nil {
+		t.Errorf("Expected the stream to end signed, got %v", err)
+	}
+
+	// An unknown key is refused with an unsigned BADKEY
+	_, msgs, _ = transfer("other-key.", secret)
+	if len(msgs) != 1 || msgs[0].Header.ResCode != packet.RcodeNotAuth || msgs[0].TSIGStart == -1 {
+		t.Fatalf("Expected a NOTAUTH reply carrying TSIG, got %+v", msgs)
+	}
+	if tsig := msgs[0].Resources[len(msgs[0].Resources)-1]; tsig.Error != packet.TSIGErrBadKey {
+		t.Errorf("Expected BADKEY, got %d", tsig.Error)
+	}
+
+	// A wrong secret is refused with BADSIG
+	_, msgs, _ = transfer("xfr-key.", []byte("wrong"))
+	if len(msgs) != 1 || msgs[0].Header.ResCode != packet.RcodeNotAuth {
+		t.Fatalf("Expected a NOTAUTH reply, got %+v", msgs)
+	}
+	if tsig := msgs[0].Resources[len(msgs[0].Resources)-1]; tsig.Error != packet.TSIGErrBadSig {
+		t.Errorf("Expected BADSIG, got %d", tsig.Error)
+	}
+}
+
+func TestTSIGSignedUpdateResponse(t *testing.T) {
+	repo := &mockServerRepo{zones: []domain.Zone{{ID: "z1", Name: "upd.test."}}}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+	srv.DisableAsync = true
+	secret := []byte("upd-secret")
+	srv.TsigKeys["upd-key."] = secret
+
+	req := packet.NewDNSPacket()
+	req.Header.ID = 99
+	req.Header.Opcode = packet.OpcodeUpdate
+	req.Questions = append(req.Questions, packet.DNSQuestion{Name: "upd.test.", QType: packet.SOA, QClass: 1})
+	req.Authorities = append(req.Authorities, packet.DNSRecord{Name: "new.upd.test.", Type: packet.A, Class: 1, TTL: 300, IP: net.ParseIP("192.0.2.9")})
+	buf := packet.NewBytePacketBuffer()
+	_ = req.Write(buf)
+	if err := req.SignTSIG(buf, "upd-key.", secret); err != nil {
+		t.Fatalf("SignTSIG failed: %v", err)
+	}
+
+	var reply []byte
+	if err := srv.handlePacket(buf.Buf[:buf.Position()], "127.0.0.1:5300", func(resp []byte) error {
+		reply = append([]byte(nil), resp...)
+		return nil
+	}, "udp"); err != nil {
+		t.Fatalf("handlePacket failed: %v", err)
+	}
+
+	resp := parseMessage(t, reply)
+	if resp.Header.ResCode != packet.RcodeNoError || resp.TSIGStart == -1 {
+		t.Fatalf("Expected a signed NOERROR response, got rcode %d, tsig at %d", resp.Header.ResCode, resp.TSIGStart)
+	}
+	stream := packet.NewTSIGStream("upd-key.", secret, req.Resources[len(req.Resources)-1].MAC)
+	if err := stream.Verify(resp, reply); err != nil {
+		t.Errorf("Expected the response MAC to cover the request MAC, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/infrastructure/metrics"
+)
+
+// DefaultUpdateDebounce is how long the NOTIFY that follows a dynamic update is
+// held back to coalesce a burst of updates to one zone.
+const DefaultUpdateDebounce = time.Second
+
+// zoneDebouncer coalesces repeated work per zone. The first trigger for a zone
+// runs at once; triggers arriving within the window after that are folded into
+// a single run when the window ends, so a burst costs at most one run per
+// window instead of one per event.
+type zoneDebouncer struct {
+	mu      sync.Mutex
+	pending map[string]*debounceState
+}
+
+type debounceState struct {
+	timer *time.Timer
+	dirty bool
+}
+
+func newZoneDebouncer() *zoneDebouncer {
+	return &zoneDebouncer{pending: make(map[string]*debounceState)}
+}
+
+// trigger runs fn for zone now, or once at the end of the current window if
+// zone already ran within it. A window of zero or less runs fn every time.
+// It reports whether fn ran immediately.
+func (d *zoneDebouncer) trigger(zone string, window time.Duration, fn func()) bool {
+	if window <= 0 {
+		fn()
+		return true
+	}
+
+	d.mu.Lock()
+	if st, ok := d.pending[zone]; ok {
+		st.dirty = true
+		d.mu.Unlock()
+		metrics.ZoneUpdatesCoalesced.Inc()
+		return false
+	}
+	st := &debounceState{}
+	st.timer = time.AfterFunc(window, func() { d.expire(zone, window, fn) })
+	d.pending[zone] = st
+	d.mu.Unlock()
+
+	fn()
+	return true
+}
+
+// expire ends a window for zone, running fn once more and opening a new window
+// if anything was coalesced during it.
+func (d *zoneDebouncer) expire(zone string, window time.Duration, fn func()) {
+	d.mu.Lock()
+	st, ok := d.pending[zone]
+	if !ok {
+		d.mu.Unlock()
+		return
+	}
+	if !st.dirty {
+		delete(d.pending, zone)
+		d.mu.Unlock()
+		return
+	}
+	st.dirty = false
+	st.timer.Reset(window)
+	d.mu.Unlock()
+
+	fn()
+}
+
+// zoneUpdated drops the cached answers a dynamic update to zoneName made stale,
+// on this node at once and on the others through Redis, and notifies the
+// zone's secondaries. Bursts of updates to the same zone within UpdateDebounce
+// are coalesced into one NOTIFY at the end of the window.
+func (s *Server) zoneUpdated(zoneName string, changes []cacheChange) {
+	for _, c := range changes {
+		s.invalidateLocal(c)
+	}
+	if s.DisableAsync {
+		s.publishInvalidations(changes)
+		return
+	}
+	if len(changes) > 0 {
+		go s.publishInvalidations(changes)
+	}
+	s.updates.trigger(zoneName, s.UpdateDebounce, func() {
+		go s.notifySlaves(zoneName)
+	})
+}
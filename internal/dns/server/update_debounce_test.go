@@ -0,0 +1,60 @@
+package server
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestZoneDebouncer(t *testing.T) {
+	d := newZoneDebouncer()
+	var runs atomic.Int32
+	fn := func() { runs.Add(1) }
+
+	if !d.trigger("a.test.", 50*time.Millisecond, fn) {
+		t.Fatal("Expected the first trigger to run immediately")
+	}
+	for i := 0; i < 10; i++ {
+		if d.trigger("a.test.", 50*time.Millisecond, fn) {
+			t.Fatal("Expected triggers within the window to be coalesced")
+		}
+	}
+	// Another zone has its own window
+	if !d.trigger("b.test.", 50*time.Millisecond, fn) {
+		t.Fatal("Expected a different zone to run immediately")
+	}
+	if got := runs.Load(); got != 2 {
+		t.Fatalf("Expected 2 immediate runs, got %d", got)
+	}
+
+	// The coalesced burst runs once when the window ends, and the zone is
+	// released after a quiet window
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		d.mu.Lock()
+		n := len(d.pending)
+		d.mu.Unlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected pending windows to expire")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := runs.Load(); got != 3 {
+		t.Errorf("Expected one trailing run for the burst, got %d runs", got)
+	}
+
+	if !d.trigger("a.test.", 50*time.Millisecond, fn) {
+		t.Error("Expected a trigger after the window to run immediately")
+	}
+
+	// A zero window never coalesces
+	zero := newZoneDebouncer()
+	for i := 0; i < 3; i++ {
+		if !zero.trigger("a.test.", 0, fn) {
+			t.Fatal("Expected every trigger to run without a window")
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"errors"
+	"net"
+
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+// xfrMessageLimit caps the size of a zone transfer message below the 64KB TCP
+// limit, leaving room for the TSIG record of a signed transfer.
+const xfrMessageLimit = packet.MaxPacketSize - 1024
+
+// errRecordTooLarge is returned for a record that does not fit in a transfer
+// message on its own.
+var errRecordTooLarge = errors.New("record too large for a transfer message")
+
+// xfrWriter streams the records of a zone transfer, packing as many into each
+// TCP message as fit (RFC 5936 2.2) and compressing names within a message.
+type xfrWriter struct {
+	conn     net.Conn
+	response *packet.DNSPacket
+	buf      *packet.BytePacketBuffer
+	// body is where the records of the current message start
+	body     int
+	answers  int
+	messages int
+}
+
+// newXFRWriter starts a transfer on conn answering question q of request id.
+// close must be called to return its buffer.
+func newXFRWriter(conn net.Conn, id uint16, q packet.DNSQuestion) *xfrWriter {
+	response := packet.NewDNSPacket()
+	response.Header.ID = id
+	response.Header.Response = true
+	response.Header.AuthoritativeAnswer = true
+	response.Questions = append(response.Questions, q)
+	w := &xfrWriter{conn: conn, response: response, buf: packet.GetBuffer()}
+	w.start()
+	return w
+}
+
+// start begins a new message with the header and question, whose zone name
+// the records' names are then compressed against.
+func (w *xfrWriter) start() {
+	w.buf.Reset()
+	w.buf.HasNames = true
+	_ = w.response.Write(w.buf)
+	w.body = w.buf.Position()
+	w.answers = 0
+}
+
+// add appends rec to the current message, sending the message first if rec
+// does not fit.
+func (w *xfrWriter) add(rec packet.DNSRecord) error {
+	if w.answers > 0 {
+		mark := w.buf.Position()
+		if _, err := rec.Write(w.buf); err == nil && w.buf.Position() <= xfrMessageLimit {
+			w.answers++
+			return nil
+		}
+		w.buf.Truncate(mark)
+		if err := w.flush(); err != nil {
+			return err
+		}
+	}
+	if _, err := rec.Write(w.buf); err != nil || w.buf.Position() > xfrMessageLimit {
+		w.buf.Truncate(w.body)
+		if err == nil {
+			err = errRecordTooLarge
+		}
+		return err
+	}
+	w.answers++
+	return nil
+}
+
+// flush sends the current message, if it has any records, and starts the next.
+func (w *xfrWriter) flush() error {
+	if w.answers == 0 {
+		return nil
+	}
+	// ANCOUNT follows the ID, flags and QDCOUNT
+	_ = w.buf.WriteRange(6, []byte{byte(w.answers >> 8), byte(w.answers)})
+	msg := w.buf.Buf[:w.buf.Position()]
+	msgLen := uint16(len(msg)) // #nosec G115
+	if _, err := w.conn.Write(append([]byte{byte(msgLen >> 8), byte(msgLen & 0xFF)}, msg...)); err != nil {
+		return err
+	}
+	w.messages++
+	w.start()
+	return nil
+}
+
+// close returns the writer's buffer to the pool.
+func (w *xfrWriter) close() {
+	packet.PutBuffer(w.buf)
+}
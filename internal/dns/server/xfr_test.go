@@ -0,0 +1,96 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+func TestAXFRPacksRecordsIntoMessages(t *testing.T) {
+	const hosts = 20000
+	records := []domain.Record{
+		{ID: "soa", ZoneID: "z1", Name: "big.test.", Type: domain.TypeSOA, Content: "ns1.big.test. admin.big.test. 7 3600 600 1209600 300", TTL: 3600},
+	}
+	for i := 0; i < hosts; i++ {
+		records = append(records, domain.Record{
+			ID: fmt.Sprintf("r%d", i), ZoneID: "z1", Name: fmt.Sprintf("host%d.big.test.", i),
+			Type: domain.TypeA, Content: fmt.Sprintf("10.%d.%d.%d", i>>16&0xff, i>>8&0xff, i&0xff), TTL: 300,
+		})
+	}
+	repo := &mockServerRepo{zones: []domain.Zone{{ID: "z1", Name: "big.test."}}, records: records}
+	srv := NewServer("127.0.0.1:0", repo, nil)
+
+	req := packet.NewDNSPacket()
+	req.Header.ID = 42
+	req.Questions = append(req.Questions, packet.DNSQuestion{Name: "big.test.", QType: packet.AXFR})
+	conn := &mockTCPConn{}
+	srv.handleAXFR(conn, req)
+
+	if len(conn.captured) < 2 || len(conn.captured) > 10 {
+		t.Fatalf("Expected %d records in a handful of messages, got %d messages", hosts+2, len(conn.captured))
+	}
+	var got []packet.DNSRecord
+	for i, msg := range conn.captured {
+		if len(msg) > xfrMessageLimit {
+			t.Errorf("Message %d is %d bytes, over the %d byte limit", i, len(msg), xfrMessageLimit)
+		}
+		resp := packet.NewDNSPacket()
+		buf := packet.NewBytePacketBuffer()
+		buf.Load(msg)
+		if err := resp.FromBuffer(buf); err != nil {
+			t.Fatalf("Message %d does not parse: %v", i, err)
+		}
+		if resp.Header.ID != 42 || !resp.Header.AuthoritativeAnswer || len(resp.Questions) != 1 {
+			t.Errorf("Message %d has an unexpected header %+v", i, resp.Header)
+		}
+		got = append(got, resp.Answers...)
+	}
+	if len(got) != hosts+2 || got[0].Type != packet.SOA || got[len(got)-1].Type != packet.SOA {
+		t.Fatalf("Expected SOA, %d A records and SOA, got %d records", hosts, len(got))
+	}
+	seen := make(map[string]bool, hosts)
+	for _, rec := range got[1 : len(got)-1] {
+		if rec.Type != packet.A || !strings.HasSuffix(rec.Name, ".big.test.") {
+			t.Fatalf("Unexpected record %+v", rec)
+		}
+		seen[strings.ToLower(rec.Name)] = true
+	}
+	if len(seen) != hosts {
+		t.Errorf("Expected %d distinct names, got %d", hosts, len(seen))
+	}
+}
+
+func TestXFRWriterRejectsOversizedRecord(t *testing.T) {
+	conn := &mockTCPConn{}
+	w := newXFRWriter(conn, 1, packet.DNSQuestion{Name: "big.test.", QType: packet.AXFR})
+	defer w.close()
+
+	if err := w.add(packet.DNSRecord{Name: "a.big.test.", Type: packet.A, TTL: 60, IP: []byte{192, 0, 2, 1}}); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	huge := packet.DNSRecord{Name: "txt.big.test.", Type: packet.TXT, TTL: 60, Txt: strings.Repeat("x", xfrMessageLimit)}
+	if err := w.add(huge); err != errRecordTooLarge {
+		t.Fatalf("Expected errRecordTooLarge, got %v", err)
+	}
+	// The record before it was sent and the writer is usable again
+	if err := w.add(packet.DNSRecord{Name: "b.big.test.", Type: packet.A, TTL: 60, IP: []byte{192, 0, 2, 2}}); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	if err := w.flush(); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+	if w.messages != 2 || len(conn.captured) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(conn.captured))
+	}
+	for _, msg := range conn.captured {
+		resp := packet.NewDNSPacket()
+		buf := packet.NewBytePacketBuffer()
+		buf.Load(msg)
+		if err := resp.FromBuffer(buf); err != nil || len(resp.Answers) != 1 || resp.Answers[0].Type != packet.A {
+			t.Errorf("Expected one A record per message, got %+v: %v", resp.Answers, err)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+)
+
+// notifyAckTimeout is how long an on-demand NOTIFY waits for each secondary
+const notifyAckTimeout = 2 * time.Second
+
+// syncZone loads a tenant's zone for an on-demand transfer or NOTIFY.
+func (s *Server) syncZone(ctx context.Context, zoneID string, tenantID string) (*domain.Zone, error) {
+	zone, err := s.Repo.GetZoneByID(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load zone: %w", err)
+	}
+	if zone == nil {
+		return nil, fmt.Errorf("zone %s: %w", zoneID, domain.ErrNotFound)
+	}
+	return zone, nil
+}
+
+// TransferZone pulls a secondary zone from its configured master now, instead
+// of waiting for the master's NOTIFY.
+func (s *Server) TransferZone(ctx context.Context, zoneID string, tenantID string) (*domain.ZoneTransferResult, error) {
+	zone, err := s.syncZone(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if zone.Role != "slave" {
+		return nil, fmt.Errorf("zone %s is not a secondary: %w", zone.Name, domain.ErrInvalidZoneRole)
+	}
+	return s.transferFromMaster(ctx, zone)
+}
+
+// NotifyZone sends NOTIFY for a primary zone to its secondaries now and reports
+// which of them acknowledged it.
+func (s *Server) NotifyZone(ctx context.Context, zoneID string, tenantID string) (*domain.NotifyResult, error) {
+	zone, err := s.syncZone(ctx, zoneID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if zone.Role == "slave" {
+		return nil, fmt.Errorf("zone %s is a secondary: %w", zone.Name, domain.ErrInvalidZoneRole)
+	}
+	return &domain.NotifyResult{
+		ZoneID:  zone.ID,
+		Zone:    zone.Name,
+		Targets: s.sendNotifies(ctx, zone.Name, notifyAckTimeout),
+	}, nil
+}
@@ -0,0 +1,247 @@
+// Package svcb converts the SvcParams of SVCB and HTTPS records (RFC 9460)
+// between presentation format, such as "alpn=h2,h3 ipv4hint=192.0.2.1", and
+// their wire-format values.
+//
+// Values containing commas or spaces cannot be expressed; the escaping rules of
+// RFC 9460 Appendix A are not supported.
+package svcb
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SvcParamKeys registered by RFC 9460 Section 14.3.2.
+const (
+	KeyMandatory     uint16 = 0
+	KeyALPN          uint16 = 1
+	KeyNoDefaultALPN uint16 = 2
+	KeyPort          uint16 = 3
+	KeyIPv4Hint      uint16 = 4
+	KeyECH           uint16 = 5
+	KeyIPv6Hint      uint16 = 6
+	// keyInvalid is reserved and may never appear in a record.
+	keyInvalid uint16 = 65535
+)
+
+var keyNames = map[uint16]string{
+	KeyMandatory:     "mandatory",
+	KeyALPN:          "alpn",
+	KeyNoDefaultALPN: "no-default-alpn",
+	KeyPort:          "port",
+	KeyIPv4Hint:      "ipv4hint",
+	KeyECH:           "ech",
+	KeyIPv6Hint:      "ipv6hint",
+}
+
+// Param is a single SvcParam with its value in wire format.
+type Param struct {
+	Key   uint16
+	Value []byte
+}
+
+// KeyName returns the presentation name of key, "keyNNNNN" when unregistered.
+func KeyName(key uint16) string {
+	if name, ok := keyNames[key]; ok {
+		return name
+	}
+	return fmt.Sprintf("key%d", key)
+}
+
+// ParseKey returns the key with presentation name name.
+func ParseKey(name string) (uint16, error) {
+	for key, known := range keyNames {
+		if known == name {
+			return key, nil
+		}
+	}
+	if num, ok := strings.CutPrefix(name, "key"); ok {
+		key, err := strconv.ParseUint(num, 10, 16)
+		if err == nil && uint16(key) != keyInvalid {
+			return uint16(key), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown SvcParamKey %q", name)
+}
+
+// Parse parses presentation-format SvcParams, one "key" or "key=value" per
+// field, and returns them in ascending key order as the wire format requires.
+func Parse(fields []string) ([]Param, error) {
+	var params []Param
+	for _, field := range fields {
+		name, val, hasVal := strings.Cut(field, "=")
+		key, err := ParseKey(strings.ToLower(name))
+		if err != nil {
+			return nil, err
+		}
+		val = strings.Trim(val, `"`)
+		value, err := parseValue(key, val, hasVal)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		params = append(params, Param{Key: key, Value: value})
+	}
+	slices.SortFunc(params, func(a, b Param) int { return int(a.Key) - int(b.Key) })
+	if err := Check(params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// Check verifies that params are in strictly ascending key order and
+// self-consistent: every mandatory key is present and no-default-alpn comes
+// with alpn.
+func Check(params []Param) error {
+	present := make(map[uint16]bool, len(params))
+	for i, p := range params {
+		if i > 0 && p.Key <= params[i-1].Key {
+			if p.Key == params[i-1].Key {
+				return fmt.Errorf("duplicate SvcParamKey %s", KeyName(p.Key))
+			}
+			return fmt.Errorf("SvcParamKeys are not in ascending order")
+		}
+		present[p.Key] = true
+	}
+	for _, p := range params {
+		if p.Key != KeyMandatory {
+			continue
+		}
+		for i := 0; i+1 < len(p.Value); i += 2 {
+			key := binary.BigEndian.Uint16(p.Value[i:])
+			if key == KeyMandatory {
+				return fmt.Errorf("mandatory cannot list itself")
+			}
+			if !present[key] {
+				return fmt.Errorf("mandatory key %s is missing", KeyName(key))
+			}
+		}
+	}
+	if present[KeyNoDefaultALPN] && !present[KeyALPN] {
+		return fmt.Errorf("no-default-alpn requires alpn")
+	}
+	return nil
+}
+
+func parseValue(key uint16, val string, hasVal bool) ([]byte, error) {
+	if key == KeyNoDefaultALPN {
+		if hasVal {
+			return nil, fmt.Errorf("takes no value")
+		}
+		return nil, nil
+	}
+	if val == "" {
+		return nil, fmt.Errorf("a value is required")
+	}
+	var out []byte
+	switch key {
+	case KeyMandatory:
+		var keys []uint16
+		for _, name := range strings.Split(val, ",") {
+			k, err := ParseKey(name)
+			if err != nil {
+				return nil, err
+			}
+			if slices.Contains(keys, k) {
+				return nil, fmt.Errorf("duplicate key %s", name)
+			}
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			out = binary.BigEndian.AppendUint16(out, k)
+		}
+	case KeyALPN:
+		for _, id := range strings.Split(val, ",") {
+			if id == "" || len(id) > 255 {
+				return nil, fmt.Errorf("invalid protocol id %q", id)
+			}
+			out = append(out, byte(len(id)))
+			out = append(out, id...)
+		}
+	case KeyPort:
+		port, err := strconv.ParseUint(val, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port %q", val)
+		}
+		out = binary.BigEndian.AppendUint16(out, uint16(port))
+	case KeyIPv4Hint, KeyIPv6Hint:
+		for _, addr := range strings.Split(val, ",") {
+			ip := net.ParseIP(addr)
+			if ip == nil || (key == KeyIPv4Hint) != (ip.To4() != nil && !strings.Contains(addr, ":")) {
+				return nil, fmt.Errorf("invalid address %q", addr)
+			}
+			if key == KeyIPv4Hint {
+				out = append(out, ip.To4()...)
+			} else {
+				out = append(out, ip.To16()...)
+			}
+		}
+	case KeyECH:
+		config, err := base64.StdEncoding.DecodeString(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 ECHConfigList")
+		}
+		out = config
+	default:
+		out = []byte(val)
+	}
+	if len(out) > 65535 {
+		return nil, fmt.Errorf("value too long")
+	}
+	return out, nil
+}
+
+// String returns the param in presentation format.
+func (p Param) String() string {
+	name := KeyName(p.Key)
+	if p.Key == KeyNoDefaultALPN {
+		return name
+	}
+	var vals []string
+	switch p.Key {
+	case KeyMandatory:
+		for i := 0; i+1 < len(p.Value); i += 2 {
+			vals = append(vals, KeyName(binary.BigEndian.Uint16(p.Value[i:])))
+		}
+	case KeyALPN:
+		for i := 0; i < len(p.Value); {
+			n := int(p.Value[i])
+			if i+1+n > len(p.Value) {
+				break
+			}
+			vals = append(vals, string(p.Value[i+1:i+1+n]))
+			i += 1 + n
+		}
+	case KeyPort:
+		if len(p.Value) == 2 {
+			vals = append(vals, strconv.Itoa(int(binary.BigEndian.Uint16(p.Value))))
+		}
+	case KeyIPv4Hint, KeyIPv6Hint:
+		size := net.IPv4len
+		if p.Key == KeyIPv6Hint {
+			size = net.IPv6len
+		}
+		for i := 0; i+size <= len(p.Value); i += size {
+			vals = append(vals, net.IP(p.Value[i:i+size]).String())
+		}
+	case KeyECH:
+		vals = append(vals, base64.StdEncoding.EncodeToString(p.Value))
+	default:
+		vals = append(vals, string(p.Value))
+	}
+	return name + "=" + strings.Join(vals, ",")
+}
+
+// Format returns params in presentation format, separated by spaces.
+func Format(params []Param) string {
+	parts := make([]string, len(params))
+	for i, p := range params {
+		parts[i] = p.String()
+	}
+	return strings.Join(parts, " ")
+}
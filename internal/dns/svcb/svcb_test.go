@@ -0,0 +1,49 @@
+package svcb
+
+import (
+	"testing"
+)
+
+func TestParseAndFormat(t *testing.T) {
+	params, err := Parse([]string{"ipv4hint=192.0.2.1,192.0.2.2", "alpn=h2,h3", "port=8443", "mandatory=alpn,port", "ech=AEX+DQ==", "ipv6hint=2001:db8::1", "key667=hello"})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	for i := 1; i < len(params); i++ {
+		if params[i].Key <= params[i-1].Key {
+			t.Fatalf("Expected ascending keys, got %+v", params)
+		}
+	}
+	want := "mandatory=alpn,port alpn=h2,h3 port=8443 ipv4hint=192.0.2.1,192.0.2.2 ech=AEX+DQ== ipv6hint=2001:db8::1 key667=hello"
+	if got := Format(params); got != want {
+		t.Errorf("Format mismatch:\n got %s\nwant %s", got, want)
+	}
+	if alpn := params[1].Value; string(alpn) != "\x02h2\x02h3" {
+		t.Errorf("Unexpected alpn wire value %q", alpn)
+	}
+
+	if params, err := Parse([]string{"alpn=h2", "no-default-alpn"}); err != nil || Format(params) != "alpn=h2 no-default-alpn" {
+		t.Errorf("Expected no-default-alpn to round-trip, got %v, %v", params, err)
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	for _, bad := range [][]string{
+		{"color=red"},
+		{"key65535=x"},
+		{"alpn=h2", "alpn=h3"},
+		{"alpn="},
+		{"port=70000"},
+		{"ipv4hint=2001:db8::1"},
+		{"ipv6hint=192.0.2.1"},
+		{"ech=not base64!"},
+		{"no-default-alpn"},
+		{"no-default-alpn=x", "alpn=h2"},
+		{"mandatory=port"},
+		{"mandatory=mandatory"},
+	} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Expected %v to be rejected", bad)
+		}
+	}
+}
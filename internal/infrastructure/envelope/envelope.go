@@ -0,0 +1,252 @@
+// Package envelope encrypts secrets for storage with envelope encryption: every
+// value is sealed with AES-256-GCM under its own random data key, and the data
+// key is wrapped by a key-encryption key (KEK). Rotating the KEK only rewraps
+// the small data keys, and a KEK held in an external KMS can be plugged in by
+// implementing KEK.
+//
+// Sealed values are ASCII, so they fit both text and binary columns:
+//
+//	enc:v1:<kek id>:<base64 wrapped data key>:<base64 nonce and ciphertext>
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const prefix = "enc:v1:"
+
+// ErrNoKEK is returned when a value was sealed with a KEK the keyring lacks.
+var ErrNoKEK = errors.New("envelope: unknown key-encryption key")
+
+// KEK wraps and unwraps data keys.
+type KEK interface {
+	// ID names the KEK in sealed values. It may not contain ':'.
+	ID() string
+	Wrap(dataKey []byte) ([]byte, error)
+	Unwrap(wrapped []byte) ([]byte, error)
+}
+
+// localKEK is a KEK held in memory, wrapping data keys with AES-256-GCM.
+type localKEK struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// NewLocalKEK returns a KEK for a 32-byte AES-256 key held in memory.
+func NewLocalKEK(id string, key []byte) (KEK, error) {
+	if id == "" || strings.Contains(id, ":") {
+		return nil, fmt.Errorf("envelope: invalid key id %q", id)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("envelope: key %s must be 32 bytes, got %d", id, len(key))
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return &localKEK{id: id, aead: aead}, nil
+}
+
+func (k *localKEK) ID() string { return k.id }
+
+func (k *localKEK) Wrap(dataKey []byte) ([]byte, error) {
+	return seal(k.aead, dataKey, []byte(k.id))
+}
+
+func (k *localKEK) Unwrap(wrapped []byte) ([]byte, error) {
+	return open(k.aead, wrapped, []byte(k.id))
+}
+
+// Keyring seals values with its current KEK and opens values sealed with any of
+// its KEKs, so that older KEKs can be kept until every value is rewrapped.
+type Keyring struct {
+	current KEK
+	keks    map[string]KEK
+}
+
+// NewKeyring returns a keyring sealing with current and also opening values
+// sealed with older.
+func NewKeyring(current KEK, older ...KEK) *Keyring {
+	k := &Keyring{current: current, keks: map[string]KEK{current.ID(): current}}
+	for _, kek := range older {
+		k.keks[kek.ID()] = kek
+	}
+	return k
+}
+
+// ParseKeyring builds a keyring of local KEKs from a comma-separated list of
+// "id:base64key" pairs. The KEK named current seals new values; when current is
+// empty the first one does.
+func ParseKeyring(spec string, current string) (*Keyring, error) {
+	var keks []KEK
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(pair, ":")
+		if !ok {
+			return nil, fmt.Errorf("envelope: key %q is not of the form id:base64key", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("envelope: key %s is not valid base64", id)
+		}
+		kek, err := NewLocalKEK(id, key)
+		if err != nil {
+			return nil, err
+		}
+		keks = append(keks, kek)
+	}
+	if len(keks) == 0 {
+		return nil, errors.New("envelope: no keys given")
+	}
+	if current == "" {
+		return NewKeyring(keks[0], keks[1:]...), nil
+	}
+	for i, kek := range keks {
+		if kek.ID() == current {
+			older := append(append([]KEK{}, keks[:i]...), keks[i+1:]...)
+			return NewKeyring(kek, older...), nil
+		}
+	}
+	return nil, fmt.Errorf("envelope: current key %q is not in the keyring", current)
+}
+
+// CurrentID returns the ID of the KEK new values are sealed with.
+func (k *Keyring) CurrentID() string {
+	return k.current.ID()
+}
+
+// IsSealed reports whether value was produced by Seal.
+func IsSealed(value []byte) bool {
+	return strings.HasPrefix(string(value), prefix)
+}
+
+// Seal encrypts plaintext under a fresh data key. aad binds the value to where
+// it is stored, such as a column and row ID, and must be given again to Open.
+func (k *Keyring) Seal(plaintext, aad []byte) ([]byte, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := seal(aead, plaintext, aad)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := k.current.Wrap(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: failed to wrap data key: %w", err)
+	}
+	return format(k.current.ID(), wrapped, ciphertext), nil
+}
+
+// Open decrypts a value produced by Seal with the same aad.
+func (k *Keyring) Open(sealed, aad []byte) ([]byte, error) {
+	kek, wrapped, ciphertext, err := k.parse(sealed)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := kek.Unwrap(wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: failed to unwrap data key: %w", err)
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := open(aead, ciphertext, aad)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: failed to decrypt value: %w", err)
+	}
+	return plaintext, nil
+}
+
+// NeedsRewrap reports whether sealed was wrapped by a KEK other than the current one.
+func (k *Keyring) NeedsRewrap(sealed []byte) bool {
+	id, _, ok := strings.Cut(strings.TrimPrefix(string(sealed), prefix), ":")
+	return ok && id != k.current.ID()
+}
+
+// Rewrap moves a sealed value to the current KEK without decrypting it: only
+// its data key is unwrapped and wrapped again.
+func (k *Keyring) Rewrap(sealed []byte) ([]byte, error) {
+	kek, wrapped, ciphertext, err := k.parse(sealed)
+	if err != nil {
+		return nil, err
+	}
+	if kek.ID() == k.current.ID() {
+		return sealed, nil
+	}
+	dataKey, err := kek.Unwrap(wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: failed to unwrap data key: %w", err)
+	}
+	if wrapped, err = k.current.Wrap(dataKey); err != nil {
+		return nil, fmt.Errorf("envelope: failed to wrap data key: %w", err)
+	}
+	return format(k.current.ID(), wrapped, ciphertext), nil
+}
+
+func (k *Keyring) parse(sealed []byte) (KEK, []byte, []byte, error) {
+	rest, ok := strings.CutPrefix(string(sealed), prefix)
+	if !ok {
+		return nil, nil, nil, errors.New("envelope: value is not sealed")
+	}
+	parts := strings.Split(rest, ":")
+	if len(parts) != 3 {
+		return nil, nil, nil, errors.New("envelope: malformed sealed value")
+	}
+	kek, ok := k.keks[parts[0]]
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("%w %q", ErrNoKEK, parts[0])
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, nil, nil, errors.New("envelope: malformed data key")
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, nil, nil, errors.New("envelope: malformed ciphertext")
+	}
+	return kek, wrapped, ciphertext, nil
+}
+
+func format(kekID string, wrapped, ciphertext []byte) []byte {
+	return []byte(prefix + kekID + ":" + base64.StdEncoding.EncodeToString(wrapped) + ":" +
+		base64.StdEncoding.EncodeToString(ciphertext))
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal returns the random nonce followed by the ciphertext.
+func seal(aead cipher.AEAD, plaintext, aad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(aead cipher.AEAD, sealed, aad []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	return aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], aad)
+}
@@ -0,0 +1,97 @@
+package envelope
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testKeyring(t *testing.T, spec, current string) *Keyring {
+	t.Helper()
+	k, err := ParseKeyring(spec, current)
+	if err != nil {
+		t.Fatalf("ParseKeyring failed: %v", err)
+	}
+	return k
+}
+
+func keySpec(id string, b byte) string {
+	return id + ":" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32))
+}
+
+func TestSealOpen(t *testing.T) {
+	k := testKeyring(t, keySpec("k1", 1), "")
+	aad := []byte("dnssec_keys.private_key:abc")
+
+	sealed, err := k.Seal([]byte("secret"), aad)
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+	if !IsSealed(sealed) || !strings.HasPrefix(string(sealed), "enc:v1:k1:") || bytes.Contains(sealed, []byte("secret")) {
+		t.Fatalf("Unexpected sealed value %q", sealed)
+	}
+	again, _ := k.Seal([]byte("secret"), aad)
+	if bytes.Equal(sealed, again) {
+		t.Error("Expected every seal to use a fresh data key and nonce")
+	}
+
+	plain, err := k.Open(sealed, aad)
+	if err != nil || string(plain) != "secret" {
+		t.Fatalf("Open = %q, %v", plain, err)
+	}
+	if _, err := k.Open(sealed, []byte("dnssec_keys.private_key:other")); err == nil {
+		t.Error("Expected a value moved to another row to fail to open")
+	}
+	tampered := append([]byte{}, sealed...)
+	tampered[len(tampered)-2] ^= 'A' ^ 'B'
+	if _, err := k.Open(tampered, aad); err == nil {
+		t.Error("Expected a tampered value to fail to open")
+	}
+	if _, err := k.Open([]byte("plain"), aad); err == nil || IsSealed([]byte("plain")) {
+		t.Error("Expected an unsealed value to be rejected")
+	}
+}
+
+func TestRotation(t *testing.T) {
+	old := testKeyring(t, keySpec("k1", 1), "")
+	sealed, _ := old.Seal([]byte("secret"), nil)
+
+	rotated := testKeyring(t, keySpec("k1", 1)+","+keySpec("k2", 2), "k2")
+	if rotated.CurrentID() != "k2" || !rotated.NeedsRewrap(sealed) {
+		t.Fatal("Expected a value sealed with k1 to need rewrapping")
+	}
+	rewrapped, err := rotated.Rewrap(sealed)
+	if err != nil || rotated.NeedsRewrap(rewrapped) || !strings.HasPrefix(string(rewrapped), "enc:v1:k2:") {
+		t.Fatalf("Rewrap = %q, %v", rewrapped, err)
+	}
+	if plain, err := rotated.Open(rewrapped, nil); err != nil || string(plain) != "secret" {
+		t.Fatalf("Open after rewrap = %q, %v", plain, err)
+	}
+
+	// Once k1 is retired only rewrapped values open
+	retired := testKeyring(t, keySpec("k2", 2), "")
+	if _, err := retired.Open(sealed, nil); !errors.Is(err, ErrNoKEK) {
+		t.Errorf("Expected ErrNoKEK, got %v", err)
+	}
+	if _, err := retired.Open(rewrapped, nil); err != nil {
+		t.Errorf("Expected the rewrapped value to open, got %v", err)
+	}
+}
+
+func TestParseKeyringErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"k1",
+		"k1:not-base64!",
+		"k1:" + base64.StdEncoding.EncodeToString([]byte("short")),
+	} {
+		if _, err := ParseKeyring(spec, ""); err == nil {
+			t.Errorf("Expected %q to be rejected", spec)
+		}
+	}
+	if _, err := ParseKeyring(keySpec("k1", 1), "k9"); err == nil {
+		t.Error("Expected an unknown current key to be rejected")
+	}
+}
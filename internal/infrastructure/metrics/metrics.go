@@ -25,6 +25,110 @@ var (
 		Help: "Total number of cache hits and misses",
 	}, []string{"level", "result"})
 
+	// CacheAdmissionRejected tracks responses kept out of a cache by the admission policy
+	CacheAdmissionRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clouddns_cache_admission_rejected_total",
+		Help: "Total number of responses not cached because they exceeded the size or record count limit",
+	}, []string{"level", "reason"})
+
+	// CachedResponseSize tracks the compressed wire size of responses admitted to the cache
+	CachedResponseSize = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "clouddns_cached_response_size_bytes",
+		Help:    "Histogram of the wire size of responses admitted to the cache",
+		Buckets: prometheus.ExponentialBuckets(64, 2, 11),
+	}, []string{"level"})
+
+	// UpstreamResponsesRejected tracks upstream replies discarded because they did not answer the query sent
+	UpstreamResponsesRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clouddns_upstream_responses_rejected_total",
+		Help: "Total number of upstream replies discarded for a mismatched ID, opcode or question, or for failing to parse",
+	}, []string{"reason"})
+
+	// UpstreamRecordsDropped tracks unsolicited or out-of-bailiwick records removed from upstream replies
+	UpstreamRecordsDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clouddns_upstream_records_dropped_total",
+		Help: "Total number of records removed from upstream replies as unsolicited or out of bailiwick",
+	}, []string{"section"})
+
+	// RedisOperationDuration tracks the latency of L2 cache reads and pipelined writes
+	RedisOperationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "clouddns_redis_operation_duration_seconds",
+		Help:    "Histogram of Redis operation latency",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"op"})
+
+	// RedisErrors tracks failed or timed out Redis operations
+	RedisErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clouddns_redis_errors_total",
+		Help: "Total number of failed Redis operations",
+	}, []string{"op"})
+
+	// RedisWriteQueueDepth tracks responses waiting to be written to Redis
+	RedisWriteQueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "clouddns_redis_write_queue_depth",
+		Help: "Number of cache writes queued for Redis",
+	})
+
+	// RateLimitDecisions tracks queries allowed or dropped by the per-client rate limiter
+	RateLimitDecisions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clouddns_rate_limit_decisions_total",
+		Help: "Total number of rate limiter decisions by outcome and backend (local or redis)",
+	}, []string{"decision", "backend"})
+
+	// RedisWritesDropped tracks cache writes discarded because the queue was full
+	RedisWritesDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "clouddns_redis_writes_dropped_total",
+		Help: "Total number of Redis cache writes dropped because the write queue was full",
+	})
+
+	// ZoneUpdatesCoalesced tracks dynamic updates whose NOTIFY was folded into an earlier one
+	ZoneUpdatesCoalesced = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "clouddns_zone_updates_coalesced_total",
+		Help: "Total number of dynamic updates whose NOTIFY was coalesced with another update to the same zone",
+	})
+
+	// DataChangesReceived tracks record and zone changes announced by the database
+	DataChangesReceived = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clouddns_data_changes_received_total",
+		Help: "Total number of database change notifications received, by table",
+	}, []string{"table"})
+
+	// ScheduledChangesApplied tracks scheduled record changes run by the scheduler
+	ScheduledChangesApplied = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clouddns_scheduled_changes_applied_total",
+		Help: "Total number of scheduled record changes run, by outcome",
+	}, []string{"status"})
+
+	// ChangeFreezeDecisions tracks changes met by an active change freeze
+	ChangeFreezeDecisions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clouddns_change_freeze_decisions_total",
+		Help: "Total number of changes made during a change freeze, by decision (rejected, break_glass)",
+	}, []string{"decision"})
+
+	// ChangeRequests tracks change requests of zones under review by outcome
+	ChangeRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clouddns_change_requests_total",
+		Help: "Total number of change requests, by status (pending, applied, failed, rejected)",
+	}, []string{"status"})
+
+	// RedisShardHealthy reports whether each Redis cache shard is part of the hash ring
+	RedisShardHealthy = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "clouddns_redis_shard_healthy",
+		Help: "Whether a Redis cache shard is in use (1) or excluded after failures (0)",
+	}, []string{"shard"})
+
+	// DNS64Synthesized tracks AAAA answers synthesized from A records, by DNS64 view
+	DNS64Synthesized = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clouddns_dns64_synthesized_total",
+		Help: "Total number of AAAA answers synthesized by DNS64 (RFC 6147), by view",
+	}, []string{"view"})
+
+	// SearchSuffixAnswers tracks single-label queries answered through a search suffix, by view
+	SearchSuffixAnswers = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clouddns_search_suffix_answers_total",
+		Help: "Total number of single-label queries answered by completing them with a search suffix, by view",
+	}, []string{"view"})
+
 	// ActiveWorkers tracks number of busy UDP workers
 	ActiveWorkers = promauto.NewGauge(prometheus.GaugeOpts{
 		Name: "clouddns_active_workers",
@@ -37,9 +141,263 @@ var (
 		Help: "Number of active database connections",
 	})
 
+	// DBFailoversTotal tracks database failover attempts by outcome
+	DBFailoversTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clouddns_db_failovers_total",
+		Help: "Total number of database failover attempts",
+	}, []string{"result"})
+
+	// DBActiveEndpoint reports which configured database endpoint is the active primary
+	DBActiveEndpoint = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "clouddns_db_active_endpoint",
+		Help: "Index of the database endpoint currently used as primary (0 = DATABASE_URL)",
+	})
+
+	// DBCircuitState reports the repository circuit breaker state
+	DBCircuitState = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "clouddns_db_circuit_state",
+		Help: "State of the database circuit breaker (0 = closed, 1 = open, 2 = half-open)",
+	})
+
+	// DBCircuitTransitions tracks circuit breaker state changes
+	DBCircuitTransitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clouddns_db_circuit_transitions_total",
+		Help: "Total number of database circuit breaker state transitions",
+	}, []string{"to"})
+
+	// EventSubscribers tracks open live event stream subscriptions
+	EventSubscribers = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "clouddns_event_subscribers",
+		Help: "Number of open live event stream subscriptions",
+	})
+
+	// EventsDropped tracks live events discarded because a subscriber fell behind
+	EventsDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "clouddns_events_dropped_total",
+		Help: "Total number of live events dropped for slow subscribers",
+	})
+
+	// SyntheticProbes tracks synthetic zone probes by query type and verdict
+	SyntheticProbes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clouddns_synthetic_probes_total",
+		Help: "Total number of synthetic zone probes by result",
+	}, []string{"qtype", "vantage", "result"})
+
+	// SyntheticProbeDuration tracks the round-trip time of synthetic probes
+	SyntheticProbeDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "clouddns_synthetic_probe_duration_seconds",
+		Help:    "Histogram of synthetic probe round-trip time",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"vantage"})
+
+	// SyntheticProbesFailing tracks how many zone probes are currently not OK
+	SyntheticProbesFailing = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "clouddns_synthetic_probes_failing",
+		Help: "Number of zone apex probes whose latest result is not OK",
+	})
+
+	// ConsistencyDivergent tracks queries whose answers differed between nodes in the latest check
+	ConsistencyDivergent = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "clouddns_consistency_divergent_queries",
+		Help: "Number of queries with divergent answers across nodes in the latest consistency check",
+	})
+
+	// PropagationResults tracks the answers seen by record propagation checks
+	PropagationResults = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clouddns_propagation_results_total",
+		Help: "Total number of resolver answers checked for record propagation, by status",
+	}, []string{"status"})
+
+	// TenantZoneQueries tracks queries per tenant and zone for the zones allowed a label of their own
+	TenantZoneQueries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clouddns_tenant_zone_queries_total",
+		Help: "Total number of queries answered per tenant and zone; zones outside the label allowlist and top-N are counted as \"other\"",
+	}, []string{"tenant", "zone"})
+
+	// TenantZoneNXDomain tracks NXDOMAIN answers per tenant and zone, labelled like TenantZoneQueries
+	TenantZoneNXDomain = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clouddns_tenant_zone_nxdomain_total",
+		Help: "Total number of NXDOMAIN answers per tenant and zone; zones outside the label allowlist and top-N are counted as \"other\"",
+	}, []string{"tenant", "zone"})
+
+	// ConfigAuditFindings tracks the problems found by the latest configuration audit
+	ConfigAuditFindings = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "clouddns_config_audit_findings",
+		Help: "Number of zone configuration problems found by the latest audit, by check",
+	}, []string{"check"})
+
+	// ConsistencyNodeErrors tracks nodes that failed to answer consistency queries
+	ConsistencyNodeErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "clouddns_consistency_node_errors_total",
+		Help: "Total number of consistency queries a node failed to answer",
+	})
+
+	// SecondariesOutOfSync tracks external secondaries not serving their zone's current serial
+	SecondariesOutOfSync = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "clouddns_secondaries_out_of_sync",
+		Help: "Number of external secondaries whose latest SOA check did not return the zone's current serial",
+	})
+
 	// BGPAnnounced indicates if the node is currently announcing routes via BGP
 	BGPAnnounced = promauto.NewGauge(prometheus.GaugeOpts{
 		Name: "clouddns_bgp_announced",
 		Help: "Binary indicator of BGP announcement status (1 = announcing, 0 = withdrawn)",
 	})
+
+	// DNSCookies tracks the DNS Cookies (RFC 7873) carried by queries
+	DNSCookies = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clouddns_dns_cookies_total",
+		Help: "Total number of queries carrying a DNS Cookie, by result (client_only, valid, invalid, malformed)",
+	}, []string{"result"})
+
+	// APIAnomalies tracks API activity flagged as a possible leaked key
+	APIAnomalies = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clouddns_api_anomalies_total",
+		Help: "Total number of API anomalies detected, by kind (request_spike, auth_failures)",
+	}, []string{"kind"})
+
+	// APIAuthBans tracks clients banned for presenting too many invalid API keys
+	APIAuthBans = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "clouddns_api_auth_bans_total",
+		Help: "Total number of clients banned from the API after repeated authentication failures",
+	})
+
+	// APIAuthBannedRequests tracks API requests refused because the client is banned
+	APIAuthBannedRequests = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "clouddns_api_auth_banned_requests_total",
+		Help: "Total number of API requests refused because the client is banned",
+	})
+
+	// APIAuthorizationDecisions tracks the answers of the external authorizer
+	APIAuthorizationDecisions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clouddns_api_authorization_decisions_total",
+		Help: "Total number of API requests put to the external authorizer, by result (allowed, denied, error)",
+	}, []string{"result"})
+
+	// RecoveryUploads tracks disaster-recovery objects written to the object store
+	RecoveryUploads = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clouddns_recovery_uploads_total",
+		Help: "Total number of disaster-recovery uploads, by kind (snapshot, changes) and result (success, error)",
+	}, []string{"kind", "result"})
+
+	// RecoveryLastUpload tracks when each kind of disaster-recovery object was last uploaded
+	RecoveryLastUpload = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "clouddns_recovery_last_upload_timestamp_seconds",
+		Help: "Unix time of the last successful disaster-recovery upload, by kind (snapshot, changes)",
+	}, []string{"kind"})
+
+	// SecondaryRefreshes tracks refreshes of secondary zones from their masters
+	SecondaryRefreshes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clouddns_secondary_refreshes_total",
+		Help: "Total number of secondary zone refreshes, by result (success, error)",
+	}, []string{"result"})
+
+	// SecondaryFullSyncs tracks the periodic full transfers of secondary zones
+	SecondaryFullSyncs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clouddns_secondary_full_syncs_total",
+		Help: "Total number of periodic full transfers of secondary zones, by result (success, error)",
+	}, []string{"result"})
+
+	// SecondaryZonesExpired tracks secondary zones past their SOA expire timer
+	SecondaryZonesExpired = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "clouddns_secondary_zones_expired",
+		Help: "Number of secondary zones that could not be refreshed within their SOA expire time and answer SERVFAIL",
+	})
+
+	// ShadowQueries tracks queries of names with a staged shadow change
+	ShadowQueries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clouddns_shadow_queries_total",
+		Help: "Total number of queries answered for names with a staged shadow change, by result (match, differ, canary, rollout)",
+	}, []string{"result"})
+
+	// SLOBreaches tracks zones starting to breach their latency SLO
+	SLOBreaches = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "clouddns_slo_breaches_total",
+		Help: "Total number of times a zone started breaching its response time SLO",
+	})
+
+	// SLOZonesBreaching tracks zones currently breaching their latency SLO
+	SLOZonesBreaching = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "clouddns_slo_zones_breaching",
+		Help: "Number of zones whose response time is above their SLO on this node",
+	})
+
+	// QueryTypePolicyActions tracks queries refused or truncated by query type policies
+	QueryTypePolicyActions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clouddns_qtype_policy_actions_total",
+		Help: "Total number of queries refused or truncated by query type policies, by action",
+	}, []string{"action"})
+
+	// NegativeCacheHits tracks queries answered from the negative cache
+	NegativeCacheHits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clouddns_negative_cache_hits_total",
+		Help: "Total number of NXDOMAIN and NODATA answers served from the negative cache, by kind",
+	}, []string{"kind"})
+
+	// CacheLookups tracks lookups in each in-memory response cache
+	CacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clouddns_memory_cache_lookups_total",
+		Help: "Total number of lookups in the in-memory response caches, by cache and result",
+	}, []string{"cache", "result"})
+
+	// CacheEvictions tracks entries removed from the in-memory response caches
+	CacheEvictions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clouddns_memory_cache_evictions_total",
+		Help: "Total number of entries removed from the in-memory response caches, by cache and reason (lru or expired)",
+	}, []string{"cache", "reason"})
+
+	// CacheEntries tracks the entries held by each in-memory response cache
+	CacheEntries = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "clouddns_memory_cache_entries",
+		Help: "Number of entries held by the in-memory response caches, by cache",
+	}, []string{"cache"})
+
+	// CacheBytes tracks the memory taken by each in-memory response cache
+	CacheBytes = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "clouddns_memory_cache_bytes",
+		Help: "Approximate bytes of keys and responses held by the in-memory response caches, by cache",
+	}, []string{"cache"})
+
+	// StaleAnswerAge tracks how long past their TTL stale answers are served
+	StaleAnswerAge = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "clouddns_stale_answer_age_seconds",
+		Help:    "Seconds past their TTL that answers served stale (RFC 8767) while the database is unreachable had expired, by cache",
+		Buckets: []float64{0, 1, 5, 15, 30, 60, 300, 900, 3600, 21600, 86400},
+	}, []string{"cache"})
+
+	// CoalescedLookups tracks lookups that waited for an identical concurrent one
+	CoalescedLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clouddns_coalesced_lookups_total",
+		Help: "Total number of lookups answered by an identical lookup already in flight instead of their own, by source (zone, records, redis)",
+	}, []string{"source"})
+
+	// RedisPoolConnections tracks the connections in each Redis shard's pool
+	RedisPoolConnections = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "clouddns_redis_pool_connections",
+		Help: "Number of connections in a Redis shard's pool, by state (total, idle)",
+	}, []string{"shard", "state"})
+
+	// RedisPoolTimeouts tracks waits for a free pooled connection that timed out
+	RedisPoolTimeouts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clouddns_redis_pool_timeouts_total",
+		Help: "Total number of times a Redis operation timed out waiting for a pooled connection, by shard",
+	}, []string{"shard"})
+
+	// CacheInvalidations tracks cache invalidations applied by this node, by scope
+	CacheInvalidations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clouddns_cache_invalidations_total",
+		Help: "Total number of cache invalidations applied to the in-memory caches, by scope (key, name, all)",
+	}, []string{"scope"})
+
+	// RedisEvictions tracks cached responses deleted from Redis by invalidations
+	RedisEvictions = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "clouddns_redis_evictions_total",
+		Help: "Total number of cached responses deleted from Redis by invalidations",
+	})
+
+	// QueryLimitExceeded tracks queries refused for exceeding a query rate limit
+	QueryLimitExceeded = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "clouddns_query_limit_exceeded_total",
+		Help: "Total number of queries refused for exceeding a tenant's query rate limits, by tenant and scope (tenant, zone)",
+	}, []string{"tenant", "scope"})
 )
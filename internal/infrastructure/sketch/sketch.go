@@ -0,0 +1,207 @@
+// Package sketch provides fixed-memory probabilistic summaries of high-volume
+// streams, used for query analytics where exact per-item state would grow with
+// traffic.
+package sketch
+
+import (
+	"math"
+	"math/bits"
+	"sort"
+	"sync/atomic"
+)
+
+// hash64 returns a well-mixed 64-bit hash of s without allocating. FNV-1a is
+// finalized with the splitmix64 mixer because its high bits are poorly
+// distributed for short, similar strings such as DNS names.
+func hash64(s string) uint64 {
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= 1099511628211
+	}
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+	return h
+}
+
+// HyperLogLog estimates the number of distinct items added to it. With precision p
+// it uses 2^p registers and has a standard error of about 1.04/sqrt(2^p). It is
+// safe for concurrent use.
+type HyperLogLog struct {
+	p         uint8
+	registers []atomic.Uint32
+}
+
+// NewHyperLogLog creates an empty estimator. Precision is clamped to [4, 16].
+func NewHyperLogLog(precision uint8) *HyperLogLog {
+	if precision < 4 {
+		precision = 4
+	}
+	if precision > 16 {
+		precision = 16
+	}
+	return &HyperLogLog{p: precision, registers: make([]atomic.Uint32, 1<<precision)}
+}
+
+// Add records an item.
+func (h *HyperLogLog) Add(item string) {
+	x := hash64(item)
+	idx := x >> (64 - h.p)
+	// The guard bit caps the rank at 64-p+1 when the remaining bits are all zero
+	rank := uint32(bits.LeadingZeros64(x<<h.p|1<<(h.p-1))) + 1 // #nosec G115
+	reg := &h.registers[idx]
+	for {
+		old := reg.Load()
+		if rank <= old || reg.CompareAndSwap(old, rank) {
+			return
+		}
+	}
+}
+
+// Estimate returns the approximate number of distinct items added.
+func (h *HyperLogLog) Estimate() uint64 {
+	m := float64(len(h.registers))
+	sum, zeros := 0.0, 0
+	for i := range h.registers {
+		r := h.registers[i].Load()
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	var alpha float64
+	switch len(h.registers) {
+	case 16:
+		alpha = 0.673
+	case 32:
+		alpha = 0.697
+	case 64:
+		alpha = 0.709
+	default:
+		alpha = 0.7213 / (1 + 1.079/m)
+	}
+	est := alpha * m * m / sum
+	if est <= 2.5*m && zeros > 0 {
+		// Linear counting is more accurate for small cardinalities
+		est = m * math.Log(m/float64(zeros))
+	}
+	return uint64(est + 0.5)
+}
+
+// CountMin estimates per-item counts in fixed memory. Estimates never undercount
+// and overcount by at most e/width of the total with probability 1-e^-depth. It is
+// safe for concurrent use.
+type CountMin struct {
+	width    uint64
+	depth    int
+	counters []atomic.Uint64
+}
+
+// NewCountMin creates an empty sketch with depth rows of width counters.
+func NewCountMin(width, depth int) *CountMin {
+	if width < 1 {
+		width = 1
+	}
+	if depth < 1 {
+		depth = 1
+	}
+	return &CountMin{width: uint64(width), depth: depth, counters: make([]atomic.Uint64, width*depth)}
+}
+
+// Add adds n to an item's count and returns its new estimate.
+func (c *CountMin) Add(item string, n uint64) uint64 {
+	h1, h2 := c.hashes(item)
+	est := uint64(math.MaxUint64)
+	for i := 0; i < c.depth; i++ {
+		v := c.counters[c.index(i, h1, h2)].Add(n)
+		if v < est {
+			est = v
+		}
+	}
+	return est
+}
+
+// Estimate returns an item's estimated count.
+func (c *CountMin) Estimate(item string) uint64 {
+	h1, h2 := c.hashes(item)
+	est := uint64(math.MaxUint64)
+	for i := 0; i < c.depth; i++ {
+		if v := c.counters[c.index(i, h1, h2)].Load(); v < est {
+			est = v
+		}
+	}
+	return est
+}
+
+// hashes derives the row hashes from one 64-bit hash by double hashing.
+func (c *CountMin) hashes(item string) (uint64, uint64) {
+	h := hash64(item)
+	return h, bits.RotateLeft64(h, 32) | 1
+}
+
+func (c *CountMin) index(row int, h1, h2 uint64) int {
+	return row*int(c.width) + int((h1+uint64(row)*h2)%c.width) // #nosec G115
+}
+
+// Item is a tracked key and its count.
+type Item struct {
+	Key   string `json:"key"`
+	Count uint64 `json:"count"`
+}
+
+// TopK keeps the k keys with the highest counts offered to it, in memory
+// proportional to k. Fed with count-min estimates it approximates the heavy
+// hitters of a stream. It is not safe for concurrent use.
+type TopK struct {
+	k      int
+	counts map[string]uint64
+}
+
+// NewTopK creates a tracker for the k highest counts.
+func NewTopK(k int) *TopK {
+	if k < 1 {
+		k = 1
+	}
+	return &TopK{k: k, counts: make(map[string]uint64, k)}
+}
+
+// Offer reports the current count of key. A key not yet tracked replaces the
+// lowest-count key when the tracker is full and its count is higher.
+func (t *TopK) Offer(key string, count uint64) {
+	if old, ok := t.counts[key]; ok || len(t.counts) < t.k {
+		if count > old {
+			t.counts[key] = count
+		}
+		return
+	}
+
+	minKey, minCount := "", uint64(math.MaxUint64)
+	for k, c := range t.counts {
+		if c < minCount {
+			minKey, minCount = k, c
+		}
+	}
+	if count > minCount {
+		delete(t.counts, minKey)
+		t.counts[key] = count
+	}
+}
+
+// List returns the tracked keys ordered by descending count, then key.
+func (t *TopK) List() []Item {
+	items := make([]Item, 0, len(t.counts))
+	for k, c := range t.counts {
+		items = append(items, Item{Key: k, Count: c})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Count != items[j].Count {
+			return items[i].Count > items[j].Count
+		}
+		return items[i].Key < items[j].Key
+	})
+	return items
+}
@@ -0,0 +1,81 @@
+package sketch
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+)
+
+func TestHyperLogLog_Estimate(t *testing.T) {
+	for _, n := range []int{0, 10, 1000, 100000} {
+		h := NewHyperLogLog(14)
+		for i := 0; i < n; i++ {
+			// Duplicates must not be counted twice
+			h.Add(fmt.Sprintf("client-%d", i))
+			h.Add(fmt.Sprintf("client-%d", i))
+		}
+		est := float64(h.Estimate())
+		if n == 0 {
+			if est != 0 {
+				t.Errorf("Expected 0 for empty sketch, got %v", est)
+			}
+			continue
+		}
+		if errRate := math.Abs(est-float64(n)) / float64(n); errRate > 0.03 {
+			t.Errorf("Estimate for %d distinct items off by %.1f%% (%v)", n, errRate*100, est)
+		}
+	}
+}
+
+func TestHyperLogLog_Concurrent(t *testing.T) {
+	h := NewHyperLogLog(12)
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 5000; i++ {
+				h.Add(fmt.Sprintf("name-%d", i))
+			}
+		}()
+	}
+	wg.Wait()
+	if est := float64(h.Estimate()); math.Abs(est-5000)/5000 > 0.05 {
+		t.Errorf("Expected about 5000 distinct items, got %v", est)
+	}
+}
+
+func TestCountMin(t *testing.T) {
+	c := NewCountMin(1024, 4)
+	for i := 0; i < 500; i++ {
+		c.Add("hot.example.com.", 1)
+	}
+	for i := 0; i < 2000; i++ {
+		c.Add(fmt.Sprintf("cold-%d.example.com.", i), 1)
+	}
+	if got := c.Add("hot.example.com.", 1); got < 501 || got > 520 {
+		t.Errorf("Expected hot estimate near 501, got %d", got)
+	}
+	if got := c.Estimate("cold-7.example.com."); got < 1 || got > 20 {
+		t.Errorf("Expected cold estimate near 1, got %d", got)
+	}
+	if got := c.Estimate("never-seen."); got > 20 {
+		t.Errorf("Expected unseen estimate near 0, got %d", got)
+	}
+}
+
+func TestTopK(t *testing.T) {
+	top := NewTopK(2)
+	top.Offer("a", 1)
+	top.Offer("b", 5)
+	top.Offer("c", 1) // Not higher than the minimum, ignored
+	top.Offer("a", 3)
+	top.Offer("d", 4) // Replaces a
+	top.Offer("b", 2) // Counts never decrease
+
+	items := top.List()
+	if len(items) != 2 || items[0] != (Item{Key: "b", Count: 5}) || items[1] != (Item{Key: "d", Count: 4}) {
+		t.Errorf("Unexpected top items: %+v", items)
+	}
+}
@@ -0,0 +1,414 @@
+package testkit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/poyrazK/cloudDNS/internal/adapters/memory"
+	"github.com/poyrazK/cloudDNS/internal/adapters/repository"
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/dnsname"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+	"github.com/poyrazK/cloudDNS/internal/dns/server"
+)
+
+const (
+	// DefaultZone is the zone a topology serves when Options leave it unset.
+	DefaultZone = "sim.test."
+	// TenantID owns the zone on every node.
+	TenantID = "testkit"
+
+	// startTimeout bounds how long a node may take to start listening.
+	startTimeout = 5 * time.Second
+	// pollInterval is how often WaitConverged compares the nodes.
+	pollInterval = 20 * time.Millisecond
+)
+
+// Options configures a topology.
+type Options struct {
+	// Zone is the zone the primary serves and the secondaries transfer.
+	Zone string
+	// Secondaries is the number of secondaries; zero means two.
+	Secondaries int
+	// Records are added to the primary's zone next to its SOA, NS and glue.
+	Records []domain.Record
+	// ManualNotify stops the primary from sending NOTIFY after updates and the
+	// secondaries from refreshing when notified, so that a test drives every
+	// NOTIFY and transfer itself through Notify and Transfer.
+	ManualNotify bool
+	// Logger receives the servers' logs; they are discarded when nil.
+	Logger *slog.Logger
+}
+
+// Node is one DNS server of a topology with its own repository. Secondaries
+// listen on further loopback addresses (127.0.0.2, 127.0.0.3, ...) on the
+// primary's port, which is where the primary sends NOTIFY.
+type Node struct {
+	Name   string
+	Addr   string
+	Repo   *memory.Repository
+	Server *server.Server
+
+	topology *Topology
+	cancel   context.CancelFunc
+	done     chan error
+}
+
+// Topology is a primary and its secondaries serving one zone, each a real DNS
+// server running in-process and reached over loopback.
+type Topology struct {
+	Zone        string
+	ZoneID      string
+	Primary     *Node
+	Secondaries []*Node
+
+	opts Options
+	port int
+}
+
+// New starts a primary and its secondaries and stops them when the test ends.
+// The secondaries start with an empty copy of the zone and transfer it from
+// the primary at once; call WaitConverged before relying on their contents.
+func New(t testing.TB, opts Options) *Topology {
+	t.Helper()
+	if opts.Zone == "" {
+		opts.Zone = DefaultZone
+	}
+	if opts.Secondaries <= 0 {
+		opts.Secondaries = 2
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	tp := &Topology{Zone: dnsname.Fqdn(opts.Zone), ZoneID: uuid.New().String(), opts: opts}
+
+	port, err := freePort(opts.Secondaries + 1)
+	if err != nil {
+		t.Skipf("no loopback port free on %d addresses: %v", opts.Secondaries+1, err)
+	}
+	tp.port = port
+
+	zone, records := tp.primaryZone()
+	tp.Primary = tp.newNode("primary", 1, zone, records)
+	for i := 1; i <= opts.Secondaries; i++ {
+		zone := domain.Zone{ID: tp.ZoneID, TenantID: TenantID, Name: tp.Zone, Role: "slave", MasterServer: tp.Primary.Addr}
+		tp.Secondaries = append(tp.Secondaries, tp.newNode(fmt.Sprintf("secondary-%d", i), i+1, zone, nil))
+	}
+
+	t.Cleanup(func() {
+		for _, node := range tp.Nodes() {
+			node.Stop()
+		}
+	})
+	for _, node := range tp.Nodes() {
+		if err := node.Start(); err != nil {
+			t.Fatalf("failed to start %s: %v", node.Name, err)
+		}
+	}
+	return tp
+}
+
+// freePort finds a port free for UDP and TCP on the first n loopback addresses.
+func freePort(n int) (int, error) {
+	var lastErr error
+	for attempt := 0; attempt < 20; attempt++ {
+		probe, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			return 0, err
+		}
+		port := probe.LocalAddr().(*net.UDPAddr).Port
+		_ = probe.Close()
+		if lastErr = portFree(port, n); lastErr == nil {
+			return port, nil
+		}
+	}
+	return 0, lastErr
+}
+
+func portFree(port, n int) error {
+	for i := 1; i <= n; i++ {
+		addr := net.JoinHostPort(fmt.Sprintf("127.0.0.%d", i), fmt.Sprint(port))
+		pc, err := net.ListenPacket("udp", addr)
+		if err != nil {
+			return err
+		}
+		l, err := net.Listen("tcp", addr)
+		_ = pc.Close()
+		if err != nil {
+			return err
+		}
+		_ = l.Close()
+	}
+	return nil
+}
+
+// primaryZone returns the primary's zone with an SOA, and an NS record and its
+// glue for every node, followed by the records of the options.
+func (tp *Topology) primaryZone() (domain.Zone, []domain.Record) {
+	zone := domain.Zone{ID: tp.ZoneID, TenantID: TenantID, Name: tp.Zone, Role: "master"}
+	records := []domain.Record{{
+		Name: tp.Zone, Type: domain.TypeSOA, TTL: 3600,
+		Content: fmt.Sprintf("ns1.%s hostmaster.%s 1 3600 600 604800 300", tp.Zone, tp.Zone),
+	}}
+	for i := 1; i <= tp.opts.Secondaries+1; i++ {
+		ns := fmt.Sprintf("ns%d.%s", i, tp.Zone)
+		records = append(records,
+			domain.Record{Name: tp.Zone, Type: domain.TypeNS, TTL: 3600, Content: ns},
+			domain.Record{Name: ns, Type: domain.TypeA, TTL: 3600, Content: fmt.Sprintf("127.0.0.%d", i)},
+		)
+	}
+	records = append(records, tp.opts.Records...)
+	for i := range records {
+		records[i].ZoneID = tp.ZoneID
+		records[i].TenantID = TenantID
+	}
+	return zone, records
+}
+
+func (tp *Topology) newNode(name string, host int, zone domain.Zone, records []domain.Record) *Node {
+	repo := memory.NewRepository()
+	zone.CreatedAt, zone.UpdatedAt = time.Now(), time.Now()
+	if err := repo.CreateZoneWithRecords(context.Background(), &zone, records); err != nil {
+		panic(fmt.Sprintf("testkit: failed to seed %s: %v", name, err))
+	}
+	return &Node{
+		Name:     name,
+		Addr:     net.JoinHostPort(fmt.Sprintf("127.0.0.%d", host), fmt.Sprint(tp.port)),
+		Repo:     repo,
+		topology: tp,
+	}
+}
+
+// Nodes returns the primary followed by the secondaries.
+func (tp *Topology) Nodes() []*Node {
+	return append([]*Node{tp.Primary}, tp.Secondaries...)
+}
+
+// Start runs a new server for the node on its repository and waits until it
+// answers over TCP. A restarted secondary refreshes the zone at once, as after
+// any restart.
+func (n *Node) Start() error {
+	if n.cancel != nil {
+		return fmt.Errorf("%s is already running", n.Name)
+	}
+	srv := server.NewServer(n.Addr, n.Repo, n.topology.opts.Logger.With("node", n.Name))
+	srv.NodeID = n.Name
+	srv.WorkerCount = 8
+	srv.NotifyPortOverride = n.topology.port
+	srv.DisableAsync = n.topology.opts.ManualNotify
+	srv.UpdateDebounce = 0
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- srv.Run(ctx) }()
+
+	deadline := time.Now().Add(startTimeout)
+	for {
+		conn, err := net.DialTimeout("tcp", n.Addr, time.Second)
+		if err == nil {
+			_ = conn.Close()
+			break
+		}
+		select {
+		case errRun := <-done:
+			cancel()
+			return fmt.Errorf("server stopped while starting: %v", errRun)
+		default:
+		}
+		if time.Now().After(deadline) {
+			cancel()
+			return fmt.Errorf("server did not start listening on %s: %w", n.Addr, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	n.Server, n.cancel, n.done = srv, cancel, done
+	return nil
+}
+
+// Stop shuts the node's server down, keeping its repository, as when a node
+// goes offline. Stopping a stopped node does nothing.
+func (n *Node) Stop() {
+	if n.cancel == nil {
+		return
+	}
+	n.cancel()
+	<-n.done
+	n.Server, n.cancel, n.done = nil, nil, nil
+}
+
+// Running reports whether the node's server is running.
+func (n *Node) Running() bool {
+	return n.cancel != nil
+}
+
+// Contents returns the node's copy of the zone as sorted "name TTL type
+// content" lines, and its SOA serial.
+func (n *Node) Contents() ([]string, uint32) {
+	lines, soaSerial, _ := n.Repo.ZoneSnapshot(n.topology.Zone)
+	return lines, soaSerial
+}
+
+// Serial returns the SOA serial of the node's copy of the zone, zero before
+// it has one.
+func (n *Node) Serial() uint32 {
+	_, soaSerial := n.Contents()
+	return soaSerial
+}
+
+// Query sends a query for name and qType to the node over UDP and returns the
+// response.
+func (n *Node) Query(ctx context.Context, name string, qType packet.QueryType) (*packet.DNSPacket, error) {
+	req := packet.NewDNSPacket()
+	req.Header.ID = transactionID()
+	req.Questions = append(req.Questions, packet.DNSQuestion{Name: dnsname.Fqdn(name), QType: qType, QClass: 1})
+	return exchange(ctx, n.Addr, req)
+}
+
+// AddRecords adds records to the zone with one dynamic update (RFC 2136) sent
+// to the primary, which bumps the serial and, unless ManualNotify is set,
+// notifies the secondaries.
+func (tp *Topology) AddRecords(ctx context.Context, records ...domain.Record) error {
+	return tp.update(ctx, 1, records)
+}
+
+// DeleteRecords removes the records with the given name, type and content from
+// the zone with one dynamic update sent to the primary.
+func (tp *Topology) DeleteRecords(ctx context.Context, records ...domain.Record) error {
+	return tp.update(ctx, 254, records)
+}
+
+func (tp *Topology) update(ctx context.Context, class uint16, records []domain.Record) error {
+	req := packet.NewDNSPacket()
+	req.Header.ID = transactionID()
+	req.Header.Opcode = packet.OpcodeUpdate
+	req.Questions = append(req.Questions, packet.DNSQuestion{Name: tp.Zone, QType: packet.SOA, QClass: 1})
+	for _, rec := range records {
+		rec.Name = dnsname.Fqdn(rec.Name)
+		pRec, err := repository.ConvertDomainToPacketRecord(rec)
+		if err != nil {
+			return fmt.Errorf("invalid record %s %s: %w", rec.Name, rec.Type, err)
+		}
+		pRec.Class = class
+		if class != 1 {
+			pRec.TTL = 0
+		}
+		req.Authorities = append(req.Authorities, pRec)
+	}
+	resp, err := exchange(ctx, tp.Primary.Addr, req)
+	if err != nil {
+		return err
+	}
+	if resp.Header.ResCode != packet.RcodeNoError {
+		return fmt.Errorf("update refused with rcode %d", resp.Header.ResCode)
+	}
+	return nil
+}
+
+// Notify has the primary send NOTIFY to every secondary now and reports which
+// acknowledged it.
+func (tp *Topology) Notify(ctx context.Context) (*domain.NotifyResult, error) {
+	if !tp.Primary.Running() {
+		return nil, fmt.Errorf("%s is not running", tp.Primary.Name)
+	}
+	return tp.Primary.Server.NotifyZone(ctx, tp.ZoneID, TenantID)
+}
+
+// Transfer has a secondary refresh the zone from the primary now, by IXFR when
+// it has a copy and by AXFR otherwise.
+func (tp *Topology) Transfer(ctx context.Context, node *Node) (*domain.ZoneTransferResult, error) {
+	if !node.Running() {
+		return nil, fmt.Errorf("%s is not running", node.Name)
+	}
+	return node.Server.TransferZone(ctx, tp.ZoneID, TenantID)
+}
+
+// WaitConverged waits until every running secondary holds exactly the primary's
+// copy of the zone. When ctx ends first it reports the serials of the nodes
+// that still differ.
+func (tp *Topology) WaitConverged(ctx context.Context) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		lagging := tp.lagging()
+		if len(lagging) == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("zone %s did not converge at primary serial %d: %s", tp.Zone, tp.Primary.Serial(), strings.Join(lagging, ", "))
+		case <-ticker.C:
+		}
+	}
+}
+
+// lagging describes the running secondaries whose copy differs from the
+// primary's.
+func (tp *Topology) lagging() []string {
+	want, _ := tp.Primary.Contents()
+	var lagging []string
+	for _, node := range tp.Secondaries {
+		if !node.Running() {
+			continue
+		}
+		got, soaSerial := node.Contents()
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			lagging = append(lagging, fmt.Sprintf("%s at serial %d with %d records", node.Name, soaSerial, len(got)))
+		}
+	}
+	return lagging
+}
+
+// exchange sends req to addr over UDP and waits for the matching response.
+func exchange(ctx context.Context, addr string, req *packet.DNSPacket) (*packet.DNSPacket, error) {
+	buf := packet.NewBytePacketBuffer()
+	if err := req.Write(buf); err != nil {
+		return nil, err
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+	deadline := time.Now().Add(5 * time.Second)
+	if dl, ok := ctx.Deadline(); ok && dl.Before(deadline) {
+		deadline = dl
+	}
+	_ = conn.SetDeadline(deadline)
+	if _, err := conn.Write(buf.Buf[:buf.Position()]); err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, packet.MaxPacketSize)
+	for {
+		n, err := conn.Read(data)
+		if err != nil {
+			return nil, err
+		}
+		if n < 2 || binary.BigEndian.Uint16(data[:2]) != req.Header.ID {
+			continue
+		}
+		resBuf := packet.NewBytePacketBuffer()
+		resBuf.Load(data[:n])
+		resp := packet.NewDNSPacket()
+		if err := resp.FromBuffer(resBuf); err != nil {
+			return nil, err
+		}
+		return resp, nil
+	}
+}
+
+func transactionID() uint16 {
+	var b [2]byte
+	_, _ = rand.Read(b[:])
+	return binary.BigEndian.Uint16(b[:])
+}
@@ -0,0 +1,174 @@
+package testkit
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/poyrazK/cloudDNS/internal/core/domain"
+	"github.com/poyrazK/cloudDNS/internal/dns/packet"
+)
+
+func converge(t *testing.T, tp *Topology) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := tp.WaitConverged(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTopologyInitialTransfer(t *testing.T) {
+	tp := New(t, Options{
+		Secondaries: 3,
+		Records:     []domain.Record{{Name: "www.sim.test.", Type: domain.TypeA, TTL: 300, Content: "192.0.2.1"}},
+	})
+	converge(t, tp)
+
+	for _, node := range tp.Secondaries {
+		if node.Serial() != 1 {
+			t.Errorf("Expected %s at serial 1, got %d", node.Name, node.Serial())
+		}
+		resp, err := node.Query(context.Background(), "www.sim.test.", packet.A)
+		if err != nil {
+			t.Fatalf("query to %s failed: %v", node.Name, err)
+		}
+		if !resp.Header.AuthoritativeAnswer || len(resp.Answers) != 1 || !resp.Answers[0].IP.Equal(net.ParseIP("192.0.2.1")) {
+			t.Errorf("Expected %s to answer from its transferred copy, got %+v", node.Name, resp.Answers)
+		}
+	}
+}
+
+func TestTopologyUpdatesReplicateThroughNotify(t *testing.T) {
+	tp := New(t, Options{})
+	converge(t, tp)
+
+	ctx := context.Background()
+	for i, ip := range []string{"192.0.2.10", "192.0.2.11", "192.0.2.12"} {
+		if err := tp.AddRecords(ctx, domain.Record{Name: "app.sim.test.", Type: domain.TypeA, TTL: 60, Content: ip}); err != nil {
+			t.Fatalf("update %d failed: %v", i, err)
+		}
+	}
+	converge(t, tp)
+	if tp.Primary.Serial() != 4 {
+		t.Errorf("Expected one serial per update, got %d", tp.Primary.Serial())
+	}
+
+	if err := tp.DeleteRecords(ctx, domain.Record{Name: "app.sim.test.", Type: domain.TypeA, Content: "192.0.2.11"}); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	converge(t, tp)
+	resp, err := tp.Secondaries[1].Query(ctx, "app.sim.test.", packet.A)
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if len(resp.Answers) != 2 {
+		t.Errorf("Expected the deleted address to be gone from the secondary, got %+v", resp.Answers)
+	}
+}
+
+func TestTopologyIncrementalTransfer(t *testing.T) {
+	tp := New(t, Options{Secondaries: 1, ManualNotify: true})
+	converge(t, tp)
+	secondary := tp.Secondaries[0]
+	ctx := context.Background()
+
+	if err := tp.AddRecords(ctx, domain.Record{Name: "mail.sim.test.", Type: domain.TypeMX, TTL: 300, Content: "mx.sim.test.", Priority: new(int)}); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	if err := tp.AddRecords(ctx, domain.Record{Name: "txt.sim.test.", Type: domain.TypeTXT, TTL: 300, Content: "v=1"}); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	if secondary.Serial() != 1 {
+		t.Fatalf("Expected the secondary to wait for a NOTIFY, got serial %d", secondary.Serial())
+	}
+
+	notified, err := tp.Notify(ctx)
+	if err != nil || len(notified.Targets) != 1 || notified.Targets[0].Status != "acknowledged" {
+		t.Fatalf("Expected the secondary to acknowledge the NOTIFY, got %+v, %v", notified, err)
+	}
+
+	res, err := tp.Transfer(ctx, secondary)
+	if err != nil {
+		t.Fatalf("transfer failed: %v", err)
+	}
+	if res.Method != "IXFR" || res.LocalSerial != 1 || res.MasterSerial != 3 {
+		t.Errorf("Expected an IXFR from serial 1 to 3, got %+v", res)
+	}
+	converge(t, tp)
+
+	if res, err := tp.Transfer(ctx, secondary); err != nil || res.Method != "none" {
+		t.Errorf("Expected an up-to-date secondary not to transfer, got %+v, %v", res, err)
+	}
+}
+
+func TestTopologySecondaryCatchesUpAfterOutage(t *testing.T) {
+	tp := New(t, Options{})
+	converge(t, tp)
+	offline := tp.Secondaries[0]
+	offline.Stop()
+
+	ctx := context.Background()
+	for _, name := range []string{"a.sim.test.", "b.sim.test."} {
+		if err := tp.AddRecords(ctx, domain.Record{Name: name, Type: domain.TypeA, TTL: 60, Content: "192.0.2.20"}); err != nil {
+			t.Fatalf("update failed: %v", err)
+		}
+	}
+	converge(t, tp)
+	if offline.Serial() != 1 || tp.Secondaries[1].Serial() != 3 {
+		t.Fatalf("Expected only the running secondary to follow, got serials %d and %d", offline.Serial(), tp.Secondaries[1].Serial())
+	}
+
+	if err := offline.Start(); err != nil {
+		t.Fatalf("restart failed: %v", err)
+	}
+	converge(t, tp)
+	if offline.Serial() != 3 {
+		t.Errorf("Expected the restarted secondary to catch up, got serial %d", offline.Serial())
+	}
+}
+
+func TestWaitConvergedReportsLaggingNodes(t *testing.T) {
+	tp := New(t, Options{Secondaries: 1, ManualNotify: true})
+	converge(t, tp)
+	if err := tp.AddRecords(context.Background(), domain.Record{Name: "late.sim.test.", Type: domain.TypeA, TTL: 60, Content: "192.0.2.30"}); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := tp.WaitConverged(ctx)
+	if err == nil {
+		t.Fatal("Expected a secondary that was never notified to lag")
+	}
+	t.Log(err)
+}
+
+func TestTopologyFullSyncRepairsDrift(t *testing.T) {
+	tp := New(t, Options{
+		Secondaries: 1,
+		Records:     []domain.Record{{Name: "www.sim.test.", Type: domain.TypeA, TTL: 300, Content: "192.0.2.1"}},
+	})
+	converge(t, tp)
+	secondary := tp.Secondaries[0]
+	ctx := context.Background()
+
+	// A copy that lost a record without its serial changing looks current
+	if err := secondary.Repo.DeleteRecordsByName(ctx, tp.ZoneID, "www.sim.test."); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if res, err := tp.Transfer(ctx, secondary); err != nil || res.Method != "none" {
+		t.Fatalf("Expected a refresh to see the same serial, got %+v, %v", res, err)
+	}
+
+	secondary.Server.FullSync(ctx)
+	converge(t, tp)
+	resp, err := secondary.Query(ctx, "www.sim.test.", packet.A)
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if len(resp.Answers) != 1 || !resp.Answers[0].IP.Equal(net.ParseIP("192.0.2.1")) {
+		t.Errorf("Expected the full sync to restore the record, got %+v", resp.Answers)
+	}
+}
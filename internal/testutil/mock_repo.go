@@ -3,6 +3,7 @@ package testutil
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/poyrazK/cloudDNS/internal/core/domain"
 	"github.com/stretchr/testify/mock"
@@ -30,6 +31,14 @@ func (m *MockRepo) GetZone(ctx context.Context, name string) (*domain.Zone, erro
 	return args.Get(0).(*domain.Zone), args.Error(1)
 }
 
+func (m *MockRepo) GetZoneByID(ctx context.Context, zoneID string, tenantID string) (*domain.Zone, error) {
+	args := m.Called(zoneID, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Zone), args.Error(1)
+}
+
 func (m *MockRepo) GetRecord(ctx context.Context, id string, zoneID string, tenantID string) (*domain.Record, error) {
 	args := m.Called(id, zoneID, tenantID)
 	if args.Get(0) == nil {
@@ -46,6 +55,11 @@ func (m *MockRepo) ListRecordsForZone(ctx context.Context, zoneID string, tenant
 	return args.Get(0).([]domain.Record), args.Error(1)
 }
 
+func (m *MockRepo) NameExists(ctx context.Context, zoneID string, name string) (bool, error) {
+	args := m.Called(zoneID, name)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *MockRepo) CreateZone(ctx context.Context, zone *domain.Zone) error {
 	args := m.Called(zone)
 	return args.Error(0)
@@ -66,6 +80,11 @@ func (m *MockRepo) BatchCreateRecords(ctx context.Context, records []domain.Reco
 	return args.Error(0)
 }
 
+func (m *MockRepo) HasZones(ctx context.Context) (bool, error) {
+	args := m.Called(ctx)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *MockRepo) ListZones(ctx context.Context, tenantID string) ([]domain.Zone, error) {
 	args := m.Called(tenantID)
 	return args.Get(0).([]domain.Zone), args.Error(1)
@@ -76,6 +95,16 @@ func (m *MockRepo) DeleteZone(ctx context.Context, zoneID string, tenantID strin
 	return args.Error(0)
 }
 
+func (m *MockRepo) SetZoneDNSSEC(ctx context.Context, zoneID string, tenantID string, enabled bool) error {
+	args := m.Called(zoneID, tenantID, enabled)
+	return args.Error(0)
+}
+
+func (m *MockRepo) SetZoneRole(ctx context.Context, zoneID string, tenantID string, role string, masterServer string) error {
+	args := m.Called(zoneID, tenantID, role, masterServer)
+	return args.Error(0)
+}
+
 func (m *MockRepo) DeleteRecord(ctx context.Context, recordID string, zoneID string, tenantID string) error {
 	args := m.Called(recordID, zoneID, tenantID)
 	return args.Error(0)
@@ -96,11 +125,26 @@ func (m *MockRepo) DeleteRecordsForZone(ctx context.Context, zoneID string) erro
 	return args.Error(0)
 }
 
+func (m *MockRepo) ReplaceZoneRecords(ctx context.Context, zoneID string, records []domain.Record) error {
+	args := m.Called(zoneID, records)
+	return args.Error(0)
+}
+
+func (m *MockRepo) ApplyZoneDiff(ctx context.Context, zoneID string, diffs []domain.IXFRChunk) error {
+	args := m.Called(zoneID, diffs)
+	return args.Error(0)
+}
+
 func (m *MockRepo) DeleteRecordSpecific(ctx context.Context, zoneID string, name string, qType domain.RecordType, content string) error {
 	args := m.Called(zoneID, name, qType, content)
 	return args.Error(0)
 }
 
+func (m *MockRepo) ReplaceRRset(ctx context.Context, zoneID string, name string, qType domain.RecordType, records []domain.Record) error {
+	args := m.Called(zoneID, name, qType, records)
+	return args.Error(0)
+}
+
 func (m *MockRepo) RecordZoneChange(ctx context.Context, change *domain.ZoneChange) error {
 	args := m.Called(change)
 	return args.Error(0)
@@ -111,6 +155,14 @@ func (m *MockRepo) ListZoneChanges(ctx context.Context, zoneID string, fromSeria
 	return args.Get(0).([]domain.ZoneChange), args.Error(1)
 }
 
+func (m *MockRepo) LastZoneChangeAt(ctx context.Context, zoneID string) (*time.Time, error) {
+	args := m.Called(ctx, zoneID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*time.Time), args.Error(1)
+}
+
 func (m *MockRepo) GetIXFRChain(ctx context.Context, zoneID string, fromSerial uint32, toSerial uint32) ([]domain.IXFRChunk, error) {
 	args := m.Called(zoneID, fromSerial, toSerial)
 	if args.Get(0) == nil {
@@ -172,6 +224,11 @@ func (m *MockRepo) DeleteAPIKey(ctx context.Context, tenantID string, id string)
 	return args.Error(0)
 }
 
+func (m *MockRepo) SetRecordProtected(ctx context.Context, recordID string, zoneID string, tenantID string, protected bool) (bool, error) {
+	args := m.Called(ctx, recordID, zoneID, tenantID, protected)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *MockRepo) UpdateRecordHealth(ctx context.Context, recordID string, status domain.HealthStatus, errMsg string) error {
 	args := m.Called(ctx, recordID, status, errMsg)
 	return args.Error(0)
@@ -185,6 +242,415 @@ func (m *MockRepo) GetRecordsToProbe(ctx context.Context) ([]domain.Record, erro
 	return args.Get(0).([]domain.Record), args.Error(1)
 }
 
+func (m *MockRepo) UpsertNode(ctx context.Context, node *domain.Node) error {
+	args := m.Called(ctx, node)
+	return args.Error(0)
+}
+
+func (m *MockRepo) ListNodes(ctx context.Context) ([]domain.Node, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Node), args.Error(1)
+}
+
+func (m *MockRepo) GetNodeConfig(ctx context.Context, nodeID string) (map[string]string, error) {
+	args := m.Called(ctx, nodeID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]string), args.Error(1)
+}
+
+func (m *MockRepo) SetNodeConfig(ctx context.Context, nodeID string, cfg map[string]string) error {
+	args := m.Called(ctx, nodeID, cfg)
+	return args.Error(0)
+}
+
+func (m *MockRepo) SaveRollout(ctx context.Context, rollout *domain.Rollout) error {
+	args := m.Called(ctx, rollout)
+	return args.Error(0)
+}
+
+func (m *MockRepo) GetRollout(ctx context.Context, id string) (*domain.Rollout, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Rollout), args.Error(1)
+}
+
+func (m *MockRepo) ListRollouts(ctx context.Context) ([]domain.Rollout, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Rollout), args.Error(1)
+}
+
+func (m *MockRepo) AddZoneUsage(ctx context.Context, usage []domain.ZoneUsage) error {
+	args := m.Called(ctx, usage)
+	return args.Error(0)
+}
+
+func (m *MockRepo) ListZoneUsage(ctx context.Context, tenantID string, zoneID string, from, to time.Time) ([]domain.ZoneUsage, error) {
+	args := m.Called(ctx, tenantID, zoneID, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.ZoneUsage), args.Error(1)
+}
+
+func (m *MockRepo) CreateSecondaryProvider(ctx context.Context, p *domain.SecondaryProvider) error {
+	args := m.Called(ctx, p)
+	return args.Error(0)
+}
+
+func (m *MockRepo) ListSecondaryProviders(ctx context.Context, zoneID string) ([]domain.SecondaryProvider, error) {
+	args := m.Called(ctx, zoneID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.SecondaryProvider), args.Error(1)
+}
+
+func (m *MockRepo) DeleteSecondaryProvider(ctx context.Context, id string, zoneID string) error {
+	args := m.Called(ctx, id, zoneID)
+	return args.Error(0)
+}
+
+func (m *MockRepo) UpdateSecondaryProviderStatus(ctx context.Context, p *domain.SecondaryProvider) error {
+	args := m.Called(ctx, p)
+	return args.Error(0)
+}
+
+func (m *MockRepo) SaveZoneRefresh(ctx context.Context, refresh *domain.ZoneRefresh) error {
+	args := m.Called(ctx, refresh)
+	return args.Error(0)
+}
+
+func (m *MockRepo) GetZoneRefresh(ctx context.Context, zoneID string) (*domain.ZoneRefresh, error) {
+	args := m.Called(ctx, zoneID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ZoneRefresh), args.Error(1)
+}
+
+func (m *MockRepo) CreateScheduledChange(ctx context.Context, c *domain.ScheduledChange) error {
+	args := m.Called(ctx, c)
+	return args.Error(0)
+}
+
+func (m *MockRepo) ListScheduledChanges(ctx context.Context, zoneID string) ([]domain.ScheduledChange, error) {
+	args := m.Called(ctx, zoneID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.ScheduledChange), args.Error(1)
+}
+
+func (m *MockRepo) CancelScheduledChange(ctx context.Context, id string, zoneID string) (bool, error) {
+	args := m.Called(ctx, id, zoneID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepo) ClaimDueScheduledChanges(ctx context.Context, now time.Time, limit int) ([]domain.ScheduledChange, error) {
+	args := m.Called(ctx, now, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.ScheduledChange), args.Error(1)
+}
+
+func (m *MockRepo) FinishScheduledChange(ctx context.Context, c *domain.ScheduledChange) error {
+	args := m.Called(ctx, c)
+	return args.Error(0)
+}
+
+func (m *MockRepo) SetZoneReview(ctx context.Context, zoneID string, tenantID string, required bool) error {
+	args := m.Called(ctx, zoneID, tenantID, required)
+	return args.Error(0)
+}
+
+func (m *MockRepo) IsZoneReviewed(ctx context.Context, zoneID string) (bool, error) {
+	args := m.Called(ctx, zoneID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepo) CreateChangeRequest(ctx context.Context, c *domain.ChangeRequest) error {
+	args := m.Called(ctx, c)
+	return args.Error(0)
+}
+
+func (m *MockRepo) ListChangeRequests(ctx context.Context, zoneID string) ([]domain.ChangeRequest, error) {
+	args := m.Called(ctx, zoneID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.ChangeRequest), args.Error(1)
+}
+
+func (m *MockRepo) GetChangeRequest(ctx context.Context, id string, zoneID string) (*domain.ChangeRequest, error) {
+	args := m.Called(ctx, id, zoneID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ChangeRequest), args.Error(1)
+}
+
+func (m *MockRepo) ReviewChangeRequest(ctx context.Context, c *domain.ChangeRequest) (bool, error) {
+	args := m.Called(ctx, c)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepo) FinishChangeRequest(ctx context.Context, c *domain.ChangeRequest) error {
+	args := m.Called(ctx, c)
+	return args.Error(0)
+}
+
+func (m *MockRepo) CreateChangeFreeze(ctx context.Context, f *domain.ChangeFreeze) error {
+	args := m.Called(ctx, f)
+	return args.Error(0)
+}
+
+func (m *MockRepo) ListChangeFreezes(ctx context.Context, tenantID string) ([]domain.ChangeFreeze, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.ChangeFreeze), args.Error(1)
+}
+
+func (m *MockRepo) DeleteChangeFreeze(ctx context.Context, id string, tenantID string) (bool, error) {
+	args := m.Called(ctx, id, tenantID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepo) GetActiveChangeFreeze(ctx context.Context, tenantID string, zoneID string, at time.Time) (*domain.ChangeFreeze, error) {
+	args := m.Called(ctx, tenantID, zoneID, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ChangeFreeze), args.Error(1)
+}
+
+func (m *MockRepo) SaveShadowChange(ctx context.Context, change *domain.ShadowChange) error {
+	args := m.Called(ctx, change)
+	return args.Error(0)
+}
+
+func (m *MockRepo) GetShadowChange(ctx context.Context, zoneID string) (*domain.ShadowChange, error) {
+	args := m.Called(ctx, zoneID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ShadowChange), args.Error(1)
+}
+
+func (m *MockRepo) ListRecordNetworks(ctx context.Context) ([]string, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockRepo) ListRecordGeoScopes(ctx context.Context) ([]string, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockRepo) ListShadowChanges(ctx context.Context) ([]domain.ShadowChange, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.ShadowChange), args.Error(1)
+}
+
+func (m *MockRepo) DeleteShadowChange(ctx context.Context, zoneID string) (bool, error) {
+	args := m.Called(ctx, zoneID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepo) SaveZoneSLO(ctx context.Context, slo *domain.ZoneSLO) error {
+	args := m.Called(ctx, slo)
+	return args.Error(0)
+}
+
+func (m *MockRepo) GetZoneSLO(ctx context.Context, zoneID string) (*domain.ZoneSLO, error) {
+	args := m.Called(ctx, zoneID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ZoneSLO), args.Error(1)
+}
+
+func (m *MockRepo) ListZoneSLOs(ctx context.Context) ([]domain.ZoneSLO, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.ZoneSLO), args.Error(1)
+}
+
+func (m *MockRepo) DeleteZoneSLO(ctx context.Context, zoneID string) (bool, error) {
+	args := m.Called(ctx, zoneID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepo) SaveQueryLimit(ctx context.Context, limit *domain.QueryLimit) error {
+	args := m.Called(ctx, limit)
+	return args.Error(0)
+}
+
+func (m *MockRepo) ListQueryLimits(ctx context.Context, tenantID string) ([]domain.QueryLimit, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.QueryLimit), args.Error(1)
+}
+
+func (m *MockRepo) DeleteQueryLimit(ctx context.Context, tenantID string, zoneID string) (bool, error) {
+	args := m.Called(ctx, tenantID, zoneID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepo) SaveView(ctx context.Context, view *domain.View) error {
+	args := m.Called(ctx, view)
+	return args.Error(0)
+}
+
+func (m *MockRepo) ListViews(ctx context.Context, tenantID string) ([]domain.View, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.View), args.Error(1)
+}
+
+func (m *MockRepo) DeleteView(ctx context.Context, id string, tenantID string) (bool, error) {
+	args := m.Called(ctx, id, tenantID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepo) SaveDefaultRecord(ctx context.Context, rec *domain.DefaultRecord) error {
+	args := m.Called(ctx, rec)
+	return args.Error(0)
+}
+
+func (m *MockRepo) ListDefaultRecords(ctx context.Context, zoneID string) ([]domain.DefaultRecord, error) {
+	args := m.Called(ctx, zoneID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.DefaultRecord), args.Error(1)
+}
+
+func (m *MockRepo) DeleteDefaultRecord(ctx context.Context, id string, zoneID string) (bool, error) {
+	args := m.Called(ctx, id, zoneID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepo) SaveTarget(ctx context.Context, target *domain.Target) error {
+	args := m.Called(ctx, target)
+	return args.Error(0)
+}
+
+func (m *MockRepo) ListTargets(ctx context.Context, tenantID string) ([]domain.Target, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Target), args.Error(1)
+}
+
+func (m *MockRepo) DeleteTarget(ctx context.Context, id string, tenantID string) (bool, error) {
+	args := m.Called(ctx, id, tenantID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepo) ListTargetRecords(ctx context.Context, targetID string) ([]domain.Record, error) {
+	args := m.Called(ctx, targetID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Record), args.Error(1)
+}
+
+func (m *MockRepo) SaveHealthCheck(ctx context.Context, check *domain.HealthCheck) error {
+	args := m.Called(ctx, check)
+	return args.Error(0)
+}
+
+func (m *MockRepo) ListHealthChecks(ctx context.Context, tenantID string) ([]domain.HealthCheck, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.HealthCheck), args.Error(1)
+}
+
+func (m *MockRepo) DeleteHealthCheck(ctx context.Context, id string, tenantID string) (bool, error) {
+	args := m.Called(ctx, id, tenantID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepo) UpdateHealthCheckStatus(ctx context.Context, id string, status domain.HealthStatus, errMsg string) error {
+	args := m.Called(ctx, id, status, errMsg)
+	return args.Error(0)
+}
+
+func (m *MockRepo) ListHealthCheckRecords(ctx context.Context, checkID string) ([]domain.Record, error) {
+	args := m.Called(ctx, checkID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Record), args.Error(1)
+}
+
+func (m *MockRepo) SaveSubnet(ctx context.Context, subnet *domain.Subnet) error {
+	args := m.Called(ctx, subnet)
+	return args.Error(0)
+}
+
+func (m *MockRepo) ListSubnets(ctx context.Context, tenantID string) ([]domain.Subnet, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Subnet), args.Error(1)
+}
+
+func (m *MockRepo) DeleteSubnet(ctx context.Context, id string, tenantID string) (bool, error) {
+	args := m.Called(ctx, id, tenantID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepo) SaveIPAllocation(ctx context.Context, alloc *domain.IPAllocation) error {
+	args := m.Called(ctx, alloc)
+	return args.Error(0)
+}
+
+func (m *MockRepo) ListIPAllocations(ctx context.Context, subnetID string) ([]domain.IPAllocation, error) {
+	args := m.Called(ctx, subnetID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.IPAllocation), args.Error(1)
+}
+
+func (m *MockRepo) DeleteIPAllocation(ctx context.Context, id string, subnetID string) (bool, error) {
+	args := m.Called(ctx, id, subnetID)
+	return args.Bool(0), args.Error(1)
+}
+
 type MockDNSService struct {
 	mock.Mock
 }
@@ -230,6 +696,70 @@ func (m *MockDNSService) ListRecordsForZone(ctx context.Context, zoneID string,
 	return args.Get(0).([]domain.Record), args.Error(1)
 }
 
+func (m *MockDNSService) GetZoneSummary(ctx context.Context, zoneID string, tenantID string) (*domain.ZoneSummary, error) {
+	args := m.Called(zoneID, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ZoneSummary), args.Error(1)
+}
+
+func (m *MockDNSService) SetZoneDNSSEC(ctx context.Context, zoneID string, tenantID string, enabled bool) (*domain.Zone, error) {
+	args := m.Called(zoneID, tenantID, enabled)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Zone), args.Error(1)
+}
+
+func (m *MockDNSService) SetZoneRole(ctx context.Context, zoneID string, tenantID string, role string, masterServer string) (*domain.Zone, error) {
+	args := m.Called(zoneID, tenantID, role, masterServer)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Zone), args.Error(1)
+}
+
+func (m *MockDNSService) CloneZone(ctx context.Context, zoneID string, tenantID string, opts domain.ZoneCloneOptions) (*domain.Zone, error) {
+	args := m.Called(zoneID, tenantID, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Zone), args.Error(1)
+}
+
+func (m *MockDNSService) GetRRset(ctx context.Context, zoneID string, tenantID string, name string, qType domain.RecordType) (*domain.RRset, error) {
+	args := m.Called(zoneID, tenantID, name, qType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.RRset), args.Error(1)
+}
+
+func (m *MockDNSService) ReplaceRRset(ctx context.Context, zoneID string, tenantID string, rrset *domain.RRset) (*domain.RRset, error) {
+	args := m.Called(zoneID, tenantID, rrset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.RRset), args.Error(1)
+}
+
+func (m *MockDNSService) DiffZone(ctx context.Context, zoneID string, tenantID string, against string) (*domain.ZoneDiff, error) {
+	args := m.Called(zoneID, tenantID, against)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ZoneDiff), args.Error(1)
+}
+
+func (m *MockDNSService) ListZoneChangeFeed(ctx context.Context, zoneID string, tenantID string, sinceSerial uint32, limit int) (*domain.ZoneChangeFeed, error) {
+	args := m.Called(zoneID, tenantID, sinceSerial, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ZoneChangeFeed), args.Error(1)
+}
+
 func (m *MockDNSService) DeleteZone(ctx context.Context, zoneID string, tenantID string) error {
 	args := m.Called(zoneID, tenantID)
 	return args.Error(0)
@@ -240,14 +770,30 @@ func (m *MockDNSService) DeleteRecord(ctx context.Context, recordID string, zone
 	return args.Error(0)
 }
 
-func (m *MockDNSService) ImportZone(ctx context.Context, tenantID string, r io.Reader) (*domain.Zone, error) {
-	args := m.Called(tenantID, r)
+func (m *MockDNSService) SetRecordProtection(ctx context.Context, recordID string, zoneID string, tenantID string, protected bool) (*domain.Record, error) {
+	args := m.Called(recordID, zoneID, tenantID, protected)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Record), args.Error(1)
+}
+
+func (m *MockDNSService) ImportZone(ctx context.Context, tenantID string, r io.Reader, vars map[string]string) (*domain.Zone, error) {
+	args := m.Called(tenantID, r, vars)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*domain.Zone), args.Error(1)
 }
 
+func (m *MockDNSService) ImportRecords(ctx context.Context, zoneID string, tenantID string, format string, r io.Reader, dryRun bool) (*domain.RecordImportResult, error) {
+	args := m.Called(zoneID, tenantID, format, r, dryRun)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.RecordImportResult), args.Error(1)
+}
+
 func (m *MockDNSService) ListAuditLogs(ctx context.Context, tenantID string) ([]domain.AuditLog, error) {
 	args := m.Called(tenantID)
 	return args.Get(0).([]domain.AuditLog), args.Error(1)